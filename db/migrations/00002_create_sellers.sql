@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE payout_status AS ENUM ('NOT_STARTED', 'PENDING', 'VERIFIED', 'REJECTED');
+
+CREATE TABLE IF NOT EXISTS sellers (
+    user_id UUID PRIMARY KEY, -- Links to Keycloak User UUID
+
+    -- Public facing, this is what ends up on listings and in the search index
+    display_name TEXT NOT NULL CHECK (char_length(display_name) BETWEEN 2 AND 50),
+    country CHAR(2) NOT NULL, -- ISO 3166-1 alpha-2
+
+    -- Billing
+    payout_status payout_status NOT NULL DEFAULT 'NOT_STARTED',
+
+    -- Legal: which version of the seller terms was accepted, and when
+    accepted_terms_version TEXT,
+    accepted_terms_at TIMESTAMP WITH TIME ZONE,
+
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_sellers_modtime BEFORE UPDATE ON sellers FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- seller_name used to default to the seller's email address, which leaked it into the public API and search index.
+-- Fall back to the username for anything already written, a reindex pushes the fix out to Typesense.
+UPDATE listings SET seller_name = seller_username WHERE seller_name LIKE '%@%';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS sellers;
+DROP TYPE IF EXISTS payout_status;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS views_count INTEGER DEFAULT 0;
+
+-- Download and view counts are buffered in Redis and flushed in batches by the listings worker.
+-- Every applied batch is recorded here so a batch replayed after a crash is not counted twice.
+CREATE TABLE IF NOT EXISTS counter_flushes (
+    batch_id TEXT PRIMARY KEY,
+    flushed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS counter_flushes;
+ALTER TABLE listings DROP COLUMN IF EXISTS views_count;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Events the gateway owes the bus, written in the same transaction as the change they describe so a listing can't be
+-- committed without its events, or the other way round. The gateway's outbox relay publishes them in id order and
+-- stamps published_at. A publish that fails is tried again at next_attempt_at. The message ID goes to JetStream with
+-- the event, so a relay that dies between the publish and the update doesn't send it twice within the duplicate window.
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    subject TEXT NOT NULL,
+    msg_id TEXT NOT NULL,
+    payload BYTEA NOT NULL, -- Exactly what goes on the bus
+
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    published_at TIMESTAMP WITH TIME ZONE,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The relay only looks at events still waiting to go out
+CREATE INDEX idx_event_outbox_due ON event_outbox(next_attempt_at) WHERE published_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS event_outbox;
+-- +goose StatementEnd
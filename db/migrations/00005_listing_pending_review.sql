@@ -0,0 +1,9 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- Listings that trip the creation abuse heuristics wait here for a moderator instead of going ACTIVE.
+-- ADD VALUE can't run inside a transaction block, hence NO TRANSACTION.
+ALTER TYPE listing_status ADD VALUE IF NOT EXISTS 'PENDING_REVIEW';
+
+-- +goose Down
+-- Postgres can't drop a value from an enum, move anything still waiting back to validation and leave the value in place
+UPDATE listings SET status = 'PENDING_VALIDATION' WHERE status = 'PENDING_REVIEW';
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE listing_status_actor AS ENUM ('SYSTEM', 'USER', 'MODERATOR');
+
+-- Every status change on a listing, written in the same transaction as the change itself.
+-- Lets sellers and support see why a listing is where it is.
+CREATE TABLE IF NOT EXISTS listing_status_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+
+    actor listing_status_actor NOT NULL,
+    actor_id UUID, -- Keycloak user for USER and MODERATOR, NULL for SYSTEM
+
+    from_status listing_status, -- NULL when the listing was created
+    to_status listing_status NOT NULL,
+    reason TEXT,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_listing_status_events_listing ON listing_status_events(listing_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS listing_status_events;
+DROP TYPE IF EXISTS listing_status_actor;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Nozzle sizes sellers can pick from, kept in step with nozzleDiametersMM in the gateway
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS nozzle_diameter_mm NUMERIC(3,2)
+    CONSTRAINT listings_nozzle_diameter_mm_check
+    CHECK (nozzle_diameter_mm IS NULL OR nozzle_diameter_mm IN (0.2, 0.25, 0.4, 0.6, 0.8, 1.0));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE listings DROP COLUMN IF EXISTS nozzle_diameter_mm;
+-- +goose StatementEnd
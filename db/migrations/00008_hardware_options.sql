@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Curated values for listings.hardware_required, so search facets don't split "M3 screw" into every spelling of it.
+-- Moderators extend the list through POST /admin/hardware-options.
+CREATE TABLE IF NOT EXISTS hardware_options (
+    name TEXT PRIMARY KEY, -- Canonical spelling, what gets stored on listings
+    created_by UUID, -- Keycloak user who added it, NULL for the seed list
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Listings are matched case-insensitively, so two spellings that only differ in case can't both be canonical
+CREATE UNIQUE INDEX IF NOT EXISTS idx_hardware_options_name_lower ON hardware_options (lower(name));
+
+INSERT INTO hardware_options (name) VALUES
+    ('M2 screw'), ('M2.5 screw'), ('M3 screw'), ('M4 screw'), ('M5 screw'),
+    ('M2 nut'), ('M3 nut'), ('M4 nut'), ('M5 nut'),
+    ('M3 washer'), ('M4 washer'),
+    ('M3 heat-set insert'), ('M4 heat-set insert'),
+    ('608 bearing'), ('625 bearing'),
+    ('Neodymium magnet'), ('Spring'), ('Rubber band'), ('Zip tie'), ('Glue'),
+    ('Threaded rod'), ('Steel rod'), ('LED'), ('Battery'), ('Servo motor'), ('Stepper motor'), ('Microcontroller'), ('Wire')
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS hardware_options;
+-- +goose StatementEnd
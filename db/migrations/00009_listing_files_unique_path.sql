@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- An upload can only ever belong to one listing, the validation worker moves it to the permanent bucket under that
+-- listing's ID. Generated files are named after their own IDs so they're left out.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_listing_files_upload_path ON listing_files(file_path) WHERE is_generated = false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_listing_files_upload_path;
+-- +goose StatementEnd
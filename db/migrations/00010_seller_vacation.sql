@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Vacation mode pauses a seller's catalogue between vacation_starts_at and vacation_ends_at. Listings, likes and stats
+-- are left alone, the listings are flagged as temporarily unavailable and the listings worker reindexes them at either end.
+ALTER TABLE sellers
+    ADD COLUMN IF NOT EXISTS vacation_starts_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS vacation_ends_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS vacation_message TEXT CHECK (char_length(vacation_message) <= 280),
+    -- Whether the search index was last brought in line with the vacation being on, owned by the listings worker
+    ADD COLUMN IF NOT EXISTS vacation_applied BOOLEAN NOT NULL DEFAULT false;
+
+-- Ending a vacation early moves both ends to now, so they can be equal
+ALTER TABLE sellers ADD CONSTRAINT sellers_vacation_check CHECK (
+    (vacation_starts_at IS NULL AND vacation_ends_at IS NULL) OR vacation_ends_at >= vacation_starts_at
+);
+
+-- The worker polls for vacations that have started or ended, only a handful of sellers are ever away at once
+CREATE INDEX IF NOT EXISTS idx_sellers_vacation ON sellers(vacation_ends_at) WHERE vacation_ends_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_sellers_vacation;
+ALTER TABLE sellers DROP CONSTRAINT IF EXISTS sellers_vacation_check;
+ALTER TABLE sellers
+    DROP COLUMN IF EXISTS vacation_applied,
+    DROP COLUMN IF EXISTS vacation_message,
+    DROP COLUMN IF EXISTS vacation_ends_at,
+    DROP COLUMN IF EXISTS vacation_starts_at;
+-- +goose StatementEnd
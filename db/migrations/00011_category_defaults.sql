@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Recommended printer settings per category, prefilled on the create form and used for soft warnings when a listing's
+-- values are far off. Moderators maintain them through PUT /admin/categories/{slug}/defaults.
+CREATE TABLE IF NOT EXISTS category_defaults (
+    category TEXT PRIMARY KEY, -- Canonical category value, see categories.Canonical
+    recommended_materials TEXT[] NOT NULL DEFAULT '{}',
+    nozzle_temp_min_c INTEGER NOT NULL,
+    nozzle_temp_max_c INTEGER NOT NULL,
+    typical_dimensions_mm JSONB NOT NULL, -- Same shape as listings.dimensions_mm, {"width", "depth", "height"}
+    updated_by UUID, -- Keycloak user who last edited it, NULL for the seed rows
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT category_defaults_nozzle_temp_range CHECK (nozzle_temp_min_c <= nozzle_temp_max_c)
+);
+
+INSERT INTO category_defaults (category, recommended_materials, nozzle_temp_min_c, nozzle_temp_max_c, typical_dimensions_mm) VALUES
+    ('functional', '{PETG,PLA,ASA}', 200, 250, '{"width": 80, "depth": 60, "height": 40}'),
+    ('artistic', '{PLA,Resin}', 190, 220, '{"width": 40, "depth": 40, "height": 60}'),
+    ('prototypes', '{PLA,PETG}', 195, 240, '{"width": 100, "depth": 80, "height": 60}'),
+    ('spare-parts', '{PETG,ABS,ASA,TPU}', 220, 260, '{"width": 50, "depth": 40, "height": 30}')
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS category_defaults;
+-- +goose StatementEnd
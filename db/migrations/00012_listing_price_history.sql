@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every price change on a listing, written in the same transaction as the update that made it. Drives the
+-- price_dropped_recently search flag and the seller's price chart. Starts empty, earlier changes weren't kept.
+CREATE TABLE IF NOT EXISTS listing_price_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+
+    old_price_min_unit BIGINT NOT NULL,
+    new_price_min_unit BIGINT NOT NULL,
+    -- A change of currency isn't a drop even when the number goes down
+    old_currency TEXT NOT NULL,
+    new_currency TEXT NOT NULL,
+
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_listing_price_history_listing ON listing_price_history(listing_id, changed_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS listing_price_history;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Listings the listings worker gave up indexing after every retry, at most one row each. The gateway writes it from
+-- ListingIndexFailedEvent, and the worker deletes it the next time the listing is indexed.
+CREATE TABLE IF NOT EXISTS listing_index_failures (
+    listing_id UUID PRIMARY KEY REFERENCES listings(id) ON DELETE CASCADE,
+
+    error_class TEXT NOT NULL, -- database, document, search or event, see the worker's indexing.ErrorClass
+    error TEXT NOT NULL,       -- The worker's last error, for moderators only
+    attempts INTEGER NOT NULL,
+
+    failed_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_listing_index_failures_failed_at ON listing_index_failures(failed_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS listing_index_failures;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Reports buyers file against a listing, one per reporter. Nothing files them yet, moderators can already filter
+-- GET /admin/listings by how many a listing has.
+CREATE TABLE IF NOT EXISTS listing_reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    reporter_id UUID NOT NULL, -- Keycloak User UUID
+    reason TEXT NOT NULL,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (listing_id, reporter_id)
+);
+
+-- The admin listings view pages through every listing, deleted ones included, on (created_at, id). The feed and
+-- portfolio indexes only cover listings that aren't deleted, so it gets its own.
+CREATE INDEX idx_listings_admin_created ON listings(created_at DESC, id DESC);
+CREATE INDEX idx_listings_admin_status ON listings(status, created_at DESC, id DESC);
+CREATE INDEX idx_listings_admin_seller ON listings(seller_id, created_at DESC, id DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_listings_admin_seller;
+DROP INDEX IF EXISTS idx_listings_admin_status;
+DROP INDEX IF EXISTS idx_listings_admin_created;
+DROP TABLE IF EXISTS listing_reports;
+-- +goose StatementEnd
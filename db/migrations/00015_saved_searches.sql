@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Searches buyers want to hear about new matches for. The gateway stores them, the listings worker runs each one
+-- against Typesense every so often and publishes SavedSearchMatchedEvent for listings it hasn't reported yet.
+CREATE TABLE IF NOT EXISTS saved_searches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL, -- Keycloak User UUID
+
+    query TEXT NOT NULL,     -- Lower case with single spaces, '' when only filters are set
+    filters JSONB NOT NULL,  -- The filter params as saved, normalized, e.g. {"category": ["toys"], "max_x_mm": ["220"]}
+    filter_by TEXT NOT NULL, -- Typesense filter_by the gateway built from filters, '' for none
+
+    -- Matches from the last run, listings created a while before last_checked_at are looked at again in case they
+    -- were only indexed since, and these stop them being reported twice
+    notified_listing_ids TEXT[] NOT NULL DEFAULT '{}',
+    last_checked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Spread over the first hour so searches saved together aren't all run together
+    next_check_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP + random() * INTERVAL '1 hour',
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (user_id, query, filter_by)
+);
+
+CREATE INDEX idx_saved_searches_next_check ON saved_searches(next_check_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS saved_searches;
+-- +goose StatementEnd
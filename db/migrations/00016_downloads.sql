@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Receipts for private file downloads. The gateway buffers them in Redis next to the download counters and the
+-- listings worker writes them here when it flushes those, so a download never waits on this insert.
+CREATE TABLE IF NOT EXISTS downloads (
+    id UUID PRIMARY KEY, -- Generated by the gateway, so a batch flushed twice records each download once
+    -- Keycloak User UUID. NULL for anonymous downloads of free listings, and once DOWNLOAD_RETENTION_DAYS have
+    -- passed, after which the row only counts towards stats.
+    user_id UUID,
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    file_id UUID NOT NULL REFERENCES listing_files(id) ON DELETE CASCADE,
+
+    downloaded_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL, -- When the presigned URL handed out stopped working
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- GET /me/downloads and the retention sweep only look at rows that still have a user
+CREATE INDEX idx_downloads_user ON downloads(user_id, listing_id, downloaded_at DESC) WHERE user_id IS NOT NULL;
+CREATE INDEX idx_downloads_retention ON downloads(downloaded_at) WHERE user_id IS NOT NULL;
+CREATE INDEX idx_downloads_listing ON downloads(listing_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS downloads;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Short links sellers share on social media, GET /l/{code} on the gateway redirects to the listing with UTM
+-- parameters from the campaign. Clicks are buffered in Redis and added on by the listings worker's counter flush.
+CREATE TABLE IF NOT EXISTS short_links (
+    code TEXT PRIMARY KEY, -- 6 base62 characters, case sensitive
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    created_by UUID NOT NULL, -- Keycloak User UUID of the seller
+    campaign TEXT, -- Sent as utm_campaign, NULL for none
+
+    clicks_count INTEGER NOT NULL DEFAULT 0,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP WITH TIME ZONE -- Revoked links answer 410 and are never reused
+);
+
+CREATE INDEX idx_short_links_listing ON short_links(listing_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS short_links;
+-- +goose StatementEnd
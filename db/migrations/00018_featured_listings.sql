@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Listings marketing features on the homepage between starts_at and ends_at. Admins manage them on the gateway, which
+-- serves the ones running now on GET /listings/featured. The listings worker sets is_featured on the search documents
+-- as windows start and end. A listing can have more than one window, and they can overlap.
+CREATE TABLE IF NOT EXISTS featured_listings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    weight INTEGER NOT NULL DEFAULT 0 CHECK (weight >= 0), -- Higher weights are shown first
+
+    created_by UUID NOT NULL, -- Keycloak User UUID of the admin
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- Whether the search index was last brought in line with this window being on, owned by the listings worker
+    applied BOOLEAN NOT NULL DEFAULT false,
+
+    CONSTRAINT featured_listings_window_check CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_featured_listings_listing ON featured_listings(listing_id);
+-- The gateway and the worker both look for windows around now, expired ones pile up behind ends_at
+CREATE INDEX idx_featured_listings_ends_at ON featured_listings(ends_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS featured_listings;
+-- +goose StatementEnd
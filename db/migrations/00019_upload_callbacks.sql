@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Callbacks integrations ask for when presigning an upload, e.g. a slicer plugin uploading straight to storage that
+-- wants to hear when its file has been validated instead of polling. The gateway records one per upload key and links
+-- it to the file when a listing claims the upload. The listings worker POSTs a signed notification once the file's
+-- validation has finished.
+CREATE TABLE IF NOT EXISTS upload_callbacks (
+    storage_key TEXT PRIMARY KEY, -- The key the upload was presigned for
+    user_id UUID NOT NULL, -- Keycloak User UUID of the uploader
+    callback_url TEXT NOT NULL,
+    destination TEXT NOT NULL, -- Host of callback_url, what rate limits and failures are counted against
+    file_id UUID REFERENCES listing_files(id) ON DELETE CASCADE, -- Set when a listing claims the upload
+
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    abandoned_at TIMESTAMP WITH TIME ZONE, -- Ran out of attempts
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL -- Dropped after this if no listing has claimed the upload
+);
+
+-- The worker's sweep only looks at claimed callbacks still waiting to be delivered
+CREATE INDEX idx_upload_callbacks_due ON upload_callbacks(next_attempt_at)
+    WHERE file_id IS NOT NULL AND delivered_at IS NULL AND abandoned_at IS NULL;
+
+-- Failures in a row per destination. Once disabled_at is set the worker stops calling it and the gateway refuses new
+-- callbacks to it, until an operator clears it.
+CREATE TABLE IF NOT EXISTS callback_destinations (
+    destination TEXT PRIMARY KEY,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    disabled_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS callback_destinations;
+DROP TABLE IF EXISTS upload_callbacks;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- When the seller last did anything signed in, so search can rank shops that are still looked after above abandoned
+-- ones. The gateway writes it at most once an hour per seller, the listings worker turns it into the coarse
+-- seller_activity_bucket on their search documents.
+ALTER TABLE sellers ADD COLUMN IF NOT EXISTS last_active_at TIMESTAMP WITH TIME ZONE;
+
+-- Best guess for sellers from before it was tracked: their last profile change or new listing. Sellers with neither
+-- stay NULL and count as dormant until they next sign in.
+UPDATE sellers s SET last_active_at = GREATEST(
+    s.updated_at,
+    (SELECT max(l.created_at) FROM listings l WHERE l.seller_id = s.user_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sellers DROP COLUMN IF EXISTS last_active_at;
+-- +goose StatementEnd
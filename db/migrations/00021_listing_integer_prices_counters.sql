@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Prices are whole minor units, 1999 is 19.99. NUMERIC(12,0) never held a fraction, so the cast is exact, but it
+-- reached Go as pgtype.Numeric (or through an sqlc override) and had to be converted back to int64 wherever it was
+-- read. BIGINT comes out as int64 on its own.
+ALTER TABLE listings
+    ALTER COLUMN price_min_unit TYPE BIGINT USING price_min_unit::BIGINT,
+    ALTER COLUMN sale_price TYPE BIGINT USING sale_price::BIGINT;
+
+-- The counters are only ever incremented from 0, a NULL count meant 0 everywhere it was read
+UPDATE listings SET
+    likes_count = COALESCE(likes_count, 0),
+    downloads_count = COALESCE(downloads_count, 0),
+    comments_count = COALESCE(comments_count, 0),
+    views_count = COALESCE(views_count, 0)
+WHERE likes_count IS NULL OR downloads_count IS NULL OR comments_count IS NULL OR views_count IS NULL;
+
+ALTER TABLE listings
+    ALTER COLUMN likes_count SET NOT NULL,
+    ALTER COLUMN downloads_count SET NOT NULL,
+    ALTER COLUMN comments_count SET NOT NULL,
+    ALTER COLUMN views_count SET NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE listings
+    ALTER COLUMN likes_count DROP NOT NULL,
+    ALTER COLUMN downloads_count DROP NOT NULL,
+    ALTER COLUMN comments_count DROP NOT NULL,
+    ALTER COLUMN views_count DROP NOT NULL;
+
+ALTER TABLE listings
+    ALTER COLUMN price_min_unit TYPE NUMERIC(12, 0) USING price_min_unit::NUMERIC(12, 0),
+    ALTER COLUMN sale_price TYPE NUMERIC(12, 0) USING sale_price::NUMERIC(12, 0);
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Listings a seller pinned to the top of their storefront, GET /sellers/{id}/listings, in the order they chose. The
+-- gateway rewrites a seller's pins as a whole and keeps them to 4, the check only backs that up.
+CREATE TABLE IF NOT EXISTS seller_pinned_listings (
+    listing_id UUID PRIMARY KEY REFERENCES listings(id) ON DELETE CASCADE,
+    seller_id UUID NOT NULL, -- The listing's seller, copied so the storefront can read pins without the listing
+    position SMALLINT NOT NULL CHECK (position BETWEEN 1 AND 4), -- 1 is shown first
+    pinned_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT seller_pinned_listings_position_key UNIQUE (seller_id, position)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS seller_pinned_listings;
+-- +goose StatementEnd
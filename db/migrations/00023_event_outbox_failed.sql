@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- An event the relay gave up on after its last attempt. It stays in the outbox for an admin to look into and retry,
+-- but the relay no longer claims it and it no longer counts as backlog.
+ALTER TABLE event_outbox ADD COLUMN IF NOT EXISTS failed_at TIMESTAMP WITH TIME ZONE;
+
+DROP INDEX IF EXISTS idx_event_outbox_due;
+CREATE INDEX idx_event_outbox_due ON event_outbox(next_attempt_at) WHERE published_at IS NULL AND failed_at IS NULL;
+CREATE INDEX idx_event_outbox_failed ON event_outbox(id) WHERE published_at IS NULL AND failed_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_event_outbox_failed;
+DROP INDEX IF EXISTS idx_event_outbox_due;
+CREATE INDEX idx_event_outbox_due ON event_outbox(next_attempt_at) WHERE published_at IS NULL;
+ALTER TABLE event_outbox DROP COLUMN IF EXISTS failed_at;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- What a banned term does to a listing whose title or description uses it: REJECT refuses the create or edit,
+-- FLAG lets it through as PENDING_REVIEW for a moderator to look at.
+CREATE TYPE banned_term_severity AS ENUM ('REJECT', 'FLAG');
+
+-- Terms listings are screened against. Moderators keep the list through /admin/banned-terms, it starts empty.
+CREATE TABLE IF NOT EXISTS banned_terms (
+    term TEXT PRIMARY KEY, -- Lower case, single spaced. Matched as whole words, ignoring case, accents, lookalike letters and leetspeak
+    severity banned_term_severity NOT NULL,
+    updated_by UUID NOT NULL, -- Keycloak user who added it or last changed its severity
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Flagged terms each listing was held for, so moderators can see why without rereading it. Rewritten by every
+-- create or edit that flags, kept when a later edit is clean so the history isn't lost before a moderator looks.
+CREATE TABLE IF NOT EXISTS listing_screening_flags (
+    listing_id UUID PRIMARY KEY REFERENCES listings(id) ON DELETE CASCADE,
+    terms TEXT[] NOT NULL,
+    flagged_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS listing_screening_flags;
+DROP TABLE IF EXISTS banned_terms;
+DROP TYPE IF EXISTS banned_term_severity;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Packages a listing is sold as, e.g. a free low-poly preview and a paid high-detail set. A listing without variants
+-- sells all its files at the listing's price. With variants, every model file has to be in at least one of them, the
+-- gateway checks that whenever one is added or removed.
+CREATE TABLE IF NOT EXISTS listing_variants (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    listing_id UUID NOT NULL REFERENCES listings(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    price_min_unit BIGINT NOT NULL CHECK (price_min_unit >= 0), -- In the listing's currency, the full price rather than a difference
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT listing_variants_name_key UNIQUE (listing_id, name)
+);
+
+-- Files each variant includes, a file can be in several
+CREATE TABLE IF NOT EXISTS listing_variant_files (
+    variant_id UUID NOT NULL REFERENCES listing_variants(id) ON DELETE CASCADE,
+    file_id UUID NOT NULL REFERENCES listing_files(id) ON DELETE CASCADE,
+    PRIMARY KEY (variant_id, file_id)
+);
+
+-- Downloads look up the variants a file is in
+CREATE INDEX IF NOT EXISTS idx_listing_variant_files_file_id ON listing_variant_files(file_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS listing_variant_files;
+DROP TABLE IF EXISTS listing_variants;
+-- +goose StatementEnd
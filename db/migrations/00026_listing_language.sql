@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The dominant language of a listing's title and description as an ISO 639-1 code, detected by the gateway whenever
+-- either is written. 'und' (undetermined) when the text is too short, mixed or not words at all. Existing listings
+-- start as 'und' and pick up a language the next time they're edited.
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS language TEXT NOT NULL DEFAULT 'und';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE listings DROP COLUMN IF EXISTS language;
+-- +goose StatementEnd
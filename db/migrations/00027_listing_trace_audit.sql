@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The OAuth client (azp) and trace ID a listing was created with are for debugging the request, not part of the
+-- listing. They move onto the status event that created it, where the listings worker nulls them once they're older
+-- than its trace retention window. Listings from before status events were recorded have nowhere to put them, theirs
+-- are long past any window and go.
+ALTER TABLE listing_status_events
+    ADD COLUMN IF NOT EXISTS client_id TEXT,
+    ADD COLUMN IF NOT EXISTS trace_id TEXT;
+
+UPDATE listing_status_events e
+    SET client_id = NULLIF(l.client_id, ''), trace_id = NULLIF(l.trace_id, '')
+    FROM listings l
+    WHERE e.listing_id = l.id AND e.from_status IS NULL;
+
+DROP INDEX IF EXISTS idx_listings_trace_id;
+ALTER TABLE listings
+    DROP COLUMN IF EXISTS client_id,
+    DROP COLUMN IF EXISTS trace_id;
+
+-- Support looking up what a trace created, and the retention job finding events it hasn't anonymized yet
+CREATE INDEX idx_listing_status_events_trace ON listing_status_events(trace_id) WHERE trace_id IS NOT NULL;
+CREATE INDEX idx_listing_status_events_identified ON listing_status_events(created_at)
+    WHERE client_id IS NOT NULL OR trace_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE listings
+    ADD COLUMN IF NOT EXISTS client_id TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS trace_id TEXT NOT NULL DEFAULT '';
+
+UPDATE listings l
+    SET client_id = COALESCE(e.client_id, ''), trace_id = COALESCE(e.trace_id, '')
+    FROM listing_status_events e
+    WHERE e.listing_id = l.id AND e.from_status IS NULL;
+
+ALTER TABLE listings
+    ALTER COLUMN client_id DROP DEFAULT,
+    ALTER COLUMN trace_id DROP DEFAULT;
+CREATE INDEX idx_listings_trace_id ON listings(trace_id);
+
+DROP INDEX IF EXISTS idx_listing_status_events_identified;
+DROP INDEX IF EXISTS idx_listing_status_events_trace;
+ALTER TABLE listing_status_events
+    DROP COLUMN IF EXISTS trace_id,
+    DROP COLUMN IF EXISTS client_id;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+-- Listings a moderator took down. Sellers can't republish them, nothing moves a listing out of SUSPENDED.
+-- ADD VALUE can't run inside a transaction block, hence NO TRANSACTION.
+ALTER TYPE listing_status ADD VALUE IF NOT EXISTS 'SUSPENDED';
+
+-- +goose Down
+-- Postgres can't drop a value from an enum, hide anything still suspended and leave the value in place
+UPDATE listings SET status = 'HIDDEN' WHERE status = 'SUSPENDED';
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Admins delete a featured window by setting deleted_at. The row stays so the listings worker sees that a window it
+-- applied is gone and takes is_featured off the search document, the same way it does when a window ends.
+ALTER TABLE featured_listings ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM featured_listings WHERE deleted_at IS NOT NULL;
+ALTER TABLE featured_listings DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
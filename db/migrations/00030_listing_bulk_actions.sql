@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Audit trail of POST /listings/bulk, one row per request that changed anything, written in the same transaction as
+-- the change. Unpublishes are also in each listing's status history, deletes aren't anywhere else.
+CREATE TABLE IF NOT EXISTS listing_bulk_actions (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    action TEXT NOT NULL, -- delete or unpublish
+    user_id UUID NOT NULL,
+    username TEXT NOT NULL,
+    listing_ids UUID[] NOT NULL, -- The listings it was applied to, not the ones it was refused for
+    failed INTEGER NOT NULL DEFAULT 0,
+    trace_id TEXT,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Support looking up what a user did
+CREATE INDEX idx_listing_bulk_actions_user ON listing_bulk_actions(user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS listing_bulk_actions;
+-- +goose StatementEnd
@@ -0,0 +1,48 @@
+// Package db owns the Postgres schema shared by the gateway and the listings worker, and the sqlc config that
+// generates both services' repositories from it. Whether the generated packages checked into each service
+// are still what sqlc would write is `sqlc diff`'s job, see the check-sqlc task.
+package db
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Target is one service's entry in sqlc.yaml
+type Target struct {
+	Name    string `yaml:"name"`
+	Queries string `yaml:"queries"`
+	Schema  string `yaml:"schema"`
+	Gen     struct {
+		Go struct {
+			Package string `yaml:"package"`
+			Out     string `yaml:"out"`
+		} `yaml:"go"`
+	} `yaml:"gen"`
+}
+
+// LoadTargets reads the sqlc config at path, resolving every path in it against the config's directory
+func LoadTargets(path string) ([]Target, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg struct {
+		SQL []Target `yaml:"sql"`
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	for i := range cfg.SQL {
+		cfg.SQL[i].Queries = filepath.Join(dir, cfg.SQL[i].Queries)
+		cfg.SQL[i].Schema = filepath.Join(dir, cfg.SQL[i].Schema)
+		cfg.SQL[i].Gen.Go.Out = filepath.Join(dir, cfg.SQL[i].Gen.Go.Out)
+	}
+	return cfg.SQL, nil
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func loadTargets(t *testing.T) []Target {
+	t.Helper()
+	targets, err := LoadTargets("sqlc.yaml")
+	require.NoError(t, err)
+	require.NotEmpty(t, targets)
+	return targets
+}
+
+// --- CHECKED IN CODE ---
+
+func TestGenerated_MatchesQueries(t *testing.T) {
+	// SCENARIO: A service's queries.sql or a migration is edited.
+	// EXPECT: sqlc diff fails until `task generate-sqlc` has been run and the output committed.
+
+	sqlc, err := exec.LookPath("sqlc")
+	if err != nil {
+		t.Skip("sqlc is not installed, CI runs `task check-sqlc`")
+	}
+
+	out, err := exec.Command(sqlc, "diff", "--file", "sqlc.yaml").CombinedOutput()
+	assert.NoError(t, err, "generated code is stale, run `task generate-sqlc`:\n%s", out)
+}
+
+func TestGenerated_ModelsMatchAcrossServices(t *testing.T) {
+	// SCENARIO: A migration adds a column and only one service is regenerated.
+	// EXPECT: The models differ between services, and this fails.
+
+	targets := loadTargets(t)
+	require.Greater(t, len(targets), 1)
+
+	models := func(target Target) string {
+		src := readFile(t, filepath.Join(target.Gen.Go.Out, "models.go"))
+		// The package clause is the only line allowed to differ
+		return strings.Replace(src, "package "+target.Gen.Go.Package+"\n", "", 1)
+	}
+
+	want := models(targets[0])
+	for _, target := range targets[1:] {
+		assert.Equal(t, want, models(target), "%s models differ from %s, run `task generate-sqlc`", target.Name, targets[0].Name)
+	}
+}
+
+func TestGenerated_SharedSchema(t *testing.T) {
+	for _, target := range loadTargets(t) {
+		assert.Equal(t, "migrations", target.Schema, "%s must generate from the shared migrations", target.Name)
+	}
+}
+
+var schemaVersionConst = regexp.MustCompile(`const SchemaVersion = (\d+)`)
+
+func TestSchemaVersion_IsLatestMigration(t *testing.T) {
+	// SCENARIO: A migration is added.
+	// EXPECT: This fails until each service's SchemaVersion is bumped, so preflight checks for the new schema.
+
+	entries, err := os.ReadDir("migrations")
+	require.NoError(t, err)
+
+	var latest int
+	for _, entry := range entries {
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		if n, err := strconv.Atoi(prefix); ok && err == nil && n > latest {
+			latest = n
+		}
+	}
+	require.NotZero(t, latest)
+
+	for _, target := range loadTargets(t) {
+		match := schemaVersionConst.FindStringSubmatch(readFile(t, filepath.Join(target.Gen.Go.Out, "..", "schema.go")))
+		require.NotNil(t, match, "%s has no SchemaVersion const", target.Name)
+		assert.Equal(t, strconv.Itoa(latest), match[1], "%s SchemaVersion is not the latest migration", target.Name)
+	}
+}
@@ -39,6 +39,9 @@ func main() {
 			{Name: "thumbnail_url", Type: "string"},
 			{Name: "categories", Type: "string[]", Facet: pointer.True()},
 			{Name: "license", Type: "string"},
+			// ISO 639-1 code the gateway detected from the title and description, "und" when it couldn't tell. Optional as
+			// documents indexed before it don't have one.
+			{Name: "language", Type: "string", Facet: pointer.True(), Optional: pointer.True()},
 
 			// AI Semantic Search Vector
 			// It stores a 768-dim vector (from OpenAI/Bert) representing the 'meaning' of the model.
@@ -49,20 +52,26 @@ func main() {
 			// SLICER & 3D TECH SPECS (Machine Readable)
 			// ==================================================
 			// Essential for an in-browser slicer to auto-configure settings
-			{Name: "is_manifold", Type: "bool", Facet: pointer.True()},      // Is it watertight?
-			{Name: "file_formats", Type: "string[]", Facet: pointer.True()}, // e.g. ["stl", "3mf", "obj", "step"]
-
-			// Physical dimensions (in mm) - vital for "Will this fit on my printer?" filters
+			// Every validated model is watertight, from the validation worker's mesh scan. Optional as it's left out
+			// until every model has been scanned.
+			{Name: "is_manifold", Type: "bool", Facet: pointer.True(), Optional: pointer.True()},
+			{Name: "file_formats", Type: "string[]", Facet: pointer.True()}, // Extensions of the validated models, e.g. ["3mf", "stl"]
+
+			// Physical dimensions (in mm) - vital for "Will this fit on my printer?" filters. Optional as digital listings
+			// and physical ones without all three are sent without them. Typesense refused those documents while these
+			// were required, run `listings-worker reindex` after this migration to index them.
 			{Name: "is_physical", Type: "bool", Facet: pointer.True()}, // Is it a physical object (vs digital art)?
-			{Name: "dim_x_mm", Type: "float", Sort: pointer.True()},
-			{Name: "dim_y_mm", Type: "float", Sort: pointer.True()},
-			{Name: "dim_z_mm", Type: "float", Sort: pointer.True()},
+			{Name: "dim_x_mm", Type: "float", Sort: pointer.True(), Optional: pointer.True()},
+			{Name: "dim_y_mm", Type: "float", Sort: pointer.True(), Optional: pointer.True()},
+			{Name: "dim_z_mm", Type: "float", Sort: pointer.True(), Optional: pointer.True()},
 
 			{Name: "is_assembly_required", Type: "bool", Facet: pointer.True()}, // Does it need assembly after printing?
 
-			{Name: "total_weight_grams", Type: "float", Sort: pointer.True()},        // e.g. 150.5g (optional for digital asset)
+			// e.g. 150.5g, left out for digital listings like the dimensions
+			{Name: "total_weight_grams", Type: "float", Sort: pointer.True(), Optional: pointer.True()},
 			{Name: "recommended_materials", Type: "string[]", Facet: pointer.True()}, // "PLA"
 			{Name: "recommended_nozzle_temp_c", Type: "int64", Sort: pointer.True()},
+			{Name: "nozzle_diameter_mm", Type: "float", Facet: pointer.True(), Optional: pointer.True()},
 			{Name: "is_hardware_required", Type: "bool", Facet: pointer.True()}, // Does it need extra hardware (screws, etc)?
 			{Name: "hardware_required", Type: "string[]"},
 			{Name: "is_multicolor", Type: "bool", Facet: pointer.True()}, // Vital for modern AMS/MMU users
@@ -89,6 +98,7 @@ func main() {
 			// Social Signals
 			{Name: "likes_count", Type: "int64", Sort: pointer.True()},
 			{Name: "downloads_count", Type: "int64", Sort: pointer.True()},
+			{Name: "views_count", Type: "int64", Sort: pointer.True()},
 			{Name: "comments_count", Type: "int64", Sort: pointer.True()},
 			// ==================================================
 			// SALES & MERCHANDISING
@@ -108,16 +118,31 @@ func main() {
 			{Name: "seller_username", Type: "string"},
 			{Name: "seller_verified", Type: "bool", Facet: pointer.True()},
 			{Name: "seller_name", Type: "string"},
+			// Search hides or demotes these, optional so documents indexed before vacations existed still count as available
+			{Name: "seller_on_vacation", Type: "bool", Facet: pointer.True(), Optional: pointer.True()},
+			// active_7d, active_30d or dormant, from when the seller was last signed in. Search boosts active shops with it,
+			// optional until the counter reconcile has reached documents indexed before it.
+			{Name: "seller_activity_bucket", Type: "string", Facet: pointer.True(), Optional: pointer.True()},
+			// Latest price change was a drop in the last 14 days, optional as documents from before price history don't have it
+			{Name: "price_dropped_recently", Type: "bool", Facet: pointer.True(), Optional: pointer.True()},
+			// A featured listing window is running, set and cleared by the listings worker as windows start and end
+			{Name: "is_featured", Type: "bool", Facet: pointer.True(), Optional: pointer.True()},
+			// Size of the validated model files, so buyers on metered connections can sort by download size. Optional for
+			// documents indexed before it. The display copy is stored for the search card and never searched.
+			{Name: "total_model_size_bytes", Type: "int64", Sort: pointer.True(), Optional: pointer.True()},
+			{Name: "total_model_size_display", Type: "string", Index: pointer.False(), Optional: pointer.True()},
 
 			{Name: "created_at", Type: "int64", Sort: pointer.True()},
 			{Name: "updated_at", Type: "int64"},
+			// Only handed back to the gateway's POST /listings/hydrate, never searched. Optional for documents indexed before it.
+			{Name: "content_hash", Type: "string", Index: pointer.False(), Optional: pointer.True()},
 		},
 		DefaultSortingField: pointer.String("created_at"),
 	}
 
 	// 1. Check if collection exists
 	log.Printf("Checking schema for '%s'...", collectionName)
-	_, err := client.Collection(collectionName).Retrieve(context.Background())
+	existing, err := client.Collection(collectionName).Retrieve(context.Background())
 
 	if err != nil {
 		// 2. CASE: Collection does not exist (404) -> CREATE
@@ -135,7 +160,7 @@ func main() {
 
 		// We pass the fields to Update. Typesense ignores fields that already exist matches.
 		updateSchema := &api.CollectionUpdateSchema{
-			Fields: schema.Fields,
+			Fields: append(madeOptional(existing.Fields, schema.Fields), schema.Fields...),
 		}
 
 		_, err := client.Collection(collectionName).Update(context.Background(), updateSchema)
@@ -145,3 +170,22 @@ func main() {
 		log.Println("✅ Schema updated (synced) successfully.")
 	}
 }
+
+// madeOptional drops the live fields that have since become optional, e.g. is_manifold. Typesense can't change a
+// field in place, dropping it in the same update that adds it back re-indexes it from the stored documents.
+func madeOptional(live []api.Field, wanted []api.Field) []api.Field {
+	var drops []api.Field
+	for _, w := range wanted {
+		for _, l := range live {
+			if l.Name == w.Name && !isTrue(l.Optional) && isTrue(w.Optional) {
+				log.Printf("Field '%s' became optional, dropping and adding it back", w.Name)
+				drops = append(drops, api.Field{Name: l.Name, Type: l.Type, Drop: pointer.True()})
+			}
+		}
+	}
+	return drops
+}
+
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
@@ -2,18 +2,49 @@ package main
 
 import (
 	"context"
+	"errors"
+	"gateway/internal/activity"
 	"gateway/internal/auth"
 	"gateway/internal/cache"
+	"gateway/internal/counters"
+	"gateway/internal/currency"
+	"gateway/internal/database/postgresql"
 	"gateway/internal/events"
+	"gateway/internal/handlers/cacheadmin"
+	"gateway/internal/handlers/categories"
+	"gateway/internal/handlers/featured"
 	"gateway/internal/handlers/files"
+	"gateway/internal/handlers/hardware"
+	"gateway/internal/handlers/listingevents"
 	"gateway/internal/handlers/listings"
+	"gateway/internal/handlers/outboxadmin"
+	"gateway/internal/handlers/pins"
+	"gateway/internal/handlers/savedsearches"
+	"gateway/internal/handlers/screening"
+	"gateway/internal/handlers/sellers"
+	"gateway/internal/handlers/shortlinks"
 	"gateway/internal/idempotency"
+	"gateway/internal/json"
+	"gateway/internal/loadshed"
+	"gateway/internal/logging"
+	"gateway/internal/maintenance"
+	"gateway/internal/openapi"
+	"gateway/internal/outbox"
+	"gateway/internal/publicurl"
+	"gateway/internal/ratelimit"
+	"gateway/internal/realip"
+	"gateway/internal/scrapeguard"
+	"gateway/internal/search"
 	"gateway/internal/storage"
-	"log"
 	"log/slog"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/signal"
+	"path"
+	"shared/version"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -23,25 +54,66 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// database is what the handlers and shutdown use from the pool, the route tests mount the router on pgxmock through it
+type database interface {
+	repo.DBTX
+	postgresql.DBPool
+	Close()
+}
+
 type application struct {
 	config        config
-	conn          *pgxpool.Pool
+	conn          database
 	cache         *cache.RedisClient
 	authenticator *auth.Authenticator
 	storage       storage.Provider
+	search        search.Client
 	eventBus      events.Bus
+	outbox        *outbox.Relay      // Publishes the events requests wrote to the outbox, stopped before NATS is drained
+	listingEvents *listingevents.Hub // Streams of GET /listings/{id}/events, closed when shutdown starts
 	logger        *slog.Logger
+	logLevel      *slog.LevelVar // Shared with the logger, changed by PUT /admin/log-level
+	build         version.Info   // On /readyz and the X-Service-Version header
+
+	// Goroutines that outlive their request (idempotency saves, async cache writes).
+	// Shutdown waits on these before closing the clients they use.
+	background sync.WaitGroup
+	// Reported by /health, flipped off at the start of shutdown so the load balancer stops routing to us
+	ready atomic.Bool
 }
 
 type config struct {
+	environment               string // APP_ENV as set, empty when unset. "production" hides the API docs
 	events                    *events.EventConfig
 	frontend                  string
 	addr                      string
 	fileConstraints           map[string]files.FileConstraint
 	fileValidationWindowHours int
-	publicFilesUrl            string
+	uploadCallbacks           files.Callbacks         // Domains each API key may be called back on and can be signed for
+	publicURLs                publicurl.Config        // Browser-facing base URLs, see PUBLIC_ASSETS_BASE_URL and CDN_IMAGE_BASE_URL
+	downloads                 listings.DownloadConfig // URL lifetime per file type, see DOWNLOAD_TTL_* in main.go
+	sellerTermsVersion        string
+	listingLimits             listings.CreationLimits // Per-seller creation throttle, see LISTING_LIMIT_* in main.go
+	imageBounds               listings.ImageBounds    // Allowed gallery image dimensions, see IMAGE_* in main.go
+	shutdownTimeout           time.Duration           // Upper bound for draining requests and background work
+	readinessDrainDelay       time.Duration           // Time between failing readiness and closing the listener
+	queryTimeout              time.Duration           // Each query's own timeout, see DB_QUERY_TIMEOUT in main.go
+	statementTimeout          time.Duration           // statement_timeout on every connection, see DB_STATEMENT_TIMEOUT in main.go
+	loadShed                  loadshed.Config
+	outbox                    outbox.Config          // See OUTBOX_* in main.go
+	scrapeGuard               scrapeguard.Config     // See SCRAPE_* in main.go
+	trustedProxies            []netip.Prefix         // Proxies whose X-Forwarded-For is believed, see TRUSTED_PROXIES
+	searchBreaker             search.BreakerConfig   // See SEARCH_BREAKER_* in main.go
+	shortLinks                shortlinks.Config      // SHORT_LINK_BASE_URL, redirects go to DOMAIN_NAME
+	previews                  listings.PreviewConfig // Link previews point at DOMAIN_NAME, see OG_PLACEHOLDER_IMAGE_URL
+	listingEvents             listingevents.Config   // Heartbeat, lifetime and per replica cap of GET /listings/{id}/events
+	currencyRates             currency.Config        // Display currency conversion, see CURRENCY_RATES_* in main.go
+
+	// What a new listing is checked against, see LISTING_VALIDATION_RULES in main.go
+	validationRules *listings.ValidationRuleSet
 }
 
 type databaseConfig struct {
@@ -49,67 +121,299 @@ type databaseConfig struct {
 }
 
 type authorizationConfig struct {
+	mode     string // AUTH_MODE, empty for Keycloak or auth.ModeStatic for local development
 	url      string
 	realm    string
 	clientID string
 	secret   string
+	keycloak auth.Config // Timeouts, key refresh and circuit breaker for the calls to Keycloak
+	// AUTHORIZATION_SERVICE_CLIENTS, service account clients allowed on /internal/, e.g. "listings-worker"
+	serviceClients []string
 }
 
 type eventBusConfig struct{}
 
 func (app *application) mount() http.Handler {
 	r := chi.NewRouter()
+	router := r // The groups below shadow r
 
-	r.Use(middleware.Logger)
-	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(named("logger", middleware.Logger))
+	r.Use(named("request-id", middleware.RequestID))
+	r.Use(named("real-ip", realip.Middleware(app.config.trustedProxies)))
+	r.Use(named("version", version.Middleware(app.build)))
 
-	r.Use(cors.Handler(cors.Options{
+	r.Use(named("cors", cors.Handler(cors.Options{
 		AllowedOrigins:   []string{app.config.frontend},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Idempotency-Key"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: false,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
-	}))
+	})))
 	slog.Info("Allowed origins", "origin", app.config.frontend)
 
-	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(named("timeout", unlessEventStream(middleware.Timeout(60*time.Second))))
 
+	// Readiness probe, deliberately outside the maintenance guard so pods stay in rotation
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		if !app.ready.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("shutting down"))
+			return
+		}
 		w.Write([]byte("looking gud bruv"))
 	})
 
+	// Same readiness as /health, plus warnings about degraded dependencies. Those never fail it, every pod
+	// shares them and taking them all out of rotation would turn a degraded homepage into an outage.
+	r.Get("/readyz", app.readyz)
+
+	r.Get("/metrics", promhttp.Handler().ServeHTTP)
+	r.Get("/openapi.json", openapi.Handler)
+	if app.config.environment != "production" {
+		r.Get("/docs", openapi.DocsHandler)
+	}
+
+	// Everything above is exempt from shedding so probes and scrapes still answer under load
+	var poolStats loadshed.PoolStats
+	if pool, ok := app.conn.(*pgxpool.Pool); ok {
+		poolStats = loadshed.FromPool(pool)
+	}
+	shedder := loadshed.New(app.config.loadShed, poolStats, app.logger)
+
+	scrapeGuard := scrapeguard.NewGuard(app.config.scrapeGuard, scrapeguard.NewStore(app.cache), ratelimit.NewStore(app.cache), app.logger)
+
 	idempotencyStore := idempotency.NewStore(app.cache)
 
-	repo := repo.New(app.conn)
-	filesService := files.NewFileService(app.storage, app.config.fileValidationWindowHours, app.config.fileConstraints, app.eventBus)
+	maintenanceStore := maintenance.NewStore(app.cache)
+	maintenanceGuard := maintenance.NewGuard(maintenanceStore, 5*time.Second, app.logger)
+	maintenanceHandler := maintenance.NewHandler(maintenanceStore, maintenanceGuard)
+
+	// The pool itself stays with the load shedder and shutdown, everything that queries goes through the timeout
+	db := postgresql.NewTimeoutDB(app.conn, app.config.queryTimeout)
+	repo := repo.New(db)
+	filesService := files.NewFileService(app.storage, repo, app.config.fileValidationWindowHours, app.config.fileConstraints, app.config.uploadCallbacks, app.eventBus)
 	filesHandler := files.NewFileHandler(filesService)
 
 	eventHandler := events.NewEventHandler(app.eventBus, app.config.events, app.logger)
 
-	listingsService := listings.NewListingsService(repo, app.conn, app.logger, app.storage, eventHandler, app.cache, app.config.publicFilesUrl)
-	listingsHandler := listings.NewListingsHandler(listingsService)
+	hardwareService := hardware.NewHardwareService(repo, app.logger)
+	hardwareHandler := hardware.NewHardwareHandler(hardwareService)
+
+	screeningService := screening.NewScreeningService(repo, app.cache, app.logger)
+	screeningHandler := screening.NewScreeningHandler(screeningService)
+	outboxHandler := outboxadmin.NewOutboxHandler(outboxadmin.NewOutboxService(repo, app.logger))
+	// A moderator's change to the term list reaches every pod straight away, not after TermsTTL
+	if err := screeningService.Listen(context.Background()); err != nil {
+		app.logger.Error("Failed to listen for banned term changes", "error", err)
+	}
+
+	// Refreshed by the job main starts, see currency.Refresher
+	rates := currency.NewConverter(currency.NewStore(app.cache), app.config.currencyRates.MaxAge, app.logger)
+
+	categoriesStore := categories.NewStore(app.cache)
+	categoriesService := categories.NewCategoriesService(repo, app.search, categoriesStore, categoriesStore, categoriesStore, app.config.publicURLs, app.logger)
+	categoriesHandler := categories.NewCategoriesHandler(categoriesService, rates)
+
+	listingsService := listings.NewListingsService(repo, db, app.logger, app.storage, eventHandler, app.cache, app.config.publicURLs, app.config.downloads, app.config.sellerTermsVersion, app.config.listingLimits, app.config.imageBounds, app.config.validationRules, ratelimit.NewStore(app.cache), hardwareService, screeningService, categoriesService, &app.background)
+	listingsHandler := listings.NewListingsHandler(listingsService, counters.NewStore(app.cache), rates)
+
+	// The worker reports listings it gave up indexing, so sellers and moderators can see them
+	if sub, ok := app.eventBus.(events.Subscriber); ok && app.config.events.ListingIndexFailed != "" {
+		if err := events.SubscribeToListingIndexFailed(sub, app.config.events, app.logger, listingsService.RecordIndexFailure); err != nil {
+			app.logger.Error("Failed to subscribe to ListingIndexFailed events", "error", err)
+		}
+	}
+
+	// Every replica hears validation progress and passes it to the sellers streaming that listing from it
+	if listener, ok := app.eventBus.(events.Listener); ok {
+		if app.config.events.FileValidated != "" {
+			if err := events.ListenToFileValidated(listener, app.config.events, app.logger, app.listingEvents.FileValidated); err != nil {
+				app.logger.Error("Failed to listen to FileValidated events", "error", err)
+			}
+		}
+		if app.config.events.ListingPublished != "" {
+			if err := events.ListenToListingPublished(listener, app.config.events, app.logger, app.listingEvents.ListingPublished); err != nil {
+				app.logger.Error("Failed to listen to ListingPublished events", "error", err)
+			}
+		}
+	}
+	listingEventsHandler := listingevents.NewListingEventsHandler(listingsService, app.listingEvents, app.config.listingEvents)
+
+	sellersService := sellers.NewSellersService(repo, app.cache, listings.CacheNamespace(app.config.publicURLs), app.logger, app.config.sellerTermsVersion)
+	sellersHandler := sellers.NewSellersHandler(sellersService)
+
+	previewHandler := listings.NewPreviewHandler(listingsService, app.config.previews)
+
+	shortLinksHandler := shortlinks.NewShortLinksHandler(shortlinks.NewShortLinksService(repo, shortlinks.NewStore(app.cache), counters.NewStore(app.cache), app.config.shortLinks, app.logger), previewHandler)
+
+	savedSearchesHandler := savedsearches.NewSavedSearchesHandler(savedsearches.NewSavedSearchesService(db, repo, app.logger))
+
+	logLevelHandler := logging.NewHandler(app.logLevel)
+
+	featuredHandler := featured.NewFeaturedHandler(featured.NewFeaturedService(repo, listingsService, eventHandler, featured.NewStore(app.cache, listings.CacheNamespace(app.config.publicURLs)), app.logger))
+
+	pinsHandler := pins.NewPinsHandler(pins.NewPinsService(db, repo, listingsService, pins.NewStore(app.cache), app.logger))
+
+	cacheAdminHandler := cacheadmin.NewHandler(cacheadmin.NewStore(app.cache, listings.CacheNamespace(app.config.publicURLs)))
+
+	activityTracker := activity.NewTracker(activity.NewStore(app.cache), repo, activity.DefaultInterval, &app.background, app.logger)
+
+	r.Route("/internal", func(r chi.Router) {
+		// Calls from our own workers with their service account tokens. Nothing under /internal/ is for browsers, the
+		// ingress must not route it, and none of the public middleware applies: no scrape guard, maintenance guard or
+		// idempotency. User tokens are refused by RequireRole, see auth.RoleService.
+		r.Use(named("recoverer", middleware.Recoverer))
+		r.Use(named("loadshed", shedder.Middleware))
+		r.Use(named("auth", app.authenticator.Middleware))
+		r.Use(named("role:service", auth.RequireRole(auth.RoleService)))
+
+		r.Get("/whoami", app.whoami)
+		r.Post("/files/{id}/validation-result", listingsHandler.ApplyValidationResult)
+	})
+
+	r.Group(func(r chi.Router) {
+		// Short link redirects and link previews, on social media where every hop shows. No scrape guard, a link
+		// doing the rounds brings a burst of clicks from the same few in-app browsers and preview crawlers.
+		r.Use(named("recoverer", middleware.Recoverer))
+		r.Use(named("loadshed", shedder.Middleware))
+
+		r.Get("/l/{code}", shortLinksHandler.Redirect)
+		r.Get("/listings/{id}/og", previewHandler.GetListingPreview)
+		// Search engine crawlers render the listing page and fetch this with it, like the preview crawlers
+		r.Get("/listings/{id}/jsonld", previewHandler.GetListingJSONLD)
+	})
 
 	r.Group(func(r chi.Router) {
 		// Public routes
-		r.Use(middleware.Recoverer)
+		r.Use(named("recoverer", middleware.Recoverer))
+		r.Use(named("loadshed", shedder.Middleware))
+		r.Use(named("scrapeguard", scrapeGuard.Middleware))
+
+		r.Get("/listings/validation-rules", listingsHandler.GetValidationRules)
+		// A token is only needed for ?preview=buyer, the seller viewing their own listing as a buyer would
+		r.With(named("auth:optional", app.authenticator.Optional)).Get("/listings/{id}", listingsHandler.GetListingByID)
+		r.Post("/listings/hydrate", listingsHandler.HydrateListing)
+		r.Get("/listings/featured", featuredHandler.GetActive)
+		// One recursive query over the whole tree
+		r.With(named("loadshed:expensive", shedder.Expensive)).Get("/listings/{id}/remixes", listingsHandler.GetRemixTree)
+		// Free files can be downloaded without an account, paid ones still need a token
+		r.With(named("auth:optional", app.authenticator.Optional)).Get("/listings/{id}/files/{fileId}/download", listingsHandler.GetFileDownload)
+		r.Get("/categories/counts", categoriesHandler.GetCounts)
+		r.Get("/categories/{slug}/defaults", categoriesHandler.GetDefaults)
+		// Fans out to search and the database, cached per category
+		r.With(named("loadshed:expensive", shedder.Expensive)).Get("/categories/{slug}/page", categoriesHandler.GetPage)
+		r.Get("/hardware-options", hardwareHandler.List)
+		// A seller's public profile page, pinned listings first
+		r.Get("/sellers/{id}/listings", pinsHandler.GetStorefront)
+		r.Get("/materials", listingsHandler.GetMaterials)
+	})
 
-		r.Get("/listings/{id}", listingsHandler.GetListingByID)
+	r.Group(func(r chi.Router) {
+		// Listing event streams. Outside the load shedder, a stream sits idle for minutes and would crowd out real
+		// requests from the in-flight ceiling, listingevents.Config.MaxStreams caps them instead.
+		r.Use(named("recoverer", middleware.Recoverer))
+		r.Use(named("auth", app.authenticator.Middleware))
+
+		r.Get(eventStreamRoute, listingEventsHandler.Stream)
 	})
 
 	r.Group(func(r chi.Router) {
-		r.Use(middleware.Recoverer)
-		r.Use(idempotency.Idempotency(idempotencyStore))
+		// Admin routes, not behind the maintenance guard so it can be switched off again
+		r.Use(named("recoverer", middleware.Recoverer))
+		r.Use(named("loadshed", shedder.Middleware))
+		r.Use(named("auth", app.authenticator.Middleware))
+
+		// Every admin route is behind one of the two role groups, the handlers don't check roles themselves
+		r.Group(func(r chi.Router) {
+			r.Use(named("role:admin", auth.RequireRole(auth.RoleAdmin)))
+
+			r.Get("/admin/maintenance", maintenanceHandler.GetMaintenance)
+			r.Put("/admin/maintenance", maintenanceHandler.SetMaintenance)
+
+			// This pod only, for following a request through with debug logs
+			r.Get("/admin/log-level", logLevelHandler.GetLevel)
+			r.Put("/admin/log-level", logLevelHandler.SetLevel)
+
+			// Every route with the middleware in front of it, to check what protects what
+			r.Get("/admin/routes", routesHandler(router))
+
+			// Homepage features, time-boxed by marketing
+			r.Get("/admin/featured-listings", featuredHandler.List)
+			r.Post("/admin/featured-listings", featuredHandler.Create)
+			r.Put("/admin/featured-listings/{id}", featuredHandler.Update)
+			r.Delete("/admin/featured-listings/{id}", featuredHandler.Delete)
+
+			// Events the outbox relay hasn't published, and a retry for the ones it gave up on
+			r.Get("/admin/outbox", outboxHandler.List)
+			r.Post("/admin/outbox/{id}/retry", outboxHandler.Retry)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(named("role:moderator", auth.RequireRole(auth.RoleModerator, auth.RoleAdmin)))
 
+			// Cached listing responses, for chasing down stale pages without a Redis shell
+			r.Get("/admin/cache/listing/{id}", cacheAdminHandler.GetListing)
+			r.Delete("/admin/cache/listing/{id}", cacheAdminHandler.DeleteListing)
+
+			r.Post("/admin/hardware-options", hardwareHandler.Add)
+
+			// Terms listing titles and descriptions are screened against
+			r.Get("/admin/banned-terms", screeningHandler.List)
+			r.Put("/admin/banned-terms/{term}", screeningHandler.Set)
+			r.Delete("/admin/banned-terms/{term}", screeningHandler.Delete)
+			r.Put("/admin/categories/{slug}/defaults", categoriesHandler.SetDefaults)
+
+			// Every listing for moderators, with filters, a cursor and a CSV export, and moderating a single listing,
+			// deleted ones included. Every change is reindexed.
+			r.Get("/admin/listings", listingsHandler.ListAdminListings)
+			r.Get("/admin/listings/{id}", listingsHandler.GetAdminListing)
+			r.Put("/admin/listings/{id}/suspension", listingsHandler.SuspendListing)
+			r.Put("/admin/listings/{id}/nsfw", listingsHandler.SetListingNSFW)
+		})
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(named("recoverer", middleware.Recoverer))
+		r.Use(named("loadshed", shedder.Middleware))
+		// Before idempotency, otherwise the 503 would be replayed after maintenance ends
+		r.Use(named("maintenance", maintenanceGuard.Middleware))
 		// Authenticated routes
-		r.Use(app.authenticator.Middleware)
+		r.Use(named("auth", app.authenticator.Middleware))
+		// Feeds the seller activity bucket search ranks by
+		r.Use(named("activity", activityTracker.Middleware))
+		// Last so idempotency.Skip() on a route can be seen
+		r.Use(named("idempotency", idempotency.Idempotency(idempotencyStore, &app.background)))
 		r.Post("/files/presign", filesHandler.PresignUpload)
 
-		r.Post("/listings", listingsHandler.CreateListing)
-		r.Get("/listings", listingsHandler.GetListingsForUser)
+		r.Get("/me/seller-profile", sellersHandler.GetProfile)
+		r.Post("/me/seller-profile", sellersHandler.UpsertProfile)
+		r.Post("/me/vacation", sellersHandler.StartVacation)
+		r.Delete("/me/vacation", sellersHandler.EndVacation)
+
+		r.Get("/me/saved-searches", savedSearchesHandler.List)
+		r.Post("/me/saved-searches", savedSearchesHandler.Save)
+		r.Delete("/me/saved-searches/{id}", savedSearchesHandler.Delete)
+
+		r.Get("/me/downloads", listingsHandler.GetDownloadHistory)
+		r.Get("/me/downloads/{id}/files/{fileId}/download", listingsHandler.DownloadAgain)
+
+		r.Put("/me/listings/{id}/pin", pinsHandler.Pin)
+		r.Delete("/me/listings/{id}/pin", pinsHandler.Unpin)
+
+		// These need a database connection for the whole request, shed them first when the pool is saturated
+		r.With(named("loadshed:expensive", shedder.Expensive)).Post("/listings", listingsHandler.CreateListing)
+		r.With(named("loadshed:expensive", shedder.Expensive)).Get("/listings", listingsHandler.GetListingsForUser)
 		r.Delete("/listings/{id}", listingsHandler.DeleteListing)
-		r.Put("/listings/{id}", listingsHandler.UpdateListings)
+		// One transaction over up to 100 listings, a retry without a key would run it all again
+		r.With(named("idempotency:require", idempotency.Require), named("loadshed:expensive", shedder.Expensive)).Post("/listings/bulk", listingsHandler.BulkUpdateListings)
+		r.With(named("loadshed:expensive", shedder.Expensive)).Put("/listings/{id}", listingsHandler.UpdateListings)
+		r.With(named("loadshed:expensive", shedder.Expensive)).Patch("/listings/{id}", listingsHandler.PatchListing)
+		r.Get("/listings/{id}/status-history", listingsHandler.GetStatusHistory)
+		r.Post("/listings/{id}/variants", listingsHandler.CreateVariant)
+		r.Delete("/listings/{id}/variants/{variantId}", listingsHandler.DeleteVariant)
+		r.Post("/listings/{id}/short-link", shortLinksHandler.Create)
+		r.Delete("/listings/{id}/short-link/{code}", shortLinksHandler.Revoke)
 
 		// Needs rate limiting in future
 
@@ -121,6 +425,66 @@ func (app *application) mount() http.Handler {
 	return r
 }
 
+// eventStreamRoute is the one route that outlives the request timeout
+const eventStreamRoute = "/listings/{id}/events"
+
+// unlessEventStream applies mw to every route but eventStreamRoute
+func unlessEventStream(mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				if ok, _ := path.Match("/listings/*/events", r.URL.Path); ok {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			wrapped.ServeHTTP(w, r)
+		})
+	}
+}
+
+// readinessResponse is the body of /readyz
+type readinessResponse struct {
+	Status   string       `json:"status"`   // "ready" or "shutting_down"
+	Warnings []string     `json:"warnings"` // Degraded dependencies, empty when everything is healthy
+	Build    version.Info `json:"build"`
+}
+
+// serviceIdentity is the body of /internal/whoami
+type serviceIdentity struct {
+	ClientID string   `json:"client_id"` // azp of the service account token
+	Subject  string   `json:"subject"`
+	Roles    []string `json:"roles"`
+}
+
+// whoami lets a worker check its service account token is accepted before it relies on the internal routes
+func (app *application) whoami(w http.ResponseWriter, r *http.Request) {
+	// RequireRole already turned away requests without a user
+	userInfo, _ := auth.GetUserInfo(r.Context())
+	json.Write(w, http.StatusOK, serviceIdentity{ClientID: userInfo.AuthorizedParty, Subject: userInfo.ID, Roles: userInfo.Roles})
+}
+
+func (app *application) readyz(w http.ResponseWriter, r *http.Request) {
+	response := readinessResponse{Status: "ready", Warnings: []string{}, Build: app.build}
+	status := http.StatusOK
+	if !app.ready.Load() {
+		response.Status = "shutting_down"
+		status = http.StatusServiceUnavailable
+	}
+
+	if breaker, ok := app.search.(*search.Breaker); ok {
+		if state := breaker.State(); state != search.StateClosed {
+			response.Warnings = append(response.Warnings, "search circuit breaker is "+state.String()+", serving stale or database results")
+		}
+	}
+	if app.authenticator != nil && app.authenticator.Unavailable() {
+		response.Warnings = append(response.Warnings, "keycloak circuit breaker is open, tokens signed with a key we haven't cached get 503")
+	}
+
+	json.Write(w, status, response)
+}
+
 func (app *application) run(h http.Handler) error {
 	svr := &http.Server{
 		Addr:         app.config.addr,
@@ -130,46 +494,150 @@ func (app *application) run(h http.Handler) error {
 		IdleTimeout:  time.Minute * 1,
 	}
 
+	if app.listingEvents != nil {
+		// Shutdown waits for open connections, the streams would hold it up for their whole lifetime
+		svr.RegisterOnShutdown(app.listingEvents.Close)
+	}
+
+	var relay *backgroundTask
+	if app.outbox != nil {
+		relay = startBackgroundTask(app.outbox.Run)
+	}
+
 	slog.Info("Starting server on " + app.config.addr)
+	serverErr := make(chan error, 1)
 	go func() {
 		if err := svr.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Listen: %s\n", err)
+			serverErr <- err
 		}
 	}()
+	app.ready.Store(true)
 
-	// Wait for Interrupt Signal (Ctrl+C or Docker Stop)
+	// Wait for Interrupt Signal (Ctrl+C or Docker Stop), or the listener failing
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
-	<-quit
 
-	log.Println("Shutting down server...")
+	var listenErr error
+	select {
+	case sig := <-quit:
+		slog.Info("Shutting down server...", "signal", sig.String())
+	case listenErr = <-serverErr:
+		// Still run the full shutdown so NATS is drained and the pools are closed
+		slog.Error("Server stopped unexpectedly, shutting down", "error", listenErr)
+	}
 
-	// Create a deadline to wait for active requests (e.g. 10 seconds)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), app.config.shutdownTimeout)
 	defer cancel()
 
-	// Shutdown HTTP
-	if err := svr.Shutdown(ctx); err != nil {
-		log.Fatal("Server Forced to Shutdown:", err)
+	err := app.shutdown(ctx, shutdownDeps{
+		server:   svr,
+		outbox:   relay,
+		eventBus: app.eventBus,
+		db:       app.conn,
+		cache:    app.cache,
+	})
+	if err := errors.Join(listenErr, err); err != nil {
 		return err
 	}
 
-	// Shutdown NATS (Drain is better than Close)
+	slog.Info("Server Exited Properly")
+	return nil
+}
+
+// shutdownDeps are the components closed during shutdown, split out from the application so the ordering can be tested
+type shutdownDeps struct {
+	server   interface{ Shutdown(context.Context) error }
+	outbox   interface{ Stop() } // nil when the relay isn't running
+	eventBus interface{ Drain() error }
+	db       interface{ Close() }
+	cache    interface{ Close() error }
+}
+
+// shutdown stops taking new work first, then waits for in-flight work, then closes the clients that work depends on.
+// Every step runs even if an earlier one fails, errors are logged and returned together.
+func (app *application) shutdown(ctx context.Context, deps shutdownDeps) error {
+	var errs []error
+
+	// 1. Fail readiness and give the load balancer a moment to notice before we stop listening
+	app.ready.Store(false)
+	select {
+	case <-time.After(app.config.readinessDrainDelay):
+	case <-ctx.Done():
+	}
+
+	// 2. Stop accepting connections and wait for active requests
+	if err := deps.server.Shutdown(ctx); err != nil {
+		app.logger.Error("Server forced to shutdown", "error", err)
+		errs = append(errs, err)
+	}
+
+	// 3. Requests are done, wait for the work they left behind (still needs Redis)
+	if !waitWithContext(ctx, &app.background) {
+		app.logger.Warn("Timed out waiting for background work, some idempotency responses or cache writes may be lost")
+	}
+
+	// 4. Stop the outbox relay, a pass in flight still marks what it published. Anything left goes out from another
+	// replica or after the restart.
+	if deps.outbox != nil {
+		deps.outbox.Stop()
+	}
+
+	// 5. Drain NATS (Drain is better than Close)
 	// Drain allows in-flight messages to finish processing
-	if err := app.eventBus.Drain(); err != nil {
-		log.Fatal("NATS Drain failed:", err)
-		return err
+	if err := deps.eventBus.Drain(); err != nil {
+		app.logger.Error("NATS drain failed", "error", err)
+		errs = append(errs, err)
 	}
 
-	// Close DB Connection Pool
-	app.conn.Close()
+	// 6. Close DB Connection Pool
+	deps.db.Close()
 
-	// Close Redis Client
-	if err := app.cache.Close(); err != nil {
-		log.Fatal("Redis Close failed:", err)
-		return err
+	// 7. Close Redis Client
+	if err := deps.cache.Close(); err != nil {
+		app.logger.Error("Redis close failed", "error", err)
+		errs = append(errs, err)
 	}
 
-	log.Println("Server Exited Properly")
-	return nil
+	return errors.Join(errs...)
+}
+
+// backgroundTask is a loop that runs for the life of the server, like the outbox relay
+type backgroundTask struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func startBackgroundTask(run func(ctx context.Context)) *backgroundTask {
+	ctx, cancel := context.WithCancel(context.Background())
+	task := &backgroundTask{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(task.done)
+		run(ctx)
+	}()
+	return task
+}
+
+// Stop cancels the loop and waits for it to return, a nil task was never started
+func (t *backgroundTask) Stop() {
+	if t == nil {
+		return
+	}
+	t.cancel()
+	<-t.done
+}
+
+// waitWithContext waits for wg, giving up when ctx is done. Reports whether everything finished.
+func waitWithContext(ctx context.Context, wg *sync.WaitGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
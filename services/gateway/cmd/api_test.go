@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gateway/internal/events"
+	"gateway/internal/mocks/mocksearch"
+	"gateway/internal/openapi"
+	"gateway/internal/search"
+	"gateway/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"shared/version"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// shutdownRecorder is shared by the fakes so the test can see the order things happened in
+type shutdownRecorder struct {
+	mu     sync.Mutex
+	events []string
+	closed map[string]bool
+}
+
+func newShutdownRecorder() *shutdownRecorder {
+	return &shutdownRecorder{closed: map[string]bool{}}
+}
+
+// use records a call against a component, failing if it has already been closed
+func (r *shutdownRecorder) use(t *testing.T, component string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	assert.False(t, r.closed[component], "%s used after it was closed", component)
+	r.events = append(r.events, "use "+component)
+}
+
+func (r *shutdownRecorder) close(component string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed[component] = true
+	r.events = append(r.events, "close "+component)
+}
+
+type FakeServer struct {
+	rec   *shutdownRecorder
+	app   *application
+	ready bool // readiness as seen when Shutdown was called
+}
+
+func (f *FakeServer) Shutdown(ctx context.Context) error {
+	f.ready = f.app.ready.Load()
+	f.rec.close("server")
+	return nil
+}
+
+type FakeBus struct {
+	rec *shutdownRecorder
+	err error
+}
+
+func (f *FakeBus) Drain() error {
+	f.rec.close("nats")
+	return f.err
+}
+
+type FakeRelay struct {
+	rec *shutdownRecorder
+}
+
+func (f *FakeRelay) Stop() {
+	f.rec.close("outbox")
+}
+
+type FakeDB struct {
+	rec *shutdownRecorder
+}
+
+func (f *FakeDB) Close() {
+	f.rec.close("db")
+}
+
+type FakeCache struct {
+	rec *shutdownRecorder
+}
+
+func (f *FakeCache) Close() error {
+	f.rec.close("redis")
+	return nil
+}
+
+func newShutdownTest() (*application, *shutdownRecorder, shutdownDeps) {
+	app := &application{logger: testutil.NewTestLogger()}
+	app.ready.Store(true)
+
+	rec := newShutdownRecorder()
+	deps := shutdownDeps{
+		server:   &FakeServer{rec: rec, app: app},
+		outbox:   &FakeRelay{rec: rec},
+		eventBus: &FakeBus{rec: rec},
+		db:       &FakeDB{rec: rec},
+		cache:    &FakeCache{rec: rec},
+	}
+	return app, rec, deps
+}
+
+func TestShutdown_Order(t *testing.T) {
+	app, rec, deps := newShutdownTest()
+
+	require.NoError(t, app.shutdown(context.Background(), deps))
+
+	assert.Equal(t, []string{"close server", "close outbox", "close nats", "close db", "close redis"}, rec.events)
+	assert.False(t, deps.server.(*FakeServer).ready, "readiness should fail before the server stops listening")
+}
+
+func TestShutdown_WaitsForBackgroundWork(t *testing.T) {
+	// SCENARIO: An idempotency save is still writing to Redis when the signal arrives.
+	// EXPECT: Redis is only closed once the save has finished.
+
+	app, rec, deps := newShutdownTest()
+
+	release := make(chan struct{})
+	app.background.Add(1)
+	go func() {
+		defer app.background.Done()
+		<-release
+		rec.use(t, "redis")
+	}()
+
+	go func() {
+		// Let shutdown reach the wait before the save completes
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+
+	require.NoError(t, app.shutdown(context.Background(), deps))
+
+	assert.Equal(t, []string{"close server", "use redis", "close outbox", "close nats", "close db", "close redis"}, rec.events)
+}
+
+func TestShutdown_BackgroundWorkTimesOut(t *testing.T) {
+	// SCENARIO: Background work hangs.
+	// EXPECT: Shutdown gives up at the deadline and still closes everything.
+
+	app, rec, deps := newShutdownTest()
+
+	app.background.Add(1)
+	defer app.background.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, app.shutdown(ctx, deps))
+
+	assert.Equal(t, []string{"close server", "close outbox", "close nats", "close db", "close redis"}, rec.events)
+}
+
+func TestShutdown_ErrorDoesNotSkipLaterSteps(t *testing.T) {
+	app, rec, deps := newShutdownTest()
+	deps.eventBus.(*FakeBus).err = errors.New("nats gone")
+
+	err := app.shutdown(context.Background(), deps)
+
+	assert.ErrorContains(t, err, "nats gone")
+	assert.True(t, rec.closed["db"])
+	assert.True(t, rec.closed["redis"])
+}
+
+func TestOpenAPI_CoversEveryRoute(t *testing.T) {
+	// SCENARIO: A route is mounted on the router without being added to internal/openapi/openapi.json.
+	// EXPECT: This fails, naming the route.
+
+	app := &application{
+		config: config{events: &events.EventConfig{}},
+		logger: testutil.NewTestLogger(),
+	}
+	router, ok := app.mount().(chi.Routes)
+	require.True(t, ok)
+
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(openapi.Spec(), &spec))
+
+	routes := 0
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes++
+		_, ok := spec.Paths[route][strings.ToLower(method)]
+		assert.True(t, ok, "%s %s is mounted but missing from openapi.json", method, route)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, routes)
+}
+
+func TestDocs_HiddenInProduction(t *testing.T) {
+	for env, want := range map[string]int{"development": http.StatusOK, "": http.StatusOK, "production": http.StatusNotFound} {
+		app := &application{
+			config: config{environment: env, events: &events.EventConfig{}},
+			logger: testutil.NewTestLogger(),
+		}
+		w := httptest.NewRecorder()
+		app.mount().ServeHTTP(w, httptest.NewRequest("GET", "/docs", nil))
+		assert.Equal(t, want, w.Code, env)
+	}
+}
+
+func TestUnlessEventStream(t *testing.T) {
+	// SCENARIO: The request timeout is wrapped for the listing event stream and ordinary routes.
+	// EXPECT: Only GET /listings/{id}/events runs without a deadline.
+
+	handler := unlessEventStream(middleware.Timeout(time.Minute))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			w.Header().Set("X-Deadline", "yes")
+		}
+	}))
+
+	for path, want := range map[string]string{
+		"GET /listings/" + routeListingID + "/events":         "",
+		"POST /listings/" + routeListingID + "/events":        "yes",
+		"GET /listings/" + routeListingID:                     "yes",
+		"GET /listings/" + routeListingID + "/events/extra":   "yes",
+		"GET /listings/" + routeListingID + "/status-history": "yes",
+	} {
+		method, target, _ := strings.Cut(path, " ")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		assert.Equal(t, want, w.Header().Get("X-Deadline"), path)
+	}
+}
+
+func TestReadyz_SearchBreakerIsOnlyAWarning(t *testing.T) {
+	// SCENARIO: The search circuit breaker has opened after Typesense failed.
+	// EXPECT: /readyz stays 200 and lists a warning, only shutting down fails it.
+
+	client := mocksearch.NewClient(t)
+	client.EXPECT().FacetCounts(mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("connection refused"))
+	breaker := search.NewBreaker(client, search.BreakerConfig{FailureThreshold: 1, OpenFor: time.Hour}, testutil.NewTestLogger())
+
+	app := &application{
+		config: config{events: &events.EventConfig{}},
+		search: breaker,
+		logger: testutil.NewTestLogger(),
+	}
+	app.ready.Store(true)
+	router := app.mount()
+
+	readyz := func() (int, readinessResponse) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+		var body readinessResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body
+	}
+
+	code, body := readyz()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, readinessResponse{Status: "ready", Warnings: []string{}}, body)
+
+	_, _ = breaker.FacetCounts(context.Background(), search.ListingsCollection, "categories")
+	code, body = readyz()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", body.Status)
+	require.Len(t, body.Warnings, 1)
+	assert.Contains(t, body.Warnings[0], "search circuit breaker is open")
+
+	app.ready.Store(false)
+	code, body = readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "shutting_down", body.Status)
+}
+
+func TestMount_ReportsBuild(t *testing.T) {
+	// SCENARIO: A pod built from a known commit answers requests and the readiness probe.
+	// EXPECT: Every response names the build in X-Service-Version, and /readyz has the full build info.
+
+	build := version.Info{Version: "1.4.0", Commit: "3f2c1ab9d0e4", BuildTime: "2026-01-31T12:00:00Z", GoVersion: "go1.24.0"}
+	app := &application{
+		config: config{events: &events.EventConfig{}},
+		logger: testutil.NewTestLogger(),
+		build:  build,
+	}
+	app.ready.Store(true)
+	router := app.mount()
+
+	for _, path := range []string{"/health", "/readyz", "/openapi.json"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, "1.4.0+3f2c1ab", w.Header().Get(version.Header), path)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	var body readinessResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, build, body.Build)
+}
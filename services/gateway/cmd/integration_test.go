@@ -0,0 +1,414 @@
+//go:build integration
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"gateway/internal/auth"
+	"gateway/internal/cache"
+	"gateway/internal/events"
+	"gateway/internal/loadshed"
+	"gateway/internal/outbox"
+	"gateway/internal/publicurl"
+	"gateway/internal/storage"
+	"gateway/internal/testutil"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"shared/natsconn"
+	"shared/testinfra"
+	"strings"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIssuer   = "https://auth.integration.test/realms/marketplace"
+	testClientID = "marketplace-gateway"
+	testKeyID    = "integration"
+
+	subjectValidateListing = "files.validate.listing"
+	// Captured by the INDEX stream the gateway creates itself, see natsconn.EnsureStreams
+	subjectIndexListing = "index.listing"
+)
+
+// env is shared by every test in the binary, containers are far too slow to start per test
+var env struct {
+	server     *httptest.Server
+	db         *pgxpool.Pool
+	cache      *cache.RedisClient
+	nats       *nats.Conn
+	signingKey *rsa.PrivateKey
+	relay      *backgroundTask
+}
+
+func TestMain(m *testing.M) {
+	os.Exit(runIntegration(m))
+}
+
+func runIntegration(m *testing.M) int {
+	suite, err := testinfra.NewSuite()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "integration: ", err)
+		return 1
+	}
+	defer suite.Close()
+
+	if err := startApp(suite); err != nil {
+		fmt.Fprintln(os.Stderr, "integration: ", err)
+		return 1
+	}
+	defer env.server.Close()
+	defer env.relay.Stop()
+
+	return m.Run()
+}
+
+// startApp boots the containers and mounts the real router against them
+func startApp(suite *testinfra.Suite) error {
+	dsn, err := suite.Postgres("../../../db/migrations")
+	if err != nil {
+		return err
+	}
+	redisAddr, err := suite.Redis()
+	if err != nil {
+		return err
+	}
+	objectStore, err := suite.MinIO(string(storage.BucketIncoming), string(storage.BucketPublic), string(storage.BucketProduct))
+	if err != nil {
+		return err
+	}
+	natsURL, err := suite.NATS("MARKETPLACE", subjectValidateListing)
+	if err != nil {
+		return err
+	}
+
+	logger := testutil.NewTestLogger()
+
+	env.db, err = pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		return err
+	}
+	env.cache, err = cache.NewRedisClient(cache.Config{Addr: redisAddr})
+	if err != nil {
+		return err
+	}
+	provider, err := storage.NewMinioProvider(objectStore.Endpoint, objectStore.AccessKey, objectStore.SecretKey, false)
+	if err != nil {
+		return err
+	}
+	eventBus, err := events.NewNATSBus(natsconn.Config{URL: natsURL}, logger)
+	if err != nil {
+		return err
+	}
+	env.nats, err = nats.Connect(natsURL)
+	if err != nil {
+		return err
+	}
+
+	env.signingKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	keySet := &oidc.StaticKeySet{PublicKeys: []crypto.PublicKey{&env.signingKey.PublicKey}}
+
+	app := &application{
+		conn:  env.db,
+		cache: env.cache,
+		config: config{
+			environment: "test",
+			events: &events.EventConfig{
+				StartListingValidation: subjectValidateListing,
+				IndexListingEvent:      subjectIndexListing,
+				ListingCreated:         "listings.created",
+			},
+			publicURLs:                publicurl.Config{AssetsBaseURL: objectStore.URL() + "/" + string(storage.BucketPublic)},
+			fileConstraints:           defaultFileConstraints(),
+			fileValidationWindowHours: 1,
+			sellerTermsVersion:        "1",
+			loadShed:                  loadshed.DefaultConfig(),
+		},
+		authenticator: auth.NewAuthenticatorWithKeySet(testIssuer, testClientID, keySet),
+		eventBus:      eventBus,
+		storage:       provider,
+		logger:        logger,
+	}
+	app.ready.Store(true)
+
+	env.server = httptest.NewServer(app.mount())
+	// Events only leave through the outbox, relayed far more often than in production so tests don't wait on it
+	env.relay = startBackgroundTask(outbox.NewRelay(repo.New(env.db), eventBus, outbox.Config{Interval: 20 * time.Millisecond}, logger).Run)
+	return nil
+}
+
+// --- HELPERS ---
+
+// tokenFor signs an access token the way Keycloak would for userID
+func tokenFor(t testing.TB, userID string) string {
+	t.Helper()
+
+	now := time.Now()
+	claims := auth.KeycloakClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    testIssuer,
+			Subject:   userID,
+			Audience:  jwt.ClaimStrings{testClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+		PreferredUsername: "maker-" + userID[:8],
+		Azp:               testClientID,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testKeyID
+
+	signed, err := token.SignedString(env.signingKey)
+	require.NoError(t, err)
+	return signed
+}
+
+// call sends a JSON request to the gateway and decodes the response into out when it's non-nil
+func call(t testing.TB, method, path, token string, body, out any) int {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, env.server.URL+path, reader)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	if out != nil && res.StatusCode < 300 {
+		require.NoError(t, json.Unmarshal(data, out), string(data))
+	}
+	if res.StatusCode >= 300 {
+		t.Logf("%s %s -> %d: %s", method, path, res.StatusCode, data)
+	}
+	return res.StatusCode
+}
+
+type presigned struct {
+	UploadURL string            `json:"uploadUrl"`
+	Fields    map[string]string `json:"fields"`
+	Key       string            `json:"key"`
+}
+
+// upload presigns a file for a draft then posts it straight to object storage like the browser does
+func upload(t testing.TB, token, draftID, fileType, filename, contentType string, size int) presigned {
+	t.Helper()
+
+	var p presigned
+	status := call(t, "POST", "/files/presign", token, map[string]string{
+		"type":         fileType,
+		"filename":     filename,
+		"content_type": contentType,
+		"draft_id":     draftID,
+	}, &p)
+	require.Equal(t, http.StatusCreated, status)
+
+	var form bytes.Buffer
+	w := multipart.NewWriter(&form)
+	for k, v := range p.Fields {
+		require.NoError(t, w.WriteField(k, v))
+	}
+	part, err := w.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = part.Write(bytes.Repeat([]byte{'x'}, size))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	res, err := http.Post(p.UploadURL, w.FormDataContentType(), &form)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	require.Less(t, res.StatusCode, 300, "upload rejected: %s", body)
+
+	return p
+}
+
+// --- TESTS ---
+
+func TestIntegration_CreateListingEndToEnd(t *testing.T) {
+	// SCENARIO: A new seller onboards, uploads a model and an image, and publishes a listing.
+	// EXPECT: One validation event is published carrying both files, and once the worker has marked
+	// everything valid the listing is publicly readable and served from the cache afterwards.
+
+	ctx := context.Background()
+	userID := uuid.NewString()
+	token := tokenFor(t, userID)
+
+	sub, err := env.nats.SubscribeSync(subjectValidateListing)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	// 1. Seller profile
+	status := call(t, "POST", "/me/seller-profile", token, map[string]string{
+		"display_name":           "Integration Maker",
+		"country":                "GB",
+		"accepted_terms_version": "1",
+	}, nil)
+	require.Equal(t, http.StatusOK, status)
+
+	// 2. Uploads, a listing's files all come from one draft
+	draftID := uuid.NewString()
+	model := upload(t, token, draftID, "model", "benchy.stl", "model/stl", 2048)
+	image := upload(t, token, draftID, "image", "benchy.png", "image/png", 1024)
+
+	// 3. Listing
+	var created struct {
+		ID string `json:"id"`
+	}
+	status = call(t, "POST", "/listings", token, map[string]any{
+		"title":       "Integration Benchy",
+		"description": "A boat that has been printed by every test suite at least once.",
+		"categories":  []string{"toys"},
+		"license":     "CC-BY",
+		"isFree":      true,
+		"files": []map[string]any{
+			{"type": "model", "path": model.Key, "size": 2048},
+			{"type": "image", "path": image.Key, "size": 1024},
+		},
+	}, &created)
+	require.Equal(t, http.StatusCreated, status)
+	require.NotEmpty(t, created.ID)
+
+	// 4. Events
+	var validationEvt events.StartListingValidationEvent
+	msg, err := sub.NextMsg(10 * time.Second)
+	require.NoError(t, err, "listing validation event was not published")
+	require.NoError(t, json.Unmarshal(msg.Data, &validationEvt))
+
+	require.Len(t, validationEvt.Files, 2)
+	assert.Equal(t, model.Key, validationEvt.Files[0].FileKey)
+	assert.Equal(t, "model", validationEvt.Files[0].FileType)
+	assert.Equal(t, image.Key, validationEvt.Files[1].FileKey)
+	assert.Equal(t, userID, validationEvt.UserID)
+	assert.Equal(t, strings.ReplaceAll(created.ID, "-", ""), strings.ReplaceAll(validationEvt.ListingID, "-", ""))
+
+	// 5. Stand in for the validation worker
+	_, err = env.db.Exec(ctx, `UPDATE listing_files SET status = 'VALID' WHERE listing_id = $1`, created.ID)
+	require.NoError(t, err)
+	_, err = env.db.Exec(ctx, `UPDATE listings SET status = 'ACTIVE' WHERE id = $1`, created.ID)
+	require.NoError(t, err)
+
+	// 6. Public read
+	var listing struct {
+		Title  string `json:"title"`
+		Status string `json:"status"`
+		Files  []struct {
+			Status string `json:"status"`
+		} `json:"files"`
+	}
+	status = call(t, "GET", "/listings/"+created.ID, "", nil, &listing)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "Integration Benchy", listing.Title)
+	assert.Equal(t, "ACTIVE", listing.Status)
+	require.Len(t, listing.Files, 2)
+	for _, f := range listing.Files {
+		assert.Equal(t, "VALID", f.Status)
+	}
+
+	// 7. The cache write is async, wait for it to land
+	require.Eventually(t, func() bool {
+		_, found, err := cache.Get[map[string]any](env.cache, ctx, "listing:"+created.ID)
+		return err == nil && found
+	}, 5*time.Second, 50*time.Millisecond, "listing was never cached")
+
+	// 8. A change behind the cache's back isn't visible, proving the read came from Redis
+	_, err = env.db.Exec(ctx, `UPDATE listings SET title = 'Renamed behind the cache' WHERE id = $1`, created.ID)
+	require.NoError(t, err)
+	status = call(t, "GET", "/listings/"+created.ID, "", nil, &listing)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "Integration Benchy", listing.Title)
+}
+
+func BenchmarkIntegration_CreateListing(b *testing.B) {
+	// SCENARIO: A seller publishes listings with as many files as a listing can carry.
+	// EXPECT: Nothing beyond success, this times POST /listings with the uploads done up front so only the
+	// transaction with its file and outbox inserts is measured.
+	//
+	//	go test -tags integration -run '^$' -bench CreateListing -benchtime 50x ./cmd/
+
+	const filesPerListing = 25
+
+	userID := uuid.NewString()
+	token := tokenFor(b, userID)
+	status := call(b, "POST", "/me/seller-profile", token, map[string]string{
+		"display_name":           "Benchmark Maker",
+		"country":                "GB",
+		"accepted_terms_version": "1",
+	}, nil)
+	require.Equal(b, http.StatusOK, status)
+
+	// A file can only be used by one listing, so every iteration needs its own draft
+	requests := make([]map[string]any, b.N)
+	for i := range requests {
+		draftID := uuid.NewString()
+		files := make([]map[string]any, 0, filesPerListing)
+		for j := range filesPerListing {
+			p := upload(b, token, draftID, "model", fmt.Sprintf("part-%02d.stl", j), "model/stl", 512)
+			files = append(files, map[string]any{"type": "model", "path": p.Key, "size": 512})
+		}
+		requests[i] = map[string]any{
+			"title":       fmt.Sprintf("Benchmark kit %d", i),
+			"description": "A kit of parts uploaded only to be timed.",
+			"categories":  []string{"toys"},
+			"license":     "CC-BY",
+			"isFree":      true,
+			"files":       files,
+		}
+	}
+
+	b.ResetTimer()
+	for i := range b.N {
+		status := call(b, "POST", "/listings", token, requests[i], nil)
+		require.Equal(b, http.StatusCreated, status)
+	}
+}
+
+func TestIntegration_RejectsUnsignedTokens(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.RegisteredClaims{
+		Issuer:    testIssuer,
+		Subject:   uuid.NewString(),
+		Audience:  jwt.ClaimStrings{testClientID},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+	signed, err := token.SignedString(otherKey)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusUnauthorized, call(t, "GET", "/me/seller-profile", signed, nil, nil))
+}
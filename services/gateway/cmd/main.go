@@ -2,46 +2,256 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"gateway/internal/auth"
 	"gateway/internal/cache"
+	"gateway/internal/currency"
+	"gateway/internal/database/postgresql"
 	"gateway/internal/events"
 	"gateway/internal/handlers/files"
+	"gateway/internal/handlers/listingevents"
+	"gateway/internal/handlers/listings"
+	"gateway/internal/handlers/shortlinks"
+	"gateway/internal/loadshed"
+	"gateway/internal/logging"
+	"gateway/internal/outbox"
+	"gateway/internal/publicurl"
+	"gateway/internal/realip"
+	"gateway/internal/scrapeguard"
+	"gateway/internal/search"
 	"gateway/internal/storage"
-	"gateway/internal/telemetry"
+	"shared/natsconn"
+	"shared/poolmetrics"
+	"shared/preflight"
+	"shared/version"
 	"strconv"
+	"strings"
+	"time"
 
 	"log/slog"
 	"os"
 
+	repo "gateway/internal/database/postgresql/sqlc"
+
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 func main() {
-	// Use JSON traced logging
-	baseHandler := slog.NewJSONHandler(os.Stdout, nil)
-	logger := slog.New(telemetry.NewTraceHandler(baseHandler))
+	// LOG_LEVEL and LOG_FORMAT, SIGHUP or PUT /admin/log-level turns on debug logging without a restart
+	logger, logLevel := logging.New(os.Getenv, os.Stdout)
 	slog.SetDefault(logger)
+	logging.ToggleDebugOnSIGHUP(context.Background(), logLevel, logger)
+
+	// Subcommands: "seed" fills a development environment with sellers and listings and exits
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		if err := runSeed(logger, os.Args[2:]); err != nil {
+			slog.Error("Seed terminated with error", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// For emergencies only, e.g. a check is wrong and blocking a fix from going out
+	skipPreflight := flag.Bool("skip-preflight", false, "Start without checking search, storage, events and the database schema")
+	flag.Parse()
+
+	build := version.Get()
+	slog.Info("Starting gateway", "build", build)
+	prometheus.MustRegister(version.NewCollector("gateway", build))
 
 	eventsConfig := events.NewEventConfig()
 
 	config := config{
-		events:         eventsConfig,
-		frontend:       os.Getenv("DOMAIN_NAME"),
-		addr:           ":" + os.Getenv("API_PORT"),
-		publicFilesUrl: os.Getenv("PUBLIC_FILES_URL"),
-		fileConstraints: map[string]files.FileConstraint{
-			"image": {
-				MaxSize:          5 * 1024 * 1024, // 5MB
-				AllowedMimeTypes: []string{"image/jpeg", "image/png", "image/gif"},
-				Prefix:           "images/",
-			},
-			"model": {
-				MaxSize:          50 * 1024 * 1024, // 50MB
-				AllowedMimeTypes: []string{"application/vnd.ms-pki.stl", "application/octet-stream", "application/vnd.ms-pki.3mf", "model/stl"},
-				Prefix:           "models/",
-			},
-		},
+		environment:               os.Getenv("APP_ENV"),
+		events:                    eventsConfig,
+		frontend:                  os.Getenv("DOMAIN_NAME"),
+		addr:                      ":" + os.Getenv("API_PORT"),
+		publicURLs:                publicurl.FromEnv(),
+		fileConstraints:           defaultFileConstraints(),
+		downloads:                 listings.DefaultDownloadConfig(),
 		fileValidationWindowHours: 1,
+		sellerTermsVersion:        os.Getenv("SELLER_TERMS_VERSION"),
+		listingLimits:             listings.DefaultCreationLimits(),
+		imageBounds:               listings.DefaultImageBounds(),
+		shutdownTimeout:           15 * time.Second,
+		queryTimeout:              postgresql.DefaultQueryTimeout,
+		statementTimeout:          postgresql.DefaultStatementTimeout,
+		readinessDrainDelay:       5 * time.Second,
+		loadShed:                  loadshed.DefaultConfig(),
+		outbox:                    outbox.DefaultConfig(),
+		scrapeGuard:               scrapeguard.DefaultConfig(),
+		searchBreaker:             search.DefaultBreakerConfig(),
+		listingEvents:             listingevents.DefaultConfig(),
+		currencyRates:             currency.DefaultConfig(),
+		shortLinks: shortlinks.Config{
+			BaseURL:        os.Getenv("SHORT_LINK_BASE_URL"),
+			ListingBaseURL: os.Getenv("DOMAIN_NAME"),
+		},
+		previews: listings.PreviewConfig{
+			WebBaseURL:       os.Getenv("DOMAIN_NAME"),
+			PlaceholderImage: os.Getenv("OG_PLACEHOLDER_IMAGE_URL"),
+		},
+	}
+	if config.previews.PlaceholderImage == "" {
+		config.previews.PlaceholderImage = config.publicURLs.Image("static/og-placeholder.png")
+	}
+
+	if n, err := strconv.ParseInt(os.Getenv("LOADSHED_MAX_IN_FLIGHT"), 10, 64); err == nil {
+		config.loadShed.MaxInFlight = n
+	}
+	if n, err := strconv.ParseInt(os.Getenv("LOADSHED_ACQUIRE_WAIT_THRESHOLD"), 10, 64); err == nil {
+		config.loadShed.AcquireWaitThreshold = n
+	}
+
+	// SCRAPE_BURST_LIMIT=0 turns the guard off, SCRAPE_SUSTAINED_PER_HOUR=0 never asks for an API key
+	for env, limit := range map[string]*int64{
+		"SCRAPE_BURST_LIMIT":         &config.scrapeGuard.BurstLimit,
+		"SCRAPE_API_KEY_BURST_LIMIT": &config.scrapeGuard.APIKeyBurstLimit,
+		"SCRAPE_STRIKES_TO_BLOCK":    &config.scrapeGuard.StrikesToBlock,
+		"SCRAPE_SUSTAINED_PER_HOUR":  &config.scrapeGuard.SustainedPerHour,
+	} {
+		if n, err := strconv.ParseInt(os.Getenv(env), 10, 64); err == nil {
+			*limit = n
+		}
+	}
+	for env, d := range map[string]*time.Duration{
+		"SCRAPE_WINDOW":     &config.scrapeGuard.Window,
+		"SCRAPE_STRIKE_TTL": &config.scrapeGuard.StrikeTTL,
+		"SCRAPE_BLOCK_FOR":  &config.scrapeGuard.BlockFor,
+	} {
+		if v, err := time.ParseDuration(os.Getenv(env)); err == nil {
+			*d = v
+		}
+	}
+	if keys := os.Getenv("SCRAPE_API_KEYS"); keys != "" {
+		config.scrapeGuard.APIKeys = strings.Split(keys, ",")
+	}
+	// Unset, X-Forwarded-For is never believed and every request is counted against the address it came from
+	if proxies, err := realip.ParsePrefixes(os.Getenv("TRUSTED_PROXIES")); err == nil {
+		config.trustedProxies = proxies
+	} else {
+		slog.Warn("Ignoring TRUSTED_PROXIES", "error", err)
+	}
+	if allowlist, err := scrapeguard.ParseAllowlist(os.Getenv("SCRAPE_ALLOWLIST")); err == nil {
+		config.scrapeGuard.Allowlist = allowlist
+	} else {
+		slog.Warn("Ignoring SCRAPE_ALLOWLIST", "error", err)
+	}
+
+	if n, err := strconv.Atoi(os.Getenv("SEARCH_BREAKER_FAILURES")); err == nil {
+		config.searchBreaker.FailureThreshold = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("SEARCH_BREAKER_OPEN_FOR")); err == nil {
+		config.searchBreaker.OpenFor = d
+	}
+
+	// JSON of API key to callback domains, e.g. {"<key>": ["hooks.slicer.example"]}. Unset, no upload can ask for one.
+	if allowlist, err := files.ParseCallbackAllowlist(os.Getenv("UPLOAD_CALLBACK_ALLOWLIST")); err == nil {
+		config.uploadCallbacks.Allowlist = allowlist
+	} else {
+		slog.Warn("Ignoring UPLOAD_CALLBACK_ALLOWLIST", "error", err)
+	}
+	// The listings worker's signing secrets, only their domains are kept. A domain without one can't be called back.
+	if signed, err := files.ParseCallbackSecrets(os.Getenv("UPLOAD_CALLBACK_SECRETS")); err == nil {
+		config.uploadCallbacks.Signed = signed
+	} else {
+		slog.Warn("Ignoring UPLOAD_CALLBACK_SECRETS", "error", err)
+	}
+
+	// 0 turns a limit off
+	if n, err := strconv.ParseInt(os.Getenv("LISTING_LIMIT_PER_HOUR"), 10, 64); err == nil {
+		config.listingLimits.PerHour = n
+	}
+	if n, err := strconv.ParseInt(os.Getenv("LISTING_LIMIT_PER_DAY_UNVERIFIED"), 10, 64); err == nil {
+		config.listingLimits.PerDayUnverified = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("LISTING_LIMIT_DUPLICATE_WINDOW")); err == nil {
+		config.listingLimits.DuplicateWindow = d
+	}
+	if n, err := strconv.ParseInt(os.Getenv("LISTING_LIMIT_DUPLICATE_THRESHOLD"), 10, 64); err == nil {
+		config.listingLimits.DuplicateThreshold = n
+	}
+
+	// 0 turns a bound off
+	for env, bound := range map[string]*int{
+		"IMAGE_MIN_WIDTH":  &config.imageBounds.MinWidth,
+		"IMAGE_MIN_HEIGHT": &config.imageBounds.MinHeight,
+		"IMAGE_MAX_WIDTH":  &config.imageBounds.MaxWidth,
+		"IMAGE_MAX_HEIGHT": &config.imageBounds.MaxHeight,
+	} {
+		if n, err := strconv.Atoi(os.Getenv(env)); err == nil {
+			*bound = n
+		}
+	}
+	if r, err := strconv.ParseFloat(os.Getenv("IMAGE_MAX_ASPECT_RATIO"), 64); err == nil {
+		config.imageBounds.MaxAspectRatio = r
+	}
+
+	// JSON, see listings.LoadValidationRules. Bad rules would reject every listing or none, so they stop startup.
+	rules, err := listings.LoadValidationRules([]byte(os.Getenv("LISTING_VALIDATION_RULES")))
+	if err != nil {
+		slog.Error("Invalid LISTING_VALIDATION_RULES", "error", err)
+		os.Exit(1)
+	}
+	config.validationRules = rules
+
+	if d, err := time.ParseDuration(os.Getenv("SHUTDOWN_READINESS_DELAY")); err == nil {
+		config.readinessDrainDelay = d
+	}
+
+	// e.g. DB_QUERY_TIMEOUT=5s for each query, DB_STATEMENT_TIMEOUT=30s enforced by the database on every connection
+	if d, err := time.ParseDuration(os.Getenv("DB_QUERY_TIMEOUT")); err == nil {
+		config.queryTimeout = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("DB_STATEMENT_TIMEOUT")); err == nil {
+		config.statementTimeout = d
+	}
+
+	// e.g. DOWNLOAD_TTL_MODEL=30m, DOWNLOAD_MAX_TTL_MODEL=4h, DOWNLOAD_TTL_IMAGE=0 for a public bucket
+	for fileType, policy := range config.downloads {
+		if d, err := time.ParseDuration(os.Getenv("DOWNLOAD_TTL_" + fileType)); err == nil {
+			policy.TTL = d
+		}
+		if d, err := time.ParseDuration(os.Getenv("DOWNLOAD_MAX_TTL_" + fileType)); err == nil {
+			policy.MaxTTL = d
+		}
+		config.downloads[fileType] = policy
+	}
+
+	// e.g. OUTBOX_RELAY_INTERVAL=250ms for events to go out sooner, OUTBOX_BATCH_SIZE=500 to catch up after an outage
+	if d, err := time.ParseDuration(os.Getenv("OUTBOX_RELAY_INTERVAL")); err == nil {
+		config.outbox.Interval = d
+	}
+	if n, err := strconv.Atoi(os.Getenv("OUTBOX_BATCH_SIZE")); err == nil {
+		config.outbox.BatchSize = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("OUTBOX_RETENTION")); err == nil {
+		config.outbox.Retention = d
+	}
+	// e.g. OUTBOX_MAX_ATTEMPTS=30 to keep trying through a longer NATS outage
+	if n, err := strconv.Atoi(os.Getenv("OUTBOX_MAX_ATTEMPTS")); err == nil {
+		config.outbox.MaxAttempts = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("OUTBOX_STALE_AFTER")); err == nil {
+		config.outbox.StaleAfter = d
+	}
+
+	// e.g. CURRENCY_RATES_URL=static on a laptop without internet access, CURRENCY_RATES_MAX_AGE=144h
+	if url := os.Getenv("CURRENCY_RATES_URL"); url != "" {
+		config.currencyRates.URL = url
+	}
+	if d, err := time.ParseDuration(os.Getenv("CURRENCY_RATES_REFRESH_EVERY")); err == nil {
+		config.currencyRates.RefreshEvery = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("CURRENCY_RATES_MAX_AGE")); err == nil {
+		config.currencyRates.MaxAge = d
+	}
+
+	if config.sellerTermsVersion == "" {
+		config.sellerTermsVersion = "1"
+		slog.Warn("SELLER_TERMS_VERSION not set, using default", "version", config.sellerTermsVersion)
 	}
 
 	poolSize, _ := strconv.Atoi(os.Getenv("REDIS_POOL_SIZE"))
@@ -63,13 +273,21 @@ func main() {
 
 	dsn := os.Getenv("DB_DSN")
 	slog.Info("Connecting to database", "addr", dsn)
-	conn, err := pgxpool.New(context.Background(), dsn)
+	poolConfig, err := postgresql.PoolConfig(dsn, config.statementTimeout)
+	if err != nil {
+		slog.Error("Invalid DB_DSN", "error", err)
+		os.Exit(1)
+	}
+	conn, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
 		slog.Error("Failed to connect to database", "error", err)
 		os.Exit(1)
 	}
 	defer conn.Close()
 
+	// Read on every scrape of /metrics, pool exhaustion is the incident we'd otherwise be blind to
+	prometheus.MustRegister(poolmetrics.NewPGXCollector("gateway", conn), poolmetrics.NewRedisCollector("gateway", rdb))
+
 	slog.Info("Connecting to object storage", "endpoint", os.Getenv("S3_ENDPOINT"))
 
 	storage, err := storage.NewMinioProvider(
@@ -83,8 +301,12 @@ func main() {
 		os.Exit(1)
 	}
 
-	slog.Info("Connecting to event bus", "endpoint", os.Getenv("NATS_ENDPOINT"))
-	eventBus, err := events.NewNATSBus(os.Getenv("NATS_ENDPOINT"), logger)
+	// Search-only key, the gateway never writes to the index
+	searchClient := search.NewTypesenseClient(os.Getenv("GATEWAY_TYPESENSE_SEARCH_KEY"), os.Getenv("TYPESENSE_URL"), logger)
+
+	natsConfig := natsconn.ConfigFromEnv()
+	slog.Info("Connecting to event bus", "endpoint", natsConfig.URL, "auth", natsConfig.AuthMode())
+	eventBus, err := events.NewNATSBus(natsConfig, logger)
 
 	if err != nil {
 		slog.Error("Failed to initialize event bus", "error", err)
@@ -92,27 +314,51 @@ func main() {
 	}
 
 	authorizationConfig := authorizationConfig{
+		mode:     os.Getenv("AUTH_MODE"),
 		url:      os.Getenv("AUTHORIZATION_URL"),
 		realm:    os.Getenv("AUTHORIZATION_REALM"),
 		clientID: os.Getenv("AUTHORIZATION_CLIENT_ID"),
 		secret:   os.Getenv("AUTHORIZATION_CLIENT_SECRET"),
-	}
-	slog.Info("Connecting to authorization service", "url", authorizationConfig.url)
+		keycloak: auth.DefaultConfig(),
 
-	authenticator, err := auth.NewAuthenticator(context.Background(), authorizationConfig.url, authorizationConfig.clientID)
+		serviceClients: strings.Fields(strings.ReplaceAll(os.Getenv("AUTHORIZATION_SERVICE_CLIENTS"), ",", " ")),
+	}
+	if d, err := time.ParseDuration(os.Getenv("AUTHORIZATION_FETCH_TIMEOUT")); err == nil {
+		authorizationConfig.keycloak.FetchTimeout = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("AUTHORIZATION_DISCOVERY_TIMEOUT")); err == nil {
+		authorizationConfig.keycloak.DiscoveryTimeout = d
+	}
+	authenticator, err := newAuthenticator(authorizationConfig, config.environment, logger)
 	if err != nil {
 		// Handle error appropriately, e.g., log and return
 		slog.Error("Failed to initialize authenticator", "error", err)
 		os.Exit(1)
 	}
 
+	if *skipPreflight {
+		slog.Warn("Skipping preflight checks, misconfiguration will only show up in requests")
+	} else if err := preflight.Run(context.Background(), preflightTimeout, preflightChecks(conn, storage, searchClient, eventBus, eventsConfig)); err != nil {
+		slog.Error("Preflight failed, refusing to start", "error", err)
+		os.Exit(1)
+	}
+
+	// Every pod runs it, only one fetches each day. Prices just go without a conversion until the first fetch lands.
+	slog.Info("Refreshing exchange rates", "url", config.currencyRates.URL, "every", config.currencyRates.RefreshEvery)
+	go currency.NewRefresher(config.currencyRates.Provider(), currency.NewStore(rdb), config.currencyRates.RefreshEvery, logger).Run(context.Background())
+
 	app := &application{
 		conn:          conn,
 		config:        config,
 		authenticator: authenticator,
 		eventBus:      eventBus,
+		outbox:        outbox.NewRelay(repo.New(conn), eventBus, config.outbox, logger),
+		listingEvents: listingevents.NewHub(config.listingEvents.MaxStreams),
 		storage:       storage,
+		search:        search.NewBreaker(searchClient, config.searchBreaker, logger),
 		logger:        logger,
+		logLevel:      logLevel,
+		build:         build,
 		cache:         rdb,
 	}
 
@@ -121,3 +367,48 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// defaultFileConstraints are the upload rules per file type, shared with the integration tests
+func defaultFileConstraints() map[string]files.FileConstraint {
+	return map[string]files.FileConstraint{
+		"image": {
+			MaxSize:          5 * 1024 * 1024, // 5MB
+			AllowedMimeTypes: []string{"image/jpeg", "image/png", "image/gif"},
+			Prefix:           "images/",
+		},
+		"model": {
+			MaxSize:          50 * 1024 * 1024, // 50MB
+			AllowedMimeTypes: []string{"application/vnd.ms-pki.stl", "application/octet-stream", "application/vnd.ms-pki.3mf", "model/stl"},
+			Prefix:           "models/",
+		},
+	}
+}
+
+// newAuthenticator verifies tokens against Keycloak, or with AUTH_MODE=static accepts dev: tokens so the gateway runs
+// without it. Static mode only starts in development and test, see auth.StaticEnvironments, so environment must be
+// APP_ENV as set.
+func newAuthenticator(config authorizationConfig, environment string, logger *slog.Logger) (*auth.Authenticator, error) {
+	var authenticator *auth.Authenticator
+	var err error
+	switch config.mode {
+	case "":
+		slog.Info("Connecting to authorization service", "url", config.url)
+		authenticator, err = auth.NewAuthenticator(context.Background(), config.url, config.clientID, config.keycloak, logger)
+	case auth.ModeStatic:
+		authenticator, err = auth.NewStaticAuthenticator(environment)
+		if err == nil {
+			slog.Warn("AUTH_MODE=static, accepting dev:<user uuid>:<roles> tokens without verifying them", "environment", environment)
+		}
+	default:
+		return nil, fmt.Errorf("unknown AUTH_MODE %q, leave it empty for Keycloak or set %q", config.mode, auth.ModeStatic)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(config.serviceClients) > 0 {
+		authenticator.TrustServiceClients(config.serviceClients...)
+		slog.Info("Trusting service account clients", "clients", config.serviceClients)
+	}
+	return authenticator, nil
+}
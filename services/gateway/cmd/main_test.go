@@ -0,0 +1,39 @@
+package main
+
+import (
+	"gateway/internal/auth"
+	"gateway/internal/testutil"
+	"gateway/internal/testutil/apitest"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAuthenticator_StaticNeedsEnvironment(t *testing.T) {
+	// SCENARIO: AUTH_MODE=static with APP_ENV unset or set to an environment other people use.
+	// EXPECT: No authenticator, so the gateway doesn't start letting anyone sign in as anyone.
+
+	for _, environment := range []string{"", "production", "staging"} {
+		a, err := newAuthenticator(authorizationConfig{mode: auth.ModeStatic}, environment, testutil.NewTestLogger())
+
+		assert.ErrorIs(t, err, auth.ErrStaticEnvironment, environment)
+		assert.Nil(t, a, environment)
+	}
+}
+
+func TestNewAuthenticator_StaticWithServiceClients(t *testing.T) {
+	// SCENARIO: AUTH_MODE=static locally with AUTHORIZATION_SERVICE_CLIENTS set, as .env.template does.
+	// EXPECT: The gateway starts and takes dev: tokens, there's no Keycloak to verify service tokens against.
+
+	a, err := newAuthenticator(authorizationConfig{
+		mode:           auth.ModeStatic,
+		serviceClients: []string{"listings-worker"},
+	}, "development", testutil.NewTestLogger())
+	require.NoError(t, err)
+
+	router := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w := apitest.Do(t, router, apitest.Request{Method: "GET", Path: "/", Token: "dev:a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
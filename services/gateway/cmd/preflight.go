@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/database/postgresql"
+	"gateway/internal/events"
+	"gateway/internal/search"
+	"gateway/internal/storage"
+	"shared/preflight"
+	"strings"
+	"time"
+)
+
+// preflightTimeout bounds each check, the slowest is a put and delete against storage
+const preflightTimeout = 10 * time.Second
+
+// preflightChecks is everything the gateway needs from its environment before it takes traffic
+func preflightChecks(db preflight.QueryRower, store storage.Provider, searchClient collectionProber, bus preflight.StreamFinder, cfg *events.EventConfig) []preflight.Check {
+	checks := []preflight.Check{
+		preflight.SchemaVersion(db, postgresql.SchemaVersion),
+		// The fields the categories facet and the web UI's search query by
+		searchCollectionCheck(searchClient, search.ListingsCollection, "title", "categories"),
+	}
+
+	if prober, ok := store.(bucketProber); ok {
+		checks = append(checks, bucketChecks(prober, storage.BucketIncoming, storage.BucketPublic, storage.BucketProduct)...)
+	}
+
+	subjects := map[string]string{
+		"EVENT_INDEX_LISTING":   cfg.IndexListingEvent,
+		"EVENT_LISTING_CREATED": cfg.ListingCreated,
+	}
+	// Files go out on one subject or the other, never both
+	if cfg.LegacyFileValidation {
+		subjects["EVENT_VALIDATE_IMAGE_START"] = cfg.StartImageValidation
+		subjects["EVENT_VALIDATE_MODEL_START"] = cfg.StartModelValidation
+	} else {
+		subjects["EVENT_VALIDATE_LISTING_START"] = cfg.StartListingValidation
+	}
+	// Optional, deletes also go out as index events
+	if cfg.DeleteListingEvent != "" {
+		subjects["EVENT_DELETE_LISTING"] = cfg.DeleteListingEvent
+	}
+	if cfg.ListingIndexFailed != "" {
+		subjects["EVENT_LISTING_INDEX_FAILED"] = cfg.ListingIndexFailed
+	}
+	// Only listened to, but the validation worker publishes them through JetStream, which fails without a stream
+	if cfg.FileValidated != "" {
+		subjects["EVENT_FILE_VALIDATED"] = cfg.FileValidated
+	}
+	if cfg.ListingPublished != "" {
+		subjects["EVENT_LISTING_PUBLISHED"] = cfg.ListingPublished
+	}
+	checks = append(checks, preflight.Subjects(bus, "fix the variable, the gateway only creates the INDEX, LISTINGS and DLQ streams itself", subjects))
+
+	return checks
+}
+
+// collectionProber is the search client, see search.TypesenseClient
+type collectionProber interface {
+	// ProbeCollection fails unless collection exists and every field can be queried
+	ProbeCollection(ctx context.Context, collection string, fields []string) error
+}
+
+// searchCollectionCheck checks the collection, or the alias in front of it, has the fields the gateway queries
+func searchCollectionCheck(search collectionProber, collection string, fields ...string) preflight.Check {
+	return preflight.Check{
+		Name: "search collection " + collection,
+		Run: func(ctx context.Context) error {
+			if err := search.ProbeCollection(ctx, collection, fields); err != nil {
+				return fmt.Errorf("%q is missing or lacks one of %s (%w), create it with infrastructure/typesense-migrations and check TYPESENSE_URL and GATEWAY_TYPESENSE_SEARCH_KEY",
+					collection, strings.Join(fields, ", "), err)
+			}
+			return nil
+		},
+	}
+}
+
+// bucketProber is the storage provider, see storage.MinioProvider
+type bucketProber interface {
+	// ProbeBucket fails unless bucket exists and an object can be written to and removed from it
+	ProbeBucket(ctx context.Context, bucket storage.Bucket) error
+}
+
+// bucketChecks checks each bucket is there and writable, one check per bucket
+func bucketChecks(store bucketProber, buckets ...storage.Bucket) []preflight.Check {
+	checks := make([]preflight.Check, 0, len(buckets))
+	for _, bucket := range buckets {
+		checks = append(checks, preflight.Check{
+			Name: "bucket " + string(bucket),
+			Run: func(ctx context.Context) error {
+				if err := store.ProbeBucket(ctx, bucket); err != nil {
+					return fmt.Errorf("%q is missing or not writable (%w), create it and check S3_ENDPOINT and the gateway's S3 credentials (GATEWAY_S3_*) can put and delete in it",
+						bucket, err)
+				}
+				return nil
+			},
+		})
+	}
+	return checks
+}
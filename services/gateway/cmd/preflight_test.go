@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"gateway/internal/storage"
+	"shared/preflight"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSearch struct{ err error }
+
+func (f fakeSearch) ProbeCollection(ctx context.Context, collection string, fields []string) error {
+	return f.err
+}
+
+type fakeStorage struct{ missing map[storage.Bucket]bool }
+
+func (f fakeStorage) ProbeBucket(ctx context.Context, bucket storage.Bucket) error {
+	if f.missing[bucket] {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func TestPreflightServiceChecks(t *testing.T) {
+	// SCENARIO: The search collection is missing and one of the buckets isn't there.
+	// EXPECT: Both are reported with what to fix, the healthy bucket isn't.
+
+	checks := append([]preflight.Check{
+		searchCollectionCheck(fakeSearch{err: errors.New("404 Not Found")}, "listings", "title"),
+	}, bucketChecks(fakeStorage{missing: map[storage.Bucket]bool{storage.BucketPublic: true}}, storage.BucketIncoming, storage.BucketPublic)...)
+
+	err := preflight.Run(context.Background(), time.Second, checks)
+
+	var perr *preflight.Error
+	require.ErrorAs(t, err, &perr)
+	require.Len(t, perr.Failures, 2)
+	assert.Equal(t, "search collection listings", perr.Failures[0].Check)
+	assert.Equal(t, "bucket "+string(storage.BucketPublic), perr.Failures[1].Check)
+	assert.Contains(t, err.Error(), "infrastructure/typesense-migrations")
+	assert.Contains(t, err.Error(), "create it and check S3_ENDPOINT")
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"gateway/internal/errors"
+	"gateway/internal/json"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// namedHandler is what a middleware registered with named hands back, it carries the name for GET /admin/routes
+type namedHandler struct {
+	http.Handler
+	name string
+}
+
+// named gives mw the name GET /admin/routes lists it under. chi only keeps the middleware functions, and those can't
+// be compared or told apart by their arguments, auth.RequireRole("admin") and auth.RequireRole("service") are the
+// same function.
+func named(name string, mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return namedHandler{Handler: mw(next), name: name}
+	}
+}
+
+// middlewareName wraps a placeholder handler in mw to read its name back, middleware registered without named are
+// listed by their function name
+func middlewareName(mw func(http.Handler) http.Handler) string {
+	if h, ok := mw(http.NotFoundHandler()).(namedHandler); ok {
+		return h.name
+	}
+	return runtime.FuncForPC(reflect.ValueOf(mw).Pointer()).Name()
+}
+
+// RouteInfo is one mounted route and the middleware in front of it, outermost first
+type RouteInfo struct {
+	Method     string   `json:"method"`
+	Pattern    string   `json:"pattern"`
+	Middleware []string `json:"middleware"`
+}
+
+// RoutesResponse is the body of GET /admin/routes
+type RoutesResponse struct {
+	Routes []RouteInfo `json:"routes"`
+}
+
+// listRoutes walks router, which is only complete once mount has returned, so the walk happens per request
+func listRoutes(router chi.Routes) ([]RouteInfo, error) {
+	routes := []RouteInfo{}
+	err := chi.Walk(router, func(method, pattern string, _ http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		names := make([]string, len(middlewares))
+		for i, mw := range middlewares {
+			names[i] = middlewareName(mw)
+		}
+		routes = append(routes, RouteInfo{Method: method, Pattern: pattern, Middleware: names})
+		return nil
+	})
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes, err
+}
+
+// routesHandler serves GET /admin/routes, for checking which middleware protects which path
+func routesHandler(router chi.Routes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		routes, err := listRoutes(router)
+		if err != nil {
+			errors.RespondError(w, r, errors.New(errors.ErrInternal, "Failed to list routes", err))
+			return
+		}
+		json.Write(w, http.StatusOK, RoutesResponse{Routes: routes})
+	}
+}
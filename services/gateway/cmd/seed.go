@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"gateway/internal/database/postgresql"
+	"gateway/internal/events"
+	"gateway/internal/seed"
+	"gateway/internal/storage"
+	"log/slog"
+	"os"
+	"os/signal"
+	"shared/natsconn"
+	"syscall"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// runSeed tops a development environment up to a number of sellers and listings with files and search documents,
+// e.g. `gateway seed --sellers 8 --listings 40`. --wipe deletes what an earlier run seeded first. Only runs with
+// APP_ENV=development or test.
+func runSeed(logger *slog.Logger, args []string) error {
+	if err := seed.CheckEnvironment(os.Getenv("APP_ENV")); err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	sellers := fs.Int("sellers", 8, "Sellers to top up to")
+	listings := fs.Int("listings", 40, "Listings to top up to, shared between the sellers")
+	wipe := fs.Bool("wipe", false, "Delete every seeded listing, its files and the seeded sellers before seeding")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	poolConfig, err := postgresql.PoolConfig(os.Getenv("DB_DSN"), postgresql.DefaultStatementTimeout)
+	if err != nil {
+		return fmt.Errorf("invalid DB_DSN: %w", err)
+	}
+	conn, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer conn.Close()
+
+	provider, err := storage.NewMinioProvider(
+		os.Getenv("S3_ENDPOINT"),
+		os.Getenv("GATEWAY_S3_ACCESS_KEY_ID"),
+		os.Getenv("GATEWAY_S3_SECRET_ACCESS_KEY"),
+		os.Getenv("S3_USE_SSL") == "true",
+	)
+	if err != nil {
+		return fmt.Errorf("failed to initialize MinIO provider: %w", err)
+	}
+	files, ok := provider.(seed.Uploader)
+	if !ok {
+		return fmt.Errorf("storage provider %T can't upload seed files", provider)
+	}
+
+	bus, err := events.NewNATSBus(natsconn.ConfigFromEnv(), logger)
+	if err != nil {
+		return fmt.Errorf("failed to connect to event bus: %w", err)
+	}
+	defer bus.Close()
+
+	seeder := seed.New(conn, repo.New(conn), files, events.NewEventHandler(bus, events.NewEventConfig(), logger), logger)
+	report, err := seeder.Run(ctx, seed.Options{Sellers: *sellers, Listings: *listings, Wipe: *wipe})
+	if err != nil {
+		return err
+	}
+	logger.Info("Seed finished", "wiped", report.Wiped, "existing", report.Existing, "created", report.Created, "indexed", report.Indexed)
+	return nil
+}
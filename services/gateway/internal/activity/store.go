@@ -0,0 +1,31 @@
+package activity
+
+import (
+	"context"
+	"gateway/internal/cache"
+	"time"
+)
+
+// Store keeps one claim per seller in Redis, so the gateway pods between them write last_active_at once per interval
+type Store interface {
+	// Claim is true for the first call for userID in every, the caller then writes the timestamp
+	Claim(ctx context.Context, userID string, every time.Duration) (bool, error)
+	// Release gives a claim back after the write failed, so the seller's next request tries again
+	Release(ctx context.Context, userID string) error
+}
+
+type RedisStore struct {
+	cache *cache.RedisClient
+}
+
+func NewStore(c *cache.RedisClient) *RedisStore {
+	return &RedisStore{cache: c}
+}
+
+func (s *RedisStore) Claim(ctx context.Context, userID string, every time.Duration) (bool, error) {
+	return cache.SetNX(s.cache, ctx, "seller-activity:"+userID, "1", every)
+}
+
+func (s *RedisStore) Release(ctx context.Context, userID string) error {
+	return cache.Del(s.cache, ctx, "seller-activity:"+userID)
+}
@@ -0,0 +1,95 @@
+// Package activity keeps sellers.last_active_at up to date, the listings worker ranks the listings of sellers who
+// haven't been seen in a while below those of sellers who have.
+package activity
+
+import (
+	"context"
+	"gateway/internal/auth"
+	"gateway/internal/detach"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// DefaultInterval is how often a seller's last_active_at is written at most. The search documents only know
+	// whether a seller was active in the last 7 or 30 days, anything finer is wasted writes.
+	DefaultInterval = time.Hour
+
+	// Bounds the write once the request that triggered it has finished
+	touchTimeout = 5 * time.Second
+)
+
+// Toucher writes the timestamp, repo.Queries' TouchSellerLastActive
+type Toucher interface {
+	TouchSellerLastActive(ctx context.Context, userID pgtype.UUID) error
+}
+
+// Tracker notes every signed in request. It never holds a request up: the claim is one Redis round trip, the write
+// happens after the response, and failures only cost the timestamp.
+type Tracker struct {
+	store      Store
+	repo       Toucher
+	every      time.Duration
+	background *sync.WaitGroup // Shutdown waits on writes still running
+	logger     *slog.Logger
+}
+
+func NewTracker(store Store, repo Toucher, every time.Duration, background *sync.WaitGroup, logger *slog.Logger) *Tracker {
+	if every <= 0 {
+		every = DefaultInterval
+	}
+	return &Tracker{
+		store:      store,
+		repo:       repo,
+		every:      every,
+		background: background,
+		logger:     logger,
+	}
+}
+
+// Middleware goes after the authenticator, requests without a user are passed straight through
+func (t *Tracker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.touch(r.Context())
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (t *Tracker) touch(ctx context.Context) {
+	userInfo, err := auth.GetUserInfo(ctx)
+	// Our own workers act on a seller's behalf, that isn't the seller being around
+	if err != nil || userInfo.HasRole(auth.RoleService) {
+		return
+	}
+	var userID pgtype.UUID
+	if err := userID.Scan(userInfo.ID); err != nil {
+		return
+	}
+
+	claimed, err := t.store.Claim(ctx, userInfo.ID, t.every)
+	if err != nil {
+		t.logger.WarnContext(ctx, "Failed to claim seller activity update", "user_id", userInfo.ID, "error", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	t.background.Add(1)
+	go func() {
+		defer t.background.Done()
+		ctx, cancel := detach.WithTimeout(ctx, touchTimeout)
+		defer cancel()
+
+		if err := t.repo.TouchSellerLastActive(ctx, userID); err != nil {
+			t.logger.ErrorContext(ctx, "Failed to update seller last active", "user_id", userInfo.ID, "error", err)
+			if err := t.store.Release(ctx, userInfo.ID); err != nil {
+				t.logger.WarnContext(ctx, "Failed to release seller activity claim", "user_id", userInfo.ID, "error", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,124 @@
+package activity_test
+
+import (
+	"context"
+	stderrors "errors"
+	"gateway/internal/activity"
+	"gateway/internal/auth"
+	"gateway/internal/testutil"
+	"gateway/internal/testutil/apitest"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+const sellerID = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+// fakeRepo counts the writes and fails them while err is set
+type fakeRepo struct {
+	mu      sync.Mutex
+	touched []string
+	err     error
+}
+
+func (f *fakeRepo) TouchSellerLastActive(_ context.Context, userID pgtype.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.touched = append(f.touched, userID.String())
+	return nil
+}
+
+func (f *fakeRepo) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.touched)
+}
+
+type trackerTest struct {
+	tracker    *activity.Tracker
+	repo       *fakeRepo
+	background *sync.WaitGroup
+}
+
+func newTrackerTest(t *testing.T) (*trackerTest, func(d time.Duration)) {
+	client, server := apitest.NewRedis(t)
+	tt := &trackerTest{repo: &fakeRepo{}, background: &sync.WaitGroup{}}
+	tt.tracker = activity.NewTracker(activity.NewStore(client), tt.repo, time.Hour, tt.background, testutil.NewTestLogger())
+	return tt, server.FastForward
+}
+
+// serve sends one request as user, nil for none, and waits for the write it started
+func (tt *trackerTest) serve(user *auth.UserInfo) {
+	r := httptest.NewRequest(http.MethodGet, "/listings", nil)
+	if user != nil {
+		r = r.WithContext(auth.WithUserInfo(r.Context(), *user))
+	}
+	handler := tt.tracker.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	tt.background.Wait()
+}
+
+func TestTracker_OncePerHour(t *testing.T) {
+	// SCENARIO: A seller browses their dashboard, a request a minute for an hour and a bit.
+	// EXPECT: last_active_at is written on the first request and again once the hour is up, not in between.
+
+	tt, fastForward := newTrackerTest(t)
+	seller := &auth.UserInfo{ID: sellerID}
+
+	tt.serve(seller)
+	assert.Equal(t, []string{sellerID}, tt.repo.touched)
+
+	for range 59 {
+		fastForward(time.Minute)
+		tt.serve(seller)
+	}
+	assert.Equal(t, 1, tt.repo.count(), "still within the hour")
+
+	fastForward(time.Minute)
+	tt.serve(seller)
+	assert.Equal(t, 2, tt.repo.count())
+}
+
+func TestTracker_FailedWriteRetried(t *testing.T) {
+	// SCENARIO: The database is down for the seller's first request.
+	// EXPECT: The claim is given back, so the next request writes the timestamp instead of waiting out the hour.
+
+	tt, _ := newTrackerTest(t)
+	seller := &auth.UserInfo{ID: sellerID}
+
+	tt.repo.err = stderrors.New("connection refused")
+	tt.serve(seller)
+	assert.Zero(t, tt.repo.count())
+
+	tt.repo.err = nil
+	tt.serve(seller)
+	assert.Equal(t, 1, tt.repo.count())
+}
+
+func TestTracker_NotASeller(t *testing.T) {
+	tests := map[string]*auth.UserInfo{
+		"No user":         nil,
+		"Service account": {ID: sellerID, Roles: []string{auth.RoleService}},
+		"Not a UUID":      {ID: "static-dev-user"},
+	}
+
+	for name, user := range tests {
+		t.Run(name, func(t *testing.T) {
+			tt, _ := newTrackerTest(t)
+
+			tt.serve(user)
+
+			assert.Zero(t, tt.repo.count())
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling Keycloak while the breaker is open
+var ErrCircuitOpen = errors.New("keycloak circuit breaker is open")
+
+type BreakerConfig struct {
+	// Consecutive failed key fetches that open the breaker
+	FailureThreshold int
+	// How long the breaker stays open before a fetch is let through as a probe
+	OpenFor time.Duration
+}
+
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold: 3,
+		OpenFor:          30 * time.Second,
+	}
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerHalfOpen
+	breakerOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerHalfOpen:
+		return "half_open"
+	case breakerOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// breaker stops fetching keys from Keycloak after a run of failures, so during an outage a request with a key we
+// haven't seen is answered at once instead of waiting out the fetch timeout. Fetches are already one at a time, see
+// keySet.refresh, so unlike search.Breaker it has no probe to hand out.
+type breaker struct {
+	config BreakerConfig
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+func newBreaker(config BreakerConfig, logger *slog.Logger) *breaker {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = DefaultBreakerConfig().FailureThreshold
+	}
+	if config.OpenFor <= 0 {
+		config.OpenFor = DefaultBreakerConfig().OpenFor
+	}
+
+	authBreakerState.Set(float64(breakerClosed))
+	return &breaker{config: config, logger: logger, now: time.Now}
+}
+
+// open reports whether fetches are being refused right now, an open breaker whose OpenFor has passed isn't
+func (b *breaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == breakerOpen && b.now().Sub(b.openedAt) < b.config.OpenFor
+}
+
+// allow decides whether a fetch may go to Keycloak, moving an open breaker to half-open once OpenFor has passed
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerOpen {
+		if b.now().Sub(b.openedAt) < b.config.OpenFor {
+			return false
+		}
+		b.transition(breakerHalfOpen)
+	}
+	return true
+}
+
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.failures = 0
+		if b.state != breakerClosed {
+			b.logger.Info("Keycloak recovered, closing the circuit breaker")
+			b.transition(breakerClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.config.FailureThreshold {
+		if b.state != breakerOpen {
+			b.logger.Warn("Keycloak failing, opening the circuit breaker", "failures", b.failures, "open_for", b.config.OpenFor, "error", err)
+		}
+		b.openedAt = b.now()
+		b.transition(breakerOpen)
+	}
+}
+
+// transition must be called with mu held
+func (b *breaker) transition(to breakerState) {
+	if b.state == to {
+		return
+	}
+	b.state = to
+	authBreakerState.Set(float64(to))
+	authBreakerTransitionsTotal.WithLabelValues(to.String()).Inc()
+}
@@ -1,6 +1,10 @@
 package auth
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+)
 
 // KeycloakClaims extracts the specific data we need from the JWT
 type KeycloakClaims struct {
@@ -12,6 +16,8 @@ type KeycloakClaims struct {
 	EmailVerified     bool   `json:"email_verified"`
 	PreferredUsername string `json:"preferred_username"`
 	Azp               string `json:"azp"`
+	DimensionUnit     string `json:"dimension_unit"`   // User attribute mapped into the token, "mm" or "in"
+	DisplayCurrency   string `json:"display_currency"` // User attribute mapped into the token, e.g. "gbp"
 	RealmAccess       struct {
 		Roles []string `json:"roles"`
 	} `json:"realm_access"`
@@ -24,4 +30,11 @@ type UserInfo struct {
 	Email           string
 	AuthorizedParty string
 	Roles           []string
+	DimensionUnit   string // The unit the user wants sizes shown in, empty when they haven't picked one
+	DisplayCurrency string // The currency the user wants prices converted to, empty when they haven't picked one
+}
+
+// HasRole checks the user's Keycloak Realm Roles, for code that already has the UserInfo in hand
+func (u UserInfo) HasRole(role string) bool {
+	return slices.Contains(u.Roles, role)
 }
@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/go-jose/go-jose/v4"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrUnavailable is returned when a token needs a signing key we don't have and Keycloak can't give it to us. The
+// token may well be fine, so Middleware answers 503 AUTH_UNAVAILABLE rather than 401.
+var ErrUnavailable = errors.New("authorization service unavailable")
+
+// Config bounds every call the authenticator makes to Keycloak
+type Config struct {
+	// Each discovery attempt and each fetch of the signing keys. A request waiting on a key rotation waits no longer.
+	FetchTimeout time.Duration
+	// How often the signing keys are fetched in the background, so a rotation is usually picked up before the first
+	// token signed with the new key arrives
+	RefreshEvery time.Duration
+	// Least time between fetches for a key we don't know, so made-up tokens can't have us hammer Keycloak
+	MinRefreshInterval time.Duration
+	Breaker            BreakerConfig
+	// How long startup keeps retrying discovery while Keycloak comes up, and the first wait between attempts. The
+	// wait doubles up to maxDiscoveryBackoff.
+	DiscoveryTimeout time.Duration
+	DiscoveryBackoff time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		FetchTimeout:       2 * time.Second,
+		RefreshEvery:       5 * time.Minute,
+		MinRefreshInterval: 10 * time.Second,
+		Breaker:            DefaultBreakerConfig(),
+		DiscoveryTimeout:   2 * time.Minute,
+		DiscoveryBackoff:   time.Second,
+	}
+}
+
+const maxDiscoveryBackoff = 15 * time.Second
+
+// signingAlgorithms are those a JWKS key may use, the verifier narrows it down to what the issuer advertises
+var signingAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.EdDSA,
+}
+
+var _ oidc.KeySet = (*keySet)(nil)
+
+// keySet is oidc.RemoteKeySet with its network calls bounded. Keys are kept until a fetch replaces them and are
+// refreshed in the background, so the request path only fetches for a key ID it hasn't seen, at most once per
+// MinRefreshInterval, within FetchTimeout and behind a circuit breaker.
+type keySet struct {
+	jwksURL string
+	client  *http.Client
+	config  Config
+	breaker *breaker
+	logger  *slog.Logger
+	now     func() time.Time
+
+	// fetches collapses the requests that need new keys at the same moment into one call to Keycloak
+	fetches singleflight.Group
+
+	mu          sync.RWMutex
+	keys        []jose.JSONWebKey
+	attemptedAt time.Time
+	attemptErr  error
+}
+
+func newKeySet(jwksURL string, client *http.Client, config Config, logger *slog.Logger) *keySet {
+	return &keySet{
+		jwksURL: jwksURL,
+		client:  client,
+		config:  config,
+		breaker: newBreaker(config.Breaker, logger),
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// VerifySignature checks jwt against the cached keys, fetching them again first when none of them signed it
+func (k *keySet) VerifySignature(ctx context.Context, jwt string) ([]byte, error) {
+	jws, err := jose.ParseSigned(jwt, signingAlgorithms)
+	if err != nil {
+		return nil, fmt.Errorf("malformed jwt: %w", err)
+	}
+
+	if payload, ok := verifyWith(jws, k.cached()); ok {
+		return payload, nil
+	}
+
+	// Not signed by a key we have, Keycloak may have rotated since the last refresh
+	keys, err := k.refresh(ctx, false)
+	if err != nil {
+		reportUnavailable(ctx, err)
+		return nil, err
+	}
+	if payload, ok := verifyWith(jws, keys); ok {
+		return payload, nil
+	}
+	return nil, errors.New("token isn't signed by any of the issuer's keys")
+}
+
+func verifyWith(jws *jose.JSONWebSignature, keys []jose.JSONWebKey) ([]byte, bool) {
+	// Tokens with more than one signature aren't supported, the first one decides
+	keyID := ""
+	if len(jws.Signatures) > 0 {
+		keyID = jws.Signatures[0].Header.KeyID
+	}
+	for _, key := range keys {
+		if keyID != "" && key.KeyID != keyID {
+			continue
+		}
+		if payload, err := jws.Verify(&key); err == nil {
+			return payload, true
+		}
+	}
+	return nil, false
+}
+
+func (k *keySet) cached() []jose.JSONWebKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.keys
+}
+
+// refresh fetches the keys unless that was tried less than MinRefreshInterval ago, in which case the outcome of that
+// attempt is returned. A scheduled refresh always fetches. The fetch has its own FetchTimeout, so nobody waits on it
+// longer than that, and one the caller gave up on still finishes for whoever comes next.
+func (k *keySet) refresh(ctx context.Context, scheduled bool) ([]jose.JSONWebKey, error) {
+	done := k.fetches.DoChan("keys", func() (any, error) {
+		return k.fetch(scheduled)
+	})
+
+	select {
+	case result := <-done:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.([]jose.JSONWebKey), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetch is only ever run by one caller at a time, see refresh
+func (k *keySet) fetch(scheduled bool) ([]jose.JSONWebKey, error) {
+	k.mu.RLock()
+	recent := k.now().Sub(k.attemptedAt) < k.config.MinRefreshInterval
+	keys, attemptErr := k.keys, k.attemptErr
+	k.mu.RUnlock()
+	if recent && !scheduled {
+		return keys, attemptErr
+	}
+
+	if !k.breaker.allow() {
+		keyFetchesTotal.WithLabelValues("rejected").Inc()
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, ErrCircuitOpen)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), k.config.FetchTimeout)
+	defer cancel()
+	fetched, err := k.get(ctx)
+	k.breaker.record(err)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.attemptedAt = k.now()
+	if err != nil {
+		keyFetchesTotal.WithLabelValues("error").Inc()
+		k.attemptErr = fmt.Errorf("%w: fetching signing keys: %w", ErrUnavailable, err)
+		return nil, k.attemptErr
+	}
+	keyFetchesTotal.WithLabelValues("ok").Inc()
+	k.keys, k.attemptErr = fetched, nil
+	return fetched, nil
+}
+
+func (k *keySet) get(ctx context.Context) ([]jose.JSONWebKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.jwksURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s answered %s", k.jwksURL, resp.Status)
+	}
+	var set jose.JSONWebKeySet
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", k.jwksURL, err)
+	}
+	if len(set.Keys) == 0 {
+		return nil, fmt.Errorf("%s has no keys", k.jwksURL)
+	}
+	return set.Keys, nil
+}
+
+// run refreshes the keys every RefreshEvery until ctx is done. A failed refresh keeps the keys we have.
+func (k *keySet) run(ctx context.Context) {
+	ticker := time.NewTicker(k.config.RefreshEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := k.refresh(ctx, true); err != nil && ctx.Err() == nil {
+			k.logger.Warn("Refreshing Keycloak signing keys failed, keeping the ones we have", "error", err)
+		}
+	}
+}
+
+type unavailableContextKey struct{}
+
+// withUnavailableSlot gives VerifySignature somewhere to report that Keycloak couldn't be reached. go-oidc flattens
+// the error it returns into a string, so errors.Is can't find ErrUnavailable in what Verify returns.
+func withUnavailableSlot(ctx context.Context) (context.Context, *error) {
+	slot := new(error)
+	return context.WithValue(ctx, unavailableContextKey{}, slot), slot
+}
+
+func reportUnavailable(ctx context.Context, err error) {
+	if slot, ok := ctx.Value(unavailableContextKey{}).(*error); ok && errors.Is(err, ErrUnavailable) {
+		*slot = err
+	}
+}
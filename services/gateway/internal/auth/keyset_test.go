@@ -0,0 +1,255 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	stdjson "encoding/json"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/testutil"
+	"gateway/internal/testutil/apitest"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const stubClientID = "marketplace-gateway"
+
+// stubKeycloak is an OIDC issuer whose discovery and JWKS endpoints can be made slow or broken mid test
+type stubKeycloak struct {
+	*httptest.Server
+
+	mu   sync.Mutex
+	keys map[string]*rsa.PrivateKey
+	// failDiscovery is how many discovery calls answer 503 before one succeeds
+	failDiscovery int
+	keysStatus    int
+	keysDelay     time.Duration
+
+	discoveryCalls atomic.Int32
+	keyCalls       atomic.Int32
+}
+
+func newStubKeycloak(t *testing.T) *stubKeycloak {
+	t.Helper()
+	s := &stubKeycloak{keys: map[string]*rsa.PrivateKey{}, keysStatus: http.StatusOK}
+	s.rotate(t, "key-1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		fail := int(s.discoveryCalls.Add(1)) <= s.failDiscovery
+		s.mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = stdjson.NewEncoder(w).Encode(map[string]any{
+			"issuer":                 s.URL,
+			"jwks_uri":               s.URL + "/certs",
+			"authorization_endpoint": s.URL + "/auth",
+			"token_endpoint":         s.URL + "/token",
+		})
+	})
+	mux.HandleFunc("/certs", func(w http.ResponseWriter, r *http.Request) {
+		s.keyCalls.Add(1)
+		s.mu.Lock()
+		status, delay := s.keysStatus, s.keysDelay
+		var set jose.JSONWebKeySet
+		for kid, key := range s.keys {
+			set.Keys = append(set.Keys, jose.JSONWebKey{Key: &key.PublicKey, KeyID: kid, Algorithm: "RS256", Use: "sig"})
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		_ = stdjson.NewEncoder(w).Encode(set)
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// rotate replaces the signing keys with a new one, as Keycloak does when a realm key is rotated
+func (s *stubKeycloak) rotate(t *testing.T, kid string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = map[string]*rsa.PrivateKey{kid: key}
+}
+
+func (s *stubKeycloak) breakKeys(status int, delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keysStatus, s.keysDelay = status, delay
+}
+
+// token is signed with the stub's current key kid
+func (s *stubKeycloak) token(t *testing.T, kid string) string {
+	t.Helper()
+	s.mu.Lock()
+	key := s.keys[kid]
+	s.mu.Unlock()
+	require.NotNil(t, key, "no key %s", kid)
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, auth.KeycloakClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.URL,
+			Subject:   userID,
+			Audience:  jwt.ClaimStrings{stubClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+// testConfig never refreshes in the background, so the tests count every fetch themselves
+func testConfig() auth.Config {
+	config := auth.DefaultConfig()
+	config.FetchTimeout = 200 * time.Millisecond
+	config.RefreshEvery = time.Hour
+	config.MinRefreshInterval = 0
+	config.Breaker = auth.BreakerConfig{FailureThreshold: 2, OpenFor: time.Hour}
+	config.DiscoveryTimeout = time.Second
+	config.DiscoveryBackoff = 10 * time.Millisecond
+	return config
+}
+
+func newStubAuthenticator(t *testing.T, stub *stubKeycloak, config auth.Config) *auth.Authenticator {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	a, err := auth.NewAuthenticator(ctx, stub.URL, stubClientID, config, testutil.NewTestLogger())
+	require.NoError(t, err)
+	return a
+}
+
+func get(t *testing.T, a *auth.Authenticator, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	handler := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return apitest.Do(t, handler, apitest.Request{Method: "GET", Path: "/me", Token: token})
+}
+
+func TestNewAuthenticator_RetriesDiscovery(t *testing.T) {
+	// SCENARIO: The gateway starts before Keycloak is ready, discovery answers 503 twice.
+	// EXPECT: Startup waits it out, fetches the keys once and accepts tokens straight away.
+
+	stub := newStubKeycloak(t)
+	stub.failDiscovery = 2
+	a := newStubAuthenticator(t, stub, testConfig())
+
+	assert.Equal(t, int32(3), stub.discoveryCalls.Load())
+	assert.Equal(t, int32(1), stub.keyCalls.Load())
+	assert.Equal(t, http.StatusNoContent, get(t, a, stub.token(t, "key-1")).Code)
+	assert.Equal(t, int32(1), stub.keyCalls.Load(), "keys should come from the cache")
+}
+
+func TestNewAuthenticator_GivesUp(t *testing.T) {
+	stub := newStubKeycloak(t)
+	stub.failDiscovery = 1000
+	config := testConfig()
+	config.DiscoveryTimeout = 100 * time.Millisecond
+
+	_, err := auth.NewAuthenticator(context.Background(), stub.URL, stubClientID, config, testutil.NewTestLogger())
+	require.Error(t, err)
+	assert.Greater(t, stub.discoveryCalls.Load(), int32(1))
+}
+
+func TestAuthenticator_KeyRotation(t *testing.T) {
+	// SCENARIO: Keycloak rotates its key between two requests.
+	// EXPECT: The token signed with the new key makes one fetch and is accepted.
+
+	stub := newStubKeycloak(t)
+	a := newStubAuthenticator(t, stub, testConfig())
+
+	stub.rotate(t, "key-2")
+	assert.Equal(t, http.StatusNoContent, get(t, a, stub.token(t, "key-2")).Code)
+	assert.Equal(t, int32(2), stub.keyCalls.Load())
+}
+
+func TestAuthenticator_KeycloakDown(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		delay  time.Duration
+	}{
+		{name: "Slow", status: http.StatusOK, delay: 2 * time.Second},
+		{name: "Erroring", status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// SCENARIO: Keycloak rotates its key and then stops answering properly.
+			// EXPECT: Tokens with the new key get 503 AUTH_UNAVAILABLE within the fetch timeout, and once the breaker
+			// opens Keycloak isn't called at all. Tokens with the cached key still pass.
+
+			stub := newStubKeycloak(t)
+			oldToken := stub.token(t, "key-1")
+			a := newStubAuthenticator(t, stub, testConfig())
+			stub.rotate(t, "key-2")
+			stub.breakKeys(tt.status, tt.delay)
+			newToken := stub.token(t, "key-2")
+
+			for range 2 {
+				start := time.Now()
+				w := get(t, a, newToken)
+				assert.Less(t, time.Since(start), time.Second)
+				assert.Equal(t, http.StatusServiceUnavailable, w.Code, w.Body.String())
+				assert.Equal(t, string(errors.ReasonAuthUnavailable), apitest.DecodeError(t, w).Reason)
+			}
+			assert.True(t, a.Unavailable(), "breaker should be open")
+
+			calls := stub.keyCalls.Load()
+			w := get(t, a, newToken)
+			assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+			assert.Equal(t, calls, stub.keyCalls.Load(), "open breaker should fail fast without calling Keycloak")
+
+			assert.Equal(t, http.StatusNoContent, get(t, a, oldToken).Code)
+		})
+	}
+}
+
+func TestAuthenticator_UnknownKeyWhileUp(t *testing.T) {
+	// SCENARIO: Someone sends a token signed with a key Keycloak never had.
+	// EXPECT: 401, Keycloak being up just means the key is wrong, and the fetches it causes are rate limited.
+
+	stub := newStubKeycloak(t)
+	config := testConfig()
+	config.MinRefreshInterval = time.Hour
+	a := newStubAuthenticator(t, stub, config)
+
+	forger := newStubKeycloak(t)
+	forger.URL = stub.URL
+	for range 3 {
+		w := get(t, a, forger.token(t, "key-1"))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, string(errors.ReasonAuthTokenInvalid), apitest.DecodeError(t, w).Reason)
+	}
+	assert.Equal(t, int32(1), stub.keyCalls.Load(), "startup fetch only")
+	assert.False(t, a.Unavailable())
+}
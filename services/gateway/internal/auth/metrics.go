@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	keyFetchesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_auth_key_fetches_total",
+		Help: "Fetches of Keycloak's signing keys by result: ok, error, or rejected when the circuit breaker was open.",
+	}, []string{"result"})
+
+	authBreakerState = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gateway_auth_breaker_state",
+		Help: "Keycloak circuit breaker state: 0 closed, 1 half-open, 2 open.",
+	})
+
+	authBreakerTransitionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_auth_breaker_transitions_total",
+		Help: "Keycloak circuit breaker state changes, by the state moved to.",
+	}, []string{"state"})
+)
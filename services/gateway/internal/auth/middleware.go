@@ -3,9 +3,13 @@ package auth
 import (
 	"context"
 	"errors"
+	"fmt"
+	apperrors "gateway/internal/errors"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 )
@@ -14,85 +18,245 @@ type UserContextKey string
 
 const userContextKey UserContextKey = "user_id"
 
+// RoleAdmin is the Keycloak realm role for marketplace operators
+const RoleAdmin = "admin"
+
+// RoleModerator is the Keycloak realm role for people who review listings
+const RoleModerator = "moderator"
+
+// RoleService is the Keycloak realm role for the service accounts of our own workers. It only counts on tokens issued
+// to a client passed to TrustServiceClients, and those tokens carry no other role.
+const RoleService = "service"
+
 // Authenticator holds the OIDC verification logic
 type Authenticator struct {
 	provider *oidc.Provider
+	// keys is nil unless the keys come from Keycloak, see NewAuthenticator
+	keys     *keySet
 	verifier *oidc.IDTokenVerifier
+	// newVerifier builds a verifier against the same issuer and keys, with different client checks
+	newVerifier func(config *oidc.Config) *oidc.IDTokenVerifier
+
+	// serviceVerifier accepts tokens for any audience, whether the azp is trusted is checked after
+	serviceVerifier *oidc.IDTokenVerifier
+	serviceClients  []string
+
+	// static takes dev: tokens instead of verifying JWTs, see NewStaticAuthenticator
+	static bool
 }
 
 // NewAuthenticator initializes the connection to Keycloak.
-// Call this ONCE in main.go
-func NewAuthenticator(ctx context.Context, issuerURL, clientID string) (*Authenticator, error) {
+// Call this ONCE in main.go. The signing keys are refreshed in the background until ctx is done.
+func NewAuthenticator(ctx context.Context, issuerURL, clientID string, config Config, logger *slog.Logger) (*Authenticator, error) {
 	// 1. Discovery: Hits {issuer}/.well-known/openid-configuration
-	provider, err := oidc.NewProvider(ctx, issuerURL)
+	provider, err := discover(ctx, issuerURL, config, logger)
 	if err != nil {
 		return nil, err
 	}
+	var endpoints struct {
+		Issuer  string `json:"issuer"`
+		JWKSURL string `json:"jwks_uri"`
+	}
+	if err := provider.Claims(&endpoints); err != nil {
+		return nil, err
+	}
+	if endpoints.JWKSURL == "" {
+		return nil, fmt.Errorf("discovery document of %s has no jwks_uri", issuerURL)
+	}
+
+	// 2. Keys: Fetched now so the first requests don't wait on Keycloak, then kept fresh in the background.
+	// Failing here isn't fatal, the first request with a token fetches them again.
+	keys := newKeySet(endpoints.JWKSURL, http.DefaultClient, config, logger)
+	if _, err := keys.refresh(ctx, true); err != nil {
+		logger.Warn("Couldn't fetch Keycloak signing keys, requests will try again", "error", err)
+	}
+	go keys.run(ctx)
 
-	// 2. Config: We want to check that the token is for OUR Client ID
-	config := &oidc.Config{
+	// 3. Config: We want to check that the token is for OUR Client ID
+	verifierConfig := &oidc.Config{
 		ClientID: clientID,
 		// SkipClientIDCheck: true, // Uncomment if you accept tokens issued for other clients (frontend)
 	}
+	newVerifier := func(config *oidc.Config) *oidc.IDTokenVerifier {
+		return oidc.NewVerifier(endpoints.Issuer, keys, config)
+	}
 
 	return &Authenticator{
-		provider: provider,
-		verifier: provider.Verifier(config),
+		provider:    provider,
+		keys:        keys,
+		verifier:    newVerifier(verifierConfig),
+		newVerifier: newVerifier,
 	}, nil
 }
 
+// discover retries while Keycloak comes up, e.g. when the whole stack starts at once, and gives up after
+// config.DiscoveryTimeout
+func discover(ctx context.Context, issuerURL string, config Config, logger *slog.Logger) (*oidc.Provider, error) {
+	giveUpAt := time.Now().Add(config.DiscoveryTimeout)
+	wait := config.DiscoveryBackoff
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, config.FetchTimeout)
+		provider, err := oidc.NewProvider(attemptCtx, issuerURL)
+		cancel()
+		if err == nil {
+			return provider, nil
+		}
+		if time.Now().Add(wait).After(giveUpAt) {
+			return nil, fmt.Errorf("discovery failed after %d attempts: %w", attempt, err)
+		}
+
+		logger.Warn("Keycloak discovery failed, retrying", "attempt", attempt, "retry_in", wait, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, maxDiscoveryBackoff)
+	}
+}
+
+// NewAuthenticatorWithKeySet skips discovery and verifies tokens against a fixed key set.
+// Used by the integration tests, which sign their own tokens instead of running Keycloak.
+func NewAuthenticatorWithKeySet(issuerURL, clientID string, keySet oidc.KeySet) *Authenticator {
+	return &Authenticator{
+		verifier: oidc.NewVerifier(issuerURL, keySet, &oidc.Config{ClientID: clientID}),
+		newVerifier: func(config *oidc.Config) *oidc.IDTokenVerifier {
+			return oidc.NewVerifier(issuerURL, keySet, config)
+		},
+	}
+}
+
+// TrustServiceClients accepts client credentials tokens issued to these Keycloak clients, e.g. the listings worker's.
+// Their audience is the Keycloak default rather than the gateway, so they are matched on azp instead. A static
+// authenticator has no Keycloak to verify them against and ignores them, every dev: token's azp is "dev".
+func (a *Authenticator) TrustServiceClients(clientIDs ...string) *Authenticator {
+	if a.static {
+		return a
+	}
+	a.serviceClients = clientIDs
+	if len(clientIDs) > 0 {
+		a.serviceVerifier = a.newVerifier(&oidc.Config{SkipClientIDCheck: true})
+	}
+	return a
+}
+
+// Unavailable reports whether the circuit breaker in front of Keycloak is open, for /readyz
+func (a *Authenticator) Unavailable() bool {
+	return a.keys != nil && a.keys.breaker.open()
+}
+
+func (a *Authenticator) isServiceClient(azp string) bool {
+	return azp != "" && slices.Contains(a.serviceClients, azp)
+}
+
 // Middleware is the standard Go/Chi middleware function
 func (a *Authenticator) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// 1. Extract Header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Missing Authorization header", http.StatusUnauthorized)
+		userInfo, appErr := a.authenticate(r)
+		if appErr != nil {
+			apperrors.RespondError(w, r, appErr)
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, "Invalid header format", http.StatusUnauthorized)
+		// 5. Inject into Context
+		next.ServeHTTP(w, r.WithContext(WithUserInfo(r.Context(), userInfo)))
+	})
+}
+
+// Optional is Middleware for routes anyone may call. A request without an Authorization header goes through with no
+// user in the context, one with a header must still carry a valid token.
+func (a *Authenticator) Optional(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
 			return
 		}
+		a.Middleware(next).ServeHTTP(w, r)
+	})
+}
+
+func (a *Authenticator) authenticate(r *http.Request) (UserInfo, *apperrors.AppError) {
+	// 1. Extract Header
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return UserInfo{}, apperrors.New(apperrors.ErrUnauthorized, "Missing Authorization header", nil).WithReason(apperrors.ReasonAuthHeaderMissing)
+	}
 
-		rawToken := parts[1]
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return UserInfo{}, apperrors.New(apperrors.ErrUnauthorized, "Invalid header format", nil).WithReason(apperrors.ReasonAuthHeaderInvalid)
+	}
+
+	rawToken := parts[1]
 
-		// 2. Verify Token (Signature, Exp, Aud)
-		// This uses cached keys from Keycloak
-		idToken, err := a.verifier.Verify(r.Context(), rawToken)
+	if a.static {
+		userInfo, err := staticUser(rawToken)
 		if err != nil {
-			slog.Warn("Token verification failed", "error", err)
-			// This covers expired tokens, bad signatures, wrong issuer
-			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
-			return
+			return UserInfo{}, apperrors.New(apperrors.ErrUnauthorized, "Invalid or expired token", err).WithReason(apperrors.ReasonAuthTokenInvalid)
 		}
+		return userInfo, nil
+	}
 
-		// 3. Extract Custom Claims (Roles, Email)
-		var claims KeycloakClaims
-		if err := idToken.Claims(&claims); err != nil {
-			http.Error(w, "Failed to parse claims", http.StatusInternalServerError)
-			return
+	// 2. Verify Token (Signature, Exp, Aud)
+	// This uses cached keys from Keycloak, only a key we haven't seen yet goes to Keycloak
+	ctx, unavailable := withUnavailableSlot(r.Context())
+	idToken, err := a.verifier.Verify(ctx, rawToken)
+	if err != nil && a.serviceVerifier != nil {
+		// Maybe a service account token, which only passes if it came from a trusted client
+		if serviceToken, serviceErr := a.serviceVerifier.Verify(ctx, rawToken); serviceErr == nil {
+			var claims KeycloakClaims
+			if serviceToken.Claims(&claims) == nil && a.isServiceClient(claims.Azp) {
+				idToken, err = serviceToken, nil
+			}
 		}
+	}
+	if err != nil && *unavailable != nil {
+		// Keycloak is down or slow and we don't have the key, the token itself may be fine
+		return UserInfo{}, apperrors.New(apperrors.ErrOverloaded, "Authorization service unavailable", *unavailable).WithReason(apperrors.ReasonAuthUnavailable)
+	}
+	if err != nil {
+		slog.Warn("Token verification failed", "error", err)
+		// This covers expired tokens, bad signatures, wrong issuer
+		return UserInfo{}, apperrors.New(apperrors.ErrUnauthorized, "Invalid or expired token", err).WithReason(apperrors.ReasonAuthTokenInvalid)
+	}
 
-		// 4. Construct Clean UserInfo
-		userInfo := UserInfo{
-			ID:              claims.Subject, // This is the stable UUID
-			Username:        claims.PreferredUsername,
-			Email:           claims.Email,
-			Roles:           claims.RealmAccess.Roles,
-			AuthorizedParty: claims.Azp,
-		}
+	// 3. Extract Custom Claims (Roles, Email)
+	var claims KeycloakClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return UserInfo{}, apperrors.New(apperrors.ErrInternal, "Failed to parse claims", err)
+	}
 
-		// 5. Inject into Context
-		ctx := context.WithValue(r.Context(), userContextKey, userInfo)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+	// 4. Construct Clean UserInfo
+	return UserInfo{
+		ID:              claims.Subject, // This is the stable UUID
+		Username:        claims.PreferredUsername,
+		Email:           claims.Email,
+		Roles:           a.roles(claims),
+		AuthorizedParty: claims.Azp,
+		DimensionUnit:   claims.DimensionUnit,
+		DisplayCurrency: claims.DisplayCurrency,
+	}, nil
+}
+
+// roles keeps the service role and everything else apart: a service account gets nothing but RoleService, however its
+// client is set up in Keycloak, and a person never gets RoleService
+func (a *Authenticator) roles(claims KeycloakClaims) []string {
+	if a.isServiceClient(claims.Azp) {
+		if slices.Contains(claims.RealmAccess.Roles, RoleService) {
+			return []string{RoleService}
+		}
+		return nil
+	}
+	return slices.DeleteFunc(slices.Clone(claims.RealmAccess.Roles), func(role string) bool { return role == RoleService })
 }
 
 // --- Helper Functions for Handlers ---
 
+// WithUserInfo attaches an authenticated user to the context, Middleware does this for every verified token
+func WithUserInfo(ctx context.Context, userInfo UserInfo) context.Context {
+	return context.WithValue(ctx, userContextKey, userInfo)
+}
+
 // GetUserInfo retrieves the user data from context
 func GetUserInfo(ctx context.Context) (UserInfo, error) {
 	val := ctx.Value(userContextKey)
@@ -117,10 +281,19 @@ func HasRole(ctx context.Context, role string) bool {
 	if err != nil {
 		return false
 	}
-	for _, r := range user.Roles {
-		if r == role {
-			return true
-		}
+	return user.HasRole(role)
+}
+
+// RequireRole refuses requests without any of the roles with a 403, for whole route groups. Mount it after Middleware.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !slices.ContainsFunc(roles, func(role string) bool { return HasRole(r.Context(), role) }) {
+				apperrors.RespondError(w, r, apperrors.New(apperrors.ErrForbidden, "Missing required role", fmt.Errorf("request needs one of the roles %q", roles)).
+					WithReason(apperrors.ReasonAuthRoleRequired).WithParam("role", strings.Join(roles, "/")))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
 	}
-	return false
 }
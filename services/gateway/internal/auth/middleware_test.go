@@ -0,0 +1,143 @@
+package auth_test
+
+import (
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/json"
+	"gateway/internal/testutil/apitest"
+	"net/http"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const userID = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+
+// newRouter mounts an internal route behind RequireRole(RoleService), a moderation route behind either of two roles
+// and an admin route that checks the role itself, all echoing the roles the caller ended up with
+func newRouter(a *auth.Authenticator) http.Handler {
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		userInfo, _ := auth.GetUserInfo(r.Context())
+		json.Write(w, http.StatusOK, userInfo.Roles)
+	}
+
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(a.Middleware)
+		r.With(auth.RequireRole(auth.RoleService)).Get("/internal/ping", echo)
+		r.With(auth.RequireRole(auth.RoleModerator, auth.RoleAdmin)).Get("/admin/listings", echo)
+		r.Get("/admin", func(w http.ResponseWriter, r *http.Request) {
+			if !auth.HasRole(r.Context(), auth.RoleAdmin) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			echo(w, r)
+		})
+	})
+	return r
+}
+
+func TestRequireRole_ServiceToken(t *testing.T) {
+	// SCENARIO: The worker calls an internal route with its client credentials token.
+	// EXPECT: Accepted although the token isn't for the gateway's audience, with only the service role.
+
+	a := apitest.NewAuthenticator(t)
+	w := apitest.Do(t, newRouter(a.Authenticator), apitest.Request{Method: "GET", Path: "/internal/ping", Token: a.ServiceToken(t, apitest.ServiceClientID, auth.RoleService)})
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var roles []string
+	apitest.Decode(t, w, &roles)
+	assert.Equal(t, []string{auth.RoleService}, roles)
+}
+
+func TestRequireRole_Refused(t *testing.T) {
+	a := apitest.NewAuthenticator(t)
+
+	tests := map[string]struct {
+		token      string
+		wantStatus int
+		wantReason errors.Reason
+	}{
+		"User token": {
+			token:      a.Token(t, auth.UserInfo{ID: userID, Roles: []string{auth.RoleAdmin}}),
+			wantStatus: http.StatusForbidden,
+			wantReason: errors.ReasonAuthRoleRequired,
+		},
+		"User token claiming the service role": {
+			token:      a.Token(t, auth.UserInfo{ID: userID, AuthorizedParty: "marketplace-web", Roles: []string{auth.RoleService}}),
+			wantStatus: http.StatusForbidden,
+			wantReason: errors.ReasonAuthRoleRequired,
+		},
+		"Trusted client without the service role": {
+			token:      a.ServiceToken(t, apitest.ServiceClientID),
+			wantStatus: http.StatusForbidden,
+			wantReason: errors.ReasonAuthRoleRequired,
+		},
+		"Untrusted client": {
+			token:      a.ServiceToken(t, "some-other-client", auth.RoleService),
+			wantStatus: http.StatusUnauthorized,
+			wantReason: errors.ReasonAuthTokenInvalid,
+		},
+		"No token": {
+			wantStatus: http.StatusUnauthorized,
+			wantReason: errors.ReasonAuthHeaderMissing,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := apitest.Do(t, newRouter(a.Authenticator), apitest.Request{Method: "GET", Path: "/internal/ping", Token: tt.token})
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, string(tt.wantReason), apitest.DecodeError(t, w).Reason)
+		})
+	}
+}
+
+func TestRequireRole_AnyOfRoles(t *testing.T) {
+	// SCENARIO: A route is open to moderators and admins, a moderator, an admin and a seller call it.
+	// EXPECT: Either role gets through, the seller is refused with a 403 naming both.
+
+	a := apitest.NewAuthenticator(t)
+	request := func(roles ...string) apitest.Request {
+		return apitest.Request{Method: "GET", Path: "/admin/listings", Token: a.Token(t, auth.UserInfo{ID: userID, Roles: roles})}
+	}
+
+	w := apitest.Do(t, newRouter(a.Authenticator), request(auth.RoleModerator))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = apitest.Do(t, newRouter(a.Authenticator), request(auth.RoleAdmin))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = apitest.Do(t, newRouter(a.Authenticator), request())
+	require.Equal(t, http.StatusForbidden, w.Code)
+	apiErr := apitest.DecodeError(t, w)
+	assert.Equal(t, string(errors.ReasonAuthRoleRequired), apiErr.Reason)
+	assert.Contains(t, apiErr.Message, "moderator/admin")
+}
+
+func TestServiceToken_RolesRestricted(t *testing.T) {
+	// SCENARIO: The worker's service account was also given the admin role in Keycloak.
+	// EXPECT: Only the service role reaches the gateway, so it can't use admin routes.
+
+	a := apitest.NewAuthenticator(t)
+	token := a.ServiceToken(t, apitest.ServiceClientID, auth.RoleService, auth.RoleAdmin)
+
+	w := apitest.Do(t, newRouter(a.Authenticator), apitest.Request{Method: "GET", Path: "/admin", Token: token})
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestUserToken_KeepsOtherRoles(t *testing.T) {
+	a := apitest.NewAuthenticator(t)
+	token := a.Token(t, auth.UserInfo{ID: userID, Roles: []string{auth.RoleAdmin, auth.RoleService}})
+
+	w := apitest.Do(t, newRouter(a.Authenticator), apitest.Request{Method: "GET", Path: "/admin", Token: token})
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var roles []string
+	apitest.Decode(t, w, &roles)
+	assert.Equal(t, []string{auth.RoleAdmin}, roles)
+}
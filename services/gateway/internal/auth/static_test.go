@@ -0,0 +1,70 @@
+package auth_test
+
+import (
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/testutil/apitest"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStaticAuthenticator_Environments(t *testing.T) {
+	// SCENARIO: AUTH_MODE=static is left on in a deploy, whatever that environment happens to be called.
+	// EXPECT: The gateway can't build the authenticator outside development and test, so it doesn't start letting
+	// anyone sign in as anyone.
+
+	for _, environment := range []string{"production", "prod", "staging", "Development", ""} {
+		t.Run(environment, func(t *testing.T) {
+			a, err := auth.NewStaticAuthenticator(environment)
+
+			assert.ErrorIs(t, err, auth.ErrStaticEnvironment)
+			assert.Nil(t, a)
+		})
+	}
+	for _, environment := range auth.StaticEnvironments {
+		t.Run(environment, func(t *testing.T) {
+			a, err := auth.NewStaticAuthenticator(environment)
+
+			require.NoError(t, err)
+			assert.NotNil(t, a)
+		})
+	}
+}
+
+func TestStaticAuthenticator_Roles(t *testing.T) {
+	// SCENARIO: A contributor running locally calls an admin route with dev tokens.
+	// EXPECT: The same role checks as with Keycloak, the roles come from the token.
+
+	a, err := auth.NewStaticAuthenticator("development")
+	require.NoError(t, err)
+
+	w := apitest.Do(t, newRouter(a), apitest.Request{Method: "GET", Path: "/admin", Token: "dev:" + userID + ":moderator,admin"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var roles []string
+	apitest.Decode(t, w, &roles)
+	assert.Equal(t, []string{auth.RoleModerator, auth.RoleAdmin}, roles)
+
+	w = apitest.Do(t, newRouter(a), apitest.Request{Method: "GET", Path: "/admin", Token: "dev:" + userID})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestStaticAuthenticator_Refused(t *testing.T) {
+	a, err := auth.NewStaticAuthenticator("development")
+	require.NoError(t, err)
+
+	for name, token := range map[string]string{
+		"Not a dev token": "eyJhbGciOiJSUzI1NiJ9.e30.c2ln",
+		"Not a UUID":      "dev:tester:admin",
+		"No user":         "dev::admin",
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := apitest.Do(t, newRouter(a), apitest.Request{Method: "GET", Path: "/admin", Token: token})
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Equal(t, string(errors.ReasonAuthTokenInvalid), apitest.DecodeError(t, w).Reason)
+		})
+	}
+}
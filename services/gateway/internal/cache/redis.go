@@ -3,6 +3,8 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -92,6 +94,32 @@ func Get[T any](c *RedisClient, ctx context.Context, key string) (*T, bool, erro
 	return &result, true, nil
 }
 
+// MGet reads several keys in one round trip. The results line up with keys, nil where a key isn't cached or holds
+// something that isn't a T, which the caller can treat as a miss and overwrite.
+func MGet[T any](c *RedisClient, ctx context.Context, keys ...string) ([]*T, error) {
+	results := make([]*T, len(keys))
+	if len(keys) == 0 {
+		return results, nil
+	}
+
+	values, err := c.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var result T
+		if err := json.Unmarshal([]byte(data), &result); err != nil {
+			continue
+		}
+		results[i] = &result
+	}
+	return results, nil
+}
+
 func SetNX(c *RedisClient, ctx context.Context, key string, value any, ttl time.Duration) (bool, error) {
 	data, err := json.Marshal(value)
 	if err != nil {
@@ -101,10 +129,167 @@ func SetNX(c *RedisClient, ctx context.Context, key string, value any, ttl time.
 	return c.rdb.SetNX(ctx, key, data, ttl).Result()
 }
 
+// HIncrBy adds delta to a hash field, creating the hash and field as needed
+func HIncrBy(c *RedisClient, ctx context.Context, key, field string, delta int64) error {
+	return c.rdb.HIncrBy(ctx, key, field, delta).Err()
+}
+
+// HIncrByAndPush is HIncrBy that also appends value as JSON to a list in the same transaction, for a tally that keeps
+// a record of each hit
+func HIncrByAndPush(c *RedisClient, ctx context.Context, key, field string, delta int64, listKey string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, key, field, delta)
+		pipe.RPush(ctx, listKey, data)
+		return nil
+	})
+	return err
+}
+
+// IncrWithTTL bumps a counter and returns its new value. The TTL is only set when the key is created,
+// so repeated hits don't push the expiry back.
+func IncrWithTTL(c *RedisClient, ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		pipe.ExpireNX(ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// SlidingWindowAdd records a hit at now and returns how many hits fall in the window ending at now. Hits are members
+// of a sorted set scored by time, member must be unique per hit. Older hits are trimmed on the way and the key expires
+// once a whole window passes without one.
+func SlidingWindowAdd(c *RedisClient, ctx context.Context, key, member string, now time.Time, window time.Duration) (int64, error) {
+	var card *redis.IntCmd
+	_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.Add(-window).UnixMicro(), 10))
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.UnixMicro()), Member: member})
+		card = pipe.ZCard(ctx, key)
+		pipe.PExpire(ctx, key, window)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return card.Val(), nil
+}
+
 func Del(c *RedisClient, ctx context.Context, key string) error {
 	return c.rdb.Del(ctx, key).Err()
 }
 
+// TTL returns how long a key has left, negative when it never expires. found is false when there's no such key.
+func TTL(c *RedisClient, ctx context.Context, key string) (time.Duration, bool, error) {
+	ttl, err := c.rdb.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, false, err
+	}
+	// go-redis passes Redis' -2 (no key) and -1 (no expiry) through as raw nanoseconds
+	if ttl == -2 {
+		return 0, false, nil
+	}
+	return ttl, true, nil
+}
+
+// Publish sends message to whoever is subscribed to channel right now, nothing is kept for later subscribers
+func Publish(c *RedisClient, ctx context.Context, channel, message string) error {
+	return c.rdb.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe calls handle with every message published to channel until ctx is done. It returns once the
+// subscription is confirmed, so a Publish after it is always heard. go-redis resubscribes after a dropped
+// connection, anything published while it was down is lost.
+func Subscribe(c *RedisClient, ctx context.Context, channel string, handle func(message string)) error {
+	sub := c.rdb.Subscribe(ctx, channel)
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return err
+	}
+
+	go func() {
+		defer sub.Close()
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				handle(msg.Payload)
+			}
+		}
+	}()
+	return nil
+}
+
+// Namespace is a key prefix whose entries can be read and deleted by ID, for admin tooling that mustn't be able to
+// reach any other key. Idempotency responses hold other users' request and response bodies, so never add them as one.
+type Namespace string
+
+// NamespaceListing holds cached GET /listings/{id} responses
+const NamespaceListing Namespace = "listing:"
+
+// Versioned nests a version under the namespace. Bumping the version orphans every entry written under the old one,
+// which then expire on their own TTL.
+func (n Namespace) Versioned(version string) Namespace {
+	return Namespace(string(n) + version + ":")
+}
+
+func (n Namespace) Key(id string) string {
+	return string(n) + id
+}
+
+// Get returns the raw value cached under id and how long it has left, negative when it never expires
+func (n Namespace) Get(c *RedisClient, ctx context.Context, id string) (json.RawMessage, time.Duration, bool, error) {
+	key := n.Key(id)
+	var (
+		get *redis.StringCmd
+		ttl *redis.DurationCmd
+	)
+	// One transaction so the TTL belongs to the value we read
+	_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		ttl = pipe.PTTL(ctx, key)
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return nil, 0, false, nil
+	}
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return json.RawMessage(get.Val()), ttl.Val(), true, nil
+}
+
+// Del removes the entry cached under id, reporting whether there was one
+func (n Namespace) Del(c *RedisClient, ctx context.Context, id string) (bool, error) {
+	deleted, err := c.rdb.Del(ctx, n.Key(id)).Result()
+	if err != nil {
+		return false, err
+	}
+	return deleted > 0, nil
+}
+
 func (c *RedisClient) Close() error {
 	return c.rdb.Close()
 }
+
+// PoolStats and Options let poolmetrics report the connection pool
+func (c *RedisClient) PoolStats() *redis.PoolStats {
+	return c.rdb.PoolStats()
+}
+
+func (c *RedisClient) Options() *redis.Options {
+	return c.rdb.Options()
+}
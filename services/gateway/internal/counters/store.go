@@ -0,0 +1,62 @@
+package counters
+
+import (
+	"context"
+	"gateway/internal/cache"
+	"time"
+)
+
+// Counter is a per-listing tally that is too hot to write to Postgres on every hit
+type Counter string
+
+const (
+	Downloads Counter = "downloads"
+	Views     Counter = "views"
+)
+
+// pendingKey is drained by the listings worker, which renames it and flushes the totals to Postgres.
+// Fields are "<listing id>:<counter>", the worker parses the same format.
+const pendingKey = "counters:pending"
+
+// clicksKey counts short link clicks between flushes, one field per code. The worker drains it with the counters.
+const clicksKey = "short-links:clicks:pending"
+
+// downloadsKey is a list of Download receipts as JSON, the worker drains it when it flushes the counters
+const downloadsKey = "downloads:pending"
+
+// Download is the receipt for one private file download, the worker parses the same JSON
+type Download struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"user_id,omitempty"` // Empty for anonymous downloads of free listings
+	ListingID    string    `json:"listing_id"`
+	FileID       string    `json:"file_id"`
+	DownloadedAt time.Time `json:"downloaded_at"`
+	ExpiresAt    time.Time `json:"expires_at"` // When the presigned URL stops working
+}
+
+type Recorder interface {
+	Incr(ctx context.Context, listingID string, counter Counter) error
+	// RecordDownload counts the download against its listing and queues its receipt for the user's history
+	RecordDownload(ctx context.Context, download Download) error
+}
+
+type Store struct {
+	cache *cache.RedisClient
+}
+
+func NewStore(c *cache.RedisClient) *Store {
+	return &Store{cache: c}
+}
+
+func (s *Store) Incr(ctx context.Context, listingID string, counter Counter) error {
+	return cache.HIncrBy(s.cache, ctx, pendingKey, listingID+":"+string(counter), 1)
+}
+
+func (s *Store) RecordDownload(ctx context.Context, download Download) error {
+	return cache.HIncrByAndPush(s.cache, ctx, pendingKey, download.ListingID+":"+string(Downloads), 1, downloadsKey, download)
+}
+
+// IncrClick counts a click on a short link
+func (s *Store) IncrClick(ctx context.Context, code string) error {
+	return cache.HIncrBy(s.cache, ctx, clicksKey, code, 1)
+}
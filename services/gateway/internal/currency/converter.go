@@ -0,0 +1,104 @@
+package currency
+
+import (
+	"context"
+	"gateway/internal/auth"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxAge is how old published rates can be and still be shown. The ECB doesn't publish at weekends or on
+	// TARGET holidays, so Thursday's rates are still the latest on the Tuesday after Easter.
+	DefaultMaxAge = 6 * 24 * time.Hour
+
+	// How long a pod trusts its local copy of the rates before asking Redis again
+	localCopyFor = time.Minute
+)
+
+// Converter hands out the stored rates while they're fresh enough to show. Every listing page asks, so each pod keeps a
+// local copy for a minute rather than adding a Redis round trip to every one.
+type Converter struct {
+	store  RatesStore
+	maxAge time.Duration
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu        sync.Mutex
+	rates     *Rates
+	fetchedAt time.Time
+}
+
+func NewConverter(store RatesStore, maxAge time.Duration, logger *slog.Logger) *Converter {
+	if maxAge <= 0 {
+		maxAge = DefaultMaxAge
+	}
+	return &Converter{
+		store:  store,
+		maxAge: maxAge,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Current returns the rates to convert with, false when there are none or they were published more than MaxAge ago.
+// Prices are then shown without a conversion rather than with a wrong one.
+func (c *Converter) Current(ctx context.Context) (Rates, bool) {
+	rates := c.load(ctx)
+	if rates == nil {
+		return Rates{}, false
+	}
+	if c.now().Sub(rates.Date) > c.maxAge {
+		return Rates{}, false
+	}
+	return *rates, true
+}
+
+// load returns the local copy, or the stored rates when it's older than a minute. If Redis is unavailable the last
+// copy is kept, its Date still decides whether it's used.
+func (c *Converter) load(ctx context.Context) *Rates {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.fetchedAt.IsZero() && c.now().Sub(c.fetchedAt) < localCopyFor {
+		return c.rates
+	}
+
+	rates, found, err := c.store.Get(ctx)
+	if err != nil {
+		c.logger.WarnContext(ctx, "Failed to read exchange rates, using the last known ones", "error", err)
+		return c.rates
+	}
+	switch {
+	case !found:
+		rates = nil
+	case c.now().Sub(rates.Date) > c.maxAge:
+		// Once a minute per pod, not on every page
+		c.logger.WarnContext(ctx, "Exchange rates are stale, not converting prices", "date", rates.Date.Format(time.DateOnly), "fetched_at", rates.FetchedAt)
+	}
+	c.rates = rates
+	c.fetchedAt = c.now()
+	return c.rates
+}
+
+// RatesSource is what handlers convert with, a Converter
+type RatesSource interface {
+	Current(ctx context.Context) (Rates, bool)
+}
+
+// DisplayQueryParam picks the currency prices are also shown in, overriding the signed in user's preference
+const DisplayQueryParam = "display_currency"
+
+// Requested is the currency the caller wants prices shown in, lower case, empty when they haven't asked for one
+func Requested(r *http.Request) string {
+	if code := r.URL.Query().Get(DisplayQueryParam); code != "" {
+		return strings.ToLower(code)
+	}
+	if userInfo, err := auth.GetUserInfo(r.Context()); err == nil {
+		return strings.ToLower(userInfo.DisplayCurrency)
+	}
+	return ""
+}
@@ -0,0 +1,234 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"gateway/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// FakeStore stands in for Redis
+type FakeStore struct {
+	mu     sync.Mutex
+	rates  *Rates
+	err    error
+	reads  int
+	writes int
+}
+
+func (f *FakeStore) Get(ctx context.Context) (*Rates, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reads++
+	if f.err != nil {
+		return nil, false, f.err
+	}
+	if f.rates == nil {
+		return nil, false, nil
+	}
+	rates := *f.rates
+	return &rates, true, nil
+}
+
+func (f *FakeStore) Set(ctx context.Context, rates Rates) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.writes++
+	f.rates = &rates
+	return f.err
+}
+
+// FakeProvider counts fetches and fails with err when it's set
+type FakeProvider struct {
+	rates   Rates
+	err     error
+	fetches int
+}
+
+func (f *FakeProvider) Latest(ctx context.Context) (Rates, error) {
+	f.fetches++
+	return f.rates, f.err
+}
+
+var (
+	published = time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	eurRates  = Rates{
+		Base:  "eur",
+		Date:  published,
+		Rates: map[string]float64{"eur": 1, "usd": 1.25, "gbp": 0.8, "jpy": 160, "kwd": 0.5},
+	}
+)
+
+func TestRates_Convert(t *testing.T) {
+	// SCENARIO: Prices are converted between currencies whose minor units have 2, 0 and 3 decimal places.
+	// EXPECT: The result is in the target's minor unit, rounded half away from zero.
+
+	tests := []struct {
+		name     string
+		amount   int64
+		from, to string
+		want     int64
+	}{
+		{"2 to 2 decimals", 1000, "usd", "gbp", 640},                // $10.00 = €8.00 = £6.40
+		{"2 to 0 decimals", 1050, "usd", "jpy", 1344},               // $10.50 = €8.40 = ¥1344
+		{"0 to 2 decimals", 1000, "jpy", "usd", 781},                // ¥1000 = €6.25 = $7.8125
+		{"2 to 3 decimals", 999, "gbp", "kwd", 6244},                // £9.99 = €12.4875 = 6.24375 KWD
+		{"3 to 2 decimals", 1234, "kwd", "eur", 247},                // 1.234 KWD = €2.468
+		{"half a minor unit rounds up", 2, "eur", "usd", 3},         // €0.02 = 2.5¢
+		{"under half a minor unit rounds down", 2, "usd", "gbp", 1}, // 2¢ = 1.28p
+		{"0 decimals round to whole units", 1, "usd", "jpy", 1},     // 1¢ = ¥1.28
+		{"less than half rounds to nothing", 1, "kwd", "jpy", 0},    // 0.001 KWD = ¥0.32
+		{"upper case codes", 1000, "USD", "GBP", 640},               // The listings store lower case, callers may not
+		{"base currency", 800, "eur", "usd", 1000},                  // €8.00 = $10.00
+		{"same currency", 1234, "gbp", "gbp", 1234},                 // Never asked for, but harmless
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := eurRates.Convert(tt.amount, tt.from, tt.to)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("unknown currency", func(t *testing.T) {
+		_, ok := eurRates.Convert(1000, "usd", "xyz")
+		assert.False(t, ok)
+		_, ok = eurRates.Convert(1000, "xyz", "usd")
+		assert.False(t, ok)
+	})
+}
+
+func TestExponent(t *testing.T) {
+	assert.Equal(t, 2, Exponent("usd"))
+	assert.Equal(t, 0, Exponent("JPY"))
+	assert.Equal(t, 3, Exponent("kwd"))
+	assert.Equal(t, 2, Exponent("xyz"), "Unknown currencies are assumed to have cents")
+}
+
+func newTestConverter(store RatesStore, now *time.Time) *Converter {
+	c := NewConverter(store, 0, testutil.NewTestLogger())
+	c.now = func() time.Time { return *now }
+	return c
+}
+
+func TestConverter_Staleness(t *testing.T) {
+	// SCENARIO: Rates published on the 15th are asked for over the following days.
+	// EXPECT: They're used up to DefaultMaxAge after they were published, after that prices go unconverted.
+
+	store := &FakeStore{rates: &eurRates}
+	now := published.Add(3 * 24 * time.Hour) // A long weekend
+	c := newTestConverter(store, &now)
+
+	rates, ok := c.Current(context.Background())
+	require.True(t, ok)
+	assert.Equal(t, published, rates.Date)
+
+	now = published.Add(DefaultMaxAge + time.Hour)
+	_, ok = c.Current(context.Background())
+	assert.False(t, ok)
+
+	// The refresher catches up, the next read of Redis picks up the new day
+	store.Set(context.Background(), Rates{Base: "eur", Date: now.Truncate(24 * time.Hour), Rates: eurRates.Rates})
+	now = now.Add(localCopyFor)
+	_, ok = c.Current(context.Background())
+	assert.True(t, ok)
+}
+
+func TestConverter_NoRates(t *testing.T) {
+	now := published
+	c := newTestConverter(&FakeStore{}, &now)
+
+	_, ok := c.Current(context.Background())
+	assert.False(t, ok)
+}
+
+func TestConverter_LocalCopy(t *testing.T) {
+	// SCENARIO: Many pages ask for the rates within a minute, then Redis goes away.
+	// EXPECT: Redis is read once a minute, and while it's down the last copy is used until it's stale.
+
+	store := &FakeStore{rates: &eurRates}
+	now := published
+	c := newTestConverter(store, &now)
+
+	for range 5 {
+		_, ok := c.Current(context.Background())
+		require.True(t, ok)
+	}
+	assert.Equal(t, 1, store.reads)
+
+	store.err = errors.New("connection refused")
+	now = now.Add(localCopyFor)
+	_, ok := c.Current(context.Background())
+	assert.True(t, ok, "Redis being down shouldn't drop conversions")
+	assert.Equal(t, 2, store.reads)
+
+	now = published.Add(DefaultMaxAge + time.Hour)
+	_, ok = c.Current(context.Background())
+	assert.False(t, ok, "The last copy still goes stale")
+}
+
+func TestRefresher_RefreshIfDue(t *testing.T) {
+	// SCENARIO: A pod checks the stored rates every hour.
+	// EXPECT: It only fetches when there are none or they were fetched RefreshEvery ago, a failed fetch keeps them.
+
+	store := &FakeStore{}
+	provider := &FakeProvider{rates: eurRates}
+	now := published.Add(16 * time.Hour)
+	r := NewRefresher(provider, store, 0, testutil.NewTestLogger())
+	r.now = func() time.Time { return now }
+
+	require.NoError(t, r.RefreshIfDue(context.Background()))
+	assert.Equal(t, 1, provider.fetches)
+	assert.Equal(t, now, store.rates.FetchedAt)
+
+	now = now.Add(23 * time.Hour)
+	require.NoError(t, r.RefreshIfDue(context.Background()))
+	assert.Equal(t, 1, provider.fetches, "Another pod fetched them less than a day ago")
+
+	now = now.Add(time.Hour)
+	provider.err = errors.New("503 Service Unavailable")
+	assert.Error(t, r.RefreshIfDue(context.Background()))
+	assert.Equal(t, 2, provider.fetches)
+	assert.Equal(t, published, store.rates.Date, "The rates we had are kept")
+}
+
+func TestHTTPProvider_Latest(t *testing.T) {
+	// SCENARIO: The rates source sends its document for the day.
+	// EXPECT: The codes are lower cased and the base is given a rate of 1.
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"amount":1.0,"base":"EUR","date":"2026-10-15","rates":{"GBP":0.8,"USD":1.25}}`))
+	}))
+	defer server.Close()
+
+	rates, err := NewHTTPProvider(server.URL, nil).Latest(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "eur", rates.Base)
+	assert.Equal(t, published, rates.Date)
+	assert.Equal(t, map[string]float64{"eur": 1, "gbp": 0.8, "usd": 1.25}, rates.Rates)
+}
+
+func TestHTTPProvider_Latest_Errors(t *testing.T) {
+	for name, respond := range map[string]func(w http.ResponseWriter){
+		"status":   func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) },
+		"no rates": func(w http.ResponseWriter) { w.Write([]byte(`{"base":"EUR","date":"2026-10-15","rates":{}}`)) },
+		"bad date": func(w http.ResponseWriter) { w.Write([]byte(`{"base":"EUR","date":"yesterday","rates":{"USD":1.25}}`)) },
+		"not json": func(w http.ResponseWriter) { w.Write([]byte(`<html>`)) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { respond(w) }))
+			defer server.Close()
+
+			_, err := NewHTTPProvider(server.URL, nil).Latest(context.Background())
+			assert.Error(t, err)
+		})
+	}
+}
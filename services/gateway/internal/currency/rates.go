@@ -0,0 +1,143 @@
+// Package currency converts listing prices into the currency a buyer browses in, for display only. The rates are
+// reference rates refreshed once a day, so a converted price is an approximation and is never what anyone is charged.
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultRatesURL serves the European Central Bank's reference rates, published every working day around 16:00 CET
+const DefaultRatesURL = "https://api.frankfurter.app/latest"
+
+// Rates is one day's exchange rates against Base. Currency codes are lower case, like the listings'.
+type Rates struct {
+	Base      string             `json:"base"`
+	Rates     map[string]float64 `json:"rates"`      // What one unit of Base buys of each currency, Base itself included
+	Date      time.Time          `json:"date"`       // The day the source published them
+	FetchedAt time.Time          `json:"fetched_at"` // When we last asked the source
+}
+
+// Convert turns amountMinUnit of from into to's minor unit, rounded half away from zero to the nearest minor unit.
+// False when either currency has no rate.
+func (r Rates) Convert(amountMinUnit int64, from, to string) (int64, bool) {
+	from, to = strings.ToLower(from), strings.ToLower(to)
+	fromRate, ok := r.Rates[from]
+	if !ok || fromRate <= 0 {
+		return 0, false
+	}
+	toRate, ok := r.Rates[to]
+	if !ok || toRate <= 0 {
+		return 0, false
+	}
+
+	major := float64(amountMinUnit) / math.Pow10(Exponent(from))
+	return int64(math.Round(major * toRate / fromRate * math.Pow10(Exponent(to)))), true
+}
+
+// zeroDecimal and threeDecimal are the ISO 4217 currencies whose minor unit isn't a hundredth
+var (
+	zeroDecimal = map[string]bool{
+		"bif": true, "clp": true, "djf": true, "gnf": true, "isk": true, "jpy": true, "kmf": true, "krw": true,
+		"pyg": true, "rwf": true, "ugx": true, "uyi": true, "vnd": true, "vuv": true, "xaf": true, "xof": true,
+		"xpf": true,
+	}
+	threeDecimal = map[string]bool{
+		"bhd": true, "iqd": true, "jod": true, "kwd": true, "lyd": true, "omr": true, "tnd": true,
+	}
+)
+
+// Exponent is how many decimal places currency's minor unit has, 2 for usd, 0 for jpy and 3 for kwd
+func Exponent(currency string) int {
+	currency = strings.ToLower(currency)
+	switch {
+	case zeroDecimal[currency]:
+		return 0
+	case threeDecimal[currency]:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// Provider fetches the latest rates from wherever they come from
+type Provider interface {
+	Latest(ctx context.Context) (Rates, error)
+}
+
+// HTTPProvider reads the {"base": "EUR", "date": "2026-10-15", "rates": {"USD": 1.09}} documents served by
+// Frankfurter's ECB feed and exchangerate.host
+type HTTPProvider struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPProvider(url string, client *http.Client) *HTTPProvider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &HTTPProvider{url: url, client: client}
+}
+
+type ratesDocument struct {
+	Base  string             `json:"base"`
+	Date  string             `json:"date"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+func (p *HTTPProvider) Latest(ctx context.Context) (Rates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return Rates{}, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Rates{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Rates{}, fmt.Errorf("%s answered %s", p.url, resp.Status)
+	}
+	var doc ratesDocument
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&doc); err != nil {
+		return Rates{}, fmt.Errorf("decoding %s: %w", p.url, err)
+	}
+	date, err := time.Parse(time.DateOnly, doc.Date)
+	if err != nil {
+		return Rates{}, fmt.Errorf("%s sent date %q: %w", p.url, doc.Date, err)
+	}
+	if doc.Base == "" || len(doc.Rates) == 0 {
+		return Rates{}, fmt.Errorf("%s sent no rates", p.url)
+	}
+
+	base := strings.ToLower(doc.Base)
+	rates := Rates{Base: base, Date: date, Rates: map[string]float64{base: 1}}
+	for code, rate := range doc.Rates {
+		rates.Rates[strings.ToLower(code)] = rate
+	}
+	return rates, nil
+}
+
+// StaticProvider always returns the same rates, dated today. For development without internet access and for tests.
+type StaticProvider struct {
+	Base  string
+	Rates map[string]float64
+}
+
+// DevRates are roughly right, good enough to see converted prices on a laptop
+var DevRates = StaticProvider{Base: "eur", Rates: map[string]float64{"eur": 1, "usd": 1.09, "gbp": 0.85, "jpy": 162}}
+
+func (p StaticProvider) Latest(ctx context.Context) (Rates, error) {
+	rates := make(map[string]float64, len(p.Rates))
+	for code, rate := range p.Rates {
+		rates[code] = rate
+	}
+	return Rates{Base: p.Base, Rates: rates, Date: time.Now().UTC().Truncate(24 * time.Hour)}, nil
+}
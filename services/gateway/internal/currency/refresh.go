@@ -0,0 +1,107 @@
+package currency
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	// DefaultRefreshEvery is how old the stored rates get before they're fetched again. The ECB publishes once a day.
+	DefaultRefreshEvery = 24 * time.Hour
+
+	// checkEvery is how often each pod looks at the stored rates. Only one that finds them older than RefreshEvery
+	// fetches, so the fleet asks the source about once a day however many pods there are or how often they restart.
+	checkEvery = time.Hour
+
+	fetchTimeout = 30 * time.Second
+)
+
+// StaticURL in place of a rates URL uses DevRates, for running without internet access
+const StaticURL = "static"
+
+// Config is where the rates come from and how long they're good for
+type Config struct {
+	URL          string        // DefaultRatesURL, or StaticURL
+	RefreshEvery time.Duration // How old the stored rates get before they're fetched again
+	MaxAge       time.Duration // Rates published longer ago than this aren't shown
+}
+
+func DefaultConfig() Config {
+	return Config{
+		URL:          DefaultRatesURL,
+		RefreshEvery: DefaultRefreshEvery,
+		MaxAge:       DefaultMaxAge,
+	}
+}
+
+// Provider is the Provider the URL names
+func (c Config) Provider() Provider {
+	if c.URL == StaticURL {
+		return DevRates
+	}
+	return NewHTTPProvider(c.URL, nil)
+}
+
+// Refresher keeps the rates in the store up to date from a Provider
+type Refresher struct {
+	provider Provider
+	store    RatesStore
+	every    time.Duration
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+func NewRefresher(provider Provider, store RatesStore, every time.Duration, logger *slog.Logger) *Refresher {
+	if every <= 0 {
+		every = DefaultRefreshEvery
+	}
+	return &Refresher{
+		provider: provider,
+		store:    store,
+		every:    every,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// Run refreshes the rates straight away if they're due, then checks every hour until ctx is done. A failed refresh
+// keeps the rates we have, the converter stops using them once they're too old.
+func (r *Refresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkEvery)
+	defer ticker.Stop()
+	for {
+		if err := r.RefreshIfDue(ctx); err != nil && ctx.Err() == nil {
+			r.logger.WarnContext(ctx, "Refreshing exchange rates failed, keeping the ones we have", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RefreshIfDue fetches new rates when the stored ones were fetched more than RefreshEvery ago or there are none
+func (r *Refresher) RefreshIfDue(ctx context.Context) error {
+	stored, found, err := r.store.Get(ctx)
+	if err != nil {
+		return err
+	}
+	if found && r.now().Sub(stored.FetchedAt) < r.every {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	rates, err := r.provider.Latest(ctx)
+	if err != nil {
+		return err
+	}
+	rates.FetchedAt = r.now()
+	if err := r.store.Set(ctx, rates); err != nil {
+		return err
+	}
+	r.logger.InfoContext(ctx, "Refreshed exchange rates", "base", rates.Base, "date", rates.Date.Format(time.DateOnly), "currencies", len(rates.Rates))
+	return nil
+}
@@ -0,0 +1,34 @@
+package currency
+
+import (
+	"context"
+	"gateway/internal/cache"
+	"time"
+)
+
+// Shared by every gateway pod, whichever refreshes first does it for all of them
+const ratesKey = "currency:rates"
+
+// ratesTTL outlives any sensible MaxAge, so stale rates are still there to be reported as stale rather than missing
+const ratesTTL = 30 * 24 * time.Hour
+
+type RatesStore interface {
+	Get(ctx context.Context) (*Rates, bool, error)
+	Set(ctx context.Context, rates Rates) error
+}
+
+type Store struct {
+	cache *cache.RedisClient
+}
+
+func NewStore(c *cache.RedisClient) *Store {
+	return &Store{cache: c}
+}
+
+func (s *Store) Get(ctx context.Context) (*Rates, bool, error) {
+	return cache.Get[Rates](s.cache, ctx, ratesKey)
+}
+
+func (s *Store) Set(ctx context.Context, rates Rates) error {
+	return cache.Set(s.cache, ctx, ratesKey, rates, ratesTTL)
+}
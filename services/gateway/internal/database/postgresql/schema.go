@@ -0,0 +1,5 @@
+package postgresql
+
+// SchemaVersion is the newest migration in db/migrations this build relies on, checked against goose_db_version
+// at startup. Bump it with the migration, the tests in db fail while it lags behind.
+const SchemaVersion = 30
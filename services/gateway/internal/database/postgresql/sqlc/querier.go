@@ -11,26 +11,197 @@ import (
 )
 
 type Querier interface {
+	AddListingVariantFiles(ctx context.Context, arg AddListingVariantFilesParams) error
+	// Must run in the transaction that creates the listing's files, their paths are still the upload keys until validation
+	AttachUploadCallbacks(ctx context.Context, listingID pgtype.UUID) error
+	// The oldest events due, pushed back to lease_until so another replica's relay passes over them while this one
+	// publishes. A relay that dies mid-batch leaves them to whoever claims them once the lease is up.
+	ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]ClaimOutboxEventsRow, error)
+	CountActiveListings(ctx context.Context) (int64, error)
+	// Fallback for the category menu counts when search is unavailable
+	CountActiveListingsByCategory(ctx context.Context) ([]CountActiveListingsByCategoryRow, error)
+	// What the listing status roll-up needs once a file has a result, the first error tells the seller where to start
+	CountListingFileStatuses(ctx context.Context, listingID pgtype.UUID) (CountListingFileStatusesRow, error)
+	// Listings the seller created since @since with the same title or description, ignoring case and surrounding whitespace
+	CountRecentDuplicateListings(ctx context.Context, arg CountRecentDuplicateListingsParams) (int64, error)
+	CountSavedSearches(ctx context.Context, userID pgtype.UUID) (int64, error)
+	// Returns no row when the listing doesn't exist or was deleted
+	CreateFeaturedListing(ctx context.Context, arg CreateFeaturedListingParams) (FeaturedListing, error)
 	// Used by the worker to save rendered images or derived models
 	CreateGeneratedFile(ctx context.Context, arg CreateGeneratedFileParams) (ListingFile, error)
+	// Returns no row when the name already exists in any case
+	CreateHardwareOption(ctx context.Context, arg CreateHardwareOptionParams) (HardwareOption, error)
 	// Note: 'categories' and 'recommended_materials' must be passed as string slices (text[]) in Go
 	CreateListing(ctx context.Context, arg CreateListingParams) (Listing, error)
-	// Used for initial user uploads
+	// Used for initial user uploads, error_message is set when the gateway rejects a file before validation
 	CreateListingFile(ctx context.Context, arg CreateListingFileParams) (ListingFile, error)
+	// A new listing's uploads in one round trip, one file per position in the arrays. An empty error message is none.
+	CreateListingFiles(ctx context.Context, arg CreateListingFilesParams) ([]ListingFile, error)
+	// Must run in the same transaction as the update that changed the price
+	CreateListingPriceChange(ctx context.Context, arg CreateListingPriceChangeParams) error
+	// Must run in the same transaction as the status change it records
+	CreateListingStatusEvent(ctx context.Context, arg CreateListingStatusEventParams) error
+	// No row when the listing already has a variant with that name
+	CreateListingVariant(ctx context.Context, arg CreateListingVariantParams) (ListingVariant, error)
+	// Returns no row when the user already saved the same search
+	CreateSavedSearch(ctx context.Context, arg CreateSavedSearchParams) (SavedSearch, error)
+	// Positions follow the order of listing_ids, from 1
+	CreateSellerPins(ctx context.Context, arg CreateSellerPinsParams) error
+	// Returns no row when the code is taken, the caller draws another
+	CreateShortLink(ctx context.Context, arg CreateShortLinkParams) (ShortLink, error)
+	// Presigning the same key again replaces its callback
+	CreateUploadCallback(ctx context.Context, arg CreateUploadCallbackParams) error
+	DeleteBannedTerm(ctx context.Context, term string) (int64, error)
+	// Soft deleted, the listings worker's sweep takes is_featured off the search document if the window had put it there.
+	// Returns the listing the window was for, so its search document can be brought up to date now.
+	DeleteFeaturedListing(ctx context.Context, id pgtype.UUID) (pgtype.UUID, error)
+	DeleteListingVariant(ctx context.Context, arg DeleteListingVariantParams) (int64, error)
+	// Not a soft delete, files, status events and everything else hanging off the listings go with them
+	DeleteListingsBySellerTerms(ctx context.Context, termsVersion string) ([]DeleteListingsBySellerTermsRow, error)
+	// JetStream only dedupes within its window, an event published before published_before is of no more use
+	DeletePublishedOutboxEvents(ctx context.Context, publishedBefore pgtype.Timestamptz) (int64, error)
+	DeleteSavedSearch(ctx context.Context, arg DeleteSavedSearchParams) (int64, error)
+	DeleteSellerPins(ctx context.Context, sellerID pgtype.UUID) error
+	DeleteSellers(ctx context.Context, userIds []pgtype.UUID) error
+	// Moves a current or upcoming vacation's end to now, the listings worker then reindexes the listings and clears it
+	EndSellerVacation(ctx context.Context, userID pgtype.UUID) (Seller, error)
+	GetCategoryDefaults(ctx context.Context, category string) (CategoryDefault, error)
+	// What the cheapest variant including the file sells for, no row when no variant includes it
+	GetFileVariantPrice(ctx context.Context, fileID pgtype.UUID) (int64, error)
 	GetFilesByListingID(ctx context.Context, listingID pgtype.UUID) ([]ListingFile, error)
+	// The seller's listings that aren't in search, shown next to each of them
+	GetIndexFailuresBySeller(ctx context.Context, sellerID pgtype.UUID) ([]ListingIndexFailure, error)
+	// The listing's price and whether its seller is away, a seller without a profile never is
+	GetListingAvailability(ctx context.Context, id pgtype.UUID) (GetListingAvailabilityRow, error)
 	GetListingByID(ctx context.Context, id pgtype.UUID) (Listing, error)
-	GetListingByIDAdmin(ctx context.Context, id pgtype.UUID) (Listing, error)
+	// Locks the row until the transaction ends, the validation worker takes the same lock before it changes the status
+	GetListingByIDForUpdate(ctx context.Context, id pgtype.UUID) (Listing, error)
+	// For restore and moderation only, everything else must go through a query that filters deleted_at
+	GetListingByIDIncludingDeleted(ctx context.Context, id pgtype.UUID) (Listing, error)
 	GetListingByIDWithFiles(ctx context.Context, id pgtype.UUID) (GetListingByIDWithFilesRow, error)
-	GetListingsBySellerID(ctx context.Context, sellerID pgtype.UUID) ([]GetListingsBySellerIDRow, error)
+	// GetListingByIDWithFiles for moderators, soft-deleted listings included
+	GetListingByIDWithFilesIncludingDeleted(ctx context.Context, id pgtype.UUID) (GetListingByIDWithFilesIncludingDeletedRow, error)
+	// Only files that passed validation, on a listing that hasn't been deleted
+	GetListingFileForDownload(ctx context.Context, arg GetListingFileForDownloadParams) (ListingFile, error)
+	GetListingFileForUpdate(ctx context.Context, id pgtype.UUID) (ListingFile, error)
+	// Locks the listing a file belongs to before the file itself, the same order as listing edits, so a validation result
+	// and an edit can't deadlock. Deleted listings are returned too, their files still take results.
+	GetListingForFileForUpdate(ctx context.Context, id pgtype.UUID) (GetListingForFileForUpdateRow, error)
+	// Dev seed data, see internal/seed. Seeded listings are the ones by sellers who accepted its terms version.
+	GetListingIDsBySellerTerms(ctx context.Context, termsVersion string) ([]pgtype.UUID, error)
+	// Everything but the client and trace IDs, which are for debugging rather than the seller's history
+	GetListingStatusEvents(ctx context.Context, listingID pgtype.UUID) ([]GetListingStatusEventsRow, error)
+	// Enough of a batch of listings to show them in a list, deleted ones included so history can still name them
+	GetListingSummaries(ctx context.Context, ids []pgtype.UUID) ([]GetListingSummariesRow, error)
+	// The batch form of GetListingByIDWithFiles, for filling the listing cache for a page of listings in one query
+	GetListingsByIDsWithFiles(ctx context.Context, ids []pgtype.UUID) ([]GetListingsByIDsWithFilesRow, error)
+	// One CASE per direction and column type, only the one for sort_column and sort_desc orders anything
+	GetListingsBySellerID(ctx context.Context, arg GetListingsBySellerIDParams) ([]GetListingsBySellerIDRow, error)
+	// Locks every listing in a bulk request so ownership and status are checked in one query and can't change before the update commits
+	GetListingsForBulkUpdate(ctx context.Context, ids []pgtype.UUID) ([]GetListingsForBulkUpdateRow, error)
 	// Finds all listings that are new OR have been updated since the last sync
 	GetListingsForSync(ctx context.Context, limit int32) ([]Listing, error)
+	// The listing's model files no variant includes. Always none for a listing without variants, which sells every file.
+	GetModelFilesOutsideVariants(ctx context.Context, listingID pgtype.UUID) ([]pgtype.UUID, error)
+	// Events still waiting to go out and the ones given up on, for the relay's gauges
+	GetOutboxStats(ctx context.Context) (GetOutboxStatsRow, error)
+	// The latest @per_listing price changes of each of the seller's listings, newest first, for their price charts
+	GetRecentPriceHistoryBySeller(ctx context.Context, arg GetRecentPriceHistoryBySellerParams) ([]GetRecentPriceHistoryBySellerRow, error)
+	// Live remixes of any of the listings, their search documents and responses depend on the parent being live
+	GetRemixIDs(ctx context.Context, parentIds []pgtype.UUID) ([]pgtype.UUID, error)
+	// The listing and every remix below it, deleted ones included so the tree can be stitched back together around them.
+	// Depth is capped so a corrupt parent chain can't recurse forever.
+	GetRemixTree(ctx context.Context, arg GetRemixTreeParams) ([]GetRemixTreeRow, error)
+	// Every listing response that can be cached for the seller, deleted listings are never served
+	GetSellerListingIDs(ctx context.Context, sellerID pgtype.UUID) ([]pgtype.UUID, error)
+	// In order. Pins of listings since unpublished or deleted are left out, the next change drops them.
+	GetSellerPins(ctx context.Context, sellerID pgtype.UUID) ([]pgtype.UUID, error)
+	GetSellerProfile(ctx context.Context, userID pgtype.UUID) (Seller, error)
+	GetSellerVacation(ctx context.Context, userID pgtype.UUID) (GetSellerVacationRow, error)
+	// Where a short link goes, with whatever stops it going there
+	GetShortLinkTarget(ctx context.Context, code string) (GetShortLinkTargetRow, error)
+	// A seller's published listings for their storefront, the pinned ones first in their order, then the newest
+	GetStorefrontListingIDs(ctx context.Context, arg GetStorefrontListingIDsParams) ([]GetStorefrontListingIDsRow, error)
+	// Uploads from the request that are already attached to a listing, deleted listings included as their files have been moved on
+	GetUsedFilePaths(ctx context.Context, paths []string) ([]string, error)
+	HasDownloadedFile(ctx context.Context, arg HasDownloadedFileParams) (bool, error)
+	// Unpublishes listings, the worker drops anything that isn't ACTIVE from the index
+	HideListings(ctx context.Context, arg HideListingsParams) error
+	// Audit row of a bulk request, in the same transaction as the change
+	InsertListingBulkAction(ctx context.Context, arg InsertListingBulkActionParams) error
+	// Must run in the transaction of the change the events describe, see event_outbox. One event per position in the
+	// arrays, given ids in that order so the relay publishes them in it.
+	// Set returning functions in one SELECT list step through their arrays together, in order
+	InsertOutboxEvents(ctx context.Context, arg InsertOutboxEventsParams) error
+	IsCallbackDestinationDisabled(ctx context.Context, destination string) (bool, error)
+	// Cheaper than reading the listing when all that matters is whether it can still be served
+	IsListingLive(ctx context.Context, id pgtype.UUID) (bool, error)
+	// Windows running at now on published listings. A listing whose windows overlap comes back once for each of them.
+	ListActiveFeaturedListings(ctx context.Context, now pgtype.Timestamptz) ([]ListActiveFeaturedListingsRow, error)
+	// Every listing for moderators, deleted and pending ones included, newest first. A NULL filter matches everything.
+	// Keyset paginated on (created_at, id), pass the last row of the previous page as the cursor.
+	ListAdminListings(ctx context.Context, arg ListAdminListingsParams) ([]ListAdminListingsRow, error)
+	ListBannedTerms(ctx context.Context) ([]BannedTerm, error)
+	// The files behind a page of ListDownloadedListings, most recently downloaded first
+	ListDownloadedFiles(ctx context.Context, arg ListDownloadedFilesParams) ([]ListDownloadedFilesRow, error)
+	// A user's downloads grouped by listing, most recently downloaded first. Keyset paginated on
+	// (last_downloaded_at, listing_id), pass the last row of the previous page as the cursor.
+	ListDownloadedListings(ctx context.Context, arg ListDownloadedListingsParams) ([]ListDownloadedListingsRow, error)
+	// Every window for the admin view, the ones ending last first
+	ListFeaturedListings(ctx context.Context) ([]FeaturedListing, error)
+	ListHardwareOptions(ctx context.Context) ([]string, error)
+	// Newest failures first, for moderators chasing listings that never made it into search
+	ListIndexFailedListings(ctx context.Context, limit int32) ([]ListIndexFailedListingsRow, error)
+	// Variants of any of the listings, cheapest first, each with the files it includes
+	ListListingVariants(ctx context.Context, listingIds []pgtype.UUID) ([]ListListingVariantsRow, error)
+	// Unpublished events, the ones given up on when failed is set, oldest first
+	ListOutboxEvents(ctx context.Context, arg ListOutboxEventsParams) ([]ListOutboxEventsRow, error)
+	ListSavedSearches(ctx context.Context, userID pgtype.UUID) ([]SavedSearch, error)
+	// Active listings in a category by downloads since @since, the all-time count breaks ties
+	ListTrendingListingsInCategory(ctx context.Context, arg ListTrendingListingsInCategoryParams) ([]ListTrendingListingsInCategoryRow, error)
+	// Serializes changes to a seller's pins, run it first in their transaction. An advisory lock on the ID rather than
+	// the sellers row, sellers without a profile have none to lock. Released with the transaction.
+	LockSellerPins(ctx context.Context, sellerID pgtype.UUID) error
+	// Serializes a user's saves so two can't both pass the limit, run it first in their transaction. Released with the
+	// transaction.
+	LockUserSavedSearches(ctx context.Context, userID pgtype.UUID) error
 	// The worker calls this AFTER successfully pushing to Typesense
 	MarkListingAsIndexed(ctx context.Context, id pgtype.UUID) error
+	MarkOutboxEventPublished(ctx context.Context, arg MarkOutboxEventPublishedParams) error
+	// Does nothing when the listing was indexed again after it failed, i.e. the event arrived late, or no longer exists
+	RecordListingIndexFailure(ctx context.Context, arg RecordListingIndexFailureParams) (int64, error)
+	// A failed publish, tried again at next_attempt_at unless failed_at gives up on it
+	RecordOutboxEventFailure(ctx context.Context, arg RecordOutboxEventFailureParams) error
+	// Puts an unpublished event back in line for the relay's next pass with a fresh run of attempts. The last error stays
+	// until it goes out.
+	RetryOutboxEvent(ctx context.Context, arg RetryOutboxEventParams) (RetryOutboxEventRow, error)
+	RevokeShortLink(ctx context.Context, arg RevokeShortLinkParams) (int64, error)
+	// A result without metadata keeps what the file has
+	SetFileValidationResult(ctx context.Context, arg SetFileValidationResultParams) error
+	SetListingCounters(ctx context.Context, arg SetListingCountersParams) error
+	// Moderators only, sellers set it when they create the listing and can't change it after
+	SetListingNsfw(ctx context.Context, arg SetListingNsfwParams) (int64, error)
+	SetListingSale(ctx context.Context, arg SetListingSaleParams) error
+	// Must run in the same transaction as the CreateListingStatusEvent that records it
+	SetListingStatus(ctx context.Context, arg SetListingStatusParams) error
 	SoftDeleteFile(ctx context.Context, id pgtype.UUID) error
 	SoftDeleteListing(ctx context.Context, arg SoftDeleteListingParams) (Listing, error)
+	SoftDeleteListings(ctx context.Context, arg SoftDeleteListingsParams) error
+	// Replaces any vacation already booked, vacation_applied is the listings worker's to reconcile
+	StartSellerVacation(ctx context.Context, arg StartSellerVacationParams) (Seller, error)
+	// Users without a seller profile have nothing to update
+	TouchSellerLastActive(ctx context.Context, userID pgtype.UUID) error
+	UpdateFeaturedListing(ctx context.Context, arg UpdateFeaturedListingParams) (FeaturedListing, error)
 	// Worker updates status (e.g., PENDING -> VALID)
 	UpdateFileStatus(ctx context.Context, arg UpdateFileStatusParams) error
 	UpdateListing(ctx context.Context, arg UpdateListingParams) (Listing, error)
+	// Adds the term, or changes the severity of one already on the list
+	UpsertBannedTerm(ctx context.Context, arg UpsertBannedTermParams) (BannedTerm, error)
+	UpsertCategoryDefaults(ctx context.Context, arg UpsertCategoryDefaultsParams) (CategoryDefault, error)
+	// Replaces the flagged terms recorded for the listing
+	UpsertListingScreeningFlags(ctx context.Context, arg UpsertListingScreeningFlagsParams) error
+	// Re-submitting the form updates the profile, payout_status is owned by billing and never touched here
+	UpsertSellerProfile(ctx context.Context, arg UpsertSellerProfileParams) (Seller, error)
 }
 
 var _ Querier = (*Queries)(nil)
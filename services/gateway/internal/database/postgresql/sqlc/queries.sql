@@ -20,16 +20,62 @@ SELECT
             )
         ) FILTER (WHERE f.id IS NOT NULL), 
         '[]'
-    )::jsonb AS files
+    )::jsonb AS files,
+    -- Why the listing is in its current status, shown to the seller when it failed
+    (
+        SELECT e.reason FROM listing_status_events e
+        WHERE e.listing_id = l.id
+        ORDER BY e.created_at DESC, e.id DESC
+        LIMIT 1
+    ) AS status_reason
 FROM listings l
 LEFT JOIN listing_files f ON l.id = f.listing_id AND f.deleted_at IS NULL
 WHERE l.id = $1 AND l.deleted_at IS NULL
 GROUP BY l.id
 ORDER BY l.created_at DESC;
 
--- name: GetListingByIDAdmin :one
+-- Locks the row until the transaction ends, the validation worker takes the same lock before it changes the status
+-- name: GetListingByIDForUpdate :one
+SELECT * FROM listings
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: GetListingByIDIncludingDeleted :one
+-- For restore and moderation only, everything else must go through a query that filters deleted_at
 SELECT * FROM listings WHERE id = $1;
 
+-- name: GetListingByIDWithFilesIncludingDeleted :one
+-- GetListingByIDWithFiles for moderators, soft-deleted listings included
+SELECT 
+    l.*,
+    COALESCE(
+        json_agg(
+            json_build_object(
+                'id', f.id,
+                'file_path', f.file_path,
+                'file_type', f.file_type,
+                'status', f.status,
+                'error_message', f.error_message,
+                'is_generated', f.is_generated,
+                'source_file_id', f.source_file_id,
+                'size', f.file_size,
+                'metadata', f.metadata
+            )
+        ) FILTER (WHERE f.id IS NOT NULL), 
+        '[]'
+    )::jsonb AS files,
+    -- Why the listing is in its current status, shown to the seller when it failed
+    (
+        SELECT e.reason FROM listing_status_events e
+        WHERE e.listing_id = l.id
+        ORDER BY e.created_at DESC, e.id DESC
+        LIMIT 1
+    ) AS status_reason
+FROM listings l
+LEFT JOIN listing_files f ON l.id = f.listing_id AND f.deleted_at IS NULL
+WHERE l.id = $1
+GROUP BY l.id;
+
 -- name: GetListingsBySellerID :many
 SELECT 
     l.*,
@@ -48,12 +94,53 @@ SELECT
             )
         ) FILTER (WHERE f.id IS NOT NULL), 
         '[]'
-    )::jsonb AS files
+    )::jsonb AS files,
+    -- Why the listing is in its current status, shown to the seller when it failed
+    (
+        SELECT e.reason FROM listing_status_events e
+        WHERE e.listing_id = l.id
+        ORDER BY e.created_at DESC, e.id DESC
+        LIMIT 1
+    ) AS status_reason
 FROM listings l
 LEFT JOIN listing_files f ON l.id = f.listing_id AND f.deleted_at IS NULL
-WHERE l.seller_id = $1 AND l.deleted_at IS NULL
+WHERE l.seller_id = sqlc.arg(seller_id) AND l.deleted_at IS NULL
+  AND (sqlc.narg(status)::listing_status IS NULL OR l.status = sqlc.narg(status))
 GROUP BY l.id
-ORDER BY l.created_at DESC;
+-- One CASE per direction and column type, only the one for sort_column and sort_desc orders anything
+ORDER BY
+    CASE WHEN sqlc.arg(sort_desc)::boolean THEN
+        CASE sqlc.arg(sort_column)::text WHEN 'created_at' THEN l.created_at WHEN 'updated_at' THEN l.updated_at END
+    END DESC,
+    CASE WHEN NOT sqlc.arg(sort_desc)::boolean THEN
+        CASE sqlc.arg(sort_column)::text WHEN 'created_at' THEN l.created_at WHEN 'updated_at' THEN l.updated_at END
+    END ASC,
+    CASE WHEN sqlc.arg(sort_desc)::boolean THEN
+        CASE sqlc.arg(sort_column)::text WHEN 'price_min_unit' THEN l.price_min_unit WHEN 'downloads_count' THEN l.downloads_count::bigint END
+    END DESC,
+    CASE WHEN NOT sqlc.arg(sort_desc)::boolean THEN
+        CASE sqlc.arg(sort_column)::text WHEN 'price_min_unit' THEN l.price_min_unit WHEN 'downloads_count' THEN l.downloads_count::bigint END
+    END ASC,
+    l.created_at DESC;
+
+-- name: CountActiveListings :one
+SELECT count(*) FROM listings
+WHERE status = 'ACTIVE' AND deleted_at IS NULL;
+
+-- name: CountActiveListingsByCategory :many
+-- Fallback for the category menu counts when search is unavailable
+SELECT category::text AS category, count(*) AS listings_count
+FROM listings, unnest(categories) AS category
+WHERE status = 'ACTIVE' AND deleted_at IS NULL
+GROUP BY category;
+
+-- name: CountRecentDuplicateListings :one
+-- Listings the seller created since @since with the same title or description, ignoring case and surrounding whitespace
+SELECT count(*) FROM listings
+WHERE seller_id = @seller_id
+  AND created_at >= @since
+  AND deleted_at IS NULL
+  AND (lower(btrim(title)) = lower(btrim(@title::text)) OR lower(btrim(description)) = lower(btrim(@description::text)));
 
 -- name: CreateListing :one
 -- Note: 'categories' and 'recommended_materials' must be passed as string slices (text[]) in Go
@@ -70,8 +157,6 @@ INSERT INTO listings (
     categories, 
     license, 
     
-    client_id, 
-    trace_id, 
     thumbnail_path, 
     status,
 
@@ -95,7 +180,10 @@ INSERT INTO listings (
     is_ai_generated,
     ai_model_name,
 
-    is_nsfw
+    is_nsfw,
+
+    nozzle_diameter_mm,
+    language
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
     $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29
@@ -109,28 +197,33 @@ UPDATE listings SET
     currency = $5,
     categories = $6,
     license = $7,
-    client_id = $8,
-    trace_id = $9,
-    thumbnail_path = $10,
-    status = $11,
+    thumbnail_path = $8,
+    status = $9,
     
     -- Update Remixing
-    is_remixing_allowed = $12,
+    is_remixing_allowed = $10,
 
     -- Update Physical Properties
-    is_physical = $13,
-    total_weight_grams = $14,
-    is_assembly_required = $15,
-    is_hardware_required = $16,
-    hardware_required = $17,
-    is_multicolor = $18,
-    dimensions_mm = $19,
-    recommended_nozzle_temp_c = $20,
-    recommended_materials = $21,
+    is_physical = $11,
+    total_weight_grams = $12,
+    is_assembly_required = $13,
+    is_hardware_required = $14,
+    hardware_required = $15,
+    is_multicolor = $16,
+    dimensions_mm = $17,
+    recommended_nozzle_temp_c = $18,
+    recommended_materials = $19,
 
     -- Update AI Info
-    is_ai_generated = $22,
-    ai_model_name = $23,
+    is_ai_generated = $20,
+    ai_model_name = $21,
+
+    nozzle_diameter_mm = $22,
+    language = $23,
+
+    -- Update Sale, the flag and end date are only set by seed data so far
+    sale_name = $24,
+    sale_price = $25,
     
     -- Always update timestamp
     updated_at = CURRENT_TIMESTAMP
@@ -141,29 +234,66 @@ RETURNING *;
 -- name: SoftDeleteListing :one
 UPDATE listings 
     SET deleted_at = CURRENT_TIMESTAMP
-    WHERE id = $1 AND seller_id = $2 -- Ensure seller owns it before deleting
+    WHERE id = $1 AND seller_id = $2 AND deleted_at IS NULL -- Ensure seller owns it before deleting
     RETURNING *;
 
+-- Locks every listing in a bulk request so ownership and status are checked in one query and can't change before the update commits
+-- name: GetListingsForBulkUpdate :many
+SELECT id, seller_id, status FROM listings
+WHERE id = ANY(@ids::uuid[]) AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: SoftDeleteListings :exec
+UPDATE listings
+    SET deleted_at = CURRENT_TIMESTAMP
+    WHERE id = ANY(@ids::uuid[]) AND seller_id = @seller_id AND deleted_at IS NULL;
+
+-- Unpublishes listings, the worker drops anything that isn't ACTIVE from the index
+-- name: HideListings :exec
+UPDATE listings
+    SET status = 'HIDDEN', updated_at = CURRENT_TIMESTAMP
+    WHERE id = ANY(@ids::uuid[]) AND seller_id = @seller_id AND deleted_at IS NULL;
+
+-- name: InsertListingBulkAction :exec
+-- Audit row of a bulk request, in the same transaction as the change
+INSERT INTO listing_bulk_actions (action, user_id, username, listing_ids, failed, trace_id)
+VALUES (@action, @user_id, @username, @listing_ids::uuid[], @failed, sqlc.narg(trace_id));
+
 -- name: MarkListingAsIndexed :exec
 -- The worker calls this AFTER successfully pushing to Typesense
 UPDATE listings 
 SET last_indexed_at = CURRENT_TIMESTAMP
-WHERE id = $1;
+WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: GetListingsForSync :many
 -- Finds all listings that are new OR have been updated since the last sync
 SELECT * FROM listings
-WHERE (last_indexed_at IS NULL OR updated_at > last_indexed_at)
+WHERE deleted_at IS NULL
+    AND (last_indexed_at IS NULL OR updated_at > last_indexed_at)
 LIMIT $1;
 
 -- name: CreateListingFile :one
--- Used for initial user uploads
+-- Used for initial user uploads, error_message is set when the gateway rejects a file before validation
 INSERT INTO listing_files (
-    listing_id, file_path, file_type, file_size, metadata, status, is_generated
+    listing_id, file_path, file_type, file_size, metadata, status, error_message, is_generated
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, false
+    $1, $2, $3, $4, $5, $6, $7, false
 ) RETURNING *;
 
+-- name: CreateListingFiles :many
+-- A new listing's uploads in one round trip, one file per position in the arrays. An empty error message is none.
+INSERT INTO listing_files (
+    listing_id, file_path, file_type, file_size, metadata, status, error_message, is_generated
+)
+SELECT sqlc.arg(listing_id)::uuid, f.file_path, f.file_type::file_type, f.file_size, NULL, f.status::file_status, NULLIF(f.error_message, ''), false
+FROM (
+    -- Set returning functions in one SELECT list step through their arrays together
+    SELECT unnest(sqlc.arg(file_paths)::text[]) AS file_path, unnest(sqlc.arg(file_types)::text[]) AS file_type,
+        unnest(sqlc.arg(file_sizes)::bigint[]) AS file_size, unnest(sqlc.arg(statuses)::text[]) AS status,
+        unnest(sqlc.arg(error_messages)::text[]) AS error_message
+) AS f
+RETURNING *;
+
 -- name: CreateGeneratedFile :one
 -- Used by the worker to save rendered images or derived models
 INSERT INTO listing_files (
@@ -176,6 +306,17 @@ INSERT INTO listing_files (
 SELECT * FROM listing_files 
 WHERE listing_id = $1 AND deleted_at IS NULL;
 
+-- name: GetListingFileForDownload :one
+-- Only files that passed validation, on a listing that hasn't been deleted
+SELECT f.* FROM listing_files f
+JOIN listings l ON l.id = f.listing_id AND l.deleted_at IS NULL
+WHERE f.id = $1 AND f.listing_id = $2 AND f.status = 'VALID' AND f.deleted_at IS NULL;
+
+-- name: GetUsedFilePaths :many
+-- Uploads from the request that are already attached to a listing, deleted listings included as their files have been moved on
+SELECT file_path FROM listing_files
+WHERE file_path = ANY(@paths::text[]) AND is_generated = false;
+
 -- name: SoftDeleteFile :exec
 UPDATE listing_files
 SET deleted_at = CURRENT_TIMESTAMP
@@ -188,4 +329,602 @@ SET
     status = $2,
     error_message = $3,
     updated_at = CURRENT_TIMESTAMP
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: GetListingForFileForUpdate :one
+-- Locks the listing a file belongs to before the file itself, the same order as listing edits, so a validation result
+-- and an edit can't deadlock. Deleted listings are returned too, their files still take results.
+SELECT l.id, l.status, l.deleted_at FROM listings l
+JOIN listing_files f ON f.listing_id = l.id
+WHERE f.id = $1
+FOR UPDATE OF l;
+
+-- name: GetListingFileForUpdate :one
+SELECT * FROM listing_files
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: SetFileValidationResult :exec
+-- A result without metadata keeps what the file has
+UPDATE listing_files
+SET
+    status = sqlc.arg(status),
+    error_message = sqlc.arg(error_message),
+    metadata = COALESCE(sqlc.narg(metadata), metadata),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
+
+-- name: CountListingFileStatuses :one
+-- What the listing status roll-up needs once a file has a result, the first error tells the seller where to start
+SELECT
+    COUNT(*) FILTER (WHERE status = 'PENDING') AS pending,
+    COUNT(*) FILTER (WHERE status IN ('INVALID', 'FAILED')) AS failed,
+    (
+        SELECT e.error_message FROM listing_files e
+        WHERE e.listing_id = $1 AND e.deleted_at IS NULL AND e.status IN ('INVALID', 'FAILED') AND e.error_message IS NOT NULL
+        ORDER BY e.updated_at LIMIT 1
+    ) AS first_error
+FROM listing_files
+WHERE listing_id = $1 AND deleted_at IS NULL;
+
+-- name: SetListingStatus :exec
+-- Must run in the same transaction as the CreateListingStatusEvent that records it
+UPDATE listings
+    SET status = $2, updated_at = CURRENT_TIMESTAMP
+    WHERE id = $1;
+
+-- name: SetListingNsfw :execrows
+-- Moderators only, sellers set it when they create the listing and can't change it after
+UPDATE listings
+    SET is_nsfw = $2, updated_at = CURRENT_TIMESTAMP
+    WHERE id = $1 AND deleted_at IS NULL;
+-- name: GetSellerProfile :one
+SELECT * FROM sellers
+WHERE user_id = $1;
+
+-- name: UpsertSellerProfile :one
+-- Re-submitting the form updates the profile, payout_status is owned by billing and never touched here
+INSERT INTO sellers (
+    user_id, display_name, country, accepted_terms_version, accepted_terms_at
+) VALUES (
+    $1, $2, $3, $4, CURRENT_TIMESTAMP
+)
+ON CONFLICT (user_id) DO UPDATE SET
+    display_name = EXCLUDED.display_name,
+    country = EXCLUDED.country,
+    accepted_terms_version = EXCLUDED.accepted_terms_version,
+    accepted_terms_at = CASE
+        WHEN sellers.accepted_terms_version IS DISTINCT FROM EXCLUDED.accepted_terms_version THEN EXCLUDED.accepted_terms_at
+        ELSE sellers.accepted_terms_at
+    END
+RETURNING *;
+
+-- name: InsertOutboxEvents :exec
+-- Must run in the transaction of the change the events describe, see event_outbox. One event per position in the
+-- arrays, given ids in that order so the relay publishes them in it.
+INSERT INTO event_outbox (subject, msg_id, payload)
+-- Set returning functions in one SELECT list step through their arrays together, in order
+SELECT unnest(sqlc.arg(subjects)::text[]), unnest(sqlc.arg(msg_ids)::text[]), unnest(sqlc.arg(payloads)::bytea[]);
+
+-- name: ClaimOutboxEvents :many
+-- The oldest events due, pushed back to lease_until so another replica's relay passes over them while this one
+-- publishes. A relay that dies mid-batch leaves them to whoever claims them once the lease is up.
+UPDATE event_outbox SET next_attempt_at = sqlc.arg(lease_until)::timestamptz
+WHERE id IN (
+    SELECT id FROM event_outbox
+    WHERE published_at IS NULL AND failed_at IS NULL AND next_attempt_at <= sqlc.arg(now)::timestamptz
+    ORDER BY id
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, subject, msg_id, payload, attempts;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE event_outbox
+SET published_at = sqlc.arg(now)::timestamptz, attempts = attempts + 1, last_error = NULL
+WHERE id = sqlc.arg(id);
+
+-- name: RecordOutboxEventFailure :exec
+-- A failed publish, tried again at next_attempt_at unless failed_at gives up on it
+UPDATE event_outbox
+SET attempts = attempts + 1, last_error = sqlc.arg(last_error), next_attempt_at = sqlc.arg(next_attempt_at),
+    failed_at = sqlc.narg(failed_at)
+WHERE id = sqlc.arg(id);
+
+-- name: DeletePublishedOutboxEvents :execrows
+-- JetStream only dedupes within its window, an event published before published_before is of no more use
+DELETE FROM event_outbox WHERE published_at < sqlc.arg(published_before)::timestamptz;
+
+-- name: GetOutboxStats :one
+-- Events still waiting to go out and the ones given up on, for the relay's gauges
+SELECT
+    COUNT(*) FILTER (WHERE failed_at IS NULL)::bigint AS pending,
+    COUNT(*) FILTER (WHERE failed_at IS NOT NULL)::bigint AS failed,
+    MIN(created_at) FILTER (WHERE failed_at IS NULL)::timestamptz AS oldest_pending_at
+FROM event_outbox
+WHERE published_at IS NULL;
+
+-- name: ListOutboxEvents :many
+-- Unpublished events, the ones given up on when failed is set, oldest first
+SELECT id, subject, msg_id, attempts, next_attempt_at, last_error, failed_at, created_at
+FROM event_outbox
+WHERE published_at IS NULL AND (failed_at IS NOT NULL) = sqlc.arg(failed)::boolean
+ORDER BY id
+LIMIT sqlc.arg(row_limit);
+
+-- name: RetryOutboxEvent :one
+-- Puts an unpublished event back in line for the relay's next pass with a fresh run of attempts. The last error stays
+-- until it goes out.
+UPDATE event_outbox
+SET failed_at = NULL, attempts = 0, next_attempt_at = sqlc.arg(now)::timestamptz
+WHERE id = sqlc.arg(id) AND published_at IS NULL
+RETURNING id, subject, msg_id, attempts, next_attempt_at, last_error, failed_at, created_at;
+-- name: StartSellerVacation :one
+-- Replaces any vacation already booked, vacation_applied is the listings worker's to reconcile
+UPDATE sellers
+SET vacation_starts_at = sqlc.arg(starts_at), vacation_ends_at = sqlc.arg(ends_at), vacation_message = sqlc.arg(message)
+WHERE user_id = sqlc.arg(user_id)
+RETURNING *;
+
+-- name: EndSellerVacation :one
+-- Moves a current or upcoming vacation's end to now, the listings worker then reindexes the listings and clears it
+UPDATE sellers
+SET vacation_starts_at = LEAST(vacation_starts_at, CURRENT_TIMESTAMP), vacation_ends_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND vacation_ends_at > CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: GetSellerVacation :one
+SELECT vacation_starts_at, vacation_ends_at, vacation_message FROM sellers
+WHERE user_id = $1;
+
+-- name: TouchSellerLastActive :exec
+-- Users without a seller profile have nothing to update
+UPDATE sellers SET last_active_at = CURRENT_TIMESTAMP
+WHERE user_id = $1;
+
+-- name: GetSellerListingIDs :many
+-- Every listing response that can be cached for the seller, deleted listings are never served
+SELECT id FROM listings
+WHERE seller_id = $1 AND deleted_at IS NULL;
+
+-- name: GetListingAvailability :one
+-- The listing's price and whether its seller is away, a seller without a profile never is
+SELECT l.seller_id, l.price_min_unit, s.vacation_starts_at, s.vacation_ends_at
+FROM listings l
+LEFT JOIN sellers s ON s.user_id = l.seller_id
+WHERE l.id = $1 AND l.deleted_at IS NULL;
+
+-- name: CreateListingStatusEvent :exec
+-- Must run in the same transaction as the status change it records
+INSERT INTO listing_status_events (
+    listing_id, actor, actor_id, from_status, to_status, reason, client_id, trace_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+);
+
+-- name: GetListingStatusEvents :many
+-- Everything but the client and trace IDs, which are for debugging rather than the seller's history
+SELECT id, listing_id, actor, actor_id, from_status, to_status, reason, created_at FROM listing_status_events
+WHERE listing_id = $1
+ORDER BY created_at, id;
+
+-- name: ListHardwareOptions :many
+SELECT name FROM hardware_options
+ORDER BY lower(name);
+
+-- name: CreateHardwareOption :one
+-- Returns no row when the name already exists in any case
+INSERT INTO hardware_options (name, created_by)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: ListBannedTerms :many
+SELECT * FROM banned_terms
+ORDER BY term;
+
+-- name: UpsertBannedTerm :one
+-- Adds the term, or changes the severity of one already on the list
+INSERT INTO banned_terms (term, severity, updated_by)
+VALUES ($1, $2, $3)
+ON CONFLICT (term) DO UPDATE SET
+    severity = EXCLUDED.severity,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeleteBannedTerm :execrows
+DELETE FROM banned_terms
+WHERE term = $1;
+
+-- name: UpsertListingScreeningFlags :exec
+-- Replaces the flagged terms recorded for the listing
+INSERT INTO listing_screening_flags (listing_id, terms)
+VALUES ($1, $2)
+ON CONFLICT (listing_id) DO UPDATE SET
+    terms = EXCLUDED.terms,
+    flagged_at = CURRENT_TIMESTAMP;
+
+-- name: GetCategoryDefaults :one
+SELECT * FROM category_defaults
+WHERE category = $1;
+
+-- name: UpsertCategoryDefaults :one
+INSERT INTO category_defaults (category, recommended_materials, nozzle_temp_min_c, nozzle_temp_max_c, typical_dimensions_mm, updated_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (category) DO UPDATE SET
+    recommended_materials = EXCLUDED.recommended_materials,
+    nozzle_temp_min_c = EXCLUDED.nozzle_temp_min_c,
+    nozzle_temp_max_c = EXCLUDED.nozzle_temp_max_c,
+    typical_dimensions_mm = EXCLUDED.typical_dimensions_mm,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: CreateListingPriceChange :exec
+-- Must run in the same transaction as the update that changed the price
+INSERT INTO listing_price_history (
+    listing_id, old_price_min_unit, new_price_min_unit, old_currency, new_currency
+) VALUES (
+    $1, $2, $3, $4, $5
+);
+
+-- name: GetRecentPriceHistoryBySeller :many
+-- The latest @per_listing price changes of each of the seller's listings, newest first, for their price charts
+SELECT listing_id, old_price_min_unit, new_price_min_unit, old_currency, new_currency, changed_at FROM (
+    SELECT h.listing_id, h.old_price_min_unit, h.new_price_min_unit, h.old_currency, h.new_currency, h.changed_at,
+        row_number() OVER (PARTITION BY h.listing_id ORDER BY h.changed_at DESC) AS position
+    FROM listing_price_history h
+    JOIN listings l ON l.id = h.listing_id
+    WHERE l.seller_id = sqlc.arg(seller_id)
+) recent
+WHERE position <= sqlc.arg(per_listing)::bigint
+ORDER BY listing_id, changed_at DESC;
+
+-- name: RecordListingIndexFailure :execrows
+-- Does nothing when the listing was indexed again after it failed, i.e. the event arrived late, or no longer exists
+INSERT INTO listing_index_failures (listing_id, error_class, error, attempts, failed_at)
+SELECT l.id, sqlc.arg(error_class)::text, sqlc.arg(error)::text, sqlc.arg(attempts)::integer, sqlc.arg(failed_at)::timestamptz
+FROM listings l
+WHERE l.id = sqlc.arg(listing_id) AND (l.last_indexed_at IS NULL OR l.last_indexed_at < sqlc.arg(failed_at)::timestamptz)
+ON CONFLICT (listing_id) DO UPDATE SET
+    error_class = EXCLUDED.error_class,
+    error = EXCLUDED.error,
+    attempts = EXCLUDED.attempts,
+    failed_at = EXCLUDED.failed_at
+WHERE listing_index_failures.failed_at < EXCLUDED.failed_at;
+
+-- name: GetIndexFailuresBySeller :many
+-- The seller's listings that aren't in search, shown next to each of them
+SELECT f.* FROM listing_index_failures f
+JOIN listings l ON l.id = f.listing_id
+WHERE l.seller_id = $1;
+
+-- name: ListAdminListings :many
+-- Every listing for moderators, deleted and pending ones included, newest first. A NULL filter matches everything.
+-- Keyset paginated on (created_at, id), pass the last row of the previous page as the cursor.
+SELECT l.id, l.title, l.seller_id, l.seller_username, l.status, l.categories, l.is_nsfw, l.price_min_unit, l.currency,
+    l.created_at, l.deleted_at, COALESCE(r.report_count, 0)::bigint AS report_count,
+    COALESCE(sf.terms, '{}')::text[] AS flagged_terms
+FROM listings l
+LEFT JOIN (
+    SELECT listing_id, count(*) AS report_count FROM listing_reports GROUP BY listing_id
+) r ON r.listing_id = l.id
+LEFT JOIN listing_screening_flags sf ON sf.listing_id = l.id
+WHERE (sqlc.narg(status)::listing_status IS NULL OR l.status = sqlc.narg(status))
+  AND (sqlc.narg(seller_id)::uuid IS NULL OR l.seller_id = sqlc.narg(seller_id))
+  AND (sqlc.narg(category)::text IS NULL OR l.categories @> ARRAY[sqlc.narg(category)::text])
+  AND (sqlc.narg(is_nsfw)::boolean IS NULL OR l.is_nsfw = sqlc.narg(is_nsfw))
+  AND (sqlc.narg(created_from)::timestamptz IS NULL OR l.created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR l.created_at < sqlc.narg(created_to))
+  AND (sqlc.narg(min_reports)::bigint IS NULL OR COALESCE(r.report_count, 0) >= sqlc.narg(min_reports))
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL
+    OR (l.created_at, l.id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY l.created_at DESC, l.id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListIndexFailedListings :many
+-- Newest failures first, for moderators chasing listings that never made it into search
+SELECT f.listing_id, l.title, l.seller_id, l.seller_username, l.status, f.error_class, f.error, f.attempts, f.failed_at
+FROM listing_index_failures f
+JOIN listings l ON l.id = f.listing_id
+WHERE l.deleted_at IS NULL
+ORDER BY f.failed_at DESC
+LIMIT $1;
+
+-- name: LockUserSavedSearches :exec
+-- Serializes a user's saves so two can't both pass the limit, run it first in their transaction. Released with the
+-- transaction.
+SELECT pg_advisory_xact_lock(hashtextextended('saved_searches:' || sqlc.arg(user_id)::uuid::text, 0));
+
+-- name: CountSavedSearches :one
+SELECT count(*) FROM saved_searches
+WHERE user_id = $1;
+
+-- name: CreateSavedSearch :one
+-- Returns no row when the user already saved the same search
+INSERT INTO saved_searches (user_id, query, filters, filter_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, query, filter_by) DO NOTHING
+RETURNING *;
+
+-- name: ListSavedSearches :many
+SELECT * FROM saved_searches
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: DeleteSavedSearch :execrows
+DELETE FROM saved_searches
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListDownloadedListings :many
+-- A user's downloads grouped by listing, most recently downloaded first. Keyset paginated on
+-- (last_downloaded_at, listing_id), pass the last row of the previous page as the cursor.
+SELECT listing_id, max(downloaded_at)::timestamptz AS last_downloaded_at
+FROM downloads
+WHERE user_id = sqlc.arg(user_id)
+GROUP BY listing_id
+HAVING sqlc.narg(cursor_downloaded_at)::timestamptz IS NULL
+    OR (max(downloaded_at), listing_id) < (sqlc.narg(cursor_downloaded_at), sqlc.narg(cursor_listing_id)::uuid)
+ORDER BY last_downloaded_at DESC, listing_id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListDownloadedFiles :many
+-- The files behind a page of ListDownloadedListings, most recently downloaded first
+SELECT d.listing_id, d.file_id, f.file_type, max(d.downloaded_at)::timestamptz AS last_downloaded_at, count(*) AS download_count
+FROM downloads d
+JOIN listing_files f ON f.id = d.file_id
+WHERE d.user_id = sqlc.arg(user_id) AND d.listing_id = ANY(sqlc.arg(listing_ids)::uuid[])
+GROUP BY d.listing_id, d.file_id, f.file_type
+ORDER BY last_downloaded_at DESC, d.file_id;
+
+-- name: GetListingSummaries :many
+-- Enough of a batch of listings to show them in a list, deleted ones included so history can still name them
+SELECT id, title, seller_username, thumbnail_path, price_min_unit, currency, deleted_at FROM listings
+WHERE id = ANY(@ids::uuid[]);
+
+-- name: HasDownloadedFile :one
+SELECT EXISTS (
+    SELECT 1 FROM downloads
+    WHERE user_id = $1 AND listing_id = $2 AND file_id = $3
+);
+
+-- name: ListTrendingListingsInCategory :many
+-- Active listings in a category by downloads since @since, the all-time count breaks ties
+SELECT l.id, l.title, l.seller_username, l.thumbnail_path, l.price_min_unit, l.currency, count(d.id) AS recent_downloads
+FROM listings l
+JOIN downloads d ON d.listing_id = l.id AND d.downloaded_at >= @since
+WHERE l.status = 'ACTIVE' AND l.deleted_at IS NULL AND @category::text = ANY(l.categories)
+GROUP BY l.id
+ORDER BY recent_downloads DESC, l.downloads_count DESC, l.id
+LIMIT @page_limit;
+
+-- name: IsListingLive :one
+-- Cheaper than reading the listing when all that matters is whether it can still be served
+SELECT EXISTS (
+    SELECT 1 FROM listings
+    WHERE id = $1 AND deleted_at IS NULL
+);
+
+-- name: GetRemixIDs :many
+-- Live remixes of any of the listings, their search documents and responses depend on the parent being live
+SELECT id FROM listings
+WHERE parent_listing_id = ANY(@parent_ids::uuid[]) AND deleted_at IS NULL;
+
+-- name: GetRemixTree :many
+-- The listing and every remix below it, deleted ones included so the tree can be stitched back together around them.
+-- Depth is capped so a corrupt parent chain can't recurse forever.
+WITH RECURSIVE tree AS (
+    SELECT listings.id, 0 AS depth FROM listings WHERE listings.id = @id
+    UNION ALL
+    SELECT l.id, t.depth + 1 FROM listings l
+    JOIN tree t ON l.parent_listing_id = t.id
+    WHERE t.depth < @max_depth::int
+)
+SELECT l.id, l.parent_listing_id, l.title, l.seller_username, l.thumbnail_path, l.deleted_at, t.depth::int AS depth
+FROM tree t
+JOIN listings l ON l.id = t.id
+ORDER BY t.depth, l.created_at, l.id;
+
+-- name: CreateShortLink :one
+-- Returns no row when the code is taken, the caller draws another
+INSERT INTO short_links (code, listing_id, created_by, campaign)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (code) DO NOTHING
+RETURNING *;
+
+-- name: GetShortLinkTarget :one
+-- Where a short link goes, with whatever stops it going there
+SELECT s.listing_id, s.campaign, s.revoked_at, l.deleted_at AS listing_deleted_at
+FROM short_links s
+JOIN listings l ON l.id = s.listing_id
+WHERE s.code = $1;
+
+-- name: RevokeShortLink :execrows
+UPDATE short_links SET revoked_at = CURRENT_TIMESTAMP
+WHERE code = $1 AND listing_id = $2 AND revoked_at IS NULL;
+
+-- name: GetListingsByIDsWithFiles :many
+-- The batch form of GetListingByIDWithFiles, for filling the listing cache for a page of listings in one query
+SELECT 
+    l.*,
+    COALESCE(
+        json_agg(
+            json_build_object(
+                'id', f.id,
+                'file_path', f.file_path,
+                'file_type', f.file_type,
+                'status', f.status,
+                'error_message', f.error_message,
+                'is_generated', f.is_generated,
+                'source_file_id', f.source_file_id,
+                'size', f.file_size,
+                'metadata', f.metadata
+            )
+        ) FILTER (WHERE f.id IS NOT NULL), 
+        '[]'
+    )::jsonb AS files,
+    -- Why the listing is in its current status, shown to the seller when it failed
+    (
+        SELECT e.reason FROM listing_status_events e
+        WHERE e.listing_id = l.id
+        ORDER BY e.created_at DESC, e.id DESC
+        LIMIT 1
+    ) AS status_reason
+FROM listings l
+LEFT JOIN listing_files f ON l.id = f.listing_id AND f.deleted_at IS NULL
+WHERE l.id = ANY(sqlc.arg(ids)::uuid[]) AND l.deleted_at IS NULL
+GROUP BY l.id;
+
+-- name: CreateFeaturedListing :one
+-- Returns no row when the listing doesn't exist or was deleted
+INSERT INTO featured_listings (listing_id, starts_at, ends_at, weight, created_by)
+SELECT l.id, sqlc.arg(starts_at)::timestamptz, sqlc.arg(ends_at)::timestamptz, sqlc.arg(weight)::integer, sqlc.arg(created_by)::uuid
+FROM listings l
+WHERE l.id = sqlc.arg(listing_id) AND l.deleted_at IS NULL
+RETURNING *;
+
+-- name: UpdateFeaturedListing :one
+UPDATE featured_listings
+SET starts_at = sqlc.arg(starts_at), ends_at = sqlc.arg(ends_at), weight = sqlc.arg(weight), updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL
+RETURNING *;
+
+-- name: DeleteFeaturedListing :one
+-- Soft deleted, the listings worker's sweep takes is_featured off the search document if the window had put it there.
+-- Returns the listing the window was for, so its search document can be brought up to date now.
+UPDATE featured_listings
+SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING listing_id;
+
+-- name: ListFeaturedListings :many
+-- Every window for the admin view, the ones ending last first
+SELECT * FROM featured_listings
+WHERE deleted_at IS NULL
+ORDER BY ends_at DESC, id ASC;
+
+-- name: ListActiveFeaturedListings :many
+-- Windows running at now on published listings. A listing whose windows overlap comes back once for each of them.
+SELECT f.listing_id, f.weight, f.starts_at FROM featured_listings f
+JOIN listings l ON l.id = f.listing_id
+WHERE f.starts_at <= sqlc.arg(now)::timestamptz AND f.ends_at > sqlc.arg(now)::timestamptz AND f.deleted_at IS NULL
+    AND l.deleted_at IS NULL AND l.status = 'ACTIVE';
+
+-- name: CreateUploadCallback :exec
+-- Presigning the same key again replaces its callback
+INSERT INTO upload_callbacks (storage_key, user_id, callback_url, destination, expires_at)
+VALUES (sqlc.arg(storage_key), sqlc.arg(user_id), sqlc.arg(callback_url), sqlc.arg(destination), sqlc.arg(expires_at))
+ON CONFLICT (storage_key) DO UPDATE
+    SET callback_url = EXCLUDED.callback_url, destination = EXCLUDED.destination, expires_at = EXCLUDED.expires_at
+    WHERE upload_callbacks.file_id IS NULL;
+
+-- name: AttachUploadCallbacks :exec
+-- Must run in the transaction that creates the listing's files, their paths are still the upload keys until validation
+UPDATE upload_callbacks SET file_id = lf.id
+FROM listing_files lf
+WHERE lf.listing_id = $1 AND lf.is_generated = false
+    AND lf.file_path = upload_callbacks.storage_key AND upload_callbacks.file_id IS NULL;
+
+-- name: IsCallbackDestinationDisabled :one
+SELECT EXISTS (
+    SELECT 1 FROM callback_destinations
+    WHERE destination = $1 AND disabled_at IS NOT NULL
+);
+
+-- name: LockSellerPins :exec
+-- Serializes changes to a seller's pins, run it first in their transaction. An advisory lock on the ID rather than
+-- the sellers row, sellers without a profile have none to lock. Released with the transaction.
+SELECT pg_advisory_xact_lock(hashtextextended('seller_pins:' || sqlc.arg(seller_id)::uuid::text, 0));
+
+-- name: GetSellerPins :many
+-- In order. Pins of listings since unpublished or deleted are left out, the next change drops them.
+SELECT p.listing_id FROM seller_pinned_listings p
+JOIN listings l ON l.id = p.listing_id
+WHERE p.seller_id = $1 AND l.deleted_at IS NULL AND l.status = 'ACTIVE'
+ORDER BY p.position;
+
+-- name: DeleteSellerPins :exec
+DELETE FROM seller_pinned_listings WHERE seller_id = $1;
+
+-- name: CreateSellerPins :exec
+-- Positions follow the order of listing_ids, from 1
+INSERT INTO seller_pinned_listings (seller_id, listing_id, position)
+SELECT sqlc.arg(seller_id)::uuid, t.listing_id, t.position::smallint
+FROM unnest(sqlc.arg(listing_ids)::uuid[]) WITH ORDINALITY AS t(listing_id, position);
+
+-- name: GetStorefrontListingIDs :many
+-- A seller's published listings for their storefront, the pinned ones first in their order, then the newest
+SELECT l.id, p.position AS pinned_position FROM listings l
+LEFT JOIN seller_pinned_listings p ON p.listing_id = l.id
+WHERE l.seller_id = sqlc.arg(seller_id) AND l.deleted_at IS NULL AND l.status = 'ACTIVE'
+ORDER BY p.position ASC NULLS LAST, l.created_at DESC, l.id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListListingVariants :many
+-- Variants of any of the listings, cheapest first, each with the files it includes
+SELECT v.*,
+    COALESCE(array_agg(vf.file_id ORDER BY vf.file_id) FILTER (WHERE vf.file_id IS NOT NULL), '{}')::uuid[] AS file_ids
+FROM listing_variants v
+LEFT JOIN listing_variant_files vf ON vf.variant_id = v.id
+WHERE v.listing_id = ANY(@listing_ids::uuid[])
+GROUP BY v.id
+ORDER BY v.price_min_unit, v.created_at, v.id;
+
+-- name: CreateListingVariant :one
+-- No row when the listing already has a variant with that name
+INSERT INTO listing_variants (listing_id, name, price_min_unit)
+VALUES ($1, $2, $3)
+ON CONFLICT ON CONSTRAINT listing_variants_name_key DO NOTHING
+RETURNING *;
+
+-- name: AddListingVariantFiles :exec
+INSERT INTO listing_variant_files (variant_id, file_id)
+SELECT sqlc.arg(variant_id)::uuid, unnest(sqlc.arg(file_ids)::uuid[]);
+
+-- name: DeleteListingVariant :execrows
+DELETE FROM listing_variants WHERE id = $1 AND listing_id = $2;
+
+-- name: GetModelFilesOutsideVariants :many
+-- The listing's model files no variant includes. Always none for a listing without variants, which sells every file.
+SELECT f.id FROM listing_files f
+WHERE f.listing_id = $1 AND f.file_type = 'MODEL' AND f.deleted_at IS NULL
+    AND EXISTS (SELECT 1 FROM listing_variants v WHERE v.listing_id = f.listing_id)
+    AND NOT EXISTS (SELECT 1 FROM listing_variant_files vf WHERE vf.file_id = f.id)
+ORDER BY f.id;
+
+-- name: GetFileVariantPrice :one
+-- What the cheapest variant including the file sells for, no row when no variant includes it
+SELECT v.price_min_unit FROM listing_variant_files vf
+JOIN listing_variants v ON v.id = vf.variant_id
+WHERE vf.file_id = $1
+ORDER BY v.price_min_unit
+LIMIT 1;
+
+-- name: GetListingIDsBySellerTerms :many
+-- Dev seed data, see internal/seed. Seeded listings are the ones by sellers who accepted its terms version.
+SELECT l.id FROM listings l
+JOIN sellers s ON s.user_id = l.seller_id
+WHERE s.accepted_terms_version = @terms_version::text
+ORDER BY l.created_at, l.id;
+
+-- name: SetListingSale :exec
+UPDATE listings
+    SET is_sale_active = TRUE, sale_price = $2, sale_name = $3, sale_end_timestamp = $4
+    WHERE id = $1;
+
+-- name: SetListingCounters :exec
+UPDATE listings
+    SET likes_count = $2, downloads_count = $3, views_count = $4
+    WHERE id = $1;
+
+-- name: DeleteListingsBySellerTerms :many
+-- Not a soft delete, files, status events and everything else hanging off the listings go with them
+DELETE FROM listings l
+USING sellers s
+WHERE s.user_id = l.seller_id AND s.accepted_terms_version = @terms_version::text
+RETURNING l.id, l.seller_id, l.thumbnail_path;
+
+-- name: DeleteSellers :exec
+DELETE FROM sellers
+WHERE user_id = ANY(@user_ids::uuid[]);
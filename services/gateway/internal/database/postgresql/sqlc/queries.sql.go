@@ -11,6 +11,241 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addListingVariantFiles = `-- name: AddListingVariantFiles :exec
+INSERT INTO listing_variant_files (variant_id, file_id)
+SELECT $1::uuid, unnest($2::uuid[])
+`
+
+type AddListingVariantFilesParams struct {
+	VariantID pgtype.UUID   `json:"variant_id"`
+	FileIds   []pgtype.UUID `json:"file_ids"`
+}
+
+func (q *Queries) AddListingVariantFiles(ctx context.Context, arg AddListingVariantFilesParams) error {
+	_, err := q.db.Exec(ctx, addListingVariantFiles, arg.VariantID, arg.FileIds)
+	return err
+}
+
+const attachUploadCallbacks = `-- name: AttachUploadCallbacks :exec
+UPDATE upload_callbacks SET file_id = lf.id
+FROM listing_files lf
+WHERE lf.listing_id = $1 AND lf.is_generated = false
+    AND lf.file_path = upload_callbacks.storage_key AND upload_callbacks.file_id IS NULL
+`
+
+// Must run in the transaction that creates the listing's files, their paths are still the upload keys until validation
+func (q *Queries) AttachUploadCallbacks(ctx context.Context, listingID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, attachUploadCallbacks, listingID)
+	return err
+}
+
+const claimOutboxEvents = `-- name: ClaimOutboxEvents :many
+UPDATE event_outbox SET next_attempt_at = $1::timestamptz
+WHERE id IN (
+    SELECT id FROM event_outbox
+    WHERE published_at IS NULL AND failed_at IS NULL AND next_attempt_at <= $2::timestamptz
+    ORDER BY id
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, subject, msg_id, payload, attempts
+`
+
+type ClaimOutboxEventsParams struct {
+	LeaseUntil pgtype.Timestamptz `json:"lease_until"`
+	Now        pgtype.Timestamptz `json:"now"`
+	BatchSize  int32              `json:"batch_size"`
+}
+
+type ClaimOutboxEventsRow struct {
+	ID       int64  `json:"id"`
+	Subject  string `json:"subject"`
+	MsgID    string `json:"msg_id"`
+	Payload  []byte `json:"payload"`
+	Attempts int32  `json:"attempts"`
+}
+
+// The oldest events due, pushed back to lease_until so another replica's relay passes over them while this one
+// publishes. A relay that dies mid-batch leaves them to whoever claims them once the lease is up.
+func (q *Queries) ClaimOutboxEvents(ctx context.Context, arg ClaimOutboxEventsParams) ([]ClaimOutboxEventsRow, error) {
+	rows, err := q.db.Query(ctx, claimOutboxEvents, arg.LeaseUntil, arg.Now, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimOutboxEventsRow
+	for rows.Next() {
+		var i ClaimOutboxEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Subject,
+			&i.MsgID,
+			&i.Payload,
+			&i.Attempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countActiveListings = `-- name: CountActiveListings :one
+SELECT count(*) FROM listings
+WHERE status = 'ACTIVE' AND deleted_at IS NULL
+`
+
+func (q *Queries) CountActiveListings(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countActiveListings)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countActiveListingsByCategory = `-- name: CountActiveListingsByCategory :many
+SELECT category::text AS category, count(*) AS listings_count
+FROM listings, unnest(categories) AS category
+WHERE status = 'ACTIVE' AND deleted_at IS NULL
+GROUP BY category
+`
+
+type CountActiveListingsByCategoryRow struct {
+	Category      string `json:"category"`
+	ListingsCount int64  `json:"listings_count"`
+}
+
+// Fallback for the category menu counts when search is unavailable
+func (q *Queries) CountActiveListingsByCategory(ctx context.Context) ([]CountActiveListingsByCategoryRow, error) {
+	rows, err := q.db.Query(ctx, countActiveListingsByCategory)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountActiveListingsByCategoryRow
+	for rows.Next() {
+		var i CountActiveListingsByCategoryRow
+		if err := rows.Scan(&i.Category, &i.ListingsCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countListingFileStatuses = `-- name: CountListingFileStatuses :one
+SELECT
+    COUNT(*) FILTER (WHERE status = 'PENDING') AS pending,
+    COUNT(*) FILTER (WHERE status IN ('INVALID', 'FAILED')) AS failed,
+    (
+        SELECT e.error_message FROM listing_files e
+        WHERE e.listing_id = $1 AND e.deleted_at IS NULL AND e.status IN ('INVALID', 'FAILED') AND e.error_message IS NOT NULL
+        ORDER BY e.updated_at LIMIT 1
+    ) AS first_error
+FROM listing_files
+WHERE listing_id = $1 AND deleted_at IS NULL
+`
+
+type CountListingFileStatusesRow struct {
+	Pending    int64       `json:"pending"`
+	Failed     int64       `json:"failed"`
+	FirstError pgtype.Text `json:"first_error"`
+}
+
+// What the listing status roll-up needs once a file has a result, the first error tells the seller where to start
+func (q *Queries) CountListingFileStatuses(ctx context.Context, listingID pgtype.UUID) (CountListingFileStatusesRow, error) {
+	row := q.db.QueryRow(ctx, countListingFileStatuses, listingID)
+	var i CountListingFileStatusesRow
+	err := row.Scan(&i.Pending, &i.Failed, &i.FirstError)
+	return i, err
+}
+
+const countRecentDuplicateListings = `-- name: CountRecentDuplicateListings :one
+SELECT count(*) FROM listings
+WHERE seller_id = $1
+  AND created_at >= $2
+  AND deleted_at IS NULL
+  AND (lower(btrim(title)) = lower(btrim($3::text)) OR lower(btrim(description)) = lower(btrim($4::text)))
+`
+
+type CountRecentDuplicateListingsParams struct {
+	SellerID    pgtype.UUID        `json:"seller_id"`
+	Since       pgtype.Timestamptz `json:"since"`
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+}
+
+// Listings the seller created since @since with the same title or description, ignoring case and surrounding whitespace
+func (q *Queries) CountRecentDuplicateListings(ctx context.Context, arg CountRecentDuplicateListingsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countRecentDuplicateListings,
+		arg.SellerID,
+		arg.Since,
+		arg.Title,
+		arg.Description,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countSavedSearches = `-- name: CountSavedSearches :one
+SELECT count(*) FROM saved_searches
+WHERE user_id = $1
+`
+
+func (q *Queries) CountSavedSearches(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countSavedSearches, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createFeaturedListing = `-- name: CreateFeaturedListing :one
+INSERT INTO featured_listings (listing_id, starts_at, ends_at, weight, created_by)
+SELECT l.id, $1::timestamptz, $2::timestamptz, $3::integer, $4::uuid
+FROM listings l
+WHERE l.id = $5 AND l.deleted_at IS NULL
+RETURNING id, listing_id, starts_at, ends_at, weight, created_by, created_at, updated_at, applied, deleted_at
+`
+
+type CreateFeaturedListingParams struct {
+	StartsAt  pgtype.Timestamptz `json:"starts_at"`
+	EndsAt    pgtype.Timestamptz `json:"ends_at"`
+	Weight    int32              `json:"weight"`
+	CreatedBy pgtype.UUID        `json:"created_by"`
+	ListingID pgtype.UUID        `json:"listing_id"`
+}
+
+// Returns no row when the listing doesn't exist or was deleted
+func (q *Queries) CreateFeaturedListing(ctx context.Context, arg CreateFeaturedListingParams) (FeaturedListing, error) {
+	row := q.db.QueryRow(ctx, createFeaturedListing,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.Weight,
+		arg.CreatedBy,
+		arg.ListingID,
+	)
+	var i FeaturedListing
+	err := row.Scan(
+		&i.ID,
+		&i.ListingID,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.Weight,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Applied,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
 const createGeneratedFile = `-- name: CreateGeneratedFile :one
 INSERT INTO listing_files (
     listing_id, file_path, file_type, file_size, metadata, status, is_generated, source_file_id
@@ -59,6 +294,26 @@ func (q *Queries) CreateGeneratedFile(ctx context.Context, arg CreateGeneratedFi
 	return i, err
 }
 
+const createHardwareOption = `-- name: CreateHardwareOption :one
+INSERT INTO hardware_options (name, created_by)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+RETURNING name, created_by, created_at
+`
+
+type CreateHardwareOptionParams struct {
+	Name      string      `json:"name"`
+	CreatedBy pgtype.UUID `json:"created_by"`
+}
+
+// Returns no row when the name already exists in any case
+func (q *Queries) CreateHardwareOption(ctx context.Context, arg CreateHardwareOptionParams) (HardwareOption, error) {
+	row := q.db.QueryRow(ctx, createHardwareOption, arg.Name, arg.CreatedBy)
+	var i HardwareOption
+	err := row.Scan(&i.Name, &i.CreatedBy, &i.CreatedAt)
+	return i, err
+}
+
 const createListing = `-- name: CreateListing :one
 INSERT INTO listings (
     seller_id, 
@@ -73,8 +328,6 @@ INSERT INTO listings (
     categories, 
     license, 
     
-    client_id, 
-    trace_id, 
     thumbnail_path, 
     status,
 
@@ -98,11 +351,14 @@ INSERT INTO listings (
     is_ai_generated,
     ai_model_name,
 
-    is_nsfw
+    is_nsfw,
+
+    nozzle_diameter_mm,
+    language
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
     $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29
-) RETURNING id, seller_id, seller_name, seller_username, seller_verified, title, description, price_min_unit, currency, categories, license, client_id, trace_id, thumbnail_path, last_indexed_at, status, is_remixing_allowed, parent_listing_id, is_physical, total_weight_grams, is_assembly_required, is_hardware_required, hardware_required, is_multicolor, dimensions_mm, recommended_nozzle_temp_c, recommended_materials, is_ai_generated, ai_model_name, likes_count, downloads_count, comments_count, is_sale_active, sale_price, sale_name, sale_end_timestamp, seller_rating_average, seller_total_ratings, seller_total_sales, is_nsfw, created_at, updated_at, deleted_at
+) RETURNING id, seller_id, seller_name, seller_username, seller_verified, title, description, price_min_unit, currency, categories, license, thumbnail_path, last_indexed_at, status, is_remixing_allowed, parent_listing_id, is_physical, total_weight_grams, is_assembly_required, is_hardware_required, hardware_required, is_multicolor, dimensions_mm, recommended_nozzle_temp_c, recommended_materials, is_ai_generated, ai_model_name, likes_count, downloads_count, comments_count, is_sale_active, sale_price, sale_name, sale_end_timestamp, seller_rating_average, seller_total_ratings, seller_total_sales, is_nsfw, created_at, updated_at, deleted_at, views_count, nozzle_diameter_mm, language
 `
 
 type CreateListingParams struct {
@@ -116,8 +372,6 @@ type CreateListingParams struct {
 	Currency               string            `json:"currency"`
 	Categories             []string          `json:"categories"`
 	License                string            `json:"license"`
-	ClientID               string            `json:"client_id"`
-	TraceID                string            `json:"trace_id"`
 	ThumbnailPath          pgtype.Text       `json:"thumbnail_path"`
 	Status                 NullListingStatus `json:"status"`
 	IsRemixingAllowed      bool              `json:"is_remixing_allowed"`
@@ -131,10 +385,12 @@ type CreateListingParams struct {
 	DimensionsMm           []byte            `json:"dimensions_mm"`
 	RecommendedNozzleTempC pgtype.Int4       `json:"recommended_nozzle_temp_c"`
 	RecommendedMaterials   []string          `json:"recommended_materials"`
-	SalePrice              pgtype.Numeric    `json:"sale_price"`
+	SalePrice              pgtype.Int8       `json:"sale_price"`
 	IsAiGenerated          bool              `json:"is_ai_generated"`
 	AiModelName            pgtype.Text       `json:"ai_model_name"`
 	IsNsfw                 bool              `json:"is_nsfw"`
+	NozzleDiameterMm       pgtype.Numeric    `json:"nozzle_diameter_mm"`
+	Language               string            `json:"language"`
 }
 
 // Note: 'categories' and 'recommended_materials' must be passed as string slices (text[]) in Go
@@ -150,8 +406,6 @@ func (q *Queries) CreateListing(ctx context.Context, arg CreateListingParams) (L
 		arg.Currency,
 		arg.Categories,
 		arg.License,
-		arg.ClientID,
-		arg.TraceID,
 		arg.ThumbnailPath,
 		arg.Status,
 		arg.IsRemixingAllowed,
@@ -169,6 +423,8 @@ func (q *Queries) CreateListing(ctx context.Context, arg CreateListingParams) (L
 		arg.IsAiGenerated,
 		arg.AiModelName,
 		arg.IsNsfw,
+		arg.NozzleDiameterMm,
+		arg.Language,
 	)
 	var i Listing
 	err := row.Scan(
@@ -183,8 +439,6 @@ func (q *Queries) CreateListing(ctx context.Context, arg CreateListingParams) (L
 		&i.Currency,
 		&i.Categories,
 		&i.License,
-		&i.ClientID,
-		&i.TraceID,
 		&i.ThumbnailPath,
 		&i.LastIndexedAt,
 		&i.Status,
@@ -215,28 +469,32 @@ func (q *Queries) CreateListing(ctx context.Context, arg CreateListingParams) (L
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.ViewsCount,
+		&i.NozzleDiameterMm,
+		&i.Language,
 	)
 	return i, err
 }
 
 const createListingFile = `-- name: CreateListingFile :one
 INSERT INTO listing_files (
-    listing_id, file_path, file_type, file_size, metadata, status, is_generated
+    listing_id, file_path, file_type, file_size, metadata, status, error_message, is_generated
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, false
+    $1, $2, $3, $4, $5, $6, $7, false
 ) RETURNING id, listing_id, file_path, file_type, file_size, metadata, status, error_message, is_generated, source_file_id, created_at, updated_at, deleted_at
 `
 
 type CreateListingFileParams struct {
-	ListingID pgtype.UUID    `json:"listing_id"`
-	FilePath  string         `json:"file_path"`
-	FileType  FileType       `json:"file_type"`
-	FileSize  pgtype.Int8    `json:"file_size"`
-	Metadata  []byte         `json:"metadata"`
-	Status    NullFileStatus `json:"status"`
+	ListingID    pgtype.UUID    `json:"listing_id"`
+	FilePath     string         `json:"file_path"`
+	FileType     FileType       `json:"file_type"`
+	FileSize     pgtype.Int8    `json:"file_size"`
+	Metadata     []byte         `json:"metadata"`
+	Status       NullFileStatus `json:"status"`
+	ErrorMessage pgtype.Text    `json:"error_message"`
 }
 
-// Used for initial user uploads
+// Used for initial user uploads, error_message is set when the gateway rejects a file before validation
 func (q *Queries) CreateListingFile(ctx context.Context, arg CreateListingFileParams) (ListingFile, error) {
 	row := q.db.QueryRow(ctx, createListingFile,
 		arg.ListingID,
@@ -245,6 +503,7 @@ func (q *Queries) CreateListingFile(ctx context.Context, arg CreateListingFilePa
 		arg.FileSize,
 		arg.Metadata,
 		arg.Status,
+		arg.ErrorMessage,
 	)
 	var i ListingFile
 	err := row.Scan(
@@ -265,13 +524,39 @@ func (q *Queries) CreateListingFile(ctx context.Context, arg CreateListingFilePa
 	return i, err
 }
 
-const getFilesByListingID = `-- name: GetFilesByListingID :many
-SELECT id, listing_id, file_path, file_type, file_size, metadata, status, error_message, is_generated, source_file_id, created_at, updated_at, deleted_at FROM listing_files 
-WHERE listing_id = $1 AND deleted_at IS NULL
+const createListingFiles = `-- name: CreateListingFiles :many
+INSERT INTO listing_files (
+    listing_id, file_path, file_type, file_size, metadata, status, error_message, is_generated
+)
+SELECT $1::uuid, f.file_path, f.file_type::file_type, f.file_size, NULL, f.status::file_status, NULLIF(f.error_message, ''), false
+FROM (
+    -- Set returning functions in one SELECT list step through their arrays together
+    SELECT unnest($2::text[]) AS file_path, unnest($3::text[]) AS file_type,
+        unnest($4::bigint[]) AS file_size, unnest($5::text[]) AS status,
+        unnest($6::text[]) AS error_message
+) AS f
+RETURNING id, listing_id, file_path, file_type, file_size, metadata, status, error_message, is_generated, source_file_id, created_at, updated_at, deleted_at
 `
 
-func (q *Queries) GetFilesByListingID(ctx context.Context, listingID pgtype.UUID) ([]ListingFile, error) {
-	rows, err := q.db.Query(ctx, getFilesByListingID, listingID)
+type CreateListingFilesParams struct {
+	ListingID     pgtype.UUID `json:"listing_id"`
+	FilePaths     []string    `json:"file_paths"`
+	FileTypes     []string    `json:"file_types"`
+	FileSizes     []int64     `json:"file_sizes"`
+	Statuses      []string    `json:"statuses"`
+	ErrorMessages []string    `json:"error_messages"`
+}
+
+// A new listing's uploads in one round trip, one file per position in the arrays. An empty error message is none.
+func (q *Queries) CreateListingFiles(ctx context.Context, arg CreateListingFilesParams) ([]ListingFile, error) {
+	rows, err := q.db.Query(ctx, createListingFiles,
+		arg.ListingID,
+		arg.FilePaths,
+		arg.FileTypes,
+		arg.FileSizes,
+		arg.Statuses,
+		arg.ErrorMessages,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -304,193 +589,514 @@ func (q *Queries) GetFilesByListingID(ctx context.Context, listingID pgtype.UUID
 	return items, nil
 }
 
-const getListingByID = `-- name: GetListingByID :one
-SELECT id, seller_id, seller_name, seller_username, seller_verified, title, description, price_min_unit, currency, categories, license, client_id, trace_id, thumbnail_path, last_indexed_at, status, is_remixing_allowed, parent_listing_id, is_physical, total_weight_grams, is_assembly_required, is_hardware_required, hardware_required, is_multicolor, dimensions_mm, recommended_nozzle_temp_c, recommended_materials, is_ai_generated, ai_model_name, likes_count, downloads_count, comments_count, is_sale_active, sale_price, sale_name, sale_end_timestamp, seller_rating_average, seller_total_ratings, seller_total_sales, is_nsfw, created_at, updated_at, deleted_at FROM listings 
-    WHERE id = $1 AND deleted_at IS NULL
+const createListingPriceChange = `-- name: CreateListingPriceChange :exec
+INSERT INTO listing_price_history (
+    listing_id, old_price_min_unit, new_price_min_unit, old_currency, new_currency
+) VALUES (
+    $1, $2, $3, $4, $5
+)
 `
 
-func (q *Queries) GetListingByID(ctx context.Context, id pgtype.UUID) (Listing, error) {
-	row := q.db.QueryRow(ctx, getListingByID, id)
-	var i Listing
+type CreateListingPriceChangeParams struct {
+	ListingID       pgtype.UUID `json:"listing_id"`
+	OldPriceMinUnit int64       `json:"old_price_min_unit"`
+	NewPriceMinUnit int64       `json:"new_price_min_unit"`
+	OldCurrency     string      `json:"old_currency"`
+	NewCurrency     string      `json:"new_currency"`
+}
+
+// Must run in the same transaction as the update that changed the price
+func (q *Queries) CreateListingPriceChange(ctx context.Context, arg CreateListingPriceChangeParams) error {
+	_, err := q.db.Exec(ctx, createListingPriceChange,
+		arg.ListingID,
+		arg.OldPriceMinUnit,
+		arg.NewPriceMinUnit,
+		arg.OldCurrency,
+		arg.NewCurrency,
+	)
+	return err
+}
+
+const createListingStatusEvent = `-- name: CreateListingStatusEvent :exec
+INSERT INTO listing_status_events (
+    listing_id, actor, actor_id, from_status, to_status, reason, client_id, trace_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+`
+
+type CreateListingStatusEventParams struct {
+	ListingID  pgtype.UUID        `json:"listing_id"`
+	Actor      ListingStatusActor `json:"actor"`
+	ActorID    pgtype.UUID        `json:"actor_id"`
+	FromStatus NullListingStatus  `json:"from_status"`
+	ToStatus   ListingStatus      `json:"to_status"`
+	Reason     pgtype.Text        `json:"reason"`
+	ClientID   pgtype.Text        `json:"client_id"`
+	TraceID    pgtype.Text        `json:"trace_id"`
+}
+
+// Must run in the same transaction as the status change it records
+func (q *Queries) CreateListingStatusEvent(ctx context.Context, arg CreateListingStatusEventParams) error {
+	_, err := q.db.Exec(ctx, createListingStatusEvent,
+		arg.ListingID,
+		arg.Actor,
+		arg.ActorID,
+		arg.FromStatus,
+		arg.ToStatus,
+		arg.Reason,
+		arg.ClientID,
+		arg.TraceID,
+	)
+	return err
+}
+
+const createListingVariant = `-- name: CreateListingVariant :one
+INSERT INTO listing_variants (listing_id, name, price_min_unit)
+VALUES ($1, $2, $3)
+ON CONFLICT ON CONSTRAINT listing_variants_name_key DO NOTHING
+RETURNING id, listing_id, name, price_min_unit, created_at
+`
+
+type CreateListingVariantParams struct {
+	ListingID    pgtype.UUID `json:"listing_id"`
+	Name         string      `json:"name"`
+	PriceMinUnit int64       `json:"price_min_unit"`
+}
+
+// No row when the listing already has a variant with that name
+func (q *Queries) CreateListingVariant(ctx context.Context, arg CreateListingVariantParams) (ListingVariant, error) {
+	row := q.db.QueryRow(ctx, createListingVariant, arg.ListingID, arg.Name, arg.PriceMinUnit)
+	var i ListingVariant
 	err := row.Scan(
 		&i.ID,
-		&i.SellerID,
-		&i.SellerName,
-		&i.SellerUsername,
-		&i.SellerVerified,
-		&i.Title,
-		&i.Description,
+		&i.ListingID,
+		&i.Name,
 		&i.PriceMinUnit,
-		&i.Currency,
-		&i.Categories,
-		&i.License,
-		&i.ClientID,
-		&i.TraceID,
-		&i.ThumbnailPath,
-		&i.LastIndexedAt,
-		&i.Status,
-		&i.IsRemixingAllowed,
-		&i.ParentListingID,
-		&i.IsPhysical,
-		&i.TotalWeightGrams,
-		&i.IsAssemblyRequired,
-		&i.IsHardwareRequired,
-		&i.HardwareRequired,
-		&i.IsMulticolor,
-		&i.DimensionsMm,
-		&i.RecommendedNozzleTempC,
-		&i.RecommendedMaterials,
-		&i.IsAiGenerated,
-		&i.AiModelName,
-		&i.LikesCount,
-		&i.DownloadsCount,
-		&i.CommentsCount,
-		&i.IsSaleActive,
-		&i.SalePrice,
-		&i.SaleName,
-		&i.SaleEndTimestamp,
-		&i.SellerRatingAverage,
-		&i.SellerTotalRatings,
-		&i.SellerTotalSales,
-		&i.IsNsfw,
 		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.DeletedAt,
 	)
 	return i, err
 }
 
-const getListingByIDAdmin = `-- name: GetListingByIDAdmin :one
-SELECT id, seller_id, seller_name, seller_username, seller_verified, title, description, price_min_unit, currency, categories, license, client_id, trace_id, thumbnail_path, last_indexed_at, status, is_remixing_allowed, parent_listing_id, is_physical, total_weight_grams, is_assembly_required, is_hardware_required, hardware_required, is_multicolor, dimensions_mm, recommended_nozzle_temp_c, recommended_materials, is_ai_generated, ai_model_name, likes_count, downloads_count, comments_count, is_sale_active, sale_price, sale_name, sale_end_timestamp, seller_rating_average, seller_total_ratings, seller_total_sales, is_nsfw, created_at, updated_at, deleted_at FROM listings WHERE id = $1
+const createSavedSearch = `-- name: CreateSavedSearch :one
+INSERT INTO saved_searches (user_id, query, filters, filter_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, query, filter_by) DO NOTHING
+RETURNING id, user_id, query, filters, filter_by, notified_listing_ids, last_checked_at, next_check_at, created_at
 `
 
-func (q *Queries) GetListingByIDAdmin(ctx context.Context, id pgtype.UUID) (Listing, error) {
-	row := q.db.QueryRow(ctx, getListingByIDAdmin, id)
-	var i Listing
+type CreateSavedSearchParams struct {
+	UserID   pgtype.UUID `json:"user_id"`
+	Query    string      `json:"query"`
+	Filters  []byte      `json:"filters"`
+	FilterBy string      `json:"filter_by"`
+}
+
+// Returns no row when the user already saved the same search
+func (q *Queries) CreateSavedSearch(ctx context.Context, arg CreateSavedSearchParams) (SavedSearch, error) {
+	row := q.db.QueryRow(ctx, createSavedSearch,
+		arg.UserID,
+		arg.Query,
+		arg.Filters,
+		arg.FilterBy,
+	)
+	var i SavedSearch
 	err := row.Scan(
 		&i.ID,
-		&i.SellerID,
-		&i.SellerName,
-		&i.SellerUsername,
-		&i.SellerVerified,
-		&i.Title,
-		&i.Description,
-		&i.PriceMinUnit,
-		&i.Currency,
-		&i.Categories,
-		&i.License,
-		&i.ClientID,
-		&i.TraceID,
-		&i.ThumbnailPath,
-		&i.LastIndexedAt,
-		&i.Status,
-		&i.IsRemixingAllowed,
-		&i.ParentListingID,
-		&i.IsPhysical,
-		&i.TotalWeightGrams,
-		&i.IsAssemblyRequired,
-		&i.IsHardwareRequired,
-		&i.HardwareRequired,
-		&i.IsMulticolor,
-		&i.DimensionsMm,
-		&i.RecommendedNozzleTempC,
-		&i.RecommendedMaterials,
-		&i.IsAiGenerated,
-		&i.AiModelName,
-		&i.LikesCount,
-		&i.DownloadsCount,
-		&i.CommentsCount,
-		&i.IsSaleActive,
-		&i.SalePrice,
-		&i.SaleName,
-		&i.SaleEndTimestamp,
-		&i.SellerRatingAverage,
-		&i.SellerTotalRatings,
-		&i.SellerTotalSales,
-		&i.IsNsfw,
+		&i.UserID,
+		&i.Query,
+		&i.Filters,
+		&i.FilterBy,
+		&i.NotifiedListingIds,
+		&i.LastCheckedAt,
+		&i.NextCheckAt,
 		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.DeletedAt,
 	)
 	return i, err
 }
 
-const getListingByIDWithFiles = `-- name: GetListingByIDWithFiles :one
-SELECT 
-    l.id, l.seller_id, l.seller_name, l.seller_username, l.seller_verified, l.title, l.description, l.price_min_unit, l.currency, l.categories, l.license, l.client_id, l.trace_id, l.thumbnail_path, l.last_indexed_at, l.status, l.is_remixing_allowed, l.parent_listing_id, l.is_physical, l.total_weight_grams, l.is_assembly_required, l.is_hardware_required, l.hardware_required, l.is_multicolor, l.dimensions_mm, l.recommended_nozzle_temp_c, l.recommended_materials, l.is_ai_generated, l.ai_model_name, l.likes_count, l.downloads_count, l.comments_count, l.is_sale_active, l.sale_price, l.sale_name, l.sale_end_timestamp, l.seller_rating_average, l.seller_total_ratings, l.seller_total_sales, l.is_nsfw, l.created_at, l.updated_at, l.deleted_at,
-    COALESCE(
-        json_agg(
-            json_build_object(
-                'id', f.id,
-                'file_path', f.file_path,
-                'file_type', f.file_type,
-                'status', f.status,
-                'error_message', f.error_message,
-                'is_generated', f.is_generated,
-                'source_file_id', f.source_file_id,
-                'size', f.file_size,
-                'metadata', f.metadata
-            )
-        ) FILTER (WHERE f.id IS NOT NULL), 
-        '[]'
-    )::jsonb AS files
+const createSellerPins = `-- name: CreateSellerPins :exec
+INSERT INTO seller_pinned_listings (seller_id, listing_id, position)
+SELECT $1::uuid, t.listing_id, t.position::smallint
+FROM unnest($2::uuid[]) WITH ORDINALITY AS t(listing_id, position)
+`
+
+type CreateSellerPinsParams struct {
+	SellerID   pgtype.UUID   `json:"seller_id"`
+	ListingIds []pgtype.UUID `json:"listing_ids"`
+}
+
+// Positions follow the order of listing_ids, from 1
+func (q *Queries) CreateSellerPins(ctx context.Context, arg CreateSellerPinsParams) error {
+	_, err := q.db.Exec(ctx, createSellerPins, arg.SellerID, arg.ListingIds)
+	return err
+}
+
+const createShortLink = `-- name: CreateShortLink :one
+INSERT INTO short_links (code, listing_id, created_by, campaign)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (code) DO NOTHING
+RETURNING code, listing_id, created_by, campaign, clicks_count, created_at, revoked_at
+`
+
+type CreateShortLinkParams struct {
+	Code      string      `json:"code"`
+	ListingID pgtype.UUID `json:"listing_id"`
+	CreatedBy pgtype.UUID `json:"created_by"`
+	Campaign  pgtype.Text `json:"campaign"`
+}
+
+// Returns no row when the code is taken, the caller draws another
+func (q *Queries) CreateShortLink(ctx context.Context, arg CreateShortLinkParams) (ShortLink, error) {
+	row := q.db.QueryRow(ctx, createShortLink,
+		arg.Code,
+		arg.ListingID,
+		arg.CreatedBy,
+		arg.Campaign,
+	)
+	var i ShortLink
+	err := row.Scan(
+		&i.Code,
+		&i.ListingID,
+		&i.CreatedBy,
+		&i.Campaign,
+		&i.ClicksCount,
+		&i.CreatedAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const createUploadCallback = `-- name: CreateUploadCallback :exec
+INSERT INTO upload_callbacks (storage_key, user_id, callback_url, destination, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (storage_key) DO UPDATE
+    SET callback_url = EXCLUDED.callback_url, destination = EXCLUDED.destination, expires_at = EXCLUDED.expires_at
+    WHERE upload_callbacks.file_id IS NULL
+`
+
+type CreateUploadCallbackParams struct {
+	StorageKey  string             `json:"storage_key"`
+	UserID      pgtype.UUID        `json:"user_id"`
+	CallbackUrl string             `json:"callback_url"`
+	Destination string             `json:"destination"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+}
+
+// Presigning the same key again replaces its callback
+func (q *Queries) CreateUploadCallback(ctx context.Context, arg CreateUploadCallbackParams) error {
+	_, err := q.db.Exec(ctx, createUploadCallback,
+		arg.StorageKey,
+		arg.UserID,
+		arg.CallbackUrl,
+		arg.Destination,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const deleteBannedTerm = `-- name: DeleteBannedTerm :execrows
+DELETE FROM banned_terms
+WHERE term = $1
+`
+
+func (q *Queries) DeleteBannedTerm(ctx context.Context, term string) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteBannedTerm, term)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteFeaturedListing = `-- name: DeleteFeaturedListing :one
+UPDATE featured_listings
+SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING listing_id
+`
+
+// Soft deleted, the listings worker's sweep takes is_featured off the search document if the window had put it there.
+// Returns the listing the window was for, so its search document can be brought up to date now.
+func (q *Queries) DeleteFeaturedListing(ctx context.Context, id pgtype.UUID) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, deleteFeaturedListing, id)
+	var listing_id pgtype.UUID
+	err := row.Scan(&listing_id)
+	return listing_id, err
+}
+
+const deleteListingVariant = `-- name: DeleteListingVariant :execrows
+DELETE FROM listing_variants WHERE id = $1 AND listing_id = $2
+`
+
+type DeleteListingVariantParams struct {
+	ID        pgtype.UUID `json:"id"`
+	ListingID pgtype.UUID `json:"listing_id"`
+}
+
+func (q *Queries) DeleteListingVariant(ctx context.Context, arg DeleteListingVariantParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteListingVariant, arg.ID, arg.ListingID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteListingsBySellerTerms = `-- name: DeleteListingsBySellerTerms :many
+DELETE FROM listings l
+USING sellers s
+WHERE s.user_id = l.seller_id AND s.accepted_terms_version = $1::text
+RETURNING l.id, l.seller_id, l.thumbnail_path
+`
+
+type DeleteListingsBySellerTermsRow struct {
+	ID            pgtype.UUID `json:"id"`
+	SellerID      pgtype.UUID `json:"seller_id"`
+	ThumbnailPath pgtype.Text `json:"thumbnail_path"`
+}
+
+// Not a soft delete, files, status events and everything else hanging off the listings go with them
+func (q *Queries) DeleteListingsBySellerTerms(ctx context.Context, termsVersion string) ([]DeleteListingsBySellerTermsRow, error) {
+	rows, err := q.db.Query(ctx, deleteListingsBySellerTerms, termsVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DeleteListingsBySellerTermsRow
+	for rows.Next() {
+		var i DeleteListingsBySellerTermsRow
+		if err := rows.Scan(&i.ID, &i.SellerID, &i.ThumbnailPath); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deletePublishedOutboxEvents = `-- name: DeletePublishedOutboxEvents :execrows
+DELETE FROM event_outbox WHERE published_at < $1::timestamptz
+`
+
+// JetStream only dedupes within its window, an event published before published_before is of no more use
+func (q *Queries) DeletePublishedOutboxEvents(ctx context.Context, publishedBefore pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePublishedOutboxEvents, publishedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSavedSearch = `-- name: DeleteSavedSearch :execrows
+DELETE FROM saved_searches
+WHERE id = $1 AND user_id = $2
+`
+
+type DeleteSavedSearchParams struct {
+	ID     pgtype.UUID `json:"id"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+func (q *Queries) DeleteSavedSearch(ctx context.Context, arg DeleteSavedSearchParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSavedSearch, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSellerPins = `-- name: DeleteSellerPins :exec
+DELETE FROM seller_pinned_listings WHERE seller_id = $1
+`
+
+func (q *Queries) DeleteSellerPins(ctx context.Context, sellerID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteSellerPins, sellerID)
+	return err
+}
+
+const deleteSellers = `-- name: DeleteSellers :exec
+DELETE FROM sellers
+WHERE user_id = ANY($1::uuid[])
+`
+
+func (q *Queries) DeleteSellers(ctx context.Context, userIds []pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteSellers, userIds)
+	return err
+}
+
+const endSellerVacation = `-- name: EndSellerVacation :one
+UPDATE sellers
+SET vacation_starts_at = LEAST(vacation_starts_at, CURRENT_TIMESTAMP), vacation_ends_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND vacation_ends_at > CURRENT_TIMESTAMP
+RETURNING user_id, display_name, country, payout_status, accepted_terms_version, accepted_terms_at, created_at, updated_at, vacation_starts_at, vacation_ends_at, vacation_message, vacation_applied, last_active_at
+`
+
+// Moves a current or upcoming vacation's end to now, the listings worker then reindexes the listings and clears it
+func (q *Queries) EndSellerVacation(ctx context.Context, userID pgtype.UUID) (Seller, error) {
+	row := q.db.QueryRow(ctx, endSellerVacation, userID)
+	var i Seller
+	err := row.Scan(
+		&i.UserID,
+		&i.DisplayName,
+		&i.Country,
+		&i.PayoutStatus,
+		&i.AcceptedTermsVersion,
+		&i.AcceptedTermsAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VacationStartsAt,
+		&i.VacationEndsAt,
+		&i.VacationMessage,
+		&i.VacationApplied,
+		&i.LastActiveAt,
+	)
+	return i, err
+}
+
+const getCategoryDefaults = `-- name: GetCategoryDefaults :one
+SELECT category, recommended_materials, nozzle_temp_min_c, nozzle_temp_max_c, typical_dimensions_mm, updated_by, updated_at FROM category_defaults
+WHERE category = $1
+`
+
+func (q *Queries) GetCategoryDefaults(ctx context.Context, category string) (CategoryDefault, error) {
+	row := q.db.QueryRow(ctx, getCategoryDefaults, category)
+	var i CategoryDefault
+	err := row.Scan(
+		&i.Category,
+		&i.RecommendedMaterials,
+		&i.NozzleTempMinC,
+		&i.NozzleTempMaxC,
+		&i.TypicalDimensionsMm,
+		&i.UpdatedBy,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getFileVariantPrice = `-- name: GetFileVariantPrice :one
+SELECT v.price_min_unit FROM listing_variant_files vf
+JOIN listing_variants v ON v.id = vf.variant_id
+WHERE vf.file_id = $1
+ORDER BY v.price_min_unit
+LIMIT 1
+`
+
+// What the cheapest variant including the file sells for, no row when no variant includes it
+func (q *Queries) GetFileVariantPrice(ctx context.Context, fileID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, getFileVariantPrice, fileID)
+	var price_min_unit int64
+	err := row.Scan(&price_min_unit)
+	return price_min_unit, err
+}
+
+const getFilesByListingID = `-- name: GetFilesByListingID :many
+SELECT id, listing_id, file_path, file_type, file_size, metadata, status, error_message, is_generated, source_file_id, created_at, updated_at, deleted_at FROM listing_files 
+WHERE listing_id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) GetFilesByListingID(ctx context.Context, listingID pgtype.UUID) ([]ListingFile, error) {
+	rows, err := q.db.Query(ctx, getFilesByListingID, listingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListingFile
+	for rows.Next() {
+		var i ListingFile
+		if err := rows.Scan(
+			&i.ID,
+			&i.ListingID,
+			&i.FilePath,
+			&i.FileType,
+			&i.FileSize,
+			&i.Metadata,
+			&i.Status,
+			&i.ErrorMessage,
+			&i.IsGenerated,
+			&i.SourceFileID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getIndexFailuresBySeller = `-- name: GetIndexFailuresBySeller :many
+SELECT f.listing_id, f.error_class, f.error, f.attempts, f.failed_at FROM listing_index_failures f
+JOIN listings l ON l.id = f.listing_id
+WHERE l.seller_id = $1
+`
+
+// The seller's listings that aren't in search, shown next to each of them
+func (q *Queries) GetIndexFailuresBySeller(ctx context.Context, sellerID pgtype.UUID) ([]ListingIndexFailure, error) {
+	rows, err := q.db.Query(ctx, getIndexFailuresBySeller, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListingIndexFailure
+	for rows.Next() {
+		var i ListingIndexFailure
+		if err := rows.Scan(
+			&i.ListingID,
+			&i.ErrorClass,
+			&i.Error,
+			&i.Attempts,
+			&i.FailedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getListingAvailability = `-- name: GetListingAvailability :one
+SELECT l.seller_id, l.price_min_unit, s.vacation_starts_at, s.vacation_ends_at
 FROM listings l
-LEFT JOIN listing_files f ON l.id = f.listing_id AND f.deleted_at IS NULL
+LEFT JOIN sellers s ON s.user_id = l.seller_id
 WHERE l.id = $1 AND l.deleted_at IS NULL
-GROUP BY l.id
-ORDER BY l.created_at DESC
 `
 
-type GetListingByIDWithFilesRow struct {
-	ID                     pgtype.UUID        `json:"id"`
-	SellerID               pgtype.UUID        `json:"seller_id"`
-	SellerName             string             `json:"seller_name"`
-	SellerUsername         string             `json:"seller_username"`
-	SellerVerified         bool               `json:"seller_verified"`
-	Title                  string             `json:"title"`
-	Description            pgtype.Text        `json:"description"`
-	PriceMinUnit           int64              `json:"price_min_unit"`
-	Currency               string             `json:"currency"`
-	Categories             []string           `json:"categories"`
-	License                string             `json:"license"`
-	ClientID               string             `json:"client_id"`
-	TraceID                string             `json:"trace_id"`
-	ThumbnailPath          pgtype.Text        `json:"thumbnail_path"`
-	LastIndexedAt          pgtype.Timestamptz `json:"last_indexed_at"`
-	Status                 NullListingStatus  `json:"status"`
-	IsRemixingAllowed      bool               `json:"is_remixing_allowed"`
-	ParentListingID        pgtype.UUID        `json:"parent_listing_id"`
-	IsPhysical             bool               `json:"is_physical"`
-	TotalWeightGrams       pgtype.Int4        `json:"total_weight_grams"`
-	IsAssemblyRequired     bool               `json:"is_assembly_required"`
-	IsHardwareRequired     bool               `json:"is_hardware_required"`
-	HardwareRequired       []string           `json:"hardware_required"`
-	IsMulticolor           bool               `json:"is_multicolor"`
-	DimensionsMm           []byte             `json:"dimensions_mm"`
-	RecommendedNozzleTempC pgtype.Int4        `json:"recommended_nozzle_temp_c"`
-	RecommendedMaterials   []string           `json:"recommended_materials"`
-	IsAiGenerated          bool               `json:"is_ai_generated"`
-	AiModelName            pgtype.Text        `json:"ai_model_name"`
-	LikesCount             pgtype.Int4        `json:"likes_count"`
-	DownloadsCount         pgtype.Int4        `json:"downloads_count"`
-	CommentsCount          pgtype.Int4        `json:"comments_count"`
-	IsSaleActive           bool               `json:"is_sale_active"`
-	SalePrice              pgtype.Numeric     `json:"sale_price"`
-	SaleName               pgtype.Text        `json:"sale_name"`
-	SaleEndTimestamp       pgtype.Timestamptz `json:"sale_end_timestamp"`
-	SellerRatingAverage    pgtype.Numeric     `json:"seller_rating_average"`
-	SellerTotalRatings     pgtype.Int4        `json:"seller_total_ratings"`
-	SellerTotalSales       pgtype.Int4        `json:"seller_total_sales"`
-	IsNsfw                 bool               `json:"is_nsfw"`
-	CreatedAt              pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt              pgtype.Timestamptz `json:"updated_at"`
-	DeletedAt              pgtype.Timestamptz `json:"deleted_at"`
-	Files                  []byte             `json:"files"`
+type GetListingAvailabilityRow struct {
+	SellerID         pgtype.UUID        `json:"seller_id"`
+	PriceMinUnit     int64              `json:"price_min_unit"`
+	VacationStartsAt pgtype.Timestamptz `json:"vacation_starts_at"`
+	VacationEndsAt   pgtype.Timestamptz `json:"vacation_ends_at"`
 }
 
-func (q *Queries) GetListingByIDWithFiles(ctx context.Context, id pgtype.UUID) (GetListingByIDWithFilesRow, error) {
-	row := q.db.QueryRow(ctx, getListingByIDWithFiles, id)
-	var i GetListingByIDWithFilesRow
+// The listing's price and whether its seller is away, a seller without a profile never is
+func (q *Queries) GetListingAvailability(ctx context.Context, id pgtype.UUID) (GetListingAvailabilityRow, error) {
+	row := q.db.QueryRow(ctx, getListingAvailability, id)
+	var i GetListingAvailabilityRow
+	err := row.Scan(
+		&i.SellerID,
+		&i.PriceMinUnit,
+		&i.VacationStartsAt,
+		&i.VacationEndsAt,
+	)
+	return i, err
+}
+
+const getListingByID = `-- name: GetListingByID :one
+SELECT id, seller_id, seller_name, seller_username, seller_verified, title, description, price_min_unit, currency, categories, license, thumbnail_path, last_indexed_at, status, is_remixing_allowed, parent_listing_id, is_physical, total_weight_grams, is_assembly_required, is_hardware_required, hardware_required, is_multicolor, dimensions_mm, recommended_nozzle_temp_c, recommended_materials, is_ai_generated, ai_model_name, likes_count, downloads_count, comments_count, is_sale_active, sale_price, sale_name, sale_end_timestamp, seller_rating_average, seller_total_ratings, seller_total_sales, is_nsfw, created_at, updated_at, deleted_at, views_count, nozzle_diameter_mm, language FROM listings 
+    WHERE id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) GetListingByID(ctx context.Context, id pgtype.UUID) (Listing, error) {
+	row := q.db.QueryRow(ctx, getListingByID, id)
+	var i Listing
 	err := row.Scan(
 		&i.ID,
 		&i.SellerID,
@@ -503,8 +1109,6 @@ func (q *Queries) GetListingByIDWithFiles(ctx context.Context, id pgtype.UUID) (
 		&i.Currency,
 		&i.Categories,
 		&i.License,
-		&i.ClientID,
-		&i.TraceID,
 		&i.ThumbnailPath,
 		&i.LastIndexedAt,
 		&i.Status,
@@ -535,38 +1139,163 @@ func (q *Queries) GetListingByIDWithFiles(ctx context.Context, id pgtype.UUID) (
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
-		&i.Files,
+		&i.ViewsCount,
+		&i.NozzleDiameterMm,
+		&i.Language,
 	)
 	return i, err
 }
 
-const getListingsBySellerID = `-- name: GetListingsBySellerID :many
-SELECT 
-    l.id, l.seller_id, l.seller_name, l.seller_username, l.seller_verified, l.title, l.description, l.price_min_unit, l.currency, l.categories, l.license, l.client_id, l.trace_id, l.thumbnail_path, l.last_indexed_at, l.status, l.is_remixing_allowed, l.parent_listing_id, l.is_physical, l.total_weight_grams, l.is_assembly_required, l.is_hardware_required, l.hardware_required, l.is_multicolor, l.dimensions_mm, l.recommended_nozzle_temp_c, l.recommended_materials, l.is_ai_generated, l.ai_model_name, l.likes_count, l.downloads_count, l.comments_count, l.is_sale_active, l.sale_price, l.sale_name, l.sale_end_timestamp, l.seller_rating_average, l.seller_total_ratings, l.seller_total_sales, l.is_nsfw, l.created_at, l.updated_at, l.deleted_at,
-    COALESCE(
-        json_agg(
-            json_build_object(
-                'id', f.id,
-                'file_path', f.file_path,
-                'file_type', f.file_type,
-                'status', f.status,
-                'error_message', f.error_message,
-                'is_generated', f.is_generated,
-                'source_file_id', f.source_file_id,
-                'size', f.file_size,
-                'metadata', f.metadata
-            )
-        ) FILTER (WHERE f.id IS NOT NULL), 
-        '[]'
-    )::jsonb AS files
-FROM listings l
-LEFT JOIN listing_files f ON l.id = f.listing_id AND f.deleted_at IS NULL
-WHERE l.seller_id = $1 AND l.deleted_at IS NULL
-GROUP BY l.id
-ORDER BY l.created_at DESC
+const getListingByIDForUpdate = `-- name: GetListingByIDForUpdate :one
+SELECT id, seller_id, seller_name, seller_username, seller_verified, title, description, price_min_unit, currency, categories, license, thumbnail_path, last_indexed_at, status, is_remixing_allowed, parent_listing_id, is_physical, total_weight_grams, is_assembly_required, is_hardware_required, hardware_required, is_multicolor, dimensions_mm, recommended_nozzle_temp_c, recommended_materials, is_ai_generated, ai_model_name, likes_count, downloads_count, comments_count, is_sale_active, sale_price, sale_name, sale_end_timestamp, seller_rating_average, seller_total_ratings, seller_total_sales, is_nsfw, created_at, updated_at, deleted_at, views_count, nozzle_diameter_mm, language FROM listings
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE
 `
 
-type GetListingsBySellerIDRow struct {
+// Locks the row until the transaction ends, the validation worker takes the same lock before it changes the status
+func (q *Queries) GetListingByIDForUpdate(ctx context.Context, id pgtype.UUID) (Listing, error) {
+	row := q.db.QueryRow(ctx, getListingByIDForUpdate, id)
+	var i Listing
+	err := row.Scan(
+		&i.ID,
+		&i.SellerID,
+		&i.SellerName,
+		&i.SellerUsername,
+		&i.SellerVerified,
+		&i.Title,
+		&i.Description,
+		&i.PriceMinUnit,
+		&i.Currency,
+		&i.Categories,
+		&i.License,
+		&i.ThumbnailPath,
+		&i.LastIndexedAt,
+		&i.Status,
+		&i.IsRemixingAllowed,
+		&i.ParentListingID,
+		&i.IsPhysical,
+		&i.TotalWeightGrams,
+		&i.IsAssemblyRequired,
+		&i.IsHardwareRequired,
+		&i.HardwareRequired,
+		&i.IsMulticolor,
+		&i.DimensionsMm,
+		&i.RecommendedNozzleTempC,
+		&i.RecommendedMaterials,
+		&i.IsAiGenerated,
+		&i.AiModelName,
+		&i.LikesCount,
+		&i.DownloadsCount,
+		&i.CommentsCount,
+		&i.IsSaleActive,
+		&i.SalePrice,
+		&i.SaleName,
+		&i.SaleEndTimestamp,
+		&i.SellerRatingAverage,
+		&i.SellerTotalRatings,
+		&i.SellerTotalSales,
+		&i.IsNsfw,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ViewsCount,
+		&i.NozzleDiameterMm,
+		&i.Language,
+	)
+	return i, err
+}
+
+const getListingByIDIncludingDeleted = `-- name: GetListingByIDIncludingDeleted :one
+SELECT id, seller_id, seller_name, seller_username, seller_verified, title, description, price_min_unit, currency, categories, license, thumbnail_path, last_indexed_at, status, is_remixing_allowed, parent_listing_id, is_physical, total_weight_grams, is_assembly_required, is_hardware_required, hardware_required, is_multicolor, dimensions_mm, recommended_nozzle_temp_c, recommended_materials, is_ai_generated, ai_model_name, likes_count, downloads_count, comments_count, is_sale_active, sale_price, sale_name, sale_end_timestamp, seller_rating_average, seller_total_ratings, seller_total_sales, is_nsfw, created_at, updated_at, deleted_at, views_count, nozzle_diameter_mm, language FROM listings WHERE id = $1
+`
+
+// For restore and moderation only, everything else must go through a query that filters deleted_at
+func (q *Queries) GetListingByIDIncludingDeleted(ctx context.Context, id pgtype.UUID) (Listing, error) {
+	row := q.db.QueryRow(ctx, getListingByIDIncludingDeleted, id)
+	var i Listing
+	err := row.Scan(
+		&i.ID,
+		&i.SellerID,
+		&i.SellerName,
+		&i.SellerUsername,
+		&i.SellerVerified,
+		&i.Title,
+		&i.Description,
+		&i.PriceMinUnit,
+		&i.Currency,
+		&i.Categories,
+		&i.License,
+		&i.ThumbnailPath,
+		&i.LastIndexedAt,
+		&i.Status,
+		&i.IsRemixingAllowed,
+		&i.ParentListingID,
+		&i.IsPhysical,
+		&i.TotalWeightGrams,
+		&i.IsAssemblyRequired,
+		&i.IsHardwareRequired,
+		&i.HardwareRequired,
+		&i.IsMulticolor,
+		&i.DimensionsMm,
+		&i.RecommendedNozzleTempC,
+		&i.RecommendedMaterials,
+		&i.IsAiGenerated,
+		&i.AiModelName,
+		&i.LikesCount,
+		&i.DownloadsCount,
+		&i.CommentsCount,
+		&i.IsSaleActive,
+		&i.SalePrice,
+		&i.SaleName,
+		&i.SaleEndTimestamp,
+		&i.SellerRatingAverage,
+		&i.SellerTotalRatings,
+		&i.SellerTotalSales,
+		&i.IsNsfw,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ViewsCount,
+		&i.NozzleDiameterMm,
+		&i.Language,
+	)
+	return i, err
+}
+
+const getListingByIDWithFiles = `-- name: GetListingByIDWithFiles :one
+SELECT 
+    l.id, l.seller_id, l.seller_name, l.seller_username, l.seller_verified, l.title, l.description, l.price_min_unit, l.currency, l.categories, l.license, l.thumbnail_path, l.last_indexed_at, l.status, l.is_remixing_allowed, l.parent_listing_id, l.is_physical, l.total_weight_grams, l.is_assembly_required, l.is_hardware_required, l.hardware_required, l.is_multicolor, l.dimensions_mm, l.recommended_nozzle_temp_c, l.recommended_materials, l.is_ai_generated, l.ai_model_name, l.likes_count, l.downloads_count, l.comments_count, l.is_sale_active, l.sale_price, l.sale_name, l.sale_end_timestamp, l.seller_rating_average, l.seller_total_ratings, l.seller_total_sales, l.is_nsfw, l.created_at, l.updated_at, l.deleted_at, l.views_count, l.nozzle_diameter_mm, l.language,
+    COALESCE(
+        json_agg(
+            json_build_object(
+                'id', f.id,
+                'file_path', f.file_path,
+                'file_type', f.file_type,
+                'status', f.status,
+                'error_message', f.error_message,
+                'is_generated', f.is_generated,
+                'source_file_id', f.source_file_id,
+                'size', f.file_size,
+                'metadata', f.metadata
+            )
+        ) FILTER (WHERE f.id IS NOT NULL), 
+        '[]'
+    )::jsonb AS files,
+    -- Why the listing is in its current status, shown to the seller when it failed
+    (
+        SELECT e.reason FROM listing_status_events e
+        WHERE e.listing_id = l.id
+        ORDER BY e.created_at DESC, e.id DESC
+        LIMIT 1
+    ) AS status_reason
+FROM listings l
+LEFT JOIN listing_files f ON l.id = f.listing_id AND f.deleted_at IS NULL
+WHERE l.id = $1 AND l.deleted_at IS NULL
+GROUP BY l.id
+ORDER BY l.created_at DESC
+`
+
+type GetListingByIDWithFilesRow struct {
 	ID                     pgtype.UUID        `json:"id"`
 	SellerID               pgtype.UUID        `json:"seller_id"`
 	SellerName             string             `json:"seller_name"`
@@ -578,8 +1307,6 @@ type GetListingsBySellerIDRow struct {
 	Currency               string             `json:"currency"`
 	Categories             []string           `json:"categories"`
 	License                string             `json:"license"`
-	ClientID               string             `json:"client_id"`
-	TraceID                string             `json:"trace_id"`
 	ThumbnailPath          pgtype.Text        `json:"thumbnail_path"`
 	LastIndexedAt          pgtype.Timestamptz `json:"last_indexed_at"`
 	Status                 NullListingStatus  `json:"status"`
@@ -596,11 +1323,11 @@ type GetListingsBySellerIDRow struct {
 	RecommendedMaterials   []string           `json:"recommended_materials"`
 	IsAiGenerated          bool               `json:"is_ai_generated"`
 	AiModelName            pgtype.Text        `json:"ai_model_name"`
-	LikesCount             pgtype.Int4        `json:"likes_count"`
-	DownloadsCount         pgtype.Int4        `json:"downloads_count"`
-	CommentsCount          pgtype.Int4        `json:"comments_count"`
+	LikesCount             int32              `json:"likes_count"`
+	DownloadsCount         int32              `json:"downloads_count"`
+	CommentsCount          int32              `json:"comments_count"`
 	IsSaleActive           bool               `json:"is_sale_active"`
-	SalePrice              pgtype.Numeric     `json:"sale_price"`
+	SalePrice              pgtype.Int8        `json:"sale_price"`
 	SaleName               pgtype.Text        `json:"sale_name"`
 	SaleEndTimestamp       pgtype.Timestamptz `json:"sale_end_timestamp"`
 	SellerRatingAverage    pgtype.Numeric     `json:"seller_rating_average"`
@@ -610,63 +1337,1830 @@ type GetListingsBySellerIDRow struct {
 	CreatedAt              pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt              pgtype.Timestamptz `json:"updated_at"`
 	DeletedAt              pgtype.Timestamptz `json:"deleted_at"`
+	ViewsCount             int32              `json:"views_count"`
+	NozzleDiameterMm       pgtype.Numeric     `json:"nozzle_diameter_mm"`
+	Language               string             `json:"language"`
 	Files                  []byte             `json:"files"`
+	StatusReason           pgtype.Text        `json:"status_reason"`
 }
 
-func (q *Queries) GetListingsBySellerID(ctx context.Context, sellerID pgtype.UUID) ([]GetListingsBySellerIDRow, error) {
-	rows, err := q.db.Query(ctx, getListingsBySellerID, sellerID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetListingsBySellerIDRow
-	for rows.Next() {
-		var i GetListingsBySellerIDRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.SellerID,
-			&i.SellerName,
-			&i.SellerUsername,
-			&i.SellerVerified,
-			&i.Title,
-			&i.Description,
-			&i.PriceMinUnit,
-			&i.Currency,
-			&i.Categories,
-			&i.License,
-			&i.ClientID,
-			&i.TraceID,
-			&i.ThumbnailPath,
-			&i.LastIndexedAt,
-			&i.Status,
-			&i.IsRemixingAllowed,
-			&i.ParentListingID,
-			&i.IsPhysical,
-			&i.TotalWeightGrams,
-			&i.IsAssemblyRequired,
-			&i.IsHardwareRequired,
-			&i.HardwareRequired,
-			&i.IsMulticolor,
-			&i.DimensionsMm,
-			&i.RecommendedNozzleTempC,
-			&i.RecommendedMaterials,
-			&i.IsAiGenerated,
-			&i.AiModelName,
-			&i.LikesCount,
-			&i.DownloadsCount,
-			&i.CommentsCount,
-			&i.IsSaleActive,
-			&i.SalePrice,
-			&i.SaleName,
-			&i.SaleEndTimestamp,
-			&i.SellerRatingAverage,
-			&i.SellerTotalRatings,
-			&i.SellerTotalSales,
-			&i.IsNsfw,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.DeletedAt,
-			&i.Files,
+func (q *Queries) GetListingByIDWithFiles(ctx context.Context, id pgtype.UUID) (GetListingByIDWithFilesRow, error) {
+	row := q.db.QueryRow(ctx, getListingByIDWithFiles, id)
+	var i GetListingByIDWithFilesRow
+	err := row.Scan(
+		&i.ID,
+		&i.SellerID,
+		&i.SellerName,
+		&i.SellerUsername,
+		&i.SellerVerified,
+		&i.Title,
+		&i.Description,
+		&i.PriceMinUnit,
+		&i.Currency,
+		&i.Categories,
+		&i.License,
+		&i.ThumbnailPath,
+		&i.LastIndexedAt,
+		&i.Status,
+		&i.IsRemixingAllowed,
+		&i.ParentListingID,
+		&i.IsPhysical,
+		&i.TotalWeightGrams,
+		&i.IsAssemblyRequired,
+		&i.IsHardwareRequired,
+		&i.HardwareRequired,
+		&i.IsMulticolor,
+		&i.DimensionsMm,
+		&i.RecommendedNozzleTempC,
+		&i.RecommendedMaterials,
+		&i.IsAiGenerated,
+		&i.AiModelName,
+		&i.LikesCount,
+		&i.DownloadsCount,
+		&i.CommentsCount,
+		&i.IsSaleActive,
+		&i.SalePrice,
+		&i.SaleName,
+		&i.SaleEndTimestamp,
+		&i.SellerRatingAverage,
+		&i.SellerTotalRatings,
+		&i.SellerTotalSales,
+		&i.IsNsfw,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ViewsCount,
+		&i.NozzleDiameterMm,
+		&i.Language,
+		&i.Files,
+		&i.StatusReason,
+	)
+	return i, err
+}
+
+const getListingByIDWithFilesIncludingDeleted = `-- name: GetListingByIDWithFilesIncludingDeleted :one
+SELECT 
+    l.id, l.seller_id, l.seller_name, l.seller_username, l.seller_verified, l.title, l.description, l.price_min_unit, l.currency, l.categories, l.license, l.thumbnail_path, l.last_indexed_at, l.status, l.is_remixing_allowed, l.parent_listing_id, l.is_physical, l.total_weight_grams, l.is_assembly_required, l.is_hardware_required, l.hardware_required, l.is_multicolor, l.dimensions_mm, l.recommended_nozzle_temp_c, l.recommended_materials, l.is_ai_generated, l.ai_model_name, l.likes_count, l.downloads_count, l.comments_count, l.is_sale_active, l.sale_price, l.sale_name, l.sale_end_timestamp, l.seller_rating_average, l.seller_total_ratings, l.seller_total_sales, l.is_nsfw, l.created_at, l.updated_at, l.deleted_at, l.views_count, l.nozzle_diameter_mm, l.language,
+    COALESCE(
+        json_agg(
+            json_build_object(
+                'id', f.id,
+                'file_path', f.file_path,
+                'file_type', f.file_type,
+                'status', f.status,
+                'error_message', f.error_message,
+                'is_generated', f.is_generated,
+                'source_file_id', f.source_file_id,
+                'size', f.file_size,
+                'metadata', f.metadata
+            )
+        ) FILTER (WHERE f.id IS NOT NULL), 
+        '[]'
+    )::jsonb AS files,
+    -- Why the listing is in its current status, shown to the seller when it failed
+    (
+        SELECT e.reason FROM listing_status_events e
+        WHERE e.listing_id = l.id
+        ORDER BY e.created_at DESC, e.id DESC
+        LIMIT 1
+    ) AS status_reason
+FROM listings l
+LEFT JOIN listing_files f ON l.id = f.listing_id AND f.deleted_at IS NULL
+WHERE l.id = $1
+GROUP BY l.id
+`
+
+type GetListingByIDWithFilesIncludingDeletedRow struct {
+	ID                     pgtype.UUID        `json:"id"`
+	SellerID               pgtype.UUID        `json:"seller_id"`
+	SellerName             string             `json:"seller_name"`
+	SellerUsername         string             `json:"seller_username"`
+	SellerVerified         bool               `json:"seller_verified"`
+	Title                  string             `json:"title"`
+	Description            pgtype.Text        `json:"description"`
+	PriceMinUnit           int64              `json:"price_min_unit"`
+	Currency               string             `json:"currency"`
+	Categories             []string           `json:"categories"`
+	License                string             `json:"license"`
+	ThumbnailPath          pgtype.Text        `json:"thumbnail_path"`
+	LastIndexedAt          pgtype.Timestamptz `json:"last_indexed_at"`
+	Status                 NullListingStatus  `json:"status"`
+	IsRemixingAllowed      bool               `json:"is_remixing_allowed"`
+	ParentListingID        pgtype.UUID        `json:"parent_listing_id"`
+	IsPhysical             bool               `json:"is_physical"`
+	TotalWeightGrams       pgtype.Int4        `json:"total_weight_grams"`
+	IsAssemblyRequired     bool               `json:"is_assembly_required"`
+	IsHardwareRequired     bool               `json:"is_hardware_required"`
+	HardwareRequired       []string           `json:"hardware_required"`
+	IsMulticolor           bool               `json:"is_multicolor"`
+	DimensionsMm           []byte             `json:"dimensions_mm"`
+	RecommendedNozzleTempC pgtype.Int4        `json:"recommended_nozzle_temp_c"`
+	RecommendedMaterials   []string           `json:"recommended_materials"`
+	IsAiGenerated          bool               `json:"is_ai_generated"`
+	AiModelName            pgtype.Text        `json:"ai_model_name"`
+	LikesCount             int32              `json:"likes_count"`
+	DownloadsCount         int32              `json:"downloads_count"`
+	CommentsCount          int32              `json:"comments_count"`
+	IsSaleActive           bool               `json:"is_sale_active"`
+	SalePrice              pgtype.Int8        `json:"sale_price"`
+	SaleName               pgtype.Text        `json:"sale_name"`
+	SaleEndTimestamp       pgtype.Timestamptz `json:"sale_end_timestamp"`
+	SellerRatingAverage    pgtype.Numeric     `json:"seller_rating_average"`
+	SellerTotalRatings     pgtype.Int4        `json:"seller_total_ratings"`
+	SellerTotalSales       pgtype.Int4        `json:"seller_total_sales"`
+	IsNsfw                 bool               `json:"is_nsfw"`
+	CreatedAt              pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt              pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt              pgtype.Timestamptz `json:"deleted_at"`
+	ViewsCount             int32              `json:"views_count"`
+	NozzleDiameterMm       pgtype.Numeric     `json:"nozzle_diameter_mm"`
+	Language               string             `json:"language"`
+	Files                  []byte             `json:"files"`
+	StatusReason           pgtype.Text        `json:"status_reason"`
+}
+
+// GetListingByIDWithFiles for moderators, soft-deleted listings included
+func (q *Queries) GetListingByIDWithFilesIncludingDeleted(ctx context.Context, id pgtype.UUID) (GetListingByIDWithFilesIncludingDeletedRow, error) {
+	row := q.db.QueryRow(ctx, getListingByIDWithFilesIncludingDeleted, id)
+	var i GetListingByIDWithFilesIncludingDeletedRow
+	err := row.Scan(
+		&i.ID,
+		&i.SellerID,
+		&i.SellerName,
+		&i.SellerUsername,
+		&i.SellerVerified,
+		&i.Title,
+		&i.Description,
+		&i.PriceMinUnit,
+		&i.Currency,
+		&i.Categories,
+		&i.License,
+		&i.ThumbnailPath,
+		&i.LastIndexedAt,
+		&i.Status,
+		&i.IsRemixingAllowed,
+		&i.ParentListingID,
+		&i.IsPhysical,
+		&i.TotalWeightGrams,
+		&i.IsAssemblyRequired,
+		&i.IsHardwareRequired,
+		&i.HardwareRequired,
+		&i.IsMulticolor,
+		&i.DimensionsMm,
+		&i.RecommendedNozzleTempC,
+		&i.RecommendedMaterials,
+		&i.IsAiGenerated,
+		&i.AiModelName,
+		&i.LikesCount,
+		&i.DownloadsCount,
+		&i.CommentsCount,
+		&i.IsSaleActive,
+		&i.SalePrice,
+		&i.SaleName,
+		&i.SaleEndTimestamp,
+		&i.SellerRatingAverage,
+		&i.SellerTotalRatings,
+		&i.SellerTotalSales,
+		&i.IsNsfw,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.ViewsCount,
+		&i.NozzleDiameterMm,
+		&i.Language,
+		&i.Files,
+		&i.StatusReason,
+	)
+	return i, err
+}
+
+const getListingFileForDownload = `-- name: GetListingFileForDownload :one
+SELECT f.id, f.listing_id, f.file_path, f.file_type, f.file_size, f.metadata, f.status, f.error_message, f.is_generated, f.source_file_id, f.created_at, f.updated_at, f.deleted_at FROM listing_files f
+JOIN listings l ON l.id = f.listing_id AND l.deleted_at IS NULL
+WHERE f.id = $1 AND f.listing_id = $2 AND f.status = 'VALID' AND f.deleted_at IS NULL
+`
+
+type GetListingFileForDownloadParams struct {
+	ID        pgtype.UUID `json:"id"`
+	ListingID pgtype.UUID `json:"listing_id"`
+}
+
+// Only files that passed validation, on a listing that hasn't been deleted
+func (q *Queries) GetListingFileForDownload(ctx context.Context, arg GetListingFileForDownloadParams) (ListingFile, error) {
+	row := q.db.QueryRow(ctx, getListingFileForDownload, arg.ID, arg.ListingID)
+	var i ListingFile
+	err := row.Scan(
+		&i.ID,
+		&i.ListingID,
+		&i.FilePath,
+		&i.FileType,
+		&i.FileSize,
+		&i.Metadata,
+		&i.Status,
+		&i.ErrorMessage,
+		&i.IsGenerated,
+		&i.SourceFileID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getListingFileForUpdate = `-- name: GetListingFileForUpdate :one
+SELECT id, listing_id, file_path, file_type, file_size, metadata, status, error_message, is_generated, source_file_id, created_at, updated_at, deleted_at FROM listing_files
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE
+`
+
+func (q *Queries) GetListingFileForUpdate(ctx context.Context, id pgtype.UUID) (ListingFile, error) {
+	row := q.db.QueryRow(ctx, getListingFileForUpdate, id)
+	var i ListingFile
+	err := row.Scan(
+		&i.ID,
+		&i.ListingID,
+		&i.FilePath,
+		&i.FileType,
+		&i.FileSize,
+		&i.Metadata,
+		&i.Status,
+		&i.ErrorMessage,
+		&i.IsGenerated,
+		&i.SourceFileID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getListingForFileForUpdate = `-- name: GetListingForFileForUpdate :one
+SELECT l.id, l.status, l.deleted_at FROM listings l
+JOIN listing_files f ON f.listing_id = l.id
+WHERE f.id = $1
+FOR UPDATE OF l
+`
+
+type GetListingForFileForUpdateRow struct {
+	ID        pgtype.UUID        `json:"id"`
+	Status    NullListingStatus  `json:"status"`
+	DeletedAt pgtype.Timestamptz `json:"deleted_at"`
+}
+
+// Locks the listing a file belongs to before the file itself, the same order as listing edits, so a validation result
+// and an edit can't deadlock. Deleted listings are returned too, their files still take results.
+func (q *Queries) GetListingForFileForUpdate(ctx context.Context, id pgtype.UUID) (GetListingForFileForUpdateRow, error) {
+	row := q.db.QueryRow(ctx, getListingForFileForUpdate, id)
+	var i GetListingForFileForUpdateRow
+	err := row.Scan(&i.ID, &i.Status, &i.DeletedAt)
+	return i, err
+}
+
+const getListingIDsBySellerTerms = `-- name: GetListingIDsBySellerTerms :many
+SELECT l.id FROM listings l
+JOIN sellers s ON s.user_id = l.seller_id
+WHERE s.accepted_terms_version = $1::text
+ORDER BY l.created_at, l.id
+`
+
+// Dev seed data, see internal/seed. Seeded listings are the ones by sellers who accepted its terms version.
+func (q *Queries) GetListingIDsBySellerTerms(ctx context.Context, termsVersion string) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, getListingIDsBySellerTerms, termsVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getListingStatusEvents = `-- name: GetListingStatusEvents :many
+SELECT id, listing_id, actor, actor_id, from_status, to_status, reason, created_at FROM listing_status_events
+WHERE listing_id = $1
+ORDER BY created_at, id
+`
+
+type GetListingStatusEventsRow struct {
+	ID         pgtype.UUID        `json:"id"`
+	ListingID  pgtype.UUID        `json:"listing_id"`
+	Actor      ListingStatusActor `json:"actor"`
+	ActorID    pgtype.UUID        `json:"actor_id"`
+	FromStatus NullListingStatus  `json:"from_status"`
+	ToStatus   ListingStatus      `json:"to_status"`
+	Reason     pgtype.Text        `json:"reason"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+// Everything but the client and trace IDs, which are for debugging rather than the seller's history
+func (q *Queries) GetListingStatusEvents(ctx context.Context, listingID pgtype.UUID) ([]GetListingStatusEventsRow, error) {
+	rows, err := q.db.Query(ctx, getListingStatusEvents, listingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetListingStatusEventsRow
+	for rows.Next() {
+		var i GetListingStatusEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ListingID,
+			&i.Actor,
+			&i.ActorID,
+			&i.FromStatus,
+			&i.ToStatus,
+			&i.Reason,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getListingSummaries = `-- name: GetListingSummaries :many
+SELECT id, title, seller_username, thumbnail_path, price_min_unit, currency, deleted_at FROM listings
+WHERE id = ANY($1::uuid[])
+`
+
+type GetListingSummariesRow struct {
+	ID             pgtype.UUID        `json:"id"`
+	Title          string             `json:"title"`
+	SellerUsername string             `json:"seller_username"`
+	ThumbnailPath  pgtype.Text        `json:"thumbnail_path"`
+	PriceMinUnit   int64              `json:"price_min_unit"`
+	Currency       string             `json:"currency"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
+}
+
+// Enough of a batch of listings to show them in a list, deleted ones included so history can still name them
+func (q *Queries) GetListingSummaries(ctx context.Context, ids []pgtype.UUID) ([]GetListingSummariesRow, error) {
+	rows, err := q.db.Query(ctx, getListingSummaries, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetListingSummariesRow
+	for rows.Next() {
+		var i GetListingSummariesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.SellerUsername,
+			&i.ThumbnailPath,
+			&i.PriceMinUnit,
+			&i.Currency,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getListingsByIDsWithFiles = `-- name: GetListingsByIDsWithFiles :many
+SELECT 
+    l.id, l.seller_id, l.seller_name, l.seller_username, l.seller_verified, l.title, l.description, l.price_min_unit, l.currency, l.categories, l.license, l.thumbnail_path, l.last_indexed_at, l.status, l.is_remixing_allowed, l.parent_listing_id, l.is_physical, l.total_weight_grams, l.is_assembly_required, l.is_hardware_required, l.hardware_required, l.is_multicolor, l.dimensions_mm, l.recommended_nozzle_temp_c, l.recommended_materials, l.is_ai_generated, l.ai_model_name, l.likes_count, l.downloads_count, l.comments_count, l.is_sale_active, l.sale_price, l.sale_name, l.sale_end_timestamp, l.seller_rating_average, l.seller_total_ratings, l.seller_total_sales, l.is_nsfw, l.created_at, l.updated_at, l.deleted_at, l.views_count, l.nozzle_diameter_mm, l.language,
+    COALESCE(
+        json_agg(
+            json_build_object(
+                'id', f.id,
+                'file_path', f.file_path,
+                'file_type', f.file_type,
+                'status', f.status,
+                'error_message', f.error_message,
+                'is_generated', f.is_generated,
+                'source_file_id', f.source_file_id,
+                'size', f.file_size,
+                'metadata', f.metadata
+            )
+        ) FILTER (WHERE f.id IS NOT NULL), 
+        '[]'
+    )::jsonb AS files,
+    -- Why the listing is in its current status, shown to the seller when it failed
+    (
+        SELECT e.reason FROM listing_status_events e
+        WHERE e.listing_id = l.id
+        ORDER BY e.created_at DESC, e.id DESC
+        LIMIT 1
+    ) AS status_reason
+FROM listings l
+LEFT JOIN listing_files f ON l.id = f.listing_id AND f.deleted_at IS NULL
+WHERE l.id = ANY($1::uuid[]) AND l.deleted_at IS NULL
+GROUP BY l.id
+`
+
+type GetListingsByIDsWithFilesRow struct {
+	ID                     pgtype.UUID        `json:"id"`
+	SellerID               pgtype.UUID        `json:"seller_id"`
+	SellerName             string             `json:"seller_name"`
+	SellerUsername         string             `json:"seller_username"`
+	SellerVerified         bool               `json:"seller_verified"`
+	Title                  string             `json:"title"`
+	Description            pgtype.Text        `json:"description"`
+	PriceMinUnit           int64              `json:"price_min_unit"`
+	Currency               string             `json:"currency"`
+	Categories             []string           `json:"categories"`
+	License                string             `json:"license"`
+	ThumbnailPath          pgtype.Text        `json:"thumbnail_path"`
+	LastIndexedAt          pgtype.Timestamptz `json:"last_indexed_at"`
+	Status                 NullListingStatus  `json:"status"`
+	IsRemixingAllowed      bool               `json:"is_remixing_allowed"`
+	ParentListingID        pgtype.UUID        `json:"parent_listing_id"`
+	IsPhysical             bool               `json:"is_physical"`
+	TotalWeightGrams       pgtype.Int4        `json:"total_weight_grams"`
+	IsAssemblyRequired     bool               `json:"is_assembly_required"`
+	IsHardwareRequired     bool               `json:"is_hardware_required"`
+	HardwareRequired       []string           `json:"hardware_required"`
+	IsMulticolor           bool               `json:"is_multicolor"`
+	DimensionsMm           []byte             `json:"dimensions_mm"`
+	RecommendedNozzleTempC pgtype.Int4        `json:"recommended_nozzle_temp_c"`
+	RecommendedMaterials   []string           `json:"recommended_materials"`
+	IsAiGenerated          bool               `json:"is_ai_generated"`
+	AiModelName            pgtype.Text        `json:"ai_model_name"`
+	LikesCount             int32              `json:"likes_count"`
+	DownloadsCount         int32              `json:"downloads_count"`
+	CommentsCount          int32              `json:"comments_count"`
+	IsSaleActive           bool               `json:"is_sale_active"`
+	SalePrice              pgtype.Int8        `json:"sale_price"`
+	SaleName               pgtype.Text        `json:"sale_name"`
+	SaleEndTimestamp       pgtype.Timestamptz `json:"sale_end_timestamp"`
+	SellerRatingAverage    pgtype.Numeric     `json:"seller_rating_average"`
+	SellerTotalRatings     pgtype.Int4        `json:"seller_total_ratings"`
+	SellerTotalSales       pgtype.Int4        `json:"seller_total_sales"`
+	IsNsfw                 bool               `json:"is_nsfw"`
+	CreatedAt              pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt              pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt              pgtype.Timestamptz `json:"deleted_at"`
+	ViewsCount             int32              `json:"views_count"`
+	NozzleDiameterMm       pgtype.Numeric     `json:"nozzle_diameter_mm"`
+	Language               string             `json:"language"`
+	Files                  []byte             `json:"files"`
+	StatusReason           pgtype.Text        `json:"status_reason"`
+}
+
+// The batch form of GetListingByIDWithFiles, for filling the listing cache for a page of listings in one query
+func (q *Queries) GetListingsByIDsWithFiles(ctx context.Context, ids []pgtype.UUID) ([]GetListingsByIDsWithFilesRow, error) {
+	rows, err := q.db.Query(ctx, getListingsByIDsWithFiles, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetListingsByIDsWithFilesRow
+	for rows.Next() {
+		var i GetListingsByIDsWithFilesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SellerID,
+			&i.SellerName,
+			&i.SellerUsername,
+			&i.SellerVerified,
+			&i.Title,
+			&i.Description,
+			&i.PriceMinUnit,
+			&i.Currency,
+			&i.Categories,
+			&i.License,
+			&i.ThumbnailPath,
+			&i.LastIndexedAt,
+			&i.Status,
+			&i.IsRemixingAllowed,
+			&i.ParentListingID,
+			&i.IsPhysical,
+			&i.TotalWeightGrams,
+			&i.IsAssemblyRequired,
+			&i.IsHardwareRequired,
+			&i.HardwareRequired,
+			&i.IsMulticolor,
+			&i.DimensionsMm,
+			&i.RecommendedNozzleTempC,
+			&i.RecommendedMaterials,
+			&i.IsAiGenerated,
+			&i.AiModelName,
+			&i.LikesCount,
+			&i.DownloadsCount,
+			&i.CommentsCount,
+			&i.IsSaleActive,
+			&i.SalePrice,
+			&i.SaleName,
+			&i.SaleEndTimestamp,
+			&i.SellerRatingAverage,
+			&i.SellerTotalRatings,
+			&i.SellerTotalSales,
+			&i.IsNsfw,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.ViewsCount,
+			&i.NozzleDiameterMm,
+			&i.Language,
+			&i.Files,
+			&i.StatusReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getListingsBySellerID = `-- name: GetListingsBySellerID :many
+SELECT 
+    l.id, l.seller_id, l.seller_name, l.seller_username, l.seller_verified, l.title, l.description, l.price_min_unit, l.currency, l.categories, l.license, l.thumbnail_path, l.last_indexed_at, l.status, l.is_remixing_allowed, l.parent_listing_id, l.is_physical, l.total_weight_grams, l.is_assembly_required, l.is_hardware_required, l.hardware_required, l.is_multicolor, l.dimensions_mm, l.recommended_nozzle_temp_c, l.recommended_materials, l.is_ai_generated, l.ai_model_name, l.likes_count, l.downloads_count, l.comments_count, l.is_sale_active, l.sale_price, l.sale_name, l.sale_end_timestamp, l.seller_rating_average, l.seller_total_ratings, l.seller_total_sales, l.is_nsfw, l.created_at, l.updated_at, l.deleted_at, l.views_count, l.nozzle_diameter_mm, l.language,
+    COALESCE(
+        json_agg(
+            json_build_object(
+                'id', f.id,
+                'file_path', f.file_path,
+                'file_type', f.file_type,
+                'status', f.status,
+                'error_message', f.error_message,
+                'is_generated', f.is_generated,
+                'source_file_id', f.source_file_id,
+                'size', f.file_size,
+                'metadata', f.metadata
+            )
+        ) FILTER (WHERE f.id IS NOT NULL), 
+        '[]'
+    )::jsonb AS files,
+    -- Why the listing is in its current status, shown to the seller when it failed
+    (
+        SELECT e.reason FROM listing_status_events e
+        WHERE e.listing_id = l.id
+        ORDER BY e.created_at DESC, e.id DESC
+        LIMIT 1
+    ) AS status_reason
+FROM listings l
+LEFT JOIN listing_files f ON l.id = f.listing_id AND f.deleted_at IS NULL
+WHERE l.seller_id = $1 AND l.deleted_at IS NULL
+  AND ($2::listing_status IS NULL OR l.status = $2)
+GROUP BY l.id
+ORDER BY
+    CASE WHEN $3::boolean THEN
+        CASE $4::text WHEN 'created_at' THEN l.created_at WHEN 'updated_at' THEN l.updated_at END
+    END DESC,
+    CASE WHEN NOT $3::boolean THEN
+        CASE $4::text WHEN 'created_at' THEN l.created_at WHEN 'updated_at' THEN l.updated_at END
+    END ASC,
+    CASE WHEN $3::boolean THEN
+        CASE $4::text WHEN 'price_min_unit' THEN l.price_min_unit WHEN 'downloads_count' THEN l.downloads_count::bigint END
+    END DESC,
+    CASE WHEN NOT $3::boolean THEN
+        CASE $4::text WHEN 'price_min_unit' THEN l.price_min_unit WHEN 'downloads_count' THEN l.downloads_count::bigint END
+    END ASC,
+    l.created_at DESC
+`
+
+type GetListingsBySellerIDParams struct {
+	SellerID   pgtype.UUID       `json:"seller_id"`
+	Status     NullListingStatus `json:"status"`
+	SortDesc   bool              `json:"sort_desc"`
+	SortColumn string            `json:"sort_column"`
+}
+
+type GetListingsBySellerIDRow struct {
+	ID                     pgtype.UUID        `json:"id"`
+	SellerID               pgtype.UUID        `json:"seller_id"`
+	SellerName             string             `json:"seller_name"`
+	SellerUsername         string             `json:"seller_username"`
+	SellerVerified         bool               `json:"seller_verified"`
+	Title                  string             `json:"title"`
+	Description            pgtype.Text        `json:"description"`
+	PriceMinUnit           int64              `json:"price_min_unit"`
+	Currency               string             `json:"currency"`
+	Categories             []string           `json:"categories"`
+	License                string             `json:"license"`
+	ThumbnailPath          pgtype.Text        `json:"thumbnail_path"`
+	LastIndexedAt          pgtype.Timestamptz `json:"last_indexed_at"`
+	Status                 NullListingStatus  `json:"status"`
+	IsRemixingAllowed      bool               `json:"is_remixing_allowed"`
+	ParentListingID        pgtype.UUID        `json:"parent_listing_id"`
+	IsPhysical             bool               `json:"is_physical"`
+	TotalWeightGrams       pgtype.Int4        `json:"total_weight_grams"`
+	IsAssemblyRequired     bool               `json:"is_assembly_required"`
+	IsHardwareRequired     bool               `json:"is_hardware_required"`
+	HardwareRequired       []string           `json:"hardware_required"`
+	IsMulticolor           bool               `json:"is_multicolor"`
+	DimensionsMm           []byte             `json:"dimensions_mm"`
+	RecommendedNozzleTempC pgtype.Int4        `json:"recommended_nozzle_temp_c"`
+	RecommendedMaterials   []string           `json:"recommended_materials"`
+	IsAiGenerated          bool               `json:"is_ai_generated"`
+	AiModelName            pgtype.Text        `json:"ai_model_name"`
+	LikesCount             int32              `json:"likes_count"`
+	DownloadsCount         int32              `json:"downloads_count"`
+	CommentsCount          int32              `json:"comments_count"`
+	IsSaleActive           bool               `json:"is_sale_active"`
+	SalePrice              pgtype.Int8        `json:"sale_price"`
+	SaleName               pgtype.Text        `json:"sale_name"`
+	SaleEndTimestamp       pgtype.Timestamptz `json:"sale_end_timestamp"`
+	SellerRatingAverage    pgtype.Numeric     `json:"seller_rating_average"`
+	SellerTotalRatings     pgtype.Int4        `json:"seller_total_ratings"`
+	SellerTotalSales       pgtype.Int4        `json:"seller_total_sales"`
+	IsNsfw                 bool               `json:"is_nsfw"`
+	CreatedAt              pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt              pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt              pgtype.Timestamptz `json:"deleted_at"`
+	ViewsCount             int32              `json:"views_count"`
+	NozzleDiameterMm       pgtype.Numeric     `json:"nozzle_diameter_mm"`
+	Language               string             `json:"language"`
+	Files                  []byte             `json:"files"`
+	StatusReason           pgtype.Text        `json:"status_reason"`
+}
+
+// One CASE per direction and column type, only the one for sort_column and sort_desc orders anything
+func (q *Queries) GetListingsBySellerID(ctx context.Context, arg GetListingsBySellerIDParams) ([]GetListingsBySellerIDRow, error) {
+	rows, err := q.db.Query(ctx, getListingsBySellerID,
+		arg.SellerID,
+		arg.Status,
+		arg.SortDesc,
+		arg.SortColumn,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetListingsBySellerIDRow
+	for rows.Next() {
+		var i GetListingsBySellerIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SellerID,
+			&i.SellerName,
+			&i.SellerUsername,
+			&i.SellerVerified,
+			&i.Title,
+			&i.Description,
+			&i.PriceMinUnit,
+			&i.Currency,
+			&i.Categories,
+			&i.License,
+			&i.ThumbnailPath,
+			&i.LastIndexedAt,
+			&i.Status,
+			&i.IsRemixingAllowed,
+			&i.ParentListingID,
+			&i.IsPhysical,
+			&i.TotalWeightGrams,
+			&i.IsAssemblyRequired,
+			&i.IsHardwareRequired,
+			&i.HardwareRequired,
+			&i.IsMulticolor,
+			&i.DimensionsMm,
+			&i.RecommendedNozzleTempC,
+			&i.RecommendedMaterials,
+			&i.IsAiGenerated,
+			&i.AiModelName,
+			&i.LikesCount,
+			&i.DownloadsCount,
+			&i.CommentsCount,
+			&i.IsSaleActive,
+			&i.SalePrice,
+			&i.SaleName,
+			&i.SaleEndTimestamp,
+			&i.SellerRatingAverage,
+			&i.SellerTotalRatings,
+			&i.SellerTotalSales,
+			&i.IsNsfw,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.ViewsCount,
+			&i.NozzleDiameterMm,
+			&i.Language,
+			&i.Files,
+			&i.StatusReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getListingsForBulkUpdate = `-- name: GetListingsForBulkUpdate :many
+SELECT id, seller_id, status FROM listings
+WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL
+FOR UPDATE
+`
+
+type GetListingsForBulkUpdateRow struct {
+	ID       pgtype.UUID       `json:"id"`
+	SellerID pgtype.UUID       `json:"seller_id"`
+	Status   NullListingStatus `json:"status"`
+}
+
+// Locks every listing in a bulk request so ownership and status are checked in one query and can't change before the update commits
+func (q *Queries) GetListingsForBulkUpdate(ctx context.Context, ids []pgtype.UUID) ([]GetListingsForBulkUpdateRow, error) {
+	rows, err := q.db.Query(ctx, getListingsForBulkUpdate, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetListingsForBulkUpdateRow
+	for rows.Next() {
+		var i GetListingsForBulkUpdateRow
+		if err := rows.Scan(&i.ID, &i.SellerID, &i.Status); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getListingsForSync = `-- name: GetListingsForSync :many
+SELECT id, seller_id, seller_name, seller_username, seller_verified, title, description, price_min_unit, currency, categories, license, thumbnail_path, last_indexed_at, status, is_remixing_allowed, parent_listing_id, is_physical, total_weight_grams, is_assembly_required, is_hardware_required, hardware_required, is_multicolor, dimensions_mm, recommended_nozzle_temp_c, recommended_materials, is_ai_generated, ai_model_name, likes_count, downloads_count, comments_count, is_sale_active, sale_price, sale_name, sale_end_timestamp, seller_rating_average, seller_total_ratings, seller_total_sales, is_nsfw, created_at, updated_at, deleted_at, views_count, nozzle_diameter_mm, language FROM listings
+WHERE deleted_at IS NULL
+    AND (last_indexed_at IS NULL OR updated_at > last_indexed_at)
+LIMIT $1
+`
+
+// Finds all listings that are new OR have been updated since the last sync
+func (q *Queries) GetListingsForSync(ctx context.Context, limit int32) ([]Listing, error) {
+	rows, err := q.db.Query(ctx, getListingsForSync, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Listing
+	for rows.Next() {
+		var i Listing
+		if err := rows.Scan(
+			&i.ID,
+			&i.SellerID,
+			&i.SellerName,
+			&i.SellerUsername,
+			&i.SellerVerified,
+			&i.Title,
+			&i.Description,
+			&i.PriceMinUnit,
+			&i.Currency,
+			&i.Categories,
+			&i.License,
+			&i.ThumbnailPath,
+			&i.LastIndexedAt,
+			&i.Status,
+			&i.IsRemixingAllowed,
+			&i.ParentListingID,
+			&i.IsPhysical,
+			&i.TotalWeightGrams,
+			&i.IsAssemblyRequired,
+			&i.IsHardwareRequired,
+			&i.HardwareRequired,
+			&i.IsMulticolor,
+			&i.DimensionsMm,
+			&i.RecommendedNozzleTempC,
+			&i.RecommendedMaterials,
+			&i.IsAiGenerated,
+			&i.AiModelName,
+			&i.LikesCount,
+			&i.DownloadsCount,
+			&i.CommentsCount,
+			&i.IsSaleActive,
+			&i.SalePrice,
+			&i.SaleName,
+			&i.SaleEndTimestamp,
+			&i.SellerRatingAverage,
+			&i.SellerTotalRatings,
+			&i.SellerTotalSales,
+			&i.IsNsfw,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.ViewsCount,
+			&i.NozzleDiameterMm,
+			&i.Language,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getModelFilesOutsideVariants = `-- name: GetModelFilesOutsideVariants :many
+SELECT f.id FROM listing_files f
+WHERE f.listing_id = $1 AND f.file_type = 'MODEL' AND f.deleted_at IS NULL
+    AND EXISTS (SELECT 1 FROM listing_variants v WHERE v.listing_id = f.listing_id)
+    AND NOT EXISTS (SELECT 1 FROM listing_variant_files vf WHERE vf.file_id = f.id)
+ORDER BY f.id
+`
+
+// The listing's model files no variant includes. Always none for a listing without variants, which sells every file.
+func (q *Queries) GetModelFilesOutsideVariants(ctx context.Context, listingID pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, getModelFilesOutsideVariants, listingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOutboxStats = `-- name: GetOutboxStats :one
+SELECT
+    COUNT(*) FILTER (WHERE failed_at IS NULL)::bigint AS pending,
+    COUNT(*) FILTER (WHERE failed_at IS NOT NULL)::bigint AS failed,
+    MIN(created_at) FILTER (WHERE failed_at IS NULL)::timestamptz AS oldest_pending_at
+FROM event_outbox
+WHERE published_at IS NULL
+`
+
+type GetOutboxStatsRow struct {
+	Pending         int64              `json:"pending"`
+	Failed          int64              `json:"failed"`
+	OldestPendingAt pgtype.Timestamptz `json:"oldest_pending_at"`
+}
+
+// Events still waiting to go out and the ones given up on, for the relay's gauges
+func (q *Queries) GetOutboxStats(ctx context.Context) (GetOutboxStatsRow, error) {
+	row := q.db.QueryRow(ctx, getOutboxStats)
+	var i GetOutboxStatsRow
+	err := row.Scan(&i.Pending, &i.Failed, &i.OldestPendingAt)
+	return i, err
+}
+
+const getRecentPriceHistoryBySeller = `-- name: GetRecentPriceHistoryBySeller :many
+SELECT listing_id, old_price_min_unit, new_price_min_unit, old_currency, new_currency, changed_at FROM (
+    SELECT h.listing_id, h.old_price_min_unit, h.new_price_min_unit, h.old_currency, h.new_currency, h.changed_at,
+        row_number() OVER (PARTITION BY h.listing_id ORDER BY h.changed_at DESC) AS position
+    FROM listing_price_history h
+    JOIN listings l ON l.id = h.listing_id
+    WHERE l.seller_id = $1
+) recent
+WHERE position <= $2::bigint
+ORDER BY listing_id, changed_at DESC
+`
+
+type GetRecentPriceHistoryBySellerParams struct {
+	SellerID   pgtype.UUID `json:"seller_id"`
+	PerListing int64       `json:"per_listing"`
+}
+
+type GetRecentPriceHistoryBySellerRow struct {
+	ListingID       pgtype.UUID        `json:"listing_id"`
+	OldPriceMinUnit int64              `json:"old_price_min_unit"`
+	NewPriceMinUnit int64              `json:"new_price_min_unit"`
+	OldCurrency     string             `json:"old_currency"`
+	NewCurrency     string             `json:"new_currency"`
+	ChangedAt       pgtype.Timestamptz `json:"changed_at"`
+}
+
+// The latest @per_listing price changes of each of the seller's listings, newest first, for their price charts
+func (q *Queries) GetRecentPriceHistoryBySeller(ctx context.Context, arg GetRecentPriceHistoryBySellerParams) ([]GetRecentPriceHistoryBySellerRow, error) {
+	rows, err := q.db.Query(ctx, getRecentPriceHistoryBySeller, arg.SellerID, arg.PerListing)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRecentPriceHistoryBySellerRow
+	for rows.Next() {
+		var i GetRecentPriceHistoryBySellerRow
+		if err := rows.Scan(
+			&i.ListingID,
+			&i.OldPriceMinUnit,
+			&i.NewPriceMinUnit,
+			&i.OldCurrency,
+			&i.NewCurrency,
+			&i.ChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRemixIDs = `-- name: GetRemixIDs :many
+SELECT id FROM listings
+WHERE parent_listing_id = ANY($1::uuid[]) AND deleted_at IS NULL
+`
+
+// Live remixes of any of the listings, their search documents and responses depend on the parent being live
+func (q *Queries) GetRemixIDs(ctx context.Context, parentIds []pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, getRemixIDs, parentIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRemixTree = `-- name: GetRemixTree :many
+WITH RECURSIVE tree AS (
+    SELECT listings.id, 0 AS depth FROM listings WHERE listings.id = $1
+    UNION ALL
+    SELECT l.id, t.depth + 1 FROM listings l
+    JOIN tree t ON l.parent_listing_id = t.id
+    WHERE t.depth < $2::int
+)
+SELECT l.id, l.parent_listing_id, l.title, l.seller_username, l.thumbnail_path, l.deleted_at, t.depth::int AS depth
+FROM tree t
+JOIN listings l ON l.id = t.id
+ORDER BY t.depth, l.created_at, l.id
+`
+
+type GetRemixTreeParams struct {
+	ID       pgtype.UUID `json:"id"`
+	MaxDepth int32       `json:"max_depth"`
+}
+
+type GetRemixTreeRow struct {
+	ID              pgtype.UUID        `json:"id"`
+	ParentListingID pgtype.UUID        `json:"parent_listing_id"`
+	Title           string             `json:"title"`
+	SellerUsername  string             `json:"seller_username"`
+	ThumbnailPath   pgtype.Text        `json:"thumbnail_path"`
+	DeletedAt       pgtype.Timestamptz `json:"deleted_at"`
+	Depth           int32              `json:"depth"`
+}
+
+// The listing and every remix below it, deleted ones included so the tree can be stitched back together around them.
+// Depth is capped so a corrupt parent chain can't recurse forever.
+func (q *Queries) GetRemixTree(ctx context.Context, arg GetRemixTreeParams) ([]GetRemixTreeRow, error) {
+	rows, err := q.db.Query(ctx, getRemixTree, arg.ID, arg.MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRemixTreeRow
+	for rows.Next() {
+		var i GetRemixTreeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParentListingID,
+			&i.Title,
+			&i.SellerUsername,
+			&i.ThumbnailPath,
+			&i.DeletedAt,
+			&i.Depth,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSellerListingIDs = `-- name: GetSellerListingIDs :many
+SELECT id FROM listings
+WHERE seller_id = $1 AND deleted_at IS NULL
+`
+
+// Every listing response that can be cached for the seller, deleted listings are never served
+func (q *Queries) GetSellerListingIDs(ctx context.Context, sellerID pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, getSellerListingIDs, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSellerPins = `-- name: GetSellerPins :many
+SELECT p.listing_id FROM seller_pinned_listings p
+JOIN listings l ON l.id = p.listing_id
+WHERE p.seller_id = $1 AND l.deleted_at IS NULL AND l.status = 'ACTIVE'
+ORDER BY p.position
+`
+
+// In order. Pins of listings since unpublished or deleted are left out, the next change drops them.
+func (q *Queries) GetSellerPins(ctx context.Context, sellerID pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, getSellerPins, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var listing_id pgtype.UUID
+		if err := rows.Scan(&listing_id); err != nil {
+			return nil, err
+		}
+		items = append(items, listing_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSellerProfile = `-- name: GetSellerProfile :one
+SELECT user_id, display_name, country, payout_status, accepted_terms_version, accepted_terms_at, created_at, updated_at, vacation_starts_at, vacation_ends_at, vacation_message, vacation_applied, last_active_at FROM sellers
+WHERE user_id = $1
+`
+
+func (q *Queries) GetSellerProfile(ctx context.Context, userID pgtype.UUID) (Seller, error) {
+	row := q.db.QueryRow(ctx, getSellerProfile, userID)
+	var i Seller
+	err := row.Scan(
+		&i.UserID,
+		&i.DisplayName,
+		&i.Country,
+		&i.PayoutStatus,
+		&i.AcceptedTermsVersion,
+		&i.AcceptedTermsAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VacationStartsAt,
+		&i.VacationEndsAt,
+		&i.VacationMessage,
+		&i.VacationApplied,
+		&i.LastActiveAt,
+	)
+	return i, err
+}
+
+const getSellerVacation = `-- name: GetSellerVacation :one
+SELECT vacation_starts_at, vacation_ends_at, vacation_message FROM sellers
+WHERE user_id = $1
+`
+
+type GetSellerVacationRow struct {
+	VacationStartsAt pgtype.Timestamptz `json:"vacation_starts_at"`
+	VacationEndsAt   pgtype.Timestamptz `json:"vacation_ends_at"`
+	VacationMessage  pgtype.Text        `json:"vacation_message"`
+}
+
+func (q *Queries) GetSellerVacation(ctx context.Context, userID pgtype.UUID) (GetSellerVacationRow, error) {
+	row := q.db.QueryRow(ctx, getSellerVacation, userID)
+	var i GetSellerVacationRow
+	err := row.Scan(&i.VacationStartsAt, &i.VacationEndsAt, &i.VacationMessage)
+	return i, err
+}
+
+const getShortLinkTarget = `-- name: GetShortLinkTarget :one
+SELECT s.listing_id, s.campaign, s.revoked_at, l.deleted_at AS listing_deleted_at
+FROM short_links s
+JOIN listings l ON l.id = s.listing_id
+WHERE s.code = $1
+`
+
+type GetShortLinkTargetRow struct {
+	ListingID        pgtype.UUID        `json:"listing_id"`
+	Campaign         pgtype.Text        `json:"campaign"`
+	RevokedAt        pgtype.Timestamptz `json:"revoked_at"`
+	ListingDeletedAt pgtype.Timestamptz `json:"listing_deleted_at"`
+}
+
+// Where a short link goes, with whatever stops it going there
+func (q *Queries) GetShortLinkTarget(ctx context.Context, code string) (GetShortLinkTargetRow, error) {
+	row := q.db.QueryRow(ctx, getShortLinkTarget, code)
+	var i GetShortLinkTargetRow
+	err := row.Scan(
+		&i.ListingID,
+		&i.Campaign,
+		&i.RevokedAt,
+		&i.ListingDeletedAt,
+	)
+	return i, err
+}
+
+const getStorefrontListingIDs = `-- name: GetStorefrontListingIDs :many
+SELECT l.id, p.position AS pinned_position FROM listings l
+LEFT JOIN seller_pinned_listings p ON p.listing_id = l.id
+WHERE l.seller_id = $1 AND l.deleted_at IS NULL AND l.status = 'ACTIVE'
+ORDER BY p.position ASC NULLS LAST, l.created_at DESC, l.id DESC
+LIMIT $2
+`
+
+type GetStorefrontListingIDsParams struct {
+	SellerID pgtype.UUID `json:"seller_id"`
+	RowLimit int32       `json:"row_limit"`
+}
+
+type GetStorefrontListingIDsRow struct {
+	ID             pgtype.UUID `json:"id"`
+	PinnedPosition pgtype.Int2 `json:"pinned_position"`
+}
+
+// A seller's published listings for their storefront, the pinned ones first in their order, then the newest
+func (q *Queries) GetStorefrontListingIDs(ctx context.Context, arg GetStorefrontListingIDsParams) ([]GetStorefrontListingIDsRow, error) {
+	rows, err := q.db.Query(ctx, getStorefrontListingIDs, arg.SellerID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetStorefrontListingIDsRow
+	for rows.Next() {
+		var i GetStorefrontListingIDsRow
+		if err := rows.Scan(&i.ID, &i.PinnedPosition); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsedFilePaths = `-- name: GetUsedFilePaths :many
+SELECT file_path FROM listing_files
+WHERE file_path = ANY($1::text[]) AND is_generated = false
+`
+
+// Uploads from the request that are already attached to a listing, deleted listings included as their files have been moved on
+func (q *Queries) GetUsedFilePaths(ctx context.Context, paths []string) ([]string, error) {
+	rows, err := q.db.Query(ctx, getUsedFilePaths, paths)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var file_path string
+		if err := rows.Scan(&file_path); err != nil {
+			return nil, err
+		}
+		items = append(items, file_path)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hasDownloadedFile = `-- name: HasDownloadedFile :one
+SELECT EXISTS (
+    SELECT 1 FROM downloads
+    WHERE user_id = $1 AND listing_id = $2 AND file_id = $3
+)
+`
+
+type HasDownloadedFileParams struct {
+	UserID    pgtype.UUID `json:"user_id"`
+	ListingID pgtype.UUID `json:"listing_id"`
+	FileID    pgtype.UUID `json:"file_id"`
+}
+
+func (q *Queries) HasDownloadedFile(ctx context.Context, arg HasDownloadedFileParams) (bool, error) {
+	row := q.db.QueryRow(ctx, hasDownloadedFile, arg.UserID, arg.ListingID, arg.FileID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const hideListings = `-- name: HideListings :exec
+UPDATE listings
+    SET status = 'HIDDEN', updated_at = CURRENT_TIMESTAMP
+    WHERE id = ANY($1::uuid[]) AND seller_id = $2 AND deleted_at IS NULL
+`
+
+type HideListingsParams struct {
+	Ids      []pgtype.UUID `json:"ids"`
+	SellerID pgtype.UUID   `json:"seller_id"`
+}
+
+// Unpublishes listings, the worker drops anything that isn't ACTIVE from the index
+func (q *Queries) HideListings(ctx context.Context, arg HideListingsParams) error {
+	_, err := q.db.Exec(ctx, hideListings, arg.Ids, arg.SellerID)
+	return err
+}
+
+const insertListingBulkAction = `-- name: InsertListingBulkAction :exec
+INSERT INTO listing_bulk_actions (action, user_id, username, listing_ids, failed, trace_id)
+VALUES ($1, $2, $3, $4::uuid[], $5, $6)
+`
+
+type InsertListingBulkActionParams struct {
+	Action     string        `json:"action"`
+	UserID     pgtype.UUID   `json:"user_id"`
+	Username   string        `json:"username"`
+	ListingIds []pgtype.UUID `json:"listing_ids"`
+	Failed     int32         `json:"failed"`
+	TraceID    pgtype.Text   `json:"trace_id"`
+}
+
+// Audit row of a bulk request, in the same transaction as the change
+func (q *Queries) InsertListingBulkAction(ctx context.Context, arg InsertListingBulkActionParams) error {
+	_, err := q.db.Exec(ctx, insertListingBulkAction,
+		arg.Action,
+		arg.UserID,
+		arg.Username,
+		arg.ListingIds,
+		arg.Failed,
+		arg.TraceID,
+	)
+	return err
+}
+
+const insertOutboxEvents = `-- name: InsertOutboxEvents :exec
+INSERT INTO event_outbox (subject, msg_id, payload)
+SELECT unnest($1::text[]), unnest($2::text[]), unnest($3::bytea[])
+`
+
+type InsertOutboxEventsParams struct {
+	Subjects []string `json:"subjects"`
+	MsgIds   []string `json:"msg_ids"`
+	Payloads [][]byte `json:"payloads"`
+}
+
+// Must run in the transaction of the change the events describe, see event_outbox. One event per position in the
+// arrays, given ids in that order so the relay publishes them in it.
+// Set returning functions in one SELECT list step through their arrays together, in order
+func (q *Queries) InsertOutboxEvents(ctx context.Context, arg InsertOutboxEventsParams) error {
+	_, err := q.db.Exec(ctx, insertOutboxEvents, arg.Subjects, arg.MsgIds, arg.Payloads)
+	return err
+}
+
+const isCallbackDestinationDisabled = `-- name: IsCallbackDestinationDisabled :one
+SELECT EXISTS (
+    SELECT 1 FROM callback_destinations
+    WHERE destination = $1 AND disabled_at IS NOT NULL
+)
+`
+
+func (q *Queries) IsCallbackDestinationDisabled(ctx context.Context, destination string) (bool, error) {
+	row := q.db.QueryRow(ctx, isCallbackDestinationDisabled, destination)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const isListingLive = `-- name: IsListingLive :one
+SELECT EXISTS (
+    SELECT 1 FROM listings
+    WHERE id = $1 AND deleted_at IS NULL
+)
+`
+
+// Cheaper than reading the listing when all that matters is whether it can still be served
+func (q *Queries) IsListingLive(ctx context.Context, id pgtype.UUID) (bool, error) {
+	row := q.db.QueryRow(ctx, isListingLive, id)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listActiveFeaturedListings = `-- name: ListActiveFeaturedListings :many
+SELECT f.listing_id, f.weight, f.starts_at FROM featured_listings f
+JOIN listings l ON l.id = f.listing_id
+WHERE f.starts_at <= $1::timestamptz AND f.ends_at > $1::timestamptz AND f.deleted_at IS NULL
+    AND l.deleted_at IS NULL AND l.status = 'ACTIVE'
+`
+
+type ListActiveFeaturedListingsRow struct {
+	ListingID pgtype.UUID        `json:"listing_id"`
+	Weight    int32              `json:"weight"`
+	StartsAt  pgtype.Timestamptz `json:"starts_at"`
+}
+
+// Windows running at now on published listings. A listing whose windows overlap comes back once for each of them.
+func (q *Queries) ListActiveFeaturedListings(ctx context.Context, now pgtype.Timestamptz) ([]ListActiveFeaturedListingsRow, error) {
+	rows, err := q.db.Query(ctx, listActiveFeaturedListings, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListActiveFeaturedListingsRow
+	for rows.Next() {
+		var i ListActiveFeaturedListingsRow
+		if err := rows.Scan(&i.ListingID, &i.Weight, &i.StartsAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAdminListings = `-- name: ListAdminListings :many
+SELECT l.id, l.title, l.seller_id, l.seller_username, l.status, l.categories, l.is_nsfw, l.price_min_unit, l.currency,
+    l.created_at, l.deleted_at, COALESCE(r.report_count, 0)::bigint AS report_count,
+    COALESCE(sf.terms, '{}')::text[] AS flagged_terms
+FROM listings l
+LEFT JOIN (
+    SELECT listing_id, count(*) AS report_count FROM listing_reports GROUP BY listing_id
+) r ON r.listing_id = l.id
+LEFT JOIN listing_screening_flags sf ON sf.listing_id = l.id
+WHERE ($1::listing_status IS NULL OR l.status = $1)
+  AND ($2::uuid IS NULL OR l.seller_id = $2)
+  AND ($3::text IS NULL OR l.categories @> ARRAY[$3::text])
+  AND ($4::boolean IS NULL OR l.is_nsfw = $4)
+  AND ($5::timestamptz IS NULL OR l.created_at >= $5)
+  AND ($6::timestamptz IS NULL OR l.created_at < $6)
+  AND ($7::bigint IS NULL OR COALESCE(r.report_count, 0) >= $7)
+  AND ($8::timestamptz IS NULL
+    OR (l.created_at, l.id) < ($8, $9::uuid))
+ORDER BY l.created_at DESC, l.id DESC
+LIMIT $10
+`
+
+type ListAdminListingsParams struct {
+	Status          NullListingStatus  `json:"status"`
+	SellerID        pgtype.UUID        `json:"seller_id"`
+	Category        pgtype.Text        `json:"category"`
+	IsNsfw          pgtype.Bool        `json:"is_nsfw"`
+	CreatedFrom     pgtype.Timestamptz `json:"created_from"`
+	CreatedTo       pgtype.Timestamptz `json:"created_to"`
+	MinReports      pgtype.Int8        `json:"min_reports"`
+	CursorCreatedAt pgtype.Timestamptz `json:"cursor_created_at"`
+	CursorID        pgtype.UUID        `json:"cursor_id"`
+	PageLimit       int32              `json:"page_limit"`
+}
+
+type ListAdminListingsRow struct {
+	ID             pgtype.UUID        `json:"id"`
+	Title          string             `json:"title"`
+	SellerID       pgtype.UUID        `json:"seller_id"`
+	SellerUsername string             `json:"seller_username"`
+	Status         NullListingStatus  `json:"status"`
+	Categories     []string           `json:"categories"`
+	IsNsfw         bool               `json:"is_nsfw"`
+	PriceMinUnit   int64              `json:"price_min_unit"`
+	Currency       string             `json:"currency"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	DeletedAt      pgtype.Timestamptz `json:"deleted_at"`
+	ReportCount    int64              `json:"report_count"`
+	FlaggedTerms   []string           `json:"flagged_terms"`
+}
+
+// Every listing for moderators, deleted and pending ones included, newest first. A NULL filter matches everything.
+// Keyset paginated on (created_at, id), pass the last row of the previous page as the cursor.
+func (q *Queries) ListAdminListings(ctx context.Context, arg ListAdminListingsParams) ([]ListAdminListingsRow, error) {
+	rows, err := q.db.Query(ctx, listAdminListings,
+		arg.Status,
+		arg.SellerID,
+		arg.Category,
+		arg.IsNsfw,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.MinReports,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAdminListingsRow
+	for rows.Next() {
+		var i ListAdminListingsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.SellerID,
+			&i.SellerUsername,
+			&i.Status,
+			&i.Categories,
+			&i.IsNsfw,
+			&i.PriceMinUnit,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.DeletedAt,
+			&i.ReportCount,
+			&i.FlaggedTerms,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBannedTerms = `-- name: ListBannedTerms :many
+SELECT term, severity, updated_by, updated_at FROM banned_terms
+ORDER BY term
+`
+
+func (q *Queries) ListBannedTerms(ctx context.Context) ([]BannedTerm, error) {
+	rows, err := q.db.Query(ctx, listBannedTerms)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BannedTerm
+	for rows.Next() {
+		var i BannedTerm
+		if err := rows.Scan(
+			&i.Term,
+			&i.Severity,
+			&i.UpdatedBy,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDownloadedFiles = `-- name: ListDownloadedFiles :many
+SELECT d.listing_id, d.file_id, f.file_type, max(d.downloaded_at)::timestamptz AS last_downloaded_at, count(*) AS download_count
+FROM downloads d
+JOIN listing_files f ON f.id = d.file_id
+WHERE d.user_id = $1 AND d.listing_id = ANY($2::uuid[])
+GROUP BY d.listing_id, d.file_id, f.file_type
+ORDER BY last_downloaded_at DESC, d.file_id
+`
+
+type ListDownloadedFilesParams struct {
+	UserID     pgtype.UUID   `json:"user_id"`
+	ListingIds []pgtype.UUID `json:"listing_ids"`
+}
+
+type ListDownloadedFilesRow struct {
+	ListingID        pgtype.UUID        `json:"listing_id"`
+	FileID           pgtype.UUID        `json:"file_id"`
+	FileType         FileType           `json:"file_type"`
+	LastDownloadedAt pgtype.Timestamptz `json:"last_downloaded_at"`
+	DownloadCount    int64              `json:"download_count"`
+}
+
+// The files behind a page of ListDownloadedListings, most recently downloaded first
+func (q *Queries) ListDownloadedFiles(ctx context.Context, arg ListDownloadedFilesParams) ([]ListDownloadedFilesRow, error) {
+	rows, err := q.db.Query(ctx, listDownloadedFiles, arg.UserID, arg.ListingIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDownloadedFilesRow
+	for rows.Next() {
+		var i ListDownloadedFilesRow
+		if err := rows.Scan(
+			&i.ListingID,
+			&i.FileID,
+			&i.FileType,
+			&i.LastDownloadedAt,
+			&i.DownloadCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDownloadedListings = `-- name: ListDownloadedListings :many
+SELECT listing_id, max(downloaded_at)::timestamptz AS last_downloaded_at
+FROM downloads
+WHERE user_id = $1
+GROUP BY listing_id
+HAVING $2::timestamptz IS NULL
+    OR (max(downloaded_at), listing_id) < ($2, $3::uuid)
+ORDER BY last_downloaded_at DESC, listing_id DESC
+LIMIT $4
+`
+
+type ListDownloadedListingsParams struct {
+	UserID             pgtype.UUID        `json:"user_id"`
+	CursorDownloadedAt pgtype.Timestamptz `json:"cursor_downloaded_at"`
+	CursorListingID    pgtype.UUID        `json:"cursor_listing_id"`
+	PageLimit          int32              `json:"page_limit"`
+}
+
+type ListDownloadedListingsRow struct {
+	ListingID        pgtype.UUID        `json:"listing_id"`
+	LastDownloadedAt pgtype.Timestamptz `json:"last_downloaded_at"`
+}
+
+// A user's downloads grouped by listing, most recently downloaded first. Keyset paginated on
+// (last_downloaded_at, listing_id), pass the last row of the previous page as the cursor.
+func (q *Queries) ListDownloadedListings(ctx context.Context, arg ListDownloadedListingsParams) ([]ListDownloadedListingsRow, error) {
+	rows, err := q.db.Query(ctx, listDownloadedListings,
+		arg.UserID,
+		arg.CursorDownloadedAt,
+		arg.CursorListingID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDownloadedListingsRow
+	for rows.Next() {
+		var i ListDownloadedListingsRow
+		if err := rows.Scan(&i.ListingID, &i.LastDownloadedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeaturedListings = `-- name: ListFeaturedListings :many
+SELECT id, listing_id, starts_at, ends_at, weight, created_by, created_at, updated_at, applied, deleted_at FROM featured_listings
+WHERE deleted_at IS NULL
+ORDER BY ends_at DESC, id ASC
+`
+
+// Every window for the admin view, the ones ending last first
+func (q *Queries) ListFeaturedListings(ctx context.Context) ([]FeaturedListing, error) {
+	rows, err := q.db.Query(ctx, listFeaturedListings)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FeaturedListing
+	for rows.Next() {
+		var i FeaturedListing
+		if err := rows.Scan(
+			&i.ID,
+			&i.ListingID,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.Weight,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Applied,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHardwareOptions = `-- name: ListHardwareOptions :many
+SELECT name FROM hardware_options
+ORDER BY lower(name)
+`
+
+func (q *Queries) ListHardwareOptions(ctx context.Context) ([]string, error) {
+	rows, err := q.db.Query(ctx, listHardwareOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listIndexFailedListings = `-- name: ListIndexFailedListings :many
+SELECT f.listing_id, l.title, l.seller_id, l.seller_username, l.status, f.error_class, f.error, f.attempts, f.failed_at
+FROM listing_index_failures f
+JOIN listings l ON l.id = f.listing_id
+WHERE l.deleted_at IS NULL
+ORDER BY f.failed_at DESC
+LIMIT $1
+`
+
+type ListIndexFailedListingsRow struct {
+	ListingID      pgtype.UUID        `json:"listing_id"`
+	Title          string             `json:"title"`
+	SellerID       pgtype.UUID        `json:"seller_id"`
+	SellerUsername string             `json:"seller_username"`
+	Status         NullListingStatus  `json:"status"`
+	ErrorClass     string             `json:"error_class"`
+	Error          string             `json:"error"`
+	Attempts       int32              `json:"attempts"`
+	FailedAt       pgtype.Timestamptz `json:"failed_at"`
+}
+
+// Newest failures first, for moderators chasing listings that never made it into search
+func (q *Queries) ListIndexFailedListings(ctx context.Context, limit int32) ([]ListIndexFailedListingsRow, error) {
+	rows, err := q.db.Query(ctx, listIndexFailedListings, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListIndexFailedListingsRow
+	for rows.Next() {
+		var i ListIndexFailedListingsRow
+		if err := rows.Scan(
+			&i.ListingID,
+			&i.Title,
+			&i.SellerID,
+			&i.SellerUsername,
+			&i.Status,
+			&i.ErrorClass,
+			&i.Error,
+			&i.Attempts,
+			&i.FailedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listListingVariants = `-- name: ListListingVariants :many
+SELECT v.id, v.listing_id, v.name, v.price_min_unit, v.created_at,
+    COALESCE(array_agg(vf.file_id ORDER BY vf.file_id) FILTER (WHERE vf.file_id IS NOT NULL), '{}')::uuid[] AS file_ids
+FROM listing_variants v
+LEFT JOIN listing_variant_files vf ON vf.variant_id = v.id
+WHERE v.listing_id = ANY($1::uuid[])
+GROUP BY v.id
+ORDER BY v.price_min_unit, v.created_at, v.id
+`
+
+type ListListingVariantsRow struct {
+	ID           pgtype.UUID        `json:"id"`
+	ListingID    pgtype.UUID        `json:"listing_id"`
+	Name         string             `json:"name"`
+	PriceMinUnit int64              `json:"price_min_unit"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	FileIds      []pgtype.UUID      `json:"file_ids"`
+}
+
+// Variants of any of the listings, cheapest first, each with the files it includes
+func (q *Queries) ListListingVariants(ctx context.Context, listingIds []pgtype.UUID) ([]ListListingVariantsRow, error) {
+	rows, err := q.db.Query(ctx, listListingVariants, listingIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListListingVariantsRow
+	for rows.Next() {
+		var i ListListingVariantsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ListingID,
+			&i.Name,
+			&i.PriceMinUnit,
+			&i.CreatedAt,
+			&i.FileIds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOutboxEvents = `-- name: ListOutboxEvents :many
+SELECT id, subject, msg_id, attempts, next_attempt_at, last_error, failed_at, created_at
+FROM event_outbox
+WHERE published_at IS NULL AND (failed_at IS NOT NULL) = $1::boolean
+ORDER BY id
+LIMIT $2
+`
+
+type ListOutboxEventsParams struct {
+	Failed   bool  `json:"failed"`
+	RowLimit int32 `json:"row_limit"`
+}
+
+type ListOutboxEventsRow struct {
+	ID            int64              `json:"id"`
+	Subject       string             `json:"subject"`
+	MsgID         string             `json:"msg_id"`
+	Attempts      int32              `json:"attempts"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	LastError     pgtype.Text        `json:"last_error"`
+	FailedAt      pgtype.Timestamptz `json:"failed_at"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+}
+
+// Unpublished events, the ones given up on when failed is set, oldest first
+func (q *Queries) ListOutboxEvents(ctx context.Context, arg ListOutboxEventsParams) ([]ListOutboxEventsRow, error) {
+	rows, err := q.db.Query(ctx, listOutboxEvents, arg.Failed, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOutboxEventsRow
+	for rows.Next() {
+		var i ListOutboxEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Subject,
+			&i.MsgID,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.FailedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSavedSearches = `-- name: ListSavedSearches :many
+SELECT id, user_id, query, filters, filter_by, notified_listing_ids, last_checked_at, next_check_at, created_at FROM saved_searches
+WHERE user_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListSavedSearches(ctx context.Context, userID pgtype.UUID) ([]SavedSearch, error) {
+	rows, err := q.db.Query(ctx, listSavedSearches, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SavedSearch
+	for rows.Next() {
+		var i SavedSearch
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Query,
+			&i.Filters,
+			&i.FilterBy,
+			&i.NotifiedListingIds,
+			&i.LastCheckedAt,
+			&i.NextCheckAt,
+			&i.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -678,66 +3172,50 @@ func (q *Queries) GetListingsBySellerID(ctx context.Context, sellerID pgtype.UUI
 	return items, nil
 }
 
-const getListingsForSync = `-- name: GetListingsForSync :many
-SELECT id, seller_id, seller_name, seller_username, seller_verified, title, description, price_min_unit, currency, categories, license, client_id, trace_id, thumbnail_path, last_indexed_at, status, is_remixing_allowed, parent_listing_id, is_physical, total_weight_grams, is_assembly_required, is_hardware_required, hardware_required, is_multicolor, dimensions_mm, recommended_nozzle_temp_c, recommended_materials, is_ai_generated, ai_model_name, likes_count, downloads_count, comments_count, is_sale_active, sale_price, sale_name, sale_end_timestamp, seller_rating_average, seller_total_ratings, seller_total_sales, is_nsfw, created_at, updated_at, deleted_at FROM listings
-WHERE (last_indexed_at IS NULL OR updated_at > last_indexed_at)
-LIMIT $1
+const listTrendingListingsInCategory = `-- name: ListTrendingListingsInCategory :many
+SELECT l.id, l.title, l.seller_username, l.thumbnail_path, l.price_min_unit, l.currency, count(d.id) AS recent_downloads
+FROM listings l
+JOIN downloads d ON d.listing_id = l.id AND d.downloaded_at >= $1
+WHERE l.status = 'ACTIVE' AND l.deleted_at IS NULL AND $2::text = ANY(l.categories)
+GROUP BY l.id
+ORDER BY recent_downloads DESC, l.downloads_count DESC, l.id
+LIMIT $3
 `
 
-// Finds all listings that are new OR have been updated since the last sync
-func (q *Queries) GetListingsForSync(ctx context.Context, limit int32) ([]Listing, error) {
-	rows, err := q.db.Query(ctx, getListingsForSync, limit)
+type ListTrendingListingsInCategoryParams struct {
+	Since     pgtype.Timestamptz `json:"since"`
+	Category  string             `json:"category"`
+	PageLimit int32              `json:"page_limit"`
+}
+
+type ListTrendingListingsInCategoryRow struct {
+	ID              pgtype.UUID `json:"id"`
+	Title           string      `json:"title"`
+	SellerUsername  string      `json:"seller_username"`
+	ThumbnailPath   pgtype.Text `json:"thumbnail_path"`
+	PriceMinUnit    int64       `json:"price_min_unit"`
+	Currency        string      `json:"currency"`
+	RecentDownloads int64       `json:"recent_downloads"`
+}
+
+// Active listings in a category by downloads since @since, the all-time count breaks ties
+func (q *Queries) ListTrendingListingsInCategory(ctx context.Context, arg ListTrendingListingsInCategoryParams) ([]ListTrendingListingsInCategoryRow, error) {
+	rows, err := q.db.Query(ctx, listTrendingListingsInCategory, arg.Since, arg.Category, arg.PageLimit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Listing
+	var items []ListTrendingListingsInCategoryRow
 	for rows.Next() {
-		var i Listing
+		var i ListTrendingListingsInCategoryRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.SellerID,
-			&i.SellerName,
-			&i.SellerUsername,
-			&i.SellerVerified,
 			&i.Title,
-			&i.Description,
+			&i.SellerUsername,
+			&i.ThumbnailPath,
 			&i.PriceMinUnit,
 			&i.Currency,
-			&i.Categories,
-			&i.License,
-			&i.ClientID,
-			&i.TraceID,
-			&i.ThumbnailPath,
-			&i.LastIndexedAt,
-			&i.Status,
-			&i.IsRemixingAllowed,
-			&i.ParentListingID,
-			&i.IsPhysical,
-			&i.TotalWeightGrams,
-			&i.IsAssemblyRequired,
-			&i.IsHardwareRequired,
-			&i.HardwareRequired,
-			&i.IsMulticolor,
-			&i.DimensionsMm,
-			&i.RecommendedNozzleTempC,
-			&i.RecommendedMaterials,
-			&i.IsAiGenerated,
-			&i.AiModelName,
-			&i.LikesCount,
-			&i.DownloadsCount,
-			&i.CommentsCount,
-			&i.IsSaleActive,
-			&i.SalePrice,
-			&i.SaleName,
-			&i.SaleEndTimestamp,
-			&i.SellerRatingAverage,
-			&i.SellerTotalRatings,
-			&i.SellerTotalSales,
-			&i.IsNsfw,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.DeletedAt,
+			&i.RecentDownloads,
 		); err != nil {
 			return nil, err
 		}
@@ -749,10 +3227,32 @@ func (q *Queries) GetListingsForSync(ctx context.Context, limit int32) ([]Listin
 	return items, nil
 }
 
+const lockSellerPins = `-- name: LockSellerPins :exec
+SELECT pg_advisory_xact_lock(hashtextextended('seller_pins:' || $1::uuid::text, 0))
+`
+
+// Serializes changes to a seller's pins, run it first in their transaction. An advisory lock on the ID rather than
+// the sellers row, sellers without a profile have none to lock. Released with the transaction.
+func (q *Queries) LockSellerPins(ctx context.Context, sellerID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, lockSellerPins, sellerID)
+	return err
+}
+
+const lockUserSavedSearches = `-- name: LockUserSavedSearches :exec
+SELECT pg_advisory_xact_lock(hashtextextended('saved_searches:' || $1::uuid::text, 0))
+`
+
+// Serializes a user's saves so two can't both pass the limit, run it first in their transaction. Released with the
+// transaction.
+func (q *Queries) LockUserSavedSearches(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, lockUserSavedSearches, userID)
+	return err
+}
+
 const markListingAsIndexed = `-- name: MarkListingAsIndexed :exec
 UPDATE listings 
 SET last_indexed_at = CURRENT_TIMESTAMP
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
 `
 
 // The worker calls this AFTER successfully pushing to Typesense
@@ -761,6 +3261,253 @@ func (q *Queries) MarkListingAsIndexed(ctx context.Context, id pgtype.UUID) erro
 	return err
 }
 
+const markOutboxEventPublished = `-- name: MarkOutboxEventPublished :exec
+UPDATE event_outbox
+SET published_at = $1::timestamptz, attempts = attempts + 1, last_error = NULL
+WHERE id = $2
+`
+
+type MarkOutboxEventPublishedParams struct {
+	Now pgtype.Timestamptz `json:"now"`
+	ID  int64              `json:"id"`
+}
+
+func (q *Queries) MarkOutboxEventPublished(ctx context.Context, arg MarkOutboxEventPublishedParams) error {
+	_, err := q.db.Exec(ctx, markOutboxEventPublished, arg.Now, arg.ID)
+	return err
+}
+
+const recordListingIndexFailure = `-- name: RecordListingIndexFailure :execrows
+INSERT INTO listing_index_failures (listing_id, error_class, error, attempts, failed_at)
+SELECT l.id, $1::text, $2::text, $3::integer, $4::timestamptz
+FROM listings l
+WHERE l.id = $5 AND (l.last_indexed_at IS NULL OR l.last_indexed_at < $4::timestamptz)
+ON CONFLICT (listing_id) DO UPDATE SET
+    error_class = EXCLUDED.error_class,
+    error = EXCLUDED.error,
+    attempts = EXCLUDED.attempts,
+    failed_at = EXCLUDED.failed_at
+WHERE listing_index_failures.failed_at < EXCLUDED.failed_at
+`
+
+type RecordListingIndexFailureParams struct {
+	ErrorClass string             `json:"error_class"`
+	Error      string             `json:"error"`
+	Attempts   int32              `json:"attempts"`
+	FailedAt   pgtype.Timestamptz `json:"failed_at"`
+	ListingID  pgtype.UUID        `json:"listing_id"`
+}
+
+// Does nothing when the listing was indexed again after it failed, i.e. the event arrived late, or no longer exists
+func (q *Queries) RecordListingIndexFailure(ctx context.Context, arg RecordListingIndexFailureParams) (int64, error) {
+	result, err := q.db.Exec(ctx, recordListingIndexFailure,
+		arg.ErrorClass,
+		arg.Error,
+		arg.Attempts,
+		arg.FailedAt,
+		arg.ListingID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const recordOutboxEventFailure = `-- name: RecordOutboxEventFailure :exec
+UPDATE event_outbox
+SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2,
+    failed_at = $3
+WHERE id = $4
+`
+
+type RecordOutboxEventFailureParams struct {
+	LastError     pgtype.Text        `json:"last_error"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	FailedAt      pgtype.Timestamptz `json:"failed_at"`
+	ID            int64              `json:"id"`
+}
+
+// A failed publish, tried again at next_attempt_at unless failed_at gives up on it
+func (q *Queries) RecordOutboxEventFailure(ctx context.Context, arg RecordOutboxEventFailureParams) error {
+	_, err := q.db.Exec(ctx, recordOutboxEventFailure,
+		arg.LastError,
+		arg.NextAttemptAt,
+		arg.FailedAt,
+		arg.ID,
+	)
+	return err
+}
+
+const retryOutboxEvent = `-- name: RetryOutboxEvent :one
+UPDATE event_outbox
+SET failed_at = NULL, attempts = 0, next_attempt_at = $1::timestamptz
+WHERE id = $2 AND published_at IS NULL
+RETURNING id, subject, msg_id, attempts, next_attempt_at, last_error, failed_at, created_at
+`
+
+type RetryOutboxEventParams struct {
+	Now pgtype.Timestamptz `json:"now"`
+	ID  int64              `json:"id"`
+}
+
+type RetryOutboxEventRow struct {
+	ID            int64              `json:"id"`
+	Subject       string             `json:"subject"`
+	MsgID         string             `json:"msg_id"`
+	Attempts      int32              `json:"attempts"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	LastError     pgtype.Text        `json:"last_error"`
+	FailedAt      pgtype.Timestamptz `json:"failed_at"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+}
+
+// Puts an unpublished event back in line for the relay's next pass with a fresh run of attempts. The last error stays
+// until it goes out.
+func (q *Queries) RetryOutboxEvent(ctx context.Context, arg RetryOutboxEventParams) (RetryOutboxEventRow, error) {
+	row := q.db.QueryRow(ctx, retryOutboxEvent, arg.Now, arg.ID)
+	var i RetryOutboxEventRow
+	err := row.Scan(
+		&i.ID,
+		&i.Subject,
+		&i.MsgID,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.FailedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const revokeShortLink = `-- name: RevokeShortLink :execrows
+UPDATE short_links SET revoked_at = CURRENT_TIMESTAMP
+WHERE code = $1 AND listing_id = $2 AND revoked_at IS NULL
+`
+
+type RevokeShortLinkParams struct {
+	Code      string      `json:"code"`
+	ListingID pgtype.UUID `json:"listing_id"`
+}
+
+func (q *Queries) RevokeShortLink(ctx context.Context, arg RevokeShortLinkParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeShortLink, arg.Code, arg.ListingID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setFileValidationResult = `-- name: SetFileValidationResult :exec
+UPDATE listing_files
+SET
+    status = $1,
+    error_message = $2,
+    metadata = COALESCE($3, metadata),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $4
+`
+
+type SetFileValidationResultParams struct {
+	Status       NullFileStatus `json:"status"`
+	ErrorMessage pgtype.Text    `json:"error_message"`
+	Metadata     []byte         `json:"metadata"`
+	ID           pgtype.UUID    `json:"id"`
+}
+
+// A result without metadata keeps what the file has
+func (q *Queries) SetFileValidationResult(ctx context.Context, arg SetFileValidationResultParams) error {
+	_, err := q.db.Exec(ctx, setFileValidationResult,
+		arg.Status,
+		arg.ErrorMessage,
+		arg.Metadata,
+		arg.ID,
+	)
+	return err
+}
+
+const setListingCounters = `-- name: SetListingCounters :exec
+UPDATE listings
+    SET likes_count = $2, downloads_count = $3, views_count = $4
+    WHERE id = $1
+`
+
+type SetListingCountersParams struct {
+	ID             pgtype.UUID `json:"id"`
+	LikesCount     int32       `json:"likes_count"`
+	DownloadsCount int32       `json:"downloads_count"`
+	ViewsCount     int32       `json:"views_count"`
+}
+
+func (q *Queries) SetListingCounters(ctx context.Context, arg SetListingCountersParams) error {
+	_, err := q.db.Exec(ctx, setListingCounters,
+		arg.ID,
+		arg.LikesCount,
+		arg.DownloadsCount,
+		arg.ViewsCount,
+	)
+	return err
+}
+
+const setListingNsfw = `-- name: SetListingNsfw :execrows
+UPDATE listings
+    SET is_nsfw = $2, updated_at = CURRENT_TIMESTAMP
+    WHERE id = $1 AND deleted_at IS NULL
+`
+
+type SetListingNsfwParams struct {
+	ID     pgtype.UUID `json:"id"`
+	IsNsfw bool        `json:"is_nsfw"`
+}
+
+// Moderators only, sellers set it when they create the listing and can't change it after
+func (q *Queries) SetListingNsfw(ctx context.Context, arg SetListingNsfwParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setListingNsfw, arg.ID, arg.IsNsfw)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setListingSale = `-- name: SetListingSale :exec
+UPDATE listings
+    SET is_sale_active = TRUE, sale_price = $2, sale_name = $3, sale_end_timestamp = $4
+    WHERE id = $1
+`
+
+type SetListingSaleParams struct {
+	ID               pgtype.UUID        `json:"id"`
+	SalePrice        pgtype.Int8        `json:"sale_price"`
+	SaleName         pgtype.Text        `json:"sale_name"`
+	SaleEndTimestamp pgtype.Timestamptz `json:"sale_end_timestamp"`
+}
+
+func (q *Queries) SetListingSale(ctx context.Context, arg SetListingSaleParams) error {
+	_, err := q.db.Exec(ctx, setListingSale,
+		arg.ID,
+		arg.SalePrice,
+		arg.SaleName,
+		arg.SaleEndTimestamp,
+	)
+	return err
+}
+
+const setListingStatus = `-- name: SetListingStatus :exec
+UPDATE listings
+    SET status = $2, updated_at = CURRENT_TIMESTAMP
+    WHERE id = $1
+`
+
+type SetListingStatusParams struct {
+	ID     pgtype.UUID       `json:"id"`
+	Status NullListingStatus `json:"status"`
+}
+
+// Must run in the same transaction as the CreateListingStatusEvent that records it
+func (q *Queries) SetListingStatus(ctx context.Context, arg SetListingStatusParams) error {
+	_, err := q.db.Exec(ctx, setListingStatus, arg.ID, arg.Status)
+	return err
+}
+
 const softDeleteFile = `-- name: SoftDeleteFile :exec
 UPDATE listing_files
 SET deleted_at = CURRENT_TIMESTAMP
@@ -775,8 +3522,8 @@ func (q *Queries) SoftDeleteFile(ctx context.Context, id pgtype.UUID) error {
 const softDeleteListing = `-- name: SoftDeleteListing :one
 UPDATE listings 
     SET deleted_at = CURRENT_TIMESTAMP
-    WHERE id = $1 AND seller_id = $2 -- Ensure seller owns it before deleting
-    RETURNING id, seller_id, seller_name, seller_username, seller_verified, title, description, price_min_unit, currency, categories, license, client_id, trace_id, thumbnail_path, last_indexed_at, status, is_remixing_allowed, parent_listing_id, is_physical, total_weight_grams, is_assembly_required, is_hardware_required, hardware_required, is_multicolor, dimensions_mm, recommended_nozzle_temp_c, recommended_materials, is_ai_generated, ai_model_name, likes_count, downloads_count, comments_count, is_sale_active, sale_price, sale_name, sale_end_timestamp, seller_rating_average, seller_total_ratings, seller_total_sales, is_nsfw, created_at, updated_at, deleted_at
+    WHERE id = $1 AND seller_id = $2 AND deleted_at IS NULL -- Ensure seller owns it before deleting
+    RETURNING id, seller_id, seller_name, seller_username, seller_verified, title, description, price_min_unit, currency, categories, license, thumbnail_path, last_indexed_at, status, is_remixing_allowed, parent_listing_id, is_physical, total_weight_grams, is_assembly_required, is_hardware_required, hardware_required, is_multicolor, dimensions_mm, recommended_nozzle_temp_c, recommended_materials, is_ai_generated, ai_model_name, likes_count, downloads_count, comments_count, is_sale_active, sale_price, sale_name, sale_end_timestamp, seller_rating_average, seller_total_ratings, seller_total_sales, is_nsfw, created_at, updated_at, deleted_at, views_count, nozzle_diameter_mm, language
 `
 
 type SoftDeleteListingParams struct {
@@ -799,8 +3546,6 @@ func (q *Queries) SoftDeleteListing(ctx context.Context, arg SoftDeleteListingPa
 		&i.Currency,
 		&i.Categories,
 		&i.License,
-		&i.ClientID,
-		&i.TraceID,
 		&i.ThumbnailPath,
 		&i.LastIndexedAt,
 		&i.Status,
@@ -831,6 +3576,114 @@ func (q *Queries) SoftDeleteListing(ctx context.Context, arg SoftDeleteListingPa
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.ViewsCount,
+		&i.NozzleDiameterMm,
+		&i.Language,
+	)
+	return i, err
+}
+
+const softDeleteListings = `-- name: SoftDeleteListings :exec
+UPDATE listings
+    SET deleted_at = CURRENT_TIMESTAMP
+    WHERE id = ANY($1::uuid[]) AND seller_id = $2 AND deleted_at IS NULL
+`
+
+type SoftDeleteListingsParams struct {
+	Ids      []pgtype.UUID `json:"ids"`
+	SellerID pgtype.UUID   `json:"seller_id"`
+}
+
+func (q *Queries) SoftDeleteListings(ctx context.Context, arg SoftDeleteListingsParams) error {
+	_, err := q.db.Exec(ctx, softDeleteListings, arg.Ids, arg.SellerID)
+	return err
+}
+
+const startSellerVacation = `-- name: StartSellerVacation :one
+UPDATE sellers
+SET vacation_starts_at = $1, vacation_ends_at = $2, vacation_message = $3
+WHERE user_id = $4
+RETURNING user_id, display_name, country, payout_status, accepted_terms_version, accepted_terms_at, created_at, updated_at, vacation_starts_at, vacation_ends_at, vacation_message, vacation_applied, last_active_at
+`
+
+type StartSellerVacationParams struct {
+	StartsAt pgtype.Timestamptz `json:"starts_at"`
+	EndsAt   pgtype.Timestamptz `json:"ends_at"`
+	Message  pgtype.Text        `json:"message"`
+	UserID   pgtype.UUID        `json:"user_id"`
+}
+
+// Replaces any vacation already booked, vacation_applied is the listings worker's to reconcile
+func (q *Queries) StartSellerVacation(ctx context.Context, arg StartSellerVacationParams) (Seller, error) {
+	row := q.db.QueryRow(ctx, startSellerVacation,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.Message,
+		arg.UserID,
+	)
+	var i Seller
+	err := row.Scan(
+		&i.UserID,
+		&i.DisplayName,
+		&i.Country,
+		&i.PayoutStatus,
+		&i.AcceptedTermsVersion,
+		&i.AcceptedTermsAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VacationStartsAt,
+		&i.VacationEndsAt,
+		&i.VacationMessage,
+		&i.VacationApplied,
+		&i.LastActiveAt,
+	)
+	return i, err
+}
+
+const touchSellerLastActive = `-- name: TouchSellerLastActive :exec
+UPDATE sellers SET last_active_at = CURRENT_TIMESTAMP
+WHERE user_id = $1
+`
+
+// Users without a seller profile have nothing to update
+func (q *Queries) TouchSellerLastActive(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, touchSellerLastActive, userID)
+	return err
+}
+
+const updateFeaturedListing = `-- name: UpdateFeaturedListing :one
+UPDATE featured_listings
+SET starts_at = $1, ends_at = $2, weight = $3, updated_at = CURRENT_TIMESTAMP
+WHERE id = $4 AND deleted_at IS NULL
+RETURNING id, listing_id, starts_at, ends_at, weight, created_by, created_at, updated_at, applied, deleted_at
+`
+
+type UpdateFeaturedListingParams struct {
+	StartsAt pgtype.Timestamptz `json:"starts_at"`
+	EndsAt   pgtype.Timestamptz `json:"ends_at"`
+	Weight   int32              `json:"weight"`
+	ID       pgtype.UUID        `json:"id"`
+}
+
+func (q *Queries) UpdateFeaturedListing(ctx context.Context, arg UpdateFeaturedListingParams) (FeaturedListing, error) {
+	row := q.db.QueryRow(ctx, updateFeaturedListing,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.Weight,
+		arg.ID,
+	)
+	var i FeaturedListing
+	err := row.Scan(
+		&i.ID,
+		&i.ListingID,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.Weight,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Applied,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -864,34 +3717,39 @@ UPDATE listings SET
     currency = $5,
     categories = $6,
     license = $7,
-    client_id = $8,
-    trace_id = $9,
-    thumbnail_path = $10,
-    status = $11,
+    thumbnail_path = $8,
+    status = $9,
     
     -- Update Remixing
-    is_remixing_allowed = $12,
+    is_remixing_allowed = $10,
 
     -- Update Physical Properties
-    is_physical = $13,
-    total_weight_grams = $14,
-    is_assembly_required = $15,
-    is_hardware_required = $16,
-    hardware_required = $17,
-    is_multicolor = $18,
-    dimensions_mm = $19,
-    recommended_nozzle_temp_c = $20,
-    recommended_materials = $21,
+    is_physical = $11,
+    total_weight_grams = $12,
+    is_assembly_required = $13,
+    is_hardware_required = $14,
+    hardware_required = $15,
+    is_multicolor = $16,
+    dimensions_mm = $17,
+    recommended_nozzle_temp_c = $18,
+    recommended_materials = $19,
 
     -- Update AI Info
-    is_ai_generated = $22,
-    ai_model_name = $23,
+    is_ai_generated = $20,
+    ai_model_name = $21,
+
+    nozzle_diameter_mm = $22,
+    language = $23,
+
+    -- Update Sale, the flag and end date are only set by seed data so far
+    sale_name = $24,
+    sale_price = $25,
     
     -- Always update timestamp
     updated_at = CURRENT_TIMESTAMP
 
 WHERE id = $1 AND deleted_at IS NULL
-RETURNING id, seller_id, seller_name, seller_username, seller_verified, title, description, price_min_unit, currency, categories, license, client_id, trace_id, thumbnail_path, last_indexed_at, status, is_remixing_allowed, parent_listing_id, is_physical, total_weight_grams, is_assembly_required, is_hardware_required, hardware_required, is_multicolor, dimensions_mm, recommended_nozzle_temp_c, recommended_materials, is_ai_generated, ai_model_name, likes_count, downloads_count, comments_count, is_sale_active, sale_price, sale_name, sale_end_timestamp, seller_rating_average, seller_total_ratings, seller_total_sales, is_nsfw, created_at, updated_at, deleted_at
+RETURNING id, seller_id, seller_name, seller_username, seller_verified, title, description, price_min_unit, currency, categories, license, thumbnail_path, last_indexed_at, status, is_remixing_allowed, parent_listing_id, is_physical, total_weight_grams, is_assembly_required, is_hardware_required, hardware_required, is_multicolor, dimensions_mm, recommended_nozzle_temp_c, recommended_materials, is_ai_generated, ai_model_name, likes_count, downloads_count, comments_count, is_sale_active, sale_price, sale_name, sale_end_timestamp, seller_rating_average, seller_total_ratings, seller_total_sales, is_nsfw, created_at, updated_at, deleted_at, views_count, nozzle_diameter_mm, language
 `
 
 type UpdateListingParams struct {
@@ -902,8 +3760,6 @@ type UpdateListingParams struct {
 	Currency               string            `json:"currency"`
 	Categories             []string          `json:"categories"`
 	License                string            `json:"license"`
-	ClientID               string            `json:"client_id"`
-	TraceID                string            `json:"trace_id"`
 	ThumbnailPath          pgtype.Text       `json:"thumbnail_path"`
 	Status                 NullListingStatus `json:"status"`
 	IsRemixingAllowed      bool              `json:"is_remixing_allowed"`
@@ -918,6 +3774,10 @@ type UpdateListingParams struct {
 	RecommendedMaterials   []string          `json:"recommended_materials"`
 	IsAiGenerated          bool              `json:"is_ai_generated"`
 	AiModelName            pgtype.Text       `json:"ai_model_name"`
+	NozzleDiameterMm       pgtype.Numeric    `json:"nozzle_diameter_mm"`
+	Language               string            `json:"language"`
+	SaleName               pgtype.Text       `json:"sale_name"`
+	SalePrice              pgtype.Int8       `json:"sale_price"`
 }
 
 func (q *Queries) UpdateListing(ctx context.Context, arg UpdateListingParams) (Listing, error) {
@@ -929,8 +3789,6 @@ func (q *Queries) UpdateListing(ctx context.Context, arg UpdateListingParams) (L
 		arg.Currency,
 		arg.Categories,
 		arg.License,
-		arg.ClientID,
-		arg.TraceID,
 		arg.ThumbnailPath,
 		arg.Status,
 		arg.IsRemixingAllowed,
@@ -945,6 +3803,10 @@ func (q *Queries) UpdateListing(ctx context.Context, arg UpdateListingParams) (L
 		arg.RecommendedMaterials,
 		arg.IsAiGenerated,
 		arg.AiModelName,
+		arg.NozzleDiameterMm,
+		arg.Language,
+		arg.SaleName,
+		arg.SalePrice,
 	)
 	var i Listing
 	err := row.Scan(
@@ -959,8 +3821,6 @@ func (q *Queries) UpdateListing(ctx context.Context, arg UpdateListingParams) (L
 		&i.Currency,
 		&i.Categories,
 		&i.License,
-		&i.ClientID,
-		&i.TraceID,
 		&i.ThumbnailPath,
 		&i.LastIndexedAt,
 		&i.Status,
@@ -991,6 +3851,152 @@ func (q *Queries) UpdateListing(ctx context.Context, arg UpdateListingParams) (L
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.ViewsCount,
+		&i.NozzleDiameterMm,
+		&i.Language,
+	)
+	return i, err
+}
+
+const upsertBannedTerm = `-- name: UpsertBannedTerm :one
+INSERT INTO banned_terms (term, severity, updated_by)
+VALUES ($1, $2, $3)
+ON CONFLICT (term) DO UPDATE SET
+    severity = EXCLUDED.severity,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING term, severity, updated_by, updated_at
+`
+
+type UpsertBannedTermParams struct {
+	Term      string             `json:"term"`
+	Severity  BannedTermSeverity `json:"severity"`
+	UpdatedBy pgtype.UUID        `json:"updated_by"`
+}
+
+// Adds the term, or changes the severity of one already on the list
+func (q *Queries) UpsertBannedTerm(ctx context.Context, arg UpsertBannedTermParams) (BannedTerm, error) {
+	row := q.db.QueryRow(ctx, upsertBannedTerm, arg.Term, arg.Severity, arg.UpdatedBy)
+	var i BannedTerm
+	err := row.Scan(
+		&i.Term,
+		&i.Severity,
+		&i.UpdatedBy,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertCategoryDefaults = `-- name: UpsertCategoryDefaults :one
+INSERT INTO category_defaults (category, recommended_materials, nozzle_temp_min_c, nozzle_temp_max_c, typical_dimensions_mm, updated_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (category) DO UPDATE SET
+    recommended_materials = EXCLUDED.recommended_materials,
+    nozzle_temp_min_c = EXCLUDED.nozzle_temp_min_c,
+    nozzle_temp_max_c = EXCLUDED.nozzle_temp_max_c,
+    typical_dimensions_mm = EXCLUDED.typical_dimensions_mm,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING category, recommended_materials, nozzle_temp_min_c, nozzle_temp_max_c, typical_dimensions_mm, updated_by, updated_at
+`
+
+type UpsertCategoryDefaultsParams struct {
+	Category             string      `json:"category"`
+	RecommendedMaterials []string    `json:"recommended_materials"`
+	NozzleTempMinC       int32       `json:"nozzle_temp_min_c"`
+	NozzleTempMaxC       int32       `json:"nozzle_temp_max_c"`
+	TypicalDimensionsMm  []byte      `json:"typical_dimensions_mm"`
+	UpdatedBy            pgtype.UUID `json:"updated_by"`
+}
+
+func (q *Queries) UpsertCategoryDefaults(ctx context.Context, arg UpsertCategoryDefaultsParams) (CategoryDefault, error) {
+	row := q.db.QueryRow(ctx, upsertCategoryDefaults,
+		arg.Category,
+		arg.RecommendedMaterials,
+		arg.NozzleTempMinC,
+		arg.NozzleTempMaxC,
+		arg.TypicalDimensionsMm,
+		arg.UpdatedBy,
+	)
+	var i CategoryDefault
+	err := row.Scan(
+		&i.Category,
+		&i.RecommendedMaterials,
+		&i.NozzleTempMinC,
+		&i.NozzleTempMaxC,
+		&i.TypicalDimensionsMm,
+		&i.UpdatedBy,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertListingScreeningFlags = `-- name: UpsertListingScreeningFlags :exec
+INSERT INTO listing_screening_flags (listing_id, terms)
+VALUES ($1, $2)
+ON CONFLICT (listing_id) DO UPDATE SET
+    terms = EXCLUDED.terms,
+    flagged_at = CURRENT_TIMESTAMP
+`
+
+type UpsertListingScreeningFlagsParams struct {
+	ListingID pgtype.UUID `json:"listing_id"`
+	Terms     []string    `json:"terms"`
+}
+
+// Replaces the flagged terms recorded for the listing
+func (q *Queries) UpsertListingScreeningFlags(ctx context.Context, arg UpsertListingScreeningFlagsParams) error {
+	_, err := q.db.Exec(ctx, upsertListingScreeningFlags, arg.ListingID, arg.Terms)
+	return err
+}
+
+const upsertSellerProfile = `-- name: UpsertSellerProfile :one
+INSERT INTO sellers (
+    user_id, display_name, country, accepted_terms_version, accepted_terms_at
+) VALUES (
+    $1, $2, $3, $4, CURRENT_TIMESTAMP
+)
+ON CONFLICT (user_id) DO UPDATE SET
+    display_name = EXCLUDED.display_name,
+    country = EXCLUDED.country,
+    accepted_terms_version = EXCLUDED.accepted_terms_version,
+    accepted_terms_at = CASE
+        WHEN sellers.accepted_terms_version IS DISTINCT FROM EXCLUDED.accepted_terms_version THEN EXCLUDED.accepted_terms_at
+        ELSE sellers.accepted_terms_at
+    END
+RETURNING user_id, display_name, country, payout_status, accepted_terms_version, accepted_terms_at, created_at, updated_at, vacation_starts_at, vacation_ends_at, vacation_message, vacation_applied, last_active_at
+`
+
+type UpsertSellerProfileParams struct {
+	UserID               pgtype.UUID `json:"user_id"`
+	DisplayName          string      `json:"display_name"`
+	Country              string      `json:"country"`
+	AcceptedTermsVersion pgtype.Text `json:"accepted_terms_version"`
+}
+
+// Re-submitting the form updates the profile, payout_status is owned by billing and never touched here
+func (q *Queries) UpsertSellerProfile(ctx context.Context, arg UpsertSellerProfileParams) (Seller, error) {
+	row := q.db.QueryRow(ctx, upsertSellerProfile,
+		arg.UserID,
+		arg.DisplayName,
+		arg.Country,
+		arg.AcceptedTermsVersion,
+	)
+	var i Seller
+	err := row.Scan(
+		&i.UserID,
+		&i.DisplayName,
+		&i.Country,
+		&i.PayoutStatus,
+		&i.AcceptedTermsVersion,
+		&i.AcceptedTermsAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VacationStartsAt,
+		&i.VacationEndsAt,
+		&i.VacationMessage,
+		&i.VacationApplied,
+		&i.LastActiveAt,
 	)
 	return i, err
 }
@@ -0,0 +1,287 @@
+//go:build integration
+
+package gateway_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+	"shared/testinfra"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var db *pgxpool.Pool
+
+func TestMain(m *testing.M) {
+	os.Exit(runIntegration(m))
+}
+
+func runIntegration(m *testing.M) int {
+	suite, err := testinfra.NewSuite()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "integration: ", err)
+		return 1
+	}
+	defer suite.Close()
+
+	dsn, err := suite.Postgres("../../../../../../db/migrations")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "integration: ", err)
+		return 1
+	}
+	db, err = pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "integration: ", err)
+		return 1
+	}
+	defer db.Close()
+
+	return m.Run()
+}
+
+// --- FIXTURES ---
+
+// softDeleteFixture is one seller with a live listing and a soft-deleted one. Both are ACTIVE with a VALID file and
+// share a title and category, so a query that forgets deleted_at returns or counts the deleted one too.
+type softDeleteFixture struct {
+	sellerID    pgtype.UUID
+	live        repo.Listing
+	deleted     repo.Listing
+	deletedFile repo.ListingFile
+}
+
+func newSoftDeleteFixture(t *testing.T, q *repo.Queries) softDeleteFixture {
+	t.Helper()
+	ctx := context.Background()
+
+	var sellerID pgtype.UUID
+	require.NoError(t, sellerID.Scan("a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"))
+
+	create := func(path string) (repo.Listing, repo.ListingFile) {
+		listing, err := q.CreateListing(ctx, repo.CreateListingParams{
+			SellerID:       sellerID,
+			SellerName:     "Tester Prints",
+			SellerUsername: "tester",
+			Title:          "Benchy",
+			Description:    pgtype.Text{String: "The classic", Valid: true},
+			PriceMinUnit:   500,
+			Currency:       "gbp",
+			Categories:     []string{"Toys"},
+			License:        "MIT",
+			Status:         repo.NullListingStatus{ListingStatus: repo.ListingStatusACTIVE, Valid: true},
+		})
+		require.NoError(t, err)
+
+		file, err := q.CreateListingFile(ctx, repo.CreateListingFileParams{
+			ListingID: listing.ID,
+			FilePath:  path,
+			FileType:  repo.FileTypeMODEL,
+			Metadata:  []byte(`{}`),
+			Status:    repo.NullFileStatus{FileStatus: repo.FileStatusVALID, Valid: true},
+		})
+		require.NoError(t, err)
+		return listing, file
+	}
+
+	live, _ := create("2025/01/01/" + t.Name() + "/live/model.stl")
+	deleted, deletedFile := create("2025/01/01/" + t.Name() + "/deleted/model.stl")
+
+	_, err := q.SoftDeleteListing(ctx, repo.SoftDeleteListingParams{ID: deleted.ID, SellerID: sellerID})
+	require.NoError(t, err)
+
+	return softDeleteFixture{sellerID: sellerID, live: live, deleted: deleted, deletedFile: deletedFile}
+}
+
+// --- TESTS ---
+
+func TestQueries_ExcludeSoftDeletedListings(t *testing.T) {
+	// SCENARIO: A seller has one live and one soft-deleted listing.
+	// EXPECT: Every query behind a public endpoint, a cache fill or the index ignores the deleted one.
+
+	ctx := context.Background()
+	tx, err := db.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+
+	q := repo.New(db).WithTx(tx)
+	f := newSoftDeleteFixture(t, q)
+
+	t.Run("GetListingByID", func(t *testing.T) {
+		_, err := q.GetListingByID(ctx, f.deleted.ID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("GetListingByIDWithFiles", func(t *testing.T) {
+		_, err := q.GetListingByIDWithFiles(ctx, f.deleted.ID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("GetListingByIDForUpdate", func(t *testing.T) {
+		_, err := q.GetListingByIDForUpdate(ctx, f.deleted.ID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("GetListingsBySellerID", func(t *testing.T) {
+		rows, err := q.GetListingsBySellerID(ctx, repo.GetListingsBySellerIDParams{SellerID: f.sellerID, SortColumn: "created_at", SortDesc: true})
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		assert.Equal(t, f.live.ID, rows[0].ID)
+	})
+
+	t.Run("CountActiveListings", func(t *testing.T) {
+		count, err := q.CountActiveListings(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("CountActiveListingsByCategory", func(t *testing.T) {
+		rows, err := q.CountActiveListingsByCategory(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []repo.CountActiveListingsByCategoryRow{{Category: "Toys", ListingsCount: 1}}, rows)
+	})
+
+	t.Run("CountRecentDuplicateListings", func(t *testing.T) {
+		count, err := q.CountRecentDuplicateListings(ctx, repo.CountRecentDuplicateListingsParams{
+			SellerID:    f.sellerID,
+			Since:       pgtype.Timestamptz{Time: time.Now().Add(-time.Hour), Valid: true},
+			Title:       "Benchy",
+			Description: "The classic",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("GetListingsForBulkUpdate", func(t *testing.T) {
+		rows, err := q.GetListingsForBulkUpdate(ctx, []pgtype.UUID{f.live.ID, f.deleted.ID})
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		assert.Equal(t, f.live.ID, rows[0].ID)
+	})
+
+	t.Run("GetListingFileForDownload", func(t *testing.T) {
+		_, err := q.GetListingFileForDownload(ctx, repo.GetListingFileForDownloadParams{ID: f.deletedFile.ID, ListingID: f.deleted.ID})
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("GetListingsForSync", func(t *testing.T) {
+		rows, err := q.GetListingsForSync(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		assert.Equal(t, f.live.ID, rows[0].ID)
+	})
+
+	t.Run("SoftDeleteListing", func(t *testing.T) {
+		// A second delete would push deleted_at, and with it the purge, further out
+		_, err := q.SoftDeleteListing(ctx, repo.SoftDeleteListingParams{ID: f.deleted.ID, SellerID: f.sellerID})
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("SetListingNsfw", func(t *testing.T) {
+		updated, err := q.SetListingNsfw(ctx, repo.SetListingNsfwParams{ID: f.deleted.ID, IsNsfw: true})
+		require.NoError(t, err)
+		assert.Zero(t, updated)
+	})
+
+	t.Run("MarkListingAsIndexed", func(t *testing.T) {
+		require.NoError(t, q.MarkListingAsIndexed(ctx, f.deleted.ID))
+
+		listing, err := q.GetListingByIDIncludingDeleted(ctx, f.deleted.ID)
+		require.NoError(t, err)
+		assert.False(t, listing.LastIndexedAt.Valid)
+	})
+}
+
+func TestQueries_IncludingDeleted(t *testing.T) {
+	// SCENARIO: Restore and moderation look a soft-deleted listing up by ID.
+	// EXPECT: The row comes back with deleted_at set.
+
+	ctx := context.Background()
+	tx, err := db.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+
+	q := repo.New(db).WithTx(tx)
+	f := newSoftDeleteFixture(t, q)
+
+	listing, err := q.GetListingByIDIncludingDeleted(ctx, f.deleted.ID)
+	require.NoError(t, err)
+	assert.Equal(t, f.deleted.ID, listing.ID)
+	assert.True(t, listing.DeletedAt.Valid)
+
+	withFiles, err := q.GetListingByIDWithFilesIncludingDeleted(ctx, f.deleted.ID)
+	require.NoError(t, err)
+	assert.Equal(t, f.deleted.ID, withFiles.ID)
+	assert.True(t, withFiles.DeletedAt.Valid)
+}
+
+func TestQueries_OutboxFailedEvents(t *testing.T) {
+	// SCENARIO: The outbox holds a published event, a pending one written ten minutes ago and one the relay gave up on.
+	// EXPECT: Only the pending one counts as backlog and is claimed, the failed one is listed separately. Once retried
+	// it's claimed like any other.
+
+	ctx := context.Background()
+	tx, err := db.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+
+	q := repo.New(db).WithTx(tx)
+	now := time.Now()
+	_, err = tx.Exec(ctx, `DELETE FROM event_outbox`)
+	require.NoError(t, err)
+
+	var published, pending, failed int64
+	insert := func(id *int64, createdAt time.Time, publishedAt, failedAt *time.Time) {
+		require.NoError(t, tx.QueryRow(ctx, `
+			INSERT INTO event_outbox (subject, msg_id, payload, attempts, next_attempt_at, published_at, failed_at, created_at)
+			VALUES ('listings.created', 'created.' || gen_random_uuid(), '{}', 15, $1, $2, $3, $1)
+			RETURNING id`, createdAt, publishedAt, failedAt).Scan(id))
+	}
+	insert(&published, now.Add(-time.Hour), &now, nil)
+	insert(&pending, now.Add(-10*time.Minute), nil, nil)
+	insert(&failed, now.Add(-20*time.Minute), nil, &now)
+
+	stats, err := q.GetOutboxStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Pending)
+	assert.Equal(t, int64(1), stats.Failed)
+	assert.WithinDuration(t, now.Add(-10*time.Minute), stats.OldestPendingAt.Time, time.Millisecond)
+
+	listed, err := q.ListOutboxEvents(ctx, repo.ListOutboxEventsParams{Failed: true, RowLimit: 10})
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, failed, listed[0].ID)
+
+	claim := func() []int64 {
+		rows, err := q.ClaimOutboxEvents(ctx, repo.ClaimOutboxEventsParams{
+			// Leased for longer than the test, a claimed event isn't claimed again
+			LeaseUntil: pgtype.Timestamptz{Time: now.Add(time.Hour), Valid: true},
+			Now:        pgtype.Timestamptz{Time: now.Add(time.Minute), Valid: true},
+			BatchSize:  10,
+		})
+		require.NoError(t, err)
+		ids := make([]int64, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []int64{pending}, claim())
+
+	retried, err := q.RetryOutboxEvent(ctx, repo.RetryOutboxEventParams{Now: pgtype.Timestamptz{Time: now, Valid: true}, ID: failed})
+	require.NoError(t, err)
+	assert.Zero(t, retried.Attempts)
+	assert.False(t, retried.FailedAt.Valid)
+	assert.Equal(t, []int64{failed}, claim())
+
+	_, err = q.RetryOutboxEvent(ctx, repo.RetryOutboxEventParams{Now: pgtype.Timestamptz{Time: now, Valid: true}, ID: published})
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
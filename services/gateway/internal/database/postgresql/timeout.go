@@ -0,0 +1,199 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	apperrors "gateway/internal/errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// DefaultQueryTimeout bounds a single query unless the caller asks for longer with WithQueryTimeout. A query that
+// misses an index would otherwise hold its connection for the whole request timeout and starve the pool.
+const DefaultQueryTimeout = 5 * time.Second
+
+// DefaultStatementTimeout is the statement_timeout the database enforces on every connection, the backstop for
+// anything the client side misses. No WithQueryTimeout can go beyond it.
+const DefaultStatementTimeout = 30 * time.Second
+
+// Postgres cancels a statement that ran past statement_timeout with query_canceled
+const queryCanceled = "57014"
+
+var queryTimeoutsTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "gateway_db_query_timeouts_total",
+	Help: "Database queries cut off by the per-query timeout or the server's statement_timeout.",
+})
+
+// PoolConfig parses dsn with statement_timeout set on every connection the pool opens
+func PoolConfig(dsn string, statementTimeout time.Duration) (*pgxpool.Config, error) {
+	config, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	config.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(statementTimeout.Milliseconds(), 10)
+	return config, nil
+}
+
+type queryTimeoutKey struct{}
+
+// WithQueryTimeout replaces the default timeout of the queries run with ctx, for exports and bulk jobs that read more
+// than a request usually does. Each query gets the whole timeout, not what is left of it.
+func WithQueryTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, queryTimeoutKey{}, timeout)
+}
+
+// Conn is what TimeoutDB wraps, the pool in production and pgxmock in tests
+type Conn interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// TimeoutDB gives every query on the connection, and in the transactions begun on it, a timeout of its own. A query
+// that runs out of it fails with an error wrapping errors.ErrQueryTimeout, which is answered with a 504. A timeout of
+// 0 leaves queries to the caller's context.
+type TimeoutDB struct {
+	conn    Conn
+	timeout time.Duration
+}
+
+func NewTimeoutDB(conn Conn, timeout time.Duration) *TimeoutDB {
+	return &TimeoutDB{conn: conn, timeout: timeout}
+}
+
+func (db *TimeoutDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return timedExec(ctx, db.timeout, db.conn.Exec, sql, args...)
+}
+
+func (db *TimeoutDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return timedQuery(ctx, db.timeout, db.conn.Query, sql, args...)
+}
+
+func (db *TimeoutDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return timedQueryRow(ctx, db.timeout, db.conn.QueryRow, sql, args...)
+}
+
+// Begin isn't bounded itself, the statements run in the transaction are
+func (db *TimeoutDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := db.conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &timeoutTx{Tx: tx, timeout: db.timeout}, nil
+}
+
+type timeoutTx struct {
+	pgx.Tx
+	timeout time.Duration
+}
+
+func (tx *timeoutTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return timedExec(ctx, tx.timeout, tx.Tx.Exec, sql, args...)
+}
+
+func (tx *timeoutTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return timedQuery(ctx, tx.timeout, tx.Tx.Query, sql, args...)
+}
+
+func (tx *timeoutTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return timedQueryRow(ctx, tx.timeout, tx.Tx.QueryRow, sql, args...)
+}
+
+// Begin starts a savepoint, which is bounded the same way
+func (tx *timeoutTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	nested, err := tx.Tx.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &timeoutTx{Tx: nested, timeout: tx.timeout}, nil
+}
+
+// query is one query's context, bounded by the caller's override or the default. The cancel releases it.
+type query struct {
+	parent  context.Context
+	ctx     context.Context
+	cancel  context.CancelFunc
+	timeout time.Duration
+}
+
+func start(parent context.Context, timeout time.Duration) *query {
+	if override, ok := parent.Value(queryTimeoutKey{}).(time.Duration); ok {
+		timeout = override
+	}
+	if timeout <= 0 {
+		ctx, cancel := context.WithCancel(parent)
+		return &query{parent: parent, ctx: ctx, cancel: cancel}
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	return &query{parent: parent, ctx: ctx, cancel: cancel, timeout: timeout}
+}
+
+// wrap marks err as a query timeout when it was our timeout or the server's that cut the query off. A caller that gave
+// up, or a request that ran out of time, keeps its own error.
+func (q *query) wrap(err error) error {
+	if err == nil || q.parent.Err() != nil {
+		return err
+	}
+	var pgErr *pgconn.PgError
+	if q.ctx.Err() == context.DeadlineExceeded || (errors.As(err, &pgErr) && pgErr.Code == queryCanceled) {
+		queryTimeoutsTotal.Inc()
+		return fmt.Errorf("%w after %s: %w", apperrors.ErrQueryTimeout, q.timeout, err)
+	}
+	return err
+}
+
+func timedExec(ctx context.Context, timeout time.Duration, exec func(context.Context, string, ...any) (pgconn.CommandTag, error), sql string, args ...any) (pgconn.CommandTag, error) {
+	q := start(ctx, timeout)
+	defer q.cancel()
+	tag, err := exec(q.ctx, sql, args...)
+	return tag, q.wrap(err)
+}
+
+// timedQuery keeps the timeout running until the rows are closed, the connection is only given back then
+func timedQuery(ctx context.Context, timeout time.Duration, run func(context.Context, string, ...any) (pgx.Rows, error), sql string, args ...any) (pgx.Rows, error) {
+	q := start(ctx, timeout)
+	rows, err := run(q.ctx, sql, args...)
+	if err != nil {
+		q.cancel()
+		return nil, q.wrap(err)
+	}
+	return &timeoutRows{Rows: rows, query: q}, nil
+}
+
+func timedQueryRow(ctx context.Context, timeout time.Duration, run func(context.Context, string, ...any) pgx.Row, sql string, args ...any) pgx.Row {
+	q := start(ctx, timeout)
+	return &timeoutRow{row: run(q.ctx, sql, args...), query: q}
+}
+
+type timeoutRows struct {
+	pgx.Rows
+	query *query
+}
+
+func (r *timeoutRows) Close() {
+	r.Rows.Close()
+	r.query.cancel()
+}
+
+func (r *timeoutRows) Err() error {
+	return r.query.wrap(r.Rows.Err())
+}
+
+type timeoutRow struct {
+	row   pgx.Row
+	query *query
+}
+
+func (r *timeoutRow) Scan(dest ...any) error {
+	defer r.query.cancel()
+	return r.query.wrap(r.row.Scan(dest...))
+}
@@ -0,0 +1,168 @@
+package postgresql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gateway/internal/database/postgresql"
+	"gateway/internal/errors"
+	"gateway/internal/testutil"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingConn remembers the context the last query was run with
+type recordingConn struct {
+	postgresql.Conn
+	ctx context.Context
+}
+
+func (c *recordingConn) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	c.ctx = ctx
+	return c.Conn.Query(ctx, sql, args...)
+}
+
+func TestTimeoutDB_SlowQueryTimesOut(t *testing.T) {
+	// SCENARIO: A query takes far longer than the timeout, through each way of running one.
+	// EXPECT: It is cut off at the timeout with ErrQueryTimeout rather than left to hold its connection.
+
+	tests := map[string]func(db *postgresql.TimeoutDB, mockPool pgxmock.PgxPoolIface) error{
+		"exec": func(db *postgresql.TimeoutDB, mockPool pgxmock.PgxPoolIface) error {
+			mockPool.ExpectExec("UPDATE listings").WillReturnResult(pgxmock.NewResult("UPDATE", 1)).WillDelayFor(time.Minute)
+			_, err := db.Exec(context.Background(), "UPDATE listings SET title = 'Benchy'")
+			return err
+		},
+		"query": func(db *postgresql.TimeoutDB, mockPool pgxmock.PgxPoolIface) error {
+			mockPool.ExpectQuery("SELECT id").WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1)).WillDelayFor(time.Minute)
+			_, err := db.Query(context.Background(), "SELECT id FROM listings")
+			return err
+		},
+		"query row": func(db *postgresql.TimeoutDB, mockPool pgxmock.PgxPoolIface) error {
+			mockPool.ExpectQuery("SELECT id").WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1)).WillDelayFor(time.Minute)
+			var id int
+			return db.QueryRow(context.Background(), "SELECT id FROM listings").Scan(&id)
+		},
+		"in a transaction": func(db *postgresql.TimeoutDB, mockPool pgxmock.PgxPoolIface) error {
+			mockPool.ExpectBegin()
+			mockPool.ExpectExec("UPDATE listings").WillReturnResult(pgxmock.NewResult("UPDATE", 1)).WillDelayFor(time.Minute)
+			tx, err := db.Begin(context.Background())
+			require.NoError(t, err)
+			_, err = tx.Exec(context.Background(), "UPDATE listings SET title = 'Benchy'")
+			return err
+		},
+	}
+
+	for name, run := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockPool := testutil.NewMockDB(t)
+			db := postgresql.NewTimeoutDB(mockPool, 20*time.Millisecond)
+
+			start := time.Now()
+			err := run(db, mockPool)
+
+			assert.ErrorIs(t, err, errors.ErrQueryTimeout)
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+			assert.Less(t, time.Since(start), 5*time.Second)
+		})
+	}
+}
+
+func TestTimeoutDB_ReleasesConnection(t *testing.T) {
+	// SCENARIO: A query's rows are read and closed well within the timeout.
+	// EXPECT: The query's context stays live while the rows are read and is released by Close, so the connection goes
+	// back to the pool then rather than when the timeout would have fired.
+
+	mockPool := testutil.NewMockDB(t)
+	mockPool.ExpectQuery("SELECT id").WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	conn := &recordingConn{Conn: mockPool}
+	db := postgresql.NewTimeoutDB(conn, time.Minute)
+
+	rows, err := db.Query(context.Background(), "SELECT id FROM listings")
+	require.NoError(t, err)
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, ids)
+	assert.ErrorIs(t, conn.ctx.Err(), context.Canceled)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestTimeoutDB_OverrideGivesLonger(t *testing.T) {
+	// SCENARIO: An export asks for longer than the default and its query outlasts the default.
+	// EXPECT: The query finishes.
+
+	mockPool := testutil.NewMockDB(t)
+	mockPool.ExpectQuery("SELECT id").WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1)).WillDelayFor(50 * time.Millisecond)
+	db := postgresql.NewTimeoutDB(mockPool, 10*time.Millisecond)
+
+	var id int
+	err := db.QueryRow(postgresql.WithQueryTimeout(context.Background(), time.Minute), "SELECT id FROM listings").Scan(&id)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, id)
+}
+
+func TestTimeoutDB_CallerGaveUp(t *testing.T) {
+	// SCENARIO: The request was canceled, or ran out of its own time, while its query ran.
+	// EXPECT: The caller's error comes back as it is, it says nothing about the query being slow.
+
+	for name, parent := range map[string]func() (context.Context, context.CancelFunc){
+		"canceled": func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+		"deadline": func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 10*time.Millisecond)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mockPool := testutil.NewMockDB(t)
+			mockPool.ExpectExec("UPDATE listings").WillReturnResult(pgxmock.NewResult("UPDATE", 1)).WillDelayFor(time.Minute)
+			db := postgresql.NewTimeoutDB(mockPool, time.Minute)
+
+			ctx, cancel := parent()
+			defer cancel()
+			if name == "canceled" {
+				cancel()
+			}
+			_, err := db.Exec(ctx, "UPDATE listings SET title = 'Benchy'")
+
+			require.Error(t, err)
+			assert.NotErrorIs(t, err, errors.ErrQueryTimeout)
+		})
+	}
+}
+
+func TestTimeoutDB_StatementTimeout(t *testing.T) {
+	// SCENARIO: The database cut the statement off at statement_timeout before our own timeout fired.
+	// EXPECT: It is reported as a query timeout too.
+
+	mockPool := testutil.NewMockDB(t)
+	mockPool.ExpectExec("UPDATE listings").WillReturnError(&pgconn.PgError{Code: "57014", Message: "canceling statement due to statement timeout"})
+	db := postgresql.NewTimeoutDB(mockPool, time.Minute)
+
+	_, err := db.Exec(context.Background(), "UPDATE listings SET title = 'Benchy'")
+
+	assert.ErrorIs(t, err, errors.ErrQueryTimeout)
+}
+
+func TestTimeoutDB_OtherErrorsUnchanged(t *testing.T) {
+	// pgx.ErrNoRows is compared with == all over the services
+	mockPool := testutil.NewMockDB(t)
+	mockPool.ExpectQuery("SELECT id").WillReturnError(pgx.ErrNoRows)
+	db := postgresql.NewTimeoutDB(mockPool, time.Minute)
+
+	var id int
+	err := db.QueryRow(context.Background(), "SELECT id FROM listings").Scan(&id)
+
+	assert.True(t, err == pgx.ErrNoRows)
+}
+
+func TestPoolConfig_SetsStatementTimeout(t *testing.T) {
+	config, err := postgresql.PoolConfig("postgres://gateway@localhost:5432/marketplace", 30*time.Second)
+
+	require.NoError(t, err)
+	assert.Equal(t, "30000", config.ConnConfig.RuntimeParams["statement_timeout"])
+}
@@ -0,0 +1,15 @@
+// Package detach is for work that has to finish after the request that started it has gone, such as events
+// published for a change that is already committed or a response being cached. Everything else should stop with the
+// request, check ctx.Err() before each call out to the database, storage or search.
+package detach
+
+import (
+	"context"
+	"time"
+)
+
+// WithTimeout is ctx without its cancellation, bounded by timeout instead. Values such as the trace span and the
+// request ID carry over, so logs from the detached work still tie back to the request.
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), timeout)
+}
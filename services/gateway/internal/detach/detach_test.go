@@ -0,0 +1,37 @@
+package detach
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type key struct{}
+
+func TestWithTimeout(t *testing.T) {
+	// SCENARIO: The request's context is canceled while detached work is running.
+	// EXPECT: The detached context carries on with the request's values until its own timeout.
+
+	parent, cancelParent := context.WithCancel(context.WithValue(context.Background(), key{}, "req-1"))
+	ctx, cancel := WithTimeout(parent, 50*time.Millisecond)
+	defer cancel()
+
+	cancelParent()
+
+	assert.NoError(t, ctx.Err())
+	assert.Equal(t, "req-1", ctx.Value(key{}))
+	<-ctx.Done()
+	assert.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+}
+
+func TestWithTimeout_AlreadyCanceled(t *testing.T) {
+	parent, cancelParent := context.WithCancel(context.Background())
+	cancelParent()
+
+	ctx, cancel := WithTimeout(parent, time.Minute)
+	defer cancel()
+
+	assert.NoError(t, ctx.Err())
+}
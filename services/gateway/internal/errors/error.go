@@ -2,10 +2,14 @@ package errors
 
 import (
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
 	"runtime/debug"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
 )
@@ -18,15 +22,36 @@ const (
 	ErrConflict     ErrorCode = "CONFLICT" // e.g. Duplicate listing
 	ErrInternal     ErrorCode = "INTERNAL" // DB died, NATS down
 	ErrNotFound     ErrorCode = "NOT_FOUND"
+	ErrGone         ErrorCode = "GONE" // Existed once and never will again, e.g. a revoked short link
 	ErrUnauthorized ErrorCode = "UNAUTHORIZED"
+	ErrForbidden    ErrorCode = "FORBIDDEN"
+	ErrMaintenance  ErrorCode = "MAINTENANCE"  // Writes disabled while we migrate
+	ErrOverloaded   ErrorCode = "OVERLOADED"   // Shed by the load shedder, retry after a short wait
+	ErrRateLimited  ErrorCode = "RATE_LIMITED" // Caller went over a per-user limit, retry once the window resets
+	ErrBlocked      ErrorCode = "BLOCKED"      // Caller kept bursting after being throttled, blocked until Retry-After
+	ErrCanceled     ErrorCode = "CANCELED"     // Caller went away before the request was done, nobody reads the response
+
+	ErrSellerProfileRequired ErrorCode = "SELLER_PROFILE_REQUIRED" // Onboarding incomplete or terms outdated
 )
 
+// ErrQueryTimeout is wrapped into the error of a database query that ran out of its own timeout, see
+// postgresql.TimeoutDB. Services wrap database errors into ErrInternal without knowing about it, RespondError finds
+// it in the chain and answers 504 with DB_QUERY_TIMEOUT.
+var ErrQueryTimeout = stderrors.New("database query timed out")
+
+// StatusClientClosedRequest is sent for ErrCanceled. Nobody receives it, it keeps the access logs and metrics from
+// counting a client hanging up as a failure of ours.
+const StatusClientClosedRequest = 499
+
 // AppError carries the "User View" and the "System View"
 type AppError struct {
-	Code     ErrorCode // Machine code (for frontend logic)
-	Message  string    // Safe user-facing message
-	Internal error     // Original error (DB error, etc) - NEVER show to user
-	Stack    string    // Stack trace for audit
+	Code       ErrorCode         // Machine code (for frontend logic)
+	Reason     Reason            // Optional, which rule failed. See reasons.go
+	Message    string            // Safe user-facing message, English. Replaced by the catalogue copy when Reason is set
+	Params     map[string]string // Values for {placeholders} in the catalogue message
+	Internal   error             // Original error (DB error, etc) - NEVER show to user
+	RetryAfter time.Duration     // Optional, sent as Retry-After so clients know when to try again
+	Stack      string            // Stack trace for audit
 }
 
 // Implement the standard error interface
@@ -44,6 +69,32 @@ func New(code ErrorCode, msg string, internal error) *AppError {
 	}
 }
 
+// Canceled is what a service method returns when it stops because ctx was canceled, err is ctx.Err()
+func Canceled(err error) *AppError {
+	return New(ErrCanceled, "Request was canceled", err)
+}
+
+// WithReason attaches a fine-grained reason, e.g. errors.New(ErrInvalidInput, msg, nil).WithReason(ReasonListingTitleLength)
+func (e *AppError) WithReason(r Reason) *AppError {
+	e.Reason = r
+	return e
+}
+
+// WithRetryAfter tells the client how long to wait before retrying
+func (e *AppError) WithRetryAfter(d time.Duration) *AppError {
+	e.RetryAfter = d
+	return e
+}
+
+// WithParam sets a value for a {placeholder} in the reason's catalogue message
+func (e *AppError) WithParam(key, value string) *AppError {
+	if e.Params == nil {
+		e.Params = map[string]string{}
+	}
+	e.Params[key] = value
+	return e
+}
+
 func RespondError(w http.ResponseWriter, r *http.Request, err error) {
 	reqID := middleware.GetReqID(r.Context())
 
@@ -63,10 +114,31 @@ func RespondError(w http.ResponseWriter, r *http.Request, err error) {
 		status = http.StatusBadRequest
 	case ErrConflict:
 		status = http.StatusConflict
+		if appErr.Reason == ReasonIdempotencyKeyReused {
+			// Nothing is running or in the way, retrying won't help, the request itself doesn't match the key
+			status = http.StatusUnprocessableEntity
+		}
 	case ErrUnauthorized:
 		status = http.StatusUnauthorized
 	case ErrNotFound:
 		status = http.StatusNotFound
+	case ErrGone:
+		status = http.StatusGone
+	case ErrForbidden, ErrSellerProfileRequired:
+		status = http.StatusForbidden
+	case ErrRateLimited, ErrBlocked:
+		status = http.StatusTooManyRequests
+	case ErrMaintenance, ErrOverloaded:
+		status = http.StatusServiceUnavailable
+	case ErrCanceled:
+		status = StatusClientClosedRequest
+	case ErrInternal:
+		if stderrors.Is(appErr.Internal, ErrQueryTimeout) {
+			status = http.StatusGatewayTimeout
+			if appErr.Reason == "" {
+				appErr.Reason = ReasonDBQueryTimeout
+			}
+		}
 	}
 
 	// 3. LOGGING (Audit Strategy)
@@ -78,8 +150,11 @@ func RespondError(w http.ResponseWriter, r *http.Request, err error) {
 		"code", appErr.Code,
 		"user_msg", appErr.Message,
 	}
+	if appErr.Reason != "" {
+		logFields = append(logFields, "reason", appErr.Reason)
+	}
 
-	if status == http.StatusInternalServerError {
+	if status == http.StatusInternalServerError || status == http.StatusGatewayTimeout {
 		// For 500s: Log EVERYTHING (Internal error + Stack trace)
 		logFields = append(logFields, "internal_err", appErr.Internal, "stack", appErr.Stack)
 		slog.Error("Internal Server Error", logFields...)
@@ -92,13 +167,27 @@ func RespondError(w http.ResponseWriter, r *http.Request, err error) {
 	}
 
 	// 4. JSON Response
+	// Only the message is translated, error_code and reason stay stable for the frontend to branch on
+	catalogue := ActiveCatalogue()
+	message, locale := catalogue.Localize(catalogue.Match(r.Header.Get("Accept-Language")), appErr)
+
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", locale)
+	if appErr.RetryAfter > 0 {
+		// Round up, telling a client to come back before the window resets just earns it another 429
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+	}
+	w.Header().Add("Vary", "Accept-Language")
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{
+	body := map[string]string{
 		"error_code": string(appErr.Code),
-		"message":    appErr.Message,
+		"message":    message,
 		"request_id": reqID, // Helpful for support tickets
-	})
+	}
+	if appErr.Reason != "" {
+		body["reason"] = string(appErr.Reason)
+	}
+	json.NewEncoder(w).Encode(body)
 }
 
 // RespondJSON is a handy helper for success cases too
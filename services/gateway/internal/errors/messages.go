@@ -0,0 +1,156 @@
+package errors
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is used when the client sends no Accept-Language we have messages for.
+// Every reason must have a message in it.
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// Catalogue holds the user-facing message for each reason, per locale.
+// Messages may contain {name} placeholders which are filled from AppError.Params.
+type Catalogue struct {
+	messages map[string]map[Reason]string
+	locales  []string
+	matcher  language.Matcher
+}
+
+// NewCatalogue builds a catalogue from locale -> reason -> message. The default locale must be present.
+func NewCatalogue(messages map[string]map[Reason]string) (*Catalogue, error) {
+	if _, ok := messages[DefaultLocale]; !ok {
+		return nil, fmt.Errorf("catalogue has no %q messages", DefaultLocale)
+	}
+
+	// The default goes first so the matcher falls back to it
+	locales := []string{DefaultLocale}
+	tags := []language.Tag{language.Make(DefaultLocale)}
+	for locale := range messages {
+		if locale == DefaultLocale {
+			continue
+		}
+		tag, err := language.Parse(locale)
+		if err != nil {
+			return nil, fmt.Errorf("invalid locale %q: %w", locale, err)
+		}
+		locales = append(locales, locale)
+		tags = append(tags, tag)
+	}
+
+	return &Catalogue{
+		messages: messages,
+		locales:  locales,
+		matcher:  language.NewMatcher(tags),
+	}, nil
+}
+
+// LoadCatalogue reads every <locale>.json file in fsys
+func LoadCatalogue(fsys fs.FS) (*Catalogue, error) {
+	files, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make(map[string]map[Reason]string, len(files))
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		var m map[Reason]string
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", file, err)
+		}
+		messages[strings.TrimSuffix(path.Base(file), ".json")] = m
+	}
+
+	return NewCatalogue(messages)
+}
+
+// Locales returns the locales in the catalogue, default first
+func (c *Catalogue) Locales() []string {
+	return c.locales
+}
+
+// Lookup returns the raw message template for a reason in one locale
+func (c *Catalogue) Lookup(locale string, r Reason) (string, bool) {
+	msg, ok := c.messages[locale][r]
+	return msg, ok
+}
+
+// Match picks the best locale for an Accept-Language header value
+func (c *Catalogue) Match(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return DefaultLocale
+	}
+	_, index, confidence := c.matcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLocale
+	}
+	return c.locales[index]
+}
+
+// Localize renders the message for appErr in the requested locale, falling back to the default locale
+// and finally to appErr.Message for errors that have no reason (or a reason nobody has written copy for yet).
+// It returns the locale the message is actually in.
+func (c *Catalogue) Localize(locale string, appErr *AppError) (string, string) {
+	if appErr.Reason == "" {
+		return appErr.Message, DefaultLocale
+	}
+
+	for _, l := range []string{locale, DefaultLocale} {
+		if tmpl, ok := c.Lookup(l, appErr.Reason); ok {
+			return fill(tmpl, appErr.Params), l
+		}
+	}
+	return appErr.Message, DefaultLocale
+}
+
+func fill(tmpl string, params map[string]string) string {
+	if len(params) == 0 {
+		return tmpl
+	}
+	pairs := make([]string, 0, len(params)*2)
+	for k, v := range params {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(tmpl)
+}
+
+var active atomic.Pointer[Catalogue]
+
+func init() {
+	sub, err := fs.Sub(localeFiles, "locales")
+	if err != nil {
+		panic(err)
+	}
+	c, err := LoadCatalogue(sub)
+	if err != nil {
+		panic(fmt.Sprintf("errors: embedded message catalogue: %v", err))
+	}
+	active.Store(c)
+}
+
+// ActiveCatalogue returns the catalogue RespondError renders with
+func ActiveCatalogue() *Catalogue {
+	return active.Load()
+}
+
+// SetCatalogue swaps the catalogue RespondError uses and returns a func that puts the previous one back.
+// Meant for tests: defer errors.SetCatalogue(c)()
+func SetCatalogue(c *Catalogue) (restore func()) {
+	prev := active.Swap(c)
+	return func() { active.Store(prev) }
+}
@@ -0,0 +1,132 @@
+package errors_test
+
+import (
+	"encoding/json"
+	"gateway/internal/errors"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var placeholder = regexp.MustCompile(`\{[a-z_]+\}`)
+
+func respond(t *testing.T, acceptLanguage string, appErr *errors.AppError) (map[string]string, string) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/listings", nil)
+	if acceptLanguage != "" {
+		r.Header.Set("Accept-Language", acceptLanguage)
+	}
+
+	errors.RespondError(w, r, appErr)
+
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	return body, w.Header().Get("Content-Language")
+}
+
+func TestCatalogue_DefaultLocaleCoversEveryReason(t *testing.T) {
+	catalogue := errors.ActiveCatalogue()
+	for r := range errors.Reasons() {
+		_, ok := catalogue.Lookup(errors.DefaultLocale, r)
+		assert.True(t, ok, "%s has no %s message", r, errors.DefaultLocale)
+	}
+}
+
+func TestCatalogue_TranslationsMatchDefault(t *testing.T) {
+	// Every key must be a real reason, and a translation can't invent placeholders English doesn't fill
+	catalogue := errors.ActiveCatalogue()
+	require.Greater(t, len(catalogue.Locales()), 1, "expected at least one translation")
+
+	for _, locale := range catalogue.Locales() {
+		for r := range errors.Reasons() {
+			msg, ok := catalogue.Lookup(locale, r)
+			if !ok {
+				continue
+			}
+			english, _ := catalogue.Lookup(errors.DefaultLocale, r)
+			assert.ElementsMatch(t, placeholder.FindAllString(english, -1), placeholder.FindAllString(msg, -1), "%s/%s placeholders", locale, r)
+		}
+	}
+}
+
+func TestRespondError_Localized(t *testing.T) {
+	appErr := func() *errors.AppError {
+		return errors.New(errors.ErrInvalidInput, "Title must be between 5 and 100 characters", nil).
+			WithReason(errors.ReasonListingTitleLength).
+			WithParam("min", "5").
+			WithParam("max", "100")
+	}
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		wantLocale     string
+		wantMessage    string
+	}{
+		{"no header", "", "en", "Title must be between 5 and 100 characters"},
+		{"german", "de-DE,de;q=0.9,en;q=0.8", "de", "Der Titel muss zwischen 5 und 100 Zeichen lang sein"},
+		{"unsupported falls back", "fr-FR", "en", "Title must be between 5 and 100 characters"},
+		{"quality order respected", "fr;q=1, de;q=0.5", "de", "Der Titel muss zwischen 5 und 100 Zeichen lang sein"},
+		{"garbage header", ";;;q=abc", "en", "Title must be between 5 and 100 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, locale := respond(t, tt.acceptLanguage, appErr())
+			assert.Equal(t, tt.wantMessage, body["message"])
+			assert.Equal(t, tt.wantLocale, locale)
+
+			// Machine fields never change with language
+			assert.Equal(t, "INVALID_INPUT", body["error_code"])
+			assert.Equal(t, "LISTING_TITLE_LENGTH", body["reason"])
+		})
+	}
+}
+
+func TestRespondError_FillsParams(t *testing.T) {
+	appErr := errors.New(errors.ErrInvalidInput, "Invalid file type 'video'. Must be 'model' or 'image'", nil).
+		WithReason(errors.ReasonListingFileTypeInvalid).
+		WithParam("type", "video")
+
+	body, _ := respond(t, "de", appErr)
+	assert.Equal(t, "Ungültiger Dateityp 'video'. Erlaubt sind 'model' oder 'image'", body["message"])
+}
+
+func TestRespondError_NoReasonKeepsMessage(t *testing.T) {
+	body, locale := respond(t, "de", errors.New(errors.ErrNotFound, "Listing not found", nil))
+	assert.Equal(t, "Listing not found", body["message"])
+	assert.Equal(t, "en", locale)
+}
+
+func TestRespondError_MissingTranslationFallsBackToEnglish(t *testing.T) {
+	// FILE_* reasons have no German copy yet
+	body, locale := respond(t, "de", errors.New(errors.ErrInvalidInput, "Filename must have an extension", nil).WithReason(errors.ReasonFileExtensionRequired))
+	assert.Equal(t, "Filename must have an extension", body["message"])
+	assert.Equal(t, "en", locale)
+}
+
+func TestSetCatalogue_SwapsAndRestores(t *testing.T) {
+	c, err := errors.NewCatalogue(map[string]map[errors.Reason]string{
+		"en": {errors.ReasonListingTitleLength: "english"},
+		"es": {errors.ReasonListingTitleLength: "español"},
+	})
+	require.NoError(t, err)
+
+	restore := errors.SetCatalogue(c)
+	body, locale := respond(t, "es", errors.New(errors.ErrInvalidInput, "x", nil).WithReason(errors.ReasonListingTitleLength))
+	assert.Equal(t, "español", body["message"])
+	assert.Equal(t, "es", locale)
+
+	restore()
+	body, _ = respond(t, "es", errors.New(errors.ErrInvalidInput, "x", nil).WithReason(errors.ReasonListingTitleLength).WithParam("min", "5").WithParam("max", "100"))
+	assert.Equal(t, "Title must be between 5 and 100 characters", body["message"])
+}
+
+func TestNewCatalogue_RequiresDefaultLocale(t *testing.T) {
+	_, err := errors.NewCatalogue(map[string]map[errors.Reason]string{"de": {}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,226 @@
+package errors
+
+import "fmt"
+
+// Reason is a fine-grained, stable code for a specific failure, e.g. LISTING_TITLE_LENGTH.
+// ErrorCode says what kind of failure it was (and picks the HTTP status), Reason says exactly which rule failed
+// so the frontend can react without matching on Message, which is free to change.
+type Reason string
+
+// registry holds every known reason with a short description. Reasons can only be created through reason(),
+// so a duplicate code fails at startup instead of silently meaning two things.
+var registry = map[Reason]string{}
+
+func reason(code, description string) Reason {
+	r := Reason(code)
+	if _, exists := registry[r]; exists {
+		panic(fmt.Sprintf("errors: reason %s registered twice", code))
+	}
+	registry[r] = description
+	return r
+}
+
+// IsRegistered reports whether r came from the catalogue below.
+func IsRegistered(r Reason) bool {
+	_, ok := registry[r]
+	return ok
+}
+
+// Reasons returns a copy of the catalogue, code -> description.
+func Reasons() map[Reason]string {
+	out := make(map[Reason]string, len(registry))
+	for k, v := range registry {
+		out[k] = v
+	}
+	return out
+}
+
+// Auth
+var (
+	ReasonAuthRequired      = reason("AUTH_REQUIRED", "No authenticated user on the request")
+	ReasonAuthHeaderMissing = reason("AUTH_HEADER_MISSING", "Authorization header was not sent")
+	ReasonAuthHeaderInvalid = reason("AUTH_HEADER_INVALID", "Authorization header is not a Bearer token")
+	ReasonAuthTokenInvalid  = reason("AUTH_TOKEN_INVALID", "Token is expired, badly signed or from the wrong issuer")
+	ReasonAuthRoleRequired  = reason("AUTH_ROLE_REQUIRED", "Endpoint needs a role the caller doesn't have, e.g. service on /internal")
+	ReasonAuthUnavailable   = reason("AUTH_UNAVAILABLE", "Keycloak couldn't be reached to check the token's signing key, retry shortly")
+)
+
+// Listings
+var (
+	ReasonListingTitleLength          = reason("LISTING_TITLE_LENGTH", "Title is outside the configured length, 5-100 characters by default")
+	ReasonListingDescriptionShort     = reason("LISTING_DESCRIPTION_TOO_SHORT", "Description is shorter than the configured minimum, 20 characters by default")
+	ReasonListingDescriptionLong      = reason("LISTING_DESCRIPTION_TOO_LONG", "Description is longer than the configured maximum, 5000 characters by default")
+	ReasonListingCategoryRequired     = reason("LISTING_CATEGORY_REQUIRED", "No categories were given")
+	ReasonListingLicenseRequired      = reason("LISTING_LICENSE_REQUIRED", "No license was given")
+	ReasonListingPriceNegative        = reason("LISTING_PRICE_NEGATIVE", "Price is below zero")
+	ReasonListingCurrencyUnsupported  = reason("LISTING_CURRENCY_UNSUPPORTED", "Currency is not one we take payments in")
+	ReasonListingDimensionsNegative   = reason("LISTING_DIMENSIONS_NEGATIVE", "A dimension is below zero")
+	ReasonListingDimensionsIncomplete = reason("LISTING_DIMENSIONS_INCOMPLETE", "Physical listing gave some dimensions but not all three")
+	ReasonListingDimensionsRange      = reason("LISTING_DIMENSIONS_RANGE", "A side is under 1mm or over 2000mm once converted to millimetres")
+	ReasonListingDimensionsUnit       = reason("LISTING_DIMENSIONS_UNIT", "Dimension unit is neither mm nor in")
+	ReasonListingNozzleTempRange      = reason("LISTING_NOZZLE_TEMP_RANGE", "Recommended nozzle temperature is outside the configured range, 180-450°C by default")
+	ReasonListingNozzleDiameter       = reason("LISTING_NOZZLE_DIAMETER", "Nozzle diameter is not one of the configured sizes, 0.2, 0.25, 0.4, 0.6, 0.8 or 1.0 mm by default")
+	ReasonListingMaterialEmpty        = reason("LISTING_MATERIAL_EMPTY", "Recommended materials contains a blank entry")
+	ReasonListingMaterialUnknown      = reason("LISTING_MATERIAL_UNKNOWN", "Recommended material is not in the canonical list, see GET /materials")
+	ReasonListingMaterialSuggestion   = reason("LISTING_MATERIAL_SUGGESTION", "Recommended material is not in the canonical list but close to one that is")
+	ReasonListingAIModelRequired      = reason("LISTING_AI_MODEL_REQUIRED", "Listing is AI generated but names no model")
+	ReasonListingFilesRequired        = reason("LISTING_FILES_REQUIRED", "No files were attached")
+	ReasonListingTooManyFiles         = reason("LISTING_TOO_MANY_FILES", "More files were attached than the seller's validation rules allow")
+	ReasonListingFileNotOwned         = reason("LISTING_FILE_NOT_OWNED", "A file path belongs to another user")
+	ReasonListingFilePathEmpty        = reason("LISTING_FILE_PATH_EMPTY", "A file has no path")
+	ReasonListingFileSizeInvalid      = reason("LISTING_FILE_SIZE_INVALID", "A file size is zero or negative")
+	ReasonListingFileTypeInvalid      = reason("LISTING_FILE_TYPE_INVALID", "A file type is neither model nor image")
+	ReasonListingFilesMixedDrafts     = reason("LISTING_FILES_MIXED_DRAFTS", "Files were uploaded for different drafts")
+	ReasonListingFileRepeated         = reason("LISTING_FILE_REPEATED", "The same file path was attached twice")
+	ReasonListingFileAlreadyUsed      = reason("LISTING_FILE_ALREADY_USED", "A file is already attached to another listing")
+	ReasonListingModelRequired        = reason("LISTING_MODEL_REQUIRED", "No 3D model file was attached")
+	ReasonListingImageRequired        = reason("LISTING_IMAGE_REQUIRED", "No gallery image was attached")
+	ReasonListingNotOwner             = reason("LISTING_NOT_OWNER", "Listing belongs to another seller")
+	ReasonListingRateLimited          = reason("LISTING_RATE_LIMITED", "Seller created too many listings in the current window")
+	ReasonListingValidating           = reason("LISTING_VALIDATING", "Files were changed while the current ones are still being validated")
+	ReasonListingNotFound             = reason("LISTING_NOT_FOUND", "Listing doesn't exist or has been deleted")
+	ReasonListingNotUnpublishable     = reason("LISTING_NOT_UNPUBLISHABLE", "Listing is rejected, suspended or waiting for review, so there is nothing to unpublish")
+	ReasonListingBulkActionUnknown    = reason("LISTING_BULK_ACTION_UNKNOWN", "Bulk action is neither delete nor unpublish")
+	ReasonListingBulkSize             = reason("LISTING_BULK_SIZE", "Bulk request has no listing IDs or more than 100")
+	ReasonListingHardwareUnknown      = reason("LISTING_HARDWARE_UNKNOWN", "Required hardware entry is not in the curated list")
+	ReasonListingHardwareSuggestion   = reason("LISTING_HARDWARE_SUGGESTION", "Required hardware entry is not in the curated list but close to an entry that is")
+	ReasonListingBannedTerm           = reason("LISTING_BANNED_TERM", "Title or description uses a banned term with the REJECT severity")
+	ReasonListingPatchInvalid         = reason("LISTING_PATCH_INVALID", "Merge patch isn't a JSON object, or a member has the wrong type")
+	ReasonListingPatchNotNullable     = reason("LISTING_PATCH_NOT_NULLABLE", "Merge patch sets a required field to null")
+	ReasonListingPatchUnknownField    = reason("LISTING_PATCH_UNKNOWN_FIELD", "Merge patch has a member that isn't an editable listing field")
+	ReasonListingsQueryInvalid        = reason("LISTINGS_QUERY_INVALID", "GET /listings status, sort or order has a value it doesn't accept")
+	ReasonListingSuspensionReason     = reason("LISTING_SUSPENSION_REASON", "Suspension reason is blank or longer than 500 characters, the seller is shown it")
+)
+
+// Listing variants
+var (
+	ReasonVariantNameLength     = reason("VARIANT_NAME_LENGTH", "Variant name is blank or longer than 60 characters")
+	ReasonVariantPriceNegative  = reason("VARIANT_PRICE_NEGATIVE", "Variant price is missing or below zero")
+	ReasonVariantFilesRequired  = reason("VARIANT_FILES_REQUIRED", "Variant includes no files")
+	ReasonVariantFileInvalid    = reason("VARIANT_FILE_INVALID", "A file isn't one of the listing's model files")
+	ReasonVariantFilesUncovered = reason("VARIANT_FILES_UNCOVERED", "Change would leave model files that no variant includes")
+	ReasonVariantNameTaken      = reason("VARIANT_NAME_TAKEN", "Listing already has a variant with that name")
+	ReasonVariantLimit          = reason("VARIANT_LIMIT", "Listing already has the most variants allowed, 10")
+	ReasonVariantNotFound       = reason("VARIANT_NOT_FOUND", "Variant doesn't exist on the listing")
+)
+
+// Requests
+var (
+	ReasonIdempotencyKeyRequired = reason("IDEMPOTENCY_KEY_REQUIRED", "Endpoint needs an Idempotency-Key header")
+	ReasonIdempotencyKeyReused   = reason("IDEMPOTENCY_KEY_REUSED", "Idempotency-Key was already used with a different request body")
+)
+
+// Hardware options
+var (
+	ReasonHardwareOptionLength = reason("HARDWARE_OPTION_LENGTH", "Hardware name is shorter than 2 or longer than 50 characters")
+	ReasonHardwareOptionExists = reason("HARDWARE_OPTION_EXISTS", "Hardware name is already in the list, ignoring case")
+)
+
+// Banned terms
+var (
+	ReasonBannedTermInvalid  = reason("BANNED_TERM_INVALID", "Banned term is shorter than 2 or longer than 100 characters, or has no letters or digits")
+	ReasonBannedTermSeverity = reason("BANNED_TERM_SEVERITY", "Severity is neither REJECT nor FLAG")
+	ReasonBannedTermNotFound = reason("BANNED_TERM_NOT_FOUND", "Term isn't on the banned term list")
+)
+
+// Files
+var (
+	ReasonFileUploadTypeUnknown  = reason("FILE_UPLOAD_TYPE_UNKNOWN", "Upload type is neither model nor image")
+	ReasonFileTypeNotAllowed     = reason("FILE_TYPE_NOT_ALLOWED", "MIME type is not accepted for this upload type")
+	ReasonFileExtensionRequired  = reason("FILE_EXTENSION_REQUIRED", "Filename has no extension")
+	ReasonFileCallbackURLInvalid = reason("FILE_CALLBACK_URL_INVALID", "callback_url isn't an https URL on the default port")
+	ReasonFileCallbackNotAllowed = reason("FILE_CALLBACK_NOT_ALLOWED", "callback_url's domain isn't allowlisted for the caller's API key, or no API key was sent")
+	ReasonFileCallbackUnsigned   = reason("FILE_CALLBACK_UNSIGNED", "callback_url's domain has no signing secret, callbacks to it couldn't be sent")
+	ReasonFileCallbackDisabled   = reason("FILE_CALLBACK_DISABLED", "callback_url's host was disabled after failing too many deliveries in a row")
+	ReasonFileNotFound           = reason("FILE_NOT_FOUND", "File doesn't exist or has been removed from its listing")
+	ReasonFileResultStatus       = reason("FILE_RESULT_STATUS", "Validation result status isn't VALID, INVALID or FAILED")
+	ReasonFileResultMetadata     = reason("FILE_RESULT_METADATA", "Validation result metadata isn't an object or is over 16 KiB")
+	ReasonFileResultFinal        = reason("FILE_RESULT_FINAL", "File already has a different validation result, results are final")
+)
+
+// Sellers
+var (
+	ReasonVacationEndInvalid    = reason("VACATION_END_INVALID", "Vacation end is missing, in the past or not after its start")
+	ReasonVacationMessageLength = reason("VACATION_MESSAGE_LENGTH", "Vacation message is longer than 280 characters")
+	ReasonVacationNotSet        = reason("VACATION_NOT_SET", "Seller has no current or upcoming vacation to end")
+	ReasonSellerOnVacation      = reason("SELLER_ON_VACATION", "Seller is on vacation, paid downloads are paused until they're back")
+)
+
+// Categories
+var (
+	ReasonCategoryNotFound           = reason("CATEGORY_NOT_FOUND", "Category isn't one of the canonical categories")
+	ReasonCategoryDefaultsNotFound   = reason("CATEGORY_DEFAULTS_NOT_FOUND", "Category isn't canonical or has no printer settings template")
+	ReasonCategoryDefaultsMaterials  = reason("CATEGORY_DEFAULTS_MATERIALS", "Template has no materials, more than 10, or one longer than 30 characters")
+	ReasonCategoryDefaultsTempRange  = reason("CATEGORY_DEFAULTS_TEMP_RANGE", "Template nozzle temperature range is outside 180-450°C or min is above max")
+	ReasonCategoryDefaultsDimensions = reason("CATEGORY_DEFAULTS_DIMENSIONS", "Template typical dimensions aren't all between 1 and 1000 mm")
+)
+
+// Listing warnings, returned with the created or edited listing and never blocking it
+var (
+	ReasonListingNozzleTempUnusual = reason("LISTING_NOZZLE_TEMP_UNUSUAL", "Recommended nozzle temperature is far outside the category's usual range")
+	ReasonListingMaterialsUnusual  = reason("LISTING_MATERIALS_UNUSUAL", "None of the recommended materials are usual for the category")
+	ReasonListingDimensionsUnusual = reason("LISTING_DIMENSIONS_UNUSUAL", "Longest side is far bigger or smaller than is typical for the category")
+	ReasonListingPriceHigh         = reason("LISTING_PRICE_HIGH", "Price is above the rules' priceWarnAboveMinUnit, often a price entered in major units")
+	ReasonListingDimensionsMissing = reason("LISTING_DIMENSIONS_MISSING", "Physical listing has no dimensions, buyers can't check it fits their printer")
+)
+
+// Admin
+var (
+	ReasonAdminListingsFilterInvalid = reason("ADMIN_LISTINGS_FILTER_INVALID", "A GET /admin/listings filter, limit or format has a value it doesn't accept")
+	ReasonAdminListingsCursorInvalid = reason("ADMIN_LISTINGS_CURSOR_INVALID", "GET /admin/listings cursor isn't one the previous page returned")
+	ReasonLogLevelInvalid            = reason("LOG_LEVEL_INVALID", "PUT /admin/log-level level isn't debug, info, warn or error")
+)
+
+// Search
+var (
+	ReasonSearchFilterInvalid = reason("SEARCH_FILTER_INVALID", "A search filter param has a value it doesn't accept, or too many values")
+)
+
+// Saved searches
+var (
+	ReasonSavedSearchEmpty       = reason("SAVED_SEARCH_EMPTY", "Saved search has neither a query nor any filters, it would match every listing")
+	ReasonSavedSearchQueryLength = reason("SAVED_SEARCH_QUERY_LENGTH", "Saved search query is longer than 200 characters")
+	ReasonSavedSearchLimit       = reason("SAVED_SEARCH_LIMIT", "User already has as many saved searches as allowed")
+	ReasonSavedSearchExists      = reason("SAVED_SEARCH_EXISTS", "User already saved the same query and filters")
+	ReasonSavedSearchNotFound    = reason("SAVED_SEARCH_NOT_FOUND", "Saved search doesn't exist or belongs to another user")
+)
+
+// Downloads
+var (
+	ReasonDownloadHistoryQueryInvalid = reason("DOWNLOAD_HISTORY_QUERY_INVALID", "GET /me/downloads limit or cursor has a value it doesn't accept")
+	ReasonDownloadNotFound            = reason("DOWNLOAD_NOT_FOUND", "User has no download of the file to repeat, or it was anonymized after the retention period")
+)
+
+// Short links
+var (
+	ReasonShortLinkCampaignInvalid = reason("SHORT_LINK_CAMPAIGN_INVALID", "Campaign tag is longer than 64 characters or has characters other than a-z, 0-9, - and _")
+	ReasonShortLinkNotFound        = reason("SHORT_LINK_NOT_FOUND", "No short link has the code, or it belongs to another listing")
+	ReasonShortLinkRevoked         = reason("SHORT_LINK_REVOKED", "Short link was revoked by the seller")
+	ReasonShortLinkListingDeleted  = reason("SHORT_LINK_LISTING_DELETED", "Short link's listing has been deleted")
+)
+
+// Featured listings
+var (
+	ReasonFeaturedWindowInvalid = reason("FEATURED_WINDOW_INVALID", "Featured listing window is missing a start or end, ends before it starts, or has already ended")
+	ReasonFeaturedWeightInvalid = reason("FEATURED_WEIGHT_INVALID", "Featured listing weight is below 0 or above the maximum")
+	ReasonFeaturedNotFound      = reason("FEATURED_NOT_FOUND", "No featured listing window has the ID")
+)
+
+// Storefront pins
+var (
+	ReasonPinLimit            = reason("PIN_LIMIT", "Seller already has as many listings pinned as allowed")
+	ReasonPinListingNotActive = reason("PIN_LISTING_NOT_ACTIVE", "Listing isn't published, only published listings can be pinned")
+	ReasonPinPositionInvalid  = reason("PIN_POSITION_INVALID", "Pin position is below 1 or above the number of pins allowed")
+)
+
+// Database
+var (
+	ReasonDBQueryTimeout = reason("DB_QUERY_TIMEOUT", "A database query ran out of its timeout, sent with INTERNAL as a 504")
+)
+
+// Scraping, public endpoints only
+var (
+	ReasonScrapeBurst          = reason("SCRAPE_BURST", "Caller made more public requests in the sliding window than the burst limit")
+	ReasonScrapeBlocked        = reason("SCRAPE_BLOCKED", "Caller was throttled too often and is temporarily blocked")
+	ReasonScrapeAPIKeyRequired = reason("SCRAPE_API_KEY_REQUIRED", "Caller went over the hourly volume allowed without an API key")
+)
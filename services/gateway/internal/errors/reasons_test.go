@@ -0,0 +1,152 @@
+package errors_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"gateway/internal/errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// catalogueNames returns the names of every Reason variable declared in reasons.go via reason(...)
+func catalogueNames(t *testing.T) map[string]bool {
+	t.Helper()
+	f, err := parser.ParseFile(token.NewFileSet(), "reasons.go", nil, 0)
+	require.NoError(t, err)
+
+	names := map[string]bool{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok || len(spec.Values) != 1 {
+			return true
+		}
+		if call, ok := spec.Values[0].(*ast.CallExpr); ok {
+			if fn, ok := call.Fun.(*ast.Ident); ok && fn.Name == "reason" {
+				names[spec.Names[0].Name] = true
+			}
+		}
+		return true
+	})
+	return names
+}
+
+func TestReasons_EveryEmittedReasonIsRegistered(t *testing.T) {
+	// SCENARIO: Someone calls WithReason(errors.Reason("SOMETHING_NEW")) instead of adding it to the catalogue.
+	// EXPECT: This test finds the call site and fails.
+
+	catalogue := catalogueNames(t)
+	require.Len(t, catalogue, len(errors.Reasons()), "every catalogue entry should register exactly one code")
+
+	found := 0
+	err := filepath.WalkDir("..", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) != 1 {
+				return true
+			}
+			if sel, ok := call.Fun.(*ast.SelectorExpr); !ok || sel.Sel.Name != "WithReason" {
+				return true
+			}
+			found++
+			arg, ok := call.Args[0].(*ast.SelectorExpr)
+			if !assert.True(t, ok, "%s: WithReason must be given an errors.Reason* catalogue entry", path) {
+				return true
+			}
+			assert.True(t, catalogue[arg.Sel.Name], "%s: %s is not in the reason catalogue", path, arg.Sel.Name)
+			return true
+		})
+		return nil
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, found, "expected to find WithReason call sites")
+}
+
+func TestReasons_AllRegistered(t *testing.T) {
+	for r, description := range errors.Reasons() {
+		assert.True(t, errors.IsRegistered(r))
+		assert.NotEmpty(t, description, "%s needs a description", r)
+		assert.Equal(t, strings.ToUpper(string(r)), string(r), "reasons are SCREAMING_SNAKE_CASE")
+	}
+	assert.False(t, errors.IsRegistered(errors.Reason("NOT_A_REAL_REASON")))
+}
+
+func TestRespondError_IncludesReason(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/listings", nil)
+
+	errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Title must be between 5 and 100 characters", nil).WithReason(errors.ReasonListingTitleLength))
+
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Equal(t, "INVALID_INPUT", body["error_code"])
+	assert.Equal(t, "LISTING_TITLE_LENGTH", body["reason"])
+}
+
+func TestRespondError_OmitsEmptyReason(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/listings/x", nil)
+
+	errors.RespondError(w, r, errors.New(errors.ErrNotFound, "Listing not found", nil))
+
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	_, ok := body["reason"]
+	assert.False(t, ok)
+}
+
+func TestRespondError_QueryTimeout(t *testing.T) {
+	// SCENARIO: A service wrapped a database error that came from a query running out of its timeout.
+	// EXPECT: 504 with DB_QUERY_TIMEOUT, the code stays INTERNAL. Other internal errors are still 500 without a reason.
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/listings", nil)
+	internal := fmt.Errorf("failed to list listings: %w", fmt.Errorf("%w after 5s: timeout: context deadline exceeded", errors.ErrQueryTimeout))
+	errors.RespondError(w, r, errors.New(errors.ErrInternal, "Failed to fetch listings", internal))
+
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Equal(t, "INTERNAL", body["error_code"])
+	assert.Equal(t, "DB_QUERY_TIMEOUT", body["reason"])
+
+	w = httptest.NewRecorder()
+	errors.RespondError(w, r, errors.New(errors.ErrInternal, "Failed to fetch listings", fmt.Errorf("connection refused")))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "reason")
+}
+
+func TestRespondError_IdempotencyKeyReused(t *testing.T) {
+	// SCENARIO: A client retries with an Idempotency-Key it already used for a different body.
+	// EXPECT: 422 with IDEMPOTENCY_KEY_REUSED, other conflicts stay 409.
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/listings", nil)
+	errors.RespondError(w, r, errors.New(errors.ErrConflict, "Idempotency key reused with different payload", nil).WithReason(errors.ReasonIdempotencyKeyReused))
+
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "CONFLICT", body["error_code"])
+	assert.Equal(t, "IDEMPOTENCY_KEY_REUSED", body["reason"])
+
+	w = httptest.NewRecorder()
+	errors.RespondError(w, r, errors.New(errors.ErrConflict, "Request is currently being processed", nil))
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
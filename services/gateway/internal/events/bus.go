@@ -1,6 +1,30 @@
 package events
 
+import "context"
+
+// Message is an event ready for the bus. Events that must not be lost are written to the outbox in this form, in the
+// transaction of the change they describe, and published by the outbox relay.
+type Message struct {
+	Subject string
+	MsgID   string // JetStream drops a second message with the same ID inside its duplicate window
+	Data    []byte
+}
+
 type Bus interface {
 	Publish(subject string, data []byte, msgId string) error
 	Drain() error
 }
+
+// Handler processes one consumed message. Returning nil acks it, an error redelivers it.
+type Handler func(ctx context.Context, payload []byte) error
+
+// Subscriber is the consuming side of the bus. The gateway mostly publishes, so it's kept out of Bus.
+type Subscriber interface {
+	Subscribe(subject, durable string, handler Handler) error
+}
+
+// Listener hears every message on a subject on every replica, but only while it's connected. Nothing is acked or
+// redelivered, it's for fan-out that only matters live, like the listing event streams.
+type Listener interface {
+	Listen(subject string, handler func(payload []byte)) error
+}
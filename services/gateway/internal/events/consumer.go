@@ -0,0 +1,47 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+)
+
+// indexFailuresDurable is the gateway's consumer of the LISTINGS stream, which fans out, so it needs its own
+const indexFailuresDurable = "gateway-index-failures"
+
+// SubscribeToListingIndexFailed hands every ListingIndexFailedEvent to handler. Returning an error redelivers it.
+func SubscribeToListingIndexFailed(sub Subscriber, config *EventConfig, logger *slog.Logger, handler func(ctx context.Context, evt ListingIndexFailedEvent) error) error {
+	subject := config.ListingIndexFailed
+	logger.Info("Subscribing to ListingIndexFailed events", "subject", subject)
+
+	return sub.Subscribe(subject, indexFailuresDurable, func(ctx context.Context, payload []byte) error {
+		var evt ListingIndexFailedEvent
+		if err := json.Unmarshal(payload, &evt); err != nil {
+			// Ack it, it would never decode
+			logger.Error("Discarding malformed JSON event", "subject", subject, "error", err)
+			return nil
+		}
+		return handler(ctx, evt)
+	})
+}
+
+// ListenToFileValidated hands every FileValidatedEvent to handler, on every replica
+func ListenToFileValidated(l Listener, config *EventConfig, logger *slog.Logger, handler func(evt FileValidatedEvent)) error {
+	return listen(l, config.FileValidated, logger, handler)
+}
+
+// ListenToListingPublished hands every ListingPublishedEvent to handler, on every replica
+func ListenToListingPublished(l Listener, config *EventConfig, logger *slog.Logger, handler func(evt ListingPublishedEvent)) error {
+	return listen(l, config.ListingPublished, logger, handler)
+}
+
+func listen[T any](l Listener, subject string, logger *slog.Logger, handler func(evt T)) error {
+	return l.Listen(subject, func(payload []byte) {
+		var evt T
+		if err := json.Unmarshal(payload, &evt); err != nil {
+			logger.Error("Discarding malformed JSON event", "subject", subject, "error", err)
+			return
+		}
+		handler(evt)
+	})
+}
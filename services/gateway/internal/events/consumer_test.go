@@ -0,0 +1,125 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"gateway/internal/events"
+	"gateway/internal/testutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSubscriber keeps the handler so tests can deliver payloads to it
+type fakeSubscriber struct {
+	subject, durable string
+	handler          events.Handler
+}
+
+func (f *fakeSubscriber) Subscribe(subject, durable string, handler events.Handler) error {
+	f.subject, f.durable, f.handler = subject, durable, handler
+	return nil
+}
+
+func TestSubscribeToListingIndexFailed_WorkerContract(t *testing.T) {
+	// SCENARIO: The listings worker reports a listing it gave up indexing.
+	// EXPECT: The payload it publishes (see TestPublishListingIndexFailed_WireFormat in the worker) decodes
+	// field for field, and a handler error is returned so the event is redelivered.
+
+	sub := &fakeSubscriber{}
+	var got events.ListingIndexFailedEvent
+	handlerErr := errors.New("postgres down")
+	err := events.SubscribeToListingIndexFailed(sub, &events.EventConfig{ListingIndexFailed: "listings.index_failed"}, testutil.NewTestLogger(),
+		func(_ context.Context, evt events.ListingIndexFailedEvent) error {
+			got = evt
+			return handlerErr
+		})
+	require.NoError(t, err)
+	assert.Equal(t, "listings.index_failed", sub.subject)
+	assert.NotEmpty(t, sub.durable)
+
+	err = sub.handler(context.Background(), []byte(`{
+		"listing_id": "550e8400e29b41d4a716446655440000",
+		"seller_id": "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11",
+		"error_class": "search",
+		"error": "503 service unavailable",
+		"attempts": 5,
+		"failed_at": "2026-10-16T09:30:00Z"
+	}`))
+
+	assert.ErrorIs(t, err, handlerErr)
+	assert.Equal(t, events.ListingIndexFailedEvent{
+		ListingID:  "550e8400e29b41d4a716446655440000",
+		SellerID:   "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11",
+		ErrorClass: "search",
+		Error:      "503 service unavailable",
+		Attempts:   5,
+		FailedAt:   "2026-10-16T09:30:00Z",
+	}, got)
+}
+
+func TestSubscribeToListingIndexFailed_AcksMalformedJSON(t *testing.T) {
+	sub := &fakeSubscriber{}
+	called := false
+	require.NoError(t, events.SubscribeToListingIndexFailed(sub, &events.EventConfig{ListingIndexFailed: "listings.index_failed"}, testutil.NewTestLogger(),
+		func(context.Context, events.ListingIndexFailedEvent) error {
+			called = true
+			return nil
+		}))
+
+	assert.NoError(t, sub.handler(context.Background(), []byte(`{"listing_id":`)))
+	assert.False(t, called)
+}
+
+// fakeListener keeps the handler so tests can deliver payloads to it
+type fakeListener struct {
+	subject string
+	handler func(payload []byte)
+}
+
+func (f *fakeListener) Listen(subject string, handler func(payload []byte)) error {
+	f.subject, f.handler = subject, handler
+	return nil
+}
+
+func TestListenToFileValidated_ValidationWorkerContract(t *testing.T) {
+	// SCENARIO: The validation worker reports one file it found invalid and one it passed.
+	// EXPECT: The payloads it publishes (see test_worker_batched_publishes_file_progress in the worker) decode field
+	// for field, a null error stays nil.
+
+	listener := &fakeListener{}
+	var got []events.FileValidatedEvent
+	require.NoError(t, events.ListenToFileValidated(listener, &events.EventConfig{FileValidated: "listings.files.validated"}, testutil.NewTestLogger(),
+		func(evt events.FileValidatedEvent) { got = append(got, evt) }))
+	assert.Equal(t, "listings.files.validated", listener.subject)
+
+	listener.handler([]byte(`{
+		"event_id": "5f0c6b0a2f6a4c1e9a1b8d7e6c5b4a39",
+		"timestamp": "2026-10-16T09:30:00Z",
+		"topic": "listings.files.validated",
+		"listing_id": "550e8400-e29b-41d4-a716-446655440000",
+		"file_id": "6fa459ea-ee8a-3ca4-894e-db77e160355e",
+		"status": "INVALID",
+		"error": "Model too complex"
+	}`))
+	listener.handler([]byte(`{"listing_id": "550e8400-e29b-41d4-a716-446655440000", "file_id": "7c9e6679-7425-40de-944b-e07fc1f90ae7", "status": "VALID", "error": null}`))
+
+	reason := "Model too complex"
+	assert.Equal(t, []events.FileValidatedEvent{
+		{ListingID: "550e8400-e29b-41d4-a716-446655440000", FileID: "6fa459ea-ee8a-3ca4-894e-db77e160355e", Status: "INVALID", Error: &reason},
+		{ListingID: "550e8400-e29b-41d4-a716-446655440000", FileID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Status: "VALID"},
+	}, got)
+}
+
+func TestListenToListingPublished_DropsMalformedJSON(t *testing.T) {
+	listener := &fakeListener{}
+	var got []events.ListingPublishedEvent
+	require.NoError(t, events.ListenToListingPublished(listener, &events.EventConfig{ListingPublished: "listings.published"}, testutil.NewTestLogger(),
+		func(evt events.ListingPublishedEvent) { got = append(got, evt) }))
+
+	listener.handler([]byte(`{"listing_id":`))
+	listener.handler([]byte(`{"event_id": "5f0c6b0a", "topic": "listings.published", "listing_id": "550e8400-e29b-41d4-a716-446655440000", "status": "ACTIVE"}`))
+
+	assert.Equal(t, []events.ListingPublishedEvent{{ListingID: "550e8400-e29b-41d4-a716-446655440000", Status: "ACTIVE"}}, got)
+}
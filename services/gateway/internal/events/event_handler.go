@@ -1,53 +1,113 @@
 package events
 
 import (
-	"encoding/json"
 	"fmt"
 	"log/slog"
+	"shared/pii"
 )
 
 type EventHandler struct {
-	bus    Bus
-	config *EventConfig
-	logger *slog.Logger
+	bus      Bus
+	config   *EventConfig
+	logger   *slog.Logger
+	redactor *pii.Redactor
 }
 
 func NewEventHandler(bus Bus, config *EventConfig, logger *slog.Logger) *EventHandler {
 	return &EventHandler{
-		bus:    bus,
-		config: config,
-		logger: logger,
+		bus:      bus,
+		config:   config,
+		logger:   logger,
+		redactor: newRedactor(config.PIIKey, logger),
 	}
 }
 
-func (h *EventHandler) RaiseStartFileValidationEvent(evt StartFileValidationEvent) error {
-
-	h.logger.Info("Raising ",
-		"listing_id", evt.ListingID,
-		"user_id", evt.UserID,
-		"file_id", evt.FileID,
-		"file_type", evt.FileType,
-	)
+// newRedactor falls back to omitting PII when the key is missing or invalid, an event never goes out in plaintext
+func newRedactor(encodedKey string, logger *slog.Logger) *pii.Redactor {
+	var key []byte
+	if encodedKey != "" {
+		var err error
+		if key, err = pii.ParseKey(encodedKey); err != nil {
+			logger.Error("Invalid EVENT_PII_KEY, PII will be left out of events", "error", err)
+			key = nil
+		}
+	}
 
-	data, err := json.Marshal(evt)
+	redactor, err := pii.NewRedactor(key)
 	if err != nil {
-		h.logger.Error("Failed to marshal StartFileValidationEvent", "error", err)
-		return err
+		logger.Error("Invalid EVENT_PII_KEY, PII will be left out of events", "error", err)
+		redactor, _ = pii.NewRedactor(nil)
 	}
+	return redactor
+}
 
-	msgId := fmt.Sprintf("start.%s.%s.%s", evt.UserID, evt.ListingID, evt.FileID)
-
+// startFileValidationMessage is one file of a listing in the per-file shape, on the subject for its type
+func (h *EventHandler) startFileValidationMessage(evt StartFileValidationEvent) (Message, error) {
+	var subject string
 	switch evt.FileType {
 	case "image":
-		h.bus.Publish(h.config.StartImageValidation, data, msgId)
+		subject = h.config.StartImageValidation
 	case "model":
-		h.bus.Publish(h.config.StartModelValidation, data, msgId)
+		subject = h.config.StartModelValidation
 	default:
 		h.logger.Error("Unsupported file type for validation event", "file_type", evt.FileType)
-		return fmt.Errorf("unsupported file type: %s", evt.FileType)
+		return Message{}, fmt.Errorf("unsupported file type: %s", evt.FileType)
 	}
 
-	return nil
+	data, err := h.redactor.Marshal(evt)
+	if err != nil {
+		h.logger.Error("Failed to marshal StartFileValidationEvent", "error", err)
+		return Message{}, err
+	}
+
+	return Message{
+		Subject: subject,
+		MsgID:   fmt.Sprintf("start.%s.%s.%s", evt.UserID, evt.ListingID, evt.FileID),
+		Data:    data,
+	}, nil
+}
+
+// StartListingValidationMessages hands every file of a listing to one validation worker. With LegacyFileValidation
+// the manifest goes out as a StartFileValidationEvent per file instead. Fails on the first file that can't be sent, so
+// a listing is never sent for validation in part.
+func (h *EventHandler) StartListingValidationMessages(evt StartListingValidationEvent) ([]Message, error) {
+	if h.config.LegacyFileValidation {
+		messages := make([]Message, 0, len(evt.Files))
+		for _, file := range evt.Files {
+			message, err := h.startFileValidationMessage(StartFileValidationEvent{
+				ListingID: evt.ListingID,
+				UserID:    evt.UserID,
+				TraceID:   evt.TraceID,
+				FileID:    file.FileID,
+				FileKey:   file.FileKey,
+				FileType:  file.FileType,
+			})
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, message)
+		}
+		return messages, nil
+	}
+
+	for _, file := range evt.Files {
+		if file.FileType != "image" && file.FileType != "model" {
+			h.logger.Error("Unsupported file type for validation event", "file_type", file.FileType)
+			return nil, fmt.Errorf("unsupported file type: %s", file.FileType)
+		}
+	}
+
+	data, err := h.redactor.Marshal(evt)
+	if err != nil {
+		h.logger.Error("Failed to marshal StartListingValidationEvent", "error", err)
+		return nil, err
+	}
+
+	return []Message{{
+		Subject: h.config.StartListingValidation,
+		MsgID:   fmt.Sprintf("start.%s.%s", evt.UserID, evt.ListingID),
+		Data:    data,
+	}}, nil
 }
 
 func (h *EventHandler) RaiseListingIndexEvent(evt ReIndexListingEvent) error {
@@ -56,14 +116,83 @@ func (h *EventHandler) RaiseListingIndexEvent(evt ReIndexListingEvent) error {
 		"trace_id", evt.TraceID,
 	)
 
-	data, err := json.Marshal(evt)
+	message, err := h.ListingIndexMessage(evt)
 	if err != nil {
-		h.logger.Error("Failed to marshal ListingIndexEvent", "error", err)
 		return err
 	}
+	h.bus.Publish(message.Subject, message.Data, message.MsgID)
+
+	return nil
+}
+
+// ListingIndexMessage has the worker re-read a listing and update or drop its search document
+func (h *EventHandler) ListingIndexMessage(evt ReIndexListingEvent) (Message, error) {
+	data, err := h.redactor.Marshal(evt)
+	if err != nil {
+		h.logger.Error("Failed to marshal ListingIndexEvent", "error", err)
+		return Message{}, err
+	}
 
-	msgId := fmt.Sprintf("index.%s", evt.ListingID)
-	h.bus.Publish(h.config.IndexListingEvent, data, msgId)
+	return Message{
+		Subject: h.config.IndexListingEvent,
+		MsgID:   fmt.Sprintf("index.%s", evt.ListingID),
+		Data:    data,
+	}, nil
+}
 
+// RaiseListingDeleteEvent has the worker remove a deleted listing's document. Nothing is sent while
+// EVENT_DELETE_LISTING is unset.
+func (h *EventHandler) RaiseListingDeleteEvent(evt DeleteListingIndexEvent) error {
+	if h.config.DeleteListingEvent == "" {
+		return nil
+	}
+	h.logger.Info("Raising DeleteListingIndexEvent",
+		"listing_id", evt.ListingID,
+		"trace_id", evt.TraceID,
+	)
+
+	messages, err := h.ListingDeleteMessages(evt)
+	if err != nil {
+		return err
+	}
+	for _, message := range messages {
+		if err := h.bus.Publish(message.Subject, message.Data, message.MsgID); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// ListingDeleteMessages is the delete event for a listing, none while EVENT_DELETE_LISTING is unset
+func (h *EventHandler) ListingDeleteMessages(evt DeleteListingIndexEvent) ([]Message, error) {
+	if h.config.DeleteListingEvent == "" {
+		return nil, nil
+	}
+
+	data, err := h.redactor.Marshal(evt)
+	if err != nil {
+		h.logger.Error("Failed to marshal DeleteListingIndexEvent", "error", err)
+		return nil, err
+	}
+
+	return []Message{{
+		Subject: h.config.DeleteListingEvent,
+		MsgID:   fmt.Sprintf("delete.%s", evt.ListingID),
+		Data:    data,
+	}}, nil
+}
+
+// ListingCreatedMessage announces a new listing
+func (h *EventHandler) ListingCreatedMessage(evt ListingCreatedEvent) (Message, error) {
+	data, err := h.redactor.Marshal(evt)
+	if err != nil {
+		h.logger.Error("Failed to marshal ListingCreatedEvent", "error", err)
+		return Message{}, err
+	}
+
+	return Message{
+		Subject: h.config.ListingCreated,
+		MsgID:   fmt.Sprintf("created.%s", evt.ListingID),
+		Data:    data,
+	}, nil
+}
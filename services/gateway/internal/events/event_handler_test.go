@@ -0,0 +1,161 @@
+package events_test
+
+import (
+	"encoding/json"
+	"gateway/internal/events"
+	"gateway/internal/mocks/mockevents"
+	"gateway/internal/testutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListingCreatedMessage_WireFormat(t *testing.T) {
+	// SCENARIO: A listing created event is written to the outbox.
+	// EXPECT: It goes to the configured subject with a stable message ID, and the payload keys match what
+	// consumers decode (see TestSubscribe_ListingCreated_GatewayContract in the listings worker).
+
+	handler := events.NewEventHandler(mockevents.NewBus(t), &events.EventConfig{ListingCreated: "listings.created"}, testutil.NewTestLogger())
+
+	message, err := handler.ListingCreatedMessage(events.ListingCreatedEvent{
+		ListingID:    "abc123",
+		SellerID:     "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11",
+		Title:        "Benchy",
+		PriceMinUnit: 1050,
+		Currency:     "gbp",
+		Categories:   []string{"Art"},
+		TraceID:      "trace",
+		RequestID:    "req",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "listings.created", message.Subject)
+	assert.Equal(t, "created.abc123", message.MsgID)
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(message.Data, &payload))
+	assert.Equal(t, map[string]any{
+		"listing_id":     "abc123",
+		"seller_id":      "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11",
+		"title":          "Benchy",
+		"price_min_unit": float64(1050),
+		"currency":       "gbp",
+		"categories":     []any{"Art"},
+		"trace_id":       "trace",
+		"request_id":     "req",
+	}, payload)
+}
+
+func TestRaiseListingDeleteEvent_WireFormat(t *testing.T) {
+	// SCENARIO: A deleted listing is sent to be dropped from search.
+	// EXPECT: The message ID differs from the listing's index events, so JetStream doesn't dedupe it against them,
+	// and the payload matches what the worker decodes (see TestSubscribeToDeleteListing_GatewayContract there).
+
+	bus := mockevents.NewBus(t)
+	handler := events.NewEventHandler(bus, &events.EventConfig{DeleteListingEvent: "index.listing.delete"}, testutil.NewTestLogger())
+
+	var payload map[string]any
+	bus.EXPECT().Publish("index.listing.delete", mock.Anything, "delete.abc123").
+		Run(func(_ string, data []byte, _ string) { assert.NoError(t, json.Unmarshal(data, &payload)) }).
+		Return(nil)
+
+	assert.NoError(t, handler.RaiseListingDeleteEvent(events.DeleteListingIndexEvent{ListingID: "abc123", TraceID: "trace"}))
+	assert.Equal(t, map[string]any{"listing_id": "abc123", "trace_id": "trace"}, payload)
+}
+
+func TestRaiseListingDeleteEvent_Unconfigured(t *testing.T) {
+	// SCENARIO: EVENT_DELETE_LISTING isn't set, e.g. the worker hasn't been updated yet.
+	// EXPECT: Nothing is published or queued, the bus mock fails the test on any call.
+
+	handler := events.NewEventHandler(mockevents.NewBus(t), &events.EventConfig{}, testutil.NewTestLogger())
+
+	assert.NoError(t, handler.RaiseListingDeleteEvent(events.DeleteListingIndexEvent{ListingID: "abc123"}))
+	messages, err := handler.ListingDeleteMessages(events.DeleteListingIndexEvent{ListingID: "abc123"})
+	assert.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+func TestStartListingValidationMessages_WireFormat(t *testing.T) {
+	// SCENARIO: A listing with a model and an image is sent for validation.
+	// EXPECT: One message for the listing with the file manifest, keyed the way the validation worker reads it
+	// (see test_worker_batched_contract in the validation worker).
+
+	handler := events.NewEventHandler(mockevents.NewBus(t), &events.EventConfig{StartListingValidation: "files.validate.listing"}, testutil.NewTestLogger())
+
+	messages, err := handler.StartListingValidationMessages(events.StartListingValidationEvent{
+		ListingID: "abc123",
+		UserID:    "user1",
+		TraceID:   "trace",
+		Files: []events.ValidationFile{
+			{FileID: "file1", FileKey: "raw/model.stl", FileType: "model"},
+			{FileID: "file2", FileKey: "raw/image.jpg", FileType: "image"},
+		},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "files.validate.listing", messages[0].Subject)
+	assert.Equal(t, "start.user1.abc123", messages[0].MsgID)
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(messages[0].Data, &payload))
+	assert.Equal(t, map[string]any{
+		"listing_id": "abc123",
+		"user_id":    "user1",
+		"trace_id":   "trace",
+		"files": []any{
+			map[string]any{"file_id": "file1", "file_key": "raw/model.stl", "file_type": "model"},
+			map[string]any{"file_id": "file2", "file_key": "raw/image.jpg", "file_type": "image"},
+		},
+	}, payload)
+}
+
+func TestStartListingValidationMessages_LegacyFanOut(t *testing.T) {
+	// SCENARIO: Validation workers still on the per-file shape, so EVENT_VALIDATE_LEGACY_FILE_EVENTS is on.
+	// EXPECT: A StartFileValidationEvent per file on the subject for its type, and nothing on the listing subject.
+
+	handler := events.NewEventHandler(mockevents.NewBus(t), &events.EventConfig{
+		StartListingValidation: "files.validate.listing",
+		LegacyFileValidation:   true,
+		StartImageValidation:   "files.validate.image",
+		StartModelValidation:   "files.validate.model",
+	}, testutil.NewTestLogger())
+
+	messages, err := handler.StartListingValidationMessages(events.StartListingValidationEvent{
+		ListingID: "abc123",
+		UserID:    "user1",
+		TraceID:   "trace",
+		Files: []events.ValidationFile{
+			{FileID: "file1", FileKey: "raw/model.stl", FileType: "model"},
+			{FileID: "file2", FileKey: "raw/image.jpg", FileType: "image"},
+		},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "files.validate.model", messages[0].Subject)
+	assert.Equal(t, "start.user1.abc123.file1", messages[0].MsgID)
+	assert.Equal(t, "files.validate.image", messages[1].Subject)
+	assert.Equal(t, "start.user1.abc123.file2", messages[1].MsgID)
+	var model events.StartFileValidationEvent
+	require.NoError(t, json.Unmarshal(messages[0].Data, &model))
+	assert.Equal(t, events.StartFileValidationEvent{
+		ListingID: "abc123",
+		UserID:    "user1",
+		TraceID:   "trace",
+		FileID:    "file1",
+		FileKey:   "raw/model.stl",
+		FileType:  "model",
+	}, model)
+}
+
+func TestStartListingValidationMessages_UnsupportedFileType(t *testing.T) {
+	handler := events.NewEventHandler(mockevents.NewBus(t), &events.EventConfig{StartListingValidation: "files.validate.listing"}, testutil.NewTestLogger())
+
+	_, err := handler.StartListingValidationMessages(events.StartListingValidationEvent{
+		ListingID: "abc123",
+		Files:     []events.ValidationFile{{FileID: "file1", FileKey: "raw/notes.txt", FileType: "text"}},
+	})
+
+	assert.Error(t, err)
+}
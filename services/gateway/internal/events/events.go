@@ -2,6 +2,7 @@ package events
 
 import (
 	"os"
+	"strconv"
 )
 
 type ReIndexListingEvent struct {
@@ -9,6 +10,14 @@ type ReIndexListingEvent struct {
 	TraceID   string `json:"trace_id"`
 }
 
+// DeleteListingIndexEvent asks the listings worker to drop a deleted listing from search straight away. The
+// ReIndexListingEvent raised with it shares its message ID with the listing's last index, so JetStream can drop it as
+// a duplicate when the listing is deleted soon after a change.
+type DeleteListingIndexEvent struct {
+	ListingID string `json:"listing_id"`
+	TraceID   string `json:"trace_id"`
+}
+
 type StartFileValidationEvent struct {
 	ListingID string `json:"listing_id"` // This is the database ID of the listing the file is associated with
 	UserID    string `json:"user_id"`    // This is the database ID of the user who uploaded the file
@@ -18,16 +27,101 @@ type StartFileValidationEvent struct {
 	FileType  string `json:"file_type"`  // This is the file type, e.g., "image" | "model"
 }
 
+// StartListingValidationEvent asks the validation worker to check every pending file of a new listing. One worker
+// takes the whole manifest, so a listing with many files doesn't have every worker fetching the same seller's
+// uploads at once, and the last file done is simply the end of the job.
+type StartListingValidationEvent struct {
+	ListingID string           `json:"listing_id"`
+	UserID    string           `json:"user_id"`
+	TraceID   string           `json:"trace_id"`
+	Files     []ValidationFile `json:"files"`
+}
+
+// ValidationFile is one entry of a StartListingValidationEvent's manifest
+type ValidationFile struct {
+	FileID   string `json:"file_id"`
+	FileKey  string `json:"file_key"`
+	FileType string `json:"file_type"` // "image" | "model"
+}
+
+// ListingCreatedEvent is written to the outbox with the listing and its files, and published once they are committed.
+// Consumers (analytics, notifications, webhooks) must tolerate duplicates, the message ID only
+// dedupes within JetStream's duplicate window.
+type ListingCreatedEvent struct {
+	ListingID    string   `json:"listing_id"`
+	SellerID     string   `json:"seller_id"`
+	Title        string   `json:"title"`
+	PriceMinUnit int64    `json:"price_min_unit"` // In the smallest unit of Currency, e.g. pence
+	Currency     string   `json:"currency"`
+	Categories   []string `json:"categories"`
+	TraceID      string   `json:"trace_id"`
+	RequestID    string   `json:"request_id"`
+}
+
+// ListingIndexFailedEvent is published by the listings worker when it gives up indexing a listing, the gateway records
+// it so the seller and moderators can see the listing isn't in search
+type ListingIndexFailedEvent struct {
+	ListingID  string `json:"listing_id"`
+	SellerID   string `json:"seller_id"`   // Empty when the worker couldn't read the listing
+	ErrorClass string `json:"error_class"` // database, document, search or event
+	Error      string `json:"error"`       // The worker's last error, never shown to sellers
+	Attempts   int    `json:"attempts"`
+	FailedAt   string `json:"failed_at"` // RFC 3339 with fractional seconds
+}
+
+// FileValidatedEvent is published by the validation worker as each file of a listing is done, for the seller watching
+// the listing's event stream
+type FileValidatedEvent struct {
+	ListingID string  `json:"listing_id"`
+	FileID    string  `json:"file_id"`
+	Status    string  `json:"status"` // VALID, INVALID or FAILED
+	Error     *string `json:"error"`  // Why the file isn't valid, nil when it is
+}
+
+// ListingPublishedEvent is published by the validation worker when a listing passes validation and goes ACTIVE
+type ListingPublishedEvent struct {
+	ListingID string `json:"listing_id"`
+	Status    string `json:"status"` // ACTIVE, empty from workers that predate it
+}
+
 type EventConfig struct {
+	StartListingValidation string
+	// LegacyFileValidation raises a StartFileValidationEvent per file on StartImageValidation and
+	// StartModelValidation instead of the one StartListingValidationEvent, for validation workers that only
+	// understand the per-file shape. Only meant for the migration to the batched event.
+	LegacyFileValidation bool
 	StartImageValidation string
 	StartModelValidation string
 	IndexListingEvent    string
+	// DeleteListingEvent is optional, without it deleted listings leave search through IndexListingEvent alone
+	DeleteListingEvent string
+	ListingCreated     string
+	// ListingIndexFailed is consumed rather than raised, without it index failures aren't recorded
+	ListingIndexFailed string
+	// FileValidated and ListingPublished are listened to rather than consumed, every replica hears them and passes them
+	// on to the sellers streaming that listing's events. Without them the streams only send heartbeats.
+	FileValidated    string
+	ListingPublished string
+	// PIIKey is the base64 key PII fields are hashed and encrypted with, shared with consumers that read them, see
+	// shared/pii. Without it PII fields are left out of every event.
+	PIIKey string
 }
 
 func NewEventConfig() *EventConfig {
+	// Anything but a true value keeps the batched event
+	legacy, _ := strconv.ParseBool(os.Getenv("EVENT_VALIDATE_LEGACY_FILE_EVENTS"))
+
 	return &EventConfig{
-		StartImageValidation: os.Getenv("EVENT_VALIDATE_IMAGE_START"),
-		StartModelValidation: os.Getenv("EVENT_VALIDATE_MODEL_START"),
-		IndexListingEvent:    os.Getenv("EVENT_INDEX_LISTING"),
+		StartListingValidation: os.Getenv("EVENT_VALIDATE_LISTING_START"),
+		LegacyFileValidation:   legacy,
+		StartImageValidation:   os.Getenv("EVENT_VALIDATE_IMAGE_START"),
+		StartModelValidation:   os.Getenv("EVENT_VALIDATE_MODEL_START"),
+		IndexListingEvent:      os.Getenv("EVENT_INDEX_LISTING"),
+		DeleteListingEvent:     os.Getenv("EVENT_DELETE_LISTING"),
+		ListingCreated:         os.Getenv("EVENT_LISTING_CREATED"),
+		ListingIndexFailed:     os.Getenv("EVENT_LISTING_INDEX_FAILED"),
+		FileValidated:          os.Getenv("EVENT_FILE_VALIDATED"),
+		ListingPublished:       os.Getenv("EVENT_LISTING_PUBLISHED"),
+		PIIKey:                 os.Getenv("EVENT_PII_KEY"),
 	}
 }
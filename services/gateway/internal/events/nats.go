@@ -1,15 +1,23 @@
 package events
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
-	"os"
+	"shared/natsconn"
 	"time"
 
 	"github.com/nats-io/nats.go"
 )
 
-var _ Bus = NATSBus{}
+var (
+	_ Bus        = NATSBus{}
+	_ Subscriber = NATSBus{}
+	_ Listener   = NATSBus{}
+)
+
+// maxDeliveries bounds redeliveries of a message the gateway can't handle, same as the listings worker
+const maxDeliveries = 5
 
 type NATSBus struct {
 	nats *nats.Conn
@@ -17,38 +25,13 @@ type NATSBus struct {
 	log  *slog.Logger
 }
 
-func NewNATSBus(addr string, logger *slog.Logger) (*NATSBus, error) {
-
-	opts := []nats.Option{
-		// 1. Identification: Makes debugging on the NATS dashboard easier
-		nats.Name("gateway-service"),
-
-		// 2. Resilience: NEVER give up trying to reconnect.
-		// Default is 60. We set -1 (infinite).
-		nats.MaxReconnects(-1),
-
-		// 3. Backoff: Don't spam the server. Wait 3s between attempts.
-		nats.ReconnectWait(3 * time.Second),
-
-		// 4. Observability: Log when things go wrong
-		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
-			logger.Warn("NATS disconnected! Buffering messages...", "error", err)
-		}),
-
-		nats.ReconnectHandler(func(nc *nats.Conn) {
-			logger.Info("NATS reconnected successfully!", "url", nc.ConnectedUrl())
-		}),
-
-		// 5. Safety Net: If the connection is permanently dead (e.g. auth failure),
-		// kill the app so Docker can restart it with fresh config/state.
-		nats.ClosedHandler(func(nc *nats.Conn) {
-			logger.Error("NATS connection closed permanently. Exiting process.")
-			os.Exit(1)
-		}),
-	}
-	nc, err := nats.Connect(addr, opts...)
+// NewNATSBus connects with cfg and creates the streams the gateway publishes to if they are missing. The error wraps
+// natsconn.ErrAuth when the credentials were turned down and natsconn.ErrUnreachable when the server couldn't be
+// reached.
+func NewNATSBus(cfg natsconn.Config, logger *slog.Logger) (*NATSBus, error) {
+	nc, err := natsconn.Connect("gateway-service", cfg, logger)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to create nats client: %w", err)
+		return nil, err
 	}
 
 	js, err := nc.JetStream()
@@ -56,6 +39,12 @@ func NewNATSBus(addr string, logger *slog.Logger) (*NATSBus, error) {
 		return nil, err
 	}
 
+	// The outbox relay publishes to LISTINGS and INDEX straight after startup, the listings worker may not have run yet
+	if err := natsconn.EnsureStreams(js, logger); err != nil {
+		nc.Close()
+		return nil, err
+	}
+
 	return &NATSBus{
 		nats: nc,
 		js:   js,
@@ -70,6 +59,56 @@ func (b NATSBus) Publish(subject string, data []byte, msgId string) error {
 	return err
 }
 
+// Subscribe consumes subject under durable, shared by every gateway replica so each message is handled once.
+// A handler error redelivers the message, up to maxDeliveries times.
+func (b NATSBus) Subscribe(subject, durable string, handler Handler) error {
+	b.log.Info("Subscribing to subject", "subject", subject, "durable", durable)
+
+	_, err := b.js.QueueSubscribe(subject, durable, func(msg *nats.Msg) {
+		// A stuck handler mustn't hang the subscription
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := handler(ctx, msg.Data); err != nil {
+			b.log.Error("Handler failed, Nacking message", "subject", subject, "error", err)
+			msg.Nak()
+			return
+		}
+		if err := msg.Ack(); err != nil {
+			b.log.Error("Failed to Ack message", "subject", subject, "error", err)
+		}
+	},
+		nats.Durable(durable),
+		nats.ManualAck(),
+		nats.AckExplicit(),
+		nats.DeliverAll(),
+		nats.MaxDeliver(maxDeliveries),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to subject %s: %w", subject, err)
+	}
+	return nil
+}
+
+// Listen subscribes outside JetStream, so every replica gets every message published while it's connected and a slow
+// handler holds up nothing but its own subscription
+func (b NATSBus) Listen(subject string, handler func(payload []byte)) error {
+	b.log.Info("Listening to subject", "subject", subject)
+
+	_, err := b.nats.Subscribe(subject, func(msg *nats.Msg) {
+		handler(msg.Data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to listen to subject %s: %w", subject, err)
+	}
+	return nil
+}
+
+// StreamForSubject names the JetStream stream that captures subject, publishes to a subject without one fail
+func (b NATSBus) StreamForSubject(subject string) (string, error) {
+	return b.js.StreamNameBySubject(subject)
+}
+
 func (b NATSBus) Drain() error {
 	b.log.Info("Draining events")
 	return b.nats.Drain()
@@ -0,0 +1,96 @@
+package cacheadmin
+
+import (
+	stdjson "encoding/json"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ListingCacheResponse is a cached listing as GET /listings/{id} would serve it
+type ListingCacheResponse struct {
+	Key        string             `json:"key"`
+	Value      stdjson.RawMessage `json:"value"`
+	TTLSeconds *int64             `json:"ttl_seconds"` // Null when the entry never expires
+}
+
+type Handler struct {
+	store ListingCache
+}
+
+func NewHandler(store ListingCache) *Handler {
+	return &Handler{store: store}
+}
+
+func (h *Handler) GetListing(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	listingID, ok := listingIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	entry, found, err := h.store.Get(ctx, listingID)
+	if err != nil {
+		errors.RespondError(w, r, errors.New(errors.ErrInternal, "Failed to read the listing cache", err))
+		return
+	}
+	if !found {
+		errors.RespondError(w, r, errors.New(errors.ErrNotFound, "Listing is not cached", nil))
+		return
+	}
+
+	response := ListingCacheResponse{Key: entry.Key, Value: entry.Value}
+	if entry.TTL >= 0 {
+		seconds := int64(entry.TTL.Seconds())
+		response.TTLSeconds = &seconds
+	}
+	json.Write(w, http.StatusOK, response)
+}
+
+func (h *Handler) DeleteListing(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+	listingID, ok := listingIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	existed, err := h.store.Del(ctx, listingID)
+	if err != nil {
+		errors.RespondError(w, r, errors.New(errors.ErrInternal, "Failed to delete the listing cache entry", err))
+		return
+	}
+
+	slog.WarnContext(ctx, "Listing cache entry deleted", "listing_id", listingID, "existed", existed, "user_id", userInfo.ID, "username", userInfo.Username)
+	json.Write(w, http.StatusNoContent, nil)
+}
+
+// currentUser responds with the error and returns false when there's no user. The routes are mounted behind
+// auth.RequireRole for moderators and admins.
+func currentUser(w http.ResponseWriter, r *http.Request) (auth.UserInfo, bool) {
+	userInfo, err := auth.GetUserInfo(r.Context())
+	if err != nil {
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return auth.UserInfo{}, false
+	}
+	return userInfo, true
+}
+
+// listingIDParam only accepts a UUID, so the ID can't be used to reach past the listing namespace
+func listingIDParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	listingID := chi.URLParam(r, "id")
+	var id pgtype.UUID
+	if err := id.Scan(listingID); err != nil {
+		errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Invalid listing ID provided", err))
+		return "", false
+	}
+	return listingID, true
+}
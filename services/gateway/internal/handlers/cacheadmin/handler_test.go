@@ -0,0 +1,113 @@
+package cacheadmin_test
+
+import (
+	"encoding/json"
+	"gateway/internal/auth"
+	"gateway/internal/handlers/cacheadmin"
+	"gateway/internal/mocks/mockcacheadmin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const listingID = "550e8400-e29b-41d4-a716-446655440000"
+
+var moderator = auth.UserInfo{ID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", Username: "mod", Roles: []string{auth.RoleModerator}}
+
+func serve(h *cacheadmin.Handler, user *auth.UserInfo, method, id string) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Get("/admin/cache/listing/{id}", h.GetListing)
+	r.Delete("/admin/cache/listing/{id}", h.DeleteListing)
+
+	req := httptest.NewRequest(method, "/admin/cache/listing/"+id, nil)
+	if user != nil {
+		req = req.WithContext(auth.WithUserInfo(req.Context(), *user))
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestGetListing_ReturnsValueAndTTL(t *testing.T) {
+	store := mockcacheadmin.NewListingCache(t)
+	store.EXPECT().Get(mock.Anything, listingID).Return(cacheadmin.Entry{
+		Key:   "listing:" + listingID,
+		Value: json.RawMessage(`{"id":"` + listingID + `","title":"Benchy"}`),
+		TTL:   42*time.Minute + 500*time.Millisecond,
+	}, true, nil)
+
+	w := serve(cacheadmin.NewHandler(store), &moderator, http.MethodGet, listingID)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body cacheadmin.ListingCacheResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "listing:"+listingID, body.Key)
+	assert.JSONEq(t, `{"id":"`+listingID+`","title":"Benchy"}`, string(body.Value))
+	require.NotNil(t, body.TTLSeconds)
+	assert.Equal(t, int64(42*60), *body.TTLSeconds)
+}
+
+func TestGetListing_NoExpiry(t *testing.T) {
+	store := mockcacheadmin.NewListingCache(t)
+	store.EXPECT().Get(mock.Anything, listingID).Return(cacheadmin.Entry{Key: "listing:" + listingID, Value: json.RawMessage(`{}`), TTL: -1}, true, nil)
+
+	w := serve(cacheadmin.NewHandler(store), &moderator, http.MethodGet, listingID)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"ttl_seconds":null`)
+}
+
+func TestGetListing_NotCached(t *testing.T) {
+	store := mockcacheadmin.NewListingCache(t)
+	store.EXPECT().Get(mock.Anything, listingID).Return(cacheadmin.Entry{}, false, nil)
+
+	w := serve(cacheadmin.NewHandler(store), &moderator, http.MethodGet, listingID)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDeleteListing_Busts(t *testing.T) {
+	admin := auth.UserInfo{ID: "b0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", Roles: []string{auth.RoleAdmin}}
+
+	for _, existed := range []bool{true, false} {
+		store := mockcacheadmin.NewListingCache(t)
+		store.EXPECT().Del(mock.Anything, listingID).Return(existed, nil).Once()
+
+		w := serve(cacheadmin.NewHandler(store), &admin, http.MethodDelete, listingID)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	}
+}
+
+func TestCacheAdmin_Rejects(t *testing.T) {
+	// The role is checked by the router, see TestRoutes_AdminRoutesListsMiddleware
+	tests := []struct {
+		name       string
+		user       *auth.UserInfo
+		id         string
+		wantStatus int
+	}{
+		// Anything but a listing ID could be used to walk into other keys
+		{"Key pattern", &moderator, "*", http.StatusBadRequest},
+		{"Other namespace", &moderator, "idempotency:" + listingID, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		for _, method := range []string{http.MethodGet, http.MethodDelete} {
+			t.Run(tt.name+" "+method, func(t *testing.T) {
+				// No expectations, the store must not be touched
+				store := mockcacheadmin.NewListingCache(t)
+
+				w := serve(cacheadmin.NewHandler(store), tt.user, method, tt.id)
+
+				require.Equal(t, tt.wantStatus, w.Code)
+			})
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package cacheadmin
+
+import (
+	"context"
+	"encoding/json"
+	"gateway/internal/cache"
+	"time"
+)
+
+// Entry is a cached value as stored, with how long it has left. TTL is negative when the entry never expires.
+type Entry struct {
+	Key   string
+	Value json.RawMessage
+	TTL   time.Duration
+}
+
+// ListingCache reads and busts the cached GET /listings/{id} responses, and nothing else in Redis
+type ListingCache interface {
+	Get(ctx context.Context, listingID string) (Entry, bool, error)
+	Del(ctx context.Context, listingID string) (bool, error)
+}
+
+type Store struct {
+	cache     *cache.RedisClient
+	namespace cache.Namespace // Must be the one the listings service writes to, see listings.CacheNamespace
+}
+
+func NewStore(c *cache.RedisClient, namespace cache.Namespace) *Store {
+	return &Store{cache: c, namespace: namespace}
+}
+
+func (s *Store) Get(ctx context.Context, listingID string) (Entry, bool, error) {
+	value, ttl, found, err := s.namespace.Get(s.cache, ctx, listingID)
+	if err != nil || !found {
+		return Entry{}, false, err
+	}
+	return Entry{Key: s.namespace.Key(listingID), Value: value, TTL: ttl}, true, nil
+}
+
+// Del busts the listing's entry, the next GET rebuilds it from the database
+func (s *Store) Del(ctx context.Context, listingID string) (bool, error) {
+	return s.namespace.Del(s.cache, ctx, listingID)
+}
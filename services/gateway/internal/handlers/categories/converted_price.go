@@ -0,0 +1,60 @@
+package categories
+
+import (
+	"encoding/json"
+	"gateway/internal/currency"
+	"strings"
+	"time"
+)
+
+// showPricesIn adds the converted price, and sale price during a sale, to every listing on the page. The cached page never carries a conversion, so
+// this runs on the copy handed to the user.
+func (p *CategoryPage) showPricesIn(rates currency.Rates, displayCurrency string) {
+	date := rates.Date.Format(time.DateOnly)
+	for _, document := range p.TopListings.Listings {
+		price, _ := asInt64(document["price_min_unit"])
+		listingCurrency, _ := document["currency"].(string)
+		if converted, ok := convert(rates, price, listingCurrency, displayCurrency); ok {
+			document["price_converted"] = converted
+			document["converted_currency"] = displayCurrency
+			document["converted_rate_date"] = date
+			if sale, ok := asInt64(document["sale_price"]); ok {
+				if converted, ok := rates.Convert(sale, listingCurrency, displayCurrency); ok {
+					document["sale_price_converted"] = converted
+				}
+			}
+		}
+	}
+	for i := range p.Trending.Listings {
+		listing := &p.Trending.Listings[i]
+		if converted, ok := convert(rates, listing.PriceMinUnit, listing.Currency, displayCurrency); ok {
+			listing.PriceConverted = &converted
+			listing.ConvertedCurrency = displayCurrency
+			listing.ConvertedRateDate = date
+		}
+	}
+}
+
+// convert skips free listings and those already in displayCurrency, like the listing page
+func convert(rates currency.Rates, price int64, from, to string) (int64, bool) {
+	if price == 0 || from == "" || strings.EqualFold(from, to) {
+		return 0, false
+	}
+	return rates.Convert(price, from, to)
+}
+
+// asInt64 reads a number out of a search document, decoded from the search response or the page cache
+func asInt64(v any) (int64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int64(n), true
+	case int64:
+		return n, true
+	case int:
+		return int64(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return i, err == nil
+	}
+	return 0, false
+}
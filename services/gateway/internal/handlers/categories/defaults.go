@@ -0,0 +1,131 @@
+package categories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gateway/internal/auth"
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DefaultsCacheTTL is long because templates only change through SetDefaults, which writes through the cache
+const DefaultsCacheTTL = 24 * time.Hour
+
+// dimensionsJSON is the stored shape, the same as listings.dimensions_mm
+type dimensionsJSON struct {
+	Width  int `json:"width"`
+	Depth  int `json:"depth"`
+	Height int `json:"height"`
+}
+
+func (s *svc) GetDefaults(ctx context.Context, category string) (*Defaults, error) {
+	if !IsCanonical(category) {
+		return nil, defaultsNotFound(category)
+	}
+
+	cached, found, err := s.defaults.GetDefaults(ctx, category)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to get category defaults from cache", "category", category, "error", err)
+	} else if found {
+		return cached, nil
+	}
+
+	row, err := s.repo.GetCategoryDefaults(ctx, category)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, defaultsNotFound(category)
+		}
+		s.logger.ErrorContext(ctx, "Failed to fetch category defaults", "category", category, "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch category defaults", err)
+	}
+
+	defaults, err := toDefaults(row)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to read category defaults", "category", category, "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch category defaults", err)
+	}
+
+	if err := s.defaults.SetDefaults(ctx, *defaults, DefaultsCacheTTL); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to cache category defaults", "category", category, "error", err)
+	}
+	return defaults, nil
+}
+
+func (s *svc) SetDefaults(ctx context.Context, userInfo auth.UserInfo, category string, req *SetDefaultsRequest) (*Defaults, error) {
+	if !IsCanonical(category) {
+		return nil, defaultsNotFound(category)
+	}
+	if appErr := req.Validate(); appErr != nil {
+		return nil, appErr
+	}
+
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userInfo.ID); err != nil {
+		return nil, errors.New(errors.ErrInternal, "Invalid user ID", fmt.Errorf("invalid user uuid: %w", err))
+	}
+
+	dims, err := json.Marshal(dimensionsJSON{
+		Width:  req.TypicalDimensionsMM.X,
+		Depth:  req.TypicalDimensionsMM.Y,
+		Height: req.TypicalDimensionsMM.Z,
+	})
+	if err != nil {
+		return nil, errors.New(errors.ErrInternal, "Failed to save category defaults", fmt.Errorf("failed to encode dimensions: %w", err))
+	}
+
+	row, err := s.repo.UpsertCategoryDefaults(ctx, repo.UpsertCategoryDefaultsParams{
+		Category:             category,
+		RecommendedMaterials: req.RecommendedMaterials,
+		NozzleTempMinC:       int32(req.NozzleTempC.Min),
+		NozzleTempMaxC:       int32(req.NozzleTempC.Max),
+		TypicalDimensionsMm:  dims,
+		UpdatedBy:            userUUID,
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to save category defaults", "category", category, "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to save category defaults", err)
+	}
+
+	defaults, err := toDefaults(row)
+	if err != nil {
+		return nil, errors.New(errors.ErrInternal, "Failed to save category defaults", err)
+	}
+
+	// Written through rather than deleted so every pod sees the edit straight away without a trip to Postgres
+	if err := s.defaults.SetDefaults(ctx, *defaults, DefaultsCacheTTL); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to cache category defaults", "category", category, "error", err)
+	}
+
+	s.logger.InfoContext(ctx, "Category defaults updated", "category", category, "user_id", userInfo.ID, "username", userInfo.Username)
+	return defaults, nil
+}
+
+func toDefaults(row repo.CategoryDefault) (*Defaults, error) {
+	var dims dimensionsJSON
+	if err := json.Unmarshal(row.TypicalDimensionsMm, &dims); err != nil {
+		return nil, fmt.Errorf("failed to decode typical dimensions of %s: %w", row.Category, err)
+	}
+
+	materials := row.RecommendedMaterials
+	if materials == nil {
+		materials = []string{}
+	}
+	return &Defaults{
+		Category:             row.Category,
+		RecommendedMaterials: materials,
+		NozzleTempC:          TempRange{Min: int(row.NozzleTempMinC), Max: int(row.NozzleTempMaxC)},
+		TypicalDimensionsMM:  DimensionsMM{X: dims.Width, Y: dims.Depth, Z: dims.Height},
+		UpdatedAt:            row.UpdatedAt.Time,
+	}, nil
+}
+
+func defaultsNotFound(category string) *errors.AppError {
+	return errors.New(errors.ErrNotFound, fmt.Sprintf("No printer settings template for '%s'", category), nil).
+		WithReason(errors.ReasonCategoryDefaultsNotFound).
+		WithParam("category", category)
+}
@@ -0,0 +1,146 @@
+package categories_test
+
+import (
+	"context"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/handlers/categories"
+	"gateway/internal/mocks/mocksearch"
+	"gateway/internal/publicurl"
+	"gateway/internal/testutil"
+	"regexp"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var defaultsCols = []string{"category", "recommended_materials", "nozzle_temp_min_c", "nozzle_temp_max_c", "typical_dimensions_mm", "updated_by", "updated_at"}
+
+func TestGetDefaults_LoadedAndCached(t *testing.T) {
+	// SCENARIO: The create form asks for a template that isn't cached yet, then asks again.
+	// EXPECT: Postgres is read once, the template is cached for DefaultsCacheTTL and served from there afterwards.
+
+	mockPool := testutil.NewMockDB(t)
+	store := &fakeStore{}
+	service := categories.NewCategoriesService(repo.New(mockPool), mocksearch.NewClient(t), store, store, store, publicurl.Config{}, testutil.NewTestLogger())
+
+	updatedAt := time.Date(2026, 9, 1, 10, 0, 0, 0, time.UTC)
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetCategoryDefaults :one`)).
+		WithArgs("functional").
+		WillReturnRows(pgxmock.NewRows(defaultsCols).
+			AddRow("functional", []string{"PETG", "PLA"}, int32(200), int32(250), []byte(`{"width": 80, "depth": 60, "height": 40}`), nil, updatedAt))
+
+	want := &categories.Defaults{
+		Category:             "functional",
+		RecommendedMaterials: []string{"PETG", "PLA"},
+		NozzleTempC:          categories.TempRange{Min: 200, Max: 250},
+		TypicalDimensionsMM:  categories.DimensionsMM{X: 80, Y: 60, Z: 40},
+		UpdatedAt:            updatedAt,
+	}
+
+	got, err := service.GetDefaults(context.Background(), "functional")
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.Equal(t, categories.DefaultsCacheTTL, store.defaultsTTL)
+
+	got, err = service.GetDefaults(context.Background(), "functional")
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestGetDefaults_NotFound(t *testing.T) {
+	tests := map[string]struct {
+		category string
+		expect   func(mockPool pgxmock.PgxPoolIface)
+	}{
+		// Never reaches the database
+		"not a category": {category: "vases", expect: func(mockPool pgxmock.PgxPoolIface) {}},
+		"no template yet": {category: "prototypes", expect: func(mockPool pgxmock.PgxPoolIface) {
+			mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetCategoryDefaults :one`)).WithArgs("prototypes").WillReturnError(pgx.ErrNoRows)
+		}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockPool := testutil.NewMockDB(t)
+			store := &fakeStore{}
+			service := categories.NewCategoriesService(repo.New(mockPool), mocksearch.NewClient(t), store, store, store, publicurl.Config{}, testutil.NewTestLogger())
+			tt.expect(mockPool)
+
+			_, err := service.GetDefaults(context.Background(), tt.category)
+
+			var appErr *errors.AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, errors.ErrNotFound, appErr.Code)
+			assert.Equal(t, errors.ReasonCategoryDefaultsNotFound, appErr.Reason)
+			assert.Empty(t, store.defaults)
+			assert.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSetDefaults(t *testing.T) {
+	moderator := auth.UserInfo{ID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", Username: "mod"}
+
+	t.Run("Saved and written through the cache", func(t *testing.T) {
+		mockPool := testutil.NewMockDB(t)
+		store := &fakeStore{defaults: map[string]categories.Defaults{"artistic": {Category: "artistic"}}}
+		service := categories.NewCategoriesService(repo.New(mockPool), mocksearch.NewClient(t), store, store, store, publicurl.Config{}, testutil.NewTestLogger())
+
+		mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: UpsertCategoryDefaults :one`)).
+			WithArgs("artistic", []string{"PLA", "Resin"}, int32(190), int32(220), pgxmock.AnyArg(), pgxmock.AnyArg()).
+			WillReturnRows(pgxmock.NewRows(defaultsCols).
+				AddRow("artistic", []string{"PLA", "Resin"}, int32(190), int32(220), []byte(`{"width": 40, "depth": 40, "height": 60}`), moderator.ID, time.Now()))
+
+		got, err := service.SetDefaults(context.Background(), moderator, "artistic", &categories.SetDefaultsRequest{
+			RecommendedMaterials: []string{" PLA ", "pla", "", "Resin"},
+			NozzleTempC:          categories.TempRange{Min: 190, Max: 220},
+			TypicalDimensionsMM:  categories.DimensionsMM{X: 40, Y: 40, Z: 60},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"PLA", "Resin"}, got.RecommendedMaterials)
+		assert.Equal(t, *got, store.defaults["artistic"])
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	invalid := map[string]struct {
+		req        categories.SetDefaultsRequest
+		wantReason errors.Reason
+	}{
+		"no materials": {
+			req:        categories.SetDefaultsRequest{RecommendedMaterials: []string{" "}, NozzleTempC: categories.TempRange{Min: 200, Max: 220}, TypicalDimensionsMM: categories.DimensionsMM{X: 1, Y: 1, Z: 1}},
+			wantReason: errors.ReasonCategoryDefaultsMaterials,
+		},
+		"min above max": {
+			req:        categories.SetDefaultsRequest{RecommendedMaterials: []string{"PLA"}, NozzleTempC: categories.TempRange{Min: 230, Max: 210}, TypicalDimensionsMM: categories.DimensionsMM{X: 1, Y: 1, Z: 1}},
+			wantReason: errors.ReasonCategoryDefaultsTempRange,
+		},
+		"flat model": {
+			req:        categories.SetDefaultsRequest{RecommendedMaterials: []string{"PLA"}, NozzleTempC: categories.TempRange{Min: 200, Max: 220}, TypicalDimensionsMM: categories.DimensionsMM{X: 10, Y: 10}},
+			wantReason: errors.ReasonCategoryDefaultsDimensions,
+		},
+	}
+	for name, tt := range invalid {
+		t.Run(name, func(t *testing.T) {
+			mockPool := testutil.NewMockDB(t)
+			store := &fakeStore{}
+			service := categories.NewCategoriesService(repo.New(mockPool), mocksearch.NewClient(t), store, store, store, publicurl.Config{}, testutil.NewTestLogger())
+
+			_, err := service.SetDefaults(context.Background(), moderator, "functional", &tt.req)
+
+			var appErr *errors.AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+			assert.Equal(t, tt.wantReason, appErr.Reason)
+			assert.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
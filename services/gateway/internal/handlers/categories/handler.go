@@ -0,0 +1,94 @@
+package categories
+
+import (
+	"gateway/internal/auth"
+	"gateway/internal/currency"
+	"gateway/internal/errors"
+	"gateway/internal/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type CategoriesHandler struct {
+	service CategoriesService
+	rates   currency.RatesSource
+}
+
+func NewCategoriesHandler(svc CategoriesService, rates currency.RatesSource) *CategoriesHandler {
+	return &CategoriesHandler{
+		service: svc,
+		rates:   rates,
+	}
+}
+
+func (h *CategoriesHandler) GetCounts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	counts, err := h.service.GetCounts(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to fetch category counts", "error", err)
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, counts)
+}
+
+// GetDefaults serves the printer settings template the create form is prefilled from
+func (h *CategoriesHandler) GetDefaults(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	defaults, err := h.service.GetDefaults(ctx, chi.URLParam(r, "slug"))
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, defaults)
+}
+
+// GetPage serves a category landing page, everything the page shows in one call
+func (h *CategoriesHandler) GetPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	page, err := h.service.GetPage(ctx, chi.URLParam(r, "slug"))
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+	if displayCurrency := currency.Requested(r); displayCurrency != "" {
+		if rates, ok := h.rates.Current(ctx); ok {
+			page.showPricesIn(rates, displayCurrency)
+		}
+	}
+
+	json.Write(w, http.StatusOK, page)
+}
+
+// SetDefaults is mounted behind auth.RequireRole for moderators and admins
+func (h *CategoriesHandler) SetDefaults(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	req := SetDefaultsRequest{}
+	if err := json.Read(r, &req); err != nil {
+		slog.WarnContext(ctx, "Invalid request body", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Input provided was not in the format expected. Please contact support if this error persists.", err))
+		return
+	}
+
+	defaults, err := h.service.SetDefaults(ctx, userInfo, chi.URLParam(r, "slug"), &req)
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, defaults)
+}
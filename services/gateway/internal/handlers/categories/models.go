@@ -0,0 +1,173 @@
+package categories
+
+import (
+	"gateway/internal/errors"
+	"shared/materials"
+	"time"
+)
+
+// Category is one entry of the navigation menu
+type Category struct {
+	Value string `json:"value"` // What's stored on listings and sent as a filter
+	Label string `json:"label"`
+}
+
+// Canonical is the category list shown in navigation, in display order.
+// Keep in sync with AVAILABLE_CATEGORIES in the web UI.
+var Canonical = []Category{
+	{Value: "functional", Label: "Functional Parts"},
+	{Value: "artistic", Label: "Artistic & Miniatures"},
+	{Value: "prototypes", Label: "Prototypes"},
+	{Value: "spare-parts", Label: "Spare Parts"},
+}
+
+const (
+	SourceSearch   = "search"
+	SourceDatabase = "database"
+)
+
+type CategoryCount struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+type CategoryCountsResponse struct {
+	Total      int64           `json:"total"`      // Active listings, one in two categories is counted once
+	Categories []CategoryCount `json:"categories"` // Every canonical category, in display order, zero if empty
+	Source     string          `json:"source"`     // "search" or "database" when search was unavailable
+	// Set while search is down and these are its last good counts, kept past their TTL for exactly this
+	Stale bool `json:"stale,omitempty"`
+}
+
+// CategoryPage is everything a category landing page shows. Each section is fetched on its own, one that fails is
+// sent stale or empty and marked degraded rather than failing the page.
+type CategoryPage struct {
+	Category    Category           `json:"category"`
+	Counts      CategoryPageCounts `json:"counts"`
+	TopListings CategoryPageTop    `json:"top_listings"`
+	Trending    CategoryPageTrend  `json:"trending"`
+	Degraded    bool               `json:"degraded"` // Any section is degraded
+	GeneratedAt time.Time          `json:"generated_at"`
+}
+
+// CategoryPageCounts is GET /categories/counts with this category's count picked out
+type CategoryPageCounts struct {
+	Count      int64           `json:"count"`
+	Total      int64           `json:"total"`
+	Categories []CategoryCount `json:"categories"`
+	Degraded   bool            `json:"degraded"` // Counts are stale or couldn't be fetched
+}
+
+// CategoryPageTop is the category's most downloaded listings, as search documents like any other search hit
+type CategoryPageTop struct {
+	Listings []map[string]any `json:"listings"`
+	Degraded bool             `json:"degraded"`
+	// Set while search is down and these are its last good top listings, also marked degraded
+	Stale bool `json:"stale,omitempty"`
+}
+
+// CategoryPageTrend is the category's most downloaded listings over the last TrendingWindow
+type CategoryPageTrend struct {
+	Listings []TrendingListing `json:"listings"`
+	Degraded bool              `json:"degraded"`
+}
+
+type TrendingListing struct {
+	ListingID       string  `json:"listing_id"`
+	Title           string  `json:"title"`
+	SellerUsername  string  `json:"seller_username"`
+	ThumbnailURL    *string `json:"thumbnail_url"`
+	PriceMinUnit    int64   `json:"price_min_unit"`
+	Currency        string  `json:"currency"`
+	RecentDownloads int64   `json:"recent_downloads"`
+	// Approximate and for display only, see ListingResponse.PriceConverted
+	PriceConverted    *int64 `json:"price_converted,omitempty"`
+	ConvertedCurrency string `json:"converted_currency,omitempty"`
+	ConvertedRateDate string `json:"converted_rate_date,omitempty"`
+}
+
+const (
+	// Same sanity range the listings service accepts for a recommended nozzle temperature
+	minNozzleTempC = 180
+	maxNozzleTempC = 450
+
+	maxMaterials          = 10
+	maxMaterialLength     = 30
+	maxTypicalDimensionMM = 1000
+)
+
+// Defaults is a category's printer settings template, the create form is prefilled from it
+type Defaults struct {
+	Category             string       `json:"category"`
+	RecommendedMaterials []string     `json:"recommended_materials"`
+	NozzleTempC          TempRange    `json:"nozzle_temp_c"`
+	TypicalDimensionsMM  DimensionsMM `json:"typical_dimensions_mm"`
+	UpdatedAt            time.Time    `json:"updated_at"`
+}
+
+type TempRange struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// DimensionsMM uses the axes of the listing create request so the form can copy it straight in
+type DimensionsMM struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	Z int `json:"z"`
+}
+
+// Longest is the largest side, what listings are compared on since sellers don't agree on which way is up
+func (d DimensionsMM) Longest() int {
+	return max(d.X, d.Y, d.Z)
+}
+
+type SetDefaultsRequest struct {
+	RecommendedMaterials []string     `json:"recommended_materials"`
+	NozzleTempC          TempRange    `json:"nozzle_temp_c"`
+	TypicalDimensionsMM  DimensionsMM `json:"typical_dimensions_mm"`
+}
+
+// Validate tidies the materials into their canonical names, dropping blanks and repeats. Materials that aren't on the
+// list are kept as they are, templates only drive warnings.
+func (req *SetDefaultsRequest) Validate() *errors.AppError {
+	normalized := materials.Normalize(req.RecommendedMaterials)
+	for _, material := range normalized {
+		if len(material) > maxMaterialLength {
+			return errors.New(errors.ErrInvalidInput, "Materials can be at most 30 characters", nil).WithReason(errors.ReasonCategoryDefaultsMaterials)
+		}
+	}
+	if len(normalized) == 0 || len(normalized) > maxMaterials {
+		return errors.New(errors.ErrInvalidInput, "Recommend between 1 and 10 materials", nil).WithReason(errors.ReasonCategoryDefaultsMaterials)
+	}
+	req.RecommendedMaterials = normalized
+
+	temp := req.NozzleTempC
+	if temp.Min < minNozzleTempC || temp.Max > maxNozzleTempC || temp.Min > temp.Max {
+		return errors.New(errors.ErrInvalidInput, "Nozzle temperature range must be within 180-450°C, with min no higher than max", nil).WithReason(errors.ReasonCategoryDefaultsTempRange)
+	}
+
+	dims := req.TypicalDimensionsMM
+	for _, side := range []int{dims.X, dims.Y, dims.Z} {
+		if side <= 0 || side > maxTypicalDimensionMM {
+			return errors.New(errors.ErrInvalidInput, "Typical dimensions must be between 1 and 1000 mm", nil).WithReason(errors.ReasonCategoryDefaultsDimensions)
+		}
+	}
+	return nil
+}
+
+// IsCanonical reports whether value is one of the navigation categories
+func IsCanonical(value string) bool {
+	_, ok := canonical(value)
+	return ok
+}
+
+func canonical(value string) (Category, bool) {
+	for _, c := range Canonical {
+		if c.Value == value {
+			return c, true
+		}
+	}
+	return Category{}, false
+}
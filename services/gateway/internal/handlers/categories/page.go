@@ -0,0 +1,155 @@
+package categories
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/errors"
+	"gateway/internal/search"
+	"gateway/internal/searchfilter"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// PageCacheTTL is how long a category page is served from Redis before its sections are fetched again
+	PageCacheTTL = 5 * time.Minute
+	// TrendingWindow is how far back downloads count towards trending
+	TrendingWindow = 7 * 24 * time.Hour
+
+	topListingsLimit = 12
+	trendingLimit    = 8
+)
+
+// GetPage composes a category landing page. The sections are fetched concurrently and independently, one that fails
+// is sent stale when it has a last good answer or empty otherwise, and marked degraded. A degraded page is only cached
+// for FallbackCacheTTL so the missing section comes back soon after its source does.
+func (s *svc) GetPage(ctx context.Context, slug string) (*CategoryPage, error) {
+	category, ok := canonical(slug)
+	if !ok {
+		return nil, errors.New(errors.ErrNotFound, fmt.Sprintf("There's no '%s' category", slug), nil).
+			WithReason(errors.ReasonCategoryNotFound).
+			WithParam("category", slug)
+	}
+
+	cached, found, err := s.pages.GetPage(ctx, category.Value)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to get category page from cache", "category", category.Value, "error", err)
+	} else if found {
+		return cached, nil
+	}
+
+	page := &CategoryPage{Category: category, GeneratedAt: time.Now().UTC()}
+
+	// Every section handles its own failure, so none of these return an error and one failing doesn't cancel the rest
+	var g errgroup.Group
+	g.Go(func() error {
+		page.Counts = s.pageCounts(ctx, category.Value)
+		return nil
+	})
+	g.Go(func() error {
+		page.TopListings = s.pageTopListings(ctx, category.Value)
+		return nil
+	})
+	g.Go(func() error {
+		page.Trending = s.pageTrending(ctx, category.Value)
+		return nil
+	})
+	g.Wait()
+
+	page.Degraded = page.Counts.Degraded || page.TopListings.Degraded || page.Trending.Degraded
+	ttl := PageCacheTTL
+	if page.Degraded {
+		ttl = FallbackCacheTTL
+	}
+	if err := s.pages.SetPage(ctx, *page, ttl); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to cache category page", "category", category.Value, "error", err)
+	}
+	return page, nil
+}
+
+// pageCounts reuses GetCounts and its fallbacks, stale counts are still shown but the section is marked degraded
+func (s *svc) pageCounts(ctx context.Context, category string) CategoryPageCounts {
+	counts, err := s.GetCounts(ctx)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Category page counts unavailable", "category", category, "error", err)
+		return CategoryPageCounts{Categories: []CategoryCount{}, Degraded: true}
+	}
+
+	section := CategoryPageCounts{Total: counts.Total, Categories: counts.Categories, Degraded: counts.Stale}
+	for _, c := range counts.Categories {
+		if c.Value == category {
+			section.Count = c.Count
+		}
+	}
+	return section
+}
+
+func (s *svc) pageTopListings(ctx context.Context, category string) CategoryPageTop {
+	filter := searchfilter.And(
+		searchfilter.Eq(searchfilter.FieldCategories, category),
+		searchfilter.NotEq(searchfilter.FieldIsNSFW, true),
+	)
+	documents, err := s.search.Documents(ctx, search.ListingsCollection, search.DocumentQuery{
+		FilterBy: filter.String(),
+		SortBy:   "downloads_count:desc",
+		Limit:    topListingsLimit,
+	})
+	if err != nil {
+		return s.topWithoutSearch(ctx, category, err)
+	}
+
+	top := CategoryPageTop{Listings: documents}
+	if err := s.pages.SetLastGoodTop(ctx, category, top, LastGoodTTL); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to keep last good top listings", "category", category, "error", err)
+	}
+	return top
+}
+
+// topWithoutSearch serves search's last good top listings for the category marked stale, or nothing when there are
+// none. Either way the section is degraded, so the page is only cached for FallbackCacheTTL.
+func (s *svc) topWithoutSearch(ctx context.Context, category string, searchErr error) CategoryPageTop {
+	lastGood, found, err := s.pages.GetLastGoodTop(ctx, category)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to get last good top listings", "category", category, "error", err)
+	} else if found {
+		s.logger.WarnContext(ctx, "Search unavailable, serving stale top listings", "category", category, "error", searchErr)
+		return CategoryPageTop{Listings: lastGood.Listings, Degraded: true, Stale: true}
+	}
+
+	s.logger.WarnContext(ctx, "Category page top listings unavailable", "category", category, "error", searchErr)
+	return CategoryPageTop{Listings: []map[string]any{}, Degraded: true}
+}
+
+func (s *svc) pageTrending(ctx context.Context, category string) CategoryPageTrend {
+	rows, err := s.repo.ListTrendingListingsInCategory(ctx, repo.ListTrendingListingsInCategoryParams{
+		Since:     pgtype.Timestamptz{Time: time.Now().Add(-TrendingWindow), Valid: true},
+		Category:  category,
+		PageLimit: trendingLimit,
+	})
+	if err != nil {
+		s.logger.WarnContext(ctx, "Category page trending listings unavailable", "category", category, "error", err)
+		return CategoryPageTrend{Listings: []TrendingListing{}, Degraded: true}
+	}
+
+	listings := make([]TrendingListing, 0, len(rows))
+	for _, row := range rows {
+		listing := TrendingListing{
+			ListingID:       row.ID.String(),
+			Title:           row.Title,
+			SellerUsername:  row.SellerUsername,
+			PriceMinUnit:    row.PriceMinUnit,
+			Currency:        row.Currency,
+			RecentDownloads: row.RecentDownloads,
+		}
+		if row.ThumbnailPath.Valid {
+			url := s.urls.Image(row.ThumbnailPath.String)
+			listing.ThumbnailURL = &url
+		}
+		listings = append(listings, listing)
+	}
+	return CategoryPageTrend{Listings: listings}
+}
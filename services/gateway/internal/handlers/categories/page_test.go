@@ -0,0 +1,215 @@
+package categories_test
+
+import (
+	"context"
+	"errors"
+	"gateway/internal/handlers/categories"
+	"gateway/internal/mocks/mocksearch"
+	"gateway/internal/publicurl"
+	"gateway/internal/search"
+	"gateway/internal/testutil"
+	"regexp"
+	"testing"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+	apperrors "gateway/internal/errors"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const trendingID = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+
+var trendingCols = []string{"id", "title", "seller_username", "thumbnail_path", "price_min_unit", "currency", "recent_downloads"}
+
+func newPageTest(t *testing.T) (categories.CategoriesService, *mocksearch.Client, pgxmock.PgxPoolIface, *fakeStore) {
+	mockPool := testutil.NewMockDB(t)
+	client := mocksearch.NewClient(t)
+	store := &fakeStore{}
+	urls := publicurl.Config{AssetsBaseURL: "https://assets.test"}
+	return categories.NewCategoriesService(repo.New(mockPool), client, store, store, store, urls, testutil.NewTestLogger()), client, mockPool, store
+}
+
+func expectCounts(client *mocksearch.Client) {
+	client.EXPECT().FacetCounts(mock.Anything, search.ListingsCollection, "categories").
+		Return(&search.FacetCounts{Total: 14, Counts: map[string]int64{"functional": 10, "spare-parts": 4}}, nil)
+}
+
+func expectTopListings(client *mocksearch.Client) {
+	client.EXPECT().Documents(mock.Anything, search.ListingsCollection, search.DocumentQuery{
+		FilterBy: "categories:=`functional` && is_nsfw:!=true",
+		SortBy:   "downloads_count:desc",
+		Limit:    12,
+	}).Return([]map[string]any{{"id": "top-1", "title": "Hinge"}}, nil)
+}
+
+func expectTrending(db pgxmock.PgxPoolIface) {
+	db.ExpectQuery(regexp.QuoteMeta(`-- name: ListTrendingListingsInCategory :many`)).
+		WithArgs(pgxmock.AnyArg(), "functional", int32(8)).
+		WillReturnRows(pgxmock.NewRows(trendingCols).
+			AddRow(trendingID, "Bracket", "maker", "listings/bracket.png", int64(0), "gbp", int64(31)))
+}
+
+func TestGetPage_AllSections(t *testing.T) {
+	// SCENARIO: Every source answers.
+	// EXPECT: All three sections are filled, nothing is degraded and the page is cached for the full TTL.
+
+	service, client, db, store := newPageTest(t)
+	expectCounts(client)
+	expectTopListings(client)
+	expectTrending(db)
+
+	page, err := service.GetPage(context.Background(), "functional")
+	require.NoError(t, err)
+
+	assert.Equal(t, categories.Canonical[0], page.Category)
+	assert.False(t, page.Degraded)
+	assert.Equal(t, int64(10), page.Counts.Count)
+	assert.Equal(t, int64(14), page.Counts.Total)
+	require.Len(t, page.TopListings.Listings, 1)
+	assert.Equal(t, "top-1", page.TopListings.Listings[0]["id"])
+	require.Len(t, page.Trending.Listings, 1)
+	trending := page.Trending.Listings[0]
+	assert.Equal(t, trendingID, trending.ListingID)
+	assert.Equal(t, int64(31), trending.RecentDownloads)
+	require.NotNil(t, trending.ThumbnailURL)
+	assert.Equal(t, "https://assets.test/listings/bracket.png", *trending.ThumbnailURL)
+
+	assert.Contains(t, store.pages, "functional")
+	assert.Equal(t, categories.PageCacheTTL, store.pageTTL)
+	assert.Equal(t, categories.CategoryPageTop{Listings: page.TopListings.Listings}, store.lastGoodTop["functional"])
+	assert.Equal(t, categories.LastGoodTTL, store.lastGoodTopTTL)
+	assert.NoError(t, db.ExpectationsWereMet())
+}
+
+func TestGetPage_StaleTopListings(t *testing.T) {
+	// SCENARIO: Search answered for the category before, now its breaker is open.
+	// EXPECT: The last good top listings are served marked stale, the section and page are degraded so the page is
+	// cached briefly, and the stale listings don't replace the last good ones.
+
+	service, client, db, store := newPageTest(t)
+	lastGood := categories.CategoryPageTop{Listings: []map[string]any{{"id": "top-1", "title": "Hinge"}}}
+	store.lastGoodTop = map[string]categories.CategoryPageTop{"functional": lastGood}
+	store.lastGoodTopTTL = categories.LastGoodTTL
+	expectCounts(client)
+	client.EXPECT().Documents(mock.Anything, mock.Anything, mock.Anything).Return(nil, search.ErrCircuitOpen)
+	expectTrending(db)
+
+	page, err := service.GetPage(context.Background(), "functional")
+	require.NoError(t, err)
+
+	assert.Equal(t, categories.CategoryPageTop{Listings: lastGood.Listings, Degraded: true, Stale: true}, page.TopListings)
+	assert.True(t, page.Degraded)
+	assert.Equal(t, categories.FallbackCacheTTL, store.pageTTL)
+	assert.Equal(t, lastGood, store.lastGoodTop["functional"])
+	assert.NoError(t, db.ExpectationsWereMet())
+}
+
+func TestGetPage_OneSectionFails(t *testing.T) {
+	// SCENARIO: One source fails while the others answer.
+	// EXPECT: Only its section is empty and degraded, the rest still render, and the page is cached briefly.
+
+	tests := []struct {
+		name   string
+		expect func(client *mocksearch.Client, db pgxmock.PgxPoolIface)
+		check  func(t *testing.T, page *categories.CategoryPage)
+	}{
+		{
+			name: "Search documents",
+			expect: func(client *mocksearch.Client, db pgxmock.PgxPoolIface) {
+				expectCounts(client)
+				client.EXPECT().Documents(mock.Anything, mock.Anything, mock.Anything).Return(nil, search.ErrCircuitOpen)
+				expectTrending(db)
+			},
+			check: func(t *testing.T, page *categories.CategoryPage) {
+				// There are no last good top listings to fall back to
+				assert.True(t, page.TopListings.Degraded)
+				assert.False(t, page.TopListings.Stale)
+				assert.NotNil(t, page.TopListings.Listings)
+				assert.Empty(t, page.TopListings.Listings)
+				assert.False(t, page.Counts.Degraded)
+				assert.Equal(t, int64(10), page.Counts.Count)
+				assert.False(t, page.Trending.Degraded)
+				assert.Len(t, page.Trending.Listings, 1)
+			},
+		},
+		{
+			name: "Trending query",
+			expect: func(client *mocksearch.Client, db pgxmock.PgxPoolIface) {
+				expectCounts(client)
+				expectTopListings(client)
+				db.ExpectQuery(regexp.QuoteMeta(`-- name: ListTrendingListingsInCategory :many`)).
+					WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+					WillReturnError(errors.New("pool exhausted"))
+			},
+			check: func(t *testing.T, page *categories.CategoryPage) {
+				assert.True(t, page.Trending.Degraded)
+				assert.NotNil(t, page.Trending.Listings)
+				assert.Empty(t, page.Trending.Listings)
+				assert.False(t, page.TopListings.Degraded)
+				assert.Len(t, page.TopListings.Listings, 1)
+				assert.False(t, page.Counts.Degraded)
+			},
+		},
+		{
+			name: "Counts",
+			expect: func(client *mocksearch.Client, db pgxmock.PgxPoolIface) {
+				// Search facets fail and so does the database fallback, there are no last good counts
+				client.EXPECT().FacetCounts(mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("connection refused"))
+				db.MatchExpectationsInOrder(false)
+				db.ExpectQuery(regexp.QuoteMeta(`-- name: CountActiveListings :one`)).WillReturnError(errors.New("pool exhausted"))
+				expectTopListings(client)
+				expectTrending(db)
+			},
+			check: func(t *testing.T, page *categories.CategoryPage) {
+				assert.True(t, page.Counts.Degraded)
+				assert.NotNil(t, page.Counts.Categories)
+				assert.False(t, page.TopListings.Degraded)
+				assert.False(t, page.Trending.Degraded)
+				assert.Len(t, page.Trending.Listings, 1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, client, db, store := newPageTest(t)
+			tt.expect(client, db)
+
+			page, err := service.GetPage(context.Background(), "functional")
+			require.NoError(t, err)
+
+			assert.True(t, page.Degraded)
+			tt.check(t, page)
+			assert.Equal(t, categories.FallbackCacheTTL, store.pageTTL)
+			assert.NoError(t, db.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetPage_Cached(t *testing.T) {
+	// The mocks have no expectations, any call to a source fails the test
+	service, _, db, store := newPageTest(t)
+	cached := categories.CategoryPage{Category: categories.Canonical[1], Counts: categories.CategoryPageCounts{Count: 7}}
+	store.pages = map[string]categories.CategoryPage{"artistic": cached}
+
+	page, err := service.GetPage(context.Background(), "artistic")
+
+	require.NoError(t, err)
+	assert.Equal(t, &cached, page)
+	assert.NoError(t, db.ExpectationsWereMet())
+}
+
+func TestGetPage_UnknownCategory(t *testing.T) {
+	service, _, _, store := newPageTest(t)
+
+	_, err := service.GetPage(context.Background(), "weapons")
+
+	var appErr *apperrors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, apperrors.ErrNotFound, appErr.Code)
+	assert.Equal(t, apperrors.ReasonCategoryNotFound, appErr.Reason)
+	assert.Empty(t, store.pages)
+}
@@ -0,0 +1,136 @@
+package categories
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/auth"
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/errors"
+	"gateway/internal/publicurl"
+	"gateway/internal/search"
+	"log/slog"
+	"time"
+)
+
+const (
+	// CountsCacheTTL is how stale the header counts may get, nobody notices "Toys (1.2k)" lagging a few minutes
+	CountsCacheTTL = 10 * time.Minute
+	// FallbackCacheTTL is shorter so we go back to search soon after it recovers
+	FallbackCacheTTL = time.Minute
+	// LastGoodTTL is how long search's last answer can stand in for it during an outage
+	LastGoodTTL = 7 * 24 * time.Hour
+)
+
+type CategoriesService interface {
+	GetCounts(ctx context.Context) (*CategoryCountsResponse, error)
+	// GetDefaults returns the printer settings template for a canonical category, cached in Redis
+	GetDefaults(ctx context.Context, category string) (*Defaults, error)
+	SetDefaults(ctx context.Context, userInfo auth.UserInfo, category string, req *SetDefaultsRequest) (*Defaults, error)
+	// GetPage returns a canonical category's landing page, see page.go
+	GetPage(ctx context.Context, category string) (*CategoryPage, error)
+}
+
+type svc struct {
+	repo     *repo.Queries
+	search   search.Client
+	store    CountsStore
+	defaults DefaultsStore
+	pages    PageStore
+	urls     publicurl.Config
+	logger   *slog.Logger
+}
+
+func NewCategoriesService(repo *repo.Queries, search search.Client, store CountsStore, defaults DefaultsStore, pages PageStore, urls publicurl.Config, logger *slog.Logger) CategoriesService {
+	return &svc{
+		repo:     repo,
+		search:   search,
+		store:    store,
+		defaults: defaults,
+		pages:    pages,
+		urls:     urls,
+		logger:   logger,
+	}
+}
+
+// GetCounts returns active listing counts per canonical category. Search is asked first with a facet-only
+// query. While it's unavailable its last good answer is served marked stale, Postgres is only counted when
+// there isn't one.
+func (s *svc) GetCounts(ctx context.Context) (*CategoryCountsResponse, error) {
+	cached, found, err := s.store.Get(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to get category counts from cache", "error", err)
+	} else if found {
+		return cached, nil
+	}
+
+	counts, ttl, err := s.countFromSearch(ctx)
+	if err == nil {
+		if err := s.store.SetLastGood(ctx, *counts, LastGoodTTL); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to keep last good category counts", "error", err)
+		}
+	} else {
+		counts, ttl, err = s.countWithoutSearch(ctx, err)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to count categories", "error", err)
+			return nil, errors.New(errors.ErrInternal, "Failed to fetch category counts", err)
+		}
+	}
+
+	if err := s.store.Set(ctx, *counts, ttl); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to cache category counts", "error", err)
+	}
+
+	return counts, nil
+}
+
+// countWithoutSearch serves search's last good counts marked stale, or counts in the database when there are none.
+// Either is only cached for FallbackCacheTTL so we go back to search soon after it recovers.
+func (s *svc) countWithoutSearch(ctx context.Context, searchErr error) (*CategoryCountsResponse, time.Duration, error) {
+	lastGood, found, err := s.store.GetLastGood(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to get last good category counts", "error", err)
+	} else if found {
+		s.logger.WarnContext(ctx, "Search unavailable, serving stale category counts", "error", searchErr)
+		lastGood.Stale = true
+		return lastGood, FallbackCacheTTL, nil
+	}
+
+	s.logger.WarnContext(ctx, "Search unavailable, counting categories in the database", "error", searchErr)
+	return s.countFromDatabase(ctx)
+}
+
+func (s *svc) countFromSearch(ctx context.Context) (*CategoryCountsResponse, time.Duration, error) {
+	facets, err := s.search.FacetCounts(ctx, search.ListingsCollection, "categories")
+	if err != nil {
+		return nil, 0, err
+	}
+	return toResponse(facets.Total, facets.Counts, SourceSearch), CountsCacheTTL, nil
+}
+
+func (s *svc) countFromDatabase(ctx context.Context) (*CategoryCountsResponse, time.Duration, error) {
+	total, err := s.repo.CountActiveListings(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count active listings: %w", err)
+	}
+
+	rows, err := s.repo.CountActiveListingsByCategory(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count listings by category: %w", err)
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Category] = row.ListingsCount
+	}
+	return toResponse(total, counts, SourceDatabase), FallbackCacheTTL, nil
+}
+
+// toResponse maps raw counts onto the canonical list. Categories that aren't canonical (typos, legacy
+// values) are left out of the menu but still part of the total.
+func toResponse(total int64, counts map[string]int64, source string) *CategoryCountsResponse {
+	categories := make([]CategoryCount, 0, len(Canonical))
+	for _, c := range Canonical {
+		categories = append(categories, CategoryCount{Value: c.Value, Label: c.Label, Count: counts[c.Value]})
+	}
+	return &CategoryCountsResponse{Total: total, Categories: categories, Source: source}
+}
@@ -0,0 +1,259 @@
+package categories_test
+
+import (
+	"context"
+	"errors"
+	"gateway/internal/handlers/categories"
+	"gateway/internal/mocks/mocksearch"
+	"gateway/internal/publicurl"
+	"gateway/internal/search"
+	"gateway/internal/testutil"
+	"regexp"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore remembers the last Set so tests can check what was cached and for how long
+type fakeStore struct {
+	cached *categories.CategoryCountsResponse
+	ttl    time.Duration
+	getErr error
+
+	lastGood    *categories.CategoryCountsResponse
+	lastGoodTTL time.Duration
+
+	defaults    map[string]categories.Defaults
+	defaultsTTL time.Duration
+
+	pages   map[string]categories.CategoryPage
+	pageTTL time.Duration
+
+	lastGoodTop    map[string]categories.CategoryPageTop
+	lastGoodTopTTL time.Duration
+}
+
+func (f *fakeStore) Get(ctx context.Context) (*categories.CategoryCountsResponse, bool, error) {
+	if f.getErr != nil {
+		return nil, false, f.getErr
+	}
+	return f.cached, f.cached != nil, nil
+}
+
+func (f *fakeStore) Set(ctx context.Context, counts categories.CategoryCountsResponse, ttl time.Duration) error {
+	f.cached, f.ttl = &counts, ttl
+	return nil
+}
+
+func (f *fakeStore) GetLastGood(ctx context.Context) (*categories.CategoryCountsResponse, bool, error) {
+	if f.lastGood == nil {
+		return nil, false, nil
+	}
+	// A copy, like a read from Redis would be
+	lastGood := *f.lastGood
+	return &lastGood, true, nil
+}
+
+func (f *fakeStore) SetLastGood(ctx context.Context, counts categories.CategoryCountsResponse, ttl time.Duration) error {
+	f.lastGood, f.lastGoodTTL = &counts, ttl
+	return nil
+}
+
+func (f *fakeStore) GetDefaults(ctx context.Context, category string) (*categories.Defaults, bool, error) {
+	defaults, found := f.defaults[category]
+	return &defaults, found, nil
+}
+
+func (f *fakeStore) SetDefaults(ctx context.Context, defaults categories.Defaults, ttl time.Duration) error {
+	if f.defaults == nil {
+		f.defaults = map[string]categories.Defaults{}
+	}
+	f.defaults[defaults.Category], f.defaultsTTL = defaults, ttl
+	return nil
+}
+
+func (f *fakeStore) GetPage(ctx context.Context, category string) (*categories.CategoryPage, bool, error) {
+	page, found := f.pages[category]
+	return &page, found, nil
+}
+
+func (f *fakeStore) SetPage(ctx context.Context, page categories.CategoryPage, ttl time.Duration) error {
+	if f.pages == nil {
+		f.pages = map[string]categories.CategoryPage{}
+	}
+	f.pages[page.Category.Value], f.pageTTL = page, ttl
+	return nil
+}
+
+func (f *fakeStore) GetLastGoodTop(ctx context.Context, category string) (*categories.CategoryPageTop, bool, error) {
+	top, found := f.lastGoodTop[category]
+	return &top, found, nil
+}
+
+func (f *fakeStore) SetLastGoodTop(ctx context.Context, category string, top categories.CategoryPageTop, ttl time.Duration) error {
+	if f.lastGoodTop == nil {
+		f.lastGoodTop = map[string]categories.CategoryPageTop{}
+	}
+	f.lastGoodTop[category], f.lastGoodTopTTL = top, ttl
+	return nil
+}
+
+func counts(functional, artistic, prototypes, spareParts int64) []categories.CategoryCount {
+	return []categories.CategoryCount{
+		{Value: categories.Canonical[0].Value, Label: categories.Canonical[0].Label, Count: functional},
+		{Value: categories.Canonical[1].Value, Label: categories.Canonical[1].Label, Count: artistic},
+		{Value: categories.Canonical[2].Value, Label: categories.Canonical[2].Label, Count: prototypes},
+		{Value: categories.Canonical[3].Value, Label: categories.Canonical[3].Label, Count: spareParts},
+	}
+}
+
+func TestGetCounts_FromSearch(t *testing.T) {
+	// SCENARIO: Search answers, including a category that isn't in the canonical list.
+	// EXPECT: Counts are mapped onto every canonical category, the unknown one is dropped,
+	// and the result is cached for the full TTL. The database is never touched.
+
+	mockPool := testutil.NewMockDB(t)
+	client := mocksearch.NewClient(t)
+	store := &fakeStore{}
+	service := categories.NewCategoriesService(repo.New(mockPool), client, store, store, store, publicurl.Config{}, testutil.NewTestLogger())
+
+	client.EXPECT().FacetCounts(mock.Anything, search.ListingsCollection, "categories").Return(&search.FacetCounts{
+		Total:  1250,
+		Counts: map[string]int64{"functional": 1200, "artistic": 45, "Toys": 5},
+	}, nil)
+
+	got, err := service.GetCounts(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, &categories.CategoryCountsResponse{
+		Total:      1250,
+		Categories: counts(1200, 45, 0, 0),
+		Source:     categories.SourceSearch,
+	}, got)
+	assert.Equal(t, categories.CountsCacheTTL, store.ttl)
+	assert.Equal(t, got, store.lastGood)
+	assert.Equal(t, categories.LastGoodTTL, store.lastGoodTTL)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestGetCounts_SearchDown_ServesStale(t *testing.T) {
+	// SCENARIO: Typesense is unreachable, but it answered before and the cached copy has since expired.
+	// EXPECT: Its last answer is served marked stale and cached briefly. The database is never touched.
+
+	mockPool := testutil.NewMockDB(t)
+	client := mocksearch.NewClient(t)
+	lastGood := &categories.CategoryCountsResponse{Total: 40, Categories: counts(30, 10, 0, 0), Source: categories.SourceSearch}
+	store := &fakeStore{lastGood: lastGood}
+	service := categories.NewCategoriesService(repo.New(mockPool), client, store, store, store, publicurl.Config{}, testutil.NewTestLogger())
+
+	client.EXPECT().FacetCounts(mock.Anything, mock.Anything, mock.Anything).Return(nil, search.ErrCircuitOpen)
+
+	got, err := service.GetCounts(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, &categories.CategoryCountsResponse{
+		Total:      40,
+		Categories: counts(30, 10, 0, 0),
+		Source:     categories.SourceSearch,
+		Stale:      true,
+	}, got)
+	assert.Equal(t, categories.FallbackCacheTTL, store.ttl)
+	assert.False(t, store.lastGood.Stale, "the last good copy itself is never marked stale")
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestGetCounts_SearchDown_FallsBackToDatabase(t *testing.T) {
+	// SCENARIO: Typesense is unreachable and has never answered.
+	// EXPECT: Counts come from Postgres and are cached briefly so search is retried soon.
+
+	mockPool := testutil.NewMockDB(t)
+	client := mocksearch.NewClient(t)
+	store := &fakeStore{}
+	service := categories.NewCategoriesService(repo.New(mockPool), client, store, store, store, publicurl.Config{}, testutil.NewTestLogger())
+
+	client.EXPECT().FacetCounts(mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("connection refused"))
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CountActiveListings :one`)).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(int64(12)))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CountActiveListingsByCategory :many`)).
+		WillReturnRows(pgxmock.NewRows([]string{"category", "listings_count"}).
+			AddRow("functional", int64(10)).
+			AddRow("spare-parts", int64(4)))
+
+	got, err := service.GetCounts(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, &categories.CategoryCountsResponse{
+		Total:      12,
+		Categories: counts(10, 0, 0, 4),
+		Source:     categories.SourceDatabase,
+	}, got)
+	assert.Equal(t, categories.FallbackCacheTTL, store.ttl)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestGetCounts_Cached(t *testing.T) {
+	// The search mock has no expectations, any call fails the test
+	cached := &categories.CategoryCountsResponse{Total: 3, Categories: counts(3, 0, 0, 0), Source: categories.SourceSearch}
+	store := &fakeStore{cached: cached}
+	service := categories.NewCategoriesService(nil, mocksearch.NewClient(t), store, store, store, publicurl.Config{}, testutil.NewTestLogger())
+
+	got, err := service.GetCounts(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, cached, got)
+}
+
+func TestGetCounts_EverythingDown(t *testing.T) {
+	mockPool := testutil.NewMockDB(t)
+	client := mocksearch.NewClient(t)
+	store := &fakeStore{getErr: errors.New("redis down")}
+	service := categories.NewCategoriesService(repo.New(mockPool), client, store, store, store, publicurl.Config{}, testutil.NewTestLogger())
+
+	client.EXPECT().FacetCounts(mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("connection refused"))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CountActiveListings :one`)).WillReturnError(errors.New("pool exhausted"))
+
+	_, err := service.GetCounts(context.Background())
+
+	assert.Error(t, err)
+	assert.Nil(t, store.cached, "a failure must not be cached")
+}
+
+func TestGetCounts_OutageAndRecovery(t *testing.T) {
+	// SCENARIO: Search goes down behind the circuit breaker while the cached counts keep expiring, then comes back.
+	// EXPECT: Stale counts are served throughout, search is only called until the breaker opens,
+	// and fresh counts are served once a probe succeeds.
+
+	client := mocksearch.NewClient(t)
+	breaker := search.NewBreaker(client, search.BreakerConfig{FailureThreshold: 2, OpenFor: 10 * time.Millisecond}, testutil.NewTestLogger())
+	lastGood := &categories.CategoryCountsResponse{Total: 40, Categories: counts(30, 10, 0, 0), Source: categories.SourceSearch}
+	store := &fakeStore{lastGood: lastGood}
+	service := categories.NewCategoriesService(nil, breaker, store, store, store, publicurl.Config{}, testutil.NewTestLogger())
+
+	client.EXPECT().FacetCounts(mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("connection refused")).Times(2)
+	for range 5 {
+		store.cached = nil // Expired
+		got, err := service.GetCounts(context.Background())
+		require.NoError(t, err)
+		assert.True(t, got.Stale)
+		assert.Equal(t, int64(40), got.Total)
+	}
+	assert.Equal(t, search.StateOpen, breaker.State())
+
+	time.Sleep(20 * time.Millisecond)
+	client.EXPECT().FacetCounts(mock.Anything, mock.Anything, mock.Anything).
+		Return(&search.FacetCounts{Total: 42, Counts: map[string]int64{"functional": 32, "artistic": 10}}, nil).Once()
+
+	store.cached = nil
+	got, err := service.GetCounts(context.Background())
+	require.NoError(t, err)
+	assert.False(t, got.Stale)
+	assert.Equal(t, int64(42), got.Total)
+	assert.Equal(t, search.StateClosed, breaker.State())
+}
@@ -0,0 +1,90 @@
+package categories
+
+import (
+	"context"
+	"gateway/internal/cache"
+	"time"
+)
+
+const (
+	countsKey         = "categories:counts"
+	lastGoodCountsKey = "categories:counts:last-good"
+)
+
+type CountsStore interface {
+	Get(ctx context.Context) (*CategoryCountsResponse, bool, error)
+	Set(ctx context.Context, counts CategoryCountsResponse, ttl time.Duration) error
+	// The last counts search answered with, outliving the cached copy so they can be served while search is down
+	GetLastGood(ctx context.Context) (*CategoryCountsResponse, bool, error)
+	SetLastGood(ctx context.Context, counts CategoryCountsResponse, ttl time.Duration) error
+}
+
+type Store struct {
+	cache *cache.RedisClient
+}
+
+func NewStore(c *cache.RedisClient) *Store {
+	return &Store{cache: c}
+}
+
+func (s *Store) Get(ctx context.Context) (*CategoryCountsResponse, bool, error) {
+	return cache.Get[CategoryCountsResponse](s.cache, ctx, countsKey)
+}
+
+func (s *Store) Set(ctx context.Context, counts CategoryCountsResponse, ttl time.Duration) error {
+	return cache.Set(s.cache, ctx, countsKey, counts, ttl)
+}
+
+func (s *Store) GetLastGood(ctx context.Context) (*CategoryCountsResponse, bool, error) {
+	return cache.Get[CategoryCountsResponse](s.cache, ctx, lastGoodCountsKey)
+}
+
+func (s *Store) SetLastGood(ctx context.Context, counts CategoryCountsResponse, ttl time.Duration) error {
+	return cache.Set(s.cache, ctx, lastGoodCountsKey, counts, ttl)
+}
+
+const defaultsKeyPrefix = "categories:defaults:"
+
+type DefaultsStore interface {
+	GetDefaults(ctx context.Context, category string) (*Defaults, bool, error)
+	SetDefaults(ctx context.Context, defaults Defaults, ttl time.Duration) error
+}
+
+func (s *Store) GetDefaults(ctx context.Context, category string) (*Defaults, bool, error) {
+	return cache.Get[Defaults](s.cache, ctx, defaultsKeyPrefix+category)
+}
+
+func (s *Store) SetDefaults(ctx context.Context, defaults Defaults, ttl time.Duration) error {
+	return cache.Set(s.cache, ctx, defaultsKeyPrefix+defaults.Category, defaults, ttl)
+}
+
+const (
+	pageKeyPrefix        = "categories:page:"
+	lastGoodTopKeyPrefix = "categories:top:last-good:"
+)
+
+// PageStore holds the composed landing page of each category, one entry per category
+type PageStore interface {
+	GetPage(ctx context.Context, category string) (*CategoryPage, bool, error)
+	SetPage(ctx context.Context, page CategoryPage, ttl time.Duration) error
+	// The top listings search last answered with for a category, outliving the cached page so they can be served
+	// while search is down
+	GetLastGoodTop(ctx context.Context, category string) (*CategoryPageTop, bool, error)
+	SetLastGoodTop(ctx context.Context, category string, top CategoryPageTop, ttl time.Duration) error
+}
+
+func (s *Store) GetPage(ctx context.Context, category string) (*CategoryPage, bool, error) {
+	return cache.Get[CategoryPage](s.cache, ctx, pageKeyPrefix+category)
+}
+
+func (s *Store) SetPage(ctx context.Context, page CategoryPage, ttl time.Duration) error {
+	return cache.Set(s.cache, ctx, pageKeyPrefix+page.Category.Value, page, ttl)
+}
+
+func (s *Store) GetLastGoodTop(ctx context.Context, category string) (*CategoryPageTop, bool, error) {
+	return cache.Get[CategoryPageTop](s.cache, ctx, lastGoodTopKeyPrefix+category)
+}
+
+func (s *Store) SetLastGoodTop(ctx context.Context, category string, top CategoryPageTop, ttl time.Duration) error {
+	return cache.Set(s.cache, ctx, lastGoodTopKeyPrefix+category, top, ttl)
+}
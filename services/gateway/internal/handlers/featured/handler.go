@@ -0,0 +1,95 @@
+package featured
+
+import (
+	"fmt"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type FeaturedHandler struct {
+	service FeaturedService
+}
+
+func NewFeaturedHandler(svc FeaturedService) *FeaturedHandler {
+	return &FeaturedHandler{
+		service: svc,
+	}
+}
+
+// GetActive serves the homepage, anonymously and from a cache shared by every caller
+func (h *FeaturedHandler) GetActive(w http.ResponseWriter, r *http.Request) {
+	active, err := h.service.GetActive(r.Context())
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(ActiveCacheTTL.Seconds())))
+	json.Write(w, http.StatusOK, active)
+}
+
+func (h *FeaturedHandler) List(w http.ResponseWriter, r *http.Request) {
+	features, err := h.service.List(r.Context())
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, features)
+}
+
+func (h *FeaturedHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+	req := CreateFeaturedListingRequest{}
+	if err := json.Read(r, &req); err != nil {
+		slog.WarnContext(ctx, "Invalid request body", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Input provided was not in the format expected. Please contact support if this error persists.", err))
+		return
+	}
+
+	feature, err := h.service.Create(ctx, userInfo, &req)
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusCreated, feature)
+}
+
+func (h *FeaturedHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	req := UpdateFeaturedListingRequest{}
+	if err := json.Read(r, &req); err != nil {
+		slog.WarnContext(ctx, "Invalid request body", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Input provided was not in the format expected. Please contact support if this error persists.", err))
+		return
+	}
+
+	feature, err := h.service.Update(ctx, chi.URLParam(r, "id"), &req)
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, feature)
+}
+
+func (h *FeaturedHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Delete(r.Context(), chi.URLParam(r, "id")); err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,90 @@
+package featured
+
+import (
+	"fmt"
+	"gateway/internal/errors"
+	"gateway/internal/handlers/listings"
+	"strconv"
+	"time"
+)
+
+const (
+	// MaxWeight is the highest weight a window can have, the order only matters relative to the other windows
+	MaxWeight = 1000
+	// MaxActive is how many listings GET /listings/featured returns, the highest weighted first
+	MaxActive = 24
+)
+
+// CreateFeaturedListingRequest features a listing between StartsAt and EndsAt. Windows of the same listing can
+// overlap, the listing is featured while any of them runs.
+type CreateFeaturedListingRequest struct {
+	ListingID string    `json:"listing_id"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Weight    int32     `json:"weight"` // 0 to MaxWeight, higher is shown first
+}
+
+// UpdateFeaturedListingRequest moves or reweighs a window, it stays with the listing it was created for
+type UpdateFeaturedListingRequest struct {
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Weight   int32     `json:"weight"`
+}
+
+type FeaturedListingResponse struct {
+	ID        string    `json:"id"`
+	ListingID string    `json:"listing_id"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Weight    int32     `json:"weight"`
+	Active    bool      `json:"active"` // Running now
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type FeaturedListingsResponse struct {
+	FeaturedListings []FeaturedListingResponse `json:"featured_listings"`
+}
+
+// ActiveFeaturedListings is GET /listings/featured, the listings featured now as GET /listings/{id} serves them
+type ActiveFeaturedListings struct {
+	Listings    []listings.ListingResponse `json:"listings"`
+	GeneratedAt time.Time                  `json:"generated_at"`
+}
+
+func invalidWindow(field, message string) *errors.AppError {
+	return errors.New(errors.ErrInvalidInput, message, nil).
+		WithReason(errors.ReasonFeaturedWindowInvalid).
+		WithParam("field", field)
+}
+
+// validateWindow checks a window that is being saved. One that has already ended would never be shown.
+func validateWindow(startsAt, endsAt time.Time, weight int32, now time.Time) *errors.AppError {
+	if startsAt.IsZero() {
+		return invalidWindow("starts_at", "starts_at is required")
+	}
+	if endsAt.IsZero() {
+		return invalidWindow("ends_at", "ends_at is required")
+	}
+	if !endsAt.After(startsAt) {
+		return invalidWindow("ends_at", "ends_at must be after starts_at")
+	}
+	if !endsAt.After(now) {
+		return invalidWindow("ends_at", "ends_at must be in the future")
+	}
+	if weight < 0 || weight > MaxWeight {
+		return errors.New(errors.ErrInvalidInput, fmt.Sprintf("weight must be between 0 and %d", MaxWeight), nil).
+			WithReason(errors.ReasonFeaturedWeightInvalid).
+			WithParam("max", strconv.Itoa(MaxWeight))
+	}
+	return nil
+}
+
+func (req *CreateFeaturedListingRequest) Validate(now time.Time) *errors.AppError {
+	return validateWindow(req.StartsAt, req.EndsAt, req.Weight, now)
+}
+
+func (req *UpdateFeaturedListingRequest) Validate(now time.Time) *errors.AppError {
+	return validateWindow(req.StartsAt, req.EndsAt, req.Weight, now)
+}
@@ -0,0 +1,266 @@
+package featured
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/auth"
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/errors"
+	"gateway/internal/events"
+	"gateway/internal/handlers/listings"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ActiveCacheTTL is how long GET /listings/featured is cached, and so how late a window can show up or disappear there
+const ActiveCacheTTL = time.Minute
+
+type FeaturedService interface {
+	Create(ctx context.Context, userInfo auth.UserInfo, req *CreateFeaturedListingRequest) (*FeaturedListingResponse, error)
+	List(ctx context.Context) (*FeaturedListingsResponse, error)
+	Update(ctx context.Context, id string, req *UpdateFeaturedListingRequest) (*FeaturedListingResponse, error)
+	Delete(ctx context.Context, id string) error
+	// GetActive is the listings featured now, the highest weighted first
+	GetActive(ctx context.Context) (*ActiveFeaturedListings, error)
+}
+
+// ListingsReader hydrates listings the way GET /listings/{id} serves them, see listings.ListingsService
+type ListingsReader interface {
+	GetListingsByIDs(ctx context.Context, listingIDs []string) ([]listings.ListingResponse, error)
+}
+
+// Reindexer has the listings worker bring a listing's search document up to date, is_featured included
+type Reindexer interface {
+	RaiseListingIndexEvent(evt events.ReIndexListingEvent) error
+}
+
+type svc struct {
+	repo      *repo.Queries
+	listings  ListingsReader
+	reindexer Reindexer
+	active    ActiveStore
+	logger    *slog.Logger
+	now       func() time.Time
+}
+
+func NewFeaturedService(repo *repo.Queries, listings ListingsReader, reindexer Reindexer, active ActiveStore, logger *slog.Logger) FeaturedService {
+	return &svc{
+		repo:      repo,
+		listings:  listings,
+		reindexer: reindexer,
+		active:    active,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+func (s *svc) Create(ctx context.Context, userInfo auth.UserInfo, req *CreateFeaturedListingRequest) (*FeaturedListingResponse, error) {
+	now := s.now()
+	if appErr := req.Validate(now); appErr != nil {
+		return nil, appErr
+	}
+
+	var userUUID, listingUUID pgtype.UUID
+	if err := userUUID.Scan(userInfo.ID); err != nil {
+		return nil, errors.New(errors.ErrInternal, "Invalid user ID", fmt.Errorf("invalid user uuid: %w", err))
+	}
+	if err := listingUUID.Scan(req.ListingID); err != nil {
+		return nil, errors.New(errors.ErrInvalidInput, "Invalid listing ID provided", err)
+	}
+
+	feature, err := s.repo.CreateFeaturedListing(ctx, repo.CreateFeaturedListingParams{
+		StartsAt:  timestamptz(req.StartsAt),
+		EndsAt:    timestamptz(req.EndsAt),
+		Weight:    req.Weight,
+		CreatedBy: userUUID,
+		ListingID: listingUUID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New(errors.ErrNotFound, "Listing not found", fmt.Errorf("listing %v not found", req.ListingID)).WithReason(errors.ReasonListingNotFound)
+		}
+		s.logger.ErrorContext(ctx, "Failed to create featured listing", "listing_id", req.ListingID, "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to create featured listing", err)
+	}
+
+	s.logger.InfoContext(ctx, "Featured listing created", "id", feature.ID.String(), "listing_id", req.ListingID, "starts_at", req.StartsAt, "ends_at", req.EndsAt, "user_id", userInfo.ID)
+	s.changed(ctx, feature.ListingID)
+	return toResponse(feature, now), nil
+}
+
+func (s *svc) List(ctx context.Context) (*FeaturedListingsResponse, error) {
+	features, err := s.repo.ListFeaturedListings(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to list featured listings", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to list featured listings", err)
+	}
+
+	now := s.now()
+	resp := &FeaturedListingsResponse{FeaturedListings: make([]FeaturedListingResponse, 0, len(features))}
+	for _, feature := range features {
+		resp.FeaturedListings = append(resp.FeaturedListings, *toResponse(feature, now))
+	}
+	return resp, nil
+}
+
+func (s *svc) Update(ctx context.Context, id string, req *UpdateFeaturedListingRequest) (*FeaturedListingResponse, error) {
+	now := s.now()
+	if appErr := req.Validate(now); appErr != nil {
+		return nil, appErr
+	}
+
+	var featureUUID pgtype.UUID
+	if err := featureUUID.Scan(id); err != nil {
+		return nil, notFound(id)
+	}
+
+	feature, err := s.repo.UpdateFeaturedListing(ctx, repo.UpdateFeaturedListingParams{
+		StartsAt: timestamptz(req.StartsAt),
+		EndsAt:   timestamptz(req.EndsAt),
+		Weight:   req.Weight,
+		ID:       featureUUID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, notFound(id)
+		}
+		s.logger.ErrorContext(ctx, "Failed to update featured listing", "id", id, "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to update featured listing", err)
+	}
+
+	s.logger.InfoContext(ctx, "Featured listing updated", "id", id, "starts_at", req.StartsAt, "ends_at", req.EndsAt, "weight", req.Weight)
+	s.changed(ctx, feature.ListingID)
+	return toResponse(feature, now), nil
+}
+
+// Delete soft deletes the window. Gone from the admin list and the homepage straight away, the listings worker's sweep
+// takes is_featured off the search document even if the reindex raised here is lost.
+func (s *svc) Delete(ctx context.Context, id string) error {
+	var featureUUID pgtype.UUID
+	if err := featureUUID.Scan(id); err != nil {
+		return notFound(id)
+	}
+
+	listingID, err := s.repo.DeleteFeaturedListing(ctx, featureUUID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return notFound(id)
+		}
+		s.logger.ErrorContext(ctx, "Failed to delete featured listing", "id", id, "error", err)
+		return errors.New(errors.ErrInternal, "Failed to delete featured listing", err)
+	}
+
+	s.logger.InfoContext(ctx, "Featured listing deleted", "id", id, "listing_id", listingID.String())
+	s.changed(ctx, listingID)
+	return nil
+}
+
+func (s *svc) GetActive(ctx context.Context) (*ActiveFeaturedListings, error) {
+	cached, found, err := s.active.GetActive(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to get featured listings from cache", "error", err)
+	} else if found {
+		return cached, nil
+	}
+
+	now := s.now()
+	windows, err := s.repo.ListActiveFeaturedListings(ctx, timestamptz(now))
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to list active featured listings", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch featured listings", err)
+	}
+
+	hydrated, err := s.listings.GetListingsByIDs(ctx, rank(windows))
+	if err != nil {
+		return nil, err
+	}
+
+	active := ActiveFeaturedListings{Listings: hydrated, GeneratedAt: now.UTC()}
+	if err := s.active.SetActive(ctx, active, ActiveCacheTTL); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to cache featured listings", "error", err)
+	}
+	return &active, nil
+}
+
+// rank orders the listings behind the running windows by weight, the earliest started first among equals. A listing
+// whose windows overlap is ranked by the heaviest of them and only shows up once.
+func rank(windows []repo.ListActiveFeaturedListingsRow) []string {
+	best := make(map[pgtype.UUID]repo.ListActiveFeaturedListingsRow, len(windows))
+	for _, window := range windows {
+		current, seen := best[window.ListingID]
+		if !seen || window.Weight > current.Weight ||
+			(window.Weight == current.Weight && window.StartsAt.Time.Before(current.StartsAt.Time)) {
+			best[window.ListingID] = window
+		}
+	}
+
+	ranked := make([]repo.ListActiveFeaturedListingsRow, 0, len(best))
+	for _, window := range best {
+		ranked = append(ranked, window)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Weight != ranked[j].Weight {
+			return ranked[i].Weight > ranked[j].Weight
+		}
+		if !ranked[i].StartsAt.Time.Equal(ranked[j].StartsAt.Time) {
+			return ranked[i].StartsAt.Time.Before(ranked[j].StartsAt.Time)
+		}
+		// Map order is random, the page mustn't shuffle between cache fills
+		return ranked[i].ListingID.String() < ranked[j].ListingID.String()
+	})
+	if len(ranked) > MaxActive {
+		ranked = ranked[:MaxActive]
+	}
+
+	ids := make([]string, len(ranked))
+	for i, window := range ranked {
+		ids[i] = window.ListingID.String()
+	}
+	return ids
+}
+
+// changed drops the cached featured listings and has the listing's is_featured brought up to date. A window that
+// starts or ends later is picked up by the worker's sweep, this covers the ones that changed now.
+func (s *svc) changed(ctx context.Context, listingID pgtype.UUID) {
+	if err := s.active.DelActive(ctx); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to bust featured listings cache", "error", err)
+	}
+
+	traceID := ""
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		traceID = spanContext.TraceID().String()
+	}
+	// Search documents are keyed by the dashless ID
+	id := fmt.Sprintf("%x", listingID.Bytes)
+	if err := s.reindexer.RaiseListingIndexEvent(events.ReIndexListingEvent{ListingID: id, TraceID: traceID}); err != nil {
+		// Logged only, the sweep brings the flag in line on its next run
+		s.logger.ErrorContext(ctx, "Failed to raise listing re-index event", "listing_id", id, "error", err)
+	}
+}
+
+func toResponse(feature repo.FeaturedListing, now time.Time) *FeaturedListingResponse {
+	return &FeaturedListingResponse{
+		ID:        feature.ID.String(),
+		ListingID: feature.ListingID.String(),
+		StartsAt:  feature.StartsAt.Time,
+		EndsAt:    feature.EndsAt.Time,
+		Weight:    feature.Weight,
+		Active:    !now.Before(feature.StartsAt.Time) && now.Before(feature.EndsAt.Time),
+		CreatedBy: feature.CreatedBy.String(),
+		CreatedAt: feature.CreatedAt.Time,
+		UpdatedAt: feature.UpdatedAt.Time,
+	}
+}
+
+func timestamptz(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
+
+func notFound(id string) *errors.AppError {
+	return errors.New(errors.ErrNotFound, "Featured listing not found", fmt.Errorf("featured listing %q not found", id)).WithReason(errors.ReasonFeaturedNotFound)
+}
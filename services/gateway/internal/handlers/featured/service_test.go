@@ -0,0 +1,337 @@
+package featured
+
+import (
+	"context"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/events"
+	"gateway/internal/handlers/listings"
+	"gateway/internal/testutil"
+	"regexp"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	adminID   = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	featureID = "f0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	listingA  = "11111111-1111-1111-1111-111111111111"
+	listingB  = "22222222-2222-2222-2222-222222222222"
+	listingC  = "33333333-3333-3333-3333-333333333333"
+
+	// listingADoc is listingA the way search documents are keyed
+	listingADoc = "11111111111111111111111111111111"
+)
+
+var (
+	adminInfo = auth.UserInfo{ID: adminID, Username: "marketing"}
+	now       = time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+)
+
+var featuredColumns = []string{"id", "listing_id", "starts_at", "ends_at", "weight", "created_by", "created_at", "updated_at", "applied", "deleted_at"}
+
+// fakeStore is an ActiveStore in memory
+type fakeStore struct {
+	active *ActiveFeaturedListings
+	sets   int
+}
+
+func (f *fakeStore) GetActive(context.Context) (*ActiveFeaturedListings, bool, error) {
+	return f.active, f.active != nil, nil
+}
+
+func (f *fakeStore) SetActive(_ context.Context, active ActiveFeaturedListings, _ time.Duration) error {
+	f.active = &active
+	f.sets++
+	return nil
+}
+
+func (f *fakeStore) DelActive(context.Context) error {
+	f.active = nil
+	return nil
+}
+
+// fakeListings hydrates every ID it is asked for, and records the order it was asked in
+type fakeListings struct {
+	asked [][]string
+}
+
+func (f *fakeListings) GetListingsByIDs(_ context.Context, listingIDs []string) ([]listings.ListingResponse, error) {
+	f.asked = append(f.asked, listingIDs)
+	resp := make([]listings.ListingResponse, len(listingIDs))
+	for i, id := range listingIDs {
+		resp[i] = listings.ListingResponse{ID: id, Title: "Listing " + id}
+	}
+	return resp, nil
+}
+
+type fakeReindexer struct {
+	raised []string
+}
+
+func (f *fakeReindexer) RaiseListingIndexEvent(evt events.ReIndexListingEvent) error {
+	f.raised = append(f.raised, evt.ListingID)
+	return nil
+}
+
+type fixture struct {
+	service   *svc
+	mockPool  pgxmock.PgxPoolIface
+	store     *fakeStore
+	listings  *fakeListings
+	reindexer *fakeReindexer
+}
+
+func newTestService(t *testing.T) fixture {
+	f := fixture{
+		mockPool:  testutil.NewMockDB(t),
+		store:     &fakeStore{},
+		listings:  &fakeListings{},
+		reindexer: &fakeReindexer{},
+	}
+	f.service = NewFeaturedService(repo.New(f.mockPool), f.listings, f.reindexer, f.store, testutil.NewTestLogger()).(*svc)
+	f.service.now = func() time.Time { return now }
+	return f
+}
+
+func uuid(t *testing.T, s string) pgtype.UUID {
+	t.Helper()
+	var id pgtype.UUID
+	require.NoError(t, id.Scan(s))
+	return id
+}
+
+func window(t *testing.T, listingID string, weight int32, startsAt time.Time) repo.ListActiveFeaturedListingsRow {
+	return repo.ListActiveFeaturedListingsRow{ListingID: uuid(t, listingID), Weight: weight, StartsAt: timestamptz(startsAt)}
+}
+
+func TestRank(t *testing.T) {
+	tests := map[string]struct {
+		windows []repo.ListActiveFeaturedListingsRow
+		want    []string
+	}{
+		"none": {
+			want: []string{},
+		},
+		"heaviest first": {
+			windows: []repo.ListActiveFeaturedListingsRow{
+				window(t, listingA, 1, now.Add(-time.Hour)),
+				window(t, listingB, 5, now.Add(-time.Hour)),
+			},
+			want: []string{listingB, listingA},
+		},
+		"earliest started first among equals": {
+			windows: []repo.ListActiveFeaturedListingsRow{
+				window(t, listingA, 3, now.Add(-time.Hour)),
+				window(t, listingB, 3, now.Add(-2*time.Hour)),
+			},
+			want: []string{listingB, listingA},
+		},
+		"overlapping windows show the listing once, by the heaviest": {
+			windows: []repo.ListActiveFeaturedListingsRow{
+				window(t, listingA, 1, now.Add(-3*time.Hour)),
+				window(t, listingB, 5, now.Add(-time.Hour)),
+				window(t, listingA, 9, now.Add(-time.Minute)),
+				window(t, listingC, 2, now.Add(-time.Hour)),
+			},
+			want: []string{listingA, listingB, listingC},
+		},
+		"overlapping windows of equal weight rank by the earliest": {
+			windows: []repo.ListActiveFeaturedListingsRow{
+				window(t, listingA, 4, now.Add(-time.Minute)),
+				window(t, listingB, 4, now.Add(-time.Hour)),
+				window(t, listingA, 4, now.Add(-2*time.Hour)),
+			},
+			want: []string{listingA, listingB},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, rank(tt.windows))
+		})
+	}
+}
+
+func TestRank_Capped(t *testing.T) {
+	windows := make([]repo.ListActiveFeaturedListingsRow, MaxActive+5)
+	for i := range windows {
+		var id pgtype.UUID
+		id.Valid = true
+		id.Bytes[15] = byte(i)
+		windows[i] = repo.ListActiveFeaturedListingsRow{ListingID: id, Weight: int32(i), StartsAt: timestamptz(now)}
+	}
+
+	ranked := rank(windows)
+
+	require.Len(t, ranked, MaxActive)
+	assert.Equal(t, windows[len(windows)-1].ListingID.String(), ranked[0])
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		req        CreateFeaturedListingRequest
+		wantReason errors.Reason
+	}{
+		"running now":           {req: CreateFeaturedListingRequest{StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour), Weight: 3}},
+		"upcoming":              {req: CreateFeaturedListingRequest{StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)}},
+		"no start":              {req: CreateFeaturedListingRequest{EndsAt: now.Add(time.Hour)}, wantReason: errors.ReasonFeaturedWindowInvalid},
+		"no end":                {req: CreateFeaturedListingRequest{StartsAt: now}, wantReason: errors.ReasonFeaturedWindowInvalid},
+		"ends before it starts": {req: CreateFeaturedListingRequest{StartsAt: now.Add(2 * time.Hour), EndsAt: now.Add(time.Hour)}, wantReason: errors.ReasonFeaturedWindowInvalid},
+		"already ended":         {req: CreateFeaturedListingRequest{StartsAt: now.Add(-2 * time.Hour), EndsAt: now}, wantReason: errors.ReasonFeaturedWindowInvalid},
+		"negative weight":       {req: CreateFeaturedListingRequest{StartsAt: now, EndsAt: now.Add(time.Hour), Weight: -1}, wantReason: errors.ReasonFeaturedWeightInvalid},
+		"weight over max":       {req: CreateFeaturedListingRequest{StartsAt: now, EndsAt: now.Add(time.Hour), Weight: MaxWeight + 1}, wantReason: errors.ReasonFeaturedWeightInvalid},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			appErr := tt.req.Validate(now)
+			if tt.wantReason == "" {
+				assert.Nil(t, appErr)
+				return
+			}
+			require.NotNil(t, appErr)
+			assert.Equal(t, tt.wantReason, appErr.Reason)
+		})
+	}
+}
+
+func TestGetActive(t *testing.T) {
+	// SCENARIO: The featured listings are read twice, with listing A featured by two overlapping windows.
+	// EXPECT: One query, A hydrated once ahead of the lighter B, and the second read served from the cache.
+
+	f := newTestService(t)
+	f.mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListActiveFeaturedListings`)).WithArgs(timestamptz(now)).
+		WillReturnRows(pgxmock.NewRows([]string{"listing_id", "weight", "starts_at"}).
+			AddRow(uuid(t, listingB), int32(5), timestamptz(now.Add(-time.Hour))).
+			AddRow(uuid(t, listingA), int32(1), timestamptz(now.Add(-2*time.Hour))).
+			AddRow(uuid(t, listingA), int32(8), timestamptz(now.Add(-time.Minute))))
+
+	first, err := f.service.GetActive(context.Background())
+	require.NoError(t, err)
+	second, err := f.service.GetActive(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, first.Listings, 2)
+	assert.Equal(t, listingA, first.Listings[0].ID)
+	assert.Equal(t, listingB, first.Listings[1].ID)
+	assert.Equal(t, now, first.GeneratedAt)
+	assert.Equal(t, first, second)
+	assert.Equal(t, [][]string{{listingA, listingB}}, f.listings.asked)
+	assert.Equal(t, 1, f.store.sets)
+	assert.NoError(t, f.mockPool.ExpectationsWereMet())
+}
+
+func TestGetActive_None(t *testing.T) {
+	f := newTestService(t)
+	f.mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListActiveFeaturedListings`)).WithArgs(timestamptz(now)).
+		WillReturnRows(pgxmock.NewRows([]string{"listing_id", "weight", "starts_at"}))
+
+	active, err := f.service.GetActive(context.Background())
+
+	require.NoError(t, err)
+	assert.Empty(t, active.Listings)
+	assert.NoError(t, f.mockPool.ExpectationsWereMet())
+}
+
+func TestCreate(t *testing.T) {
+	// SCENARIO: An admin features a listing while the featured listings are cached.
+	// EXPECT: The window is saved, the cache is dropped so it shows up, and the listing is reindexed for is_featured.
+
+	f := newTestService(t)
+	f.store.active = &ActiveFeaturedListings{}
+	startsAt, endsAt := now.Add(-time.Minute), now.Add(24*time.Hour)
+	f.mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CreateFeaturedListing`)).
+		WithArgs(timestamptz(startsAt), timestamptz(endsAt), int32(7), uuid(t, adminID), uuid(t, listingA)).
+		WillReturnRows(pgxmock.NewRows(featuredColumns).
+			AddRow(uuid(t, featureID), uuid(t, listingA), timestamptz(startsAt), timestamptz(endsAt), int32(7), uuid(t, adminID), timestamptz(now), timestamptz(now), false, nil))
+
+	feature, err := f.service.Create(context.Background(), adminInfo, &CreateFeaturedListingRequest{ListingID: listingA, StartsAt: startsAt, EndsAt: endsAt, Weight: 7})
+
+	require.NoError(t, err)
+	assert.Equal(t, featureID, feature.ID)
+	assert.Equal(t, listingA, feature.ListingID)
+	assert.True(t, feature.Active)
+	assert.Nil(t, f.store.active)
+	assert.Equal(t, []string{listingADoc}, f.reindexer.raised)
+	assert.NoError(t, f.mockPool.ExpectationsWereMet())
+}
+
+func TestCreate_ListingMissing(t *testing.T) {
+	f := newTestService(t)
+	f.mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CreateFeaturedListing`)).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err := f.service.Create(context.Background(), adminInfo, &CreateFeaturedListingRequest{ListingID: listingA, StartsAt: now, EndsAt: now.Add(time.Hour)})
+
+	require.Error(t, err)
+	assert.Equal(t, errors.ReasonListingNotFound, err.(*errors.AppError).Reason)
+	assert.Empty(t, f.reindexer.raised)
+	assert.NoError(t, f.mockPool.ExpectationsWereMet())
+}
+
+func TestUpdate_Upcoming(t *testing.T) {
+	// SCENARIO: A running window is moved into the future.
+	// EXPECT: It is no longer active, and the listing is reindexed so is_featured is cleared.
+
+	f := newTestService(t)
+	startsAt, endsAt := now.Add(time.Hour), now.Add(2*time.Hour)
+	f.mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: UpdateFeaturedListing`)).
+		WithArgs(timestamptz(startsAt), timestamptz(endsAt), int32(0), uuid(t, featureID)).
+		WillReturnRows(pgxmock.NewRows(featuredColumns).
+			AddRow(uuid(t, featureID), uuid(t, listingA), timestamptz(startsAt), timestamptz(endsAt), int32(0), uuid(t, adminID), timestamptz(now), timestamptz(now), true, nil))
+
+	feature, err := f.service.Update(context.Background(), featureID, &UpdateFeaturedListingRequest{StartsAt: startsAt, EndsAt: endsAt})
+
+	require.NoError(t, err)
+	assert.False(t, feature.Active)
+	assert.Equal(t, []string{listingADoc}, f.reindexer.raised)
+	assert.NoError(t, f.mockPool.ExpectationsWereMet())
+}
+
+func TestDelete(t *testing.T) {
+	f := newTestService(t)
+	f.store.active = &ActiveFeaturedListings{}
+	f.mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: DeleteFeaturedListing`)).WithArgs(uuid(t, featureID)).
+		WillReturnRows(pgxmock.NewRows([]string{"listing_id"}).AddRow(uuid(t, listingA)))
+
+	require.NoError(t, f.service.Delete(context.Background(), featureID))
+
+	assert.Nil(t, f.store.active)
+	assert.Equal(t, []string{listingADoc}, f.reindexer.raised)
+	assert.NoError(t, f.mockPool.ExpectationsWereMet())
+}
+
+func TestDelete_NotFound(t *testing.T) {
+	tests := map[string]func(pgxmock.PgxPoolIface){
+		"unknown id": func(mockPool pgxmock.PgxPoolIface) {
+			mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: DeleteFeaturedListing`)).WithArgs(pgxmock.AnyArg()).WillReturnError(pgx.ErrNoRows)
+		},
+		"not a uuid": nil,
+	}
+	for name, expect := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := newTestService(t)
+			id := featureID
+			if expect != nil {
+				expect(f.mockPool)
+			} else {
+				id = "nope"
+			}
+
+			err := f.service.Delete(context.Background(), id)
+
+			require.Error(t, err)
+			assert.Equal(t, errors.ReasonFeaturedNotFound, err.(*errors.AppError).Reason)
+			assert.Empty(t, f.reindexer.raised)
+			assert.NoError(t, f.mockPool.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package featured
+
+import (
+	"context"
+	"gateway/internal/cache"
+	"time"
+)
+
+const activeKeyPrefix = "featured:active:"
+
+type ActiveStore interface {
+	GetActive(ctx context.Context) (*ActiveFeaturedListings, bool, error)
+	SetActive(ctx context.Context, active ActiveFeaturedListings, ttl time.Duration) error
+	DelActive(ctx context.Context) error
+}
+
+type Store struct {
+	cache     *cache.RedisClient
+	activeKey string
+}
+
+// NewStore keys the featured listings by the listing cache's namespace, they hold listing responses and must be
+// dropped with them when those change shape
+func NewStore(c *cache.RedisClient, listingCache cache.Namespace) *Store {
+	return &Store{cache: c, activeKey: activeKeyPrefix + string(listingCache)}
+}
+
+func (s *Store) GetActive(ctx context.Context) (*ActiveFeaturedListings, bool, error) {
+	return cache.Get[ActiveFeaturedListings](s.cache, ctx, s.activeKey)
+}
+
+func (s *Store) SetActive(ctx context.Context, active ActiveFeaturedListings, ttl time.Duration) error {
+	return cache.Set(s.cache, ctx, s.activeKey, active, ttl)
+}
+
+func (s *Store) DelActive(ctx context.Context) error {
+	return cache.Del(s.cache, ctx, s.activeKey)
+}
@@ -0,0 +1,162 @@
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gateway/internal/errors"
+	"net/url"
+	"strings"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// MaxCallbackURLLength bounds callback_url, it is stored with the upload and sent nowhere else
+const MaxCallbackURLLength = 2048
+
+// callbackClaimGrace is how long past the upload window a callback waits for a listing to claim its upload before the
+// listings worker drops it
+const callbackClaimGrace = 24 * time.Hour
+
+// Callbacks is who may ask for upload callbacks and where
+type Callbacks struct {
+	Allowlist CallbackAllowlist
+	Signed    CallbackDomains
+}
+
+// CallbackAllowlist maps an API key to the domains its uploads may ask to be called back on, a domain allows its
+// subdomains too. Callers without a key, or with a key that isn't listed, can't ask for callbacks.
+type CallbackAllowlist map[string][]string
+
+// ParseCallbackAllowlist reads UPLOAD_CALLBACK_ALLOWLIST, a JSON object of API key to domains:
+//
+//	{"<api key>": ["hooks.slicer.example"]}
+//
+// An empty value turns callbacks off.
+func ParseCallbackAllowlist(raw string) (CallbackAllowlist, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var allowlist CallbackAllowlist
+	if err := json.Unmarshal([]byte(raw), &allowlist); err != nil {
+		return nil, err
+	}
+	for key, domains := range allowlist {
+		if key == "" {
+			return nil, fmt.Errorf("empty API key")
+		}
+		for i, domain := range domains {
+			domain = strings.ToLower(strings.TrimSpace(domain))
+			if domain == "" || strings.ContainsAny(domain, "/:*") {
+				return nil, fmt.Errorf("invalid domain %q, give a bare host such as hooks.example.com", domains[i])
+			}
+			domains[i] = domain
+		}
+	}
+	return allowlist, nil
+}
+
+// CallbackDomains are the domains the listings worker has a signing secret for, a domain covers its subdomains too
+type CallbackDomains []string
+
+// ParseCallbackSecrets reads the domains out of UPLOAD_CALLBACK_SECRETS, the listings worker's JSON object of domain to
+// signing secret. The gateway never signs, the secrets themselves are dropped.
+func ParseCallbackSecrets(raw string) (CallbackDomains, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var secrets map[string]string
+	if err := json.Unmarshal([]byte(raw), &secrets); err != nil {
+		return nil, err
+	}
+	var domains CallbackDomains
+	for domain, secret := range secrets {
+		normalized := strings.ToLower(strings.TrimSpace(domain))
+		if normalized == "" || strings.ContainsAny(normalized, "/:*") {
+			return nil, fmt.Errorf("invalid domain %q, give a bare host such as hooks.example.com", domain)
+		}
+		if secret == "" {
+			return nil, fmt.Errorf("empty secret for %s", normalized)
+		}
+		domains = append(domains, normalized)
+	}
+	return domains, nil
+}
+
+// covers tells whether host is one of domains or a subdomain of one
+func covers(domains []string, host string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// destination checks rawURL is an https URL on a domain allowed for apiKey that callbacks can be signed for, and
+// returns its host, which deliveries are rate limited and disabled by
+func (c Callbacks) destination(apiKey, rawURL string) (string, *errors.AppError) {
+	u, err := url.Parse(rawURL)
+	if err != nil || len(rawURL) > MaxCallbackURLLength || u.Scheme != "https" || u.Hostname() == "" || u.User != nil ||
+		(u.Port() != "" && u.Port() != "443") {
+		return "", errors.New(errors.ErrInvalidInput, "callback_url must be an https URL", err).WithReason(errors.ReasonFileCallbackURLInvalid)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if apiKey == "" || !covers(c.Allowlist[apiKey], host) {
+		return "", errors.New(errors.ErrForbidden, fmt.Sprintf("Callbacks to %s aren't allowed for this API key", host), nil).
+			WithReason(errors.ReasonFileCallbackNotAllowed).
+			WithParam("host", host)
+	}
+	// Without a secret the worker would never send it
+	if !covers(c.Signed, host) {
+		return "", errors.New(errors.ErrForbidden, fmt.Sprintf("Callbacks to %s can't be signed yet", host), nil).
+			WithReason(errors.ReasonFileCallbackUnsigned).
+			WithParam("host", host)
+	}
+	return host, nil
+}
+
+// checkCallback refuses a callback_url the caller may not use, or whose host has been disabled, before anything is
+// presigned. Returns the host.
+func (s *service) checkCallback(ctx context.Context, apiKey, callbackURL string) (string, error) {
+	destination, appErr := s.callbacks.destination(apiKey, callbackURL)
+	if appErr != nil {
+		return "", appErr
+	}
+
+	disabled, err := s.repo.IsCallbackDestinationDisabled(ctx, destination)
+	if err != nil {
+		return "", errors.New(errors.ErrInternal, "Failed to check callback_url", fmt.Errorf("failed to check callback destination %s: %w", destination, err))
+	}
+	if disabled {
+		return "", errors.New(errors.ErrForbidden, fmt.Sprintf("Callbacks to %s are turned off after failing too often", destination), nil).
+			WithReason(errors.ReasonFileCallbackDisabled).
+			WithParam("host", destination)
+	}
+	return destination, nil
+}
+
+// saveCallback stores the callback against the upload key, the listings worker calls it once a listing has claimed
+// the upload and its validation has finished
+func (s *service) saveCallback(ctx context.Context, userID, key, callbackURL, destination string, window time.Duration) error {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return errors.New(errors.ErrInternal, "Invalid user ID", fmt.Errorf("invalid user uuid: %w", err))
+	}
+
+	err := s.repo.CreateUploadCallback(ctx, repo.CreateUploadCallbackParams{
+		StorageKey:  key,
+		UserID:      userUUID,
+		CallbackUrl: callbackURL,
+		Destination: destination,
+		ExpiresAt:   pgtype.Timestamptz{Time: time.Now().Add(window + callbackClaimGrace), Valid: true},
+	})
+	if err != nil {
+		return errors.New(errors.ErrInternal, "Failed to save callback_url", fmt.Errorf("failed to save upload callback: %w", err))
+	}
+	return nil
+}
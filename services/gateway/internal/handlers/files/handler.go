@@ -4,6 +4,7 @@ import (
 	"gateway/internal/auth"
 	"gateway/internal/errors"
 	"gateway/internal/json"
+	"gateway/internal/scrapeguard"
 	"net/http"
 )
 
@@ -21,17 +22,17 @@ func (h *FileHandler) PresignUpload(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	userId, err := auth.GetUserID(ctx)
 	if err != nil {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
 		return
 	}
 
 	preSignedRequest := PresignRequest{}
 	if err := json.Read(r, &preSignedRequest); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Input provided was not in the format expected. Please contact support if this error persists.", err))
 		return
 	}
 
-	response, err := h.svc.PresignUpload(r.Context(), userId, preSignedRequest)
+	response, err := h.svc.PresignUpload(r.Context(), userId, r.Header.Get(scrapeguard.APIKeyHeader), preSignedRequest)
 	if err != nil {
 		errors.RespondError(w, r, err)
 		return
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"fmt"
+	repo "gateway/internal/database/postgresql/sqlc"
 	"gateway/internal/errors"
 	"gateway/internal/events"
 	"gateway/internal/storage"
@@ -19,6 +20,9 @@ type PresignRequest struct {
 	Filename    string `json:"filename"`
 	ContentType string `json:"content_type"`
 	DraftId     string `json:"draft_id"`
+	// Optional, POSTed a signed notification once the file has been validated. Needs an X-API-Key whose allowlist
+	// covers the domain, and the domain needs a signing secret, see Callbacks.
+	CallbackURL string `json:"callback_url,omitempty"`
 }
 
 type PresignResponse struct {
@@ -38,13 +42,15 @@ type FileConstraint struct {
 
 type service struct {
 	storage               storage.Provider
+	repo                  *repo.Queries
+	callbacks             Callbacks
 	constraints           map[string]FileConstraint
 	bus                   events.Bus
 	fileExtensionMappings map[string]string
 	validationWindowHours int
 }
 
-func NewFileService(storage storage.Provider, validationWindowHours int, constraints map[string]FileConstraint, bus events.Bus) *service {
+func NewFileService(storage storage.Provider, repo *repo.Queries, validationWindowHours int, constraints map[string]FileConstraint, callbacks Callbacks, bus events.Bus) *service {
 
 	fileExtensionMappings := map[string]string{
 		".stl": "model/stl",
@@ -54,6 +60,8 @@ func NewFileService(storage storage.Provider, validationWindowHours int, constra
 
 	return &service{
 		storage:               storage,
+		repo:                  repo,
+		callbacks:             callbacks,
 		constraints:           constraints,
 		bus:                   bus,
 		fileExtensionMappings: fileExtensionMappings,
@@ -63,11 +71,13 @@ func NewFileService(storage storage.Provider, validationWindowHours int, constra
 
 const bucket = storage.BucketIncoming
 
-func (s *service) PresignUpload(ctx context.Context, userID string, req PresignRequest) (*PresignResponse, error) { // 1. Constraints based on file type
+// PresignUpload hands out a form for uploading one file straight to storage. apiKey is the caller's X-API-Key, only
+// needed for a callback_url.
+func (s *service) PresignUpload(ctx context.Context, userID, apiKey string, req PresignRequest) (*PresignResponse, error) { // 1. Constraints based on file type
 	// Get the constraints for this file type
 	constraints, exists := s.constraints[req.Type]
 	if !exists {
-		return nil, errors.New(errors.ErrInvalidInput, "Unknown file_type. Must be 'model' or 'image'", nil)
+		return nil, errors.New(errors.ErrInvalidInput, "Unknown file_type. Must be 'model' or 'image'", nil).WithReason(errors.ReasonFileUploadTypeUnknown)
 	}
 
 	// 2. Validate Mime Type
@@ -81,19 +91,28 @@ func (s *service) PresignUpload(ctx context.Context, userID string, req PresignR
 	}
 
 	if !slices.Contains(constraints.AllowedMimeTypes, mimeType) {
-		return nil, errors.New(errors.ErrInvalidInput, fmt.Sprintf("File type '%s' is not allowed for %s uploads", mimeType, req.Type), nil)
+		return nil, errors.New(errors.ErrInvalidInput, fmt.Sprintf("File type '%s' is not allowed for %s uploads", mimeType, req.Type), nil).WithReason(errors.ReasonFileTypeNotAllowed).WithParam("mime_type", mimeType).WithParam("upload_type", req.Type)
 	}
 
 	// 3. Generate Secure Path (Key)
 	// Pattern: incoming/YYYY/MM/DD/userID/draftID/type/uuid.ext
 	ext := strings.ToLower(filepath.Ext(req.Filename))
 	if ext == "" {
-		return nil, errors.New(errors.ErrInvalidInput, "Filename must have an extension", nil)
+		return nil, errors.New(errors.ErrInvalidInput, "Filename must have an extension", nil).WithReason(errors.ReasonFileExtensionRequired)
 	}
 
 	key := generateStorageKey(userID, req.DraftId, req.Filename, constraints.Prefix, ext)
 
-	// 4. Ask Provider for the POST Policy
+	// 4. A callback is checked before anything is signed, a refused one fails the whole request
+	var destination string
+	if req.CallbackURL != "" {
+		var err error
+		if destination, err = s.checkCallback(ctx, apiKey, req.CallbackURL); err != nil {
+			return nil, err
+		}
+	}
+
+	// 5. Ask Provider for the POST Policy
 	config := storage.UploadConfig{
 		Bucket:      bucket,
 		Key:         key,
@@ -107,6 +126,12 @@ func (s *service) PresignUpload(ctx context.Context, userID string, req PresignR
 		return nil, errors.New(errors.ErrInternal, "Failed to generate upload signature", err)
 	}
 
+	if req.CallbackURL != "" {
+		if err := s.saveCallback(ctx, userID, key, req.CallbackURL, destination, config.Expiry); err != nil {
+			return nil, err
+		}
+	}
+
 	return &PresignResponse{
 		UploadURL: url,
 		FormData:  formData,
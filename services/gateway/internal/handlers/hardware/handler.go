@@ -0,0 +1,58 @@
+package hardware
+
+import (
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/json"
+	"log/slog"
+	"net/http"
+)
+
+type HardwareHandler struct {
+	service HardwareService
+}
+
+func NewHardwareHandler(svc HardwareService) *HardwareHandler {
+	return &HardwareHandler{
+		service: svc,
+	}
+}
+
+// List serves the vocabulary for the listing form's hardware autocomplete
+func (h *HardwareHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	options, err := h.service.List(ctx)
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, options)
+}
+
+// Add is mounted behind auth.RequireRole for moderators and admins
+func (h *HardwareHandler) Add(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	req := AddOptionRequest{}
+	if err := json.Read(r, &req); err != nil {
+		slog.WarnContext(ctx, "Invalid request body", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Input provided was not in the format expected. Please contact support if this error persists.", err))
+		return
+	}
+
+	option, err := h.service.Add(ctx, userInfo, &req)
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusCreated, option)
+}
@@ -0,0 +1,37 @@
+package hardware
+
+import (
+	"gateway/internal/errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	minNameLength = 2
+	maxNameLength = 50
+)
+
+// OptionsResponse is the vocabulary for the listing form's autocomplete, sorted ignoring case
+type OptionsResponse struct {
+	Options []string `json:"options"`
+}
+
+type AddOptionRequest struct {
+	Name string `json:"name"`
+}
+
+type OptionResponse struct {
+	Name      string    `json:"name"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate tidies the name into the spelling that will be stored on listings
+func (req *AddOptionRequest) Validate() *errors.AppError {
+	req.Name = strings.Join(strings.Fields(req.Name), " ")
+	if length := utf8.RuneCountInString(req.Name); length < minNameLength || length > maxNameLength {
+		return errors.New(errors.ErrInvalidInput, "Hardware name must be between 2 and 50 characters", nil).WithReason(errors.ReasonHardwareOptionLength)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package hardware
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/auth"
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/errors"
+	"log/slog"
+	"shared/fuzzy"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// VocabularyTTL is how long a pod keeps its copy of the list. An option added on another pod is refused here until then.
+const VocabularyTTL = time.Minute
+
+type HardwareService interface {
+	List(ctx context.Context) (*OptionsResponse, error)
+	Add(ctx context.Context, userInfo auth.UserInfo, req *AddOptionRequest) (*OptionResponse, error)
+
+	// Canonicalize swaps every entry for its canonical spelling and refuses ones that aren't in the vocabulary
+	Canonicalize(ctx context.Context, entries []string) ([]string, error)
+	// Normalize is Canonicalize for values already stored, anything unknown is kept as it is
+	Normalize(ctx context.Context, entries []string) []string
+}
+
+type svc struct {
+	repo   *repo.Queries
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu         sync.Mutex
+	vocabulary *vocabulary
+	loadedAt   time.Time
+}
+
+func NewHardwareService(repo *repo.Queries, logger *slog.Logger) HardwareService {
+	return &svc{
+		repo:   repo,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+func (s *svc) List(ctx context.Context) (*OptionsResponse, error) {
+	v, err := s.load(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to load hardware options", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch hardware options", err)
+	}
+	return &OptionsResponse{Options: v.names}, nil
+}
+
+func (s *svc) Add(ctx context.Context, userInfo auth.UserInfo, req *AddOptionRequest) (*OptionResponse, error) {
+	if appErr := req.Validate(); appErr != nil {
+		return nil, appErr
+	}
+
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userInfo.ID); err != nil {
+		return nil, errors.New(errors.ErrInternal, "Invalid user ID", fmt.Errorf("invalid user uuid: %w", err))
+	}
+
+	option, err := s.repo.CreateHardwareOption(ctx, repo.CreateHardwareOptionParams{Name: req.Name, CreatedBy: userUUID})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New(errors.ErrConflict, "That hardware is already in the list", nil).WithReason(errors.ReasonHardwareOptionExists)
+		}
+		s.logger.ErrorContext(ctx, "Failed to add hardware option", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to add hardware option", err)
+	}
+
+	// Reloaded on next use so this pod accepts it straight away
+	s.mu.Lock()
+	s.vocabulary = nil
+	s.mu.Unlock()
+
+	s.logger.InfoContext(ctx, "Hardware option added", "name", option.Name, "user_id", userInfo.ID, "username", userInfo.Username)
+	return &OptionResponse{Name: option.Name, CreatedBy: userInfo.ID, CreatedAt: option.CreatedAt.Time}, nil
+}
+
+func (s *svc) Canonicalize(ctx context.Context, entries []string) ([]string, error) {
+	if len(entries) == 0 {
+		return entries, nil
+	}
+	v, err := s.load(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to load hardware options", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to check the hardware list. Please try again later.", err)
+	}
+
+	canonical := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		name, ok := v.lookup(entry)
+		if !ok {
+			return nil, v.unknown(entry)
+		}
+		canonical = appendUnique(canonical, name)
+	}
+	return canonical, nil
+}
+
+func (s *svc) Normalize(ctx context.Context, entries []string) []string {
+	if len(entries) == 0 {
+		return entries
+	}
+	v, err := s.load(ctx)
+	if err != nil {
+		// Not worth failing an edit over, the values are normalized the next time round
+		s.logger.WarnContext(ctx, "Failed to load hardware options, leaving hardware as it is", "error", err)
+		return entries
+	}
+
+	normalized := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if name, ok := v.lookup(entry); ok {
+			entry = name
+		}
+		normalized = appendUnique(normalized, entry)
+	}
+	return normalized
+}
+
+// load returns the cached vocabulary, reading it again once it's older than VocabularyTTL
+func (s *svc) load(ctx context.Context) (*vocabulary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.vocabulary != nil && s.now().Sub(s.loadedAt) < VocabularyTTL {
+		return s.vocabulary, nil
+	}
+
+	names, err := s.repo.ListHardwareOptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hardware options: %w", err)
+	}
+	s.vocabulary = newVocabulary(names)
+	s.loadedAt = s.now()
+	return s.vocabulary, nil
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// vocabulary matches free text against the canonical names, ignoring case and repeated spaces
+type vocabulary struct {
+	names []string
+	keys  []string // key(names[i])
+	byKey map[string]string
+}
+
+func newVocabulary(names []string) *vocabulary {
+	v := &vocabulary{names: names, byKey: make(map[string]string, len(names))}
+	if v.names == nil {
+		v.names = []string{}
+	}
+	for _, name := range names {
+		k := key(name)
+		v.byKey[k] = name
+		v.keys = append(v.keys, k)
+	}
+	return v
+}
+
+func key(value string) string {
+	return strings.ToLower(strings.Join(strings.Fields(value), " "))
+}
+
+func (v *vocabulary) lookup(entry string) (string, bool) {
+	name, ok := v.byKey[key(entry)]
+	return name, ok
+}
+
+// suggest finds the closest canonical name, or "" when nothing is close enough to be what the seller meant
+func (v *vocabulary) suggest(entry string) string {
+	k := key(entry)
+	// A typo or plural in a short name, more slack for longer ones
+	best, bestDistance := "", max(2, len(k)/4)+1
+	for i, candidate := range v.keys {
+		if d := fuzzy.Levenshtein(k, candidate); d < bestDistance {
+			best, bestDistance = v.names[i], d
+		}
+	}
+	return best
+}
+
+func (v *vocabulary) unknown(entry string) *errors.AppError {
+	entry = strings.TrimSpace(entry)
+	if suggestion := v.suggest(entry); suggestion != "" {
+		return errors.New(errors.ErrInvalidInput, fmt.Sprintf("'%s' isn't in the hardware list, did you mean '%s'?", entry, suggestion), nil).
+			WithReason(errors.ReasonListingHardwareSuggestion).
+			WithParam("value", entry).
+			WithParam("suggestion", suggestion)
+	}
+	return errors.New(errors.ErrInvalidInput, fmt.Sprintf("'%s' isn't in the hardware list", entry), nil).
+		WithReason(errors.ReasonListingHardwareUnknown).
+		WithParam("value", entry)
+}
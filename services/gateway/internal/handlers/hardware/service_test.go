@@ -0,0 +1,174 @@
+package hardware
+
+import (
+	"context"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/testutil"
+	"regexp"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var seeded = []string{"608 bearing", "Heat-set insert M3", "M3 nut", "M3 screw", "M4 screw", "Neodymium magnet 6x3mm"}
+
+func newTestService(t *testing.T) (*svc, pgxmock.PgxPoolIface) {
+	mockPool := testutil.NewMockDB(t)
+	service := NewHardwareService(repo.New(mockPool), testutil.NewTestLogger()).(*svc)
+	return service, mockPool
+}
+
+func expectOptions(mockPool pgxmock.PgxPoolIface, names ...string) {
+	rows := pgxmock.NewRows([]string{"name"})
+	for _, name := range names {
+		rows.AddRow(name)
+	}
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListHardwareOptions :many`)).WillReturnRows(rows)
+}
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name           string
+		entries        []string
+		want           []string
+		wantReason     errors.Reason
+		wantSuggestion string
+	}{
+		{name: "Exact", entries: []string{"M3 screw"}, want: []string{"M3 screw"}},
+		{name: "Case and spacing", entries: []string{"  m3   SCREW "}, want: []string{"M3 screw"}},
+		{name: "Repeats collapse", entries: []string{"M3 screw", "m3 screw", "M3 nut"}, want: []string{"M3 screw", "M3 nut"}},
+		{name: "Blank entries dropped", entries: []string{"", "  ", "608 bearing"}, want: []string{"608 bearing"}},
+		{name: "Plural suggests", entries: []string{"m3 screws"}, wantReason: errors.ReasonListingHardwareSuggestion, wantSuggestion: "M3 screw"},
+		{name: "Typo suggests", entries: []string{"Neodymuim magnet 6x3mm"}, wantReason: errors.ReasonListingHardwareSuggestion, wantSuggestion: "Neodymium magnet 6x3mm"},
+		{name: "Nothing close", entries: []string{"M3x8 bolt"}, wantReason: errors.ReasonListingHardwareUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockPool := newTestService(t)
+			expectOptions(mockPool, seeded...)
+
+			got, err := service.Canonicalize(context.Background(), tt.entries)
+			if tt.wantReason != "" {
+				var appErr *errors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+				assert.Equal(t, tt.wantReason, appErr.Reason)
+				if tt.wantSuggestion != "" {
+					assert.Equal(t, tt.wantSuggestion, appErr.Params["suggestion"])
+					assert.Contains(t, appErr.Message, "did you mean '"+tt.wantSuggestion+"'?")
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCanonicalize_NothingToCheck(t *testing.T) {
+	// SCENARIO: A listing without hardware is saved.
+	// EXPECT: The vocabulary isn't read at all.
+
+	service, mockPool := newTestService(t)
+
+	got, err := service.Canonicalize(context.Background(), []string{})
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestNormalize_KeepsLegacyValues(t *testing.T) {
+	// SCENARIO: A listing saved before the vocabulary existed is edited without touching its hardware.
+	// EXPECT: Known values take the canonical spelling, ones that aren't in the list are left alone.
+
+	service, mockPool := newTestService(t)
+	expectOptions(mockPool, seeded...)
+
+	got := service.Normalize(context.Background(), []string{"m3 screw", "M3x8 bolt", "M3 SCREW"})
+	assert.Equal(t, []string{"M3 screw", "M3x8 bolt"}, got)
+}
+
+func TestNormalize_VocabularyUnavailable(t *testing.T) {
+	service, mockPool := newTestService(t)
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListHardwareOptions :many`)).WillReturnError(assert.AnError)
+
+	entries := []string{"m3 screw"}
+	assert.Equal(t, entries, service.Normalize(context.Background(), entries))
+}
+
+func TestLoad_CachedForTTL(t *testing.T) {
+	// SCENARIO: Several listings are saved within a minute, then another after the TTL.
+	// EXPECT: The list is read once, then again once it's stale.
+
+	service, mockPool := newTestService(t)
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	expectOptions(mockPool, "M3 screw")
+	_, err := service.Canonicalize(context.Background(), []string{"M3 screw"})
+	require.NoError(t, err)
+	_, err = service.List(context.Background())
+	require.NoError(t, err)
+
+	now = now.Add(VocabularyTTL)
+	expectOptions(mockPool, "M3 screw", "M4 screw")
+	options, err := service.List(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"M3 screw", "M4 screw"}, options.Options)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestAdd(t *testing.T) {
+	userInfo := auth.UserInfo{ID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", Username: "mod"}
+
+	t.Run("Added and accepted straight away", func(t *testing.T) {
+		service, mockPool := newTestService(t)
+		expectOptions(mockPool, "M3 screw")
+		_, err := service.List(context.Background())
+		require.NoError(t, err)
+
+		mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CreateHardwareOption :one`)).
+			WithArgs("M5 screw", pgxmock.AnyArg()).
+			WillReturnRows(pgxmock.NewRows([]string{"name", "created_by", "created_at"}).AddRow("M5 screw", userInfo.ID, time.Now()))
+
+		option, err := service.Add(context.Background(), userInfo, &AddOptionRequest{Name: "  M5   screw "})
+		require.NoError(t, err)
+		assert.Equal(t, "M5 screw", option.Name)
+
+		expectOptions(mockPool, "M3 screw", "M5 screw")
+		got, err := service.Canonicalize(context.Background(), []string{"m5 screw"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"M5 screw"}, got)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Already exists in another case", func(t *testing.T) {
+		service, mockPool := newTestService(t)
+		mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CreateHardwareOption :one`)).
+			WithArgs("m3 SCREW", pgxmock.AnyArg()).
+			WillReturnError(pgx.ErrNoRows)
+
+		_, err := service.Add(context.Background(), userInfo, &AddOptionRequest{Name: "m3 SCREW"})
+		var appErr *errors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, errors.ErrConflict, appErr.Code)
+		assert.Equal(t, errors.ReasonHardwareOptionExists, appErr.Reason)
+	})
+
+	t.Run("Name too short", func(t *testing.T) {
+		service, _ := newTestService(t)
+		_, err := service.Add(context.Background(), userInfo, &AddOptionRequest{Name: " x "})
+		var appErr *errors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, errors.ReasonHardwareOptionLength, appErr.Reason)
+	})
+}
@@ -0,0 +1,143 @@
+package listingevents
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Config bounds each stream
+type Config struct {
+	// Heartbeat is how often an idle stream sends a comment, so proxies don't close it and a dead client is noticed
+	Heartbeat time.Duration
+	// Lifetime is how long a stream stays open before it's closed with a close event. The listing form falls back
+	// to polling by then, validation that takes longer than this has gone wrong.
+	Lifetime time.Duration
+	// MaxStreams caps the streams open on one replica, 0 for no cap
+	MaxStreams int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Heartbeat:  15 * time.Second,
+		Lifetime:   10 * time.Minute,
+		MaxStreams: 1000,
+	}
+}
+
+// Owners checks the caller is the listing's seller, ListingsService does
+type Owners interface {
+	CheckListingOwner(ctx context.Context, userInfo auth.UserInfo, listingID string) error
+}
+
+type ListingEventsHandler struct {
+	owners Owners
+	hub    *Hub
+	config Config
+}
+
+func NewListingEventsHandler(owners Owners, hub *Hub, config Config) *ListingEventsHandler {
+	return &ListingEventsHandler{
+		owners: owners,
+		hub:    hub,
+		config: config,
+	}
+}
+
+// Stream serves GET /listings/{id}/events to the listing's seller. It's mounted outside the request timeout, Lifetime
+// bounds it instead.
+func (h *ListingEventsHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	listingID := chi.URLParam(r, "id")
+
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	if err := h.owners.CheckListingOwner(ctx, userInfo, listingID); err != nil {
+		slog.WarnContext(ctx, "Refused listing event stream", "listing_id", listingID, "error", err)
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	stream, unsubscribe, err := h.hub.Subscribe(listingID)
+	if err != nil {
+		slog.WarnContext(ctx, "Refused listing event stream", "listing_id", listingID, "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrOverloaded, "Live updates aren't available right now, refresh the listing instead.", err))
+		return
+	}
+	defer unsubscribe()
+
+	rc := http.NewResponseController(w)
+	// The server's write timeout is for ordinary responses. Not every writer supports deadlines, the test recorder
+	// doesn't, and those have no timeout to lift.
+	if err := rc.SetWriteDeadline(time.Now().Add(h.config.Lifetime + h.config.Heartbeat)); err != nil && !stderrors.Is(err, http.ErrNotSupported) {
+		slog.WarnContext(ctx, "Failed to extend the write deadline of a listing event stream", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Stops nginx buffering the stream until it's over
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	// Reconnect after a dropped connection sooner than browsers do by default
+	if _, err := fmt.Fprint(w, "retry: 3000\n\n"); err != nil {
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		slog.ErrorContext(ctx, "Listing event stream can't be flushed", "error", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(h.config.Heartbeat)
+	defer heartbeat.Stop()
+	lifetime := time.NewTimer(h.config.Lifetime)
+	defer lifetime.Stop()
+
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-stream:
+			if !ok {
+				// The hub closed for shutdown, the browser reconnects to another replica
+				return
+			}
+			err = writeEvent(w, event.Name, event.Data)
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		case <-lifetime.C:
+			writeEvent(w, EventClose, struct{}{})
+			rc.Flush()
+			return
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			slog.DebugContext(ctx, "Listing event stream closed by the client", "listing_id", listingID, "error", err)
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, name string, data any) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, body)
+	return err
+}
@@ -0,0 +1,262 @@
+package listingevents_test
+
+import (
+	"bytes"
+	"context"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/events"
+	"gateway/internal/handlers/listingevents"
+	"gateway/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	listingID = "550e8400-e29b-41d4-a716-446655440000"
+	sellerID  = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+)
+
+// flushRecorder hands over what the handler wrote each time it flushes, so a test can read the stream as it goes
+type flushRecorder struct {
+	header  http.Header
+	mu      sync.Mutex
+	code    int
+	pending bytes.Buffer
+	frames  chan string
+}
+
+func newFlushRecorder() *flushRecorder {
+	return &flushRecorder{header: http.Header{}, frames: make(chan string, 64)}
+}
+
+func (f *flushRecorder) Header() http.Header { return f.header }
+
+func (f *flushRecorder) WriteHeader(code int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.code = code
+}
+
+func (f *flushRecorder) Write(b []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.code == 0 {
+		f.code = http.StatusOK
+	}
+	return f.pending.Write(b)
+}
+
+func (f *flushRecorder) Flush() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.pending.Len() > 0 {
+		f.frames <- f.pending.String()
+		f.pending.Reset()
+	}
+}
+
+// next is the next flushed frame, failing the test if none comes
+func (f *flushRecorder) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case frame := <-f.frames:
+		return frame
+	case <-time.After(2 * time.Second):
+		t.Fatal("no frame flushed")
+		return ""
+	}
+}
+
+// fakeListener is the bus, tests push payloads through the handler it was given as NATS would
+type fakeListener struct {
+	handlers map[string]func(payload []byte)
+}
+
+func (f *fakeListener) Listen(subject string, handler func(payload []byte)) error {
+	f.handlers[subject] = handler
+	return nil
+}
+
+func (f *fakeListener) push(subject, payload string) {
+	f.handlers[subject]([]byte(payload))
+}
+
+type fakeOwners struct{ err error }
+
+func (f fakeOwners) CheckListingOwner(context.Context, auth.UserInfo, string) error { return f.err }
+
+// newListenedHub is a hub fed by a fake bus the way mount wires the real one
+func newListenedHub(t *testing.T, maxStreams int) (*listingevents.Hub, *fakeListener) {
+	t.Helper()
+	hub := listingevents.NewHub(maxStreams)
+	listener := &fakeListener{handlers: map[string]func(payload []byte){}}
+	config := &events.EventConfig{FileValidated: "listings.files.validated", ListingPublished: "listings.published"}
+	require.NoError(t, events.ListenToFileValidated(listener, config, testutil.NewTestLogger(), hub.FileValidated))
+	require.NoError(t, events.ListenToListingPublished(listener, config, testutil.NewTestLogger(), hub.ListingPublished))
+	return hub, listener
+}
+
+// stream starts GET /listings/{id}/events as the seller, the returned channel is closed once the handler returns
+func stream(ctx context.Context, handler *listingevents.ListingEventsHandler, w http.ResponseWriter) <-chan struct{} {
+	r := chi.NewRouter()
+	r.Get("/listings/{id}/events", handler.Stream)
+
+	req := httptest.NewRequest(http.MethodGet, "/listings/"+listingID+"/events", nil)
+	req = req.WithContext(auth.WithUserInfo(ctx, auth.UserInfo{ID: sellerID}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.ServeHTTP(w, req)
+	}()
+	return done
+}
+
+func waitDone(t *testing.T, done <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream didn't end")
+	}
+}
+
+func TestStream_ValidationProgress(t *testing.T) {
+	// SCENARIO: The seller watches their new listing while its model fails, its image passes and it goes live, with
+	// another listing's file finishing in between.
+	// EXPECT: Their own listing's events arrive in order as SSE, the other listing's don't, and the stream lets go
+	// of its slot once the seller leaves.
+
+	hub, bus := newListenedHub(t, 1)
+	handler := listingevents.NewListingEventsHandler(fakeOwners{}, hub, listingevents.DefaultConfig())
+	ctx, cancel := context.WithCancel(context.Background())
+	w := newFlushRecorder()
+	done := stream(ctx, handler, w)
+
+	assert.Equal(t, "retry: 3000\n\n", w.next(t))
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+
+	bus.push("listings.files.validated", `{"listing_id":"`+listingID+`","file_id":"model-1","status":"INVALID","error":"Model too complex"}`)
+	bus.push("listings.files.validated", `{"listing_id":"7c9e6679-7425-40de-944b-e07fc1f90ae7","file_id":"other","status":"VALID","error":null}`)
+	bus.push("listings.files.validated", `{"listing_id":"`+listingID+`","file_id":"image-1","status":"VALID","error":null}`)
+	bus.push("listings.published", `{"listing_id":"`+listingID+`","status":"ACTIVE"}`)
+
+	assert.Equal(t, "event: file\ndata: {\"file_id\":\"model-1\",\"status\":\"INVALID\",\"error\":\"Model too complex\"}\n\n", w.next(t))
+	assert.Equal(t, "event: file\ndata: {\"file_id\":\"image-1\",\"status\":\"VALID\"}\n\n", w.next(t))
+	assert.Equal(t, "event: listing\ndata: {\"status\":\"ACTIVE\"}\n\n", w.next(t))
+
+	cancel()
+	waitDone(t, done)
+
+	_, unsubscribe, err := hub.Subscribe(listingID)
+	require.NoError(t, err, "the stream should have given its slot back")
+	unsubscribe()
+}
+
+func TestStream_ListingIDForms(t *testing.T) {
+	// SCENARIO: The validation worker sends the listing's ID without dashes, and a worker from before the published
+	// event carried a status sends one without it.
+	// EXPECT: The file still reaches the seller watching the dashed ID, the status-less event is left to their next
+	// poll and the published one that follows goes out with the status it names.
+
+	hub, bus := newListenedHub(t, 1)
+	handler := listingevents.NewListingEventsHandler(fakeOwners{}, hub, listingevents.DefaultConfig())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := newFlushRecorder()
+	done := stream(ctx, handler, w)
+	w.next(t) // retry
+
+	dashless := strings.ReplaceAll(listingID, "-", "")
+	bus.push("listings.files.validated", `{"listing_id":"`+dashless+`","file_id":"model-1","status":"VALID","error":null}`)
+	bus.push("listings.published", `{"listing_id":"`+dashless+`"}`)
+	bus.push("listings.published", `{"listing_id":"`+dashless+`","status":"ACTIVE"}`)
+
+	assert.Equal(t, "event: file\ndata: {\"file_id\":\"model-1\",\"status\":\"VALID\"}\n\n", w.next(t))
+	assert.Equal(t, "event: listing\ndata: {\"status\":\"ACTIVE\"}\n\n", w.next(t))
+
+	cancel()
+	waitDone(t, done)
+}
+
+func TestHub_InvalidListingID(t *testing.T) {
+	hub := listingevents.NewHub(0)
+	_, _, err := hub.Subscribe("not-a-uuid")
+	assert.ErrorIs(t, err, listingevents.ErrInvalidListingID)
+}
+
+func TestStream_HeartbeatThenClose(t *testing.T) {
+	// SCENARIO: Nothing happens on the listing for the whole lifetime of the stream.
+	// EXPECT: Heartbeat comments keep it open, then a close event tells the browser not to reconnect.
+
+	hub, _ := newListenedHub(t, 0)
+	handler := listingevents.NewListingEventsHandler(fakeOwners{}, hub, listingevents.Config{Heartbeat: 20 * time.Millisecond, Lifetime: 150 * time.Millisecond})
+	w := newFlushRecorder()
+	done := stream(context.Background(), handler, w)
+
+	w.next(t) // retry
+	assert.Equal(t, ": heartbeat\n\n", w.next(t))
+	assert.Equal(t, ": heartbeat\n\n", w.next(t))
+	waitDone(t, done)
+
+	var last string
+	for len(w.frames) > 0 {
+		last = <-w.frames
+	}
+	assert.Equal(t, "event: close\ndata: {}\n\n", last)
+}
+
+func TestStream_HubClosedForShutdown(t *testing.T) {
+	hub, _ := newListenedHub(t, 0)
+	handler := listingevents.NewListingEventsHandler(fakeOwners{}, hub, listingevents.DefaultConfig())
+	w := newFlushRecorder()
+	done := stream(context.Background(), handler, w)
+	w.next(t)
+
+	hub.Close()
+
+	waitDone(t, done)
+	_, _, err := hub.Subscribe(listingID)
+	assert.ErrorIs(t, err, listingevents.ErrClosed)
+}
+
+func TestStream_Refused(t *testing.T) {
+	notOwner := errors.New(errors.ErrUnauthorized, "You do not own this listing", nil).WithReason(errors.ReasonListingNotOwner)
+
+	tests := []struct {
+		name       string
+		owners     fakeOwners
+		fill       bool // Take the hub's only slot first
+		wantStatus int
+	}{
+		{name: "Not the seller", owners: fakeOwners{err: notOwner}, wantStatus: http.StatusUnauthorized},
+		{name: "Replica full", fill: true, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub, _ := newListenedHub(t, 1)
+			if tt.fill {
+				_, unsubscribe, err := hub.Subscribe(listingID)
+				require.NoError(t, err)
+				defer unsubscribe()
+			}
+			handler := listingevents.NewListingEventsHandler(tt.owners, hub, listingevents.DefaultConfig())
+
+			w := httptest.NewRecorder()
+			waitDone(t, stream(context.Background(), handler, w))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.False(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream"))
+		})
+	}
+}
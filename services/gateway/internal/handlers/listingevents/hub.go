@@ -0,0 +1,173 @@
+// Package listingevents streams a listing's validation progress to its seller as server-sent events, so the listing
+// form can show each file land instead of polling GET /listings/{id}. Every replica hears the validation worker's
+// events and passes them to the streams it holds, nothing is stored, a seller who isn't connected just polls.
+package listingevents
+
+import (
+	stderrors "errors"
+	"gateway/internal/events"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// SSE event names
+const (
+	EventFile    = "file"    // One file finished validation, data is a FileStatusChange
+	EventListing = "listing" // The listing changed status, data is a ListingStatusChange
+	EventClose   = "close"   // The stream hit its lifetime, reconnecting isn't expected, data is {}
+)
+
+// streamBuffer is how many events a stream can fall behind by before newer ones are dropped for it
+const streamBuffer = 16
+
+// ErrTooManyStreams is returned by Subscribe once MaxStreams are open on this replica
+var ErrTooManyStreams = stderrors.New("too many open listing event streams")
+
+// ErrClosed is returned by Subscribe once the hub is shutting down
+var ErrClosed = stderrors.New("listing event hub is closed")
+
+// ErrInvalidListingID is returned by Subscribe for an ID that isn't a UUID
+var ErrInvalidListingID = stderrors.New("invalid listing ID")
+
+// Event is one message on a listing's stream
+type Event struct {
+	Name string // EventFile or EventListing
+	Data any    // Written as JSON
+}
+
+// FileStatusChange is the data of a file event
+type FileStatusChange struct {
+	FileID string  `json:"file_id"`
+	Status string  `json:"status"`          // VALID, INVALID or FAILED
+	Error  *string `json:"error,omitempty"` // Why the file isn't valid
+}
+
+// ListingStatusChange is the data of a listing event
+type ListingStatusChange struct {
+	Status string `json:"status"`
+}
+
+// Hub passes events on to the streams open for their listing on this replica
+type Hub struct {
+	mu         sync.Mutex
+	streams    map[streamKey]map[chan Event]struct{}
+	open       int
+	maxStreams int
+	closed     bool
+}
+
+// streamKey is the listing ID's bytes. The route takes the dashed form and the validation worker sends whichever it
+// was given, so streams aren't keyed by the string.
+type streamKey [16]byte
+
+func parseStreamKey(listingID string) (streamKey, bool) {
+	var id pgtype.UUID
+	if err := id.Scan(listingID); err != nil || !id.Valid {
+		return streamKey{}, false
+	}
+	return id.Bytes, true
+}
+
+// NewHub holds at most maxStreams streams at once, 0 for no limit
+func NewHub(maxStreams int) *Hub {
+	return &Hub{streams: map[streamKey]map[chan Event]struct{}{}, maxStreams: maxStreams}
+}
+
+// Subscribe opens a stream of listingID's events. The channel is closed when the hub is, call unsubscribe once done
+// with it.
+func (h *Hub) Subscribe(listingID string) (<-chan Event, func(), error) {
+	key, ok := parseStreamKey(listingID)
+	if !ok {
+		return nil, nil, ErrInvalidListingID
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, nil, ErrClosed
+	}
+	if h.maxStreams > 0 && h.open >= h.maxStreams {
+		return nil, nil, ErrTooManyStreams
+	}
+
+	stream := make(chan Event, streamBuffer)
+	if h.streams[key] == nil {
+		h.streams[key] = map[chan Event]struct{}{}
+	}
+	h.streams[key][stream] = struct{}{}
+	h.open++
+	openStreams.Inc()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if _, ok := h.streams[key][stream]; !ok {
+				return // Already closed by Close
+			}
+			delete(h.streams[key], stream)
+			if len(h.streams[key]) == 0 {
+				delete(h.streams, key)
+			}
+			h.open--
+			openStreams.Dec()
+		})
+	}
+	return stream, unsubscribe, nil
+}
+
+// Publish sends event to every stream of listingID, in either UUID form. It never blocks, a stream that has fallen
+// streamBuffer events behind misses it and its seller sees the change on their next poll. An ID that isn't a UUID
+// has no streams.
+func (h *Hub) Publish(listingID string, event Event) {
+	key, ok := parseStreamKey(listingID)
+	if !ok {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for stream := range h.streams[key] {
+		select {
+		case stream <- event:
+			eventsSent.WithLabelValues(event.Name).Inc()
+		default:
+			eventsDropped.Inc()
+		}
+	}
+}
+
+// FileValidated is the events.ListenToFileValidated handler
+func (h *Hub) FileValidated(evt events.FileValidatedEvent) {
+	h.Publish(evt.ListingID, Event{Name: EventFile, Data: FileStatusChange{FileID: evt.FileID, Status: evt.Status, Error: evt.Error}})
+}
+
+// ListingPublished is the events.ListenToListingPublished handler. Workers from before the status was sent leave it
+// out, their sellers see it on their next poll instead.
+func (h *Hub) ListingPublished(evt events.ListingPublishedEvent) {
+	if evt.Status == "" {
+		return
+	}
+	h.Publish(evt.ListingID, Event{Name: EventListing, Data: ListingStatusChange{Status: evt.Status}})
+}
+
+// Close ends every open stream and refuses new ones, so shutdown isn't held up by sellers watching their listings.
+// They reconnect to another replica.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for key, streams := range h.streams {
+		for stream := range streams {
+			close(stream)
+			openStreams.Dec()
+		}
+		delete(h.streams, key)
+	}
+	h.open = 0
+}
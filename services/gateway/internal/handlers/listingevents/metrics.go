@@ -0,0 +1,23 @@
+package listingevents
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	openStreams = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gateway_listing_event_streams",
+		Help: "Listing event streams currently open on this replica.",
+	})
+
+	eventsSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_listing_events_sent_total",
+		Help: "Events handed to listing event streams, by event name.",
+	}, []string{"event"})
+
+	eventsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "gateway_listing_events_dropped_total",
+		Help: "Events a listing event stream missed because it had fallen too far behind.",
+	})
+)
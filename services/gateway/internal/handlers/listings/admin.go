@@ -0,0 +1,285 @@
+package listings
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gateway/internal/database/postgresql"
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/errors"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// AdminListingsDefaultLimit is the page size when limit isn't given, AdminListingsMaxLimit the most it can be
+	AdminListingsDefaultLimit = 50
+	AdminListingsMaxLimit     = 200
+	// adminExportPageSize is how many rows the CSV export reads from Postgres at a time
+	adminExportPageSize = 500
+	// adminExportQueryTimeout is how long each of those pages may take, a broad filter scans far more than a request
+	adminExportQueryTimeout = 30 * time.Second
+)
+
+// AdminListingsFilter narrows GET /admin/listings, a nil field matches every listing
+type AdminListingsFilter struct {
+	Status      *string
+	SellerID    *string
+	Category    *string
+	IsNSFW      *bool
+	CreatedFrom *time.Time // Inclusive
+	CreatedTo   *time.Time // Exclusive
+	MinReports  *int64
+	Cursor      string // next_cursor of the previous page, empty for the first
+	Limit       int
+}
+
+// AdminListing is a row of the moderators' listings view
+type AdminListing struct {
+	ID             string     `json:"id"`
+	Title          string     `json:"title"`
+	SellerID       string     `json:"seller_id"`
+	SellerUsername string     `json:"seller_username"`
+	Status         string     `json:"status"`
+	Categories     []string   `json:"categories"`
+	IsNSFW         bool       `json:"is_nsfw"`
+	PriceMinUnit   int64      `json:"price_min_unit"`
+	Currency       string     `json:"currency"`
+	ReportCount    int64      `json:"report_count"`
+	FlaggedTerms   []string   `json:"flagged_terms"` // Banned terms the listing was held for, see listing_screening_flags
+	CreatedAt      time.Time  `json:"created_at"`
+	DeletedAt      *time.Time `json:"deleted_at"`
+}
+
+// AdminListingsPage is one page of the moderators' listings view
+type AdminListingsPage struct {
+	Listings   []AdminListing `json:"listings"`
+	NextCursor *string        `json:"next_cursor"` // Null on the last page
+}
+
+// ParseAdminListingsFilter reads the filter from the query string. Dates are RFC 3339 or YYYY-MM-DD, a created_to
+// date includes the whole day.
+func ParseAdminListingsFilter(query url.Values) (AdminListingsFilter, *errors.AppError) {
+	filter := AdminListingsFilter{Cursor: query.Get("cursor"), Limit: AdminListingsDefaultLimit}
+
+	if v := query.Get("status"); v != "" {
+		switch repo.ListingStatus(v) {
+		case repo.ListingStatusPENDINGVALIDATION, repo.ListingStatusPENDINGREVIEW, repo.ListingStatusACTIVE, repo.ListingStatusREJECTED, repo.ListingStatusHIDDEN,
+			repo.ListingStatusSUSPENDED:
+			filter.Status = &v
+		default:
+			return filter, invalidAdminFilter("status", v)
+		}
+	}
+	if v := query.Get("seller_id"); v != "" {
+		var id pgtype.UUID
+		if err := id.Scan(v); err != nil {
+			return filter, invalidAdminFilter("seller_id", v)
+		}
+		filter.SellerID = &v
+	}
+	if v := strings.TrimSpace(query.Get("category")); v != "" {
+		filter.Category = &v
+	}
+	if v := query.Get("nsfw"); v != "" {
+		nsfw, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, invalidAdminFilter("nsfw", v)
+		}
+		filter.IsNSFW = &nsfw
+	}
+	if v := query.Get("created_from"); v != "" {
+		from, ok := parseAdminDate(v, false)
+		if !ok {
+			return filter, invalidAdminFilter("created_from", v)
+		}
+		filter.CreatedFrom = &from
+	}
+	if v := query.Get("created_to"); v != "" {
+		to, ok := parseAdminDate(v, true)
+		if !ok {
+			return filter, invalidAdminFilter("created_to", v)
+		}
+		filter.CreatedTo = &to
+	}
+	if v := query.Get("min_reports"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			return filter, invalidAdminFilter("min_reports", v)
+		}
+		filter.MinReports = &n
+	}
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > AdminListingsMaxLimit {
+			return filter, invalidAdminFilter("limit", v)
+		}
+		filter.Limit = n
+	}
+
+	return filter, nil
+}
+
+// parseAdminDate accepts a timestamp or a bare date, which is midnight UTC, or the midnight after when endOfDay
+func parseAdminDate(v string, endOfDay bool) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t.UTC(), true
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, true
+}
+
+func invalidAdminFilter(field, value string) *errors.AppError {
+	return errors.New(errors.ErrInvalidInput, fmt.Sprintf("'%s' isn't a valid %s", value, field), nil).
+		WithReason(errors.ReasonAdminListingsFilterInvalid).
+		WithParam("field", field).
+		WithParam("value", value)
+}
+
+// ListAdminListings is a page of every listing for moderators, deleted and pending ones included, newest first
+func (s *svc) ListAdminListings(ctx context.Context, filter AdminListingsFilter) (*AdminListingsPage, error) {
+	params, err := adminListingsParams(filter)
+	if err != nil {
+		return nil, err
+	}
+	// One more than the page, so we know whether there's a next one without counting
+	params.PageLimit = int32(filter.Limit + 1)
+
+	rows, err := s.repo.ListAdminListings(ctx, params)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to list admin listings", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to list listings", err)
+	}
+
+	page := &AdminListingsPage{Listings: make([]AdminListing, 0, min(len(rows), filter.Limit))}
+	for i, row := range rows {
+		if i == filter.Limit {
+			next := encodeAdminCursor(rows[i-1])
+			page.NextCursor = &next
+			break
+		}
+		page.Listings = append(page.Listings, toAdminListing(row))
+	}
+	return page, nil
+}
+
+// ExportAdminListings hands every listing matching the filter to each, a page at a time. Cursor and limit are
+// ignored, the export is always the whole filter. Stops at the first error from each.
+func (s *svc) ExportAdminListings(ctx context.Context, filter AdminListingsFilter, each func(AdminListing) error) error {
+	filter.Cursor = ""
+	params, err := adminListingsParams(filter)
+	if err != nil {
+		return err
+	}
+	params.PageLimit = adminExportPageSize
+	ctx = postgresql.WithQueryTimeout(ctx, adminExportQueryTimeout)
+
+	for {
+		rows, err := s.repo.ListAdminListings(ctx, params)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to export admin listings", "error", err)
+			return errors.New(errors.ErrInternal, "Failed to export listings", err)
+		}
+		for _, row := range rows {
+			if err := each(toAdminListing(row)); err != nil {
+				return err
+			}
+		}
+		if len(rows) < adminExportPageSize {
+			return nil
+		}
+		last := rows[len(rows)-1]
+		params.CursorCreatedAt, params.CursorID = last.CreatedAt, last.ID
+	}
+}
+
+func adminListingsParams(filter AdminListingsFilter) (repo.ListAdminListingsParams, error) {
+	var params repo.ListAdminListingsParams
+	if filter.Status != nil {
+		params.Status = repo.NullListingStatus{ListingStatus: repo.ListingStatus(*filter.Status), Valid: true}
+	}
+	if filter.SellerID != nil {
+		if err := params.SellerID.Scan(*filter.SellerID); err != nil {
+			return params, invalidAdminFilter("seller_id", *filter.SellerID)
+		}
+	}
+	if filter.Category != nil {
+		params.Category = pgtype.Text{String: *filter.Category, Valid: true}
+	}
+	if filter.IsNSFW != nil {
+		params.IsNsfw = pgtype.Bool{Bool: *filter.IsNSFW, Valid: true}
+	}
+	if filter.CreatedFrom != nil {
+		params.CreatedFrom = pgtype.Timestamptz{Time: *filter.CreatedFrom, Valid: true}
+	}
+	if filter.CreatedTo != nil {
+		params.CreatedTo = pgtype.Timestamptz{Time: *filter.CreatedTo, Valid: true}
+	}
+	if filter.MinReports != nil {
+		params.MinReports = pgtype.Int8{Int64: *filter.MinReports, Valid: true}
+	}
+	if filter.Cursor != "" {
+		createdAt, id, ok := decodeCursor(filter.Cursor)
+		if !ok {
+			return params, errors.New(errors.ErrInvalidInput, "Invalid cursor", nil).WithReason(errors.ReasonAdminListingsCursorInvalid)
+		}
+		params.CursorCreatedAt, params.CursorID = createdAt, id
+	}
+	return params, nil
+}
+
+// encodeAdminCursor is the position after row, opaque to clients
+func encodeAdminCursor(row repo.ListAdminListingsRow) string {
+	return encodeCursor(row.CreatedAt, row.ID)
+}
+
+// encodeCursor is a keyset position on (timestamp, id), decodeCursor reads it back
+func encodeCursor(at pgtype.Timestamptz, id pgtype.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(at.Time.UTC().Format(time.RFC3339Nano) + "," + id.String()))
+}
+
+func decodeCursor(cursor string) (pgtype.Timestamptz, pgtype.UUID, bool) {
+	var id pgtype.UUID
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return pgtype.Timestamptz{}, id, false
+	}
+	ts, rawID, found := strings.Cut(string(raw), ",")
+	if !found {
+		return pgtype.Timestamptz{}, id, false
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil || id.Scan(rawID) != nil {
+		return pgtype.Timestamptz{}, id, false
+	}
+	return pgtype.Timestamptz{Time: createdAt, Valid: true}, id, true
+}
+
+func toAdminListing(row repo.ListAdminListingsRow) AdminListing {
+	return AdminListing{
+		ID:             row.ID.String(),
+		Title:          row.Title,
+		SellerID:       row.SellerID.String(),
+		SellerUsername: row.SellerUsername,
+		Status:         string(row.Status.ListingStatus),
+		Categories:     orEmpty(row.Categories),
+		IsNSFW:         row.IsNsfw,
+		PriceMinUnit:   row.PriceMinUnit,
+		Currency:       row.Currency,
+		ReportCount:    row.ReportCount,
+		FlaggedTerms:   orEmpty(row.FlaggedTerms),
+		CreatedAt:      utc(row.CreatedAt),
+		DeletedAt:      utcPtr(row.DeletedAt),
+	}
+}
@@ -0,0 +1,227 @@
+package listings
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/errors"
+	"gateway/internal/testutil"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var adminListingCols = []string{"id", "title", "seller_id", "seller_username", "status", "categories", "is_nsfw", "price_min_unit", "currency", "created_at", "deleted_at", "report_count", "flagged_terms"}
+
+// adminListingID is the UUID of the nth test listing
+func adminListingID(n int) string {
+	return fmt.Sprintf("00000000-0000-0000-0000-%012d", n)
+}
+
+func adminRows(created []time.Time) *pgxmock.Rows {
+	rows := pgxmock.NewRows(adminListingCols)
+	for i, at := range created {
+		rows.AddRow(adminListingID(i+1), "Listing", historyOwnerID, "tester", "ACTIVE", []string{"Art"}, false, int64(100), "gbp", at, nil, int64(0), []string{})
+	}
+	return rows
+}
+
+func uuidArg(t *testing.T, id string) pgtype.UUID {
+	var u pgtype.UUID
+	require.NoError(t, u.Scan(id))
+	return u
+}
+
+func TestParseAdminListingsFilter_EachFilter(t *testing.T) {
+	// SCENARIO: A moderator narrows the view one filter at a time.
+	// EXPECT: Only that filter reaches the query, every other one stays NULL and matches everything.
+
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		query string
+		want  func(p *repo.ListAdminListingsParams)
+	}{
+		"no filter": {query: "", want: func(p *repo.ListAdminListingsParams) {}},
+		"status": {query: "status=PENDING_REVIEW", want: func(p *repo.ListAdminListingsParams) {
+			p.Status = repo.NullListingStatus{ListingStatus: repo.ListingStatusPENDINGREVIEW, Valid: true}
+		}},
+		"seller": {query: "seller_id=" + historyOwnerID, want: func(p *repo.ListAdminListingsParams) {
+			p.SellerID = uuidArg(t, historyOwnerID)
+		}},
+		"category": {query: "category=Art", want: func(p *repo.ListAdminListingsParams) {
+			p.Category = pgtype.Text{String: "Art", Valid: true}
+		}},
+		"not nsfw": {query: "nsfw=false", want: func(p *repo.ListAdminListingsParams) {
+			p.IsNsfw = pgtype.Bool{Bool: false, Valid: true}
+		}},
+		"created from a date": {query: "created_from=2026-10-01", want: func(p *repo.ListAdminListingsParams) {
+			p.CreatedFrom = pgtype.Timestamptz{Time: day, Valid: true}
+		}},
+		"created to a date includes the day": {query: "created_to=2026-10-01", want: func(p *repo.ListAdminListingsParams) {
+			p.CreatedTo = pgtype.Timestamptz{Time: day.AddDate(0, 0, 1), Valid: true}
+		}},
+		"created to a timestamp": {query: "created_to=2026-10-01T02:00:00%2B02:00", want: func(p *repo.ListAdminListingsParams) {
+			p.CreatedTo = pgtype.Timestamptz{Time: day, Valid: true}
+		}},
+		"report count": {query: "min_reports=3", want: func(p *repo.ListAdminListingsParams) {
+			p.MinReports = pgtype.Int8{Int64: 3, Valid: true}
+		}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+			filter, appErr := ParseAdminListingsFilter(query)
+			require.Nil(t, appErr)
+
+			mockPool := testutil.NewMockDB(t)
+			service := &svc{repo: repo.New(mockPool), db: mockPool, logger: testutil.NewTestLogger()}
+
+			want := repo.ListAdminListingsParams{PageLimit: AdminListingsDefaultLimit + 1}
+			tt.want(&want)
+			mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListAdminListings :many`)).
+				WithArgs(want.Status, want.SellerID, want.Category, want.IsNsfw, want.CreatedFrom, want.CreatedTo, want.MinReports, want.CursorCreatedAt, want.CursorID, want.PageLimit).
+				WillReturnRows(adminRows(nil))
+
+			page, err := service.ListAdminListings(context.Background(), filter)
+
+			require.NoError(t, err)
+			assert.Empty(t, page.Listings)
+			assert.Nil(t, page.NextCursor)
+			assert.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestParseAdminListingsFilter_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"status":       "status=DELETED",
+		"seller_id":    "seller_id=tester",
+		"nsfw":         "nsfw=maybe",
+		"created_from": "created_from=yesterday",
+		"created_to":   "created_to=2026-13-01",
+		"min_reports":  "min_reports=-1",
+		"limit":        "limit=201",
+	}
+
+	for field, raw := range tests {
+		t.Run(field, func(t *testing.T) {
+			query, err := url.ParseQuery(raw)
+			require.NoError(t, err)
+
+			_, appErr := ParseAdminListingsFilter(query)
+
+			require.NotNil(t, appErr)
+			assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+			assert.Equal(t, errors.ReasonAdminListingsFilterInvalid, appErr.Reason)
+			assert.Equal(t, field, appErr.Params["field"])
+		})
+	}
+}
+
+func TestListAdminListings_CursorIsStableAcrossPages(t *testing.T) {
+	// SCENARIO: A moderator pages through listings two at a time, where the second and third were created in the
+	// same instant, and a new listing is created between the two requests.
+	// EXPECT: The second page picks up right after the last row of the first, using the id to break the tie, so
+	// nothing is skipped or repeated and the new listing doesn't shift the pages.
+
+	mockPool := testutil.NewMockDB(t)
+	service := &svc{repo: repo.New(mockPool), db: mockPool, logger: testutil.NewTestLogger()}
+
+	t0 := time.Date(2026, 10, 1, 12, 0, 0, 123456000, time.UTC)
+	created := []time.Time{t0, t0.Add(-time.Minute), t0.Add(-time.Minute), t0.Add(-time.Hour)}
+
+	// The query returns one more than the page so the service knows there's a next one
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListAdminListings :many`)).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+			pgtype.Timestamptz{}, pgtype.UUID{}, int32(3)).
+		WillReturnRows(adminRows(created[:3]))
+
+	first, err := service.ListAdminListings(context.Background(), AdminListingsFilter{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, first.Listings, 2)
+	assert.Equal(t, adminListingID(1), first.Listings[0].ID)
+	assert.Equal(t, adminListingID(2), first.Listings[1].ID)
+	require.NotNil(t, first.NextCursor)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListAdminListings :many`)).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+			pgtype.Timestamptz{Time: created[1], Valid: true}, uuidArg(t, adminListingID(2)), int32(3)).
+		WillReturnRows(adminRows(nil).
+			AddRow(adminListingID(3), "Listing", historyOwnerID, "tester", "ACTIVE", []string{"Art"}, false, int64(100), "gbp", created[2], nil, int64(0), []string{}).
+			AddRow(adminListingID(4), "Listing", historyOwnerID, "tester", "ACTIVE", []string{"Art"}, false, int64(100), "gbp", created[3], nil, int64(0), []string{}))
+
+	second, err := service.ListAdminListings(context.Background(), AdminListingsFilter{Limit: 2, Cursor: *first.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, second.Listings, 2)
+	assert.Equal(t, adminListingID(3), second.Listings[0].ID)
+	assert.Equal(t, adminListingID(4), second.Listings[1].ID)
+	assert.Nil(t, second.NextCursor, "last page")
+
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestListAdminListings_InvalidCursor(t *testing.T) {
+	mockPool := testutil.NewMockDB(t)
+	service := &svc{repo: repo.New(mockPool), db: mockPool, logger: testutil.NewTestLogger()}
+
+	for _, cursor := range []string{"not base64!", "bm8tY29tbWE", "MjAyNi0xMC0wMSxub3QtYS11dWlk"} {
+		_, err := service.ListAdminListings(context.Background(), AdminListingsFilter{Limit: 2, Cursor: cursor})
+
+		var appErr *errors.AppError
+		require.ErrorAs(t, err, &appErr, cursor)
+		assert.Equal(t, errors.ReasonAdminListingsCursorInvalid, appErr.Reason)
+	}
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestExportAdminListings_ReadsEveryPage(t *testing.T) {
+	// SCENARIO: A moderator exports a filter that matches more than one export page.
+	// EXPECT: Every row is handed over in order, the second read starts after the last row of the first, and the
+	// page size, not the filter's limit or cursor, decides how much is read.
+
+	mockPool := testutil.NewMockDB(t)
+	service := &svc{repo: repo.New(mockPool), db: mockPool, logger: testutil.NewTestLogger()}
+
+	t0 := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	created := make([]time.Time, adminExportPageSize)
+	for i := range created {
+		created[i] = t0.Add(-time.Duration(i) * time.Second)
+	}
+	last := created[len(created)-1]
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListAdminListings :many`)).
+		WithArgs(repo.NullListingStatus{ListingStatus: repo.ListingStatusHIDDEN, Valid: true}, pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+			pgtype.Timestamptz{}, pgtype.UUID{}, int32(adminExportPageSize)).
+		WillReturnRows(adminRows(created))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListAdminListings :many`)).
+		WithArgs(repo.NullListingStatus{ListingStatus: repo.ListingStatusHIDDEN, Valid: true}, pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+			pgtype.Timestamptz{Time: last, Valid: true}, uuidArg(t, adminListingID(adminExportPageSize)), int32(adminExportPageSize)).
+		WillReturnRows(adminRows(nil).
+			AddRow(adminListingID(adminExportPageSize+1), "Listing", historyOwnerID, "tester", "HIDDEN", nil, false, int64(100), "gbp", last.Add(-time.Second), last, int64(4), []string{"scam"}))
+
+	hidden := "HIDDEN"
+	var got []AdminListing
+	err := service.ExportAdminListings(context.Background(), AdminListingsFilter{Status: &hidden, Limit: 2, Cursor: "ignored"}, func(l AdminListing) error {
+		got = append(got, l)
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Len(t, got, adminExportPageSize+1)
+	assert.Equal(t, adminListingID(1), got[0].ID)
+	tail := got[adminExportPageSize]
+	assert.Equal(t, []string{}, tail.Categories)
+	assert.Equal(t, int64(4), tail.ReportCount)
+	assert.Equal(t, []string{"scam"}, tail.FlaggedTerms)
+	require.NotNil(t, tail.DeletedAt)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -0,0 +1,119 @@
+package listings
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/cache"
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/detach"
+	"gateway/internal/errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// GetListingsByIDs is GetListingByID for pages that show several listings at once. The cached responses are read in
+// one round trip and the rest in one query, whose responses are then cached the same way GetListingByID caches them.
+// Listings that don't exist or were deleted are left out, the others keep the order of listingIDs. One GetListingByID
+// has cached as not found is left out without asking the database.
+func (s *svc) GetListingsByIDs(ctx context.Context, listingIDs []string) ([]ListingResponse, error) {
+	ids := make([]pgtype.UUID, len(listingIDs))
+	keys := make([]string, len(listingIDs))
+	for i, listingID := range listingIDs {
+		if err := ids[i].Scan(listingID); err != nil {
+			return nil, errors.New(errors.ErrInvalidInput, "Invalid listing ID provided", err)
+		}
+		keys[i] = s.listingCache.Key(listingID)
+	}
+
+	cached, err := cache.MGet[cachedListing](s.cache, ctx, keys...)
+	if err != nil {
+		// Everything is a miss, the database can still answer
+		s.logger.ErrorContext(ctx, "Failed to get listings from cache", "listings", len(listingIDs), "error", err)
+		cached = make([]*cachedListing, len(listingIDs))
+	}
+
+	var missing []pgtype.UUID
+	for i, listing := range cached {
+		switch {
+		case listing == nil:
+			missing = append(missing, ids[i])
+		case !listing.NotFound:
+			s.applyParent(ctx, &listing.ListingResponse)
+		}
+	}
+
+	loaded, err := s.loadListings(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	listings := make([]ListingResponse, 0, len(listingIDs))
+	for i, entry := range cached {
+		listing := loaded[ids[i]]
+		if entry != nil && !entry.NotFound {
+			listing = &entry.ListingResponse
+		}
+		if listing != nil {
+			listings = append(listings, *listing)
+		}
+	}
+	return listings, nil
+}
+
+// loadListings is loadListing for a batch, keyed by ID. Responses are cached under the dashed ID, the form a seller's
+// profile changes invalidate.
+func (s *svc) loadListings(ctx context.Context, ids []pgtype.UUID) (map[pgtype.UUID]*ListingResponse, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	rows, err := s.repo.GetListingsByIDsWithFiles(ctx, ids)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to fetch listings from database", "listings", len(ids), "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch listings", fmt.Errorf("failed to fetch %d listings: %w", len(ids), err))
+	}
+
+	variants, err := s.listingVariants(ctx, ids)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to fetch listing variants", "listings", len(ids), "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch listings", fmt.Errorf("failed to fetch variants of %d listings: %w", len(ids), err))
+	}
+
+	type entry struct {
+		key      string
+		response ListingResponse
+		ttl      time.Duration
+	}
+	var toCache []entry
+
+	loaded := make(map[pgtype.UUID]*ListingResponse, len(rows))
+	for _, row := range rows {
+		// Same columns as GetListingByIDWithFiles, so the single listing path's conversion applies as it is
+		response := s.toListingResponse(ctx, repo.GetListingByIDWithFilesRow(row))
+		if v, ok := variants[row.ID]; ok {
+			response.Variants = v
+		}
+		ttl := s.applyVacation(ctx, row.SellerID, &response)
+		s.applyParent(ctx, &response)
+		loaded[row.ID] = &response
+
+		if ttl > 0 {
+			toCache = append(toCache, entry{key: s.listingCache.Key(row.ID.String()), response: response, ttl: ttl})
+		}
+	}
+
+	// Rendered for a client that went away part way through, the links may not have been signed
+	if len(toCache) > 0 && ctx.Err() == nil {
+		s.background.Add(1)
+		go func() {
+			defer s.background.Done()
+			cacheCtx, cancel := detach.WithTimeout(ctx, cacheWriteTimeout)
+			defer cancel()
+			for _, e := range toCache {
+				cache.Set(s.cache, cacheCtx, e.key, e.response, e.ttl)
+			}
+		}()
+	}
+	return loaded, nil
+}
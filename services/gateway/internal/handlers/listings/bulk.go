@@ -0,0 +1,277 @@
+package listings
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/events"
+	"gateway/internal/outbox"
+	"strconv"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BulkAction is what POST /listings/bulk does to every listing it names
+type BulkAction string
+
+const (
+	BulkActionDelete    BulkAction = "delete"
+	BulkActionUnpublish BulkAction = "unpublish"
+)
+
+// MaxBulkListings caps one bulk request, every listing in it stays locked until the transaction commits
+const MaxBulkListings = 100
+
+// unpublishReason is shown to the seller next to a listing they hid themselves
+const unpublishReason = "Unpublished by the seller"
+
+type BulkListingsRequest struct {
+	Action     BulkAction `json:"action"`
+	ListingIDs []string   `json:"listing_ids"`
+}
+
+// BulkListingsResponse splits the request into the listings the action was applied to and the ones left alone.
+// Repeated IDs are only reported once.
+type BulkListingsResponse struct {
+	Action   BulkAction           `json:"action"`
+	Affected []string             `json:"affected"`
+	Failed   []BulkListingFailure `json:"failed"`
+}
+
+type BulkListingFailure struct {
+	ListingID string        `json:"listing_id"`
+	Reason    errors.Reason `json:"reason"` // LISTING_NOT_FOUND, LISTING_NOT_OWNER or LISTING_NOT_UNPUBLISHABLE
+}
+
+// unpublishableStatuses can be hidden by the seller. Rejected listings and ones waiting for review belong to moderation.
+var unpublishableStatuses = map[repo.ListingStatus]bool{
+	repo.ListingStatusACTIVE:            true,
+	repo.ListingStatusPENDINGVALIDATION: true,
+	repo.ListingStatusHIDDEN:            true,
+}
+
+// Validate checks the action and size and returns the listing IDs parsed, without repeats, in request order
+func (req *BulkListingsRequest) Validate() ([]pgtype.UUID, *errors.AppError) {
+	if req.Action != BulkActionDelete && req.Action != BulkActionUnpublish {
+		return nil, errors.New(errors.ErrInvalidInput, "Action must be 'delete' or 'unpublish'", nil).WithReason(errors.ReasonListingBulkActionUnknown)
+	}
+	if len(req.ListingIDs) == 0 || len(req.ListingIDs) > MaxBulkListings {
+		return nil, errors.New(errors.ErrInvalidInput, fmt.Sprintf("Select between 1 and %d listings", MaxBulkListings), nil).
+			WithReason(errors.ReasonListingBulkSize).
+			WithParam("max", strconv.Itoa(MaxBulkListings))
+	}
+
+	ids := make([]pgtype.UUID, 0, len(req.ListingIDs))
+	seen := make(map[pgtype.UUID]bool, len(req.ListingIDs))
+	for _, listingID := range req.ListingIDs {
+		var id pgtype.UUID
+		if err := id.Scan(listingID); err != nil {
+			return nil, errors.New(errors.ErrInvalidInput, "Invalid listing ID provided", fmt.Errorf("invalid listing id %q: %w", listingID, err))
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// BulkUpdateListings deletes or unpublishes many of the caller's listings at once. Listings that can't be changed
+// are reported in Failed, the rest are changed together in one transaction.
+func (s *svc) BulkUpdateListings(ctx context.Context, userInfo auth.UserInfo, req *BulkListingsRequest) (*BulkListingsResponse, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userInfo.ID); err != nil {
+		return nil, errors.New(errors.ErrInvalidInput, "Invalid user ID provided", err)
+	}
+
+	ids, appErr := req.Validate()
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	traceID := ""
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		traceID = spanContext.TraceID().String()
+	}
+
+	response, err := s.applyBulkAction(ctx, userInfo, userUUID, req.Action, ids, traceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The audit row is written with the change, see applyBulkAction
+	s.logger.InfoContext(ctx, "Bulk listing action applied",
+		"action", req.Action,
+		"user_id", userInfo.ID,
+		"affected", response.Affected,
+		"failed", len(response.Failed),
+	)
+
+	deleted := make([]pgtype.UUID, 0, len(response.Affected))
+	for _, listingID := range response.Affected {
+		var id pgtype.UUID
+		if id.Scan(listingID) != nil {
+			continue
+		}
+		s.forgetListing(ctx, id)
+		if req.Action == BulkActionDelete {
+			deleted = append(deleted, id)
+		}
+	}
+	if len(deleted) > 0 {
+		s.forgetLiveness(ctx, deleted)
+	}
+
+	return response, nil
+}
+
+// applyBulkAction checks ownership for every listing with one locking query and applies the action to the ones that
+// pass. Their index events and the audit row are written in the same transaction, so none of them can be lost.
+func (s *svc) applyBulkAction(ctx context.Context, userInfo auth.UserInfo, userUUID pgtype.UUID, action BulkAction, ids []pgtype.UUID, traceID string) (*BulkListingsResponse, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, errors.New(errors.ErrInternal, "Failed to start transaction. Please try again later.", fmt.Errorf("failed to begin transaction: %w", err))
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.repo.WithTx(tx)
+
+	rows, err := qtx.GetListingsForBulkUpdate(ctx, ids)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to lock listings for bulk update", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to update listings", fmt.Errorf("failed to lock listings: %w", err))
+	}
+	found := make(map[pgtype.UUID]repo.GetListingsForBulkUpdateRow, len(rows))
+	for _, row := range rows {
+		found[row.ID] = row
+	}
+
+	response := &BulkListingsResponse{Action: action, Affected: []string{}, Failed: []BulkListingFailure{}}
+	var apply []repo.GetListingsForBulkUpdateRow
+	for _, id := range ids {
+		row, ok := found[id]
+		var reason errors.Reason
+		switch {
+		case !ok:
+			reason = errors.ReasonListingNotFound
+		case row.SellerID != userUUID:
+			reason = errors.ReasonListingNotOwner
+		case action == BulkActionUnpublish && !unpublishableStatuses[row.Status.ListingStatus]:
+			reason = errors.ReasonListingNotUnpublishable
+		}
+		if reason != "" {
+			response.Failed = append(response.Failed, BulkListingFailure{ListingID: id.String(), Reason: reason})
+			continue
+		}
+		apply = append(apply, row)
+		response.Affected = append(response.Affected, id.String())
+	}
+
+	if len(apply) == 0 {
+		return response, nil
+	}
+
+	applyIDs := make([]pgtype.UUID, len(apply))
+	for i, row := range apply {
+		applyIDs[i] = row.ID
+	}
+
+	switch action {
+	case BulkActionDelete:
+		err = qtx.SoftDeleteListings(ctx, repo.SoftDeleteListingsParams{Ids: applyIDs, SellerID: userUUID})
+	case BulkActionUnpublish:
+		err = qtx.HideListings(ctx, repo.HideListingsParams{Ids: applyIDs, SellerID: userUUID})
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to apply bulk action", "action", action, "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to update listings", fmt.Errorf("failed to %s listings: %w", action, err))
+	}
+
+	if action == BulkActionUnpublish {
+		for _, row := range apply {
+			if row.Status.ListingStatus == repo.ListingStatusHIDDEN {
+				continue
+			}
+			from := row.Status.ListingStatus
+			if err := recordStatusChange(ctx, qtx, statusChange{
+				ListingID: row.ID,
+				Actor:     repo.ListingStatusActorUSER,
+				ActorID:   userInfo.ID,
+				From:      &from,
+				To:        repo.ListingStatusHIDDEN,
+				Reason:    unpublishReason,
+			}); err != nil {
+				return nil, errors.New(errors.ErrInternal, "Failed to update listings", err)
+			}
+		}
+	}
+
+	messages, err := s.bulkMessages(ctx, qtx, action, applyIDs, traceID)
+	if err != nil {
+		return nil, errors.New(errors.ErrInternal, "Failed to update listings", err)
+	}
+	if err := outbox.Write(ctx, qtx, messages...); err != nil {
+		return nil, errors.New(errors.ErrInternal, "Failed to update listings", fmt.Errorf("failed to queue bulk %s events: %w", action, err))
+	}
+
+	if err := qtx.InsertListingBulkAction(ctx, repo.InsertListingBulkActionParams{
+		Action:     string(action),
+		UserID:     userUUID,
+		Username:   userInfo.Username,
+		ListingIds: applyIDs,
+		Failed:     int32(len(response.Failed)),
+		TraceID:    pgtype.Text{String: traceID, Valid: traceID != ""},
+	}); err != nil {
+		return nil, errors.New(errors.ErrInternal, "Failed to update listings", fmt.Errorf("failed to record bulk %s: %w", action, err))
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errors.New(errors.ErrInternal, "Failed to update listings", fmt.Errorf("failed to commit bulk %s: %w", action, err))
+	}
+	return response, nil
+}
+
+// bulkMessages has the worker re-read every changed listing, dropping deleted and hidden ones from the index. Deleted
+// listings also get a delete event, and their live remixes are re-indexed without the parent. DeleteListing sends the
+// same for its one listing.
+func (s *svc) bulkMessages(ctx context.Context, qtx *repo.Queries, action BulkAction, ids []pgtype.UUID, traceID string) ([]events.Message, error) {
+	var messages []events.Message
+	for _, id := range ids {
+		// Search documents are keyed by the dashless ID
+		listingID := fmt.Sprintf("%x", id.Bytes)
+		index, err := s.eventHandler.ListingIndexMessage(events.ReIndexListingEvent{ListingID: listingID, TraceID: traceID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to build re-index event for %s: %w", listingID, err)
+		}
+		messages = append(messages, index)
+		if action != BulkActionDelete {
+			continue
+		}
+		deletes, err := s.eventHandler.ListingDeleteMessages(events.DeleteListingIndexEvent{ListingID: listingID, TraceID: traceID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to build delete event for %s: %w", listingID, err)
+		}
+		messages = append(messages, deletes...)
+	}
+	if action != BulkActionDelete {
+		return messages, nil
+	}
+
+	remixes, err := qtx.GetRemixIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch remixes of deleted listings: %w", err)
+	}
+	for _, remix := range remixes {
+		listingID := fmt.Sprintf("%x", remix.Bytes)
+		index, err := s.eventHandler.ListingIndexMessage(events.ReIndexListingEvent{ListingID: listingID, TraceID: traceID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to build remix re-index event for %s: %w", listingID, err)
+		}
+		messages = append(messages, index)
+	}
+	return messages, nil
+}
@@ -0,0 +1,276 @@
+package listings
+
+import (
+	"context"
+	"gateway/internal/auth"
+	"gateway/internal/cache"
+	"gateway/internal/errors"
+	"gateway/internal/events"
+	"gateway/internal/mocks/mockevents"
+	"gateway/internal/testutil/apitest"
+	"regexp"
+	"strings"
+	"testing"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	bulkOwnListing     = "11111111-1111-1111-1111-111111111111"
+	bulkHiddenListing  = "22222222-2222-2222-2222-222222222222"
+	bulkOtherListing   = "33333333-3333-3333-3333-333333333333"
+	bulkMissingListing = "44444444-4444-4444-4444-444444444444"
+	bulkOtherSeller    = "c0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+
+	// The listings as search documents and events know them
+	bulkOwnDocument    = "11111111111111111111111111111111"
+	bulkHiddenDocument = "22222222222222222222222222222222"
+)
+
+// bulkRows is what the locking query finds, the missing listing is never among them
+func bulkRows(t *testing.T) *pgxmock.Rows {
+	return pgxmock.NewRows([]string{"id", "seller_id", "status"}).
+		AddRow(mustUUID(t, bulkOwnListing), mustUUID(t, updateSellerID), repo.NullListingStatus{ListingStatus: repo.ListingStatusACTIVE, Valid: true}).
+		AddRow(mustUUID(t, bulkHiddenListing), mustUUID(t, updateSellerID), repo.NullListingStatus{ListingStatus: repo.ListingStatusHIDDEN, Valid: true}).
+		AddRow(mustUUID(t, bulkOtherListing), mustUUID(t, bulkOtherSeller), repo.NullListingStatus{ListingStatus: repo.ListingStatusACTIVE, Valid: true})
+}
+
+func bulkIDs(t *testing.T) []pgtype.UUID {
+	return []pgtype.UUID{mustUUID(t, bulkOwnListing), mustUUID(t, bulkHiddenListing), mustUUID(t, bulkOtherListing), mustUUID(t, bulkMissingListing)}
+}
+
+// bulkSeller is the caller, the owner of bulkOwnListing and bulkHiddenListing
+var bulkSeller = auth.UserInfo{ID: updateSellerID, Username: "tester"}
+
+// newBulkTest is an update test whose events go to the outbox on these subjects
+func newBulkTest(t *testing.T) (*svc, pgxmock.PgxPoolIface) {
+	t.Helper()
+
+	service, mockPool := newUpdateTest(t)
+	service.eventHandler = events.NewEventHandler(mockevents.NewBus(t), &events.EventConfig{
+		IndexListingEvent:  "listings.index",
+		DeleteListingEvent: "listings.delete",
+	}, service.logger)
+	return service, mockPool
+}
+
+// expectBulkAudit expects the audit row of a bulk request from bulkSeller
+func expectBulkAudit(t *testing.T, mockPool pgxmock.PgxPoolIface, action BulkAction, applied []pgtype.UUID, failed int32) *pgxmock.ExpectedExec {
+	return mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO listing_bulk_actions`)).
+		WithArgs(string(action), mustUUID(t, updateSellerID), bulkSeller.Username, applied, failed, pgtype.Text{String: "trace", Valid: true})
+}
+
+var bulkFailures = []BulkListingFailure{
+	{ListingID: bulkOtherListing, Reason: errors.ReasonListingNotOwner},
+	{ListingID: bulkMissingListing, Reason: errors.ReasonListingNotFound},
+}
+
+func TestApplyBulkAction_Delete(t *testing.T) {
+	// SCENARIO: A seller deletes two of their listings, one owned by someone else and one that doesn't exist. One of
+	// the deleted listings has a remix.
+	// EXPECT: One locking query, one update for the seller's own listings, the other two reported back. The re-index
+	// and delete events, the remix's re-index and the audit row are written before the commit.
+
+	service, mockPool := newBulkTest(t)
+	owned := []pgtype.UUID{mustUUID(t, bulkOwnListing), mustUUID(t, bulkHiddenListing)}
+	remix := mustUUID(t, "55555555-5555-5555-5555-555555555555")
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).WithArgs(bulkIDs(t)).WillReturnRows(bulkRows(t))
+	mockPool.ExpectExec(regexp.QuoteMeta(`SET deleted_at = CURRENT_TIMESTAMP`)).
+		WithArgs(owned, mustUUID(t, updateSellerID)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetRemixIDs :many`)).WithArgs(owned).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(remix))
+	var indexEvent, deleteEvent map[string]any
+	expectOutbox(mockPool,
+		outboxEvent{"listings.index", "index." + bulkOwnDocument, &indexEvent},
+		outboxEvent{"listings.delete", "delete." + bulkOwnDocument, &deleteEvent},
+		outboxEvent{"listings.index", "index." + bulkHiddenDocument, nil},
+		outboxEvent{"listings.delete", "delete." + bulkHiddenDocument, nil},
+		outboxEvent{"listings.index", "index.55555555555555555555555555555555", nil},
+	)
+	expectBulkAudit(t, mockPool, BulkActionDelete, owned, 2).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockPool.ExpectCommit()
+
+	response, err := service.applyBulkAction(context.Background(), bulkSeller, mustUUID(t, updateSellerID), BulkActionDelete, bulkIDs(t), "trace")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{bulkOwnListing, bulkHiddenListing}, response.Affected)
+	assert.Equal(t, bulkFailures, response.Failed)
+	// Dashless like the search documents, or the worker finds nothing to drop
+	assert.Equal(t, bulkOwnDocument, indexEvent["listing_id"])
+	assert.Equal(t, map[string]any{"listing_id": bulkOwnDocument, "trace_id": "trace"}, deleteEvent)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestApplyBulkAction_AuditFails(t *testing.T) {
+	// SCENARIO: The audit row can't be written after the listings were deleted and their events queued.
+	// EXPECT: An internal error and a rollback, nothing is deleted without its events and audit row.
+
+	service, mockPool := newBulkTest(t)
+	owned := []pgtype.UUID{mustUUID(t, bulkOwnListing), mustUUID(t, bulkHiddenListing)}
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).WithArgs(bulkIDs(t)).WillReturnRows(bulkRows(t))
+	mockPool.ExpectExec(regexp.QuoteMeta(`SET deleted_at = CURRENT_TIMESTAMP`)).
+		WithArgs(owned, mustUUID(t, updateSellerID)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetRemixIDs :many`)).WithArgs(owned).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}))
+	expectOutbox(mockPool,
+		outboxEvent{"listings.index", "index." + bulkOwnDocument, nil},
+		outboxEvent{"listings.delete", "delete." + bulkOwnDocument, nil},
+		outboxEvent{"listings.index", "index." + bulkHiddenDocument, nil},
+		outboxEvent{"listings.delete", "delete." + bulkHiddenDocument, nil},
+	)
+	expectBulkAudit(t, mockPool, BulkActionDelete, owned, 2).WillReturnError(assert.AnError)
+	mockPool.ExpectRollback()
+
+	_, err := service.applyBulkAction(context.Background(), bulkSeller, mustUUID(t, updateSellerID), BulkActionDelete, bulkIDs(t), "trace")
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrInternal, appErr.Code)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestApplyBulkAction_Unpublish(t *testing.T) {
+	// SCENARIO: The same request, unpublishing instead. One of the seller's listings is already hidden.
+	// EXPECT: Both are hidden, only the listing that changed status gets a history entry. Both are re-indexed, without
+	// delete events.
+
+	service, mockPool := newBulkTest(t)
+	owned := []pgtype.UUID{mustUUID(t, bulkOwnListing), mustUUID(t, bulkHiddenListing)}
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).WithArgs(bulkIDs(t)).WillReturnRows(bulkRows(t))
+	mockPool.ExpectExec(regexp.QuoteMeta(`SET status = 'HIDDEN'`)).
+		WithArgs(owned, mustUUID(t, updateSellerID)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO listing_status_events`)).
+		WithArgs(
+			mustUUID(t, bulkOwnListing), repo.ListingStatusActorUSER, mustUUID(t, updateSellerID),
+			repo.NullListingStatus{ListingStatus: repo.ListingStatusACTIVE, Valid: true}, repo.ListingStatusHIDDEN,
+			pgtype.Text{String: unpublishReason, Valid: true}, pgtype.Text{}, pgtype.Text{},
+		).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	var indexEvent map[string]any
+	expectOutbox(mockPool,
+		outboxEvent{"listings.index", "index." + bulkOwnDocument, &indexEvent},
+		outboxEvent{"listings.index", "index." + bulkHiddenDocument, nil},
+	)
+	expectBulkAudit(t, mockPool, BulkActionUnpublish, owned, 2).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockPool.ExpectCommit()
+
+	response, err := service.applyBulkAction(context.Background(), bulkSeller, mustUUID(t, updateSellerID), BulkActionUnpublish, bulkIDs(t), "trace")
+
+	require.NoError(t, err)
+	assert.Equal(t, bulkOwnDocument, indexEvent["listing_id"])
+	assert.Equal(t, []string{bulkOwnListing, bulkHiddenListing}, response.Affected)
+	assert.Equal(t, bulkFailures, response.Failed)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestBulkUpdateListings_ForgetsCachedListings(t *testing.T) {
+	// SCENARIO: A listing is cached under both ID forms, its dashed one and the dashless one search hits carry, when
+	// its seller unpublishes it.
+	// EXPECT: Both entries are dropped, neither keeps serving the listing as it was.
+
+	service, mockPool := newBulkTest(t)
+	rdb, _ := apitest.NewRedis(t)
+	service.cache = rdb
+	listing := mustUUID(t, bulkOwnListing)
+	for _, id := range []string{bulkOwnListing, bulkOwnDocument} {
+		require.NoError(t, cache.Set(rdb, context.Background(), service.listingCache.Key(id), notFoundEntry{NotFound: true}, NotFoundCacheTTL))
+	}
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).WithArgs([]pgtype.UUID{listing}).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "seller_id", "status"}).
+			AddRow(listing, mustUUID(t, updateSellerID), repo.NullListingStatus{ListingStatus: repo.ListingStatusHIDDEN, Valid: true}))
+	mockPool.ExpectExec(regexp.QuoteMeta(`SET status = 'HIDDEN'`)).
+		WithArgs([]pgtype.UUID{listing}, mustUUID(t, updateSellerID)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	expectOutbox(mockPool, outboxEvent{"listings.index", "index." + bulkOwnDocument, nil})
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO listing_bulk_actions`)).WithArgs(anyArgs(6)...).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockPool.ExpectCommit()
+
+	_, err := service.BulkUpdateListings(context.Background(), bulkSeller, &BulkListingsRequest{Action: BulkActionUnpublish, ListingIDs: []string{bulkOwnListing}})
+
+	require.NoError(t, err)
+	for _, id := range []string{bulkOwnListing, bulkOwnDocument} {
+		_, found, err := cache.Get[notFoundEntry](rdb, context.Background(), service.listingCache.Key(id))
+		require.NoError(t, err)
+		assert.False(t, found, id)
+	}
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestApplyBulkAction_NothingApplicable(t *testing.T) {
+	// SCENARIO: Every listing belongs to someone else or is under moderation.
+	// EXPECT: Nothing is written, not even an audit row, the locks are released by the rollback.
+
+	service, mockPool := newBulkTest(t)
+	rejected := mustUUID(t, bulkOwnListing)
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).
+		WithArgs([]pgtype.UUID{rejected, mustUUID(t, bulkOtherListing)}).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "seller_id", "status"}).
+			AddRow(rejected, mustUUID(t, updateSellerID), repo.NullListingStatus{ListingStatus: repo.ListingStatusREJECTED, Valid: true}).
+			AddRow(mustUUID(t, bulkOtherListing), mustUUID(t, bulkOtherSeller), repo.NullListingStatus{ListingStatus: repo.ListingStatusACTIVE, Valid: true}))
+	mockPool.ExpectRollback()
+
+	response, err := service.applyBulkAction(context.Background(), bulkSeller, mustUUID(t, updateSellerID), BulkActionUnpublish, []pgtype.UUID{rejected, mustUUID(t, bulkOtherListing)}, "trace")
+
+	require.NoError(t, err)
+	assert.Empty(t, response.Affected)
+	assert.Equal(t, []BulkListingFailure{
+		{ListingID: bulkOwnListing, Reason: errors.ReasonListingNotUnpublishable},
+		{ListingID: bulkOtherListing, Reason: errors.ReasonListingNotOwner},
+	}, response.Failed)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestBulkListingsRequest_Validate(t *testing.T) {
+	tooMany := make([]string, MaxBulkListings+1)
+	for i := range tooMany {
+		tooMany[i] = bulkOwnListing
+	}
+
+	tests := []struct {
+		name       string
+		req        BulkListingsRequest
+		wantIDs    int
+		wantReason errors.Reason
+		wantErr    bool
+	}{
+		{name: "Repeats collapsed", req: BulkListingsRequest{Action: BulkActionDelete, ListingIDs: []string{bulkOwnListing, bulkOtherListing, bulkOwnListing}}, wantIDs: 2},
+		{name: "Unknown action", req: BulkListingsRequest{Action: "archive", ListingIDs: []string{bulkOwnListing}}, wantErr: true, wantReason: errors.ReasonListingBulkActionUnknown},
+		{name: "Empty", req: BulkListingsRequest{Action: BulkActionUnpublish}, wantErr: true, wantReason: errors.ReasonListingBulkSize},
+		// Counted before repeats are collapsed, the limit is on the request body
+		{name: "Too many", req: BulkListingsRequest{Action: BulkActionDelete, ListingIDs: tooMany}, wantErr: true, wantReason: errors.ReasonListingBulkSize},
+		{name: "Bad ID", req: BulkListingsRequest{Action: BulkActionDelete, ListingIDs: []string{strings.Repeat("x", 36)}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, appErr := tt.req.Validate()
+			if !tt.wantErr {
+				require.Nil(t, appErr)
+				assert.Len(t, ids, tt.wantIDs)
+				return
+			}
+			require.NotNil(t, appErr)
+			assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+			assert.Equal(t, tt.wantReason, appErr.Reason)
+		})
+	}
+}
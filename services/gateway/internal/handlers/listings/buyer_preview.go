@@ -0,0 +1,31 @@
+package listings
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// PreviewListingAsBuyer is GET /listings/{id}?preview=buyer, the seller's own listing as an anonymous buyer is served
+// it. It's read from the database so a change the seller just made shows up even while an older copy is cached, and
+// it's never written to the cache, the public copy stays the one anonymous reads put there.
+func (s *svc) PreviewListingAsBuyer(ctx context.Context, userInfo auth.UserInfo, listingID string) (*ListingResponse, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userInfo.ID); err != nil {
+		return nil, errors.New(errors.ErrInternal, "Invalid user ID", fmt.Errorf("invalid user uuid: %w", err))
+	}
+
+	response, sellerID, _, err := s.renderListing(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+	// Anyone can already read the listing, but the preview is only offered to its seller
+	if sellerID != userUUID {
+		return nil, errors.New(errors.ErrUnauthorized, "Only the seller can preview this listing", fmt.Errorf("user %s doesn't own listing %s", userInfo.ID, listingID)).WithReason(errors.ReasonListingNotOwner)
+	}
+
+	return &response, nil
+}
@@ -0,0 +1,41 @@
+package listings
+
+import (
+	"gateway/internal/currency"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// showPriceIn adds the price, and the sale price during a sale, converted to displayCurrency. Cached responses never
+// carry a conversion, so this runs on the copy handed to the user, like showDimensionsIn.
+func (l *ListingResponse) showPriceIn(rates currency.Rates, displayCurrency string) {
+	if displayCurrency == "" || l.PriceMinUnit == 0 || strings.EqualFold(l.Currency, displayCurrency) {
+		return
+	}
+	converted, ok := rates.Convert(l.PriceMinUnit, l.Currency, displayCurrency)
+	if !ok {
+		return
+	}
+	l.PriceConverted = &converted
+	l.ConvertedCurrency = displayCurrency
+	l.ConvertedRateDate = rates.Date.Format(time.DateOnly)
+
+	if l.SalePriceMinUnit != nil {
+		// Same rate as the price, so the discount shown is the one the seller set
+		if sale, ok := rates.Convert(*l.SalePriceMinUnit, l.Currency, displayCurrency); ok {
+			l.SalePriceConverted = &sale
+		}
+	}
+}
+
+// showPrice converts the listing's price for callers who asked for another currency
+func (h *ListingsHandler) showPrice(r *http.Request, listing *ListingResponse) {
+	displayCurrency := currency.Requested(r)
+	if displayCurrency == "" {
+		return
+	}
+	if rates, ok := h.rates.Current(r.Context()); ok {
+		listing.showPriceIn(rates, displayCurrency)
+	}
+}
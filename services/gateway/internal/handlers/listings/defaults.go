@@ -0,0 +1,124 @@
+package listings
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/errors"
+	"gateway/internal/handlers/categories"
+	"strconv"
+	"strings"
+)
+
+const (
+	// nozzleTempWarnMarginC is how far past a category's range a temperature has to be before it's worth a warning
+	nozzleTempWarnMarginC = 20
+	// dimensionsWarnFactor is how many times bigger or smaller than typical the longest side has to be
+	dimensionsWarnFactor = 10
+)
+
+// CategoryDefaults is the per-category printer settings template, categories.CategoriesService implements it
+type CategoryDefaults interface {
+	GetDefaults(ctx context.Context, category string) (*categories.Defaults, error)
+}
+
+// defaultsWarnings compares a listing against the templates of its categories. Warnings are a nicety, so a
+// template that can't be loaded is skipped rather than failing a listing that was already saved.
+func (s *svc) defaultsWarnings(ctx context.Context, in warningInput) []ListingWarning {
+	if s.defaults == nil {
+		return []ListingWarning{}
+	}
+
+	templates := make([]categories.Defaults, 0, len(in.categories))
+	for _, category := range in.categories {
+		if !categories.IsCanonical(category) {
+			continue
+		}
+		defaults, err := s.defaults.GetDefaults(ctx, category)
+		if err != nil {
+			s.logger.WarnContext(ctx, "Failed to load category defaults, skipping its warnings", "category", category, "error", err)
+			continue
+		}
+		templates = append(templates, *defaults)
+	}
+
+	return compareWithDefaults(templates, in.settings, in.dimensions)
+}
+
+// compareWithDefaults warns about values that are far off every one of the templates, a listing in two categories
+// only needs to look usual for one of them
+func compareWithDefaults(templates []categories.Defaults, settings ListingPrinterSettings, dims *ListingDimensions) []ListingWarning {
+	warnings := []ListingWarning{}
+	if len(templates) == 0 {
+		return warnings
+	}
+
+	if temp := nozzleTempC(settings.RecommendedNozzleTempC, settings.NozzleTemperature); temp != nil {
+		low, high := templates[0].NozzleTempC.Min, templates[0].NozzleTempC.Max
+		for _, t := range templates[1:] {
+			low, high = min(low, t.NozzleTempC.Min), max(high, t.NozzleTempC.Max)
+		}
+		if *temp < float64(low-nozzleTempWarnMarginC) || *temp > float64(high+nozzleTempWarnMarginC) {
+			value := strconv.FormatFloat(*temp, 'f', -1, 64)
+			warnings = append(warnings, ListingWarning{
+				Field:   "printerSettings.recommendedNozzleTempC",
+				Reason:  errors.ReasonListingNozzleTempUnusual,
+				Message: fmt.Sprintf("%s°C is unusual for this category, most listings use %d-%d°C", value, low, high),
+				Params:  map[string]string{"value": value, "min": strconv.Itoa(low), "max": strconv.Itoa(high)},
+			})
+		}
+	}
+
+	if settings.RecommendedMaterials != nil && len(*settings.RecommendedMaterials) > 0 {
+		usual := map[string]bool{}
+		recommended := []string{}
+		for _, t := range templates {
+			for _, material := range t.RecommendedMaterials {
+				if !usual[strings.ToLower(material)] {
+					usual[strings.ToLower(material)] = true
+					recommended = append(recommended, material)
+				}
+			}
+		}
+		matched := false
+		for _, material := range *settings.RecommendedMaterials {
+			if usual[strings.ToLower(strings.TrimSpace(material))] {
+				matched = true
+				break
+			}
+		}
+		if !matched && len(recommended) > 0 {
+			list := strings.Join(recommended, ", ")
+			warnings = append(warnings, ListingWarning{
+				Field:   "printerSettings.recommendedMaterials",
+				Reason:  errors.ReasonListingMaterialsUnusual,
+				Message: "These materials are unusual for this category, most listings recommend " + list,
+				Params:  map[string]string{"recommended": list},
+			})
+		}
+	}
+
+	if dims != nil {
+		if longest := max(dims.X, dims.Y, dims.Z); longest > 0 {
+			typical, usual := 0, false
+			for _, t := range templates {
+				side := float64(t.TypicalDimensionsMM.Longest())
+				if longest <= side*dimensionsWarnFactor && longest >= side/dimensionsWarnFactor {
+					usual = true
+					break
+				}
+				typical = max(typical, t.TypicalDimensionsMM.Longest())
+			}
+			if !usual {
+				value := strconv.FormatFloat(longest, 'f', -1, 64)
+				warnings = append(warnings, ListingWarning{
+					Field:   "dimensions",
+					Reason:  errors.ReasonListingDimensionsUnusual,
+					Message: fmt.Sprintf("A longest side of %s mm is unusual for this category, %d mm is typical", value, typical),
+					Params:  map[string]string{"value": value, "typical": strconv.Itoa(typical)},
+				})
+			}
+		}
+	}
+
+	return warnings
+}
@@ -0,0 +1,115 @@
+package listings
+
+import (
+	"gateway/internal/errors"
+	"gateway/internal/handlers/categories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	functionalDefaults = categories.Defaults{
+		Category:             "functional",
+		RecommendedMaterials: []string{"PETG", "PLA", "ASA"},
+		NozzleTempC:          categories.TempRange{Min: 200, Max: 250},
+		TypicalDimensionsMM:  categories.DimensionsMM{X: 80, Y: 60, Z: 40},
+	}
+	artisticDefaults = categories.Defaults{
+		Category:             "artistic",
+		RecommendedMaterials: []string{"PLA", "Resin"},
+		NozzleTempC:          categories.TempRange{Min: 190, Max: 220},
+		TypicalDimensionsMM:  categories.DimensionsMM{X: 40, Y: 40, Z: 60},
+	}
+)
+
+func float(v float64) *float64 { return &v }
+
+func TestCompareWithDefaults(t *testing.T) {
+	tests := map[string]struct {
+		templates   []categories.Defaults
+		settings    ListingPrinterSettings
+		dims        *ListingDimensions
+		wantReasons []errors.Reason
+		wantParams  map[string]string // Of the first warning
+	}{
+		"usual values": {
+			templates: []categories.Defaults{functionalDefaults},
+			settings:  ListingPrinterSettings{RecommendedNozzleTempC: float(235), RecommendedMaterials: &[]string{"petg", "Nylon"}},
+			dims:      &ListingDimensions{X: 120, Y: 30, Z: 10},
+		},
+		"a little outside the range is fine": {
+			templates: []categories.Defaults{functionalDefaults},
+			settings:  ListingPrinterSettings{RecommendedNozzleTempC: float(270)},
+		},
+		"nozzle far too hot": {
+			templates:   []categories.Defaults{functionalDefaults},
+			settings:    ListingPrinterSettings{RecommendedNozzleTempC: float(300)},
+			wantReasons: []errors.Reason{errors.ReasonListingNozzleTempUnusual},
+			wantParams:  map[string]string{"value": "300", "min": "200", "max": "250"},
+		},
+		"deprecated temperature field is checked too": {
+			templates:   []categories.Defaults{artisticDefaults},
+			settings:    ListingPrinterSettings{NozzleTemperature: float(260)},
+			wantReasons: []errors.Reason{errors.ReasonListingNozzleTempUnusual},
+		},
+		"no usual material": {
+			templates:   []categories.Defaults{artisticDefaults},
+			settings:    ListingPrinterSettings{RecommendedMaterials: &[]string{"TPU", "Nylon"}},
+			wantReasons: []errors.Reason{errors.ReasonListingMaterialsUnusual},
+			wantParams:  map[string]string{"recommended": "PLA, Resin"},
+		},
+		"no materials given": {
+			templates: []categories.Defaults{artisticDefaults},
+			settings:  ListingPrinterSettings{RecommendedMaterials: &[]string{}},
+		},
+		"far too big": {
+			templates:   []categories.Defaults{artisticDefaults},
+			dims:        &ListingDimensions{X: 700, Y: 100, Z: 50},
+			wantReasons: []errors.Reason{errors.ReasonListingDimensionsUnusual},
+			wantParams:  map[string]string{"value": "700", "typical": "60"},
+		},
+		"far too small": {
+			templates:   []categories.Defaults{functionalDefaults},
+			dims:        &ListingDimensions{X: 5, Y: 5, Z: 2},
+			wantReasons: []errors.Reason{errors.ReasonListingDimensionsUnusual},
+		},
+		"usual for one of two categories": {
+			templates: []categories.Defaults{artisticDefaults, functionalDefaults},
+			settings:  ListingPrinterSettings{RecommendedNozzleTempC: float(265), RecommendedMaterials: &[]string{"ASA"}},
+		},
+		"everything off": {
+			templates: []categories.Defaults{functionalDefaults},
+			settings:  ListingPrinterSettings{RecommendedNozzleTempC: float(170), RecommendedMaterials: &[]string{"Resin"}},
+			dims:      &ListingDimensions{X: 1200, Y: 10, Z: 10},
+			wantReasons: []errors.Reason{
+				errors.ReasonListingNozzleTempUnusual,
+				errors.ReasonListingMaterialsUnusual,
+				errors.ReasonListingDimensionsUnusual,
+			},
+		},
+		"no templates": {
+			settings: ListingPrinterSettings{RecommendedNozzleTempC: float(450)},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			warnings := compareWithDefaults(tt.templates, tt.settings, tt.dims)
+
+			reasons := []errors.Reason{}
+			for _, w := range warnings {
+				reasons = append(reasons, w.Reason)
+				assert.NotEmpty(t, w.Field)
+				assert.NotEmpty(t, w.Message)
+			}
+			if tt.wantReasons == nil {
+				tt.wantReasons = []errors.Reason{}
+			}
+			assert.Equal(t, tt.wantReasons, reasons)
+			if tt.wantParams != nil {
+				assert.Equal(t, tt.wantParams, warnings[0].Params)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package listings
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/storage"
+	"strings"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// FileURLPolicy is how long the URL handed out for one file type stays valid
+type FileURLPolicy struct {
+	// TTL is used when the client expresses no preference. Zero means files of this type live in the
+	// public bucket and are linked directly instead of presigned.
+	TTL time.Duration
+	// MaxTTL is what prefer_long_ttl gets, for customers downloading large files over slow connections
+	MaxTTL time.Duration
+}
+
+// DownloadConfig maps a file type (MODEL, IMAGE) to its URL policy
+type DownloadConfig map[string]FileURLPolicy
+
+// defaultPresignTTL covers file types missing from the config, which are treated as private
+const defaultPresignTTL = 15 * time.Minute
+
+func DefaultDownloadConfig() DownloadConfig {
+	return DownloadConfig{
+		"MODEL": {TTL: 15 * time.Minute, MaxTTL: 2 * time.Hour},
+		"IMAGE": {},
+	}
+}
+
+// TTL returns how long a URL for fileType should live. A zero result means the file is public.
+func (c DownloadConfig) TTL(fileType string, preferLong bool) time.Duration {
+	policy, ok := c[strings.ToUpper(fileType)]
+	if !ok {
+		return defaultPresignTTL
+	}
+	if preferLong && policy.MaxTTL > policy.TTL {
+		return policy.MaxTTL
+	}
+	return policy.TTL
+}
+
+// FileDownloadResponse is a link to a single listing file
+type FileDownloadResponse struct {
+	URL       string     `json:"url"`
+	ExpiresAt *time.Time `json:"expires_at"` // Nil for public files, which don't expire
+}
+
+// GetFileDownload hands out a link to one validated file. Private files get a presigned URL attributed
+// to the requesting user, so a link that turns up somewhere it shouldn't can be traced back. userInfo is the zero
+// value for anonymous callers, who only get the files of free listings.
+func (s *svc) GetFileDownload(ctx context.Context, userInfo auth.UserInfo, listingID string, fileID string, preferLongTTL bool) (*FileDownloadResponse, error) {
+	var params repo.GetListingFileForDownloadParams
+	if err := params.ListingID.Scan(listingID); err != nil {
+		return nil, errors.New(errors.ErrInvalidInput, "Invalid listing ID provided", err)
+	}
+	if err := params.ID.Scan(fileID); err != nil {
+		return nil, errors.New(errors.ErrInvalidInput, "Invalid file ID provided", err)
+	}
+
+	file, err := s.repo.GetListingFileForDownload(ctx, params)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New(errors.ErrNotFound, "File not found", fmt.Errorf("file %v on listing %v not found", fileID, listingID))
+		}
+		s.logger.ErrorContext(ctx, "Failed to fetch listing file", "listing_id", listingID, "file_id", fileID, "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch file", fmt.Errorf("failed to fetch file %v: %w", fileID, err))
+	}
+
+	ttl := s.downloads.TTL(string(file.FileType), preferLongTTL)
+	if ttl == 0 {
+		return &FileDownloadResponse{URL: s.publicFileURL(string(file.FileType), file.FilePath)}, nil
+	}
+
+	// Public files are linked from the listing anyway, only the private ones are what buyers pay for
+	if err := s.checkDownloadAllowed(ctx, userInfo, params.ListingID, params.ID); err != nil {
+		return nil, err
+	}
+
+	url, err := s.storage.PresignGet(ctx, storage.BucketProduct, file.FilePath, storage.PresignOptions{
+		Expiry:   ttl,
+		Audience: userInfo.ID,
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to sign download url", "file_id", fileID, "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to create download link", err)
+	}
+
+	s.logger.InfoContext(ctx, "Issued file download", "listing_id", listingID, "file_id", fileID, "user_id", userInfo.ID, "ttl", ttl)
+
+	expiresAt := time.Now().UTC().Add(ttl)
+	return &FileDownloadResponse{URL: url, ExpiresAt: &expiresAt}, nil
+}
+
+// publicFileURL links to a file in the public bucket, images go through the CDN when one is configured
+func (s *svc) publicFileURL(fileType, filePath string) string {
+	if strings.EqualFold(fileType, string(repo.FileTypeIMAGE)) {
+		return s.urls.Image(filePath)
+	}
+	return s.urls.File(filePath)
+}
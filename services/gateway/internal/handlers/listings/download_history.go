@@ -0,0 +1,168 @@
+package listings
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gateway/internal/auth"
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/errors"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// DownloadHistoryDefaultLimit is how many listings a page of GET /me/downloads has when limit isn't given,
+	// DownloadHistoryMaxLimit the most it can be
+	DownloadHistoryDefaultLimit = 20
+	DownloadHistoryMaxLimit     = 100
+)
+
+// DownloadHistoryPage is one page of a user's downloads, grouped by listing
+type DownloadHistoryPage struct {
+	Listings   []DownloadedListing `json:"listings"`
+	NextCursor *string             `json:"next_cursor"` // Null on the last page
+}
+
+// DownloadedListing is a listing the user downloaded files from
+type DownloadedListing struct {
+	ListingID        string           `json:"listing_id"`
+	Title            string           `json:"title"`
+	SellerUsername   string           `json:"seller_username"`
+	ThumbnailURL     *string          `json:"thumbnail_url"`
+	PriceMinUnit     int64            `json:"price_min_unit"`
+	Currency         string           `json:"currency"`
+	Available        bool             `json:"available"` // False once the listing is deleted, its files can't be downloaded again
+	LastDownloadedAt time.Time        `json:"last_downloaded_at"`
+	Files            []DownloadedFile `json:"files"`
+}
+
+// DownloadedFile is one file of a DownloadedListing, with how often the user downloaded it
+type DownloadedFile struct {
+	FileID           string    `json:"file_id"`
+	FileType         string    `json:"file_type"`
+	DownloadCount    int64     `json:"download_count"`
+	LastDownloadedAt time.Time `json:"last_downloaded_at"`
+}
+
+func invalidDownloadHistoryQuery(field, value string) *errors.AppError {
+	return errors.New(errors.ErrInvalidInput, fmt.Sprintf("'%s' isn't a valid %s", value, field), nil).
+		WithReason(errors.ReasonDownloadHistoryQueryInvalid).
+		WithParam("field", field).
+		WithParam("value", value)
+}
+
+// GetDownloadHistory is a page of the listings the user downloaded files from, most recently downloaded first.
+// Listings are fetched in one batch for the page rather than one at a time, deleted ones are kept and marked
+// unavailable so the history doesn't lose track of what was bought.
+func (s *svc) GetDownloadHistory(ctx context.Context, userInfo auth.UserInfo, cursor string, limit int) (*DownloadHistoryPage, error) {
+	params := repo.ListDownloadedListingsParams{PageLimit: int32(limit + 1)}
+	if err := params.UserID.Scan(userInfo.ID); err != nil {
+		return nil, errors.New(errors.ErrInvalidInput, "Invalid user ID", err)
+	}
+	if cursor != "" {
+		at, id, ok := decodeCursor(cursor)
+		if !ok {
+			return nil, invalidDownloadHistoryQuery("cursor", cursor)
+		}
+		params.CursorDownloadedAt, params.CursorListingID = at, id
+	}
+
+	rows, err := s.repo.ListDownloadedListings(ctx, params)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to list downloaded listings", "user_id", userInfo.ID, "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch download history", err)
+	}
+
+	page := &DownloadHistoryPage{Listings: make([]DownloadedListing, 0, min(len(rows), limit))}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		next := encodeCursor(rows[limit-1].LastDownloadedAt, rows[limit-1].ListingID)
+		page.NextCursor = &next
+	}
+	if len(rows) == 0 {
+		return page, nil
+	}
+
+	ids := make([]pgtype.UUID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ListingID
+	}
+
+	summaries, err := s.repo.GetListingSummaries(ctx, ids)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to fetch downloaded listings", "user_id", userInfo.ID, "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch download history", err)
+	}
+	files, err := s.repo.ListDownloadedFiles(ctx, repo.ListDownloadedFilesParams{UserID: params.UserID, ListingIds: ids})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to fetch downloaded files", "user_id", userInfo.ID, "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch download history", err)
+	}
+
+	byID := make(map[pgtype.UUID]repo.GetListingSummariesRow, len(summaries))
+	for _, summary := range summaries {
+		byID[summary.ID] = summary
+	}
+	filesByListing := make(map[pgtype.UUID][]DownloadedFile, len(rows))
+	for _, file := range files {
+		filesByListing[file.ListingID] = append(filesByListing[file.ListingID], DownloadedFile{
+			FileID:           file.FileID.String(),
+			FileType:         string(file.FileType),
+			DownloadCount:    file.DownloadCount,
+			LastDownloadedAt: utc(file.LastDownloadedAt),
+		})
+	}
+
+	for _, row := range rows {
+		// Downloads cascade with their listing, a missing summary only means it was purged since the first query
+		summary, ok := byID[row.ListingID]
+		if !ok {
+			continue
+		}
+		listing := DownloadedListing{
+			ListingID:        row.ListingID.String(),
+			Title:            summary.Title,
+			SellerUsername:   summary.SellerUsername,
+			PriceMinUnit:     summary.PriceMinUnit,
+			Currency:         summary.Currency,
+			Available:        !summary.DeletedAt.Valid,
+			LastDownloadedAt: utc(row.LastDownloadedAt),
+			Files:            orEmpty(filesByListing[row.ListingID]),
+		}
+		if summary.ThumbnailPath.Valid {
+			url := s.urls.Image(summary.ThumbnailPath.String)
+			listing.ThumbnailURL = &url
+		}
+		page.Listings = append(page.Listings, listing)
+	}
+	return page, nil
+}
+
+// DownloadAgain is GetFileDownload for a file in the user's history. Having downloaded it once isn't an entitlement
+// of its own, everything GetFileDownload checks is checked again, so a deleted listing or a seller on vacation
+// refuses it like any other download.
+func (s *svc) DownloadAgain(ctx context.Context, userInfo auth.UserInfo, listingID string, fileID string, preferLongTTL bool) (*FileDownloadResponse, error) {
+	var params repo.HasDownloadedFileParams
+	if err := params.UserID.Scan(userInfo.ID); err != nil {
+		return nil, errors.New(errors.ErrInvalidInput, "Invalid user ID", err)
+	}
+	if err := params.ListingID.Scan(listingID); err != nil {
+		return nil, errors.New(errors.ErrInvalidInput, "Invalid listing ID provided", err)
+	}
+	if err := params.FileID.Scan(fileID); err != nil {
+		return nil, errors.New(errors.ErrInvalidInput, "Invalid file ID provided", err)
+	}
+
+	downloaded, err := s.repo.HasDownloadedFile(ctx, params)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to check download history", "user_id", userInfo.ID, "file_id", fileID, "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch file", err)
+	}
+	if !downloaded {
+		return nil, errors.New(errors.ErrNotFound, "You haven't downloaded this file before", nil).WithReason(errors.ReasonDownloadNotFound)
+	}
+
+	return s.GetFileDownload(ctx, userInfo, listingID, fileID, preferLongTTL)
+}
@@ -0,0 +1,107 @@
+package listings
+
+import (
+	"context"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/publicurl"
+	"gateway/internal/testutil"
+	"regexp"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const downloaderID = "d0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+
+func TestGetDownloadHistory_GroupsAndPages(t *testing.T) {
+	// SCENARIO: A buyer downloaded files from three listings, one since deleted, and asks for two at a time.
+	// EXPECT: The first page has the two most recent listings hydrated in one batch with their files, the deleted
+	// one marked unavailable, and the cursor picks up after the last of them.
+
+	mockPool := testutil.NewMockDB(t)
+	service := &svc{repo: repo.New(mockPool), db: mockPool, logger: testutil.NewTestLogger(), urls: publicurl.Config{AssetsBaseURL: "https://assets.test"}}
+
+	t0 := time.Date(2026, 10, 1, 12, 0, 0, 123456000, time.UTC)
+	deletedAt := t0.Add(time.Hour)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListDownloadedListings :many`)).
+		WithArgs(uuidArg(t, downloaderID), pgtype.Timestamptz{}, pgtype.UUID{}, int32(3)).
+		WillReturnRows(pgxmock.NewRows([]string{"listing_id", "last_downloaded_at"}).
+			AddRow(adminListingID(1), t0).
+			AddRow(adminListingID(2), t0.Add(-time.Minute)).
+			AddRow(adminListingID(3), t0.Add(-time.Hour)))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingSummaries :many`)).
+		WithArgs([]pgtype.UUID{uuidArg(t, adminListingID(1)), uuidArg(t, adminListingID(2))}).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "title", "seller_username", "thumbnail_path", "price_min_unit", "currency", "deleted_at"}).
+			AddRow(adminListingID(2), "Gone", "maker", nil, int64(500), "gbp", deletedAt).
+			AddRow(adminListingID(1), "Benchy", "maker", "listings/benchy.png", int64(0), "gbp", nil))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListDownloadedFiles :many`)).
+		WithArgs(uuidArg(t, downloaderID), []pgtype.UUID{uuidArg(t, adminListingID(1)), uuidArg(t, adminListingID(2))}).
+		WillReturnRows(pgxmock.NewRows([]string{"listing_id", "file_id", "file_type", "last_downloaded_at", "download_count"}).
+			AddRow(adminListingID(1), adminListingID(11), repo.FileTypeMODEL, t0, int64(3)).
+			AddRow(adminListingID(1), adminListingID(12), repo.FileTypeMODEL, t0.Add(-time.Second), int64(1)).
+			AddRow(adminListingID(2), adminListingID(21), repo.FileTypeMODEL, t0.Add(-time.Minute), int64(1)))
+
+	page, err := service.GetDownloadHistory(context.Background(), auth.UserInfo{ID: downloaderID}, "", 2)
+	require.NoError(t, err)
+	require.Len(t, page.Listings, 2)
+
+	first := page.Listings[0]
+	assert.Equal(t, adminListingID(1), first.ListingID)
+	assert.Equal(t, "Benchy", first.Title)
+	assert.True(t, first.Available)
+	require.NotNil(t, first.ThumbnailURL)
+	assert.Equal(t, "https://assets.test/listings/benchy.png", *first.ThumbnailURL)
+	require.Len(t, first.Files, 2)
+	assert.Equal(t, adminListingID(11), first.Files[0].FileID)
+	assert.Equal(t, int64(3), first.Files[0].DownloadCount)
+
+	second := page.Listings[1]
+	assert.Equal(t, "Gone", second.Title)
+	assert.False(t, second.Available)
+	assert.Nil(t, second.ThumbnailURL)
+	require.Len(t, second.Files, 1)
+
+	require.NotNil(t, page.NextCursor)
+	at, id, ok := decodeCursor(*page.NextCursor)
+	require.True(t, ok)
+	assert.True(t, at.Time.Equal(t0.Add(-time.Minute)))
+	assert.Equal(t, adminListingID(2), id.String())
+
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestGetDownloadHistory_EmptySkipsHydration(t *testing.T) {
+	mockPool := testutil.NewMockDB(t)
+	service := &svc{repo: repo.New(mockPool), db: mockPool, logger: testutil.NewTestLogger()}
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListDownloadedListings :many`)).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"listing_id", "last_downloaded_at"}))
+
+	page, err := service.GetDownloadHistory(context.Background(), auth.UserInfo{ID: downloaderID}, "", 20)
+	require.NoError(t, err)
+	assert.NotNil(t, page.Listings)
+	assert.Empty(t, page.Listings)
+	assert.Nil(t, page.NextCursor)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestGetDownloadHistory_InvalidCursor(t *testing.T) {
+	mockPool := testutil.NewMockDB(t)
+	service := &svc{repo: repo.New(mockPool), db: mockPool, logger: testutil.NewTestLogger()}
+
+	_, err := service.GetDownloadHistory(context.Background(), auth.UserInfo{ID: downloaderID}, "not base64!", 20)
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ReasonDownloadHistoryQueryInvalid, appErr.Reason)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
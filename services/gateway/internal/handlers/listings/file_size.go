@@ -0,0 +1,37 @@
+package listings
+
+import (
+	"fmt"
+	"math"
+)
+
+// totalModelSize is the size of the download package, the model files that passed validation. Files still being
+// validated or rejected can't be downloaded, so they don't count.
+func totalModelSize(files []ListingFileDTO) int64 {
+	var total int64
+	for _, f := range files {
+		if f.FileType == "MODEL" && f.Status == "VALID" {
+			total += f.Size
+		}
+	}
+	return total
+}
+
+var fileSizeUnits = []string{"KB", "MB", "GB", "TB"}
+
+// formatFileSize reads like a file manager does, in 1024s with one decimal: "512 B", "1.5 KB", "12.0 MB". A size that
+// rounds up to 1024 of a unit is shown in the next one, 1048575 bytes is "1.0 MB" and not "1024.0 KB".
+// The listings worker formats the search document's copy the same way.
+func formatFileSize(bytes int64) string {
+	if bytes < 1024 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value, unit := float64(bytes), ""
+	for _, unit = range fileSizeUnits {
+		value /= 1024
+		if math.Round(value*10) < 1024*10 {
+			break
+		}
+	}
+	return fmt.Sprintf("%.1f %s", value, unit)
+}
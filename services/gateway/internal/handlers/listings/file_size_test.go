@@ -0,0 +1,31 @@
+package listings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatFileSize(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KB"},
+		{1536, "1.5 KB"},
+		{1<<20 - 52, "1023.9 KB"},
+		{1<<20 - 1, "1.0 MB"}, // Rounds up to 1024.0 KB
+		{1 << 20, "1.0 MB"},
+		{12_900_000, "12.3 MB"},
+		{1<<30 - 1, "1.0 GB"},
+		{1 << 30, "1.0 GB"},
+		{5 << 30, "5.0 GB"},
+		{1 << 40, "1.0 TB"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, formatFileSize(tt.bytes), "%d bytes", tt.bytes)
+	}
+}
@@ -1,22 +1,36 @@
 package listings
 
 import (
+	"encoding/csv"
+	stdjson "encoding/json"
+	"fmt"
 	"gateway/internal/auth"
+	"gateway/internal/counters"
+	"gateway/internal/currency"
 	"gateway/internal/errors"
 	"gateway/internal/json"
 	"log/slog"
 	"net/http"
+	"shared/materials"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 )
 
 type ListingsHandler struct {
-	service ListingsService
+	service  ListingsService
+	counters counters.Recorder
+	rates    currency.RatesSource
 }
 
-func NewListingsHandler(svc ListingsService) *ListingsHandler {
+func NewListingsHandler(svc ListingsService, counters counters.Recorder, rates currency.RatesSource) *ListingsHandler {
 	return &ListingsHandler{
-		service: svc,
+		service:  svc,
+		counters: counters,
+		rates:    rates,
 	}
 }
 
@@ -25,7 +39,7 @@ func (h *ListingsHandler) CreateListing(w http.ResponseWriter, r *http.Request)
 	userInfo, err := auth.GetUserInfo(ctx)
 	if err != nil {
 		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
-		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err))
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
 		return
 	}
 
@@ -54,19 +68,27 @@ func (h *ListingsHandler) GetListingsForUser(w http.ResponseWriter, r *http.Requ
 
 	if err != nil {
 		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
-		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err))
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
 		return
 	}
 
 	slog.DebugContext(ctx, "Fetching listings for user", "user_id", userInfo.ID)
 
-	listings, err := h.service.GetListingsForUser(ctx, userInfo)
+	query := r.URL.Query()
+	listings, err := h.service.GetListingsForUser(ctx, userInfo, SellerListingsQuery{
+		Status: query.Get("status"),
+		Sort:   query.Get("sort"),
+		Order:  query.Get("order"),
+	})
 
 	if err != nil {
 		slog.WarnContext(ctx, "Failed to fetch listings for user", "error", err)
 		errors.RespondError(w, r, err)
 		return
 	}
+	for i := range listings {
+		listings[i].showDimensionsIn(userInfo.DimensionUnit)
+	}
 
 	json.Write(w, http.StatusOK, listings)
 }
@@ -84,7 +106,7 @@ func (h *ListingsHandler) DeleteListing(w http.ResponseWriter, r *http.Request)
 	userInfo, err := auth.GetUserInfo(ctx)
 	if err != nil {
 		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
-		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err))
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
 		return
 	}
 
@@ -100,13 +122,43 @@ func (h *ListingsHandler) DeleteListing(w http.ResponseWriter, r *http.Request)
 	json.Write(w, http.StatusNoContent, nil)
 }
 
+func (h *ListingsHandler) BulkUpdateListings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	bulkRequest := BulkListingsRequest{}
+	if err := json.Read(r, &bulkRequest); err != nil {
+		slog.WarnContext(ctx, "Invalid request body", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Input provided was not in the format expected. Please contact support if this error persists.", err))
+		return
+	}
+
+	slog.DebugContext(ctx, "Bulk updating listings", "user_id", userInfo.ID, "action", bulkRequest.Action, "count", len(bulkRequest.ListingIDs))
+
+	response, err := h.service.BulkUpdateListings(ctx, userInfo, &bulkRequest)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to bulk update listings", "error", err)
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, response)
+}
+
+// UpdateListings is the PUT edit. Deprecated for PATCH /listings/{id}, which can clear fields, and kept working
+// until clients have moved over.
 func (h *ListingsHandler) UpdateListings(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	listingID := chi.URLParam(r, "id")
 	userInfo, err := auth.GetUserInfo(ctx)
 	if err != nil {
 		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
-		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err))
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
 		return
 	}
 
@@ -119,14 +171,48 @@ func (h *ListingsHandler) UpdateListings(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	_, err = h.service.UpdateListing(ctx, userInfo, listingID, &updateListingRequest)
+	listing, err := h.service.UpdateListing(ctx, userInfo, listingID, &updateListingRequest)
 	if err != nil {
 		slog.WarnContext(ctx, "Failed to update listing", "error", err)
 		errors.RespondError(w, r, err)
 		return
 	}
 
-	json.Write(w, http.StatusOK, nil)
+	json.Write(w, http.StatusOK, listing)
+}
+
+// PatchListing edits a listing with an RFC 7396 merge patch, the only way to clear a field that PUT can't
+func (h *ListingsHandler) PatchListing(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	listingID := chi.URLParam(r, "id")
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	var body stdjson.RawMessage
+	if err := json.Read(r, &body); err != nil {
+		slog.WarnContext(ctx, "Invalid request body", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "A merge patch must be a JSON object", err))
+		return
+	}
+	patch, appErr := ParseListingPatch(body)
+	if appErr != nil {
+		slog.WarnContext(ctx, "Invalid listing patch", "listing_id", listingID, "error", appErr)
+		errors.RespondError(w, r, appErr)
+		return
+	}
+
+	slog.DebugContext(ctx, "Patching listing", "user_id", userInfo.ID, "listing_id", listingID)
+	if _, err := h.service.PatchListing(ctx, userInfo, listingID, patch); err != nil {
+		slog.WarnContext(ctx, "Failed to patch listing", "error", err)
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusNoContent, nil)
 }
 
 // Unauthorized API so we need to make sure there is an API Key check and there is a rate limiter in front of this
@@ -139,6 +225,16 @@ func (h *ListingsHandler) GetListingByID(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	switch preview := r.URL.Query().Get("preview"); preview {
+	case "":
+	case "buyer":
+		h.previewAsBuyer(w, r, listingID)
+		return
+	default:
+		errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "preview must be 'buyer'", fmt.Errorf("unknown preview %q", preview)))
+		return
+	}
+
 	slog.DebugContext(ctx, "Fetching listing by ID", "listing_id", listingID)
 
 	listing, err := h.service.GetListingByID(ctx, listingID)
@@ -148,6 +244,455 @@ func (h *ListingsHandler) GetListingByID(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Buffered in Redis and flushed by the worker, a failure here should never fail the page
+	if err := h.counters.Incr(ctx, listing.ID, counters.Views); err != nil {
+		slog.WarnContext(ctx, "Failed to record listing view", "listing_id", listing.ID, "error", err)
+	}
+	// Signed out callers see millimetres
+	if userInfo, err := auth.GetUserInfo(ctx); err == nil {
+		listing.showDimensionsIn(userInfo.DimensionUnit)
+	}
+	h.showPrice(r, listing)
+
+	json.Write(w, http.StatusOK, listing)
+}
+
+// previewAsBuyer serves GET /listings/{id}?preview=buyer to the listing's seller. The seller looking isn't a buyer's
+// view, so it isn't counted, and the response is theirs alone.
+func (h *ListingsHandler) previewAsBuyer(w http.ResponseWriter, r *http.Request, listingID string) {
+	ctx := r.Context()
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Sign in to preview your listing", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	listing, err := h.service.PreviewListingAsBuyer(ctx, userInfo, listingID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to preview listing as a buyer", "listing_id", listingID, "error", err)
+		errors.RespondError(w, r, err)
+		return
+	}
+	listing.showDimensionsIn(userInfo.DimensionUnit)
+	h.showPrice(r, listing)
+
+	w.Header().Set("Cache-Control", "private, no-store")
+	json.Write(w, http.StatusOK, listing)
+}
+
+// HydrateListing serves POST /listings/hydrate, the listing a search hit came from. It stands in for
+// GET /listings/{id} when a hit is opened, so it counts the view the same way.
+func (h *ListingsHandler) HydrateListing(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req := HydrateListingRequest{}
+	if err := json.Read(r, &req); err != nil {
+		slog.WarnContext(ctx, "Invalid request body", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Input provided was not in the format expected. Please contact support if this error persists.", err))
+		return
+	}
+
+	listing, err := h.service.HydrateListing(ctx, &req)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to hydrate listing", "listing_id", req.ID, "error", err)
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	if err := h.counters.Incr(ctx, listing.ID, counters.Views); err != nil {
+		slog.WarnContext(ctx, "Failed to record listing view", "listing_id", listing.ID, "error", err)
+	}
+
+	json.Write(w, http.StatusOK, listing)
+}
+
+// GetRemixTree serves GET /listings/{id}/remixes, the listing and every generation of remixes made from it
+func (h *ListingsHandler) GetRemixTree(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	listingID := chi.URLParam(r, "id")
+
+	tree, err := h.service.GetRemixTree(ctx, listingID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to fetch remix tree", "listing_id", listingID, "error", err)
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, tree)
+}
+
+// GetValidationRules serves GET /listings/validation-rules, the rules CreateListing checks so the form can check them
+// first. They only change with a deploy, so browsers may cache them for a while.
+func (h *ListingsHandler) GetValidationRules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.Write(w, http.StatusOK, h.service.GetValidationRules())
+}
+
+// GetMaterials serves GET /materials, the canonical materials the listing form offers. Submissions are matched on
+// names and aliases, so the aliases are there for the form's autocomplete. Changes only with a deploy.
+func (h *ListingsHandler) GetMaterials(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.Write(w, http.StatusOK, MaterialsResponse{Materials: materials.List()})
+}
+
+// GetFileDownload serves GET /listings/{id}/files/{fileId}/download. Authentication is optional, anonymous callers
+// only get the files of free listings.
+func (h *ListingsHandler) GetFileDownload(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	listingID := chi.URLParam(r, "id")
+	fileID := chi.URLParam(r, "fileId")
+
+	// No user is fine here, the service decides whether the listing needs one
+	userInfo, _ := auth.GetUserInfo(ctx)
+
+	// Only a hint, the service caps it at the configured maximum for the file type
+	preferLongTTL := r.URL.Query().Get("prefer_long_ttl") == "true"
+
+	download, err := h.service.GetFileDownload(ctx, userInfo, listingID, fileID, preferLongTTL)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to create file download", "listing_id", listingID, "file_id", fileID, "error", err)
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	h.recordDownload(r, userInfo, listingID, fileID, download)
+	json.Write(w, http.StatusOK, download)
+}
+
+// GetDownloadHistory serves GET /me/downloads, the listings the user downloaded files from, most recent first
+func (h *ListingsHandler) GetDownloadHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	query := r.URL.Query()
+	limit := DownloadHistoryDefaultLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > DownloadHistoryMaxLimit {
+			errors.RespondError(w, r, invalidDownloadHistoryQuery("limit", v))
+			return
+		}
+		limit = n
+	}
+
+	page, err := h.service.GetDownloadHistory(ctx, userInfo, query.Get("cursor"), limit)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to get download history", "user_id", userInfo.ID, "error", err)
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, page)
+}
+
+// DownloadAgain serves GET /me/downloads/{id}/files/{fileId}/download, a fresh link to a file in the user's history
+func (h *ListingsHandler) DownloadAgain(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	listingID := chi.URLParam(r, "id")
+	fileID := chi.URLParam(r, "fileId")
+
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	preferLongTTL := r.URL.Query().Get("prefer_long_ttl") == "true"
+
+	download, err := h.service.DownloadAgain(ctx, userInfo, listingID, fileID, preferLongTTL)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to download file again", "listing_id", listingID, "file_id", fileID, "error", err)
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	h.recordDownload(r, userInfo, listingID, fileID, download)
+	json.Write(w, http.StatusOK, download)
+}
+
+// recordDownload queues the receipt for a presigned download, public files are linked from the listing page and
+// aren't counted. Like views, a failure here should never fail the download.
+func (h *ListingsHandler) recordDownload(r *http.Request, userInfo auth.UserInfo, listingID, fileID string, download *FileDownloadResponse) {
+	if download.ExpiresAt == nil {
+		return
+	}
+	ctx := r.Context()
+	receipt := counters.Download{
+		ID:           uuid.NewString(),
+		UserID:       userInfo.ID,
+		ListingID:    listingID,
+		FileID:       fileID,
+		DownloadedAt: time.Now().UTC(),
+		ExpiresAt:    *download.ExpiresAt,
+	}
+	if err := h.counters.RecordDownload(ctx, receipt); err != nil {
+		slog.WarnContext(ctx, "Failed to record download", "listing_id", listingID, "file_id", fileID, "error", err)
+	}
+}
+
+func (h *ListingsHandler) GetStatusHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	listingID := chi.URLParam(r, "id")
+
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	history, err := h.service.GetStatusHistory(ctx, userInfo, listingID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to get status history", "listing_id", listingID, "error", err)
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, history)
+}
+
+// CreateVariant serves POST /listings/{id}/variants for the listing's seller
+func (h *ListingsHandler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	listingID := chi.URLParam(r, "id")
+
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	variantRequest := CreateVariantRequest{}
+	if err := json.Read(r, &variantRequest); err != nil {
+		slog.WarnContext(ctx, "Invalid request body", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Input provided was not in the format expected. Please contact support if this error persists.", err))
+		return
+	}
+
+	variant, err := h.service.CreateVariant(ctx, userInfo, listingID, &variantRequest)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to create variant", "listing_id", listingID, "error", err)
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusCreated, variant)
+}
+
+// DeleteVariant serves DELETE /listings/{id}/variants/{variantId} for the listing's seller
+func (h *ListingsHandler) DeleteVariant(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	listingID := chi.URLParam(r, "id")
+	variantID := chi.URLParam(r, "variantId")
+
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	if err := h.service.DeleteVariant(ctx, userInfo, listingID, variantID); err != nil {
+		slog.WarnContext(ctx, "Failed to delete variant", "listing_id", listingID, "variant_id", variantID, "error", err)
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusNoContent, nil)
+}
+
+// ApplyValidationResult serves POST /internal/files/{id}/validation-result for the validation worker
+func (h *ListingsHandler) ApplyValidationResult(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	fileID := chi.URLParam(r, "id")
+
+	result := FileValidationResult{}
+	if err := json.Read(r, &result); err != nil {
+		slog.WarnContext(ctx, "Invalid request body", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Input provided was not in the format expected. Please contact support if this error persists.", err))
+		return
+	}
+
+	response, err := h.service.ApplyValidationResult(ctx, fileID, &result)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to apply validation result", "file_id", fileID, "error", err)
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, response)
+}
+
+// ListAdminListings serves GET /admin/listings, every listing for moderators with filters and a cursor, or the whole
+// filter as CSV with format=csv. index_failed=true lists the listings the worker gave up putting in search instead.
+// It's mounted behind auth.RequireRole for moderators and admins.
+func (h *ListingsHandler) ListAdminListings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+	if query.Get("index_failed") == "true" {
+		failures, err := h.service.ListIndexFailures(ctx)
+		if err != nil {
+			errors.RespondError(w, r, err)
+			return
+		}
+		json.Write(w, http.StatusOK, failures)
+		return
+	}
+
+	filter, appErr := ParseAdminListingsFilter(query)
+	if appErr != nil {
+		errors.RespondError(w, r, appErr)
+		return
+	}
+
+	switch format := query.Get("format"); format {
+	case "", "json":
+		page, err := h.service.ListAdminListings(ctx, filter)
+		if err != nil {
+			errors.RespondError(w, r, err)
+			return
+		}
+		json.Write(w, http.StatusOK, page)
+	case "csv":
+		h.exportAdminListings(w, r, filter)
+	default:
+		errors.RespondError(w, r, invalidAdminFilter("format", format))
+	}
+}
+
+var adminCSVHeader = []string{"id", "title", "seller_id", "seller_username", "status", "categories", "is_nsfw", "price_min_unit", "currency", "report_count", "created_at", "deleted_at"}
+
+// exportAdminListings streams the filter as CSV. The header goes out with the first row, so an error before then is
+// still a normal error response. After that the status is sent, an error can only cut the file short.
+func (h *ListingsHandler) exportAdminListings(w http.ResponseWriter, r *http.Request, filter AdminListingsFilter) {
+	ctx := r.Context()
+	out := csv.NewWriter(w)
+	flusher, _ := w.(http.Flusher)
+	rows := 0
+
+	writeHeader := func() error {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="listings.csv"`)
+		w.WriteHeader(http.StatusOK)
+		return out.Write(adminCSVHeader)
+	}
+
+	err := h.service.ExportAdminListings(ctx, filter, func(l AdminListing) error {
+		if rows == 0 {
+			if err := writeHeader(); err != nil {
+				return err
+			}
+		}
+		deletedAt := ""
+		if l.DeletedAt != nil {
+			deletedAt = l.DeletedAt.Format(time.RFC3339)
+		}
+		if err := out.Write([]string{
+			l.ID, l.Title, l.SellerID, l.SellerUsername, l.Status, strings.Join(l.Categories, ";"),
+			strconv.FormatBool(l.IsNSFW), strconv.FormatInt(l.PriceMinUnit, 10), l.Currency,
+			strconv.FormatInt(l.ReportCount, 10), l.CreatedAt.Format(time.RFC3339), deletedAt,
+		}); err != nil {
+			return err
+		}
+		rows++
+		if rows%adminExportPageSize == 0 {
+			out.Flush()
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return out.Error()
+	})
+
+	switch {
+	case err != nil && rows == 0:
+		errors.RespondError(w, r, err)
+		return
+	case err != nil:
+		slog.ErrorContext(ctx, "Admin listings export cut short", "rows", rows, "error", err)
+	case rows == 0:
+		if err := writeHeader(); err != nil {
+			slog.ErrorContext(ctx, "Failed to write admin listings export", "error", err)
+		}
+	}
+	out.Flush()
+}
+
+// GetAdminListing serves GET /admin/listings/{id}, any listing including soft-deleted ones. The route group checks
+// the moderator or admin role.
+func (h *ListingsHandler) GetAdminListing(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	listingID := chi.URLParam(r, "id")
+
+	listing, err := h.service.GetAdminListing(ctx, listingID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to get listing for moderation", "listing_id", listingID, "error", err)
+		errors.RespondError(w, r, err)
+		return
+	}
+
 	json.Write(w, http.StatusOK, listing)
+}
+
+// SuspendListing serves PUT /admin/listings/{id}/suspension, taking the listing down until a moderator says otherwise
+func (h *ListingsHandler) SuspendListing(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	listingID := chi.URLParam(r, "id")
+
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	suspendRequest := SuspendListingRequest{}
+	if err := json.Read(r, &suspendRequest); err != nil {
+		slog.WarnContext(ctx, "Invalid request body", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Input provided was not in the format expected. Please contact support if this error persists.", err))
+		return
+	}
+
+	if err := h.service.SuspendListing(ctx, userInfo, listingID, &suspendRequest); err != nil {
+		slog.WarnContext(ctx, "Failed to suspend listing", "listing_id", listingID, "error", err)
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetListingNSFW serves PUT /admin/listings/{id}/nsfw, overriding the seller's NSFW flag
+func (h *ListingsHandler) SetListingNSFW(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	listingID := chi.URLParam(r, "id")
+
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	nsfwRequest := SetListingNSFWRequest{}
+	if err := json.Read(r, &nsfwRequest); err != nil || nsfwRequest.IsNSFW == nil {
+		slog.WarnContext(ctx, "Invalid request body", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "is_nsfw must be true or false", err))
+		return
+	}
+
+	if err := h.service.SetListingNSFW(ctx, userInfo, listingID, *nsfwRequest.IsNSFW); err != nil {
+		slog.WarnContext(ctx, "Failed to set listing NSFW flag", "listing_id", listingID, "error", err)
+		errors.RespondError(w, r, err)
+		return
+	}
 
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,384 @@
+package listings_test
+
+import (
+	"context"
+	"encoding/json"
+	"gateway/internal/auth"
+	"gateway/internal/counters"
+	"gateway/internal/currency"
+	"gateway/internal/errors"
+	"gateway/internal/handlers/listings"
+	"gateway/internal/mocks/mockcounters"
+	"gateway/internal/mocks/mocklistings"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const listingID = "550e8400-e29b-41d4-a716-446655440000"
+
+func getListing(h *listings.ListingsHandler) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Get("/listings/{id}", h.GetListingByID)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/listings/"+listingID, nil))
+	return w
+}
+
+func TestGetListingByID_CountsView(t *testing.T) {
+	svc := mocklistings.NewListingsService(t)
+	recorder := mockcounters.NewRecorder(t)
+
+	svc.EXPECT().GetListingByID(mock.Anything, listingID).Return(&listings.ListingResponse{ID: listingID, Title: "Benchy"}, nil)
+	recorder.EXPECT().Incr(mock.Anything, listingID, counters.Views).Return(nil)
+
+	w := getListing(listings.NewListingsHandler(svc, recorder, nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body listings.ListingResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "Benchy", body.Title)
+}
+
+func TestGetListingByID_CounterFailure_StillServes(t *testing.T) {
+	svc := mocklistings.NewListingsService(t)
+	recorder := mockcounters.NewRecorder(t)
+
+	svc.EXPECT().GetListingByID(mock.Anything, listingID).Return(&listings.ListingResponse{ID: listingID}, nil)
+	recorder.EXPECT().Incr(mock.Anything, listingID, counters.Views).Return(context.DeadlineExceeded)
+
+	w := getListing(listings.NewListingsHandler(svc, recorder, nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestGetListingByID_NotFound_NoView(t *testing.T) {
+	// The recorder has no expectations, so any Incr call fails the test
+	svc := mocklistings.NewListingsService(t)
+	recorder := mockcounters.NewRecorder(t)
+
+	svc.EXPECT().GetListingByID(mock.Anything, listingID).Return(nil, errors.New(errors.ErrNotFound, "Listing not found", nil))
+
+	w := getListing(listings.NewListingsHandler(svc, recorder, nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetListingByID_DimensionsInPreferredUnit(t *testing.T) {
+	// SCENARIO: A buyer who set their sizes to inches opens a physical listing, then a signed out visitor does.
+	// EXPECT: The buyer reads the size in inches, the visitor in millimetres, the mm fields are the same for both.
+	tests := []struct {
+		name string
+		user *auth.UserInfo
+		want string
+	}{
+		{name: "Prefers inches", user: &auth.UserInfo{ID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", DimensionUnit: "in"}, want: "5 × 2 × 1 in"},
+		{name: "Prefers millimetres", user: &auth.UserInfo{ID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", DimensionUnit: "mm"}, want: "127 × 51 × 26 mm"},
+		{name: "Signed out", want: "127 × 51 × 26 mm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocklistings.NewListingsService(t)
+			recorder := mockcounters.NewRecorder(t)
+			x, y, z := 127, 51, 26
+			svc.EXPECT().GetListingByID(mock.Anything, listingID).Return(&listings.ListingResponse{
+				ID: listingID, DimXMM: &x, DimYMM: &y, DimZMM: &z, DimensionsDisplay: "127 × 51 × 26 mm",
+			}, nil)
+			recorder.EXPECT().Incr(mock.Anything, listingID, counters.Views).Return(nil)
+
+			r := chi.NewRouter()
+			r.Get("/listings/{id}", listings.NewListingsHandler(svc, recorder, nil).GetListingByID)
+			req := httptest.NewRequest("GET", "/listings/"+listingID, nil)
+			if tt.user != nil {
+				req = req.WithContext(auth.WithUserInfo(req.Context(), *tt.user))
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			var body listings.ListingResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.want, body.DimensionsDisplay)
+			assert.Equal(t, 127, *body.DimXMM)
+		})
+	}
+}
+
+// fixedRates is a currency.RatesSource that always has the same rates, or none
+type fixedRates struct {
+	rates currency.Rates
+	ok    bool
+}
+
+func (f fixedRates) Current(ctx context.Context) (currency.Rates, bool) {
+	return f.rates, f.ok
+}
+
+func TestGetListingByID_DisplayCurrency(t *testing.T) {
+	// SCENARIO: A $10.50 listing is opened by buyers browsing in other currencies, with and without fresh rates.
+	// EXPECT: The query param wins over the token claim, the converted price is only added when there's something to
+	// convert and a rate to do it with, and price_min_unit is untouched.
+	rates := fixedRates{ok: true, rates: currency.Rates{
+		Base:  "eur",
+		Date:  time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC),
+		Rates: map[string]float64{"eur": 1, "usd": 1.25, "gbp": 0.8, "jpy": 160},
+	}}
+	inGBP, inJPY := int64(672), int64(1344)
+	tests := []struct {
+		name     string
+		query    string
+		user     *auth.UserInfo
+		rates    fixedRates
+		price    int64
+		want     *int64
+		wantCode string
+	}{
+		{name: "Query param", query: "?display_currency=GBP", rates: rates, price: 1050, want: &inGBP, wantCode: "gbp"},
+		{name: "Preference", user: &auth.UserInfo{ID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", DisplayCurrency: "jpy"}, rates: rates, price: 1050, want: &inJPY, wantCode: "jpy"},
+		{name: "Query param over preference", query: "?display_currency=gbp", user: &auth.UserInfo{ID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", DisplayCurrency: "jpy"}, rates: rates, price: 1050, want: &inGBP, wantCode: "gbp"},
+		{name: "Not asked for", rates: rates, price: 1050},
+		{name: "Same currency", query: "?display_currency=usd", rates: rates, price: 1050},
+		{name: "Free", query: "?display_currency=gbp", rates: rates, price: 0},
+		{name: "No rate for the currency", query: "?display_currency=chf", rates: rates, price: 1050},
+		{name: "Stale or missing rates", query: "?display_currency=gbp", rates: fixedRates{}, price: 1050},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := mocklistings.NewListingsService(t)
+			recorder := mockcounters.NewRecorder(t)
+			svc.EXPECT().GetListingByID(mock.Anything, listingID).Return(&listings.ListingResponse{ID: listingID, PriceMinUnit: tt.price, Currency: "usd"}, nil)
+			recorder.EXPECT().Incr(mock.Anything, listingID, counters.Views).Return(nil)
+
+			r := chi.NewRouter()
+			r.Get("/listings/{id}", listings.NewListingsHandler(svc, recorder, tt.rates).GetListingByID)
+			req := httptest.NewRequest("GET", "/listings/"+listingID+tt.query, nil)
+			if tt.user != nil {
+				req = req.WithContext(auth.WithUserInfo(req.Context(), *tt.user))
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			var body listings.ListingResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.want, body.PriceConverted)
+			assert.Equal(t, tt.wantCode, body.ConvertedCurrency)
+			assert.Equal(t, tt.price, body.PriceMinUnit)
+			if tt.want != nil {
+				assert.Equal(t, "2026-10-15", body.ConvertedRateDate)
+			}
+		})
+	}
+}
+
+func TestGetListingByID_DisplayCurrencySale(t *testing.T) {
+	// SCENARIO: A $10.50 listing on sale for $8.40 is opened by a buyer browsing in pounds.
+	// EXPECT: The sale price is converted at the same rate as the price.
+	rates := fixedRates{ok: true, rates: currency.Rates{
+		Base:  "eur",
+		Date:  time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC),
+		Rates: map[string]float64{"eur": 1, "usd": 1.25, "gbp": 0.8},
+	}}
+	salePrice := int64(840)
+
+	svc := mocklistings.NewListingsService(t)
+	recorder := mockcounters.NewRecorder(t)
+	svc.EXPECT().GetListingByID(mock.Anything, listingID).Return(&listings.ListingResponse{ID: listingID, PriceMinUnit: 1050, Currency: "usd", IsSaleActive: true, SalePriceMinUnit: &salePrice}, nil)
+	recorder.EXPECT().Incr(mock.Anything, listingID, counters.Views).Return(nil)
+
+	r := chi.NewRouter()
+	r.Get("/listings/{id}", listings.NewListingsHandler(svc, recorder, rates).GetListingByID)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/listings/"+listingID+"?display_currency=gbp", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body listings.ListingResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.NotNil(t, body.PriceConverted)
+	assert.Equal(t, int64(672), *body.PriceConverted)
+	require.NotNil(t, body.SalePriceConverted)
+	assert.Equal(t, int64(538), *body.SalePriceConverted)
+	assert.Equal(t, int64(840), *body.SalePriceMinUnit)
+}
+
+func TestListAdminListings(t *testing.T) {
+	moderator := auth.UserInfo{ID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", Roles: []string{auth.RoleModerator}}
+
+	tests := map[string]struct {
+		user       auth.UserInfo
+		query      string
+		wantStatus int
+	}{
+		"moderator":            {user: moderator, query: "?index_failed=true", wantStatus: http.StatusOK},
+		"unknown filter value": {user: moderator, query: "?status=GONE", wantStatus: http.StatusBadRequest},
+		"unknown format":       {user: moderator, query: "?format=xml", wantStatus: http.StatusBadRequest},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			svc := mocklistings.NewListingsService(t)
+			if tt.wantStatus == http.StatusOK {
+				svc.EXPECT().ListIndexFailures(mock.Anything).Return([]listings.IndexFailedListing{{ListingID: listingID, ErrorClass: "search"}}, nil)
+			}
+
+			req := httptest.NewRequest("GET", "/admin/listings"+tt.query, nil)
+			req = req.WithContext(auth.WithUserInfo(req.Context(), tt.user))
+			w := httptest.NewRecorder()
+			listings.NewListingsHandler(svc, mockcounters.NewRecorder(t), nil).ListAdminListings(w, req)
+
+			require.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				var body []listings.IndexFailedListing
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, listingID, body[0].ListingID)
+			}
+		})
+	}
+}
+
+func getAdminListings(t *testing.T, svc *mocklistings.ListingsService, query string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/admin/listings"+query, nil)
+	req = req.WithContext(auth.WithUserInfo(req.Context(), auth.UserInfo{ID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", Roles: []string{auth.RoleAdmin}}))
+	w := httptest.NewRecorder()
+	listings.NewListingsHandler(svc, mockcounters.NewRecorder(t), nil).ListAdminListings(w, req)
+	return w
+}
+
+func TestListAdminListings_Page(t *testing.T) {
+	svc := mocklistings.NewListingsService(t)
+	nsfw := true
+	svc.EXPECT().ListAdminListings(mock.Anything, mock.MatchedBy(func(f listings.AdminListingsFilter) bool {
+		return f.IsNSFW != nil && *f.IsNSFW == nsfw && f.Cursor == "abc" && f.Limit == 10
+	})).Return(&listings.AdminListingsPage{Listings: []listings.AdminListing{{ID: listingID}}}, nil)
+
+	w := getAdminListings(t, svc, "?nsfw=true&cursor=abc&limit=10")
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"listings": [{"id": "`+listingID+`", "title": "", "seller_id": "", "seller_username": "", "status": "",
+		"categories": null, "is_nsfw": false, "price_min_unit": 0, "currency": "", "report_count": 0, "flagged_terms": null,
+		"created_at": "0001-01-01T00:00:00Z", "deleted_at": null}], "next_cursor": null}`, w.Body.String())
+}
+
+func TestListAdminListings_CSV(t *testing.T) {
+	created := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	deleted := created.Add(time.Hour)
+
+	t.Run("Streams every row with a header", func(t *testing.T) {
+		svc := mocklistings.NewListingsService(t)
+		svc.EXPECT().ExportAdminListings(mock.Anything, mock.Anything, mock.Anything).
+			RunAndReturn(func(_ context.Context, _ listings.AdminListingsFilter, each func(listings.AdminListing) error) error {
+				require.NoError(t, each(listings.AdminListing{ID: "1", Title: "Benchy, the boat", Status: "ACTIVE", Categories: []string{"Art", "Toys"}, PriceMinUnit: 1050, Currency: "gbp", ReportCount: 2, CreatedAt: created}))
+				return each(listings.AdminListing{ID: "2", Title: "Vase", Status: "HIDDEN", IsNSFW: true, CreatedAt: created, DeletedAt: &deleted})
+			})
+
+		w := getAdminListings(t, svc, "?format=csv")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "id,title,seller_id,seller_username,status,categories,is_nsfw,price_min_unit,currency,report_count,created_at,deleted_at\n"+
+			"1,\"Benchy, the boat\",,,ACTIVE,Art;Toys,false,1050,gbp,2,2026-10-01T12:00:00Z,\n"+
+			"2,Vase,,,HIDDEN,,true,0,,0,2026-10-01T12:00:00Z,2026-10-01T13:00:00Z\n", w.Body.String())
+	})
+
+	t.Run("Nothing matches, still a header", func(t *testing.T) {
+		svc := mocklistings.NewListingsService(t)
+		svc.EXPECT().ExportAdminListings(mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		w := getAdminListings(t, svc, "?format=csv&status=REJECTED")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "id,title,seller_id,seller_username,status,categories,is_nsfw,price_min_unit,currency,report_count,created_at,deleted_at\n", w.Body.String())
+	})
+
+	t.Run("Error before the first row is a normal error", func(t *testing.T) {
+		svc := mocklistings.NewListingsService(t)
+		svc.EXPECT().ExportAdminListings(mock.Anything, mock.Anything, mock.Anything).Return(errors.New(errors.ErrInternal, "Failed to export listings", nil))
+
+		w := getAdminListings(t, svc, "?format=csv")
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.NotEqual(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	})
+}
+
+func TestGetValidationRules(t *testing.T) {
+	svc := mocklistings.NewListingsService(t)
+	rules, err := listings.LoadValidationRules([]byte(`{"overrides": [{"role": "verified_seller", "maxFiles": 20}]}`))
+	require.NoError(t, err)
+	svc.EXPECT().GetValidationRules().Return(rules)
+
+	w := httptest.NewRecorder()
+	listings.NewListingsHandler(svc, mockcounters.NewRecorder(t), nil).GetValidationRules(w, httptest.NewRequest("GET", "/listings/validation-rules", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body listings.ValidationRuleSet
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, listings.DefaultValidationRules(), body.Default)
+	require.Len(t, body.Overrides, 1)
+	assert.Equal(t, listings.RoleVerifiedSeller, body.Overrides[0].Role)
+	assert.Equal(t, 20, body.Overrides[0].Rules.MaxFiles)
+}
+
+func TestHydrateListing_TakesSearchHit(t *testing.T) {
+	// SCENARIO: The frontend posts a search hit's document as it came from Typesense.
+	// EXPECT: Only the ID and hash reach the service, and the view is counted like an opened listing.
+
+	svc := mocklistings.NewListingsService(t)
+	recorder := mockcounters.NewRecorder(t)
+
+	want := &listings.HydrateListingRequest{ID: listingID, ContentHash: "b1356dd0fc1f50463080b15f11abe682"}
+	svc.EXPECT().HydrateListing(mock.Anything, want).Return(&listings.ListingResponse{ID: listingID, Title: "Benchy"}, nil)
+	recorder.EXPECT().Incr(mock.Anything, listingID, counters.Views).Return(nil)
+
+	hit := `{"id": "` + listingID + `", "title": "Free Benchy", "price_min_unit": 0, "content_hash": "b1356dd0fc1f50463080b15f11abe682"}`
+	w := httptest.NewRecorder()
+	listings.NewListingsHandler(svc, recorder, nil).HydrateListing(w, httptest.NewRequest("POST", "/listings/hydrate", strings.NewReader(hit)))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body listings.ListingResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "Benchy", body.Title)
+}
+
+func TestUpdateListings_Warnings(t *testing.T) {
+	// SCENARIO: A seller's PUT edit saves but leaves their physical listing without dimensions.
+	// EXPECT: Still a 200, with the saved listing's fields and the warning side by side in the body.
+
+	svc := mocklistings.NewListingsService(t)
+	response := &listings.UpdateListingResponse{Warnings: []listings.ListingWarning{{
+		Field:   "dimensions",
+		Reason:  errors.ReasonListingDimensionsMissing,
+		Message: "Add the dimensions so buyers can check it fits their printer",
+		Params:  map[string]string{},
+	}}}
+	response.Title = "Benchy"
+	svc.EXPECT().UpdateListing(mock.Anything, mock.Anything, listingID, mock.Anything).Return(response, nil)
+
+	r := chi.NewRouter()
+	r.Put("/listings/{id}", listings.NewListingsHandler(svc, mockcounters.NewRecorder(t), nil).UpdateListings)
+	req := httptest.NewRequest("PUT", "/listings/"+listingID, strings.NewReader(`{"title": "Benchy"}`))
+	req = req.WithContext(auth.WithUserInfo(req.Context(), auth.UserInfo{ID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"}))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "Benchy", body["title"])
+	assert.Equal(t, []any{map[string]any{
+		"field":   "dimensions",
+		"reason":  "LISTING_DIMENSIONS_MISSING",
+		"message": "Add the dimensions so buyers can check it fits their printer",
+		"params":  map[string]any{},
+	}}, body["warnings"])
+}
@@ -0,0 +1,54 @@
+package listings
+
+import (
+	"context"
+	"strings"
+)
+
+// HardwareVocabulary is the curated hardware list, hardware.HardwareService implements it
+type HardwareVocabulary interface {
+	Canonicalize(ctx context.Context, entries []string) ([]string, error)
+	Normalize(ctx context.Context, entries []string) []string
+}
+
+// updatedHardware decides what an edit stores in hardware_required. Entries the seller added have to be in the
+// vocabulary. Entries already stored may have been saved before it was enforced, so like the rest they're only
+// normalized and a listing with one the vocabulary doesn't know can still be edited.
+func (s *svc) updatedHardware(ctx context.Context, patch *ListingPatch, stored, hardware []string) ([]string, error) {
+	if s.hardware == nil {
+		return hardware, nil
+	}
+	if !patch.HardwareRequired.Set || patch.HardwareRequired.Null {
+		return s.hardware.Normalize(ctx, hardware), nil
+	}
+
+	kept := make([]string, 0, len(hardware))
+	var added []string
+	for _, entry := range hardware {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		if spelling, ok := storedHardware(stored, entry); ok {
+			kept = append(kept, spelling)
+			continue
+		}
+		kept = append(kept, entry)
+		added = append(added, entry)
+	}
+	if _, err := s.hardware.Canonicalize(ctx, added); err != nil {
+		return nil, err
+	}
+	return s.hardware.Normalize(ctx, kept), nil
+}
+
+// storedHardware finds entry in the stored hardware, ignoring case and spacing the way the vocabulary does, and
+// returns it spelled as it was stored
+func storedHardware(stored []string, entry string) (string, bool) {
+	entry = strings.Join(strings.Fields(entry), " ")
+	for _, h := range stored {
+		if strings.EqualFold(strings.Join(strings.Fields(h), " "), entry) {
+			return h, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,103 @@
+package listings
+
+import (
+	"context"
+	"gateway/internal/errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubVocabulary knows "M3 screw" in any case and refuses everything else
+type stubVocabulary struct{}
+
+func (stubVocabulary) Canonicalize(_ context.Context, entries []string) ([]string, error) {
+	canonical := []string{}
+	for _, entry := range entries {
+		if !strings.EqualFold(entry, "m3 screw") {
+			return nil, errors.New(errors.ErrInvalidInput, "unknown", nil).WithReason(errors.ReasonListingHardwareUnknown)
+		}
+		canonical = append(canonical, "M3 screw")
+	}
+	return canonical, nil
+}
+
+func (stubVocabulary) Normalize(_ context.Context, entries []string) []string {
+	normalized := []string{}
+	for _, entry := range entries {
+		if strings.EqualFold(entry, "m3 screw") {
+			entry = "M3 screw"
+		}
+		normalized = append(normalized, entry)
+	}
+	return normalized
+}
+
+func TestUpdatedHardware(t *testing.T) {
+	service := &svc{hardware: stubVocabulary{}}
+	legacy := []string{"m3 screw", "M3x8 bolt"}
+
+	t.Run("Untouched values are only normalized", func(t *testing.T) {
+		got, err := service.updatedHardware(context.Background(), &ListingPatch{}, legacy, legacy)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"M3 screw", "M3x8 bolt"}, got)
+	})
+
+	t.Run("New values are checked", func(t *testing.T) {
+		req := &UpdateListingRequest{PrinterSettings: &UpdateListingPrinterSettings{HardwareRequired: &[]string{"m3 SCREW"}}}
+		got, err := service.updatedHardware(context.Background(), req.Patch(), nil, []string{"m3 SCREW"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"M3 screw"}, got)
+	})
+
+	t.Run("Resending a legacy value is allowed", func(t *testing.T) {
+		hardware := []string{"m3 screw", " m3X8  bolt ", ""}
+		req := &UpdateListingRequest{PrinterSettings: &UpdateListingPrinterSettings{HardwareRequired: &hardware}}
+		got, err := service.updatedHardware(context.Background(), req.Patch(), legacy, hardware)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"M3 screw", "M3x8 bolt"}, got)
+	})
+
+	t.Run("Adding an unknown value next to a legacy one is refused", func(t *testing.T) {
+		hardware := []string{"M3x8 bolt", "M4 nut"}
+		req := &UpdateListingRequest{PrinterSettings: &UpdateListingPrinterSettings{HardwareRequired: &hardware}}
+		_, err := service.updatedHardware(context.Background(), req.Patch(), legacy, hardware)
+		var appErr *errors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, errors.ReasonListingHardwareUnknown, appErr.Reason)
+	})
+
+	t.Run("No vocabulary accepts anything", func(t *testing.T) {
+		req := &UpdateListingRequest{PrinterSettings: &UpdateListingPrinterSettings{HardwareRequired: &legacy}}
+		got, err := (&svc{}).updatedHardware(context.Background(), req.Patch(), nil, legacy)
+		require.NoError(t, err)
+		assert.Equal(t, legacy, got)
+	})
+}
+
+func TestSaveListingUpdate_UnknownHardware(t *testing.T) {
+	// SCENARIO: A seller adds hardware that isn't in the vocabulary.
+	// EXPECT: The edit is refused before anything is written.
+
+	service, mockPool := newUpdateTest(t)
+	service.hardware = stubVocabulary{}
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).
+		WithArgs(mustUUID(t, updateListingID)).
+		WillReturnRows(listingRows(updateSellerID, "Benchy", "public/thumb.webp", repo.ListingStatusACTIVE))
+	mockPool.ExpectRollback()
+
+	req := &UpdateListingRequest{PrinterSettings: &UpdateListingPrinterSettings{HardwareRequired: &[]string{"M3x8 bolt"}}}
+	_, err := service.saveListingUpdate(context.Background(), mustUUID(t, updateSellerID), mustUUID(t, updateListingID), req.Patch(), DefaultValidationRules())
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ReasonListingHardwareUnknown, appErr.Reason)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
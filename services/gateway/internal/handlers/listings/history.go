@@ -0,0 +1,135 @@
+package listings
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// StatusEvent is one status change in a listing's history
+type StatusEvent struct {
+	Actor      string    `json:"actor"`       // SYSTEM, USER or MODERATOR
+	FromStatus *string   `json:"from_status"` // Nil for the event that created the listing
+	ToStatus   string    `json:"to_status"`
+	Reason     *string   `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// failureStatuses get the reason for their latest transition in ListingResponse, so the seller can see what went wrong
+var failureStatuses = map[repo.ListingStatus]bool{
+	repo.ListingStatusREJECTED:  true,
+	repo.ListingStatusHIDDEN:    true,
+	repo.ListingStatusSUSPENDED: true,
+}
+
+// statusChange is a single transition to record. Leave From unset when the listing is being created.
+type statusChange struct {
+	ListingID pgtype.UUID
+	Actor     repo.ListingStatusActor
+	ActorID   string // Keycloak user, empty for SYSTEM
+	From      *repo.ListingStatus
+	To        repo.ListingStatus
+	Reason    string
+
+	// The OAuth client (azp) and trace of the request, only recorded when the listing is created. The listings worker
+	// clears them once they're older than its trace retention window.
+	ClientID string
+	TraceID  string
+}
+
+// recordStatusChange writes a history entry. q must be bound to the transaction that changed the status,
+// otherwise the history can end up describing a change that was rolled back.
+func recordStatusChange(ctx context.Context, q *repo.Queries, change statusChange) error {
+	params := repo.CreateListingStatusEventParams{
+		ListingID: change.ListingID,
+		Actor:     change.Actor,
+		ToStatus:  change.To,
+		Reason:    pgtype.Text{String: change.Reason, Valid: change.Reason != ""},
+		ClientID:  pgtype.Text{String: change.ClientID, Valid: change.ClientID != ""},
+		TraceID:   pgtype.Text{String: change.TraceID, Valid: change.TraceID != ""},
+	}
+	if change.ActorID != "" {
+		if err := params.ActorID.Scan(change.ActorID); err != nil {
+			return fmt.Errorf("invalid actor id: %w", err)
+		}
+	}
+	if change.From != nil {
+		params.FromStatus = repo.NullListingStatus{ListingStatus: *change.From, Valid: true}
+	}
+
+	if err := q.CreateListingStatusEvent(ctx, params); err != nil {
+		return fmt.Errorf("failed to record status change: %w", err)
+	}
+	return nil
+}
+
+// CheckListingOwner is nil when the caller owns the listing, for routes that only need to know that
+func (s *svc) CheckListingOwner(ctx context.Context, userInfo auth.UserInfo, listingID string) error {
+	_, err := s.ownedListing(ctx, userInfo, listingID)
+	return err
+}
+
+// ownedListing loads a listing and refuses it unless the caller is its seller
+func (s *svc) ownedListing(ctx context.Context, userInfo auth.UserInfo, listingID string) (repo.Listing, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userInfo.ID); err != nil {
+		return repo.Listing{}, errors.New(errors.ErrInvalidInput, "Invalid user ID provided", err)
+	}
+
+	var listingUUID pgtype.UUID
+	if err := listingUUID.Scan(listingID); err != nil {
+		return repo.Listing{}, errors.New(errors.ErrInvalidInput, "Invalid listing ID provided", err)
+	}
+
+	listing, err := s.repo.GetListingByID(ctx, listingUUID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return repo.Listing{}, errors.New(errors.ErrNotFound, "Listing not found", fmt.Errorf("listing %v not found", listingID))
+		}
+		s.logger.ErrorContext(ctx, "Failed to fetch listing", "listing_id", listingID, "error", err)
+		return repo.Listing{}, errors.New(errors.ErrInternal, "Failed to fetch listing", fmt.Errorf("failed to fetch listing %v: %w", listingID, err))
+	}
+	if listing.SellerID != userUUID {
+		return repo.Listing{}, errors.New(errors.ErrUnauthorized, "You do not own this listing", fmt.Errorf("user %v doesn't own listing %v", userInfo.ID, listingID)).WithReason(errors.ReasonListingNotOwner)
+	}
+	return listing, nil
+}
+
+// GetStatusHistory returns every status change on a listing the caller owns, oldest first
+func (s *svc) GetStatusHistory(ctx context.Context, userInfo auth.UserInfo, listingID string) ([]StatusEvent, error) {
+	listing, err := s.ownedListing(ctx, userInfo, listingID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.GetListingStatusEvents(ctx, listing.ID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to fetch status history", "listing_id", listingID, "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch status history", fmt.Errorf("failed to fetch status history for %v: %w", listingID, err))
+	}
+
+	history := make([]StatusEvent, len(rows))
+	for i, row := range rows {
+		history[i] = StatusEvent{
+			Actor:     string(row.Actor),
+			ToStatus:  string(row.ToStatus),
+			CreatedAt: utc(row.CreatedAt),
+		}
+		if row.FromStatus.Valid {
+			from := string(row.FromStatus.ListingStatus)
+			history[i].FromStatus = &from
+		}
+		if row.Reason.Valid {
+			history[i].Reason = &row.Reason.String
+		}
+	}
+
+	return history, nil
+}
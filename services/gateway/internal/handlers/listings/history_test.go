@@ -0,0 +1,115 @@
+package listings
+
+import (
+	"context"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/testutil"
+	"gateway/internal/testutil/fixtures"
+	"regexp"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	historyListingID = "11111111-1111-1111-1111-111111111111"
+	historyOwnerID   = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+)
+
+var statusEventCols = []string{"id", "listing_id", "actor", "actor_id", "from_status", "to_status", "reason", "created_at"}
+
+func expectListingOwnedBy(mockPool pgxmock.PgxPoolIface, sellerID string, status string) {
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingByID :one`)).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(fixtures.ListingRows(fixtures.NewListing(
+			fixtures.WithID(historyListingID), fixtures.WithSeller(sellerID), fixtures.WithStatus(repo.ListingStatus(status)),
+		)))
+}
+
+func TestGetStatusHistory_Owner(t *testing.T) {
+	// SCENARIO: A seller asks why their listing was rejected.
+	// EXPECT: Every transition oldest first, creation has no from status, moderator identities aren't exposed.
+
+	mockPool := testutil.NewMockDB(t)
+	service := &svc{repo: repo.New(mockPool), db: mockPool, logger: testutil.NewTestLogger()}
+
+	created := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	expectListingOwnedBy(mockPool, historyOwnerID, "REJECTED")
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingStatusEvents :many`)).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(statusEventCols).
+			AddRow("22222222-2222-2222-2222-222222222222", historyListingID, "USER", historyOwnerID, nil, "PENDING_VALIDATION", reasonCreated, created).
+			AddRow("33333333-3333-3333-3333-333333333333", historyListingID, "SYSTEM", nil, "PENDING_VALIDATION", "REJECTED", "model.stl: mesh is not watertight", created.Add(time.Minute)))
+
+	history, err := service.GetStatusHistory(context.Background(), auth.UserInfo{ID: historyOwnerID}, historyListingID)
+
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	assert.Equal(t, "USER", history[0].Actor)
+	assert.Nil(t, history[0].FromStatus)
+	assert.Equal(t, "PENDING_VALIDATION", history[0].ToStatus)
+
+	assert.Equal(t, "SYSTEM", history[1].Actor)
+	require.NotNil(t, history[1].FromStatus)
+	assert.Equal(t, "PENDING_VALIDATION", *history[1].FromStatus)
+	assert.Equal(t, "REJECTED", history[1].ToStatus)
+	require.NotNil(t, history[1].Reason)
+	assert.Equal(t, "model.stl: mesh is not watertight", *history[1].Reason)
+	assert.Equal(t, created.Add(time.Minute), history[1].CreatedAt)
+
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestGetStatusHistory_NotOwner(t *testing.T) {
+	mockPool := testutil.NewMockDB(t)
+	service := &svc{repo: repo.New(mockPool), db: mockPool, logger: testutil.NewTestLogger()}
+
+	expectListingOwnedBy(mockPool, "b0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", "ACTIVE")
+
+	_, err := service.GetStatusHistory(context.Background(), auth.UserInfo{ID: historyOwnerID}, historyListingID)
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ReasonListingNotOwner, appErr.Reason)
+	// History is never read for someone else's listing
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestToListingResponse_StatusReasonOnlyForFailures(t *testing.T) {
+	service := &svc{logger: testutil.NewTestLogger()}
+	reason := pgtype.Text{String: "model.stl: mesh is not watertight", Valid: true}
+
+	tests := []struct {
+		status repo.ListingStatus
+		want   bool
+	}{
+		{repo.ListingStatusREJECTED, true},
+		{repo.ListingStatusHIDDEN, true},
+		{repo.ListingStatusACTIVE, false},
+		{repo.ListingStatusPENDINGVALIDATION, false},
+		{repo.ListingStatusPENDINGREVIEW, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			response := service.toListingResponse(context.Background(), fixtures.NewListingRow(
+				fixtures.WithStatus(tt.status), fixtures.WithStatusReason(reason.String),
+			))
+
+			if tt.want {
+				require.NotNil(t, response.StatusReason)
+				assert.Equal(t, reason.String, *response.StatusReason)
+			} else {
+				assert.Nil(t, response.StatusReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package listings
+
+import (
+	"context"
+	"fmt"
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/errors"
+	"shared/contenthash"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// HydrateListingRequest is the body of POST /listings/hydrate. A search hit's document decodes into it as it is, the
+// fields other than id and content_hash are ignored.
+type HydrateListingRequest struct {
+	ID          string `json:"id"`
+	ContentHash string `json:"content_hash"`
+}
+
+// contentHash is the hash of what a search hit shows of the listing. It can't be the row's updated_at, the worker
+// bumps that when it marks the listing indexed and when it flushes counters.
+func contentHash(row repo.GetListingByIDWithFilesRow) string {
+	content := contenthash.Listing{
+		ID:             fmt.Sprintf("%x", row.ID.Bytes),
+		Status:         string(row.Status.ListingStatus),
+		Title:          row.Title,
+		Description:    row.Description.String,
+		PriceMinUnit:   row.PriceMinUnit,
+		Currency:       row.Currency,
+		Categories:     row.Categories,
+		License:        row.License,
+		ThumbnailPath:  row.ThumbnailPath.String,
+		IsSaleActive:   row.IsSaleActive,
+		SaleName:       row.SaleName.String,
+		SellerName:     publicSellerName(row.SellerName, row.SellerUsername),
+		SellerUsername: row.SellerUsername,
+		SellerVerified: row.SellerVerified,
+		IsNSFW:         row.IsNsfw,
+		IsAIGenerated:  row.IsAiGenerated,
+		IsPhysical:     row.IsPhysical,
+	}
+	if row.SalePrice.Valid {
+		content.SalePrice = &row.SalePrice.Int64
+	}
+	if row.SaleEndTimestamp.Valid {
+		end := row.SaleEndTimestamp.Time.Unix()
+		content.SaleEnd = &end
+	}
+	return content.Sum()
+}
+
+// HydrateListing turns a search hit into the listing it came from, read like GetListingByID so the cache is used
+// whatever the hit says. The hit's content hash is only compared afterwards: edits drop the cached listing, so a hash
+// that differs is a search document that hasn't caught up yet, or one that was made up, and neither is worth a
+// database read. A missing or made up hash isn't an error.
+func (s *svc) HydrateListing(ctx context.Context, req *HydrateListingRequest) (*ListingResponse, error) {
+	if req.ID == "" {
+		return nil, errors.New(errors.ErrInvalidInput, "Listing ID is required", nil)
+	}
+	var listingUUID pgtype.UUID
+	if err := listingUUID.Scan(req.ID); err != nil {
+		return nil, errors.New(errors.ErrInvalidInput, "Invalid listing ID provided", err)
+	}
+
+	listing, err := s.GetListingByID(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if contenthash.Valid(req.ContentHash) && req.ContentHash != listing.ContentHash {
+		s.logger.DebugContext(ctx, "Search hit doesn't match the listing", "listing_id", req.ID, "hit_hash", req.ContentHash, "content_hash", listing.ContentHash)
+	}
+	return listing, nil
+}
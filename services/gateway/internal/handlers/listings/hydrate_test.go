@@ -0,0 +1,156 @@
+package listings
+
+import (
+	"context"
+	"gateway/internal/cache"
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/errors"
+	"gateway/internal/publicurl"
+	"gateway/internal/testutil"
+	"gateway/internal/testutil/apitest"
+	"gateway/internal/testutil/fixtures"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixtureContentHash is contentHash of fixtures.NewListingRow(). The listings worker's test pins the same value for
+// its document of fixtures.NewListing(), if either changes the two services no longer agree.
+const fixtureContentHash = "b1356dd0fc1f50463080b15f11abe682"
+
+func newHydrateTest(t *testing.T) (*svc, pgxmock.PgxPoolIface, *cache.RedisClient) {
+	t.Helper()
+
+	mockPool := testutil.NewMockDB(t)
+	rdb, _ := apitest.NewRedis(t)
+	urls := publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"}
+	service := &svc{
+		repo:         repo.New(mockPool),
+		db:           mockPool,
+		logger:       testutil.NewTestLogger(),
+		cache:        rdb,
+		urls:         urls,
+		listingCache: CacheNamespace(urls),
+		background:   &sync.WaitGroup{},
+		now:          time.Now,
+	}
+	return service, mockPool, rdb
+}
+
+func expectListingRead(mockPool pgxmock.PgxPoolIface, row repo.GetListingByIDWithFilesRow) {
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingByIDWithFiles`)).WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(fixtures.ListingWithFilesRows(row))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListListingVariants`)).WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(testutil.ListingVariantCols))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetSellerVacation`)).WithArgs(pgxmock.AnyArg()).
+		WillReturnError(pgx.ErrNoRows)
+}
+
+func TestContentHash_AgreesWithWorker(t *testing.T) {
+	assert.Equal(t, fixtureContentHash, contentHash(fixtures.NewListingRow()))
+
+	// The URL the thumbnail is served from isn't part of it, nor is anything that moves without the listing changing
+	assert.Equal(t, fixtureContentHash, contentHash(fixtures.NewListingRow(fixtures.With(func(l *repo.Listing) {
+		l.ViewsCount++
+		l.UpdatedAt.Time = l.UpdatedAt.Time.Add(time.Minute)
+		l.LastIndexedAt.Time = l.LastIndexedAt.Time.Add(time.Minute)
+	}))))
+	assert.NotEqual(t, fixtureContentHash, contentHash(fixtures.NewListingRow(fixtures.WithTitle("Benchy v2"))))
+}
+
+func TestHydrateListing_HashMatch_ServesCache(t *testing.T) {
+	// SCENARIO: A hit is opened whose content hash is the cached response's.
+	// EXPECT: The cached response is returned without a query.
+
+	service, mockPool, rdb := newHydrateTest(t)
+	cached := ListingResponse{ID: "550e8400e29b41d4a716446655440000", Title: "Benchy", ContentHash: fixtureContentHash}
+	require.NoError(t, cache.Set(rdb, context.Background(), service.listingCache.Key(fixtures.ListingID), cached, time.Minute))
+
+	listing, err := service.HydrateListing(context.Background(), &HydrateListingRequest{ID: fixtures.ListingID, ContentHash: fixtureContentHash})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Benchy", listing.Title)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestHydrateListing_StaleHash_ServesCache(t *testing.T) {
+	// SCENARIO: The seller renamed the listing, which dropped the cached copy and cached the new one, while the index
+	// hasn't caught up, so the hit's hash is the old one.
+	// EXPECT: The current listing is served from the cache, a hash that doesn't match is no reason to read the
+	// database.
+
+	service, mockPool, rdb := newHydrateTest(t)
+	current := ListingResponse{ID: "550e8400e29b41d4a716446655440000", Title: "Benchy v2", ContentHash: contentHash(fixtures.NewListingRow(fixtures.WithTitle("Benchy v2")))}
+	require.NoError(t, cache.Set(rdb, context.Background(), service.listingCache.Key(fixtures.ListingID), current, time.Minute))
+
+	listing, err := service.HydrateListing(context.Background(), &HydrateListingRequest{ID: fixtures.ListingID, ContentHash: fixtureContentHash})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Benchy v2", listing.Title)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestHydrateListing_NotCached_ReadsDatabase(t *testing.T) {
+	// SCENARIO: A hit is opened for a listing nobody has asked for since it was last cached.
+	// EXPECT: It's read from the database and cached like GetListingByID.
+
+	service, mockPool, rdb := newHydrateTest(t)
+	expectListingRead(mockPool, fixtures.NewListingRow())
+
+	listing, err := service.HydrateListing(context.Background(), &HydrateListingRequest{ID: fixtures.ListingID, ContentHash: fixtureContentHash})
+
+	require.NoError(t, err)
+	assert.Equal(t, fixtureContentHash, listing.ContentHash)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+
+	service.background.Wait()
+	cached, found, err := cache.Get[ListingResponse](rdb, context.Background(), service.listingCache.Key(fixtures.ListingID))
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, fixtureContentHash, cached.ContentHash)
+}
+
+func TestHydrateListing_TamperedInput(t *testing.T) {
+	// SCENARIO: Hits that were edited before being sent back, with a cached copy of the listing in Redis.
+	// EXPECT: Nothing but the ID and hash is read from them. A hash that isn't the cached one, or isn't a hash at all,
+	// still gets the cached copy without touching the database, and a bad ID is refused before any lookup.
+
+	tests := []struct {
+		name      string
+		req       HydrateListingRequest
+		wantError errors.ErrorCode
+	}{
+		{name: "made up hash", req: HydrateListingRequest{ID: fixtures.ListingID, ContentHash: "00000000000000000000000000000000"}},
+		{name: "not a hash", req: HydrateListingRequest{ID: fixtures.ListingID, ContentHash: `{"title":"Free Benchy"}`}},
+		{name: "no hash", req: HydrateListingRequest{ID: fixtures.ListingID}},
+		{name: "not a listing ID", req: HydrateListingRequest{ID: "../admin", ContentHash: fixtureContentHash}, wantError: errors.ErrInvalidInput},
+		{name: "no ID", req: HydrateListingRequest{ContentHash: fixtureContentHash}, wantError: errors.ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockPool, rdb := newHydrateTest(t)
+			cached := ListingResponse{ID: "550e8400e29b41d4a716446655440000", Title: "Benchy (cached)", ContentHash: fixtureContentHash}
+			require.NoError(t, cache.Set(rdb, context.Background(), service.listingCache.Key(fixtures.ListingID), cached, time.Minute))
+
+			listing, err := service.HydrateListing(context.Background(), &tt.req)
+
+			if tt.wantError != "" {
+				var appErr *errors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, tt.wantError, appErr.Code)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "Benchy (cached)", listing.Title, "served from the cache, not the hit")
+			}
+			service.background.Wait()
+			assert.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package listings
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/storage"
+	"image"
+	"io"
+
+	// Registers the formats the upload policy accepts with image.DecodeConfig
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// ImageBounds rejects gallery images that are too small to look good or too big to process.
+// Upload policies can't see inside the file, so this is checked when the listing is created. A zero field disables that bound.
+type ImageBounds struct {
+	MinWidth, MinHeight int
+	MaxWidth, MaxHeight int
+	MaxAspectRatio      float64 // Longest side divided by the shortest
+}
+
+func DefaultImageBounds() ImageBounds {
+	return ImageBounds{
+		MinWidth:       512,
+		MinHeight:      512,
+		MaxWidth:       8192,
+		MaxHeight:      8192,
+		MaxAspectRatio: 3,
+	}
+}
+
+// imageHeaderLimit caps how much of an upload is read to find its dimensions.
+// PNG has them in the first few dozen bytes, JPEG puts them after any EXIF/ICC segments which can run to tens of KB.
+const imageHeaderLimit = 256 << 10
+
+// check returns why an image of this size isn't allowed, or "" when it is
+func (b ImageBounds) check(width, height int) string {
+	if (b.MinWidth > 0 && width < b.MinWidth) || (b.MinHeight > 0 && height < b.MinHeight) {
+		return fmt.Sprintf("Image is %dx%d pixels, it must be at least %dx%d", width, height, b.MinWidth, b.MinHeight)
+	}
+	if (b.MaxWidth > 0 && width > b.MaxWidth) || (b.MaxHeight > 0 && height > b.MaxHeight) {
+		return fmt.Sprintf("Image is %dx%d pixels, it must be at most %dx%d", width, height, b.MaxWidth, b.MaxHeight)
+	}
+	if b.MaxAspectRatio > 0 && width > 0 && height > 0 {
+		long, short := max(width, height), min(width, height)
+		if float64(long)/float64(short) > b.MaxAspectRatio {
+			return fmt.Sprintf("Image is %dx%d pixels, its sides must be no more than %g:1", width, height, b.MaxAspectRatio)
+		}
+	}
+	return ""
+}
+
+// readImageConfig decodes just the image header, never reading more than imageHeaderLimit bytes of r
+func readImageConfig(r io.Reader) (image.Config, error) {
+	config, _, err := image.DecodeConfig(io.LimitReader(r, imageHeaderLimit))
+	return config, err
+}
+
+// checkImageDimensions reads the header of an uploaded image and returns why it's out of bounds, or "" when it's fine.
+// Anything that stops the check, a missing object or a format we can't decode, lets the file through to the
+// validation worker, which rejects broken images properly.
+func (s *svc) checkImageDimensions(ctx context.Context, key string) string {
+	if s.images == (ImageBounds{}) {
+		return ""
+	}
+
+	obj, err := s.storage.Get(ctx, storage.BucketIncoming, key)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Failed to open image, skipping dimension check", "key", key, "error", err)
+		return ""
+	}
+	// Closing early abandons the rest of the download
+	defer obj.Close()
+
+	config, err := readImageConfig(obj)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Failed to read image header, skipping dimension check", "key", key, "error", err)
+		return ""
+	}
+
+	reason := s.images.check(config.Width, config.Height)
+	if reason != "" {
+		s.logger.InfoContext(ctx, "Image outside allowed dimensions", "key", key, "width", config.Width, "height", config.Height)
+	}
+	return reason
+}
@@ -0,0 +1,255 @@
+package listings
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"gateway/internal/events"
+	"gateway/internal/mocks/mockevents"
+	"gateway/internal/mocks/mockstorage"
+	"gateway/internal/storage"
+	"gateway/internal/testutil"
+	"hash/crc32"
+	"io"
+	"regexp"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// pngHeader is the signature and IHDR chunk of an RGBA PNG, everything DecodeConfig needs
+func pngHeader(width, height int) []byte {
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(width))
+	binary.BigEndian.PutUint32(ihdr[8:], uint32(height))
+	ihdr[12] = 8 // Bit depth
+	ihdr[13] = 6 // RGBA
+
+	var b bytes.Buffer
+	b.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&b, binary.BigEndian, uint32(13))
+	b.Write(ihdr)
+	binary.Write(&b, binary.BigEndian, crc32.ChecksumIEEE(ihdr))
+	return b.Bytes()
+}
+
+// jpegHeader is SOI, an optional APP1 segment standing in for EXIF data, a baseline SOF0 for a YCbCr image and the start of the scan
+func jpegHeader(width, height, exifBytes int) []byte {
+	var b bytes.Buffer
+	b.Write([]byte{0xFF, 0xD8})
+	for exifBytes > 0 {
+		n := min(exifBytes, 0xFFFF-2)
+		b.Write([]byte{0xFF, 0xE1})
+		binary.Write(&b, binary.BigEndian, uint16(n+2))
+		b.Write(make([]byte, n))
+		exifBytes -= n
+	}
+	b.Write([]byte{0xFF, 0xC0, 0x00, 0x11, 0x08})
+	binary.Write(&b, binary.BigEndian, uint16(height))
+	binary.Write(&b, binary.BigEndian, uint16(width))
+	b.Write([]byte{0x03, 0x01, 0x22, 0x00, 0x02, 0x11, 0x01, 0x03, 0x11, 0x01})
+	// Without a JFIF marker the decoder looks for an Adobe one until the scan starts
+	b.Write([]byte{0xFF, 0xDA, 0x00, 0x0C, 0x03, 0x01, 0x00, 0x02, 0x11, 0x03, 0x11, 0x00, 0x3F, 0x00})
+	return b.Bytes()
+}
+
+// countingReader records how much of an object has been pulled
+type countingReader struct {
+	r    io.Reader
+	read int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += n
+	return n, err
+}
+
+func anyArgs(n int) []any {
+	args := make([]any, n)
+	for i := range args {
+		args[i] = pgxmock.AnyArg()
+	}
+	return args
+}
+
+func TestImageBounds_Check(t *testing.T) {
+	bounds := DefaultImageBounds()
+
+	tests := []struct {
+		name          string
+		width, height int
+		wantReason    string
+	}{
+		{"Square minimum", 512, 512, ""},
+		{"Square maximum", 8192, 8192, ""},
+		{"Exactly 3:1", 3000, 1000, ""},
+		{"Exactly 1:3", 1000, 3000, ""},
+		{"Thumbnail", 50, 50, "Image is 50x50 pixels, it must be at least 512x512"},
+		{"Too narrow", 511, 1024, "Image is 511x1024 pixels, it must be at least 512x512"},
+		{"Too short", 1024, 511, "Image is 1024x511 pixels, it must be at least 512x512"},
+		{"Too wide", 8193, 4000, "Image is 8193x4000 pixels, it must be at most 8192x8192"},
+		{"Too tall", 4000, 8193, "Image is 4000x8193 pixels, it must be at most 8192x8192"},
+		{"Panorama", 3001, 1000, "Image is 3001x1000 pixels, its sides must be no more than 3:1"},
+		{"Banner", 600, 1801, "Image is 600x1801 pixels, its sides must be no more than 3:1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantReason, bounds.check(tt.width, tt.height))
+		})
+	}
+
+	t.Run("Zero fields are not enforced", func(t *testing.T) {
+		assert.Empty(t, ImageBounds{MaxAspectRatio: 3}.check(10, 10))
+		assert.Empty(t, ImageBounds{MinWidth: 512}.check(600, 100_000))
+	})
+}
+
+func TestReadImageConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		header        []byte
+		width, height int
+	}{
+		{"PNG thumbnail", pngHeader(50, 50), 50, 50},
+		{"PNG square", pngHeader(1024, 1024), 1024, 1024},
+		{"PNG 100 megapixels", pngHeader(12500, 8000), 12500, 8000},
+		{"JPEG minimum", jpegHeader(512, 512, 0), 512, 512},
+		{"JPEG panorama", jpegHeader(6000, 1000, 0), 6000, 1000},
+		{"JPEG after EXIF", jpegHeader(4032, 3024, 64<<10), 4032, 3024},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Megabytes of pixel data follow the header, none of it should be read
+			obj := &countingReader{r: io.MultiReader(bytes.NewReader(tt.header), bytes.NewReader(make([]byte, 16<<20)))}
+
+			config, err := readImageConfig(obj)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.width, config.Width)
+			assert.Equal(t, tt.height, config.Height)
+			assert.LessOrEqual(t, obj.read, imageHeaderLimit)
+		})
+	}
+
+	t.Run("Header past the limit is not searched for", func(t *testing.T) {
+		obj := &countingReader{r: bytes.NewReader(jpegHeader(1024, 1024, imageHeaderLimit))}
+
+		_, err := readImageConfig(obj)
+
+		assert.Error(t, err)
+		assert.LessOrEqual(t, obj.read, imageHeaderLimit)
+	})
+
+	t.Run("Not an image", func(t *testing.T) {
+		_, err := readImageConfig(bytes.NewReader([]byte("solid cube\nendsolid cube\n")))
+		assert.Error(t, err)
+	})
+}
+
+func TestCheckImageDimensions(t *testing.T) {
+	const key = "2025/01/01/a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11/draft/images/image.png"
+
+	tests := []struct {
+		name       string
+		object     []byte
+		getErr     error
+		wantReason string
+	}{
+		{"Within bounds", pngHeader(1200, 800), nil, ""},
+		{"Too small", pngHeader(300, 200), nil, "Image is 300x200 pixels, it must be at least 512x512"},
+		{"Too stretched", jpegHeader(4000, 1000, 0), nil, "Image is 4000x1000 pixels, its sides must be no more than 3:1"},
+		// The validation worker deals with these properly
+		{"Missing object lets the file through", nil, storage.ErrNotFound, ""},
+		{"Unknown format lets the file through", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStorage := mockstorage.NewProvider(t)
+			service := &svc{storage: mockStorage, logger: testutil.NewTestLogger(), images: DefaultImageBounds()}
+
+			if tt.getErr != nil {
+				mockStorage.EXPECT().Get(mock.Anything, storage.BucketIncoming, key).Return(nil, tt.getErr).Once()
+			} else {
+				mockStorage.EXPECT().Get(mock.Anything, storage.BucketIncoming, key).Return(io.NopCloser(bytes.NewReader(tt.object)), nil).Once()
+			}
+
+			assert.Equal(t, tt.wantReason, service.checkImageDimensions(context.Background(), key))
+		})
+	}
+
+	t.Run("Zero bounds skip the check", func(t *testing.T) {
+		// No expectations, any storage call fails the test
+		service := &svc{storage: mockstorage.NewProvider(t), logger: testutil.NewTestLogger()}
+		assert.Empty(t, service.checkImageDimensions(context.Background(), key))
+	})
+}
+
+func TestCreateListing_ImageOutsideBoundsSavedInvalid(t *testing.T) {
+	// SCENARIO: A seller attaches a 50 pixel thumbnail as their gallery image.
+	// EXPECT: The image is saved INVALID with the reason, and only the model is sent for validation.
+
+	service, mockPool, userInfo, req := newSellerCheckTest(t)
+	modelPath, imagePath := req.Files[0].Path, req.Files[1].Path
+
+	mockStorage := mockstorage.NewProvider(t)
+	mockStorage.EXPECT().Get(mock.Anything, storage.BucketIncoming, imagePath).Return(io.NopCloser(bytes.NewReader(pngHeader(50, 50))), nil).Once()
+	service.storage = mockStorage
+	service.images = DefaultImageBounds()
+
+	service.eventHandler = events.NewEventHandler(mockevents.NewBus(t), &events.EventConfig{
+		StartListingValidation: "file.listing.start",
+		ListingCreated:         "listings.created",
+	}, service.logger)
+
+	const listingID = "11111111-1111-1111-1111-111111111111"
+	listingUUID := mustUUID(t, listingID)
+	fileRow := func(id, path string, fileType repo.FileType, status string, errorMessage any) []any {
+		return []any{id, listingID, path, fileType, int64(500), []byte("{}"), status, errorMessage, false, nil, time.Now(), time.Now(), nil}
+	}
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT user_id, display_name`)).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(testutil.SellerCols).AddRow(
+			userInfo.ID, "Tester Prints", "GB", "VERIFIED", "2", time.Now(), time.Now(), time.Now(), nil, nil, nil, false, nil,
+		))
+	expectFilesUnused(mockPool)
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`INSERT INTO listings`)).
+		WithArgs(anyArgs(29)...).
+		WillReturnRows(createdListingRows(listingID, userInfo.ID))
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO listing_status_events`)).
+		WithArgs(anyArgs(8)...).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`INSERT INTO listing_files`)).
+		WithArgs(
+			listingUUID, []string{modelPath, imagePath}, []string{"MODEL", "IMAGE"}, pgxmock.AnyArg(),
+			[]string{"PENDING", "INVALID"},
+			[]string{"", "Image is 50x50 pixels, it must be at least 512x512"},
+		).
+		WillReturnRows(pgxmock.NewRows(testutil.ListingFileCols).
+			AddRow(fileRow("22222222-2222-2222-2222-222222222222", modelPath, repo.FileTypeMODEL, "PENDING", nil)...).
+			AddRow(fileRow("33333333-3333-3333-3333-333333333333", imagePath, repo.FileTypeIMAGE, "INVALID", "Image is 50x50 pixels, it must be at least 512x512")...))
+	mockPool.ExpectExec(regexp.QuoteMeta(`UPDATE upload_callbacks`)).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	var validation events.StartListingValidationEvent
+	expectOutbox(mockPool, outboxEvent{subject: "file.listing.start", payload: &validation}, outboxEvent{subject: "listings.created"})
+	mockPool.ExpectCommit()
+
+	_, err := service.CreateListing(context.Background(), userInfo, req)
+
+	require.NoError(t, err)
+	assert.Equal(t, []events.ValidationFile{{FileID: "22222222222222222222222222222222", FileKey: modelPath, FileType: "model"}}, validation.Files)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -0,0 +1,115 @@
+package listings
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/errors"
+	"gateway/internal/events"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// indexFailuresPageSize is how many failures moderators get at once, newest first
+const indexFailuresPageSize = 100
+
+// indexErrorMessage is what the owner sees on a listing that isn't in search. The worker's own error is for
+// moderators, it can name internal hosts.
+const indexErrorMessage = "This listing couldn't be added to search. We've been notified and it will appear once it's fixed."
+
+// IndexFailedListing is a listing the listings worker gave up indexing, as moderators see it
+type IndexFailedListing struct {
+	ListingID      string    `json:"listing_id"`
+	Title          string    `json:"title"`
+	SellerID       string    `json:"seller_id"`
+	SellerUsername string    `json:"seller_username"`
+	Status         string    `json:"status"`
+	ErrorClass     string    `json:"error_class"` // database, document, search or event
+	Error          string    `json:"error"`
+	Attempts       int       `json:"attempts"`
+	FailedAt       time.Time `json:"failed_at"`
+}
+
+// RecordIndexFailure stores a failure reported by the listings worker, which clears it again once the listing indexes.
+// Late and replayed events are ignored by the query rather than here.
+func (s *svc) RecordIndexFailure(ctx context.Context, evt events.ListingIndexFailedEvent) error {
+	var listingUUID pgtype.UUID
+	if err := listingUUID.Scan(evt.ListingID); err != nil {
+		s.logger.ErrorContext(ctx, "Index failure for an invalid listing ID, discarding", "listing_id", evt.ListingID)
+		return nil
+	}
+
+	failedAt, err := time.Parse(time.RFC3339Nano, evt.FailedAt)
+	if err != nil {
+		// The event is only moments old, better recorded a little late than not at all
+		s.logger.WarnContext(ctx, "Index failure without a valid failed_at, using now", "listing_id", evt.ListingID, "failed_at", evt.FailedAt)
+		failedAt = s.now()
+	}
+
+	recorded, err := s.repo.RecordListingIndexFailure(ctx, repo.RecordListingIndexFailureParams{
+		ListingID:  listingUUID,
+		ErrorClass: evt.ErrorClass,
+		Error:      evt.Error,
+		Attempts:   int32(evt.Attempts),
+		FailedAt:   pgtype.Timestamptz{Time: failedAt, Valid: true},
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to record index failure", "listing_id", evt.ListingID, "error", err)
+		return fmt.Errorf("failed to record index failure for %s: %w", evt.ListingID, err)
+	}
+	if recorded == 0 {
+		s.logger.InfoContext(ctx, "Listing was indexed after it failed or no longer exists, ignoring the failure", "listing_id", evt.ListingID)
+		return nil
+	}
+
+	s.logger.WarnContext(ctx, "Listing failed to index",
+		"listing_id", evt.ListingID,
+		"seller_id", evt.SellerID,
+		"error_class", evt.ErrorClass,
+		"attempts", evt.Attempts,
+	)
+	return nil
+}
+
+// ListIndexFailures is every listing that isn't in search because indexing gave up, for moderators
+func (s *svc) ListIndexFailures(ctx context.Context) ([]IndexFailedListing, error) {
+	rows, err := s.repo.ListIndexFailedListings(ctx, indexFailuresPageSize)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to list index failures", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to list index failures", err)
+	}
+
+	failures := make([]IndexFailedListing, len(rows))
+	for i, row := range rows {
+		failures[i] = IndexFailedListing{
+			ListingID:      row.ListingID.String(),
+			Title:          row.Title,
+			SellerID:       row.SellerID.String(),
+			SellerUsername: row.SellerUsername,
+			Status:         string(row.Status.ListingStatus),
+			ErrorClass:     row.ErrorClass,
+			Error:          row.Error,
+			Attempts:       int(row.Attempts),
+			FailedAt:       utc(row.FailedAt),
+		}
+	}
+	return failures, nil
+}
+
+// sellerIndexFailures is the set of the seller's listings that failed to index. Like the price chart it's a nicety,
+// a failure is logged and the listings are served without it.
+func (s *svc) sellerIndexFailures(ctx context.Context, sellerID pgtype.UUID) map[pgtype.UUID]bool {
+	rows, err := s.repo.GetIndexFailuresBySeller(ctx, sellerID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to fetch index failures", "seller_id", sellerID.String(), "error", err)
+		return nil
+	}
+
+	failed := make(map[pgtype.UUID]bool, len(rows))
+	for _, row := range rows {
+		failed[row.ListingID] = true
+	}
+	return failed
+}
@@ -0,0 +1,106 @@
+package listings
+
+import (
+	"context"
+	"gateway/internal/events"
+	"gateway/internal/testutil"
+	"regexp"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var indexFailedListingCols = []string{"listing_id", "title", "seller_id", "seller_username", "status", "error_class", "error", "attempts", "failed_at"}
+
+func TestRecordIndexFailure(t *testing.T) {
+	// Sub-second, so a failure moments after the last successful index still sorts after it
+	failedAt := time.Date(2026, 10, 16, 9, 30, 0, 250_000_000, time.UTC)
+	evt := events.ListingIndexFailedEvent{
+		ListingID:  historyListingID,
+		SellerID:   historyOwnerID,
+		ErrorClass: "search",
+		Error:      "503 service unavailable",
+		Attempts:   5,
+		FailedAt:   failedAt.Format(time.RFC3339Nano),
+	}
+
+	// Rows recorded by the query, it skips failures older than the last successful index
+	tests := map[string]struct {
+		rows int64
+	}{
+		"recorded":                       {rows: 1},
+		"indexed since, or already gone": {rows: 0},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockPool := testutil.NewMockDB(t)
+			service := &svc{repo: repo.New(mockPool), db: mockPool, logger: testutil.NewTestLogger(), now: time.Now}
+
+			mockPool.ExpectExec(regexp.QuoteMeta(`-- name: RecordListingIndexFailure :execrows`)).
+				WithArgs("search", "503 service unavailable", int32(5), pgtype.Timestamptz{Time: failedAt, Valid: true}, pgxmock.AnyArg()).
+				WillReturnResult(pgxmock.NewResult("INSERT", tt.rows))
+
+			assert.NoError(t, service.RecordIndexFailure(context.Background(), evt))
+			assert.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+
+	t.Run("invalid listing ID is discarded", func(t *testing.T) {
+		mockPool := testutil.NewMockDB(t)
+		service := &svc{repo: repo.New(mockPool), db: mockPool, logger: testutil.NewTestLogger(), now: time.Now}
+
+		bad := evt
+		bad.ListingID = "not-a-uuid"
+		assert.NoError(t, service.RecordIndexFailure(context.Background(), bad))
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("database error is returned for redelivery", func(t *testing.T) {
+		mockPool := testutil.NewMockDB(t)
+		service := &svc{repo: repo.New(mockPool), db: mockPool, logger: testutil.NewTestLogger(), now: time.Now}
+
+		mockPool.ExpectExec(regexp.QuoteMeta(`-- name: RecordListingIndexFailure :execrows`)).
+			WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+			WillReturnError(assert.AnError)
+
+		assert.ErrorIs(t, service.RecordIndexFailure(context.Background(), evt), assert.AnError)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestListIndexFailures(t *testing.T) {
+	// SCENARIO: A moderator looks for listings that aren't in search.
+	// EXPECT: Each failure with its listing and seller, times in UTC.
+
+	mockPool := testutil.NewMockDB(t)
+	service := &svc{repo: repo.New(mockPool), db: mockPool, logger: testutil.NewTestLogger()}
+
+	failedAt := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListIndexFailedListings :many`)).
+		WithArgs(int32(indexFailuresPageSize)).
+		WillReturnRows(pgxmock.NewRows(indexFailedListingCols).
+			AddRow(historyListingID, "Benchy", historyOwnerID, "tester", "ACTIVE", "document", "listing has no categories", int32(5), failedAt.In(localZone)))
+
+	failures, err := service.ListIndexFailures(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []IndexFailedListing{{
+		ListingID:      historyListingID,
+		Title:          "Benchy",
+		SellerID:       historyOwnerID,
+		SellerUsername: "tester",
+		Status:         "ACTIVE",
+		ErrorClass:     "document",
+		Error:          "listing has no categories",
+		Attempts:       5,
+		FailedAt:       failedAt,
+	}}, failures)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -0,0 +1,161 @@
+package listings
+
+import (
+	"encoding/json"
+	"fmt"
+	"gateway/internal/errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	schemaOrg = "https://schema.org"
+
+	availabilityInStock    = "https://schema.org/InStock"
+	availabilityOutOfStock = "https://schema.org/OutOfStock"
+	priceTypeStrikethrough = "https://schema.org/StrikethroughPrice"
+)
+
+// ProductJSONLD is the schema.org Product a listing is described to search engines as, see
+// https://developers.google.com/search/docs/appearance/structured-data/product
+type ProductJSONLD struct {
+	Context              string                     `json:"@context"`
+	Type                 string                     `json:"@type"`
+	Name                 string                     `json:"name"`
+	Description          string                     `json:"description"`
+	SKU                  string                     `json:"sku"` // The listing ID
+	URL                  string                     `json:"url"`
+	Image                []string                   `json:"image,omitempty"`
+	Brand                BrandJSONLD                `json:"brand"` // The seller
+	Offers               OfferJSONLD                `json:"offers"`
+	InteractionStatistic []InteractionCounterJSONLD `json:"interactionStatistic"`
+}
+
+type BrandJSONLD struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// OfferJSONLD is what the listing sells for now. During a sale Price is the sale price, and PriceSpecification carries
+// it again next to the regular price as a StrikethroughPrice.
+type OfferJSONLD struct {
+	Type               string                     `json:"@type"`
+	URL                string                     `json:"url"`
+	Price              string                     `json:"price"` // Decimal, e.g. 10.50
+	PriceCurrency      string                     `json:"priceCurrency"`
+	Availability       string                     `json:"availability"`
+	PriceValidUntil    string                     `json:"priceValidUntil,omitempty"` // YYYY-MM-DD, the day a sale ends
+	PriceSpecification []PriceSpecificationJSONLD `json:"priceSpecification,omitempty"`
+}
+
+type PriceSpecificationJSONLD struct {
+	Type          string `json:"@type"`
+	Name          string `json:"name,omitempty"`      // The sale's name
+	PriceType     string `json:"priceType,omitempty"` // StrikethroughPrice for the regular price during a sale
+	Price         string `json:"price"`
+	PriceCurrency string `json:"priceCurrency"`
+	ValidThrough  string `json:"validThrough,omitempty"` // RFC 3339, when the sale ends
+}
+
+type InteractionCounterJSONLD struct {
+	Type                 string `json:"@type"`
+	InteractionType      string `json:"interactionType"`
+	UserInteractionCount int    `json:"userInteractionCount"`
+}
+
+// GetListingJSONLD serves GET /listings/{id}/jsonld, the listing as schema.org JSON-LD for the web UI to put in its
+// page head. NSFW listings are never described to search engines, so they are a 404 here.
+func (h *PreviewHandler) GetListingJSONLD(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	listingID := chi.URLParam(r, "id")
+
+	listing, err := h.publicListing(r, listingID)
+	if err == nil && listing.IsNSFW {
+		err = errors.New(errors.ErrNotFound, "Listing not found", fmt.Errorf("listing %v is NSFW", listingID)).WithReason(errors.ReasonListingNotFound)
+	}
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	// json.Marshal escapes <, > and &, so the document can be pasted into a <script> element as it is
+	body, err := json.Marshal(h.toProductJSONLD(listing, time.Now()))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to render listing JSON-LD", "listing_id", listingID, "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrInternal, "Failed to render listing JSON-LD", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/ld+json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+func (h *PreviewHandler) toProductJSONLD(listing *ListingResponse, now time.Time) ProductJSONLD {
+	url := h.listingURL(listing.ID)
+	currency := strings.ToUpper(listing.Currency)
+
+	product := ProductJSONLD{
+		Context:     schemaOrg,
+		Type:        "Product",
+		Name:        listing.Title,
+		Description: listing.Description,
+		SKU:         listing.ID,
+		URL:         url,
+		Brand:       BrandJSONLD{Type: "Brand", Name: listing.SellerName},
+		Offers: OfferJSONLD{
+			Type:          "Offer",
+			URL:           url,
+			Price:         formatPrice(listing.PriceMinUnit),
+			PriceCurrency: currency,
+			Availability:  availabilityInStock,
+		},
+		InteractionStatistic: []InteractionCounterJSONLD{
+			{Type: "InteractionCounter", InteractionType: schemaOrg + "/LikeAction", UserInteractionCount: listing.LikesCount},
+			{Type: "InteractionCounter", InteractionType: schemaOrg + "/DownloadAction", UserInteractionCount: listing.DownloadsCount},
+			{Type: "InteractionCounter", InteractionType: schemaOrg + "/ViewAction", UserInteractionCount: listing.ViewsCount},
+			{Type: "InteractionCounter", InteractionType: schemaOrg + "/CommentAction", UserInteractionCount: listing.CommentsCount},
+		},
+	}
+	if listing.ThumbnailPath != nil {
+		product.Image = []string{*listing.ThumbnailPath}
+	}
+	// Paid downloads are paused while the seller is away
+	if listing.TemporarilyUnavailable && listing.PriceMinUnit > 0 {
+		product.Offers.Availability = availabilityOutOfStock
+	}
+
+	if onSale(listing, now) {
+		sale := PriceSpecificationJSONLD{
+			Type:          "UnitPriceSpecification",
+			Name:          getValue(listing.SaleName),
+			Price:         formatPrice(*listing.SalePriceMinUnit),
+			PriceCurrency: currency,
+		}
+		if listing.SaleEndTimestamp != nil {
+			sale.ValidThrough = listing.SaleEndTimestamp.UTC().Format(time.RFC3339)
+			product.Offers.PriceValidUntil = listing.SaleEndTimestamp.UTC().Format(time.DateOnly)
+		}
+		product.Offers.Price = sale.Price
+		product.Offers.PriceSpecification = []PriceSpecificationJSONLD{
+			sale,
+			{Type: "UnitPriceSpecification", PriceType: priceTypeStrikethrough, Price: formatPrice(listing.PriceMinUnit), PriceCurrency: currency},
+		}
+	}
+
+	return product
+}
+
+// onSale is whether the sale price is what the listing sells for right now. The flag is only cleared by the seller, so
+// a sale whose end has passed is over regardless, and one that isn't below the regular price isn't a sale.
+func onSale(listing *ListingResponse, now time.Time) bool {
+	if !listing.IsSaleActive || listing.SalePriceMinUnit == nil || *listing.SalePriceMinUnit >= listing.PriceMinUnit {
+		return false
+	}
+	return listing.SaleEndTimestamp == nil || listing.SaleEndTimestamp.After(now)
+}
@@ -0,0 +1,218 @@
+package listings_test
+
+import (
+	"encoding/json"
+	"gateway/internal/handlers/listings"
+	"gateway/internal/mocks/mocklistings"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func getJSONLD(t *testing.T, listing *listings.ListingResponse) *httptest.ResponseRecorder {
+	t.Helper()
+	svc := mocklistings.NewListingsService(t)
+	svc.EXPECT().GetListingByID(mock.Anything, listingID).Return(listing, nil)
+
+	r := chi.NewRouter()
+	r.Get("/listings/{id}/jsonld", listings.NewPreviewHandler(svc, previewConfig).GetListingJSONLD)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/listings/"+listingID+"/jsonld", nil))
+	return w
+}
+
+// decodeJSONLD reads the document with schema.org's field names, not the Go struct's
+func decodeJSONLD(t *testing.T, body []byte) map[string]any {
+	t.Helper()
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(body, &doc), string(body))
+	return doc
+}
+
+func jsonldListing() *listings.ListingResponse {
+	listing := previewListing()
+	listing.SellerName = "Tester Prints"
+	listing.LikesCount, listing.DownloadsCount, listing.ViewsCount, listing.CommentsCount = 12, 34, 210, 5
+	return listing
+}
+
+func TestGetListingJSONLD(t *testing.T) {
+	w := getJSONLD(t, jsonldListing())
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "application/ld+json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"@context": "https://schema.org",
+		"@type": "Product",
+		"name": "Benchy",
+		"description": "The classic",
+		"sku": "550e8400e29b41d4a716446655440000",
+		"url": "https://web.test/listings/550e8400e29b41d4a716446655440000",
+		"image": ["https://cdn.test/listings/1/thumb.webp"],
+		"brand": {"@type": "Brand", "name": "Tester Prints"},
+		"offers": {
+			"@type": "Offer",
+			"url": "https://web.test/listings/550e8400e29b41d4a716446655440000",
+			"price": "10.50",
+			"priceCurrency": "GBP",
+			"availability": "https://schema.org/InStock"
+		},
+		"interactionStatistic": [
+			{"@type": "InteractionCounter", "interactionType": "https://schema.org/LikeAction", "userInteractionCount": 12},
+			{"@type": "InteractionCounter", "interactionType": "https://schema.org/DownloadAction", "userInteractionCount": 34},
+			{"@type": "InteractionCounter", "interactionType": "https://schema.org/ViewAction", "userInteractionCount": 210},
+			{"@type": "InteractionCounter", "interactionType": "https://schema.org/CommentAction", "userInteractionCount": 5}
+		]
+	}`, w.Body.String())
+}
+
+func TestGetListingJSONLD_SalePricing(t *testing.T) {
+	// SCENARIO: Listings at 10.50 with a sale in each state the sale fields can be in.
+	// EXPECT: Only a running sale below the regular price changes the offer. Its price is the sale price, and
+	// priceSpecification has the sale price and the regular price as the StrikethroughPrice.
+
+	ends := time.Now().Add(72 * time.Hour).UTC().Truncate(time.Second)
+	sale := func(priceMinUnit int64, ends *time.Time) func(l *listings.ListingResponse) {
+		return func(l *listings.ListingResponse) {
+			name := "Spring sale"
+			l.IsSaleActive, l.SaleName, l.SalePriceMinUnit, l.SaleEndTimestamp = true, &name, &priceMinUnit, ends
+		}
+	}
+	ended := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		edit      func(l *listings.ListingResponse)
+		wantOffer string
+	}{
+		{"running", sale(750, &ends), `{
+			"@type": "Offer",
+			"url": "https://web.test/listings/550e8400e29b41d4a716446655440000",
+			"price": "7.50",
+			"priceCurrency": "GBP",
+			"availability": "https://schema.org/InStock",
+			"priceValidUntil": "` + ends.Format(time.DateOnly) + `",
+			"priceSpecification": [
+				{"@type": "UnitPriceSpecification", "name": "Spring sale", "price": "7.50", "priceCurrency": "GBP", "validThrough": "` + ends.Format(time.RFC3339) + `"},
+				{"@type": "UnitPriceSpecification", "priceType": "https://schema.org/StrikethroughPrice", "price": "10.50", "priceCurrency": "GBP"}
+			]
+		}`},
+		{"no end date", sale(750, nil), `{
+			"@type": "Offer",
+			"url": "https://web.test/listings/550e8400e29b41d4a716446655440000",
+			"price": "7.50",
+			"priceCurrency": "GBP",
+			"availability": "https://schema.org/InStock",
+			"priceSpecification": [
+				{"@type": "UnitPriceSpecification", "name": "Spring sale", "price": "7.50", "priceCurrency": "GBP"},
+				{"@type": "UnitPriceSpecification", "priceType": "https://schema.org/StrikethroughPrice", "price": "10.50", "priceCurrency": "GBP"}
+			]
+		}`},
+		{"ended", sale(750, &ended), regularOffer},
+		{"not below the regular price", sale(1050, &ends), regularOffer},
+		{"flag off", func(l *listings.ListingResponse) {
+			sale(750, &ends)(l)
+			l.IsSaleActive = false
+		}, regularOffer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listing := jsonldListing()
+			tt.edit(listing)
+
+			w := getJSONLD(t, listing)
+
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			offers, err := json.Marshal(decodeJSONLD(t, w.Body.Bytes())["offers"])
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.wantOffer, string(offers))
+		})
+	}
+}
+
+const regularOffer = `{
+	"@type": "Offer",
+	"url": "https://web.test/listings/550e8400e29b41d4a716446655440000",
+	"price": "10.50",
+	"priceCurrency": "GBP",
+	"availability": "https://schema.org/InStock"
+}`
+
+func TestGetListingJSONLD_SellerAway(t *testing.T) {
+	// SCENARIO: The seller is on vacation, which pauses paid downloads but not free ones.
+	// EXPECT: The paid listing is out of stock, the free one is still in stock.
+
+	paid := jsonldListing()
+	paid.TemporarilyUnavailable = true
+	free := jsonldListing()
+	free.TemporarilyUnavailable, free.PriceMinUnit = true, 0
+
+	offers := decodeJSONLD(t, getJSONLD(t, paid).Body.Bytes())["offers"].(map[string]any)
+	assert.Equal(t, "https://schema.org/OutOfStock", offers["availability"])
+	offers = decodeJSONLD(t, getJSONLD(t, free).Body.Bytes())["offers"].(map[string]any)
+	assert.Equal(t, "https://schema.org/InStock", offers["availability"])
+	assert.Equal(t, "0.00", offers["price"])
+}
+
+func TestGetListingJSONLD_Escapes(t *testing.T) {
+	// SCENARIO: The title and seller name try to close the <script> element the document is pasted into.
+	// EXPECT: The text reads back intact, but the body has no markup in it.
+
+	listing := jsonldListing()
+	listing.Title = `Benchy</script><script>alert("x")</script>`
+	listing.SellerName = `Tester & <Prints>`
+
+	w := getJSONLD(t, listing)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "<")
+	assert.NotContains(t, w.Body.String(), "&")
+	doc := decodeJSONLD(t, w.Body.Bytes())
+	assert.Equal(t, listing.Title, doc["name"])
+	assert.Equal(t, listing.SellerName, doc["brand"].(map[string]any)["name"])
+}
+
+func TestGetListingJSONLD_NotListed(t *testing.T) {
+	nsfw := jsonldListing()
+	nsfw.IsNSFW = true
+	hidden := jsonldListing()
+	hidden.Status = "HIDDEN"
+
+	for name, listing := range map[string]*listings.ListingResponse{"nsfw": nsfw, "hidden": hidden} {
+		t.Run(name, func(t *testing.T) {
+			w := getJSONLD(t, listing)
+			assert.Equal(t, http.StatusNotFound, w.Code)
+		})
+	}
+}
+
+var jsonldScript = regexp.MustCompile(`<script type="application/ld\+json">(.*)</script>`)
+
+func TestGetListingPreview_EmbedsJSONLD(t *testing.T) {
+	listing := jsonldListing()
+	listing.Title = `Benchy</script>`
+
+	w := getPreview(t, listing)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	match := jsonldScript.FindStringSubmatch(w.Body.String())
+	require.NotNil(t, match, w.Body.String())
+	doc := decodeJSONLD(t, []byte(match[1]))
+	assert.Equal(t, "Product", doc["@type"])
+	assert.Equal(t, "Benchy</script>", doc["name"])
+
+	listing.IsNSFW = true
+	w = getPreview(t, listing)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, strings.Contains(w.Body.String(), "application/ld+json"), "NSFW listings are previewed without structured data")
+}
@@ -0,0 +1,107 @@
+package listings
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/ratelimit"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CreationLimits slows down scripted listing creation. A zero value disables that check.
+type CreationLimits struct {
+	PerHour          int64 // Listings any seller can create per clock hour
+	PerDayUnverified int64 // Listings a seller whose payouts aren't verified can create per UTC day
+
+	// A new listing whose title or description matches DuplicateThreshold or more of the seller's listings
+	// from the last DuplicateWindow is held as PENDING_REVIEW instead of going live after validation
+	DuplicateWindow    time.Duration
+	DuplicateThreshold int64
+}
+
+func DefaultCreationLimits() CreationLimits {
+	return CreationLimits{
+		PerHour:            10,
+		PerDayUnverified:   30,
+		DuplicateWindow:    24 * time.Hour,
+		DuplicateThreshold: 1,
+	}
+}
+
+// checkCreationRate counts this attempt against the seller's windows and rejects it once any of them is over its limit.
+// Attempts are counted before the listing is written, so ones that fail later still use up the allowance.
+// Redis being down lets the request through, these limits are a speed bump and not worth failing creation over.
+func (s *svc) checkCreationRate(ctx context.Context, userInfo auth.UserInfo, seller repo.Seller) error {
+	if s.creations == nil || userInfo.HasRole(auth.RoleModerator) {
+		return nil
+	}
+
+	type check struct {
+		window ratelimit.Window
+		limit  int64
+	}
+	checks := []check{{ratelimit.Hour, s.limits.PerHour}}
+	if seller.PayoutStatus != repo.PayoutStatusVERIFIED {
+		checks = append(checks, check{ratelimit.Day, s.limits.PerDayUnverified})
+	}
+
+	now := s.now()
+	for _, c := range checks {
+		if c.limit <= 0 {
+			continue
+		}
+		count, err := s.creations.Incr(ctx, "listings:"+userInfo.ID, c.window, now)
+		if err != nil {
+			s.logger.WarnContext(ctx, "Failed to count listing creation, skipping rate limit", "window", c.window.Name, "error", err)
+			continue
+		}
+		if count > c.limit {
+			_, reset := c.window.Bounds(now)
+			s.logger.WarnContext(ctx, "Listing creation rate limited", "user", userInfo.ID, "window", c.window.Name, "count", count, "limit", c.limit)
+			return errors.New(errors.ErrRateLimited, "You have created too many listings, please try again later",
+				fmt.Errorf("%d listings this %s, limit is %d", count, c.window.Name, c.limit)).
+				WithReason(errors.ReasonListingRateLimited).
+				WithParam("reset_at", reset.UTC().Format(time.RFC3339)).
+				WithRetryAfter(reset.Sub(now))
+		}
+	}
+
+	return nil
+}
+
+// Reasons recorded in the status history for a new listing
+const (
+	reasonCreated         = "Listing created, files queued for validation"
+	reasonDuplicateReview = "Title or description matches a recent listing from the same seller, held for review"
+)
+
+// initialStatus picks PENDING_REVIEW for listings that look like the seller pasting the same thing over and over,
+// which the validation worker leaves alone for a moderator. Everything else starts in PENDING_VALIDATION as usual.
+// The reason goes into the listing's status history.
+func (s *svc) initialStatus(ctx context.Context, userInfo auth.UserInfo, sellerID pgtype.UUID, req *CreateListingRequest) (repo.ListingStatus, string, error) {
+	if s.limits.DuplicateWindow <= 0 || s.limits.DuplicateThreshold <= 0 || userInfo.HasRole(auth.RoleModerator) {
+		return repo.ListingStatusPENDINGVALIDATION, reasonCreated, nil
+	}
+
+	duplicates, err := s.repo.CountRecentDuplicateListings(ctx, repo.CountRecentDuplicateListingsParams{
+		SellerID:    sellerID,
+		Since:       pgtype.Timestamptz{Time: s.now().Add(-s.limits.DuplicateWindow), Valid: true},
+		Title:       req.Title,
+		Description: req.Description,
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to check for duplicate listings", "error", err)
+		return "", "", errors.New(errors.ErrInternal, "Failed to create listing. Please try again later.", fmt.Errorf("failed to count duplicate listings: %w", err))
+	}
+
+	if duplicates >= s.limits.DuplicateThreshold {
+		s.logger.WarnContext(ctx, "Listing duplicates recent listings, holding for review", "user", userInfo.ID, "duplicates", duplicates)
+		return repo.ListingStatusPENDINGREVIEW, reasonDuplicateReview, nil
+	}
+	return repo.ListingStatusPENDINGVALIDATION, reasonCreated, nil
+}
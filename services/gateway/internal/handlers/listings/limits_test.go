@@ -0,0 +1,210 @@
+package listings
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/ratelimit"
+	"gateway/internal/testutil"
+	"regexp"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCounter keys counts by window start, like the Redis store, so moving the clock across a boundary starts over
+type fakeCounter struct {
+	counts map[string]int64
+	err    error
+}
+
+func (f *fakeCounter) Incr(_ context.Context, subject string, window ratelimit.Window, now time.Time) (int64, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	if f.counts == nil {
+		f.counts = map[string]int64{}
+	}
+	start, _ := window.Bounds(now)
+	key := fmt.Sprintf("%s:%s:%d", subject, window.Name, start.Unix())
+	f.counts[key]++
+	return f.counts[key], nil
+}
+
+func newLimitsTest(limits CreationLimits) (*svc, *fakeCounter, *time.Time) {
+	counter := &fakeCounter{}
+	clock := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	service := &svc{
+		logger:    testutil.NewTestLogger(),
+		limits:    limits,
+		creations: counter,
+		now:       func() time.Time { return clock },
+	}
+	return service, counter, &clock
+}
+
+func mustUUID(t *testing.T, id string) pgtype.UUID {
+	t.Helper()
+	var u pgtype.UUID
+	require.NoError(t, u.Scan(id))
+	return u
+}
+
+var (
+	limitsUser     = auth.UserInfo{ID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"}
+	verifiedSeller = repo.Seller{PayoutStatus: repo.PayoutStatusVERIFIED}
+	newSeller      = repo.Seller{PayoutStatus: repo.PayoutStatusNOTSTARTED}
+)
+
+func TestCheckCreationRate_HourlyWindowBoundary(t *testing.T) {
+	// SCENARIO: A seller uses their whole hourly allowance, tries once more just before the hour, then again on the hour.
+	// EXPECT: The extra attempt gets a 429 pointing at the top of the hour, and the next window starts from zero.
+
+	service, _, clock := newLimitsTest(CreationLimits{PerHour: 10})
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, service.checkCreationRate(ctx, limitsUser, verifiedSeller), "attempt %d", i+1)
+	}
+
+	*clock = time.Date(2025, 3, 1, 10, 59, 59, 0, time.UTC)
+	err := service.checkCreationRate(ctx, limitsUser, verifiedSeller)
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrRateLimited, appErr.Code)
+	assert.Equal(t, errors.ReasonListingRateLimited, appErr.Reason)
+	assert.Equal(t, "2025-03-01T11:00:00Z", appErr.Params["reset_at"])
+	assert.Equal(t, time.Second, appErr.RetryAfter)
+
+	*clock = time.Date(2025, 3, 1, 11, 0, 0, 0, time.UTC)
+	assert.NoError(t, service.checkCreationRate(ctx, limitsUser, verifiedSeller))
+}
+
+func TestCheckCreationRate_DailyLimitOnlyForUnverifiedSellers(t *testing.T) {
+	// SCENARIO: Sellers spread creations over the day so the hourly limit never trips.
+	// EXPECT: The daily limit stops an unverified seller at 3, a verified one carries on, and it resets at UTC midnight.
+
+	service, _, clock := newLimitsTest(CreationLimits{PerHour: 10, PerDayUnverified: 3})
+	ctx := context.Background()
+	verifiedUser := auth.UserInfo{ID: "b0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"}
+
+	for i := 0; i < 3; i++ {
+		*clock = time.Date(2025, 3, 1, 9+i, 0, 0, 0, time.UTC)
+		require.NoError(t, service.checkCreationRate(ctx, limitsUser, newSeller))
+		require.NoError(t, service.checkCreationRate(ctx, verifiedUser, verifiedSeller))
+	}
+
+	*clock = time.Date(2025, 3, 1, 23, 0, 0, 0, time.UTC)
+	err := service.checkCreationRate(ctx, limitsUser, newSeller)
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrRateLimited, appErr.Code)
+	assert.Equal(t, "2025-03-02T00:00:00Z", appErr.Params["reset_at"])
+	assert.Equal(t, time.Hour, appErr.RetryAfter)
+
+	assert.NoError(t, service.checkCreationRate(ctx, verifiedUser, verifiedSeller))
+
+	*clock = time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, service.checkCreationRate(ctx, limitsUser, newSeller))
+}
+
+func TestCheckCreationRate_ModeratorBypasses(t *testing.T) {
+	service, counter, _ := newLimitsTest(CreationLimits{PerHour: 1, PerDayUnverified: 1})
+	moderator := auth.UserInfo{ID: limitsUser.ID, Roles: []string{auth.RoleModerator}}
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, service.checkCreationRate(context.Background(), moderator, newSeller))
+	}
+	assert.Empty(t, counter.counts, "moderators aren't counted at all")
+}
+
+func TestCheckCreationRate_CounterDown_Allows(t *testing.T) {
+	service, counter, _ := newLimitsTest(CreationLimits{PerHour: 1})
+	counter.err = stderrors.New("connection refused")
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, service.checkCreationRate(context.Background(), limitsUser, verifiedSeller))
+	}
+}
+
+func TestCreateListing_RateLimited_NoTransaction(t *testing.T) {
+	service, mockPool, userInfo, req := newSellerCheckTest(t)
+	service.termsVersion = "1"
+	service.limits = CreationLimits{PerHour: 1}
+	service.creations = &fakeCounter{}
+	service.now = time.Now
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT user_id, display_name`)).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(testutil.SellerCols).AddRow(
+			userInfo.ID, "Tester Prints", "GB", "VERIFIED", "1", time.Now(), time.Now(), time.Now(), nil, nil, nil, false, nil,
+		))
+	// Used up by an earlier request
+	require.NoError(t, service.checkCreationRate(context.Background(), userInfo, verifiedSeller))
+
+	_, err := service.CreateListing(context.Background(), userInfo, req)
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrRateLimited, appErr.Code)
+	// Rejected before a transaction was started
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestInitialStatus_DuplicateContent(t *testing.T) {
+	tests := []struct {
+		name       string
+		roles      []string
+		duplicates int64
+		queried    bool
+		want       repo.ListingStatus
+	}{
+		{name: "fresh content is validated as usual", duplicates: 0, queried: true, want: repo.ListingStatusPENDINGVALIDATION},
+		{name: "same title or description as a recent listing is held", duplicates: 1, queried: true, want: repo.ListingStatusPENDINGREVIEW},
+		{name: "many copies are held", duplicates: 7, queried: true, want: repo.ListingStatusPENDINGREVIEW},
+		{name: "moderators are never held", roles: []string{auth.RoleModerator}, want: repo.ListingStatusPENDINGVALIDATION},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockPool, userInfo, req := newSellerCheckTest(t)
+			clock := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+			service.now = func() time.Time { return clock }
+			service.limits = CreationLimits{DuplicateWindow: 24 * time.Hour, DuplicateThreshold: 1}
+			userInfo.Roles = tt.roles
+
+			userUUID := mustUUID(t, userInfo.ID)
+			if tt.queried {
+				mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM listings`)).
+					WithArgs(userUUID, pgxmock.AnyArg(), req.Title, req.Description).
+					WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(tt.duplicates))
+			}
+
+			status, reason, err := service.initialStatus(context.Background(), userInfo, userUUID, req)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, status)
+			assert.NotEmpty(t, reason, "recorded in the status history")
+			assert.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestInitialStatus_Disabled_NoQuery(t *testing.T) {
+	service, mockPool, userInfo, req := newSellerCheckTest(t)
+
+	status, _, err := service.initialStatus(context.Background(), userInfo, mustUUID(t, userInfo.ID), req)
+
+	require.NoError(t, err)
+	assert.Equal(t, repo.ListingStatusPENDINGVALIDATION, status)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
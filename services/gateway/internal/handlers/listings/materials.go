@@ -0,0 +1,35 @@
+package listings
+
+import (
+	"fmt"
+	"gateway/internal/errors"
+	"shared/materials"
+	"strings"
+)
+
+// canonicalizeMaterials is materials.Normalize for what a seller submits: a blank entry or one that isn't on the list
+// is refused, with the closest canonical name when one is close enough to be what they meant
+func canonicalizeMaterials(values []string) ([]string, *errors.AppError) {
+	for _, value := range values {
+		if strings.TrimSpace(value) == "" {
+			return nil, errors.New(errors.ErrInvalidInput, "Material list cannot contain empty entries", nil).WithReason(errors.ReasonListingMaterialEmpty)
+		}
+		if _, ok := materials.Lookup(value); !ok {
+			return nil, unknownMaterial(value)
+		}
+	}
+	return materials.Normalize(values), nil
+}
+
+func unknownMaterial(value string) *errors.AppError {
+	value = strings.TrimSpace(value)
+	if suggestion := materials.Suggest(value); suggestion != "" {
+		return errors.New(errors.ErrInvalidInput, fmt.Sprintf("'%s' isn't a material we know, did you mean '%s'?", value, suggestion), nil).
+			WithReason(errors.ReasonListingMaterialSuggestion).
+			WithParam("value", value).
+			WithParam("suggestion", suggestion)
+	}
+	return errors.New(errors.ErrInvalidInput, fmt.Sprintf("'%s' isn't a material we know, pick one from the list", value), nil).
+		WithReason(errors.ReasonListingMaterialUnknown).
+		WithParam("value", value)
+}
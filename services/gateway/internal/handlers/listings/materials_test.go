@@ -0,0 +1,47 @@
+package listings
+
+import (
+	"gateway/internal/errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalizeMaterials(t *testing.T) {
+	tests := []struct {
+		name           string
+		values         []string
+		want           []string
+		wantReason     errors.Reason
+		wantSuggestion string
+	}{
+		{name: "Exact", values: []string{"PLA", "PETG"}, want: []string{"PLA", "PETG"}},
+		{name: "Mixed case", values: []string{"pLa", "nylon"}, want: []string{"PLA", "Nylon"}},
+		{name: "Aliases", values: []string{"Polylactic Acid", "PET-G", "pla plus"}, want: []string{"PLA", "PETG", "PLA+"}},
+		{name: "Spacing and hyphens", values: []string{"  pla-cf ", "wood  pla"}, want: []string{"PLA-CF", "Wood PLA"}},
+		{name: "Repeats collapse", values: []string{"PLA", "pla", "polylactic acid"}, want: []string{"PLA"}},
+		{name: "Blank refused", values: []string{"PLA", " "}, wantReason: errors.ReasonListingMaterialEmpty},
+		{name: "Typo suggests", values: []string{"PLAA"}, wantReason: errors.ReasonListingMaterialSuggestion, wantSuggestion: "PLA"},
+		{name: "Longer typo suggests", values: []string{"polycarbonat"}, wantReason: errors.ReasonListingMaterialSuggestion, wantSuggestion: "PC"},
+		{name: "Nothing close", values: []string{"unobtainium"}, wantReason: errors.ReasonListingMaterialUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, appErr := canonicalizeMaterials(tt.values)
+			if tt.wantReason != "" {
+				require.NotNil(t, appErr)
+				assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+				assert.Equal(t, tt.wantReason, appErr.Reason)
+				if tt.wantSuggestion != "" {
+					assert.Equal(t, tt.wantSuggestion, appErr.Params["suggestion"])
+					assert.Contains(t, appErr.Message, "did you mean '"+tt.wantSuggestion+"'?")
+				}
+				return
+			}
+			require.Nil(t, appErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package listings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// MaxFileMetadataBytes caps listing_files.metadata. The validation worker refuses to write more,
+// and anything bigger that still makes it into the table is dropped rather than sent to clients.
+const MaxFileMetadataBytes = 16 * 1024
+
+var (
+	ErrFileMetadataTooLarge = errors.New("file metadata exceeds size limit")
+	ErrFileMetadataShape    = errors.New("file metadata has an unexpected shape")
+)
+
+// FileMetadata is the whitelist of what the validation worker may tell clients about a file.
+// listing_files.metadata is free-form JSONB, so it's always parsed into this rather than passed through.
+type FileMetadata struct {
+	Format        string       `json:"format,omitempty"` // Detected MIME type, e.g. "model/stl"
+	TriangleCount *int64       `json:"triangle_count,omitempty"`
+	BoundingBox   *BoundingBox `json:"bounding_box,omitempty"`
+	ByteSize      *int64       `json:"byte_size,omitempty"`
+	Scan          *FileScan    `json:"scan,omitempty"`
+}
+
+// BoundingBox is in model space, which for everything we accept means millimetres
+type BoundingBox struct {
+	Min [3]float64 `json:"min"`
+	Max [3]float64 `json:"max"`
+}
+
+// FileScan holds the mesh checks run by the validation worker
+type FileScan struct {
+	Watertight        *bool `json:"watertight,omitempty"`
+	WindingConsistent *bool `json:"winding_consistent,omitempty"`
+}
+
+// ParseFileMetadata validates a raw metadata document and normalizes it into FileMetadata.
+// Keys written by older validation workers ("mime", "triangles", "bounds", ...) are mapped onto the
+// current fields. Unknown keys are dropped and returned so the caller can log them.
+// An empty or null document gives nil metadata and no error.
+func ParseFileMetadata(raw []byte) (*FileMetadata, []string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil, nil
+	}
+	if len(raw) > MaxFileMetadataBytes {
+		return nil, nil, fmt.Errorf("%w: %d bytes", ErrFileMetadataTooLarge, len(raw))
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrFileMetadataShape, err)
+	}
+
+	meta := &FileMetadata{}
+	var dropped []string
+	for key, value := range doc {
+		if string(value) == "null" {
+			continue
+		}
+
+		var err error
+		switch key {
+		case "format", "mime":
+			err = json.Unmarshal(value, &meta.Format)
+		case "triangle_count", "triangles":
+			err = json.Unmarshal(value, &meta.TriangleCount)
+		case "byte_size":
+			err = json.Unmarshal(value, &meta.ByteSize)
+		case "bounding_box":
+			var box struct{ Min, Max []float64 }
+			if err = json.Unmarshal(value, &box); err == nil {
+				meta.BoundingBox, err = newBoundingBox(box.Min, box.Max)
+			}
+		case "bounds":
+			// Older workers wrote trimesh's bounds as-is: [[minX, minY, minZ], [maxX, maxY, maxZ]]
+			var bounds [][]float64
+			if err = json.Unmarshal(value, &bounds); err == nil {
+				if len(bounds) != 2 {
+					err = fmt.Errorf("want 2 corners, got %d", len(bounds))
+					break
+				}
+				meta.BoundingBox, err = newBoundingBox(bounds[0], bounds[1])
+			}
+		case "scan":
+			err = json.Unmarshal(value, &meta.Scan)
+		case "is_watertight":
+			err = json.Unmarshal(value, &meta.scan().Watertight)
+		case "is_winding_consistent":
+			err = json.Unmarshal(value, &meta.scan().WindingConsistent)
+		default:
+			dropped = append(dropped, key)
+			continue
+		}
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %s: %v", ErrFileMetadataShape, key, err)
+		}
+	}
+
+	sort.Strings(dropped)
+	return meta, dropped, nil
+}
+
+// newBoundingBox checks both corners are 3D, json.Unmarshal into a [3]float64 would silently zero-fill
+func newBoundingBox(min, max []float64) (*BoundingBox, error) {
+	if len(min) != 3 || len(max) != 3 {
+		return nil, fmt.Errorf("corners must have 3 coordinates, got %d and %d", len(min), len(max))
+	}
+	return &BoundingBox{Min: [3]float64(min), Max: [3]float64(max)}, nil
+}
+
+func (m *FileMetadata) scan() *FileScan {
+	if m.Scan == nil {
+		m.Scan = &FileScan{}
+	}
+	return m.Scan
+}
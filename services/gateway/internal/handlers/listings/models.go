@@ -1,10 +1,17 @@
 package listings
 
 import (
-	"encoding/json"
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/errors"
+	"shared/materials"
 	"time"
 )
 
+// MaterialsResponse is the canonical list recommended materials are checked against, in the form's order
+type MaterialsResponse struct {
+	Materials []materials.Material `json:"materials"`
+}
+
 type CreateListingRequest struct {
 	// Core Identity
 	Title       string   `json:"title"`
@@ -13,8 +20,7 @@ type CreateListingRequest struct {
 	License     string   `json:"license"`
 
 	// Sales & Merch
-	// Note: TS sends 'price' as a number (float), likely in major units (e.g., 10.50).
-	// We will convert this to 'PriceMinUnit' in the service logic.
+	// Whole minor units, 1050 is 10.50. Stored as is, a price never passes through a decimal.
 	PriceMinUnit int64  `json:"price_min_unit"`
 	Currency     string `json:"currency"`
 	IsFree       bool   `json:"isFree"`
@@ -37,6 +43,34 @@ type CreateListingRequest struct {
 	Files []CreateListingFile `json:"files"`
 }
 
+// CreateListingResponse is the created listing plus anything that looked off, see listingWarnings.
+// Warnings never block the listing, they're for the seller to double check.
+type CreateListingResponse struct {
+	repo.Listing
+	Warnings []ListingWarning `json:"warnings"`
+}
+
+// UpdateListingResponse is CreateListingResponse for the PUT edit, warned about as the listing stands after it
+type UpdateListingResponse struct {
+	repo.Listing
+	Warnings []ListingWarning `json:"warnings"`
+}
+
+type ListingWarning struct {
+	Field   string            `json:"field"` // Request field the warning is about, e.g. "printerSettings.recommendedNozzleTempC"
+	Reason  errors.Reason     `json:"reason"`
+	Message string            `json:"message"`
+	Params  map[string]string `json:"params"` // Values for the {placeholders} in the reason's catalogue message
+}
+
+// SellerListingsQuery narrows and orders GET /listings, the caller's own listings. Empty fields keep the defaults,
+// every status newest first.
+type SellerListingsQuery struct {
+	Status string // A listing status, e.g. PENDING_VALIDATION or ACTIVE
+	Sort   string // created_at, updated_at, price_min_unit or downloads_count
+	Order  string // asc or desc, desc when only Sort is given
+}
+
 type UpdateListingRequest struct {
 	// Core Identity
 	Title       *string  `json:"title"` // Pointer allows distinguishing "" from nil
@@ -62,10 +96,14 @@ type UpdateListingRequest struct {
 
 	// Community
 	IsRemixingAllowed *bool `json:"isRemixingAllowed"`
+
+	// Any value, even empty, asks for the file set to change. Refused with a 409 while the current files are validating.
+	Files []CreateListingFile `json:"files"`
 }
 type UpdateListingPrinterSettings struct {
-	NozzleDiameter         *string   `json:"nozzleDiameter"`
-	NozzleTemperature      *float64  `json:"nozzleTemperature"` // Pointer for null
+	NozzleDiameter *string `json:"nozzleDiameter"` // "0.4" or "0.4mm", empty clears it
+	// Deprecated: use RecommendedNozzleTempC, this is only read when that is not set
+	NozzleTemperature      *float64  `json:"nozzleTemperature"`
 	RecommendedMaterials   *[]string `json:"recommendedMaterials"`
 	RecommendedNozzleTempC *float64  `json:"recommendedNozzleTempC"`
 	IsAssemblyRequired     *bool     `json:"isAssemblyRequired"`
@@ -75,13 +113,15 @@ type UpdateListingPrinterSettings struct {
 }
 
 type ListingDimensions struct {
-	X float64 `json:"x"`
-	Y float64 `json:"y"`
-	Z float64 `json:"z"`
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+	Z    float64 `json:"z"`
+	Unit string  `json:"unit"` // mm or in, mm when empty. Stored in mm either way.
 }
 type ListingPrinterSettings struct {
-	NozzleDiameter         *string   `json:"nozzleDiameter"`
-	NozzleTemperature      *float64  `json:"nozzleTemperature"` // Pointer for null
+	NozzleDiameter *string `json:"nozzleDiameter"` // "0.4" or "0.4mm", empty clears it
+	// Deprecated: use RecommendedNozzleTempC, this is only read when that is not set
+	NozzleTemperature      *float64  `json:"nozzleTemperature"`
 	RecommendedMaterials   *[]string `json:"recommendedMaterials"`
 	RecommendedNozzleTempC *float64  `json:"recommendedNozzleTempC"`
 	IsAssemblyRequired     bool      `json:"isAssemblyRequired"`
@@ -97,18 +137,19 @@ type CreateListingFile struct {
 }
 
 type ListingFileDTO struct {
-	ID           string          `json:"id"`
-	FilePath     *string         `json:"file_path"` // Presigned URL for accessing the file if the file has been validated
-	FileType     string          `json:"file_type"`
-	Status       string          `json:"status"`
-	Size         int64           `json:"size"`
-	Metadata     json.RawMessage `json:"metadata"`
-	ErrorMessage *string         `json:"error_message"`
-	IsGenerated  bool            `json:"is_generated"`
-	SourceFileID *string         `json:"source_file_id,omitempty"`
+	ID           string        `json:"id"`
+	FilePath     *string       `json:"file_path"` // Presigned URL for accessing the file if the file has been validated
+	FileType     string        `json:"file_type"`
+	Status       string        `json:"status"`
+	Size         int64         `json:"size"`
+	Metadata     *FileMetadata `json:"metadata,omitempty"` // Nil until validated, see ParseFileMetadata
+	ErrorMessage *string       `json:"error_message"`
+	IsGenerated  bool          `json:"is_generated"`
+	SourceFileID *string       `json:"source_file_id,omitempty"`
 }
 
-// ListingResponse maps to the TypeScript interface 'ListingProps'
+// ListingResponse maps to the TypeScript interface 'ListingProps'. Array fields are [] when empty and never null,
+// optional scalars are pointers and null when unset. TestListingResponse_ZeroValueShape pins this down.
 type ListingResponse struct {
 	ID string `json:"id"`
 
@@ -125,14 +166,31 @@ type ListingResponse struct {
 	Currency     string   `json:"currency"`
 	Categories   []string `json:"categories"`
 	License      string   `json:"license"`
+	Language     string   `json:"language"` // Detected from the title and description, e.g. "de", or "und" when it couldn't be told
+	// PriceMinUnit in the currency asked for with display_currency or the user's preference, at the day's reference
+	// rate. Approximate and for display only, the listing is always charged in Currency. Omitted when nothing was asked
+	// for, the listing is free or in that currency already, or there's no fresh rate.
+	PriceConverted    *int64 `json:"price_converted,omitempty"`
+	ConvertedCurrency string `json:"converted_currency,omitempty"`
+	ConvertedRateDate string `json:"converted_rate_date,omitempty"` // The day the rate was published, e.g. "2026-10-15"
+	// SalePriceMinUnit converted the same way, set with PriceConverted while a sale is on
+	SalePriceConverted *int64 `json:"sale_price_converted,omitempty"`
 
 	// --- Files & Images ---
 	ThumbnailPath *string          `json:"thumbnail_path"`
 	Files         []ListingFileDTO `json:"files"`
+	// What a buyer downloads: the VALID model files, images aren't part of the package. The display is for the
+	// listing page, e.g. "12.4 MB".
+	TotalModelSizeBytes   int64  `json:"total_model_size_bytes"`
+	TotalModelSizeDisplay string `json:"total_model_size_display"`
+	// Packages the files are sold as, cheapest first. Empty when the listing sells every file at PriceMinUnit.
+	Variants []ListingVariant `json:"variants"`
 
 	// --- Remixing ---
 	IsRemixingAllowed bool    `json:"is_remixing_allowed"`
 	ParentListingID   *string `json:"parent_listing_id"`
+	// The parent has been deleted. The ID is kept so the remix still credits it, but it can't be followed.
+	ParentUnavailable bool `json:"parent_unavailable"`
 
 	// --- Physical Properties ---
 	IsPhysical       bool `json:"is_physical"`
@@ -143,16 +201,20 @@ type ListingResponse struct {
 	DimXMM *int `json:"dim_x_mm"`
 	DimYMM *int `json:"dim_y_mm"`
 	DimZMM *int `json:"dim_z_mm"`
+	// The same size written out, in the caller's preferred unit when they're signed in and have one, otherwise mm.
+	// Empty when there's no size.
+	DimensionsDisplay string `json:"dimensions_display,omitempty"`
 
 	// Assembly Hardware
-	IsAssemblyRequired bool      `json:"is_assembly_required"`
-	IsHardwareRequired bool      `json:"is_hardware_required"`
-	HardwareRequired   *[]string `json:"hardware_required"`
+	IsAssemblyRequired bool     `json:"is_assembly_required"`
+	IsHardwareRequired bool     `json:"is_hardware_required"`
+	HardwareRequired   []string `json:"hardware_required"`
 
 	// --- Printer Settings ---
 	IsMulticolor           bool     `json:"is_multicolor"`
 	RecommendedMaterials   []string `json:"recommended_materials"`
 	RecommendedNozzleTempC *int     `json:"recommended_nozzle_temp_c"`
+	NozzleDiameterMM       *float64 `json:"nozzle_diameter_mm"`
 
 	// --- AI Info ---
 	IsAIGenerated bool    `json:"is_ai_generated"`
@@ -164,19 +226,39 @@ type ListingResponse struct {
 	// --- Social Signals ---
 	LikesCount     int `json:"likes_count"`
 	DownloadsCount int `json:"downloads_count"`
+	ViewsCount     int `json:"views_count"`
 	CommentsCount  int `json:"comments_count"`
 
 	// --- Sales ---
 	IsSaleActive     bool       `json:"is_sale_active"`
 	SaleName         *string    `json:"sale_name"`
 	SaleEndTimestamp *time.Time `json:"sale_end_timestamp"`
+	// What the listing sells for during the sale, in the same minor unit as PriceMinUnit
+	SalePriceMinUnit *int64 `json:"sale_price_min_unit"`
+
+	// --- Price Chart ---
+	// Only sent to the owner, their latest price changes newest first
+	PriceHistory []PriceChange `json:"price_history,omitempty"`
+
+	// --- Availability ---
+	// The seller is on vacation until UnavailableUntil, paid downloads are paused until then
+	TemporarilyUnavailable bool       `json:"temporarily_unavailable"`
+	UnavailableUntil       *time.Time `json:"unavailable_until"`
+	SellerAwayMessage      *string    `json:"seller_away_message"`
 
 	// --- Metadata ---
+	// Times are always UTC (RFC3339 ending in Z), CreatedAtUnix is the same instant for clients that sort on it
 	Status        string     `json:"status"`
+	StatusReason  *string    `json:"status_reason"` // Why the listing failed, only set for REJECTED and HIDDEN
 	CreatedAt     time.Time  `json:"created_at"`
+	CreatedAtUnix int64      `json:"created_at_unix"`
 	UpdatedAt     time.Time  `json:"updated_at"`
 	LastIndexedAt *time.Time `json:"last_indexed_at"`
 	DeletedAt     *time.Time `json:"deleted_at,omitempty"` // omitempty is useful here
+	// Only sent to the owner, set while the listings worker has given up putting the listing in search
+	IndexError *string `json:"index_error,omitempty"`
+	// The same hash the search document carries, see POST /listings/hydrate
+	ContentHash string `json:"content_hash"`
 }
 
 // Helper struct for unmarshalling the DB JSONB column internally
@@ -0,0 +1,171 @@
+package listings
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/events"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MaxSuspensionReasonLength is the longest reason a moderator can give, in characters
+const MaxSuspensionReasonLength = 500
+
+// AdminListingDetail is a listing as moderators see it, whether or not the seller deleted it
+type AdminListingDetail struct {
+	ListingResponse
+	DeletedAt *time.Time `json:"deleted_at"` // Null unless the seller deleted the listing
+}
+
+// SuspendListingRequest is the body of PUT /admin/listings/{id}/suspension
+type SuspendListingRequest struct {
+	Reason string `json:"reason"` // Shown to the seller with the listing and in its status history
+}
+
+// SetListingNSFWRequest is the body of PUT /admin/listings/{id}/nsfw
+type SetListingNSFWRequest struct {
+	IsNSFW *bool `json:"is_nsfw"`
+}
+
+// GetAdminListing reads a listing for moderators, soft-deleted ones included. It never comes from or goes into the
+// listing cache, which only holds what everyone else is served.
+func (s *svc) GetAdminListing(ctx context.Context, listingID string) (*AdminListingDetail, error) {
+	var listingUUID pgtype.UUID
+	if err := listingUUID.Scan(listingID); err != nil {
+		return nil, errors.New(errors.ErrInvalidInput, "Invalid listing ID provided", err)
+	}
+
+	row, err := s.repo.GetListingByIDWithFilesIncludingDeleted(ctx, listingUUID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, listingNotFound(listingID).WithReason(errors.ReasonListingNotFound)
+		}
+		s.logger.ErrorContext(ctx, "Failed to fetch listing for moderation", "listing_id", listingID, "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch listing", fmt.Errorf("failed to fetch listing %v: %w", listingID, err))
+	}
+
+	variants, err := s.listingVariants(ctx, []pgtype.UUID{listingUUID})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to fetch listing variants", "listing_id", listingID, "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch listing", fmt.Errorf("failed to fetch variants of %v: %w", listingID, err))
+	}
+
+	detail := &AdminListingDetail{ListingResponse: s.toListingResponse(ctx, repo.GetListingByIDWithFilesRow(row))}
+	if v, ok := variants[row.ID]; ok {
+		detail.Variants = v
+	}
+	if row.DeletedAt.Valid {
+		deletedAt := row.DeletedAt.Time
+		detail.DeletedAt = &deletedAt
+	}
+	return detail, nil
+}
+
+// SuspendListing takes a listing out of search and out of the seller's hands. Sellers can't unpublish or validate a
+// listing out of SUSPENDED. Suspending a listing that already is changes nothing.
+func (s *svc) SuspendListing(ctx context.Context, userInfo auth.UserInfo, listingID string, req *SuspendListingRequest) error {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" || utf8.RuneCountInString(reason) > MaxSuspensionReasonLength {
+		return errors.New(errors.ErrInvalidInput, fmt.Sprintf("Give a reason of up to %d characters", MaxSuspensionReasonLength), nil).
+			WithReason(errors.ReasonListingSuspensionReason).
+			WithParam("max", strconv.Itoa(MaxSuspensionReasonLength))
+	}
+
+	var listingUUID pgtype.UUID
+	if err := listingUUID.Scan(listingID); err != nil {
+		return errors.New(errors.ErrInvalidInput, "Invalid listing ID provided", err)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return errors.New(errors.ErrInternal, "Failed to start transaction. Please try again later.", fmt.Errorf("failed to begin transaction: %w", err))
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.repo.WithTx(tx)
+	existing, err := qtx.GetListingByIDForUpdate(ctx, listingUUID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return listingNotFound(listingID).WithReason(errors.ReasonListingNotFound)
+		}
+		return errors.New(errors.ErrInternal, "Failed to suspend listing", fmt.Errorf("failed to lock listing %v: %w", listingID, err))
+	}
+
+	from := existing.Status.ListingStatus
+	if from == repo.ListingStatusSUSPENDED {
+		return nil
+	}
+
+	if err := qtx.SetListingStatus(ctx, repo.SetListingStatusParams{
+		ID:     listingUUID,
+		Status: repo.NullListingStatus{ListingStatus: repo.ListingStatusSUSPENDED, Valid: true},
+	}); err != nil {
+		return errors.New(errors.ErrInternal, "Failed to suspend listing", fmt.Errorf("failed to suspend listing %v: %w", listingID, err))
+	}
+	if err := recordStatusChange(ctx, qtx, statusChange{
+		ListingID: listingUUID,
+		Actor:     repo.ListingStatusActorMODERATOR,
+		ActorID:   userInfo.ID,
+		From:      &from,
+		To:        repo.ListingStatusSUSPENDED,
+		Reason:    reason,
+	}); err != nil {
+		return errors.New(errors.ErrInternal, "Failed to suspend listing", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.New(errors.ErrInternal, "Failed to suspend listing", fmt.Errorf("failed to commit suspension of %v: %w", listingID, err))
+	}
+
+	s.logger.InfoContext(ctx, "Listing suspended by moderator", "listing_id", listingID, "user_id", userInfo.ID, "from_status", from)
+	// The worker drops listings that aren't ACTIVE from the index when it re-reads them
+	s.reindexModerated(ctx, listingUUID)
+	return nil
+}
+
+// SetListingNSFW overrides what the seller said when they created the listing. They can't change it back, edits
+// leave is_nsfw alone.
+func (s *svc) SetListingNSFW(ctx context.Context, userInfo auth.UserInfo, listingID string, isNSFW bool) error {
+	var listingUUID pgtype.UUID
+	if err := listingUUID.Scan(listingID); err != nil {
+		return errors.New(errors.ErrInvalidInput, "Invalid listing ID provided", err)
+	}
+
+	updated, err := s.repo.SetListingNsfw(ctx, repo.SetListingNsfwParams{ID: listingUUID, IsNsfw: isNSFW})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to set listing NSFW flag", "listing_id", listingID, "error", err)
+		return errors.New(errors.ErrInternal, "Failed to update listing", fmt.Errorf("failed to set NSFW on %v: %w", listingID, err))
+	}
+	if updated == 0 {
+		return listingNotFound(listingID).WithReason(errors.ReasonListingNotFound)
+	}
+
+	s.logger.InfoContext(ctx, "Listing NSFW flag set by moderator", "listing_id", listingID, "user_id", userInfo.ID, "is_nsfw", isNSFW)
+	s.reindexModerated(ctx, listingUUID)
+	return nil
+}
+
+// reindexModerated drops the listing's cached response and has the worker re-read it after a moderator changed it
+func (s *svc) reindexModerated(ctx context.Context, id pgtype.UUID) {
+	s.forgetListing(ctx, id)
+	traceID := ""
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		traceID = spanContext.TraceID().String()
+	}
+	// Search documents are keyed by the dashless ID, the dashed form would index a second document
+	listingID := fmt.Sprintf("%x", id.Bytes)
+	if err := s.eventHandler.RaiseListingIndexEvent(events.ReIndexListingEvent{ListingID: listingID, TraceID: traceID}); err != nil {
+		// Logged only, the change is saved and the stale sweep picks the listing up on its next run
+		s.logger.ErrorContext(ctx, "Failed to raise listing re-index event", "listing_id", listingID, "error", err)
+	}
+}
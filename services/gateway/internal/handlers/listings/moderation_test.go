@@ -0,0 +1,180 @@
+package listings
+
+import (
+	"context"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/events"
+	"gateway/internal/mocks/mockevents"
+	"gateway/internal/testutil"
+	"gateway/internal/testutil/apitest"
+	"gateway/internal/testutil/fixtures"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const moderatorID = "c0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+
+// newModerationTest is newUpdateTest with updateListingID cached and the number of reindexes expected, cached tells
+// whether it still is. Reindexes must use the dashless ID the search documents are keyed by.
+func newModerationTest(t *testing.T, reindexes int) (*svc, pgxmock.PgxPoolIface, func() bool) {
+	t.Helper()
+
+	service, mockPool := newUpdateTest(t)
+	rdb, redis := apitest.NewRedis(t)
+	service.cache = rdb
+	redis.Set(service.listingCache.Key(updateListingID), "{}")
+	mockBus := mockevents.NewBus(t)
+	if reindexes > 0 {
+		mockBus.EXPECT().Publish("listings.index", mock.Anything, "index."+strings.ReplaceAll(updateListingID, "-", "")).Return(nil).Times(reindexes)
+	}
+	service.eventHandler = events.NewEventHandler(mockBus, &events.EventConfig{IndexListingEvent: "listings.index"}, service.logger)
+	return service, mockPool, func() bool { return redis.Exists(service.listingCache.Key(updateListingID)) }
+}
+
+func TestSuspendListing(t *testing.T) {
+	// SCENARIO: A moderator suspends an active listing.
+	// EXPECT: The status change and the reason are recorded against the moderator in one transaction, the cached
+	// listing is dropped and the listing is sent for reindexing so it leaves search.
+
+	service, mockPool, cached := newModerationTest(t, 1)
+
+	eventArgs := anyArgs(8)
+	eventArgs[1] = repo.ListingStatusActorMODERATOR
+	eventArgs[2] = mustUUID(t, moderatorID)
+	eventArgs[3] = repo.NullListingStatus{ListingStatus: repo.ListingStatusACTIVE, Valid: true}
+	eventArgs[4] = repo.ListingStatusSUSPENDED
+	eventArgs[5] = pgtype.Text{String: "Sells a copyrighted model", Valid: true}
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingByIDForUpdate`)).
+		WithArgs(mustUUID(t, updateListingID)).
+		WillReturnRows(listingRows(updateSellerID, "Benchy", "public/thumb.webp", repo.ListingStatusACTIVE))
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: SetListingStatus`)).
+		WithArgs(mustUUID(t, updateListingID), repo.NullListingStatus{ListingStatus: repo.ListingStatusSUSPENDED, Valid: true}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: CreateListingStatusEvent`)).
+		WithArgs(eventArgs...).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockPool.ExpectCommit()
+
+	err := service.SuspendListing(context.Background(), auth.UserInfo{ID: moderatorID}, updateListingID, &SuspendListingRequest{Reason: "  Sells a copyrighted model "})
+
+	require.NoError(t, err)
+	assert.False(t, cached())
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestSuspendListing_AlreadySuspended(t *testing.T) {
+	// SCENARIO: Two moderators suspend the same listing.
+	// EXPECT: The second changes nothing, records nothing and doesn't reindex.
+
+	service, mockPool, cached := newModerationTest(t, 0)
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingByIDForUpdate`)).
+		WithArgs(mustUUID(t, updateListingID)).
+		WillReturnRows(listingRows(updateSellerID, "Benchy", "public/thumb.webp", repo.ListingStatusSUSPENDED))
+	mockPool.ExpectRollback()
+
+	err := service.SuspendListing(context.Background(), auth.UserInfo{ID: moderatorID}, updateListingID, &SuspendListingRequest{Reason: "Duplicate report"})
+
+	require.NoError(t, err)
+	assert.True(t, cached())
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestSuspendListing_Refused(t *testing.T) {
+	tests := map[string]struct {
+		reason     string
+		wantReason errors.Reason
+	}{
+		"Blank reason":    {reason: " \n ", wantReason: errors.ReasonListingSuspensionReason},
+		"Long reason":     {reason: strings.Repeat("ü", MaxSuspensionReasonLength+1), wantReason: errors.ReasonListingSuspensionReason},
+		"Missing listing": {reason: "Spam", wantReason: errors.ReasonListingNotFound},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			service, mockPool, _ := newModerationTest(t, 0)
+			if tt.wantReason == errors.ReasonListingNotFound {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingByIDForUpdate`)).
+					WithArgs(mustUUID(t, updateListingID)).
+					WillReturnError(pgx.ErrNoRows)
+				mockPool.ExpectRollback()
+			}
+
+			err := service.SuspendListing(context.Background(), auth.UserInfo{ID: moderatorID}, updateListingID, &SuspendListingRequest{Reason: tt.reason})
+
+			var appErr *errors.AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, tt.wantReason, appErr.Reason)
+			assert.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSetListingNSFW(t *testing.T) {
+	// SCENARIO: A moderator marks a listing NSFW the seller didn't.
+	// EXPECT: The flag is saved, the cached listing is dropped and the listing is reindexed so search hides it.
+
+	service, mockPool, cached := newModerationTest(t, 1)
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: SetListingNsfw`)).
+		WithArgs(mustUUID(t, updateListingID), true).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	err := service.SetListingNSFW(context.Background(), auth.UserInfo{ID: moderatorID}, updateListingID, true)
+
+	require.NoError(t, err)
+	assert.False(t, cached())
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestSetListingNSFW_Deleted(t *testing.T) {
+	service, mockPool, _ := newModerationTest(t, 0)
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: SetListingNsfw`)).
+		WithArgs(mustUUID(t, updateListingID), false).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	err := service.SetListingNSFW(context.Background(), auth.UserInfo{ID: moderatorID}, updateListingID, false)
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ReasonListingNotFound, appErr.Reason)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestGetAdminListing_Deleted(t *testing.T) {
+	// SCENARIO: A moderator opens a listing the seller deleted.
+	// EXPECT: It's returned with when it was deleted.
+
+	service, mockPool := newUpdateTest(t)
+	deletedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingByIDWithFilesIncludingDeleted`)).
+		WithArgs(mustUUID(t, updateListingID)).
+		WillReturnRows(fixtures.ListingWithFilesRows(fixtures.NewListingRow(
+			fixtures.WithID(updateListingID), fixtures.WithSeller(updateSellerID), fixtures.WithDeleted(deletedAt),
+		)))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListListingVariants`)).
+		WithArgs([]pgtype.UUID{mustUUID(t, updateListingID)}).
+		WillReturnRows(pgxmock.NewRows(testutil.ListingVariantCols))
+
+	listing, err := service.GetAdminListing(context.Background(), updateListingID)
+
+	require.NoError(t, err)
+	assert.Equal(t, strings.ReplaceAll(updateListingID, "-", ""), listing.ID)
+	require.NotNil(t, listing.DeletedAt)
+	assert.Equal(t, deletedAt, *listing.DeletedAt)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
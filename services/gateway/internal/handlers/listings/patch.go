@@ -0,0 +1,339 @@
+package listings
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gateway/internal/errors"
+	"shared/materials"
+	"sort"
+	"strings"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// PatchField is one member of a JSON merge patch (RFC 7396), absent, null or a value
+type PatchField[T any] struct {
+	Set   bool // The member was in the patch, otherwise the column is left alone
+	Null  bool // The member was null, which clears the column
+	Value T
+}
+
+// fromPtr reads a PUT field, where nil means unchanged and there is no way to say null
+func fromPtr[T any](v *T) PatchField[T] {
+	if v == nil {
+		return PatchField[T]{}
+	}
+	return PatchField[T]{Set: true, Value: *v}
+}
+
+// ListingPatch is an edit to a listing. PATCH parses it from a merge patch with ParseListingPatch, PUT builds it from
+// an UpdateListingRequest. Only the nullable columns accept Null, the parser refuses it for the rest.
+type ListingPatch struct {
+	Title             PatchField[string]
+	Description       PatchField[string] // Nullable
+	License           PatchField[string]
+	PriceMinUnit      PatchField[int64]
+	Currency          PatchField[string]
+	IsNSFW            PatchField[bool]
+	IsPhysical        PatchField[bool]
+	IsAIGenerated     PatchField[bool]
+	AIModelName       PatchField[string] // Nullable, blank is null too
+	IsRemixingAllowed PatchField[bool]
+	// Replaced as a whole rather than merged, a size is only valid with all three. Nullable.
+	Dimensions PatchField[ListingDimensions]
+
+	// sale
+	SaleName         PatchField[string] // Nullable
+	SalePriceMinUnit PatchField[int64]  // Nullable
+
+	// printerSettings
+	IsAssemblyRequired     PatchField[bool]
+	IsHardwareRequired     PatchField[bool]
+	IsMulticolor           PatchField[bool]
+	HardwareRequired       PatchField[[]string] // Nullable
+	RecommendedMaterials   PatchField[[]string] // Nullable
+	RecommendedNozzleTempC PatchField[float64]  // Nullable
+	NozzleDiameter         PatchField[string]   // Nullable, blank is null too
+
+	// Files is true when the edit asks for a different file set, which existing listings can't have yet
+	Files bool
+}
+
+// Patch is the PUT body as a ListingPatch. PUT keeps its original meaning: nil leaves a field alone, the blank
+// AI model name and nozzle diameter are the only ways to clear anything, and isMulticolor is ignored.
+func (req *UpdateListingRequest) Patch() *ListingPatch {
+	patch := &ListingPatch{
+		Title:             fromPtr(req.Title),
+		Description:       fromPtr(req.Description),
+		License:           fromPtr(req.License),
+		PriceMinUnit:      fromPtr(req.PriceMinUnit),
+		Currency:          fromPtr(req.Currency),
+		IsNSFW:            fromPtr(req.IsNSFW),
+		IsPhysical:        fromPtr(req.IsPhysical),
+		IsAIGenerated:     fromPtr(req.IsAIGenerated),
+		AIModelName:       fromPtr(req.AIModelName),
+		IsRemixingAllowed: fromPtr(req.IsRemixingAllowed),
+		Dimensions:        fromPtr(req.Dimensions),
+		Files:             req.Files != nil,
+	}
+	if ps := req.PrinterSettings; ps != nil {
+		patch.IsAssemblyRequired = fromPtr(ps.IsAssemblyRequired)
+		patch.IsHardwareRequired = fromPtr(ps.IsHardwareRequired)
+		patch.HardwareRequired = fromPtr(ps.HardwareRequired)
+		patch.RecommendedMaterials = fromPtr(ps.RecommendedMaterials)
+		patch.RecommendedNozzleTempC = fromPtr(nozzleTempC(ps.RecommendedNozzleTempC, ps.NozzleTemperature))
+		patch.NozzleDiameter = fromPtr(ps.NozzleDiameter)
+	}
+	return patch
+}
+
+// ParseListingPatch reads an RFC 7396 merge patch. Members use the PUT body's names. A member that is absent leaves
+// the field alone, null clears it and any other value replaces it. A null printerSettings clears every printer setting.
+// Unknown members, nulls for required fields and values of the wrong type are refused rather than ignored.
+func ParseListingPatch(body []byte) (*ListingPatch, *errors.AppError) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		return nil, errors.New(errors.ErrInvalidInput, "A merge patch must be a JSON object", err)
+	}
+
+	patch := &ListingPatch{}
+	decoders := []*errors.AppError{
+		decodeMember(members, "", "title", false, &patch.Title),
+		decodeMember(members, "", "description", true, &patch.Description),
+		decodeMember(members, "", "license", false, &patch.License),
+		decodeMember(members, "", "price_min_unit", false, &patch.PriceMinUnit),
+		decodeMember(members, "", "currency", false, &patch.Currency),
+		decodeMember(members, "", "isNSFW", false, &patch.IsNSFW),
+		decodeMember(members, "", "isPhysical", false, &patch.IsPhysical),
+		decodeMember(members, "", "isAIGenerated", false, &patch.IsAIGenerated),
+		decodeMember(members, "", "aiModelName", true, &patch.AIModelName),
+		decodeMember(members, "", "isRemixingAllowed", false, &patch.IsRemixingAllowed),
+		decodeMember(members, "", "dimensions", true, &patch.Dimensions),
+		decodeMember(members, "", "sale_name", true, &patch.SaleName),
+		decodeMember(members, "", "sale_price_min_unit", true, &patch.SalePriceMinUnit),
+		patch.decodePrinterSettings(members),
+	}
+	for _, appErr := range decoders {
+		if appErr != nil {
+			return nil, appErr
+		}
+	}
+
+	if _, ok := members["files"]; ok {
+		patch.Files = true
+		delete(members, "files")
+	}
+	if appErr := unknownMember(members, ""); appErr != nil {
+		return nil, appErr
+	}
+	return patch, nil
+}
+
+func (p *ListingPatch) decodePrinterSettings(members map[string]json.RawMessage) *errors.AppError {
+	const name = "printerSettings"
+	raw, ok := members[name]
+	if !ok {
+		return nil
+	}
+	delete(members, name)
+
+	if isNull(raw) {
+		p.IsAssemblyRequired = PatchField[bool]{Set: true}
+		p.IsHardwareRequired = PatchField[bool]{Set: true}
+		p.IsMulticolor = PatchField[bool]{Set: true}
+		p.HardwareRequired = PatchField[[]string]{Set: true, Null: true}
+		p.RecommendedMaterials = PatchField[[]string]{Set: true, Null: true}
+		p.RecommendedNozzleTempC = PatchField[float64]{Set: true, Null: true}
+		p.NozzleDiameter = PatchField[string]{Set: true, Null: true}
+		return nil
+	}
+
+	var settings map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &settings); err != nil {
+		return invalidMember(name, err)
+	}
+	prefix := name + "."
+	for _, appErr := range []*errors.AppError{
+		decodeMember(settings, prefix, "isAssemblyRequired", false, &p.IsAssemblyRequired),
+		decodeMember(settings, prefix, "isHardwareRequired", false, &p.IsHardwareRequired),
+		decodeMember(settings, prefix, "isMulticolor", false, &p.IsMulticolor),
+		decodeMember(settings, prefix, "hardwareRequired", true, &p.HardwareRequired),
+		decodeMember(settings, prefix, "recommendedMaterials", true, &p.RecommendedMaterials),
+		decodeMember(settings, prefix, "recommendedNozzleTempC", true, &p.RecommendedNozzleTempC),
+		decodeMember(settings, prefix, "nozzleDiameter", true, &p.NozzleDiameter),
+	} {
+		if appErr != nil {
+			return appErr
+		}
+	}
+	return unknownMember(settings, prefix)
+}
+
+// decodeMember moves members[name] into field, so whatever is left over afterwards is unknown
+func decodeMember[T any](members map[string]json.RawMessage, prefix, name string, nullable bool, field *PatchField[T]) *errors.AppError {
+	raw, ok := members[name]
+	if !ok {
+		return nil
+	}
+	delete(members, name)
+
+	if isNull(raw) {
+		if !nullable {
+			return errors.New(errors.ErrInvalidInput, fmt.Sprintf("'%s' can't be null", prefix+name), nil).
+				WithReason(errors.ReasonListingPatchNotNullable).
+				WithParam("field", prefix+name)
+		}
+		*field = PatchField[T]{Set: true, Null: true}
+		return nil
+	}
+
+	var value T
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return invalidMember(prefix+name, err)
+	}
+	*field = PatchField[T]{Set: true, Value: value}
+	return nil
+}
+
+func isNull(raw json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
+
+func invalidMember(field string, err error) *errors.AppError {
+	return errors.New(errors.ErrInvalidInput, fmt.Sprintf("'%s' has the wrong type", field), err).
+		WithReason(errors.ReasonListingPatchInvalid).
+		WithParam("field", field)
+}
+
+// unknownMember refuses the first leftover member, sorted so the error doesn't change between requests
+func unknownMember(members map[string]json.RawMessage, prefix string) *errors.AppError {
+	if len(members) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return errors.New(errors.ErrInvalidInput, fmt.Sprintf("'%s' isn't a listing field that can be changed", prefix+names[0]), nil).
+		WithReason(errors.ReasonListingPatchUnknownField).
+		WithParam("field", prefix+names[0])
+}
+
+// CreateUpdatedListing applies the patch to the listing as it stands, checking the printer settings against rules
+func (p *ListingPatch) CreateUpdatedListing(listing repo.Listing, rules ValidationRules) (repo.Listing, *errors.AppError) {
+	if p.Title.Set {
+		listing.Title = p.Title.Value
+	}
+	if p.Description.Set {
+		listing.Description = pgtype.Text{String: p.Description.Value, Valid: !p.Description.Null}
+	}
+	if p.Currency.Set {
+		listing.Currency = p.Currency.Value
+	}
+	if p.License.Set {
+		listing.License = p.License.Value
+	}
+	if p.PriceMinUnit.Set {
+		if p.PriceMinUnit.Value < 0 {
+			return listing, errors.New(errors.ErrInvalidInput, "Price cannot be negative", nil)
+		}
+		listing.PriceMinUnit = p.PriceMinUnit.Value
+	}
+
+	if p.SaleName.Set {
+		listing.SaleName = pgtype.Text{String: p.SaleName.Value, Valid: !p.SaleName.Null}
+	}
+	if p.SalePriceMinUnit.Null {
+		listing.SalePrice = pgtype.Int8{}
+	} else if p.SalePriceMinUnit.Set {
+		if p.SalePriceMinUnit.Value < 0 {
+			return listing, errors.New(errors.ErrInvalidInput, "Sale price cannot be negative", nil)
+		}
+		listing.SalePrice = pgtype.Int8{Int64: p.SalePriceMinUnit.Value, Valid: true}
+	}
+
+	if p.AIModelName.Set {
+		// A blank name is no name
+		valid := !p.AIModelName.Null && strings.TrimSpace(p.AIModelName.Value) != ""
+		listing.AiModelName = pgtype.Text{String: p.AIModelName.Value, Valid: valid}
+	}
+
+	if p.IsRemixingAllowed.Set {
+		listing.IsRemixingAllowed = p.IsRemixingAllowed.Value
+	}
+	if p.IsPhysical.Set {
+		listing.IsPhysical = p.IsPhysical.Value
+	}
+
+	// --- JSON Columns ---
+	// Checked against the listing as it will be saved, so switching to digital-only clears a size set earlier
+	if !listing.IsPhysical {
+		listing.DimensionsMm = nil
+		listing.TotalWeightGrams = pgtype.Int4{}
+	} else if p.Dimensions.Null {
+		listing.DimensionsMm = nil
+	} else if p.Dimensions.Set {
+		bytes, appErr := dimensionsColumn(true, &p.Dimensions.Value)
+		if appErr != nil {
+			return listing, appErr
+		}
+		listing.DimensionsMm = bytes
+	}
+	if p.IsNSFW.Set {
+		listing.IsNsfw = p.IsNSFW.Value
+	}
+	if p.IsAIGenerated.Set {
+		listing.IsAiGenerated = p.IsAIGenerated.Value
+	}
+
+	// --- Printer settings ---
+	if p.IsAssemblyRequired.Set {
+		listing.IsAssemblyRequired = p.IsAssemblyRequired.Value
+	}
+	if p.IsHardwareRequired.Set {
+		listing.IsHardwareRequired = p.IsHardwareRequired.Value
+	}
+	if p.IsMulticolor.Set {
+		listing.IsMulticolor = p.IsMulticolor.Value
+	}
+	if p.HardwareRequired.Set {
+		listing.HardwareRequired = p.HardwareRequired.Value // Nil when Null
+	}
+	// New materials have to be on the list, ones the seller didn't touch were saved before it was enforced so they're
+	// only normalized
+	if p.RecommendedMaterials.Set && !p.RecommendedMaterials.Null {
+		canonical, appErr := canonicalizeMaterials(p.RecommendedMaterials.Value)
+		if appErr != nil {
+			return listing, appErr
+		}
+		listing.RecommendedMaterials = canonical
+	} else if p.RecommendedMaterials.Set {
+		listing.RecommendedMaterials = nil
+	} else {
+		listing.RecommendedMaterials = materials.Normalize(listing.RecommendedMaterials)
+	}
+	if p.RecommendedNozzleTempC.Null {
+		listing.RecommendedNozzleTempC = pgtype.Int4{}
+	} else if p.RecommendedNozzleTempC.Set {
+		temp := p.RecommendedNozzleTempC.Value
+		if appErr := validateNozzleTemp(&temp, rules); appErr != nil {
+			return listing, appErr
+		}
+		// Convert int64/int to int32 for Postgres
+		listing.RecommendedNozzleTempC = pgtype.Int4{Int32: int32(temp), Valid: true}
+	}
+	if p.NozzleDiameter.Null {
+		listing.NozzleDiameterMm = pgtype.Numeric{}
+	} else if p.NozzleDiameter.Set {
+		diameter, appErr := parseNozzleDiameter(p.NozzleDiameter.Value, rules)
+		if appErr != nil {
+			return listing, appErr
+		}
+		listing.NozzleDiameterMm = diameter
+	}
+	return listing, nil
+}
@@ -0,0 +1,292 @@
+package listings
+
+import (
+	"gateway/internal/errors"
+	"gateway/internal/testutil/fixtures"
+	"strings"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// patchedListing is a listing with every patchable column set, so clearing or changing any of them shows
+func patchedListing() repo.Listing {
+	return fixtures.NewListing(fixtures.WithAIModel("diffusion"), fixtures.WithSale("Spring sale", 800, fixtures.CreatedAt.Add(time.Hour)))
+}
+
+// patchBody is a merge patch setting one member, "printerSettings.x" nests it
+func patchBody(member, value string) string {
+	if parent, child, ok := strings.Cut(member, "."); ok {
+		return `{"` + parent + `":{"` + child + `":` + value + `}}`
+	}
+	return `{"` + member + `":` + value + `}`
+}
+
+func TestListingPatch_EveryField(t *testing.T) {
+	// SCENARIO: Each patchable member is sent absent, as null, as a value and as a value of the wrong type.
+	// EXPECT: Absent leaves the column alone, null clears nullable columns and is refused for the rest, a value
+	// replaces the column and the wrong type is refused naming the member.
+
+	tests := []struct {
+		member    string
+		value     string // JSON
+		wrongType string // JSON
+		column    func(repo.Listing) any
+		want      any
+		nullable  bool
+		wantNull  any // The column after null, when nullable
+	}{
+		{member: "title", value: `"Benchy tug"`, wrongType: `5`,
+			column: func(l repo.Listing) any { return l.Title }, want: "Benchy tug"},
+		{member: "description", value: `"A tug boat for calibrating printers"`, wrongType: `true`,
+			column: func(l repo.Listing) any { return l.Description }, want: pgtype.Text{String: "A tug boat for calibrating printers", Valid: true},
+			nullable: true, wantNull: pgtype.Text{}},
+		{member: "license", value: `"MIT"`, wrongType: `[]`,
+			column: func(l repo.Listing) any { return l.License }, want: "MIT"},
+		{member: "price_min_unit", value: `900`, wrongType: `"900"`,
+			column: func(l repo.Listing) any { return l.PriceMinUnit }, want: int64(900)},
+		{member: "currency", value: `"usd"`, wrongType: `1`,
+			column: func(l repo.Listing) any { return l.Currency }, want: "usd"},
+		{member: "isNSFW", value: `true`, wrongType: `"yes"`,
+			column: func(l repo.Listing) any { return l.IsNsfw }, want: true},
+		{member: "isPhysical", value: `false`, wrongType: `0`,
+			column: func(l repo.Listing) any { return l.IsPhysical }, want: false},
+		{member: "isAIGenerated", value: `true`, wrongType: `"true"`,
+			column: func(l repo.Listing) any { return l.IsAiGenerated }, want: true},
+		{member: "aiModelName", value: `"flux"`, wrongType: `{}`,
+			column: func(l repo.Listing) any { return l.AiModelName }, want: pgtype.Text{String: "flux", Valid: true},
+			nullable: true, wantNull: pgtype.Text{}},
+		{member: "isRemixingAllowed", value: `true`, wrongType: `1`,
+			column: func(l repo.Listing) any { return l.IsRemixingAllowed }, want: true},
+		{member: "dimensions", value: `{"x":10,"y":20,"z":30}`, wrongType: `"10x20x30"`,
+			column: func(l repo.Listing) any { return string(l.DimensionsMm) }, want: `{"width":10,"depth":20,"height":30}`,
+			nullable: true, wantNull: ""},
+		{member: "sale_name", value: `"Summer sale"`, wrongType: `false`,
+			column: func(l repo.Listing) any { return l.SaleName }, want: pgtype.Text{String: "Summer sale", Valid: true},
+			nullable: true, wantNull: pgtype.Text{}},
+		{member: "sale_price_min_unit", value: `700`, wrongType: `"700"`,
+			column: func(l repo.Listing) any { return l.SalePrice }, want: pgtype.Int8{Int64: 700, Valid: true},
+			nullable: true, wantNull: pgtype.Int8{}},
+		{member: "printerSettings.isAssemblyRequired", value: `true`, wrongType: `"no"`,
+			column: func(l repo.Listing) any { return l.IsAssemblyRequired }, want: true},
+		{member: "printerSettings.isHardwareRequired", value: `true`, wrongType: `[]`,
+			column: func(l repo.Listing) any { return l.IsHardwareRequired }, want: true},
+		{member: "printerSettings.isMulticolor", value: `true`, wrongType: `1`,
+			column: func(l repo.Listing) any { return l.IsMulticolor }, want: true},
+		{member: "printerSettings.hardwareRequired", value: `["M4 nut"]`, wrongType: `"M4 nut"`,
+			column: func(l repo.Listing) any { return l.HardwareRequired }, want: []string{"M4 nut"},
+			nullable: true, wantNull: []string(nil)},
+		{member: "printerSettings.recommendedMaterials", value: `["PETG","ABS"]`, wrongType: `{"PETG":true}`,
+			column: func(l repo.Listing) any { return l.RecommendedMaterials }, want: []string{"PETG", "ABS"},
+			nullable: true, wantNull: []string(nil)},
+		{member: "printerSettings.recommendedNozzleTempC", value: `235`, wrongType: `"235C"`,
+			column: func(l repo.Listing) any { return l.RecommendedNozzleTempC }, want: pgtype.Int4{Int32: 235, Valid: true},
+			nullable: true, wantNull: pgtype.Int4{}},
+		{member: "printerSettings.nozzleDiameter", value: `"0.6mm"`, wrongType: `0.6`,
+			column: func(l repo.Listing) any { return nozzleDiameterMM(l.NozzleDiameterMm) }, want: ptr(0.6),
+			nullable: true, wantNull: (*float64)(nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.member, func(t *testing.T) {
+			existing := patchedListing()
+
+			t.Run("absent", func(t *testing.T) {
+				other := "title"
+				if tt.member == other {
+					other = "license"
+				}
+				patch, appErr := ParseListingPatch([]byte(patchBody(other, `"Something else"`)))
+				require.Nil(t, appErr)
+				listing, appErr := patch.CreateUpdatedListing(existing, DefaultValidationRules())
+				require.Nil(t, appErr)
+				assert.Equal(t, tt.column(existing), tt.column(listing))
+			})
+
+			t.Run("null", func(t *testing.T) {
+				patch, appErr := ParseListingPatch([]byte(patchBody(tt.member, `null`)))
+				if !tt.nullable {
+					require.NotNil(t, appErr)
+					assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+					assert.Equal(t, errors.ReasonListingPatchNotNullable, appErr.Reason)
+					assert.Equal(t, tt.member, appErr.Params["field"])
+					return
+				}
+				require.Nil(t, appErr)
+				listing, appErr := patch.CreateUpdatedListing(existing, DefaultValidationRules())
+				require.Nil(t, appErr)
+				assert.Equal(t, tt.wantNull, tt.column(listing))
+			})
+
+			t.Run("value", func(t *testing.T) {
+				patch, appErr := ParseListingPatch([]byte(patchBody(tt.member, tt.value)))
+				require.Nil(t, appErr)
+				listing, appErr := patch.CreateUpdatedListing(existing, DefaultValidationRules())
+				require.Nil(t, appErr)
+				assert.Equal(t, tt.want, tt.column(listing))
+			})
+
+			t.Run("wrong type", func(t *testing.T) {
+				_, appErr := ParseListingPatch([]byte(patchBody(tt.member, tt.wrongType)))
+				require.NotNil(t, appErr)
+				assert.Equal(t, errors.ReasonListingPatchInvalid, appErr.Reason)
+				assert.Equal(t, tt.member, appErr.Params["field"])
+			})
+		})
+	}
+}
+
+func TestListingPatch_NullPrinterSettings(t *testing.T) {
+	// SCENARIO: The seller sends "printerSettings": null.
+	// EXPECT: Every printer setting is cleared, the flags go back to false and nothing else changes.
+
+	existing := patchedListing()
+	existing.IsAssemblyRequired, existing.IsHardwareRequired, existing.IsMulticolor = true, true, true
+
+	patch, appErr := ParseListingPatch([]byte(`{"printerSettings":null}`))
+	require.Nil(t, appErr)
+	listing, appErr := patch.CreateUpdatedListing(existing, DefaultValidationRules())
+	require.Nil(t, appErr)
+
+	assert.False(t, listing.IsAssemblyRequired)
+	assert.False(t, listing.IsHardwareRequired)
+	assert.False(t, listing.IsMulticolor)
+	assert.Nil(t, listing.HardwareRequired)
+	assert.Nil(t, listing.RecommendedMaterials)
+	assert.False(t, listing.RecommendedNozzleTempC.Valid)
+	assert.False(t, listing.NozzleDiameterMm.Valid)
+	assert.Equal(t, existing.Title, listing.Title)
+	assert.Equal(t, existing.DimensionsMm, listing.DimensionsMm)
+}
+
+func TestListingPatch_Materials(t *testing.T) {
+	// SCENARIO: A listing saved before materials were checked is edited, once leaving its materials alone and once
+	// sending a material that isn't on the list.
+	// EXPECT: Untouched materials are renamed to their canonical names with unknown ones kept, sent ones are refused
+	// with a suggestion.
+
+	existing := patchedListing()
+	existing.RecommendedMaterials = []string{"pla", "PET-G", "Glow filament"}
+
+	patch, appErr := ParseListingPatch([]byte(`{"title":"Benchy tug"}`))
+	require.Nil(t, appErr)
+	listing, appErr := patch.CreateUpdatedListing(existing, DefaultValidationRules())
+	require.Nil(t, appErr)
+	assert.Equal(t, []string{"PLA", "PETG", "Glow filament"}, listing.RecommendedMaterials)
+
+	patch, appErr = ParseListingPatch([]byte(patchBody("printerSettings.recommendedMaterials", `["PLAA"]`)))
+	require.Nil(t, appErr)
+	_, appErr = patch.CreateUpdatedListing(existing, DefaultValidationRules())
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ReasonListingMaterialSuggestion, appErr.Reason)
+	assert.Equal(t, "PLA", appErr.Params["suggestion"])
+}
+
+func TestParseListingPatch_Refused(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantReason errors.Reason
+		wantField  string
+	}{
+		{name: "Not an object", body: `["title"]`},
+		{name: "Null document", body: `null`},
+		{name: "Not JSON", body: `{"title":`},
+		{name: "Unknown member", body: `{"title":"Benchy tug","colour":"red"}`, wantReason: errors.ReasonListingPatchUnknownField, wantField: "colour"},
+		{name: "PUT-only member", body: `{"categories":["toys"]}`, wantReason: errors.ReasonListingPatchUnknownField, wantField: "categories"},
+		{name: "Unknown printer setting", body: `{"printerSettings":{"bedTempC":60}}`, wantReason: errors.ReasonListingPatchUnknownField, wantField: "printerSettings.bedTempC"},
+		{name: "Deprecated printer setting", body: `{"printerSettings":{"nozzleTemperature":210}}`, wantReason: errors.ReasonListingPatchUnknownField, wantField: "printerSettings.nozzleTemperature"},
+		{name: "Printer settings not an object", body: `{"printerSettings":true}`, wantReason: errors.ReasonListingPatchInvalid, wantField: "printerSettings"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, appErr := ParseListingPatch([]byte(tt.body))
+			require.NotNil(t, appErr)
+			assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+			assert.Equal(t, tt.wantReason, appErr.Reason)
+			if tt.wantField != "" {
+				assert.Equal(t, tt.wantField, appErr.Params["field"])
+			}
+		})
+	}
+}
+
+func TestListingPatch_Rules(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		check      func(t *testing.T, listing repo.Listing)
+		wantReason errors.Reason
+	}{
+		{name: "Empty patch changes nothing", body: `{}`, check: func(t *testing.T, l repo.Listing) {
+			assert.Equal(t, patchedListing(), l)
+		}},
+		{name: "Blank AI model name is null", body: `{"aiModelName":"  "}`, check: func(t *testing.T, l repo.Listing) {
+			assert.False(t, l.AiModelName.Valid)
+		}},
+		{name: "Switched to digital drops the size and weight", body: `{"isPhysical":false}`, check: func(t *testing.T, l repo.Listing) {
+			assert.Nil(t, l.DimensionsMm)
+			assert.False(t, l.TotalWeightGrams.Valid)
+		}},
+		{name: "Size cleared keeps the weight", body: `{"dimensions":null}`, check: func(t *testing.T, l repo.Listing) {
+			assert.Nil(t, l.DimensionsMm)
+			assert.True(t, l.TotalWeightGrams.Valid)
+		}},
+		{name: "Other printer settings untouched", body: `{"printerSettings":{"isMulticolor":true}}`, check: func(t *testing.T, l repo.Listing) {
+			assert.Equal(t, fixtures.NewListing().HardwareRequired, l.HardwareRequired)
+			assert.Equal(t, fixtures.NewListing().RecommendedNozzleTempC, l.RecommendedNozzleTempC)
+		}},
+		{name: "Sale name cleared keeps the sale price", body: `{"sale_name":null}`, check: func(t *testing.T, l repo.Listing) {
+			assert.False(t, l.SaleName.Valid)
+			assert.Equal(t, pgtype.Int8{Int64: 800, Valid: true}, l.SalePrice)
+			assert.True(t, l.IsSaleActive)
+		}},
+		{name: "Negative price", body: `{"price_min_unit":-1}`, wantReason: ""},
+		{name: "Negative sale price", body: `{"sale_price_min_unit":-1}`, wantReason: ""},
+		{name: "Partial size", body: `{"dimensions":{"x":10}}`, wantReason: errors.ReasonListingDimensionsIncomplete},
+		{name: "Nozzle too hot", body: `{"printerSettings":{"recommendedNozzleTempC":600}}`, wantReason: errors.ReasonListingNozzleTempRange},
+		{name: "Unsupported nozzle", body: `{"printerSettings":{"nozzleDiameter":"0.5"}}`, wantReason: errors.ReasonListingNozzleDiameter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, appErr := ParseListingPatch([]byte(tt.body))
+			require.Nil(t, appErr)
+			listing, appErr := patch.CreateUpdatedListing(patchedListing(), DefaultValidationRules())
+			if tt.check == nil {
+				require.NotNil(t, appErr)
+				assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+				assert.Equal(t, tt.wantReason, appErr.Reason)
+				return
+			}
+			require.Nil(t, appErr)
+			tt.check(t, listing)
+		})
+	}
+}
+
+func TestUpdateListingRequest_PatchKeepsPutSemantics(t *testing.T) {
+	// SCENARIO: A PUT body with nil fields, the deprecated nozzleTemperature and isMulticolor.
+	// EXPECT: Nil fields stay absent, the old temperature is folded in and isMulticolor is still ignored by PUT.
+
+	req := &UpdateListingRequest{
+		Title: ptr("Benchy tug"),
+		PrinterSettings: &UpdateListingPrinterSettings{
+			NozzleTemperature: ptr(200.0),
+			IsMulticolor:      ptr(true),
+		},
+	}
+	patch := req.Patch()
+
+	assert.Equal(t, PatchField[string]{Set: true, Value: "Benchy tug"}, patch.Title)
+	assert.False(t, patch.Description.Set)
+	assert.Equal(t, PatchField[float64]{Set: true, Value: 200}, patch.RecommendedNozzleTempC)
+	assert.False(t, patch.IsMulticolor.Set)
+	assert.False(t, patch.Files)
+}
@@ -0,0 +1,108 @@
+package listings
+
+import (
+	"encoding/json"
+	"fmt"
+	"gateway/internal/errors"
+	"math"
+	"strconv"
+)
+
+// Units a listing's dimensions can be given and shown in. They are always stored in millimetres.
+const (
+	DimensionUnitMM = "mm"
+	DimensionUnitIn = "in"
+)
+
+// A physical listing's sides have to be within these, in millimetres once converted
+const (
+	MinDimensionMM = 1
+	MaxDimensionMM = 2000
+)
+
+const mmPerInch = 25.4
+
+// toMillimetres converts a length in unit, "" being millimetres, and rounds it to a tenth of a millimetre. The rounding
+// keeps float noise from the conversion out of the whole millimetres stored, 5in is 127.00000000000001mm.
+func toMillimetres(v float64, unit string) float64 {
+	if unit == DimensionUnitIn {
+		v *= mmPerInch
+	}
+	return math.Round(v*10) / 10
+}
+
+// Millimetres is the size in millimetres, see toMillimetres. The unit must be one dimensionsColumn accepts.
+func (d ListingDimensions) Millimetres() ListingDimensions {
+	return ListingDimensions{
+		X:    toMillimetres(d.X, d.Unit),
+		Y:    toMillimetres(d.Y, d.Unit),
+		Z:    toMillimetres(d.Z, d.Unit),
+		Unit: DimensionUnitMM,
+	}
+}
+
+// formatDimensions is a stored size as sellers and buyers read it, whole millimetres or inches to a tenth
+func formatDimensions(x, y, z int, unit string) string {
+	if unit == DimensionUnitIn {
+		in := func(mm int) string { return strconv.FormatFloat(math.Round(float64(mm)/mmPerInch*10)/10, 'f', -1, 64) }
+		return fmt.Sprintf("%s × %s × %s in", in(x), in(y), in(z))
+	}
+	return fmt.Sprintf("%d × %d × %d mm", x, y, z)
+}
+
+// dimensionsColumn checks a listing's size against whether it's physical and returns what to store in dimensions_mm,
+// nil when there's nothing to store.
+//
+// Digital-only listings have no size, any dimensions sent for one are dropped rather than refused: the create and edit
+// forms keep the last values around after the physical switch is turned off. For the same reason all three axes at 0
+// means no size was given. A physical listing with some axes set needs all of them positive, a 0 there would make it
+// fit on every printer. Sizes given in inches are converted, every side has to come to MinDimensionMM-MaxDimensionMM.
+func dimensionsColumn(isPhysical bool, dims *ListingDimensions) ([]byte, *errors.AppError) {
+	if dims == nil || !isPhysical {
+		return nil, nil
+	}
+	if dims.Unit != "" && dims.Unit != DimensionUnitMM && dims.Unit != DimensionUnitIn {
+		return nil, errors.New(errors.ErrInvalidInput, "Dimension unit must be mm or in", nil).
+			WithReason(errors.ReasonListingDimensionsUnit).
+			WithParam("unit", dims.Unit)
+	}
+	if dims.X < 0 || dims.Y < 0 || dims.Z < 0 {
+		return nil, errors.New(errors.ErrInvalidInput, "Dimensions cannot be negative", nil).WithReason(errors.ReasonListingDimensionsNegative)
+	}
+	if dims.X == 0 && dims.Y == 0 && dims.Z == 0 {
+		return nil, nil
+	}
+	if dims.X == 0 || dims.Y == 0 || dims.Z == 0 {
+		return nil, errors.New(errors.ErrInvalidInput, "Width, depth and height must all be given for a physical listing", nil).WithReason(errors.ReasonListingDimensionsIncomplete)
+	}
+
+	mm := dims.Millimetres()
+	for _, side := range []float64{mm.X, mm.Y, mm.Z} {
+		if side < MinDimensionMM || side > MaxDimensionMM {
+			return nil, errors.New(errors.ErrInvalidInput, fmt.Sprintf("Each side must be %d-%dmm", MinDimensionMM, MaxDimensionMM), nil).
+				WithReason(errors.ReasonListingDimensionsRange).
+				WithParam("min", strconv.Itoa(MinDimensionMM)).
+				WithParam("max", strconv.Itoa(MaxDimensionMM))
+		}
+	}
+
+	// Stored whole millimetres, rounded up so a fit check never lets through a part that's slightly too big
+	bytes, err := json.Marshal(ListingDimensionsJSON{
+		Width:  int(math.Ceil(mm.X)),
+		Depth:  int(math.Ceil(mm.Y)),
+		Height: int(math.Ceil(mm.Z)),
+	})
+	if err != nil {
+		return nil, errors.New(errors.ErrInvalidInput, "Invalid dimensions format", err)
+	}
+	return bytes, nil
+}
+
+// showDimensionsIn re-renders DimensionsDisplay in the user's preferred unit. Cached responses are always in mm, so
+// this runs on the copy handed to the user.
+func (l *ListingResponse) showDimensionsIn(unit string) {
+	if unit != DimensionUnitIn || l.DimXMM == nil || l.DimYMM == nil || l.DimZMM == nil {
+		return
+	}
+	l.DimensionsDisplay = formatDimensions(*l.DimXMM, *l.DimYMM, *l.DimZMM, unit)
+}
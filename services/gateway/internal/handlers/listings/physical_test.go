@@ -0,0 +1,164 @@
+package listings
+
+import (
+	"fmt"
+	"gateway/internal/errors"
+	"gateway/internal/testutil/fixtures"
+	"testing"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDimensionsColumn(t *testing.T) {
+	tests := []struct {
+		name       string
+		isPhysical bool
+		dims       *ListingDimensions
+		want       string // stored JSON, "" for NULL
+		wantReason errors.Reason
+	}{
+		{name: "Physical, full size", isPhysical: true, dims: &ListingDimensions{X: 120, Y: 80, Z: 45}, want: `{"width":120,"depth":80,"height":45}`},
+		{name: "Physical, fractions round up", isPhysical: true, dims: &ListingDimensions{X: 1.2, Y: 79.2, Z: 45}, want: `{"width":2,"depth":80,"height":45}`},
+		{name: "Physical, mm given", isPhysical: true, dims: &ListingDimensions{X: 120, Y: 80, Z: 45, Unit: "mm"}, want: `{"width":120,"depth":80,"height":45}`},
+		{name: "Physical, inches converted", isPhysical: true, dims: &ListingDimensions{X: 5, Y: 2, Z: 1, Unit: "in"}, want: `{"width":127,"depth":51,"height":26}`},
+		{name: "Physical, conversion noise doesn't round up", isPhysical: true, dims: &ListingDimensions{X: 10, Y: 10, Z: 10, Unit: "in"}, want: `{"width":254,"depth":254,"height":254}`},
+		{name: "Physical, smallest side", isPhysical: true, dims: &ListingDimensions{X: 1, Y: 1, Z: 1}, want: `{"width":1,"depth":1,"height":1}`},
+		{name: "Physical, largest side", isPhysical: true, dims: &ListingDimensions{X: 2000, Y: 2000, Z: 2000}, want: `{"width":2000,"depth":2000,"height":2000}`},
+		{name: "Physical, under 1mm", isPhysical: true, dims: &ListingDimensions{X: 0.4, Y: 79.2, Z: 45}, wantReason: errors.ReasonListingDimensionsRange},
+		{name: "Physical, rounds to under 1mm", isPhysical: true, dims: &ListingDimensions{X: 0.94, Y: 80, Z: 45}, wantReason: errors.ReasonListingDimensionsRange},
+		{name: "Physical, over 2000mm", isPhysical: true, dims: &ListingDimensions{X: 120, Y: 80, Z: 2000.1}, wantReason: errors.ReasonListingDimensionsRange},
+		{name: "Physical, over 2000mm in inches", isPhysical: true, dims: &ListingDimensions{X: 79, Y: 1, Z: 1, Unit: "in"}, wantReason: errors.ReasonListingDimensionsRange},
+		{name: "Physical, unknown unit", isPhysical: true, dims: &ListingDimensions{X: 12, Y: 8, Z: 4, Unit: "cm"}, wantReason: errors.ReasonListingDimensionsUnit},
+		{name: "Physical, unit is case sensitive", isPhysical: true, dims: &ListingDimensions{X: 12, Y: 8, Z: 4, Unit: "IN"}, wantReason: errors.ReasonListingDimensionsUnit},
+		{name: "Physical, negative inches", isPhysical: true, dims: &ListingDimensions{X: 5, Y: -2, Z: 1, Unit: "in"}, wantReason: errors.ReasonListingDimensionsNegative},
+		{name: "Physical, inches with an axis missing", isPhysical: true, dims: &ListingDimensions{X: 5, Y: 2, Unit: "in"}, wantReason: errors.ReasonListingDimensionsIncomplete},
+		{name: "Digital, unknown unit dropped", isPhysical: false, dims: &ListingDimensions{X: 12, Y: 8, Z: 4, Unit: "cm"}, want: ""},
+		{name: "Physical, no size", isPhysical: true, dims: nil, want: ""},
+		{name: "Physical, all zero is no size", isPhysical: true, dims: &ListingDimensions{}, want: ""},
+		{name: "Physical, one axis missing", isPhysical: true, dims: &ListingDimensions{X: 120, Y: 80}, wantReason: errors.ReasonListingDimensionsIncomplete},
+		{name: "Physical, negative axis", isPhysical: true, dims: &ListingDimensions{X: 120, Y: -80, Z: 45}, wantReason: errors.ReasonListingDimensionsNegative},
+		{name: "Digital, size dropped", isPhysical: false, dims: &ListingDimensions{X: 120, Y: 80, Z: 45}, want: ""},
+		{name: "Digital, bad size dropped", isPhysical: false, dims: &ListingDimensions{X: -1}, want: ""},
+		{name: "Digital, no size", isPhysical: false, dims: nil, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			column, appErr := dimensionsColumn(tt.isPhysical, tt.dims)
+			if tt.wantReason != "" {
+				require.NotNil(t, appErr)
+				assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+				assert.Equal(t, tt.wantReason, appErr.Reason)
+				return
+			}
+			require.Nil(t, appErr)
+			if tt.want == "" {
+				assert.Nil(t, column)
+				return
+			}
+			assert.JSONEq(t, tt.want, string(column))
+		})
+	}
+}
+
+func TestToMillimetres(t *testing.T) {
+	tests := []struct {
+		v    float64
+		unit string
+		want float64
+	}{
+		{v: 0, unit: "", want: 0},
+		{v: 120, unit: "", want: 120},
+		{v: 120, unit: "mm", want: 120},
+		{v: 79.25, unit: "mm", want: 79.3},
+		{v: 79.24, unit: "mm", want: 79.2},
+		{v: 0.94, unit: "mm", want: 0.9},
+		{v: 0.95, unit: "mm", want: 1},
+		{v: 1, unit: "in", want: 25.4},
+		{v: 5, unit: "in", want: 127},
+		{v: 10, unit: "in", want: 254},
+		{v: 0.5, unit: "in", want: 12.7},
+		{v: 0.1, unit: "in", want: 2.5},
+		{v: 0.03, unit: "in", want: 0.8},
+		{v: 0.04, unit: "in", want: 1},
+		{v: 3.3, unit: "in", want: 83.8},
+		{v: 78.74, unit: "in", want: 2000},
+		{v: 78.75, unit: "in", want: 2000.3},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v%s", tt.v, tt.unit), func(t *testing.T) {
+			assert.Equal(t, tt.want, toMillimetres(tt.v, tt.unit))
+		})
+	}
+}
+
+func TestFormatDimensions(t *testing.T) {
+	assert.Equal(t, "120 × 80 × 45 mm", formatDimensions(120, 80, 45, DimensionUnitMM))
+	assert.Equal(t, "120 × 80 × 45 mm", formatDimensions(120, 80, 45, ""))
+	assert.Equal(t, "5 × 2 × 1 in", formatDimensions(127, 51, 26, DimensionUnitIn))
+	assert.Equal(t, "4.7 × 3.1 × 1.8 in", formatDimensions(120, 80, 45, DimensionUnitIn))
+	assert.Equal(t, "0 × 78.7 × 0.1 in", formatDimensions(1, 2000, 2, DimensionUnitIn))
+}
+
+func TestShowDimensionsIn(t *testing.T) {
+	x, y, z := 127, 51, 26
+	listing := ListingResponse{DimXMM: &x, DimYMM: &y, DimZMM: &z, DimensionsDisplay: "127 × 51 × 26 mm"}
+
+	listing.showDimensionsIn("")
+	assert.Equal(t, "127 × 51 × 26 mm", listing.DimensionsDisplay)
+	listing.showDimensionsIn("furlongs")
+	assert.Equal(t, "127 × 51 × 26 mm", listing.DimensionsDisplay)
+	listing.showDimensionsIn(DimensionUnitIn)
+	assert.Equal(t, "5 × 2 × 1 in", listing.DimensionsDisplay)
+
+	digital := ListingResponse{}
+	digital.showDimensionsIn(DimensionUnitIn)
+	assert.Empty(t, digital.DimensionsDisplay)
+}
+
+func TestCreateUpdatedListing_Physical(t *testing.T) {
+	const stored = fixtures.Dimensions
+	physical := fixtures.NewListing()
+	digital := fixtures.NewListing(fixtures.WithDigital())
+
+	tests := []struct {
+		name       string
+		existing   repo.Listing
+		req        UpdateListingRequest
+		wantDims   string // "" for NULL
+		wantWeight bool
+		wantReason errors.Reason
+	}{
+		{name: "Physical, untouched", existing: physical, req: UpdateListingRequest{}, wantDims: stored, wantWeight: true},
+		{name: "Physical, new size", existing: physical, req: UpdateListingRequest{Dimensions: &ListingDimensions{X: 10, Y: 20, Z: 30}}, wantDims: `{"width":10,"depth":20,"height":30}`, wantWeight: true},
+		{name: "Physical, size cleared", existing: physical, req: UpdateListingRequest{Dimensions: &ListingDimensions{}}, wantDims: "", wantWeight: true},
+		{name: "Physical, partial size", existing: physical, req: UpdateListingRequest{Dimensions: &ListingDimensions{X: 10, Z: 30}}, wantReason: errors.ReasonListingDimensionsIncomplete},
+		{name: "Switched to digital", existing: physical, req: UpdateListingRequest{IsPhysical: ptr(false)}, wantDims: ""},
+		{name: "Switched to digital with a size", existing: physical, req: UpdateListingRequest{IsPhysical: ptr(false), Dimensions: &ListingDimensions{X: 10, Y: 20, Z: 30}}, wantDims: ""},
+		{name: "Digital, size ignored", existing: digital, req: UpdateListingRequest{Dimensions: &ListingDimensions{X: 10, Y: 20, Z: 30}}, wantDims: ""},
+		{name: "Switched to physical with a size", existing: digital, req: UpdateListingRequest{IsPhysical: ptr(true), Dimensions: &ListingDimensions{X: 10, Y: 20, Z: 30}}, wantDims: `{"width":10,"depth":20,"height":30}`},
+		{name: "Switched to physical with a partial size", existing: digital, req: UpdateListingRequest{IsPhysical: ptr(true), Dimensions: &ListingDimensions{X: 10}}, wantReason: errors.ReasonListingDimensionsIncomplete},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listing, appErr := tt.req.Patch().CreateUpdatedListing(tt.existing, DefaultValidationRules())
+			if tt.wantReason != "" {
+				require.NotNil(t, appErr)
+				assert.Equal(t, tt.wantReason, appErr.Reason)
+				return
+			}
+			require.Nil(t, appErr)
+			if tt.wantDims == "" {
+				assert.Nil(t, listing.DimensionsMm)
+			} else {
+				assert.JSONEq(t, tt.wantDims, string(listing.DimensionsMm))
+			}
+			assert.Equal(t, tt.wantWeight, listing.TotalWeightGrams.Valid)
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package listings
+
+import (
+	"bytes"
+	"fmt"
+	"gateway/internal/errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// previewDescriptionLength is roughly what Discord and Twitter show before cutting a description off themselves
+const previewDescriptionLength = 200
+
+// PreviewConfig is where link previews point people and what they show in place of an NSFW thumbnail
+type PreviewConfig struct {
+	// WebBaseURL is the web UI, previews link to its /listings/{id} page
+	WebBaseURL string
+	// PlaceholderImage is shown instead of the thumbnail of NSFW listings and listings without one
+	PlaceholderImage string
+}
+
+// linkPreviewBots are user agent fragments of the crawlers that unfurl links in chats and feeds. They don't run the
+// SPA, so they are sent the preview page instead.
+var linkPreviewBots = []string{
+	"discordbot",
+	"twitterbot",
+	"facebookexternalhit",
+	"facebot",
+	"slackbot",
+	"linkedinbot",
+	"whatsapp",
+	"telegrambot",
+	"redditbot",
+	"pinterest",
+	"mastodon",
+	"skypeuripreview",
+	"embedly",
+	"iframely",
+	"applebot",
+}
+
+// IsLinkPreviewBot reports whether the user agent is a crawler building a link preview
+func IsLinkPreviewBot(userAgent string) bool {
+	userAgent = strings.ToLower(userAgent)
+	for _, bot := range linkPreviewBots {
+		if strings.Contains(userAgent, bot) {
+			return true
+		}
+	}
+	return false
+}
+
+// previewTemplate is html/template, every value is escaped for where it lands, titles with quotes included
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<link rel="canonical" href="{{.URL}}">
+<meta name="description" content="{{.Description}}">
+<meta property="og:type" content="product">
+<meta property="og:site_name" content="Printing Marketplace">
+<meta property="og:title" content="{{.Title}}">
+<meta property="og:description" content="{{.Description}}">
+<meta property="og:url" content="{{.URL}}">
+{{- if .Image}}
+<meta property="og:image" content="{{.Image}}">
+<meta property="og:image:alt" content="{{.Title}}">
+{{- end}}
+<meta property="product:price:amount" content="{{.Price}}">
+<meta property="product:price:currency" content="{{.Currency}}">
+<meta name="twitter:card" content="summary_large_image">
+<meta name="twitter:title" content="{{.Title}}">
+<meta name="twitter:description" content="{{.Description}}">
+{{- if .Image}}
+<meta name="twitter:image" content="{{.Image}}">
+{{- end}}
+{{- if .Product}}
+<script type="application/ld+json">{{.Product}}</script>
+{{- end}}
+</head>
+<body>
+<a href="{{.URL}}">{{.Title}}</a>
+</body>
+</html>
+`))
+
+type previewPage struct {
+	Title       string
+	Description string
+	URL         string
+	Image       string
+	Price       string
+	Currency    string
+	Product     *ProductJSONLD // nil for NSFW listings
+}
+
+// PreviewHandler serves the Open Graph page link previews are built from
+type PreviewHandler struct {
+	service ListingsService
+	config  PreviewConfig
+}
+
+func NewPreviewHandler(svc ListingsService, config PreviewConfig) *PreviewHandler {
+	return &PreviewHandler{
+		service: svc,
+		config:  config,
+	}
+}
+
+// GetListingPreview serves GET /listings/{id}/og
+func (h *PreviewHandler) GetListingPreview(w http.ResponseWriter, r *http.Request) {
+	h.WriteListingPreview(w, r, chi.URLParam(r, "id"))
+}
+
+// WriteListingPreview writes the preview page for a listing, for routes that answer bots differently to people
+func (h *PreviewHandler) WriteListingPreview(w http.ResponseWriter, r *http.Request, listingID string) {
+	ctx := r.Context()
+
+	listing, err := h.publicListing(r, listingID)
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	var page bytes.Buffer
+	if err := previewTemplate.Execute(&page, h.toPreviewPage(listing)); err != nil {
+		slog.ErrorContext(ctx, "Failed to render listing preview", "listing_id", listingID, "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrInternal, "Failed to render listing preview", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// Crawlers come back for the same link many times as it's shared, the listing cache is what keeps it fresh
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	w.Write(page.Bytes())
+}
+
+// publicListing is the listing for a preview, a 404 unless anyone could browse to it
+func (h *PreviewHandler) publicListing(r *http.Request, listingID string) (*ListingResponse, error) {
+	// The same cached response the listing page is served from, a link doing the rounds costs no queries
+	listing, err := h.service.GetListingByID(r.Context(), listingID)
+	if err != nil {
+		return nil, err
+	}
+	if listing.DeletedAt != nil || listing.Status != "ACTIVE" {
+		return nil, errors.New(errors.ErrNotFound, "Listing not found", fmt.Errorf("listing %v is %s", listingID, listing.Status)).WithReason(errors.ReasonListingNotFound)
+	}
+	return listing, nil
+}
+
+func (h *PreviewHandler) listingURL(listingID string) string {
+	return strings.TrimRight(h.config.WebBaseURL, "/") + "/listings/" + listingID
+}
+
+func (h *PreviewHandler) toPreviewPage(listing *ListingResponse) previewPage {
+	page := previewPage{
+		Title:       listing.Title,
+		Description: previewDescription(listing.Description),
+		URL:         h.listingURL(listing.ID),
+		Image:       h.config.PlaceholderImage,
+		Price:       formatPrice(listing.PriceMinUnit),
+		Currency:    strings.ToUpper(listing.Currency),
+	}
+	// Thumbnails are at least 512px on each side, see ImageBounds, which every preview card scales down from
+	if listing.ThumbnailPath != nil && !listing.IsNSFW {
+		page.Image = *listing.ThumbnailPath
+	}
+	// Same as GET /listings/{id}/jsonld, search engines are never told about NSFW listings
+	if !listing.IsNSFW {
+		product := h.toProductJSONLD(listing, time.Now())
+		page.Product = &product
+	}
+	return page
+}
+
+// formatPrice is a price in minor units as a decimal, 1050 is 10.50. It assumes two decimal places, like usd and gbp.
+func formatPrice(minUnit int64) string {
+	return fmt.Sprintf("%d.%02d", minUnit/100, minUnit%100)
+}
+
+// previewDescription flattens the description to one line without control characters and cuts it at a word
+func previewDescription(description string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, description)
+	cleaned = strings.Join(strings.Fields(cleaned), " ")
+
+	runes := []rune(cleaned)
+	if len(runes) <= previewDescriptionLength {
+		return cleaned
+	}
+	cut := string(runes[:previewDescriptionLength])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:-") + "…"
+}
@@ -0,0 +1,171 @@
+package listings_test
+
+import (
+	"gateway/internal/errors"
+	"gateway/internal/handlers/listings"
+	"gateway/internal/mocks/mocklistings"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var previewConfig = listings.PreviewConfig{WebBaseURL: "https://web.test/", PlaceholderImage: "https://cdn.test/static/og-placeholder.png"}
+
+func getPreview(t *testing.T, listing *listings.ListingResponse) *httptest.ResponseRecorder {
+	t.Helper()
+	svc := mocklistings.NewListingsService(t)
+	svc.EXPECT().GetListingByID(mock.Anything, listingID).Return(listing, nil)
+
+	r := chi.NewRouter()
+	r.Get("/listings/{id}/og", listings.NewPreviewHandler(svc, previewConfig).GetListingPreview)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/listings/"+listingID+"/og", nil))
+	return w
+}
+
+var metaPattern = regexp.MustCompile(`<meta (?:property|name)="([^"]+)" content="([^"]*)">`)
+
+// previewMeta is the page's meta tags, unescaped the way a crawler reads them
+func previewMeta(t *testing.T, page string) map[string]string {
+	t.Helper()
+	meta := map[string]string{}
+	for _, m := range metaPattern.FindAllStringSubmatch(page, -1) {
+		meta[m[1]] = html.UnescapeString(m[2])
+	}
+	require.NotEmpty(t, meta, page)
+	return meta
+}
+
+func previewListing() *listings.ListingResponse {
+	thumbnail := "https://cdn.test/listings/1/thumb.webp"
+	return &listings.ListingResponse{
+		ID:            "550e8400e29b41d4a716446655440000",
+		Title:         "Benchy",
+		Description:   "The classic",
+		PriceMinUnit:  1050,
+		Currency:      "gbp",
+		ThumbnailPath: &thumbnail,
+		Status:        "ACTIVE",
+	}
+}
+
+func TestGetListingPreview(t *testing.T) {
+	w := getPreview(t, previewListing())
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	meta := previewMeta(t, w.Body.String())
+	assert.Equal(t, "Benchy", meta["og:title"])
+	assert.Equal(t, "The classic", meta["og:description"])
+	assert.Equal(t, "https://cdn.test/listings/1/thumb.webp", meta["og:image"])
+	assert.Equal(t, "https://web.test/listings/550e8400e29b41d4a716446655440000", meta["og:url"])
+	assert.Equal(t, "10.50", meta["product:price:amount"])
+	assert.Equal(t, "GBP", meta["product:price:currency"])
+	assert.Contains(t, w.Body.String(), `<link rel="canonical" href="https://web.test/listings/550e8400e29b41d4a716446655440000">`)
+}
+
+func TestGetListingPreview_EscapesTitle(t *testing.T) {
+	// SCENARIO: A listing's title and description try to close the attribute and the head to inject markup.
+	// EXPECT: The text reaches the crawler intact, but nothing of it is parsed as HTML.
+
+	listing := previewListing()
+	listing.Title = `"Benchy" <script>alert('x')</script> & "friends'`
+	listing.Description = `"><meta property="og:image" content="https://evil.test/x.png">`
+
+	w := getPreview(t, listing)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	page := w.Body.String()
+	assert.NotContains(t, page, "<script>")
+	assert.NotContains(t, page, "evil.test/x.png\">")
+	assert.Equal(t, 1, strings.Count(page, `property="og:image"`), "the description must not add a second image")
+
+	meta := previewMeta(t, page)
+	assert.Equal(t, listing.Title, meta["og:title"])
+	assert.Equal(t, listing.Description, meta["og:description"])
+	assert.Equal(t, "https://cdn.test/listings/1/thumb.webp", meta["og:image"])
+}
+
+func TestGetListingPreview_Description(t *testing.T) {
+	listing := previewListing()
+	listing.Description = "Prints\nwithout\tsupports.\x00  " + strings.Repeat("Very detailed ", 30)
+
+	w := getPreview(t, listing)
+
+	description := previewMeta(t, w.Body.String())["og:description"]
+	assert.True(t, strings.HasPrefix(description, "Prints without supports. Very detailed"), description)
+	assert.True(t, strings.HasSuffix(description, " Very…"), description)
+	assert.LessOrEqual(t, len([]rune(description)), 201)
+}
+
+func TestGetListingPreview_Placeholder(t *testing.T) {
+	nsfw := previewListing()
+	nsfw.IsNSFW = true
+	noThumbnail := previewListing()
+	noThumbnail.ThumbnailPath = nil
+
+	for name, listing := range map[string]*listings.ListingResponse{"NSFW": nsfw, "No thumbnail": noThumbnail} {
+		t.Run(name, func(t *testing.T) {
+			w := getPreview(t, listing)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, previewConfig.PlaceholderImage, previewMeta(t, w.Body.String())["og:image"])
+		})
+	}
+}
+
+func TestGetListingPreview_NotListed(t *testing.T) {
+	deletedAt := time.Now()
+	pending := previewListing()
+	pending.Status = "PENDING_VALIDATION"
+	deleted := previewListing()
+	deleted.DeletedAt = &deletedAt
+
+	for name, listing := range map[string]*listings.ListingResponse{"Pending": pending, "Deleted": deleted} {
+		t.Run(name, func(t *testing.T) {
+			w := getPreview(t, listing)
+
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			assert.NotContains(t, w.Body.String(), "Benchy")
+		})
+	}
+}
+
+func TestGetListingPreview_Missing(t *testing.T) {
+	svc := mocklistings.NewListingsService(t)
+	svc.EXPECT().GetListingByID(mock.Anything, listingID).Return(nil, errors.New(errors.ErrNotFound, "Listing not found", nil))
+
+	r := chi.NewRouter()
+	r.Get("/listings/{id}/og", listings.NewPreviewHandler(svc, previewConfig).GetListingPreview)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/listings/"+listingID+"/og", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestIsLinkPreviewBot(t *testing.T) {
+	tests := map[string]bool{
+		"Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)": true,
+		"Twitterbot/1.0": true,
+		"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)": true,
+		"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)":                true,
+		"WhatsApp/2.23.20.0": true,
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Safari": false,
+		"": false,
+	}
+	for ua, want := range tests {
+		t.Run(ua, func(t *testing.T) {
+			assert.Equal(t, want, listings.IsLinkPreviewBot(ua))
+		})
+	}
+}
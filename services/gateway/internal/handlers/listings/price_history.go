@@ -0,0 +1,67 @@
+package listings
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// priceHistoryPoints is how many changes per listing the seller's price chart gets
+const priceHistoryPoints = 10
+
+// PriceChange is one point on a listing's price chart
+type PriceChange struct {
+	OldPriceMinUnit int64     `json:"old_price_min_unit"`
+	NewPriceMinUnit int64     `json:"new_price_min_unit"`
+	OldCurrency     string    `json:"old_currency"`
+	NewCurrency     string    `json:"new_currency"`
+	ChangedAt       time.Time `json:"changed_at"`
+}
+
+// recordPriceChange writes a history entry when the price or currency changed and does nothing otherwise. q must be
+// bound to the transaction that saved the update so a rolled back edit leaves no trace.
+func recordPriceChange(ctx context.Context, q *repo.Queries, before, after repo.Listing) error {
+	if before.PriceMinUnit == after.PriceMinUnit && before.Currency == after.Currency {
+		return nil
+	}
+
+	if err := q.CreateListingPriceChange(ctx, repo.CreateListingPriceChangeParams{
+		ListingID:       after.ID,
+		OldPriceMinUnit: before.PriceMinUnit,
+		NewPriceMinUnit: after.PriceMinUnit,
+		OldCurrency:     before.Currency,
+		NewCurrency:     after.Currency,
+	}); err != nil {
+		return fmt.Errorf("failed to record price change: %w", err)
+	}
+	return nil
+}
+
+// sellerPriceHistory loads the recent price changes of every listing a seller owns in one query. The chart is a
+// nicety, so a failure is logged and the listings are served without it.
+func (s *svc) sellerPriceHistory(ctx context.Context, sellerID pgtype.UUID) map[pgtype.UUID][]PriceChange {
+	rows, err := s.repo.GetRecentPriceHistoryBySeller(ctx, repo.GetRecentPriceHistoryBySellerParams{
+		SellerID:   sellerID,
+		PerListing: priceHistoryPoints,
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to fetch price history", "seller_id", sellerID.String(), "error", err)
+		return nil
+	}
+
+	history := make(map[pgtype.UUID][]PriceChange)
+	for _, row := range rows {
+		history[row.ListingID] = append(history[row.ListingID], PriceChange{
+			OldPriceMinUnit: row.OldPriceMinUnit,
+			NewPriceMinUnit: row.NewPriceMinUnit,
+			OldCurrency:     row.OldCurrency,
+			NewCurrency:     row.NewCurrency,
+			ChangedAt:       utc(row.ChangedAt),
+		})
+	}
+	return history
+}
@@ -0,0 +1,76 @@
+package listings
+
+import (
+	"fmt"
+	"gateway/internal/errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// nozzleDiametersMM are the nozzle sizes a listing can recommend, the listings table has a CHECK with the same values.
+// ValidationRules can narrow them down but never add to them.
+var nozzleDiametersMM = []float64{0.2, 0.25, 0.4, 0.6, 0.8, 1.0}
+
+// parseNozzleDiameter reads the diameter the way sellers type it, "0.4" or "0.4mm". An empty value clears it.
+func parseNozzleDiameter(value string, rules ValidationRules) (pgtype.Numeric, *errors.AppError) {
+	value = strings.TrimSpace(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(value)), "mm"))
+	if value == "" {
+		return pgtype.Numeric{}, nil
+	}
+
+	mm, err := strconv.ParseFloat(value, 64)
+	if err == nil {
+		for _, allowed := range rules.NozzleDiametersMM {
+			if mm == allowed {
+				var diameter pgtype.Numeric
+				if err := diameter.Scan(strconv.FormatFloat(mm, 'f', -1, 64)); err != nil {
+					return pgtype.Numeric{}, errors.New(errors.ErrInternal, "Failed to read nozzle diameter", fmt.Errorf("failed to convert nozzle diameter %v: %w", mm, err))
+				}
+				return diameter, nil
+			}
+		}
+	}
+
+	diameters := rules.diameterList()
+	return pgtype.Numeric{}, errors.New(errors.ErrInvalidInput, fmt.Sprintf("Nozzle diameter must be one of %s mm", diameters), nil).
+		WithReason(errors.ReasonListingNozzleDiameter).
+		WithParam("diameters", diameters)
+}
+
+// nozzleDiameterMM is the stored diameter for responses, nil when the seller didn't give one
+func nozzleDiameterMM(diameter pgtype.Numeric) *float64 {
+	value, err := diameter.Float64Value()
+	if err != nil || !value.Valid {
+		return nil
+	}
+	// NUMERIC(3,2) comes back as e.g. 40e-2, round off the float error so 0.4 reads as 0.4
+	mm := math.Round(value.Float64*100) / 100
+	return &mm
+}
+
+// nozzleTempC folds the deprecated nozzleTemperature field into recommendedNozzleTempC, which wins if both are sent.
+// Older clients send 0 for an emptied temperature field, that means no temperature rather than 0°C.
+func nozzleTempC(recommended, deprecated *float64) *float64 {
+	if recommended != nil {
+		return recommended
+	}
+	if deprecated != nil && *deprecated != 0 {
+		return deprecated
+	}
+	return nil
+}
+
+func validateNozzleTemp(temp *float64, rules ValidationRules) *errors.AppError {
+	// Sanity range for consumer 3D printing
+	if temp != nil && (*temp < rules.NozzleTempMinC || *temp > rules.NozzleTempMaxC) {
+		low, high := strconv.FormatFloat(rules.NozzleTempMinC, 'f', -1, 64), strconv.FormatFloat(rules.NozzleTempMaxC, 'f', -1, 64)
+		return errors.New(errors.ErrInvalidInput, fmt.Sprintf("Recommended nozzle temperature must be within a realistic range (%s-%s°C)", low, high), nil).
+			WithReason(errors.ReasonListingNozzleTempRange).
+			WithParam("min", low).
+			WithParam("max", high)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package listings
+
+import (
+	"context"
+	"gateway/internal/errors"
+	"gateway/internal/testutil"
+	"gateway/internal/testutil/fixtures"
+	"testing"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestParseNozzleDiameter(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    *float64
+		wantErr bool
+	}{
+		{value: "0.4", want: ptr(0.4)},
+		{value: "0.4mm", want: ptr(0.4)},
+		{value: " 0.25 MM ", want: ptr(0.25)},
+		{value: "1", want: ptr(1.0)},
+		{value: "1.0mm", want: ptr(1.0)},
+		{value: "", want: nil},
+		{value: "0.5", wantErr: true},
+		{value: "0.40000001", wantErr: true},
+		{value: "-0.4", wantErr: true},
+		{value: "wide", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			diameter, appErr := parseNozzleDiameter(tt.value, DefaultValidationRules())
+			if tt.wantErr {
+				require.NotNil(t, appErr)
+				assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+				assert.Equal(t, errors.ReasonListingNozzleDiameter, appErr.Reason)
+				return
+			}
+			require.Nil(t, appErr)
+			assert.Equal(t, tt.want, nozzleDiameterMM(diameter))
+		})
+	}
+}
+
+func TestNozzleTempC_DeprecatedField(t *testing.T) {
+	assert.Equal(t, ptr(215.0), nozzleTempC(ptr(215.0), ptr(200.0)), "recommendedNozzleTempC wins")
+	assert.Equal(t, ptr(200.0), nozzleTempC(nil, ptr(200.0)), "nozzleTemperature is read when it is the only one")
+	assert.Nil(t, nozzleTempC(nil, ptr(0.0)), "an emptied field from an older client is no temperature")
+	assert.Nil(t, nozzleTempC(nil, nil))
+}
+
+func TestCreateUpdatedListing_NozzleRoundTrip(t *testing.T) {
+	// SCENARIO: A seller sets the nozzle on an existing listing from the old edit form, which still sends nozzleTemperature.
+	// EXPECT: Both end up on the listing and come back out in the response.
+
+	req := &UpdateListingRequest{PrinterSettings: &UpdateListingPrinterSettings{
+		NozzleDiameter:    ptr("0.6mm"),
+		NozzleTemperature: ptr(230.0),
+	}}
+
+	listing, appErr := req.Patch().CreateUpdatedListing(fixtures.NewListing(), DefaultValidationRules())
+	require.Nil(t, appErr)
+	assert.Equal(t, pgtype.Int4{Int32: 230, Valid: true}, listing.RecommendedNozzleTempC)
+
+	service := &svc{logger: testutil.NewTestLogger()}
+	response := service.toListingResponse(context.Background(), fixtures.NewListingRow(fixtures.With(func(l *repo.Listing) {
+		l.RecommendedNozzleTempC = listing.RecommendedNozzleTempC
+		l.NozzleDiameterMm = listing.NozzleDiameterMm
+	})))
+
+	assert.Equal(t, ptr(0.6), response.NozzleDiameterMM)
+	assert.Equal(t, ptr(230), response.RecommendedNozzleTempC)
+}
+
+func TestCreateUpdatedListing_NozzleDiameter(t *testing.T) {
+	existing := func(t *testing.T) repo.Listing {
+		return fixtures.NewListing()
+	}
+
+	t.Run("Left out keeps it", func(t *testing.T) {
+		req := &UpdateListingRequest{PrinterSettings: &UpdateListingPrinterSettings{IsMulticolor: ptr(true)}}
+		listing, appErr := req.Patch().CreateUpdatedListing(existing(t), DefaultValidationRules())
+		require.Nil(t, appErr)
+		assert.Equal(t, ptr(0.4), nozzleDiameterMM(listing.NozzleDiameterMm))
+	})
+
+	t.Run("Empty clears it", func(t *testing.T) {
+		req := &UpdateListingRequest{PrinterSettings: &UpdateListingPrinterSettings{NozzleDiameter: ptr("")}}
+		listing, appErr := req.Patch().CreateUpdatedListing(existing(t), DefaultValidationRules())
+		require.Nil(t, appErr)
+		assert.False(t, listing.NozzleDiameterMm.Valid)
+	})
+
+	t.Run("Unlisted size refused", func(t *testing.T) {
+		req := &UpdateListingRequest{PrinterSettings: &UpdateListingPrinterSettings{NozzleDiameter: ptr("0.3mm")}}
+		_, appErr := req.Patch().CreateUpdatedListing(existing(t), DefaultValidationRules())
+		require.NotNil(t, appErr)
+		assert.Equal(t, errors.ReasonListingNozzleDiameter, appErr.Reason)
+	})
+
+	t.Run("Temperature out of range refused", func(t *testing.T) {
+		req := &UpdateListingRequest{PrinterSettings: &UpdateListingPrinterSettings{RecommendedNozzleTempC: ptr(600.0)}}
+		_, appErr := req.Patch().CreateUpdatedListing(existing(t), DefaultValidationRules())
+		require.NotNil(t, appErr)
+		assert.Equal(t, errors.ReasonListingNozzleTempRange, appErr.Reason)
+	})
+}
@@ -0,0 +1,148 @@
+package listings
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/cache"
+	"gateway/internal/errors"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// ParentCheckTTL is how long whether a listing is live is cached for remixes that point at it. Deletes clear it
+	// straight away, so this only bounds how long a parent restored outside the gateway still shows as unavailable.
+	ParentCheckTTL = 10 * time.Minute
+	// RemixTreeMaxDepth caps how many generations of remixes GET /listings/{id}/remixes returns
+	RemixTreeMaxDepth = 10
+
+	liveKeyPrefix = "listing-live:"
+)
+
+// RemixNode is a listing in a remix tree with the remixes made from it
+type RemixNode struct {
+	ListingID      string       `json:"listing_id"`
+	Title          string       `json:"title"`
+	SellerUsername string       `json:"seller_username"`
+	ThumbnailURL   *string      `json:"thumbnail_url"`
+	Remixes        []*RemixNode `json:"remixes"`
+}
+
+// liveKey is keyed on the dashless ID, the same listing is asked about with and without dashes
+func liveKey(id pgtype.UUID) string {
+	return fmt.Sprintf("%s%x", liveKeyPrefix, id.Bytes)
+}
+
+// applyParent flags a remix whose parent has been deleted. The listing response is cached for far longer than the
+// parent check, so this runs on every read instead of being baked into the cached copy.
+func (s *svc) applyParent(ctx context.Context, response *ListingResponse) {
+	response.ParentUnavailable = false
+	if response.ParentListingID == nil {
+		return
+	}
+
+	var parentID pgtype.UUID
+	if err := parentID.Scan(*response.ParentListingID); err != nil {
+		s.logger.WarnContext(ctx, "Remix has an unreadable parent ID", "listing_id", response.ID, "parent_listing_id", *response.ParentListingID)
+		return
+	}
+
+	live, err := s.isListingLive(ctx, parentID)
+	if err != nil {
+		// Show the link rather than hide a parent that is probably still there
+		s.logger.ErrorContext(ctx, "Failed to check remix parent", "listing_id", response.ID, "parent_listing_id", *response.ParentListingID, "error", err)
+		return
+	}
+	response.ParentUnavailable = !live
+}
+
+func (s *svc) isListingLive(ctx context.Context, id pgtype.UUID) (bool, error) {
+	key := liveKey(id)
+	cached, found, err := cache.Get[bool](s.cache, ctx, key)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to get listing liveness from cache", "listing_id", id.String(), "error", err)
+	} else if found {
+		return *cached, nil
+	}
+
+	live, err := s.repo.IsListingLive(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if err := cache.Set(s.cache, ctx, key, live, ParentCheckTTL); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to cache listing liveness", "listing_id", id.String(), "error", err)
+	}
+	return live, nil
+}
+
+// forgetLiveness clears the cached liveness of deleted listings, so their remixes stop showing them as parents
+func (s *svc) forgetLiveness(ctx context.Context, ids []pgtype.UUID) {
+	for _, id := range ids {
+		if err := cache.Del(s.cache, ctx, liveKey(id)); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to bust listing liveness", "listing_id", id.String(), "error", err)
+		}
+	}
+}
+
+// GetRemixTree returns the listing with every generation of remixes below it. Deleted remixes are left out and their
+// own remixes move up to the nearest ancestor that is still live, so deleting one listing never hides a whole branch.
+func (s *svc) GetRemixTree(ctx context.Context, listingID string) (*RemixNode, error) {
+	var listingUUID pgtype.UUID
+	if err := listingUUID.Scan(listingID); err != nil {
+		return nil, errors.New(errors.ErrInvalidInput, "Invalid listing ID provided", err)
+	}
+
+	rows, err := s.repo.GetRemixTree(ctx, repo.GetRemixTreeParams{ID: listingUUID, MaxDepth: RemixTreeMaxDepth})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to fetch remix tree", "listing_id", listingID, "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch remixes", fmt.Errorf("failed to fetch remix tree of %v: %w", listingID, err))
+	}
+	if len(rows) == 0 || rows[0].DeletedAt.Valid {
+		return nil, errors.New(errors.ErrNotFound, "Listing not found", fmt.Errorf("listing %v not found", listingID)).WithReason(errors.ReasonListingNotFound)
+	}
+
+	return s.buildRemixTree(rows), nil
+}
+
+// buildRemixTree expects rows in depth order, root first, so a node's parent has always been placed before it
+func (s *svc) buildRemixTree(rows []repo.GetRemixTreeRow) *RemixNode {
+	root := s.remixNode(rows[0])
+	// Where each listing's remixes go, a deleted listing hands them to the node its own remixes would have gone to
+	attachTo := map[[16]byte]*RemixNode{rows[0].ID.Bytes: root}
+
+	for _, row := range rows[1:] {
+		if _, seen := attachTo[row.ID.Bytes]; seen {
+			// Only a parent chain that loops back on itself gets here
+			continue
+		}
+		parent, ok := attachTo[row.ParentListingID.Bytes]
+		if !ok {
+			continue
+		}
+		if row.DeletedAt.Valid {
+			attachTo[row.ID.Bytes] = parent
+			continue
+		}
+		node := s.remixNode(row)
+		parent.Remixes = append(parent.Remixes, node)
+		attachTo[row.ID.Bytes] = node
+	}
+	return root
+}
+
+func (s *svc) remixNode(row repo.GetRemixTreeRow) *RemixNode {
+	node := &RemixNode{
+		ListingID:      fmt.Sprintf("%x", row.ID.Bytes),
+		Title:          row.Title,
+		SellerUsername: row.SellerUsername,
+		Remixes:        []*RemixNode{},
+	}
+	if row.ThumbnailPath.Valid {
+		url := s.urls.Image(row.ThumbnailPath.String)
+		node.ThumbnailURL = &url
+	}
+	return node
+}
@@ -0,0 +1,114 @@
+package listings
+
+import (
+	"fmt"
+	"gateway/internal/publicurl"
+	"gateway/internal/testutil"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func remixID(n byte) pgtype.UUID {
+	return pgtype.UUID{Bytes: [16]byte{15: n}, Valid: true}
+}
+
+// remixRow is listing n remixed from listing parent, deleted when deleted is set
+func remixRow(n, parent byte, depth int32, deleted bool) repo.GetRemixTreeRow {
+	row := repo.GetRemixTreeRow{
+		ID:             remixID(n),
+		Title:          fmt.Sprintf("Listing %d", n),
+		SellerUsername: "maker",
+		Depth:          depth,
+	}
+	if depth > 0 {
+		row.ParentListingID = remixID(parent)
+	}
+	if deleted {
+		row.DeletedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	}
+	return row
+}
+
+// shape renders a tree as "title(child, child)" so the expected layout fits on one line
+func shape(node *RemixNode) string {
+	out := node.Title
+	if len(node.Remixes) == 0 {
+		return out
+	}
+	out += "("
+	for i, remix := range node.Remixes {
+		if i > 0 {
+			out += ", "
+		}
+		out += shape(remix)
+	}
+	return out + ")"
+}
+
+func TestBuildRemixTree(t *testing.T) {
+	// Every case is rooted at listing 1: 2 and 3 remix it, 4 remixes 2, 5 remixes 4
+	tests := []struct {
+		name    string
+		deleted map[byte]bool
+		want    string
+	}{
+		{name: "Nothing deleted", want: "Listing 1(Listing 2(Listing 4(Listing 5)), Listing 3)"},
+		{name: "Leaf deleted", deleted: map[byte]bool{5: true}, want: "Listing 1(Listing 2(Listing 4), Listing 3)"},
+		{name: "Middle deleted, grandchild moves up", deleted: map[byte]bool{2: true}, want: "Listing 1(Listing 3, Listing 4(Listing 5))"},
+		{name: "Two generations deleted", deleted: map[byte]bool{2: true, 4: true}, want: "Listing 1(Listing 3, Listing 5)"},
+		{name: "Every remix deleted", deleted: map[byte]bool{2: true, 3: true, 4: true, 5: true}, want: "Listing 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &svc{logger: testutil.NewTestLogger()}
+			rows := []repo.GetRemixTreeRow{
+				remixRow(1, 0, 0, false),
+				remixRow(2, 1, 1, tt.deleted[2]),
+				remixRow(3, 1, 1, tt.deleted[3]),
+				remixRow(4, 2, 2, tt.deleted[4]),
+				remixRow(5, 4, 3, tt.deleted[5]),
+			}
+
+			tree := service.buildRemixTree(rows)
+
+			assert.Equal(t, tt.want, shape(tree))
+		})
+	}
+}
+
+func TestBuildRemixTree_Node(t *testing.T) {
+	service := &svc{logger: testutil.NewTestLogger(), urls: publicurl.Config{AssetsBaseURL: "https://assets.test"}}
+	root := remixRow(1, 0, 0, false)
+	root.ThumbnailPath = pgtype.Text{String: "listings/benchy.webp", Valid: true}
+
+	tree := service.buildRemixTree([]repo.GetRemixTreeRow{root, remixRow(2, 1, 1, false)})
+
+	assert.Equal(t, "00000000000000000000000000000001", tree.ListingID)
+	require.NotNil(t, tree.ThumbnailURL)
+	assert.Equal(t, "https://assets.test/listings/benchy.webp", *tree.ThumbnailURL)
+	require.Len(t, tree.Remixes, 1)
+	assert.Nil(t, tree.Remixes[0].ThumbnailURL)
+	assert.NotNil(t, tree.Remixes[0].Remixes, "a leaf sends [] rather than null")
+}
+
+func TestBuildRemixTree_LoopedParents(t *testing.T) {
+	// SCENARIO: A corrupt parent chain leads back to the root, so the recursive query returns it again below itself.
+	// EXPECT: Each listing appears once.
+
+	service := &svc{logger: testutil.NewTestLogger()}
+	rows := []repo.GetRemixTreeRow{
+		remixRow(1, 2, 0, false),
+		remixRow(2, 1, 1, false),
+		remixRow(1, 2, 2, false),
+		remixRow(2, 1, 3, false),
+	}
+
+	assert.Equal(t, "Listing 1(Listing 2)", shape(service.buildRemixTree(rows)))
+}
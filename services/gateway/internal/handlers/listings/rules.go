@@ -0,0 +1,241 @@
+package listings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// RoleVerifiedSeller is the role a seller whose payouts are verified is checked under. It never comes from the token,
+// the service adds it from the seller profile.
+const RoleVerifiedSeller = "verified_seller"
+
+// ValidationRules are the bounds and allowlists a new listing is checked against, see CreateListingRequest.Validate.
+// The frontend gets the same rules from GET /listings/validation-rules so its form can check them before submitting.
+type ValidationRules struct {
+	TitleMinLength       int       `json:"titleMinLength"`
+	TitleMaxLength       int       `json:"titleMaxLength"`
+	DescriptionMinLength int       `json:"descriptionMinLength"`
+	DescriptionMaxLength int       `json:"descriptionMaxLength"`
+	Currencies           []string  `json:"currencies"` // Lower case, only checked on paid listings
+	NozzleTempMinC       float64   `json:"nozzleTempMinC"`
+	NozzleTempMaxC       float64   `json:"nozzleTempMaxC"`
+	NozzleDiametersMM    []float64 `json:"nozzleDiametersMM"` // Must stay within the listings table's CHECK
+	MaxFiles             int       `json:"maxFiles"`          // Models and images together, 0 is no limit
+	// Prices above this get a LISTING_PRICE_HIGH warning rather than being refused, 0 turns the warning off
+	PriceWarnAboveMinUnit int64 `json:"priceWarnAboveMinUnit"`
+}
+
+func DefaultValidationRules() ValidationRules {
+	return ValidationRules{
+		TitleMinLength:        5,
+		TitleMaxLength:        100,
+		DescriptionMinLength:  20,
+		DescriptionMaxLength:  5000,
+		Currencies:            []string{"usd", "gbp"},
+		NozzleTempMinC:        180,
+		NozzleTempMaxC:        450,
+		NozzleDiametersMM:     slices.Clone(nozzleDiametersMM),
+		MaxFiles:              0,
+		PriceWarnAboveMinUnit: 50000, // 500.00
+	}
+}
+
+// RoleValidationRules replaces the default rules for callers with Role
+type RoleValidationRules struct {
+	Role  string          `json:"role"`
+	Rules ValidationRules `json:"rules"`
+}
+
+// ValidationRuleSet is the rules for everyone plus the overrides for particular roles
+type ValidationRuleSet struct {
+	Default   ValidationRules       `json:"default"`
+	Overrides []RoleValidationRules `json:"overrides"` // In config order, the first one matching a caller's roles wins
+}
+
+func DefaultValidationRuleSet() *ValidationRuleSet {
+	return &ValidationRuleSet{Default: DefaultValidationRules(), Overrides: []RoleValidationRules{}}
+}
+
+// For returns the rules for a caller with roles
+func (s *ValidationRuleSet) For(roles []string) ValidationRules {
+	for _, override := range s.Overrides {
+		if slices.Contains(roles, override.Role) {
+			return override.Rules
+		}
+	}
+	return s.Default
+}
+
+// LoadValidationRules reads the rule set from LISTING_VALIDATION_RULES. Fields left out keep their defaults, and an
+// override starts from the configured default rules, so it only needs the fields it changes:
+//
+//	{"titleMaxLength": 120, "overrides": [{"role": "verified_seller", "maxFiles": 50}]}
+//
+// Empty config is the default rule set.
+func LoadValidationRules(raw []byte) (*ValidationRuleSet, error) {
+	set := DefaultValidationRuleSet()
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return set, nil
+	}
+
+	var config struct {
+		ValidationRules
+		Overrides []json.RawMessage `json:"overrides"`
+	}
+	config.ValidationRules = set.Default
+	if err := decodeStrict(raw, &config); err != nil {
+		return nil, err
+	}
+	if err := config.ValidationRules.check(); err != nil {
+		return nil, err
+	}
+	set.Default = config.ValidationRules
+
+	for i, rawOverride := range config.Overrides {
+		override := struct {
+			Role string `json:"role"`
+			ValidationRules
+		}{ValidationRules: set.Default.clone()}
+		if err := decodeStrict(rawOverride, &override); err != nil {
+			return nil, fmt.Errorf("override %d: %w", i, err)
+		}
+		if override.Role == "" {
+			return nil, fmt.Errorf("override %d has no role", i)
+		}
+		if slices.ContainsFunc(set.Overrides, func(r RoleValidationRules) bool { return r.Role == override.Role }) {
+			return nil, fmt.Errorf("role %q has more than one override", override.Role)
+		}
+		if err := override.ValidationRules.check(); err != nil {
+			return nil, fmt.Errorf("override for %q: %w", override.Role, err)
+		}
+		set.Overrides = append(set.Overrides, RoleValidationRules{Role: override.Role, Rules: override.ValidationRules})
+	}
+
+	return set, nil
+}
+
+func decodeStrict(raw []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// check refuses rules that would reject every listing or let through ones the database won't store
+func (r ValidationRules) check() error {
+	switch {
+	case r.TitleMinLength < 0 || r.TitleMaxLength < r.TitleMinLength:
+		return fmt.Errorf("title length must be 0 <= min <= max, got %d-%d", r.TitleMinLength, r.TitleMaxLength)
+	case r.DescriptionMinLength < 0 || r.DescriptionMaxLength < r.DescriptionMinLength:
+		return fmt.Errorf("description length must be 0 <= min <= max, got %d-%d", r.DescriptionMinLength, r.DescriptionMaxLength)
+	case len(r.Currencies) == 0:
+		return fmt.Errorf("at least one currency is required")
+	case len(r.NozzleDiametersMM) == 0:
+		return fmt.Errorf("at least one nozzle diameter is required")
+	case r.NozzleTempMinC < 0 || r.NozzleTempMaxC < r.NozzleTempMinC:
+		return fmt.Errorf("nozzle temperature must be 0 <= min <= max, got %g-%g", r.NozzleTempMinC, r.NozzleTempMaxC)
+	case r.MaxFiles < 0:
+		return fmt.Errorf("max files can't be negative, got %d", r.MaxFiles)
+	case r.PriceWarnAboveMinUnit < 0:
+		return fmt.Errorf("price warning threshold can't be negative, got %d", r.PriceWarnAboveMinUnit)
+	}
+	for _, currency := range r.Currencies {
+		if currency == "" || currency != strings.ToLower(currency) {
+			return fmt.Errorf("currency %q must be a lower case code", currency)
+		}
+	}
+	for _, mm := range r.NozzleDiametersMM {
+		if !slices.Contains(nozzleDiametersMM, mm) {
+			return fmt.Errorf("nozzle diameter %g mm isn't allowed by the listings table", mm)
+		}
+	}
+	return nil
+}
+
+func (r ValidationRules) clone() ValidationRules {
+	r.Currencies = slices.Clone(r.Currencies)
+	r.NozzleDiametersMM = slices.Clone(r.NozzleDiametersMM)
+	return r
+}
+
+// validationRules picks the rules for a seller from the token's roles, plus RoleVerifiedSeller once their payouts are
+// verified. Services built without a rule set use the defaults.
+func (s *svc) validationRules(userInfo auth.UserInfo, verifiedSeller bool) ValidationRules {
+	if s.rules == nil {
+		return DefaultValidationRules()
+	}
+	roles := userInfo.Roles
+	if verifiedSeller {
+		roles = append(slices.Clip(roles), RoleVerifiedSeller)
+	}
+	return s.rules.For(roles)
+}
+
+// sellerValidationRules is validationRules for a seller editing what they already listed, so an edit is checked
+// against the same rules the create was. The payout status is only read when the rule set has an override for
+// verified sellers, and a caller without a seller profile isn't one.
+func (s *svc) sellerValidationRules(ctx context.Context, userInfo auth.UserInfo) (ValidationRules, error) {
+	if s.rules == nil || !slices.ContainsFunc(s.rules.Overrides, func(o RoleValidationRules) bool { return o.Role == RoleVerifiedSeller }) {
+		return s.validationRules(userInfo, false), nil
+	}
+
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userInfo.ID); err != nil {
+		return ValidationRules{}, errors.New(errors.ErrInvalidInput, "Invalid user ID provided", err)
+	}
+	seller, err := s.repo.GetSellerProfile(ctx, userUUID)
+	if err == pgx.ErrNoRows {
+		return s.validationRules(userInfo, false), nil
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to fetch seller profile", "error", err)
+		return ValidationRules{}, errors.New(errors.ErrInternal, "Failed to update listing. Please try again later.", fmt.Errorf("failed to fetch seller profile: %w", err))
+	}
+	return s.validationRules(userInfo, seller.PayoutStatus == repo.PayoutStatusVERIFIED), nil
+}
+
+func (s *svc) GetValidationRules() *ValidationRuleSet {
+	if s.rules == nil {
+		return DefaultValidationRuleSet()
+	}
+	return s.rules
+}
+
+// currencyList is the currencies for a message, 'usd' or 'gbp'
+func (r ValidationRules) currencyList() string {
+	quoted := make([]string, len(r.Currencies))
+	for i, currency := range r.Currencies {
+		quoted[i] = "'" + currency + "'"
+	}
+	return joinOr(quoted)
+}
+
+// diameterList is the nozzle diameters for a message, 0.2, 0.4 or 0.6
+func (r ValidationRules) diameterList() string {
+	formatted := make([]string, len(r.NozzleDiametersMM))
+	for i, mm := range r.NozzleDiametersMM {
+		formatted[i] = strconv.FormatFloat(mm, 'f', -1, 64)
+		if mm == float64(int(mm)) {
+			formatted[i] = strconv.FormatFloat(mm, 'f', 1, 64)
+		}
+	}
+	return joinOr(formatted)
+}
+
+func joinOr(items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " or " + items[len(items)-1]
+}
@@ -0,0 +1,226 @@
+package listings
+
+import (
+	"context"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/testutil"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadValidationRules_Empty_Defaults(t *testing.T) {
+	set, err := LoadValidationRules([]byte("  "))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultValidationRules(), set.Default)
+	assert.Empty(t, set.Overrides)
+}
+
+func TestLoadValidationRules_Overrides(t *testing.T) {
+	// SCENARIO: Production raises the title limit for everyone and lets verified sellers attach more files.
+	// EXPECT: Unset fields keep their defaults, and the override starts from the configured rules, not the built-in ones.
+
+	set, err := LoadValidationRules([]byte(`{
+		"titleMaxLength": 120,
+		"currencies": ["usd", "gbp", "eur"],
+		"maxFiles": 10,
+		"overrides": [{"role": "verified_seller", "maxFiles": 50}]
+	}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, 120, set.Default.TitleMaxLength)
+	assert.Equal(t, 5, set.Default.TitleMinLength)
+	assert.Equal(t, 10, set.Default.MaxFiles)
+
+	verified := set.For([]string{"admin", RoleVerifiedSeller})
+	assert.Equal(t, 50, verified.MaxFiles)
+	assert.Equal(t, 120, verified.TitleMaxLength)
+	assert.Equal(t, []string{"usd", "gbp", "eur"}, verified.Currencies)
+
+	assert.Equal(t, set.Default, set.For(nil))
+}
+
+func TestLoadValidationRules_Rejects(t *testing.T) {
+	tests := map[string]string{
+		"not json":            `titleMaxLength=120`,
+		"unknown field":       `{"titleMax": 120}`,
+		"min over max":        `{"titleMinLength": 50, "titleMaxLength": 10}`,
+		"no currencies":       `{"currencies": []}`,
+		"upper case currency": `{"currencies": ["USD"]}`,
+		"diameter not stored": `{"nozzleDiametersMM": [0.4, 0.5]}`,
+		"negative max files":  `{"maxFiles": -1}`,
+		"override no role":    `{"overrides": [{"maxFiles": 50}]}`,
+		"duplicate role":      `{"overrides": [{"role": "verified_seller"}, {"role": "verified_seller"}]}`,
+		"bad override":        `{"overrides": [{"role": "verified_seller", "nozzleTempMinC": 500}]}`,
+	}
+
+	for name, raw := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadValidationRules([]byte(raw))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestValidate_TwoRuleSets(t *testing.T) {
+	// SCENARIO: The same requests are checked against the default rules and a stricter environment's rules.
+	// EXPECT: Each rule set draws the line where it says, and the error carries the configured bounds.
+
+	const userID = "550e8400-e29b-41d4-a716-446655440000"
+	valid := func() *CreateListingRequest {
+		return &CreateListingRequest{
+			Title:        "Valid Listing",
+			Description:  "A great item for the whole family",
+			PriceMinUnit: 500,
+			Currency:     "gbp",
+			Categories:   []string{"Art"},
+			License:      "MIT",
+			Files: []CreateListingFile{
+				{Type: "model", Path: "2025/01/01/" + userID + "/draft/model/model.stl", Size: 1024},
+				{Type: "image", Path: "2025/01/01/" + userID + "/draft/image/image.jpg", Size: 500},
+			},
+		}
+	}
+
+	strict := DefaultValidationRules()
+	strict.TitleMaxLength = 10
+	strict.DescriptionMaxLength = 40
+	strict.Currencies = []string{"eur"}
+	strict.NozzleTempMinC, strict.NozzleTempMaxC = 190, 260
+	strict.NozzleDiametersMM = []float64{0.4}
+	strict.MaxFiles = 2
+
+	tests := []struct {
+		name       string
+		mutate     func(req *CreateListingRequest)
+		wantDef    errors.Reason // "" is valid
+		wantStrict errors.Reason
+		params     map[string]string // On the strict rule set's error
+	}{
+		{"as is", func(r *CreateListingRequest) {}, "", errors.ReasonListingTitleLength, map[string]string{"min": "5", "max": "10"}},
+		{"paid in gbp", func(r *CreateListingRequest) { r.Title = "Vases" }, "", errors.ReasonListingCurrencyUnsupported, map[string]string{"currencies": "'eur'"}},
+		{"long description", func(r *CreateListingRequest) {
+			r.Title, r.Currency, r.Description = "Vases", "eur", strings.Repeat("a", 41)
+		}, errors.ReasonListingCurrencyUnsupported, errors.ReasonListingDescriptionLong, map[string]string{"max": "40"}},
+		{"hot nozzle", func(r *CreateListingRequest) {
+			r.Title, r.PriceMinUnit = "Vases", 0
+			r.PrinterSettings.RecommendedNozzleTempC = ptr(300.0)
+		}, "", errors.ReasonListingNozzleTempRange, map[string]string{"min": "190", "max": "260"}},
+		{"big nozzle", func(r *CreateListingRequest) {
+			r.Title, r.PriceMinUnit = "Vases", 0
+			r.PrinterSettings.NozzleDiameter = ptr("0.6mm")
+		}, "", errors.ReasonListingNozzleDiameter, map[string]string{"diameters": "0.4"}},
+		{"third file", func(r *CreateListingRequest) {
+			r.Title, r.PriceMinUnit = "Vases", 0
+			r.Files = append(r.Files, CreateListingFile{Type: "image", Path: "2025/01/01/" + userID + "/draft/image/side.jpg", Size: 500})
+		}, "", errors.ReasonListingTooManyFiles, map[string]string{"max": "2"}},
+		{"free listing any currency", func(r *CreateListingRequest) { r.Title, r.PriceMinUnit = "Vases", 0 }, "", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid()
+			tt.mutate(req)
+
+			if appErr := req.Validate(userID, DefaultValidationRules()); tt.wantDef == "" {
+				assert.Nil(t, appErr)
+			} else if assert.NotNil(t, appErr) {
+				assert.Equal(t, tt.wantDef, appErr.Reason)
+			}
+
+			appErr := req.Validate(userID, strict)
+			if tt.wantStrict == "" {
+				assert.Nil(t, appErr)
+				return
+			}
+			if assert.NotNil(t, appErr) {
+				assert.Equal(t, tt.wantStrict, appErr.Reason)
+				assert.Equal(t, tt.params, appErr.Params)
+			}
+		})
+	}
+}
+
+func TestValidationRules_VerifiedSellerOverride(t *testing.T) {
+	rules, err := LoadValidationRules([]byte(`{"maxFiles": 1, "overrides": [{"role": "verified_seller", "maxFiles": 5}]}`))
+	require.NoError(t, err)
+	service := &svc{rules: rules}
+	seller := auth.UserInfo{ID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"}
+
+	assert.Equal(t, 1, service.validationRules(seller, false).MaxFiles)
+	assert.Equal(t, 5, service.validationRules(seller, true).MaxFiles)
+	assert.Equal(t, DefaultValidationRules(), (&svc{}).validationRules(seller, true), "no rule set is the defaults")
+}
+
+func TestSellerValidationRules(t *testing.T) {
+	// SCENARIO: A seller edits a listing under a rule set that gives verified sellers more files.
+	// EXPECT: Their payout status is read the same way create reads it, and picks the rules. Without a profile, or
+	// without an override for verified sellers, they get the rules for their roles.
+
+	seller := auth.UserInfo{ID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"}
+	sellerRow := func(payoutStatus string) *pgxmock.Rows {
+		return pgxmock.NewRows(testutil.SellerCols).AddRow(
+			seller.ID, "Tester Prints", "GB", payoutStatus, "1", time.Now(), time.Now(), time.Now(), nil, nil, nil, false, nil,
+		)
+	}
+	tests := map[string]struct {
+		rules        string
+		rows         *pgxmock.Rows // nil is no profile, no query is expected without an override at all
+		wantMaxFiles int
+	}{
+		"Verified seller":   {rules: `{"maxFiles": 1, "overrides": [{"role": "verified_seller", "maxFiles": 5}]}`, rows: sellerRow("VERIFIED"), wantMaxFiles: 5},
+		"Unverified seller": {rules: `{"maxFiles": 1, "overrides": [{"role": "verified_seller", "maxFiles": 5}]}`, rows: sellerRow("PENDING"), wantMaxFiles: 1},
+		"No profile":        {rules: `{"maxFiles": 1, "overrides": [{"role": "verified_seller", "maxFiles": 5}]}`, rows: pgxmock.NewRows(testutil.SellerCols), wantMaxFiles: 1},
+		"No override":       {rules: `{"maxFiles": 1}`, wantMaxFiles: 1},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			service, mockPool := newUpdateTest(t)
+			rules, err := LoadValidationRules([]byte(tt.rules))
+			require.NoError(t, err)
+			service.rules = rules
+			if tt.rows != nil {
+				mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT user_id, display_name`)).
+					WithArgs(pgxmock.AnyArg()).
+					WillReturnRows(tt.rows)
+			}
+
+			got, err := service.sellerValidationRules(context.Background(), seller)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMaxFiles, got.MaxFiles)
+			assert.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCreateListing_UnverifiedSeller_FileLimit(t *testing.T) {
+	// SCENARIO: Only verified sellers may attach more than one file, and a seller whose payouts are pending sends two.
+	// EXPECT: Refused with LISTING_TOO_MANY_FILES after the seller profile is read, before any writes.
+
+	service, mockPool, userInfo, req := newSellerCheckTest(t)
+	service.termsVersion = "1"
+	rules, err := LoadValidationRules([]byte(`{"maxFiles": 1, "overrides": [{"role": "verified_seller", "maxFiles": 5}]}`))
+	require.NoError(t, err)
+	service.rules = rules
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT user_id, display_name`)).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(testutil.SellerCols).AddRow(
+			userInfo.ID, "Tester Prints", "GB", "PENDING", "1", time.Now(), time.Now(), time.Now(), nil, nil, nil, false, nil,
+		))
+
+	_, err = service.CreateListing(context.Background(), userInfo, req)
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ReasonListingTooManyFiles, appErr.Reason)
+	assert.Equal(t, "1", appErr.Params["max"])
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
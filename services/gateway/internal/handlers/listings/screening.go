@@ -0,0 +1,68 @@
+package listings
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/errors"
+	"slices"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// TermScreener checks listing text against the banned terms, screening.ScreeningService implements it
+type TermScreener interface {
+	Screen(ctx context.Context, text string) (rejected, flagged []string, err error)
+}
+
+// Recorded in the status history for a listing held because of its text
+const reasonScreeningReview = "Title or description uses a flagged term, held for review"
+
+// screeningHolds are the statuses an edit with flagged terms takes a listing out of. A rejected listing or one
+// already waiting for review stays where it is, the terms are recorded either way.
+var screeningHolds = map[repo.ListingStatus]bool{
+	repo.ListingStatusPENDINGVALIDATION: true,
+	repo.ListingStatusACTIVE:            true,
+	repo.ListingStatusHIDDEN:            true,
+}
+
+// screenText checks the title and description being saved, leave either empty when it isn't changing. A REJECT term
+// refuses the save, the FLAG terms found are returned so the listing can be held for review.
+func (s *svc) screenText(ctx context.Context, title, description string) ([]string, error) {
+	if s.screener == nil {
+		return nil, nil
+	}
+
+	var flagged []string
+	for _, field := range []struct{ name, text string }{{"title", title}, {"description", description}} {
+		if field.text == "" {
+			continue
+		}
+		rejected, found, err := s.screener.Screen(ctx, field.text)
+		if err != nil {
+			return nil, err
+		}
+		if len(rejected) > 0 {
+			s.logger.WarnContext(ctx, "Listing uses a banned term", "field", field.name)
+			return nil, errors.New(errors.ErrInvalidInput, fmt.Sprintf("'%s' isn't allowed in a listing %s", rejected[0], field.name), nil).
+				WithReason(errors.ReasonListingBannedTerm).
+				WithParam("term", rejected[0]).
+				WithParam("field", field.name)
+		}
+		for _, term := range found {
+			if !slices.Contains(flagged, term) {
+				flagged = append(flagged, term)
+			}
+		}
+	}
+	return flagged, nil
+}
+
+// recordScreeningFlags keeps the flagged terms for moderators. q must be bound to the transaction saving the listing.
+func recordScreeningFlags(ctx context.Context, q *repo.Queries, listingID pgtype.UUID, terms []string) error {
+	if err := q.UpsertListingScreeningFlags(ctx, repo.UpsertListingScreeningFlagsParams{ListingID: listingID, Terms: terms}); err != nil {
+		return fmt.Errorf("failed to record flagged terms: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package listings
+
+import (
+	"context"
+	"gateway/internal/errors"
+	"gateway/internal/testutil"
+	"regexp"
+	"strings"
+	"testing"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubScreener rejects "scam" and flags "replica" wherever they appear
+type stubScreener struct{}
+
+func (stubScreener) Screen(_ context.Context, text string) ([]string, []string, error) {
+	var rejected, flagged []string
+	if strings.Contains(strings.ToLower(text), "scam") {
+		rejected = append(rejected, "scam")
+	}
+	if strings.Contains(strings.ToLower(text), "replica") {
+		flagged = append(flagged, "replica")
+	}
+	return rejected, flagged, nil
+}
+
+func TestScreenText(t *testing.T) {
+	service := &svc{screener: stubScreener{}, logger: testutil.NewTestLogger()}
+
+	t.Run("Rejected term names the field", func(t *testing.T) {
+		_, err := service.screenText(context.Background(), "Benchy", "Not a scam, honest")
+		var appErr *errors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+		assert.Equal(t, errors.ReasonListingBannedTerm, appErr.Reason)
+		assert.Equal(t, "scam", appErr.Params["term"])
+		assert.Equal(t, "description", appErr.Params["field"])
+	})
+
+	t.Run("Flagged terms are listed once", func(t *testing.T) {
+		flagged, err := service.screenText(context.Background(), "Replica helmet", "A replica of the film prop")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"replica"}, flagged)
+	})
+
+	t.Run("No screener lets everything through", func(t *testing.T) {
+		flagged, err := (&svc{}).screenText(context.Background(), "Scam replica", "")
+		require.NoError(t, err)
+		assert.Empty(t, flagged)
+	})
+}
+
+// expectScreeningFlags expects the flagged terms to be saved for the listing being updated
+func expectScreeningFlags(t *testing.T, mockPool pgxmock.PgxPoolIface, terms ...string) {
+	t.Helper()
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: UpsertListingScreeningFlags :exec`)).
+		WithArgs(mustUUID(t, updateListingID), terms).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+}
+
+func TestSaveListingUpdate_FlaggedTermHoldsListing(t *testing.T) {
+	// SCENARIO: The seller renames an ACTIVE listing to something with a flagged term.
+	// EXPECT: The listing goes to PENDING_REVIEW in the same transaction, with the terms and the status change recorded.
+
+	service, mockPool := newUpdateTest(t)
+	service.screener = stubScreener{}
+	title := "Replica helmet"
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).
+		WithArgs(mustUUID(t, updateListingID)).
+		WillReturnRows(listingRows(updateSellerID, "Helmet", "public/thumb.webp", repo.ListingStatusACTIVE))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`UPDATE listings SET`)).
+		WithArgs(updateArgs(title, "public/thumb.webp", repo.ListingStatusPENDINGREVIEW)...).
+		WillReturnRows(listingRows(updateSellerID, title, "public/thumb.webp", repo.ListingStatusPENDINGREVIEW))
+	expectScreeningFlags(t, mockPool, "replica")
+	statusArgs := anyArgs(8)
+	statusArgs[3] = repo.NullListingStatus{ListingStatus: repo.ListingStatusACTIVE, Valid: true}
+	statusArgs[4] = repo.ListingStatusPENDINGREVIEW
+	statusArgs[5] = pgtype.Text{String: reasonScreeningReview, Valid: true}
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO listing_status_events`)).
+		WithArgs(statusArgs...).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockPool.ExpectCommit()
+
+	listing, err := service.saveListingUpdate(context.Background(), mustUUID(t, updateSellerID), mustUUID(t, updateListingID), (&UpdateListingRequest{Title: &title}).Patch(), DefaultValidationRules())
+
+	require.NoError(t, err)
+	assert.Equal(t, repo.ListingStatusPENDINGREVIEW, listing.Status.ListingStatus)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestSaveListingUpdate_FlaggedTermOnRejectedListing(t *testing.T) {
+	// SCENARIO: The seller edits a REJECTED listing and the new title uses a flagged term.
+	// EXPECT: The listing stays REJECTED, the terms are still recorded for moderators.
+
+	service, mockPool := newUpdateTest(t)
+	service.screener = stubScreener{}
+	title := "Replica helmet"
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).
+		WithArgs(mustUUID(t, updateListingID)).
+		WillReturnRows(listingRows(updateSellerID, "Helmet", "public/thumb.webp", repo.ListingStatusREJECTED))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`UPDATE listings SET`)).
+		WithArgs(updateArgs(title, "public/thumb.webp", repo.ListingStatusREJECTED)...).
+		WillReturnRows(listingRows(updateSellerID, title, "public/thumb.webp", repo.ListingStatusREJECTED))
+	expectScreeningFlags(t, mockPool, "replica")
+	mockPool.ExpectCommit()
+
+	_, err := service.saveListingUpdate(context.Background(), mustUUID(t, updateSellerID), mustUUID(t, updateListingID), (&UpdateListingRequest{Title: &title}).Patch(), DefaultValidationRules())
+
+	require.NoError(t, err)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestSaveListingUpdate_RejectedTerm(t *testing.T) {
+	// SCENARIO: The seller puts a REJECT term in the description.
+	// EXPECT: The edit is refused before the row is locked.
+
+	service, mockPool := newUpdateTest(t)
+	service.screener = stubScreener{}
+	description := "Definitely not a scam"
+
+	_, err := service.saveListingUpdate(context.Background(), mustUUID(t, updateSellerID), mustUUID(t, updateListingID), (&UpdateListingRequest{Description: &description}).Patch(), DefaultValidationRules())
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ReasonListingBannedTerm, appErr.Reason)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -8,14 +8,24 @@ import (
 	"gateway/internal/cache"
 	"gateway/internal/database/postgresql"
 	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/detach"
 	"gateway/internal/errors"
 	"gateway/internal/events"
+	"gateway/internal/language"
+	"gateway/internal/outbox"
+	"gateway/internal/publicurl"
+	"gateway/internal/ratelimit"
 	"gateway/internal/storage"
 	"log/slog"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -25,45 +35,120 @@ var listings []byte
 // Standard TTL: 30 mins to 1 hour is usually fine for Listings
 const ListingCacheTTL = time.Hour * 1
 
+// cacheWriteTimeout bounds the cache writes that carry on after the response has been sent
+const cacheWriteTimeout = 5 * time.Second
+
+// NotFoundCacheTTL is how long a listing that isn't there is remembered as missing, so bots asking for made up or
+// deleted IDs get their 404 from Redis rather than Postgres. Creating or editing the listing forgets it straight away.
+const NotFoundCacheTTL = 60 * time.Second
+
+// cachedListing is what's read back from a listing's cache key, a ListingResponse or the notFoundEntry marker. A
+// response never has not_found, so the two can't be mistaken for one another.
+type cachedListing struct {
+	ListingResponse
+	NotFound bool `json:"not_found,omitempty"`
+}
+
+// notFoundEntry is cached under a listing's key when the database has no such listing
+type notFoundEntry struct {
+	NotFound bool `json:"not_found"`
+}
+
+// listingResponseVersion changes whenever the shape of a cached ListingResponse does, so entries written by an older
+// build are left to expire rather than served with missing fields
+const listingResponseVersion = "8"
+
+// CacheNamespace is where listing responses are cached. They embed public URLs, so a new base URL starts a fresh namespace
+// instead of serving links to the old host for up to ListingCacheTTL.
+func CacheNamespace(urls publicurl.Config) cache.Namespace {
+	return cache.NamespaceListing.Versioned(listingResponseVersion).Versioned(urls.Version())
+}
+
 type ListingsService interface {
-	CreateListing(ctx context.Context, userInfo auth.UserInfo, req *CreateListingRequest) (repo.Listing, error)
-	GetListingsForUser(ctx context.Context, userInfo auth.UserInfo) ([]ListingResponse, error)
+	CreateListing(ctx context.Context, userInfo auth.UserInfo, req *CreateListingRequest) (*CreateListingResponse, error)
+	GetListingsForUser(ctx context.Context, userInfo auth.UserInfo, query SellerListingsQuery) ([]ListingResponse, error)
 	DeleteListing(ctx context.Context, userInfo auth.UserInfo, listingID string) error
-	UpdateListing(ctx context.Context, userInfo auth.UserInfo, listingID string, req *UpdateListingRequest) (*repo.Listing, error)
+	BulkUpdateListings(ctx context.Context, userInfo auth.UserInfo, req *BulkListingsRequest) (*BulkListingsResponse, error)
+	UpdateListing(ctx context.Context, userInfo auth.UserInfo, listingID string, req *UpdateListingRequest) (*UpdateListingResponse, error)
+	PatchListing(ctx context.Context, userInfo auth.UserInfo, listingID string, patch *ListingPatch) (*repo.Listing, error)
 	GetListingByID(ctx context.Context, listingID string) (*ListingResponse, error)
+	GetRemixTree(ctx context.Context, listingID string) (*RemixNode, error)
+	GetFileDownload(ctx context.Context, userInfo auth.UserInfo, listingID string, fileID string, preferLongTTL bool) (*FileDownloadResponse, error)
+	GetDownloadHistory(ctx context.Context, userInfo auth.UserInfo, cursor string, limit int) (*DownloadHistoryPage, error)
+	DownloadAgain(ctx context.Context, userInfo auth.UserInfo, listingID string, fileID string, preferLongTTL bool) (*FileDownloadResponse, error)
+	GetStatusHistory(ctx context.Context, userInfo auth.UserInfo, listingID string) ([]StatusEvent, error)
+	CreateVariant(ctx context.Context, userInfo auth.UserInfo, listingID string, req *CreateVariantRequest) (*ListingVariant, error)
+	DeleteVariant(ctx context.Context, userInfo auth.UserInfo, listingID string, variantID string) error
+	CheckListingOwner(ctx context.Context, userInfo auth.UserInfo, listingID string) error
+	RecordIndexFailure(ctx context.Context, evt events.ListingIndexFailedEvent) error
+	ListIndexFailures(ctx context.Context) ([]IndexFailedListing, error)
+	ListAdminListings(ctx context.Context, filter AdminListingsFilter) (*AdminListingsPage, error)
+	ExportAdminListings(ctx context.Context, filter AdminListingsFilter, each func(AdminListing) error) error
+	GetAdminListing(ctx context.Context, listingID string) (*AdminListingDetail, error)
+	SuspendListing(ctx context.Context, userInfo auth.UserInfo, listingID string, req *SuspendListingRequest) error
+	SetListingNSFW(ctx context.Context, userInfo auth.UserInfo, listingID string, isNSFW bool) error
+	GetValidationRules() *ValidationRuleSet
+	HydrateListing(ctx context.Context, req *HydrateListingRequest) (*ListingResponse, error)
+	GetListingsByIDs(ctx context.Context, listingIDs []string) ([]ListingResponse, error)
+	PreviewListingAsBuyer(ctx context.Context, userInfo auth.UserInfo, listingID string) (*ListingResponse, error)
+	ApplyValidationResult(ctx context.Context, fileID string, req *FileValidationResult) (*FileValidationResultResponse, error)
 }
 
 type svc struct {
-	repo           *repo.Queries
-	logger         *slog.Logger
-	db             postgresql.DBPool
-	storage        storage.Provider
-	eventHandler   *events.EventHandler
-	cache          *cache.RedisClient
-	publicFilesURL string
+	repo         *repo.Queries
+	logger       *slog.Logger
+	db           postgresql.DBPool
+	storage      storage.Provider
+	eventHandler *events.EventHandler
+	cache        *cache.RedisClient
+	urls         publicurl.Config
+	listingCache cache.Namespace // Versioned by urls, see CacheNamespace
+	downloads    DownloadConfig  // How long file URLs live, per file type
+	termsVersion string          // Current seller terms, sellers must have accepted these to list
+	limits       CreationLimits
+	images       ImageBounds        // Allowed gallery image dimensions, zero value skips the check
+	rules        *ValidationRuleSet // What Validate checks, nil uses DefaultValidationRules
+	creations    ratelimit.Counter  // Per-seller creation counts, nil disables the rate limits
+	hardware     HardwareVocabulary // Checks hardware_required, nil accepts any value
+	screener     TermScreener       // Checks titles and descriptions for banned terms, nil lets everything through
+	defaults     CategoryDefaults   // Templates for the create warnings, nil skips them
+	background   *sync.WaitGroup    // Tracks async cache writes so shutdown can wait for them before closing Redis
+	now          func() time.Time
 }
 
-func NewListingsService(repo *repo.Queries, db postgresql.DBPool, logger *slog.Logger, storage storage.Provider, eventHandler *events.EventHandler, cache *cache.RedisClient, publicFilesURL string) ListingsService {
+func NewListingsService(repo *repo.Queries, db postgresql.DBPool, logger *slog.Logger, storage storage.Provider, eventHandler *events.EventHandler, cache *cache.RedisClient, urls publicurl.Config, downloads DownloadConfig, termsVersion string, limits CreationLimits, images ImageBounds, rules *ValidationRuleSet, creations ratelimit.Counter, hardware HardwareVocabulary, screener TermScreener, defaults CategoryDefaults, background *sync.WaitGroup) ListingsService {
 	return &svc{
-		repo:           repo,
-		db:             db,
-		logger:         logger,
-		storage:        storage,
-		eventHandler:   eventHandler,
-		cache:          cache,
-		publicFilesURL: publicFilesURL,
+		repo:         repo,
+		db:           db,
+		logger:       logger,
+		storage:      storage,
+		eventHandler: eventHandler,
+		cache:        cache,
+		urls:         urls,
+		listingCache: CacheNamespace(urls),
+		downloads:    downloads,
+		termsVersion: termsVersion,
+		limits:       limits,
+		images:       images,
+		rules:        rules,
+		creations:    creations,
+		hardware:     hardware,
+		screener:     screener,
+		defaults:     defaults,
+		background:   background,
+		now:          time.Now,
 	}
 }
 
-type fileEventData struct {
-	ListingID string
-	FileID    string
-	FilePath  string
-	FileKey   string
-	FileType  string
+func (s *svc) CreateListing(ctx context.Context, userInfo auth.UserInfo, req *CreateListingRequest) (*CreateListingResponse, error) {
+	listing, rules, err := s.createListing(ctx, userInfo, req)
+	if err != nil {
+		return nil, err
+	}
+	return &CreateListingResponse{Listing: listing, Warnings: s.listingWarnings(ctx, createWarningInput(req), rules)}, nil
 }
 
-func (s *svc) CreateListing(ctx context.Context, userInfo auth.UserInfo, req *CreateListingRequest) (repo.Listing, error) {
+func (s *svc) createListing(ctx context.Context, userInfo auth.UserInfo, req *CreateListingRequest) (repo.Listing, ValidationRules, error) {
 	spanContext := trace.SpanContextFromContext(ctx)
 	traceIDVal := ""
 	if spanContext.IsValid() {
@@ -71,39 +156,112 @@ func (s *svc) CreateListing(ctx context.Context, userInfo auth.UserInfo, req *Cr
 	}
 
 	s.logger.InfoContext(ctx, "Creating listing", "user", userInfo.ID, "title", req.Title)
-	if err := req.Validate(userInfo.ID); err != nil {
-		s.logger.WarnContext(ctx, "Validation failed", "error", err)
-		return repo.Listing{}, err
-	}
-
-	s.logger.DebugContext(ctx, "Request validated successfully: \n%+v", req)
 
 	// 1. Convert UserID (String -> UUID)
 	var userUUID pgtype.UUID
 	if err := userUUID.Scan(userInfo.ID); err != nil {
 		s.logger.WarnContext(ctx, "Invalid user ID", "error", err)
-		return repo.Listing{}, errors.New(errors.ErrInternal, "Invalid user ID", fmt.Errorf("invalid user uuid: %w", err))
+		return repo.Listing{}, ValidationRules{}, errors.New(errors.ErrInternal, "Invalid user ID", fmt.Errorf("invalid user uuid: %w", err))
+	}
+
+	// 2. Seller must be onboarded, billing can't pay out to a seller we know nothing about
+	if err := ctx.Err(); err != nil {
+		return repo.Listing{}, ValidationRules{}, errors.Canceled(err)
+	}
+	seller, err := s.repo.GetSellerProfile(ctx, userUUID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return repo.Listing{}, ValidationRules{}, errors.New(errors.ErrSellerProfileRequired, "Please complete your seller profile before creating a listing", nil)
+		}
+		s.logger.ErrorContext(ctx, "Failed to fetch seller profile", "error", err)
+		return repo.Listing{}, ValidationRules{}, errors.New(errors.ErrInternal, "Failed to create listing. Please try again later.", fmt.Errorf("failed to fetch seller profile: %w", err))
+	}
+	if !seller.AcceptedTermsVersion.Valid || seller.AcceptedTermsVersion.String != s.termsVersion {
+		return repo.Listing{}, ValidationRules{}, errors.New(errors.ErrSellerProfileRequired, "Please accept the latest seller terms before creating a listing", nil)
 	}
 
-	var dimensionsJSON []byte
-	var err error
-	if req.Dimensions != nil {
-		dimensionsJSON, err = json.Marshal(req.Dimensions)
+	// 3. Validate against the rules for this seller, verified sellers may have their own
+	rules := s.validationRules(userInfo, seller.PayoutStatus == repo.PayoutStatusVERIFIED)
+	if err := req.Validate(userInfo.ID, rules); err != nil {
+		s.logger.WarnContext(ctx, "Validation failed", "error", err)
+		return repo.Listing{}, ValidationRules{}, err
+	}
+
+	if s.hardware != nil && req.PrinterSettings.HardwareRequired != nil {
+		hardware, err := s.hardware.Canonicalize(ctx, *req.PrinterSettings.HardwareRequired)
 		if err != nil {
-			return repo.Listing{}, errors.New(errors.ErrInvalidInput, "Invalid dimensions", err)
+			s.logger.WarnContext(ctx, "Hardware validation failed", "error", err)
+			return repo.Listing{}, ValidationRules{}, err
+		}
+		req.PrinterSettings.HardwareRequired = &hardware
+	}
+
+	flagged, err := s.screenText(ctx, req.Title, req.Description)
+	if err != nil {
+		return repo.Listing{}, ValidationRules{}, err
+	}
+
+	s.logger.DebugContext(ctx, "Request validated successfully", "req", req)
+
+	// 4. Throttle scripted creation, and hold back listings that look like copy-paste spam
+	if err := s.checkCreationRate(ctx, userInfo, seller); err != nil {
+		return repo.Listing{}, ValidationRules{}, err
+	}
+	status, statusReason, err := s.initialStatus(ctx, userInfo, userUUID, req)
+	if err != nil {
+		return repo.Listing{}, ValidationRules{}, err
+	}
+	if len(flagged) > 0 {
+		s.logger.WarnContext(ctx, "Listing uses flagged terms, holding for review", "user", userInfo.ID, "terms", len(flagged))
+		status, statusReason = repo.ListingStatusPENDINGREVIEW, reasonScreeningReview
+	}
+
+	dimensionsJSON, appErr := dimensionsColumn(req.IsPhysical, req.Dimensions)
+	if appErr != nil {
+		return repo.Listing{}, ValidationRules{}, appErr
+	}
+
+	var nozzleDiameter pgtype.Numeric
+	if req.PrinterSettings.NozzleDiameter != nil {
+		if nozzleDiameter, appErr = parseNozzleDiameter(*req.PrinterSettings.NozzleDiameter, rules); appErr != nil {
+			return repo.Listing{}, ValidationRules{}, appErr
+		}
+	}
+
+	// 5. Reject gallery images outside the allowed dimensions, done before the transaction as it reads from storage.
+	// These files are saved as INVALID and never sent for validation.
+	rejectedImages := make(map[string]string)
+	for _, file := range req.Files {
+		if strings.ToLower(file.Type) != "image" {
+			continue
+		}
+		// A canceled read would otherwise come back as an image we couldn't check
+		if err := ctx.Err(); err != nil {
+			return repo.Listing{}, ValidationRules{}, errors.Canceled(err)
+		}
+		if reason := s.checkImageDimensions(ctx, file.Path); reason != "" {
+			rejectedImages[file.Path] = reason
 		}
 	}
 
-	// 3. Start Transaction
+	// 6. An upload can only belong to one listing, the validation worker moves it under that listing's ID
+	if err := s.checkFilesUnused(ctx, req.Files); err != nil {
+		return repo.Listing{}, ValidationRules{}, err
+	}
+
+	// 7. Start Transaction, unless the client has already gone. Past this point the listing is either committed or not.
+	if err := ctx.Err(); err != nil {
+		return repo.Listing{}, ValidationRules{}, errors.Canceled(err)
+	}
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
-		return repo.Listing{}, errors.New(errors.ErrInternal, "Failed to start transaction. Please try again later.", fmt.Errorf("failed to begin transaction: %w", err))
+		return repo.Listing{}, ValidationRules{}, errors.New(errors.ErrInternal, "Failed to start transaction. Please try again later.", fmt.Errorf("failed to begin transaction: %w", err))
 	}
 	defer tx.Rollback(ctx)
 
 	qtx := s.repo.WithTx(tx)
 
-	// 4. Create Listing Record
+	// 8. Create Listing Record
 	listing, err := qtx.CreateListing(ctx, repo.CreateListingParams{
 		SellerID:             userUUID,
 		Title:                req.Title,
@@ -112,42 +270,56 @@ func (s *svc) CreateListing(ctx context.Context, userInfo auth.UserInfo, req *Cr
 		PriceMinUnit:         req.PriceMinUnit,
 		Categories:           req.Categories,
 		License:              req.License,
-		ClientID:             userInfo.AuthorizedParty,
-		TraceID:              traceIDVal,
-		SellerName:           userInfo.Email,
+		SellerName:           seller.DisplayName, // Never the email, this is public
 		SellerUsername:       userInfo.Username,
 		ThumbnailPath:        pgtype.Text{String: req.Files[0].Path, Valid: true},
-		Status:               repo.NullListingStatus{ListingStatus: repo.ListingStatusPENDINGVALIDATION, Valid: true},
+		Status:               repo.NullListingStatus{ListingStatus: status, Valid: true},
 		IsNsfw:               req.IsNSFW,
 		IsPhysical:           req.IsPhysical,
 		IsAiGenerated:        req.IsAIGenerated,
 		AiModelName:          pgtype.Text{String: getValue(req.AIModelName), Valid: req.AIModelName != nil},
 		IsRemixingAllowed:    req.IsRemixingAllowed,
 		HardwareRequired:     getStringSlice(req.PrinterSettings.HardwareRequired),
-		DimensionsMm:         dimensionsJSON, // Handle null/nil logic in DB driver or pass []byte("null") if needed
+		DimensionsMm:         dimensionsJSON, // nil for digital-only listings and ones without a size
 		IsAssemblyRequired:   req.PrinterSettings.IsAssemblyRequired,
 		IsHardwareRequired:   req.PrinterSettings.IsHardwareRequired,
 		RecommendedMaterials: getStringSlice(req.PrinterSettings.RecommendedMaterials),
 		RecommendedNozzleTempC: func() pgtype.Int4 {
-			if req.PrinterSettings.RecommendedNozzleTempC != nil {
-				var temp pgtype.Int4
-				temp.Int32 = int32(*req.PrinterSettings.RecommendedNozzleTempC)
-				temp.Valid = true
-				return temp
+			if temp := nozzleTempC(req.PrinterSettings.RecommendedNozzleTempC, req.PrinterSettings.NozzleTemperature); temp != nil {
+				return pgtype.Int4{Int32: int32(*temp), Valid: true}
 			}
 			return pgtype.Int4{Valid: false}
 		}(),
+		NozzleDiameterMm: nozzleDiameter,
+		Language:         language.Detect(req.Title + "\n" + req.Description),
 	})
 
 	if err != nil {
 		s.logger.ErrorContext(ctx, "Failed to create listing", "error", err)
-		return repo.Listing{}, errors.New(errors.ErrInternal, "Failed to create listing. Please try again later.", fmt.Errorf("failed to create listing: %w", err))
+		return repo.Listing{}, ValidationRules{}, errors.New(errors.ErrInternal, "Failed to create listing. Please try again later.", fmt.Errorf("failed to create listing: %w", err))
 	}
 
-	var eventsToPublish []fileEventData
+	if err := recordStatusChange(ctx, qtx, statusChange{
+		ListingID: listing.ID,
+		Actor:     repo.ListingStatusActorUSER,
+		ActorID:   userInfo.ID,
+		To:        status,
+		Reason:    statusReason,
+		ClientID:  userInfo.AuthorizedParty,
+		TraceID:   traceIDVal,
+	}); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to record listing status", "error", err)
+		return repo.Listing{}, ValidationRules{}, errors.New(errors.ErrInternal, "Failed to create listing. Please try again later.", err)
+	}
+	if len(flagged) > 0 {
+		if err := recordScreeningFlags(ctx, qtx, listing.ID, flagged); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to record flagged terms", "error", err)
+			return repo.Listing{}, ValidationRules{}, errors.New(errors.ErrInternal, "Failed to create listing. Please try again later.", err)
+		}
+	}
 
-	// 5. Handle File Uploads (Fan-out)
-	// Process Models
+	// 9. Save every file in one statement, in request order
+	fileRows := repo.CreateListingFilesParams{ListingID: listing.ID}
 	for _, file := range req.Files {
 		var dbFileType repo.FileType
 		switch strings.ToLower(file.Type) {
@@ -156,89 +328,178 @@ func (s *svc) CreateListing(ctx context.Context, userInfo auth.UserInfo, req *Cr
 		case "image":
 			dbFileType = repo.FileTypeIMAGE
 		default:
-			return repo.Listing{}, errors.New(errors.ErrInvalidInput, "Unsupported file type: "+file.Type, nil)
+			return repo.Listing{}, ValidationRules{}, errors.New(errors.ErrInvalidInput, "Unsupported file type: "+file.Type, nil)
 		}
 
-		var sizeNumeric pgtype.Int8
-		if err := sizeNumeric.Scan(file.Size); err != nil {
-			return repo.Listing{}, errors.New(errors.ErrInvalidInput, "Invalid file size.", err)
+		fileStatus := repo.FileStatusPENDING
+		rejection, rejected := rejectedImages[file.Path]
+		if rejected {
+			fileStatus = repo.FileStatusINVALID
 		}
 
-		fileRecord, err := qtx.CreateListingFile(ctx, repo.CreateListingFileParams{
-			ListingID: listing.ID, // Link to the new listing
-			FilePath:  file.Path,
-			FileType:  dbFileType,
-			FileSize:  sizeNumeric,
-			Status:    repo.NullFileStatus{FileStatus: repo.FileStatusPENDING, Valid: true},
-		})
+		fileRows.FilePaths = append(fileRows.FilePaths, file.Path)
+		fileRows.FileTypes = append(fileRows.FileTypes, string(dbFileType))
+		fileRows.FileSizes = append(fileRows.FileSizes, file.Size)
+		fileRows.Statuses = append(fileRows.Statuses, string(fileStatus))
+		fileRows.ErrorMessages = append(fileRows.ErrorMessages, rejection)
+	}
 
-		if err != nil {
-			s.logger.ErrorContext(ctx, "Failed to save listing file", "error", err)
-			return repo.Listing{}, errors.New(errors.ErrInternal, "Failed to save model file. Please try again later.", fmt.Errorf("failed to save model file: %w", err))
+	fileRecords, err := qtx.CreateListingFiles(ctx, fileRows)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return repo.Listing{}, ValidationRules{}, fileAlreadyUsed()
 		}
+		s.logger.ErrorContext(ctx, "Failed to save listing files", "files", len(req.Files), "error", err)
+		return repo.Listing{}, ValidationRules{}, errors.New(errors.ErrInternal, "Failed to save model file. Please try again later.", fmt.Errorf("failed to save model files: %w", err))
+	}
+
+	// RETURNING doesn't promise the order the rows went in, the paths tell them apart. Rejected images aren't validated.
+	fileIDs := make(map[string]pgtype.UUID, len(fileRecords))
+	for _, fileRecord := range fileRecords {
+		fileIDs[fileRecord.FilePath] = fileRecord.ID
+	}
+	var filesToValidate []events.ValidationFile
+	for _, file := range req.Files {
+		if _, rejected := rejectedImages[file.Path]; rejected {
+			continue
+		}
+		filesToValidate = append(filesToValidate, events.ValidationFile{
+			FileID:   fmt.Sprintf("%x", fileIDs[file.Path].Bytes),
+			FileKey:  file.Path,
+			FileType: file.Type,
+		})
+	}
+
+	// Callbacks asked for at presign time follow the files they were for, the listings worker sends them once the files
+	// are validated
+	if err := qtx.AttachUploadCallbacks(ctx, listing.ID); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to attach upload callbacks", "error", err)
+		return repo.Listing{}, ValidationRules{}, errors.New(errors.ErrInternal, "Failed to save model files. Please try again later.", fmt.Errorf("failed to attach upload callbacks: %w", err))
+	}
 
-		eventsToPublish = append(eventsToPublish, fileEventData{
+	// 10. Queue the events in the same transaction, the outbox relay publishes them once it has committed. The files go
+	// to the validation worker in one event for the whole listing, then everyone else hears the listing exists.
+	var messages []events.Message
+	if len(filesToValidate) > 0 {
+		validation, err := s.eventHandler.StartListingValidationMessages(events.StartListingValidationEvent{
 			ListingID: fmt.Sprintf("%x", listing.ID.Bytes),
-			FileID:    fmt.Sprintf("%x", fileRecord.ID.Bytes),
-			FilePath:  file.Path,
-			FileType:  file.Type,
-			FileKey:   file.Path,
+			UserID:    userInfo.ID,
+			TraceID:   traceIDVal,
+			Files:     filesToValidate,
 		})
+		if err != nil {
+			return repo.Listing{}, ValidationRules{}, errors.New(errors.ErrInternal, "Failed to create listing. Please try again later.", fmt.Errorf("failed to build listing validation event: %w", err))
+		}
+		messages = append(messages, validation...)
+	}
+	created, err := s.eventHandler.ListingCreatedMessage(events.ListingCreatedEvent{
+		ListingID:    fmt.Sprintf("%x", listing.ID.Bytes),
+		SellerID:     userInfo.ID,
+		Title:        listing.Title,
+		PriceMinUnit: listing.PriceMinUnit,
+		Currency:     listing.Currency,
+		Categories:   listing.Categories,
+		TraceID:      traceIDVal,
+		RequestID:    middleware.GetReqID(ctx),
+	})
+	if err != nil {
+		return repo.Listing{}, ValidationRules{}, errors.New(errors.ErrInternal, "Failed to create listing. Please try again later.", fmt.Errorf("failed to build listing created event: %w", err))
+	}
+	messages = append(messages, created)
+
+	if err := outbox.Write(ctx, qtx, messages...); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to queue listing events", "error", err)
+		return repo.Listing{}, ValidationRules{}, errors.New(errors.ErrInternal, "Failed to create listing. Please try again later.", err)
 	}
 
 	// Only commit if everything above succeeded
 	if err := tx.Commit(ctx); err != nil {
 		s.logger.ErrorContext(ctx, "Failed to commit transaction", "error", err)
-		return repo.Listing{}, errors.New(errors.ErrInternal, "Failed to finalise transaction", err)
+		return repo.Listing{}, ValidationRules{}, errors.New(errors.ErrInternal, "Failed to finalise transaction", err)
 	}
+	// Someone may have asked for the ID before it existed
+	s.forgetListing(ctx, listing.ID)
 
-	// 6. Publish Events to Validate Files
-	s.logger.DebugContext(ctx, "Publishing file validation events", "count", len(eventsToPublish))
-	for _, evt := range eventsToPublish {
-		payload := events.StartFileValidationEvent{
-			ListingID: evt.ListingID,
-			FileID:    evt.FileID,
-			UserID:    userInfo.ID,
-			FileType:  evt.FileType,
-			FileKey:   evt.FileKey,
-			TraceID:   traceIDVal,
-		}
+	s.logger.DebugContext(ctx, "Queued listing events",
+		"listing_id", fmt.Sprintf("%x", listing.ID.Bytes),
+		"files", len(filesToValidate),
+		"trace_id", traceIDVal,
+	)
 
-		// Note: In production, if this fails, we should rely on a "sweeper" or the user to retry.
-		if err := s.eventHandler.RaiseStartFileValidationEvent(payload); err != nil {
-			s.logger.ErrorContext(ctx, "Failed to publish file validation event",
-				"file_id", evt.FileID,
-				"file_type", evt.FileType,
-				"file_key", evt.FileKey,
-				"listing_id", evt.ListingID,
-				"trace_id", traceIDVal,
-				"error", err,
-			)
-		} else {
-			s.logger.DebugContext(ctx, "Published file validation event",
-				"file_id", evt.FileID,
-				"file_type", evt.FileType,
-				"file_key", evt.FileKey,
-				"listing_id", evt.ListingID,
-				"trace_id", traceIDVal,
-			)
+	return listing, rules, nil
+}
+
+// Statuses and sort columns GET /listings accepts, in the order the error lists them
+var (
+	sellerListingStatuses = []string{
+		string(repo.ListingStatusPENDINGVALIDATION), string(repo.ListingStatusPENDINGREVIEW), string(repo.ListingStatusACTIVE),
+		string(repo.ListingStatusREJECTED), string(repo.ListingStatusHIDDEN), string(repo.ListingStatusSUSPENDED),
+	}
+	sellerListingSorts  = []string{"created_at", "updated_at", "price_min_unit", "downloads_count"}
+	sellerListingOrders = []string{"asc", "desc"}
+)
+
+// sellerListingsParams checks the query against the values GetListingsBySellerID can filter and order by. An empty
+// query is every listing newest first.
+func sellerListingsParams(sellerID pgtype.UUID, query SellerListingsQuery) (repo.GetListingsBySellerIDParams, *errors.AppError) {
+	params := repo.GetListingsBySellerIDParams{SellerID: sellerID, SortColumn: "created_at", SortDesc: true}
+
+	if query.Status != "" {
+		if !slices.Contains(sellerListingStatuses, query.Status) {
+			return params, invalidSellerListingsQuery("status", query.Status, sellerListingStatuses)
 		}
+		params.Status = repo.NullListingStatus{ListingStatus: repo.ListingStatus(query.Status), Valid: true}
 	}
+	if query.Sort != "" {
+		if !slices.Contains(sellerListingSorts, query.Sort) {
+			return params, invalidSellerListingsQuery("sort", query.Sort, sellerListingSorts)
+		}
+		params.SortColumn = query.Sort
+	}
+	if query.Order != "" {
+		if !slices.Contains(sellerListingOrders, query.Order) {
+			return params, invalidSellerListingsQuery("order", query.Order, sellerListingOrders)
+		}
+		params.SortDesc = query.Order == "desc"
+	}
+	return params, nil
+}
 
-	return listing, nil
+func invalidSellerListingsQuery(field, value string, allowed []string) *errors.AppError {
+	return errors.New(errors.ErrInvalidInput, fmt.Sprintf("'%s' isn't a valid %s, use one of %s", value, field, strings.Join(allowed, ", ")), nil).
+		WithReason(errors.ReasonListingsQueryInvalid).
+		WithParam("field", field).
+		WithParam("value", value).
+		WithParam("allowed", strings.Join(allowed, ", "))
 }
 
-func (s *svc) GetListingsForUser(ctx context.Context, userInfo auth.UserInfo) ([]ListingResponse, error) {
+func (s *svc) GetListingsForUser(ctx context.Context, userInfo auth.UserInfo, query SellerListingsQuery) ([]ListingResponse, error) {
 	var userUUID pgtype.UUID
 	if err := userUUID.Scan(userInfo.ID); err != nil {
 		return nil, errors.New(errors.ErrInvalidInput, "Invalid user ID provided", err)
 	}
+	params, appErr := sellerListingsParams(userUUID, query)
+	if appErr != nil {
+		return nil, appErr
+	}
 
-	rows, err := s.repo.GetListingsBySellerID(ctx, userUUID)
+	rows, err := s.repo.GetListingsBySellerID(ctx, params)
 	if err != nil {
 		return nil, errors.New(errors.ErrInternal, "Unable to get the users listings", err)
 	}
 
+	priceHistory := s.sellerPriceHistory(ctx, userUUID)
+	indexFailures := s.sellerIndexFailures(ctx, userUUID)
+	ids := make([]pgtype.UUID, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	variants, err := s.listingVariants(ctx, ids)
+	if err != nil {
+		// The seller's own list isn't cached, their listings show without variants until the next load
+		s.logger.ErrorContext(ctx, "Failed to fetch listing variants", "seller_id", userInfo.ID, "error", err)
+	}
+
 	// 3. Transform Rows -> Responses
 	response := make([]ListingResponse, len(rows))
 	for i, row := range rows {
@@ -251,14 +512,14 @@ func (s *svc) GetListingsForUser(ctx context.Context, userInfo auth.UserInfo) ([
 			Currency:               row.Currency,
 			Categories:             row.Categories,
 			License:                row.License,
-			ClientID:               row.ClientID,
-			TraceID:                row.TraceID,
 			ThumbnailPath:          row.ThumbnailPath,
 			Status:                 row.Status,
 			Files:                  row.Files,
+			StatusReason:           row.StatusReason,
 			DownloadsCount:         row.DownloadsCount,
+			ViewsCount:             row.ViewsCount,
 			CommentsCount:          row.CommentsCount,
-			SellerName:             row.SellerName,
+			SellerName:             publicSellerName(row.SellerName, row.SellerUsername),
 			SellerUsername:         row.SellerUsername,
 			SellerVerified:         row.SellerVerified,
 			DeletedAt:              row.DeletedAt,
@@ -277,43 +538,60 @@ func (s *svc) GetListingsForUser(ctx context.Context, userInfo auth.UserInfo) ([
 			DimensionsMm:           row.DimensionsMm,
 			RecommendedNozzleTempC: row.RecommendedNozzleTempC,
 			RecommendedMaterials:   row.RecommendedMaterials,
-		}, s.publicFilesURL)
-
+			NozzleDiameterMm:       row.NozzleDiameterMm,
+			Language:               row.Language,
+		})
+		response[i].PriceHistory = priceHistory[row.ID]
+		if v, ok := variants[row.ID]; ok {
+			response[i].Variants = v
+		}
+		if indexFailures[row.ID] {
+			message := indexErrorMessage
+			response[i].IndexError = &message
+		}
 	}
 
 	return response, nil
 }
 
-func (req *CreateListingRequest) Validate(userId string) *errors.AppError {
+// Validate checks the request against rules, see ValidationRuleSet.For for picking them
+func (req *CreateListingRequest) Validate(userId string, rules ValidationRules) *errors.AppError {
 	// ----------------------------------
 	// A. Core Identity & Quality Control
 	// ----------------------------------
 
 	// 1. Title
 	titleLen := len(strings.TrimSpace(req.Title))
-	if titleLen < 5 || titleLen > 100 {
-		return errors.New(errors.ErrInvalidInput, "Title must be between 5 and 100 characters", nil)
+	if titleLen < rules.TitleMinLength || titleLen > rules.TitleMaxLength {
+		return errors.New(errors.ErrInvalidInput, fmt.Sprintf("Title must be between %d and %d characters", rules.TitleMinLength, rules.TitleMaxLength), nil).
+			WithReason(errors.ReasonListingTitleLength).
+			WithParam("min", strconv.Itoa(rules.TitleMinLength)).
+			WithParam("max", strconv.Itoa(rules.TitleMaxLength))
 	}
 
 	// 2. Description (New)
 	// Enforce a minimum length to ensure quality listings
 	descLen := len(strings.TrimSpace(req.Description))
-	if descLen < 20 {
-		return errors.New(errors.ErrInvalidInput, "Description must be at least 20 characters", nil)
+	if descLen < rules.DescriptionMinLength {
+		return errors.New(errors.ErrInvalidInput, fmt.Sprintf("Description must be at least %d characters", rules.DescriptionMinLength), nil).
+			WithReason(errors.ReasonListingDescriptionShort).
+			WithParam("min", strconv.Itoa(rules.DescriptionMinLength))
 	}
-	if descLen > 5000 {
-		return errors.New(errors.ErrInvalidInput, "Description cannot exceed 5000 characters", nil)
+	if descLen > rules.DescriptionMaxLength {
+		return errors.New(errors.ErrInvalidInput, fmt.Sprintf("Description cannot exceed %d characters", rules.DescriptionMaxLength), nil).
+			WithReason(errors.ReasonListingDescriptionLong).
+			WithParam("max", strconv.Itoa(rules.DescriptionMaxLength))
 	}
 
 	// 3. Categories
 	if len(req.Categories) == 0 {
-		return errors.New(errors.ErrInvalidInput, "At least one category is required", nil)
+		return errors.New(errors.ErrInvalidInput, "At least one category is required", nil).WithReason(errors.ReasonListingCategoryRequired)
 	}
 	// Optional: Validate that categories exist in your allowed list if you have one hardcoded or cached
 
 	// 4. License (New)
 	if strings.TrimSpace(req.License) == "" {
-		return errors.New(errors.ErrInvalidInput, "A valid license type is required", nil)
+		return errors.New(errors.ErrInvalidInput, "A valid license type is required", nil).WithReason(errors.ReasonListingLicenseRequired)
 	}
 
 	// ----------------------------------
@@ -322,46 +600,44 @@ func (req *CreateListingRequest) Validate(userId string) *errors.AppError {
 
 	// 1. Price Sanity
 	if req.PriceMinUnit < 0 {
-		return errors.New(errors.ErrInvalidInput, "Price cannot be negative", nil)
+		return errors.New(errors.ErrInvalidInput, "Price cannot be negative", nil).WithReason(errors.ReasonListingPriceNegative)
 	}
 
 	// 2. Currency Validation (Only if not free)
-	if req.PriceMinUnit > 0 {
-		switch strings.ToLower(req.Currency) {
-		case "usd", "gbp":
-			// valid
-		default:
-			return errors.New(errors.ErrInvalidInput, "Currency must be 'usd' or 'gbp'", nil)
-		}
+	if req.PriceMinUnit > 0 && !slices.Contains(rules.Currencies, strings.ToLower(req.Currency)) {
+		currencies := rules.currencyList()
+		return errors.New(errors.ErrInvalidInput, "Currency must be "+currencies, nil).
+			WithReason(errors.ReasonListingCurrencyUnsupported).
+			WithParam("currencies", currencies)
 	}
 
 	// ----------------------------------
 	// C. Technical Specs (New)
 	// ----------------------------------
 
-	// 1. Dimensions
-	if req.Dimensions != nil {
-		if req.Dimensions.X < 0 || req.Dimensions.Y < 0 || req.Dimensions.Z < 0 {
-			return errors.New(errors.ErrInvalidInput, "Dimensions cannot be negative", nil)
-		}
-		// Optional: Check for '0' if IsPhysical is true, but often 0 is just "unknown"
+	// 1. Dimensions, dropped for digital-only listings
+	if _, appErr := dimensionsColumn(req.IsPhysical, req.Dimensions); appErr != nil {
+		return appErr
 	}
 
-	// 2. Printer Settings - Temperature
-	if req.PrinterSettings.RecommendedNozzleTempC != nil {
-		temp := *req.PrinterSettings.RecommendedNozzleTempC
-		// Sanity range for consumer 3D printing (e.g., 180°C - 450°C)
-		if temp < 180 || temp > 450 {
-			return errors.New(errors.ErrInvalidInput, "Recommended nozzle temperature must be within a realistic range (180-450°C)", nil)
+	// 2. Printer Settings - Temperature & Nozzle
+	if appErr := validateNozzleTemp(nozzleTempC(req.PrinterSettings.RecommendedNozzleTempC, req.PrinterSettings.NozzleTemperature), rules); appErr != nil {
+		return appErr
+	}
+	if req.PrinterSettings.NozzleDiameter != nil {
+		if _, appErr := parseNozzleDiameter(*req.PrinterSettings.NozzleDiameter, rules); appErr != nil {
+			return appErr
 		}
 	}
 
 	// 3. Printer Settings - Materials
-	// Ensure no empty strings in the list
-	for _, mat := range *req.PrinterSettings.RecommendedMaterials {
-		if strings.TrimSpace(mat) == "" {
-			return errors.New(errors.ErrInvalidInput, "Material list cannot contain empty entries", nil)
+	// Saved under their canonical names, so search facets have one bucket per material
+	if req.PrinterSettings.RecommendedMaterials != nil {
+		canonical, appErr := canonicalizeMaterials(*req.PrinterSettings.RecommendedMaterials)
+		if appErr != nil {
+			return appErr
 		}
+		req.PrinterSettings.RecommendedMaterials = &canonical
 	}
 
 	// ----------------------------------
@@ -372,7 +648,7 @@ func (req *CreateListingRequest) Validate(userId string) *errors.AppError {
 	// If marked as AI Generated, we strictly require the Model Name for transparency
 	if req.IsAIGenerated {
 		if req.AIModelName == nil || strings.TrimSpace(*req.AIModelName) == "" {
-			return errors.New(errors.ErrInvalidInput, "AI Model Name is required for AI-generated content", nil)
+			return errors.New(errors.ErrInvalidInput, "AI Model Name is required for AI-generated content", nil).WithReason(errors.ReasonListingAIModelRequired)
 		}
 	}
 
@@ -381,26 +657,42 @@ func (req *CreateListingRequest) Validate(userId string) *errors.AppError {
 	// ----------------------------------
 
 	if len(req.Files) == 0 {
-		return errors.New(errors.ErrInvalidInput, "At least one file is required", nil)
+		return errors.New(errors.ErrInvalidInput, "At least one file is required", nil).WithReason(errors.ReasonListingFilesRequired)
+	}
+	if rules.MaxFiles > 0 && len(req.Files) > rules.MaxFiles {
+		return errors.New(errors.ErrInvalidInput, fmt.Sprintf("A listing can have at most %d files", rules.MaxFiles), nil).
+			WithReason(errors.ReasonListingTooManyFiles).
+			WithParam("max", strconv.Itoa(rules.MaxFiles))
 	}
 
 	hasModel := false
 	hasImage := false
+	draftID := fileDraftID(req.Files[0].Path)
+	seen := make(map[string]bool, len(req.Files))
 
 	for _, f := range req.Files {
 		// 1. Ownership Check
 		if !checkUserOwnsFile(userId, f.Path) {
 			// Log this security event?
 			fmt.Printf("Security Alert: User %s attempted to use unowned file %s\n", userId, f.Path)
-			return errors.New(errors.ErrInvalidInput, "You do not have permission to use this file", nil)
+			return errors.New(errors.ErrInvalidInput, "You do not have permission to use this file", nil).WithReason(errors.ReasonListingFileNotOwned)
 		}
 
+		// One draft per listing, files from another draft may be on their way into a different listing
+		if fileDraftID(f.Path) != draftID {
+			return errors.New(errors.ErrInvalidInput, "All files must come from the same draft", nil).WithReason(errors.ReasonListingFilesMixedDrafts)
+		}
+		if seen[f.Path] {
+			return errors.New(errors.ErrInvalidInput, "The same file was added twice", nil).WithReason(errors.ReasonListingFileRepeated)
+		}
+		seen[f.Path] = true
+
 		// 2. Basic Integrity
 		if f.Path == "" {
-			return errors.New(errors.ErrInvalidInput, "File path cannot be empty", nil)
+			return errors.New(errors.ErrInvalidInput, "File path cannot be empty", nil).WithReason(errors.ReasonListingFilePathEmpty)
 		}
 		if f.Size <= 0 {
-			return errors.New(errors.ErrInvalidInput, "File size must be positive", nil)
+			return errors.New(errors.ErrInvalidInput, "File size must be positive", nil).WithReason(errors.ReasonListingFileSizeInvalid)
 		}
 
 		// 3. Type Check
@@ -410,16 +702,16 @@ func (req *CreateListingRequest) Validate(userId string) *errors.AppError {
 		} else if t == "image" {
 			hasImage = true
 		} else {
-			return errors.New(errors.ErrInvalidInput, fmt.Sprintf("Invalid file type '%s'. Must be 'model' or 'image'", f.Type), nil)
+			return errors.New(errors.ErrInvalidInput, fmt.Sprintf("Invalid file type '%s'. Must be 'model' or 'image'", f.Type), nil).WithReason(errors.ReasonListingFileTypeInvalid).WithParam("type", f.Type)
 		}
 	}
 
 	// 4. Composition Check
 	if !hasModel {
-		return errors.New(errors.ErrInvalidInput, "You must upload at least one 3D model file", nil)
+		return errors.New(errors.ErrInvalidInput, "You must upload at least one 3D model file", nil).WithReason(errors.ReasonListingModelRequired)
 	}
 	if !hasImage {
-		return errors.New(errors.ErrInvalidInput, "You must upload at least one gallery image", nil)
+		return errors.New(errors.ErrInvalidInput, "You must upload at least one gallery image", nil).WithReason(errors.ReasonListingImageRequired)
 	}
 
 	return nil
@@ -436,10 +728,74 @@ func checkUserOwnsFile(userID string, filePath string) bool {
 	}
 
 	ownerID := parts[3]
-	return ownerID == userID
+	return ownerID == userID && parts[4] != ""
+}
+
+// fileDraftID is the draft segment of an upload key, the files service puts it straight after the user ID so a draft
+// always belongs to the user whose key it's under
+func fileDraftID(filePath string) string {
+	parts := strings.SplitN(filePath, "/", 6)
+	if len(parts) < 6 {
+		return ""
+	}
+	return parts[4]
+}
+
+// checkFilesUnused refuses uploads that are already attached to a listing. The unique index on listing_files catches
+// the race between two creates, this is here for the friendlier error.
+func (s *svc) checkFilesUnused(ctx context.Context, files []CreateListingFile) error {
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+
+	used, err := s.repo.GetUsedFilePaths(ctx, paths)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to check listing files", "error", err)
+		return errors.New(errors.ErrInternal, "Failed to create listing. Please try again later.", fmt.Errorf("failed to check listing files: %w", err))
+	}
+	if len(used) > 0 {
+		s.logger.WarnContext(ctx, "Listing files are already attached to a listing", "paths", used)
+		return fileAlreadyUsed()
+	}
+	return nil
+}
+
+func fileAlreadyUsed() *errors.AppError {
+	return errors.New(errors.ErrInvalidInput, "A file is already used by another listing", nil).WithReason(errors.ReasonListingFileAlreadyUsed)
 }
 
-func (s *svc) UpdateListing(ctx context.Context, userInfo auth.UserInfo, listingID string, req *UpdateListingRequest) (*repo.Listing, error) {
+// isUniqueViolation is true for Postgres error 23505, a write that would break a unique index
+func isUniqueViolation(err error) bool {
+	pgErr, ok := err.(*pgconn.PgError)
+	return ok && pgErr.Code == "23505"
+}
+
+// UpdateListing is the PUT edit, kept until clients have moved to PatchListing. A nil field is left alone and
+// nothing but the AI model name and nozzle diameter can be cleared.
+func (s *svc) UpdateListing(ctx context.Context, userInfo auth.UserInfo, listingID string, req *UpdateListingRequest) (*UpdateListingResponse, error) {
+	rules, err := s.sellerValidationRules(ctx, userInfo)
+	if err != nil {
+		return nil, err
+	}
+	listing, err := s.patchListing(ctx, userInfo, listingID, req.Patch(), rules)
+	if err != nil {
+		return nil, err
+	}
+	return &UpdateListingResponse{Listing: *listing, Warnings: s.listingWarnings(ctx, savedWarningInput(*listing), rules)}, nil
+}
+
+// PatchListing applies a merge patch to one of the caller's listings and re-indexes it
+func (s *svc) PatchListing(ctx context.Context, userInfo auth.UserInfo, listingID string, patch *ListingPatch) (*repo.Listing, error) {
+	rules, err := s.sellerValidationRules(ctx, userInfo)
+	if err != nil {
+		return nil, err
+	}
+	return s.patchListing(ctx, userInfo, listingID, patch, rules)
+}
+
+// patchListing is PatchListing checked against rules, the seller's rules the same as their create was
+func (s *svc) patchListing(ctx context.Context, userInfo auth.UserInfo, listingID string, patch *ListingPatch, rules ValidationRules) (*repo.Listing, error) {
 	spanContext := trace.SpanContextFromContext(ctx)
 	traceIDVal := ""
 	if spanContext.IsValid() {
@@ -456,28 +812,92 @@ func (s *svc) UpdateListing(ctx context.Context, userInfo auth.UserInfo, listing
 		return nil, errors.New(errors.ErrInvalidInput, "Invalid listing ID provided", err)
 	}
 
-	// 1. Fetch Existing Listing
-	existing, err := s.repo.GetListingByID(ctx, listingUUID)
+	updatedListing, err := s.saveListingUpdate(ctx, userUUID, listingUUID, patch, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	s.forgetListing(ctx, listingUUID)
+
+	err = s.eventHandler.RaiseListingIndexEvent(events.ReIndexListingEvent{
+		ListingID: listingID,
+		TraceID:   traceIDVal,
+	})
+
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to raise listing re-index event", "listing_id", listingID, "error", err)
+		// Non-critical error, so we log it but don't fail the whole operation
+	}
+
+	return &updatedListing, nil
+}
+
+// saveListingUpdate applies an edit to the listing with its row locked. The validation worker takes the same lock before
+// it moves a listing out of PENDING_VALIDATION, so the status and thumbnail we write back are never older than the
+// ones it wrote, and its transition never overwrites an edit it didn't see.
+func (s *svc) saveListingUpdate(ctx context.Context, userUUID, listingUUID pgtype.UUID, patch *ListingPatch, rules ValidationRules) (repo.Listing, error) {
+	listingID := listingUUID.String()
+
+	// Screened before the row is locked, a refused edit shouldn't hold up the validation worker
+	var title, description string
+	if patch.Title.Set {
+		title = patch.Title.Value
+	}
+	if patch.Description.Set && !patch.Description.Null {
+		description = patch.Description.Value
+	}
+	flagged, err := s.screenText(ctx, title, description)
+	if err != nil {
+		return repo.Listing{}, err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return repo.Listing{}, errors.New(errors.ErrInternal, "Failed to start transaction. Please try again later.", fmt.Errorf("failed to begin transaction: %w", err))
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.repo.WithTx(tx)
+
+	// 1. Fetch & Lock Existing Listing
+	existing, err := qtx.GetListingByIDForUpdate(ctx, listingUUID)
 	if err != nil {
 		if pgx.ErrNoRows.Error() == err.Error() {
-			return nil, errors.New(errors.ErrNotFound, "Listing not found", fmt.Errorf("Listing %v not found", listingID))
+			return repo.Listing{}, errors.New(errors.ErrNotFound, "Listing not found", fmt.Errorf("Listing %v not found", listingID))
 		}
 
-		return nil, errors.New(errors.ErrInternal, "Failed to fetch existing listing", fmt.Errorf("Failed to fetch listing %v: %w", listingID, err))
+		return repo.Listing{}, errors.New(errors.ErrInternal, "Failed to fetch existing listing", fmt.Errorf("Failed to fetch listing %v: %w", listingID, err))
 	}
 
 	// Validate the request fits the required datatypes & sanitise if required.
 	if existing.SellerID != userUUID {
-		return nil, errors.New(errors.ErrUnauthorized, "You do not own this listing", fmt.Errorf("User %v doesn't own listing %v", userInfo.ID, existing.ID.String()))
+		return repo.Listing{}, errors.New(errors.ErrUnauthorized, "You do not own this listing", fmt.Errorf("User %v doesn't own listing %v", userUUID.String(), existing.ID.String())).WithReason(errors.ReasonListingNotOwner)
 	}
 
-	// 2. Apply Updates
-	listing, appErr := req.CreateUpdatedListing(userUUID, existing)
+	// 2. Metadata can change at any time, but the files being validated decide whether the listing goes live.
+	// Swapping them mid-validation would let a result for the old set activate the new one.
+	if patch.Files {
+		if existing.Status.Valid && existing.Status.ListingStatus == repo.ListingStatusPENDINGVALIDATION {
+			return repo.Listing{}, errors.New(errors.ErrConflict, "Files can't be changed until validation has finished", fmt.Errorf("listing %v is still validating", listingID)).WithReason(errors.ReasonListingValidating)
+		}
+		return repo.Listing{}, errors.New(errors.ErrInvalidInput, "Files can't be changed on an existing listing", nil)
+	}
+
+	// 3. Apply Updates
+	listing, appErr := patch.CreateUpdatedListing(existing, rules)
 	if appErr != nil {
-		return nil, appErr
+		return repo.Listing{}, appErr
+	}
+	if listing.HardwareRequired, err = s.updatedHardware(ctx, patch, existing.HardwareRequired, listing.HardwareRequired); err != nil {
+		return repo.Listing{}, err
+	}
+	from := existing.Status.ListingStatus
+	held := len(flagged) > 0 && screeningHolds[from]
+	if held {
+		listing.Status = repo.NullListingStatus{ListingStatus: repo.ListingStatusPENDINGREVIEW, Valid: true}
 	}
 
-	updatedListing, err := s.repo.UpdateListing(ctx, repo.UpdateListingParams{
+	updatedListing, err := qtx.UpdateListing(ctx, repo.UpdateListingParams{
 		ID:                     listing.ID,
 		Title:                  listing.Title,
 		Description:            listing.Description,
@@ -485,8 +905,6 @@ func (s *svc) UpdateListing(ctx context.Context, userInfo auth.UserInfo, listing
 		Currency:               listing.Currency,
 		Categories:             listing.Categories,
 		License:                listing.License,
-		ClientID:               listing.ClientID,
-		TraceID:                listing.TraceID,
 		ThumbnailPath:          listing.ThumbnailPath,
 		Status:                 listing.Status,
 		IsRemixingAllowed:      listing.IsRemixingAllowed,
@@ -501,104 +919,49 @@ func (s *svc) UpdateListing(ctx context.Context, userInfo auth.UserInfo, listing
 		RecommendedMaterials:   listing.RecommendedMaterials,
 		IsAiGenerated:          listing.IsAiGenerated,
 		AiModelName:            listing.AiModelName,
+		NozzleDiameterMm:       listing.NozzleDiameterMm,
+		Language:               language.Detect(listing.Title + "\n" + listing.Description.String),
+		SaleName:               listing.SaleName,
+		SalePrice:              listing.SalePrice,
 	})
 
 	if err != nil {
 		s.logger.ErrorContext(ctx, "Failed to update listing in database", "listing_id", listingID, "error", err)
-		return nil, errors.New(errors.ErrInternal, "Failed to save listing updates", err)
-	}
-
-	cacheKey := "listing:" + listingID
-	cache.Del(s.cache, ctx, cacheKey)
-
-	err = s.eventHandler.RaiseListingIndexEvent(events.ReIndexListingEvent{
-		ListingID: listingID,
-		TraceID:   traceIDVal,
-	})
-
-	if err != nil {
-		s.logger.ErrorContext(ctx, "Failed to raise listing re-index event", "listing_id", listingID, "error", err)
-		// Non-critical error, so we log it but don't fail the whole operation
+		return repo.Listing{}, errors.New(errors.ErrInternal, "Failed to save listing updates", err)
 	}
 
-	return &updatedListing, nil
-}
-
-func (req *UpdateListingRequest) CreateUpdatedListing(userID pgtype.UUID, listing repo.Listing) (repo.Listing, *errors.AppError) {
-	if req.Title != nil {
-		listing.Title = *req.Title
+	if err := recordPriceChange(ctx, qtx, existing, updatedListing); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to record price change", "listing_id", listingID, "error", err)
+		return repo.Listing{}, errors.New(errors.ErrInternal, "Failed to save listing updates", err)
 	}
 
-	if req.Description != nil {
-		// Handle sql.NullString logic if using sqlc's pgtype
-		listing.Description = pgtype.Text{String: *req.Description, Valid: true}
-	}
-	if req.Currency != nil {
-		listing.Currency = *req.Currency
-	}
-	if req.License != nil {
-		listing.License = *req.License
-	}
-	if req.PriceMinUnit != nil {
-		if *req.PriceMinUnit < 0 {
-			return listing, errors.New(errors.ErrInvalidInput, "Price cannot be negative", nil)
+	// 4. Edits with flagged terms go back to a moderator, the listing drops out of search with the re-index
+	if len(flagged) > 0 {
+		s.logger.WarnContext(ctx, "Edit uses flagged terms", "listing_id", listingID, "terms", len(flagged), "held", held)
+		if err := recordScreeningFlags(ctx, qtx, listing.ID, flagged); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to record flagged terms", "listing_id", listingID, "error", err)
+			return repo.Listing{}, errors.New(errors.ErrInternal, "Failed to save listing updates", err)
 		}
-
-		listing.PriceMinUnit = *req.PriceMinUnit
-	}
-
-	if req.AIModelName != nil {
-		// If empty string is passed, we treat it as NULL/Invalid
-		isValid := strings.TrimSpace(*req.AIModelName) != ""
-		listing.AiModelName = pgtype.Text{String: *req.AIModelName, Valid: isValid}
 	}
-
-	// --- JSON Columns ---
-	if req.Dimensions != nil {
-		bytes, err := json.Marshal(req.Dimensions)
-		if err != nil {
-			return listing, errors.New(errors.ErrInvalidInput, "Invalid dimensions format", err)
+	if held {
+		if err := recordStatusChange(ctx, qtx, statusChange{
+			ListingID: listing.ID,
+			Actor:     repo.ListingStatusActorUSER,
+			ActorID:   userUUID.String(),
+			From:      &from,
+			To:        repo.ListingStatusPENDINGREVIEW,
+			Reason:    reasonScreeningReview,
+		}); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to record listing status", "listing_id", listingID, "error", err)
+			return repo.Listing{}, errors.New(errors.ErrInternal, "Failed to save listing updates", err)
 		}
-		listing.DimensionsMm = bytes
 	}
 
-	if req.IsRemixingAllowed != nil {
-		listing.IsRemixingAllowed = *req.IsRemixingAllowed
-	}
-	if req.IsPhysical != nil {
-		listing.IsPhysical = *req.IsPhysical
-	}
-	if req.IsNSFW != nil {
-		listing.IsNsfw = *req.IsNSFW
-	}
-	if req.IsAIGenerated != nil {
-		listing.IsAiGenerated = *req.IsAIGenerated
+	if err := tx.Commit(ctx); err != nil {
+		return repo.Listing{}, errors.New(errors.ErrInternal, "Failed to save listing updates", fmt.Errorf("failed to commit transaction: %w", err))
 	}
 
-	if req.PrinterSettings != nil {
-		ps := req.PrinterSettings
-
-		if ps.IsAssemblyRequired != nil {
-			listing.IsAssemblyRequired = *ps.IsAssemblyRequired
-		}
-		if ps.IsHardwareRequired != nil {
-			listing.IsHardwareRequired = *ps.IsHardwareRequired
-		}
-		if ps.HardwareRequired != nil {
-			listing.HardwareRequired = *ps.HardwareRequired
-		}
-		if ps.RecommendedMaterials != nil {
-			listing.RecommendedMaterials = *ps.RecommendedMaterials
-		}
-		if ps.RecommendedNozzleTempC != nil {
-			// Convert int64/int to int32 for Postgres
-			listing.RecommendedNozzleTempC = pgtype.Int4{
-				Int32: int32(*ps.RecommendedNozzleTempC),
-				Valid: true,
-			}
-		}
-	}
-	return listing, nil
+	return updatedListing, nil
 }
 
 func (s *svc) UpdateFilesForListing(ctx context.Context, userInfo auth.UserInfo, listingID string, files []ListingFileDTO) error {
@@ -610,45 +973,106 @@ func (s *svc) UpdateFilesForListing(ctx context.Context, userInfo auth.UserInfo,
 }
 
 func (s *svc) GetListingByID(ctx context.Context, listingID string) (*ListingResponse, error) {
-	s.logger.DebugContext(ctx, "Get listing: %s", listingID)
+	s.logger.DebugContext(ctx, "Get listing", "listing_id", listingID)
 
-	cacheKey := "listing:" + listingID
+	cacheKey := s.listingCache.Key(listingID)
 
 	// check redis cache first (TODO)
-	cachedListing, found, err := cache.Get[ListingResponse](s.cache, ctx, cacheKey)
+	cached, found, err := cache.Get[cachedListing](s.cache, ctx, cacheKey)
 	if err != nil {
 		s.logger.ErrorContext(ctx, "Failed to get listing from cache", "listing_id", listingID, "error", err)
+	} else if found && cached.NotFound {
+		s.logger.DebugContext(ctx, "Listing cached as not found", "listing_id", listingID)
+		return nil, listingNotFound(listingID)
 	} else if found {
 		s.logger.DebugContext(ctx, "Listing found in cache", "listing_id", listingID)
-		return cachedListing, nil
+		s.applyParent(ctx, &cached.ListingResponse)
+		return &cached.ListingResponse, nil
 	}
 
-	// fetch from db if not found in cache
+	return s.loadListing(ctx, listingID, cacheKey)
+}
+
+func listingNotFound(listingID string) *errors.AppError {
+	return errors.New(errors.ErrNotFound, "Listing not found", fmt.Errorf("Listing %v not found", listingID))
+}
+
+// loadListing reads a listing from the database and caches the response under cacheKey, or that there's no such
+// listing for NotFoundCacheTTL
+func (s *svc) loadListing(ctx context.Context, listingID string, cacheKey string) (*ListingResponse, error) {
+	listingResponse, _, ttl, err := s.renderListing(ctx, listingID)
+	if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ErrNotFound {
+		if err := cache.Set(s.cache, ctx, cacheKey, notFoundEntry{NotFound: true}, NotFoundCacheTTL); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to cache listing as not found", "listing_id", listingID, "error", err)
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ttl == 0 {
+		return &listingResponse, nil
+	}
+
+	// Rendered for a client that went away part way through, its links may not have been signed
+	if ctx.Err() != nil {
+		return &listingResponse, nil
+	}
+
+	s.background.Add(1)
+	go func(data ListingResponse) {
+		defer s.background.Done()
+		cacheCtx, cancel := detach.WithTimeout(ctx, cacheWriteTimeout)
+		defer cancel()
+		// cache.Set does the marshalling, passing pre-encoded bytes here would store a base64 string that Get can't read back
+		cache.Set(s.cache, cacheCtx, cacheKey, data, ttl)
+	}(listingResponse)
+
+	return &listingResponse, nil
+}
+
+// forgetListing drops what's cached for the listing, a response or that it wasn't found. IDs are asked for with and
+// without dashes, so both keys go.
+func (s *svc) forgetListing(ctx context.Context, id pgtype.UUID) {
+	for _, key := range []string{s.listingCache.Key(id.String()), s.listingCache.Key(fmt.Sprintf("%x", id.Bytes))} {
+		if err := cache.Del(s.cache, ctx, key); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to bust listing cache", "listing_id", id.String(), "error", err)
+		}
+	}
+}
+
+// renderListing reads a listing from the database and builds the response anyone is served, along with its seller and
+// how long it may be cached for
+func (s *svc) renderListing(ctx context.Context, listingID string) (ListingResponse, pgtype.UUID, time.Duration, error) {
 	var listingUUID pgtype.UUID
 	if err := listingUUID.Scan(listingID); err != nil {
-		return nil, errors.New(errors.ErrInvalidInput, "Invalid listing ID provided", err)
+		return ListingResponse{}, pgtype.UUID{}, 0, errors.New(errors.ErrInvalidInput, "Invalid listing ID provided", err)
 	}
 
 	listing, err := s.repo.GetListingByIDWithFiles(ctx, listingUUID)
 	if err != nil {
 		if pgx.ErrNoRows.Error() == err.Error() {
-			return nil, errors.New(errors.ErrNotFound, "Listing not found", fmt.Errorf("Listing %v not found", listingID))
+			return ListingResponse{}, pgtype.UUID{}, 0, listingNotFound(listingID)
 		}
 
 		s.logger.ErrorContext(ctx, "Failed to fetch listing from database", "listing_id", listingID, "error", err)
-		return nil, errors.New(errors.ErrInternal, "Failed to fetch listing", fmt.Errorf("Failed to fetch listing %v: %w", listingID, err))
+		return ListingResponse{}, pgtype.UUID{}, 0, errors.New(errors.ErrInternal, "Failed to fetch listing", fmt.Errorf("Failed to fetch listing %v: %w", listingID, err))
 	}
 
-	listingResponse := s.toListingResponse(ctx, listing, s.publicFilesURL)
-
-	go func(data ListingResponse) {
-		// Marshal to JSON
-		bytes, _ := json.Marshal(data)
-		// Set with TTL
-		cache.Set(s.cache, context.Background(), cacheKey, bytes, ListingCacheTTL)
-	}(listingResponse)
+	variants, err := s.listingVariants(ctx, []pgtype.UUID{listingUUID})
+	if err != nil {
+		// Not cached without them, a response missing its variants would show every file at the listing's price
+		s.logger.ErrorContext(ctx, "Failed to fetch listing variants", "listing_id", listingID, "error", err)
+		return ListingResponse{}, pgtype.UUID{}, 0, errors.New(errors.ErrInternal, "Failed to fetch listing", fmt.Errorf("Failed to fetch variants of %v: %w", listingID, err))
+	}
 
-	return &listingResponse, nil
+	listingResponse := s.toListingResponse(ctx, listing)
+	if v, ok := variants[listing.ID]; ok {
+		listingResponse.Variants = v
+	}
+	ttl := s.applyVacation(ctx, listing.SellerID, &listingResponse)
+	s.applyParent(ctx, &listingResponse)
+	return listingResponse, listing.SellerID, ttl, nil
 }
 
 func (s *svc) DeleteListing(ctx context.Context, userInfo auth.UserInfo, listingID string) error {
@@ -663,15 +1087,47 @@ func (s *svc) DeleteListing(ctx context.Context, userInfo auth.UserInfo, listing
 		return fmt.Errorf("invalid user id: %w", err)
 	}
 
+	traceID := ""
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		traceID = spanContext.TraceID().String()
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := s.repo.WithTx(tx)
+
 	// Delete the listing from the database for the user
-	_, err := s.repo.SoftDeleteListing(ctx, repo.SoftDeleteListingParams{
+	_, err = qtx.SoftDeleteListing(ctx, repo.SoftDeleteListingParams{
 		SellerID: userID,
 		ID:       id,
 	})
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			// Someone else's listing, or already deleted
+			return errors.New(errors.ErrNotFound, "Listing not found", fmt.Errorf("listing %v not found", listingID)).WithReason(errors.ReasonListingNotFound)
+		}
 		return fmt.Errorf("failed to delete listing: %w", err)
 	}
 
+	// The same events as a bulk delete of one listing, written with the delete so they can't be lost
+	messages, err := s.bulkMessages(ctx, qtx, BulkActionDelete, []pgtype.UUID{id}, traceID)
+	if err != nil {
+		return fmt.Errorf("failed to build delete events: %w", err)
+	}
+	if err := outbox.Write(ctx, qtx, messages...); err != nil {
+		return fmt.Errorf("failed to queue delete events: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit delete: %w", err)
+	}
+
+	s.forgetListing(ctx, id)
+	s.forgetLiveness(ctx, []pgtype.UUID{id})
+
 	return nil
 }
 
@@ -689,15 +1145,48 @@ func getStringSlice(s *[]string) []string {
 	return *s
 }
 
-func (s *svc) toListingResponse(ctx context.Context, row repo.GetListingByIDWithFilesRow, publicFilesURL string) ListingResponse {
+// publicSellerName guards against legacy rows where seller_name was defaulted to the seller's email address.
+func publicSellerName(name, username string) string {
+	if strings.Contains(name, "@") {
+		return username
+	}
+	return name
+}
+
+// listingFileRow is one element of the files JSON aggregate, with metadata still raw
+type listingFileRow struct {
+	ListingFileDTO
+	Metadata json.RawMessage `json:"metadata"`
+}
 
-	var files []ListingFileDTO
+// fileMetadata whitelists what the validation worker stored for a file. Bad documents are logged and
+// hidden instead of failing the whole listing.
+func (s *svc) fileMetadata(ctx context.Context, fileID string, raw json.RawMessage) *FileMetadata {
+	meta, dropped, err := ParseFileMetadata(raw)
+	if err != nil {
+		s.logger.WarnContext(ctx, "Discarding invalid file metadata", "file_id", fileID, "size", len(raw), "error", err)
+		return nil
+	}
+	if len(dropped) > 0 {
+		s.logger.WarnContext(ctx, "Dropping unknown file metadata keys", "file_id", fileID, "keys", dropped)
+	}
+	return meta
+}
+
+func (s *svc) toListingResponse(ctx context.Context, row repo.GetListingByIDWithFilesRow) ListingResponse {
+
+	files := []ListingFileDTO{}
 	if len(row.Files) > 0 {
-		if err := json.Unmarshal(row.Files, &files); err != nil {
+		var rows []listingFileRow
+		if err := json.Unmarshal(row.Files, &rows); err != nil {
 			// Log this error but don't fail the request? Or fail?
 			// Usually safer to just log and return empty files array to keep UI working.
 			fmt.Printf("error unmarshaling files for listing %s: %v\n", row.ID, err)
-			files = []ListingFileDTO{}
+		}
+		files = make([]ListingFileDTO, 0, len(rows))
+		for _, r := range rows {
+			r.ListingFileDTO.Metadata = s.fileMetadata(ctx, r.ID, r.Metadata)
+			files = append(files, r.ListingFileDTO)
 		}
 
 		// Remove the file urls / paths / keyss that have not been approved / validated yet.
@@ -723,27 +1212,22 @@ func (s *svc) toListingResponse(ctx context.Context, row repo.GetListingByIDWith
 					continue
 				}
 
-				// LOGIC SPLIT: Private vs Public
-				if strings.ToUpper(f.FileType) == "MODEL" {
-					// 1. MODELS -> PRIVATE BUCKET (product-files)
-					// We MUST generate a temporary Pre-Signed URL
-					// Ensure you have `storage.BucketProduct` constant defined as "product-files"
-					signedUrl, err := s.storage.PresignGet(ctx, storage.BucketProduct, *f.FilePath, time.Minute*15)
-
-					if err != nil {
+				// Listing responses are cached and shared between users, so these links aren't attributed
+				// to anyone. Attributed links come from GetFileDownload.
+				if ttl := s.downloads.TTL(f.FileType, false); ttl > 0 {
+					if ctx.Err() != nil {
+						// Nobody is waiting for the link
+						finalPath = nil
+					} else if signedUrl, err := s.storage.PresignGet(ctx, storage.BucketProduct, *f.FilePath, storage.PresignOptions{Expiry: ttl}); err != nil {
 						s.logger.ErrorContext(ctx, "Failed to sign model url", "file_id", f.ID, "error", err)
 						finalPath = nil
 					} else {
 						finalPath = &signedUrl
 					}
-
 				} else {
-					// 2. IMAGES -> PUBLIC BUCKET (public-files)
-					// No need to hit S3. Just construct the permanent URL.
+					// Public bucket (public-files), no need to hit S3. Just construct the permanent URL.
 					// This is faster and lets the browser cache the image.
-					// Ensure publicFilesURL doesn't have trailing slash
-					// and f.FilePath doesn't have leading slash if you want to be safe
-					url := fmt.Sprintf("%s/%s", strings.TrimRight(s.publicFilesURL, "/"), *f.FilePath)
+					url := s.publicFileURL(f.FileType, *f.FilePath)
 					finalPath = &url
 				}
 
@@ -763,12 +1247,16 @@ func (s *svc) toListingResponse(ctx context.Context, row repo.GetListingByIDWith
 		files = filteredFiles
 	}
 
+	modelSize := totalModelSize(files)
+
 	var dimX, dimY, dimZ *int
-	if len(row.DimensionsMm) > 0 {
+	var dimsDisplay string
+	if row.IsPhysical && len(row.DimensionsMm) > 0 {
 		var dims ListingDimensionsJSON
 		if err := json.Unmarshal(row.DimensionsMm, &dims); err == nil {
 			x, y, z := dims.Width, dims.Depth, dims.Height
 			dimX, dimY, dimZ = &x, &y, &z
+			dimsDisplay = formatDimensions(x, y, z, DimensionUnitMM)
 		}
 	}
 
@@ -777,7 +1265,7 @@ func (s *svc) toListingResponse(ctx context.Context, row repo.GetListingByIDWith
 
 		// Seller Info
 		SellerID:       fmt.Sprintf("%x", row.SellerID.Bytes),
-		SellerName:     row.SellerName,
+		SellerName:     publicSellerName(row.SellerName, row.SellerUsername),
 		SellerUsername: row.SellerUsername,
 		SellerVerified: row.SellerVerified,
 
@@ -786,13 +1274,17 @@ func (s *svc) toListingResponse(ctx context.Context, row repo.GetListingByIDWith
 		Description:  row.Description.String, // Assumes pgtype.Text
 		PriceMinUnit: row.PriceMinUnit,       // Assumes sqlc override to int64
 		Currency:     row.Currency,
-		Categories:   row.Categories,
+		Categories:   orEmpty(row.Categories),
 		License:      row.License,
+		Language:     row.Language,
 
-		Files: files,
+		Files:                 files,
+		TotalModelSizeBytes:   modelSize,
+		TotalModelSizeDisplay: formatFileSize(modelSize),
+		Variants:              []ListingVariant{},
 		ThumbnailPath: func() *string {
 			if row.ThumbnailPath.Valid {
-				url := publicFilesURL + row.ThumbnailPath.String
+				url := s.urls.Image(row.ThumbnailPath.String)
 				return &url
 			}
 			return nil
@@ -819,16 +1311,18 @@ func (s *svc) toListingResponse(ctx context.Context, row repo.GetListingByIDWith
 		}(),
 		IsAssemblyRequired: row.IsAssemblyRequired,
 		IsHardwareRequired: row.IsHardwareRequired,
-		HardwareRequired:   &row.HardwareRequired,
+		HardwareRequired:   orEmpty(row.HardwareRequired),
 
 		// Dimensions
 		DimXMM: dimX,
 		DimYMM: dimY,
 		DimZMM: dimZ,
 
+		DimensionsDisplay: dimsDisplay,
+
 		// Printer Settings
 		IsMulticolor:         row.IsMulticolor,
-		RecommendedMaterials: row.RecommendedMaterials,
+		RecommendedMaterials: orEmpty(row.RecommendedMaterials),
 		RecommendedNozzleTempC: func() *int {
 			if row.RecommendedNozzleTempC.Valid {
 				val := int(row.RecommendedNozzleTempC.Int32)
@@ -836,6 +1330,7 @@ func (s *svc) toListingResponse(ctx context.Context, row repo.GetListingByIDWith
 			}
 			return nil
 		}(),
+		NozzleDiameterMM: nozzleDiameterMM(row.NozzleDiameterMm),
 
 		// AI Info
 		IsAIGenerated: row.IsAiGenerated,
@@ -849,10 +1344,11 @@ func (s *svc) toListingResponse(ctx context.Context, row repo.GetListingByIDWith
 		// Legal
 		IsNSFW: row.IsNsfw,
 
-		// Social Signals (Handle Nullables by defaulting to 0 if null)
-		LikesCount:     int(row.LikesCount.Int32), // Assumes pgtype.Int4
-		DownloadsCount: int(row.DownloadsCount.Int32),
-		CommentsCount:  int(row.CommentsCount.Int32),
+		// Social Signals
+		LikesCount:     int(row.LikesCount),
+		DownloadsCount: int(row.DownloadsCount),
+		ViewsCount:     int(row.ViewsCount),
+		CommentsCount:  int(row.CommentsCount),
 
 		// Sales
 		IsSaleActive: row.IsSaleActive,
@@ -862,10 +1358,10 @@ func (s *svc) toListingResponse(ctx context.Context, row repo.GetListingByIDWith
 			}
 			return nil
 		}(),
-		SaleEndTimestamp: func() *time.Time {
-			if row.SaleEndTimestamp.Valid {
-				t := row.SaleEndTimestamp.Time
-				return &t
+		SaleEndTimestamp: utcPtr(row.SaleEndTimestamp),
+		SalePriceMinUnit: func() *int64 {
+			if row.SalePrice.Valid {
+				return &row.SalePrice.Int64
 			}
 			return nil
 		}(),
@@ -877,21 +1373,40 @@ func (s *svc) toListingResponse(ctx context.Context, row repo.GetListingByIDWith
 			}
 			return "UNKNOWN"
 		}(),
-		CreatedAt: row.CreatedAt.Time,
-		UpdatedAt: row.UpdatedAt.Time,
-		LastIndexedAt: func() *time.Time {
-			if row.LastIndexedAt.Valid {
-				t := row.LastIndexedAt.Time
-				return &t
-			}
-			return nil
-		}(),
-		DeletedAt: func() *time.Time {
-			if row.DeletedAt.Valid {
-				t := row.DeletedAt.Time
-				return &t
+		StatusReason: func() *string {
+			if row.Status.Valid && failureStatuses[row.Status.ListingStatus] && row.StatusReason.Valid {
+				return &row.StatusReason.String
 			}
 			return nil
 		}(),
+		CreatedAt:     utc(row.CreatedAt),
+		CreatedAtUnix: row.CreatedAt.Time.Unix(),
+		UpdatedAt:     utc(row.UpdatedAt),
+		LastIndexedAt: utcPtr(row.LastIndexedAt),
+		DeletedAt:     utcPtr(row.DeletedAt),
+		ContentHash:   contentHash(row),
+	}
+}
+
+// utc reads a timestamp for a response. pgx hands back times in the process's local zone, which would be serialized
+// with that zone's offset, so every time leaving this package goes through here and ends in Z.
+func utc(ts pgtype.Timestamptz) time.Time {
+	return ts.Time.UTC()
+}
+
+// orEmpty is for array fields of a response, which are [] when there's nothing in them and never null
+func orEmpty[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// utcPtr is utc for nullable columns
+func utcPtr(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
 	}
+	t := utc(ts)
+	return &t
 }
@@ -2,52 +2,55 @@ package listings
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"gateway/internal/auth"
+	"gateway/internal/cache"
+	"gateway/internal/errors"
 	"gateway/internal/events"
+	"gateway/internal/mocks/mockevents"
+	"gateway/internal/mocks/mockstorage"
+	"gateway/internal/publicurl"
+	"gateway/internal/storage"
 	"gateway/internal/testutil"
+	"gateway/internal/testutil/apitest"
+	"gateway/internal/testutil/fixtures"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	repo "gateway/internal/database/postgresql/sqlc"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
-type MockBus struct {
-	mock.Mock
-}
-
-func (m *MockBus) Publish(subject string, data []byte, msgId string) error {
-	args := m.Called(subject, data, msgId)
-	return args.Error(0)
-}
-
-func (m *MockBus) Drain() error {
-	return nil
-}
-
 func TestCreateListing_Success(t *testing.T) {
 	mockPool := testutil.NewMockDB(t)
 	logger := testutil.NewTestLogger()
-	mockBus := new(MockBus)
+	// Nothing is published inline, the events go through the outbox
+	mockBus := mockevents.NewBus(t)
 	eventConfig := events.EventConfig{
-		StartImageValidation: "file.image.start",
-		StartModelValidation: "file.model.start",
+		StartListingValidation: "file.listing.start",
+		ListingCreated:         "listings.created",
 	}
-	// We expect 2 publish events (one for model, one for image)
-	mockBus.On("Publish", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(2)
 	evtHandler := events.NewEventHandler(mockBus, &eventConfig, logger)
 
 	// Assemble service
+	rdb, _ := apitest.NewRedis(t)
 	service := &svc{
 		repo:         repo.New(mockPool),
 		db:           mockPool,
 		logger:       logger,
 		eventHandler: evtHandler,
+		cache:        rdb,
+		termsVersion: "1",
 	}
 
 	const validUserUUID = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
@@ -73,7 +76,7 @@ func TestCreateListing_Success(t *testing.T) {
 
 	req := &CreateListingRequest{
 		Title:        "Valid Listing",
-		Description:  "A great item",
+		Description:  "A great item for the whole family",
 		PriceMinUnit: 1050,
 		Currency:     "gbp",
 		Categories:   []string{"Art"},
@@ -85,17 +88,25 @@ func TestCreateListing_Success(t *testing.T) {
 		// We assume defaults for the new physical/AI fields in this specific test
 	}
 
-	// 1. Expect Begin Transaction
+	// 0. Expect Seller Profile Lookup
+	mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT user_id, display_name`)).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(testutil.SellerCols).AddRow(
+			validUserUUID, "Tester Prints", "GB", "NOT_STARTED", "1", time.Now(), time.Now(), time.Now(), nil, nil, nil, false, nil,
+		))
+
+	// 1. Expect the files to be checked against other listings, then Begin Transaction
+	expectFilesUnused(mockPool, inputFile1Path, inputFile2Path)
 	mockPool.ExpectBegin()
 
 	// 2. Expect Listing Insert
 	// Arguments must match the order in queries.sql -> CreateListing
 	mockPool.ExpectQuery(regexp.QuoteMeta(`INSERT INTO listings`)).
 		WithArgs(
-			pgxmock.AnyArg(),   // 1. seller_id
-			"test@example.com", // 2. seller_name
-			"tester",           // 3. seller_username
-			false,              // 4. seller_verified (Default)
+			pgxmock.AnyArg(), // 1. seller_id
+			"Tester Prints",  // 2. seller_name (from the seller profile, NEVER the email)
+			"tester",         // 3. seller_username
+			false,            // 4. seller_verified (Default)
 
 			"Valid Listing",  // 5. title
 			pgxmock.AnyArg(), // 6. description
@@ -104,51 +115,56 @@ func TestCreateListing_Success(t *testing.T) {
 			[]string{"Art"},  // 9. categories
 			"MIT",            // 10. license
 
-			"Go-Test",        // 11. client_id
-			pgxmock.AnyArg(), // 12. trace_id
-			pgxmock.AnyArg(), // 13. thumbnail_path
-			pgxmock.AnyArg(), // 14. status
-
-			true, // 15. is_remixing_allowed (Default)
-			nil,  // 16. parent_listing_id (Default)
-
-			true,  // 17. is_physical (Default)
-			nil,   // 18. total_weight_grams
-			false, // 19. is_assembly_required
-			false, // 20. is_hardware_required
-			nil,   // 21. hardware_required
-			false, // 22. is_multicolor
-			nil,   // 23. dimensions_mm
-			nil,   // 24. recommended_nozzle_temp_c
-			nil,   // 25. recommended_materials
-
-			false, // 26. is_ai_generated
-			nil,   // 27. ai_model_name
+			pgxmock.AnyArg(), // 11. thumbnail_path
+			pgxmock.AnyArg(), // 12. status
+
+			false,            // 13. is_remixing_allowed (Default)
+			pgxmock.AnyArg(), // 14. parent_listing_id (Default)
+
+			false,            // 15. is_physical (Default)
+			pgxmock.AnyArg(), // 16. total_weight_grams
+			false,            // 17. is_assembly_required
+			false,            // 18. is_hardware_required
+			pgxmock.AnyArg(), // 19. hardware_required
+			false,            // 20. is_multicolor
+			pgxmock.AnyArg(), // 21. dimensions_mm
+			pgxmock.AnyArg(), // 22. recommended_nozzle_temp_c
+			pgxmock.AnyArg(), // 23. recommended_materials
+			pgxmock.AnyArg(), // 24. sale_price
+
+			false,            // 25. is_ai_generated
+			pgxmock.AnyArg(), // 26. ai_model_name
+
+			false, // 27. is_nsfw
+
+			pgtype.Numeric{}, // 28. nozzle_diameter_mm, none given
+			"en",             // 29. language, detected from the title and description
+		).
+		WillReturnRows(createdListingRows(generatedListingID, validUserUUID))
+
+	// 3. Expect the creation to be recorded in the status history, inside the transaction
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO listing_status_events`)).
+		WithArgs(
+			expectedListingUUID,                         // 1. listing_id
+			repo.ListingStatusActorUSER,                 // 2. actor
+			pgxmock.AnyArg(),                            // 3. actor_id
+			repo.NullListingStatus{},                    // 4. from_status, none for a new listing
+			repo.ListingStatusPENDINGVALIDATION,         // 5. to_status
+			pgxmock.AnyArg(),                            // 6. reason
+			pgtype.Text{String: "Go-Test", Valid: true}, // 7. client_id, the token's azp
+			pgxmock.AnyArg(),                            // 8. trace_id
 		).
-		WillReturnRows(pgxmock.NewRows(testutil.ListingsCols).
-			AddRow(
-				generatedListingID,
-				validUserUUID, "test@example.com", "tester", false, // Seller
-				"Valid Listing", "Desc", int64(1050), "gbp", []string{"Art"}, "MIT", // Core
-				"Go-Test", "trace", "path/to/thumb", nil, "PENDING_VALIDATION", // Sys
-				true, nil, // Remix
-				true, nil, false, false, nil, false, nil, nil, nil, // Physical
-				false, nil, // AI
-				0, 0, 0, false, nil, nil, 0.0, 0, 0, false, // Stats
-				time.Now(), time.Now(), nil, // Timestamps
-			))
-
-	// 3. Expect File Inserts
-	// File 1 (Model)
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	// 4. Expect both files in one insert, in request order
 	mockPool.ExpectQuery(regexp.QuoteMeta(`INSERT INTO listing_files`)).
 		WithArgs(
-			expectedListingUUID, // 1. ListingID
-			inputFile1Path,      // 2. FilePath
-			repo.FileTypeMODEL,  // 3. FileType
-			pgxmock.AnyArg(),    // 4. FileSize
-			pgxmock.AnyArg(),    // 5. Metadata
-			pgxmock.AnyArg(),    // 6. Status
-			false,               // 7. is_generated (Default for uploads)
+			expectedListingUUID,                      // 1. ListingID
+			[]string{inputFile1Path, inputFile2Path}, // 2. FilePaths
+			[]string{"MODEL", "IMAGE"},               // 3. FileTypes
+			[]int64{1024, 500},                       // 4. FileSizes
+			[]string{"PENDING", "PENDING"},           // 5. Statuses
+			[]string{"", ""},                         // 6. ErrorMessages
 		).
 		WillReturnRows(pgxmock.NewRows(testutil.ListingFileCols).AddRow(
 			generatedFileID1,
@@ -156,32 +172,19 @@ func TestCreateListing_Success(t *testing.T) {
 			inputFile1Path,
 			repo.FileTypeMODEL,
 			int64(1024),
-			[]byte("{}"), // Metadata
-			"PENDING",    // Status
-			nil,          // Error
-			false,        // is_generated
-			nil,          // source_file_id
+			nil,       // Metadata, until the validation worker fills it in
+			"PENDING", // Status
+			nil,       // Error
+			false,     // is_generated
+			nil,       // source_file_id
 			time.Now(), time.Now(), nil,
-		))
-
-	// File 2 (Image)
-	mockPool.ExpectQuery(regexp.QuoteMeta(`INSERT INTO listing_files`)).
-		WithArgs(
-			expectedListingUUID, // 1. ListingID
-			inputFile2Path,      // 2. FilePath
-			repo.FileTypeIMAGE,  // 3. FileType
-			pgxmock.AnyArg(),    // 4. FileSize
-			pgxmock.AnyArg(),    // 5. Metadata
-			pgxmock.AnyArg(),    // 6. Status
-			false,               // 7. is_generated
-		).
-		WillReturnRows(pgxmock.NewRows(testutil.ListingFileCols).AddRow(
+		).AddRow(
 			generatedFileID2,
 			generatedListingID,
 			inputFile2Path,
 			repo.FileTypeIMAGE,
 			int64(500),
-			[]byte("{}"),
+			nil,
 			"PENDING",
 			nil,
 			false,
@@ -189,9 +192,25 @@ func TestCreateListing_Success(t *testing.T) {
 			time.Now(), time.Now(), nil,
 		))
 
-	// 4. Expect Commit
+	mockPool.ExpectExec(regexp.QuoteMeta(`UPDATE upload_callbacks`)).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	// 5. Expect one validation event carrying both files, then the listing created event, in one write in the same
+	// transaction
+	var validation events.StartListingValidationEvent
+	var created events.ListingCreatedEvent
+	expectOutbox(mockPool,
+		outboxEvent{"file.listing.start", "start.a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11.11111111111111111111111111111111", &validation},
+		outboxEvent{"listings.created", "created.11111111111111111111111111111111", &created},
+	)
+
+	// 6. Expect Commit
 	mockPool.ExpectCommit()
 
+	// A bot asked for the ID before it was created
+	require.NoError(t, cache.Set(rdb, context.Background(), service.listingCache.Key(generatedListingID), notFoundEntry{NotFound: true}, NotFoundCacheTTL))
+
 	result, err := service.CreateListing(context.Background(), userInfo, req)
 
 	if err != nil {
@@ -199,5 +218,1171 @@ func TestCreateListing_Success(t *testing.T) {
 	}
 	assert.NoError(t, err)
 	assert.Equal(t, "Valid Listing", result.Title)
+	assert.NotEqual(t, userInfo.Email, result.SellerName)
+	assert.Equal(t, events.StartListingValidationEvent{
+		ListingID: "11111111111111111111111111111111",
+		UserID:    validUserUUID,
+		Files: []events.ValidationFile{
+			{FileID: "22222222222222222222222222222222", FileKey: inputFile1Path, FileType: "model"},
+			{FileID: "33333333333333333333333333333333", FileKey: inputFile2Path, FileType: "image"},
+		},
+	}, validation)
+	assert.Equal(t, events.ListingCreatedEvent{
+		ListingID:    "11111111111111111111111111111111",
+		SellerID:     validUserUUID,
+		Title:        "Valid Listing",
+		PriceMinUnit: 1050,
+		Currency:     "gbp",
+		Categories:   []string{"Art"},
+	}, created)
+	_, found, err := cache.Get[cachedListing](rdb, context.Background(), service.listingCache.Key(generatedListingID))
+	require.NoError(t, err)
+	assert.False(t, found, "the not found entry is forgotten once the listing exists")
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+// outboxEvent is one event expected in an outbox write. An empty msgID matches any, payload is decoded into when set,
+// for the assertions after the call.
+type outboxEvent struct {
+	subject string
+	msgID   string
+	payload any
+}
+
+// matchArg matches a query argument with a function
+type matchArg func(arg any) bool
+
+func (m matchArg) Match(arg any) bool { return m(arg) }
+
+// expectOutbox expects the events written to the outbox together, in order, in one statement
+func expectOutbox(mockPool pgxmock.PgxPoolIface, events ...outboxEvent) {
+	subjects := make([]string, len(events))
+	for i, e := range events {
+		subjects[i] = e.subject
+	}
+	msgIDs := matchArg(func(arg any) bool {
+		ids, ok := arg.([]string)
+		if !ok || len(ids) != len(events) {
+			return false
+		}
+		for i, e := range events {
+			if e.msgID != "" && ids[i] != e.msgID {
+				return false
+			}
+		}
+		return true
+	})
+	payloads := matchArg(func(arg any) bool {
+		data, ok := arg.([][]byte)
+		if !ok || len(data) != len(events) {
+			return false
+		}
+		for i, e := range events {
+			if e.payload != nil && json.Unmarshal(data[i], e.payload) != nil {
+				return false
+			}
+		}
+		return true
+	})
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: InsertOutboxEvents :exec`)).
+		WithArgs(subjects, msgIDs, payloads).
+		WillReturnResult(pgxmock.NewResult("INSERT", int64(len(events))))
+}
+
+// expectFilesUnused expects the check that the uploads aren't attached to another listing, with these paths when given
+func expectFilesUnused(mockPool pgxmock.PgxPoolIface, paths ...string) {
+	query := mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetUsedFilePaths :many`))
+	if len(paths) > 0 {
+		query = query.WithArgs(paths)
+	} else {
+		query = query.WithArgs(pgxmock.AnyArg())
+	}
+	query.WillReturnRows(pgxmock.NewRows([]string{"file_path"}))
+}
+
+// createdListingRows is what INSERT INTO listings returns for the listing the create tests send
+func createdListingRows(listingID, sellerID string) *pgxmock.Rows {
+	return fixtures.ListingRows(fixtures.NewListing(
+		fixtures.WithID(listingID), fixtures.WithSeller(sellerID), fixtures.WithTitle("Valid Listing"),
+		fixtures.WithThumbnail("path/to/thumb"), fixtures.WithStatus(repo.ListingStatusPENDINGVALIDATION),
+	))
+}
+
+func TestCreateListing_FileAlreadyUsed(t *testing.T) {
+	// SCENARIO: A client resends files that are already attached to another listing.
+	// EXPECT: Refused with a reason before a transaction is started.
+
+	service, mockPool, userInfo, req := newSellerCheckTest(t)
+	service.termsVersion = "1"
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT user_id, display_name`)).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(testutil.SellerCols).AddRow(
+			userInfo.ID, "Tester Prints", "GB", "VERIFIED", "1", time.Now(), time.Now(), time.Now(), nil, nil, nil, false, nil,
+		))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetUsedFilePaths :many`)).
+		WithArgs([]string{req.Files[0].Path, req.Files[1].Path}).
+		WillReturnRows(pgxmock.NewRows([]string{"file_path"}).AddRow(req.Files[1].Path))
+
+	_, err := service.CreateListing(context.Background(), userInfo, req)
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+	assert.Equal(t, errors.ReasonListingFileAlreadyUsed, appErr.Reason)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestCreateListing_FileClaimedConcurrently(t *testing.T) {
+	// SCENARIO: Two creates with the same files pass the check together, the other one commits first.
+	// EXPECT: The unique index refuses the file insert and the seller gets the same error as the pre-check.
+
+	service, mockPool, userInfo, req := newSellerCheckTest(t)
+	service.termsVersion = "1"
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT user_id, display_name`)).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(testutil.SellerCols).AddRow(
+			userInfo.ID, "Tester Prints", "GB", "VERIFIED", "1", time.Now(), time.Now(), time.Now(), nil, nil, nil, false, nil,
+		))
+	expectFilesUnused(mockPool)
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`INSERT INTO listings`)).
+		WithArgs(anyArgs(29)...).
+		WillReturnRows(createdListingRows("11111111-1111-1111-1111-111111111111", userInfo.ID))
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO listing_status_events`)).
+		WithArgs(anyArgs(8)...).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`INSERT INTO listing_files`)).
+		WithArgs(anyArgs(6)...).
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_listing_files_upload_path"})
+	mockPool.ExpectRollback()
+
+	_, err := service.CreateListing(context.Background(), userInfo, req)
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ReasonListingFileAlreadyUsed, appErr.Reason)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func newSellerCheckTest(t *testing.T) (*svc, pgxmock.PgxPoolIface, auth.UserInfo, *CreateListingRequest) {
+	t.Helper()
+
+	mockPool := testutil.NewMockDB(t)
+	rdb, _ := apitest.NewRedis(t)
+	service := &svc{
+		repo:         repo.New(mockPool),
+		db:           mockPool,
+		logger:       testutil.NewTestLogger(),
+		cache:        rdb,
+		termsVersion: "2",
+	}
+
+	const userID = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	userInfo := auth.UserInfo{ID: userID, Email: "test@example.com", Username: "tester"}
+
+	req := &CreateListingRequest{
+		Title:        "Valid Listing",
+		Description:  "A great item for the whole family",
+		PriceMinUnit: 1050,
+		Currency:     "gbp",
+		Categories:   []string{"Art"},
+		License:      "MIT",
+		Files: []CreateListingFile{
+			{Type: "model", Path: "2025/01/01/" + userID + "/draft/model/model.stl", Size: 1024},
+			{Type: "image", Path: "2025/01/01/" + userID + "/draft/image/image.jpg", Size: 500},
+		},
+	}
+
+	return service, mockPool, userInfo, req
+}
+
+func TestCreateListing_NoSellerProfile(t *testing.T) {
+	service, mockPool, userInfo, req := newSellerCheckTest(t)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT user_id, display_name`)).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(testutil.SellerCols))
+
+	_, err := service.CreateListing(context.Background(), userInfo, req)
+
+	var appErr *errors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrSellerProfileRequired, appErr.Code)
+	// No transaction should have been started
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestCreateListing_Canceled(t *testing.T) {
+	// SCENARIO: The client hung up before the listing was created.
+	// EXPECT: CANCELED without touching the database or publishing anything.
+
+	service, mockPool, userInfo, req := newSellerCheckTest(t)
+	service.eventHandler = events.NewEventHandler(mockevents.NewBus(t), &events.EventConfig{}, service.logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := service.CreateListing(ctx, userInfo, req)
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrCanceled, appErr.Code)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestCreateListing_OutdatedSellerTerms(t *testing.T) {
+	service, mockPool, userInfo, req := newSellerCheckTest(t)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT user_id, display_name`)).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(testutil.SellerCols).AddRow(
+			userInfo.ID, "Tester Prints", "GB", "VERIFIED", "1", time.Now(), time.Now(), time.Now(), nil, nil, nil, false, nil,
+		))
+
+	_, err := service.CreateListing(context.Background(), userInfo, req)
+
+	var appErr *errors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrSellerProfileRequired, appErr.Code)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestToListingResponse_EmailSellerName_NotExposed(t *testing.T) {
+	// SCENARIO: Legacy row written before seller profiles, seller_name holds the email.
+	// EXPECT: The email never appears anywhere in the serialized response.
+
+	service := &svc{logger: testutil.NewTestLogger()}
+
+	const email = "john.doe@example.com"
+	row := fixtures.NewListingRow(fixtures.With(func(l *repo.Listing) {
+		l.SellerName = email
+		l.SellerUsername = "johndoe"
+	}))
+
+	response := service.toListingResponse(context.Background(), row)
+	assert.Equal(t, "johndoe", response.SellerName)
+
+	body, err := json.Marshal(response)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(body), email)
+}
+
+func TestToListingResponse_NoClientOrTrace(t *testing.T) {
+	// SCENARIO: Any listing is serialized for the API.
+	// EXPECT: Which client and trace created it aren't in the response, they're only in its status history.
+
+	service := &svc{logger: testutil.NewTestLogger()}
+
+	body, err := json.Marshal(service.toListingResponse(context.Background(), fixtures.NewListingRow()))
+	assert.NoError(t, err)
+
+	var fields map[string]any
+	assert.NoError(t, json.Unmarshal(body, &fields))
+	assert.NotContains(t, fields, "client_id")
+	assert.NotContains(t, fields, "trace_id")
+}
+
+func TestParseFileMetadata_NormalizesLegacyKeys(t *testing.T) {
+	raw := []byte(`{
+		"mime": "model/stl",
+		"triangles": 1200,
+		"bounds": [[0, 0, 0], [20, 30, 40.5]],
+		"is_watertight": true,
+		"is_winding_consistent": false,
+		"euler_number": 2,
+		"internal_path": "/tmp/worker/abc.stl"
+	}`)
+
+	meta, dropped, err := ParseFileMetadata(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"euler_number", "internal_path"}, dropped)
+
+	watertight, winding := true, false
+	triangles := int64(1200)
+	assert.Equal(t, &FileMetadata{
+		Format:        "model/stl",
+		TriangleCount: &triangles,
+		BoundingBox:   &BoundingBox{Min: [3]float64{0, 0, 0}, Max: [3]float64{20, 30, 40.5}},
+		Scan:          &FileScan{Watertight: &watertight, WindingConsistent: &winding},
+	}, meta)
+}
+
+func TestParseFileMetadata_Rejects(t *testing.T) {
+	oversized := []byte(`{"format": "model/stl", "notes": "` + strings.Repeat("x", MaxFileMetadataBytes) + `"}`)
+
+	tests := map[string]struct {
+		raw  string
+		want error
+	}{
+		"oversized":              {string(oversized), ErrFileMetadataTooLarge},
+		"array":                  {`[1, 2, 3]`, ErrFileMetadataShape},
+		"string":                 {`"model/stl"`, ErrFileMetadataShape},
+		"triangle count as text": {`{"triangle_count": "lots"}`, ErrFileMetadataShape},
+		"two dimensional bounds": {`{"bounds": [[0, 0], [1, 1]]}`, ErrFileMetadataShape},
+		"box missing a corner":   {`{"bounding_box": {"min": [0, 0, 0]}}`, ErrFileMetadataShape},
+		"scan not an object":     {`{"scan": true}`, ErrFileMetadataShape},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			meta, _, err := ParseFileMetadata([]byte(tt.raw))
+			assert.ErrorIs(t, err, tt.want)
+			assert.Nil(t, meta)
+		})
+	}
+}
+
+func TestToListingResponse_FileMetadataWhitelisted(t *testing.T) {
+	// SCENARIO: One file has metadata with extra internal keys, another has a multi-megabyte blob.
+	// EXPECT: Only whitelisted fields reach the response, and the oversized document is hidden
+	// without failing the listing.
+
+	service := &svc{logger: testutil.NewTestLogger()}
+
+	files, err := json.Marshal([]map[string]any{
+		{
+			"id": "file-1", "file_type": "MODEL", "status": "PENDING", "size": 2048,
+			"metadata": map[string]any{"format": "model/stl", "triangle_count": 12, "worker_host": "validation-7"},
+		},
+		{
+			"id": "file-2", "file_type": "IMAGE", "status": "PENDING", "size": 1024,
+			"metadata": map[string]any{"format": "image/png", "blob": strings.Repeat("x", 4*1024*1024)},
+		},
+	})
+	assert.NoError(t, err)
+
+	row := fixtures.NewListingRow(fixtures.WithFiles(files))
+
+	response := service.toListingResponse(context.Background(), row)
+	if assert.Len(t, response.Files, 2) {
+		assert.Equal(t, "model/stl", response.Files[0].Metadata.Format)
+		assert.Nil(t, response.Files[1].Metadata)
+	}
+
+	body, err := json.Marshal(response)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(body), "validation-7")
+	assert.Less(t, len(body), MaxFileMetadataBytes)
+}
+
+func TestToListingResponse_FileURLTTLFromConfig(t *testing.T) {
+	// SCENARIO: A listing with a validated model and image, model URLs configured to live 40 minutes.
+	// EXPECT: The model is presigned for 40 minutes without an audience (the response is cached and shared),
+	// the image gets a plain public URL without touching storage.
+
+	store := mockstorage.NewProvider(t)
+	store.EXPECT().
+		PresignGet(mock.Anything, storage.BucketProduct, "models/benchy.stl", storage.PresignOptions{Expiry: 40 * time.Minute}).
+		Return("http://minio/product-files/models/benchy.stl?X-Amz-Signature=abc", nil)
+
+	service := &svc{
+		logger:  testutil.NewTestLogger(),
+		storage: store,
+		urls:    publicurl.Config{AssetsBaseURL: "http://localhost:9000/public-files/"},
+		downloads: DownloadConfig{
+			"MODEL": {TTL: 40 * time.Minute, MaxTTL: 4 * time.Hour},
+			"IMAGE": {},
+		},
+	}
+
+	files, err := json.Marshal([]map[string]any{
+		{"id": "file-1", "file_type": "MODEL", "status": "VALID", "file_path": "models/benchy.stl"},
+		{"id": "file-2", "file_type": "IMAGE", "status": "VALID", "file_path": "images/benchy.png"},
+	})
+	assert.NoError(t, err)
+
+	response := service.toListingResponse(context.Background(), fixtures.NewListingRow(fixtures.WithFiles(files)))
+
+	if assert.Len(t, response.Files, 2) {
+		assert.Equal(t, "http://minio/product-files/models/benchy.stl?X-Amz-Signature=abc", *response.Files[0].FilePath)
+		assert.Equal(t, "http://localhost:9000/public-files/images/benchy.png", *response.Files[1].FilePath)
+	}
+}
+
+func TestToListingResponse_CDNServesImagesOnly(t *testing.T) {
+	// SCENARIO: Images are fronted by a CDN on its own hostname.
+	// EXPECT: The thumbnail and gallery image use the CDN, the model is still presigned against S3.
+
+	store := mockstorage.NewProvider(t)
+	store.EXPECT().
+		PresignGet(mock.Anything, storage.BucketProduct, "models/benchy.stl", storage.PresignOptions{Expiry: 40 * time.Minute}).
+		Return("http://minio/product-files/models/benchy.stl?X-Amz-Signature=abc", nil)
+
+	service := &svc{
+		logger:  testutil.NewTestLogger(),
+		storage: store,
+		urls:    publicurl.Config{AssetsBaseURL: "http://localhost:9000/public-files", CDNImageBaseURL: "https://img.example.com"},
+		downloads: DownloadConfig{
+			"MODEL": {TTL: 40 * time.Minute, MaxTTL: 4 * time.Hour},
+			"IMAGE": {},
+		},
+	}
+
+	files, err := json.Marshal([]map[string]any{
+		{"id": "file-1", "file_type": "MODEL", "status": "VALID", "file_path": "models/benchy.stl"},
+		{"id": "file-2", "file_type": "IMAGE", "status": "VALID", "file_path": "images/benchy.webp"},
+	})
+	assert.NoError(t, err)
+
+	response := service.toListingResponse(context.Background(), fixtures.NewListingRow(
+		fixtures.WithThumbnail("images/benchy.webp"), fixtures.WithFiles(files),
+	))
+
+	assert.Equal(t, "https://img.example.com/images/benchy.webp", *response.ThumbnailPath)
+	if assert.Len(t, response.Files, 2) {
+		assert.Equal(t, "http://minio/product-files/models/benchy.stl?X-Amz-Signature=abc", *response.Files[0].FilePath)
+		assert.Equal(t, "https://img.example.com/images/benchy.webp", *response.Files[1].FilePath)
+	}
+}
+
+func TestToListingResponse_CanceledNotPresigned(t *testing.T) {
+	// SCENARIO: The client hung up while its listing was being built.
+	// EXPECT: The model isn't presigned for nobody, public URLs are still filled in as they cost nothing.
+
+	service := &svc{
+		logger:    testutil.NewTestLogger(),
+		storage:   mockstorage.NewProvider(t),
+		urls:      publicurl.Config{AssetsBaseURL: "http://localhost:9000/public-files"},
+		downloads: DownloadConfig{"MODEL": {TTL: 40 * time.Minute, MaxTTL: 4 * time.Hour}, "IMAGE": {}},
+	}
+
+	files, err := json.Marshal([]map[string]any{
+		{"id": "file-1", "file_type": "MODEL", "status": "VALID", "file_path": "models/benchy.stl"},
+		{"id": "file-2", "file_type": "IMAGE", "status": "VALID", "file_path": "images/benchy.png"},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	response := service.toListingResponse(ctx, fixtures.NewListingRow(fixtures.WithFiles(files)))
+
+	if assert.Len(t, response.Files, 2) {
+		assert.Nil(t, response.Files[0].FilePath)
+		assert.Equal(t, "http://localhost:9000/public-files/images/benchy.png", *response.Files[1].FilePath)
+	}
+}
+
+func TestToListingResponse_TotalModelSize(t *testing.T) {
+	// SCENARIO: A listing with two validated models, one still validating, one rejected and a validated image.
+	// EXPECT: Only the validated models count towards the download size.
+
+	service := &svc{
+		logger:    testutil.NewTestLogger(),
+		urls:      publicurl.Config{AssetsBaseURL: "http://localhost:9000/public-files"},
+		downloads: DownloadConfig{"MODEL": {}, "IMAGE": {}},
+	}
+
+	files, err := json.Marshal([]map[string]any{
+		{"id": "file-1", "file_type": "MODEL", "status": "VALID", "file_path": "models/base.stl", "size": 3 << 20},
+		{"id": "file-2", "file_type": "MODEL", "status": "VALID", "file_path": "models/lid.stl", "size": 512 << 10},
+		{"id": "file-3", "file_type": "MODEL", "status": "PENDING", "size": 40 << 20},
+		{"id": "file-4", "file_type": "MODEL", "status": "INVALID", "size": 7 << 20},
+		{"id": "file-5", "file_type": "IMAGE", "status": "VALID", "file_path": "images/base.png", "size": 2 << 20},
+	})
+	require.NoError(t, err)
+
+	response := service.toListingResponse(context.Background(), fixtures.NewListingRow(fixtures.WithFiles(files)))
+
+	assert.Len(t, response.Files, 5)
+	assert.Equal(t, int64(3<<20+512<<10), response.TotalModelSizeBytes)
+	assert.Equal(t, "3.5 MB", response.TotalModelSizeDisplay)
+}
+
+// localZone is the zone pgx hands back timestamps in when the gateway runs with TZ=UTC-5
+var localZone = time.FixedZone("UTC-5", -5*60*60)
+
+func TestToListingResponse_TimesInUTC(t *testing.T) {
+	// SCENARIO: The gateway runs with TZ=UTC-5, so pgx hands back every timestamp in that zone.
+	// EXPECT: Every time in the response is serialized in UTC ending in Z, created_at_unix is the same instant.
+
+	service := &svc{logger: testutil.NewTestLogger()}
+
+	createdAt := time.Date(2026, 3, 1, 22, 30, 0, 0, localZone) // Already the 2nd in UTC
+	at := func(t time.Time) pgtype.Timestamptz { return pgtype.Timestamptz{Time: t, Valid: true} }
+	response := service.toListingResponse(context.Background(), fixtures.NewListingRow(
+		fixtures.WithSale("Spring sale", 800, createdAt.Add(24*time.Hour)),
+		fixtures.With(func(l *repo.Listing) {
+			l.CreatedAt = at(createdAt)
+			l.UpdatedAt = at(createdAt.Add(time.Hour))
+			l.LastIndexedAt = at(createdAt.Add(2 * time.Hour))
+		}),
+	))
+
+	body, err := json.Marshal(response)
+	require.NoError(t, err)
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(body, &got))
+	assert.Equal(t, "2026-03-02T03:30:00Z", got["created_at"])
+	assert.Equal(t, "2026-03-02T04:30:00Z", got["updated_at"])
+	assert.Equal(t, "2026-03-02T05:30:00Z", got["last_indexed_at"])
+	assert.Equal(t, "2026-03-03T03:30:00Z", got["sale_end_timestamp"])
+	assert.Equal(t, float64(createdAt.Unix()), got["created_at_unix"])
+	assert.NotContains(t, got, "deleted_at")
+}
+
+func TestToListingResponse_PricesExact(t *testing.T) {
+	// SCENARIO: Listings priced at 19.99 and at just over a million, one on sale, are read back through the repository.
+	// EXPECT: The response carries the exact minor units stored, nothing passes through a decimal on the way.
+
+	service := &svc{logger: testutil.NewTestLogger()}
+
+	tests := []struct {
+		name      string
+		row       repo.GetListingByIDWithFilesRow
+		wantPrice string
+		wantSale  string
+	}{
+		{name: "19.99", row: fixtures.NewListingRow(fixtures.WithPrice(1999, "usd")), wantPrice: "1999", wantSale: "null"},
+		{name: "1000000.01", row: fixtures.NewListingRow(fixtures.WithPrice(100000001, "usd")), wantPrice: "100000001", wantSale: "null"},
+		{
+			name:      "On sale",
+			row:       fixtures.NewListingRow(fixtures.WithPrice(100000001, "usd"), fixtures.WithSale("Spring sale", 1999, fixtures.CreatedAt.Add(time.Hour))),
+			wantPrice: "100000001",
+			wantSale:  "1999",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockPool := testutil.NewMockDB(t)
+			mockPool.ExpectQuery(`-- name: GetListingByIDWithFiles :one`).WithArgs(pgxmock.AnyArg()).
+				WillReturnRows(fixtures.ListingWithFilesRows(tt.row))
+			row, err := repo.New(mockPool).GetListingByIDWithFiles(context.Background(), tt.row.ID)
+			require.NoError(t, err)
+
+			body, err := json.Marshal(service.toListingResponse(context.Background(), row))
+			require.NoError(t, err)
+			var got map[string]json.RawMessage
+			require.NoError(t, json.Unmarshal(body, &got))
+			assert.Equal(t, tt.wantPrice, string(got["price_min_unit"]))
+			assert.Equal(t, tt.wantSale, string(got["sale_price_min_unit"]))
+		})
+	}
+}
+
+func TestListingResponse_ZeroValueShape(t *testing.T) {
+	// SCENARIO: A listing with nothing set at all, every array column NULL in Postgres.
+	// EXPECT: Arrays are [], optional scalars are null, and no field is left out. A new field has to be added here,
+	// which is the point: decide whether it's an array, nullable or always set.
+
+	service := &svc{logger: testutil.NewTestLogger()}
+	response := service.toListingResponse(context.Background(), repo.GetListingByIDWithFilesRow{})
+
+	body, err := json.Marshal(response)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"id": "00000000000000000000000000000000",
+		"seller_id": "00000000000000000000000000000000",
+		"seller_name": "",
+		"seller_username": "",
+		"seller_verified": false,
+		"title": "",
+		"description": "",
+		"price_min_unit": 0,
+		"currency": "",
+		"categories": [],
+		"license": "",
+		"language": "",
+		"thumbnail_path": null,
+		"files": [],
+		"total_model_size_bytes": 0,
+		"total_model_size_display": "0 B",
+		"variants": [],
+		"is_remixing_allowed": false,
+		"parent_listing_id": null,
+		"parent_unavailable": false,
+		"is_physical": false,
+		"total_weight_grams": null,
+		"dim_x_mm": null,
+		"dim_y_mm": null,
+		"dim_z_mm": null,
+		"is_assembly_required": false,
+		"is_hardware_required": false,
+		"hardware_required": [],
+		"is_multicolor": false,
+		"recommended_materials": [],
+		"recommended_nozzle_temp_c": null,
+		"nozzle_diameter_mm": null,
+		"is_ai_generated": false,
+		"ai_model_name": null,
+		"is_nsfw": false,
+		"likes_count": 0,
+		"downloads_count": 0,
+		"views_count": 0,
+		"comments_count": 0,
+		"is_sale_active": false,
+		"sale_name": null,
+		"sale_end_timestamp": null,
+		"sale_price_min_unit": null,
+		"temporarily_unavailable": false,
+		"unavailable_until": null,
+		"seller_away_message": null,
+		"status": "UNKNOWN",
+		"status_reason": null,
+		"created_at": "0001-01-01T00:00:00Z",
+		"created_at_unix": -62135596800,
+		"updated_at": "0001-01-01T00:00:00Z",
+		"last_indexed_at": null,
+		"content_hash": "8d269a703cacedfd2a45e35097f6e3af"
+	}`, string(body))
+}
+
+func TestCacheNamespace_MovesWithURLs(t *testing.T) {
+	s3 := publicurl.Config{AssetsBaseURL: "http://localhost:9000/public-files"}
+	cdn := publicurl.Config{AssetsBaseURL: s3.AssetsBaseURL, CDNImageBaseURL: "https://img.example.com"}
+
+	assert.True(t, strings.HasPrefix(CacheNamespace(s3).Key("abc"), "listing:"))
+	assert.Equal(t, CacheNamespace(s3), CacheNamespace(publicurl.Config{AssetsBaseURL: s3.AssetsBaseURL}))
+	assert.NotEqual(t, CacheNamespace(s3).Key("abc"), CacheNamespace(cdn).Key("abc"))
+}
+
+func TestDownloadConfig_TTL(t *testing.T) {
+	config := DownloadConfig{
+		"MODEL": {TTL: 15 * time.Minute, MaxTTL: 2 * time.Hour},
+		"IMAGE": {},
+		"SLICE": {TTL: time.Hour, MaxTTL: 30 * time.Minute}, // Misconfigured, the hint must never shorten a link
+	}
+
+	tests := map[string]struct {
+		fileType   string
+		preferLong bool
+		want       time.Duration
+	}{
+		"model":                 {"MODEL", false, 15 * time.Minute},
+		"model prefers long":    {"MODEL", true, 2 * time.Hour},
+		"lower case type":       {"model", false, 15 * time.Minute},
+		"image is public":       {"IMAGE", false, 0},
+		"image prefers long":    {"IMAGE", true, 0},
+		"max below default":     {"SLICE", true, time.Hour},
+		"unknown type presigns": {"GCODE", false, defaultPresignTTL},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, config.TTL(tt.fileType, tt.preferLong))
+		})
+	}
+}
+
+func TestGetFileDownload_TTLPerFileType(t *testing.T) {
+	const (
+		userID    = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+		listingID = "11111111-1111-1111-1111-111111111111"
+		fileID    = "22222222-2222-2222-2222-222222222222"
+	)
+
+	config := DownloadConfig{
+		"MODEL": {TTL: 20 * time.Minute, MaxTTL: 3 * time.Hour},
+		"IMAGE": {},
+	}
+
+	tests := map[string]struct {
+		fileType   repo.FileType
+		path       string
+		preferLong bool
+		wantTTL    time.Duration // Zero when storage must not be asked to sign
+		wantURL    string
+	}{
+		"model":              {repo.FileTypeMODEL, "models/benchy.stl", false, 20 * time.Minute, "http://minio/signed"},
+		"model prefers long": {repo.FileTypeMODEL, "models/benchy.stl", true, 3 * time.Hour, "http://minio/signed"},
+		"image is public":    {repo.FileTypeIMAGE, "images/benchy.png", true, 0, "http://localhost:9000/public-files/images/benchy.png"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockPool := testutil.NewMockDB(t)
+			store := mockstorage.NewProvider(t)
+			service := &svc{
+				repo:      repo.New(mockPool),
+				db:        mockPool,
+				logger:    testutil.NewTestLogger(),
+				storage:   store,
+				urls:      publicurl.Config{AssetsBaseURL: "http://localhost:9000/public-files"},
+				downloads: config,
+				now:       time.Now,
+			}
+
+			mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingFileForDownload`)).
+				WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+				WillReturnRows(pgxmock.NewRows(testutil.ListingFileCols).AddRow(
+					fileID, listingID, tt.path, tt.fileType, int64(1024),
+					[]byte("{}"), "VALID", nil, false, nil,
+					time.Now(), time.Now(), nil,
+				))
+
+			if tt.wantTTL > 0 {
+				mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingAvailability`)).
+					WithArgs(pgxmock.AnyArg()).
+					WillReturnRows(pgxmock.NewRows(availabilityCols).AddRow(awaySellerID, int64(500), nil, nil))
+				mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetFileVariantPrice`)).
+					WithArgs(pgxmock.AnyArg()).
+					WillReturnError(pgx.ErrNoRows)
+				store.EXPECT().
+					PresignGet(mock.Anything, storage.BucketProduct, tt.path, storage.PresignOptions{Expiry: tt.wantTTL, Audience: userID}).
+					Return("http://minio/signed", nil)
+			}
+
+			before := time.Now()
+			download, err := service.GetFileDownload(context.Background(), auth.UserInfo{ID: userID}, listingID, fileID, tt.preferLong)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantURL, download.URL)
+			if tt.wantTTL > 0 {
+				if assert.NotNil(t, download.ExpiresAt) {
+					assert.WithinDuration(t, before.Add(tt.wantTTL), *download.ExpiresAt, time.Second)
+				}
+			} else {
+				assert.Nil(t, download.ExpiresAt)
+			}
+			assert.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetFileDownload_NotFound(t *testing.T) {
+	// SCENARIO: The file doesn't exist, isn't VALID yet, or its listing was deleted.
+	// EXPECT: NOT_FOUND and nothing gets signed.
+
+	mockPool := testutil.NewMockDB(t)
+	service := &svc{
+		repo:      repo.New(mockPool),
+		db:        mockPool,
+		logger:    testutil.NewTestLogger(),
+		storage:   mockstorage.NewProvider(t),
+		downloads: DefaultDownloadConfig(),
+	}
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingFileForDownload`)).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(testutil.ListingFileCols))
+
+	_, err := service.GetFileDownload(context.Background(), auth.UserInfo{ID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"},
+		"11111111-1111-1111-1111-111111111111", "22222222-2222-2222-2222-222222222222", false)
+
+	var appErr *errors.AppError
+	assert.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrNotFound, appErr.Code)
+}
+
+func TestDeleteListing(t *testing.T) {
+	// SCENARIO: A seller deletes a listing by the dashed ID API responses carry, while it's cached under both ID forms.
+	// EXPECT: The re-index and delete events name the dashless ID its search document is keyed by and go to the
+	// outbox with the delete. Both cache entries are dropped.
+
+	service, mockPool := newBulkTest(t)
+	rdb, _ := apitest.NewRedis(t)
+	service.cache = rdb
+	for _, id := range []string{bulkOwnListing, bulkOwnDocument} {
+		require.NoError(t, cache.Set(rdb, context.Background(), service.listingCache.Key(id), notFoundEntry{NotFound: true}, NotFoundCacheTTL))
+	}
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: SoftDeleteListing`)).
+		WithArgs(mustUUID(t, bulkOwnListing), mustUUID(t, updateSellerID)).
+		WillReturnRows(listingRows(updateSellerID, "Benchy", "", repo.ListingStatusACTIVE))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetRemixIDs :many`)).WithArgs([]pgtype.UUID{mustUUID(t, bulkOwnListing)}).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}))
+	var indexEvent, deleteEvent map[string]any
+	expectOutbox(mockPool,
+		outboxEvent{"listings.index", "index." + bulkOwnDocument, &indexEvent},
+		outboxEvent{"listings.delete", "delete." + bulkOwnDocument, &deleteEvent},
+	)
+	mockPool.ExpectCommit()
+
+	err := service.DeleteListing(context.Background(), bulkSeller, bulkOwnListing)
+
+	require.NoError(t, err)
+	assert.Equal(t, bulkOwnDocument, indexEvent["listing_id"])
+	assert.Equal(t, bulkOwnDocument, deleteEvent["listing_id"])
+	for _, id := range []string{bulkOwnListing, bulkOwnDocument} {
+		_, found, err := cache.Get[notFoundEntry](rdb, context.Background(), service.listingCache.Key(id))
+		require.NoError(t, err)
+		assert.False(t, found, id)
+	}
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestDeleteListing_AlreadyDeleted(t *testing.T) {
+	// SCENARIO: The seller deletes a listing twice, or one that belongs to someone else.
+	// EXPECT: LISTING_NOT_FOUND, and the original deleted_at is left alone so the purge date doesn't move.
+
+	service, mockPool := newUpdateTest(t)
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: SoftDeleteListing`)).
+		WithArgs(mustUUID(t, updateListingID), mustUUID(t, updateSellerID)).
+		WillReturnRows(pgxmock.NewRows(testutil.ListingsCols))
+	mockPool.ExpectRollback()
+
+	err := service.DeleteListing(context.Background(), auth.UserInfo{ID: updateSellerID}, updateListingID)
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrNotFound, appErr.Code)
+	assert.Equal(t, errors.ReasonListingNotFound, appErr.Reason)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestSellerListingsParams(t *testing.T) {
+	sellerID := mustUUID(t, updateSellerID)
+
+	tests := []struct {
+		name  string
+		query SellerListingsQuery
+		want  repo.GetListingsBySellerIDParams
+		field string // The param rejected, empty when the query is valid
+	}{
+		{
+			// Sellers who don't send anything get the order they always had
+			name: "Defaults",
+			want: repo.GetListingsBySellerIDParams{SellerID: sellerID, SortColumn: "created_at", SortDesc: true},
+		},
+		{
+			name:  "Status",
+			query: SellerListingsQuery{Status: "PENDING_VALIDATION"},
+			want: repo.GetListingsBySellerIDParams{SellerID: sellerID, SortColumn: "created_at", SortDesc: true,
+				Status: repo.NullListingStatus{ListingStatus: repo.ListingStatusPENDINGVALIDATION, Valid: true}},
+		},
+		{
+			name:  "Sort without order is descending",
+			query: SellerListingsQuery{Sort: "downloads_count"},
+			want:  repo.GetListingsBySellerIDParams{SellerID: sellerID, SortColumn: "downloads_count", SortDesc: true},
+		},
+		{
+			name:  "Ascending",
+			query: SellerListingsQuery{Sort: "updated_at", Order: "asc"},
+			want:  repo.GetListingsBySellerIDParams{SellerID: sellerID, SortColumn: "updated_at"},
+		},
+		{name: "Unknown status", query: SellerListingsQuery{Status: "PUBLISHED"}, field: "status"},
+		{name: "Lowercase status", query: SellerListingsQuery{Status: "active"}, field: "status"},
+		{name: "Unknown sort", query: SellerListingsQuery{Sort: "title"}, field: "sort"},
+		{name: "Unknown order", query: SellerListingsQuery{Sort: "created_at", Order: "up"}, field: "order"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, appErr := sellerListingsParams(sellerID, tt.query)
+
+			if tt.field == "" {
+				require.Nil(t, appErr)
+				assert.Equal(t, tt.want, params)
+				return
+			}
+			require.NotNil(t, appErr)
+			assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+			assert.Equal(t, errors.ReasonListingsQueryInvalid, appErr.Reason)
+			assert.Equal(t, tt.field, appErr.Params["field"])
+			assert.NotEmpty(t, appErr.Params["allowed"])
+		})
+	}
+}
+
+func TestGetListingsForUser_InvalidStatus_NoQuery(t *testing.T) {
+	// SCENARIO: A seller filters on a status that doesn't exist.
+	// EXPECT: INVALID_INPUT naming the statuses there are, without querying the database.
+
+	service, mockPool := newUpdateTest(t)
+
+	_, err := service.GetListingsForUser(context.Background(), auth.UserInfo{ID: updateSellerID}, SellerListingsQuery{Status: "PUBLISHED"})
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+	assert.Contains(t, appErr.Message, "PENDING_VALIDATION, PENDING_REVIEW, ACTIVE, REJECTED, HIDDEN")
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestValidate_ReasonsAreRegistered(t *testing.T) {
+	const userID = "550e8400-e29b-41d4-a716-446655440000"
+	valid := func() *CreateListingRequest {
+		return &CreateListingRequest{
+			Title:       "Valid Listing",
+			Description: "A great item for the whole family",
+			Currency:    "gbp",
+			Categories:  []string{"Art"},
+			License:     "MIT",
+			Files: []CreateListingFile{
+				{Type: "model", Path: "2025/01/01/" + userID + "/draft/model/model.stl", Size: 1024},
+				{Type: "image", Path: "2025/01/01/" + userID + "/draft/image/image.jpg", Size: 500},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(req *CreateListingRequest)
+		reason errors.Reason
+	}{
+		{"short title", func(r *CreateListingRequest) { r.Title = "abc" }, errors.ReasonListingTitleLength},
+		{"short description", func(r *CreateListingRequest) { r.Description = "too short" }, errors.ReasonListingDescriptionShort},
+		{"no categories", func(r *CreateListingRequest) { r.Categories = nil }, errors.ReasonListingCategoryRequired},
+		{"bad currency", func(r *CreateListingRequest) { r.PriceMinUnit = 500; r.Currency = "eur" }, errors.ReasonListingCurrencyUnsupported},
+		{"someone else's file", func(r *CreateListingRequest) {
+			r.Files[0].Path = "2025/01/01/11111111-1111-1111-1111-111111111111/draft/model/model.stl"
+		}, errors.ReasonListingFileNotOwned},
+		{"no draft segment", func(r *CreateListingRequest) {
+			r.Files[0].Path = "2025/01/01/" + userID + "//model/model.stl"
+		}, errors.ReasonListingFileNotOwned},
+		{"files from two drafts", func(r *CreateListingRequest) {
+			r.Files[1].Path = "2025/01/01/" + userID + "/other-draft/image/image.jpg"
+		}, errors.ReasonListingFilesMixedDrafts},
+		{"same file twice", func(r *CreateListingRequest) { r.Files = append(r.Files, r.Files[1]) }, errors.ReasonListingFileRepeated},
+		{"no image", func(r *CreateListingRequest) { r.Files = r.Files[:1] }, errors.ReasonListingImageRequired},
+		{"partial size", func(r *CreateListingRequest) { r.IsPhysical = true; r.Dimensions = &ListingDimensions{X: 120, Y: 80} }, errors.ReasonListingDimensionsIncomplete},
+		{"unlisted nozzle", func(r *CreateListingRequest) { r.PrinterSettings.NozzleDiameter = ptr("0.5mm") }, errors.ReasonListingNozzleDiameter},
+		{"deprecated temperature", func(r *CreateListingRequest) { r.PrinterSettings.NozzleTemperature = ptr(120.0) }, errors.ReasonListingNozzleTempRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid()
+			tt.mutate(req)
+
+			appErr := req.Validate(userID, DefaultValidationRules())
+			if assert.NotNil(t, appErr) {
+				assert.Equal(t, tt.reason, appErr.Reason)
+				assert.True(t, errors.IsRegistered(appErr.Reason))
+			}
+		})
+	}
+
+	assert.Nil(t, valid().Validate(userID, DefaultValidationRules()))
+}
+
+func expectListingMissing(mockPool pgxmock.PgxPoolIface) {
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingByIDWithFiles`)).WithArgs(pgxmock.AnyArg()).
+		WillReturnError(pgx.ErrNoRows)
+}
+
+func TestGetListingByID_NotFound_Cached(t *testing.T) {
+	// SCENARIO: A bot asks for a listing that doesn't exist, twice.
+	// EXPECT: The first request reads the database and remembers the miss for NotFoundCacheTTL, the second is a 404
+	// straight from Redis.
+
+	service, mockPool, rdb := newHydrateTest(t)
+	expectListingMissing(mockPool)
+
+	for range 2 {
+		_, err := service.GetListingByID(context.Background(), fixtures.ListingID)
+		var appErr *errors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, errors.ErrNotFound, appErr.Code)
+	}
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+
+	ttl, found, err := cache.TTL(rdb, context.Background(), service.listingCache.Key(fixtures.ListingID))
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, NotFoundCacheTTL, ttl)
+}
+
+func TestGetListingByID_DatabaseDown_NotCachedAsMissing(t *testing.T) {
+	service, mockPool, rdb := newHydrateTest(t)
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingByIDWithFiles`)).WithArgs(pgxmock.AnyArg()).
+		WillReturnError(context.DeadlineExceeded)
+
+	_, err := service.GetListingByID(context.Background(), fixtures.ListingID)
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrInternal, appErr.Code)
+	_, found, err := cache.Get[cachedListing](rdb, context.Background(), service.listingCache.Key(fixtures.ListingID))
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestCachedListing_TellsEntriesApart(t *testing.T) {
+	// SCENARIO: A response and the not found marker are read back from the cache.
+	// EXPECT: Each is recognised for what it is, a response with every field at its zero value included.
+
+	service, _, rdb := newHydrateTest(t)
+	ctx := context.Background()
+	for _, tt := range []struct {
+		value        any
+		wantNotFound bool
+	}{
+		{value: ListingResponse{ID: "550e8400e29b41d4a716446655440000", Title: "Benchy"}},
+		{value: ListingResponse{}},
+		{value: notFoundEntry{NotFound: true}, wantNotFound: true},
+	} {
+		require.NoError(t, cache.Set(rdb, ctx, service.listingCache.Key(fixtures.ListingID), tt.value, time.Minute))
+		cached, found, err := cache.Get[cachedListing](rdb, ctx, service.listingCache.Key(fixtures.ListingID))
+		require.NoError(t, err)
+		require.True(t, found)
+		assert.Equal(t, tt.wantNotFound, cached.NotFound, "%+v", tt.value)
+		if response, ok := tt.value.(ListingResponse); ok {
+			assert.Equal(t, response, cached.ListingResponse)
+		}
+	}
+}
+
+func TestForgetListing_DropsNotFound(t *testing.T) {
+	// SCENARIO: A listing was cached as missing under both forms of its ID, then it's created or edited.
+	// EXPECT: Both entries go, the next read comes from the database.
+
+	service, mockPool, rdb := newHydrateTest(t)
+	ctx := context.Background()
+	id := fixtures.UUID(fixtures.ListingID)
+	for _, key := range []string{fixtures.ListingID, "550e8400e29b41d4a716446655440000"} {
+		require.NoError(t, cache.Set(rdb, ctx, service.listingCache.Key(key), notFoundEntry{NotFound: true}, NotFoundCacheTTL))
+	}
+
+	service.forgetListing(ctx, id)
+
+	expectListingRead(mockPool, fixtures.NewListingRow())
+	listing, err := service.GetListingByID(ctx, "550e8400e29b41d4a716446655440000")
+	require.NoError(t, err)
+	assert.Equal(t, "550e8400e29b41d4a716446655440000", listing.ID)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestGetListingsByIDs_SkipsNotFound(t *testing.T) {
+	// SCENARIO: A page of listings where one was cached as missing and one is cached.
+	// EXPECT: The missing one is left out without a query, the other is served from the cache.
+
+	service, mockPool, rdb := newHydrateTest(t)
+	ctx := context.Background()
+	const missing = "00000000-0000-0000-0000-000000000001"
+	require.NoError(t, cache.Set(rdb, ctx, service.listingCache.Key(missing), notFoundEntry{NotFound: true}, NotFoundCacheTTL))
+	require.NoError(t, cache.Set(rdb, ctx, service.listingCache.Key(fixtures.ListingID), ListingResponse{ID: "550e8400e29b41d4a716446655440000", Title: "Benchy"}, time.Minute))
+
+	listings, err := service.GetListingsByIDs(ctx, []string{missing, fixtures.ListingID})
+
+	require.NoError(t, err)
+	require.Len(t, listings, 1)
+	assert.Equal(t, "Benchy", listings[0].Title)
 	assert.NoError(t, mockPool.ExpectationsWereMet())
 }
+
+func TestHydrateListing_NotFoundEntry(t *testing.T) {
+	// SCENARIO: A search hit is opened for a listing cached as missing.
+	// EXPECT: The marker is never served as a listing, the hit gets the 404 GET /listings/{id} would without a
+	// database read. Creating the listing drops the marker, so only a listing that is gone gets here.
+
+	service, mockPool, rdb := newHydrateTest(t)
+	require.NoError(t, cache.Set(rdb, context.Background(), service.listingCache.Key(fixtures.ListingID), notFoundEntry{NotFound: true}, NotFoundCacheTTL))
+
+	_, err := service.HydrateListing(context.Background(), &HydrateListingRequest{ID: fixtures.ListingID, ContentHash: fixtureContentHash})
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrNotFound, appErr.Code)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func BenchmarkCreateListing(b *testing.B) {
+	// SCENARIO: A seller creates listings with 25 files each, against a database that takes roundTrip to answer
+	// every statement, as a Postgres on the network would.
+	// EXPECT: Nothing beyond success, this shows how many round trips a create costs. With the legacy per-file
+	// validation events there's an event per file as well as the created event.
+	//
+	//	go test -run '^$' -bench CreateListing ./internal/handlers/listings/
+	//
+	// The times are synthetic, roundTrip stands in for the database, so they compare trees by their round trips
+	// rather than measure a create. BenchmarkIntegration_CreateListing in cmd times the same against a real Postgres
+	// and NATS.
+
+	const (
+		roundTrip       = 500 * time.Microsecond
+		filesPerListing = 25
+		sellerID        = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+		listingID       = "11111111-1111-1111-1111-111111111111"
+	)
+
+	files := make([]CreateListingFile, filesPerListing)
+	paths := make([]string, filesPerListing)
+	for i := range files {
+		files[i] = CreateListingFile{Type: "model", Path: fmt.Sprintf("2025/01/01/%s/%s/model/part-%02d.stl", sellerID, listingID, i), Size: 1024}
+		if i == 0 {
+			files[i] = CreateListingFile{Type: "image", Path: fmt.Sprintf("2025/01/01/%s/%s/image/cover.jpg", sellerID, listingID), Size: 500}
+		}
+		paths[i] = files[i].Path
+	}
+	req := &CreateListingRequest{
+		Title:        "Valid Listing",
+		Description:  "A great item for the whole family",
+		PriceMinUnit: 1050,
+		Currency:     "gbp",
+		Categories:   []string{"Art"},
+		License:      "MIT",
+		Files:        files,
+	}
+	userInfo := auth.UserInfo{ID: sellerID, Username: "tester", AuthorizedParty: "Go-Test"}
+
+	for _, legacy := range []bool{false, true} {
+		b.Run(fmt.Sprintf("legacy_file_events=%t", legacy), func(b *testing.B) {
+			mockPool, err := pgxmock.NewPool()
+			require.NoError(b, err)
+			defer mockPool.Close()
+
+			rdb, _ := apitest.NewRedis(b)
+			logger := testutil.NewTestLogger()
+			eventConfig := events.EventConfig{
+				StartListingValidation: "file.listing.start",
+				LegacyFileValidation:   legacy,
+				StartImageValidation:   "file.image.start",
+				StartModelValidation:   "file.model.start",
+				ListingCreated:         "listings.created",
+			}
+			service := &svc{
+				repo:         repo.New(mockPool),
+				db:           mockPool,
+				logger:       logger,
+				eventHandler: events.NewEventHandler(nil, &eventConfig, logger),
+				cache:        rdb,
+				termsVersion: "1",
+			}
+
+			for b.Loop() {
+				b.StopTimer()
+				mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT user_id, display_name`)).
+					WithArgs(anyArgs(1)...).
+					WillReturnRows(pgxmock.NewRows(testutil.SellerCols).AddRow(
+						sellerID, "Tester Prints", "GB", "NOT_STARTED", "1", time.Now(), time.Now(), time.Now(), nil, nil, nil, false, nil,
+					)).
+					WillDelayFor(roundTrip)
+				mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetUsedFilePaths :many`)).
+					WithArgs(anyArgs(1)...).
+					WillReturnRows(pgxmock.NewRows([]string{"file_path"})).
+					WillDelayFor(roundTrip)
+				mockPool.ExpectBegin().WillDelayFor(roundTrip)
+				mockPool.ExpectQuery(regexp.QuoteMeta(`INSERT INTO listings`)).
+					WithArgs(anyArgs(29)...).
+					WillReturnRows(createdListingRows(listingID, sellerID)).
+					WillDelayFor(roundTrip)
+				mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO listing_status_events`)).
+					WithArgs(anyArgs(8)...).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1)).
+					WillDelayFor(roundTrip)
+				rows := pgxmock.NewRows(testutil.ListingFileCols)
+				for i, f := range files {
+					rows.AddRow(
+						fmt.Sprintf("22222222-2222-2222-2222-%012d", i), listingID, f.Path, repo.FileType(strings.ToUpper(f.Type)),
+						f.Size, nil, "PENDING", nil, false, nil, time.Now(), time.Now(), nil,
+					)
+				}
+				mockPool.ExpectQuery(regexp.QuoteMeta(`INSERT INTO listing_files`)).
+					WithArgs(anyArgs(6)...).
+					WillReturnRows(rows).
+					WillDelayFor(roundTrip)
+				mockPool.ExpectExec(regexp.QuoteMeta(`UPDATE upload_callbacks`)).
+					WithArgs(anyArgs(1)...).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0)).
+					WillDelayFor(roundTrip)
+				mockPool.ExpectExec(regexp.QuoteMeta(`-- name: InsertOutboxEvents :exec`)).
+					WithArgs(anyArgs(3)...).
+					WillReturnResult(pgxmock.NewResult("INSERT", 2)).
+					WillDelayFor(roundTrip)
+				mockPool.ExpectCommit().WillDelayFor(roundTrip)
+				b.StartTimer()
+
+				if _, err := service.CreateListing(context.Background(), userInfo, req); err != nil {
+					b.Fatal(err)
+				}
+			}
+			require.NoError(b, mockPool.ExpectationsWereMet())
+		})
+	}
+}
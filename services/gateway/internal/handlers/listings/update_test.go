@@ -0,0 +1,273 @@
+package listings
+
+import (
+	"context"
+	"gateway/internal/errors"
+	"gateway/internal/testutil"
+	"gateway/internal/testutil/fixtures"
+	"regexp"
+	"testing"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	updateListingID = "11111111-1111-1111-1111-111111111111"
+	updateSellerID  = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+)
+
+func newUpdateTest(t *testing.T) (*svc, pgxmock.PgxPoolIface) {
+	t.Helper()
+
+	mockPool := testutil.NewMockDB(t)
+	return &svc{
+		repo:   repo.New(mockPool),
+		db:     mockPool,
+		logger: testutil.NewTestLogger(),
+	}, mockPool
+}
+
+// listingRows is the locked listing as the row currently stands
+func listingRows(sellerID, title, thumbnail string, status repo.ListingStatus) *pgxmock.Rows {
+	return pricedListingRows(sellerID, title, thumbnail, status, 1050, "gbp")
+}
+
+func pricedListingRows(sellerID, title, thumbnail string, status repo.ListingStatus, price int64, currency string) *pgxmock.Rows {
+	return fixtures.ListingRows(fixtures.NewListing(
+		fixtures.WithID(updateListingID), fixtures.WithSeller(sellerID), fixtures.WithTitle(title),
+		fixtures.WithThumbnail(thumbnail), fixtures.WithStatus(status), fixtures.WithPrice(price, currency),
+	))
+}
+
+// updateArgs expects the UPDATE to write title, thumbnail and status, anything for the rest
+func updateArgs(title, thumbnail string, status repo.ListingStatus) []any {
+	args := anyArgs(25)
+	args[1] = title
+	args[7] = pgtype.Text{String: thumbnail, Valid: true}
+	args[8] = repo.NullListingStatus{ListingStatus: status, Valid: true}
+	return args
+}
+
+func TestSaveListingUpdate_MetadataDuringValidation(t *testing.T) {
+	// SCENARIO: The seller fixes a typo in the title while the files are still PENDING validation.
+	// EXPECT: The edit goes through under the row lock and keeps the status it read there.
+
+	service, mockPool := newUpdateTest(t)
+	title := "Fixed Benchy"
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).
+		WithArgs(mustUUID(t, updateListingID)).
+		WillReturnRows(listingRows(updateSellerID, "Fixd Benchy", "draft/thumb.png", repo.ListingStatusPENDINGVALIDATION))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`UPDATE listings SET`)).
+		WithArgs(updateArgs(title, "draft/thumb.png", repo.ListingStatusPENDINGVALIDATION)...).
+		WillReturnRows(listingRows(updateSellerID, title, "draft/thumb.png", repo.ListingStatusPENDINGVALIDATION))
+	mockPool.ExpectCommit()
+
+	listing, err := service.saveListingUpdate(context.Background(), mustUUID(t, updateSellerID), mustUUID(t, updateListingID), (&UpdateListingRequest{Title: &title}).Patch(), DefaultValidationRules())
+
+	require.NoError(t, err)
+	assert.Equal(t, title, listing.Title)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestSaveListingUpdate_ValidationCommittedFirst(t *testing.T) {
+	// SCENARIO: The validation worker activated the listing and swapped in the WebP thumbnail while the edit waited for the lock.
+	// EXPECT: The edit writes back what it read under the lock, so the listing stays ACTIVE with the new thumbnail.
+
+	service, mockPool := newUpdateTest(t)
+	title := "Fixed Benchy"
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).
+		WithArgs(mustUUID(t, updateListingID)).
+		WillReturnRows(listingRows(updateSellerID, "Fixd Benchy", "public/thumb.webp", repo.ListingStatusACTIVE))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`UPDATE listings SET`)).
+		WithArgs(updateArgs(title, "public/thumb.webp", repo.ListingStatusACTIVE)...).
+		WillReturnRows(listingRows(updateSellerID, title, "public/thumb.webp", repo.ListingStatusACTIVE))
+	mockPool.ExpectCommit()
+
+	_, err := service.saveListingUpdate(context.Background(), mustUUID(t, updateSellerID), mustUUID(t, updateListingID), (&UpdateListingRequest{Title: &title}).Patch(), DefaultValidationRules())
+
+	require.NoError(t, err)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestSaveListingUpdate_RedetectsLanguage(t *testing.T) {
+	// SCENARIO: A seller rewrites their English description in German.
+	// EXPECT: The UPDATE writes the language detected from the new text, not the one the row had.
+
+	service, mockPool := newUpdateTest(t)
+	description := "Eine einfache Wandhalterung für Kopfhörer und Kabel, die ohne Stützstrukturen gedruckt wird."
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).
+		WithArgs(mustUUID(t, updateListingID)).
+		WillReturnRows(listingRows(updateSellerID, "Kopfhörerhalter", "public/thumb.webp", repo.ListingStatusACTIVE))
+	args := updateArgs("Kopfhörerhalter", "public/thumb.webp", repo.ListingStatusACTIVE)
+	args[22] = "de" // language
+	mockPool.ExpectQuery(regexp.QuoteMeta(`UPDATE listings SET`)).
+		WithArgs(args...).
+		WillReturnRows(listingRows(updateSellerID, "Kopfhörerhalter", "public/thumb.webp", repo.ListingStatusACTIVE))
+	mockPool.ExpectCommit()
+
+	_, err := service.saveListingUpdate(context.Background(), mustUUID(t, updateSellerID), mustUUID(t, updateListingID), (&UpdateListingRequest{Description: &description}).Patch(), DefaultValidationRules())
+
+	require.NoError(t, err)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestSaveListingUpdate_PriceHistory(t *testing.T) {
+	title := "Benchy"
+
+	tests := []struct {
+		name         string
+		req          UpdateListingRequest
+		wantPrice    int64
+		wantCurrency string
+		wantHistory  bool
+	}{
+		{name: "Price dropped", req: UpdateListingRequest{PriceMinUnit: ptr(int64(900))}, wantPrice: 900, wantCurrency: "gbp", wantHistory: true},
+		{name: "Price raised", req: UpdateListingRequest{PriceMinUnit: ptr(int64(1200))}, wantPrice: 1200, wantCurrency: "gbp", wantHistory: true},
+		{name: "Currency changed", req: UpdateListingRequest{Currency: ptr("eur")}, wantPrice: 1050, wantCurrency: "eur", wantHistory: true},
+		{name: "Title only", req: UpdateListingRequest{Title: &title}, wantPrice: 1050, wantCurrency: "gbp"},
+		{name: "Same price sent again", req: UpdateListingRequest{PriceMinUnit: ptr(int64(1050)), Currency: ptr("gbp")}, wantPrice: 1050, wantCurrency: "gbp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// SCENARIO: The seller saves an edit to an ACTIVE listing priced at 10.50 GBP.
+			// EXPECT: A history row is written inside the update's transaction only when the price or currency changed.
+
+			service, mockPool := newUpdateTest(t)
+
+			args := updateArgs(title, "public/thumb.webp", repo.ListingStatusACTIVE)
+			args[3] = tt.wantPrice
+			args[4] = tt.wantCurrency
+
+			mockPool.ExpectBegin()
+			mockPool.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).
+				WithArgs(mustUUID(t, updateListingID)).
+				WillReturnRows(listingRows(updateSellerID, title, "public/thumb.webp", repo.ListingStatusACTIVE))
+			mockPool.ExpectQuery(regexp.QuoteMeta(`UPDATE listings SET`)).
+				WithArgs(args...).
+				WillReturnRows(pricedListingRows(updateSellerID, title, "public/thumb.webp", repo.ListingStatusACTIVE, tt.wantPrice, tt.wantCurrency))
+			if tt.wantHistory {
+				mockPool.ExpectExec(regexp.QuoteMeta(`-- name: CreateListingPriceChange :exec`)).
+					WithArgs(mustUUID(t, updateListingID), int64(1050), tt.wantPrice, "gbp", tt.wantCurrency).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			}
+			mockPool.ExpectCommit()
+
+			_, err := service.saveListingUpdate(context.Background(), mustUUID(t, updateSellerID), mustUUID(t, updateListingID), tt.req.Patch(), DefaultValidationRules())
+
+			require.NoError(t, err)
+			assert.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSaveListingUpdate_PriceHistoryFailed(t *testing.T) {
+	// SCENARIO: The price change can't be recorded.
+	// EXPECT: The whole update is rolled back so the price never changes without its history.
+
+	service, mockPool := newUpdateTest(t)
+	price := int64(900)
+
+	args := updateArgs("Benchy", "public/thumb.webp", repo.ListingStatusACTIVE)
+	args[3] = price
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).
+		WithArgs(mustUUID(t, updateListingID)).
+		WillReturnRows(listingRows(updateSellerID, "Benchy", "public/thumb.webp", repo.ListingStatusACTIVE))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`UPDATE listings SET`)).
+		WithArgs(args...).
+		WillReturnRows(pricedListingRows(updateSellerID, "Benchy", "public/thumb.webp", repo.ListingStatusACTIVE, price, "gbp"))
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: CreateListingPriceChange :exec`)).
+		WithArgs(anyArgs(5)...).
+		WillReturnError(assert.AnError)
+	mockPool.ExpectRollback()
+
+	_, err := service.saveListingUpdate(context.Background(), mustUUID(t, updateSellerID), mustUUID(t, updateListingID), (&UpdateListingRequest{PriceMinUnit: &price}).Patch(), DefaultValidationRules())
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrInternal, appErr.Code)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestSaveListingUpdate_Refused(t *testing.T) {
+	const otherSellerID = "c0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	title := "Fixed Benchy"
+	files := []CreateListingFile{{Type: "model", Path: "2025/01/01/" + updateSellerID + "/draft/model/v2.stl", Size: 1024}}
+
+	tests := []struct {
+		name       string
+		rows       *pgxmock.Rows
+		req        UpdateListingRequest
+		wantCode   errors.ErrorCode
+		wantReason errors.Reason
+	}{
+		{
+			name:       "Files changed during validation",
+			rows:       listingRows(updateSellerID, title, "draft/thumb.png", repo.ListingStatusPENDINGVALIDATION),
+			req:        UpdateListingRequest{Title: &title, Files: files},
+			wantCode:   errors.ErrConflict,
+			wantReason: errors.ReasonListingValidating,
+		},
+		{
+			name:       "Files emptied during validation",
+			rows:       listingRows(updateSellerID, title, "draft/thumb.png", repo.ListingStatusPENDINGVALIDATION),
+			req:        UpdateListingRequest{Files: []CreateListingFile{}},
+			wantCode:   errors.ErrConflict,
+			wantReason: errors.ReasonListingValidating,
+		},
+		{
+			name:     "Files changed after validation",
+			rows:     listingRows(updateSellerID, title, "public/thumb.webp", repo.ListingStatusACTIVE),
+			req:      UpdateListingRequest{Files: files},
+			wantCode: errors.ErrInvalidInput,
+		},
+		{
+			// Soft deleted between the seller loading the page and saving
+			name:     "Deleted",
+			rows:     pgxmock.NewRows(testutil.ListingsCols),
+			req:      UpdateListingRequest{Title: &title},
+			wantCode: errors.ErrNotFound,
+		},
+		{
+			name:       "Not owner",
+			rows:       listingRows(otherSellerID, title, "public/thumb.webp", repo.ListingStatusACTIVE),
+			req:        UpdateListingRequest{Title: &title},
+			wantCode:   errors.ErrUnauthorized,
+			wantReason: errors.ReasonListingNotOwner,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockPool := newUpdateTest(t)
+
+			// Nothing is written, the lock is released by the rollback
+			mockPool.ExpectBegin()
+			mockPool.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).
+				WithArgs(mustUUID(t, updateListingID)).
+				WillReturnRows(tt.rows)
+			mockPool.ExpectRollback()
+
+			_, err := service.saveListingUpdate(context.Background(), mustUUID(t, updateSellerID), mustUUID(t, updateListingID), tt.req.Patch(), DefaultValidationRules())
+
+			var appErr *errors.AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, tt.wantCode, appErr.Code)
+			assert.Equal(t, tt.wantReason, appErr.Reason)
+			assert.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package listings
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// vacationDateLayout is how the end of a vacation is shown in the downloads error
+const vacationDateLayout = "2 January 2006"
+
+// onVacation is true between a booked vacation's start and end
+func onVacation(startsAt, endsAt pgtype.Timestamptz, now time.Time) bool {
+	return startsAt.Valid && endsAt.Valid && !startsAt.Time.After(now) && endsAt.Time.After(now)
+}
+
+// applyVacation flags the response when the seller is away and returns how long it may be cached for. That is never
+// past the vacation's next start or end, so a scheduled change shows up without anything having to bust the cache.
+// Zero means don't cache, the seller couldn't be looked up.
+func (s *svc) applyVacation(ctx context.Context, sellerID pgtype.UUID, response *ListingResponse) time.Duration {
+	vacation, err := s.repo.GetSellerVacation(ctx, sellerID)
+	if err == pgx.ErrNoRows {
+		return ListingCacheTTL
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to fetch seller vacation", "seller_id", sellerID.String(), "error", err)
+		return 0
+	}
+	if !vacation.VacationStartsAt.Valid || !vacation.VacationEndsAt.Valid {
+		return ListingCacheTTL
+	}
+
+	now := s.now()
+	next := vacation.VacationStartsAt.Time
+	if onVacation(vacation.VacationStartsAt, vacation.VacationEndsAt, now) {
+		response.TemporarilyUnavailable = true
+		response.UnavailableUntil = utcPtr(vacation.VacationEndsAt)
+		if vacation.VacationMessage.Valid {
+			response.SellerAwayMessage = &vacation.VacationMessage.String
+		}
+		next = vacation.VacationEndsAt.Time
+	}
+
+	if untilNext := next.Sub(now); untilNext > 0 && untilNext < ListingCacheTTL {
+		return untilNext
+	}
+	return ListingCacheTTL
+}
+
+// checkDownloadAllowed refuses paid downloads to anonymous callers, and to everyone but the seller while the seller is
+// away. Free files can be downloaded without an account, a file is free when a free variant includes it or the listing
+// is free and has no variants.
+func (s *svc) checkDownloadAllowed(ctx context.Context, userInfo auth.UserInfo, listingID, fileID pgtype.UUID) error {
+	listing, err := s.repo.GetListingAvailability(ctx, listingID)
+	if err == pgx.ErrNoRows {
+		return errors.New(errors.ErrNotFound, "File not found", fmt.Errorf("listing %v not found", listingID.String()))
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to check listing availability", "listing_id", listingID.String(), "error", err)
+		return errors.New(errors.ErrInternal, "Failed to fetch file", fmt.Errorf("failed to check availability of %v: %w", listingID.String(), err))
+	}
+
+	price, err := s.filePrice(ctx, fileID, listing.PriceMinUnit)
+	if err != nil {
+		return err
+	}
+	if price == 0 {
+		return nil
+	}
+	if userInfo.ID == "" {
+		return errors.New(errors.ErrUnauthorized, "Sign in to download paid files", nil).WithReason(errors.ReasonAuthRequired)
+	}
+	if listing.SellerID.String() == userInfo.ID || !onVacation(listing.VacationStartsAt, listing.VacationEndsAt, s.now()) {
+		return nil
+	}
+
+	until := listing.VacationEndsAt.Time.UTC().Format(vacationDateLayout)
+	return errors.New(errors.ErrConflict, fmt.Sprintf("This seller is away until %s, paid downloads will be back when they are", until), nil).
+		WithReason(errors.ReasonSellerOnVacation).
+		WithParam("until", until)
+}
@@ -0,0 +1,166 @@
+package listings
+
+import (
+	"context"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/mocks/mockstorage"
+	"gateway/internal/testutil"
+	"regexp"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/storage"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const awaySellerID = "b0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+
+var (
+	availabilityCols = []string{"seller_id", "price_min_unit", "vacation_starts_at", "vacation_ends_at"}
+	vacationCols     = []string{"vacation_starts_at", "vacation_ends_at", "vacation_message"}
+)
+
+func TestApplyVacation(t *testing.T) {
+	// SCENARIO: A listing is read while its seller is away, before they go, or with no vacation booked.
+	// EXPECT: Only a seller who is away flags the listing, and the response is never cached past the next start or end.
+
+	now := time.Date(2026, 8, 1, 12, 0, 0, 0, time.UTC)
+	message := "Back after the summer"
+
+	tests := map[string]struct {
+		rows        *pgxmock.Rows
+		wantAway    bool
+		wantMessage *string
+		wantTTL     time.Duration
+	}{
+		"away": {
+			rows:        pgxmock.NewRows(vacationCols).AddRow(now.Add(-time.Hour), now.Add(10*time.Minute), message),
+			wantAway:    true,
+			wantMessage: &message,
+			wantTTL:     10 * time.Minute,
+		},
+		"away for longer than the cache": {
+			rows:     pgxmock.NewRows(vacationCols).AddRow(now.Add(-time.Hour), now.Add(30*24*time.Hour), nil),
+			wantAway: true,
+			wantTTL:  ListingCacheTTL,
+		},
+		"going away soon": {
+			rows:    pgxmock.NewRows(vacationCols).AddRow(now.Add(5*time.Minute), now.Add(24*time.Hour), nil),
+			wantTTL: 5 * time.Minute,
+		},
+		"no vacation": {
+			rows:    pgxmock.NewRows(vacationCols).AddRow(nil, nil, nil),
+			wantTTL: ListingCacheTTL,
+		},
+		"no seller profile": {
+			rows:    pgxmock.NewRows(vacationCols),
+			wantTTL: ListingCacheTTL,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockPool := testutil.NewMockDB(t)
+			service := &svc{
+				repo:   repo.New(mockPool),
+				db:     mockPool,
+				logger: testutil.NewTestLogger(),
+				now:    func() time.Time { return now },
+			}
+			mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetSellerVacation`)).WithArgs(pgxmock.AnyArg()).WillReturnRows(tt.rows)
+
+			var sellerID pgtype.UUID
+			require.NoError(t, sellerID.Scan(awaySellerID))
+			response := ListingResponse{}
+			ttl := service.applyVacation(context.Background(), sellerID, &response)
+
+			assert.Equal(t, tt.wantTTL, ttl)
+			assert.Equal(t, tt.wantAway, response.TemporarilyUnavailable)
+			assert.Equal(t, tt.wantAway, response.UnavailableUntil != nil)
+			assert.Equal(t, tt.wantMessage, response.SellerAwayMessage)
+			assert.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetFileDownload_SellerOnVacation(t *testing.T) {
+	// SCENARIO: A model is downloaded while the listing's seller is away.
+	// EXPECT: Buyers of paid listings are turned away until the seller is back, free listings and the seller themselves aren't.
+
+	const (
+		buyerID   = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+		listingID = "11111111-1111-1111-1111-111111111111"
+		fileID    = "22222222-2222-2222-2222-222222222222"
+	)
+	now := time.Date(2026, 8, 1, 12, 0, 0, 0, time.UTC)
+	startsAt, endsAt := now.Add(-time.Hour), time.Date(2026, 8, 20, 9, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		caller    string
+		price     int64
+		startsAt  any
+		endsAt    any
+		wantError bool
+	}{
+		"paid listing, seller away":   {caller: buyerID, price: 500, startsAt: startsAt, endsAt: endsAt, wantError: true},
+		"free listing, seller away":   {caller: buyerID, price: 0, startsAt: startsAt, endsAt: endsAt},
+		"free listing, anonymous":     {caller: "", price: 0, startsAt: startsAt, endsAt: endsAt},
+		"seller downloads their own":  {caller: awaySellerID, price: 500, startsAt: startsAt, endsAt: endsAt},
+		"paid listing, vacation soon": {caller: buyerID, price: 500, startsAt: now.Add(time.Hour), endsAt: endsAt},
+		"paid listing, no vacation":   {caller: buyerID, price: 500},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockPool := testutil.NewMockDB(t)
+			store := mockstorage.NewProvider(t)
+			service := &svc{
+				repo:      repo.New(mockPool),
+				db:        mockPool,
+				logger:    testutil.NewTestLogger(),
+				storage:   store,
+				downloads: DefaultDownloadConfig(),
+				now:       func() time.Time { return now },
+			}
+
+			mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingFileForDownload`)).
+				WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+				WillReturnRows(pgxmock.NewRows(testutil.ListingFileCols).AddRow(
+					fileID, listingID, "models/benchy.stl", repo.FileTypeMODEL, int64(1024),
+					[]byte("{}"), "VALID", nil, false, nil,
+					time.Now(), time.Now(), nil,
+				))
+			mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingAvailability`)).
+				WithArgs(pgxmock.AnyArg()).
+				WillReturnRows(pgxmock.NewRows(availabilityCols).AddRow(awaySellerID, tt.price, tt.startsAt, tt.endsAt))
+			mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetFileVariantPrice`)).
+				WithArgs(pgxmock.AnyArg()).
+				WillReturnError(pgx.ErrNoRows)
+			if !tt.wantError {
+				store.EXPECT().PresignGet(mock.Anything, storage.BucketProduct, "models/benchy.stl", mock.Anything).Return("http://minio/signed", nil)
+			}
+
+			download, err := service.GetFileDownload(context.Background(), auth.UserInfo{ID: tt.caller}, listingID, fileID, false)
+
+			if tt.wantError {
+				var appErr *errors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, errors.ErrConflict, appErr.Code)
+				assert.Equal(t, errors.ReasonSellerOnVacation, appErr.Reason)
+				assert.Equal(t, "20 August 2026", appErr.Params["until"])
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "http://minio/signed", download.URL)
+			}
+			assert.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package listings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/errors"
+	"gateway/internal/events"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// FileValidationResult is the body of POST /internal/files/{id}/validation-result, what the validation worker found
+// once it has checked a file. TestFileValidationResult_Contract pins down the payload the worker sends.
+type FileValidationResult struct {
+	Status       string          `json:"status"`                  // VALID, INVALID or FAILED
+	ErrorMessage *string         `json:"error_message,omitempty"` // Why the file was rejected, or a warning about a VALID one
+	Metadata     json.RawMessage `json:"metadata,omitempty"`      // Parsed into FileMetadata, left out to keep what the file has
+}
+
+// FileValidationResultResponse tells the worker what its result did
+type FileValidationResultResponse struct {
+	FileID        string `json:"file_id"`
+	ListingID     string `json:"listing_id"`
+	Status        string `json:"status"`         // The file's status
+	ListingStatus string `json:"listing_status"` // The listing's status after the roll-up
+	// False when the file already had this result. Re-posting a result is safe and changes nothing.
+	Applied bool `json:"applied"`
+}
+
+// resultStatuses are the results a file can be given. Each is final, PENDING is only ever the status a file starts in.
+var resultStatuses = map[string]repo.FileStatus{
+	string(repo.FileStatusVALID):   repo.FileStatusVALID,
+	string(repo.FileStatusINVALID): repo.FileStatusINVALID,
+	string(repo.FileStatusFAILED):  repo.FileStatusFAILED,
+}
+
+// validatingStatuses are the only ones a validation result moves a listing out of. Anything else was decided since
+// the file was queued, e.g. the seller unpublished it or a moderator hid it, and isn't overwritten by a late result.
+var validatingStatuses = map[repo.ListingStatus]bool{
+	repo.ListingStatusPENDINGVALIDATION: true,
+	repo.ListingStatusPENDINGREVIEW:     true,
+}
+
+const validationPassedReason = "All files passed validation"
+
+// fileResult is a FileValidationResult checked and ready to store
+type fileResult struct {
+	status       repo.FileStatus
+	errorMessage pgtype.Text
+	metadata     []byte // Normalized FileMetadata, nil keeps what the file has
+}
+
+func (r *FileValidationResult) validate() (fileResult, *errors.AppError) {
+	status, ok := resultStatuses[r.Status]
+	if !ok {
+		return fileResult{}, errors.New(errors.ErrInvalidInput, "status must be VALID, INVALID or FAILED", nil).
+			WithReason(errors.ReasonFileResultStatus)
+	}
+	result := fileResult{status: status}
+	if r.ErrorMessage != nil && *r.ErrorMessage != "" {
+		result.errorMessage = pgtype.Text{String: *r.ErrorMessage, Valid: true}
+	}
+
+	// Stored the way the listing response reads it back, so keys from older workers are renamed once here
+	meta, _, err := ParseFileMetadata(r.Metadata)
+	if err != nil {
+		return fileResult{}, errors.New(errors.ErrInvalidInput, "metadata must be a JSON object", err).
+			WithReason(errors.ReasonFileResultMetadata).
+			WithParam("max", strconv.Itoa(MaxFileMetadataBytes))
+	}
+	if meta != nil {
+		if result.metadata, err = json.Marshal(meta); err != nil {
+			return fileResult{}, errors.New(errors.ErrInternal, "Failed to store metadata", err)
+		}
+	}
+	return result, nil
+}
+
+// ApplyValidationResult records the validation worker's result for a file and, once the listing has no files left
+// to check, moves the listing out of validation. The listing is then reindexed and its cached response dropped.
+func (s *svc) ApplyValidationResult(ctx context.Context, fileID string, req *FileValidationResult) (*FileValidationResultResponse, error) {
+	var fileUUID pgtype.UUID
+	if err := fileUUID.Scan(fileID); err != nil {
+		return nil, errors.New(errors.ErrInvalidInput, "Invalid file ID provided", err)
+	}
+	result, appErr := req.validate()
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	response, settled, err := s.applyValidationResult(ctx, fileUUID, result)
+	if err != nil {
+		return nil, err
+	}
+	if !response.Applied {
+		s.logger.InfoContext(ctx, "Validation result already applied", "file_id", response.FileID, "status", response.Status)
+		return response, nil
+	}
+	s.logger.InfoContext(ctx, "Validation result applied",
+		"file_id", response.FileID,
+		"listing_id", response.ListingID,
+		"status", response.Status,
+		"listing_status", response.ListingStatus,
+	)
+
+	// The response carries the dashed ID, the cache and the search document know the listing by both forms
+	var listingUUID pgtype.UUID
+	if err := listingUUID.Scan(response.ListingID); err != nil {
+		return nil, errors.New(errors.ErrInternal, "Failed to apply validation result", fmt.Errorf("invalid listing uuid %q: %w", response.ListingID, err))
+	}
+	listingID := fmt.Sprintf("%x", listingUUID.Bytes)
+
+	// The listing response shows every file's status
+	s.forgetListing(ctx, listingUUID)
+
+	// Only once every file has a result, the worker would otherwise index a listing that's still being checked
+	if settled {
+		traceID := ""
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+			traceID = spanContext.TraceID().String()
+		}
+		if err := s.eventHandler.RaiseListingIndexEvent(events.ReIndexListingEvent{ListingID: listingID, TraceID: traceID}); err != nil {
+			// Non-critical, the stale sweep picks the listing up on its next run
+			s.logger.ErrorContext(ctx, "Failed to raise listing re-index event", "listing_id", listingID, "error", err)
+		}
+	}
+	return response, nil
+}
+
+// applyValidationResult stores the result and rolls the listing's status up in one transaction. settled is true when
+// none of the listing's files are waiting for a result any more.
+func (s *svc) applyValidationResult(ctx context.Context, fileID pgtype.UUID, result fileResult) (*FileValidationResultResponse, bool, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, false, errors.New(errors.ErrInternal, "Failed to start transaction. Please try again later.", fmt.Errorf("failed to begin transaction: %w", err))
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.repo.WithTx(tx)
+
+	notFound := errors.New(errors.ErrNotFound, "File not found", fmt.Errorf("file %s not found", fileID.String())).WithReason(errors.ReasonFileNotFound)
+	listing, err := qtx.GetListingForFileForUpdate(ctx, fileID)
+	if err == pgx.ErrNoRows {
+		return nil, false, notFound
+	}
+	if err != nil {
+		return nil, false, errors.New(errors.ErrInternal, "Failed to apply validation result", fmt.Errorf("failed to lock listing: %w", err))
+	}
+	file, err := qtx.GetListingFileForUpdate(ctx, fileID)
+	if err == pgx.ErrNoRows {
+		// Removed from the listing while it was being checked
+		return nil, false, notFound
+	}
+	if err != nil {
+		return nil, false, errors.New(errors.ErrInternal, "Failed to apply validation result", fmt.Errorf("failed to lock file: %w", err))
+	}
+
+	response := &FileValidationResultResponse{
+		FileID:        fileID.String(),
+		ListingID:     listing.ID.String(),
+		Status:        string(result.status),
+		ListingStatus: string(listing.Status.ListingStatus),
+	}
+
+	if current := file.Status.FileStatus; file.Status.Valid && current != repo.FileStatusPENDING {
+		if current == result.status && file.ErrorMessage == result.errorMessage {
+			// A retry of a result we already have, e.g. the worker's response got lost
+			return response, false, nil
+		}
+		return nil, false, errors.New(errors.ErrConflict, fmt.Sprintf("File was already validated as %s", current), nil).
+			WithReason(errors.ReasonFileResultFinal).
+			WithParam("status", string(current))
+	}
+
+	if err := qtx.SetFileValidationResult(ctx, repo.SetFileValidationResultParams{
+		ID:           fileID,
+		Status:       repo.NullFileStatus{FileStatus: result.status, Valid: true},
+		ErrorMessage: result.errorMessage,
+		Metadata:     result.metadata,
+	}); err != nil {
+		return nil, false, errors.New(errors.ErrInternal, "Failed to apply validation result", fmt.Errorf("failed to update file: %w", err))
+	}
+	response.Applied = true
+
+	to, settled, err := rollUpListingStatus(ctx, qtx, listing)
+	if err != nil {
+		return nil, false, errors.New(errors.ErrInternal, "Failed to apply validation result", err)
+	}
+	response.ListingStatus = string(to)
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, errors.New(errors.ErrInternal, "Failed to apply validation result", fmt.Errorf("failed to commit validation result: %w", err))
+	}
+	return response, settled, nil
+}
+
+// rollUpListingStatus moves a listing that's being validated on once all of its files have a result: REJECTED if any
+// of them didn't pass, otherwise ACTIVE. Listings flagged at creation stay in PENDING_REVIEW until a moderator lets
+// them through. Returns the listing's status and whether all of its files have a result.
+func rollUpListingStatus(ctx context.Context, qtx *repo.Queries, listing repo.GetListingForFileForUpdateRow) (repo.ListingStatus, bool, error) {
+	from := listing.Status.ListingStatus
+
+	counts, err := qtx.CountListingFileStatuses(ctx, listing.ID)
+	if err != nil {
+		return from, false, fmt.Errorf("failed to count file statuses: %w", err)
+	}
+	if counts.Pending > 0 {
+		return from, false, nil
+	}
+	// The file results still count, but the listing's status is no longer ours to change
+	if listing.DeletedAt.Valid || !validatingStatuses[from] {
+		return from, true, nil
+	}
+
+	to, reason := repo.ListingStatusACTIVE, validationPassedReason
+	if counts.Failed > 0 {
+		to, reason = repo.ListingStatusREJECTED, fmt.Sprintf("%d file(s) failed validation", counts.Failed)
+		if counts.FirstError.Valid {
+			reason += ": " + counts.FirstError.String
+		}
+	} else if from == repo.ListingStatusPENDINGREVIEW {
+		return from, true, nil
+	}
+
+	if err := qtx.SetListingStatus(ctx, repo.SetListingStatusParams{
+		ID:     listing.ID,
+		Status: repo.NullListingStatus{ListingStatus: to, Valid: true},
+	}); err != nil {
+		return from, false, fmt.Errorf("failed to update listing status: %w", err)
+	}
+	if err := recordStatusChange(ctx, qtx, statusChange{
+		ListingID: listing.ID,
+		Actor:     repo.ListingStatusActorSYSTEM,
+		From:      &from,
+		To:        to,
+		Reason:    reason,
+	}); err != nil {
+		return from, false, err
+	}
+	return to, true, nil
+}
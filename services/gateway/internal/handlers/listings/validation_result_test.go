@@ -0,0 +1,314 @@
+package listings
+
+import (
+	"context"
+	"encoding/json"
+	"gateway/internal/errors"
+	"gateway/internal/events"
+	"gateway/internal/mocks/mockevents"
+	"gateway/internal/testutil"
+	"gateway/internal/testutil/apitest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	resultFileID    = "22222222-2222-2222-2222-222222222222"
+	resultListingID = "11111111-1111-1111-1111-111111111111"
+)
+
+func TestFileValidationResult_Contract(t *testing.T) {
+	// SCENARIO: The payloads the validation worker sends, exactly as it serializes them.
+	// EXPECT: Each is accepted as documented. Metadata in the keys older workers wrote is stored the current way.
+
+	tests := map[string]struct {
+		payload      string
+		wantStatus   repo.FileStatus
+		wantError    pgtype.Text
+		wantMetadata string
+	}{
+		"Valid model": {
+			payload:      `{"status": "VALID", "metadata": {"format": "model/stl", "triangle_count": 120000, "bounding_box": {"min": [0, 0, 0], "max": [120, 80, 45]}, "scan": {"watertight": true}}}`,
+			wantStatus:   repo.FileStatusVALID,
+			wantMetadata: `{"format": "model/stl", "triangle_count": 120000, "bounding_box": {"min": [0, 0, 0], "max": [120, 80, 45]}, "scan": {"watertight": true}}`,
+		},
+		"Valid with a warning": {
+			payload:    `{"status": "VALID", "error_message": "Model has 3 non-manifold edges"}`,
+			wantStatus: repo.FileStatusVALID,
+			wantError:  pgtype.Text{String: "Model has 3 non-manifold edges", Valid: true},
+		},
+		"Invalid": {
+			payload:    `{"status": "INVALID", "error_message": "File is not a valid STL", "metadata": null}`,
+			wantStatus: repo.FileStatusINVALID,
+			wantError:  pgtype.Text{String: "File is not a valid STL", Valid: true},
+		},
+		"Failed": {
+			payload:    `{"status": "FAILED", "error_message": "Worker ran out of memory"}`,
+			wantStatus: repo.FileStatusFAILED,
+			wantError:  pgtype.Text{String: "Worker ran out of memory", Valid: true},
+		},
+		"Older worker's metadata keys": {
+			payload:      `{"status": "VALID", "metadata": {"mime": "model/stl", "triangles": 500, "renderer": "blender"}}`,
+			wantStatus:   repo.FileStatusVALID,
+			wantMetadata: `{"format": "model/stl", "triangle_count": 500}`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var req FileValidationResult
+			require.NoError(t, json.Unmarshal([]byte(tt.payload), &req))
+
+			result, appErr := req.validate()
+			require.Nil(t, appErr)
+			assert.Equal(t, tt.wantStatus, result.status)
+			assert.Equal(t, tt.wantError, result.errorMessage)
+			if tt.wantMetadata == "" {
+				assert.Nil(t, result.metadata)
+			} else {
+				assert.JSONEq(t, tt.wantMetadata, string(result.metadata))
+			}
+		})
+	}
+
+	t.Run("Response", func(t *testing.T) {
+		body, err := json.Marshal(FileValidationResultResponse{
+			FileID: resultFileID, ListingID: resultListingID, Status: "VALID", ListingStatus: "ACTIVE", Applied: true,
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"file_id": "22222222-2222-2222-2222-222222222222",
+			"listing_id": "11111111-1111-1111-1111-111111111111",
+			"status": "VALID",
+			"listing_status": "ACTIVE",
+			"applied": true
+		}`, string(body))
+	})
+}
+
+func TestFileValidationResult_Rejected(t *testing.T) {
+	tests := map[string]struct {
+		payload    string
+		wantReason errors.Reason
+	}{
+		"Pending isn't a result": {payload: `{"status": "PENDING"}`, wantReason: errors.ReasonFileResultStatus},
+		"Lower case status":      {payload: `{"status": "valid"}`, wantReason: errors.ReasonFileResultStatus},
+		"Metadata not an object": {payload: `{"status": "VALID", "metadata": [1, 2]}`, wantReason: errors.ReasonFileResultMetadata},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var req FileValidationResult
+			require.NoError(t, json.Unmarshal([]byte(tt.payload), &req))
+
+			_, appErr := req.validate()
+			require.NotNil(t, appErr)
+			assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+			assert.Equal(t, tt.wantReason, appErr.Reason)
+		})
+	}
+}
+
+// expectResultLocks expects the listing and then the file to be locked, the file currently in fileStatus
+func expectResultLocks(t *testing.T, mockPool pgxmock.PgxPoolIface, listingStatus repo.ListingStatus, fileStatus string, errorMessage any) {
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE OF l`)).
+		WithArgs(mustUUID(t, resultFileID)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "status", "deleted_at"}).
+			AddRow(mustUUID(t, resultListingID), repo.NullListingStatus{ListingStatus: listingStatus, Valid: true}, nil))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingFileForUpdate :one`)).
+		WithArgs(mustUUID(t, resultFileID)).
+		WillReturnRows(pgxmock.NewRows(testutil.ListingFileCols).AddRow(
+			resultFileID, resultListingID, "models/benchy.stl", repo.FileTypeMODEL, int64(500), []byte("{}"), fileStatus, errorMessage,
+			false, nil, time.Now(), time.Now(), nil,
+		))
+}
+
+func expectFileResult(t *testing.T, mockPool pgxmock.PgxPoolIface, status repo.FileStatus, errorMessage pgtype.Text, pending, failed int64, firstError any) {
+	mockPool.ExpectExec(regexp.QuoteMeta(`UPDATE listing_files`)).
+		WithArgs(repo.NullFileStatus{FileStatus: status, Valid: true}, errorMessage, pgxmock.AnyArg(), mustUUID(t, resultFileID)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CountListingFileStatuses :one`)).
+		WithArgs(mustUUID(t, resultListingID)).
+		WillReturnRows(pgxmock.NewRows([]string{"pending", "failed", "first_error"}).AddRow(pending, failed, firstError))
+}
+
+func expectListingMoved(t *testing.T, mockPool pgxmock.PgxPoolIface, from, to repo.ListingStatus, reason string) {
+	mockPool.ExpectExec(regexp.QuoteMeta(`UPDATE listings`)).
+		WithArgs(mustUUID(t, resultListingID), repo.NullListingStatus{ListingStatus: to, Valid: true}).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO listing_status_events`)).
+		WithArgs(
+			mustUUID(t, resultListingID), repo.ListingStatusActorSYSTEM, pgtype.UUID{},
+			repo.NullListingStatus{ListingStatus: from, Valid: true}, to, pgtype.Text{String: reason, Valid: true},
+			pgtype.Text{}, pgtype.Text{},
+		).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+}
+
+func TestApplyValidationResult_LastFileValid(t *testing.T) {
+	// SCENARIO: The last file still being checked passes.
+	// EXPECT: The listing goes live and the change is in its history, all in one transaction.
+
+	service, mockPool := newUpdateTest(t)
+	expectResultLocks(t, mockPool, repo.ListingStatusPENDINGVALIDATION, "PENDING", nil)
+	expectFileResult(t, mockPool, repo.FileStatusVALID, pgtype.Text{}, 0, 0, nil)
+	expectListingMoved(t, mockPool, repo.ListingStatusPENDINGVALIDATION, repo.ListingStatusACTIVE, validationPassedReason)
+	mockPool.ExpectCommit()
+
+	response, settled, err := service.applyValidationResult(context.Background(), mustUUID(t, resultFileID), fileResult{status: repo.FileStatusVALID})
+
+	require.NoError(t, err)
+	assert.True(t, settled)
+	assert.Equal(t, &FileValidationResultResponse{
+		FileID: resultFileID, ListingID: resultListingID, Status: "VALID", ListingStatus: "ACTIVE", Applied: true,
+	}, response)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestApplyValidationResult_OtherFilesPending(t *testing.T) {
+	// SCENARIO: A file passes while another one of the listing's files is still being checked.
+	// EXPECT: Only the file changes, the listing waits for the other result.
+
+	service, mockPool := newUpdateTest(t)
+	expectResultLocks(t, mockPool, repo.ListingStatusPENDINGVALIDATION, "PENDING", nil)
+	expectFileResult(t, mockPool, repo.FileStatusVALID, pgtype.Text{}, 1, 0, nil)
+	mockPool.ExpectCommit()
+
+	response, settled, err := service.applyValidationResult(context.Background(), mustUUID(t, resultFileID), fileResult{status: repo.FileStatusVALID})
+
+	require.NoError(t, err)
+	assert.False(t, settled)
+	assert.True(t, response.Applied)
+	assert.Equal(t, "PENDING_VALIDATION", response.ListingStatus)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestApplyValidationResult_LastFileInvalid(t *testing.T) {
+	// SCENARIO: The last file of a listing held for review is invalid.
+	// EXPECT: The listing is rejected with the first error, review doesn't hold back a rejection.
+
+	service, mockPool := newUpdateTest(t)
+	invalid := pgtype.Text{String: "File is not a valid STL", Valid: true}
+	expectResultLocks(t, mockPool, repo.ListingStatusPENDINGREVIEW, "PENDING", nil)
+	expectFileResult(t, mockPool, repo.FileStatusINVALID, invalid, 0, 1, "File is not a valid STL")
+	expectListingMoved(t, mockPool, repo.ListingStatusPENDINGREVIEW, repo.ListingStatusREJECTED, "1 file(s) failed validation: File is not a valid STL")
+	mockPool.ExpectCommit()
+
+	response, settled, err := service.applyValidationResult(context.Background(), mustUUID(t, resultFileID), fileResult{status: repo.FileStatusINVALID, errorMessage: invalid})
+
+	require.NoError(t, err)
+	assert.True(t, settled)
+	assert.Equal(t, "REJECTED", response.ListingStatus)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestApplyValidationResult_ListingNotValidating(t *testing.T) {
+	tests := map[string]repo.ListingStatus{
+		"Held for review": repo.ListingStatusPENDINGREVIEW,
+		"Unpublished":     repo.ListingStatusHIDDEN,
+	}
+
+	for name, status := range tests {
+		t.Run(name, func(t *testing.T) {
+			// SCENARIO: Every file passes, but the listing is waiting on a moderator or was unpublished meanwhile.
+			// EXPECT: The file result is stored and the listing keeps its status.
+
+			service, mockPool := newUpdateTest(t)
+			expectResultLocks(t, mockPool, status, "PENDING", nil)
+			expectFileResult(t, mockPool, repo.FileStatusVALID, pgtype.Text{}, 0, 0, nil)
+			mockPool.ExpectCommit()
+
+			response, settled, err := service.applyValidationResult(context.Background(), mustUUID(t, resultFileID), fileResult{status: repo.FileStatusVALID})
+
+			require.NoError(t, err)
+			assert.True(t, settled)
+			assert.Equal(t, string(status), response.ListingStatus)
+			assert.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestApplyValidationResult_Reposted(t *testing.T) {
+	// SCENARIO: The worker retries a result after its first request timed out, the gateway had already applied it.
+	// EXPECT: Nothing is written and the worker is told the result is in place.
+
+	service, mockPool := newUpdateTest(t)
+	invalid := pgtype.Text{String: "File is not a valid STL", Valid: true}
+	expectResultLocks(t, mockPool, repo.ListingStatusREJECTED, "INVALID", "File is not a valid STL")
+	mockPool.ExpectRollback()
+
+	response, settled, err := service.applyValidationResult(context.Background(), mustUUID(t, resultFileID), fileResult{status: repo.FileStatusINVALID, errorMessage: invalid})
+
+	require.NoError(t, err)
+	assert.False(t, settled)
+	assert.False(t, response.Applied)
+	assert.Equal(t, "REJECTED", response.ListingStatus)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestApplyValidationResult_AlreadyFinal(t *testing.T) {
+	tests := map[string]fileResult{
+		"Different status":        {status: repo.FileStatusVALID},
+		"Different error message": {status: repo.FileStatusINVALID, errorMessage: pgtype.Text{String: "Too many triangles", Valid: true}},
+	}
+
+	for name, result := range tests {
+		t.Run(name, func(t *testing.T) {
+			// SCENARIO: A file that was found invalid is given another result.
+			// EXPECT: 409 FILE_RESULT_FINAL, the first result stands.
+
+			service, mockPool := newUpdateTest(t)
+			expectResultLocks(t, mockPool, repo.ListingStatusREJECTED, "INVALID", "File is not a valid STL")
+			mockPool.ExpectRollback()
+
+			_, _, err := service.applyValidationResult(context.Background(), mustUUID(t, resultFileID), result)
+
+			var appErr *errors.AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, errors.ErrConflict, appErr.Code)
+			assert.Equal(t, errors.ReasonFileResultFinal, appErr.Reason)
+			assert.Equal(t, "INVALID", appErr.Params["status"])
+			assert.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestApplyValidationResult_ReindexesByDashlessID(t *testing.T) {
+	// SCENARIO: The last file passes for a listing cached under both forms of its ID.
+	// EXPECT: Both cached responses are dropped and the reindex uses the dashless ID the search document is keyed by.
+
+	service, mockPool := newUpdateTest(t)
+	rdb, redis := apitest.NewRedis(t)
+	service.cache = rdb
+	dashless := strings.ReplaceAll(resultListingID, "-", "")
+	redis.Set(service.listingCache.Key(resultListingID), "{}")
+	redis.Set(service.listingCache.Key(dashless), "{}")
+	mockBus := mockevents.NewBus(t)
+	mockBus.EXPECT().Publish("listings.index", mock.MatchedBy(func(data []byte) bool {
+		return strings.Contains(string(data), `"listing_id":"`+dashless+`"`)
+	}), "index."+dashless).Return(nil).Once()
+	service.eventHandler = events.NewEventHandler(mockBus, &events.EventConfig{IndexListingEvent: "listings.index"}, service.logger)
+
+	expectResultLocks(t, mockPool, repo.ListingStatusPENDINGVALIDATION, "PENDING", nil)
+	expectFileResult(t, mockPool, repo.FileStatusVALID, pgtype.Text{}, 0, 0, nil)
+	expectListingMoved(t, mockPool, repo.ListingStatusPENDINGVALIDATION, repo.ListingStatusACTIVE, validationPassedReason)
+	mockPool.ExpectCommit()
+
+	_, err := service.ApplyValidationResult(context.Background(), resultFileID, &FileValidationResult{Status: "VALID"})
+
+	require.NoError(t, err)
+	assert.False(t, redis.Exists(service.listingCache.Key(resultListingID)))
+	assert.False(t, redis.Exists(service.listingCache.Key(dashless)))
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -0,0 +1,289 @@
+package listings
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/events"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MaxListingVariants caps the packages one listing is sold as
+const MaxListingVariants = 10
+
+// maxVariantNameLength is in characters, the name is shown on a button on the listing page
+const maxVariantNameLength = 60
+
+// ListingVariant is one package a listing is sold as, e.g. a free low-poly preview and a paid high-detail set.
+// The price is in the listing's currency.
+type ListingVariant struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	PriceMinUnit int64    `json:"price_min_unit"`
+	FileIDs      []string `json:"file_ids"`
+}
+
+// CreateVariantRequest adds a variant to a listing. FileIDs are the listing's model files it includes, images are
+// public anyway.
+type CreateVariantRequest struct {
+	Name         string   `json:"name"`
+	PriceMinUnit *int64   `json:"price_min_unit"` // The full price rather than a difference to the listing's
+	FileIDs      []string `json:"file_ids"`
+}
+
+// Validate tidies the name and returns the file IDs parsed, without repeats, in request order
+func (req *CreateVariantRequest) Validate() ([]pgtype.UUID, *errors.AppError) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || utf8.RuneCountInString(req.Name) > maxVariantNameLength {
+		return nil, errors.New(errors.ErrInvalidInput, fmt.Sprintf("Variant names must be between 1 and %d characters", maxVariantNameLength), nil).WithReason(errors.ReasonVariantNameLength)
+	}
+	if req.PriceMinUnit == nil || *req.PriceMinUnit < 0 {
+		return nil, errors.New(errors.ErrInvalidInput, "Variant price must be zero or more", nil).WithReason(errors.ReasonVariantPriceNegative)
+	}
+	if len(req.FileIDs) == 0 {
+		return nil, errors.New(errors.ErrInvalidInput, "A variant has to include at least one file", nil).WithReason(errors.ReasonVariantFilesRequired)
+	}
+
+	ids := make([]pgtype.UUID, 0, len(req.FileIDs))
+	seen := make(map[pgtype.UUID]bool, len(req.FileIDs))
+	for _, fileID := range req.FileIDs {
+		var id pgtype.UUID
+		if err := id.Scan(fileID); err != nil {
+			return nil, errors.New(errors.ErrInvalidInput, "Invalid file ID provided", fmt.Errorf("invalid file id %q: %w", fileID, err))
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// CreateVariant adds a variant to one of the caller's listings. The first one has to include every model file,
+// after that a listing's model files are always all in at least one variant.
+func (s *svc) CreateVariant(ctx context.Context, userInfo auth.UserInfo, listingID string, req *CreateVariantRequest) (*ListingVariant, error) {
+	fileIDs, appErr := req.Validate()
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	rules, err := s.sellerValidationRules(ctx, userInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	var variant repo.ListingVariant
+	err = s.changeVariants(ctx, userInfo, listingID, func(qtx *repo.Queries, listing repo.Listing) error {
+		// A free listing may have been created without a currency, a paid variant needs one the same as a paid listing
+		if *req.PriceMinUnit > 0 && !slices.Contains(rules.Currencies, strings.ToLower(listing.Currency)) {
+			currencies := rules.currencyList()
+			return errors.New(errors.ErrInvalidInput, "Currency must be "+currencies, nil).
+				WithReason(errors.ReasonListingCurrencyUnsupported).
+				WithParam("currencies", currencies)
+		}
+
+		existing, err := qtx.ListListingVariants(ctx, []pgtype.UUID{listing.ID})
+		if err != nil {
+			return errors.New(errors.ErrInternal, "Failed to fetch variants", fmt.Errorf("failed to list variants of %v: %w", listingID, err))
+		}
+		if len(existing) >= MaxListingVariants {
+			return errors.New(errors.ErrConflict, fmt.Sprintf("A listing can have at most %d variants", MaxListingVariants), nil).
+				WithReason(errors.ReasonVariantLimit).
+				WithParam("max", strconv.Itoa(MaxListingVariants))
+		}
+
+		if err := checkVariantFiles(ctx, qtx, listing.ID, fileIDs); err != nil {
+			return err
+		}
+
+		variant, err = qtx.CreateListingVariant(ctx, repo.CreateListingVariantParams{
+			ListingID:    listing.ID,
+			Name:         req.Name,
+			PriceMinUnit: *req.PriceMinUnit,
+		})
+		if err == pgx.ErrNoRows {
+			return errors.New(errors.ErrConflict, "This listing already has a variant with that name", nil).WithReason(errors.ReasonVariantNameTaken)
+		}
+		if err != nil {
+			return errors.New(errors.ErrInternal, "Failed to create variant", fmt.Errorf("failed to create variant on %v: %w", listingID, err))
+		}
+
+		if err := qtx.AddListingVariantFiles(ctx, repo.AddListingVariantFilesParams{VariantID: variant.ID, FileIds: fileIDs}); err != nil {
+			return errors.New(errors.ErrInternal, "Failed to create variant", fmt.Errorf("failed to add files to variant %v: %w", variant.ID.String(), err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListingVariant{
+		ID:           variant.ID.String(),
+		Name:         variant.Name,
+		PriceMinUnit: variant.PriceMinUnit,
+		FileIDs:      variantFileIDs(fileIDs),
+	}, nil
+}
+
+// DeleteVariant removes a variant from one of the caller's listings, unless that would leave a model file no
+// remaining variant includes. Removing the last variant is fine, the listing goes back to selling every file.
+func (s *svc) DeleteVariant(ctx context.Context, userInfo auth.UserInfo, listingID string, variantID string) error {
+	var variantUUID pgtype.UUID
+	if err := variantUUID.Scan(variantID); err != nil {
+		return errors.New(errors.ErrInvalidInput, "Invalid variant ID provided", err)
+	}
+
+	return s.changeVariants(ctx, userInfo, listingID, func(qtx *repo.Queries, listing repo.Listing) error {
+		deleted, err := qtx.DeleteListingVariant(ctx, repo.DeleteListingVariantParams{ID: variantUUID, ListingID: listing.ID})
+		if err != nil {
+			return errors.New(errors.ErrInternal, "Failed to delete variant", fmt.Errorf("failed to delete variant %v: %w", variantID, err))
+		}
+		if deleted == 0 {
+			return errors.New(errors.ErrNotFound, "Variant not found", fmt.Errorf("variant %v not found on listing %v", variantID, listingID)).WithReason(errors.ReasonVariantNotFound)
+		}
+		return nil
+	})
+}
+
+// changeVariants runs change with the caller's listing locked, then refuses the result if it leaves a model file
+// outside every variant. Locking the listing keeps two changes from each passing the check on their own.
+func (s *svc) changeVariants(ctx context.Context, userInfo auth.UserInfo, listingID string, change func(qtx *repo.Queries, listing repo.Listing) error) error {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userInfo.ID); err != nil {
+		return errors.New(errors.ErrInvalidInput, "Invalid user ID provided", err)
+	}
+	var listingUUID pgtype.UUID
+	if err := listingUUID.Scan(listingID); err != nil {
+		return errors.New(errors.ErrInvalidInput, "Invalid listing ID provided", err)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return errors.New(errors.ErrInternal, "Failed to start transaction. Please try again later.", fmt.Errorf("failed to begin transaction: %w", err))
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.repo.WithTx(tx)
+
+	listing, err := qtx.GetListingByIDForUpdate(ctx, listingUUID)
+	if err == pgx.ErrNoRows {
+		return listingNotFound(listingID)
+	}
+	if err != nil {
+		return errors.New(errors.ErrInternal, "Failed to fetch existing listing", fmt.Errorf("failed to fetch listing %v: %w", listingID, err))
+	}
+	if listing.SellerID != userUUID {
+		return errors.New(errors.ErrUnauthorized, "You do not own this listing", fmt.Errorf("user %v doesn't own listing %v", userInfo.ID, listingID)).WithReason(errors.ReasonListingNotOwner)
+	}
+
+	if err := change(qtx, listing); err != nil {
+		return err
+	}
+
+	uncovered, err := qtx.GetModelFilesOutsideVariants(ctx, listingUUID)
+	if err != nil {
+		return errors.New(errors.ErrInternal, "Failed to check variant files", fmt.Errorf("failed to check variant files of %v: %w", listingID, err))
+	}
+	if len(uncovered) > 0 {
+		return errors.New(errors.ErrInvalidInput, fmt.Sprintf("%d model files wouldn't be in any variant, every model file has to be in at least one", len(uncovered)), nil).
+			WithReason(errors.ReasonVariantFilesUncovered).
+			WithParam("count", strconv.Itoa(len(uncovered)))
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.New(errors.ErrInternal, "Failed to save variants", fmt.Errorf("failed to commit variants of %v: %w", listingID, err))
+	}
+
+	s.logger.InfoContext(ctx, "Listing variants changed", "listing_id", listingID, "user_id", userInfo.ID)
+
+	// Variants are part of the response, and the cheapest one is the price search sorts on
+	s.forgetListing(ctx, listingUUID)
+	traceID := ""
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		traceID = spanContext.TraceID().String()
+	}
+	// Search documents are keyed by the dashless ID
+	if err := s.eventHandler.RaiseListingIndexEvent(events.ReIndexListingEvent{ListingID: fmt.Sprintf("%x", listingUUID.Bytes), TraceID: traceID}); err != nil {
+		// Logged only, the variants are saved and the search price catches up on the next reindex
+		s.logger.ErrorContext(ctx, "Failed to raise listing re-index event", "listing_id", listingID, "error", err)
+	}
+	return nil
+}
+
+// checkVariantFiles refuses a file that isn't one of the listing's model files
+func checkVariantFiles(ctx context.Context, qtx *repo.Queries, listingID pgtype.UUID, fileIDs []pgtype.UUID) error {
+	files, err := qtx.GetFilesByListingID(ctx, listingID)
+	if err != nil {
+		return errors.New(errors.ErrInternal, "Failed to fetch listing files", fmt.Errorf("failed to fetch files of %v: %w", listingID.String(), err))
+	}
+
+	models := make(map[pgtype.UUID]bool, len(files))
+	for _, f := range files {
+		if f.FileType == repo.FileTypeMODEL {
+			models[f.ID] = true
+		}
+	}
+	for _, id := range fileIDs {
+		if !models[id] {
+			return errors.New(errors.ErrInvalidInput, fmt.Sprintf("File %s isn't one of this listing's model files", id.String()), nil).
+				WithReason(errors.ReasonVariantFileInvalid).
+				WithParam("file_id", id.String())
+		}
+	}
+	return nil
+}
+
+// listingVariants reads the variants of each of the listings, listings without any are left out
+func (s *svc) listingVariants(ctx context.Context, listingIDs []pgtype.UUID) (map[pgtype.UUID][]ListingVariant, error) {
+	if len(listingIDs) == 0 {
+		return nil, nil
+	}
+
+	rows, err := s.repo.ListListingVariants(ctx, listingIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	variants := make(map[pgtype.UUID][]ListingVariant)
+	for _, row := range rows {
+		variants[row.ListingID] = append(variants[row.ListingID], ListingVariant{
+			ID:           row.ID.String(),
+			Name:         row.Name,
+			PriceMinUnit: row.PriceMinUnit,
+			FileIDs:      variantFileIDs(row.FileIds),
+		})
+	}
+	return variants, nil
+}
+
+func variantFileIDs(ids []pgtype.UUID) []string {
+	fileIDs := make([]string, len(ids))
+	for i, id := range ids {
+		fileIDs[i] = id.String()
+	}
+	return fileIDs
+}
+
+// filePrice is what a file sells for: the cheapest variant that includes it, otherwise the listing's price. Images,
+// and every file of a listing without variants, aren't in any.
+func (s *svc) filePrice(ctx context.Context, fileID pgtype.UUID, listingPrice int64) (int64, error) {
+	price, err := s.repo.GetFileVariantPrice(ctx, fileID)
+	if err == pgx.ErrNoRows {
+		return listingPrice, nil
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to fetch file price", "file_id", fileID.String(), "error", err)
+		return 0, errors.New(errors.ErrInternal, "Failed to fetch file", fmt.Errorf("failed to fetch price of file %v: %w", fileID.String(), err))
+	}
+	return price, nil
+}
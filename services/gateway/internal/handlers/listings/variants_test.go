@@ -0,0 +1,392 @@
+package listings
+
+import (
+	"context"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/events"
+	"gateway/internal/mocks/mockevents"
+	"gateway/internal/mocks/mockstorage"
+	"gateway/internal/testutil"
+	"gateway/internal/testutil/apitest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/storage"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	variantID    = "44444444-4444-4444-4444-444444444444"
+	lowPolyID    = "22222222-2222-2222-2222-222222222222"
+	highDetailID = "33333333-3333-3333-3333-333333333333"
+	galleryID    = "55555555-5555-5555-5555-555555555555"
+)
+
+var variantCols = []string{"id", "listing_id", "name", "price_min_unit", "created_at"}
+
+func price(p int64) *int64 { return &p }
+
+// variantFileRows is the listing's files: two models and a gallery image
+func variantFileRows() *pgxmock.Rows {
+	rows := pgxmock.NewRows(testutil.ListingFileCols)
+	for _, f := range []struct {
+		id       string
+		fileType repo.FileType
+	}{{lowPolyID, repo.FileTypeMODEL}, {highDetailID, repo.FileTypeMODEL}, {galleryID, repo.FileTypeIMAGE}} {
+		rows.AddRow(f.id, updateListingID, "listings/"+f.id, f.fileType, int64(1024), []byte("{}"), "VALID", nil, false, nil, time.Now(), time.Now(), nil)
+	}
+	return rows
+}
+
+// expectVariantLock expects the transaction and the listing locked in it
+func expectVariantLock(t *testing.T, mockPool pgxmock.PgxPoolIface, sellerID string, price int64, currency string) {
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingByIDForUpdate`)).
+		WithArgs(mustUUID(t, updateListingID)).
+		WillReturnRows(pricedListingRows(sellerID, "Benchy", "public/thumb.webp", repo.ListingStatusACTIVE, price, currency))
+}
+
+// expectUncovered expects the coverage check, answering with the model files no variant includes
+func expectUncovered(t *testing.T, mockPool pgxmock.PgxPoolIface, fileIDs ...string) {
+	rows := pgxmock.NewRows([]string{"id"})
+	for _, id := range fileIDs {
+		rows.AddRow(mustUUID(t, id))
+	}
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetModelFilesOutsideVariants`)).
+		WithArgs(mustUUID(t, updateListingID)).
+		WillReturnRows(rows)
+}
+
+func TestCreateVariantRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        CreateVariantRequest
+		wantReason errors.Reason
+	}{
+		{name: "Blank name", req: CreateVariantRequest{Name: "  ", PriceMinUnit: price(0), FileIDs: []string{lowPolyID}}, wantReason: errors.ReasonVariantNameLength},
+		{name: "Long name", req: CreateVariantRequest{Name: strings.Repeat("ü", 61), PriceMinUnit: price(0), FileIDs: []string{lowPolyID}}, wantReason: errors.ReasonVariantNameLength},
+		{name: "No price", req: CreateVariantRequest{Name: "Preview", FileIDs: []string{lowPolyID}}, wantReason: errors.ReasonVariantPriceNegative},
+		{name: "Negative price", req: CreateVariantRequest{Name: "Preview", PriceMinUnit: price(-1), FileIDs: []string{lowPolyID}}, wantReason: errors.ReasonVariantPriceNegative},
+		{name: "No files", req: CreateVariantRequest{Name: "Preview", PriceMinUnit: price(0)}, wantReason: errors.ReasonVariantFilesRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, appErr := tt.req.Validate()
+			require.NotNil(t, appErr)
+			assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+			assert.Equal(t, tt.wantReason, appErr.Reason)
+		})
+	}
+
+	t.Run("Name is trimmed and repeated files dropped", func(t *testing.T) {
+		req := CreateVariantRequest{Name: " Preview ", PriceMinUnit: price(0), FileIDs: []string{lowPolyID, highDetailID, lowPolyID}}
+		ids, appErr := req.Validate()
+		require.Nil(t, appErr)
+		assert.Equal(t, "Preview", req.Name)
+		assert.Equal(t, []pgtype.UUID{mustUUID(t, lowPolyID), mustUUID(t, highDetailID)}, ids)
+	})
+}
+
+func TestCreateVariant(t *testing.T) {
+	// SCENARIO: The seller of a paid listing adds its first variant, covering both model files.
+	// EXPECT: The variant and its files are saved in one transaction, the cached listing is dropped and the listing
+	// is sent for reindexing so search sorts on the new price.
+
+	service, mockPool := newUpdateTest(t)
+	rdb, redis := apitest.NewRedis(t)
+	service.cache = rdb
+	redis.Set(service.listingCache.Key(updateListingID), "{}")
+	mockBus := mockevents.NewBus(t)
+	mockBus.EXPECT().Publish("listings.index", mock.Anything, "index."+strings.ReplaceAll(updateListingID, "-", "")).Return(nil).Once()
+	service.eventHandler = events.NewEventHandler(mockBus, &events.EventConfig{IndexListingEvent: "listings.index"}, service.logger)
+
+	expectVariantLock(t, mockPool, updateSellerID, 1050, "gbp")
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListListingVariants`)).
+		WithArgs([]pgtype.UUID{mustUUID(t, updateListingID)}).
+		WillReturnRows(pgxmock.NewRows(testutil.ListingVariantCols))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetFilesByListingID`)).
+		WithArgs(mustUUID(t, updateListingID)).
+		WillReturnRows(variantFileRows())
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CreateListingVariant`)).
+		WithArgs(mustUUID(t, updateListingID), "High detail", int64(1500)).
+		WillReturnRows(pgxmock.NewRows(variantCols).AddRow(mustUUID(t, variantID), mustUUID(t, updateListingID), "High detail", int64(1500), time.Now()))
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: AddListingVariantFiles`)).
+		WithArgs(mustUUID(t, variantID), []pgtype.UUID{mustUUID(t, lowPolyID), mustUUID(t, highDetailID)}).
+		WillReturnResult(pgxmock.NewResult("INSERT", 2))
+	expectUncovered(t, mockPool)
+	mockPool.ExpectCommit()
+
+	variant, err := service.CreateVariant(context.Background(), auth.UserInfo{ID: updateSellerID}, updateListingID, &CreateVariantRequest{
+		Name:         "High detail",
+		PriceMinUnit: price(1500),
+		FileIDs:      []string{lowPolyID, highDetailID},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, &ListingVariant{ID: variantID, Name: "High detail", PriceMinUnit: 1500, FileIDs: []string{lowPolyID, highDetailID}}, variant)
+	assert.False(t, redis.Exists(service.listingCache.Key(updateListingID)))
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestCreateVariant_VerifiedSellerCurrency(t *testing.T) {
+	// SCENARIO: Only verified sellers may sell in EUR, and a verified seller adds a paid variant to their EUR listing.
+	// EXPECT: The variant is checked against the verified seller's rules, the same ones the listing was created under.
+
+	service, mockPool := newUpdateTest(t)
+	rules, err := LoadValidationRules([]byte(`{"currencies": ["gbp"], "overrides": [{"role": "verified_seller", "currencies": ["gbp", "eur"]}]}`))
+	require.NoError(t, err)
+	service.rules = rules
+	service.cache, _ = apitest.NewRedis(t)
+	mockBus := mockevents.NewBus(t)
+	mockBus.EXPECT().Publish("listings.index", mock.Anything, mock.Anything).Return(nil).Once()
+	service.eventHandler = events.NewEventHandler(mockBus, &events.EventConfig{IndexListingEvent: "listings.index"}, service.logger)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT user_id, display_name`)).
+		WithArgs(mustUUID(t, updateSellerID)).
+		WillReturnRows(pgxmock.NewRows(testutil.SellerCols).AddRow(
+			updateSellerID, "Tester Prints", "DE", "VERIFIED", "1", time.Now(), time.Now(), time.Now(), nil, nil, nil, false, nil,
+		))
+	expectVariantLock(t, mockPool, updateSellerID, 1050, "eur")
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListListingVariants`)).
+		WithArgs([]pgtype.UUID{mustUUID(t, updateListingID)}).
+		WillReturnRows(pgxmock.NewRows(testutil.ListingVariantCols))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetFilesByListingID`)).
+		WithArgs(mustUUID(t, updateListingID)).
+		WillReturnRows(variantFileRows())
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CreateListingVariant`)).
+		WithArgs(mustUUID(t, updateListingID), "High detail", int64(1500)).
+		WillReturnRows(pgxmock.NewRows(variantCols).AddRow(mustUUID(t, variantID), mustUUID(t, updateListingID), "High detail", int64(1500), time.Now()))
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: AddListingVariantFiles`)).
+		WithArgs(mustUUID(t, variantID), []pgtype.UUID{mustUUID(t, lowPolyID), mustUUID(t, highDetailID)}).
+		WillReturnResult(pgxmock.NewResult("INSERT", 2))
+	expectUncovered(t, mockPool)
+	mockPool.ExpectCommit()
+
+	_, err = service.CreateVariant(context.Background(), auth.UserInfo{ID: updateSellerID}, updateListingID, &CreateVariantRequest{
+		Name:         "High detail",
+		PriceMinUnit: price(1500),
+		FileIDs:      []string{lowPolyID, highDetailID},
+	})
+
+	require.NoError(t, err)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestCreateVariant_Refused(t *testing.T) {
+	t.Run("Another seller's listing", func(t *testing.T) {
+		service, mockPool := newUpdateTest(t)
+		expectVariantLock(t, mockPool, awaySellerID, 1050, "gbp")
+		mockPool.ExpectRollback()
+
+		_, err := service.CreateVariant(context.Background(), auth.UserInfo{ID: updateSellerID}, updateListingID, &CreateVariantRequest{
+			Name: "Preview", PriceMinUnit: price(0), FileIDs: []string{lowPolyID},
+		})
+
+		var appErr *errors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, errors.ReasonListingNotOwner, appErr.Reason)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Paid variant on a listing without a currency", func(t *testing.T) {
+		service, mockPool := newUpdateTest(t)
+		expectVariantLock(t, mockPool, updateSellerID, 0, "")
+		mockPool.ExpectRollback()
+
+		_, err := service.CreateVariant(context.Background(), auth.UserInfo{ID: updateSellerID}, updateListingID, &CreateVariantRequest{
+			Name: "High detail", PriceMinUnit: price(1500), FileIDs: []string{lowPolyID},
+		})
+
+		var appErr *errors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, errors.ReasonListingCurrencyUnsupported, appErr.Reason)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Image instead of a model", func(t *testing.T) {
+		service, mockPool := newUpdateTest(t)
+		expectVariantLock(t, mockPool, updateSellerID, 1050, "gbp")
+		mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListListingVariants`)).
+			WithArgs(pgxmock.AnyArg()).
+			WillReturnRows(pgxmock.NewRows(testutil.ListingVariantCols))
+		mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetFilesByListingID`)).
+			WithArgs(mustUUID(t, updateListingID)).
+			WillReturnRows(variantFileRows())
+		mockPool.ExpectRollback()
+
+		_, err := service.CreateVariant(context.Background(), auth.UserInfo{ID: updateSellerID}, updateListingID, &CreateVariantRequest{
+			Name: "Preview", PriceMinUnit: price(0), FileIDs: []string{lowPolyID, galleryID},
+		})
+
+		var appErr *errors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, errors.ReasonVariantFileInvalid, appErr.Reason)
+		assert.Equal(t, galleryID, appErr.Params["file_id"])
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Name already used", func(t *testing.T) {
+		service, mockPool := newUpdateTest(t)
+		expectVariantLock(t, mockPool, updateSellerID, 1050, "gbp")
+		mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListListingVariants`)).
+			WithArgs(pgxmock.AnyArg()).
+			WillReturnRows(pgxmock.NewRows(testutil.ListingVariantCols))
+		mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetFilesByListingID`)).
+			WithArgs(mustUUID(t, updateListingID)).
+			WillReturnRows(variantFileRows())
+		mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CreateListingVariant`)).
+			WithArgs(mustUUID(t, updateListingID), "Preview", int64(0)).
+			WillReturnError(pgx.ErrNoRows)
+		mockPool.ExpectRollback()
+
+		_, err := service.CreateVariant(context.Background(), auth.UserInfo{ID: updateSellerID}, updateListingID, &CreateVariantRequest{
+			Name: "Preview", PriceMinUnit: price(0), FileIDs: []string{lowPolyID},
+		})
+
+		var appErr *errors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, errors.ErrConflict, appErr.Code)
+		assert.Equal(t, errors.ReasonVariantNameTaken, appErr.Reason)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("First variant leaves a model out", func(t *testing.T) {
+		// A free preview on its own would leave the high-detail model unsold
+		service, mockPool := newUpdateTest(t)
+		expectVariantLock(t, mockPool, updateSellerID, 1050, "gbp")
+		mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListListingVariants`)).
+			WithArgs(pgxmock.AnyArg()).
+			WillReturnRows(pgxmock.NewRows(testutil.ListingVariantCols))
+		mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetFilesByListingID`)).
+			WithArgs(mustUUID(t, updateListingID)).
+			WillReturnRows(variantFileRows())
+		mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CreateListingVariant`)).
+			WithArgs(mustUUID(t, updateListingID), "Preview", int64(0)).
+			WillReturnRows(pgxmock.NewRows(variantCols).AddRow(mustUUID(t, variantID), mustUUID(t, updateListingID), "Preview", int64(0), time.Now()))
+		mockPool.ExpectExec(regexp.QuoteMeta(`-- name: AddListingVariantFiles`)).
+			WithArgs(mustUUID(t, variantID), []pgtype.UUID{mustUUID(t, lowPolyID)}).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		expectUncovered(t, mockPool, highDetailID)
+		mockPool.ExpectRollback()
+
+		_, err := service.CreateVariant(context.Background(), auth.UserInfo{ID: updateSellerID}, updateListingID, &CreateVariantRequest{
+			Name: "Preview", PriceMinUnit: price(0), FileIDs: []string{lowPolyID},
+		})
+
+		var appErr *errors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+		assert.Equal(t, errors.ReasonVariantFilesUncovered, appErr.Reason)
+		assert.Equal(t, "1", appErr.Params["count"])
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestDeleteVariant_Refused(t *testing.T) {
+	t.Run("Not on the listing", func(t *testing.T) {
+		service, mockPool := newUpdateTest(t)
+		expectVariantLock(t, mockPool, updateSellerID, 1050, "gbp")
+		mockPool.ExpectExec(regexp.QuoteMeta(`-- name: DeleteListingVariant`)).
+			WithArgs(mustUUID(t, variantID), mustUUID(t, updateListingID)).
+			WillReturnResult(pgxmock.NewResult("DELETE", 0))
+		mockPool.ExpectRollback()
+
+		err := service.DeleteVariant(context.Background(), auth.UserInfo{ID: updateSellerID}, updateListingID, variantID)
+
+		var appErr *errors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, errors.ErrNotFound, appErr.Code)
+		assert.Equal(t, errors.ReasonVariantNotFound, appErr.Reason)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Only variant with a model", func(t *testing.T) {
+		// SCENARIO: The listing has a preview and a full set, and the seller removes the full set.
+		// EXPECT: Refused, the high-detail model would be in no variant. The delete is rolled back.
+		service, mockPool := newUpdateTest(t)
+		expectVariantLock(t, mockPool, updateSellerID, 1050, "gbp")
+		mockPool.ExpectExec(regexp.QuoteMeta(`-- name: DeleteListingVariant`)).
+			WithArgs(mustUUID(t, variantID), mustUUID(t, updateListingID)).
+			WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		expectUncovered(t, mockPool, highDetailID)
+		mockPool.ExpectRollback()
+
+		err := service.DeleteVariant(context.Background(), auth.UserInfo{ID: updateSellerID}, updateListingID, variantID)
+
+		var appErr *errors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, errors.ReasonVariantFilesUncovered, appErr.Reason)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+}
+
+func TestGetFileDownload_VariantPrice(t *testing.T) {
+	// SCENARIO: Anonymous callers download a model from a listing with variants.
+	// EXPECT: The cheapest variant including the file decides, not the listing's own price.
+
+	tests := map[string]struct {
+		listingPrice int64
+		variantPrice *int64 // Nil when no variant includes the file
+		wantError    bool
+	}{
+		"free preview of a paid listing":   {listingPrice: 1500, variantPrice: price(0)},
+		"paid set of a free listing":       {listingPrice: 0, variantPrice: price(1500), wantError: true},
+		"file in no variant, paid listing": {listingPrice: 1500, wantError: true},
+		"file in no variant, free listing": {listingPrice: 0},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockPool := testutil.NewMockDB(t)
+			store := mockstorage.NewProvider(t)
+			service := &svc{
+				repo:      repo.New(mockPool),
+				db:        mockPool,
+				logger:    testutil.NewTestLogger(),
+				storage:   store,
+				downloads: DefaultDownloadConfig(),
+				now:       time.Now,
+			}
+
+			mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingFileForDownload`)).
+				WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+				WillReturnRows(pgxmock.NewRows(testutil.ListingFileCols).AddRow(
+					lowPolyID, updateListingID, "models/benchy.stl", repo.FileTypeMODEL, int64(1024),
+					[]byte("{}"), "VALID", nil, false, nil,
+					time.Now(), time.Now(), nil,
+				))
+			mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingAvailability`)).
+				WithArgs(pgxmock.AnyArg()).
+				WillReturnRows(pgxmock.NewRows(availabilityCols).AddRow(updateSellerID, tt.listingPrice, nil, nil))
+			variantPrice := mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetFileVariantPrice`)).WithArgs(mustUUID(t, lowPolyID))
+			if tt.variantPrice != nil {
+				variantPrice.WillReturnRows(pgxmock.NewRows([]string{"price_min_unit"}).AddRow(*tt.variantPrice))
+			} else {
+				variantPrice.WillReturnError(pgx.ErrNoRows)
+			}
+			if !tt.wantError {
+				store.EXPECT().PresignGet(mock.Anything, storage.BucketProduct, "models/benchy.stl", mock.Anything).Return("http://minio/signed", nil)
+			}
+
+			_, err := service.GetFileDownload(context.Background(), auth.UserInfo{}, updateListingID, lowPolyID, false)
+
+			if tt.wantError {
+				var appErr *errors.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, errors.ErrUnauthorized, appErr.Code)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
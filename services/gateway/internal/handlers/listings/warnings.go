@@ -0,0 +1,91 @@
+package listings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/errors"
+	"strings"
+)
+
+// warningInput is what the warning rules look at, taken from the create request or, for an edit, the saved listing
+type warningInput struct {
+	categories   []string
+	settings     ListingPrinterSettings
+	isPhysical   bool
+	dimensions   *ListingDimensions // nil when none were given, or the listing is digital-only
+	priceMinUnit int64
+	currency     string
+}
+
+func createWarningInput(req *CreateListingRequest) warningInput {
+	in := warningInput{
+		categories:   req.Categories,
+		settings:     req.PrinterSettings,
+		isPhysical:   req.IsPhysical,
+		priceMinUnit: req.PriceMinUnit,
+		currency:     req.Currency,
+	}
+	// All three at 0 is no size, see dimensionsColumn
+	if dims := req.Dimensions; req.IsPhysical && dims != nil && (dims.X != 0 || dims.Y != 0 || dims.Z != 0) {
+		mm := dims.Millimetres()
+		in.dimensions = &mm
+	}
+	return in
+}
+
+func savedWarningInput(listing repo.Listing) warningInput {
+	in := warningInput{
+		categories:   listing.Categories,
+		isPhysical:   listing.IsPhysical,
+		priceMinUnit: listing.PriceMinUnit,
+		currency:     listing.Currency,
+	}
+	if listing.RecommendedNozzleTempC.Valid {
+		temp := float64(listing.RecommendedNozzleTempC.Int32)
+		in.settings.RecommendedNozzleTempC = &temp
+	}
+	if listing.RecommendedMaterials != nil {
+		in.settings.RecommendedMaterials = &listing.RecommendedMaterials
+	}
+	var dims ListingDimensionsJSON
+	if listing.IsPhysical && len(listing.DimensionsMm) > 0 && json.Unmarshal(listing.DimensionsMm, &dims) == nil {
+		in.dimensions = &ListingDimensions{X: float64(dims.Width), Y: float64(dims.Depth), Z: float64(dims.Height)}
+	}
+	return in
+}
+
+// listingWarnings is the non-fatal pass run once a listing has passed Validate and been saved: values that are allowed
+// but often a mistake. They go back with the listing for the seller to double check and never change the status code.
+func (s *svc) listingWarnings(ctx context.Context, in warningInput, rules ValidationRules) []ListingWarning {
+	warnings := s.defaultsWarnings(ctx, in)
+	warnings = append(warnings, checkWarnings(in, rules)...)
+	return warnings
+}
+
+// checkWarnings are the rules that need nothing but the listing and the seller's rules
+func checkWarnings(in warningInput, rules ValidationRules) []ListingWarning {
+	warnings := []ListingWarning{}
+
+	if rules.PriceWarnAboveMinUnit > 0 && in.priceMinUnit > rules.PriceWarnAboveMinUnit {
+		value, currency := formatPrice(in.priceMinUnit), strings.ToUpper(in.currency)
+		warnings = append(warnings, ListingWarning{
+			Field:   "price_min_unit",
+			Reason:  errors.ReasonListingPriceHigh,
+			Message: fmt.Sprintf("%s %s is much higher than most listings, check the price is in cents or pence", value, currency),
+			Params:  map[string]string{"value": value, "currency": currency},
+		})
+	}
+
+	if in.isPhysical && in.dimensions == nil {
+		warnings = append(warnings, ListingWarning{
+			Field:   "dimensions",
+			Reason:  errors.ReasonListingDimensionsMissing,
+			Message: "Add the dimensions so buyers can check it fits their printer",
+			Params:  map[string]string{},
+		})
+	}
+
+	return warnings
+}
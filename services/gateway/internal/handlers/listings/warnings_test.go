@@ -0,0 +1,117 @@
+package listings
+
+import (
+	"context"
+	"gateway/internal/errors"
+	"gateway/internal/handlers/categories"
+	"gateway/internal/testutil"
+	"gateway/internal/testutil/fixtures"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckWarnings(t *testing.T) {
+	rules := DefaultValidationRules()
+	noPriceWarning := DefaultValidationRules()
+	noPriceWarning.PriceWarnAboveMinUnit = 0
+
+	tests := map[string]struct {
+		in          warningInput
+		rules       ValidationRules
+		wantReasons []errors.Reason
+		wantParams  map[string]string
+	}{
+		"Usual listing": {
+			in:    savedWarningInput(fixtures.NewListing()),
+			rules: rules,
+		},
+		"Price at the threshold": {
+			in:    savedWarningInput(fixtures.NewListing(fixtures.WithPrice(50000, "usd"))),
+			rules: rules,
+		},
+		"Price above the threshold": {
+			in:          savedWarningInput(fixtures.NewListing(fixtures.WithPrice(199900, "usd"))),
+			rules:       rules,
+			wantReasons: []errors.Reason{errors.ReasonListingPriceHigh},
+			wantParams:  map[string]string{"value": "1999.00", "currency": "USD"},
+		},
+		"Price warning turned off": {
+			in:    savedWarningInput(fixtures.NewListing(fixtures.WithPrice(199900, "usd"))),
+			rules: noPriceWarning,
+		},
+		"Physical without dimensions": {
+			in:          savedWarningInput(fixtures.NewListing(fixtures.WithNoDimensions())),
+			rules:       rules,
+			wantReasons: []errors.Reason{errors.ReasonListingDimensionsMissing},
+			wantParams:  map[string]string{},
+		},
+		"Physical created with all dimensions at 0": {
+			in:          createWarningInput(&CreateListingRequest{IsPhysical: true, Dimensions: &ListingDimensions{}}),
+			rules:       rules,
+			wantReasons: []errors.Reason{errors.ReasonListingDimensionsMissing},
+			wantParams:  map[string]string{},
+		},
+		"Digital without dimensions": {
+			in:    savedWarningInput(fixtures.NewListing(fixtures.WithDigital())),
+			rules: rules,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			warnings := checkWarnings(tt.in, tt.rules)
+
+			reasons := []errors.Reason{}
+			for _, warning := range warnings {
+				reasons = append(reasons, warning.Reason)
+				assert.NotEmpty(t, warning.Field)
+				assert.NotEmpty(t, warning.Message)
+				assert.Equal(t, tt.wantParams, warning.Params)
+			}
+			assert.ElementsMatch(t, tt.wantReasons, reasons)
+		})
+	}
+}
+
+func TestSavedWarningInput(t *testing.T) {
+	// SCENARIO: An edit is warned about from the row it saved.
+	// EXPECT: The stored temperature, materials and size read back the way the create request has them.
+
+	in := savedWarningInput(fixtures.NewListing())
+
+	assert.Equal(t, []string{"Art"}, in.categories)
+	assert.Equal(t, 215.0, *in.settings.RecommendedNozzleTempC)
+	assert.Equal(t, []string{"PLA"}, *in.settings.RecommendedMaterials)
+	assert.Equal(t, &ListingDimensions{X: 120, Y: 80, Z: 45}, in.dimensions)
+	assert.Equal(t, int64(1050), in.priceMinUnit)
+}
+
+func TestListingWarnings_DefaultsAndChecks(t *testing.T) {
+	// SCENARIO: A physical listing is far too hot for its category, has no size and a price typed in pence twice over.
+	// EXPECT: The category defaults warning and both check warnings together.
+
+	s := &svc{defaults: fakeDefaults{"functional": functionalDefaults}, logger: testutil.NewTestLogger()}
+	req := &CreateListingRequest{
+		Categories:      []string{"functional"},
+		IsPhysical:      true,
+		PriceMinUnit:    105000,
+		Currency:        "gbp",
+		PrinterSettings: ListingPrinterSettings{RecommendedNozzleTempC: float(300)},
+	}
+
+	warnings := s.listingWarnings(context.Background(), createWarningInput(req), DefaultValidationRules())
+
+	reasons := []errors.Reason{}
+	for _, warning := range warnings {
+		reasons = append(reasons, warning.Reason)
+	}
+	assert.Equal(t, []errors.Reason{errors.ReasonListingNozzleTempUnusual, errors.ReasonListingPriceHigh, errors.ReasonListingDimensionsMissing}, reasons)
+}
+
+type fakeDefaults map[string]categories.Defaults
+
+func (f fakeDefaults) GetDefaults(_ context.Context, category string) (*categories.Defaults, error) {
+	defaults := f[category]
+	return &defaults, nil
+}
@@ -0,0 +1,48 @@
+package outboxadmin
+
+import (
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type OutboxHandler struct {
+	service OutboxService
+}
+
+func NewOutboxHandler(svc OutboxService) *OutboxHandler {
+	return &OutboxHandler{
+		service: svc,
+	}
+}
+
+// List serves GET /admin/outbox?status=pending|failed, pending when left out
+func (h *OutboxHandler) List(w http.ResponseWriter, r *http.Request) {
+	events, err := h.service.List(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, events)
+}
+
+func (h *OutboxHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	event, err := h.service.Retry(ctx, userInfo, chi.URLParam(r, "id"))
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, event)
+}
@@ -0,0 +1,54 @@
+package outboxadmin
+
+import (
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+)
+
+// ListLimit caps GET /admin/outbox, a backlog past it shows on the gauges rather than in one response
+const ListLimit = 100
+
+const (
+	// StatusPending are events the relay is still trying
+	StatusPending = "pending"
+	// StatusFailed are events the relay gave up on after their last attempt
+	StatusFailed = "failed"
+)
+
+// OutboxEventsResponse is the oldest events with the status asked for
+type OutboxEventsResponse struct {
+	Status string                `json:"status"`
+	Events []OutboxEventResponse `json:"events"`
+}
+
+// OutboxEventResponse is an event that hasn't been published, without its payload
+type OutboxEventResponse struct {
+	ID            int64      `json:"id"`
+	Subject       string     `json:"subject"`
+	MsgID         string     `json:"msg_id"`
+	Attempts      int32      `json:"attempts"`
+	LastError     *string    `json:"last_error"`      // Null until a publish has failed
+	NextAttemptAt time.Time  `json:"next_attempt_at"` // Meaningless once failed_at is set
+	FailedAt      *time.Time `json:"failed_at"`       // Null while the relay is still trying
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func toEventResponse(e repo.ListOutboxEventsRow) OutboxEventResponse {
+	res := OutboxEventResponse{
+		ID:            e.ID,
+		Subject:       e.Subject,
+		MsgID:         e.MsgID,
+		Attempts:      e.Attempts,
+		NextAttemptAt: e.NextAttemptAt.Time.UTC(),
+		CreatedAt:     e.CreatedAt.Time.UTC(),
+	}
+	if e.LastError.Valid {
+		res.LastError = &e.LastError.String
+	}
+	if e.FailedAt.Valid {
+		failedAt := e.FailedAt.Time.UTC()
+		res.FailedAt = &failedAt
+	}
+	return res
+}
@@ -0,0 +1,82 @@
+package outboxadmin
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type OutboxService interface {
+	// List returns the oldest unpublished events with the status, pending or failed
+	List(ctx context.Context, status string) (*OutboxEventsResponse, error)
+	// Retry hands an unpublished event back to the relay for its next pass, with a fresh run of attempts
+	Retry(ctx context.Context, userInfo auth.UserInfo, id string) (*OutboxEventResponse, error)
+}
+
+type svc struct {
+	repo   *repo.Queries
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+func NewOutboxService(repo *repo.Queries, logger *slog.Logger) OutboxService {
+	return &svc{
+		repo:   repo,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+func (s *svc) List(ctx context.Context, status string) (*OutboxEventsResponse, error) {
+	if status == "" {
+		status = StatusPending
+	}
+	if status != StatusPending && status != StatusFailed {
+		return nil, errors.New(errors.ErrInvalidInput, "Status must be 'pending' or 'failed'", fmt.Errorf("unknown outbox status %q", status))
+	}
+
+	rows, err := s.repo.ListOutboxEvents(ctx, repo.ListOutboxEventsParams{Failed: status == StatusFailed, RowLimit: ListLimit})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to list outbox events", "status", status, "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch outbox events", err)
+	}
+
+	res := &OutboxEventsResponse{Status: status, Events: make([]OutboxEventResponse, 0, len(rows))}
+	for _, row := range rows {
+		res.Events = append(res.Events, toEventResponse(row))
+	}
+	return res, nil
+}
+
+func (s *svc) Retry(ctx context.Context, userInfo auth.UserInfo, id string) (*OutboxEventResponse, error) {
+	eventID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || eventID <= 0 {
+		return nil, errors.New(errors.ErrInvalidInput, "Invalid outbox event ID provided", fmt.Errorf("invalid outbox event id %q", id))
+	}
+
+	row, err := s.repo.RetryOutboxEvent(ctx, repo.RetryOutboxEventParams{
+		Now: pgtype.Timestamptz{Time: s.now(), Valid: true},
+		ID:  eventID,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			// Published since, and possibly pruned
+			return nil, errors.New(errors.ErrNotFound, "Outbox event not found or already published", fmt.Errorf("outbox event %d not found", eventID))
+		}
+		s.logger.ErrorContext(ctx, "Failed to retry outbox event", "id", eventID, "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to retry outbox event", err)
+	}
+
+	s.logger.WarnContext(ctx, "Outbox event retried", "id", eventID, "subject", row.Subject, "user_id", userInfo.ID, "username", userInfo.Username)
+	res := toEventResponse(repo.ListOutboxEventsRow(row))
+	return &res, nil
+}
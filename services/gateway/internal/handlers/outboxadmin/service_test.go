@@ -0,0 +1,140 @@
+package outboxadmin
+
+import (
+	"context"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/testutil"
+	"regexp"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	admin = auth.UserInfo{ID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", Username: "admin", Roles: []string{auth.RoleAdmin}}
+	now   = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+)
+
+var eventCols = []string{"id", "subject", "msg_id", "attempts", "next_attempt_at", "last_error", "failed_at", "created_at"}
+
+func newTestService(t *testing.T) (*svc, pgxmock.PgxPoolIface) {
+	mockPool := testutil.NewMockDB(t)
+	service := NewOutboxService(repo.New(mockPool), testutil.NewTestLogger()).(*svc)
+	service.now = func() time.Time { return now }
+	return service, mockPool
+}
+
+func timestamptz(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
+
+// failedRow is event 7, given up on after 15 attempts
+func failedRow() *pgxmock.Rows {
+	return pgxmock.NewRows(eventCols).AddRow(
+		int64(7), "listings.created", "created.abc123", int32(15), timestamptz(now.Add(5*time.Minute)),
+		pgtype.Text{String: "nats: no response from stream", Valid: true}, timestamptz(now.Add(-time.Minute)), timestamptz(now.Add(-time.Hour)),
+	)
+}
+
+func TestList(t *testing.T) {
+	// SCENARIO: An admin lists pending events, with no status, and then the failed ones.
+	// EXPECT: Each status reads its own rows. A pending event that hasn't failed yet has no error or failed_at, the
+	// failed one has both and its attempt count.
+
+	service, mockPool := newTestService(t)
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListOutboxEvents :many`)).
+		WithArgs(false, int32(ListLimit)).
+		WillReturnRows(pgxmock.NewRows(eventCols).AddRow(
+			int64(8), "index.listing", "index.abc123", int32(0), timestamptz(now), pgtype.Text{}, pgtype.Timestamptz{}, timestamptz(now),
+		))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListOutboxEvents :many`)).
+		WithArgs(true, int32(ListLimit)).
+		WillReturnRows(failedRow())
+
+	pending, err := service.List(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, pending.Status)
+	require.Len(t, pending.Events, 1)
+	assert.Equal(t, int64(8), pending.Events[0].ID)
+	assert.Nil(t, pending.Events[0].LastError)
+	assert.Nil(t, pending.Events[0].FailedAt)
+
+	failed, err := service.List(context.Background(), StatusFailed)
+	require.NoError(t, err)
+	require.Len(t, failed.Events, 1)
+	event := failed.Events[0]
+	assert.Equal(t, int32(15), event.Attempts)
+	require.NotNil(t, event.LastError)
+	assert.Equal(t, "nats: no response from stream", *event.LastError)
+	require.NotNil(t, event.FailedAt)
+	assert.Equal(t, now.Add(-time.Minute), *event.FailedAt)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestList_UnknownStatus(t *testing.T) {
+	service, _ := newTestService(t)
+
+	_, err := service.List(context.Background(), "published")
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+}
+
+func TestRetry(t *testing.T) {
+	// SCENARIO: An admin retries an event the relay gave up on.
+	// EXPECT: It's due now with its attempts reset and failed_at cleared, the last error kept until it goes out.
+
+	service, mockPool := newTestService(t)
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: RetryOutboxEvent :one`)).
+		WithArgs(timestamptz(now), int64(7)).
+		WillReturnRows(pgxmock.NewRows(eventCols).AddRow(
+			int64(7), "listings.created", "created.abc123", int32(0), timestamptz(now),
+			pgtype.Text{String: "nats: no response from stream", Valid: true}, pgtype.Timestamptz{}, timestamptz(now.Add(-time.Hour)),
+		))
+
+	event, err := service.Retry(context.Background(), admin, "7")
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), event.Attempts)
+	assert.Equal(t, now, event.NextAttemptAt)
+	assert.Nil(t, event.FailedAt)
+	require.NotNil(t, event.LastError)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRetry_AlreadyPublished(t *testing.T) {
+	// SCENARIO: The event went out, or was pruned, before the admin got to it.
+	// EXPECT: 404, nothing to retry.
+
+	service, mockPool := newTestService(t)
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: RetryOutboxEvent :one`)).
+		WithArgs(timestamptz(now), int64(7)).
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err := service.Retry(context.Background(), admin, "7")
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrNotFound, appErr.Code)
+}
+
+func TestRetry_InvalidID(t *testing.T) {
+	service, _ := newTestService(t)
+
+	for _, id := range []string{"abc", "0", "-1"} {
+		_, err := service.Retry(context.Background(), admin, id)
+
+		var appErr *errors.AppError
+		require.ErrorAs(t, err, &appErr, id)
+		assert.Equal(t, errors.ErrInvalidInput, appErr.Code, id)
+	}
+}
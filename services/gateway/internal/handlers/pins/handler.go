@@ -0,0 +1,79 @@
+package pins
+
+import (
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type PinsHandler struct {
+	service PinsService
+}
+
+func NewPinsHandler(svc PinsService) *PinsHandler {
+	return &PinsHandler{
+		service: svc,
+	}
+}
+
+func (h *PinsHandler) Pin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	// Without a body the listing goes after the other pins
+	req := PinListingRequest{}
+	if r.ContentLength != 0 {
+		if err := json.Read(r, &req); err != nil {
+			slog.WarnContext(ctx, "Invalid request body", "error", err)
+			errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Input provided was not in the format expected. Please contact support if this error persists.", err))
+			return
+		}
+	}
+
+	pins, err := h.service.Pin(ctx, userInfo, chi.URLParam(r, "id"), &req)
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, pins)
+}
+
+func (h *PinsHandler) Unpin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	pins, err := h.service.Unpin(ctx, userInfo, chi.URLParam(r, "id"))
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, pins)
+}
+
+// GetStorefront serves a seller's public profile page, anonymously. Not cacheable by browsers, a seller checking their
+// page after changing their pins must see the change.
+func (h *PinsHandler) GetStorefront(w http.ResponseWriter, r *http.Request) {
+	storefront, err := h.service.GetStorefront(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, storefront)
+}
@@ -0,0 +1,41 @@
+package pins
+
+import (
+	"fmt"
+	"gateway/internal/errors"
+	"gateway/internal/handlers/listings"
+	"strconv"
+)
+
+const (
+	// MaxPins is how many listings a seller can pin to the top of their storefront
+	MaxPins = 4
+	// StorefrontLimit is how many listings GET /sellers/{id}/listings returns, the pinned ones included. Search
+	// filtered on the seller has the rest.
+	StorefrontLimit = 48
+)
+
+// PinListingRequest pins a listing, or moves one that is already pinned. The body is optional.
+type PinListingRequest struct {
+	Position *int `json:"position"` // 1 to MaxPins, the listings from there on move down one. After the other pins when left out.
+}
+
+// PinsResponse is the seller's pins after a change, the first shown first
+type PinsResponse struct {
+	ListingIDs []string `json:"listing_ids"`
+}
+
+// StorefrontResponse is GET /sellers/{id}/listings, the seller's published listings as GET /listings/{id} serves them
+type StorefrontResponse struct {
+	Listings    []listings.ListingResponse `json:"listings"`
+	PinnedCount int                        `json:"pinned_count"` // The first PinnedCount listings are pinned
+}
+
+func (req *PinListingRequest) Validate() *errors.AppError {
+	if req.Position != nil && (*req.Position < 1 || *req.Position > MaxPins) {
+		return errors.New(errors.ErrInvalidInput, fmt.Sprintf("position must be between 1 and %d", MaxPins), nil).
+			WithReason(errors.ReasonPinPositionInvalid).
+			WithParam("max", strconv.Itoa(MaxPins))
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package pins
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/auth"
+	"gateway/internal/database/postgresql"
+	"gateway/internal/errors"
+	"gateway/internal/handlers/listings"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// StorefrontCacheTTL is how long a storefront's order is cached, and so how late a newly published listing can show
+// up there. Pin changes drop it straight away.
+const StorefrontCacheTTL = 5 * time.Minute
+
+type PinsService interface {
+	Pin(ctx context.Context, userInfo auth.UserInfo, listingID string, req *PinListingRequest) (*PinsResponse, error)
+	Unpin(ctx context.Context, userInfo auth.UserInfo, listingID string) (*PinsResponse, error)
+	// GetStorefront is the seller's published listings, the pinned ones first
+	GetStorefront(ctx context.Context, sellerID string) (*StorefrontResponse, error)
+}
+
+// ListingsReader hydrates listings the way GET /listings/{id} serves them, see listings.ListingsService
+type ListingsReader interface {
+	GetListingsByIDs(ctx context.Context, listingIDs []string) ([]listings.ListingResponse, error)
+}
+
+type svc struct {
+	db         postgresql.DBPool
+	repo       *repo.Queries
+	listings   ListingsReader
+	storefront StorefrontStore
+	logger     *slog.Logger
+}
+
+func NewPinsService(db postgresql.DBPool, repo *repo.Queries, listings ListingsReader, storefront StorefrontStore, logger *slog.Logger) PinsService {
+	return &svc{
+		db:         db,
+		repo:       repo,
+		listings:   listings,
+		storefront: storefront,
+		logger:     logger,
+	}
+}
+
+// Pin adds the listing to the seller's pins, or moves it when it is already pinned. Only published listings can be
+// pinned, and no more than MaxPins of them.
+func (s *svc) Pin(ctx context.Context, userInfo auth.UserInfo, listingID string, req *PinListingRequest) (*PinsResponse, error) {
+	if appErr := req.Validate(); appErr != nil {
+		return nil, appErr
+	}
+
+	sellerUUID, listing, err := s.ownListing(ctx, userInfo, listingID)
+	if err != nil {
+		return nil, err
+	}
+	if listing.Status.ListingStatus != repo.ListingStatusACTIVE {
+		return nil, errors.New(errors.ErrConflict, "Only published listings can be pinned", fmt.Errorf("listing %v is %s", listingID, listing.Status.ListingStatus)).
+			WithReason(errors.ReasonPinListingNotActive)
+	}
+
+	return s.updatePins(ctx, sellerUUID, func(pins []pgtype.UUID) ([]pgtype.UUID, error) {
+		pins = slices.DeleteFunc(pins, func(id pgtype.UUID) bool { return id == listing.ID })
+		if len(pins) >= MaxPins {
+			return nil, errors.New(errors.ErrConflict, fmt.Sprintf("You can pin up to %d listings", MaxPins), nil).
+				WithReason(errors.ReasonPinLimit).
+				WithParam("limit", strconv.Itoa(MaxPins))
+		}
+
+		at := len(pins)
+		if req.Position != nil {
+			at = min(*req.Position-1, len(pins))
+		}
+		return slices.Insert(pins, at, listing.ID), nil
+	})
+}
+
+// Unpin takes the listing off the seller's pins, the ones after it move up. Unpinning a listing that isn't pinned
+// changes nothing.
+func (s *svc) Unpin(ctx context.Context, userInfo auth.UserInfo, listingID string) (*PinsResponse, error) {
+	sellerUUID, listing, err := s.ownListing(ctx, userInfo, listingID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.updatePins(ctx, sellerUUID, func(pins []pgtype.UUID) ([]pgtype.UUID, error) {
+		return slices.DeleteFunc(pins, func(id pgtype.UUID) bool { return id == listing.ID }), nil
+	})
+}
+
+// updatePins rewrites the seller's pins as change orders them, under a lock on the seller's ID so two changes can't
+// both go under the limit or race into the same positions. The storefront is dropped from the cache once the change is committed.
+func (s *svc) updatePins(ctx context.Context, sellerID pgtype.UUID, change func(pins []pgtype.UUID) ([]pgtype.UUID, error)) (*PinsResponse, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to begin transaction", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to save pins", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := s.repo.WithTx(tx)
+
+	if err := qtx.LockSellerPins(ctx, sellerID); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to lock seller", "seller_id", sellerID.String(), "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to save pins", err)
+	}
+	current, err := qtx.GetSellerPins(ctx, sellerID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to fetch pins", "seller_id", sellerID.String(), "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to save pins", err)
+	}
+
+	pins, err := change(slices.Clone(current))
+	if err != nil {
+		return nil, err
+	}
+
+	// Rewritten even when nothing moved, it drops pins of listings that are no longer published
+	if err := qtx.DeleteSellerPins(ctx, sellerID); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to clear pins", "seller_id", sellerID.String(), "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to save pins", err)
+	}
+	if len(pins) > 0 {
+		if err := qtx.CreateSellerPins(ctx, repo.CreateSellerPinsParams{SellerID: sellerID, ListingIds: pins}); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to save pins", "seller_id", sellerID.String(), "error", err)
+			return nil, errors.New(errors.ErrInternal, "Failed to save pins", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to commit pins", "seller_id", sellerID.String(), "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to save pins", err)
+	}
+
+	if err := s.storefront.DelStorefront(ctx, sellerID.String()); err != nil {
+		// The old order is served until StorefrontCacheTTL runs out
+		s.logger.ErrorContext(ctx, "Failed to bust storefront cache", "seller_id", sellerID.String(), "error", err)
+	}
+
+	resp := &PinsResponse{ListingIDs: make([]string, len(pins))}
+	for i, id := range pins {
+		resp.ListingIDs[i] = fmt.Sprintf("%x", id.Bytes)
+	}
+	s.logger.InfoContext(ctx, "Seller pins saved", "seller_id", sellerID.String(), "pins", resp.ListingIDs)
+	return resp, nil
+}
+
+func (s *svc) GetStorefront(ctx context.Context, sellerID string) (*StorefrontResponse, error) {
+	var sellerUUID pgtype.UUID
+	if err := sellerUUID.Scan(sellerID); err != nil {
+		return nil, errors.New(errors.ErrInvalidInput, "Invalid seller ID provided", err)
+	}
+	// Keyed on the dashed ID, the same seller is asked about with and without dashes
+	key := sellerUUID.String()
+
+	storefront, found, err := s.storefront.GetStorefront(ctx, key)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to get storefront from cache", "seller_id", key, "error", err)
+	}
+	if !found {
+		if storefront, err = s.loadStorefront(ctx, sellerUUID); err != nil {
+			return nil, err
+		}
+		if err := s.storefront.SetStorefront(ctx, key, *storefront, StorefrontCacheTTL); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to cache storefront", "seller_id", key, "error", err)
+		}
+	}
+
+	hydrated, err := s.listings.GetListingsByIDs(ctx, storefront.ListingIDs)
+	if err != nil {
+		return nil, err
+	}
+	// Listings deleted since the order was cached are left out by GetListingsByIDs, unpublished ones here
+	resp := &StorefrontResponse{Listings: make([]listings.ListingResponse, 0, len(hydrated))}
+	pinned := storefront.ListingIDs[:storefront.PinnedCount]
+	for _, listing := range hydrated {
+		if listing.Status != string(repo.ListingStatusACTIVE) {
+			continue
+		}
+		resp.Listings = append(resp.Listings, listing)
+		if slices.Contains(pinned, listing.ID) {
+			resp.PinnedCount++
+		}
+	}
+	return resp, nil
+}
+
+func (s *svc) loadStorefront(ctx context.Context, sellerID pgtype.UUID) (*Storefront, error) {
+	rows, err := s.repo.GetStorefrontListingIDs(ctx, repo.GetStorefrontListingIDsParams{SellerID: sellerID, RowLimit: StorefrontLimit})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to fetch storefront", "seller_id", sellerID.String(), "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch the seller's listings", err)
+	}
+
+	storefront := &Storefront{ListingIDs: make([]string, len(rows))}
+	for i, row := range rows {
+		storefront.ListingIDs[i] = fmt.Sprintf("%x", row.ID.Bytes) // As listing responses have it
+		if row.PinnedPosition.Valid {
+			storefront.PinnedCount++
+		}
+	}
+	return storefront, nil
+}
+
+// ownListing reads the listing, which has to exist and belong to the user
+func (s *svc) ownListing(ctx context.Context, userInfo auth.UserInfo, listingID string) (pgtype.UUID, repo.Listing, error) {
+	var userUUID, listingUUID pgtype.UUID
+	if err := userUUID.Scan(userInfo.ID); err != nil {
+		return userUUID, repo.Listing{}, errors.New(errors.ErrInternal, "Invalid user ID", fmt.Errorf("invalid user uuid: %w", err))
+	}
+	if err := listingUUID.Scan(listingID); err != nil {
+		return userUUID, repo.Listing{}, errors.New(errors.ErrInvalidInput, "Invalid listing ID provided", err)
+	}
+
+	listing, err := s.repo.GetListingByID(ctx, listingUUID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return userUUID, listing, errors.New(errors.ErrNotFound, "Listing not found", fmt.Errorf("listing %v not found", listingID)).WithReason(errors.ReasonListingNotFound)
+		}
+		s.logger.ErrorContext(ctx, "Failed to fetch listing", "listing_id", listingID, "error", err)
+		return userUUID, listing, errors.New(errors.ErrInternal, "Failed to fetch listing", fmt.Errorf("failed to fetch listing %v: %w", listingID, err))
+	}
+	if listing.SellerID != userUUID {
+		return userUUID, listing, errors.New(errors.ErrUnauthorized, "You do not own this listing", fmt.Errorf("user %v doesn't own listing %v", userInfo.ID, listingID)).WithReason(errors.ReasonListingNotOwner)
+	}
+	return userUUID, listing, nil
+}
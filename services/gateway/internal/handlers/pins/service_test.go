@@ -0,0 +1,301 @@
+package pins
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/handlers/listings"
+	"gateway/internal/testutil"
+	"gateway/internal/testutil/fixtures"
+	"regexp"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	sellerID      = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	otherSellerID = "b0eebc99-9c0b-4ef8-bb6d-6bb9bd380a22"
+	listingA      = "11111111-1111-1111-1111-111111111111"
+	listingB      = "22222222-2222-2222-2222-222222222222"
+	listingC      = "33333333-3333-3333-3333-333333333333"
+	listingD      = "44444444-4444-4444-4444-444444444444"
+	listingE      = "55555555-5555-5555-5555-555555555555"
+)
+
+var sellerInfo = auth.UserInfo{ID: sellerID, Username: "benchy-maker"}
+
+// fakeStore is a StorefrontStore in memory
+type fakeStore struct {
+	storefronts map[string]Storefront
+	deleted     []string
+}
+
+func (f *fakeStore) GetStorefront(_ context.Context, sellerID string) (*Storefront, bool, error) {
+	storefront, found := f.storefronts[sellerID]
+	return &storefront, found, nil
+}
+
+func (f *fakeStore) SetStorefront(_ context.Context, sellerID string, storefront Storefront, _ time.Duration) error {
+	if f.storefronts == nil {
+		f.storefronts = map[string]Storefront{}
+	}
+	f.storefronts[sellerID] = storefront
+	return nil
+}
+
+func (f *fakeStore) DelStorefront(_ context.Context, sellerID string) error {
+	delete(f.storefronts, sellerID)
+	f.deleted = append(f.deleted, sellerID)
+	return nil
+}
+
+// fakeListings hydrates every ID it is asked for as an ACTIVE listing, unless it is in unpublished
+type fakeListings struct {
+	unpublished map[string]bool
+}
+
+func (f *fakeListings) GetListingsByIDs(_ context.Context, listingIDs []string) ([]listings.ListingResponse, error) {
+	resp := make([]listings.ListingResponse, len(listingIDs))
+	for i, id := range listingIDs {
+		status := repo.ListingStatusACTIVE
+		if f.unpublished[id] {
+			status = repo.ListingStatusHIDDEN
+		}
+		resp[i] = listings.ListingResponse{ID: id, Status: string(status)}
+	}
+	return resp, nil
+}
+
+type fixture struct {
+	service  *svc
+	mockPool pgxmock.PgxPoolIface
+	store    *fakeStore
+	listings *fakeListings
+}
+
+func newTestService(t *testing.T) fixture {
+	f := fixture{
+		mockPool: testutil.NewMockDB(t),
+		store:    &fakeStore{},
+		listings: &fakeListings{},
+	}
+	f.service = NewPinsService(f.mockPool, repo.New(f.mockPool), f.listings, f.store, testutil.NewTestLogger()).(*svc)
+	return f
+}
+
+// hex is a listing ID the way responses have it
+func hex(id string) string {
+	return fmt.Sprintf("%x", fixtures.UUID(id).Bytes)
+}
+
+func expectListing(mockPool pgxmock.PgxPoolIface, id, seller string, status repo.ListingStatus) {
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingByID :one`)).
+		WithArgs(fixtures.UUID(id)).
+		WillReturnRows(fixtures.ListingRows(fixtures.NewListing(fixtures.WithID(id), fixtures.WithSeller(seller), fixtures.WithStatus(status))))
+}
+
+// expectPins expects the seller to be locked and their current pins read, in order
+func expectPins(mockPool pgxmock.PgxPoolIface, current ...string) {
+	mockPool.ExpectBegin()
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: LockSellerPins`)).
+		WithArgs(fixtures.UUID(sellerID)).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	rows := pgxmock.NewRows([]string{"listing_id"})
+	for _, id := range current {
+		rows.AddRow(fixtures.UUID(id))
+	}
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetSellerPins`)).
+		WithArgs(fixtures.UUID(sellerID)).
+		WillReturnRows(rows)
+}
+
+// expectSaved expects the seller's pins to be rewritten as saved, in order
+func expectSaved(mockPool pgxmock.PgxPoolIface, saved ...string) {
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: DeleteSellerPins`)).
+		WithArgs(fixtures.UUID(sellerID)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	if len(saved) > 0 {
+		ids := make([]pgtype.UUID, len(saved))
+		for i, id := range saved {
+			ids[i] = fixtures.UUID(id)
+		}
+		mockPool.ExpectExec(regexp.QuoteMeta(`-- name: CreateSellerPins`)).
+			WithArgs(fixtures.UUID(sellerID), ids).
+			WillReturnResult(pgxmock.NewResult("INSERT", int64(len(saved))))
+	}
+	mockPool.ExpectCommit()
+}
+
+func hexes(ids ...string) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = hex(id)
+	}
+	return out
+}
+
+func TestPin(t *testing.T) {
+	one, three := 1, 3
+	tests := []struct {
+		name     string
+		position *int
+		listing  string
+		current  []string
+		want     []string
+	}{
+		{name: "First pin", listing: listingA, want: []string{listingA}},
+		{name: "Goes after the others", listing: listingC, current: []string{listingA, listingB}, want: []string{listingA, listingB, listingC}},
+		{name: "At the top", listing: listingC, position: &one, current: []string{listingA, listingB}, want: []string{listingC, listingA, listingB}},
+		{name: "Position past the end", listing: listingC, position: &three, current: []string{listingA}, want: []string{listingA, listingC}},
+		{name: "Moved up", listing: listingC, position: &one, current: []string{listingA, listingB, listingC}, want: []string{listingC, listingA, listingB}},
+		{name: "Moved down", listing: listingA, position: &three, current: []string{listingA, listingB, listingC}, want: []string{listingB, listingC, listingA}},
+		{name: "Already pinned with four", listing: listingD, current: []string{listingA, listingB, listingC, listingD}, want: []string{listingA, listingB, listingC, listingD}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// SCENARIO: The seller pins a published listing, or moves one they already pinned.
+			// EXPECT: The pins are rewritten in the new order and the storefront is dropped from the cache.
+
+			f := newTestService(t)
+			expectListing(f.mockPool, tt.listing, sellerID, repo.ListingStatusACTIVE)
+			expectPins(f.mockPool, tt.current...)
+			expectSaved(f.mockPool, tt.want...)
+
+			pins, err := f.service.Pin(context.Background(), sellerInfo, tt.listing, &PinListingRequest{Position: tt.position})
+
+			require.NoError(t, err)
+			assert.Equal(t, hexes(tt.want...), pins.ListingIDs)
+			assert.Equal(t, []string{sellerID}, f.store.deleted)
+			assert.NoError(t, f.mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPin_Limit(t *testing.T) {
+	// SCENARIO: The seller already has four listings pinned and pins a fifth.
+	// EXPECT: 409 PIN_LIMIT, nothing is written and the cached storefront stays.
+
+	f := newTestService(t)
+	expectListing(f.mockPool, listingE, sellerID, repo.ListingStatusACTIVE)
+	expectPins(f.mockPool, listingA, listingB, listingC, listingD)
+	f.mockPool.ExpectRollback()
+
+	_, err := f.service.Pin(context.Background(), sellerInfo, listingE, &PinListingRequest{})
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrConflict, appErr.Code)
+	assert.Equal(t, errors.ReasonPinLimit, appErr.Reason)
+	assert.Empty(t, f.store.deleted)
+	assert.NoError(t, f.mockPool.ExpectationsWereMet())
+}
+
+func TestPin_Rejected(t *testing.T) {
+	seven := 7
+	tests := []struct {
+		name     string
+		seller   string
+		status   repo.ListingStatus
+		position *int
+		code     errors.ErrorCode
+		reason   errors.Reason
+	}{
+		{name: "Unpublished", seller: sellerID, status: repo.ListingStatusHIDDEN, code: errors.ErrConflict, reason: errors.ReasonPinListingNotActive},
+		{name: "Still validating", seller: sellerID, status: repo.ListingStatusPENDINGVALIDATION, code: errors.ErrConflict, reason: errors.ReasonPinListingNotActive},
+		{name: "Another seller's", seller: otherSellerID, status: repo.ListingStatusACTIVE, code: errors.ErrUnauthorized, reason: errors.ReasonListingNotOwner},
+		{name: "Position out of range", position: &seven, code: errors.ErrInvalidInput, reason: errors.ReasonPinPositionInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// SCENARIO: The seller pins a listing that can't be pinned.
+			// EXPECT: The request is rejected before the pins are touched.
+
+			f := newTestService(t)
+			if tt.position == nil {
+				expectListing(f.mockPool, listingA, tt.seller, tt.status)
+			}
+
+			_, err := f.service.Pin(context.Background(), sellerInfo, listingA, &PinListingRequest{Position: tt.position})
+
+			var appErr *errors.AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, tt.code, appErr.Code)
+			assert.Equal(t, tt.reason, appErr.Reason)
+			assert.Empty(t, f.store.deleted)
+			assert.NoError(t, f.mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUnpin(t *testing.T) {
+	// SCENARIO: The seller unpins the second of three pins.
+	// EXPECT: The third moves up and the storefront is dropped from the cache.
+
+	f := newTestService(t)
+	expectListing(f.mockPool, listingB, sellerID, repo.ListingStatusACTIVE)
+	expectPins(f.mockPool, listingA, listingB, listingC)
+	expectSaved(f.mockPool, listingA, listingC)
+
+	pins, err := f.service.Unpin(context.Background(), sellerInfo, listingB)
+
+	require.NoError(t, err)
+	assert.Equal(t, hexes(listingA, listingC), pins.ListingIDs)
+	assert.Equal(t, []string{sellerID}, f.store.deleted)
+	assert.NoError(t, f.mockPool.ExpectationsWereMet())
+}
+
+func TestGetStorefront_PinnedFirst(t *testing.T) {
+	// SCENARIO: A buyer opens the profile page of a seller with two pinned listings.
+	// EXPECT: The pinned listings come first in their order, the order is cached and served from there next time.
+
+	f := newTestService(t)
+	f.mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetStorefrontListingIDs`)).
+		WithArgs(fixtures.UUID(sellerID), int32(StorefrontLimit)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "pinned_position"}).
+			AddRow(fixtures.UUID(listingC), pgtype.Int2{Int16: 1, Valid: true}).
+			AddRow(fixtures.UUID(listingA), pgtype.Int2{Int16: 2, Valid: true}).
+			AddRow(fixtures.UUID(listingD), pgtype.Int2{}).
+			AddRow(fixtures.UUID(listingB), pgtype.Int2{}))
+
+	storefront, err := f.service.GetStorefront(context.Background(), sellerID)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, storefront.PinnedCount)
+	ids := make([]string, len(storefront.Listings))
+	for i, listing := range storefront.Listings {
+		ids[i] = listing.ID
+	}
+	assert.Equal(t, hexes(listingC, listingA, listingD, listingB), ids)
+
+	// Without dashes it's the same seller and the same cache entry
+	again, err := f.service.GetStorefront(context.Background(), hex(sellerID))
+	require.NoError(t, err)
+	assert.Equal(t, storefront, again)
+	assert.NoError(t, f.mockPool.ExpectationsWereMet())
+}
+
+func TestGetStorefront_UnpublishedSinceCached(t *testing.T) {
+	// SCENARIO: A pinned listing was unpublished after the storefront order was cached.
+	// EXPECT: It's left out and no longer counted as pinned.
+
+	f := newTestService(t)
+	f.store.storefronts = map[string]Storefront{sellerID: {ListingIDs: hexes(listingA, listingB, listingC), PinnedCount: 2}}
+	f.listings.unpublished = map[string]bool{hex(listingA): true}
+
+	storefront, err := f.service.GetStorefront(context.Background(), sellerID)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, storefront.PinnedCount)
+	require.Len(t, storefront.Listings, 2)
+	assert.Equal(t, hex(listingB), storefront.Listings[0].ID)
+}
@@ -0,0 +1,42 @@
+package pins
+
+import (
+	"context"
+	"gateway/internal/cache"
+	"time"
+)
+
+const storefrontKeyPrefix = "storefront:"
+
+// Storefront is the order of a seller's storefront. Only the IDs are cached, the listings come from the listing cache
+// so an edit shows up without waiting for this to expire.
+type Storefront struct {
+	ListingIDs  []string `json:"listing_ids"`
+	PinnedCount int      `json:"pinned_count"`
+}
+
+type StorefrontStore interface {
+	GetStorefront(ctx context.Context, sellerID string) (*Storefront, bool, error)
+	SetStorefront(ctx context.Context, sellerID string, storefront Storefront, ttl time.Duration) error
+	DelStorefront(ctx context.Context, sellerID string) error
+}
+
+type Store struct {
+	cache *cache.RedisClient
+}
+
+func NewStore(c *cache.RedisClient) *Store {
+	return &Store{cache: c}
+}
+
+func (s *Store) GetStorefront(ctx context.Context, sellerID string) (*Storefront, bool, error) {
+	return cache.Get[Storefront](s.cache, ctx, storefrontKeyPrefix+sellerID)
+}
+
+func (s *Store) SetStorefront(ctx context.Context, sellerID string, storefront Storefront, ttl time.Duration) error {
+	return cache.Set(s.cache, ctx, storefrontKeyPrefix+sellerID, storefront, ttl)
+}
+
+func (s *Store) DelStorefront(ctx context.Context, sellerID string) error {
+	return cache.Del(s.cache, ctx, storefrontKeyPrefix+sellerID)
+}
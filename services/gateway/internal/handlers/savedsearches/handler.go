@@ -0,0 +1,83 @@
+package savedsearches
+
+import (
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type SavedSearchesHandler struct {
+	service SavedSearchesService
+}
+
+func NewSavedSearchesHandler(svc SavedSearchesService) *SavedSearchesHandler {
+	return &SavedSearchesHandler{
+		service: svc,
+	}
+}
+
+func (h *SavedSearchesHandler) Save(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	req := SaveSearchRequest{}
+	if err := json.Read(r, &req); err != nil {
+		slog.WarnContext(ctx, "Invalid request body", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Input provided was not in the format expected. Please contact support if this error persists.", err))
+		return
+	}
+
+	req.acceptLanguage = r.Header.Get("Accept-Language")
+
+	saved, err := h.service.Save(ctx, userInfo, &req)
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusCreated, saved)
+}
+
+func (h *SavedSearchesHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	searches, err := h.service.List(ctx, userInfo)
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, searches)
+}
+
+func (h *SavedSearchesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	if err := h.service.Delete(ctx, userInfo, chi.URLParam(r, "id")); err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,69 @@
+package savedsearches
+
+import (
+	"fmt"
+	"gateway/internal/errors"
+	"gateway/internal/searchfilter"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxSavedSearches is how many searches a user can save, each one is run against Typesense every check interval
+	MaxSavedSearches = 20
+	maxQueryLength   = 200
+)
+
+// SaveSearchRequest is a search from the browse page. Filters are the same params the page puts in its query
+// string, e.g. {"category": ["functional"], "max_z_mm": ["220"]}.
+type SaveSearchRequest struct {
+	Query   string              `json:"query"`
+	Filters map[string][]string `json:"filters"`
+
+	// The Accept-Language the search was saved with, lang defaults to it like it does on the browse page
+	acceptLanguage string
+}
+
+type SavedSearchResponse struct {
+	ID            string              `json:"id"`
+	Query         string              `json:"query"`
+	Filters       map[string][]string `json:"filters"`
+	CreatedAt     time.Time           `json:"created_at"`
+	LastCheckedAt time.Time           `json:"last_checked_at"`
+}
+
+type SavedSearchesResponse struct {
+	SavedSearches []SavedSearchResponse `json:"saved_searches"`
+}
+
+// Validate normalizes the query and filters, so the same search saved twice is stored the same way, and builds the
+// filter_by the worker runs it with
+func (req *SaveSearchRequest) Validate() (searchfilter.Filter, *errors.AppError) {
+	req.Query = strings.ToLower(strings.Join(strings.Fields(req.Query), " "))
+	if utf8.RuneCountInString(req.Query) > maxQueryLength {
+		return searchfilter.Filter{}, errors.New(errors.ErrInvalidInput, fmt.Sprintf("Search terms can be at most %d characters", maxQueryLength), nil).
+			WithReason(errors.ReasonSavedSearchQueryLength).
+			WithParam("max", strconv.Itoa(maxQueryLength))
+	}
+
+	filters := searchfilter.Normalize(url.Values(req.Filters))
+	filter, appErr := searchfilter.Parse(filters)
+	if appErr != nil {
+		return searchfilter.Filter{}, appErr
+	}
+
+	if req.Query == "" && filter.IsZero() {
+		return searchfilter.Filter{}, errors.New(errors.ErrInvalidInput, "A saved search needs a query or at least one filter", nil).WithReason(errors.ReasonSavedSearchEmpty)
+	}
+
+	// Only after the empty check, the browser's language on its own isn't a search worth saving
+	filters = searchfilter.DefaultLanguage(filters, req.acceptLanguage)
+	if filter, appErr = searchfilter.Parse(filters); appErr != nil {
+		return searchfilter.Filter{}, appErr
+	}
+	req.Filters = filters
+	return filter, nil
+}
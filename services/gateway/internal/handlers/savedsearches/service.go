@@ -0,0 +1,164 @@
+package savedsearches
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gateway/internal/auth"
+	"gateway/internal/database/postgresql"
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type SavedSearchesService interface {
+	Save(ctx context.Context, userInfo auth.UserInfo, req *SaveSearchRequest) (*SavedSearchResponse, error)
+	List(ctx context.Context, userInfo auth.UserInfo) (*SavedSearchesResponse, error)
+	Delete(ctx context.Context, userInfo auth.UserInfo, id string) error
+}
+
+type svc struct {
+	db     postgresql.DBPool
+	repo   *repo.Queries
+	logger *slog.Logger
+}
+
+func NewSavedSearchesService(db postgresql.DBPool, repo *repo.Queries, logger *slog.Logger) SavedSearchesService {
+	return &svc{
+		db:     db,
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *svc) Save(ctx context.Context, userInfo auth.UserInfo, req *SaveSearchRequest) (*SavedSearchResponse, error) {
+	filter, appErr := req.Validate()
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	userUUID, err := userID(userInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	filters, err := json.Marshal(req.Filters)
+	if err != nil {
+		return nil, errors.New(errors.ErrInternal, "Failed to save search", err)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to begin transaction", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to save search", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := s.repo.WithTx(tx)
+
+	// Held until commit, so the count can't change before the insert
+	if err := qtx.LockUserSavedSearches(ctx, userUUID); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to lock saved searches", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to save search", err)
+	}
+	count, err := qtx.CountSavedSearches(ctx, userUUID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to count saved searches", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to save search", err)
+	}
+	if count >= MaxSavedSearches {
+		return nil, errors.New(errors.ErrConflict, fmt.Sprintf("You can save up to %d searches", MaxSavedSearches), nil).
+			WithReason(errors.ReasonSavedSearchLimit).
+			WithParam("limit", strconv.Itoa(MaxSavedSearches))
+	}
+
+	saved, err := qtx.CreateSavedSearch(ctx, repo.CreateSavedSearchParams{
+		UserID:   userUUID,
+		Query:    req.Query,
+		Filters:  filters,
+		FilterBy: filter.String(),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New(errors.ErrConflict, "You already saved this search", nil).WithReason(errors.ReasonSavedSearchExists)
+		}
+		s.logger.ErrorContext(ctx, "Failed to save search", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to save search", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to commit saved search", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to save search", err)
+	}
+
+	s.logger.InfoContext(ctx, "Search saved", "saved_search_id", saved.ID.String(), "user_id", userInfo.ID)
+	return toResponse(saved), nil
+}
+
+func (s *svc) List(ctx context.Context, userInfo auth.UserInfo) (*SavedSearchesResponse, error) {
+	userUUID, err := userID(userInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.ListSavedSearches(ctx, userUUID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to list saved searches", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch saved searches", err)
+	}
+
+	resp := &SavedSearchesResponse{SavedSearches: make([]SavedSearchResponse, 0, len(rows))}
+	for _, row := range rows {
+		resp.SavedSearches = append(resp.SavedSearches, *toResponse(row))
+	}
+	return resp, nil
+}
+
+func (s *svc) Delete(ctx context.Context, userInfo auth.UserInfo, id string) error {
+	userUUID, err := userID(userInfo)
+	if err != nil {
+		return err
+	}
+
+	var searchUUID pgtype.UUID
+	if err := searchUUID.Scan(id); err != nil {
+		return notFound()
+	}
+
+	deleted, err := s.repo.DeleteSavedSearch(ctx, repo.DeleteSavedSearchParams{ID: searchUUID, UserID: userUUID})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to delete saved search", "error", err)
+		return errors.New(errors.ErrInternal, "Failed to delete saved search", err)
+	}
+	if deleted == 0 {
+		return notFound()
+	}
+	return nil
+}
+
+func userID(userInfo auth.UserInfo) (pgtype.UUID, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userInfo.ID); err != nil {
+		return userUUID, errors.New(errors.ErrInternal, "Invalid user ID", fmt.Errorf("invalid user uuid: %w", err))
+	}
+	return userUUID, nil
+}
+
+func notFound() *errors.AppError {
+	return errors.New(errors.ErrNotFound, "Saved search not found", nil).WithReason(errors.ReasonSavedSearchNotFound)
+}
+
+func toResponse(row repo.SavedSearch) *SavedSearchResponse {
+	resp := &SavedSearchResponse{
+		ID:            row.ID.String(),
+		Query:         row.Query,
+		Filters:       map[string][]string{},
+		CreatedAt:     row.CreatedAt.Time,
+		LastCheckedAt: row.LastCheckedAt.Time,
+	}
+	// Written by Save, so always an object
+	_ = json.Unmarshal(row.Filters, &resp.Filters)
+	return resp
+}
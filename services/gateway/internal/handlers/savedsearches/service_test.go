@@ -0,0 +1,179 @@
+package savedsearches
+
+import (
+	"context"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/testutil"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var userInfo = auth.UserInfo{ID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", Username: "buyer"}
+
+var savedSearchColumns = []string{"id", "user_id", "query", "filters", "filter_by", "notified_listing_ids", "last_checked_at", "next_check_at", "created_at"}
+
+func newTestService(t *testing.T) (*svc, pgxmock.PgxPoolIface) {
+	mockPool := testutil.NewMockDB(t)
+	service := NewSavedSearchesService(mockPool, repo.New(mockPool), testutil.NewTestLogger()).(*svc)
+	return service, mockPool
+}
+
+// expectCount expects the user's saves to be locked and counted, in the save's transaction
+func expectCount(mockPool pgxmock.PgxPoolIface, count int64) {
+	mockPool.ExpectBegin()
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: LockUserSavedSearches :exec`)).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CountSavedSearches :one`)).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(count))
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		req          SaveSearchRequest
+		wantQuery    string
+		wantFilters  map[string][]string
+		wantFilterBy string
+	}{
+		"query tidied": {
+			req:         SaveSearchRequest{Query: "  Articulated   DRAGON "},
+			wantQuery:   "articulated dragon",
+			wantFilters: map[string][]string{},
+		},
+		"filters normalized": {
+			req: SaveSearchRequest{Query: "dragon", Filters: map[string][]string{
+				"category":  {"toys", " articulated ", "toys"},
+				"max_x_mm":  {"220"},
+				"price_max": {" 500 "},
+				"page":      {"3"},
+			}},
+			wantQuery:    "dragon",
+			wantFilters:  map[string][]string{"category": {"articulated", "toys"}, "max_x_mm": {"220"}, "price_max": {"500"}},
+			wantFilterBy: "categories:=[`articulated`,`toys`] && sale_price:<=500 && dim_x_mm:<=220",
+		},
+		"language from the browser": {
+			req:          SaveSearchRequest{Query: "drache", acceptLanguage: "de-DE,de;q=0.9"},
+			wantQuery:    "drache",
+			wantFilters:  map[string][]string{"lang": {"de"}},
+			wantFilterBy: "language:=[`de`,`und`]",
+		},
+		"every language": {
+			req:          SaveSearchRequest{Query: "dragon", Filters: map[string][]string{"lang": {"all"}}, acceptLanguage: "de-DE"},
+			wantQuery:    "dragon",
+			wantFilters:  map[string][]string{"lang": {"all"}},
+			wantFilterBy: "",
+		},
+		"filters only": {
+			req:          SaveSearchRequest{Filters: map[string][]string{"format": {"3mf"}}},
+			wantFilters:  map[string][]string{"format": {"3mf"}},
+			wantFilterBy: "file_formats:=[`3mf`]",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			filter, appErr := tt.req.Validate()
+			require.Nil(t, appErr)
+			assert.Equal(t, tt.wantQuery, tt.req.Query)
+			assert.Equal(t, tt.wantFilters, tt.req.Filters)
+			assert.Equal(t, tt.wantFilterBy, filter.String())
+		})
+	}
+}
+
+func TestValidate_Invalid(t *testing.T) {
+	tests := map[string]struct {
+		req        SaveSearchRequest
+		wantReason errors.Reason
+	}{
+		"empty":           {SaveSearchRequest{Query: "   ", Filters: map[string][]string{"page": {"2"}, "category": {" "}}}, errors.ReasonSavedSearchEmpty},
+		"only a language": {SaveSearchRequest{acceptLanguage: "fr-FR"}, errors.ReasonSavedSearchEmpty},
+		"query too long":  {SaveSearchRequest{Query: strings.Repeat("a", maxQueryLength+1)}, errors.ReasonSavedSearchQueryLength},
+		"invalid filter":  {SaveSearchRequest{Query: "dragon", Filters: map[string][]string{"nsfw": {"maybe"}}}, errors.ReasonSearchFilterInvalid},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, appErr := tt.req.Validate()
+			require.NotNil(t, appErr)
+			assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+			assert.Equal(t, tt.wantReason, appErr.Reason)
+		})
+	}
+}
+
+func TestSave(t *testing.T) {
+	t.Run("Saved normalized", func(t *testing.T) {
+		service, mockPool := newTestService(t)
+		expectCount(mockPool, 3)
+		mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CreateSavedSearch :one`)).
+			WithArgs(pgxmock.AnyArg(), "dragon", []byte(`{"category":["toys"]}`), "categories:=[`toys`]").
+			WillReturnRows(pgxmock.NewRows(savedSearchColumns).AddRow(
+				"550e8400-e29b-41d4-a716-446655440000", userInfo.ID, "dragon", []byte(`{"category":["toys"]}`), "categories:=[`toys`]",
+				[]string{}, time.Now(), time.Now(), time.Now()))
+		mockPool.ExpectCommit()
+
+		saved, err := service.Save(context.Background(), userInfo, &SaveSearchRequest{Query: "Dragon", Filters: map[string][]string{"category": {"toys", "toys"}}})
+
+		require.NoError(t, err)
+		assert.Equal(t, "550e8400-e29b-41d4-a716-446655440000", saved.ID)
+		assert.Equal(t, map[string][]string{"category": {"toys"}}, saved.Filters)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Limit reached", func(t *testing.T) {
+		service, mockPool := newTestService(t)
+		expectCount(mockPool, MaxSavedSearches)
+		mockPool.ExpectRollback()
+
+		_, err := service.Save(context.Background(), userInfo, &SaveSearchRequest{Query: "dragon"})
+
+		var appErr *errors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, errors.ErrConflict, appErr.Code)
+		assert.Equal(t, errors.ReasonSavedSearchLimit, appErr.Reason)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	t.Run("Already saved", func(t *testing.T) {
+		service, mockPool := newTestService(t)
+		expectCount(mockPool, 1)
+		mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CreateSavedSearch :one`)).
+			WithArgs(pgxmock.AnyArg(), "dragon", []byte(`{}`), "").
+			WillReturnError(pgx.ErrNoRows)
+		mockPool.ExpectRollback()
+
+		_, err := service.Save(context.Background(), userInfo, &SaveSearchRequest{Query: "dragon"})
+
+		var appErr *errors.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, errors.ErrConflict, appErr.Code)
+		assert.Equal(t, errors.ReasonSavedSearchExists, appErr.Reason)
+	})
+}
+
+func TestDelete_NotOwned(t *testing.T) {
+	service, mockPool := newTestService(t)
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: DeleteSavedSearch :execrows`)).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	err := service.Delete(context.Background(), userInfo, "550e8400-e29b-41d4-a716-446655440000")
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrNotFound, appErr.Code)
+	assert.Equal(t, errors.ReasonSavedSearchNotFound, appErr.Reason)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
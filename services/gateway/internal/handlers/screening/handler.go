@@ -0,0 +1,83 @@
+package screening
+
+import (
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ScreeningHandler struct {
+	service ScreeningService
+}
+
+func NewScreeningHandler(svc ScreeningService) *ScreeningHandler {
+	return &ScreeningHandler{
+		service: svc,
+	}
+}
+
+func (h *ScreeningHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	terms, err := h.service.List(ctx)
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, terms)
+}
+
+// Set adds the term in the path or changes its severity, every pod screens with it straight away
+func (h *ScreeningHandler) Set(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	req := SetTermRequest{}
+	if err := json.Read(r, &req); err != nil {
+		slog.WarnContext(ctx, "Invalid request body", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Input provided was not in the format expected. Please contact support if this error persists.", err))
+		return
+	}
+
+	term, err := h.service.Set(ctx, userInfo, chi.URLParam(r, "term"), &req)
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusOK, term)
+}
+
+func (h *ScreeningHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo, ok := currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(ctx, userInfo, chi.URLParam(r, "term")); err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// currentUser responds with the error and returns false when there's no user. The routes are mounted behind
+// auth.RequireRole for moderators and admins.
+func currentUser(w http.ResponseWriter, r *http.Request) (auth.UserInfo, bool) {
+	userInfo, err := auth.GetUserInfo(r.Context())
+	if err != nil {
+		slog.WarnContext(r.Context(), "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return auth.UserInfo{}, false
+	}
+	return userInfo, true
+}
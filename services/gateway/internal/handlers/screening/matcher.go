@@ -0,0 +1,151 @@
+package screening
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// confusables are letters from other scripts that pass for latin ones once lower cased. Accents, fullwidth letters
+// and ligatures are already taken care of by NFKD.
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'с': 'c', 'ԁ': 'd', 'е': 'e', 'һ': 'h', 'і': 'i', 'ј': 'j', 'ӏ': 'l', 'о': 'o', 'р': 'p', 'ԛ': 'q',
+	'ѕ': 's', 'у': 'y', 'х': 'x', 'ԝ': 'w',
+	// Greek
+	'α': 'a', 'ε': 'e', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x',
+	// Latin letters NFKD leaves alone
+	'ı': 'i', 'ł': 'l', 'ø': 'o', 'đ': 'd',
+}
+
+// leetspeak is what the second pass reads digits and symbols as. l and i are folded together both ways, as 1 and !
+// stand in for either.
+var leetspeak = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b', '9': 'g',
+	'@': 'a', '$': 's', '!': 'i', '|': 'i', '+': 't', 'l': 'i',
+}
+
+// fold lower cases text and strips what is used to dodge a word list without changing how it reads: accents,
+// compatibility forms, invisible characters and lookalike letters
+func fold(text string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(text) {
+		if unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Cf, r) {
+			continue
+		}
+		r = unicode.ToLower(r)
+		if c, ok := confusables[r]; ok {
+			r = c
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// exactWords splits folded text into words on anything that isn't a letter or digit
+func exactWords(folded string) []string {
+	return strings.FieldsFunc(folded, func(r rune) bool { return !isWordRune(r) })
+}
+
+// leetWords splits folded text into words with the leetspeak symbols kept in them, then reads those as letters.
+// A trailing ! is punctuation, not an i.
+func leetWords(folded string) []string {
+	chunks := strings.FieldsFunc(folded, func(r rune) bool {
+		_, leet := leetspeak[r]
+		return !isWordRune(r) && !leet
+	})
+
+	words := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		chunk = strings.TrimRight(chunk, "!")
+		if chunk == "" {
+			continue
+		}
+		words = append(words, strings.Map(func(r rune) rune {
+			if l, ok := leetspeak[r]; ok {
+				return l
+			}
+			return r
+		}, chunk))
+	}
+	return words
+}
+
+// pattern is a banned term as the words it has to appear as, consecutively and whole
+type pattern struct {
+	term     string
+	severity repo.BannedTermSeverity
+	words    []string
+}
+
+// matcher finds banned terms as whole words, so "class" never matches "ass". Each term is looked for twice: as
+// written, and with leetspeak read as letters in both the term and the text.
+type matcher struct {
+	exact map[string][]pattern // By first word
+	leet  map[string][]pattern
+}
+
+func newMatcher(terms []repo.BannedTerm) *matcher {
+	m := &matcher{exact: map[string][]pattern{}, leet: map[string][]pattern{}}
+	for _, t := range terms {
+		folded := fold(t.Term)
+		// A term spelled with symbols is only meant as leetspeak, "$hit" isn't a ban on "hit"
+		if !strings.ContainsFunc(folded, func(r rune) bool { return !isWordRune(r) && !unicode.IsSpace(r) }) {
+			m.add(m.exact, t, exactWords(folded))
+		}
+		m.add(m.leet, t, leetWords(folded))
+	}
+	return m
+}
+
+func (m *matcher) add(index map[string][]pattern, t repo.BannedTerm, words []string) {
+	if len(words) == 0 {
+		return
+	}
+	index[words[0]] = append(index[words[0]], pattern{term: t.Term, severity: t.Severity, words: words})
+}
+
+// match returns the terms found in text, each once and sorted
+func (m *matcher) match(text string) []pattern {
+	folded := fold(text)
+	found := map[string]pattern{}
+	find(exactWords(folded), m.exact, found)
+	find(leetWords(folded), m.leet, found)
+
+	matches := make([]pattern, 0, len(found))
+	for _, p := range found {
+		matches = append(matches, p)
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].term < matches[j].term })
+	return matches
+}
+
+func find(words []string, index map[string][]pattern, found map[string]pattern) {
+	for i, word := range words {
+		for _, p := range index[word] {
+			if hasWords(words[i:], p.words) {
+				found[p.term] = p
+			}
+		}
+	}
+}
+
+func hasWords(words, prefix []string) bool {
+	if len(words) < len(prefix) {
+		return false
+	}
+	for i, w := range prefix {
+		if words[i] != w {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package screening
+
+import (
+	"testing"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bannedTerms(pairs ...string) []repo.BannedTerm {
+	terms := make([]repo.BannedTerm, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		terms = append(terms, repo.BannedTerm{Term: pairs[i], Severity: repo.BannedTermSeverity(pairs[i+1])})
+	}
+	return terms
+}
+
+func TestMatch(t *testing.T) {
+	m := newMatcher(bannedTerms(
+		"scam", "REJECT",
+		"ass", "REJECT",
+		"kill", "FLAG",
+		"crap", "FLAG",
+		"free download", "FLAG",
+		"$hit", "REJECT",
+	))
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "Exact", text: "Totally not a SCAM.", want: []string{"scam"}},
+		{name: "Leetspeak", text: "totally not a $c4m", want: []string{"scam"}},
+		{name: "Digits for letters", text: "5cam alert", want: []string{"scam"}},
+		{name: "Ones for l", text: "k1ll switch", want: []string{"kill"}},
+		{name: "Cyrillic lookalikes", text: "not a ѕсаm", want: []string{"scam"}},
+		{name: "Fullwidth", text: "ＳＣＡＭ", want: []string{"scam"}},
+		{name: "Accents", text: "scám", want: []string{"scam"}},
+		{name: "Zero width space", text: "sc\u200bam", want: []string{"scam"}},
+		{name: "Trailing exclamation", text: "What a crap!", want: []string{"crap"}},
+		{name: "Multi word", text: "FREE   download, today", want: []string{"free download"}},
+		{name: "Multi word over punctuation", text: "free-download", want: []string{"free download"}},
+		{name: "Symbol term as leetspeak", text: "$hit happens", want: []string{"$hit"}},
+		{name: "Each term once, sorted", text: "scam scam k1ll", want: []string{"kill", "scam"}},
+
+		{name: "Inside a word", text: "First class print, for the assassin's creed fans", want: []string{}},
+		{name: "Inside a place name", text: "Made in Scunthorpe", want: []string{}},
+		{name: "Words apart", text: "free shipping, download now", want: []string{}},
+		{name: "Words joined", text: "freedownload", want: []string{}},
+		{name: "Symbol term needs the symbol", text: "Hit the button", want: []string{}},
+		{name: "Clean", text: "A Benchy in PLA", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, p := range m.match(tt.text) {
+				got = append(got, p.term)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMatch_NoTerms(t *testing.T) {
+	assert.Empty(t, newMatcher(nil).match("scam"))
+}
@@ -0,0 +1,63 @@
+package screening
+
+import (
+	"gateway/internal/errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+)
+
+const (
+	minTermLength = 2
+	maxTermLength = 100
+)
+
+// TermsResponse is the whole banned term list, sorted
+type TermsResponse struct {
+	Terms []TermResponse `json:"terms"`
+}
+
+type TermResponse struct {
+	Term      string    `json:"term"`
+	Severity  string    `json:"severity"`
+	UpdatedBy string    `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SetTermRequest struct {
+	Severity string `json:"severity"`
+}
+
+func toTermResponse(t repo.BannedTerm) TermResponse {
+	return TermResponse{
+		Term:      t.Term,
+		Severity:  string(t.Severity),
+		UpdatedBy: t.UpdatedBy.String(),
+		UpdatedAt: t.UpdatedAt.Time.UTC(),
+	}
+}
+
+// termKey is how a term is stored, lower case and single spaced
+func termKey(term string) string {
+	return strings.ToLower(strings.Join(strings.Fields(term), " "))
+}
+
+// validateTerm tidies the term into its stored spelling
+func validateTerm(term string) (string, *errors.AppError) {
+	term = termKey(term)
+	length := utf8.RuneCountInString(term)
+	if length < minTermLength || length > maxTermLength || !strings.ContainsFunc(term, isWordRune) {
+		return "", errors.New(errors.ErrInvalidInput, "Banned terms must be between 2 and 100 characters, with at least one letter or digit", nil).WithReason(errors.ReasonBannedTermInvalid)
+	}
+	return term, nil
+}
+
+func (req *SetTermRequest) Validate() *errors.AppError {
+	switch repo.BannedTermSeverity(req.Severity) {
+	case repo.BannedTermSeverityREJECT, repo.BannedTermSeverityFLAG:
+		return nil
+	}
+	return errors.New(errors.ErrInvalidInput, "Severity must be REJECT or FLAG", nil).WithReason(errors.ReasonBannedTermSeverity)
+}
@@ -0,0 +1,177 @@
+package screening
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/auth"
+	"gateway/internal/cache"
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// TermsTTL is how long a pod keeps its copy of the list. A change is normally picked up straight away through
+// termsChannel, this only matters when that message was missed.
+const TermsTTL = 5 * time.Minute
+
+// termsChannel is where the pod that changed the list tells the others to read it again
+const termsChannel = "screening:banned-terms-changed"
+
+type ScreeningService interface {
+	List(ctx context.Context) (*TermsResponse, error)
+	// Set adds the term, or changes its severity when it's already on the list
+	Set(ctx context.Context, userInfo auth.UserInfo, term string, req *SetTermRequest) (*TermResponse, error)
+	Delete(ctx context.Context, userInfo auth.UserInfo, term string) error
+
+	// Screen finds the banned terms in text, split by what they do to the listing
+	Screen(ctx context.Context, text string) (rejected, flagged []string, err error)
+	// Listen drops this pod's copy of the list whenever any pod changes it, until ctx is done
+	Listen(ctx context.Context) error
+}
+
+type svc struct {
+	repo   *repo.Queries
+	cache  *cache.RedisClient // Tells the other pods about changes, nil leaves them to TermsTTL
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu       sync.Mutex
+	matcher  *matcher
+	loadedAt time.Time
+}
+
+func NewScreeningService(repo *repo.Queries, cache *cache.RedisClient, logger *slog.Logger) ScreeningService {
+	return &svc{
+		repo:   repo,
+		cache:  cache,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+func (s *svc) List(ctx context.Context) (*TermsResponse, error) {
+	terms, err := s.repo.ListBannedTerms(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to list banned terms", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch banned terms", err)
+	}
+
+	res := &TermsResponse{Terms: make([]TermResponse, 0, len(terms))}
+	for _, t := range terms {
+		res.Terms = append(res.Terms, toTermResponse(t))
+	}
+	return res, nil
+}
+
+func (s *svc) Set(ctx context.Context, userInfo auth.UserInfo, term string, req *SetTermRequest) (*TermResponse, error) {
+	term, appErr := validateTerm(term)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if appErr := req.Validate(); appErr != nil {
+		return nil, appErr
+	}
+
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userInfo.ID); err != nil {
+		return nil, errors.New(errors.ErrInternal, "Invalid user ID", fmt.Errorf("invalid user uuid: %w", err))
+	}
+
+	saved, err := s.repo.UpsertBannedTerm(ctx, repo.UpsertBannedTermParams{
+		Term:      term,
+		Severity:  repo.BannedTermSeverity(req.Severity),
+		UpdatedBy: userUUID,
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to save banned term", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to save banned term", err)
+	}
+	s.changed(ctx)
+
+	// The term itself stays out of the logs, the list is for moderators
+	s.logger.InfoContext(ctx, "Banned term set", "severity", saved.Severity, "user_id", userInfo.ID, "username", userInfo.Username)
+	res := toTermResponse(saved)
+	return &res, nil
+}
+
+func (s *svc) Delete(ctx context.Context, userInfo auth.UserInfo, term string) error {
+	deleted, err := s.repo.DeleteBannedTerm(ctx, termKey(term))
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to delete banned term", "error", err)
+		return errors.New(errors.ErrInternal, "Failed to delete banned term", err)
+	}
+	if deleted == 0 {
+		return errors.New(errors.ErrNotFound, "That term isn't on the list", nil).WithReason(errors.ReasonBannedTermNotFound)
+	}
+	s.changed(ctx)
+
+	s.logger.InfoContext(ctx, "Banned term deleted", "user_id", userInfo.ID, "username", userInfo.Username)
+	return nil
+}
+
+func (s *svc) Screen(ctx context.Context, text string) ([]string, []string, error) {
+	m, err := s.load(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to load banned terms", "error", err)
+		return nil, nil, errors.New(errors.ErrInternal, "Failed to check the listing text. Please try again later.", err)
+	}
+
+	var rejected, flagged []string
+	for _, p := range m.match(text) {
+		if p.severity == repo.BannedTermSeverityREJECT {
+			rejected = append(rejected, p.term)
+		} else {
+			flagged = append(flagged, p.term)
+		}
+	}
+	return rejected, flagged, nil
+}
+
+func (s *svc) Listen(ctx context.Context) error {
+	if s.cache == nil {
+		return nil
+	}
+	return cache.Subscribe(s.cache, ctx, termsChannel, func(string) {
+		s.logger.Debug("Banned terms changed, reloading on next use")
+		s.forget()
+	})
+}
+
+// changed makes this pod read the list again on next use and tells the others to do the same
+func (s *svc) changed(ctx context.Context) {
+	s.forget()
+	if s.cache == nil {
+		return
+	}
+	if err := cache.Publish(s.cache, ctx, termsChannel, "changed"); err != nil {
+		s.logger.WarnContext(ctx, "Failed to tell other pods the banned terms changed, they catch up within TermsTTL", "error", err)
+	}
+}
+
+func (s *svc) forget() {
+	s.mu.Lock()
+	s.matcher = nil
+	s.mu.Unlock()
+}
+
+// load returns the cached matcher, reading the list again once it's older than TermsTTL
+func (s *svc) load(ctx context.Context) (*matcher, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.matcher != nil && s.now().Sub(s.loadedAt) < TermsTTL {
+		return s.matcher, nil
+	}
+
+	terms, err := s.repo.ListBannedTerms(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list banned terms: %w", err)
+	}
+	s.matcher = newMatcher(terms)
+	s.loadedAt = s.now()
+	return s.matcher, nil
+}
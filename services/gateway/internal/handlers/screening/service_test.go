@@ -0,0 +1,189 @@
+package screening
+
+import (
+	"context"
+	"gateway/internal/auth"
+	"gateway/internal/cache"
+	"gateway/internal/errors"
+	"gateway/internal/testutil"
+	"gateway/internal/testutil/apitest"
+	"regexp"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const moderatorID = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+
+var termCols = []string{"term", "severity", "updated_by", "updated_at"}
+
+func moderatorUUID(t *testing.T) pgtype.UUID {
+	t.Helper()
+	var id pgtype.UUID
+	require.NoError(t, id.Scan(moderatorID))
+	return id
+}
+
+func newTestService(t *testing.T, redis *cache.RedisClient) (*svc, pgxmock.PgxPoolIface) {
+	mockPool := testutil.NewMockDB(t)
+	service := NewScreeningService(repo.New(mockPool), redis, testutil.NewTestLogger()).(*svc)
+	return service, mockPool
+}
+
+// expectTerms expects the list to be read, pairs of term and severity
+func expectTerms(t *testing.T, mockPool pgxmock.PgxPoolIface, pairs ...string) {
+	rows := pgxmock.NewRows(termCols)
+	for i := 0; i < len(pairs); i += 2 {
+		rows.AddRow(pairs[i], repo.BannedTermSeverity(pairs[i+1]), moderatorUUID(t), time.Now())
+	}
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListBannedTerms :many`)).WillReturnRows(rows)
+}
+
+func TestScreen(t *testing.T) {
+	// SCENARIO: The list has a REJECT and a FLAG term and a listing uses both, twice over.
+	// EXPECT: Each comes back once under its severity, and the second call uses the copy already loaded.
+
+	service, mockPool := newTestService(t, nil)
+	expectTerms(t, mockPool, "replica", "FLAG", "scam", "REJECT")
+
+	for range 2 {
+		rejected, flagged, err := service.Screen(context.Background(), "Not a sc4m, a REPLICA of the real scam")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"scam"}, rejected)
+		assert.Equal(t, []string{"replica"}, flagged)
+	}
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestScreen_ReloadsAfterTTL(t *testing.T) {
+	// SCENARIO: A pod missed the change message and its copy of the list has expired.
+	// EXPECT: The next screen reads the list again and sees the new term.
+
+	service, mockPool := newTestService(t, nil)
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	expectTerms(t, mockPool)
+	_, flagged, err := service.Screen(context.Background(), "Replica helmet")
+	require.NoError(t, err)
+	assert.Empty(t, flagged)
+
+	now = now.Add(TermsTTL)
+	expectTerms(t, mockPool, "replica", "FLAG")
+	_, flagged, err = service.Screen(context.Background(), "Replica helmet")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"replica"}, flagged)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestScreen_ListUnavailable(t *testing.T) {
+	// SCENARIO: The list can't be read.
+	// EXPECT: The screen fails rather than letting the text through unchecked.
+
+	service, mockPool := newTestService(t, nil)
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ListBannedTerms :many`)).WillReturnError(assert.AnError)
+
+	_, _, err := service.Screen(context.Background(), "Benchy")
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrInternal, appErr.Code)
+}
+
+func TestSet(t *testing.T) {
+	userInfo := auth.UserInfo{ID: moderatorID, Username: "mod"}
+
+	t.Run("Term is tidied and saved", func(t *testing.T) {
+		service, mockPool := newTestService(t, nil)
+		mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: UpsertBannedTerm :one`)).
+			WithArgs("free download", repo.BannedTermSeverityFLAG, moderatorUUID(t)).
+			WillReturnRows(pgxmock.NewRows(termCols).AddRow("free download", repo.BannedTermSeverityFLAG, moderatorUUID(t), time.Now()))
+
+		res, err := service.Set(context.Background(), userInfo, "  Free   DOWNLOAD ", &SetTermRequest{Severity: "FLAG"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "free download", res.Term)
+		assert.Equal(t, moderatorID, res.UpdatedBy)
+		assert.NoError(t, mockPool.ExpectationsWereMet())
+	})
+
+	tests := []struct {
+		name       string
+		term       string
+		severity   string
+		wantReason errors.Reason
+	}{
+		{name: "Too short", term: "a", severity: "FLAG", wantReason: errors.ReasonBannedTermInvalid},
+		{name: "Only symbols", term: "$$$", severity: "FLAG", wantReason: errors.ReasonBannedTermInvalid},
+		{name: "Unknown severity", term: "scam", severity: "BLOCK", wantReason: errors.ReasonBannedTermSeverity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockPool := newTestService(t, nil)
+
+			_, err := service.Set(context.Background(), userInfo, tt.term, &SetTermRequest{Severity: tt.severity})
+
+			var appErr *errors.AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+			assert.Equal(t, tt.wantReason, appErr.Reason)
+			assert.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDelete_NotOnList(t *testing.T) {
+	service, mockPool := newTestService(t, nil)
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: DeleteBannedTerm :execrows`)).
+		WithArgs("scam").
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	err := service.Delete(context.Background(), auth.UserInfo{ID: moderatorID}, "SCAM")
+
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, errors.ErrNotFound, appErr.Code)
+	assert.Equal(t, errors.ReasonBannedTermNotFound, appErr.Reason)
+}
+
+func TestListen_OtherPodChangesList(t *testing.T) {
+	// SCENARIO: One pod has the list loaded when a moderator deletes a term through another pod.
+	// EXPECT: The first pod hears about it and reads the list again on its next screen, well before TermsTTL.
+
+	redis, _ := apitest.NewRedis(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	listening, listeningPool := newTestService(t, redis)
+	require.NoError(t, listening.Listen(ctx))
+	changing, changingPool := newTestService(t, redis)
+
+	expectTerms(t, listeningPool, "replica", "FLAG")
+	_, flagged, err := listening.Screen(ctx, "Replica helmet")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"replica"}, flagged)
+
+	changingPool.ExpectExec(regexp.QuoteMeta(`-- name: DeleteBannedTerm :execrows`)).
+		WithArgs("replica").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	require.NoError(t, changing.Delete(ctx, auth.UserInfo{ID: moderatorID}, "replica"))
+
+	require.Eventually(t, func() bool {
+		listening.mu.Lock()
+		defer listening.mu.Unlock()
+		return listening.matcher == nil
+	}, time.Second, 10*time.Millisecond)
+
+	expectTerms(t, listeningPool)
+	_, flagged, err = listening.Screen(ctx, "Replica helmet")
+	require.NoError(t, err)
+	assert.Empty(t, flagged)
+	assert.NoError(t, listeningPool.ExpectationsWereMet())
+	assert.NoError(t, changingPool.ExpectationsWereMet())
+}
@@ -0,0 +1,97 @@
+package sellers
+
+import (
+	"fmt"
+	"gateway/internal/errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type UpsertSellerProfileRequest struct {
+	DisplayName          string `json:"display_name"`
+	Country              string `json:"country"`                // ISO 3166-1 alpha-2, e.g. "GB"
+	AcceptedTermsVersion string `json:"accepted_terms_version"` // Must match the current terms version
+}
+
+type SellerProfileResponse struct {
+	DisplayName          string     `json:"display_name"`
+	Country              string     `json:"country"`
+	PayoutStatus         string     `json:"payout_status"`
+	AcceptedTermsVersion *string    `json:"accepted_terms_version"`
+	AcceptedTermsAt      *time.Time `json:"accepted_terms_at"`
+
+	// False when the seller needs to re-accept updated terms before listing again
+	TermsUpToDate bool `json:"terms_up_to_date"`
+	// Current or upcoming vacation, nil when none is booked
+	Vacation *VacationResponse `json:"vacation"`
+}
+
+// maxVacationMessageLength matches the check on sellers.vacation_message
+const maxVacationMessageLength = 280
+
+type StartVacationRequest struct {
+	StartsAt *time.Time `json:"starts_at"` // Defaults to now, a time in the past starts it now
+	EndsAt   time.Time  `json:"ends_at"`
+	Message  *string    `json:"message"` // Shown to buyers on the seller's listings while they're away
+}
+
+type VacationResponse struct {
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Message  *string   `json:"message"`
+	// True between starts_at and ends_at, the listings are flagged and paid downloads are paused
+	Active bool `json:"active"`
+}
+
+func (req *UpsertSellerProfileRequest) Validate(currentTermsVersion string) *errors.AppError {
+	req.DisplayName = strings.TrimSpace(req.DisplayName)
+	req.Country = strings.ToUpper(strings.TrimSpace(req.Country))
+
+	// 1. Display Name
+	nameLen := utf8.RuneCountInString(req.DisplayName)
+	if nameLen < 2 || nameLen > 50 {
+		return errors.New(errors.ErrInvalidInput, "Display name must be between 2 and 50 characters", nil)
+	}
+	// The display name is public, don't let people paste their email address in by accident
+	if strings.Contains(req.DisplayName, "@") {
+		return errors.New(errors.ErrInvalidInput, "Display name cannot contain an email address", nil)
+	}
+
+	// 2. Country
+	if len(req.Country) != 2 || req.Country[0] < 'A' || req.Country[0] > 'Z' || req.Country[1] < 'A' || req.Country[1] > 'Z' {
+		return errors.New(errors.ErrInvalidInput, "Country must be a two letter ISO country code", nil)
+	}
+
+	// 3. Terms
+	if req.AcceptedTermsVersion != currentTermsVersion {
+		return errors.New(errors.ErrInvalidInput, fmt.Sprintf("You must accept the current seller terms (version %s)", currentTermsVersion), nil)
+	}
+
+	return nil
+}
+
+// Validate fills in the start and tidies the message, now is when the request came in
+func (req *StartVacationRequest) Validate(now time.Time) *errors.AppError {
+	if req.StartsAt == nil || req.StartsAt.Before(now) {
+		req.StartsAt = &now
+	}
+	if !req.EndsAt.After(now) || !req.EndsAt.After(*req.StartsAt) {
+		return errors.New(errors.ErrInvalidInput, "Vacation must end in the future, after it starts", nil).WithReason(errors.ReasonVacationEndInvalid)
+	}
+
+	if req.Message != nil {
+		message := strings.TrimSpace(*req.Message)
+		if utf8.RuneCountInString(message) > maxVacationMessageLength {
+			return errors.New(errors.ErrInvalidInput, fmt.Sprintf("Vacation message must be at most %d characters", maxVacationMessageLength), nil).
+				WithReason(errors.ReasonVacationMessageLength).
+				WithParam("max", strconv.Itoa(maxVacationMessageLength))
+		}
+		req.Message = &message
+		if message == "" {
+			req.Message = nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package sellers
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/auth"
+	"gateway/internal/cache"
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type SellersService interface {
+	GetProfile(ctx context.Context, userInfo auth.UserInfo) (*SellerProfileResponse, error)
+	UpsertProfile(ctx context.Context, userInfo auth.UserInfo, req *UpsertSellerProfileRequest) (*SellerProfileResponse, error)
+	StartVacation(ctx context.Context, userInfo auth.UserInfo, req *StartVacationRequest) (*SellerProfileResponse, error)
+	EndVacation(ctx context.Context, userInfo auth.UserInfo) (*SellerProfileResponse, error)
+}
+
+type svc struct {
+	repo         *repo.Queries
+	cache        *cache.RedisClient
+	listingCache cache.Namespace // Where the seller's listing responses are cached, see listings.CacheNamespace
+	logger       *slog.Logger
+	termsVersion string
+	now          func() time.Time
+}
+
+func NewSellersService(repo *repo.Queries, cache *cache.RedisClient, listingCache cache.Namespace, logger *slog.Logger, termsVersion string) SellersService {
+	return &svc{
+		repo:         repo,
+		cache:        cache,
+		listingCache: listingCache,
+		logger:       logger,
+		termsVersion: termsVersion,
+		now:          time.Now,
+	}
+}
+
+func (s *svc) GetProfile(ctx context.Context, userInfo auth.UserInfo) (*SellerProfileResponse, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userInfo.ID); err != nil {
+		return nil, errors.New(errors.ErrInternal, "Invalid user ID", fmt.Errorf("invalid user uuid: %w", err))
+	}
+
+	seller, err := s.repo.GetSellerProfile(ctx, userUUID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New(errors.ErrNotFound, "Seller profile not found", nil)
+		}
+		s.logger.ErrorContext(ctx, "Failed to fetch seller profile", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to fetch seller profile", err)
+	}
+
+	return s.toResponse(seller), nil
+}
+
+func (s *svc) UpsertProfile(ctx context.Context, userInfo auth.UserInfo, req *UpsertSellerProfileRequest) (*SellerProfileResponse, error) {
+	if err := req.Validate(s.termsVersion); err != nil {
+		return nil, err
+	}
+
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userInfo.ID); err != nil {
+		return nil, errors.New(errors.ErrInternal, "Invalid user ID", fmt.Errorf("invalid user uuid: %w", err))
+	}
+
+	seller, err := s.repo.UpsertSellerProfile(ctx, repo.UpsertSellerProfileParams{
+		UserID:               userUUID,
+		DisplayName:          req.DisplayName,
+		Country:              req.Country,
+		AcceptedTermsVersion: pgtype.Text{String: req.AcceptedTermsVersion, Valid: true},
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to save seller profile", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to save seller profile. Please try again later.", err)
+	}
+
+	s.logger.InfoContext(ctx, "Seller profile saved", "user_id", userInfo.ID, "terms_version", req.AcceptedTermsVersion)
+	return s.toResponse(seller), nil
+}
+
+// StartVacation books a vacation, replacing any the seller already has. The listings worker reindexes the seller's
+// listings when it starts and ends, the cached responses are dropped here so the next read picks up the new dates.
+func (s *svc) StartVacation(ctx context.Context, userInfo auth.UserInfo, req *StartVacationRequest) (*SellerProfileResponse, error) {
+	if err := req.Validate(s.now()); err != nil {
+		return nil, err
+	}
+
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userInfo.ID); err != nil {
+		return nil, errors.New(errors.ErrInternal, "Invalid user ID", fmt.Errorf("invalid user uuid: %w", err))
+	}
+
+	params := repo.StartSellerVacationParams{
+		StartsAt: pgtype.Timestamptz{Time: *req.StartsAt, Valid: true},
+		EndsAt:   pgtype.Timestamptz{Time: req.EndsAt, Valid: true},
+		UserID:   userUUID,
+	}
+	if req.Message != nil {
+		params.Message = pgtype.Text{String: *req.Message, Valid: true}
+	}
+
+	seller, err := s.repo.StartSellerVacation(ctx, params)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New(errors.ErrSellerProfileRequired, "Please complete your seller profile first", nil)
+		}
+		s.logger.ErrorContext(ctx, "Failed to save vacation", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to save vacation. Please try again later.", err)
+	}
+
+	s.invalidateListings(ctx, userUUID)
+	s.logger.InfoContext(ctx, "Seller vacation booked", "user_id", userInfo.ID, "starts_at", req.StartsAt, "ends_at", req.EndsAt)
+	return s.toResponse(seller), nil
+}
+
+// EndVacation ends a current vacation, or cancels an upcoming one, straight away
+func (s *svc) EndVacation(ctx context.Context, userInfo auth.UserInfo) (*SellerProfileResponse, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userInfo.ID); err != nil {
+		return nil, errors.New(errors.ErrInternal, "Invalid user ID", fmt.Errorf("invalid user uuid: %w", err))
+	}
+
+	seller, err := s.repo.EndSellerVacation(ctx, userUUID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New(errors.ErrNotFound, "You don't have a vacation to end", nil).WithReason(errors.ReasonVacationNotSet)
+		}
+		s.logger.ErrorContext(ctx, "Failed to end vacation", "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to end vacation. Please try again later.", err)
+	}
+
+	s.invalidateListings(ctx, userUUID)
+	s.logger.InfoContext(ctx, "Seller vacation ended", "user_id", userInfo.ID)
+	return s.toResponse(seller), nil
+}
+
+// invalidateListings drops every cached response for the seller's listings. Failures are logged only, responses
+// cached while a vacation is booked never outlive its next start or end anyway.
+func (s *svc) invalidateListings(ctx context.Context, sellerID pgtype.UUID) {
+	ids, err := s.repo.GetSellerListingIDs(ctx, sellerID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to list seller's listings for cache invalidation", "seller_id", sellerID.String(), "error", err)
+		return
+	}
+	for _, id := range ids {
+		if _, err := s.listingCache.Del(s.cache, ctx, id.String()); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to bust listing cache", "listing_id", id.String(), "error", err)
+		}
+	}
+}
+
+func (s *svc) toResponse(seller repo.Seller) *SellerProfileResponse {
+	response := &SellerProfileResponse{
+		DisplayName:   seller.DisplayName,
+		Country:       seller.Country,
+		PayoutStatus:  string(seller.PayoutStatus),
+		TermsUpToDate: seller.AcceptedTermsVersion.Valid && seller.AcceptedTermsVersion.String == s.termsVersion,
+	}
+
+	if seller.AcceptedTermsVersion.Valid {
+		response.AcceptedTermsVersion = &seller.AcceptedTermsVersion.String
+	}
+	if seller.AcceptedTermsAt.Valid {
+		response.AcceptedTermsAt = &seller.AcceptedTermsAt.Time
+	}
+	// An ended vacation stays on the row until the listings worker has reindexed and cleared it
+	if now := s.now(); seller.VacationEndsAt.Valid && seller.VacationEndsAt.Time.After(now) {
+		response.Vacation = &VacationResponse{
+			StartsAt: seller.VacationStartsAt.Time,
+			EndsAt:   seller.VacationEndsAt.Time,
+			Active:   !seller.VacationStartsAt.Time.After(now),
+		}
+		if seller.VacationMessage.Valid {
+			response.Vacation.Message = &seller.VacationMessage.String
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,46 @@
+package shortlinks
+
+import (
+	"crypto/rand"
+)
+
+const (
+	// CodeLength is 62^6, about 57 billion codes, so a random draw almost never collides and codes can't be walked
+	CodeLength = 6
+
+	codeAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// rejectAbove is the largest multiple of 62 a byte can hold, bytes from it up are dropped so every character is
+	// equally likely
+	rejectAbove = 256 - 256%len(codeAlphabet)
+)
+
+// newCode draws a random base62 code
+func newCode() (string, error) {
+	code := make([]byte, 0, CodeLength)
+	buf := make([]byte, CodeLength)
+	for len(code) < CodeLength {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) < rejectAbove && len(code) < CodeLength {
+				code = append(code, codeAlphabet[int(b)%len(codeAlphabet)])
+			}
+		}
+	}
+	return string(code), nil
+}
+
+// validCode is checked before anything is looked up, so junk never reaches Redis or Postgres
+func validCode(code string) bool {
+	if len(code) != CodeLength {
+		return false
+	}
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		if !('0' <= c && c <= '9' || 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package shortlinks
+
+import (
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/handlers/listings"
+	"gateway/internal/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// PreviewWriter writes a listing's link preview page, see listings.PreviewHandler
+type PreviewWriter interface {
+	WriteListingPreview(w http.ResponseWriter, r *http.Request, listingID string)
+}
+
+type ShortLinksHandler struct {
+	service  ShortLinksService
+	previews PreviewWriter
+}
+
+func NewShortLinksHandler(svc ShortLinksService, previews PreviewWriter) *ShortLinksHandler {
+	return &ShortLinksHandler{
+		service:  svc,
+		previews: previews,
+	}
+}
+
+func (h *ShortLinksHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	// Every field is optional, so no body at all is fine
+	req := CreateShortLinkRequest{}
+	if r.ContentLength != 0 {
+		if err := json.Read(r, &req); err != nil {
+			slog.WarnContext(ctx, "Invalid request body", "error", err)
+			errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Input provided was not in the format expected. Please contact support if this error persists.", err))
+			return
+		}
+	}
+
+	link, err := h.service.Create(ctx, userInfo, chi.URLParam(r, "id"), &req)
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusCreated, link)
+}
+
+func (h *ShortLinksHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Unauthorized access attempt", "error", err)
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	if err := h.service.Revoke(ctx, userInfo, chi.URLParam(r, "id"), chi.URLParam(r, "code")); err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	json.Write(w, http.StatusNoContent, nil)
+}
+
+// Redirect sends a short link on to its listing page. Crawlers unfurling the link are served the listing's preview
+// page instead, they don't run the web UI and aren't counted as clicks.
+func (h *ShortLinksHandler) Redirect(w http.ResponseWriter, r *http.Request) {
+	// The preview page can be cached, it mustn't be served to people from that cache
+	w.Header().Set("Vary", "User-Agent")
+	if listings.IsLinkPreviewBot(r.UserAgent()) {
+		target, err := h.service.Lookup(r.Context(), chi.URLParam(r, "code"))
+		if err != nil {
+			errors.RespondError(w, r, err)
+			return
+		}
+		h.previews.WriteListingPreview(w, r, target.ListingID)
+		return
+	}
+
+	target, err := h.service.Resolve(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		errors.RespondError(w, r, err)
+		return
+	}
+
+	// Every click has to reach us to be counted, and a revoked link must stop working
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, target, http.StatusFound)
+}
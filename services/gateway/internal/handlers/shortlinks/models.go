@@ -0,0 +1,52 @@
+package shortlinks
+
+import (
+	"gateway/internal/errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Config is where short links and the pages they redirect to live
+type Config struct {
+	// BaseURL is what a code is appended to for the link that gets shared, e.g. https://prnt.mk. The host in front of
+	// it rewrites /{code} to the gateway's /l/{code}.
+	BaseURL string
+	// ListingBaseURL is the web UI, links redirect to its /listings/{id} page
+	ListingBaseURL string
+}
+
+// campaignPattern keeps tags usable as utm_campaign without escaping and readable in analytics
+var campaignPattern = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+type CreateShortLinkRequest struct {
+	// Campaign is sent as utm_campaign, e.g. "spring-sale". Optional.
+	Campaign *string `json:"campaign"`
+}
+
+type ShortLinkResponse struct {
+	Code        string    `json:"code"`
+	URL         string    `json:"url"`
+	ListingID   string    `json:"listing_id"`
+	Campaign    *string   `json:"campaign"`
+	ClicksCount int32     `json:"clicks_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Validate lower cases the campaign and drops a blank one
+func (req *CreateShortLinkRequest) Validate() *errors.AppError {
+	if req.Campaign == nil {
+		return nil
+	}
+	campaign := strings.ToLower(strings.TrimSpace(*req.Campaign))
+	if campaign == "" {
+		req.Campaign = nil
+		return nil
+	}
+	if !campaignPattern.MatchString(campaign) {
+		return errors.New(errors.ErrInvalidInput, "Campaign tags can be at most 64 characters of a-z, 0-9, - and _", nil).
+			WithReason(errors.ReasonShortLinkCampaignInvalid)
+	}
+	req.Campaign = &campaign
+	return nil
+}
@@ -0,0 +1,227 @@
+package shortlinks
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/auth"
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/errors"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// TargetCacheTTL bounds how long a link keeps redirecting after its listing is deleted, revoking clears it at once
+	TargetCacheTTL = time.Minute
+	// maxCodeAttempts is how many codes are drawn before giving up, one collision is already unlikely
+	maxCodeAttempts = 5
+)
+
+type ShortLinksService interface {
+	Create(ctx context.Context, userInfo auth.UserInfo, listingID string, req *CreateShortLinkRequest) (*ShortLinkResponse, error)
+	Revoke(ctx context.Context, userInfo auth.UserInfo, listingID, code string) error
+	// Resolve counts a click and returns the URL to redirect it to
+	Resolve(ctx context.Context, code string) (string, error)
+	// Lookup is where a link goes without counting a click, for crawlers building a preview of it
+	Lookup(ctx context.Context, code string) (*Target, error)
+}
+
+type svc struct {
+	repo    *repo.Queries
+	targets TargetStore
+	clicks  ClickCounter
+	config  Config
+	logger  *slog.Logger
+}
+
+func NewShortLinksService(repo *repo.Queries, targets TargetStore, clicks ClickCounter, config Config, logger *slog.Logger) ShortLinksService {
+	return &svc{
+		repo:    repo,
+		targets: targets,
+		clicks:  clicks,
+		config:  config,
+		logger:  logger,
+	}
+}
+
+func (s *svc) Create(ctx context.Context, userInfo auth.UserInfo, listingID string, req *CreateShortLinkRequest) (*ShortLinkResponse, error) {
+	if appErr := req.Validate(); appErr != nil {
+		return nil, appErr
+	}
+
+	userUUID, listingUUID, err := s.ownListing(ctx, userInfo, listingID)
+	if err != nil {
+		return nil, err
+	}
+
+	params := repo.CreateShortLinkParams{ListingID: listingUUID, CreatedBy: userUUID}
+	if req.Campaign != nil {
+		params.Campaign = pgtype.Text{String: *req.Campaign, Valid: true}
+	}
+	for attempt := 1; attempt <= maxCodeAttempts; attempt++ {
+		params.Code, err = newCode()
+		if err != nil {
+			return nil, errors.New(errors.ErrInternal, "Failed to create short link", fmt.Errorf("failed to draw short link code: %w", err))
+		}
+
+		link, err := s.repo.CreateShortLink(ctx, params)
+		if err == pgx.ErrNoRows {
+			s.logger.WarnContext(ctx, "Short link code collision, drawing another", "code", params.Code, "attempt", attempt)
+			continue
+		}
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to create short link", "listing_id", listingID, "error", err)
+			return nil, errors.New(errors.ErrInternal, "Failed to create short link", err)
+		}
+
+		s.logger.InfoContext(ctx, "Short link created", "code", link.Code, "listing_id", listingID, "user_id", userInfo.ID)
+		return s.toResponse(link), nil
+	}
+	return nil, errors.New(errors.ErrInternal, "Failed to create short link", fmt.Errorf("no free short link code after %d attempts", maxCodeAttempts))
+}
+
+func (s *svc) Revoke(ctx context.Context, userInfo auth.UserInfo, listingID, code string) error {
+	_, listingUUID, err := s.ownListing(ctx, userInfo, listingID)
+	if err != nil {
+		return err
+	}
+	if !validCode(code) {
+		return notFound(code)
+	}
+
+	revoked, err := s.repo.RevokeShortLink(ctx, repo.RevokeShortLinkParams{Code: code, ListingID: listingUUID})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to revoke short link", "code", code, "error", err)
+		return errors.New(errors.ErrInternal, "Failed to revoke short link", err)
+	}
+	if revoked == 0 {
+		return notFound(code)
+	}
+
+	// Otherwise the link would keep redirecting until the cached target expires
+	if err := s.targets.DelTarget(ctx, code); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to bust short link target", "code", code, "error", err)
+	}
+	s.logger.InfoContext(ctx, "Short link revoked", "code", code, "listing_id", listingID, "user_id", userInfo.ID)
+	return nil
+}
+
+func (s *svc) Resolve(ctx context.Context, code string) (string, error) {
+	target, err := s.Lookup(ctx, code)
+	if err != nil {
+		return "", err
+	}
+
+	// A lost click isn't worth failing the redirect over
+	if err := s.clicks.IncrClick(ctx, code); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to count short link click", "code", code, "error", err)
+	}
+	return s.redirectURL(code, target), nil
+}
+
+func (s *svc) Lookup(ctx context.Context, code string) (*Target, error) {
+	if !validCode(code) {
+		return nil, notFound(code)
+	}
+
+	target, err := s.target(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if target.Revoked {
+		return nil, errors.New(errors.ErrGone, "This link has been turned off", nil).WithReason(errors.ReasonShortLinkRevoked)
+	}
+	if target.ListingDeleted {
+		return nil, errors.New(errors.ErrGone, "This listing is no longer available", nil).WithReason(errors.ReasonShortLinkListingDeleted)
+	}
+	return target, nil
+}
+
+func (s *svc) target(ctx context.Context, code string) (*Target, error) {
+	cached, found, err := s.targets.GetTarget(ctx, code)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to get short link target from cache", "code", code, "error", err)
+	} else if found {
+		return cached, nil
+	}
+
+	row, err := s.repo.GetShortLinkTarget(ctx, code)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, notFound(code)
+		}
+		s.logger.ErrorContext(ctx, "Failed to fetch short link", "code", code, "error", err)
+		return nil, errors.New(errors.ErrInternal, "Failed to follow link", err)
+	}
+
+	target := Target{
+		ListingID:      fmt.Sprintf("%x", row.ListingID.Bytes),
+		Campaign:       row.Campaign.String,
+		Revoked:        row.RevokedAt.Valid,
+		ListingDeleted: row.ListingDeletedAt.Valid,
+	}
+	if err := s.targets.SetTarget(ctx, code, target, TargetCacheTTL); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to cache short link target", "code", code, "error", err)
+	}
+	return &target, nil
+}
+
+// redirectURL is the listing page tagged for analytics. utm_content is the code, so links shared in different places
+// for the same campaign can still be told apart.
+func (s *svc) redirectURL(code string, target *Target) string {
+	query := url.Values{}
+	query.Set("utm_source", "short_link")
+	query.Set("utm_medium", "social")
+	if target.Campaign != "" {
+		query.Set("utm_campaign", target.Campaign)
+	}
+	query.Set("utm_content", code)
+	return strings.TrimRight(s.config.ListingBaseURL, "/") + "/listings/" + target.ListingID + "?" + query.Encode()
+}
+
+// ownListing checks the listing exists and belongs to the user
+func (s *svc) ownListing(ctx context.Context, userInfo auth.UserInfo, listingID string) (pgtype.UUID, pgtype.UUID, error) {
+	var userUUID, listingUUID pgtype.UUID
+	if err := userUUID.Scan(userInfo.ID); err != nil {
+		return userUUID, listingUUID, errors.New(errors.ErrInternal, "Invalid user ID", fmt.Errorf("invalid user uuid: %w", err))
+	}
+	if err := listingUUID.Scan(listingID); err != nil {
+		return userUUID, listingUUID, errors.New(errors.ErrInvalidInput, "Invalid listing ID provided", err)
+	}
+
+	listing, err := s.repo.GetListingByID(ctx, listingUUID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return userUUID, listingUUID, errors.New(errors.ErrNotFound, "Listing not found", fmt.Errorf("listing %v not found", listingID)).WithReason(errors.ReasonListingNotFound)
+		}
+		s.logger.ErrorContext(ctx, "Failed to fetch listing", "listing_id", listingID, "error", err)
+		return userUUID, listingUUID, errors.New(errors.ErrInternal, "Failed to fetch listing", fmt.Errorf("failed to fetch listing %v: %w", listingID, err))
+	}
+	if listing.SellerID != userUUID {
+		return userUUID, listingUUID, errors.New(errors.ErrUnauthorized, "You do not own this listing", fmt.Errorf("user %v doesn't own listing %v", userInfo.ID, listingID)).WithReason(errors.ReasonListingNotOwner)
+	}
+	return userUUID, listingUUID, nil
+}
+
+func (s *svc) toResponse(link repo.ShortLink) *ShortLinkResponse {
+	resp := &ShortLinkResponse{
+		Code:        link.Code,
+		URL:         strings.TrimRight(s.config.BaseURL, "/") + "/" + link.Code,
+		ListingID:   fmt.Sprintf("%x", link.ListingID.Bytes),
+		ClicksCount: link.ClicksCount,
+		CreatedAt:   link.CreatedAt.Time,
+	}
+	if link.Campaign.Valid {
+		resp.Campaign = &link.Campaign.String
+	}
+	return resp
+}
+
+func notFound(code string) *errors.AppError {
+	return errors.New(errors.ErrNotFound, "Link not found", fmt.Errorf("short link %q not found", code)).WithReason(errors.ReasonShortLinkNotFound)
+}
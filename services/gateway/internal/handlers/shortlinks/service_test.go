@@ -0,0 +1,270 @@
+package shortlinks
+
+import (
+	"context"
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/testutil"
+	"gateway/internal/testutil/fixtures"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	sellerID  = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	otherID   = "c0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	listingID = "11111111-1111-1111-1111-111111111111"
+)
+
+var userInfo = auth.UserInfo{ID: sellerID, Username: "tester"}
+
+var shortLinkColumns = []string{"code", "listing_id", "created_by", "campaign", "clicks_count", "created_at", "revoked_at"}
+
+// fakeStore is a TargetStore and ClickCounter in memory
+type fakeStore struct {
+	targets map[string]Target
+	clicks  map[string]int
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{targets: map[string]Target{}, clicks: map[string]int{}}
+}
+
+func (f *fakeStore) GetTarget(_ context.Context, code string) (*Target, bool, error) {
+	target, ok := f.targets[code]
+	if !ok {
+		return nil, false, nil
+	}
+	return &target, true, nil
+}
+
+func (f *fakeStore) SetTarget(_ context.Context, code string, target Target, _ time.Duration) error {
+	f.targets[code] = target
+	return nil
+}
+
+func (f *fakeStore) DelTarget(_ context.Context, code string) error {
+	delete(f.targets, code)
+	return nil
+}
+
+func (f *fakeStore) IncrClick(_ context.Context, code string) error {
+	f.clicks[code]++
+	return nil
+}
+
+func newTestService(t *testing.T) (*svc, pgxmock.PgxPoolIface, *fakeStore) {
+	mockPool := testutil.NewMockDB(t)
+	store := newFakeStore()
+	config := Config{BaseURL: "https://prnt.test/", ListingBaseURL: "https://web.test"}
+	service := NewShortLinksService(repo.New(mockPool), store, store, config, testutil.NewTestLogger()).(*svc)
+	return service, mockPool, store
+}
+
+func expectListing(mockPool pgxmock.PgxPoolIface, owner string) {
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingByID :one`)).WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(fixtures.ListingRows(fixtures.NewListing(fixtures.WithID(listingID), fixtures.WithSeller(owner))))
+}
+
+func TestNewCode(t *testing.T) {
+	// SCENARIO: Lots of codes are drawn.
+	// EXPECT: Every one is CodeLength base62 characters, passes validCode and none repeat.
+
+	seen := map[string]bool{}
+	for range 10000 {
+		code, err := newCode()
+		require.NoError(t, err)
+		require.Len(t, code, CodeLength)
+		require.True(t, validCode(code), code)
+		for _, c := range code {
+			require.True(t, strings.ContainsRune(codeAlphabet, c), code)
+		}
+		require.False(t, seen[code], "duplicate code %s", code)
+		seen[code] = true
+	}
+}
+
+func TestValidCode(t *testing.T) {
+	tests := map[string]bool{
+		"aZ09xY":  true,
+		"aZ09x":   false,
+		"aZ09xYz": false,
+		"aZ09x-":  false,
+		"aZ09x/":  false,
+		"äZ09x":   false,
+		"":        false,
+	}
+	for code, want := range tests {
+		t.Run(code, func(t *testing.T) {
+			assert.Equal(t, want, validCode(code))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	campaign := func(s string) *string { return &s }
+	tests := map[string]struct {
+		campaign   *string
+		want       *string
+		wantReason errors.Reason
+	}{
+		"none":            {},
+		"blank dropped":   {campaign: campaign("  ")},
+		"lower cased":     {campaign: campaign(" Spring_Sale-2026 "), want: campaign("spring_sale-2026")},
+		"spaces refused":  {campaign: campaign("spring sale"), wantReason: errors.ReasonShortLinkCampaignInvalid},
+		"symbols refused": {campaign: campaign("sale&utm_source=x"), wantReason: errors.ReasonShortLinkCampaignInvalid},
+		"too long":        {campaign: campaign(strings.Repeat("a", 65)), wantReason: errors.ReasonShortLinkCampaignInvalid},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := CreateShortLinkRequest{Campaign: tt.campaign}
+			appErr := req.Validate()
+			if tt.wantReason != "" {
+				require.NotNil(t, appErr)
+				assert.Equal(t, tt.wantReason, appErr.Reason)
+				return
+			}
+			require.Nil(t, appErr)
+			assert.Equal(t, tt.want, req.Campaign)
+		})
+	}
+}
+
+func TestCreate_RetriesCollision(t *testing.T) {
+	// SCENARIO: The first code drawn is already taken.
+	// EXPECT: Another code is drawn and the link is created with it.
+
+	service, mockPool, _ := newTestService(t)
+	expectListing(mockPool, sellerID)
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CreateShortLink`)).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnError(pgx.ErrNoRows)
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CreateShortLink`)).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(shortLinkColumns).AddRow("Ab3dE9", listingID, sellerID, "spring", int32(0), time.Now(), nil))
+
+	campaign := "Spring"
+	link, err := service.Create(context.Background(), userInfo, listingID, &CreateShortLinkRequest{Campaign: &campaign})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Ab3dE9", link.Code)
+	assert.Equal(t, "https://prnt.test/Ab3dE9", link.URL)
+	assert.Equal(t, strings.ReplaceAll(listingID, "-", ""), link.ListingID)
+	require.NotNil(t, link.Campaign)
+	assert.Equal(t, "spring", *link.Campaign)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestCreate_GivesUp(t *testing.T) {
+	service, mockPool, _ := newTestService(t)
+	expectListing(mockPool, sellerID)
+	for range maxCodeAttempts {
+		mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CreateShortLink`)).
+			WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+			WillReturnError(pgx.ErrNoRows)
+	}
+
+	_, err := service.Create(context.Background(), userInfo, listingID, &CreateShortLinkRequest{})
+
+	require.Error(t, err)
+	assert.Equal(t, errors.ErrInternal, err.(*errors.AppError).Code)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestCreate_NotOwner(t *testing.T) {
+	service, mockPool, _ := newTestService(t)
+	expectListing(mockPool, otherID)
+
+	_, err := service.Create(context.Background(), userInfo, listingID, &CreateShortLinkRequest{})
+
+	require.Error(t, err)
+	assert.Equal(t, errors.ReasonListingNotOwner, err.(*errors.AppError).Reason)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestResolve(t *testing.T) {
+	// SCENARIO: A short link is followed twice.
+	// EXPECT: The listing page with UTM parameters both times, one query with the second served from the cache, and
+	// both clicks counted.
+
+	service, mockPool, store := newTestService(t)
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetShortLinkTarget`)).WithArgs("Ab3dE9").
+		WillReturnRows(pgxmock.NewRows([]string{"listing_id", "campaign", "revoked_at", "listing_deleted_at"}).
+			AddRow(listingID, "spring", nil, nil))
+
+	for range 2 {
+		target, err := service.Resolve(context.Background(), "Ab3dE9")
+		require.NoError(t, err)
+
+		u, err := url.Parse(target)
+		require.NoError(t, err)
+		assert.Equal(t, "https://web.test/listings/"+strings.ReplaceAll(listingID, "-", ""), u.Scheme+"://"+u.Host+u.Path)
+		assert.Equal(t, url.Values{
+			"utm_source":   {"short_link"},
+			"utm_medium":   {"social"},
+			"utm_campaign": {"spring"},
+			"utm_content":  {"Ab3dE9"},
+		}, u.Query())
+	}
+	assert.Equal(t, 2, store.clicks["Ab3dE9"])
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestResolve_Refused(t *testing.T) {
+	deleted := time.Now()
+	tests := map[string]struct {
+		code       string
+		row        []any
+		wantCode   errors.ErrorCode
+		wantReason errors.Reason
+	}{
+		"malformed code":  {code: "../etc", wantCode: errors.ErrNotFound, wantReason: errors.ReasonShortLinkNotFound},
+		"unknown code":    {code: "Ab3dE9", wantCode: errors.ErrNotFound, wantReason: errors.ReasonShortLinkNotFound},
+		"revoked":         {code: "Ab3dE9", row: []any{listingID, nil, deleted, nil}, wantCode: errors.ErrGone, wantReason: errors.ReasonShortLinkRevoked},
+		"listing deleted": {code: "Ab3dE9", row: []any{listingID, nil, nil, deleted}, wantCode: errors.ErrGone, wantReason: errors.ReasonShortLinkListingDeleted},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			service, mockPool, store := newTestService(t)
+			if validCode(tt.code) {
+				rows := pgxmock.NewRows([]string{"listing_id", "campaign", "revoked_at", "listing_deleted_at"})
+				if tt.row != nil {
+					rows.AddRow(tt.row...)
+				}
+				mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetShortLinkTarget`)).WithArgs(tt.code).WillReturnRows(rows)
+			}
+
+			_, err := service.Resolve(context.Background(), tt.code)
+
+			require.Error(t, err)
+			appErr := err.(*errors.AppError)
+			assert.Equal(t, tt.wantCode, appErr.Code)
+			assert.Equal(t, tt.wantReason, appErr.Reason)
+			assert.Empty(t, store.clicks)
+			assert.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRevoke_BustsCache(t *testing.T) {
+	service, mockPool, store := newTestService(t)
+	store.targets["Ab3dE9"] = Target{ListingID: listingID}
+	expectListing(mockPool, sellerID)
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: RevokeShortLink`)).WithArgs("Ab3dE9", pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	require.NoError(t, service.Revoke(context.Background(), userInfo, listingID, "Ab3dE9"))
+
+	assert.NotContains(t, store.targets, "Ab3dE9")
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -0,0 +1,48 @@
+package shortlinks
+
+import (
+	"context"
+	"gateway/internal/cache"
+	"time"
+)
+
+const targetKeyPrefix = "short-link:"
+
+// Target is where a short link goes, cached so a link doing the rounds doesn't cost a query per click
+type Target struct {
+	ListingID      string `json:"listing_id"` // Dashless
+	Campaign       string `json:"campaign"`   // "" for none
+	Revoked        bool   `json:"revoked"`
+	ListingDeleted bool   `json:"listing_deleted"`
+}
+
+type TargetStore interface {
+	GetTarget(ctx context.Context, code string) (*Target, bool, error)
+	SetTarget(ctx context.Context, code string, target Target, ttl time.Duration) error
+	DelTarget(ctx context.Context, code string) error
+}
+
+// ClickCounter buffers clicks for the listings worker's counter flush, see counters.Store
+type ClickCounter interface {
+	IncrClick(ctx context.Context, code string) error
+}
+
+type Store struct {
+	cache *cache.RedisClient
+}
+
+func NewStore(c *cache.RedisClient) *Store {
+	return &Store{cache: c}
+}
+
+func (s *Store) GetTarget(ctx context.Context, code string) (*Target, bool, error) {
+	return cache.Get[Target](s.cache, ctx, targetKeyPrefix+code)
+}
+
+func (s *Store) SetTarget(ctx context.Context, code string, target Target, ttl time.Duration) error {
+	return cache.Set(s.cache, ctx, targetKeyPrefix+code, target, ttl)
+}
+
+func (s *Store) DelTarget(ctx context.Context, code string) error {
+	return cache.Del(s.cache, ctx, targetKeyPrefix+code)
+}
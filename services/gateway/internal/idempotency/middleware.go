@@ -3,9 +3,15 @@ package idempotency
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"gateway/internal/auth"
+	"gateway/internal/detach"
 	"gateway/internal/errors"
+	"io"
 	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -20,6 +26,10 @@ type IdempotencyResponse struct {
 	StatusCode int                 `json:"status_code"`
 	Headers    map[string][]string `json:"headers"`
 	Body       []byte              `json:"body"`
+
+	// SHA-256 of the request body that produced the response, a retry with another body is refused rather than given
+	// a response for a payload it didn't send. Empty for responses saved before it was recorded, those replay as before.
+	RequestHash string `json:"request_hash,omitempty"`
 }
 
 var ignoredHeaders = map[string]bool{
@@ -33,17 +43,84 @@ var ignoredHeaders = map[string]bool{
 	"Connection":                       true,
 }
 
-func Idempotency(store IdempotencyStore) func(http.Handler) http.Handler {
+// storeTimeout bounds the lock rollbacks and response saves, which run detached from the request
+const storeTimeout = 5 * time.Second
+
+// Responses bigger than this are streamed straight through and never stored
+const maxRecordedBodyBytes = 1 << 20 // 1MB
+
+// Only this much of a request body is hashed, the rest streams to the handler unread. Every JSON body the gateway takes
+// fits, files go straight to object storage.
+const maxHashedBodyBytes = 1 << 20 // 1MB
+
+// skipped marks a route that opted out with Skip
+type skipped struct {
+	http.Handler
+}
+
+// Skip opts a route out of idempotency, for endpoints that stream large bodies that must not be buffered.
+// It is detected when the route is registered, so it has to be the first middleware given to With and
+// Idempotency has to be the last middleware of the group:
+//
+//	r.With(idempotency.Skip()).Post("/listings/export", h.Export)
+func Skip() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return skipped{next}
+	}
+}
+
+// Require refuses mutating requests that don't send an Idempotency-Key, for endpoints where a blind retry after a
+// timeout would be expensive or confusing to repeat. Idempotency still does the replaying, Require only makes the key mandatory:
+//
+//	r.With(idempotency.Require).Post("/listings/bulk", h.BulkUpdateListings)
+func Require(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isMutating(r.Method) && r.Header.Get("Idempotency-Key") == "" {
+			errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Idempotency-Key header is required", nil).WithReason(errors.ReasonIdempotencyKeyRequired))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// scopedKey is where a request's lock and response are kept. The client's key alone isn't enough: two users sending the
+// same key, by accident or to replay someone else's response with its presigned URLs, must never see each other's, and
+// the same key sent to another endpoint is another request.
+func scopedKey(userID, method, path, key string) string {
+	return "idempotency:" + userID + ":" + method + ":" + path + ":" + key
+}
+
+// Idempotency replays stored responses for repeated Idempotency-Key headers, per user, method and path.
+// Only mutating methods of signed in users are covered, reads are safe to repeat and can be large, and an anonymous
+// request has no one to scope the key to. It has to come after the authenticator.
+// Responses are saved in the background after the request completes, background tracks those saves so shutdown can wait for them.
+func Idempotency(store IdempotencyStore, background *sync.WaitGroup) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
+		if _, ok := next.(skipped); ok {
+			return next
+		}
+
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
 
 			// A. Check for the Header
-			key := r.Header.Get("Idempotency-Key")
-			if key == "" {
+			clientKey := r.Header.Get("Idempotency-Key")
+			if clientKey == "" || !isMutating(r.Method) {
 				next.ServeHTTP(w, r)
 				return
 			}
+			userInfo, err := auth.GetUserInfo(ctx)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key := scopedKey(userInfo.ID, r.Method, r.URL.Path, clientKey)
+
+			requestHash, err := hashBody(r)
+			if err != nil {
+				errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Failed to read request body", err))
+				return
+			}
 
 			// A. TRY TO LOCK (Atomic SETNX)
 			// This prevents the Race Condition. Only one request passes this line.
@@ -64,6 +141,12 @@ func Idempotency(store IdempotencyStore) func(http.Handler) http.Handler {
 					return
 				}
 
+				if found && cachedResp != nil && cachedResp.RequestHash != "" && cachedResp.RequestHash != requestHash {
+					// A client bug, the replay would answer for a payload it didn't send this time
+					errors.RespondError(w, r, errors.New(errors.ErrConflict, "Idempotency key reused with different payload", nil).WithReason(errors.ReasonIdempotencyKeyReused))
+					return
+				}
+
 				if found && cachedResp != nil {
 					// SUCCESS: We have a saved response. Replay it.
 					for k, v := range cachedResp.Headers {
@@ -92,21 +175,40 @@ func Idempotency(store IdempotencyStore) func(http.Handler) http.Handler {
 				ResponseWriter: w,
 				statusCode:     http.StatusOK,
 				body:           &bytes.Buffer{},
+				limit:          maxRecordedBodyBytes,
 			}
 
 			// Run the actual handler
 			next.ServeHTTP(recorder, r)
 
 			/// 1. Server Error (5xx) -> ROLLBACK
-			if recorder.statusCode >= 500 || recorder.statusCode == http.StatusTooManyRequests {
+			if isRetryable(recorder.statusCode) {
 				slog.WarnContext(ctx, "Idempotency: Server error detected, deleting lock", "key", key)
-				_ = store.Delete(context.Background(), key)
+				rollback(ctx, store, key)
+				return
+			}
+
+			// The client went away before the handler answered, so nothing says how far it got. The retry that follows
+			// has to run the request again rather than wait out the lock or replay an empty 200. A handler that did
+			// answer finished its work, that answer is kept like any other.
+			if ctx.Err() != nil && !recorder.answered {
+				slog.WarnContext(ctx, "Idempotency: Request canceled, deleting lock", "key", key, "error", ctx.Err())
+				rollback(ctx, store, key)
+				return
+			}
+
+			// Too big to keep, let a retry run the request again rather than hold it in Redis
+			if recorder.overflowed {
+				slog.WarnContext(ctx, "Idempotency: Response too large to store, not caching", "key", key, "limit_bytes", recorder.limit)
+				rollback(ctx, store, key)
 				return
 			}
 			// 2. Success/Client Error -> SAVE PERMANENTLY
-			// Use detached context for saving
-			go func(k string, status int, headers http.Header, body []byte) {
-				saveCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			// Detached, the client may already have hung up after reading the response
+			background.Add(1)
+			go func(k string, status int, headers http.Header, body []byte, requestHash string) {
+				defer background.Done()
+				saveCtx, cancel := detach.WithTimeout(ctx, storeTimeout)
 				defer cancel()
 
 				cleanHeaders := make(http.Header)
@@ -117,37 +219,104 @@ func Idempotency(store IdempotencyStore) func(http.Handler) http.Handler {
 				}
 
 				resp := IdempotencyResponse{
-					StatusCode: status,
-					Headers:    cleanHeaders,
-					Body:       body,
+					StatusCode:  status,
+					Headers:     cleanHeaders,
+					Body:        body,
+					RequestHash: requestHash,
 				}
 
 				// This Overwrites the "PROCESSING" lock with the real data
 				if err := store.SaveResponse(saveCtx, k, resp); err != nil {
 					slog.ErrorContext(saveCtx, "Failed to save idempotency response", "error", err)
 				}
-			}(key, recorder.statusCode, recorder.Header(), recorder.body.Bytes())
+			}(key, recorder.statusCode, recorder.Header(), recorder.body.Bytes(), requestHash)
 		})
 	}
 }
 
+// hashBody is the hex SHA-256 of the first maxHashedBodyBytes of r's body, which is put back for the handler to read
+func hashBody(r *http.Request) (string, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return hex.EncodeToString(sha256.New().Sum(nil)), nil
+	}
+	head, err := io.ReadAll(io.LimitReader(r.Body, maxHashedBodyBytes))
+	if err != nil {
+		return "", err
+	}
+	r.Body = readCloser{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+	sum := sha256.Sum256(head)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// readCloser reads the replayed head and then the rest of the body, closing closes the original
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// rollback deletes the key so a retry runs the request again. Detached, ctx may be the one that was canceled.
+func rollback(ctx context.Context, store IdempotencyStore, key string) {
+	deleteCtx, cancel := detach.WithTimeout(ctx, storeTimeout)
+	defer cancel()
+	if err := store.Delete(deleteCtx, key); err != nil {
+		slog.ErrorContext(ctx, "Idempotency: Failed to delete lock", "key", key, "error", err)
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// Responses that are rolled back instead of stored, so the client can retry with the same key. 429 covers both
+// RATE_LIMITED and a scrape guard BLOCKED, neither says anything about the request itself. 499 is a handler that
+// stopped because the client went away.
+func isRetryable(status int) bool {
+	return status >= 500 || status == http.StatusTooManyRequests || status == errors.StatusClientClosedRequest
+}
+
 // This hooks into the response stream to copy the data as it goes out.
 type responseRecorder struct {
 	http.ResponseWriter
 	statusCode int
 	body       *bytes.Buffer
+	limit      int
+	answered   bool // The handler wrote a status or body
+
+	// Set once the body passes limit, the buffer is dropped and the rest streams through
+	overflowed bool
 }
 
 // Intercept WriteHeader to capture the status code
 func (r *responseRecorder) WriteHeader(code int) {
 	r.statusCode = code
+	r.answered = true
 	r.ResponseWriter.WriteHeader(code)
 }
 
 // Intercept Write to capture the body data
 func (r *responseRecorder) Write(b []byte) (int, error) {
-	// Write to our buffer
-	r.body.Write(b)
+	r.answered = true
+	// Errors are rolled back and big bodies aren't stored, no point buffering either
+	if !r.overflowed && !isRetryable(r.statusCode) {
+		if r.body.Len()+len(b) > r.limit {
+			r.overflowed = true
+			r.body = &bytes.Buffer{}
+		} else {
+			r.body.Write(b)
+		}
+	}
+
 	// Write to the actual client
 	return r.ResponseWriter.Write(b)
 }
+
+// Flush lets streaming handlers push data out through the recorder
+func (r *responseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
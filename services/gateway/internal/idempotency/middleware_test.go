@@ -0,0 +1,471 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// FakeStore keeps locks and responses in memory. Like Redis it refuses writes on a canceled context.
+type FakeStore struct {
+	mu        sync.Mutex
+	locks     map[string]bool
+	responses map[string]IdempotencyResponse
+	calls     int
+}
+
+func NewFakeStore() *FakeStore {
+	return &FakeStore{locks: map[string]bool{}, responses: map[string]IdempotencyResponse{}}
+}
+
+func (f *FakeStore) Lock(ctx context.Context, key string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if _, done := f.responses[key]; done || f.locks[key] {
+		return false, nil
+	}
+	f.locks[key] = true
+	return true, nil
+}
+
+func (f *FakeStore) GetResponse(ctx context.Context, key string) (*IdempotencyResponse, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp, ok := f.responses[key]
+	if !ok {
+		return nil, false, nil
+	}
+	return &resp, true, nil
+}
+
+func (f *FakeStore) SaveResponse(ctx context.Context, key string, resp IdempotencyResponse) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[key] = resp
+	delete(f.locks, key)
+	return nil
+}
+
+func (f *FakeStore) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.locks, key)
+	delete(f.responses, key)
+	return nil
+}
+
+// countingHandler writes body with status and counts how often it ran
+type countingHandler struct {
+	mu     sync.Mutex
+	runs   int
+	status int
+	body   []byte
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.runs++
+	h.mu.Unlock()
+	w.WriteHeader(h.status)
+	w.Write(h.body)
+}
+
+func newTest(status int, body []byte) (*FakeStore, *countingHandler, *sync.WaitGroup, http.Handler) {
+	store := NewFakeStore()
+	handler := &countingHandler{status: status, body: body}
+	background := &sync.WaitGroup{}
+	return store, handler, background, Idempotency(store, background)(handler)
+}
+
+const (
+	userA = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	userB = "b0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+)
+
+// asUser signs req in as userID, like the authenticator in front of Idempotency would
+func asUser(req *http.Request, userID string) *http.Request {
+	return req.WithContext(auth.WithUserInfo(req.Context(), auth.UserInfo{ID: userID}))
+}
+
+// storedKey is where userA's POST /listings with key is kept
+func storedKey(key string) string {
+	return scopedKey(userA, http.MethodPost, "/listings", key)
+}
+
+// send sends method /listings as userA
+func send(h http.Handler, method, key string) *httptest.ResponseRecorder {
+	return sendAs(h, userA, method, "/listings", key)
+}
+
+func sendAs(h http.Handler, userID, method, path, key string) *httptest.ResponseRecorder {
+	return sendBody(h, userID, method, path, key, "")
+}
+
+func sendBody(h http.Handler, userID, method, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	if userID != "" {
+		req = asUser(req, userID)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotency_ReplaysMutatingRequest(t *testing.T) {
+	store, handler, background, h := newTest(http.StatusCreated, []byte(`{"id":"1"}`))
+
+	first := send(h, http.MethodPost, "key-1")
+	background.Wait()
+	second := send(h, http.MethodPost, "key-1")
+
+	assert.Equal(t, 1, handler.runs)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get("X-Idempotency-Hit"))
+	assert.Contains(t, store.responses, storedKey("key-1"))
+}
+
+func TestIdempotency_InProgress_Conflict(t *testing.T) {
+	store, handler, _, h := newTest(http.StatusCreated, nil)
+	store.locks[storedKey("key-1")] = true
+
+	rec := send(h, http.MethodPost, "key-1")
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.Equal(t, 0, handler.runs)
+}
+
+func TestIdempotency_ReadsPassThrough(t *testing.T) {
+	// SCENARIO: Clients send the header on every request, including GET /listings.
+	// EXPECT: Reads run every time and nothing is locked or stored.
+
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodOptions} {
+		store, handler, background, h := newTest(http.StatusOK, []byte(`[]`))
+
+		send(h, method, "key-1")
+		background.Wait()
+		send(h, method, "key-1")
+
+		assert.Equal(t, 2, handler.runs, method)
+		assert.Zero(t, store.calls, method)
+		assert.Empty(t, store.responses, method)
+	}
+}
+
+func TestIdempotency_LargeBody_StreamedNotStored(t *testing.T) {
+	// SCENARIO: A mutating endpoint returns more than the recorder cap.
+	// EXPECT: The client still gets the whole body, nothing is stored and the lock is released.
+
+	body := bytes.Repeat([]byte("x"), maxRecordedBodyBytes+1)
+	store, handler, background, h := newTest(http.StatusOK, body)
+
+	rec := send(h, http.MethodPost, "key-1")
+	background.Wait()
+
+	assert.Equal(t, len(body), rec.Body.Len())
+	assert.Empty(t, store.responses)
+	assert.Empty(t, store.locks)
+
+	// A retry runs again instead of being told it's in progress
+	send(h, http.MethodPost, "key-1")
+	assert.Equal(t, 2, handler.runs)
+}
+
+func TestIdempotency_ServerError_RolledBackWithoutBuffering(t *testing.T) {
+	store := NewFakeStore()
+	var recorder *responseRecorder
+	h := Idempotency(store, &sync.WaitGroup{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder = w.(*responseRecorder)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error_code":"INTERNAL"}`))
+	}))
+
+	rec := send(h, http.MethodPost, "key-1")
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, `{"error_code":"INTERNAL"}`, rec.Body.String())
+	assert.Zero(t, recorder.body.Len(), "5xx bodies are rolled back, they shouldn't be buffered")
+	assert.Empty(t, store.locks)
+	assert.Empty(t, store.responses)
+}
+
+func TestIdempotency_BlockedNotStored(t *testing.T) {
+	// SCENARIO: A caller is blocked by the scrape guard, waits out Retry-After and retries with the same key.
+	// EXPECT: The block isn't stored against the key, so the retry runs instead of replaying the 429.
+
+	store, handler, background, h := newTest(http.StatusTooManyRequests, []byte(`{"error_code":"BLOCKED","reason":"SCRAPE_BLOCKED"}`))
+
+	rec := send(h, http.MethodPost, "key-1")
+	background.Wait()
+
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Empty(t, store.responses)
+	assert.Empty(t, store.locks)
+
+	handler.status, handler.body = http.StatusCreated, []byte(`{"id":"1"}`)
+	rec = send(h, http.MethodPost, "key-1")
+	background.Wait()
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, 2, handler.runs)
+}
+
+func TestIdempotency_CanceledMidHandler_RolledBack(t *testing.T) {
+	// SCENARIO: The client hangs up while the handler runs, and the handler gives up without answering or answers 499.
+	// EXPECT: The lock is released rather than left to expire or stored as an empty 200, so a retry runs again.
+
+	tests := map[string]func(w http.ResponseWriter){
+		"no answer": func(w http.ResponseWriter) {},
+		"499":       func(w http.ResponseWriter) { w.WriteHeader(errors.StatusClientClosedRequest) },
+	}
+
+	for name, answer := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := NewFakeStore()
+			background := &sync.WaitGroup{}
+			runs := 0
+			var cancel context.CancelFunc
+			h := Idempotency(store, background)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				runs++
+				if cancel != nil {
+					cancel()
+					answer(w)
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+			}))
+
+			ctx, c := context.WithCancel(context.Background())
+			cancel = c
+			req := asUser(httptest.NewRequestWithContext(ctx, http.MethodPost, "/listings", nil), userA)
+			req.Header.Set("Idempotency-Key", "key-1")
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			background.Wait()
+
+			assert.Empty(t, store.locks)
+			assert.Empty(t, store.responses)
+
+			cancel = nil
+			rec := send(h, http.MethodPost, "key-1")
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, 2, runs)
+		})
+	}
+}
+
+func TestIdempotency_CanceledAfterAnswer_Stored(t *testing.T) {
+	// SCENARIO: The handler created something and answered, then the client hung up before reading it.
+	// EXPECT: The answer is stored anyway, so the retry replays it instead of creating a second one.
+
+	store := NewFakeStore()
+	background := &sync.WaitGroup{}
+	ctx, cancel := context.WithCancel(context.Background())
+	h := Idempotency(store, background)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"1"}`))
+		cancel()
+	}))
+
+	req := asUser(httptest.NewRequestWithContext(ctx, http.MethodPost, "/listings", nil), userA)
+	req.Header.Set("Idempotency-Key", "key-1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	background.Wait()
+
+	require.Contains(t, store.responses, storedKey("key-1"))
+	assert.Equal(t, http.StatusCreated, store.responses[storedKey("key-1")].StatusCode)
+}
+
+func TestIdempotency_SkipOptsRouteOut(t *testing.T) {
+	store := NewFakeStore()
+	runs := 0
+
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(Idempotency(store, &sync.WaitGroup{}))
+		r.With(Skip()).Post("/exports", func(w http.ResponseWriter, r *http.Request) {
+			runs++
+			w.Write([]byte(strings.Repeat("row\n", 10)))
+		})
+		r.Post("/listings", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		})
+	})
+
+	for i := 0; i < 2; i++ {
+		req := asUser(httptest.NewRequest(http.MethodPost, "/exports", nil), userA)
+		req.Header.Set("Idempotency-Key", "key-1")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+	}
+	assert.Equal(t, 2, runs)
+	assert.Zero(t, store.calls)
+
+	// Routes without Skip in the same group are still covered
+	req := asUser(httptest.NewRequest(http.MethodPost, "/listings", nil), userA)
+	req.Header.Set("Idempotency-Key", "key-2")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, 1, store.calls)
+}
+
+func TestRequire_RejectsMissingKey(t *testing.T) {
+	store, handler, background, _ := newTest(http.StatusOK, []byte(`{"results":[]}`))
+	h := Idempotency(store, background)(Require(handler))
+
+	rec := send(h, http.MethodPost, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "IDEMPOTENCY_KEY_REQUIRED")
+	assert.Zero(t, handler.runs)
+
+	// With a key the request is served and replayed as usual
+	first := send(h, http.MethodPost, "key-1")
+	background.Wait()
+	second := send(h, http.MethodPost, "key-1")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "true", second.Header().Get("X-Idempotency-Hit"))
+	assert.Equal(t, 1, handler.runs)
+}
+
+func TestIdempotency_ScopedPerUser(t *testing.T) {
+	// SCENARIO: Two users send POST /listings with the same Idempotency-Key, then each retries.
+	// EXPECT: Both requests run and each user's retry replays their own response, never the other's.
+
+	store := NewFakeStore()
+	background := &sync.WaitGroup{}
+	h := Idempotency(store, background)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userInfo, _ := auth.GetUserInfo(r.Context())
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"seller_id":"` + userInfo.ID + `"}`))
+	}))
+
+	firstA := sendAs(h, userA, http.MethodPost, "/listings", "shared-key")
+	background.Wait()
+	firstB := sendAs(h, userB, http.MethodPost, "/listings", "shared-key")
+	background.Wait()
+
+	assert.Empty(t, firstB.Header().Get("X-Idempotency-Hit"), "userB must not get userA's response")
+	assert.Contains(t, firstB.Body.String(), userB)
+	assert.NotContains(t, firstB.Body.String(), userA)
+
+	retryA := sendAs(h, userA, http.MethodPost, "/listings", "shared-key")
+	retryB := sendAs(h, userB, http.MethodPost, "/listings", "shared-key")
+	assert.Equal(t, "true", retryA.Header().Get("X-Idempotency-Hit"))
+	assert.Equal(t, firstA.Body.String(), retryA.Body.String())
+	assert.Equal(t, "true", retryB.Header().Get("X-Idempotency-Hit"))
+	assert.Equal(t, firstB.Body.String(), retryB.Body.String())
+}
+
+func TestIdempotency_ScopedPerRoute(t *testing.T) {
+	// SCENARIO: A client reuses one key for a delete and a create, and for two different listings.
+	// EXPECT: Each method and path is its own request, none replays another's response.
+
+	store, handler, background, h := newTest(http.StatusCreated, []byte(`{"id":"1"}`))
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodPost, "/listings"},
+		{http.MethodDelete, "/listings/1"},
+		{http.MethodDelete, "/listings/2"},
+	} {
+		rec := sendAs(h, userA, req.method, req.path, "key-1")
+		background.Wait()
+		assert.Empty(t, rec.Header().Get("X-Idempotency-Hit"), req.method+" "+req.path)
+	}
+	assert.Equal(t, 3, handler.runs)
+	assert.Len(t, store.responses, 3)
+}
+
+func TestIdempotency_AnonymousPassesThrough(t *testing.T) {
+	// SCENARIO: A signed out caller sends an Idempotency-Key to a public route.
+	// EXPECT: There's no one to scope the key to, so the request runs every time and nothing is locked or stored.
+
+	store, handler, background, h := newTest(http.StatusCreated, []byte(`{"id":"1"}`))
+
+	sendAs(h, "", http.MethodPost, "/listings/hydrate", "key-1")
+	background.Wait()
+	rec := sendAs(h, "", http.MethodPost, "/listings/hydrate", "key-1")
+
+	assert.Empty(t, rec.Header().Get("X-Idempotency-Hit"))
+	assert.Equal(t, 2, handler.runs)
+	assert.Zero(t, store.calls)
+}
+
+func TestIdempotency_DifferentBody_Rejected(t *testing.T) {
+	// SCENARIO: A client bug sends a second create with the key of the first but a different payload.
+	// EXPECT: 422 IDEMPOTENCY_KEY_REUSED instead of the first response, the handler doesn't run and the first response
+	// still replays for the payload it was for.
+
+	store, handler, background, h := newTest(http.StatusCreated, []byte(`{"id":"1"}`))
+
+	sendBody(h, userA, http.MethodPost, "/listings", "key-1", `{"title":"Dragon"}`)
+	background.Wait()
+	rec := sendBody(h, userA, http.MethodPost, "/listings", "key-1", `{"title":"Fox"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "IDEMPOTENCY_KEY_REUSED")
+	assert.Empty(t, rec.Header().Get("X-Idempotency-Hit"))
+	assert.Equal(t, 1, handler.runs)
+
+	rec = sendBody(h, userA, http.MethodPost, "/listings", "key-1", `{"title":"Dragon"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get("X-Idempotency-Hit"))
+	assert.Len(t, store.responses, 1)
+}
+
+func TestIdempotency_HandlerReadsWholeBody(t *testing.T) {
+	// SCENARIO: A body bigger than the hashed part is sent.
+	// EXPECT: The handler still reads every byte, and a retry that only differs after the hashed part replays.
+
+	store := NewFakeStore()
+	background := &sync.WaitGroup{}
+	var read []int
+	h := Idempotency(store, background)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		read = append(read, len(body))
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	head := strings.Repeat("x", maxHashedBodyBytes)
+	sendBody(h, userA, http.MethodPost, "/listings", "key-1", head+"tail")
+	background.Wait()
+	rec := sendBody(h, userA, http.MethodPost, "/listings", "key-1", head+"other tail")
+
+	assert.Equal(t, []int{maxHashedBodyBytes + 4}, read)
+	assert.Equal(t, "true", rec.Header().Get("X-Idempotency-Hit"))
+}
+
+func TestIdempotency_StoredWithoutHash_Replays(t *testing.T) {
+	// SCENARIO: A response saved before request hashes were recorded is retried.
+	// EXPECT: It replays whatever the body, there's nothing to compare it to.
+
+	store, handler, _, h := newTest(http.StatusCreated, nil)
+	store.responses[storedKey("key-1")] = IdempotencyResponse{StatusCode: http.StatusCreated, Body: []byte(`{"id":"1"}`)}
+
+	rec := sendBody(h, userA, http.MethodPost, "/listings", "key-1", `{"title":"Fox"}`)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, `{"id":"1"}`, rec.Body.String())
+	assert.Zero(t, handler.runs)
+}
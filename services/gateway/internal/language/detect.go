@@ -0,0 +1,225 @@
+// Package language guesses the dominant language of a listing's title and description so search can filter on it.
+// It's a character trigram detector trained on the samples in samples/ when the package loads, with no external calls.
+// A guess it isn't confident in is Undetermined rather than wrong.
+package language
+
+import (
+	"embed"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+
+	textlanguage "golang.org/x/text/language"
+)
+
+// Undetermined is the ISO 639-2 code for text whose language can't be told: too short, too close to call or not words
+const Undetermined = "und"
+
+const (
+	// Fewer letters than this is a title like "Benchy" that reads the same in every language
+	minLetters = 20
+
+	// Only the start of a long description is looked at, the language doesn't change halfway through
+	maxRunes = 2000
+
+	// The best language has to beat the runner up by this much per trigram, on the natural log scale. Close languages
+	// like Spanish and Portuguese are usually 0.2 apart on a sentence or two, below this it's a guess.
+	minMargin = 0.03
+
+	// Majority of letters in one of these scripts decides the language without trigrams
+	minScriptShare = 0.5
+)
+
+//go:embed samples/*.txt
+var samples embed.FS
+
+// profile is how likely each trigram is in a language, as a log probability
+type profile struct {
+	code     string
+	logProb  map[string]float64
+	unseenLP float64 // For trigrams the sample never had
+}
+
+var profiles = loadProfiles()
+
+func loadProfiles() []profile {
+	entries, err := samples.ReadDir("samples")
+	if err != nil {
+		panic(err)
+	}
+	var loaded []profile
+	for _, entry := range entries {
+		text, err := samples.ReadFile(path.Join("samples", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		loaded = append(loaded, train(strings.TrimSuffix(entry.Name(), ".txt"), string(text)))
+	}
+	return loaded
+}
+
+// train smooths the sample's trigram counts by one, so a trigram it never saw is unlikely rather than impossible
+func train(code, text string) profile {
+	counts, total := trigrams(text)
+	vocabulary := float64(len(counts) + 1)
+	p := profile{code: code, logProb: make(map[string]float64, len(counts))}
+	for gram, n := range counts {
+		p.logProb[gram] = math.Log((float64(n) + 1) / (float64(total) + vocabulary))
+	}
+	p.unseenLP = math.Log(1 / (float64(total) + vocabulary))
+	return p
+}
+
+// scripts are writing systems only one of the languages we tag uses, Han on its own is assumed to be Chinese
+var scripts = []struct {
+	table *unicode.RangeTable
+	code  string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Han, "zh"},
+	{unicode.Greek, "el"},
+	{unicode.Hebrew, "he"},
+	{unicode.Arabic, "ar"},
+	{unicode.Thai, "th"},
+}
+
+// Supported are the codes Detect can return besides Undetermined, sorted
+func Supported() []string {
+	seen := map[string]bool{}
+	for _, p := range profiles {
+		seen[p.code] = true
+	}
+	for _, s := range scripts {
+		seen[s.code] = true
+	}
+	codes := make([]string, 0, len(seen))
+	for code := range seen {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// FromAcceptLanguage is the language the client prefers most out of the ones Detect tags, from an Accept-Language
+// header. False when it sends none of them, or no header.
+func FromAcceptLanguage(header string) (string, bool) {
+	tags, _, err := textlanguage.ParseAcceptLanguage(header)
+	if err != nil || len(tags) == 0 {
+		return "", false
+	}
+	supported := Supported()
+	candidates := make([]textlanguage.Tag, len(supported))
+	for i, code := range supported {
+		candidates[i] = textlanguage.Make(code)
+	}
+	_, index, confidence := textlanguage.NewMatcher(candidates).Match(tags...)
+	if confidence < textlanguage.High {
+		return "", false
+	}
+	return supported[index], true
+}
+
+// Detect returns the ISO 639-1 code of text's dominant language, or Undetermined. It never fails, so it can't hold up
+// creating a listing.
+func Detect(text string) string {
+	if len(text) > maxRunes*4 {
+		text = text[:maxRunes*4]
+	}
+	if runes := []rune(text); len(runes) > maxRunes {
+		text = string(runes[:maxRunes])
+	}
+
+	if code, ok := byScript(text); ok {
+		return code
+	}
+
+	counts, total := trigrams(text)
+	if letters(text) < minLetters || total == 0 {
+		return Undetermined
+	}
+
+	best, second := math.Inf(-1), math.Inf(-1)
+	code := Undetermined
+	for _, p := range profiles {
+		score := 0.0
+		for gram, n := range counts {
+			lp, ok := p.logProb[gram]
+			if !ok {
+				lp = p.unseenLP
+			}
+			score += float64(n) * lp
+		}
+		switch {
+		case score > best:
+			best, second, code = score, best, p.code
+		case score > second:
+			second = score
+		}
+	}
+
+	if (best-second)/float64(total) < minMargin {
+		return Undetermined
+	}
+	return code
+}
+
+// byScript recognises text mostly written in a script from scripts. Kana anywhere makes Han text Japanese.
+func byScript(text string) (string, bool) {
+	counts := map[string]int{}
+	kana, total := false, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		total++
+		for _, s := range scripts {
+			if unicode.Is(s.table, r) {
+				counts[s.code]++
+				kana = kana || s.code == "ja"
+				break
+			}
+		}
+	}
+	if total == 0 {
+		return "", false
+	}
+	if kana {
+		counts["ja"] += counts["zh"]
+		delete(counts, "zh")
+	}
+	for code, n := range counts {
+		if float64(n)/float64(total) > minScriptShare {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+func letters(text string) int {
+	n := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// trigrams counts the three letter sequences of each lower cased word padded with a space either side, so "the"
+// gives " th", "the" and "he ". Digits, punctuation and emoji separate words and are never part of one.
+func trigrams(text string) (map[string]int, int) {
+	counts := map[string]int{}
+	total := 0
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		padded := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			counts[string(padded[i:i+3])]++
+			total++
+		}
+	}
+	return counts, total
+}
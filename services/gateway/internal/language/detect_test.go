@@ -0,0 +1,118 @@
+package language
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	// SCENARIO: Titles and descriptions as sellers write them, none of which are in the training samples.
+	// EXPECT: The language they're written in.
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"english", "Modular cable organiser\nClips onto the edge of any desk up to 30 mm thick and keeps your chargers from falling behind it.", "en"},
+		{"german", "Modularer Kabelhalter\nWird an die Kante jedes Schreibtischs bis 30 mm geklemmt und verhindert, dass die Ladekabel dahinter fallen.", "de"},
+		{"french", "Organisateur de câbles modulaire\nSe fixe sur le bord de n'importe quel bureau jusqu'à 30 mm et empêche vos chargeurs de tomber derrière.", "fr"},
+		{"spanish", "Organizador de cables modular\nSe sujeta al borde de cualquier escritorio de hasta 30 mm y evita que los cargadores se caigan por detrás.", "es"},
+		{"italian", "Organizzatore di cavi modulare\nSi aggancia al bordo di qualsiasi scrivania fino a 30 mm e impedisce ai caricatori di cadere dietro.", "it"},
+		{"dutch", "Modulaire kabelhouder\nKlemt op de rand van elk bureau tot 30 mm dik en zorgt dat je opladers er niet achter vallen.", "nl"},
+		{"portuguese", "Organizador de cabos modular\nPrende-se à borda de qualquer secretária até 30 mm e evita que os carregadores caiam para trás.", "pt"},
+		{"polish", "Modułowy organizer na kable\nZaczepia się o krawędź każdego biurka do 30 mm grubości i nie pozwala ładowarkom spadać za nie.", "pl"},
+		{"russian", "Модульный органайзер для кабелей\nКрепится на край любого стола толщиной до 30 мм и не даёт зарядкам падать за него.", "ru"},
+		{"japanese", "ケーブルオーガナイザー\n厚さ30mmまでの机の端に取り付けられます。", "ja"},
+		{"korean", "모듈식 케이블 정리함\n두께 30mm까지의 책상 가장자리에 끼울 수 있습니다.", "ko"},
+		{"chinese", "模块化理线器\n可夹在厚度不超过30毫米的任何桌子边缘。", "zh"},
+		{"close to spanish", "Vaso em espiral\nImprimir no modo vaso com um bico de 0,6 mm.", "pt"},
+		{"close to portuguese", "Jarrón en espiral\nImprimir en modo jarrón con una boquilla de 0,6 mm.", "es"},
+		{"greek", "Αρθρωτός οργανωτής καλωδίων για κάθε γραφείο", "el"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Detect(tt.text))
+		})
+	}
+}
+
+func TestDetect_ShortText(t *testing.T) {
+	// SCENARIO: A listing with only a short title and no description.
+	// EXPECT: Undetermined, a word or two doesn't say which language it is. Longer titles are enough on their own.
+
+	assert.Equal(t, Undetermined, Detect("Benchy"))
+	assert.Equal(t, Undetermined, Detect("Low poly fox"))
+	assert.Equal(t, Undetermined, Detect("Vase #3 (v2)"))
+	assert.Equal(t, Undetermined, Detect(""))
+	assert.Equal(t, "de", Detect("Wandhalterung für Kopfhörer mit Ablage"))
+	assert.Equal(t, "en", Detect("Wall mounted holder for headphones with a shelf"))
+}
+
+func TestDetect_MixedLanguages(t *testing.T) {
+	// SCENARIO: Text mixing languages the way sellers do.
+	// EXPECT: The language most of it is in.
+
+	// English print settings and jargon in a German description
+	assert.Equal(t, "de", Detect("Articulated Dragon\nDer Drache wird print-in-place gedruckt, ohne Supports. "+
+		"Ich empfehle Silk PLA, 0.2 mm Layer Height und mindestens drei Wände. Die Gelenke sind nach dem Druck sofort beweglich."))
+
+	// An English title on a longer French description
+	assert.Equal(t, "fr", Detect("Phone stand\nUn support de téléphone simple qui tient le téléphone en mode portrait "+
+		"ou paysage. Il s'imprime sans supports en moins de deux heures."))
+
+	// Two short languages in one title, still the one with more of the text
+	assert.Equal(t, "es", Detect("Vase mode / Jarrón en espiral para imprimir en modo jarrón"))
+}
+
+func TestDetect_NoWords(t *testing.T) {
+	// SCENARIO: Emoji, numbers and punctuation with no words at all.
+	// EXPECT: Undetermined, and emoji don't get in the way when there are words.
+
+	assert.Equal(t, Undetermined, Detect("🐉🐉🐉 🔥✨"))
+	assert.Equal(t, Undetermined, Detect("🦊🦊 !!! 100% ⭐⭐⭐⭐⭐ 25x25x40 mm"))
+	assert.Equal(t, Undetermined, Detect(strings.Repeat("🚀", 500)))
+	assert.Equal(t, "en", Detect("🐉 Articulated dragon 🔥 prints in place without any supports ✨"))
+}
+
+func TestDetect_LongText(t *testing.T) {
+	// A very long description is cut off rather than scored in full, and doesn't break a character in half
+	text := strings.Repeat("Dieser Halter passt auf jeden Schreibtisch. ", 500) + "ü"
+	assert.Equal(t, "de", Detect(text))
+}
+
+func TestSupported(t *testing.T) {
+	supported := Supported()
+	assert.Contains(t, supported, "en")
+	assert.Contains(t, supported, "de")
+	assert.Contains(t, supported, "ja")
+	assert.NotContains(t, supported, Undetermined)
+	assert.IsIncreasing(t, supported)
+}
+
+func TestFromAcceptLanguage(t *testing.T) {
+	tests := map[string]struct {
+		header string
+		want   string
+		ok     bool
+	}{
+		"plain":        {"de", "de", true},
+		"region":       {"de-CH,de;q=0.9", "de", true},
+		"weights":      {"fr;q=0.4, en-GB;q=0.8", "en", true},
+		"first we tag": {"sv-SE,sv;q=0.9,nl;q=0.5", "nl", true},
+		"script":       {"zh-Hans-CN", "zh", true},
+		"none we tag":  {"sv-SE,fi;q=0.8", "", false},
+		"wildcard":     {"*", "", false},
+		"empty":        {"", "", false},
+		"garbage":      {"not a header;;q=x", "", false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := FromAcceptLanguage(tt.header)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
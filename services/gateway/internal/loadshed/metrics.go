@@ -0,0 +1,18 @@
+package loadshed
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	inFlightRequests = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gateway_in_flight_requests",
+		Help: "Requests currently being served, as counted by the load shedder.",
+	})
+
+	shedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_load_shed_total",
+		Help: "Requests rejected with 503 by the load shedder, by the signal that triggered it.",
+	}, []string{"signal"})
+)
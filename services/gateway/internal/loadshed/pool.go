@@ -0,0 +1,29 @@
+package loadshed
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PoolSnapshot is the slice of pgxpool.Stat the shedder looks at. pgxpool.Stat can't be built outside pgx,
+// so tests hand in snapshots directly.
+type PoolSnapshot struct {
+	AcquiredConns int32
+	MaxConns      int32
+	// Cumulative count of acquires that had to wait for a connection
+	EmptyAcquireCount int64
+}
+
+// PoolStats returns the current pool state
+type PoolStats func() PoolSnapshot
+
+// FromPool reads stats from a pgx pool
+func FromPool(pool *pgxpool.Pool) PoolStats {
+	return func() PoolSnapshot {
+		stat := pool.Stat()
+		return PoolSnapshot{
+			AcquiredConns:     stat.AcquiredConns(),
+			MaxConns:          stat.MaxConns(),
+			EmptyAcquireCount: stat.EmptyAcquireCount(),
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package loadshed
+
+import (
+	"gateway/internal/errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	SignalInFlight = "in_flight"
+	SignalDBPool   = "db_pool"
+)
+
+type Config struct {
+	// Requests served at once before new ones get a 503. 0 disables the ceiling.
+	MaxInFlight int64
+	// Waited-for pool acquires per sample interval that count as saturated, on top of the pool being fully checked out.
+	// 0 means only an exhausted pool counts.
+	AcquireWaitThreshold int64
+	// How often pool stats are re-read, so a burst of requests doesn't all take the pool lock
+	PoolSampleInterval time.Duration
+	// Hint sent to clients in Retry-After
+	RetryAfter time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		MaxInFlight:          1000,
+		AcquireWaitThreshold: 20,
+		PoolSampleInterval:   250 * time.Millisecond,
+		RetryAfter:           2 * time.Second,
+	}
+}
+
+// Shedder rejects work early when the gateway is overloaded instead of letting requests queue until they time out.
+// Middleware caps the total number of in-flight requests. Expensive additionally sheds the routes that need
+// a database connection while the pool is saturated, so cached reads keep working.
+type Shedder struct {
+	config Config
+	pool   PoolStats
+	logger *slog.Logger
+	now    func() time.Time
+
+	inFlight atomic.Int64
+
+	mu        sync.Mutex
+	sampledAt time.Time
+	last      PoolSnapshot
+	saturated bool
+}
+
+// New creates a shedder. pool may be nil, in which case Expensive never sheds.
+func New(config Config, pool PoolStats, logger *slog.Logger) *Shedder {
+	if config.PoolSampleInterval <= 0 {
+		config.PoolSampleInterval = DefaultConfig().PoolSampleInterval
+	}
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = DefaultConfig().RetryAfter
+	}
+
+	return &Shedder{
+		config: config,
+		pool:   pool,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// InFlight is the number of requests currently inside Middleware
+func (s *Shedder) InFlight() int64 {
+	return s.inFlight.Load()
+}
+
+// Middleware enforces the in-flight ceiling
+func (s *Shedder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := s.inFlight.Add(1)
+		inFlightRequests.Inc()
+		defer func() {
+			s.inFlight.Add(-1)
+			inFlightRequests.Dec()
+		}()
+
+		if s.config.MaxInFlight > 0 && n > s.config.MaxInFlight {
+			s.shed(w, r, SignalInFlight, "in_flight", n, "max_in_flight", s.config.MaxInFlight)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Expensive sheds the wrapped routes while the database pool is saturated
+func (s *Shedder) Expensive(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if saturated, snapshot := s.poolSaturated(); saturated {
+			s.shed(w, r, SignalDBPool, "acquired_conns", snapshot.AcquiredConns, "max_conns", snapshot.MaxConns)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// poolSaturated samples the pool at most once per PoolSampleInterval.
+// The pool counts as saturated when every connection is checked out, or when enough acquires had to wait since the last sample.
+func (s *Shedder) poolSaturated() (bool, PoolSnapshot) {
+	if s.pool == nil {
+		return false, PoolSnapshot{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if !s.sampledAt.IsZero() && now.Sub(s.sampledAt) < s.config.PoolSampleInterval {
+		return s.saturated, s.last
+	}
+
+	snapshot := s.pool()
+	waited := snapshot.EmptyAcquireCount - s.last.EmptyAcquireCount
+	exhausted := snapshot.MaxConns > 0 && snapshot.AcquiredConns >= snapshot.MaxConns
+
+	// The first sample has no baseline, only trust the exhausted check
+	waiting := !s.sampledAt.IsZero() && s.config.AcquireWaitThreshold > 0 && waited >= s.config.AcquireWaitThreshold
+
+	s.saturated = exhausted || waiting
+	s.last = snapshot
+	s.sampledAt = now
+	return s.saturated, snapshot
+}
+
+func (s *Shedder) shed(w http.ResponseWriter, r *http.Request, signal string, details ...any) {
+	shedTotal.WithLabelValues(signal).Inc()
+	s.logger.WarnContext(r.Context(), "Shedding request", append([]any{"signal", signal, "method", r.Method, "path", r.URL.Path}, details...)...)
+
+	w.Header().Set("Retry-After", strconv.Itoa(max(1, int(s.config.RetryAfter.Round(time.Second).Seconds()))))
+	errors.RespondError(w, r, errors.New(errors.ErrOverloaded, "The marketplace is busy right now. Please try again in a moment.", nil))
+}
@@ -0,0 +1,211 @@
+package loadshed
+
+import (
+	"encoding/json"
+	"gateway/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowHandler blocks until release is closed, recording the highest concurrency it saw
+type slowHandler struct {
+	release chan struct{}
+	entered chan struct{}
+	current atomic.Int64
+	peak    atomic.Int64
+}
+
+func newSlowHandler() *slowHandler {
+	return &slowHandler{release: make(chan struct{}), entered: make(chan struct{}, 1000)}
+}
+
+func (h *slowHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := h.current.Add(1)
+	defer h.current.Add(-1)
+	for {
+		peak := h.peak.Load()
+		if n <= peak || h.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	h.entered <- struct{}{}
+	<-h.release
+	w.WriteHeader(http.StatusOK)
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestMiddleware_CeilingHoldsUnderLoad(t *testing.T) {
+	// SCENARIO: Postgres is slow so every request hangs, and 50 arrive at once with a ceiling of 10.
+	// EXPECT: Exactly 10 reach the handler, the other 40 are shed immediately with a 503 and Retry-After.
+
+	const ceiling, total = 10, 50
+
+	shedder := New(Config{MaxInFlight: ceiling, RetryAfter: 3 * time.Second}, nil, testutil.NewTestLogger())
+	slow := newSlowHandler()
+	handler := shedder.Middleware(slow)
+
+	codes := make(chan *httptest.ResponseRecorder, total)
+	var wg sync.WaitGroup
+	for i := 0; i < total; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/listings", nil))
+			codes <- rec
+		}()
+	}
+
+	// Shed requests return straight away, so once 40 responses are in the rest must be parked in the handler
+	shed := 0
+	for shed < total-ceiling {
+		select {
+		case rec := <-codes:
+			require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+			assert.Equal(t, "3", rec.Header().Get("Retry-After"))
+
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			assert.Equal(t, "OVERLOADED", body["error_code"])
+			shed++
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d requests shed", shed)
+		}
+	}
+	assert.Equal(t, int64(ceiling), shedder.InFlight())
+
+	close(slow.release)
+	wg.Wait()
+	close(codes)
+
+	for rec := range codes {
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	assert.Equal(t, int64(ceiling), slow.peak.Load(), "handler concurrency must never exceed the ceiling")
+	assert.Equal(t, int64(0), shedder.InFlight())
+
+	// Capacity comes back once the slow requests finish
+	rec := httptest.NewRecorder()
+	shedder.Middleware(okHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/listings/1", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestMiddleware_ZeroCeilingDisabled(t *testing.T) {
+	shedder := New(Config{}, nil, testutil.NewTestLogger())
+	slow := newSlowHandler()
+	handler := shedder.Middleware(slow)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		<-slow.entered
+	}
+	close(slow.release)
+	wg.Wait()
+	assert.Equal(t, int64(20), slow.peak.Load())
+}
+
+// fakePool returns whatever snapshot is set and counts reads
+type fakePool struct {
+	mu       sync.Mutex
+	snapshot PoolSnapshot
+	reads    int
+}
+
+func (f *fakePool) stats() PoolSnapshot {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reads++
+	return f.snapshot
+}
+
+func (f *fakePool) set(s PoolSnapshot) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.snapshot = s
+}
+
+func newPoolTest(config Config) (*Shedder, *fakePool, *time.Time) {
+	pool := &fakePool{snapshot: PoolSnapshot{MaxConns: 10}}
+	shedder := New(config, pool.stats, testutil.NewTestLogger())
+	now := time.Unix(0, 0)
+	shedder.now = func() time.Time { return now }
+	return shedder, pool, &now
+}
+
+func serveExpensive(s *Shedder) int {
+	rec := httptest.NewRecorder()
+	s.Expensive(okHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/listings", nil))
+	return rec.Code
+}
+
+func TestExpensive_ExhaustedPool_Shed(t *testing.T) {
+	shedder, pool, now := newPoolTest(Config{PoolSampleInterval: time.Second})
+
+	assert.Equal(t, http.StatusOK, serveExpensive(shedder))
+
+	pool.set(PoolSnapshot{AcquiredConns: 10, MaxConns: 10})
+	*now = now.Add(time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, serveExpensive(shedder))
+
+	// Cheap routes aren't wrapped in Expensive and keep being served
+	rec := httptest.NewRecorder()
+	shedder.Middleware(okHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/listings/1", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	pool.set(PoolSnapshot{AcquiredConns: 3, MaxConns: 10})
+	*now = now.Add(time.Second)
+	assert.Equal(t, http.StatusOK, serveExpensive(shedder), "recovers once connections free up")
+}
+
+func TestExpensive_AcquireWaits_Shed(t *testing.T) {
+	shedder, pool, now := newPoolTest(Config{PoolSampleInterval: time.Second, AcquireWaitThreshold: 5})
+
+	pool.set(PoolSnapshot{AcquiredConns: 8, MaxConns: 10, EmptyAcquireCount: 100})
+	assert.Equal(t, http.StatusOK, serveExpensive(shedder), "first sample has no baseline")
+
+	// Only 2 more waits in the window, under the threshold
+	pool.set(PoolSnapshot{AcquiredConns: 8, MaxConns: 10, EmptyAcquireCount: 102})
+	*now = now.Add(time.Second)
+	assert.Equal(t, http.StatusOK, serveExpensive(shedder))
+
+	pool.set(PoolSnapshot{AcquiredConns: 8, MaxConns: 10, EmptyAcquireCount: 110})
+	*now = now.Add(time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, serveExpensive(shedder))
+}
+
+func TestExpensive_SamplesPoolOncePerInterval(t *testing.T) {
+	shedder, pool, now := newPoolTest(Config{PoolSampleInterval: time.Second})
+
+	for i := 0; i < 100; i++ {
+		serveExpensive(shedder)
+	}
+	assert.Equal(t, 1, pool.reads)
+
+	*now = now.Add(time.Second)
+	serveExpensive(shedder)
+	assert.Equal(t, 2, pool.reads)
+}
+
+func TestExpensive_NoPool_NeverSheds(t *testing.T) {
+	shedder := New(Config{}, nil, testutil.NewTestLogger())
+	assert.Equal(t, http.StatusOK, serveExpensive(shedder))
+}
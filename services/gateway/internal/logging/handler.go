@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/json"
+	"log/slog"
+	"net/http"
+)
+
+type LevelResponse struct {
+	Level string `json:"level"`
+}
+
+type SetLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// Handler reads and changes the log level of the pod that serves the request, other pods are left alone. That's
+// usually what's wanted when following one request through, for every pod set LOG_LEVEL and roll them.
+type Handler struct {
+	level *slog.LevelVar
+}
+
+func NewHandler(level *slog.LevelVar) *Handler {
+	return &Handler{level: level}
+}
+
+func (h *Handler) GetLevel(w http.ResponseWriter, r *http.Request) {
+	json.Write(w, http.StatusOK, LevelResponse{Level: h.level.Level().String()})
+}
+
+func (h *Handler) SetLevel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+
+	req := SetLevelRequest{}
+	if err := json.Read(r, &req); err != nil {
+		errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Input provided was not in the format expected.", err))
+		return
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+		errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Level must be debug, info, warn or error", err).
+			WithReason(errors.ReasonLogLevelInvalid).
+			WithParam("value", req.Level))
+		return
+	}
+
+	previous := h.level.Level()
+	h.level.Set(level)
+
+	slog.WarnContext(ctx, "Log level changed", "from", previous.String(), "to", level.String(), "user_id", userInfo.ID)
+	json.Write(w, http.StatusOK, LevelResponse{Level: level.String()})
+}
@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"bytes"
+	"gateway/internal/auth"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_SetLevel(t *testing.T) {
+	// The role is checked by the router, see TestRoutes_AdminRoutesListsMiddleware
+	admin := &auth.UserInfo{ID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", Roles: []string{auth.RoleAdmin}}
+
+	tests := map[string]struct {
+		user       *auth.UserInfo
+		body       string
+		wantStatus int
+		wantLevel  slog.Level
+	}{
+		"admin turns on debug": {user: admin, body: `{"level":"debug"}`, wantStatus: http.StatusOK, wantLevel: slog.LevelDebug},
+		"unknown level":        {user: admin, body: `{"level":"verbose"}`, wantStatus: http.StatusBadRequest, wantLevel: slog.LevelInfo},
+		"anonymous":            {body: `{"level":"debug"}`, wantStatus: http.StatusUnauthorized, wantLevel: slog.LevelInfo},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			level := &slog.LevelVar{}
+			h := NewHandler(level)
+
+			req := httptest.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader(tt.body))
+			if tt.user != nil {
+				req = req.WithContext(auth.WithUserInfo(req.Context(), *tt.user))
+			}
+			rec := httptest.NewRecorder()
+			h.SetLevel(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			assert.Equal(t, tt.wantLevel, level.Level())
+		})
+	}
+}
+
+func TestHandler_LevelReachesLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger, level := New(env(map[string]string{}), buf)
+	h := NewHandler(level)
+	ctx := auth.WithUserInfo(t.Context(), auth.UserInfo{ID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", Roles: []string{auth.RoleAdmin}})
+
+	logger.Debug("before")
+	h.SetLevel(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader(`{"level":"debug"}`)).WithContext(ctx))
+	logger.Debug("after")
+
+	rec := httptest.NewRecorder()
+	h.GetLevel(rec, httptest.NewRequest(http.MethodGet, "/admin/log-level", nil).WithContext(ctx))
+	assert.JSONEq(t, `{"level":"DEBUG"}`, rec.Body.String())
+	assert.Equal(t, []string{"after"}, messages(lines(t, buf)))
+}
@@ -0,0 +1,80 @@
+// Package logging builds the process logger from LOG_LEVEL and LOG_FORMAT. The listings worker has a copy of this
+// package, keep the two in step so both services can be configured the same way.
+package logging
+
+import (
+	"context"
+	"gateway/internal/telemetry"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// New builds the logger from env, usually os.Getenv. LOG_LEVEL is debug, info, warn or error and defaults to info,
+// LOG_FORMAT is json or text and defaults to json. An unknown value falls back to the default with a warning
+// rather than stopping the service. The returned level can be changed while the service runs.
+func New(env func(string) string, w io.Writer) (*slog.Logger, *slog.LevelVar) {
+	level := &slog.LevelVar{}
+	levelErr := level.UnmarshalText([]byte(orDefault(env("LOG_LEVEL"), "info")))
+	if levelErr != nil {
+		level.Set(slog.LevelInfo)
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	format := strings.ToLower(orDefault(env("LOG_FORMAT"), "json"))
+	switch format {
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	logger := slog.New(telemetry.NewTraceHandler(handler))
+
+	if levelErr != nil {
+		logger.Warn("Ignoring unknown LOG_LEVEL, logging at info", "value", env("LOG_LEVEL"))
+	}
+	if format != "json" && format != "text" {
+		logger.Warn("Ignoring unknown LOG_FORMAT, logging json", "value", env("LOG_FORMAT"))
+	}
+	return logger, level
+}
+
+// ToggleDebugOnSIGHUP switches level to debug on SIGHUP and back to what it was on the next one, until ctx is done.
+// It's per process, e.g. `kill -HUP 1` in the one container being looked at.
+func ToggleDebugOnSIGHUP(ctx context.Context, level *slog.LevelVar, logger *slog.Logger) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(sigs)
+		toggleOn(ctx, sigs, level, logger)
+	}()
+}
+
+func toggleOn(ctx context.Context, sigs <-chan os.Signal, level *slog.LevelVar, logger *slog.Logger) {
+	previous := level.Level()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigs:
+			if level.Level() == slog.LevelDebug && previous != slog.LevelDebug {
+				level.Set(previous)
+			} else {
+				previous = level.Level()
+				level.Set(slog.LevelDebug)
+			}
+			logger.Warn("Log level changed by SIGHUP", "level", level.Level().String())
+		}
+	}
+}
+
+func orDefault(v, fallback string) string {
+	if v = strings.TrimSpace(v); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,148 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+// lines decodes every JSON line written so far
+func lines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		out = append(out, entry)
+	}
+	return out
+}
+
+func messages(entries []map[string]any) []string {
+	out := []string{}
+	for _, e := range entries {
+		out = append(out, e["msg"].(string))
+	}
+	return out
+}
+
+func TestNew_LevelFiltering(t *testing.T) {
+	tests := map[string]struct {
+		level string
+		want  []string
+	}{
+		"default is info": {"", []string{"info", "warn", "error"}},
+		"debug":           {"debug", []string{"debug", "info", "warn", "error"}},
+		"upper case":      {"WARN", []string{"warn", "error"}},
+		"error":           {" error ", []string{"error"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			logger, _ := New(env(map[string]string{"LOG_LEVEL": tt.level}), buf)
+
+			logger.Debug("debug")
+			logger.Info("info")
+			logger.Warn("warn")
+			logger.Error("error")
+
+			assert.Equal(t, tt.want, messages(lines(t, buf)))
+		})
+	}
+}
+
+func TestNew_UnknownValuesFallBack(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger, level := New(env(map[string]string{"LOG_LEVEL": "verbose", "LOG_FORMAT": "logfmt"}), buf)
+
+	assert.Equal(t, slog.LevelInfo, level.Level())
+	logger.Debug("dropped")
+	entries := lines(t, buf) // Still JSON
+	require.Len(t, entries, 2)
+	assert.Equal(t, "verbose", entries[0]["value"])
+	assert.Equal(t, "logfmt", entries[1]["value"])
+}
+
+func TestNew_TextFormat(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger, _ := New(env(map[string]string{"LOG_FORMAT": "text"}), buf)
+
+	logger.Info("Listing created", "listing_id", "abc")
+
+	assert.Contains(t, buf.String(), `level=INFO msg="Listing created" listing_id=abc`)
+}
+
+func TestNew_TraceAttributes(t *testing.T) {
+	// SCENARIO: A request handler logs inside a span, through a logger that already has attributes and a group.
+	// EXPECT: trace_id and span_id are on the line either way, With doesn't drop the trace handler.
+
+	buf := &bytes.Buffer{}
+	logger, _ := New(env(map[string]string{}), buf)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled,
+	}))
+
+	logger.InfoContext(ctx, "plain")
+	logger.With("listing_id", "abc").InfoContext(ctx, "with attrs")
+	logger.InfoContext(context.Background(), "no span")
+
+	entries := lines(t, buf)
+	require.Len(t, entries, 3)
+	for _, e := range entries[:2] {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", e["trace_id"], e["msg"])
+		assert.Equal(t, "00f067aa0ba902b7", e["span_id"], e["msg"])
+	}
+	assert.Equal(t, "abc", entries[1]["listing_id"])
+	assert.NotContains(t, entries[2], "trace_id")
+}
+
+func TestToggleDebugOnSIGHUP(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger, level := New(env(map[string]string{"LOG_LEVEL": "warn"}), buf)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigs := make(chan os.Signal)
+	done := make(chan struct{})
+	go func() {
+		toggleOn(ctx, sigs, level, logger)
+		close(done)
+	}()
+	levelBecomes := func(want slog.Level) {
+		t.Helper()
+		require.Eventually(t, func() bool { return level.Level() == want }, time.Second, time.Millisecond)
+	}
+
+	sigs <- syscall.SIGHUP
+	levelBecomes(slog.LevelDebug)
+	sigs <- syscall.SIGHUP
+	levelBecomes(slog.LevelWarn)
+	sigs <- syscall.SIGHUP
+	levelBecomes(slog.LevelDebug)
+
+	cancel()
+	<-done
+	logger.Debug("visible")
+	assert.Contains(t, messages(lines(t, buf)), "visible")
+}
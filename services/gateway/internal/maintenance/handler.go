@@ -0,0 +1,70 @@
+package maintenance
+
+import (
+	"gateway/internal/auth"
+	"gateway/internal/errors"
+	"gateway/internal/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type SetMaintenanceRequest struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message"`
+}
+
+type Handler struct {
+	store MaintenanceStore
+	guard *Guard
+}
+
+func NewHandler(store MaintenanceStore, guard *Guard) *Handler {
+	return &Handler{
+		store: store,
+		guard: guard,
+	}
+}
+
+func (h *Handler) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	state, err := h.store.Get(ctx)
+	if err != nil {
+		errors.RespondError(w, r, errors.New(errors.ErrInternal, "Failed to read maintenance state", err))
+		return
+	}
+
+	json.Write(w, http.StatusOK, state)
+}
+
+func (h *Handler) SetMaintenance(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo, err := auth.GetUserInfo(ctx)
+	if err != nil {
+		errors.RespondError(w, r, errors.New(errors.ErrUnauthorized, "Unauthorized access", err).WithReason(errors.ReasonAuthRequired))
+		return
+	}
+	req := SetMaintenanceRequest{}
+	if err := json.Read(r, &req); err != nil {
+		errors.RespondError(w, r, errors.New(errors.ErrInvalidInput, "Input provided was not in the format expected.", err))
+		return
+	}
+
+	state := State{
+		Enabled:   req.Enabled,
+		Message:   req.Message,
+		UpdatedBy: userInfo.ID,
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	if err := h.store.Set(ctx, state); err != nil {
+		errors.RespondError(w, r, errors.New(errors.ErrInternal, "Failed to update maintenance state", err))
+		return
+	}
+
+	// Other pods pick this up within their refresh interval, this one applies it immediately
+	h.guard.Invalidate()
+
+	slog.WarnContext(ctx, "Maintenance mode updated", "enabled", state.Enabled, "user_id", userInfo.ID)
+	json.Write(w, http.StatusOK, state)
+}
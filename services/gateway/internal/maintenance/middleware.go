@@ -0,0 +1,107 @@
+package maintenance
+
+import (
+	"context"
+	"gateway/internal/errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// How long a pod trusts its local copy of the flag before asking Redis again
+	defaultRefreshInterval = 5 * time.Second
+
+	// Hint for clients, migrations usually take a couple of minutes
+	retryAfterSeconds = 120
+)
+
+// Guard rejects mutating requests while maintenance mode is on.
+// The flag lives in Redis so it can be toggled at runtime, but is cached locally
+// for a few seconds so we don't add a Redis round trip to every write.
+type Guard struct {
+	store           MaintenanceStore
+	refreshInterval time.Duration
+	logger          *slog.Logger
+	now             func() time.Time
+
+	mu        sync.Mutex
+	state     State
+	fetchedAt time.Time
+}
+
+func NewGuard(store MaintenanceStore, refreshInterval time.Duration, logger *slog.Logger) *Guard {
+	if refreshInterval <= 0 {
+		refreshInterval = defaultRefreshInterval
+	}
+
+	return &Guard{
+		store:           store,
+		refreshInterval: refreshInterval,
+		logger:          logger,
+		now:             time.Now,
+	}
+}
+
+// State returns the (possibly cached) maintenance state.
+// If Redis is unavailable we keep using the last known state rather than failing every write.
+func (g *Guard) State(ctx context.Context) State {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.fetchedAt.IsZero() && g.now().Sub(g.fetchedAt) < g.refreshInterval {
+		return g.state
+	}
+
+	state, err := g.store.Get(ctx)
+	if err != nil {
+		g.logger.WarnContext(ctx, "Failed to read maintenance flag, using last known state", "error", err, "enabled", g.state.Enabled)
+		return g.state
+	}
+
+	g.state = state
+	g.fetchedAt = g.now()
+	return g.state
+}
+
+// Invalidate forces the next request to re-read the flag from Redis
+func (g *Guard) Invalidate() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.fetchedAt = time.Time{}
+}
+
+func (g *Guard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Reads keep working (from cache where possible), only writes are blocked
+		if isSafeMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		state := g.State(r.Context())
+		if !state.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		message := state.Message
+		if message == "" {
+			message = "The marketplace is undergoing maintenance. Please try again shortly."
+		}
+
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds))
+		errors.RespondError(w, r, errors.New(errors.ErrMaintenance, message, nil))
+	})
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gateway/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// FakeStore stands in for Redis
+type FakeStore struct {
+	mu    sync.Mutex
+	state State
+	err   error
+	reads int
+}
+
+func (f *FakeStore) Get(ctx context.Context) (State, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reads++
+	return f.state, f.err
+}
+
+func (f *FakeStore) Set(ctx context.Context, state State) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.state = state
+	return nil
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func serve(g *Guard, method string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	g.Middleware(okHandler()).ServeHTTP(rec, httptest.NewRequest(method, "/listings", nil))
+	return rec
+}
+
+func TestMiddleware_Disabled_AllowsWrites(t *testing.T) {
+	guard := NewGuard(&FakeStore{}, time.Second, testutil.NewTestLogger())
+
+	rec := serve(guard, http.MethodPost)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestMiddleware_Enabled_RejectsWrites(t *testing.T) {
+	store := &FakeStore{state: State{Enabled: true}}
+	guard := NewGuard(store, time.Second, testutil.NewTestLogger())
+
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch} {
+		rec := serve(guard, method)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code, method)
+		assert.Equal(t, "120", rec.Header().Get("Retry-After"), method)
+
+		var body map[string]string
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "MAINTENANCE", body["error_code"])
+	}
+}
+
+func TestMiddleware_Enabled_AllowsReads(t *testing.T) {
+	store := &FakeStore{state: State{Enabled: true}}
+	guard := NewGuard(store, time.Second, testutil.NewTestLogger())
+
+	assert.Equal(t, http.StatusOK, serve(guard, http.MethodGet).Code)
+	assert.Equal(t, http.StatusOK, serve(guard, http.MethodHead).Code)
+	assert.Equal(t, 0, store.reads, "reads should not touch the flag at all")
+}
+
+func TestMiddleware_ToggleTakesEffectWithoutRestart(t *testing.T) {
+	// SCENARIO: Another pod flips the flag in Redis.
+	// EXPECT: This pod keeps its cached value until the refresh interval passes, then picks it up.
+
+	store := &FakeStore{}
+	guard := NewGuard(store, 5*time.Second, testutil.NewTestLogger())
+
+	clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	guard.now = func() time.Time { return clock }
+
+	assert.Equal(t, http.StatusOK, serve(guard, http.MethodPost).Code)
+
+	// Flag flipped elsewhere
+	require.NoError(t, store.Set(context.Background(), State{Enabled: true}))
+
+	clock = clock.Add(2 * time.Second)
+	assert.Equal(t, http.StatusOK, serve(guard, http.MethodPost).Code, "still within local cache TTL")
+
+	clock = clock.Add(4 * time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, serve(guard, http.MethodPost).Code)
+
+	// And back off again
+	require.NoError(t, store.Set(context.Background(), State{Enabled: false}))
+	clock = clock.Add(6 * time.Second)
+	assert.Equal(t, http.StatusOK, serve(guard, http.MethodPost).Code)
+}
+
+func TestMiddleware_Invalidate_AppliesImmediately(t *testing.T) {
+	store := &FakeStore{}
+	guard := NewGuard(store, time.Hour, testutil.NewTestLogger())
+
+	assert.Equal(t, http.StatusOK, serve(guard, http.MethodPost).Code)
+
+	require.NoError(t, store.Set(context.Background(), State{Enabled: true}))
+	guard.Invalidate()
+
+	assert.Equal(t, http.StatusServiceUnavailable, serve(guard, http.MethodPost).Code)
+}
+
+func TestMiddleware_StoreError_KeepsLastKnownState(t *testing.T) {
+	store := &FakeStore{state: State{Enabled: true}}
+	guard := NewGuard(store, time.Second, testutil.NewTestLogger())
+
+	clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	guard.now = func() time.Time { return clock }
+
+	assert.Equal(t, http.StatusServiceUnavailable, serve(guard, http.MethodPost).Code)
+
+	// Redis goes away mid-maintenance
+	store.err = errors.New("connection refused")
+	clock = clock.Add(2 * time.Second)
+
+	assert.Equal(t, http.StatusServiceUnavailable, serve(guard, http.MethodPost).Code)
+}
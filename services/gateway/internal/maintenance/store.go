@@ -0,0 +1,48 @@
+package maintenance
+
+import (
+	"context"
+	"gateway/internal/cache"
+	"time"
+)
+
+// Shared across every gateway pod, so flipping it once affects the whole fleet
+const maintenanceKey = "system:maintenance"
+
+type State struct {
+	Enabled   bool      `json:"enabled"`
+	Message   string    `json:"message,omitempty"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type MaintenanceStore interface {
+	Get(ctx context.Context) (State, error)
+	Set(ctx context.Context, state State) error
+}
+
+type Store struct {
+	cache *cache.RedisClient
+}
+
+func NewStore(c *cache.RedisClient) *Store {
+	return &Store{cache: c}
+}
+
+// Get returns the current state. A missing key means maintenance is off.
+func (s *Store) Get(ctx context.Context) (State, error) {
+	state, found, err := cache.Get[State](s.cache, ctx, maintenanceKey)
+	if err != nil {
+		return State{}, err
+	}
+	if !found {
+		return State{}, nil
+	}
+
+	return *state, nil
+}
+
+// Set persists the state with no TTL, maintenance only ends when someone turns it off
+func (s *Store) Set(ctx context.Context, state State) error {
+	return cache.Set(s.cache, ctx, maintenanceKey, state, 0)
+}
@@ -0,0 +1,9 @@
+// Package mocks holds the generated testify mocks for the gateway's service interfaces, see .mockery.yaml
+// in the service root for the list. Each source package gets its own subpackage (mockstorage, mockevents, ...)
+// so a package's own tests never import a mock that imports them back.
+//
+// Prefer these for wiring tests. Behavioural fakes that need real semantics (the idempotency FakeStore,
+// for example) still live next to the tests that use them.
+package mocks
+
+//go:generate sh -c "cd ../.. && go run github.com/vektra/mockery/v2@v2.53.7"
@@ -0,0 +1,317 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"gateway/internal/errors"
+	"gateway/internal/handlers/cacheadmin"
+	"gateway/internal/handlers/categories"
+	"gateway/internal/handlers/featured"
+	"gateway/internal/handlers/files"
+	"gateway/internal/handlers/hardware"
+	"gateway/internal/handlers/listingevents"
+	"gateway/internal/handlers/listings"
+	"gateway/internal/handlers/outboxadmin"
+	"gateway/internal/handlers/pins"
+	"gateway/internal/handlers/savedsearches"
+	"gateway/internal/handlers/screening"
+	"gateway/internal/handlers/sellers"
+	"gateway/internal/handlers/shortlinks"
+	"gateway/internal/logging"
+	"gateway/internal/maintenance"
+	"gateway/internal/openapi"
+	"net/http/httptest"
+	"reflect"
+	"shared/materials"
+	"shared/version"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type schema struct {
+	Ref        string             `json:"$ref"`
+	Type       string             `json:"type"`
+	Required   []string           `json:"required"`
+	Properties map[string]*schema `json:"properties"`
+	Items      *schema            `json:"items"`
+	Nullable   bool               `json:"nullable"`
+}
+
+type document struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas         map[string]*schema         `json:"schemas"`
+		SecuritySchemes map[string]json.RawMessage `json:"securitySchemes"`
+		Parameters      map[string]struct {
+			Name string `json:"name"`
+			In   string `json:"in"`
+		} `json:"parameters"`
+	} `json:"components"`
+}
+
+func loadSpec(t *testing.T) document {
+	t.Helper()
+	var doc document
+	require.NoError(t, json.Unmarshal(openapi.Spec(), &doc))
+	return doc
+}
+
+// jsonFields returns the JSON names of a struct's fields, the way encoding/json would
+func jsonFields(typ reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// openAPIType maps a Go type to the schema type it should be documented as
+func openAPIType(typ reflect.Type) string {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == reflect.TypeOf(time.Time{}) {
+		return "string"
+	}
+	if typ == reflect.TypeOf(json.RawMessage{}) {
+		return "object"
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func TestSpec_SchemasMatchStructs(t *testing.T) {
+	// SCENARIO: A field is added to, renamed in, or removed from a request/response struct without touching the spec.
+	// EXPECT: The property sets differ and this fails.
+
+	doc := loadSpec(t)
+
+	structs := map[string]any{
+		"PresignRequest":               files.PresignRequest{},
+		"PresignResponse":              files.PresignResponse{},
+		"CreateListingRequest":         listings.CreateListingRequest{},
+		"CreateListingFile":            listings.CreateListingFile{},
+		"UpdateListingRequest":         listings.UpdateListingRequest{},
+		"ListingPrinterSettings":       listings.ListingPrinterSettings{},
+		"UpdateListingPrinterSettings": listings.UpdateListingPrinterSettings{},
+		"ListingDimensions":            listings.ListingDimensions{},
+		"ListingFile":                  listings.ListingFileDTO{},
+		"FileMetadata":                 listings.FileMetadata{},
+		"BoundingBox":                  listings.BoundingBox{},
+		"FileScan":                     listings.FileScan{},
+		"HydrateListingRequest":        listings.HydrateListingRequest{},
+		"ListingResponse":              listings.ListingResponse{},
+		"ListingVariant":               listings.ListingVariant{},
+		"CreateVariantRequest":         listings.CreateVariantRequest{},
+		"FileValidationResult":         listings.FileValidationResult{},
+		"FileValidationResultResponse": listings.FileValidationResultResponse{},
+		"FileDownloadResponse":         listings.FileDownloadResponse{},
+		"PriceChange":                  listings.PriceChange{},
+		"StatusEvent":                  listings.StatusEvent{},
+		"FileStatusChange":             listingevents.FileStatusChange{},
+		"ListingStatusChange":          listingevents.ListingStatusChange{},
+		"IndexFailedListing":           listings.IndexFailedListing{},
+		"AdminListing":                 listings.AdminListing{},
+		"AdminListingsPage":            listings.AdminListingsPage{},
+		"DownloadHistoryPage":          listings.DownloadHistoryPage{},
+		"DownloadedListing":            listings.DownloadedListing{},
+		"DownloadedFile":               listings.DownloadedFile{},
+		"RemixNode":                    listings.RemixNode{},
+		"ProductJSONLD":                listings.ProductJSONLD{},
+		"BrandJSONLD":                  listings.BrandJSONLD{},
+		"OfferJSONLD":                  listings.OfferJSONLD{},
+		"PriceSpecificationJSONLD":     listings.PriceSpecificationJSONLD{},
+		"InteractionCounterJSONLD":     listings.InteractionCounterJSONLD{},
+		"ValidationRuleSet":            listings.ValidationRuleSet{},
+		"RoleValidationRules":          listings.RoleValidationRules{},
+		"ValidationRules":              listings.ValidationRules{},
+		"CategoryCount":                categories.CategoryCount{},
+		"CategoryCountsResponse":       categories.CategoryCountsResponse{},
+		"Category":                     categories.Category{},
+		"CategoryPage":                 categories.CategoryPage{},
+		"CategoryPageCounts":           categories.CategoryPageCounts{},
+		"CategoryPageTop":              categories.CategoryPageTop{},
+		"CategoryPageTrend":            categories.CategoryPageTrend{},
+		"TrendingListing":              categories.TrendingListing{},
+		"CategoryDefaults":             categories.Defaults{},
+		"SetCategoryDefaultsRequest":   categories.SetDefaultsRequest{},
+		"TempRange":                    categories.TempRange{},
+		"CategoryDimensions":           categories.DimensionsMM{},
+		"ListingWarning":               listings.ListingWarning{},
+		"UpsertSellerProfileRequest":   sellers.UpsertSellerProfileRequest{},
+		"SellerProfileResponse":        sellers.SellerProfileResponse{},
+		"StartVacationRequest":         sellers.StartVacationRequest{},
+		"VacationResponse":             sellers.VacationResponse{},
+		"SetMaintenanceRequest":        maintenance.SetMaintenanceRequest{},
+		"MaintenanceState":             maintenance.State{},
+		"LogLevelResponse":             logging.LevelResponse{},
+		"SetLogLevelRequest":           logging.SetLevelRequest{},
+		"ListingCacheResponse":         cacheadmin.ListingCacheResponse{},
+		"OutboxEventsResponse":         outboxadmin.OutboxEventsResponse{},
+		"OutboxEvent":                  outboxadmin.OutboxEventResponse{},
+		"HardwareOptionsResponse":      hardware.OptionsResponse{},
+		"AddHardwareOptionRequest":     hardware.AddOptionRequest{},
+		"HardwareOptionResponse":       hardware.OptionResponse{},
+		"BannedTermsResponse":          screening.TermsResponse{},
+		"SetBannedTermRequest":         screening.SetTermRequest{},
+		"BannedTermResponse":           screening.TermResponse{},
+		"MaterialsResponse":            listings.MaterialsResponse{},
+		"Material":                     materials.Material{},
+		"SaveSearchRequest":            savedsearches.SaveSearchRequest{},
+		"SavedSearch":                  savedsearches.SavedSearchResponse{},
+		"SavedSearchesResponse":        savedsearches.SavedSearchesResponse{},
+		"CreateShortLinkRequest":       shortlinks.CreateShortLinkRequest{},
+		"ShortLink":                    shortlinks.ShortLinkResponse{},
+		"CreateFeaturedListingRequest": featured.CreateFeaturedListingRequest{},
+		"UpdateFeaturedListingRequest": featured.UpdateFeaturedListingRequest{},
+		"FeaturedListing":              featured.FeaturedListingResponse{},
+		"FeaturedListingsResponse":     featured.FeaturedListingsResponse{},
+		"ActiveFeaturedListings":       featured.ActiveFeaturedListings{},
+		"PinListingRequest":            pins.PinListingRequest{},
+		"PinsResponse":                 pins.PinsResponse{},
+		"StorefrontResponse":           pins.StorefrontResponse{},
+		"BuildInfo":                    version.Info{},
+	}
+
+	for name, v := range structs {
+		t.Run(name, func(t *testing.T) {
+			s, ok := doc.Components.Schemas[name]
+			require.True(t, ok, "schema %s missing from openapi.json", name)
+
+			fields := jsonFields(reflect.TypeOf(v))
+			var specProps, goFields []string
+			for p := range s.Properties {
+				specProps = append(specProps, p)
+			}
+			for f := range fields {
+				goFields = append(goFields, f)
+			}
+			assert.ElementsMatch(t, goFields, specProps)
+
+			for field, typ := range fields {
+				prop, ok := s.Properties[field]
+				if !ok {
+					continue
+				}
+				if prop.Ref != "" {
+					assert.Equal(t, "object", openAPIType(typ), "%s.%s is a $ref but not a struct", name, field)
+					continue
+				}
+				assert.Equal(t, openAPIType(typ), prop.Type, "%s.%s type", name, field)
+			}
+		})
+	}
+}
+
+func TestSpec_ListingPatchMatchesParser(t *testing.T) {
+	// SCENARIO: ListingPatch has no struct to compare against, the parser decides which members it takes.
+	// EXPECT: Every documented member is known to ParseListingPatch, and the ones documented as nullable accept null.
+
+	doc := loadSpec(t)
+	members := map[string]*schema{}
+	for name, prop := range doc.Components.Schemas["ListingPatch"].Properties {
+		members[name] = prop
+	}
+	for name, prop := range doc.Components.Schemas["ListingPatchPrinterSettings"].Properties {
+		members["printerSettings."+name] = prop
+	}
+	require.NotEmpty(t, members)
+
+	for name, prop := range members {
+		t.Run(name, func(t *testing.T) {
+			body := `{"` + name + `":null}`
+			if parent, child, ok := strings.Cut(name, "."); ok {
+				body = `{"` + parent + `":{"` + child + `":null}}`
+			}
+
+			_, appErr := listings.ParseListingPatch([]byte(body))
+			if appErr != nil {
+				assert.NotEqual(t, errors.ReasonListingPatchUnknownField, appErr.Reason)
+			}
+			if prop.Nullable {
+				assert.Nil(t, appErr, "%s is documented as nullable", name)
+			}
+		})
+	}
+}
+
+func TestSpec_ErrorEnvelopeMatchesRespondError(t *testing.T) {
+	envelope := loadSpec(t).Components.Schemas["ErrorResponse"]
+	require.NotNil(t, envelope)
+
+	w := httptest.NewRecorder()
+	errors.RespondError(w, httptest.NewRequest("POST", "/listings", nil),
+		errors.New(errors.ErrInvalidInput, "Title must be between 5 and 100 characters", nil).WithReason(errors.ReasonListingTitleLength))
+
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	for key := range body {
+		assert.Contains(t, envelope.Properties, key, "RespondError sends %q but the spec doesn't document it", key)
+	}
+	for _, key := range envelope.Required {
+		assert.Contains(t, body, key)
+	}
+}
+
+func TestSpec_AuthSchemes(t *testing.T) {
+	doc := loadSpec(t)
+
+	assert.Contains(t, doc.Components.SecuritySchemes, "bearerAuth")
+
+	idem, ok := doc.Components.Parameters["IdempotencyKey"]
+	require.True(t, ok)
+	assert.Equal(t, "Idempotency-Key", idem.Name)
+	assert.Equal(t, "header", idem.In)
+}
+
+func TestSpec_EveryRefResolves(t *testing.T) {
+	var raw any
+	require.NoError(t, json.Unmarshal(openapi.Spec(), &raw))
+	doc := raw.(map[string]any)
+
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				target := any(doc)
+				for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+					m, _ := target.(map[string]any)
+					target = m[part]
+				}
+				assert.NotNil(t, target, "dangling $ref %s", ref)
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+}
@@ -0,0 +1,24 @@
+package outbox
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Set by whichever replica measured last, every replica sees the same table
+var (
+	pendingEvents = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gateway_outbox_pending_events",
+		Help: "Outbox events not published yet and still being tried.",
+	})
+
+	failedEvents = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gateway_outbox_failed_events",
+		Help: "Outbox events the relay gave up on after their last attempt, waiting for an admin to retry them.",
+	})
+
+	oldestPendingAge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gateway_outbox_oldest_pending_age_seconds",
+		Help: "Age of the oldest outbox event not published yet, 0 when there is none.",
+	})
+)
@@ -0,0 +1,34 @@
+// Package outbox gets events onto the bus without losing them. Write stores them in the transaction of the change they
+// describe, and the Relay publishes them once that has committed, retrying until the bus takes them.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/events"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+)
+
+// Write stores messages for the relay in one statement, in order. q must be bound to the transaction of the change they
+// describe, so they are published if and only if it commits.
+func Write(ctx context.Context, q *repo.Queries, messages ...events.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	batch := repo.InsertOutboxEventsParams{
+		Subjects: make([]string, len(messages)),
+		MsgIds:   make([]string, len(messages)),
+		Payloads: make([][]byte, len(messages)),
+	}
+	for i, message := range messages {
+		batch.Subjects[i] = message.Subject
+		batch.MsgIds[i] = message.MsgID
+		batch.Payloads[i] = message.Data
+	}
+	if err := q.InsertOutboxEvents(ctx, batch); err != nil {
+		return fmt.Errorf("failed to write %d events to the outbox: %w", len(messages), err)
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package outbox
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"gateway/internal/events"
+	"log/slog"
+	"slices"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	firstRetryIn = time.Second
+	maxRetryIn   = 5 * time.Minute
+
+	// pruneEvery is how often a relay deletes the events published more than Config.Retention ago
+	pruneEvery = time.Hour
+)
+
+type Config struct {
+	// Interval is how often the relay looks for events to publish. A full batch is followed straight away by the next.
+	Interval time.Duration
+	// BatchSize is how many events one pass claims.
+	BatchSize int
+	// Lease is how long claimed events are left to this replica before another may take them. Longer than a batch of
+	// publishes ever takes, or an event could go out from two replicas, which JetStream's duplicate window catches.
+	Lease time.Duration
+	// Retention is how long published events are kept, for debugging.
+	Retention time.Duration
+	// MaxAttempts is how many failed publishes an event gets before the relay gives up on it, see GET /admin/outbox.
+	MaxAttempts int
+	// MeasureEvery is how often the backlog gauges are refreshed.
+	MeasureEvery time.Duration
+	// StaleAfter is how old the oldest pending event gets before every measurement warns about it.
+	StaleAfter time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Interval:     time.Second,
+		BatchSize:    100,
+		Lease:        time.Minute,
+		Retention:    24 * time.Hour,
+		MaxAttempts:  15, // About 40 minutes of backoff
+		MeasureEvery: 30 * time.Second,
+		StaleAfter:   5 * time.Minute,
+	}
+}
+
+// Report summarises a pass. GaveUp are the failures that were the event's last attempt.
+type Report struct {
+	Published int `json:"published"`
+	Failed    int `json:"failed"`
+	GaveUp    int `json:"gave_up"`
+}
+
+// Stats is the backlog a measurement found
+type Stats struct {
+	Pending          int64
+	Failed           int64
+	OldestPendingAge time.Duration
+}
+
+// Relay publishes the events Write stored. Every replica runs one, each claims its own batch.
+type Relay struct {
+	repo   *repo.Queries
+	bus    events.Bus
+	logger *slog.Logger
+	config Config
+	now    func() time.Time
+}
+
+func NewRelay(repo *repo.Queries, bus events.Bus, config Config, logger *slog.Logger) *Relay {
+	defaults := DefaultConfig()
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.Lease <= 0 {
+		config.Lease = defaults.Lease
+	}
+	if config.Retention <= 0 {
+		config.Retention = defaults.Retention
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.MeasureEvery <= 0 {
+		config.MeasureEvery = defaults.MeasureEvery
+	}
+	if config.StaleAfter <= 0 {
+		config.StaleAfter = defaults.StaleAfter
+	}
+
+	return &Relay{
+		repo:   repo,
+		bus:    bus,
+		logger: logger,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Run relays every Interval until ctx is done. The pass in flight when it's canceled still records what it published,
+// so stop it before draining the bus.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+	lastPrune, lastMeasure := time.Time{}, time.Time{}
+
+	for {
+		report, err := r.Relay(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.ErrorContext(ctx, "Relaying outbox events failed", "error", err)
+		}
+
+		if r.now().Sub(lastPrune) >= pruneEvery {
+			lastPrune = r.now()
+			r.prune(ctx)
+		}
+		if r.now().Sub(lastMeasure) >= r.config.MeasureEvery {
+			lastMeasure = r.now()
+			if _, err := r.Measure(ctx); err != nil && ctx.Err() == nil {
+				r.logger.WarnContext(ctx, "Measuring the outbox failed", "error", err)
+			}
+		}
+
+		// A full batch that all went out means there are likely more waiting. While the bus is failing wait instead.
+		if err == nil && report.Published == r.config.BatchSize && ctx.Err() == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Relay publishes the events that are due, oldest first. A failed publish is tried again with backoff.
+func (r *Relay) Relay(ctx context.Context) (Report, error) {
+	var report Report
+
+	now := r.now()
+	due, err := r.repo.ClaimOutboxEvents(ctx, repo.ClaimOutboxEventsParams{
+		LeaseUntil: pgtype.Timestamptz{Time: now.Add(r.config.Lease), Valid: true},
+		Now:        pgtype.Timestamptz{Time: now, Valid: true},
+		BatchSize:  int32(r.config.BatchSize),
+	})
+	if err != nil {
+		return report, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+	// RETURNING doesn't keep the subquery's order
+	slices.SortFunc(due, func(a, b repo.ClaimOutboxEventsRow) int { return cmp.Compare(a.ID, b.ID) })
+
+	// Marking what's been published goes ahead even if the caller has given up, or it's published again next time
+	record := context.WithoutCancel(ctx)
+	for _, event := range due {
+		if err := r.bus.Publish(event.Subject, event.Payload, event.MsgID); err != nil {
+			report.Failed++
+			attempt := int(event.Attempts) + 1
+			failure := repo.RecordOutboxEventFailureParams{
+				LastError:     pgtype.Text{String: err.Error(), Valid: true},
+				NextAttemptAt: pgtype.Timestamptz{Time: r.now().Add(RetryIn(int(event.Attempts))), Valid: true},
+				ID:            event.ID,
+			}
+			if attempt >= r.config.MaxAttempts {
+				report.GaveUp++
+				failure.FailedAt = pgtype.Timestamptz{Time: r.now(), Valid: true}
+				r.logger.ErrorContext(ctx, "Giving up on outbox event", "id", event.ID, "subject", event.Subject, "attempt", attempt, "error", err)
+			} else {
+				r.logger.WarnContext(ctx, "Failed to publish outbox event", "id", event.ID, "subject", event.Subject, "attempt", attempt, "error", err)
+			}
+			if err := r.repo.RecordOutboxEventFailure(record, failure); err != nil {
+				// The lease runs out and it's tried again then
+				r.logger.ErrorContext(ctx, "Failed to record outbox event failure", "id", event.ID, "error", err)
+			}
+			continue
+		}
+
+		report.Published++
+		if err := r.repo.MarkOutboxEventPublished(record, repo.MarkOutboxEventPublishedParams{
+			Now: pgtype.Timestamptz{Time: r.now(), Valid: true},
+			ID:  event.ID,
+		}); err != nil {
+			// Published again once the lease is up, JetStream drops it as a duplicate inside its window
+			r.logger.ErrorContext(ctx, "Failed to mark outbox event published", "id", event.ID, "error", err)
+		}
+	}
+
+	if len(due) > 0 {
+		r.logger.DebugContext(ctx, "Relayed outbox events", "report", report)
+	}
+	return report, nil
+}
+
+// Measure refreshes the backlog gauges and warns when the oldest pending event is older than StaleAfter, which means
+// the bus has been failing or no relay is keeping up
+func (r *Relay) Measure(ctx context.Context) (Stats, error) {
+	row, err := r.repo.GetOutboxStats(ctx)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to count outbox events: %w", err)
+	}
+
+	stats := Stats{Pending: row.Pending, Failed: row.Failed}
+	if row.OldestPendingAt.Valid {
+		stats.OldestPendingAge = max(r.now().Sub(row.OldestPendingAt.Time), 0)
+	}
+	pendingEvents.Set(float64(stats.Pending))
+	failedEvents.Set(float64(stats.Failed))
+	oldestPendingAge.Set(stats.OldestPendingAge.Seconds())
+
+	if stats.OldestPendingAge > r.config.StaleAfter {
+		r.logger.WarnContext(ctx, "Outbox events are waiting too long to be published",
+			"pending", stats.Pending,
+			"oldest_age", stats.OldestPendingAge.Round(time.Second).String(),
+			"stale_after", r.config.StaleAfter.String(),
+		)
+	}
+	return stats, nil
+}
+
+// prune deletes events published more than Retention ago
+func (r *Relay) prune(ctx context.Context) {
+	deleted, err := r.repo.DeletePublishedOutboxEvents(ctx, pgtype.Timestamptz{Time: r.now().Add(-r.config.Retention), Valid: true})
+	if err != nil {
+		if ctx.Err() == nil {
+			r.logger.WarnContext(ctx, "Failed to delete published outbox events", "error", err)
+		}
+		return
+	}
+	if deleted > 0 {
+		r.logger.InfoContext(ctx, "Deleted published outbox events", "deleted", deleted)
+	}
+}
+
+// RetryIn is how long after its attempts-th failure (counting from 0) an event is published again, doubling from a
+// second up to five minutes
+func RetryIn(attempts int) time.Duration {
+	if attempts >= 9 { // 2^9 seconds is past the cap already
+		return maxRetryIn
+	}
+	return min(firstRetryIn<<attempts, maxRetryIn)
+}
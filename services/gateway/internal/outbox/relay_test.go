@@ -0,0 +1,195 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"gateway/internal/events"
+	"gateway/internal/mocks/mockevents"
+	"gateway/internal/testutil"
+	"regexp"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var claimedCols = []string{"id", "subject", "msg_id", "payload", "attempts"}
+
+func newRelayTest(t *testing.T) (*Relay, pgxmock.PgxPoolIface, *mockevents.Bus, time.Time) {
+	t.Helper()
+	mockPool := testutil.NewMockDB(t)
+	bus := mockevents.NewBus(t)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	relay := NewRelay(repo.New(mockPool), bus, Config{BatchSize: 10}, testutil.NewTestLogger())
+	relay.now = func() time.Time { return now }
+	return relay, mockPool, bus, now
+}
+
+func timestamptz(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
+
+func TestWrite(t *testing.T) {
+	// SCENARIO: A listing's validation and created events are written in its transaction.
+	// EXPECT: One statement for both, in order, with the subjects, message IDs and payloads as they'll be published.
+
+	mockPool := testutil.NewMockDB(t)
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: InsertOutboxEvents :exec`)).
+		WithArgs(
+			[]string{"files.validate.listing", "listings.created"},
+			[]string{"start.user1.abc123", "created.abc123"},
+			[][]byte{[]byte(`{"listing_id":"abc123"}`), []byte(`{"listing_id":"abc123"}`)},
+		).
+		WillReturnResult(pgxmock.NewResult("INSERT", 2))
+
+	err := Write(context.Background(), repo.New(mockPool),
+		events.Message{Subject: "files.validate.listing", MsgID: "start.user1.abc123", Data: []byte(`{"listing_id":"abc123"}`)},
+		events.Message{Subject: "listings.created", MsgID: "created.abc123", Data: []byte(`{"listing_id":"abc123"}`)},
+	)
+
+	require.NoError(t, err)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestWrite_Nothing(t *testing.T) {
+	// SCENARIO: A change with no events to send, e.g. EVENT_DELETE_LISTING unset.
+	// EXPECT: No statement at all, the mock fails on any query.
+
+	require.NoError(t, Write(context.Background(), repo.New(testutil.NewMockDB(t))))
+}
+
+func TestRelay_PublishesInOrder(t *testing.T) {
+	// SCENARIO: Two events are due and come back from the claim out of order.
+	// EXPECT: They're published oldest first with their message IDs, and each is marked published.
+
+	relay, mockPool, bus, now := newRelayTest(t)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ClaimOutboxEvents :many`)).
+		WithArgs(timestamptz(now.Add(time.Minute)), timestamptz(now), int32(10)).
+		WillReturnRows(pgxmock.NewRows(claimedCols).
+			AddRow(int64(8), "listings.created", "created.abc123", []byte(`{"b":2}`), int32(0)).
+			AddRow(int64(7), "files.validate.listing", "start.user1.abc123", []byte(`{"a":1}`), int32(0)))
+
+	first := bus.EXPECT().Publish("files.validate.listing", []byte(`{"a":1}`), "start.user1.abc123").Return(nil).Once()
+	bus.EXPECT().Publish("listings.created", []byte(`{"b":2}`), "created.abc123").Return(nil).Once().NotBefore(first)
+
+	for _, id := range []int64{7, 8} {
+		mockPool.ExpectExec(regexp.QuoteMeta(`-- name: MarkOutboxEventPublished :exec`)).
+			WithArgs(timestamptz(now), id).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	}
+
+	report, err := relay.Relay(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, Report{Published: 2}, report)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRelay_FailedPublishIsRetriedLater(t *testing.T) {
+	// SCENARIO: NATS refuses an event on its third attempt.
+	// EXPECT: The error is kept on the row and it's due again after the backoff for a third failure, 4s.
+
+	relay, mockPool, bus, now := newRelayTest(t)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ClaimOutboxEvents :many`)).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(claimedCols).
+			AddRow(int64(7), "listings.created", "created.abc123", []byte(`{}`), int32(2)))
+	bus.EXPECT().Publish("listings.created", []byte(`{}`), "created.abc123").Return(errors.New("nats: no response from stream")).Once()
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: RecordOutboxEventFailure :exec`)).
+		WithArgs(pgtype.Text{String: "nats: no response from stream", Valid: true}, timestamptz(now.Add(4*time.Second)), pgtype.Timestamptz{}, int64(7)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	report, err := relay.Relay(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, Report{Failed: 1}, report)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRelay_GivesUpAfterMaxAttempts(t *testing.T) {
+	// SCENARIO: An event fails its last attempt.
+	// EXPECT: It's marked failed along with the error, the relay doesn't claim it again until an admin retries it.
+
+	relay, mockPool, bus, now := newRelayTest(t)
+	relay.config.MaxAttempts = 3
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ClaimOutboxEvents :many`)).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(claimedCols).
+			AddRow(int64(7), "listings.created", "created.abc123", []byte(`{}`), int32(2)))
+	bus.EXPECT().Publish("listings.created", []byte(`{}`), "created.abc123").Return(errors.New("nats: no response from stream")).Once()
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: RecordOutboxEventFailure :exec`)).
+		WithArgs(pgtype.Text{String: "nats: no response from stream", Valid: true}, pgxmock.AnyArg(), timestamptz(now), int64(7)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	report, err := relay.Relay(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, Report{Failed: 1, GaveUp: 1}, report)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRelay_ClaimFails(t *testing.T) {
+	relay, mockPool, _, _ := newRelayTest(t)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: ClaimOutboxEvents :many`)).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnError(errors.New("connection refused"))
+
+	_, err := relay.Relay(context.Background())
+
+	assert.ErrorContains(t, err, "connection refused")
+}
+
+var statsCols = []string{"pending", "failed", "oldest_pending_at"}
+
+func TestRelay_Measure(t *testing.T) {
+	// SCENARIO: Three events are pending, the oldest written ten minutes ago, and one was given up on.
+	// EXPECT: The gauges show the backlog and its age. Past the five minute threshold, so it warns too.
+
+	relay, mockPool, _, now := newRelayTest(t)
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetOutboxStats :one`)).
+		WillReturnRows(pgxmock.NewRows(statsCols).AddRow(int64(3), int64(1), timestamptz(now.Add(-10*time.Minute))))
+
+	stats, err := relay.Measure(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Pending: 3, Failed: 1, OldestPendingAge: 10 * time.Minute}, stats)
+	assert.Equal(t, 3.0, promtest.ToFloat64(pendingEvents))
+	assert.Equal(t, 1.0, promtest.ToFloat64(failedEvents))
+	assert.Equal(t, 600.0, promtest.ToFloat64(oldestPendingAge))
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRelay_Measure_Empty(t *testing.T) {
+	// SCENARIO: Everything has been published.
+	// EXPECT: The gauges go back to 0 rather than keep the last backlog seen.
+
+	relay, mockPool, _, _ := newRelayTest(t)
+	oldestPendingAge.Set(600)
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetOutboxStats :one`)).
+		WillReturnRows(pgxmock.NewRows(statsCols).AddRow(int64(0), int64(0), pgtype.Timestamptz{}))
+
+	stats, err := relay.Measure(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, Stats{}, stats)
+	assert.Equal(t, 0.0, promtest.ToFloat64(pendingEvents))
+	assert.Equal(t, 0.0, promtest.ToFloat64(oldestPendingAge))
+}
+
+func TestRetryIn(t *testing.T) {
+	assert.Equal(t, time.Second, RetryIn(0))
+	assert.Equal(t, 4*time.Second, RetryIn(2))
+	assert.Equal(t, 5*time.Minute, RetryIn(9))
+	assert.Equal(t, 5*time.Minute, RetryIn(100))
+}
@@ -0,0 +1,56 @@
+// Package publicurl builds the browser-facing URLs for objects in the public-files bucket.
+// The listings worker has a copy of this package, keep the two in step so search results and API responses agree.
+package publicurl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+)
+
+// Config is where public objects are served from. Private objects (models) never go through here, they get presigned URLs.
+type Config struct {
+	// AssetsBaseURL serves everything in the public-files bucket, usually the S3 public endpoint
+	AssetsBaseURL string
+	// CDNImageBaseURL serves images only when set, e.g. a CDN in front of the bucket with its own hostname
+	CDNImageBaseURL string
+}
+
+// FromEnv reads PUBLIC_ASSETS_BASE_URL and CDN_IMAGE_BASE_URL. PUBLIC_FILES_URL is still read when
+// PUBLIC_ASSETS_BASE_URL isn't set, so existing deployments keep working until they're moved over.
+func FromEnv() Config {
+	base := os.Getenv("PUBLIC_ASSETS_BASE_URL")
+	if base == "" {
+		base = os.Getenv("PUBLIC_FILES_URL")
+	}
+	return Config{
+		AssetsBaseURL:   base,
+		CDNImageBaseURL: os.Getenv("CDN_IMAGE_BASE_URL"),
+	}
+}
+
+// File returns the URL for any public object
+func (c Config) File(path string) string {
+	return join(c.AssetsBaseURL, path)
+}
+
+// Image returns the URL for a public image, through the CDN when there is one
+func (c Config) Image(path string) string {
+	if c.CDNImageBaseURL != "" {
+		return join(c.CDNImageBaseURL, path)
+	}
+	return c.File(path)
+}
+
+// Version changes whenever either base URL does. Anything that caches generated URLs keys on it,
+// so moving to a new host doesn't keep serving links to the old one until the entries expire.
+func (c Config) Version() string {
+	sum := sha256.Sum256([]byte(c.AssetsBaseURL + "\n" + c.CDNImageBaseURL))
+	return hex.EncodeToString(sum[:4])
+}
+
+// join puts exactly one slash between base and path, object keys are stored both with and without a leading one
+func join(base, path string) string {
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(path, "/")
+}
@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/cache"
+	"time"
+)
+
+// Window is a fixed counting window aligned to the epoch, so an hourly window runs from the top of one hour to the next.
+// Windows of a day or less line up with UTC midnight.
+type Window struct {
+	Name string // Part of the Redis key, keeps an hourly and a daily counter for the same subject apart
+	Size time.Duration
+}
+
+var (
+	Hour = Window{Name: "hour", Size: time.Hour}
+	Day  = Window{Name: "day", Size: 24 * time.Hour}
+)
+
+// Bounds returns the start of the window now falls in and the moment the next one begins
+func (w Window) Bounds(now time.Time) (start, reset time.Time) {
+	start = now.Truncate(w.Size)
+	return start, start.Add(w.Size)
+}
+
+// Counter counts hits per subject, e.g. listings created by one user
+type Counter interface {
+	// Incr counts one hit against subject in the window now falls in and returns the total for that window
+	Incr(ctx context.Context, subject string, window Window, now time.Time) (int64, error)
+}
+
+type Store struct {
+	cache *cache.RedisClient
+}
+
+func NewStore(c *cache.RedisClient) *Store {
+	return &Store{cache: c}
+}
+
+// Incr keys each window by its start, so a new window starts from zero without anyone resetting the old key.
+// Old keys expire once their window has passed.
+func (s *Store) Incr(ctx context.Context, subject string, window Window, now time.Time) (int64, error) {
+	start, reset := window.Bounds(now)
+	key := fmt.Sprintf("ratelimit:%s:%s:%d", subject, window.Name, start.Unix())
+	return cache.IncrWithTTL(s.cache, ctx, key, reset.Sub(now)+time.Minute)
+}
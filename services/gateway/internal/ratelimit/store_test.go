@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindow_Bounds(t *testing.T) {
+	tests := []struct {
+		name      string
+		window    Window
+		now       time.Time
+		wantStart time.Time
+		wantReset time.Time
+	}{
+		{
+			name:      "hour, mid window",
+			window:    Hour,
+			now:       time.Date(2025, 3, 1, 10, 30, 0, 0, time.UTC),
+			wantStart: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+			wantReset: time.Date(2025, 3, 1, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "hour, last instant",
+			window:    Hour,
+			now:       time.Date(2025, 3, 1, 10, 59, 59, 999999999, time.UTC),
+			wantStart: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+			wantReset: time.Date(2025, 3, 1, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "hour, exactly on the boundary starts the next window",
+			window:    Hour,
+			now:       time.Date(2025, 3, 1, 11, 0, 0, 0, time.UTC),
+			wantStart: time.Date(2025, 3, 1, 11, 0, 0, 0, time.UTC),
+			wantReset: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "day lines up with UTC midnight",
+			window:    Day,
+			now:       time.Date(2025, 3, 1, 23, 59, 59, 0, time.UTC),
+			wantStart: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantReset: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "day ignores the caller's time zone",
+			window:    Day,
+			now:       time.Date(2025, 3, 2, 0, 30, 0, 0, time.FixedZone("CET", 3600)),
+			wantStart: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantReset: time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, reset := tt.window.Bounds(tt.now)
+			assert.True(t, tt.wantStart.Equal(start), "start %v, want %v", start, tt.wantStart)
+			assert.True(t, tt.wantReset.Equal(reset), "reset %v, want %v", reset, tt.wantReset)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Package realip sets a request's RemoteAddr to the client's address when it came through one of our proxies. Unlike
+// chi's middleware.RealIP, X-Forwarded-For and X-Real-IP are only believed from a configured trusted proxy, anyone
+// else could put any address there and be counted as someone new on every request.
+package realip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ParsePrefixes reads a comma separated list of CIDRs and bare IPs, e.g. TRUSTED_PROXIES. A bare IP is a single host.
+func ParsePrefixes(list string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid IP or CIDR %q: %w", entry, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP or CIDR %q: %w", entry, err)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return prefixes, nil
+}
+
+// Middleware rewrites RemoteAddr to the client behind the trusted proxies. The client is the right-most address in
+// X-Forwarded-For that isn't a trusted proxy, every proxy appends the address it was called from, so everything left
+// of that could have been sent by the client. X-Real-IP is used when there's no X-Forwarded-For. Requests straight
+// from an untrusted address are left as they are, headers and all ignored.
+func Middleware(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	isTrusted := func(addr netip.Addr) bool {
+		for _, prefix := range trusted {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer, ok := parseAddr(r.RemoteAddr)
+			if !ok || !isTrusted(peer) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if client, ok := forwardedFor(r.Header.Values("X-Forwarded-For"), isTrusted); ok {
+				r.RemoteAddr = client.String()
+			} else if client, ok := parseAddr(r.Header.Get("X-Real-IP")); ok {
+				r.RemoteAddr = client.String()
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedFor walks the X-Forwarded-For chain from the right, past the trusted proxies. When every hop is trusted the
+// left-most is the client. A hop that isn't an IP stops the walk, nothing left of it can be believed.
+func forwardedFor(headers []string, isTrusted func(netip.Addr) bool) (netip.Addr, bool) {
+	var hops []string
+	for _, header := range headers {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	var client netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseAddr(hops[i])
+		if !ok {
+			break
+		}
+		client = addr
+		if !isTrusted(addr) {
+			break
+		}
+	}
+	return client, client.IsValid()
+}
+
+// parseAddr takes an address with or without a port
+func parseAddr(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
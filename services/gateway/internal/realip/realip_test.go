@@ -0,0 +1,86 @@
+package realip_test
+
+import (
+	"gateway/internal/realip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// remoteAddr is what the handler behind the middleware sees
+func remoteAddr(t *testing.T, trusted string, peer string, headers map[string]string) string {
+	t.Helper()
+
+	proxies, err := realip.ParsePrefixes(trusted)
+	require.NoError(t, err)
+
+	var seen string
+	handler := realip.Middleware(proxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.RemoteAddr
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/listings", nil)
+	req.RemoteAddr = peer
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	return seen
+}
+
+func TestMiddleware(t *testing.T) {
+	// SCENARIO: Requests straight from clients and through the load balancer (10.0.0.5) and CDN (203.0.113.0/24), some
+	// with forwarded headers the client made up.
+	// EXPECT: Forwarded headers are only believed from a trusted proxy, and only as far back as the last hop that
+	// isn't one.
+
+	const trusted = "10.0.0.0/8, 203.0.113.0/24"
+
+	tests := []struct {
+		name    string
+		peer    string
+		headers map[string]string
+		want    string
+	}{
+		{name: "Direct", peer: "198.51.100.7:5000", want: "198.51.100.7:5000"},
+		{name: "Direct with a made up header", peer: "198.51.100.7:5000", headers: map[string]string{"X-Forwarded-For": "192.0.2.1"}, want: "198.51.100.7:5000"},
+		{name: "Direct with a made up X-Real-IP", peer: "198.51.100.7:5000", headers: map[string]string{"X-Real-IP": "192.0.2.1"}, want: "198.51.100.7:5000"},
+		{name: "Through the load balancer", peer: "10.0.0.5:443", headers: map[string]string{"X-Forwarded-For": "198.51.100.7"}, want: "198.51.100.7"},
+		{name: "Through the CDN and load balancer", peer: "10.0.0.5:443", headers: map[string]string{"X-Forwarded-For": "198.51.100.7, 203.0.113.9"}, want: "198.51.100.7"},
+		{name: "Client prepends an address", peer: "10.0.0.5:443", headers: map[string]string{"X-Forwarded-For": "192.0.2.1, 198.51.100.7"}, want: "198.51.100.7"},
+		{name: "Garbage left of the client", peer: "10.0.0.5:443", headers: map[string]string{"X-Forwarded-For": "nonsense, 198.51.100.7"}, want: "198.51.100.7"},
+		{name: "Only proxies", peer: "10.0.0.5:443", headers: map[string]string{"X-Forwarded-For": "10.0.0.9, 10.0.0.8"}, want: "10.0.0.9"},
+		{name: "X-Real-IP from the load balancer", peer: "10.0.0.5:443", headers: map[string]string{"X-Real-IP": "198.51.100.7"}, want: "198.51.100.7"},
+		{name: "No header from the load balancer", peer: "10.0.0.5:443", want: "10.0.0.5:443"},
+		{name: "IPv6 client", peer: "10.0.0.5:443", headers: map[string]string{"X-Forwarded-For": "2001:db8::1"}, want: "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, remoteAddr(t, trusted, tt.peer, tt.headers))
+		})
+	}
+}
+
+func TestMiddleware_NoTrustedProxies(t *testing.T) {
+	// SCENARIO: TRUSTED_PROXIES is unset and a client sends X-Forwarded-For.
+	// EXPECT: It's ignored.
+
+	assert.Equal(t, "10.0.0.5:443", remoteAddr(t, "", "10.0.0.5:443", map[string]string{"X-Forwarded-For": "198.51.100.7"}))
+}
+
+func TestParsePrefixes(t *testing.T) {
+	prefixes, err := realip.ParsePrefixes(" 10.0.0.0/8 ,, 203.0.113.7, ::ffff:192.0.2.1")
+	require.NoError(t, err)
+	require.Len(t, prefixes, 3)
+	assert.Equal(t, "10.0.0.0/8", prefixes[0].String())
+	assert.Equal(t, "203.0.113.7/32", prefixes[1].String())
+	assert.Equal(t, "192.0.2.1/32", prefixes[2].String())
+
+	_, err = realip.ParsePrefixes("10.0.0.0/33")
+	assert.Error(t, err)
+	_, err = realip.ParsePrefixes("lb.example.com")
+	assert.Error(t, err)
+}
@@ -0,0 +1,250 @@
+package scrapeguard
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"gateway/internal/errors"
+	"gateway/internal/ratelimit"
+	"gateway/internal/realip"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIKeyHeader carries a key for callers allowed more traffic, e.g. partners syncing the catalogue
+const APIKeyHeader = "X-API-Key"
+
+type Config struct {
+	// Sliding window bursts are counted over
+	Window time.Duration
+	// Requests per Window from one IP before it is throttled. 0 disables the guard.
+	BurstLimit int64
+	// Requests per Window with a known API key, counted per key rather than per IP
+	APIKeyBurstLimit int64
+	// Throttled bursts within StrikeTTL before the caller is blocked for BlockFor
+	StrikesToBlock int64
+	StrikeTTL      time.Duration
+	BlockFor       time.Duration
+	// Requests per hour from one IP before it needs an API key. 0 never requires one.
+	SustainedPerHour int64
+	// Keys that get APIKeyBurstLimit and skip SustainedPerHour, an unknown key counts as no key
+	APIKeys []string
+	// CDNs, health checkers and other callers that are never counted
+	Allowlist []netip.Prefix
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Window:           10 * time.Second,
+		BurstLimit:       50,
+		APIKeyBurstLimit: 500,
+		StrikesToBlock:   3,
+		StrikeTTL:        10 * time.Minute,
+		BlockFor:         15 * time.Minute,
+	}
+}
+
+// ParseAllowlist reads a comma separated list of IPs and CIDRs, e.g. "10.0.0.0/8, 203.0.113.7"
+func ParseAllowlist(list string) ([]netip.Prefix, error) {
+	return realip.ParsePrefixes(list)
+}
+
+// Guard slows down callers that page through public endpoints faster than a person would. Going over the burst
+// limit earns a 429 and a strike, enough strikes in a row earn a temporary block with its own error code. Counters
+// live in Redis, when it's unavailable requests are let through rather than failing every public page.
+type Guard struct {
+	config    Config
+	store     Store
+	sustained ratelimit.Counter // Hourly counts for SustainedPerHour, may be nil when that's off
+	keys      map[string]bool
+	logger    *slog.Logger
+	now       func() time.Time
+}
+
+func NewGuard(config Config, store Store, sustained ratelimit.Counter, logger *slog.Logger) *Guard {
+	defaults := DefaultConfig()
+	if config.Window <= 0 {
+		config.Window = defaults.Window
+	}
+	if config.StrikeTTL <= 0 {
+		config.StrikeTTL = defaults.StrikeTTL
+	}
+	if config.BlockFor <= 0 {
+		config.BlockFor = defaults.BlockFor
+	}
+
+	keys := make(map[string]bool, len(config.APIKeys))
+	for _, key := range config.APIKeys {
+		if key = strings.TrimSpace(key); key != "" {
+			keys[key] = true
+		}
+	}
+
+	return &Guard{
+		config:    config,
+		store:     store,
+		sustained: sustained,
+		keys:      keys,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+func (g *Guard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.config.BurstLimit <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ip, ok := clientIP(r)
+		if ok && g.allowlisted(ip) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Without a parseable IP the raw address still keeps callers apart
+		caller := r.RemoteAddr
+		if ok {
+			caller = ip.String()
+		}
+
+		ctx := r.Context()
+		subject, limit, keyed := "ip:"+caller, g.config.BurstLimit, false
+		if key := r.Header.Get(APIKeyHeader); key != "" && g.keys[key] {
+			subject, limit, keyed = "key:"+fingerprint(key), g.config.APIKeyBurstLimit, true
+		}
+
+		if appErr := g.check(ctx, subject, limit); appErr != nil {
+			errors.RespondError(w, r, appErr)
+			return
+		}
+
+		if !keyed {
+			if appErr := g.checkSustained(ctx, caller); appErr != nil {
+				errors.RespondError(w, r, appErr)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// check escalates from a 429 to a block. Only the request that tips a burst over the limit adds a strike, so a
+// scraper hammering away at a 429 earns one strike per burst rather than one per request.
+func (g *Guard) check(ctx context.Context, subject string, limit int64) *errors.AppError {
+	left, blocked, err := g.store.Blocked(ctx, subject)
+	if err != nil {
+		g.storeError(ctx, "Failed to read scrape block, letting the request through", subject, err)
+		return nil
+	}
+	if blocked {
+		return g.blocked(ctx, subject, left)
+	}
+
+	hits, err := g.store.Hit(ctx, subject, g.config.Window, g.now())
+	if err != nil {
+		g.storeError(ctx, "Failed to count request, letting it through", subject, err)
+		return nil
+	}
+	if hits <= limit {
+		return nil
+	}
+
+	if hits == limit+1 {
+		strikes, err := g.store.Strike(ctx, subject, g.config.StrikeTTL)
+		if err != nil {
+			g.storeError(ctx, "Failed to record scrape strike", subject, err)
+		} else if g.config.StrikesToBlock > 0 && strikes >= g.config.StrikesToBlock {
+			if err := g.store.Block(ctx, subject, g.config.BlockFor); err != nil {
+				g.storeError(ctx, "Failed to block scraper", subject, err)
+			} else {
+				g.logger.WarnContext(ctx, "Blocking likely scraper", "subject", subject, "strikes", strikes, "block_for", g.config.BlockFor)
+				return g.blocked(ctx, subject, g.config.BlockFor)
+			}
+		}
+	}
+
+	actionsTotal.WithLabelValues(ActionThrottled).Inc()
+	g.logger.InfoContext(ctx, "Throttling public requests", "subject", subject, "hits", hits, "limit", limit, "window", g.config.Window)
+	return errors.New(errors.ErrRateLimited, "Too many requests, please slow down", nil).
+		WithReason(errors.ReasonScrapeBurst).
+		WithParam("retry_after", seconds(g.config.Window)).
+		WithRetryAfter(g.config.Window)
+}
+
+func (g *Guard) checkSustained(ctx context.Context, caller string) *errors.AppError {
+	if g.config.SustainedPerHour <= 0 || g.sustained == nil {
+		return nil
+	}
+
+	now := g.now()
+	count, err := g.sustained.Incr(ctx, "scrape:"+caller, ratelimit.Hour, now)
+	if err != nil {
+		g.storeError(ctx, "Failed to count hourly requests, letting the request through", caller, err)
+		return nil
+	}
+	if count <= g.config.SustainedPerHour {
+		return nil
+	}
+
+	_, reset := ratelimit.Hour.Bounds(now)
+	actionsTotal.WithLabelValues(ActionKeyRequired).Inc()
+	return errors.New(errors.ErrRateLimited, "Too many requests without an API key", nil).
+		WithReason(errors.ReasonScrapeAPIKeyRequired).
+		WithRetryAfter(reset.Sub(now))
+}
+
+func (g *Guard) blocked(ctx context.Context, subject string, left time.Duration) *errors.AppError {
+	actionsTotal.WithLabelValues(ActionBlocked).Inc()
+	g.logger.InfoContext(ctx, "Turning away blocked caller", "subject", subject, "left", left)
+	return errors.New(errors.ErrBlocked, "Too many requests, access is paused", nil).
+		WithReason(errors.ReasonScrapeBlocked).
+		WithParam("retry_after", seconds(left)).
+		WithRetryAfter(left)
+}
+
+func (g *Guard) storeError(ctx context.Context, msg, subject string, err error) {
+	actionsTotal.WithLabelValues(ActionStoreUnhealthy).Inc()
+	g.logger.WarnContext(ctx, msg, "subject", subject, "error", err)
+}
+
+func (g *Guard) allowlisted(ip netip.Addr) bool {
+	for _, prefix := range g.config.Allowlist {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP is the caller's address, RemoteAddr has already been rewritten by realip.Middleware when the request came
+// through a trusted proxy
+func clientIP(r *http.Request) (netip.Addr, bool) {
+	host := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		host = h
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// fingerprint keeps API keys out of Redis keys and logs
+func fingerprint(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// seconds rounds up, like the Retry-After header
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int((d + time.Second - 1) / time.Second))
+}
@@ -0,0 +1,258 @@
+package scrapeguard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gateway/internal/ratelimit"
+	"gateway/internal/testutil"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// FakeStore keeps hits, strikes and blocks in memory on the guard's clock
+type FakeStore struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	hits    map[string][]time.Time
+	strikes map[string]int64
+	blocks  map[string]time.Time // Subject -> block expiry
+	err     error
+}
+
+func NewFakeStore(now func() time.Time) *FakeStore {
+	return &FakeStore{now: now, hits: map[string][]time.Time{}, strikes: map[string]int64{}, blocks: map[string]time.Time{}}
+}
+
+func (f *FakeStore) Hit(ctx context.Context, subject string, window time.Duration, now time.Time) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return 0, f.err
+	}
+	kept := []time.Time{}
+	for _, at := range f.hits[subject] {
+		if at.After(now.Add(-window)) {
+			kept = append(kept, at)
+		}
+	}
+	f.hits[subject] = append(kept, now)
+	return int64(len(f.hits[subject])), nil
+}
+
+func (f *FakeStore) Strike(ctx context.Context, subject string, ttl time.Duration) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.strikes[subject]++
+	return f.strikes[subject], nil
+}
+
+func (f *FakeStore) Block(ctx context.Context, subject string, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.blocks[subject] = f.now().Add(ttl)
+	return nil
+}
+
+func (f *FakeStore) Blocked(ctx context.Context, subject string) (time.Duration, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return 0, false, f.err
+	}
+	until, ok := f.blocks[subject]
+	if !ok || !until.After(f.now()) {
+		return 0, false, nil
+	}
+	return until.Sub(f.now()), true, nil
+}
+
+// FakeCounter is an hourly counter that never resets
+type FakeCounter struct{ counts map[string]int64 }
+
+func (f *FakeCounter) Incr(ctx context.Context, subject string, window ratelimit.Window, now time.Time) (int64, error) {
+	f.counts[subject]++
+	return f.counts[subject], nil
+}
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func newTestGuard(config Config) (*Guard, *FakeStore, *clock) {
+	c := &clock{t: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)}
+	store := NewFakeStore(c.now)
+	guard := NewGuard(config, store, &FakeCounter{counts: map[string]int64{}}, testutil.NewTestLogger())
+	guard.now = c.now
+	return guard, store, c
+}
+
+func get(g *Guard, remoteAddr string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/listings/abc", nil)
+	req.RemoteAddr = remoteAddr
+	for k, v := range header {
+		req.Header.Set(k, v[0])
+	}
+	rec := httptest.NewRecorder()
+	g.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})).ServeHTTP(rec, req)
+	return rec
+}
+
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) (string, string) {
+	t.Helper()
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return body["error_code"], body["reason"]
+}
+
+func TestGuard_ScrapeEscalates(t *testing.T) {
+	// SCENARIO: A scraper pulls listing pages as fast as it can, backing off only as long as each 429 asks.
+	// EXPECT: Within a window it gets the burst limit through, then a 429 with Retry-After. The third throttled
+	// burst within StrikeTTL blocks it with BLOCKED for BlockFor, and it gets through again once the block ends.
+
+	config := Config{Window: 10 * time.Second, BurstLimit: 5, StrikesToBlock: 3, StrikeTTL: 10 * time.Minute, BlockFor: 15 * time.Minute}
+	guard, _, c := newTestGuard(config)
+	const scraper = "198.51.100.7:53211"
+
+	for burst := 1; burst <= 2; burst++ {
+		for i := 0; i < 5; i++ {
+			require.Equal(t, http.StatusOK, get(guard, scraper, nil).Code, "burst %d request %d", burst, i)
+		}
+		for i := 0; i < 3; i++ {
+			rec := get(guard, scraper, nil)
+			require.Equal(t, http.StatusTooManyRequests, rec.Code, "burst %d", burst)
+			assert.Equal(t, "10", rec.Header().Get("Retry-After"))
+			code, reason := errorCode(t, rec)
+			assert.Equal(t, "RATE_LIMITED", code)
+			assert.Equal(t, "SCRAPE_BURST", reason)
+		}
+		c.t = c.t.Add(config.Window)
+	}
+
+	for i := 0; i < 5; i++ {
+		require.Equal(t, http.StatusOK, get(guard, scraper, nil).Code)
+	}
+	rec := get(guard, scraper, nil)
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "900", rec.Header().Get("Retry-After"))
+	code, reason := errorCode(t, rec)
+	assert.Equal(t, "BLOCKED", code)
+	assert.Equal(t, "SCRAPE_BLOCKED", reason)
+
+	// Waiting out the window isn't enough any more
+	c.t = c.t.Add(time.Minute)
+	rec = get(guard, scraper, nil)
+	code, _ = errorCode(t, rec)
+	assert.Equal(t, "BLOCKED", code)
+	assert.Equal(t, "840", rec.Header().Get("Retry-After"))
+
+	c.t = c.t.Add(config.BlockFor)
+	assert.Equal(t, http.StatusOK, get(guard, scraper, nil).Code)
+
+	// Another caller was never affected
+	assert.Equal(t, http.StatusOK, get(guard, "203.0.113.9:4000", nil).Code)
+}
+
+func TestGuard_SteadyBrowsingIsNeverThrottled(t *testing.T) {
+	guard, _, c := newTestGuard(Config{Window: 10 * time.Second, BurstLimit: 5, StrikesToBlock: 3})
+
+	for i := 0; i < 200; i++ {
+		require.Equal(t, http.StatusOK, get(guard, "198.51.100.7:1", nil).Code, i)
+		c.t = c.t.Add(2 * time.Second)
+	}
+}
+
+func TestGuard_Allowlist(t *testing.T) {
+	allowlist, err := ParseAllowlist("10.0.0.0/8, 203.0.113.7,2001:db8::/32")
+	require.NoError(t, err)
+	guard, _, _ := newTestGuard(Config{Window: time.Minute, BurstLimit: 1, StrikesToBlock: 1, Allowlist: allowlist})
+
+	for _, addr := range []string{"10.1.2.3:80", "203.0.113.7:443", "[2001:db8::1]:443"} {
+		for i := 0; i < 10; i++ {
+			require.Equal(t, http.StatusOK, get(guard, addr, nil).Code, addr)
+		}
+	}
+
+	get(guard, "203.0.113.8:443", nil)
+	assert.Equal(t, http.StatusTooManyRequests, get(guard, "203.0.113.8:443", nil).Code, "neighbours aren't allowlisted")
+
+	_, err = ParseAllowlist("10.0.0.0/33")
+	assert.Error(t, err)
+	_, err = ParseAllowlist("cdn.example.com")
+	assert.Error(t, err)
+}
+
+func TestGuard_APIKey(t *testing.T) {
+	config := Config{Window: time.Minute, BurstLimit: 2, APIKeyBurstLimit: 20, StrikesToBlock: 3, SustainedPerHour: 3, APIKeys: []string{"partner-key"}}
+
+	t.Run("Known key gets its own higher limit", func(t *testing.T) {
+		guard, store, _ := newTestGuard(config)
+		header := http.Header{APIKeyHeader: {"partner-key"}}
+
+		for i := 0; i < 20; i++ {
+			require.Equal(t, http.StatusOK, get(guard, "198.51.100.7:1", header).Code, i)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, get(guard, "198.51.100.7:1", header).Code)
+		for subject := range store.hits {
+			assert.NotContains(t, subject, "partner-key", "keys stay out of Redis")
+		}
+	})
+
+	t.Run("Unknown key counts as no key", func(t *testing.T) {
+		guard, _, _ := newTestGuard(config)
+		header := http.Header{APIKeyHeader: {"made-up"}}
+
+		get(guard, "198.51.100.7:1", header)
+		get(guard, "198.51.100.7:1", header)
+		assert.Equal(t, http.StatusTooManyRequests, get(guard, "198.51.100.7:1", header).Code)
+	})
+
+	t.Run("Sustained volume without a key needs one", func(t *testing.T) {
+		guard, _, c := newTestGuard(config)
+
+		for i := 0; i < 3; i++ {
+			require.Equal(t, http.StatusOK, get(guard, "198.51.100.7:1", nil).Code, i)
+			c.t = c.t.Add(time.Minute)
+		}
+		rec := get(guard, "198.51.100.7:1", nil)
+		require.Equal(t, http.StatusTooManyRequests, rec.Code)
+		_, reason := errorCode(t, rec)
+		assert.Equal(t, "SCRAPE_API_KEY_REQUIRED", reason)
+		assert.Equal(t, "3420", rec.Header().Get("Retry-After"), "until the hour resets")
+
+		assert.Equal(t, http.StatusOK, get(guard, "198.51.100.7:1", http.Header{APIKeyHeader: {"partner-key"}}).Code)
+	})
+}
+
+func TestGuard_StoreDown_LetsRequestsThrough(t *testing.T) {
+	guard, store, _ := newTestGuard(Config{Window: time.Minute, BurstLimit: 1})
+	store.err = errors.New("redis: connection refused")
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, get(guard, "198.51.100.7:1", nil).Code)
+	}
+}
+
+func TestGuard_Disabled(t *testing.T) {
+	guard, store, _ := newTestGuard(Config{})
+
+	for i := 0; i < 100; i++ {
+		require.Equal(t, http.StatusOK, get(guard, "198.51.100.7:1", nil).Code)
+	}
+	assert.Empty(t, store.hits)
+}
+
+func TestParseAllowlist_BareIPsAreSingleHosts(t *testing.T) {
+	prefixes, err := ParseAllowlist(" 203.0.113.7 ,, ::ffff:192.0.2.1")
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("203.0.113.7/32"), netip.MustParsePrefix("192.0.2.1/32")}, prefixes)
+}
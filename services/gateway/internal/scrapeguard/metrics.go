@@ -0,0 +1,18 @@
+package scrapeguard
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	ActionThrottled      = "throttled"
+	ActionBlocked        = "blocked"
+	ActionKeyRequired    = "api_key_required"
+	ActionStoreUnhealthy = "store_error"
+)
+
+var actionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateway_scrape_guard_total",
+	Help: "Public requests the scrape guard turned away, by action, and store errors it let through.",
+}, []string{"action"})
@@ -0,0 +1,47 @@
+package scrapeguard
+
+import (
+	"context"
+	"fmt"
+	"gateway/internal/cache"
+	"math/rand/v2"
+	"time"
+)
+
+// Store keeps the guard's counters in Redis so every gateway pod sees the same caller
+type Store interface {
+	// Hit records a request from subject and returns how many it made in the window ending at now
+	Hit(ctx context.Context, subject string, window time.Duration, now time.Time) (int64, error)
+	// Strike counts a throttled burst against subject and returns the strikes it has, each lasts ttl from the first
+	Strike(ctx context.Context, subject string, ttl time.Duration) (int64, error)
+	// Block turns subject away for ttl
+	Block(ctx context.Context, subject string, ttl time.Duration) error
+	// Blocked returns how long subject's block has left, false when it isn't blocked
+	Blocked(ctx context.Context, subject string) (time.Duration, bool, error)
+}
+
+type RedisStore struct {
+	cache *cache.RedisClient
+}
+
+func NewStore(c *cache.RedisClient) *RedisStore {
+	return &RedisStore{cache: c}
+}
+
+func (s *RedisStore) Hit(ctx context.Context, subject string, window time.Duration, now time.Time) (int64, error) {
+	// Two pods can take a request in the same microsecond, the random part keeps both hits
+	member := fmt.Sprintf("%d-%d", now.UnixNano(), rand.Uint32())
+	return cache.SlidingWindowAdd(s.cache, ctx, "scrape:hits:"+subject, member, now, window)
+}
+
+func (s *RedisStore) Strike(ctx context.Context, subject string, ttl time.Duration) (int64, error) {
+	return cache.IncrWithTTL(s.cache, ctx, "scrape:strikes:"+subject, ttl)
+}
+
+func (s *RedisStore) Block(ctx context.Context, subject string, ttl time.Duration) error {
+	return cache.Set(s.cache, ctx, "scrape:block:"+subject, time.Now().UTC(), ttl)
+}
+
+func (s *RedisStore) Blocked(ctx context.Context, subject string) (time.Duration, bool, error) {
+	return cache.TTL(s.cache, ctx, "scrape:block:"+subject)
+}
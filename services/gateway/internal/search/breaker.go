@@ -0,0 +1,223 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrCircuitOpen is returned without calling search while the breaker is open, callers should fall back straight away
+var ErrCircuitOpen = errors.New("search circuit breaker is open")
+
+type BreakerState int
+
+const (
+	StateClosed BreakerState = iota
+	// One probe is let through to see whether search has recovered, everything else is still refused
+	StateHalfOpen
+	StateOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case StateHalfOpen:
+		return "half_open"
+	case StateOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+type BreakerConfig struct {
+	// Consecutive failures that open the breaker
+	FailureThreshold int
+	// How long the breaker stays open before a probe is let through
+	OpenFor time.Duration
+}
+
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold: 5,
+		OpenFor:          30 * time.Second,
+	}
+}
+
+var _ Client = (*Breaker)(nil)
+
+// Breaker stops calling search after a run of failures, so during an outage requests go to their fallback
+// at once instead of each waiting out the timeout. Its state is exported as gateway_search_breaker_state
+// and reported by /readyz.
+//
+// Identical calls made while one is in flight wait for its answer rather than each going to search, so a burst
+// of homepage loads while Typesense is slow is one query and, if it fails, one failure. Callers share the result
+// and mustn't modify it.
+type Breaker struct {
+	next   Client
+	config BreakerConfig
+	logger *slog.Logger
+	now    func() time.Time
+
+	calls singleflight.Group
+	// Called once a caller is waiting on the call for its key, started or joined, so tests know it has joined
+	waiting func()
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewBreaker(next Client, config BreakerConfig, logger *slog.Logger) *Breaker {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = DefaultBreakerConfig().FailureThreshold
+	}
+	if config.OpenFor <= 0 {
+		config.OpenFor = DefaultBreakerConfig().OpenFor
+	}
+
+	breakerState.Set(float64(StateClosed))
+	return &Breaker{
+		next:   next,
+		config: config,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// State is the breaker's current state. An open breaker whose OpenFor has passed reports half-open, it lets the
+// next call through as a probe.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.config.OpenFor {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+func (b *Breaker) FacetCounts(ctx context.Context, collection string, field string) (*FacetCounts, error) {
+	key := fmt.Sprintf("facets\x00%s\x00%s", collection, field)
+	counts, err := b.call(ctx, key, func(ctx context.Context) (any, error) {
+		return b.next.FacetCounts(ctx, collection, field)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts.(*FacetCounts), nil
+}
+
+func (b *Breaker) Documents(ctx context.Context, collection string, query DocumentQuery) ([]map[string]any, error) {
+	key := fmt.Sprintf("documents\x00%s\x00%s\x00%s\x00%d", collection, query.FilterBy, query.SortBy, query.Limit)
+	documents, err := b.call(ctx, key, func(ctx context.Context) (any, error) {
+		return b.next.Documents(ctx, collection, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return documents.([]map[string]any), nil
+}
+
+// call runs fn unless the breaker refuses it, or joins the call for key already in flight. fn doesn't stop when
+// the caller that started it gives up, the others may still be waiting for it and the client's timeout bounds it.
+func (b *Breaker) call(ctx context.Context, key string, fn func(ctx context.Context) (any, error)) (any, error) {
+	// Checked first, a canceled call mustn't use up the half-open probe
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ran := false
+	done := b.calls.DoChan(key, func() (any, error) {
+		ran = true
+		if !b.allow() {
+			breakerRejectedTotal.Inc()
+			return nil, ErrCircuitOpen
+		}
+
+		callCtx := context.WithoutCancel(ctx)
+		result, err := fn(callCtx)
+		b.record(callCtx, err)
+		return result, err
+	})
+	if b.waiting != nil {
+		b.waiting()
+	}
+
+	select {
+	case result := <-done:
+		// Set before fn returned, which happened before its result was sent
+		if !ran {
+			coalescedTotal.Inc()
+		}
+		return result.Val, result.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// allow decides whether a call may go to search, moving an open breaker to half-open once OpenFor has passed
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.config.OpenFor {
+			return false
+		}
+		b.transition(StateHalfOpen)
+		b.probing = true
+		return true
+	case StateHalfOpen:
+		// Only the one probe, the rest wait for its answer
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *Breaker) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	if err == nil {
+		b.failures = 0
+		if b.state != StateClosed {
+			b.logger.InfoContext(ctx, "Search recovered, closing the circuit breaker")
+			b.transition(StateClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.config.FailureThreshold {
+		if b.state != StateOpen {
+			b.logger.WarnContext(ctx, "Search failing, opening the circuit breaker", "failures", b.failures, "open_for", b.config.OpenFor, "error", err)
+		}
+		b.openedAt = b.now()
+		b.transition(StateOpen)
+	}
+}
+
+// transition must be called with mu held
+func (b *Breaker) transition(to BreakerState) {
+	if b.state == to {
+		return
+	}
+	b.state = to
+	breakerState.Set(float64(to))
+	breakerTransitionsTotal.WithLabelValues(to.String()).Inc()
+}
@@ -0,0 +1,298 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gateway/internal/testutil"
+
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient fails while err is set and counts the calls that reached it
+type fakeClient struct {
+	err   error
+	calls int
+	// Called during FacetCounts, to make a second call while the first is still in flight
+	during func()
+	// When set FacetCounts says it has started on started, then holds the call until release is closed
+	started chan struct{}
+	release chan struct{}
+	ctxErr  error // Of the context the last FacetCounts was called with, when it returned
+}
+
+func (f *fakeClient) FacetCounts(ctx context.Context, collection string, field string) (*FacetCounts, error) {
+	f.calls++
+	if f.during != nil {
+		during := f.during
+		f.during = nil
+		during()
+	}
+	if f.release != nil {
+		f.started <- struct{}{}
+		<-f.release
+	}
+	f.ctxErr = ctx.Err()
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &FacetCounts{Total: 1, Counts: map[string]int64{"functional": 1}}, nil
+}
+
+func (f *fakeClient) Documents(ctx context.Context, collection string, query DocumentQuery) ([]map[string]any, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []map[string]any{{"id": "1"}}, nil
+}
+
+func newTestBreaker(client Client) (*Breaker, *time.Time) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	breaker := NewBreaker(client, BreakerConfig{FailureThreshold: 3, OpenFor: 30 * time.Second}, testutil.NewTestLogger())
+	breaker.now = func() time.Time { return now }
+	return breaker, &now
+}
+
+func facetCounts(b *Breaker) error {
+	_, err := b.FacetCounts(context.Background(), ListingsCollection, "categories")
+	return err
+}
+
+func TestBreaker_OpensAfterFailureBurst(t *testing.T) {
+	// SCENARIO: Typesense starts refusing connections.
+	// EXPECT: The third failure in a row opens the breaker, after which calls are refused without reaching search.
+
+	client := &fakeClient{err: errors.New("connection refused")}
+	breaker, _ := newTestBreaker(client)
+	beforeRejected := promtest.ToFloat64(breakerRejectedTotal)
+
+	for range 3 {
+		assert.EqualError(t, facetCounts(breaker), "connection refused")
+	}
+	assert.Equal(t, StateOpen, breaker.State())
+	assert.Equal(t, float64(StateOpen), promtest.ToFloat64(breakerState))
+
+	for range 10 {
+		assert.ErrorIs(t, facetCounts(breaker), ErrCircuitOpen)
+	}
+	assert.Equal(t, 3, client.calls)
+	assert.Equal(t, beforeRejected+10, promtest.ToFloat64(breakerRejectedTotal))
+}
+
+func TestBreaker_SuccessResetsFailures(t *testing.T) {
+	// SCENARIO: Search fails now and then, never three times in a row.
+	// EXPECT: The breaker stays closed.
+
+	client := &fakeClient{}
+	breaker, _ := newTestBreaker(client)
+
+	for range 3 {
+		client.err = errors.New("timeout")
+		_ = facetCounts(breaker)
+		_ = facetCounts(breaker)
+		client.err = nil
+		require.NoError(t, facetCounts(breaker))
+	}
+	assert.Equal(t, StateClosed, breaker.State())
+	assert.Equal(t, 9, client.calls)
+}
+
+// slowClient is a fakeClient whose FacetCounts holds until release is closed
+func slowClient(err error) *fakeClient {
+	return &fakeClient{err: err, started: make(chan struct{}, 1), release: make(chan struct{})}
+}
+
+// waitingOn has joined.Wait return once n callers are waiting on a call in breaker
+func waitingOn(breaker *Breaker, n int) *sync.WaitGroup {
+	var joined sync.WaitGroup
+	joined.Add(n)
+	breaker.waiting = joined.Done
+	return &joined
+}
+
+// facetCountsTogether makes n identical FacetCounts calls while the first is held by client, then lets it finish once
+// the others have joined it
+func facetCountsTogether(breaker *Breaker, client *fakeClient, n int) ([]*FacetCounts, []error) {
+	results := make([]*FacetCounts, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	call := func(i int) {
+		defer wg.Done()
+		results[i], errs[i] = breaker.FacetCounts(context.Background(), ListingsCollection, "categories")
+	}
+
+	joined := waitingOn(breaker, n)
+	wg.Add(n)
+	go call(0)
+	<-client.started
+	for i := 1; i < n; i++ {
+		go call(i)
+	}
+	joined.Wait()
+	close(client.release)
+	wg.Wait()
+	return results, errs
+}
+
+func TestBreaker_CoalescesIdenticalCalls(t *testing.T) {
+	// SCENARIO: Five homepage loads ask for the category counts while search is slow to answer the first.
+	// EXPECT: One query reaches search and all five get its answer.
+
+	client := slowClient(nil)
+	breaker, _ := newTestBreaker(client)
+	beforeCoalesced := promtest.ToFloat64(coalescedTotal)
+
+	results, errs := facetCountsTogether(breaker, client, 5)
+
+	for i := range 5 {
+		require.NoError(t, errs[i])
+		assert.Same(t, results[0], results[i])
+	}
+	assert.Equal(t, 1, client.calls)
+	assert.Equal(t, beforeCoalesced+4, promtest.ToFloat64(coalescedTotal))
+}
+
+func TestBreaker_CoalescedFailureCountsOnce(t *testing.T) {
+	// SCENARIO: Five identical calls wait on one query, which fails.
+	// EXPECT: They all get the error but it's one failure, not enough to open the breaker at three.
+
+	client := slowClient(errors.New("connection refused"))
+	breaker, _ := newTestBreaker(client)
+
+	_, errs := facetCountsTogether(breaker, client, 5)
+
+	for _, err := range errs {
+		assert.EqualError(t, err, "connection refused")
+	}
+	assert.Equal(t, 1, client.calls)
+	assert.Equal(t, StateClosed, breaker.State())
+}
+
+func TestBreaker_DifferentQueriesNotCoalesced(t *testing.T) {
+	// SCENARIO: A category page asks for its top listings while the category counts are in flight.
+	// EXPECT: Different queries, so both reach search.
+
+	client := &fakeClient{}
+	breaker, _ := newTestBreaker(client)
+
+	var documents []map[string]any
+	var err error
+	client.during = func() {
+		documents, err = breaker.Documents(context.Background(), ListingsCollection, DocumentQuery{SortBy: "downloads_count:desc", Limit: 10})
+	}
+	require.NoError(t, facetCounts(breaker))
+
+	require.NoError(t, err)
+	assert.Len(t, documents, 1)
+	assert.Equal(t, 2, client.calls)
+}
+
+func TestBreaker_CallerHangsUp(t *testing.T) {
+	// SCENARIO: The client whose request started a query hangs up before search answers, another is waiting on it.
+	// EXPECT: The first gets context.Canceled straight away, the query carries on uncanceled for the other and its
+	// answer counts towards the breaker as usual.
+
+	client := slowClient(nil)
+	breaker, _ := newTestBreaker(client)
+	joined := waitingOn(breaker, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := breaker.FacetCounts(ctx, ListingsCollection, "categories")
+		first <- err
+	}()
+	<-client.started
+
+	second := make(chan error, 1)
+	go func() { second <- facetCounts(breaker) }()
+	joined.Wait()
+
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled)
+
+	close(client.release)
+	require.NoError(t, <-second)
+	assert.NoError(t, client.ctxErr)
+	assert.Equal(t, 1, client.calls)
+	assert.Equal(t, StateClosed, breaker.State())
+}
+
+func TestBreaker_CanceledBeforeCall(t *testing.T) {
+	// SCENARIO: The client has already gone when a half-open breaker is asked for search.
+	// EXPECT: Nothing reaches search and the probe is left for the next caller, whose success closes the breaker.
+
+	client := &fakeClient{err: errors.New("connection refused")}
+	breaker, now := newTestBreaker(client)
+	for range 3 {
+		_ = facetCounts(breaker)
+	}
+	*now = now.Add(30 * time.Second)
+	client.err = nil
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := breaker.FacetCounts(ctx, ListingsCollection, "categories")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = breaker.Documents(ctx, ListingsCollection, DocumentQuery{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 3, client.calls)
+	assert.Equal(t, StateHalfOpen, breaker.State())
+
+	require.NoError(t, facetCounts(breaker))
+	assert.Equal(t, StateClosed, breaker.State())
+}
+
+func TestBreaker_Recovery(t *testing.T) {
+	// SCENARIO: The breaker opened during an outage, OpenFor later search is back.
+	// EXPECT: A single probe is let through while the others are still refused, and its success closes the breaker.
+
+	client := &fakeClient{err: errors.New("connection refused")}
+	breaker, now := newTestBreaker(client)
+	for range 3 {
+		_ = facetCounts(breaker)
+	}
+
+	*now = now.Add(29 * time.Second)
+	assert.ErrorIs(t, facetCounts(breaker), ErrCircuitOpen)
+
+	*now = now.Add(time.Second)
+	assert.Equal(t, StateHalfOpen, breaker.State())
+
+	client.err = nil
+	var concurrent error
+	client.during = func() {
+		_, concurrent = breaker.Documents(context.Background(), ListingsCollection, DocumentQuery{Limit: 10})
+	}
+	require.NoError(t, facetCounts(breaker))
+
+	assert.ErrorIs(t, concurrent, ErrCircuitOpen, "only the probe reaches search")
+	assert.Equal(t, 4, client.calls)
+	assert.Equal(t, StateClosed, breaker.State())
+	assert.Equal(t, float64(StateClosed), promtest.ToFloat64(breakerState))
+}
+
+func TestBreaker_FailedProbeReopens(t *testing.T) {
+	// SCENARIO: Search is still down when the probe goes through.
+	// EXPECT: The breaker opens again for another OpenFor without needing three more failures.
+
+	client := &fakeClient{err: errors.New("connection refused")}
+	breaker, now := newTestBreaker(client)
+	for range 3 {
+		_ = facetCounts(breaker)
+	}
+
+	*now = now.Add(30 * time.Second)
+	assert.EqualError(t, facetCounts(breaker), "connection refused")
+	assert.Equal(t, StateOpen, breaker.State())
+
+	*now = now.Add(29 * time.Second)
+	assert.ErrorIs(t, facetCounts(breaker), ErrCircuitOpen)
+	assert.Equal(t, 4, client.calls)
+}
@@ -0,0 +1,32 @@
+package search
+
+import (
+	"shared/typesensehttp"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	typesenseMetrics = typesensehttp.NewMetrics(prometheus.DefaultRegisterer, "gateway", []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2})
+
+	breakerState = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "gateway_search_breaker_state",
+		Help: "Search circuit breaker state: 0 closed, 1 half-open, 2 open.",
+	})
+
+	breakerTransitionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_search_breaker_transitions_total",
+		Help: "Search circuit breaker state changes, by the state moved to.",
+	}, []string{"state"})
+
+	breakerRejectedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "gateway_search_breaker_rejected_total",
+		Help: "Search calls refused without reaching Typesense because the circuit breaker was open.",
+	})
+
+	coalescedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "gateway_search_coalesced_total",
+		Help: "Search calls answered by an identical call that was already in flight.",
+	})
+)
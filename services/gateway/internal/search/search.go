@@ -0,0 +1,28 @@
+package search
+
+import "context"
+
+// ListingsCollection is the alias the listings worker indexes into
+const ListingsCollection = "listings"
+
+// FacetCounts is the result of a facet-only query
+type FacetCounts struct {
+	Total  int64            // Documents matching the query, not the sum of the counts
+	Counts map[string]int64 // Facet value -> number of documents with it
+}
+
+// DocumentQuery picks the top documents of a collection by filter and sort alone, without a text query
+type DocumentQuery struct {
+	FilterBy string // A filter_by built with searchfilter, empty matches every document
+	SortBy   string // e.g. "downloads_count:desc"
+	Limit    int
+}
+
+// Client is the read side of the search engine. The gateway never writes to the index, that's the
+// listings worker's job.
+type Client interface {
+	// FacetCounts counts every document by the values of field, without returning any hits
+	FacetCounts(ctx context.Context, collection string, field string) (*FacetCounts, error)
+	// Documents returns the documents matching query as the listings worker indexed them
+	Documents(ctx context.Context, collection string, query DocumentQuery) ([]map[string]any, error)
+}
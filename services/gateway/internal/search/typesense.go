@@ -0,0 +1,130 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"shared/typesensehttp"
+	"strings"
+	"time"
+
+	"github.com/typesense/typesense-go/typesense"
+	"github.com/typesense/typesense-go/typesense/api"
+	"github.com/typesense/typesense-go/typesense/api/pointer"
+)
+
+var _ Client = (*TypesenseClient)(nil)
+
+// maxFacetValues is more than the number of categories we'll ever have, Typesense defaults to 10
+const maxFacetValues = 250
+
+type TypesenseClient struct {
+	client *typesense.Client
+}
+
+func NewTypesenseClient(apiKey, url string, logger *slog.Logger) *TypesenseClient {
+	client := typesense.NewClient(
+		// Callers have a database fallback, fail fast instead of holding the request
+		typesense.WithAPIClient(newAPIClient(apiKey, url, 2*time.Second, logger)),
+	)
+	return &TypesenseClient{client: client}
+}
+
+// newAPIClient builds the client typesense.NewClient would with the instrumented transport underneath, the only way
+// typesense-go takes one. Its own circuit breaker is left out, Breaker sits above the client instead so its state can
+// be reported.
+func newAPIClient(apiKey, url string, timeout time.Duration, logger *slog.Logger) typesense.APIClientInterface {
+	httpClient := &http.Client{
+		Timeout:   timeout,
+		Transport: typesensehttp.NewTransport(nil, typesenseMetrics, logger),
+	}
+	// Only errors when an option does, neither of these can
+	apiClient, _ := api.NewClientWithResponses(url, api.WithAPIKey(apiKey), api.WithHTTPClient(httpClient))
+	return apiClient
+}
+
+func (t *TypesenseClient) FacetCounts(ctx context.Context, collection string, field string) (*FacetCounts, error) {
+	result, err := t.client.Collection(collection).Documents().Search(ctx, &api.SearchCollectionParams{
+		Q:              "*",
+		QueryBy:        field,
+		FacetBy:        pointer.String(field),
+		MaxFacetValues: pointer.Int(maxFacetValues),
+		PerPage:        pointer.Int(0),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("typesense facet query failed: %w", err)
+	}
+
+	return parseFacetCounts(result, field), nil
+}
+
+func (t *TypesenseClient) Documents(ctx context.Context, collection string, query DocumentQuery) ([]map[string]any, error) {
+	params := &api.SearchCollectionParams{
+		Q:       "*",
+		QueryBy: "title",
+		PerPage: pointer.Int(query.Limit),
+	}
+	if query.FilterBy != "" {
+		params.FilterBy = pointer.String(query.FilterBy)
+	}
+	if query.SortBy != "" {
+		params.SortBy = pointer.String(query.SortBy)
+	}
+
+	result, err := t.client.Collection(collection).Documents().Search(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("typesense document query failed: %w", err)
+	}
+	return parseDocuments(result), nil
+}
+
+// ProbeCollection runs an empty search against collection querying every field, which Typesense rejects if the
+// collection or any of the fields is missing. A search-only key is enough.
+func (t *TypesenseClient) ProbeCollection(ctx context.Context, collection string, fields []string) error {
+	_, err := t.client.Collection(collection).Documents().Search(ctx, &api.SearchCollectionParams{
+		Q:       "*",
+		QueryBy: strings.Join(fields, ","),
+		PerPage: pointer.Int(0),
+	})
+	return err
+}
+
+// parseFacetCounts pulls one field's counts out of a search result, missing pieces count as zero
+func parseFacetCounts(result *api.SearchResult, field string) *FacetCounts {
+	counts := &FacetCounts{Counts: map[string]int64{}}
+	if result.Found != nil {
+		counts.Total = int64(*result.Found)
+	}
+	if result.FacetCounts == nil {
+		return counts
+	}
+
+	for _, facet := range *result.FacetCounts {
+		if facet.FieldName == nil || *facet.FieldName != field || facet.Counts == nil {
+			continue
+		}
+		for _, c := range *facet.Counts {
+			if c.Value == nil || c.Count == nil {
+				continue
+			}
+			counts.Counts[*c.Value] = int64(*c.Count)
+		}
+	}
+	return counts
+}
+
+// parseDocuments pulls the documents out of a search result's hits, in order
+func parseDocuments(result *api.SearchResult) []map[string]any {
+	if result.Hits == nil {
+		return []map[string]any{}
+	}
+	documents := make([]map[string]any, 0, len(*result.Hits))
+	for _, hit := range *result.Hits {
+		if hit.Document == nil {
+			continue
+		}
+		documents = append(documents, *hit.Document)
+	}
+	return documents
+}
@@ -0,0 +1,122 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"gateway/internal/testutil"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/typesense/typesense-go/typesense/api"
+)
+
+func TestParseFacetCounts(t *testing.T) {
+	// A trimmed response to q=*&facet_by=categories&per_page=0
+	body := `{
+		"found": 1250,
+		"out_of": 1300,
+		"hits": [],
+		"facet_counts": [
+			{"field_name": "license", "counts": [{"count": 900, "value": "MIT"}]},
+			{"field_name": "categories", "counts": [
+				{"count": 1200, "highlighted": "functional", "value": "functional"},
+				{"count": 45, "highlighted": "artistic", "value": "artistic"},
+				{"count": 3}
+			]}
+		]
+	}`
+
+	var result api.SearchResult
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+
+	counts := parseFacetCounts(&result, "categories")
+
+	assert.Equal(t, int64(1250), counts.Total)
+	assert.Equal(t, map[string]int64{"functional": 1200, "artistic": 45}, counts.Counts)
+}
+
+func TestParseFacetCounts_NoFacets(t *testing.T) {
+	var result api.SearchResult
+	require.NoError(t, json.Unmarshal([]byte(`{"found": 0}`), &result))
+
+	counts := parseFacetCounts(&result, "categories")
+
+	assert.Equal(t, &FacetCounts{Counts: map[string]int64{}}, counts)
+}
+
+func TestParseDocuments(t *testing.T) {
+	body := `{
+		"found": 3,
+		"hits": [
+			{"document": {"id": "b", "downloads_count": 40}},
+			{"highlights": []},
+			{"document": {"id": "a", "downloads_count": 12}}
+		]
+	}`
+
+	var result api.SearchResult
+	require.NoError(t, json.Unmarshal([]byte(body), &result))
+
+	documents := parseDocuments(&result)
+
+	require.Len(t, documents, 2)
+	assert.Equal(t, "b", documents[0]["id"])
+	assert.Equal(t, "a", documents[1]["id"])
+}
+
+func TestParseDocuments_NoHits(t *testing.T) {
+	var result api.SearchResult
+	require.NoError(t, json.Unmarshal([]byte(`{"found": 0}`), &result))
+
+	assert.Equal(t, []map[string]any{}, parseDocuments(&result))
+}
+
+// observedCount reads how many values a histogram series has seen
+func observedCount(t *testing.T, operation string) uint64 {
+	t.Helper()
+
+	var metric dto.Metric
+	require.NoError(t, typesenseMetrics.RequestDuration.WithLabelValues(operation).(prometheus.Histogram).Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestTypesenseClient_RecordsMetrics(t *testing.T) {
+	// SCENARIO: Typesense is still loading on the first attempt, then answers the facet query.
+	// EXPECT: The search is a GET, so it is retried once, observed once in the histogram and counted under the class
+	// of the final response.
+
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/collections/listings/documents/search", r.URL.Path)
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"found": 2, "hits": [], "facet_counts": [{"field_name": "categories", "counts": [{"count": 2, "value": "Art"}]}]}`))
+	}))
+	defer server.Close()
+
+	const operation = "documents.search"
+	beforeObserved := observedCount(t, operation)
+	beforeRetries := promtest.ToFloat64(typesenseMetrics.Retries.WithLabelValues(operation))
+	beforeOK := promtest.ToFloat64(typesenseMetrics.Requests.WithLabelValues(operation, "2xx"))
+
+	client := NewTypesenseClient("search-key", server.URL, testutil.NewTestLogger())
+	counts, err := client.FacetCounts(context.Background(), "listings", "categories")
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), counts.Counts["Art"])
+	assert.Equal(t, int32(2), attempts.Load())
+	assert.Equal(t, beforeObserved+1, observedCount(t, operation))
+	assert.Equal(t, beforeRetries+1, promtest.ToFloat64(typesenseMetrics.Retries.WithLabelValues(operation)))
+	assert.Equal(t, beforeOK+1, promtest.ToFloat64(typesenseMetrics.Requests.WithLabelValues(operation, "2xx")))
+}
@@ -0,0 +1,127 @@
+// Package searchfilter builds Typesense filter_by strings without concatenating user input into filter syntax.
+package searchfilter
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Field is a filterable field of the listings collection. Only the constants below are filtered on, so a field name
+// never comes from a request.
+type Field string
+
+const (
+	FieldCategories           Field = "categories"
+	FieldRecommendedMaterials Field = "recommended_materials"
+	FieldFileFormats          Field = "file_formats"
+	FieldSellerID             Field = "seller_id"
+	FieldParentListingID      Field = "parent_listing_id"
+	FieldIsNSFW               Field = "is_nsfw"
+	FieldIsPhysical           Field = "is_physical"
+	FieldIsMulticolor         Field = "is_multicolor"
+	FieldSellerOnVacation     Field = "seller_on_vacation"
+	FieldSalePrice            Field = "sale_price"
+	FieldDimXMM               Field = "dim_x_mm"
+	FieldDimYMM               Field = "dim_y_mm"
+	FieldDimZMM               Field = "dim_z_mm"
+	FieldNozzleDiameterMM     Field = "nozzle_diameter_mm"
+	FieldLanguage             Field = "language"
+)
+
+// Value is anything a filter compares a field with
+type Value interface {
+	~string | ~bool | ~int | ~int32 | ~int64 | ~float64
+}
+
+// Number is a Value that can be a range bound
+type Number interface {
+	~int | ~int32 | ~int64 | ~float64
+}
+
+// Filter is one clause, or several joined by And. The zero Filter matches every document.
+type Filter struct {
+	clauses []string
+}
+
+// Eq matches documents whose field is value, or contains it for an array field
+func Eq[T Value](field Field, value T) Filter {
+	return clause(field, ":=", render(value))
+}
+
+// NotEq matches documents whose field isn't value. Documents without the field match too.
+func NotEq[T Value](field Field, value T) Filter {
+	return clause(field, ":!=", render(value))
+}
+
+// In matches documents whose field is any of values. No values is no constraint, not a filter nothing passes.
+func In[T Value](field Field, values ...T) Filter {
+	if len(values) == 0 {
+		return Filter{}
+	}
+	rendered := make([]string, len(values))
+	for i, v := range values {
+		rendered[i] = render(v)
+	}
+	return clause(field, ":=", "["+strings.Join(rendered, ",")+"]")
+}
+
+// Range matches documents with min <= field <= max
+func Range[T Number](field Field, min, max T) Filter {
+	return clause(field, ":", "["+render(min)+".."+render(max)+"]")
+}
+
+// AtLeast matches documents with field >= min
+func AtLeast[T Number](field Field, min T) Filter {
+	return clause(field, ":>=", render(min))
+}
+
+// AtMost matches documents with field <= max
+func AtMost[T Number](field Field, max T) Filter {
+	return clause(field, ":<=", render(max))
+}
+
+// And matches documents every filter matches, zero Filters are skipped
+func And(filters ...Filter) Filter {
+	var combined Filter
+	for _, f := range filters {
+		combined.clauses = append(combined.clauses, f.clauses...)
+	}
+	return combined
+}
+
+// IsZero is true for a filter that matches everything, which Typesense wants as no filter_by at all
+func (f Filter) IsZero() bool {
+	return len(f.clauses) == 0
+}
+
+// String renders filter_by, "" for the zero Filter
+func (f Filter) String() string {
+	return strings.Join(f.clauses, " && ")
+}
+
+func clause(field Field, op, value string) Filter {
+	return Filter{clauses: []string{string(field) + op + value}}
+}
+
+// render formats a value the way Typesense parses it. Strings are always backtick quoted, so commas, && and
+// operators inside them are literal.
+func render[T Value](value T) string {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String:
+		return quote(v.String())
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	default:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	}
+}
+
+// quote wraps s in backticks. Typesense has no escape for a backtick inside a quoted value, so those are dropped,
+// which at worst makes the filter miss a value no category, material or ID contains anyway.
+func quote(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "") + "`"
+}
@@ -0,0 +1,98 @@
+package searchfilter
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var hostile = []string{
+	"functional && is_nsfw:=true",
+	"a`b",
+	"``",
+	"`&& seller_id:!=x`",
+	"x:=[y,z]",
+	"price:>0 || true",
+	"a,b",
+	"[1..2]",
+	"(",
+	")",
+	"\\`",
+	"Ümlaut 日本語 🚀",
+	"​‮",
+	"line\nbreak",
+	"",
+}
+
+// clausePattern is field, operator, then a single quoted or plain value, or a bracketed list or range of them
+var clausePattern = regexp.MustCompile("^[a-z_]+(:=|:!=|:>=|:<=|:)(`[^`]*`|[^`\\[\\] &]+|\\[((`[^`]*`|[^`\\[\\],&]+)(,|\\.\\.)?)+\\])$")
+
+// clauses splits filter_by on the && outside backticks, failing on an unterminated quote
+func clauses(t *testing.T, filter string) []string {
+	t.Helper()
+	var out []string
+	quoted, start := false, 0
+	for i := 0; i < len(filter); i++ {
+		switch {
+		case filter[i] == '`':
+			quoted = !quoted
+		case !quoted && strings.HasPrefix(filter[i:], " && "):
+			out = append(out, filter[start:i])
+			start = i + 4
+			i += 3
+		}
+	}
+	require.False(t, quoted, "unterminated backtick in %q", filter)
+	return append(out, filter[start:])
+}
+
+func TestFilter_HostileValuesStayWellFormed(t *testing.T) {
+	// SCENARIO: Every builder gets values written to break out of their clause.
+	// EXPECT: Each renders exactly one well-formed clause, joined with the others by the only top-level &&.
+
+	for _, v := range hostile {
+		filter := And(
+			Eq(FieldSellerID, v),
+			NotEq(FieldParentListingID, v),
+			In(FieldCategories, v, "functional", v),
+			AtMost(FieldDimXMM, 200.5),
+		)
+
+		got := clauses(t, filter.String())
+		require.Len(t, got, 4, "%q rendered %q", v, filter.String())
+		for _, c := range got {
+			assert.Regexp(t, clausePattern, c, "value %q", v)
+		}
+		assert.Equal(t, "seller_id:=`"+strings.ReplaceAll(v, "`", "")+"`", got[0], "backticks inside a value are dropped")
+	}
+}
+
+func TestFilter_Render(t *testing.T) {
+	type status string
+
+	tests := map[string]struct {
+		filter Filter
+		want   string
+	}{
+		"string":           {Eq(FieldSellerID, "a0eebc99"), "seller_id:=`a0eebc99`"},
+		"named string":     {Eq(FieldCategories, status("functional")), "categories:=`functional`"},
+		"bool":             {NotEq(FieldSellerOnVacation, true), "seller_on_vacation:!=true"},
+		"in":               {In(FieldCategories, "functional", "a, b"), "categories:=[`functional`,`a, b`]"},
+		"in nothing":       {In[string](FieldCategories), ""},
+		"int range":        {Range(FieldSalePrice, int64(100), int64(2500)), "sale_price:[100..2500]"},
+		"float bounds":     {And(AtLeast(FieldNozzleDiameterMM, 0.4), AtMost(FieldDimZMM, 250.0)), "nozzle_diameter_mm:>=0.4 && dim_z_mm:<=250"},
+		"zero and skipped": {And(Filter{}, Eq(FieldIsNSFW, false), And()), "is_nsfw:=false"},
+		"nested":           {And(And(Eq(FieldIsPhysical, true)), Eq(FieldIsMulticolor, true)), "is_physical:=true && is_multicolor:=true"},
+		"unicode":          {Eq(FieldRecommendedMaterials, "PLA+ 日本"), "recommended_materials:=`PLA+ 日本`"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.String())
+			assert.Equal(t, tt.want == "", tt.filter.IsZero())
+		})
+	}
+}
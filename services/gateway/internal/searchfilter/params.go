@@ -0,0 +1,210 @@
+package searchfilter
+
+import (
+	"fmt"
+	"gateway/internal/errors"
+	"gateway/internal/language"
+	"math"
+	"net/url"
+	"regexp"
+	"shared/materials"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MaxValuesPerParam caps repeated params like ?category=a&category=b, a longer list is rejected
+const MaxValuesPerParam = 20
+
+// AllLanguages as lang searches every language, rather than the one Accept-Language defaults it to
+const AllLanguages = "all"
+
+// languageCode is an ISO 639 code, the only thing lang takes besides AllLanguages
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// param is a query param and the field it filters, in the order clauses are rendered
+type param struct {
+	name  string
+	field Field
+}
+
+// Parse turns the filter params clients may send into a Filter. Anything else in the query is left to the caller
+// (q, page, sort). category, material and format can be repeated and match any of the values. max_x_mm, max_y_mm
+// and max_z_mm are a printer's build volume and match listings that fit in it. lang matches listings in that language
+// and ones whose language couldn't be told, see DefaultLanguage.
+func Parse(query url.Values) (Filter, *errors.AppError) {
+	var filters []Filter
+
+	for _, p := range []param{{"category", FieldCategories}, {"material", FieldRecommendedMaterials}, {"format", FieldFileFormats}} {
+		values, appErr := list(query, p.name)
+		if appErr != nil {
+			return Filter{}, appErr
+		}
+		if p.field == FieldRecommendedMaterials {
+			// Listings are indexed under the canonical names, ?material=pla finds PLA
+			values = materials.Normalize(values)
+		}
+		filters = append(filters, In(p.field, values...))
+	}
+
+	if v := strings.TrimSpace(query.Get("seller_id")); v != "" {
+		filters = append(filters, Eq(FieldSellerID, v))
+	}
+
+	for _, p := range []param{{"nsfw", FieldIsNSFW}, {"physical", FieldIsPhysical}, {"multicolor", FieldIsMulticolor}} {
+		v := query.Get(p.name)
+		if v == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Filter{}, invalid(p.name, v)
+		}
+		filters = append(filters, Eq(p.field, b))
+	}
+
+	minPrice, appErr := integer(query, "price_min")
+	if appErr != nil {
+		return Filter{}, appErr
+	}
+	maxPrice, appErr := integer(query, "price_max")
+	if appErr != nil {
+		return Filter{}, appErr
+	}
+	switch {
+	case minPrice != nil && maxPrice != nil:
+		if *minPrice > *maxPrice {
+			return Filter{}, invalid("price_min", query.Get("price_min"))
+		}
+		filters = append(filters, Range(FieldSalePrice, *minPrice, *maxPrice))
+	case minPrice != nil:
+		filters = append(filters, AtLeast(FieldSalePrice, *minPrice))
+	case maxPrice != nil:
+		filters = append(filters, AtMost(FieldSalePrice, *maxPrice))
+	}
+
+	// Each side checked on its own, rotating a model to fit is up to the buyer
+	for _, p := range []param{{"max_x_mm", FieldDimXMM}, {"max_y_mm", FieldDimYMM}, {"max_z_mm", FieldDimZMM}} {
+		mm, appErr := measurement(query, p.name)
+		if appErr != nil {
+			return Filter{}, appErr
+		}
+		if mm != nil {
+			filters = append(filters, AtMost(p.field, *mm))
+		}
+	}
+
+	nozzle, appErr := measurement(query, "nozzle_mm")
+	if appErr != nil {
+		return Filter{}, appErr
+	}
+	if nozzle != nil {
+		filters = append(filters, Eq(FieldNozzleDiameterMM, *nozzle))
+	}
+
+	if v := strings.ToLower(strings.TrimSpace(query.Get("lang"))); v != "" && v != AllLanguages {
+		if !languageCode.MatchString(v) {
+			return Filter{}, invalid("lang", v)
+		}
+		filters = append(filters, Language(v))
+	}
+
+	return And(filters...), nil
+}
+
+// Language matches listings in code, and the ones whose language couldn't be told, which would otherwise drop out of
+// every language's results
+func Language(code string) Filter {
+	return In(FieldLanguage, code, language.Undetermined)
+}
+
+// DefaultLanguage sets lang to the client's preferred language from its Accept-Language header when the query has no
+// lang. A client that prefers none of the languages we detect searches them all, as does one sending lang=all.
+func DefaultLanguage(query url.Values, acceptLanguage string) url.Values {
+	if strings.TrimSpace(query.Get("lang")) != "" {
+		return query
+	}
+	code, ok := language.FromAcceptLanguage(acceptLanguage)
+	if !ok {
+		return query
+	}
+	withLang := url.Values{}
+	for name, values := range query {
+		withLang[name] = values
+	}
+	withLang.Set("lang", code)
+	return withLang
+}
+
+// Normalize keeps only the params Parse reads, trimmed and without blanks. Repeated params are sorted and
+// deduplicated and the rest keep their first value, so two queries filtering the same way normalize the same.
+// Values aren't validated, that's left to Parse.
+func Normalize(query url.Values) url.Values {
+	normalized := url.Values{}
+	for _, name := range []string{"category", "material", "format"} {
+		var values []string
+		for _, v := range query[name] {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		if len(values) > 0 {
+			slices.Sort(values)
+			normalized[name] = slices.Compact(values)
+		}
+	}
+	for _, name := range []string{"seller_id", "nsfw", "physical", "multicolor", "price_min", "price_max", "max_x_mm", "max_y_mm", "max_z_mm", "nozzle_mm", "lang"} {
+		if v := strings.TrimSpace(query.Get(name)); v != "" {
+			normalized.Set(name, v)
+		}
+	}
+	if v := normalized.Get("lang"); v != "" {
+		normalized.Set("lang", strings.ToLower(v))
+	}
+	return normalized
+}
+
+// list is every non-blank value of a repeated param
+func list(query url.Values, param string) ([]string, *errors.AppError) {
+	var values []string
+	for _, v := range query[param] {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	if len(values) > MaxValuesPerParam {
+		return nil, invalid(param, fmt.Sprintf("%d values", len(values)))
+	}
+	return values, nil
+}
+
+func integer(query url.Values, param string) (*int64, *errors.AppError) {
+	v := query.Get(param)
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return nil, invalid(param, v)
+	}
+	return &n, nil
+}
+
+func measurement(query url.Values, param string) (*float64, *errors.AppError) {
+	v := query.Get(param)
+	if v == "" {
+		return nil, nil
+	}
+	mm, err := strconv.ParseFloat(v, 64)
+	if err != nil || mm <= 0 || math.IsInf(mm, 0) || math.IsNaN(mm) {
+		return nil, invalid(param, v)
+	}
+	return &mm, nil
+}
+
+func invalid(param, value string) *errors.AppError {
+	return errors.New(errors.ErrInvalidInput, fmt.Sprintf("'%s' isn't a valid %s", value, param), nil).
+		WithReason(errors.ReasonSearchFilterInvalid).
+		WithParam("field", param).
+		WithParam("value", value)
+}
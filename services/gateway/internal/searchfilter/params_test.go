@@ -0,0 +1,131 @@
+package searchfilter
+
+import (
+	"gateway/internal/errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		query string
+		want  string
+	}{
+		"nothing":       {"q=benchy&page=2", ""},
+		"categories":    {"category=functional&category=artistic&category=+", "categories:=[`functional`,`artistic`]"},
+		"injected":      {"category=" + url.QueryEscape("x] && is_nsfw:=true && categories:=[y"), "categories:=[`x] && is_nsfw:=true && categories:=[y`]"},
+		"backticks":     {"seller_id=" + url.QueryEscape("`abc` || true"), "seller_id:=`abc || true`"},
+		"flags":         {"nsfw=false&physical=1&multicolor=true", "is_nsfw:=false && is_physical:=true && is_multicolor:=true"},
+		"price range":   {"price_min=100&price_max=2500", "sale_price:[100..2500]"},
+		"price floor":   {"price_min=0", "sale_price:>=0"},
+		"printer":       {"max_x_mm=256&max_y_mm=256&max_z_mm=256&nozzle_mm=0.4", "dim_x_mm:<=256 && dim_y_mm:<=256 && dim_z_mm:<=256 && nozzle_diameter_mm:=0.4"},
+		"ordered":       {"nozzle_mm=0.6&format=stl&material=PETG&category=functional", "categories:=[`functional`] && recommended_materials:=[`PETG`] && file_formats:=[`stl`] && nozzle_diameter_mm:=0.6"},
+		"unknown param": {"sort_by=price&is_nsfw=true", ""},
+		"language":      {"lang=DE", "language:=[`de`,`und`]"},
+		"all languages": {"lang=all&format=stl", "file_formats:=[`stl`]"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+
+			filter, appErr := Parse(query)
+
+			require.Nil(t, appErr)
+			assert.Equal(t, tt.want, filter.String())
+			for _, c := range clauses(t, filter.String()) {
+				if c != "" {
+					assert.Regexp(t, clausePattern, c)
+				}
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := map[string]struct {
+		query string
+		field string
+	}{
+		"not a bool":        {"nsfw=maybe", "nsfw"},
+		"negative price":    {"price_max=-1", "price_max"},
+		"price not a num":   {"price_min=1e3", "price_min"},
+		"min above max":     {"price_min=500&price_max=100", "price_min"},
+		"zero build volume": {"max_z_mm=0", "max_z_mm"},
+		"not finite":        {"max_x_mm=Inf", "max_x_mm"},
+		"NaN nozzle":        {"nozzle_mm=NaN", "nozzle_mm"},
+		"too many values":   {"category=" + strings.Repeat("a&category=", MaxValuesPerParam) + "a", "category"},
+		"not a language":    {"lang=deutsch", "lang"},
+		"language injected": {"lang=" + url.QueryEscape("de`] || true"), "lang"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+
+			_, appErr := Parse(query)
+
+			require.NotNil(t, appErr)
+			assert.Equal(t, errors.ErrInvalidInput, appErr.Code)
+			assert.Equal(t, errors.ReasonSearchFilterInvalid, appErr.Reason)
+			assert.Equal(t, tt.field, appErr.Params["field"])
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]struct {
+		query string
+		want  string
+	}{
+		"empty":            {"", ""},
+		"unknown dropped":  {"q=benchy&page=2&sort_by=price", ""},
+		"sorted and dedup": {"category=toys&category=+functional+&category=toys&category=", "category=functional&category=toys"},
+		"first single":     {"nsfw=+false+&nsfw=true&price_max=", "nsfw=false"},
+		"same filters":     {"material=PLA&material=PETG&max_z_mm=220", "material=PETG&material=PLA&max_z_mm=220"},
+		"language":         {"lang=+DE+", "lang=de"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, Normalize(query).Encode())
+		})
+	}
+}
+
+func TestDefaultLanguage(t *testing.T) {
+	// SCENARIO: Searches from browsers set to different languages, some picking one themselves.
+	// EXPECT: lang defaults to the browser's language when it's one we detect, and a lang the client sent wins.
+
+	tests := map[string]struct {
+		query          string
+		acceptLanguage string
+		want           string
+	}{
+		"from the header":       {"category=toys", "de-DE,de;q=0.9,en;q=0.8", "category=toys&lang=de"},
+		"client picked one":     {"lang=fr", "de-DE", "lang=fr"},
+		"client wants all":      {"lang=all", "de-DE", "lang=all"},
+		"language we don't tag": {"category=toys", "sv-SE", "category=toys"},
+		"no header":             {"category=toys", "", "category=toys"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+
+			before := query.Encode()
+
+			assert.Equal(t, tt.want, DefaultLanguage(query, tt.acceptLanguage).Encode())
+			assert.Equal(t, before, query.Encode(), "The caller's query isn't changed")
+		})
+	}
+}
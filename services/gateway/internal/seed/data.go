@@ -0,0 +1,201 @@
+package seed
+
+import (
+	"encoding/json"
+	"fmt"
+	"gateway/internal/handlers/listings"
+	"image/color"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Seller is a seeded seller. Its ID is derived from its position, so a re-run upserts the same sellers.
+type Seller struct {
+	ID       pgtype.UUID
+	Name     string
+	Username string
+	Country  string
+	Verified bool
+}
+
+// Listing is everything the seed writes for one listing. Listing k is the same on every run, whoever else is in the
+// database, so topping up after a wipe gives the same data back.
+type Listing struct {
+	Index    int
+	Seller   Seller
+	Params   repo.CreateListingParams // Without ParentListingID, which is only known once the parent exists
+	RemixOf  int                      // Index of the listing this remixes, -1 for none
+	Sale     *Sale
+	Counters repo.SetListingCountersParams // Without the ID
+	Color    color.RGBA                    // Of the placeholder image
+	Size     listings.ListingDimensionsJSON
+}
+
+// Sale is a seeded listing's sale, Ends is from the time of the run
+type Sale struct {
+	PriceMinUnit int64
+	Name         string
+	Ends         time.Duration
+}
+
+// namespace keeps seeded IDs apart from anything else derived with uuid.NewSHA1
+var namespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://github.com/MrJoshE/printing-marketplace/seed"))
+
+var (
+	sellerNames = []string{"Layer Lines", "Nozzle & Nook", "Brim Works", "Print Goblin", "Infill Studio", "Raft Co", "Spool Shed", "Bridging Gap"}
+	countries   = []string{"GB", "US", "DE", "NL", "CA", "AU"}
+
+	adjectives = []string{"Modular", "Parametric", "Low-Poly", "Articulated", "Stackable", "Snap-Fit", "Hexagonal", "Minimal", "Rugged", "Twisted"}
+	nouns      = map[string][]string{
+		"functional":  {"Cable Organizer", "Headphone Stand", "Drawer Divider", "Wall Hook", "Phone Dock", "Tool Holder"},
+		"artistic":    {"Dragon", "Vase", "Planter", "Lithophane Frame", "Knight Miniature", "Desk Sculpture"},
+		"prototypes":  {"Gear Train Test", "Hinge Prototype", "Enclosure Mockup", "Bracket Concept", "Latch Study"},
+		"spare-parts": {"Dishwasher Wheel", "Vacuum Clip", "Knob Replacement", "Battery Cover", "Fan Duct", "Spool Holder"},
+	}
+	categories = []string{"functional", "artistic", "prototypes", "spare-parts"}
+
+	licenses   = []string{"CC-BY-4.0", "CC-BY-NC-4.0", "CC-BY-SA-4.0", "MIT", "Standard Digital File License"}
+	currencies = []string{"usd", "gbp"}
+	hardware   = []string{"M3 screws", "Heat-set inserts", "608 bearings", "Magnets 6x3mm", "Rubber feet"}
+	aiModels   = []string{"Meshy-4", "Tripo 2.0", "Shap-E"}
+
+	// Nozzle temperature is picked from the material's usual range
+	materialTemps = []struct {
+		name     string
+		min, max int32
+	}{
+		{"PLA", 195, 220}, {"PETG", 230, 250}, {"ABS", 240, 260}, {"TPU", 215, 235}, {"ASA", 240, 260},
+	}
+)
+
+// nozzleDiameter is 0.4mm, what everything is tested on
+var nozzleDiameter = func() pgtype.Numeric {
+	var n pgtype.Numeric
+	_ = n.Scan("0.40")
+	return n
+}()
+
+// Sellers is the first n seeded sellers, in order
+func Sellers(n int) []Seller {
+	sellers := make([]Seller, n)
+	for i := range sellers {
+		base := sellerNames[i%len(sellerNames)]
+		name := base
+		if round := i / len(sellerNames); round > 0 {
+			name = fmt.Sprintf("%s %d", base, round+1)
+		}
+		sellers[i] = Seller{
+			ID:       pgtype.UUID{Bytes: uuid.NewSHA1(namespace, []byte(fmt.Sprintf("seller/%d", i))), Valid: true},
+			Name:     name,
+			Username: strings.ReplaceAll(strings.ToLower(strings.ReplaceAll(name, "& ", "")), " ", "-"),
+			Country:  countries[i%len(countries)],
+			Verified: i%3 != 2,
+		}
+	}
+	return sellers
+}
+
+// NewListing is listing k, by one of sellers. Every edge case the UI has a state for comes up in the first dozen:
+//   - free: k%6 == 1
+//   - on sale: k%6 == 2
+//   - remix of listing k/2: k%5 == 3
+//   - digital only, no size or weight: k%7 == 5
+//   - multicolor: k%4 == 0
+//   - NSFW: k%10 == 7
+//   - AI generated: k%9 == 4
+//   - needs hardware: k%8 == 6
+func NewListing(k int, sellers []Seller) Listing {
+	r := rand.New(rand.NewPCG(uint64(k), 0x5eed))
+	seller := sellers[k%len(sellers)]
+
+	category := categories[k%len(categories)]
+	cats := []string{category}
+	if r.IntN(3) == 0 {
+		if other := categories[r.IntN(len(categories))]; other != category {
+			cats = append(cats, other)
+		}
+	}
+	title := fmt.Sprintf("%s %s", adjectives[r.IntN(len(adjectives))], pick(r, nouns[category]))
+	material := materialTemps[r.IntN(len(materialTemps))]
+
+	listing := Listing{
+		Index:   k,
+		Seller:  seller,
+		RemixOf: -1,
+		Color:   color.RGBA{R: uint8(64 + r.IntN(192)), G: uint8(64 + r.IntN(192)), B: uint8(64 + r.IntN(192)), A: 255},
+		Size:    listings.ListingDimensionsJSON{Width: 20 + r.IntN(230), Depth: 20 + r.IntN(230), Height: 10 + r.IntN(200)},
+		Params: repo.CreateListingParams{
+			SellerID:               seller.ID,
+			SellerName:             seller.Name,
+			SellerUsername:         seller.Username,
+			SellerVerified:         seller.Verified,
+			Title:                  title,
+			Description:            pgtype.Text{String: fmt.Sprintf("A %s print, tested on a 0.4mm nozzle in %s. Seed data for local development.", strings.ToLower(title), material.name), Valid: true},
+			PriceMinUnit:           int64(1+r.IntN(50))*100 - 1, // 99 to 4999
+			Currency:               pick(r, currencies),
+			Categories:             cats,
+			License:                pick(r, licenses),
+			Status:                 repo.NullListingStatus{ListingStatus: repo.ListingStatusACTIVE, Valid: true},
+			IsRemixingAllowed:      r.IntN(4) != 0,
+			IsPhysical:             true,
+			TotalWeightGrams:       pgtype.Int4{Int32: int32(10 + r.IntN(790)), Valid: true},
+			IsAssemblyRequired:     r.IntN(3) == 0,
+			IsMulticolor:           k%4 == 0,
+			RecommendedNozzleTempC: pgtype.Int4{Int32: material.min + r.Int32N(material.max-material.min+1), Valid: true},
+			RecommendedMaterials:   []string{material.name},
+			NozzleDiameterMm:       nozzleDiameter,
+		},
+		Counters: repo.SetListingCountersParams{
+			LikesCount:     r.Int32N(300),
+			DownloadsCount: r.Int32N(1500),
+			ViewsCount:     r.Int32N(20000),
+		},
+	}
+
+	if k%6 == 1 {
+		listing.Params.PriceMinUnit = 0
+	}
+	if k%6 == 2 {
+		listing.Sale = &Sale{
+			PriceMinUnit: listing.Params.PriceMinUnit * 7 / 10,
+			Name:         "Seed sale",
+			Ends:         time.Duration(1+r.IntN(7)) * 24 * time.Hour,
+		}
+	}
+	if k%5 == 3 {
+		listing.RemixOf = k / 2
+		listing.Params.Title += " (Remix)"
+	}
+	if k%7 == 5 {
+		listing.Params.IsPhysical = false
+		listing.Params.TotalWeightGrams = pgtype.Int4{}
+		listing.Params.IsAssemblyRequired = false
+	}
+	if k%10 == 7 {
+		listing.Params.IsNsfw = true
+	}
+	if k%9 == 4 {
+		listing.Params.IsAiGenerated = true
+		listing.Params.AiModelName = pgtype.Text{String: pick(r, aiModels), Valid: true}
+	}
+	if k%8 == 6 {
+		listing.Params.IsHardwareRequired = true
+		listing.Params.HardwareRequired = []string{pick(r, hardware)}
+	}
+
+	if listing.Params.IsPhysical {
+		// Marshalling three ints can't fail
+		listing.Params.DimensionsMm, _ = json.Marshal(listing.Size)
+	}
+	return listing
+}
+
+func pick[T any](r *rand.Rand, from []T) T {
+	return from[r.IntN(len(from))]
+}
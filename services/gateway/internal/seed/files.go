@@ -0,0 +1,85 @@
+package seed
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gateway/internal/handlers/listings"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+const (
+	imageWidth  = 800
+	imageHeight = 600
+)
+
+// placeholderImage is a flat colour with diagonal stripes, enough to tell seeded listings apart in a grid
+func placeholderImage(c color.RGBA) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, imageWidth, imageHeight))
+	stripe := color.RGBA{R: c.R / 2, G: c.G / 2, B: c.B / 2, A: 255}
+	for y := range imageHeight {
+		for x := range imageWidth {
+			if (x+y)/40%2 == 0 {
+				img.SetRGBA(x, y, c)
+			} else {
+				img.SetRGBA(x, y, stripe)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode placeholder image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// placeholderModel is an ASCII STL of a box the listing's size, so the bounding box in its metadata is true
+func placeholderModel(size listings.ListingDimensionsJSON) []byte {
+	x, y, z := float64(size.Width), float64(size.Depth), float64(size.Height)
+	v := [8][3]float64{{0, 0, 0}, {x, 0, 0}, {x, y, 0}, {0, y, 0}, {0, 0, z}, {x, 0, z}, {x, y, z}, {0, y, z}}
+	// Two triangles per face, wound counter-clockwise seen from outside
+	faces := []struct {
+		normal [3]float64
+		tris   [2][3]int
+	}{
+		{[3]float64{0, 0, -1}, [2][3]int{{0, 2, 1}, {0, 3, 2}}},
+		{[3]float64{0, 0, 1}, [2][3]int{{4, 5, 6}, {4, 6, 7}}},
+		{[3]float64{0, -1, 0}, [2][3]int{{0, 1, 5}, {0, 5, 4}}},
+		{[3]float64{1, 0, 0}, [2][3]int{{1, 2, 6}, {1, 6, 5}}},
+		{[3]float64{0, 1, 0}, [2][3]int{{2, 3, 7}, {2, 7, 6}}},
+		{[3]float64{-1, 0, 0}, [2][3]int{{3, 0, 4}, {3, 4, 7}}},
+	}
+
+	var b strings.Builder
+	b.WriteString("solid seed\n")
+	for _, face := range faces {
+		for _, tri := range face.tris {
+			fmt.Fprintf(&b, "  facet normal %g %g %g\n    outer loop\n", face.normal[0], face.normal[1], face.normal[2])
+			for _, i := range tri {
+				fmt.Fprintf(&b, "      vertex %g %g %g\n", v[i][0], v[i][1], v[i][2])
+			}
+			b.WriteString("    endloop\n  endfacet\n")
+		}
+	}
+	b.WriteString("endsolid seed\n")
+	return []byte(b.String())
+}
+
+// modelMetadata is what the validation worker would have found in placeholderModel
+func modelMetadata(size listings.ListingDimensionsJSON, byteSize int64) ([]byte, error) {
+	triangles := int64(12)
+	watertight := true
+	return json.Marshal(listings.FileMetadata{
+		Format:        "model/stl",
+		TriangleCount: &triangles,
+		BoundingBox: &listings.BoundingBox{
+			Max: [3]float64{float64(size.Width), float64(size.Depth), float64(size.Height)},
+		},
+		ByteSize: &byteSize,
+		Scan:     &listings.FileScan{Watertight: &watertight, WindingConsistent: &watertight},
+	})
+}
@@ -0,0 +1,287 @@
+// Package seed fills a development database, bucket and search index with sellers and listings to click through.
+// Every seller it writes accepted termsVersion, so it can be topped up or wiped without touching anything else.
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gateway/internal/database/postgresql"
+	"gateway/internal/events"
+	"gateway/internal/language"
+	"gateway/internal/storage"
+	"log/slog"
+	"path"
+	"slices"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// ClientID is the client_id recorded against every seeded listing's creation
+	ClientID = "seed"
+
+	// termsVersion is what seeded sellers accepted, so the profile page doesn't ask them to accept again. No real seller
+	// accepts it, so it's how a re-run finds the listings that are already there.
+	termsVersion = "seed"
+)
+
+// Environments are the APP_ENV values the seed runs in. Anything else, unset included, may be a shared database.
+var Environments = []string{"development", "test"}
+
+// ErrEnvironment is returned by CheckEnvironment, the seed writes fake sellers and a wipe deletes listings
+var ErrEnvironment = errors.New("seed: only runs with APP_ENV=development or APP_ENV=test")
+
+// CheckEnvironment fails outside the Environments, run it before connecting to anything
+func CheckEnvironment(environment string) error {
+	if !slices.Contains(Environments, environment) {
+		return ErrEnvironment
+	}
+	return nil
+}
+
+// Uploader stores the placeholder files, see storage.MinioProvider
+type Uploader interface {
+	Put(ctx context.Context, bucket storage.Bucket, key string, data []byte, contentType string) error
+	Delete(ctx context.Context, bucket storage.Bucket, key string) error
+}
+
+// Indexer asks the listings worker to index a listing, events.EventHandler's RaiseListingIndexEvent
+type Indexer interface {
+	RaiseListingIndexEvent(evt events.ReIndexListingEvent) error
+}
+
+type Options struct {
+	Sellers  int
+	Listings int
+	Wipe     bool // Delete everything seeded before seeding again
+}
+
+// Report is what a run did
+type Report struct {
+	Wiped    int // Listings deleted by Wipe
+	Existing int // Seeded listings already there, kept as they are
+	Created  int
+	Indexed  int // Index events raised, for every seeded listing so an emptied index fills up again
+}
+
+type Seeder struct {
+	db      postgresql.DBPool
+	repo    *repo.Queries
+	files   Uploader
+	indexer Indexer
+	logger  *slog.Logger
+	now     func() time.Time
+}
+
+func New(db postgresql.DBPool, repo *repo.Queries, files Uploader, indexer Indexer, logger *slog.Logger) *Seeder {
+	return &Seeder{db: db, repo: repo, files: files, indexer: indexer, logger: logger, now: time.Now}
+}
+
+// Run tops the seeded data up to opts.Sellers sellers and opts.Listings listings. Listings already there are left
+// alone, so running it twice creates nothing the second time. It never removes listings beyond opts.Listings, use
+// Wipe for that.
+func (s *Seeder) Run(ctx context.Context, opts Options) (Report, error) {
+	var report Report
+	if opts.Sellers < 1 {
+		return report, fmt.Errorf("seed: at least one seller is needed, got %d", opts.Sellers)
+	}
+	if opts.Listings < 0 {
+		return report, fmt.Errorf("seed: listings can't be negative, got %d", opts.Listings)
+	}
+
+	if opts.Wipe {
+		wiped, err := s.wipe(ctx, opts.Sellers)
+		if err != nil {
+			return report, err
+		}
+		report.Wiped = wiped
+	}
+
+	sellers := Sellers(opts.Sellers)
+	for _, seller := range sellers {
+		if _, err := s.repo.UpsertSellerProfile(ctx, repo.UpsertSellerProfileParams{
+			UserID:               seller.ID,
+			DisplayName:          seller.Name,
+			Country:              seller.Country,
+			AcceptedTermsVersion: pgtype.Text{String: termsVersion, Valid: true},
+		}); err != nil {
+			return report, fmt.Errorf("failed to seed seller %s: %w", seller.Username, err)
+		}
+	}
+
+	ids, err := s.repo.GetListingIDsBySellerTerms(ctx, termsVersion)
+	if err != nil {
+		return report, fmt.Errorf("failed to read seeded listings: %w", err)
+	}
+	report.Existing = len(ids)
+	if len(ids) > opts.Listings {
+		s.logger.Info("More listings already seeded than asked for, leaving them", "seeded", len(ids), "listings", opts.Listings)
+	}
+
+	for k := len(ids); k < opts.Listings; k++ {
+		listing := NewListing(k, sellers)
+		if listing.RemixOf >= 0 {
+			listing.Params.ParentListingID = ids[listing.RemixOf]
+		}
+		id, err := s.createListing(ctx, listing)
+		if err != nil {
+			return report, fmt.Errorf("failed to seed listing %d: %w", k, err)
+		}
+		ids = append(ids, id)
+		report.Created++
+	}
+
+	for _, id := range ids {
+		// Search documents are keyed by the dashless ID
+		listingID := fmt.Sprintf("%x", id.Bytes)
+		if err := s.indexer.RaiseListingIndexEvent(events.ReIndexListingEvent{ListingID: listingID, TraceID: "seed"}); err != nil {
+			return report, fmt.Errorf("failed to index listing %s: %w", listingID, err)
+		}
+		report.Indexed++
+	}
+	return report, nil
+}
+
+// objectKeys are where a listing's placeholder files go, the validation worker's {seller}/{listing}/ layout with
+// "seed-{k}" standing in for the listing's ID, which isn't known until it's created
+func objectKeys(sellerID pgtype.UUID, k int) (image, model string) {
+	prefix := fmt.Sprintf("%s/seed-%d/", sellerID.String(), k)
+	return prefix + "image.png", prefix + "model.stl"
+}
+
+// objectKeysOf are the keys objectKeys gave a seeded listing, from its thumbnail
+func objectKeysOf(thumbnailPath string) (image, model string) {
+	return thumbnailPath, path.Dir(thumbnailPath) + "/model.stl"
+}
+
+// createListing writes one listing with its files as if they had passed validation, all or nothing
+func (s *Seeder) createListing(ctx context.Context, listing Listing) (pgtype.UUID, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return pgtype.UUID{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := s.repo.WithTx(tx)
+
+	imageKey, modelKey := objectKeys(listing.Params.SellerID, listing.Index)
+	params := listing.Params
+	params.ThumbnailPath = pgtype.Text{String: imageKey, Valid: true}
+	params.Language = language.Detect(params.Title + "\n" + params.Description.String)
+	created, err := qtx.CreateListing(ctx, params)
+	if err != nil {
+		return pgtype.UUID{}, fmt.Errorf("failed to create listing: %w", err)
+	}
+
+	img, err := placeholderImage(listing.Color)
+	if err != nil {
+		return pgtype.UUID{}, err
+	}
+	model := placeholderModel(listing.Size)
+	metadata, err := modelMetadata(listing.Size, int64(len(model)))
+	if err != nil {
+		return pgtype.UUID{}, fmt.Errorf("failed to build model metadata: %w", err)
+	}
+
+	// Uploaded before the commit, a failed run leaves orphaned objects rather than listings without files
+	if err := s.files.Put(ctx, storage.BucketPublic, imageKey, img, "image/png"); err != nil {
+		return pgtype.UUID{}, fmt.Errorf("failed to upload image: %w", err)
+	}
+	if err := s.files.Put(ctx, storage.BucketProduct, modelKey, model, "model/stl"); err != nil {
+		return pgtype.UUID{}, fmt.Errorf("failed to upload model: %w", err)
+	}
+
+	valid := repo.NullFileStatus{FileStatus: repo.FileStatusVALID, Valid: true}
+	if _, err := qtx.CreateListingFile(ctx, repo.CreateListingFileParams{
+		ListingID: created.ID,
+		FilePath:  imageKey,
+		FileType:  repo.FileTypeIMAGE,
+		FileSize:  pgtype.Int8{Int64: int64(len(img)), Valid: true},
+		Status:    valid,
+	}); err != nil {
+		return pgtype.UUID{}, fmt.Errorf("failed to create image file: %w", err)
+	}
+	if _, err := qtx.CreateListingFile(ctx, repo.CreateListingFileParams{
+		ListingID: created.ID,
+		FilePath:  modelKey,
+		FileType:  repo.FileTypeMODEL,
+		FileSize:  pgtype.Int8{Int64: int64(len(model)), Valid: true},
+		Metadata:  metadata,
+		Status:    valid,
+	}); err != nil {
+		return pgtype.UUID{}, fmt.Errorf("failed to create model file: %w", err)
+	}
+
+	if listing.Sale != nil {
+		if err := qtx.SetListingSale(ctx, repo.SetListingSaleParams{
+			ID:               created.ID,
+			SalePrice:        pgtype.Int8{Int64: listing.Sale.PriceMinUnit, Valid: true},
+			SaleName:         pgtype.Text{String: listing.Sale.Name, Valid: true},
+			SaleEndTimestamp: pgtype.Timestamptz{Time: s.now().Add(listing.Sale.Ends), Valid: true},
+		}); err != nil {
+			return pgtype.UUID{}, fmt.Errorf("failed to start sale: %w", err)
+		}
+	}
+	counters := listing.Counters
+	counters.ID = created.ID
+	if err := qtx.SetListingCounters(ctx, counters); err != nil {
+		return pgtype.UUID{}, fmt.Errorf("failed to set counters: %w", err)
+	}
+	if err := qtx.CreateListingStatusEvent(ctx, repo.CreateListingStatusEventParams{
+		ListingID: created.ID,
+		Actor:     repo.ListingStatusActorSYSTEM,
+		ToStatus:  repo.ListingStatusACTIVE,
+		Reason:    pgtype.Text{String: "Seed data", Valid: true},
+		ClientID:  pgtype.Text{String: ClientID, Valid: true},
+		TraceID:   pgtype.Text{String: fmt.Sprintf("seed-%d", listing.Index), Valid: true},
+	}); err != nil {
+		return pgtype.UUID{}, fmt.Errorf("failed to record status: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return pgtype.UUID{}, fmt.Errorf("failed to commit: %w", err)
+	}
+	return created.ID, nil
+}
+
+// wipe deletes every seeded listing, their files and the seeded sellers, then has the worker drop the listings from
+// search. Returns how many listings went.
+func (s *Seeder) wipe(ctx context.Context, sellers int) (int, error) {
+	deleted, err := s.repo.DeleteListingsBySellerTerms(ctx, termsVersion)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete seeded listings: %w", err)
+	}
+
+	sellerIDs := map[pgtype.UUID]bool{}
+	for _, seller := range Sellers(sellers) {
+		sellerIDs[seller.ID] = true
+	}
+	for _, listing := range deleted {
+		sellerIDs[listing.SellerID] = true
+
+		imageKey, modelKey := objectKeysOf(listing.ThumbnailPath.String)
+		for bucket, key := range map[storage.Bucket]string{storage.BucketPublic: imageKey, storage.BucketProduct: modelKey} {
+			if err := s.files.Delete(ctx, bucket, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				s.logger.Warn("Failed to delete seeded file", "bucket", bucket, "key", key, "error", err)
+			}
+		}
+		// The worker removes listings it can't find from the index
+		listingID := fmt.Sprintf("%x", listing.ID.Bytes)
+		if err := s.indexer.RaiseListingIndexEvent(events.ReIndexListingEvent{ListingID: listingID, TraceID: "seed"}); err != nil {
+			s.logger.Warn("Failed to remove seeded listing from search", "listing_id", listingID, "error", err)
+		}
+	}
+
+	ids := make([]pgtype.UUID, 0, len(sellerIDs))
+	for id := range sellerIDs {
+		ids = append(ids, id)
+	}
+	if err := s.repo.DeleteSellers(ctx, ids); err != nil {
+		return 0, fmt.Errorf("failed to delete seeded sellers: %w", err)
+	}
+	s.logger.Info("Wiped seed data", "listings", len(deleted), "sellers", len(ids))
+	return len(deleted), nil
+}
@@ -0,0 +1,246 @@
+package seed
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"gateway/internal/events"
+	"gateway/internal/storage"
+	"gateway/internal/testutil"
+	"gateway/internal/testutil/fixtures"
+	"image/png"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	repo "gateway/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUploader struct {
+	put     map[string][]byte
+	deleted []string
+}
+
+func (f *fakeUploader) Put(_ context.Context, bucket storage.Bucket, key string, data []byte, _ string) error {
+	if f.put == nil {
+		f.put = map[string][]byte{}
+	}
+	f.put[string(bucket)+"/"+key] = data
+	return nil
+}
+
+func (f *fakeUploader) Delete(_ context.Context, bucket storage.Bucket, key string) error {
+	f.deleted = append(f.deleted, string(bucket)+"/"+key)
+	return nil
+}
+
+type fakeIndexer struct {
+	listings []string
+}
+
+func (f *fakeIndexer) RaiseListingIndexEvent(evt events.ReIndexListingEvent) error {
+	f.listings = append(f.listings, evt.ListingID)
+	return nil
+}
+
+func newSeedTest(t *testing.T) (*Seeder, pgxmock.PgxPoolIface, *fakeUploader, *fakeIndexer) {
+	t.Helper()
+	mockPool := testutil.NewMockDB(t)
+	files, indexer := &fakeUploader{}, &fakeIndexer{}
+	seeder := New(mockPool, repo.New(mockPool), files, indexer, testutil.NewTestLogger())
+	seeder.now = func() time.Time { return time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC) }
+	return seeder, mockPool, files, indexer
+}
+
+// docID is the ID the listing's search document is keyed by
+func docID(id pgtype.UUID) string {
+	return fmt.Sprintf("%x", id.Bytes)
+}
+
+func expectSellers(mockPool pgxmock.PgxPoolIface, sellers []Seller) {
+	for _, seller := range sellers {
+		mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: UpsertSellerProfile`)).
+			WithArgs(seller.ID, seller.Name, seller.Country, pgtype.Text{String: termsVersion, Valid: true}).
+			WillReturnRows(pgxmock.NewRows(testutil.SellerCols).AddRow(
+				seller.ID, seller.Name, seller.Country, "NOT_STARTED", termsVersion, time.Now(), time.Now(), time.Now(), nil, nil, nil, false, nil,
+			))
+	}
+}
+
+func TestCheckEnvironment(t *testing.T) {
+	assert.NoError(t, CheckEnvironment("development"))
+	assert.NoError(t, CheckEnvironment("test"))
+	for _, environment := range []string{"production", "staging", "Development", ""} {
+		assert.ErrorIs(t, CheckEnvironment(environment), ErrEnvironment, environment)
+	}
+}
+
+func TestNewListing_Deterministic(t *testing.T) {
+	// SCENARIO: The seed is wiped and run again.
+	// EXPECT: Every listing comes back the same, sellers included.
+
+	assert.Equal(t, Sellers(5), Sellers(5))
+	for k := range 20 {
+		assert.Equal(t, NewListing(k, Sellers(3)), NewListing(k, Sellers(3)))
+	}
+}
+
+func TestNewListing_EdgeCasesInFirstDozen(t *testing.T) {
+	// SCENARIO: A developer seeds a dozen listings to work on the listing page.
+	// EXPECT: Free, sale, remix, digital, multicolor, NSFW, AI and hardware listings are all among them.
+
+	seen := map[string]bool{}
+	for k := range 12 {
+		listing := NewListing(k, Sellers(4))
+		assert.Equal(t, repo.ListingStatusACTIVE, listing.Params.Status.ListingStatus)
+		if listing.RemixOf >= 0 {
+			assert.Less(t, listing.RemixOf, k, "a remix's parent has to be seeded first")
+		}
+		if listing.Sale != nil {
+			assert.Less(t, listing.Sale.PriceMinUnit, listing.Params.PriceMinUnit)
+		}
+
+		seen["free"] = seen["free"] || listing.Params.PriceMinUnit == 0
+		seen["sale"] = seen["sale"] || listing.Sale != nil
+		seen["remix"] = seen["remix"] || listing.RemixOf >= 0
+		seen["digital"] = seen["digital"] || !listing.Params.IsPhysical
+		seen["multicolor"] = seen["multicolor"] || listing.Params.IsMulticolor
+		seen["nsfw"] = seen["nsfw"] || listing.Params.IsNsfw
+		seen["ai"] = seen["ai"] || listing.Params.IsAiGenerated
+		seen["hardware"] = seen["hardware"] || listing.Params.IsHardwareRequired
+	}
+	for _, edge := range []string{"free", "sale", "remix", "digital", "multicolor", "nsfw", "ai", "hardware"} {
+		assert.True(t, seen[edge], edge)
+	}
+}
+
+func TestPlaceholderFiles_Valid(t *testing.T) {
+	listing := NewListing(0, Sellers(1))
+
+	img, err := placeholderImage(listing.Color)
+	require.NoError(t, err)
+	decoded, err := png.Decode(bytes.NewReader(img))
+	require.NoError(t, err)
+	assert.Equal(t, imageWidth, decoded.Bounds().Dx())
+	assert.Equal(t, imageHeight, decoded.Bounds().Dy())
+
+	model := string(placeholderModel(listing.Size))
+	assert.True(t, strings.HasPrefix(model, "solid seed\n"))
+	assert.Equal(t, 12, strings.Count(model, "facet normal"))
+	assert.Equal(t, 36, strings.Count(model, "vertex "))
+}
+
+func TestRun_TopsUp(t *testing.T) {
+	// SCENARIO: Three listings were seeded earlier and the developer asks for four.
+	// EXPECT: Only listing 3 is created, a remix of listing 1, and all four are sent to be indexed.
+
+	seeder, mockPool, files, indexer := newSeedTest(t)
+	sellers := Sellers(1)
+	existing := []pgtype.UUID{
+		fixtures.UUID("00000000-0000-0000-0000-000000000001"),
+		fixtures.UUID("00000000-0000-0000-0000-000000000002"),
+		fixtures.UUID("00000000-0000-0000-0000-000000000003"),
+	}
+	createdID := "00000000-0000-0000-0000-000000000004"
+	imageKey, modelKey := objectKeys(sellers[0].ID, 3)
+
+	expectSellers(mockPool, sellers)
+	rows := pgxmock.NewRows([]string{"id"})
+	for _, id := range existing {
+		rows.AddRow(id)
+	}
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingIDsBySellerTerms`)).WithArgs(termsVersion).WillReturnRows(rows)
+
+	mockPool.ExpectBegin()
+	createArgs := make([]any, 29)
+	for i := range createArgs {
+		createArgs[i] = pgxmock.AnyArg()
+	}
+	createArgs[10] = pgtype.Text{String: imageKey, Valid: true}
+	createArgs[13] = existing[1]
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CreateListing`)).
+		WithArgs(createArgs...).
+		WillReturnRows(fixtures.ListingRows(fixtures.NewListing(fixtures.WithID(createdID), fixtures.WithRemixOf(existing[1].String()))))
+	for range 2 {
+		mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: CreateListingFile`)).
+			WithArgs(fixtures.UUID(createdID), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+				repo.NullFileStatus{FileStatus: repo.FileStatusVALID, Valid: true}, pgxmock.AnyArg()).
+			WillReturnRows(pgxmock.NewRows(testutil.ListingFileCols).AddRow(
+				fixtures.UUID("00000000-0000-0000-0000-0000000000f1"), fixtures.UUID(createdID), imageKey, repo.FileTypeIMAGE, int64(1024),
+				[]byte("{}"), "VALID", nil, false, nil,
+				time.Now(), time.Now(), nil,
+			))
+	}
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: SetListingCounters`)).
+		WithArgs(fixtures.UUID(createdID), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: CreateListingStatusEvent`)).
+		WithArgs(fixtures.UUID(createdID), repo.ListingStatusActorSYSTEM, pgxmock.AnyArg(), pgxmock.AnyArg(), repo.ListingStatusACTIVE, pgxmock.AnyArg(),
+			pgtype.Text{String: ClientID, Valid: true}, pgtype.Text{String: "seed-3", Valid: true}).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockPool.ExpectCommit()
+
+	report, err := seeder.Run(context.Background(), Options{Sellers: 1, Listings: 4})
+
+	require.NoError(t, err)
+	assert.Equal(t, Report{Existing: 3, Created: 1, Indexed: 4}, report)
+	assert.Contains(t, files.put, string(storage.BucketPublic)+"/"+imageKey)
+	assert.Contains(t, files.put, string(storage.BucketProduct)+"/"+modelKey)
+	// Search documents are keyed by the dashless ID
+	assert.Equal(t, []string{docID(existing[0]), docID(existing[1]), docID(existing[2]), strings.ReplaceAll(createdID, "-", "")}, indexer.listings)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRun_NothingToDo(t *testing.T) {
+	// SCENARIO: The seed runs a second time with the same numbers.
+	// EXPECT: Nothing is created, the listings are indexed again in case the index was emptied.
+
+	seeder, mockPool, files, indexer := newSeedTest(t)
+	expectSellers(mockPool, Sellers(2))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingIDsBySellerTerms`)).
+		WithArgs(termsVersion).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(fixtures.UUID("00000000-0000-0000-0000-000000000001")))
+
+	report, err := seeder.Run(context.Background(), Options{Sellers: 2, Listings: 1})
+
+	require.NoError(t, err)
+	assert.Equal(t, Report{Existing: 1, Indexed: 1}, report)
+	assert.Empty(t, files.put)
+	assert.Len(t, indexer.listings, 1)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRun_Wipe(t *testing.T) {
+	// SCENARIO: The developer runs the seed with --wipe and no listings.
+	// EXPECT: Seeded listings, their files and sellers are deleted and the listings are dropped from search.
+
+	seeder, mockPool, files, indexer := newSeedTest(t)
+	sellers := Sellers(1)
+	listingID := fixtures.UUID("00000000-0000-0000-0000-000000000001")
+	imageKey, modelKey := objectKeys(sellers[0].ID, 0)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: DeleteListingsBySellerTerms`)).
+		WithArgs(termsVersion).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "seller_id", "thumbnail_path"}).AddRow(listingID, sellers[0].ID, pgtype.Text{String: imageKey, Valid: true}))
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: DeleteSellers`)).
+		WithArgs([]pgtype.UUID{sellers[0].ID}).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	expectSellers(mockPool, sellers)
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingIDsBySellerTerms`)).
+		WithArgs(termsVersion).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}))
+
+	report, err := seeder.Run(context.Background(), Options{Sellers: 1, Listings: 0, Wipe: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, Report{Wiped: 1}, report)
+	assert.ElementsMatch(t, []string{string(storage.BucketPublic) + "/" + imageKey, string(storage.BucketProduct) + "/" + modelKey}, files.deleted)
+	assert.Equal(t, []string{docID(listingID)}, indexer.listings)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
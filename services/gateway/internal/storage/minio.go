@@ -1,10 +1,16 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/minio/minio-go/v7"
@@ -77,15 +83,34 @@ func (m *MinioProvider) GenerateUploadURL(ctx context.Context, cfg UploadConfig)
 }
 
 // PresignGet generates a temporary download URL (for private buckets).
-func (m *MinioProvider) PresignGet(ctx context.Context, bucket Bucket, key string, expiry time.Duration) (string, error) {
+func (m *MinioProvider) PresignGet(ctx context.Context, bucket Bucket, key string, opts PresignOptions) (string, error) {
+	var reqParams url.Values
+	if opts.Audience != "" {
+		reqParams = url.Values{}
+		reqParams.Set("response-content-disposition", contentDisposition(key, opts))
+	}
+
 	// PresignedGetObject generates a GET URL.
-	u, err := m.client.PresignedGetObject(ctx, string(bucket), key, expiry, nil)
+	u, err := m.client.PresignedGetObject(ctx, string(bucket), key, opts.Expiry, reqParams)
 	if err != nil {
 		return "", mapMinioError(err)
 	}
 	return u.String(), nil
 }
 
+// contentDisposition carries the audience as an extension parameter, browsers ignore it but it's
+// covered by the signature so it can't be stripped without breaking the link.
+func contentDisposition(key string, opts PresignOptions) string {
+	filename := opts.Filename
+	if filename == "" {
+		filename = path.Base(key)
+	}
+	return mime.FormatMediaType("attachment", map[string]string{
+		"filename": filename,
+		"audience": opts.Audience,
+	})
+}
+
 // Copy performs a Server-Side Copy.
 func (m *MinioProvider) Copy(ctx context.Context, srcBucket Bucket, srcKey string, destBucket Bucket, destKey string) error {
 	// Define Source
@@ -143,6 +168,32 @@ func (m *MinioProvider) Get(ctx context.Context, bucket Bucket, key string) (io.
 	return obj, nil
 }
 
+// Put writes data to key, replacing anything there. Uploads go straight from the browser with GenerateUploadURL, this
+// is for files the gateway makes itself, e.g. the dev seed's placeholders.
+func (m *MinioProvider) Put(ctx context.Context, bucket Bucket, key string, data []byte, contentType string) error {
+	_, err := m.client.PutObject(ctx, string(bucket), key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{ContentType: contentType})
+	return mapMinioError(err)
+}
+
+// ProbeBucket checks the bucket exists and these credentials can write to it, by putting and removing a
+// sentinel object. Used by the startup preflight.
+func (m *MinioProvider) ProbeBucket(ctx context.Context, bucket Bucket) error {
+	exists, err := m.client.BucketExists(ctx, string(bucket))
+	if err != nil {
+		return mapMinioError(err)
+	}
+	if !exists {
+		return ErrNotFound
+	}
+
+	host, _ := os.Hostname()
+	key := fmt.Sprintf(".preflight/%s-%d", host, time.Now().UnixNano())
+	if _, err := m.client.PutObject(ctx, string(bucket), key, strings.NewReader("ok"), 2, minio.PutObjectOptions{}); err != nil {
+		return mapMinioError(err)
+	}
+	return m.Delete(ctx, bucket, key)
+}
+
 // --- Helper: Error Mapping ---
 
 // mapMinioError translates MinIO SDK errors into our domain errors
@@ -40,12 +40,24 @@ type UploadConfig struct {
 	Expiry      time.Duration
 }
 
+// PresignOptions shapes a download URL
+type PresignOptions struct {
+	Expiry time.Duration
+
+	// Audience is the user the URL was issued to. It's signed into the URL's
+	// response-content-disposition, so a leaked link still says who it was handed to.
+	Audience string
+
+	// Filename is suggested to the browser when Audience is set, defaults to the last part of the key
+	Filename string
+}
+
 // Provider abstracts S3, MinIO, or Google Cloud Storage.
 type Provider interface {
 	GenerateUploadURL(ctx context.Context, cfg UploadConfig) (string, map[string]string, error)
 
 	// PresignGet generates a temporary download URL (if bucket is private).
-	PresignGet(ctx context.Context, bucket Bucket, key string, expiry time.Duration) (string, error)
+	PresignGet(ctx context.Context, bucket Bucket, key string, opts PresignOptions) (string, error)
 
 	// Copy moves a file internally (e.g., Quarantine -> Public).
 	// This happens on the server side (MinIO/AWS) without downloading the data.
@@ -35,3 +35,12 @@ func (h *TraceHandler) Handle(ctx context.Context, r slog.Record) error {
 	// 4. Pass the modified record to the underlying handler
 	return h.Handler.Handle(ctx, r)
 }
+
+// WithAttrs keeps the wrapper, otherwise logger.With(...) would hand back the bare handler and lose the trace IDs
+func (h *TraceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &TraceHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *TraceHandler) WithGroup(name string) slog.Handler {
+	return &TraceHandler{Handler: h.Handler.WithGroup(name)}
+}
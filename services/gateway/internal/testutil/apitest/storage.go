@@ -0,0 +1,87 @@
+package apitest
+
+import (
+	"bytes"
+	"context"
+	"gateway/internal/storage"
+	"io"
+	"net/url"
+	"sync"
+)
+
+// BaseURL is the host every upload and download URL from Storage points at
+const BaseURL = "https://storage.apitest.local"
+
+// Storage is a storage.Provider that keeps objects in memory. Presigned URLs are plain links to BaseURL with the
+// options in the query so tests can assert on them.
+type Storage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func NewStorage() *Storage {
+	return &Storage{objects: map[string][]byte{}}
+}
+
+func objectKey(bucket storage.Bucket, key string) string {
+	return string(bucket) + "/" + key
+}
+
+// Put stores an object as if it had been uploaded
+func (s *Storage) Put(bucket storage.Bucket, key string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[objectKey(bucket, key)] = data
+}
+
+// Has reports whether an object exists
+func (s *Storage) Has(bucket storage.Bucket, key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.objects[objectKey(bucket, key)]
+	return ok
+}
+
+func (s *Storage) GenerateUploadURL(ctx context.Context, cfg storage.UploadConfig) (string, map[string]string, error) {
+	return BaseURL + "/" + string(cfg.Bucket), map[string]string{
+		"key":          cfg.Key,
+		"Content-Type": cfg.ContentType,
+	}, nil
+}
+
+func (s *Storage) PresignGet(ctx context.Context, bucket storage.Bucket, key string, opts storage.PresignOptions) (string, error) {
+	query := url.Values{}
+	query.Set("expiry", opts.Expiry.String())
+	if opts.Audience != "" {
+		query.Set("audience", opts.Audience)
+	}
+	return BaseURL + "/" + objectKey(bucket, key) + "?" + query.Encode(), nil
+}
+
+func (s *Storage) Copy(ctx context.Context, srcBucket storage.Bucket, srcKey string, destBucket storage.Bucket, destKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[objectKey(srcBucket, srcKey)]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	s.objects[objectKey(destBucket, destKey)] = data
+	return nil
+}
+
+func (s *Storage) Delete(ctx context.Context, bucket storage.Bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, objectKey(bucket, key))
+	return nil
+}
+
+func (s *Storage) Get(ctx context.Context, bucket storage.Bucket, key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[objectKey(bucket, key)]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
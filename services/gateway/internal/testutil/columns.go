@@ -9,7 +9,7 @@ var ListingsCols = []string{
 
 	// Core Info
 	"title", "description", "price_min_unit", "currency", "categories", "license",
-	"client_id", "trace_id", "thumbnail_path", "last_indexed_at", "status",
+	"thumbnail_path", "last_indexed_at", "status",
 
 	// Remixing
 	"is_remixing_allowed", "parent_listing_id",
@@ -24,12 +24,17 @@ var ListingsCols = []string{
 
 	// Social & Stats (Default 0/Null)
 	"likes_count", "downloads_count", "comments_count",
-	"is_sale_active", "sale_name", "sale_end_timestamp",
+	"is_sale_active", "sale_price", "sale_name", "sale_end_timestamp",
 	"seller_rating_average", "seller_total_ratings", "seller_total_sales",
 	"is_nsfw",
 
 	// Timestamps
 	"created_at", "updated_at", "deleted_at",
+
+	// Added after the initial schema, so they come last
+	"views_count",
+	"nozzle_diameter_mm",
+	"language",
 }
 
 // ListingFileCols must match the RETURNING clause order in queries.sql for ListingFiles
@@ -39,3 +44,15 @@ var ListingFileCols = []string{
 	"is_generated", "source_file_id", // Newly added columns
 	"created_at", "updated_at", "deleted_at",
 }
+
+// SellerCols must match the column order of the sellers table
+var SellerCols = []string{
+	"user_id", "display_name", "country", "payout_status",
+	"accepted_terms_version", "accepted_terms_at",
+	"created_at", "updated_at",
+	"vacation_starts_at", "vacation_ends_at", "vacation_message", "vacation_applied",
+	"last_active_at",
+}
+
+// ListingVariantCols must match the select list of ListListingVariants
+var ListingVariantCols = []string{"id", "listing_id", "name", "price_min_unit", "created_at", "file_ids"}
@@ -0,0 +1,262 @@
+// Package fixtures builds listings for tests. Every column is filled with a value that agrees with the others, so a
+// test only spells out what it's about and a new column can't go unnoticed as a zero value, see listing_test.go.
+package fixtures
+
+import (
+	"database/sql/driver"
+	"fmt"
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/testutil"
+	"reflect"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+const (
+	ListingID = "550e8400-e29b-41d4-a716-446655440000"
+	SellerID  = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	ParentID  = "660e8400-e29b-41d4-a716-446655440000"
+)
+
+// CreatedAt is when every fixture listing was created, the other timestamps follow from it
+var CreatedAt = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// Dimensions is the size every physical fixture listing has, in the shape of the dimensions_mm column
+const Dimensions = `{"width":120,"depth":80,"height":45}`
+
+type builder struct {
+	listing      repo.Listing
+	files        []byte
+	statusReason pgtype.Text
+}
+
+// Opt changes the default listing, options are applied in order
+type Opt func(*builder)
+
+func defaultListing() repo.Listing {
+	return repo.Listing{
+		ID:             UUID(ListingID),
+		SellerID:       UUID(SellerID),
+		SellerName:     "Tester Prints",
+		SellerUsername: "tester",
+		SellerVerified: true,
+
+		Title:         "Benchy",
+		Description:   pgtype.Text{String: "The classic", Valid: true},
+		PriceMinUnit:  1050,
+		Currency:      "gbp",
+		Categories:    []string{"Art"},
+		License:       "MIT",
+		ThumbnailPath: pgtype.Text{String: "public/thumb.webp", Valid: true},
+		LastIndexedAt: pgtype.Timestamptz{Time: CreatedAt.Add(2 * time.Hour), Valid: true},
+		Status:        repo.NullListingStatus{ListingStatus: repo.ListingStatusACTIVE, Valid: true},
+
+		IsRemixingAllowed: true,
+
+		IsPhysical:             true,
+		TotalWeightGrams:       pgtype.Int4{Int32: 150, Valid: true},
+		IsAssemblyRequired:     true,
+		IsHardwareRequired:     true,
+		HardwareRequired:       []string{"M3 screws"},
+		IsMulticolor:           true,
+		DimensionsMm:           []byte(Dimensions),
+		RecommendedNozzleTempC: pgtype.Int4{Int32: 215, Valid: true},
+		RecommendedMaterials:   []string{"PLA"},
+		NozzleDiameterMm:       Numeric("0.40"),
+		Language:               "en",
+
+		LikesCount:          12,
+		DownloadsCount:      34,
+		CommentsCount:       5,
+		ViewsCount:          210,
+		SellerRatingAverage: Numeric("4.5"),
+		SellerTotalRatings:  pgtype.Int4{Int32: 8, Valid: true},
+		SellerTotalSales:    pgtype.Int4{Int32: 40, Valid: true},
+
+		CreatedAt: pgtype.Timestamptz{Time: CreatedAt, Valid: true},
+		UpdatedAt: pgtype.Timestamptz{Time: CreatedAt.Add(time.Hour), Valid: true},
+	}
+}
+
+func build(opts []Opt) *builder {
+	b := &builder{listing: defaultListing(), files: []byte(`[]`)}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// NewListing is an active physical listing by SellerID, not on sale, not a remix
+func NewListing(opts ...Opt) repo.Listing {
+	return build(opts).listing
+}
+
+// NewListingRow is NewListing as GetListingByIDWithFiles returns it, without files unless WithFiles is given
+func NewListingRow(opts ...Opt) repo.GetListingByIDWithFilesRow {
+	b := build(opts)
+	var row repo.GetListingByIDWithFilesRow
+	copyColumns(&row, b.listing)
+	row.Files = b.files
+	row.StatusReason = b.statusReason
+	return row
+}
+
+// With edits the listing directly, for the one-off values no other option covers
+func With(edit func(l *repo.Listing)) Opt {
+	return func(b *builder) { edit(&b.listing) }
+}
+
+func WithID(id string) Opt {
+	return func(b *builder) { b.listing.ID = UUID(id) }
+}
+
+func WithSeller(id string) Opt {
+	return func(b *builder) { b.listing.SellerID = UUID(id) }
+}
+
+func WithTitle(title string) Opt {
+	return func(b *builder) { b.listing.Title = title }
+}
+
+func WithStatus(status repo.ListingStatus) Opt {
+	return func(b *builder) {
+		b.listing.Status = repo.NullListingStatus{ListingStatus: status, Valid: true}
+	}
+}
+
+// WithStatusReason is what a moderator or the validator gave for the current status, only rows carry it
+func WithStatusReason(reason string) Opt {
+	return func(b *builder) { b.statusReason = pgtype.Text{String: reason, Valid: true} }
+}
+
+func WithPrice(minUnit int64, currency string) Opt {
+	return func(b *builder) {
+		b.listing.PriceMinUnit = minUnit
+		b.listing.Currency = currency
+	}
+}
+
+// WithThumbnail sets the thumbnail's object path, "" for a listing without one
+func WithThumbnail(path string) Opt {
+	return func(b *builder) { b.listing.ThumbnailPath = pgtype.Text{String: path, Valid: path != ""} }
+}
+
+// WithNoDimensions is a physical listing whose seller never gave its size
+func WithNoDimensions() Opt {
+	return func(b *builder) { b.listing.DimensionsMm = nil }
+}
+
+// WithDigital is a digital-only listing, so nothing about shipping a print is set
+func WithDigital() Opt {
+	return func(b *builder) {
+		b.listing.IsPhysical = false
+		b.listing.DimensionsMm = nil
+		b.listing.TotalWeightGrams = pgtype.Int4{}
+		b.listing.IsAssemblyRequired = false
+		b.listing.IsHardwareRequired = false
+		b.listing.HardwareRequired = nil
+	}
+}
+
+// WithSale puts the listing on sale at priceMinUnit, in the listing's currency, until ends
+func WithSale(name string, priceMinUnit int64, ends time.Time) Opt {
+	return func(b *builder) {
+		b.listing.IsSaleActive = true
+		b.listing.SaleName = pgtype.Text{String: name, Valid: true}
+		b.listing.SalePrice = pgtype.Int8{Int64: priceMinUnit, Valid: true}
+		b.listing.SaleEndTimestamp = pgtype.Timestamptz{Time: ends, Valid: true}
+	}
+}
+
+func WithRemixOf(parentID string) Opt {
+	return func(b *builder) { b.listing.ParentListingID = UUID(parentID) }
+}
+
+func WithAIModel(name string) Opt {
+	return func(b *builder) {
+		b.listing.IsAiGenerated = true
+		b.listing.AiModelName = pgtype.Text{String: name, Valid: true}
+	}
+}
+
+func WithNSFW() Opt {
+	return func(b *builder) { b.listing.IsNsfw = true }
+}
+
+func WithDeleted(at time.Time) Opt {
+	return func(b *builder) { b.listing.DeletedAt = pgtype.Timestamptz{Time: at, Valid: true} }
+}
+
+// WithFiles is the files column of a row, the JSON array GetListingByIDWithFiles aggregates
+func WithFiles(files []byte) Opt {
+	return func(b *builder) { b.files = files }
+}
+
+// ListingRows returns the listings the way pgxmock hands back SELECT * FROM listings
+func ListingRows(listings ...repo.Listing) *pgxmock.Rows {
+	rows := pgxmock.NewRows(testutil.ListingsCols)
+	for _, l := range listings {
+		rows.AddRow(values(l)...)
+	}
+	return rows
+}
+
+// ListingWithFilesRows returns the rows the way pgxmock hands back GetListingByIDWithFiles and GetListingsBySellerID
+func ListingWithFilesRows(rows ...repo.GetListingByIDWithFilesRow) *pgxmock.Rows {
+	cols := append(append([]string{}, testutil.ListingsCols...), "files", "status_reason")
+	mockRows := pgxmock.NewRows(cols)
+	for _, row := range rows {
+		mockRows.AddRow(values(row)...)
+	}
+	return mockRows
+}
+
+// UUID parses a fixture ID, panicking on a typo
+func UUID(id string) pgtype.UUID {
+	var u pgtype.UUID
+	if err := u.Scan(id); err != nil {
+		panic(fmt.Sprintf("fixtures: invalid UUID %q: %v", id, err))
+	}
+	return u
+}
+
+// Numeric parses a decimal, panicking on a typo
+func Numeric(n string) pgtype.Numeric {
+	var num pgtype.Numeric
+	if err := num.Scan(n); err != nil {
+		panic(fmt.Sprintf("fixtures: invalid numeric %q: %v", n, err))
+	}
+	return num
+}
+
+// values is a struct's fields in declaration order, which sqlc keeps in column order, as the driver values pgx
+// would have read them
+func values(row any) []any {
+	v := reflect.ValueOf(row)
+	out := make([]any, v.NumField())
+	for i := range out {
+		field := v.Field(i).Interface()
+		if valuer, ok := field.(driver.Valuer); ok {
+			value, err := valuer.Value()
+			if err != nil {
+				panic(fmt.Sprintf("fixtures: %s: %v", v.Type().Field(i).Name, err))
+			}
+			field = value
+		}
+		out[i] = field
+	}
+	return out
+}
+
+// copyColumns sets every field of dst that src has a field of the same name for
+func copyColumns(dst any, src any) {
+	d := reflect.ValueOf(dst).Elem()
+	s := reflect.ValueOf(src)
+	for i := range s.NumField() {
+		if f := d.FieldByName(s.Type().Field(i).Name); f.IsValid() {
+			f.Set(s.Field(i))
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package fixtures_test
+
+import (
+	"context"
+	"encoding/json"
+	repo "gateway/internal/database/postgresql/sqlc"
+	"gateway/internal/testutil"
+	"gateway/internal/testutil/fixtures"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unsetByDefault are the columns the default listing leaves NULL or false, each has an option that sets it
+var unsetByDefault = map[string]bool{
+	"ParentListingID":  true, // WithRemixOf
+	"IsAiGenerated":    true, // WithAIModel
+	"AiModelName":      true,
+	"IsSaleActive":     true, // WithSale
+	"SalePrice":        true,
+	"SaleName":         true,
+	"SaleEndTimestamp": true,
+	"IsNsfw":           true, // WithNSFW
+	"DeletedAt":        true, // WithDeleted
+	"StatusReason":     true, // WithStatusReason
+}
+
+// everything turns on every option that fills a column the default leaves unset
+var everything = []fixtures.Opt{
+	fixtures.WithRemixOf(fixtures.ParentID),
+	fixtures.WithAIModel("Meshy"),
+	fixtures.WithSale("Spring sale", 800, fixtures.CreatedAt.Add(7*24*time.Hour)),
+	fixtures.WithNSFW(),
+	fixtures.WithDeleted(fixtures.CreatedAt.Add(48 * time.Hour)),
+	fixtures.WithStatusReason("Blurry photos"),
+}
+
+func zeroFields(row any) (zero []string) {
+	v := reflect.ValueOf(row)
+	for i := range v.NumField() {
+		if v.Field(i).IsZero() {
+			zero = append(zero, v.Type().Field(i).Name)
+		}
+	}
+	return zero
+}
+
+func TestNewListing_Complete(t *testing.T) {
+	// SCENARIO: A column was added to listings and regenerated into the sqlc structs.
+	// EXPECT: This fails until the default fixture fills it, or it's listed in unsetByDefault with an option that does.
+
+	for name, build := range map[string]func(opts ...fixtures.Opt) any{
+		"Listing": func(opts ...fixtures.Opt) any { return fixtures.NewListing(opts...) },
+		"Row":     func(opts ...fixtures.Opt) any { return fixtures.NewListingRow(opts...) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			for _, field := range zeroFields(build()) {
+				assert.True(t, unsetByDefault[field], "%s is zero in the default fixture, fill it in defaultListing", field)
+			}
+			assert.Empty(t, zeroFields(build(everything...)), "no option sets these, add one")
+		})
+	}
+}
+
+func TestNewListing_Consistent(t *testing.T) {
+	listing := fixtures.NewListing()
+
+	var dims struct{ Width, Depth, Height int }
+	require.NoError(t, json.Unmarshal(listing.DimensionsMm, &dims))
+	assert.Positive(t, dims.Width*dims.Depth*dims.Height, "a physical listing has a full size")
+	assert.True(t, listing.TotalWeightGrams.Valid)
+
+	digital := fixtures.NewListing(fixtures.WithDigital())
+	assert.False(t, digital.IsPhysical)
+	assert.Nil(t, digital.DimensionsMm)
+	assert.False(t, digital.TotalWeightGrams.Valid)
+	assert.Empty(t, digital.HardwareRequired)
+
+	sale := fixtures.NewListing(fixtures.WithSale("Spring sale", 800, fixtures.CreatedAt.Add(time.Hour)))
+	assert.True(t, sale.SalePrice.Valid)
+	assert.Less(t, sale.SalePrice.Int64, sale.PriceMinUnit, "a sale is cheaper than the listing")
+	assert.True(t, sale.SaleEndTimestamp.Time.After(sale.CreatedAt.Time))
+}
+
+func TestListingRows_ScanBack(t *testing.T) {
+	// SCENARIO: The mocked rows are read by the real sqlc queries.
+	// EXPECT: The listing comes back exactly as built, so the column order matches testutil.ListingsCols.
+
+	mockPool := testutil.NewMockDB(t)
+	queries := repo.New(mockPool)
+	listing := fixtures.NewListing(everything...)
+	row := fixtures.NewListingRow(everything...)
+
+	mockPool.ExpectQuery(`-- name: GetListingByID :one`).WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(fixtures.ListingRows(listing))
+	mockPool.ExpectQuery(`-- name: GetListingByIDWithFiles :one`).WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(fixtures.ListingWithFilesRows(row))
+
+	got, err := queries.GetListingByID(context.Background(), listing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, listing, got)
+
+	gotRow, err := queries.GetListingByIDWithFiles(context.Background(), listing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, row, gotRow)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -2,50 +2,173 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"indexer/internal/auth"
+	"indexer/internal/callbacks"
+	"indexer/internal/counters"
+	"indexer/internal/database/postgresql"
 	repo "indexer/internal/database/postgresql/sqlc"
 	"indexer/internal/events"
 	"indexer/internal/indexing"
+	"indexer/internal/lock"
+	"indexer/internal/logging"
+	"indexer/internal/notifications"
+	"indexer/internal/publicurl"
+	"indexer/internal/purge"
+	"indexer/internal/queues"
+	"indexer/internal/savedsearch"
+	"indexer/internal/storage"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"shared/natsconn"
+	"shared/poolmetrics"
+	"shared/preflight"
+	"shared/version"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 )
 
 type Config struct {
-	Env            string
-	Port           string
-	DatabaseURL    string
-	NatsURL        string
-	TypesenseURL   string
-	TypesenseKey   string
-	PublicFilesURL string
-	EventsConfig   *events.EventConfig
+	Env          string
+	Port         string
+	DatabaseURL  string
+	Nats         natsconn.Config
+	TypesenseURL string
+	TypesenseKey string
+	PublicURLs   publicurl.Config
+	EventsConfig *events.EventConfig
+
+	// Read-only DSN the reindex, backfill and reconcile scans use instead of the primary, optional
+	ReplicaDatabaseURL string
+
+	S3Endpoint  string
+	S3AccessKey string
+	S3SecretKey string
+	S3UseSSL    bool
+
+	Purge         purge.Config
+	PurgeInterval time.Duration
+
+	RedisAddr            string
+	RedisPassword        string
+	CounterFlushInterval time.Duration
+	// How long downloads keep the user who made them, 0 keeps it forever. The flush anonymizes older ones.
+	DownloadRetention time.Duration
+	// How often counters are checked against the downloads table and the search documents, and how far off they may be
+	CounterReconcileInterval time.Duration
+	CounterReconcile         counters.ReconcileConfig
+
+	// How often sellers' listings are reindexed when their vacation starts or ends, and how many are done at a time
+	VacationSyncInterval  time.Duration
+	VacationSyncBatchSize int
+
+	// How often listings whose price drop has expired are reindexed, and the most done per pass
+	PriceDropSweepInterval  time.Duration
+	PriceDropSweepBatchSize int
+
+	// How often is_featured is patched onto listings whose featured windows started or ended, and the most per pass
+	FeaturedSyncInterval  time.Duration
+	FeaturedSyncBatchSize int
+
+	// How often due saved searches are looked for, each one is run every SavedSearch.CheckEvery
+	SavedSearchInterval time.Duration
+	SavedSearch         savedsearch.Config
+
+	// How often validated uploads' callbacks are sent, off while UPLOAD_CALLBACK_SECRETS is unset
+	UploadCallbackInterval time.Duration
+	UploadCallbacks        callbacks.Config
+
+	AdminToken string // Shared secret for the /admin endpoints, they refuse every request when it's empty
+
+	// The worker's Keycloak client and where the gateway's /internal/ routes are, both unset until a feature needs them
+	ServiceAccount     auth.Config
+	GatewayInternalURL string
 }
 
 func main() {
-	handler := slog.NewJSONHandler(os.Stdout, nil)
-	logger := slog.New(handler)
-	slog.SetDefault(logger) // Set global logger
+	// LOG_LEVEL and LOG_FORMAT, SIGHUP turns on debug logging without a restart
+	logger, logLevel := logging.New(os.Getenv, os.Stdout)
+	slog.SetDefault(logger)
+	logging.ToggleDebugOnSIGHUP(context.Background(), logLevel, logger)
+
+	// Subcommands: "purge" runs a single purge pass and exits
+	if len(os.Args) > 1 && os.Args[1] == "purge" {
+		if err := runPurge(logger, os.Args[2:]); err != nil {
+			slog.Error("Purge terminated with error", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	if err := run(logger); err != nil {
+	// "reindex" pushes every stale listing back into Typesense and exits
+	if len(os.Args) > 1 && os.Args[1] == "reindex" {
+		if err := runReindex(logger, os.Args[2:]); err != nil {
+			slog.Error("Reindex terminated with error", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// "reconcile" corrects counters that drifted between Postgres and Typesense and exits
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		if err := runReconcile(logger, os.Args[2:]); err != nil {
+			slog.Error("Reconcile terminated with error", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// "backfill" writes new search fields onto existing documents and exits
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		if err := runBackfill(logger, os.Args[2:]); err != nil {
+			slog.Error("Backfill terminated with error", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fs := flag.NewFlagSet("listings-worker", flag.ExitOnError)
+	// For emergencies only, e.g. a check is wrong and blocking a fix from going out
+	skipPreflight := fs.Bool("skip-preflight", false, "Start without checking search, storage, events and the database schema")
+	// For debugging a listing that won't index, without publishing an event by hand
+	indexOne := fs.String("index-one", os.Getenv("INDEX_ONE"), "Index this listing once with debug logging and exit instead of consuming events")
+	dryRun := fs.Bool("dry-run", false, "With --index-one, print the document without writing it to the index")
+	_ = fs.Parse(os.Args[1:])
+
+	if *indexOne != "" {
+		if err := runIndexOne(*indexOne, *dryRun); err != nil {
+			slog.Error("Index one terminated with error", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := run(logger, *skipPreflight); err != nil {
 		slog.Error("Application terminated with error", "error", err)
 		os.Exit(1)
 	}
 }
 
-func run(logger *slog.Logger) error {
+func run(logger *slog.Logger, skipPreflight bool) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// 2. Load Configuration
 	cfg := loadConfig()
-	logger.Info("Starting Listings Worker", "env", cfg.Env)
+	build := version.Get()
+	logger.Info("Starting Listings Worker", "env", cfg.Env, "build", build)
 
 	// 3. Initialize Database (Postgres)
 	dbPool, err := pgxpool.New(ctx, cfg.DatabaseURL)
@@ -58,39 +181,179 @@ func run(logger *slog.Logger) error {
 		return fmt.Errorf("failed to ping db: %w", err)
 	}
 
+	replicaPool, err := openReplica(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+	if replicaPool != nil {
+		defer replicaPool.Close()
+		if err := replicaPool.Ping(ctx); err != nil {
+			return fmt.Errorf("failed to ping replica db: %w", err)
+		}
+	}
+
 	// 4. Initialize NATS (Event Bus)
-	bus, err := events.NewNATSBus(cfg.NatsURL, logger)
+	bus, err := events.NewNATSBus(cfg.Nats, logger)
 	if err != nil {
 		return fmt.Errorf("failed to connect to nats: %w", err)
 	}
 
 	// 5. Initialize Search Indexer (Typesense)
-	indexer := indexing.NewClient(cfg.TypesenseKey, cfg.TypesenseURL)
+	indexer := indexing.NewClient(cfg.TypesenseKey, cfg.TypesenseURL, logger)
 
 	// 6. Initialize Service Layer
 	// Wire up the SQLC repository and the Indexer
 	queries := repo.New(dbPool)
-	svc := indexing.NewService(indexer, queries, logger, cfg.PublicFilesURL)
+	replicaQueries := readerFor(replicaPool)
+	svc := indexing.NewServiceWithReader(indexer, queries, replicaQueries, logger, cfg.PublicURLs)
 
 	reader := events.NewEventReader(bus, cfg.EventsConfig, logger)
 
-	// 8. Start Subscriptions
+	// 7. Initialize Redis
+	// Holds the gateway's buffered counters and the locks that keep scheduled jobs to one replica at a time
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     cfg.RedisAddr,
+		Password: cfg.RedisPassword,
+	})
+	defer rdb.Close()
+
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	locker := lock.New(rdb, logger)
+
+	// Read on every scrape of /metrics, pool exhaustion is the incident we'd otherwise be blind to
+	prometheus.MustRegister(poolmetrics.NewPGXCollector("listings_worker", dbPool), poolmetrics.NewRedisCollector("listings_worker", rdb), version.NewCollector("listings_worker", build))
+
+	// 8. Initialize Storage
+	store, err := storage.NewMinioProvider(cfg.S3Endpoint, cfg.S3AccessKey, cfg.S3SecretKey, cfg.S3UseSSL)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	// 9. Preflight
+	// Fail the deploy on a misconfigured dependency instead of on the first message that touches it
+	if skipPreflight {
+		logger.Warn("Skipping preflight checks, misconfiguration will only show up in message handling")
+	} else if err := preflight.Run(ctx, preflightTimeout, preflightChecks(dbPool, store, indexer, bus, cfg)); err != nil {
+		return err
+	}
+
+	// 10. Start Purge Job
+	purgeSvc := purge.NewService(queries, dbPool, store, indexer, logger, cfg.Purge)
+	go runExclusivePeriodically(ctx, locker, logger, "purge", cfg.PurgeInterval, func(ctx context.Context) error {
+		_, err := purgeSvc.Run(ctx, false)
+		return err
+	})
+	// Listings remember which client and trace created them for debugging, not for good
+	go runExclusivePeriodically(ctx, locker, logger, "trace-retention", cfg.PurgeInterval, func(ctx context.Context) error {
+		_, err := purgeSvc.AnonymizeTraces(ctx)
+		return err
+	})
+
+	// 11. Initialize Counter Flush
+	// The gateway buffers download/view counts in Redis, we move them to Postgres in batches
+	writer := events.NewEventWriter(bus, cfg.EventsConfig, logger)
+	countersSvc := counters.NewService(queries, dbPool, counters.NewRedisStore(rdb), counters.NewRedisReceiptStore(rdb), counters.NewRedisClickStore(rdb), writer, logger, cfg.DownloadRetention)
+	go runExclusivePeriodically(ctx, locker, logger, "counter-flush", cfg.CounterFlushInterval, func(ctx context.Context) error {
+		_, err := countersSvc.Flush(ctx)
+		return err
+	})
+	// Nightly, under the flush's lock so no flush lands halfway through a reconcile
+	reconciler := counters.NewReconciler(queries, replicaQueries, countersSvc, indexer, logger, cfg.CounterReconcile)
+	go runExclusivePeriodically(ctx, locker, logger, "counter-flush", cfg.CounterReconcileInterval, func(ctx context.Context) error {
+		_, err := reconciler.Run(ctx, "")
+		return err
+	})
+
+	// 12. Start Vacation Sync
+	// Hides a seller's listings from search while they're away and brings them back after
+	go runExclusivePeriodically(ctx, locker, logger, "vacation-sync", cfg.VacationSyncInterval, func(ctx context.Context) error {
+		_, err := svc.SyncVacations(ctx, cfg.VacationSyncBatchSize)
+		return err
+	})
+
+	// Clears price_dropped_recently from listings whose drop has aged out, nothing on the row changes to trigger it
+	go runExclusivePeriodically(ctx, locker, logger, "price-drop-sweep", cfg.PriceDropSweepInterval, func(ctx context.Context) error {
+		_, err := svc.SweepPriceDrops(ctx, cfg.PriceDropSweepBatchSize)
+		return err
+	})
+
+	// Sets and clears is_featured as the windows admins book on the gateway start and end
+	go runExclusivePeriodically(ctx, locker, logger, "featured-sync", cfg.FeaturedSyncInterval, func(ctx context.Context) error {
+		_, err := svc.SyncFeatures(ctx, cfg.FeaturedSyncBatchSize)
+		return err
+	})
+
+	// Tells buyers about new listings matching their saved searches, off until there's a subject to send them on
+	if cfg.EventsConfig.SavedSearchMatched != "" {
+		savedSearchSvc := savedsearch.NewService(queries, indexer, writer, logger, cfg.SavedSearch)
+		go runExclusivePeriodically(ctx, locker, logger, "saved-searches", cfg.SavedSearchInterval, func(ctx context.Context) error {
+			_, err := savedSearchSvc.Run(ctx)
+			return err
+		})
+	}
+
+	// Tells partners who asked at presign time that their upload has been validated
+	if len(cfg.UploadCallbacks.Secrets) > 0 {
+		dispatcher := callbacks.NewDispatcher(queries, logger, cfg.UploadCallbacks)
+		go runExclusivePeriodically(ctx, locker, logger, "upload-callbacks", cfg.UploadCallbackInterval, func(ctx context.Context) error {
+			_, err := dispatcher.Deliver(ctx)
+			return err
+		})
+	}
+
+	// 13. Start Subscriptions
 	// This starts the background workers processing messages
-	err = reader.SubscribeToIndexListingEvents(func(evt events.IndexListingEvent) error {
-		// Bridge the event payload to the service logic
-		return svc.IndexListing(context.Background(), evt.ListingID)
+	err = reader.SubscribeToIndexEvents(func(evt events.IndexEvent) error {
+		// Bridge the event payload to the service logic, which picks the source for the entity type
+		return svc.Index(context.Background(), evt.EntityType, evt.EntityID)
 	})
 	if err != nil {
 		return fmt.Errorf("failed to subscribe to events: %w", err)
 	}
 
+	// Listings given up on are reported to the gateway, which shows the seller and moderators they aren't in search
+	var failurePublisher indexing.FailurePublisher
+	if cfg.EventsConfig.ListingIndexFailed != "" {
+		failurePublisher = writer
+	}
+	reader.OnIndexDeadLetter(indexing.NewFailureReporter(queries, failurePublisher, logger).Report)
+
+	// Deletes skip reading the listing back, so they can't be held up by the index event the delete also raises
+	if cfg.EventsConfig.DeleteListing != "" {
+		err = reader.SubscribeToDeleteListingEvents(func(evt events.DeleteListingEvent) error {
+			return svc.DeleteListing(context.Background(), evt.ListingID)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to delete events: %w", err)
+		}
+	}
+
+	err = reader.SubscribeToListingCountersEvents(func(evt events.ListingCountersEvent) error {
+		return svc.UpdateCounters(context.Background(), evt.ListingID, evt.DownloadsCount, evt.ViewsCount, evt.SellerActivityBucket)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to counter events: %w", err)
+	}
+
+	// Lifecycle events fan out, each consumer subscribes under its own name
+	notify := notifications.NewService(logger)
+	if err := reader.SubscribeToListingCreatedEvents(notifications.Consumer, notify.ListingCreated); err != nil {
+		return fmt.Errorf("failed to subscribe to listing created events: %w", err)
+	}
+	if err := reader.SubscribeToListingPublishedEvents(notifications.Consumer, notify.ListingPublished); err != nil {
+		return fmt.Errorf("failed to subscribe to listing published events: %w", err)
+	}
+
 	logger.Info("Worker is running and listening for events...")
 
-	// 9. Start Health Check Server (For Kubernetes)
+	// 14. Start Health Check Server (For Kubernetes)
 	// Run in a goroutine so it doesn't block
+	queuesHandler := queues.NewHandler(queues.NewService(bus.JetStream(), bus.Consumers, logger), cfg.AdminToken)
 	srv := &http.Server{
 		Addr:    ":" + cfg.Port,
-		Handler: healthHandler(dbPool, bus), // Simple handler checking DB/NATS ping
+		Handler: healthMux(dbPool, replicaPool, bus, queuesHandler, build),
 	}
 
 	go func() {
@@ -99,7 +362,7 @@ func run(logger *slog.Logger) error {
 		}
 	}()
 
-	// 10. Graceful Shutdown Handler
+	// 15. Graceful Shutdown Handler
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
@@ -122,7 +385,10 @@ func run(logger *slog.Logger) error {
 		logger.Error("NATS drain error", "error", err)
 	}
 
-	// C. Close DB Pool (handled by defer, but explicit here for clarity order)
+	// C. Stop background jobs before the pool they use goes away
+	cancel()
+
+	// D. Close DB Pool (handled by defer, but explicit here for clarity order)
 	dbPool.Close()
 
 	logger.Info("Shutdown complete.")
@@ -138,18 +404,446 @@ func loadConfig() Config {
 		return fallback
 	}
 
+	getInt := func(key string, fallback int) int {
+		if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+			return v
+		}
+		return fallback
+	}
+
+	purgeInterval, err := time.ParseDuration(get("PURGE_INTERVAL", "24h"))
+	if err != nil {
+		purgeInterval = 24 * time.Hour
+	}
+
+	counterFlushInterval, err := time.ParseDuration(get("COUNTER_FLUSH_INTERVAL", "30s"))
+	if err != nil {
+		counterFlushInterval = 30 * time.Second
+	}
+
+	counterReconcileInterval, err := time.ParseDuration(get("COUNTER_RECONCILE_INTERVAL", "24h"))
+	if err != nil {
+		counterReconcileInterval = 24 * time.Hour
+	}
+
+	vacationSyncInterval, err := time.ParseDuration(get("VACATION_SYNC_INTERVAL", "1m"))
+	if err != nil {
+		vacationSyncInterval = time.Minute
+	}
+
+	priceDropSweepInterval, err := time.ParseDuration(get("PRICE_DROP_SWEEP_INTERVAL", "1h"))
+	if err != nil {
+		priceDropSweepInterval = time.Hour
+	}
+
+	featuredSyncInterval, err := time.ParseDuration(get("FEATURED_SYNC_INTERVAL", "1m"))
+	if err != nil {
+		featuredSyncInterval = time.Minute
+	}
+
+	savedSearchInterval, err := time.ParseDuration(get("SAVED_SEARCH_INTERVAL", "1m"))
+	if err != nil {
+		savedSearchInterval = time.Minute
+	}
+
+	savedSearchCheckEvery, err := time.ParseDuration(get("SAVED_SEARCH_CHECK_EVERY", "1h"))
+	if err != nil {
+		savedSearchCheckEvery = time.Hour
+	}
+
+	savedSearchLookback, err := time.ParseDuration(get("SAVED_SEARCH_LOOKBACK", "24h"))
+	if err != nil {
+		savedSearchLookback = 24 * time.Hour
+	}
+
+	uploadCallbackInterval, err := time.ParseDuration(get("UPLOAD_CALLBACK_INTERVAL", "15s"))
+	if err != nil {
+		uploadCallbackInterval = 15 * time.Second
+	}
+
+	uploadCallbackTimeout, err := time.ParseDuration(get("UPLOAD_CALLBACK_TIMEOUT", "10s"))
+	if err != nil {
+		uploadCallbackTimeout = 10 * time.Second
+	}
+
+	uploadCallbackSecrets, err := callbacks.ParseSecrets(os.Getenv("UPLOAD_CALLBACK_SECRETS"))
+	if err != nil {
+		slog.Warn("Ignoring UPLOAD_CALLBACK_SECRETS, upload callbacks are off", "error", err)
+	}
+
 	return Config{
-		Env:            get("INDEX_WORKER_ENV", "production"),
-		Port:           get("INDEX_WORKER_PORT", "4084"),
-		DatabaseURL:    os.Getenv("DB_DSN"),
-		NatsURL:        os.Getenv("NATS_ENDPOINT"),
-		TypesenseURL:   os.Getenv("TYPESENSE_URL"),
-		TypesenseKey:   os.Getenv("TYPESENSE_API_KEY"),
-		EventsConfig:   events.NewEventConfig(),
-		PublicFilesURL: os.Getenv("PUBLIC_FILES_URL"),
+		Env:          get("INDEX_WORKER_ENV", "production"),
+		Port:         get("INDEX_WORKER_PORT", "4084"),
+		DatabaseURL:  os.Getenv("DB_DSN"),
+		Nats:         natsconn.ConfigFromEnv(),
+		TypesenseURL: os.Getenv("TYPESENSE_URL"),
+		TypesenseKey: os.Getenv("TYPESENSE_API_KEY"),
+		EventsConfig: events.NewEventConfig(),
+		PublicURLs:   publicurl.FromEnv(),
+
+		S3Endpoint:  os.Getenv("S3_ENDPOINT"),
+		S3AccessKey: os.Getenv("LISTINGS_WORKER_S3_ACCESS_KEY_ID"),
+		S3SecretKey: os.Getenv("LISTINGS_WORKER_S3_SECRET_ACCESS_KEY"),
+		S3UseSSL:    os.Getenv("S3_USE_SSL") == "true",
+
+		Purge: purge.Config{
+			RetentionDays:          getInt("PURGE_RETENTION_DAYS", 30),
+			BatchSize:              getInt("PURGE_BATCH_SIZE", 100),
+			ObjectDeletesPerSecond: getInt("PURGE_STORAGE_RPS", 20),
+			TraceRetentionDays:     getInt("TRACE_RETENTION_DAYS", 90),
+		},
+		PurgeInterval: purgeInterval,
+
+		RedisAddr:            os.Getenv("REDIS_ADDR"),
+		RedisPassword:        os.Getenv("REDIS_PASSWORD"),
+		CounterFlushInterval: counterFlushInterval,
+		DownloadRetention:    time.Duration(getInt("DOWNLOAD_RETENTION_DAYS", 365)) * 24 * time.Hour,
+
+		CounterReconcileInterval: counterReconcileInterval,
+		CounterReconcile: counters.ReconcileConfig{
+			BatchSize: getInt("COUNTER_RECONCILE_BATCH_SIZE", 500),
+			Tolerance: int64(getInt("COUNTER_RECONCILE_TOLERANCE", 5)),
+		},
+
+		VacationSyncInterval:  vacationSyncInterval,
+		VacationSyncBatchSize: getInt("VACATION_SYNC_BATCH_SIZE", 100),
+
+		PriceDropSweepInterval:  priceDropSweepInterval,
+		PriceDropSweepBatchSize: getInt("PRICE_DROP_SWEEP_BATCH_SIZE", 500),
+
+		FeaturedSyncInterval:  featuredSyncInterval,
+		FeaturedSyncBatchSize: getInt("FEATURED_SYNC_BATCH_SIZE", 100),
+
+		SavedSearchInterval: savedSearchInterval,
+		SavedSearch: savedsearch.Config{
+			BatchSize:         getInt("SAVED_SEARCH_BATCH_SIZE", 500),
+			SearchesPerSecond: getInt("SAVED_SEARCH_RPS", 10),
+			CheckEvery:        savedSearchCheckEvery,
+			Lookback:          savedSearchLookback,
+		},
+
+		UploadCallbackInterval: uploadCallbackInterval,
+		UploadCallbacks: callbacks.Config{
+			Secrets:        uploadCallbackSecrets,
+			BatchSize:      getInt("UPLOAD_CALLBACK_BATCH_SIZE", 100),
+			PerDestination: getInt("UPLOAD_CALLBACK_PER_DESTINATION", 10),
+			MaxAttempts:    getInt("UPLOAD_CALLBACK_MAX_ATTEMPTS", 8),
+			DisableAfter:   getInt("UPLOAD_CALLBACK_DISABLE_AFTER", 20),
+			Timeout:        uploadCallbackTimeout,
+		},
+
+		AdminToken: os.Getenv("INDEX_WORKER_ADMIN_TOKEN"),
+
+		ServiceAccount: auth.Config{
+			IssuerURL:    os.Getenv("AUTHORIZATION_URL"),
+			ClientID:     os.Getenv("LISTINGS_WORKER_CLIENT_ID"),
+			ClientSecret: os.Getenv("LISTINGS_WORKER_CLIENT_SECRET"),
+		},
+		GatewayInternalURL: os.Getenv("GATEWAY_INTERNAL_URL"),
 	}
 }
 
+// runPurge executes a single purge pass, e.g. `listings-worker purge --dry-run`
+func runPurge(logger *slog.Logger, args []string) error {
+	cfg := loadConfig()
+
+	fs := flag.NewFlagSet("purge", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "Report what would be purged without deleting anything")
+	fs.IntVar(&cfg.Purge.RetentionDays, "retention-days", cfg.Purge.RetentionDays, "Purge listings soft-deleted more than this many days ago")
+	fs.IntVar(&cfg.Purge.BatchSize, "batch-size", cfg.Purge.BatchSize, "Listings fetched per page")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	dbPool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to db: %w", err)
+	}
+	defer dbPool.Close()
+
+	store, err := storage.NewMinioProvider(cfg.S3Endpoint, cfg.S3AccessKey, cfg.S3SecretKey, cfg.S3UseSSL)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	indexer := indexing.NewClient(cfg.TypesenseKey, cfg.TypesenseURL, logger)
+	svc := purge.NewService(repo.New(dbPool), dbPool, store, indexer, logger, cfg.Purge)
+
+	report, err := svc.Run(ctx, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Purge report", "report", report)
+	return nil
+}
+
+// runReindex reindexes every listing changed since it was last indexed, e.g. `listings-worker reindex --batch-size 500`
+func runReindex(logger *slog.Logger, args []string) error {
+	cfg := loadConfig()
+
+	fs := flag.NewFlagSet("reindex", flag.ExitOnError)
+	batchSize := fs.Int("batch-size", 200, "Listings fetched per page")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	dbPool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to db: %w", err)
+	}
+	defer dbPool.Close()
+
+	replicaPool, err := openReplica(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+	if replicaPool != nil {
+		defer replicaPool.Close()
+	}
+
+	indexer := indexing.NewClient(cfg.TypesenseKey, cfg.TypesenseURL, logger)
+	svc := indexing.NewServiceWithReader(indexer, repo.New(dbPool), readerFor(replicaPool), logger, cfg.PublicURLs)
+
+	_, err = svc.ReindexStale(ctx, *batchSize)
+	return err
+}
+
+// runReconcile reconciles the counters once, e.g. `listings-worker reconcile --listing-id <id> --tolerance 0` when
+// support is looking into one listing's counts. It flushes first, so it needs Redis and NATS like the worker does.
+func runReconcile(logger *slog.Logger, args []string) error {
+	cfg := loadConfig()
+
+	fs := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	listingID := fs.String("listing-id", "", "Only reconcile this listing")
+	fs.Int64Var(&cfg.CounterReconcile.Tolerance, "tolerance", cfg.CounterReconcile.Tolerance, "Fix counts further off than this")
+	fs.IntVar(&cfg.CounterReconcile.BatchSize, "batch-size", cfg.CounterReconcile.BatchSize, "Listings fetched per page")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	dbPool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to db: %w", err)
+	}
+	defer dbPool.Close()
+
+	replicaPool, err := openReplica(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+	if replicaPool != nil {
+		defer replicaPool.Close()
+	}
+
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     cfg.RedisAddr,
+		Password: cfg.RedisPassword,
+	})
+	defer rdb.Close()
+
+	bus, err := events.NewNATSBus(cfg.Nats, logger)
+	if err != nil {
+		return fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	defer bus.Close()
+
+	queries := repo.New(dbPool)
+	indexer := indexing.NewClient(cfg.TypesenseKey, cfg.TypesenseURL, logger)
+	writer := events.NewEventWriter(bus, cfg.EventsConfig, logger)
+	countersSvc := counters.NewService(queries, dbPool, counters.NewRedisStore(rdb), counters.NewRedisReceiptStore(rdb), counters.NewRedisClickStore(rdb), writer, logger, cfg.DownloadRetention)
+	reconciler := counters.NewReconciler(queries, readerFor(replicaPool), countersSvc, indexer, logger, cfg.CounterReconcile)
+
+	var report counters.ReconcileReport
+	err = lock.New(rdb, logger).RunExclusive(ctx, "counter-flush", func(ctx context.Context) error {
+		report, err = reconciler.Run(ctx, *listingID)
+		return err
+	})
+	if errors.Is(err, lock.ErrNotAcquired) {
+		return fmt.Errorf("a counter flush or reconcile is running, try again shortly")
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Reconcile report", "report", report)
+	return nil
+}
+
+// runBackfill fills in search fields added since listings were indexed, e.g. `listings-worker backfill --fields views_count,file_formats`.
+// The last finished listing is saved to --checkpoint after every page, rerunning the same command picks up from there.
+func runBackfill(logger *slog.Logger, args []string) error {
+	cfg := loadConfig()
+
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	fields := fs.String("fields", "", "Comma separated search fields to backfill")
+	batchSize := fs.Int("batch-size", 200, "Listings fetched per page")
+	checkpoint := fs.String("checkpoint", "backfill.checkpoint", "File the progress is saved to, removed once the backfill finishes")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	opts := indexing.BackfillOptions{BatchSize: *batchSize}
+	for _, field := range strings.Split(*fields, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			opts.Fields = append(opts.Fields, field)
+		}
+	}
+
+	if saved, err := os.ReadFile(*checkpoint); err == nil {
+		if err := opts.After.Scan(strings.TrimSpace(string(saved))); err != nil {
+			return fmt.Errorf("invalid checkpoint in %s: %w", *checkpoint, err)
+		}
+		logger.Info("Resuming backfill from checkpoint", "checkpoint", *checkpoint, "after", opts.After.String())
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	opts.Progress = func(progress indexing.BackfillProgress) {
+		if err := os.WriteFile(*checkpoint, []byte(progress.LastID.String()), 0o644); err != nil {
+			logger.Error("Failed to save backfill checkpoint", "checkpoint", *checkpoint, "error", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	dbPool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to db: %w", err)
+	}
+	defer dbPool.Close()
+
+	replicaPool, err := openReplica(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+	if replicaPool != nil {
+		defer replicaPool.Close()
+	}
+
+	indexer := indexing.NewClient(cfg.TypesenseKey, cfg.TypesenseURL, logger)
+	svc := indexing.NewServiceWithReader(indexer, repo.New(dbPool), readerFor(replicaPool), logger, cfg.PublicURLs)
+
+	if _, err := svc.Backfill(ctx, opts); err != nil {
+		return err
+	}
+	// Nothing is saved when there were no listings to go through
+	if err := os.Remove(*checkpoint); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove checkpoint: %w", err)
+	}
+	return nil
+}
+
+// runIndexOne builds one listing's document and indexes it the way an event would, e.g. `listings-worker --index-one <id> --dry-run`.
+// The document goes to stdout and debug logs to stderr, so the output can be piped straight into jq.
+func runIndexOne(listingID string, dryRun bool) error {
+	cfg := loadConfig()
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	slog.SetDefault(logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	dbPool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to db: %w", err)
+	}
+	defer dbPool.Close()
+
+	indexer := indexing.NewClient(cfg.TypesenseKey, cfg.TypesenseURL, logger)
+	svc := indexing.NewService(indexer, repo.New(dbPool), logger, cfg.PublicURLs)
+
+	document, action, err := svc.Build(ctx, indexing.EntityListing, listingID)
+	if err != nil {
+		return fmt.Errorf("failed to build document: %w", err)
+	}
+	logger.Info("Built listing document", "listing_id", listingID, "action", action.String(), "dry_run", dryRun)
+
+	if action == indexing.ActionUpsert {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(document); err != nil {
+			return fmt.Errorf("failed to print document: %w", err)
+		}
+	}
+	if action == indexing.ActionSkip {
+		// Acked in the worker, but here it's the answer to "why isn't it indexed", the reason is in the logs above
+		return fmt.Errorf("listing %s was skipped", listingID)
+	}
+	if dryRun {
+		return nil
+	}
+	return svc.Apply(ctx, indexing.EntityListing, listingID, document, action)
+}
+
+// runPeriodically calls fn every interval until ctx is cancelled.
+func runPeriodically(ctx context.Context, interval time.Duration, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn()
+		}
+	}
+}
+
+// runExclusivePeriodically runs job every interval on whichever replica holds its lock, the others skip that tick.
+func runExclusivePeriodically(ctx context.Context, locker *lock.Locker, logger *slog.Logger, name string, interval time.Duration, job func(ctx context.Context) error) {
+	runPeriodically(ctx, interval, func() {
+		err := locker.RunExclusive(ctx, name, job)
+		switch {
+		case errors.Is(err, lock.ErrNotAcquired):
+			logger.Debug("Skipping job, another replica is running it", "job", name)
+		case err != nil:
+			logger.Error("Scheduled job failed", "job", name, "error", err)
+		}
+	})
+}
+
+// openReplica connects to DATABASE_REPLICA_URL, nil when it isn't set and the scans read from the primary
+func openReplica(ctx context.Context, cfg Config, logger *slog.Logger) (*pgxpool.Pool, error) {
+	if cfg.ReplicaDatabaseURL == "" {
+		return nil, nil
+	}
+	pool, err := pgxpool.New(ctx, cfg.ReplicaDatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to replica db: %w", err)
+	}
+	logger.Info("Reading bulk scans from the replica database")
+	return pool, nil
+}
+
+// readerFor is the repository the scans read from, nil without a replica so the services fall back to the primary
+func readerFor(replica *pgxpool.Pool) repo.Querier {
+	if replica == nil {
+		return nil
+	}
+	return repo.New(replica)
+}
+
+// healthMux serves the health check, Prometheus metrics and the queue admin endpoints
+func healthMux(db *pgxpool.Pool, replica *pgxpool.Pool, bus events.Bus, queuesHandler *queues.Handler, build version.Info) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	queuesHandler.Register(mux)
+	mux.Handle("/health/detail", healthDetailHandler(db, replica, build))
+	mux.Handle("/", healthHandler(db, bus))
+	return mux
+}
+
 // healthHandler provides a simple /healthz endpoint
 func healthHandler(db *pgxpool.Pool, bus events.Bus) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -169,3 +863,49 @@ func healthHandler(db *pgxpool.Pool, bus events.Bus) http.HandlerFunc {
 		w.Write([]byte("OK"))
 	}
 }
+
+// healthDetail is what /health/detail reports. The replica only slows the scans down when it's behind or gone, it
+// never fails the probe.
+type healthDetail struct {
+	Status   string         `json:"status"`
+	Database string         `json:"database"`
+	Replica  *replicaHealth `json:"replica,omitempty"`
+	Build    version.Info   `json:"build"`
+}
+
+type replicaHealth struct {
+	Status     string  `json:"status"`
+	LagSeconds float64 `json:"lag_seconds"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// healthDetailHandler reports the build and the databases, including how far the replica is behind the primary
+func healthDetailHandler(db *pgxpool.Pool, replica *pgxpool.Pool, build version.Info) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+
+		detail := healthDetail{Status: "ok", Database: "ok", Build: build}
+		status := http.StatusOK
+		if err := db.Ping(ctx); err != nil {
+			detail.Status, detail.Database = "unavailable", "unavailable"
+			status = http.StatusServiceUnavailable
+		}
+
+		if replica != nil {
+			detail.Replica = &replicaHealth{Status: "ok"}
+			lag, err := postgresql.ReplicaLag(ctx, repo.New(replica))
+			if err != nil {
+				detail.Replica.Status, detail.Replica.Error = "unavailable", err.Error()
+				if status == http.StatusOK {
+					detail.Status = "degraded"
+				}
+			}
+			detail.Replica.LagSeconds = lag.Seconds()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(detail)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"indexer/internal/auth"
+	"indexer/internal/database/postgresql"
+	"indexer/internal/indexing"
+	"indexer/internal/storage"
+	"net/http"
+	"shared/preflight"
+	"slices"
+	"strings"
+	"time"
+)
+
+// preflightTimeout bounds each check
+const preflightTimeout = 10 * time.Second
+
+// preflightChecks is everything the worker needs from its environment before it starts consuming
+func preflightChecks(db preflight.QueryRower, store storage.Provider, indexer fieldLister, bus preflight.StreamFinder, config Config) []preflight.Check {
+	cfg := config.EventsConfig
+	checks := []preflight.Check{
+		preflight.SchemaVersion(db, postgresql.SchemaVersion),
+		// The fields written outside a full document upsert, by the counter updates and backfills
+		searchCollectionCheck(indexer, "listings", "title", "categories", "downloads_count", "views_count"),
+	}
+
+	// Purge deletes listing images and product files
+	if prober, ok := store.(bucketProber); ok {
+		checks = append(checks, bucketChecks(prober, storage.BucketPublic, storage.BucketProduct)...)
+	}
+
+	subjects := map[string]string{
+		"EVENT_INDEX_LISTING":     cfg.IndexListing,
+		"EVENT_LISTING_COUNTERS":  cfg.ListingCounters,
+		"EVENT_LISTING_CREATED":   cfg.ListingCreated,
+		"EVENT_LISTING_PUBLISHED": cfg.ListingPublished,
+	}
+	// Optional, index failures are only logged without it
+	if cfg.ListingIndexFailed != "" {
+		subjects["EVENT_LISTING_INDEX_FAILED"] = cfg.ListingIndexFailed
+	}
+	if cfg.SavedSearchMatched != "" {
+		subjects["EVENT_SAVED_SEARCH_MATCHED"] = cfg.SavedSearchMatched
+	}
+	if cfg.DeleteListing != "" {
+		subjects["EVENT_DELETE_LISTING"] = cfg.DeleteListing
+	}
+	checks = append(checks, preflight.Subjects(bus, "fix the variable or the stream subjects in events.NewNATSBus", subjects))
+
+	// Only once the worker has somewhere to call with its service account
+	if config.ServiceAccount.Enabled() && config.GatewayInternalURL != "" {
+		client := auth.NewClient(auth.NewClientCredentials(config.ServiceAccount, nil), preflightTimeout)
+		checks = append(checks, serviceAccountCheck(client, config.GatewayInternalURL))
+	}
+
+	return checks
+}
+
+// fieldLister is the search indexer, see indexing.Indexer
+type fieldLister interface {
+	Fields(ctx context.Context, collectionName string) ([]string, error)
+}
+
+// searchCollectionCheck checks the collection, or the alias in front of it, has the fields the worker writes or
+// updates on their own
+func searchCollectionCheck(indexer fieldLister, collection string, fields ...string) preflight.Check {
+	return preflight.Check{
+		Name: "search collection " + collection,
+		Run: func(ctx context.Context) error {
+			schema, err := indexer.Fields(ctx, collection)
+			switch {
+			case errors.Is(err, indexing.ErrNotFound):
+				return fmt.Errorf("%q does not exist, create it with infrastructure/typesense-migrations", collection)
+			case err != nil:
+				return fmt.Errorf("failed to read the %q schema (%w), check TYPESENSE_URL and TYPESENSE_API_KEY", collection, err)
+			}
+
+			var missing []string
+			for _, field := range fields {
+				if !slices.Contains(schema, field) {
+					missing = append(missing, field)
+				}
+			}
+			if len(missing) > 0 {
+				return fmt.Errorf("%q has no %s field(s), run the pending infrastructure/typesense-migrations",
+					collection, strings.Join(missing, ", "))
+			}
+			return nil
+		},
+	}
+}
+
+// bucketProber is the storage provider, see storage.MinioProvider
+type bucketProber interface {
+	// ProbeBucket fails unless bucket exists and objects can be deleted from it
+	ProbeBucket(ctx context.Context, bucket storage.Bucket) error
+}
+
+// bucketChecks checks each bucket is there and the worker may delete from it, one check per bucket
+func bucketChecks(store bucketProber, buckets ...storage.Bucket) []preflight.Check {
+	checks := make([]preflight.Check, 0, len(buckets))
+	for _, bucket := range buckets {
+		checks = append(checks, preflight.Check{
+			Name: "bucket " + string(bucket),
+			Run: func(ctx context.Context) error {
+				if err := store.ProbeBucket(ctx, bucket); err != nil {
+					return fmt.Errorf("%q is missing or not deletable (%w), create it and check S3_ENDPOINT and the worker's S3 credentials (LISTINGS_WORKER_S3_*) can delete in it",
+						bucket, err)
+				}
+				return nil
+			},
+		})
+	}
+	return checks
+}
+
+// doer is an http.Client signing requests with the worker's service account token, see auth.NewClient
+type doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// serviceAccountCheck checks the gateway accepts the worker's service account token on its internal routes
+func serviceAccountCheck(client doer, gatewayURL string) preflight.Check {
+	return preflight.Check{
+		Name: "gateway service account",
+		Run: func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(gatewayURL, "/")+"/internal/whoami", nil)
+			if err != nil {
+				return fmt.Errorf("invalid GATEWAY_INTERNAL_URL: %w", err)
+			}
+			res, err := client.Do(req)
+			if err != nil {
+				return fmt.Errorf("failed to call the gateway (%w), check GATEWAY_INTERNAL_URL and the LISTINGS_WORKER_CLIENT_* credentials", err)
+			}
+			defer res.Body.Close()
+
+			switch res.StatusCode {
+			case http.StatusOK:
+				return nil
+			case http.StatusUnauthorized:
+				return fmt.Errorf("the gateway refused the token, add LISTINGS_WORKER_CLIENT_ID to its AUTHORIZATION_SERVICE_CLIENTS")
+			case http.StatusForbidden:
+				return fmt.Errorf("the service account has no service role, grant it the \"service\" realm role in Keycloak")
+			default:
+				return fmt.Errorf("the gateway answered %d", res.StatusCode)
+			}
+		},
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"indexer/internal/indexing"
+	"indexer/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"shared/preflight"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeIndexer struct {
+	fields []string
+	err    error
+}
+
+func (f fakeIndexer) Fields(ctx context.Context, collectionName string) ([]string, error) {
+	return f.fields, f.err
+}
+
+type fakeStorage struct{ denied map[storage.Bucket]bool }
+
+func (f fakeStorage) ProbeBucket(ctx context.Context, bucket storage.Bucket) error {
+	if f.denied[bucket] {
+		return storage.ErrAccessDenied
+	}
+	return nil
+}
+
+func runOne(t *testing.T, check preflight.Check) error {
+	t.Helper()
+	return preflight.Run(context.Background(), time.Second, []preflight.Check{check})
+}
+
+func TestSearchCollectionCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		indexer fakeIndexer
+		wantErr string
+	}{
+		{name: "Current", indexer: fakeIndexer{fields: []string{"id", "title", "views_count"}}},
+		{name: "Missing", indexer: fakeIndexer{err: indexing.ErrNotFound}, wantErr: `"listings" does not exist`},
+		// The collection predates a typesense migration that added views_count
+		{name: "Missing fields", indexer: fakeIndexer{fields: []string{"id", "title"}}, wantErr: "no views_count field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runOne(t, searchCollectionCheck(tt.indexer, "listings", "title", "views_count"))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.Contains(t, err.Error(), "infrastructure/typesense-migrations")
+		})
+	}
+}
+
+func TestBucketChecks(t *testing.T) {
+	checks := bucketChecks(fakeStorage{denied: map[storage.Bucket]bool{storage.BucketProduct: true}}, storage.BucketPublic, storage.BucketProduct)
+
+	err := preflight.Run(context.Background(), time.Second, checks)
+
+	var perr *preflight.Error
+	require.ErrorAs(t, err, &perr)
+	require.Len(t, perr.Failures, 1)
+	assert.Equal(t, "bucket "+string(storage.BucketProduct), perr.Failures[0].Check)
+	assert.ErrorIs(t, perr.Failures[0].Err, storage.ErrAccessDenied)
+}
+
+func TestServiceAccountCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr string
+	}{
+		{name: "Accepted", status: http.StatusOK},
+		{name: "Client not trusted", status: http.StatusUnauthorized, wantErr: "AUTHORIZATION_SERVICE_CLIENTS"},
+		{name: "No service role", status: http.StatusForbidden, wantErr: `"service" realm role`},
+		{name: "Gateway down", status: http.StatusServiceUnavailable, wantErr: "503"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/internal/whoami", r.URL.Path)
+				w.WriteHeader(tt.status)
+			}))
+			defer gateway.Close()
+
+			err := runOne(t, serviceAccountCheck(gateway.Client(), gateway.URL+"/"))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Package auth gives the worker an identity for calls back into the gateway: a Keycloak service account token from
+// the client credentials grant, cached until shortly before it expires. The gateway only accepts it on /internal/.
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRefreshMargin is how long before expiry a token is replaced, so one never runs out in the middle of a request
+const maxRefreshMargin = 30 * time.Second
+
+// Config is the worker's Keycloak client, its service account needs the "service" realm role
+type Config struct {
+	// IssuerURL is the realm, e.g. https://auth.example.com/realms/marketplace, the same AUTHORIZATION_URL the gateway has
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+}
+
+// Enabled is false until a client is configured, callers skip the internal routes without one
+func (c Config) Enabled() bool {
+	return c.IssuerURL != "" && c.ClientID != "" && c.ClientSecret != ""
+}
+
+func (c Config) tokenURL() string {
+	return strings.TrimRight(c.IssuerURL, "/") + "/protocol/openid-connect/token"
+}
+
+// ClientCredentials hands out the service account token, fetching a new one when the cached one is close to expiry.
+// Safe for concurrent use, callers waiting on a refresh share its result.
+type ClientCredentials struct {
+	config Config
+	client *http.Client
+	now    func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	refreshAt time.Time
+}
+
+func NewClientCredentials(config Config, client *http.Client) *ClientCredentials {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &ClientCredentials{
+		config: config,
+		client: client,
+		now:    time.Now,
+	}
+}
+
+// tokenResponse is the part of Keycloak's token endpoint response the worker needs
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"` // Seconds
+	TokenType   string `json:"token_type"`
+}
+
+// Token returns a token valid for at least a little longer, fetching one if needed
+func (c *ClientCredentials) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && c.now().Before(c.refreshAt) {
+		return c.token, nil
+	}
+
+	issuedAt := c.now()
+	resp, err := c.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	lifetime := time.Duration(resp.ExpiresIn) * time.Second
+	c.token = resp.AccessToken
+	c.refreshAt = issuedAt.Add(lifetime - min(maxRefreshMargin, lifetime/2))
+	return c.token, nil
+}
+
+// Invalidate drops the cached token, e.g. after the gateway answered 401 because Keycloak rotated its keys
+func (c *ClientCredentials) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = ""
+}
+
+func (c *ClientCredentials) fetch(ctx context.Context) (*tokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", c.config.ClientID)
+	form.Set("client_secret", c.config.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.tokenURL(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request service account token: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		// Keycloak's error body says which of the client ID and secret is wrong, it never echoes the secret
+		return nil, fmt.Errorf("token endpoint answered %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("invalid token response: %w", err)
+	}
+	if token.AccessToken == "" || token.ExpiresIn <= 0 {
+		return nil, errors.New("token response has no access token or expiry")
+	}
+	return &token, nil
+}
+
+// Transport signs every request with the service account token. A 401 drops the cached token so the next request
+// fetches a fresh one, the request itself isn't retried since its body may already be spent.
+type Transport struct {
+	Source *ClientCredentials
+	// Base makes the actual request, http.DefaultTransport when nil
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.Source.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	// RoundTrippers must not change the request they were given
+	signed := req.Clone(req.Context())
+	signed.Header.Set("Authorization", "Bearer "+token)
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	res, err := base.RoundTrip(signed)
+	if err == nil && res.StatusCode == http.StatusUnauthorized {
+		t.Source.Invalidate()
+	}
+	return res, err
+}
+
+// NewClient is an http.Client for the gateway's internal routes
+func NewClient(source *ClientCredentials, timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: &Transport{Source: source}}
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKeycloak is the token endpoint of a realm, it numbers the tokens it issues
+type fakeKeycloak struct {
+	server    *httptest.Server
+	issued    atomic.Int32
+	expiresIn int64
+	status    int
+}
+
+func newFakeKeycloak(t *testing.T) *fakeKeycloak {
+	k := &fakeKeycloak{expiresIn: 300, status: http.StatusOK}
+	k.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/realms/marketplace/protocol/openid-connect/token" || r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		if r.FormValue("grant_type") != "client_credentials" || r.FormValue("client_id") != "listings-worker" || r.FormValue("client_secret") != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"unauthorized_client","error_description":"Invalid client or Invalid client credentials"}`)
+			return
+		}
+		if k.status != http.StatusOK {
+			w.WriteHeader(k.status)
+			return
+		}
+		n := k.issued.Add(1)
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":%d,"token_type":"Bearer"}`, n, k.expiresIn)
+	}))
+	t.Cleanup(k.server.Close)
+	return k
+}
+
+func (k *fakeKeycloak) config() Config {
+	return Config{IssuerURL: k.server.URL + "/realms/marketplace/", ClientID: "listings-worker", ClientSecret: "s3cret"}
+}
+
+// clock is a settable now
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func newTestCredentials(k *fakeKeycloak) (*ClientCredentials, *clock) {
+	c := &clock{t: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	creds := NewClientCredentials(k.config(), k.server.Client())
+	creds.now = c.now
+	return creds, c
+}
+
+func TestToken_CachedUntilCloseToExpiry(t *testing.T) {
+	// SCENARIO: A 5 minute token is asked for repeatedly as time passes.
+	// EXPECT: One fetch until 30 seconds before it expires, then a new token.
+
+	k := newFakeKeycloak(t)
+	creds, clock := newTestCredentials(k)
+	ctx := context.Background()
+
+	token, err := creds.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	clock.t = clock.t.Add(4*time.Minute + 29*time.Second)
+	token, err = creds.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	clock.t = clock.t.Add(time.Second)
+	token, err = creds.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token)
+	assert.EqualValues(t, 2, k.issued.Load())
+}
+
+func TestToken_ShortLivedRefreshedAtHalfLife(t *testing.T) {
+	k := newFakeKeycloak(t)
+	k.expiresIn = 20
+	creds, clock := newTestCredentials(k)
+
+	_, err := creds.Token(context.Background())
+	require.NoError(t, err)
+	clock.t = clock.t.Add(9 * time.Second)
+	token, _ := creds.Token(context.Background())
+	assert.Equal(t, "token-1", token)
+
+	clock.t = clock.t.Add(time.Second)
+	token, _ = creds.Token(context.Background())
+	assert.Equal(t, "token-2", token)
+}
+
+func TestToken_ConcurrentCallersShareAFetch(t *testing.T) {
+	k := newFakeKeycloak(t)
+	creds, _ := newTestCredentials(k)
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := creds.Token(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, "token-1", token)
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, k.issued.Load())
+}
+
+func TestToken_Errors(t *testing.T) {
+	k := newFakeKeycloak(t)
+
+	wrongSecret := k.config()
+	wrongSecret.ClientSecret = "nope"
+	_, err := NewClientCredentials(wrongSecret, k.server.Client()).Token(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+	assert.Contains(t, err.Error(), "Invalid client credentials")
+	assert.NotContains(t, err.Error(), "nope")
+
+	k.status = http.StatusServiceUnavailable
+	creds, _ := newTestCredentials(k)
+	_, err = creds.Token(context.Background())
+	assert.Error(t, err)
+}
+
+func TestTransport(t *testing.T) {
+	// SCENARIO: The gateway accepts the first token, then answers 401 as if Keycloak had rotated its keys.
+	// EXPECT: Every request carries the bearer token, and the one after the 401 has a fresh token.
+
+	k := newFakeKeycloak(t)
+	creds, _ := newTestCredentials(k)
+
+	var seen []string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("Authorization"))
+		if len(seen) == 2 {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(gateway.Close)
+
+	client := NewClient(creds, time.Second)
+	for range 3 {
+		res, err := client.Get(gateway.URL + "/internal/whoami")
+		require.NoError(t, err)
+		res.Body.Close()
+	}
+
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-1", "Bearer token-2"}, seen)
+}
+
+func TestConfig_Enabled(t *testing.T) {
+	assert.False(t, Config{}.Enabled())
+	assert.False(t, Config{IssuerURL: "https://auth.test/realms/marketplace", ClientID: "listings-worker"}.Enabled())
+	assert.True(t, Config{IssuerURL: "https://auth.test/realms/marketplace", ClientID: "listings-worker", ClientSecret: "s3cret"}.Enabled())
+}
@@ -0,0 +1,247 @@
+package callbacks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	repo "indexer/internal/database/postgresql/sqlc"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Event is the only callback sent so far
+const Event = "upload.validated"
+
+const (
+	firstRetryIn = time.Minute
+	maxRetryIn   = time.Hour
+)
+
+type Config struct {
+	// Secrets sign each destination's callbacks, see Sign. Callbacks to a host without one aren't sent.
+	Secrets Secrets
+	// BatchSize is how many due callbacks one pass looks at.
+	BatchSize int
+	// PerDestination caps deliveries to one host per pass, the rest wait for the next so one partner's burst of
+	// uploads can't hold up everyone else's. Applied by the due query, ahead of BatchSize. 0 disables the cap.
+	PerDestination int
+	// MaxAttempts is how many times a callback is tried before it is given up on.
+	MaxAttempts int
+	// DisableAfter is how many failures in a row turn a host off. Its pending callbacks stay queued and new uploads
+	// can't ask for it until support resets it.
+	DisableAfter int
+	// Timeout bounds each delivery.
+	Timeout time.Duration
+}
+
+// Payload is the JSON body of an upload.validated callback
+type Payload struct {
+	Event   string `json:"event"`
+	FileKey string `json:"file_key"`
+	Size    int64  `json:"size"`
+	Status  string `json:"status"`          // valid, invalid or failed
+	Error   string `json:"error,omitempty"` // Why the file isn't valid
+}
+
+// Report summarises a pass
+type Report struct {
+	Delivered int `json:"delivered"`
+	Failed    int `json:"failed"`
+	Abandoned int `json:"abandoned"`
+	Deferred  int `json:"deferred"` // Their host was turned off during the pass
+	Expired   int `json:"expired"`  // Never claimed by a listing
+}
+
+// Sends the callbacks uploads asked for at presign time once their files have been validated
+type Dispatcher struct {
+	repo   repo.Querier
+	client *http.Client
+	logger *slog.Logger
+	config Config
+	now    func() time.Time
+}
+
+func NewDispatcher(repo repo.Querier, logger *slog.Logger, config Config) *Dispatcher {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 8
+	}
+	if config.DisableAfter <= 0 {
+		config.DisableAfter = 20
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	return &Dispatcher{
+		repo: repo,
+		client: &http.Client{
+			Timeout: config.Timeout,
+			// The allowlist is for the URL the partner gave, a redirect could point anywhere
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		logger: logger,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Deliver sends the callbacks that are due. A failed one is retried with backoff until MaxAttempts, and counts
+// against its host, which is turned off after DisableAfter failures in a row.
+func (d *Dispatcher) Deliver(ctx context.Context) (Report, error) {
+	var report Report
+
+	expired, err := d.repo.DeleteExpiredUploadCallbacks(ctx)
+	if err != nil {
+		d.logger.Error("Failed to delete expired upload callbacks", "error", err)
+	}
+	report.Expired = int(expired)
+
+	now := d.now()
+	due, err := d.repo.GetDueUploadCallbacks(ctx, repo.GetDueUploadCallbacksParams{
+		Now:            pgtype.Timestamptz{Time: now, Valid: true},
+		PerDestination: int32(d.config.PerDestination),
+		BatchSize:      int32(d.config.BatchSize),
+	})
+	if err != nil {
+		return report, fmt.Errorf("failed to fetch due upload callbacks: %w", err)
+	}
+
+	disabled := map[string]bool{}
+	for _, callback := range due {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if disabled[callback.Destination] {
+			report.Deferred++
+			continue
+		}
+
+		if err := d.send(ctx, callback); err != nil {
+			d.logger.Warn("Upload callback failed", "destination", callback.Destination, "file_key", callback.StorageKey, "attempt", callback.Attempts+1, "error", err)
+			if d.recordFailure(ctx, callback, err, now, &report) {
+				disabled[callback.Destination] = true
+			}
+			continue
+		}
+
+		deliveriesTotal.WithLabelValues("delivered").Inc()
+		report.Delivered++
+		if err := d.repo.MarkUploadCallbackDelivered(ctx, repo.MarkUploadCallbackDeliveredParams{
+			Now:        pgtype.Timestamptz{Time: d.now(), Valid: true},
+			StorageKey: callback.StorageKey,
+		}); err != nil {
+			// It goes again on the next pass, receivers dedupe on file_key
+			d.logger.Error("Failed to mark upload callback delivered", "file_key", callback.StorageKey, "error", err)
+		}
+		if err := d.repo.ResetCallbackDestination(ctx, callback.Destination); err != nil {
+			d.logger.Error("Failed to reset callback destination", "destination", callback.Destination, "error", err)
+		}
+	}
+
+	if len(due) > 0 || report.Expired > 0 {
+		d.logger.Info("Sent upload callbacks", "report", report)
+	}
+	return report, nil
+}
+
+// send POSTs the signed payload, anything but a 2xx is a failure
+func (d *Dispatcher) send(ctx context.Context, callback repo.GetDueUploadCallbacksRow) error {
+	// The gateway refuses callback URLs without a secret, this is one whose secret has since been removed
+	secret := d.config.Secrets.For(callback.Destination)
+	if secret == nil {
+		return fmt.Errorf("no UPLOAD_CALLBACK_SECRETS entry for %s", callback.Destination)
+	}
+
+	body, err := json.Marshal(payload(callback))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callback.CallbackUrl, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "printing-marketplace-callbacks")
+	req.Header.Set(SignatureHeader, Sign(secret, d.now().Unix(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Drained so the connection can be reused, receivers shouldn't be sending much back
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("callback answered %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// recordFailure schedules the next attempt, or gives up on the callback, and counts the failure against its host.
+// Returns whether the host is now turned off.
+func (d *Dispatcher) recordFailure(ctx context.Context, callback repo.GetDueUploadCallbacksRow, cause error, now time.Time, report *Report) bool {
+	abandon := int(callback.Attempts)+1 >= d.config.MaxAttempts
+	if abandon {
+		deliveriesTotal.WithLabelValues("abandoned").Inc()
+		report.Abandoned++
+	} else {
+		deliveriesTotal.WithLabelValues("failed").Inc()
+		report.Failed++
+	}
+
+	if err := d.repo.RecordUploadCallbackFailure(ctx, repo.RecordUploadCallbackFailureParams{
+		LastError:     pgtype.Text{String: cause.Error(), Valid: true},
+		NextAttemptAt: pgtype.Timestamptz{Time: now.Add(RetryIn(int(callback.Attempts))), Valid: true},
+		Abandon:       abandon,
+		StorageKey:    callback.StorageKey,
+	}); err != nil {
+		d.logger.Error("Failed to record upload callback failure", "file_key", callback.StorageKey, "error", err)
+	}
+
+	disabled, err := d.repo.RecordCallbackDestinationFailure(ctx, repo.RecordCallbackDestinationFailureParams{
+		Destination:  callback.Destination,
+		DisableAfter: int32(d.config.DisableAfter),
+	})
+	if err != nil {
+		d.logger.Error("Failed to record callback destination failure", "destination", callback.Destination, "error", err)
+		return false
+	}
+	if disabled {
+		destinationsDisabledTotal.Inc()
+		d.logger.Warn("Callback destination turned off after repeated failures", "destination", callback.Destination)
+	}
+	return disabled
+}
+
+// RetryIn is how long after its attempts-th failure (counting from 0) a callback is tried again, doubling from a
+// minute up to an hour
+func RetryIn(attempts int) time.Duration {
+	if attempts >= 6 { // 2^6 minutes is past the cap already
+		return maxRetryIn
+	}
+	return min(firstRetryIn<<attempts, maxRetryIn)
+}
+
+func payload(callback repo.GetDueUploadCallbacksRow) Payload {
+	p := Payload{
+		Event:   Event,
+		FileKey: callback.StorageKey,
+		Size:    callback.FileSize.Int64,
+		Status:  strings.ToLower(string(callback.Status.FileStatus)),
+	}
+	if callback.Status.FileStatus != repo.FileStatusVALID {
+		p.Error = callback.ErrorMessage.String
+	}
+	return p
+}
@@ -0,0 +1,240 @@
+package callbacks_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"indexer/internal/callbacks"
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/mocks/mockrepo"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	secret  = []byte("whsec_test")
+	secrets = callbacks.Secrets{"slicer.test": secret, "other.test": []byte("whsec_other")}
+)
+
+// received is one request the fake receiver got
+type received struct {
+	signature string
+	body      []byte
+}
+
+// newReceiver answers every callback with status and records what it was sent
+func newReceiver(t *testing.T, status int) (*httptest.Server, *[]received) {
+	t.Helper()
+	var got []received
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = append(got, received{signature: r.Header.Get(callbacks.SignatureHeader), body: body})
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &got
+}
+
+func dueCallback(url, key string, attempts int32, status repo.FileStatus, errorMessage string) repo.GetDueUploadCallbacksRow {
+	return repo.GetDueUploadCallbacksRow{
+		StorageKey:   key,
+		CallbackUrl:  url,
+		Destination:  "hooks.slicer.test",
+		Attempts:     attempts,
+		FileSize:     pgtype.Int8{Int64: 1024, Valid: true},
+		Status:       repo.NullFileStatus{FileStatus: status, Valid: true},
+		ErrorMessage: pgtype.Text{String: errorMessage, Valid: errorMessage != ""},
+	}
+}
+
+func TestSign(t *testing.T) {
+	// Receivers in other languages check against this, it must not change
+	assert.Equal(t,
+		"t=1792137600,v1=c9cfbe0185f784e5df2a0ae5c4045c24bfb39784b65c609ba0ff2d6056c919af",
+		callbacks.Sign(secret, 1792137600, []byte(`{"event":"upload.validated"}`)))
+
+	assert.NotEqual(t, callbacks.Sign(secret, 1792137600, []byte(`{}`)), callbacks.Sign(secret, 1792137601, []byte(`{}`)), "timestamp is signed")
+	assert.NotEqual(t, callbacks.Sign(secret, 1792137600, []byte(`{}`)), callbacks.Sign([]byte("other"), 1792137600, []byte(`{}`)))
+}
+
+func TestRetryIn(t *testing.T) {
+	assert.Equal(t, time.Minute, callbacks.RetryIn(0))
+	assert.Equal(t, 4*time.Minute, callbacks.RetryIn(2))
+	assert.Equal(t, time.Hour, callbacks.RetryIn(6))
+	assert.Equal(t, time.Hour, callbacks.RetryIn(100))
+}
+
+func TestDeliver_Signed(t *testing.T) {
+	// SCENARIO: Two validated uploads are due, one valid and one rejected.
+	// EXPECT: Each is POSTed with a signature over its exact body, then marked delivered and its host's failures reset.
+
+	server, got := newReceiver(t, http.StatusNoContent)
+	mockRepo := mockrepo.NewQuerier(t)
+	mockRepo.EXPECT().DeleteExpiredUploadCallbacks(mock.Anything).Return(0, nil)
+	mockRepo.EXPECT().GetDueUploadCallbacks(mock.Anything, mock.MatchedBy(func(arg repo.GetDueUploadCallbacksParams) bool {
+		return arg.BatchSize == 100 && arg.PerDestination == 10 && arg.Now.Valid
+	})).Return([]repo.GetDueUploadCallbacksRow{
+		dueCallback(server.URL+"/uploads", "models/benchy.stl", 0, repo.FileStatusVALID, ""),
+		dueCallback(server.URL+"/uploads", "models/broken.stl", 0, repo.FileStatusINVALID, "Model isn't manifold"),
+	}, nil)
+	mockRepo.EXPECT().MarkUploadCallbackDelivered(mock.Anything, mock.Anything).Return(nil).Twice()
+	mockRepo.EXPECT().ResetCallbackDestination(mock.Anything, "hooks.slicer.test").Return(nil).Twice()
+
+	dispatcher := callbacks.NewDispatcher(mockRepo, slog.Default(), callbacks.Config{Secrets: secrets, PerDestination: 10})
+	report, err := dispatcher.Deliver(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, callbacks.Report{Delivered: 2}, report)
+	require.Len(t, *got, 2)
+
+	var payloads []callbacks.Payload
+	for _, r := range *got {
+		ts, _, ok := strings.Cut(strings.TrimPrefix(r.signature, "t="), ",")
+		require.True(t, ok, r.signature)
+		timestamp, err := strconv.ParseInt(ts, 10, 64)
+		require.NoError(t, err)
+		assert.Equal(t, callbacks.Sign(secret, timestamp, r.body), r.signature)
+		assert.WithinDuration(t, time.Now(), time.Unix(timestamp, 0), time.Minute)
+
+		var p callbacks.Payload
+		require.NoError(t, json.Unmarshal(r.body, &p))
+		payloads = append(payloads, p)
+	}
+	assert.Equal(t, []callbacks.Payload{
+		{Event: "upload.validated", FileKey: "models/benchy.stl", Size: 1024, Status: "valid"},
+		{Event: "upload.validated", FileKey: "models/broken.stl", Size: 1024, Status: "invalid", Error: "Model isn't manifold"},
+	}, payloads)
+}
+
+func TestDeliver_FailureRetriedThenAbandoned(t *testing.T) {
+	// SCENARIO: The receiver errors, for a callback on its first attempt and one on its last.
+	// EXPECT: The first is rescheduled a minute out, the second is given up on, and both count against the host.
+
+	server, _ := newReceiver(t, http.StatusInternalServerError)
+	mockRepo := mockrepo.NewQuerier(t)
+	mockRepo.EXPECT().DeleteExpiredUploadCallbacks(mock.Anything).Return(0, nil)
+	mockRepo.EXPECT().GetDueUploadCallbacks(mock.Anything, mock.Anything).Return([]repo.GetDueUploadCallbacksRow{
+		dueCallback(server.URL, "models/first.stl", 0, repo.FileStatusVALID, ""),
+		dueCallback(server.URL, "models/last.stl", 2, repo.FileStatusVALID, ""),
+	}, nil)
+	failures := map[string]repo.RecordUploadCallbackFailureParams{}
+	mockRepo.EXPECT().RecordUploadCallbackFailure(mock.Anything, mock.Anything).
+		Run(func(_ context.Context, arg repo.RecordUploadCallbackFailureParams) { failures[arg.StorageKey] = arg }).
+		Return(nil)
+	mockRepo.EXPECT().RecordCallbackDestinationFailure(mock.Anything, repo.RecordCallbackDestinationFailureParams{
+		Destination: "hooks.slicer.test", DisableAfter: 20,
+	}).Return(false, nil).Twice()
+
+	dispatcher := callbacks.NewDispatcher(mockRepo, slog.Default(), callbacks.Config{Secrets: secrets, MaxAttempts: 3})
+	report, err := dispatcher.Deliver(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, callbacks.Report{Failed: 1, Abandoned: 1}, report)
+
+	first := failures["models/first.stl"]
+	assert.False(t, first.Abandon)
+	assert.Equal(t, "callback answered 500", first.LastError.String)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), first.NextAttemptAt.Time, 5*time.Second)
+	assert.True(t, failures["models/last.stl"].Abandon)
+}
+
+func TestDeliver_DisabledHostSkipped(t *testing.T) {
+	// SCENARIO: A failure takes the host to its limit of failures in a row, with more of its callbacks due.
+	// EXPECT: The rest aren't tried this pass, the due query leaves them out from then on.
+
+	server, got := newReceiver(t, http.StatusBadGateway)
+	mockRepo := mockrepo.NewQuerier(t)
+	mockRepo.EXPECT().DeleteExpiredUploadCallbacks(mock.Anything).Return(0, nil)
+	mockRepo.EXPECT().GetDueUploadCallbacks(mock.Anything, mock.Anything).Return([]repo.GetDueUploadCallbacksRow{
+		dueCallback(server.URL, "models/a.stl", 0, repo.FileStatusVALID, ""),
+		dueCallback(server.URL, "models/b.stl", 0, repo.FileStatusVALID, ""),
+	}, nil)
+	mockRepo.EXPECT().RecordUploadCallbackFailure(mock.Anything, mock.Anything).Return(nil).Once()
+	mockRepo.EXPECT().RecordCallbackDestinationFailure(mock.Anything, mock.Anything).Return(true, nil).Once()
+
+	dispatcher := callbacks.NewDispatcher(mockRepo, slog.Default(), callbacks.Config{Secrets: secrets})
+	report, err := dispatcher.Deliver(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, callbacks.Report{Failed: 1, Deferred: 1}, report)
+	assert.Len(t, *got, 1)
+}
+
+func TestDeliver_NoSecret(t *testing.T) {
+	// SCENARIO: A callback is due to a host whose secret was removed from UPLOAD_CALLBACK_SECRETS after it was asked for.
+	// EXPECT: It isn't sent, signed with another partner's secret or otherwise, and counts as a failure.
+
+	server, got := newReceiver(t, http.StatusOK)
+	callback := dueCallback(server.URL, "models/a.stl", 0, repo.FileStatusVALID, "")
+	callback.Destination = "hooks.unknown.test"
+
+	mockRepo := mockrepo.NewQuerier(t)
+	mockRepo.EXPECT().DeleteExpiredUploadCallbacks(mock.Anything).Return(0, nil)
+	mockRepo.EXPECT().GetDueUploadCallbacks(mock.Anything, mock.Anything).Return([]repo.GetDueUploadCallbacksRow{callback}, nil)
+	mockRepo.EXPECT().RecordUploadCallbackFailure(mock.Anything, mock.MatchedBy(func(arg repo.RecordUploadCallbackFailureParams) bool {
+		return arg.LastError.String == "no UPLOAD_CALLBACK_SECRETS entry for hooks.unknown.test"
+	})).Return(nil)
+	mockRepo.EXPECT().RecordCallbackDestinationFailure(mock.Anything, mock.Anything).Return(false, nil)
+
+	dispatcher := callbacks.NewDispatcher(mockRepo, slog.Default(), callbacks.Config{Secrets: secrets})
+	report, err := dispatcher.Deliver(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, callbacks.Report{Failed: 1}, report)
+	assert.Empty(t, *got)
+}
+
+func TestSecrets(t *testing.T) {
+	parsed, err := callbacks.ParseSecrets(`{" Slicer.test ": "whsec_a", "eu.slicer.test": "whsec_b", "other.test": "whsec_c"}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte("whsec_a"), parsed.For("slicer.test"))
+	assert.Equal(t, []byte("whsec_a"), parsed.For("hooks.slicer.test"), "a domain covers its subdomains")
+	assert.Equal(t, []byte("whsec_b"), parsed.For("hooks.eu.slicer.test"), "the most specific domain wins")
+	assert.Nil(t, parsed.For("notslicer.test"))
+	assert.Nil(t, parsed.For("test"))
+
+	for _, raw := range []string{`{"hooks.test/path": "whsec"}`, `{"*.slicer.test": "whsec"}`, `{"slicer.test": ""}`, `["whsec"]`} {
+		_, err := callbacks.ParseSecrets(raw)
+		assert.Error(t, err, raw)
+	}
+
+	parsed, err = callbacks.ParseSecrets("")
+	require.NoError(t, err)
+	assert.Empty(t, parsed)
+}
+
+func TestDeliver_RedirectNotFollowed(t *testing.T) {
+	// SCENARIO: The allowlisted host redirects the callback elsewhere.
+	// EXPECT: The redirect isn't followed, it counts as a failure.
+
+	elsewhere, got := newReceiver(t, http.StatusOK)
+	server := httptest.NewServer(http.RedirectHandler(elsewhere.URL, http.StatusTemporaryRedirect))
+	t.Cleanup(server.Close)
+
+	mockRepo := mockrepo.NewQuerier(t)
+	mockRepo.EXPECT().DeleteExpiredUploadCallbacks(mock.Anything).Return(0, nil)
+	mockRepo.EXPECT().GetDueUploadCallbacks(mock.Anything, mock.Anything).Return([]repo.GetDueUploadCallbacksRow{
+		dueCallback(server.URL, "models/a.stl", 0, repo.FileStatusVALID, ""),
+	}, nil)
+	mockRepo.EXPECT().RecordUploadCallbackFailure(mock.Anything, mock.Anything).Return(nil)
+	mockRepo.EXPECT().RecordCallbackDestinationFailure(mock.Anything, mock.Anything).Return(false, nil)
+
+	dispatcher := callbacks.NewDispatcher(mockRepo, slog.Default(), callbacks.Config{Secrets: secrets})
+	report, err := dispatcher.Deliver(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Failed)
+	assert.Empty(t, *got)
+}
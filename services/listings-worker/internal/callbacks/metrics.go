@@ -0,0 +1,18 @@
+package callbacks
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	deliveriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "listings_worker_upload_callback_deliveries_total",
+		Help: "Upload callback attempts by outcome (delivered, failed, abandoned).",
+	}, []string{"outcome"})
+
+	destinationsDisabledTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "listings_worker_upload_callback_destinations_disabled_total",
+		Help: "Callback hosts turned off after failing too many times in a row.",
+	})
+)
@@ -0,0 +1,69 @@
+package callbacks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SignatureHeader carries Sign's output on every callback
+const SignatureHeader = "X-Marketplace-Signature"
+
+// Sign is the SignatureHeader value for body sent at timestamp (unix seconds): "t=<timestamp>,v1=<hex HMAC-SHA256 of
+// "<timestamp>.<body>">". Receivers recompute it with their secret and should refuse old timestamps, the
+// timestamp is signed so a captured callback can't be replayed later with a fresh one.
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	ts := strconv.FormatInt(timestamp, 10)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Secrets maps a callback domain to the secret its callbacks are signed with, a domain covers its subdomains too. Each
+// partner only gets their own secret, so one can't sign a callback that another would believe.
+type Secrets map[string][]byte
+
+// ParseSecrets reads UPLOAD_CALLBACK_SECRETS, a JSON object of domain to secret:
+//
+//	{"hooks.slicer.example": "whsec_..."}
+//
+// An empty value turns callbacks off.
+func ParseSecrets(raw string) (Secrets, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var values map[string]string
+	if err := json.Unmarshal([]byte(raw), &values); err != nil {
+		return nil, err
+	}
+	secrets := make(Secrets, len(values))
+	for domain, secret := range values {
+		normalized := strings.ToLower(strings.TrimSpace(domain))
+		if normalized == "" || strings.ContainsAny(normalized, "/:*") {
+			return nil, fmt.Errorf("invalid domain %q, give a bare host such as hooks.example.com", domain)
+		}
+		if secret == "" {
+			return nil, fmt.Errorf("empty secret for %s", normalized)
+		}
+		secrets[normalized] = []byte(secret)
+	}
+	return secrets, nil
+}
+
+// For is the secret for callbacks to host, from the most specific domain covering it. nil when there's none.
+func (s Secrets) For(host string) []byte {
+	host = strings.ToLower(host)
+	for domain := host; domain != ""; {
+		if secret, ok := s[domain]; ok {
+			return secret
+		}
+		_, domain, _ = strings.Cut(domain, ".")
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package counters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	repo "indexer/internal/database/postgresql/sqlc"
+)
+
+// flushClicks adds pending short link clicks to Postgres, leftover batches first. Clicks aren't in the search index,
+// so unlike the listing counters there is nothing to publish.
+func (s *svc) flushClicks(ctx context.Context) (int, error) {
+	leftover, err := s.clicks.Batches(ctx)
+	if err != nil {
+		return 0, err
+	}
+	batchID, err := s.clicks.Claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if batchID != "" {
+		leftover = append(leftover, batchID)
+	}
+
+	total := 0
+	for _, batchID := range leftover {
+		n, err := s.applyClicks(ctx, batchID)
+		if err != nil {
+			flushFailuresTotal.Inc()
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// applyClicks records the batch ID in the same transaction as the increments, like applyBatch
+func (s *svc) applyClicks(ctx context.Context, batchID string) (int, error) {
+	raw, err := s.clicks.Read(ctx, batchID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Sorted so concurrent flushes lock rows in the same order
+	codes := make([]string, 0, len(raw))
+	for code := range raw {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := repo.New(tx)
+	recorded, err := qtx.RecordCounterFlush(ctx, batchID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record click batch: %w", err)
+	}
+
+	clicks := 0
+	if recorded == 0 {
+		s.logger.Info("Click batch already applied, skipping increments", "batch_id", batchID)
+		replayedBatchesTotal.Inc()
+	} else {
+		for _, code := range codes {
+			err := qtx.IncrementShortLinkClicks(ctx, repo.IncrementShortLinkClicksParams{
+				Clicks: clampInt32(raw[code]),
+				Code:   code,
+			})
+			if err != nil {
+				return 0, fmt.Errorf("failed to increment clicks for %s: %w", code, err)
+			}
+			clicks += int(raw[code])
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit click batch: %w", err)
+	}
+	flushedClicksTotal.Add(float64(clicks))
+
+	if err := s.clicks.Clear(ctx, batchID); err != nil {
+		return clicks, fmt.Errorf("failed to clear click batch: %w", err)
+	}
+	s.logger.Info("Flushed short link clicks", "batch_id", batchID, "links", len(codes), "clicks", clicks)
+	return clicks, nil
+}
@@ -0,0 +1,191 @@
+package counters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	repo "indexer/internal/database/postgresql/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// downloadsKey is the list of receipts the gateway pushes, one JSON Download per private file download
+	downloadsKey = "downloads:pending"
+	// downloadsBatchPrefix marks a list claimed by a flush, like batchPrefix for the counters
+	downloadsBatchPrefix = "downloads:batch:"
+)
+
+// Download is a receipt as the gateway writes it
+type Download struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"user_id,omitempty"` // Empty for anonymous downloads of free listings
+	ListingID    string    `json:"listing_id"`
+	FileID       string    `json:"file_id"`
+	DownloadedAt time.Time `json:"downloaded_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// ReceiptStore holds download receipts between flushes, claimed in batches the same way as PendingStore.
+type ReceiptStore interface {
+	Claim(ctx context.Context) (string, error)
+	Batches(ctx context.Context) ([]string, error)
+	// Read returns the receipts in a batch as the raw JSON the gateway pushed.
+	Read(ctx context.Context, batchID string) ([]string, error)
+	Clear(ctx context.Context, batchID string) error
+}
+
+type RedisReceiptStore struct {
+	rdb *redis.Client
+}
+
+func NewRedisReceiptStore(rdb *redis.Client) *RedisReceiptStore {
+	return &RedisReceiptStore{rdb: rdb}
+}
+
+func (s *RedisReceiptStore) Claim(ctx context.Context) (string, error) {
+	batchID := uuid.NewString()
+
+	err := s.rdb.Rename(ctx, downloadsKey, downloadsBatchPrefix+batchID).Err()
+	if err != nil {
+		if strings.Contains(err.Error(), "no such key") {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to claim pending downloads: %w", err)
+	}
+	return batchID, nil
+}
+
+func (s *RedisReceiptStore) Batches(ctx context.Context) ([]string, error) {
+	var batches []string
+
+	iter := s.rdb.Scan(ctx, 0, downloadsBatchPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		batches = append(batches, strings.TrimPrefix(iter.Val(), downloadsBatchPrefix))
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list download batches: %w", err)
+	}
+	return batches, nil
+}
+
+func (s *RedisReceiptStore) Read(ctx context.Context, batchID string) ([]string, error) {
+	raw, err := s.rdb.LRange(ctx, downloadsBatchPrefix+batchID, 0, -1).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to read download batch %s: %w", batchID, err)
+	}
+	return raw, nil
+}
+
+func (s *RedisReceiptStore) Clear(ctx context.Context, batchID string) error {
+	return s.rdb.Del(ctx, downloadsBatchPrefix+batchID).Err()
+}
+
+// flushDownloads writes every pending receipt to Postgres, leftover batches first. Receipts carry their own ID, so
+// a batch applied twice after a crash inserts each download once.
+func (s *svc) flushDownloads(ctx context.Context) (int, error) {
+	leftover, err := s.receipts.Batches(ctx)
+	if err != nil {
+		return 0, err
+	}
+	batchID, err := s.receipts.Claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if batchID != "" {
+		leftover = append(leftover, batchID)
+	}
+
+	total := 0
+	for _, batchID := range leftover {
+		n, err := s.applyDownloads(ctx, batchID)
+		if err != nil {
+			flushFailuresTotal.Inc()
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (s *svc) applyDownloads(ctx context.Context, batchID string) (int, error) {
+	raw, err := s.receipts.Read(ctx, batchID)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := repo.New(tx)
+
+	recorded := 0
+	for _, entry := range raw {
+		params, ok := s.downloadParams(batchID, entry)
+		if !ok {
+			continue
+		}
+		n, err := qtx.RecordDownload(ctx, params)
+		if err != nil {
+			return 0, fmt.Errorf("failed to record download %x: %w", params.ID.Bytes, err)
+		}
+		recorded += int(n)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit download batch: %w", err)
+	}
+	recordedDownloadsTotal.Add(float64(recorded))
+
+	if err := s.receipts.Clear(ctx, batchID); err != nil {
+		return recorded, fmt.Errorf("failed to clear download batch: %w", err)
+	}
+	s.logger.Info("Recorded downloads", "batch_id", batchID, "downloads", recorded, "skipped", len(raw)-recorded)
+	return recorded, nil
+}
+
+// downloadParams parses one receipt, a malformed one is logged and skipped rather than holding up the batch forever
+func (s *svc) downloadParams(batchID, entry string) (repo.RecordDownloadParams, bool) {
+	var download Download
+	var params repo.RecordDownloadParams
+	if err := json.Unmarshal([]byte(entry), &download); err != nil {
+		s.logger.Warn("Skipping malformed download receipt", "batch_id", batchID, "error", err)
+		return params, false
+	}
+	if params.ID.Scan(download.ID) != nil || params.ListingID.Scan(download.ListingID) != nil || params.FileID.Scan(download.FileID) != nil {
+		s.logger.Warn("Skipping download receipt with an invalid ID", "batch_id", batchID, "receipt", entry)
+		return params, false
+	}
+	if download.UserID != "" && params.UserID.Scan(download.UserID) != nil {
+		s.logger.Warn("Skipping download receipt with an invalid user ID", "batch_id", batchID, "receipt", entry)
+		return params, false
+	}
+	params.DownloadedAt = pgtype.Timestamptz{Time: download.DownloadedAt, Valid: true}
+	params.ExpiresAt = pgtype.Timestamptz{Time: download.ExpiresAt, Valid: true}
+	return params, true
+}
+
+// anonymizeDownloads drops the user from downloads older than the retention period, 0 keeps them forever
+func (s *svc) anonymizeDownloads(ctx context.Context) {
+	if s.downloadRetention <= 0 {
+		return
+	}
+	cutoff := pgtype.Timestamptz{Time: time.Now().Add(-s.downloadRetention), Valid: true}
+	n, err := s.repo.AnonymizeDownloadsBefore(ctx, cutoff)
+	if err != nil {
+		s.logger.Warn("Failed to anonymize old downloads", "error", err)
+		return
+	}
+	if n > 0 {
+		anonymizedDownloadsTotal.Add(float64(n))
+		s.logger.Info("Anonymized downloads past retention", "downloads", n, "retention", s.downloadRetention)
+	}
+}
@@ -0,0 +1,43 @@
+package counters
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	flushedListingsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "listings_worker_counter_flush_listings_total",
+		Help: "Listing rows updated by the counter flush.",
+	})
+
+	replayedBatchesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "listings_worker_counter_flush_replayed_batches_total",
+		Help: "Batches found already applied, e.g. after a crash between commit and clearing Redis.",
+	})
+
+	flushFailuresTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "listings_worker_counter_flush_failures_total",
+		Help: "Counter batches that failed to apply (retried on the next flush).",
+	})
+	recordedDownloadsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "listings_worker_download_receipts_recorded_total",
+		Help: "Download receipts written to Postgres, replayed and stale ones excluded.",
+	})
+	anonymizedDownloadsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "listings_worker_downloads_anonymized_total",
+		Help: "Downloads whose user was dropped after the retention period.",
+	})
+	flushedClicksTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "listings_worker_short_link_clicks_flushed_total",
+		Help: "Short link clicks added to Postgres by the counter flush.",
+	})
+	reconcileDriftTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "listings_worker_counter_reconcile_drift_total",
+		Help: "How far counters were found off by the reconcile job, in either direction, by store and counter.",
+	}, []string{"store", "counter"})
+	reconcileFixesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "listings_worker_counter_reconcile_fixes_total",
+		Help: "Listing rows and search documents whose counters the reconcile job corrected, by store.",
+	}, []string{"store"})
+)
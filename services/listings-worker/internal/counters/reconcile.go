@@ -0,0 +1,389 @@
+package counters
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"indexer/internal/database/postgresql"
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/indexing"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const listingsCollection = "listings"
+
+// Store and counter labels on the drift metrics
+const (
+	storePostgres  = "postgres"
+	storeTypesense = "typesense"
+	counterLikes   = "likes"
+)
+
+// Flusher moves whatever is still buffered in Redis to Postgres, the counter service's Flush
+type Flusher interface {
+	Flush(ctx context.Context) (int, error)
+}
+
+type ReconcileConfig struct {
+	// BatchSize is how many listings are fetched from the DB per page.
+	BatchSize int
+	// Tolerance is how far a count may be off before it is fixed. Drift at or under it is still reported.
+	Tolerance int64
+}
+
+// ReconcileReport summarises a reconcile run
+type ReconcileReport struct {
+	Listings       int `json:"listings"`
+	Drifted        int `json:"drifted"`
+	ColumnsFixed   int `json:"columns_fixed"`
+	DocumentsFixed int `json:"documents_fixed"`
+	Failed         int `json:"failed"`
+	// Skipped looked drifted on the replica but changed on the primary within the replica's lag, left for the next run
+	Skipped int `json:"skipped"`
+	// ActivityUpdated had their seller_activity_bucket moved on. That's time passing rather than drift, so these
+	// aren't counted as drifted.
+	ActivityUpdated int `json:"activity_updated"`
+}
+
+// Reconciler puts the counters back in line after a crash lost a flush or a counter event.
+//
+// Downloads are recounted from the downloads table, which has a receipt for every download since receipts were
+// added. Nothing records a like or a view one by one, so for those the listings row is the authority and only the
+// search document is corrected.
+//
+// With a replica the pages are read from it, and only a listing that looks drifted there is read again from the
+// primary before anything is fixed.
+//
+// The seller's activity bucket is brought up to date on the way through. Nothing publishes an event when a seller
+// goes quiet, so this run is what moves their listings from active_7d to active_30d to dormant.
+type Reconciler struct {
+	repo       repo.Querier
+	reader     repo.Querier
+	replicated bool
+	flusher    Flusher
+	indexer    indexing.Indexer
+	logger     *slog.Logger
+	config     ReconcileConfig
+}
+
+// NewReconciler reads the listings from reader, or from repo when reader is nil. Fixes always go to repo.
+func NewReconciler(repo repo.Querier, reader repo.Querier, flusher Flusher, indexer indexing.Indexer, logger *slog.Logger, config ReconcileConfig) *Reconciler {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 500
+	}
+	if config.Tolerance < 0 {
+		config.Tolerance = 0
+	}
+
+	replicated := reader != nil
+	if !replicated {
+		reader = repo
+	}
+
+	return &Reconciler{
+		repo:       repo,
+		reader:     reader,
+		replicated: replicated,
+		flusher:    flusher,
+		indexer:    indexer,
+		logger:     logger,
+		config:     config,
+	}
+}
+
+// Run reconciles every live listing, or only listingID when it isn't empty. The caller must hold the counter-flush
+// lock, a flush landing halfway through would look like drift.
+func (r *Reconciler) Run(ctx context.Context, listingID string) (ReconcileReport, error) {
+	var report ReconcileReport
+
+	var only pgtype.UUID
+	if listingID != "" {
+		if err := only.Scan(listingID); err != nil {
+			return report, fmt.Errorf("invalid listing ID %q: %w", listingID, err)
+		}
+	}
+
+	// Counts still buffered in Redis aren't drift, get them into Postgres first
+	if _, err := r.flusher.Flush(ctx); err != nil {
+		return report, fmt.Errorf("failed to flush counters before reconciling: %w", err)
+	}
+
+	// Taken once, the flush above is the newest write the replica has to catch up with
+	var lag time.Duration
+	if r.replicated {
+		var err error
+		if lag, err = postgresql.ReplicaLag(ctx, r.reader); err != nil {
+			return report, fmt.Errorf("failed to read replica lag: %w", err)
+		}
+	}
+
+	var afterID pgtype.UUID // Zero UUID sorts first, so the first page starts at the beginning
+	afterID.Valid = true
+
+	for {
+		rows, err := r.reader.GetListingCountsForReconcile(ctx, repo.GetListingCountsForReconcileParams{
+			AfterID:   afterID,
+			ListingID: only,
+			BatchSize: int32(r.config.BatchSize),
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to fetch listing counts: %w", err)
+		}
+
+		for _, row := range rows {
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+			r.reconcile(ctx, row, lag, &report)
+		}
+
+		if len(rows) < r.config.BatchSize {
+			break
+		}
+		afterID = rows[len(rows)-1].ID
+	}
+
+	r.logger.Info("Counter reconcile complete",
+		"listings", report.Listings,
+		"drifted", report.Drifted,
+		"columns_fixed", report.ColumnsFixed,
+		"documents_fixed", report.DocumentsFixed,
+		"failed", report.Failed,
+		"skipped", report.Skipped,
+		"activity_updated", report.ActivityUpdated,
+		"replica_lag", lag,
+	)
+	return report, nil
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, row repo.GetListingCountsForReconcileRow, lag time.Duration, report *ReconcileReport) {
+	listingID := fmt.Sprintf("%x", row.ID.Bytes)
+	report.Listings++
+
+	// Not indexed is nil, it gets the counts from the row when it is
+	fields, err := r.document(ctx, listingID)
+	if err != nil {
+		r.logger.Error("Failed to fetch search document counters", "listing_id", listingID, "error", err)
+		report.Failed++
+	}
+
+	if r.replicated && looksDrifted(row, fields) {
+		var ok bool
+		if row, ok = r.fromPrimary(ctx, row, lag, report); !ok {
+			return
+		}
+	}
+
+	drifted := false
+
+	// 1. Postgres, downloads only. The column is only ever raised: downloads from before receipts existed are in the
+	// column with nothing in the table to back them.
+	downloads := int64(row.DownloadsCount)
+	if drift := row.RecordedDownloads - downloads; drift != 0 {
+		drifted = true
+		r.observe(storePostgres, counterDownloads, drift)
+		if drift > r.config.Tolerance {
+			err := r.repo.SetListingDownloadsCount(ctx, repo.SetListingDownloadsCountParams{
+				ID:        row.ID,
+				Downloads: clampInt32(row.RecordedDownloads),
+			})
+			if err != nil {
+				r.logger.Error("Failed to fix downloads count", "listing_id", listingID, "error", err)
+				report.Failed++
+				return
+			}
+			r.logger.Info("Fixed downloads count", "listing_id", listingID, "was", downloads, "now", row.RecordedDownloads)
+			reconcileFixesTotal.WithLabelValues(storePostgres).Inc()
+			report.ColumnsFixed++
+			downloads = row.RecordedDownloads
+		}
+	}
+
+	// 2. Typesense, against the row as it now stands
+	if fields != nil {
+		fixed, documentDrifted, err := r.reconcileDocument(ctx, listingID, fields, wantCounts(row, downloads))
+		if err != nil {
+			r.logger.Error("Failed to reconcile search document counters", "listing_id", listingID, "error", err)
+			report.Failed++
+		}
+		if fixed {
+			report.DocumentsFixed++
+		}
+		drifted = drifted || documentDrifted
+
+		updated, err := r.reconcileActivity(ctx, listingID, fields, indexing.SellerActivityBucket(row.SellerLastActiveAt, time.Now()))
+		if err != nil {
+			r.logger.Error("Failed to update seller activity bucket", "listing_id", listingID, "error", err)
+			report.Failed++
+		}
+		if updated {
+			report.ActivityUpdated++
+		}
+	}
+	if drifted {
+		report.Drifted++
+	}
+}
+
+// reconcileActivity patches the document's seller_activity_bucket when it isn't want. Documents indexed before the
+// bucket existed have none and get it here.
+func (r *Reconciler) reconcileActivity(ctx context.Context, listingID string, fields map[string]any, want string) (bool, error) {
+	if got, _ := fields["seller_activity_bucket"].(string); got == want {
+		return false, nil
+	}
+	err := r.indexer.Update(ctx, listingsCollection, listingID, map[string]any{"seller_activity_bucket": want})
+	if errors.Is(err, indexing.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// fromPrimary reads a row that looks drifted on the replica again from the primary. False leaves the listing alone:
+// it changed within the replica's lag, so its counts may still be on their way to the search document, or it was
+// deleted.
+func (r *Reconciler) fromPrimary(ctx context.Context, row repo.GetListingCountsForReconcileRow, lag time.Duration, report *ReconcileReport) (repo.GetListingCountsForReconcileRow, bool) {
+	listingID := fmt.Sprintf("%x", row.ID.Bytes)
+	rows, err := r.repo.GetListingCountsForReconcile(ctx, repo.GetListingCountsForReconcileParams{
+		AfterID:   pgtype.UUID{Valid: true},
+		ListingID: row.ID,
+		BatchSize: 1,
+	})
+	if err != nil {
+		r.logger.Error("Failed to read listing counts from the primary", "listing_id", listingID, "error", err)
+		report.Failed++
+		return row, false
+	}
+	if len(rows) == 0 {
+		return row, false
+	}
+
+	if changed := rows[0].UpdatedAt.Time; time.Since(changed) < lag {
+		r.logger.Debug("Skipping listing changed within the replica lag", "listing_id", listingID, "updated_at", changed, "lag", lag)
+		report.Skipped++
+		return row, false
+	}
+	return rows[0], true
+}
+
+// wantCounts is the counters the search document should have for row, with downloads as the column stands now
+func wantCounts(row repo.GetListingCountsForReconcileRow, downloads int64) map[string]int64 {
+	return map[string]int64{
+		"downloads_count": downloads,
+		"views_count":     int64(row.ViewsCount),
+		"likes_count":     int64(row.LikesCount),
+	}
+}
+
+// document is the listing's search document, nil when it isn't indexed
+func (r *Reconciler) document(ctx context.Context, listingID string) (map[string]any, error) {
+	doc, found, err := r.indexer.Get(ctx, listingsCollection, listingID)
+	if err != nil || !found {
+		return nil, err
+	}
+	fields, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected document type %T", doc)
+	}
+	return fields, nil
+}
+
+// looksDrifted is true when the row disagrees with the downloads recorded for it or with its search document, by any
+// amount
+func looksDrifted(row repo.GetListingCountsForReconcileRow, fields map[string]any) bool {
+	downloads := int64(row.DownloadsCount)
+	if row.RecordedDownloads != downloads {
+		return true
+	}
+	if fields == nil {
+		return false
+	}
+	for field, value := range wantCounts(row, downloads) {
+		if got, _ := documentCount(fields[field]); got != value {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcileDocument patches the document's counters when any of them is further off than the tolerance
+func (r *Reconciler) reconcileDocument(ctx context.Context, listingID string, fields map[string]any, want map[string]int64) (fixed bool, drifted bool, err error) {
+	overTolerance := false
+	for field, value := range want {
+		got, _ := documentCount(fields[field])
+		drift := value - got
+		if drift == 0 {
+			continue
+		}
+		drifted = true
+		r.observe(storeTypesense, counterName(field), drift)
+		if drift > r.config.Tolerance || -drift > r.config.Tolerance {
+			overTolerance = true
+		}
+	}
+	if !overTolerance {
+		return false, drifted, nil
+	}
+
+	update := make(map[string]any, len(want))
+	for field, value := range want {
+		update[field] = value
+	}
+	err = r.indexer.Update(ctx, listingsCollection, listingID, update)
+	if errors.Is(err, indexing.ErrNotFound) {
+		// Removed from the index since the Get
+		return false, drifted, nil
+	}
+	if err != nil {
+		return false, drifted, err
+	}
+	reconcileFixesTotal.WithLabelValues(storeTypesense).Inc()
+	return true, drifted, nil
+}
+
+func (r *Reconciler) observe(store, counter string, drift int64) {
+	if drift < 0 {
+		drift = -drift
+	}
+	reconcileDriftTotal.WithLabelValues(store, counter).Add(float64(drift))
+}
+
+// counterName maps a document field to its metric label
+func counterName(field string) string {
+	switch field {
+	case "downloads_count":
+		return counterDownloads
+	case "views_count":
+		return counterViews
+	default:
+		return counterLikes
+	}
+}
+
+// documentCount reads a counter field however the document came back: JSON numbers from Typesense, Go integers from
+// the in-memory indexer. A missing field counts as 0.
+func documentCount(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case float64:
+		return int64(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return i, err == nil
+	case pgtype.Int4:
+		return int64(n.Int32), n.Valid
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,306 @@
+package counters_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"testing"
+	"time"
+
+	"indexer/internal/counters"
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/indexing"
+	"indexer/internal/mocks/mockrepo"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- FAKES ---
+
+// FakeFlusher counts flushes, a reconcile must always start with one
+type FakeFlusher struct {
+	flushes int
+	err     error
+}
+
+func (f *FakeFlusher) Flush(ctx context.Context) (int, error) {
+	f.flushes++
+	return 0, f.err
+}
+
+// countsTable serves GetListingCountsForReconcile and SetListingDownloadsCount from memory, so a second run sees
+// what the first one wrote
+type countsTable struct {
+	rows map[[16]byte]*repo.GetListingCountsForReconcileRow
+}
+
+func newCountsTable(t *testing.T, rows ...repo.GetListingCountsForReconcileRow) *mockrepo.Querier {
+	t.Helper()
+	table := &countsTable{rows: map[[16]byte]*repo.GetListingCountsForReconcileRow{}}
+	for i := range rows {
+		table.rows[rows[i].ID.Bytes] = &rows[i]
+	}
+
+	querier := mockrepo.NewQuerier(t)
+	querier.EXPECT().GetListingCountsForReconcile(mock.Anything, mock.Anything).RunAndReturn(table.page).Maybe()
+	querier.EXPECT().SetListingDownloadsCount(mock.Anything, mock.Anything).RunAndReturn(table.set).Maybe()
+	return querier
+}
+
+func (c *countsTable) page(ctx context.Context, arg repo.GetListingCountsForReconcileParams) ([]repo.GetListingCountsForReconcileRow, error) {
+	var page []repo.GetListingCountsForReconcileRow
+	for _, row := range c.rows {
+		if bytes.Compare(row.ID.Bytes[:], arg.AfterID.Bytes[:]) <= 0 {
+			continue
+		}
+		if arg.ListingID.Valid && row.ID.Bytes != arg.ListingID.Bytes {
+			continue
+		}
+		page = append(page, *row)
+	}
+	sort.Slice(page, func(i, j int) bool { return bytes.Compare(page[i].ID.Bytes[:], page[j].ID.Bytes[:]) < 0 })
+	if len(page) > int(arg.BatchSize) {
+		page = page[:arg.BatchSize]
+	}
+	return page, nil
+}
+
+func (c *countsTable) set(ctx context.Context, arg repo.SetListingDownloadsCountParams) error {
+	row, ok := c.rows[arg.ID.Bytes]
+	if !ok {
+		return errors.New("no such listing")
+	}
+	row.DownloadsCount = arg.Downloads
+	return nil
+}
+
+// --- HELPERS ---
+
+func reconcileID(n byte) pgtype.UUID {
+	return pgtype.UUID{Bytes: [16]byte{15: n}, Valid: true}
+}
+
+// countsRow is listing n with its column counts and the downloads recorded for it
+func countsRow(n byte, downloads, views, likes int32, recorded int64) repo.GetListingCountsForReconcileRow {
+	return repo.GetListingCountsForReconcileRow{
+		ID:                reconcileID(n),
+		DownloadsCount:    downloads,
+		ViewsCount:        views,
+		LikesCount:        likes,
+		RecordedDownloads: recorded,
+	}
+}
+
+// indexCounts puts listing n in the index with the given counters, as JSON numbers like Typesense returns them. Its
+// seller is dormant, like a countsRow's.
+func indexCounts(t *testing.T, indexer indexing.Indexer, n byte, downloads, views, likes float64) {
+	t.Helper()
+	require.NoError(t, indexer.Upsert(context.Background(), "listings", map[string]any{
+		"id":                     fmt.Sprintf("%x", reconcileID(n).Bytes),
+		"downloads_count":        downloads,
+		"views_count":            views,
+		"likes_count":            likes,
+		"seller_activity_bucket": indexing.ActivityDormant,
+	}))
+}
+
+func documentCounts(t *testing.T, indexer indexing.Indexer, n byte) map[string]any {
+	t.Helper()
+	doc, found, err := indexer.Get(context.Background(), "listings", fmt.Sprintf("%x", reconcileID(n).Bytes))
+	require.NoError(t, err)
+	require.True(t, found)
+	fields := doc.(map[string]any)
+	return map[string]any{
+		"downloads_count": fields["downloads_count"],
+		"views_count":     fields["views_count"],
+		"likes_count":     fields["likes_count"],
+	}
+}
+
+func newReconciler(querier *mockrepo.Querier, flusher counters.Flusher, indexer indexing.Indexer, batchSize int) *counters.Reconciler {
+	return counters.NewReconciler(querier, nil, flusher, indexer, slog.Default(), counters.ReconcileConfig{BatchSize: batchSize, Tolerance: 5})
+}
+
+// --- TESTS ---
+
+func TestReconcile_ConvergesSeededDrift(t *testing.T) {
+	// SCENARIO: Listing 1 lost a flush (downloads recorded but never counted), listing 2 lost its counter events (the
+	// document is behind the row), listing 3 is off by no more than the tolerance, listing 4 has legacy downloads from
+	// before receipts and listing 5 isn't indexed. Pages of two make the run go through several pages.
+	// EXPECT: Drift past the tolerance is fixed in both stores, the rest is reported but left alone, and a second run
+	// finds nothing to fix.
+
+	querier := newCountsTable(t,
+		countsRow(1, 10, 100, 3, 40),
+		countsRow(2, 20, 200, 7, 20),
+		countsRow(3, 30, 300, 9, 33),
+		countsRow(4, 500, 50, 1, 120),
+		countsRow(5, 1, 1, 1, 50),
+	)
+	indexer := indexing.NewInMemoryIndexer()
+	indexCounts(t, indexer, 1, 10, 100, 3)
+	indexCounts(t, indexer, 2, 2, 150, 0)
+	indexCounts(t, indexer, 3, 30, 298, 9)
+	indexCounts(t, indexer, 4, 500, 50, 1)
+	flusher := &FakeFlusher{}
+	reconciler := newReconciler(querier, flusher, indexer, 2)
+
+	report, err := reconciler.Run(context.Background(), "")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, flusher.flushes)
+	assert.Equal(t, counters.ReconcileReport{Listings: 5, Drifted: 5, ColumnsFixed: 2, DocumentsFixed: 2}, report)
+	assert.Equal(t, map[string]any{"downloads_count": int64(40), "views_count": int64(100), "likes_count": int64(3)}, documentCounts(t, indexer, 1))
+	assert.Equal(t, map[string]any{"downloads_count": int64(20), "views_count": int64(200), "likes_count": int64(7)}, documentCounts(t, indexer, 2))
+	assert.Equal(t, map[string]any{"downloads_count": float64(30), "views_count": float64(298), "likes_count": float64(9)}, documentCounts(t, indexer, 3), "within tolerance")
+	assert.Equal(t, map[string]any{"downloads_count": float64(500), "views_count": float64(50), "likes_count": float64(1)}, documentCounts(t, indexer, 4), "legacy downloads are never taken off")
+
+	querier.AssertCalled(t, "SetListingDownloadsCount", mock.Anything, repo.SetListingDownloadsCountParams{ID: reconcileID(1), Downloads: 40})
+	querier.AssertCalled(t, "SetListingDownloadsCount", mock.Anything, repo.SetListingDownloadsCountParams{ID: reconcileID(5), Downloads: 50})
+	querier.AssertNumberOfCalls(t, "SetListingDownloadsCount", 2)
+
+	// Second run: converged, only listing 3's drift under the tolerance and listing 4's legacy downloads are left
+	report, err = reconciler.Run(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, counters.ReconcileReport{Listings: 5, Drifted: 2}, report)
+	querier.AssertNumberOfCalls(t, "SetListingDownloadsCount", 2)
+}
+
+func TestReconcile_Replica(t *testing.T) {
+	// SCENARIO: The pages come from a replica 30s behind. On it listing 1 is missing downloads that were counted since,
+	// listing 2 is missing views that the document already has and were counted a moment ago, listing 3 lost a flush
+	// and listing 4 agrees with its document.
+	// EXPECT: Only what looks drifted on the replica is read again from the primary. Listing 1 is left alone as the
+	// primary is in line, listing 2 is skipped as it changed within the lag, listing 3 is fixed on the primary with
+	// its count from there.
+
+	replica := newCountsTable(t,
+		countsRow(1, 10, 100, 3, 40),
+		countsRow(2, 20, 100, 7, 20),
+		countsRow(3, 30, 300, 9, 60),
+		countsRow(4, 40, 400, 1, 40),
+	)
+	replica.EXPECT().GetReplicaLagSeconds(mock.Anything).Return(30, nil)
+
+	recent := countsRow(2, 20, 150, 7, 20)
+	recent.UpdatedAt = pgtype.Timestamptz{Time: time.Now().Add(-5 * time.Second), Valid: true}
+	primary := newCountsTable(t,
+		countsRow(1, 40, 100, 3, 40),
+		recent,
+		countsRow(3, 30, 300, 9, 61),
+	)
+
+	indexer := indexing.NewInMemoryIndexer()
+	indexCounts(t, indexer, 1, 40, 100, 3)
+	indexCounts(t, indexer, 2, 20, 150, 7)
+	indexCounts(t, indexer, 3, 30, 300, 9)
+	indexCounts(t, indexer, 4, 40, 400, 1)
+	reconciler := counters.NewReconciler(primary, replica, &FakeFlusher{}, indexer, slog.Default(), counters.ReconcileConfig{BatchSize: 100, Tolerance: 5})
+
+	report, err := reconciler.Run(context.Background(), "")
+	require.NoError(t, err)
+
+	assert.Equal(t, counters.ReconcileReport{Listings: 4, Drifted: 1, ColumnsFixed: 1, DocumentsFixed: 1, Skipped: 1}, report)
+	assert.Equal(t, map[string]any{"downloads_count": float64(20), "views_count": float64(150), "likes_count": float64(7)}, documentCounts(t, indexer, 2), "skipped")
+	assert.Equal(t, int64(61), documentCounts(t, indexer, 3)["downloads_count"])
+
+	primary.AssertCalled(t, "SetListingDownloadsCount", mock.Anything, repo.SetListingDownloadsCountParams{ID: reconcileID(3), Downloads: 61})
+	primary.AssertNumberOfCalls(t, "SetListingDownloadsCount", 1)
+	primary.AssertNumberOfCalls(t, "GetListingCountsForReconcile", 3)
+	replica.AssertNotCalled(t, "SetListingDownloadsCount", mock.Anything, mock.Anything)
+}
+
+func TestReconcile_SingleListing(t *testing.T) {
+	// SCENARIO: Support reconciles one listing while another is drifting too.
+	// EXPECT: Only the asked for listing is touched.
+
+	querier := newCountsTable(t,
+		countsRow(1, 10, 10, 0, 40),
+		countsRow(2, 10, 10, 0, 40),
+	)
+	indexer := indexing.NewInMemoryIndexer()
+	indexCounts(t, indexer, 1, 10, 10, 0)
+	indexCounts(t, indexer, 2, 10, 10, 0)
+	reconciler := newReconciler(querier, &FakeFlusher{}, indexer, 100)
+
+	report, err := reconciler.Run(context.Background(), reconcileID(2).String())
+	require.NoError(t, err)
+
+	assert.Equal(t, counters.ReconcileReport{Listings: 1, Drifted: 1, ColumnsFixed: 1, DocumentsFixed: 1}, report)
+	assert.Equal(t, int64(40), documentCounts(t, indexer, 2)["downloads_count"])
+	assert.Equal(t, float64(10), documentCounts(t, indexer, 1)["downloads_count"])
+	querier.AssertCalled(t, "SetListingDownloadsCount", mock.Anything, repo.SetListingDownloadsCountParams{ID: reconcileID(2), Downloads: 40})
+	querier.AssertNumberOfCalls(t, "SetListingDownloadsCount", 1)
+}
+
+func TestReconcile_SellerActivity(t *testing.T) {
+	// SCENARIO: Listing 1's seller was seen yesterday but its document still says dormant, listing 2's seller hasn't
+	// been seen for six weeks, listing 3 was indexed before the bucket existed and listing 4 is up to date. All the
+	// counters agree.
+	// EXPECT: The three stale buckets are patched without being counted as drift, and a second run changes nothing.
+
+	seen := func(n byte, ago time.Duration) repo.GetListingCountsForReconcileRow {
+		row := countsRow(n, 10, 10, 0, 10)
+		row.SellerLastActiveAt = pgtype.Timestamptz{Time: time.Now().Add(-ago), Valid: true}
+		return row
+	}
+	querier := newCountsTable(t,
+		seen(1, 24*time.Hour),
+		seen(2, 42*24*time.Hour),
+		seen(3, 10*24*time.Hour),
+		countsRow(4, 10, 10, 0, 10),
+	)
+	indexer := indexing.NewInMemoryIndexer()
+	for n := byte(1); n <= 4; n++ {
+		indexCounts(t, indexer, n, 10, 10, 0)
+	}
+	require.NoError(t, indexer.Update(context.Background(), "listings", fmt.Sprintf("%x", reconcileID(2).Bytes), map[string]any{"seller_activity_bucket": indexing.ActivityActive7d}))
+	require.NoError(t, indexer.Upsert(context.Background(), "listings", map[string]any{
+		"id": fmt.Sprintf("%x", reconcileID(3).Bytes), "downloads_count": 10, "views_count": 10, "likes_count": 0,
+	}))
+	reconciler := newReconciler(querier, &FakeFlusher{}, indexer, 100)
+
+	report, err := reconciler.Run(context.Background(), "")
+	require.NoError(t, err)
+
+	assert.Equal(t, counters.ReconcileReport{Listings: 4, ActivityUpdated: 3}, report)
+	for n, want := range map[byte]string{1: indexing.ActivityActive7d, 2: indexing.ActivityDormant, 3: indexing.ActivityActive30d, 4: indexing.ActivityDormant} {
+		doc, _, err := indexer.Get(context.Background(), "listings", fmt.Sprintf("%x", reconcileID(n).Bytes))
+		require.NoError(t, err)
+		assert.Equal(t, want, doc.(map[string]any)["seller_activity_bucket"], "listing %d", n)
+	}
+
+	report, err = reconciler.Run(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, counters.ReconcileReport{Listings: 4}, report)
+}
+
+func TestReconcile_FlushFails(t *testing.T) {
+	// SCENARIO: Redis can't be flushed, so Postgres is missing counts that aren't drift.
+	// EXPECT: Nothing is compared or fixed.
+
+	querier := mockrepo.NewQuerier(t)
+	indexer := indexing.NewInMemoryIndexer()
+	reconciler := newReconciler(querier, &FakeFlusher{err: errors.New("redis down")}, indexer, 100)
+
+	_, err := reconciler.Run(context.Background(), "")
+
+	assert.ErrorContains(t, err, "redis down")
+	querier.AssertNotCalled(t, "GetListingCountsForReconcile", mock.Anything, mock.Anything)
+}
+
+func TestReconcile_InvalidListingID(t *testing.T) {
+	flusher := &FakeFlusher{}
+	reconciler := newReconciler(mockrepo.NewQuerier(t), flusher, indexing.NewInMemoryIndexer(), 100)
+
+	_, err := reconciler.Run(context.Background(), "not-a-uuid")
+
+	assert.Error(t, err)
+	assert.Zero(t, flusher.flushes)
+}
@@ -0,0 +1,250 @@
+package counters
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"indexer/internal/database/postgresql"
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/events"
+	"indexer/internal/indexing"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Counter names, must match what the gateway writes
+const (
+	counterDownloads = "downloads"
+	counterViews     = "views"
+)
+
+// Applied batch IDs are kept this long, far longer than a batch can sit in Redis
+const flushRetention = 7 * 24 * time.Hour
+
+type Publisher interface {
+	PublishListingCounters(evt events.ListingCountersEvent, msgID string) error
+}
+
+type listingDelta struct {
+	id        pgtype.UUID
+	downloads int64
+	views     int64
+}
+
+// Moves download and view counts from Redis to Postgres in batches,
+// so a hot listing costs one UPDATE per flush instead of one per hit.
+// Download receipts and short link clicks ride along, they are written in the same flush.
+type svc struct {
+	repo      repo.Querier
+	db        postgresql.DBPool
+	pending   PendingStore
+	receipts  ReceiptStore
+	clicks    PendingStore
+	publisher Publisher
+	logger    *slog.Logger
+	// Downloads older than this lose their user ID, 0 keeps it forever
+	downloadRetention time.Duration
+}
+
+func NewService(repo repo.Querier, db postgresql.DBPool, pending PendingStore, receipts ReceiptStore, clicks PendingStore, publisher Publisher, logger *slog.Logger, downloadRetention time.Duration) *svc {
+	return &svc{
+		repo:              repo,
+		db:                db,
+		pending:           pending,
+		receipts:          receipts,
+		clicks:            clicks,
+		publisher:         publisher,
+		logger:            logger,
+		downloadRetention: downloadRetention,
+	}
+}
+
+// Flush first finishes any batch a previous flush left behind, then claims and applies everything pending, counters
+// before download receipts and short link clicks. Returns the number of listing rows updated.
+func (s *svc) Flush(ctx context.Context) (int, error) {
+	leftover, err := s.pending.Batches(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, batchID := range leftover {
+		s.logger.Warn("Found unfinished counter batch, applying", "batch_id", batchID)
+		n, err := s.applyBatch(ctx, batchID)
+		if err != nil {
+			flushFailuresTotal.Inc()
+			return total, err
+		}
+		total += n
+	}
+
+	batchID, err := s.pending.Claim(ctx)
+	if err != nil {
+		return total, err
+	}
+	if batchID != "" {
+		n, err := s.applyBatch(ctx, batchID)
+		if err != nil {
+			flushFailuresTotal.Inc()
+			return total, err
+		}
+		total += n
+	}
+
+	if _, err := s.flushDownloads(ctx); err != nil {
+		return total, err
+	}
+	if _, err := s.flushClicks(ctx); err != nil {
+		return total, err
+	}
+
+	cutoff := pgtype.Timestamptz{Time: time.Now().Add(-flushRetention), Valid: true}
+	if err := s.repo.DeleteCounterFlushesBefore(ctx, cutoff); err != nil {
+		s.logger.Warn("Failed to prune applied counter batches", "error", err)
+	}
+	s.anonymizeDownloads(ctx)
+
+	return total, nil
+}
+
+// applyBatch is safe to run any number of times for the same batch:
+// the batch ID is recorded in the same transaction as the increments, so a replay skips straight to publishing.
+func (s *svc) applyBatch(ctx context.Context, batchID string) (int, error) {
+	raw, err := s.pending.Read(ctx, batchID)
+	if err != nil {
+		return 0, err
+	}
+
+	deltas := s.aggregate(batchID, raw)
+	if len(deltas) == 0 {
+		return 0, s.pending.Clear(ctx, batchID)
+	}
+
+	// 1. Postgres, one UPDATE per listing
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := repo.New(tx)
+	recorded, err := qtx.RecordCounterFlush(ctx, batchID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record counter batch: %w", err)
+	}
+
+	updated := 0
+	if recorded == 0 {
+		s.logger.Info("Counter batch already applied, skipping increments", "batch_id", batchID)
+		replayedBatchesTotal.Inc()
+	} else {
+		for _, d := range deltas {
+			err := qtx.IncrementListingCounters(ctx, repo.IncrementListingCountersParams{
+				ID:        d.id,
+				Downloads: clampInt32(d.downloads),
+				Views:     clampInt32(d.views),
+			})
+			if err != nil {
+				return 0, fmt.Errorf("failed to increment counters for %x: %w", d.id.Bytes, err)
+			}
+			updated++
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit counter batch: %w", err)
+	}
+	flushedListingsTotal.Add(float64(updated))
+
+	// 2. Search index, one patch event per listing with the new totals
+	ids := make([]pgtype.UUID, 0, len(deltas))
+	for _, d := range deltas {
+		ids = append(ids, d.id)
+	}
+
+	rows, err := s.repo.GetListingCounters(ctx, ids)
+	if err != nil {
+		return updated, fmt.Errorf("failed to read back counters: %w", err)
+	}
+	now := time.Now()
+	for _, row := range rows {
+		listingID := fmt.Sprintf("%x", row.ID.Bytes)
+		evt := events.ListingCountersEvent{
+			ListingID:            listingID,
+			DownloadsCount:       int64(row.DownloadsCount),
+			ViewsCount:           int64(row.ViewsCount),
+			SellerActivityBucket: indexing.SellerActivityBucket(row.SellerLastActiveAt, now),
+		}
+		// Stable ID so a replayed batch is deduplicated by JetStream
+		if err := s.publisher.PublishListingCounters(evt, batchID+":"+listingID); err != nil {
+			return updated, fmt.Errorf("failed to publish counters for %s: %w", listingID, err)
+		}
+	}
+
+	// 3. Only now is it safe to forget the batch
+	if err := s.pending.Clear(ctx, batchID); err != nil {
+		return updated, fmt.Errorf("failed to clear counter batch: %w", err)
+	}
+
+	s.logger.Info("Flushed listing counters", "batch_id", batchID, "listings", len(deltas), "updated", updated)
+	return updated, nil
+}
+
+// aggregate folds "<listing id>:<counter>" fields into one delta per listing, sorted by ID
+// so concurrent flushes lock rows in the same order.
+func (s *svc) aggregate(batchID string, raw map[string]int64) []*listingDelta {
+	byID := make(map[[16]byte]*listingDelta)
+
+	for field, n := range raw {
+		idStr, counter, ok := strings.Cut(field, ":")
+		if !ok {
+			s.logger.Warn("Skipping malformed counter field", "batch_id", batchID, "field", field)
+			continue
+		}
+
+		var id pgtype.UUID
+		if err := id.Scan(idStr); err != nil {
+			s.logger.Warn("Skipping counter for invalid listing ID", "batch_id", batchID, "field", field)
+			continue
+		}
+
+		d, exists := byID[id.Bytes]
+		if !exists {
+			d = &listingDelta{id: id}
+			byID[id.Bytes] = d
+		}
+
+		switch counter {
+		case counterDownloads:
+			d.downloads += n
+		case counterViews:
+			d.views += n
+		default:
+			s.logger.Warn("Skipping unknown counter", "batch_id", batchID, "field", field)
+		}
+	}
+
+	deltas := make([]*listingDelta, 0, len(byID))
+	for _, d := range byID {
+		deltas = append(deltas, d)
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		return bytes.Compare(deltas[i].id.Bytes[:], deltas[j].id.Bytes[:]) < 0
+	})
+	return deltas
+}
+
+func clampInt32(n int64) int32 {
+	if n > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	if n < math.MinInt32 {
+		return math.MinInt32
+	}
+	return int32(n)
+}
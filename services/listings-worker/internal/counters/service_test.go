@@ -0,0 +1,472 @@
+package counters_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"indexer/internal/counters"
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/events"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- FAKES ---
+
+// FakeStore mimics the Redis rename-then-read flow in memory
+type FakeStore struct {
+	mu       sync.Mutex
+	pending  map[string]int64
+	batches  map[string]map[string]int64
+	nextID   int
+	clearErr error
+}
+
+func NewFakeStore() *FakeStore {
+	return &FakeStore{
+		pending: map[string]int64{},
+		batches: map[string]map[string]int64{},
+	}
+}
+
+func (f *FakeStore) Incr(field string, n int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pending[field] += n
+}
+
+func (f *FakeStore) Claim(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.pending) == 0 {
+		return "", nil
+	}
+	f.nextID++
+	batchID := fmt.Sprintf("batch-%d", f.nextID)
+	f.batches[batchID] = f.pending
+	f.pending = map[string]int64{}
+	return batchID, nil
+}
+
+func (f *FakeStore) Batches(ctx context.Context) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var ids []string
+	for id := range f.batches {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (f *FakeStore) Read(ctx context.Context, batchID string) (map[string]int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.batches[batchID], nil
+}
+
+func (f *FakeStore) Clear(ctx context.Context, batchID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.clearErr != nil {
+		return f.clearErr
+	}
+	delete(f.batches, batchID)
+	return nil
+}
+
+type FakePublisher struct {
+	published []events.ListingCountersEvent
+	msgIDs    []string
+}
+
+func (f *FakePublisher) PublishListingCounters(evt events.ListingCountersEvent, msgID string) error {
+	f.published = append(f.published, evt)
+	f.msgIDs = append(f.msgIDs, msgID)
+	return nil
+}
+
+// FakeReceipts mimics the Redis list of download receipts, claimed the same way as the counters
+type FakeReceipts struct {
+	mu      sync.Mutex
+	pending []string
+	batches map[string][]string
+	nextID  int
+}
+
+func NewFakeReceipts() *FakeReceipts {
+	return &FakeReceipts{batches: map[string][]string{}}
+}
+
+func (f *FakeReceipts) Push(receipt string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pending = append(f.pending, receipt)
+}
+
+func (f *FakeReceipts) Claim(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.pending) == 0 {
+		return "", nil
+	}
+	f.nextID++
+	batchID := fmt.Sprintf("downloads-%d", f.nextID)
+	f.batches[batchID] = f.pending
+	f.pending = nil
+	return batchID, nil
+}
+
+func (f *FakeReceipts) Batches(ctx context.Context) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var ids []string
+	for id := range f.batches {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (f *FakeReceipts) Read(ctx context.Context, batchID string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.batches[batchID], nil
+}
+
+func (f *FakeReceipts) Clear(ctx context.Context, batchID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.batches, batchID)
+	return nil
+}
+
+// --- HELPERS ---
+
+const listingID = "550e8400e29b41d4a716446655440000"
+
+var counterCols = []string{"id", "downloads_count", "views_count", "seller_last_active_at"}
+
+func listingUUID(t *testing.T) pgtype.UUID {
+	t.Helper()
+	var id pgtype.UUID
+	require.NoError(t, id.Scan(listingID))
+	return id
+}
+
+func newTestService(t *testing.T) (*FakeStore, *FakePublisher, pgxmock.PgxPoolIface, interface {
+	Flush(ctx context.Context) (int, error)
+}) {
+	t.Helper()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	t.Cleanup(mockPool.Close)
+
+	store := NewFakeStore()
+	publisher := &FakePublisher{}
+	svc := counters.NewService(repo.New(mockPool), mockPool, store, NewFakeReceipts(), NewFakeStore(), publisher, slog.Default(), 0)
+
+	return store, publisher, mockPool, svc
+}
+
+func newReceiptsTestService(t *testing.T, retention time.Duration) (*FakeReceipts, pgxmock.PgxPoolIface, interface {
+	Flush(ctx context.Context) (int, error)
+}) {
+	t.Helper()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	t.Cleanup(mockPool.Close)
+
+	receipts := NewFakeReceipts()
+	svc := counters.NewService(repo.New(mockPool), mockPool, NewFakeStore(), receipts, NewFakeStore(), &FakePublisher{}, slog.Default(), retention)
+
+	return receipts, mockPool, svc
+}
+
+func newClicksTestService(t *testing.T) (*FakeStore, pgxmock.PgxPoolIface, interface {
+	Flush(ctx context.Context) (int, error)
+}) {
+	t.Helper()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	t.Cleanup(mockPool.Close)
+
+	clicks := NewFakeStore()
+	svc := counters.NewService(repo.New(mockPool), mockPool, NewFakeStore(), NewFakeReceipts(), clicks, &FakePublisher{}, slog.Default(), 0)
+
+	return clicks, mockPool, svc
+}
+
+func expectApply(mockPool pgxmock.PgxPoolIface, batchID string, id pgtype.UUID, downloads, views int32) {
+	mockPool.ExpectBegin()
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO counter_flushes`)).
+		WithArgs(batchID).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockPool.ExpectExec(regexp.QuoteMeta(`UPDATE listings`)).
+		WithArgs(downloads, views, id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockPool.ExpectCommit()
+}
+
+// expectReadBack serves the listing's new totals, its seller was last seen two days ago
+func expectReadBack(mockPool pgxmock.PgxPoolIface, id pgtype.UUID, downloads, views int32) {
+	lastActive := pgtype.Timestamptz{Time: time.Now().Add(-48 * time.Hour), Valid: true}
+	mockPool.ExpectQuery(regexp.QuoteMeta(`-- name: GetListingCounters :many`)).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(counterCols).AddRow(id, downloads, views, lastActive))
+}
+
+func expectPrune(mockPool pgxmock.PgxPoolIface) {
+	mockPool.ExpectExec(regexp.QuoteMeta(`DELETE FROM counter_flushes`)).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+}
+
+// --- TESTS ---
+
+func TestFlush_AggregatesIntoOneUpdatePerListing(t *testing.T) {
+	store, publisher, mockPool, svc := newTestService(t)
+	id := listingUUID(t)
+
+	// Thousands of hits collapse into a single row update
+	for i := 0; i < 1000; i++ {
+		store.Incr(listingID+":views", 1)
+	}
+	store.Incr(listingID+":downloads", 3)
+
+	expectApply(mockPool, "batch-1", id, 3, 1000)
+	expectReadBack(mockPool, id, 13, 1500)
+	expectPrune(mockPool)
+
+	n, err := svc.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	require.Len(t, publisher.published, 1)
+	assert.Equal(t, events.ListingCountersEvent{ListingID: listingID, DownloadsCount: 13, ViewsCount: 1500, SellerActivityBucket: "active_7d"}, publisher.published[0])
+	assert.Empty(t, store.batches, "batch should be cleared once applied")
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestFlush_NothingPending_TouchesNothing(t *testing.T) {
+	_, publisher, mockPool, svc := newTestService(t)
+
+	expectPrune(mockPool)
+
+	n, err := svc.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Empty(t, publisher.published)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestFlush_CrashBetweenReadAndWrite_NotLost(t *testing.T) {
+	// SCENARIO: The batch is claimed and read, then the DB write fails (the process dies).
+	// EXPECT: The batch stays in Redis and the next flush applies it, exactly once.
+
+	store, publisher, mockPool, svc := newTestService(t)
+	id := listingUUID(t)
+
+	store.Incr(listingID+":views", 5)
+
+	mockPool.ExpectBegin().WillReturnError(errors.New("connection reset"))
+
+	_, err := svc.Flush(context.Background())
+	require.Error(t, err)
+	assert.Len(t, store.batches, 1, "claimed batch must survive the failed write")
+
+	// New hits arriving meanwhile go to a fresh pending hash
+	store.Incr(listingID+":views", 2)
+
+	expectApply(mockPool, "batch-1", id, 0, 5)
+	expectReadBack(mockPool, id, 0, 5)
+	expectApply(mockPool, "batch-2", id, 0, 2)
+	expectReadBack(mockPool, id, 0, 7)
+	expectPrune(mockPool)
+
+	n, err := svc.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Empty(t, store.batches)
+	assert.Equal(t, int64(7), publisher.published[len(publisher.published)-1].ViewsCount)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestFlush_CrashAfterCommit_NotDoubleApplied(t *testing.T) {
+	// SCENARIO: The increments commit but the process dies before the batch is cleared from Redis.
+	// EXPECT: The replay sees the batch ID already recorded and skips the UPDATE, then clears it.
+
+	store, publisher, mockPool, svc := newTestService(t)
+	id := listingUUID(t)
+
+	store.Incr(listingID+":downloads", 4)
+	store.clearErr = errors.New("redis went away")
+
+	expectApply(mockPool, "batch-1", id, 4, 0)
+	expectReadBack(mockPool, id, 4, 0)
+
+	_, err := svc.Flush(context.Background())
+	require.Error(t, err)
+	assert.Len(t, store.batches, 1)
+
+	store.clearErr = nil
+
+	// Replay: batch already recorded, no UPDATE expected
+	mockPool.ExpectBegin()
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO counter_flushes`)).
+		WithArgs("batch-1").
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+	mockPool.ExpectCommit()
+	expectReadBack(mockPool, id, 4, 0)
+	expectPrune(mockPool)
+
+	n, err := svc.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Empty(t, store.batches)
+
+	// Both publishes carry the same message ID so JetStream drops the duplicate
+	require.Len(t, publisher.msgIDs, 2)
+	assert.Equal(t, publisher.msgIDs[0], publisher.msgIDs[1])
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestFlush_MalformedFields_Skipped(t *testing.T) {
+	store, _, mockPool, svc := newTestService(t)
+	id := listingUUID(t)
+
+	store.Incr("not-a-uuid:views", 1)
+	store.Incr("garbage", 1)
+	store.Incr(listingID+":views", 1)
+
+	expectApply(mockPool, "batch-1", id, 0, 1)
+	expectReadBack(mockPool, id, 0, 1)
+	expectPrune(mockPool)
+
+	n, err := svc.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+const (
+	receiptID  = "7d444840-9dc0-11d1-b245-5ffdce74fad2"
+	receiptID2 = "7d444840-9dc0-11d1-b245-5ffdce74fad3"
+	buyerID    = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	fileID     = "22222222-2222-2222-2222-222222222222"
+)
+
+func receipt(id, userID string) string {
+	user := ""
+	if userID != "" {
+		user = `"user_id":"` + userID + `",`
+	}
+	return `{"id":"` + id + `",` + user + `"listing_id":"` + listingID + `","file_id":"` + fileID + `","downloaded_at":"2026-10-16T09:30:00Z","expires_at":"2026-10-16T09:45:00Z"}`
+}
+
+func uuidOf(t *testing.T, s string) pgtype.UUID {
+	t.Helper()
+	var id pgtype.UUID
+	require.NoError(t, id.Scan(s))
+	return id
+}
+
+func TestFlush_RecordsDownloadReceipts(t *testing.T) {
+	// SCENARIO: A buyer and an anonymous visitor download a file, and a garbled receipt is in the same batch.
+	// EXPECT: Both downloads are inserted in one transaction, the anonymous one without a user, the garbled one is
+	// skipped and the batch is cleared.
+
+	receipts, mockPool, svc := newReceiptsTestService(t, 0)
+	receipts.Push(receipt(receiptID, buyerID))
+	receipts.Push(`{"id":`)
+	receipts.Push(receipt(receiptID2, ""))
+
+	downloadedAt := pgtype.Timestamptz{Time: time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC), Valid: true}
+	expiresAt := pgtype.Timestamptz{Time: time.Date(2026, 10, 16, 9, 45, 0, 0, time.UTC), Valid: true}
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO downloads`)).
+		WithArgs(uuidOf(t, receiptID), uuidOf(t, buyerID), downloadedAt, expiresAt, uuidOf(t, fileID), listingUUID(t)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO downloads`)).
+		WithArgs(uuidOf(t, receiptID2), pgtype.UUID{}, downloadedAt, expiresAt, uuidOf(t, fileID), listingUUID(t)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockPool.ExpectCommit()
+	expectPrune(mockPool)
+
+	_, err := svc.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, receipts.batches)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestFlush_DownloadWriteFails_BatchKept(t *testing.T) {
+	receipts, mockPool, svc := newReceiptsTestService(t, 0)
+	receipts.Push(receipt(receiptID, buyerID))
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO downloads`)).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnError(errors.New("connection reset"))
+	mockPool.ExpectRollback()
+
+	_, err := svc.Flush(context.Background())
+	require.Error(t, err)
+	assert.Len(t, receipts.batches, 1, "receipts must survive the failed write")
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestFlush_AnonymizesDownloadsPastRetention(t *testing.T) {
+	// SCENARIO: Downloads are kept against users for 30 days.
+	// EXPECT: Each flush drops the user from downloads made before then.
+
+	_, mockPool, svc := newReceiptsTestService(t, 30*24*time.Hour)
+
+	expectPrune(mockPool)
+	mockPool.ExpectExec(regexp.QuoteMeta(`UPDATE downloads SET user_id = NULL`)).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 12))
+
+	_, err := svc.Flush(context.Background())
+	require.NoError(t, err)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestFlush_ShortLinkClicks(t *testing.T) {
+	// SCENARIO: Two short links were clicked, one of them a batch a crashed flush already committed.
+	// EXPECT: One UPDATE per code for the new batch, nothing re-added for the replay, and both batches cleared.
+
+	clicks, mockPool, svc := newClicksTestService(t)
+	clicks.batches["replayed"] = map[string]int64{"Ab3dE9": 4}
+	clicks.Incr("Ab3dE9", 2)
+	clicks.Incr("Zz9yX8", 1)
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO counter_flushes`)).WithArgs("replayed").
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+	mockPool.ExpectCommit()
+	mockPool.ExpectBegin()
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO counter_flushes`)).WithArgs("batch-1").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockPool.ExpectExec(regexp.QuoteMeta(`UPDATE short_links`)).WithArgs(int32(2), "Ab3dE9").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockPool.ExpectExec(regexp.QuoteMeta(`UPDATE short_links`)).WithArgs(int32(1), "Zz9yX8").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockPool.ExpectCommit()
+	expectPrune(mockPool)
+
+	_, err := svc.Flush(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, clicks.batches)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -0,0 +1,98 @@
+package counters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// pendingKey is the hash the gateway increments, one field per "<listing id>:<counter>"
+	pendingKey = "counters:pending"
+	// batchPrefix marks a hash claimed by a flush. Nothing writes to it after the rename.
+	batchPrefix = "counters:batch:"
+	// clicksKey is the hash of short link clicks, one field per code
+	clicksKey = "short-links:clicks:pending"
+	// clicksBatchPrefix marks a clicks hash claimed by a flush, like batchPrefix
+	clicksBatchPrefix = "short-links:clicks:batch:"
+)
+
+// PendingStore holds counter deltas between flushes, listing counters and short link clicks each have their own.
+type PendingStore interface {
+	// Claim atomically moves the pending deltas into a new batch and returns its ID, or "" if nothing is pending.
+	Claim(ctx context.Context) (string, error)
+	// Batches lists batches that were claimed but never cleared, i.e. a flush died part way through.
+	Batches(ctx context.Context) ([]string, error)
+	// Read returns the deltas in a batch keyed by "<listing id>:<counter>".
+	Read(ctx context.Context, batchID string) (map[string]int64, error)
+	// Clear removes a batch once it has been applied.
+	Clear(ctx context.Context, batchID string) error
+}
+
+type RedisStore struct {
+	rdb         *redis.Client
+	pendingKey  string
+	batchPrefix string
+}
+
+func NewRedisStore(rdb *redis.Client) *RedisStore {
+	return &RedisStore{rdb: rdb, pendingKey: pendingKey, batchPrefix: batchPrefix}
+}
+
+// NewRedisClickStore holds short link clicks, the deltas are keyed by code
+func NewRedisClickStore(rdb *redis.Client) *RedisStore {
+	return &RedisStore{rdb: rdb, pendingKey: clicksKey, batchPrefix: clicksBatchPrefix}
+}
+
+func (s *RedisStore) Claim(ctx context.Context) (string, error) {
+	batchID := uuid.NewString()
+
+	// RENAME is atomic: increments that land after this go to a fresh pending hash, none are lost
+	err := s.rdb.Rename(ctx, s.pendingKey, s.batchPrefix+batchID).Err()
+	if err != nil {
+		if strings.Contains(err.Error(), "no such key") {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to claim %s: %w", s.pendingKey, err)
+	}
+	return batchID, nil
+}
+
+func (s *RedisStore) Batches(ctx context.Context) ([]string, error) {
+	var batches []string
+
+	iter := s.rdb.Scan(ctx, 0, s.batchPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		batches = append(batches, strings.TrimPrefix(iter.Val(), s.batchPrefix))
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list %s batches: %w", s.pendingKey, err)
+	}
+	return batches, nil
+}
+
+func (s *RedisStore) Read(ctx context.Context, batchID string) (map[string]int64, error) {
+	raw, err := s.rdb.HGetAll(ctx, s.batchPrefix+batchID).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to read counter batch %s: %w", batchID, err)
+	}
+
+	deltas := make(map[string]int64, len(raw))
+	for field, value := range raw {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid counter value for %s in batch %s: %w", field, batchID, err)
+		}
+		deltas[field] = n
+	}
+	return deltas, nil
+}
+
+func (s *RedisStore) Clear(ctx context.Context, batchID string) error {
+	return s.rdb.Del(ctx, s.batchPrefix+batchID).Err()
+}
@@ -0,0 +1,21 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+)
+
+// LagReader is the sqlc repository of the database the heavy scans read from, see repo.Querier
+type LagReader interface {
+	GetReplicaLagSeconds(ctx context.Context) (float64, error)
+}
+
+// ReplicaLag is how far the database db reads from is behind its primary, changes made on the primary within the lag
+// aren't visible there yet. A primary is never behind, so it's 0 when the worker has no replica.
+func ReplicaLag(ctx context.Context, db LagReader) (time.Duration, error) {
+	seconds, err := db.GetReplicaLagSeconds(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
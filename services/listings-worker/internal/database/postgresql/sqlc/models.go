@@ -11,6 +11,48 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type BannedTermSeverity string
+
+const (
+	BannedTermSeverityREJECT BannedTermSeverity = "REJECT"
+	BannedTermSeverityFLAG   BannedTermSeverity = "FLAG"
+)
+
+func (e *BannedTermSeverity) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = BannedTermSeverity(s)
+	case string:
+		*e = BannedTermSeverity(s)
+	default:
+		return fmt.Errorf("unsupported scan type for BannedTermSeverity: %T", src)
+	}
+	return nil
+}
+
+type NullBannedTermSeverity struct {
+	BannedTermSeverity BannedTermSeverity `json:"banned_term_severity"`
+	Valid              bool               `json:"valid"` // Valid is true if BannedTermSeverity is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullBannedTermSeverity) Scan(value interface{}) error {
+	if value == nil {
+		ns.BannedTermSeverity, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.BannedTermSeverity.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullBannedTermSeverity) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.BannedTermSeverity), nil
+}
+
 type FileStatus string
 
 const (
@@ -104,6 +146,8 @@ const (
 	ListingStatusACTIVE            ListingStatus = "ACTIVE"
 	ListingStatusREJECTED          ListingStatus = "REJECTED"
 	ListingStatusHIDDEN            ListingStatus = "HIDDEN"
+	ListingStatusPENDINGREVIEW     ListingStatus = "PENDING_REVIEW"
+	ListingStatusSUSPENDED         ListingStatus = "SUSPENDED"
 )
 
 func (e *ListingStatus) Scan(src interface{}) error {
@@ -141,6 +185,164 @@ func (ns NullListingStatus) Value() (driver.Value, error) {
 	return string(ns.ListingStatus), nil
 }
 
+type ListingStatusActor string
+
+const (
+	ListingStatusActorSYSTEM    ListingStatusActor = "SYSTEM"
+	ListingStatusActorUSER      ListingStatusActor = "USER"
+	ListingStatusActorMODERATOR ListingStatusActor = "MODERATOR"
+)
+
+func (e *ListingStatusActor) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ListingStatusActor(s)
+	case string:
+		*e = ListingStatusActor(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ListingStatusActor: %T", src)
+	}
+	return nil
+}
+
+type NullListingStatusActor struct {
+	ListingStatusActor ListingStatusActor `json:"listing_status_actor"`
+	Valid              bool               `json:"valid"` // Valid is true if ListingStatusActor is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullListingStatusActor) Scan(value interface{}) error {
+	if value == nil {
+		ns.ListingStatusActor, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ListingStatusActor.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullListingStatusActor) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ListingStatusActor), nil
+}
+
+type PayoutStatus string
+
+const (
+	PayoutStatusNOTSTARTED PayoutStatus = "NOT_STARTED"
+	PayoutStatusPENDING    PayoutStatus = "PENDING"
+	PayoutStatusVERIFIED   PayoutStatus = "VERIFIED"
+	PayoutStatusREJECTED   PayoutStatus = "REJECTED"
+)
+
+func (e *PayoutStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = PayoutStatus(s)
+	case string:
+		*e = PayoutStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for PayoutStatus: %T", src)
+	}
+	return nil
+}
+
+type NullPayoutStatus struct {
+	PayoutStatus PayoutStatus `json:"payout_status"`
+	Valid        bool         `json:"valid"` // Valid is true if PayoutStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullPayoutStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.PayoutStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.PayoutStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullPayoutStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.PayoutStatus), nil
+}
+
+type BannedTerm struct {
+	Term      string             `json:"term"`
+	Severity  BannedTermSeverity `json:"severity"`
+	UpdatedBy pgtype.UUID        `json:"updated_by"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type CallbackDestination struct {
+	Destination         string             `json:"destination"`
+	ConsecutiveFailures int32              `json:"consecutive_failures"`
+	DisabledAt          pgtype.Timestamptz `json:"disabled_at"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+}
+
+type CategoryDefault struct {
+	Category             string             `json:"category"`
+	RecommendedMaterials []string           `json:"recommended_materials"`
+	NozzleTempMinC       int32              `json:"nozzle_temp_min_c"`
+	NozzleTempMaxC       int32              `json:"nozzle_temp_max_c"`
+	TypicalDimensionsMm  []byte             `json:"typical_dimensions_mm"`
+	UpdatedBy            pgtype.UUID        `json:"updated_by"`
+	UpdatedAt            pgtype.Timestamptz `json:"updated_at"`
+}
+
+type CounterFlush struct {
+	BatchID   string             `json:"batch_id"`
+	FlushedAt pgtype.Timestamptz `json:"flushed_at"`
+}
+
+type Download struct {
+	ID           pgtype.UUID        `json:"id"`
+	UserID       pgtype.UUID        `json:"user_id"`
+	ListingID    pgtype.UUID        `json:"listing_id"`
+	FileID       pgtype.UUID        `json:"file_id"`
+	DownloadedAt pgtype.Timestamptz `json:"downloaded_at"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+type EventOutbox struct {
+	ID            int64              `json:"id"`
+	Subject       string             `json:"subject"`
+	MsgID         string             `json:"msg_id"`
+	Payload       []byte             `json:"payload"`
+	Attempts      int32              `json:"attempts"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	LastError     pgtype.Text        `json:"last_error"`
+	PublishedAt   pgtype.Timestamptz `json:"published_at"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	FailedAt      pgtype.Timestamptz `json:"failed_at"`
+}
+
+type FeaturedListing struct {
+	ID        pgtype.UUID        `json:"id"`
+	ListingID pgtype.UUID        `json:"listing_id"`
+	StartsAt  pgtype.Timestamptz `json:"starts_at"`
+	EndsAt    pgtype.Timestamptz `json:"ends_at"`
+	Weight    int32              `json:"weight"`
+	CreatedBy pgtype.UUID        `json:"created_by"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	Applied   bool               `json:"applied"`
+	DeletedAt pgtype.Timestamptz `json:"deleted_at"`
+}
+
+type HardwareOption struct {
+	Name      string             `json:"name"`
+	CreatedBy pgtype.UUID        `json:"created_by"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
 type Listing struct {
 	ID                     pgtype.UUID        `json:"id"`
 	SellerID               pgtype.UUID        `json:"seller_id"`
@@ -153,8 +355,6 @@ type Listing struct {
 	Currency               string             `json:"currency"`
 	Categories             []string           `json:"categories"`
 	License                string             `json:"license"`
-	ClientID               string             `json:"client_id"`
-	TraceID                string             `json:"trace_id"`
 	ThumbnailPath          pgtype.Text        `json:"thumbnail_path"`
 	LastIndexedAt          pgtype.Timestamptz `json:"last_indexed_at"`
 	Status                 NullListingStatus  `json:"status"`
@@ -171,11 +371,11 @@ type Listing struct {
 	RecommendedMaterials   []string           `json:"recommended_materials"`
 	IsAiGenerated          bool               `json:"is_ai_generated"`
 	AiModelName            pgtype.Text        `json:"ai_model_name"`
-	LikesCount             pgtype.Int4        `json:"likes_count"`
-	DownloadsCount         pgtype.Int4        `json:"downloads_count"`
-	CommentsCount          pgtype.Int4        `json:"comments_count"`
+	LikesCount             int32              `json:"likes_count"`
+	DownloadsCount         int32              `json:"downloads_count"`
+	CommentsCount          int32              `json:"comments_count"`
 	IsSaleActive           bool               `json:"is_sale_active"`
-	SalePrice              pgtype.Numeric     `json:"sale_price"`
+	SalePrice              pgtype.Int8        `json:"sale_price"`
 	SaleName               pgtype.Text        `json:"sale_name"`
 	SaleEndTimestamp       pgtype.Timestamptz `json:"sale_end_timestamp"`
 	SellerRatingAverage    pgtype.Numeric     `json:"seller_rating_average"`
@@ -185,6 +385,20 @@ type Listing struct {
 	CreatedAt              pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt              pgtype.Timestamptz `json:"updated_at"`
 	DeletedAt              pgtype.Timestamptz `json:"deleted_at"`
+	ViewsCount             int32              `json:"views_count"`
+	NozzleDiameterMm       pgtype.Numeric     `json:"nozzle_diameter_mm"`
+	Language               string             `json:"language"`
+}
+
+type ListingBulkAction struct {
+	ID         int64              `json:"id"`
+	Action     string             `json:"action"`
+	UserID     pgtype.UUID        `json:"user_id"`
+	Username   string             `json:"username"`
+	ListingIds []pgtype.UUID      `json:"listing_ids"`
+	Failed     int32              `json:"failed"`
+	TraceID    pgtype.Text        `json:"trace_id"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
 }
 
 type ListingFile struct {
@@ -202,3 +416,121 @@ type ListingFile struct {
 	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
 	DeletedAt    pgtype.Timestamptz `json:"deleted_at"`
 }
+
+type ListingIndexFailure struct {
+	ListingID  pgtype.UUID        `json:"listing_id"`
+	ErrorClass string             `json:"error_class"`
+	Error      string             `json:"error"`
+	Attempts   int32              `json:"attempts"`
+	FailedAt   pgtype.Timestamptz `json:"failed_at"`
+}
+
+type ListingPriceHistory struct {
+	ID              pgtype.UUID        `json:"id"`
+	ListingID       pgtype.UUID        `json:"listing_id"`
+	OldPriceMinUnit int64              `json:"old_price_min_unit"`
+	NewPriceMinUnit int64              `json:"new_price_min_unit"`
+	OldCurrency     string             `json:"old_currency"`
+	NewCurrency     string             `json:"new_currency"`
+	ChangedAt       pgtype.Timestamptz `json:"changed_at"`
+}
+
+type ListingReport struct {
+	ID         pgtype.UUID        `json:"id"`
+	ListingID  pgtype.UUID        `json:"listing_id"`
+	ReporterID pgtype.UUID        `json:"reporter_id"`
+	Reason     string             `json:"reason"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type ListingScreeningFlag struct {
+	ListingID pgtype.UUID        `json:"listing_id"`
+	Terms     []string           `json:"terms"`
+	FlaggedAt pgtype.Timestamptz `json:"flagged_at"`
+}
+
+type ListingStatusEvent struct {
+	ID         pgtype.UUID        `json:"id"`
+	ListingID  pgtype.UUID        `json:"listing_id"`
+	Actor      ListingStatusActor `json:"actor"`
+	ActorID    pgtype.UUID        `json:"actor_id"`
+	FromStatus NullListingStatus  `json:"from_status"`
+	ToStatus   ListingStatus      `json:"to_status"`
+	Reason     pgtype.Text        `json:"reason"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	ClientID   pgtype.Text        `json:"client_id"`
+	TraceID    pgtype.Text        `json:"trace_id"`
+}
+
+type ListingVariant struct {
+	ID           pgtype.UUID        `json:"id"`
+	ListingID    pgtype.UUID        `json:"listing_id"`
+	Name         string             `json:"name"`
+	PriceMinUnit int64              `json:"price_min_unit"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+type ListingVariantFile struct {
+	VariantID pgtype.UUID `json:"variant_id"`
+	FileID    pgtype.UUID `json:"file_id"`
+}
+
+type SavedSearch struct {
+	ID                 pgtype.UUID        `json:"id"`
+	UserID             pgtype.UUID        `json:"user_id"`
+	Query              string             `json:"query"`
+	Filters            []byte             `json:"filters"`
+	FilterBy           string             `json:"filter_by"`
+	NotifiedListingIds []string           `json:"notified_listing_ids"`
+	LastCheckedAt      pgtype.Timestamptz `json:"last_checked_at"`
+	NextCheckAt        pgtype.Timestamptz `json:"next_check_at"`
+	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+}
+
+type Seller struct {
+	UserID               pgtype.UUID        `json:"user_id"`
+	DisplayName          string             `json:"display_name"`
+	Country              string             `json:"country"`
+	PayoutStatus         PayoutStatus       `json:"payout_status"`
+	AcceptedTermsVersion pgtype.Text        `json:"accepted_terms_version"`
+	AcceptedTermsAt      pgtype.Timestamptz `json:"accepted_terms_at"`
+	CreatedAt            pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt            pgtype.Timestamptz `json:"updated_at"`
+	VacationStartsAt     pgtype.Timestamptz `json:"vacation_starts_at"`
+	VacationEndsAt       pgtype.Timestamptz `json:"vacation_ends_at"`
+	VacationMessage      pgtype.Text        `json:"vacation_message"`
+	VacationApplied      bool               `json:"vacation_applied"`
+	LastActiveAt         pgtype.Timestamptz `json:"last_active_at"`
+}
+
+type SellerPinnedListing struct {
+	ListingID pgtype.UUID        `json:"listing_id"`
+	SellerID  pgtype.UUID        `json:"seller_id"`
+	Position  int16              `json:"position"`
+	PinnedAt  pgtype.Timestamptz `json:"pinned_at"`
+}
+
+type ShortLink struct {
+	Code        string             `json:"code"`
+	ListingID   pgtype.UUID        `json:"listing_id"`
+	CreatedBy   pgtype.UUID        `json:"created_by"`
+	Campaign    pgtype.Text        `json:"campaign"`
+	ClicksCount int32              `json:"clicks_count"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	RevokedAt   pgtype.Timestamptz `json:"revoked_at"`
+}
+
+type UploadCallback struct {
+	StorageKey    string             `json:"storage_key"`
+	UserID        pgtype.UUID        `json:"user_id"`
+	CallbackUrl   string             `json:"callback_url"`
+	Destination   string             `json:"destination"`
+	FileID        pgtype.UUID        `json:"file_id"`
+	Attempts      int32              `json:"attempts"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	LastError     pgtype.Text        `json:"last_error"`
+	DeliveredAt   pgtype.Timestamptz `json:"delivered_at"`
+	AbandonedAt   pgtype.Timestamptz `json:"abandoned_at"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	ExpiresAt     pgtype.Timestamptz `json:"expires_at"`
+}
@@ -11,10 +11,83 @@ import (
 )
 
 type Querier interface {
+	// Forgets who made downloads older than the retention period, the rows stay for aggregate stats
+	AnonymizeDownloadsBefore(ctx context.Context, downloadedAt pgtype.Timestamptz) (int64, error)
+	// Forgets which client and trace created listings longer ago than the trace retention window, a batch at a time
+	AnonymizeStatusEventsBefore(ctx context.Context, arg AnonymizeStatusEventsBeforeParams) (int64, error)
+	// Same guard as SetSellerVacationApplied, a new vacation booked while the last one was being wound down is kept
+	ClearSellerVacation(ctx context.Context, arg ClearSellerVacationParams) error
+	// Refcount check: other listings pointing at the same object keep it alive
+	CountOtherFileReferences(ctx context.Context, arg CountOtherFileReferencesParams) (int64, error)
+	DeleteCounterFlushesBefore(ctx context.Context, flushedAt pgtype.Timestamptz) error
+	// Callbacks for uploads no listing claimed in time
+	DeleteExpiredUploadCallbacks(ctx context.Context) (int64, error)
+	// Runs in the purge transaction before the parent row goes, the foreign key would do the same but silently
+	DetachRemixes(ctx context.Context, parentListingID pgtype.UUID) (int64, error)
+	// Includes soft-deleted files, the purge needs every object the listing ever owned
+	GetAllFilesByListingID(ctx context.Context, listingID pgtype.UUID) ([]ListingFile, error)
+	// No row when the listing isn't sold as variants
+	GetCheapestVariantPrice(ctx context.Context, listingID pgtype.UUID) (int64, error)
+	// Saved searches whose next check has come round, longest overdue first
+	GetDueSavedSearches(ctx context.Context, batchSize int32) ([]SavedSearch, error)
+	// Callbacks whose file has finished validation and that are due an attempt, leaving out disabled destinations. At most
+	// per_destination per destination (0 for no cap) so one busy partner can't fill the batch, oldest first.
+	GetDueUploadCallbacks(ctx context.Context, arg GetDueUploadCallbacksParams) ([]GetDueUploadCallbacksRow, error)
+	// A listing's featured windows that haven't ended yet, it is featured while any of them runs
+	GetFeatureWindows(ctx context.Context, listingID pgtype.UUID) ([]GetFeatureWindowsRow, error)
 	GetFilesByListingID(ctx context.Context, listingID pgtype.UUID) ([]ListingFile, error)
+	GetLatestPriceChange(ctx context.Context, listingID pgtype.UUID) (ListingPriceHistory, error)
 	GetListingByID(ctx context.Context, id pgtype.UUID) (Listing, error)
-	// The worker calls this AFTER successfully pushing to Typesense
+	// Deleted listings are left out, a counter patch for one would put its search document back
+	GetListingCounters(ctx context.Context, ids []pgtype.UUID) ([]GetListingCountersRow, error)
+	// Live listings' counters next to the downloads recorded for them, keyset paginated. listing_id narrows it to one.
+	GetListingCountsForReconcile(ctx context.Context, arg GetListingCountsForReconcileParams) ([]GetListingCountsForReconcileRow, error)
+	// Every live listing, keyset paginated so a backfill can resume after the last listing it finished
+	GetListingsForBackfill(ctx context.Context, arg GetListingsForBackfillParams) ([]Listing, error)
+	// Keyset paginated, oldest soft-deleted listings first so an interrupted purge resumes where it stopped
+	GetListingsForPurge(ctx context.Context, arg GetListingsForPurgeParams) ([]Listing, error)
+	// Listings still indexed as price_dropped_recently whose drop is now older than the window, so the flag can be cleared
+	GetListingsWithExpiredPriceDrops(ctx context.Context, arg GetListingsWithExpiredPriceDropsParams) ([]pgtype.UUID, error)
+	// Listings with a featured window that started, ended or was deleted since is_featured was last brought in line with it
+	GetListingsWithFeatureChanges(ctx context.Context, arg GetListingsWithFeatureChangesParams) ([]pgtype.UUID, error)
+	// How far behind its primary this database is, 0 once it has replayed everything it received or when it is the primary
+	GetReplicaLagSeconds(ctx context.Context) (float64, error)
+	GetSellerByUserID(ctx context.Context, userID pgtype.UUID) (Seller, error)
+	GetSellerLastActive(ctx context.Context, userID pgtype.UUID) (pgtype.Timestamptz, error)
+	// A seller's live listings, keyset paginated for reindexing them when a vacation starts or ends
+	GetSellerListingIDs(ctx context.Context, arg GetSellerListingIDsParams) ([]pgtype.UUID, error)
+	GetSellerVacation(ctx context.Context, userID pgtype.UUID) (GetSellerVacationRow, error)
+	// Sellers whose listings are indexed for the wrong side of a vacation boundary, or whose vacation is over and can be cleared
+	GetSellersWithVacationChanges(ctx context.Context, batchSize int32) ([]GetSellersWithVacationChangesRow, error)
+	// Listings whose search document is missing or older than the row, keyset paginated for bulk reindexing
+	GetStaleListingIDs(ctx context.Context, arg GetStaleListingIDsParams) ([]pgtype.UUID, error)
+	// Only ever removes rows that have already been soft-deleted
+	HardDeleteListing(ctx context.Context, id pgtype.UUID) error
+	HardDeleteListingFiles(ctx context.Context, listingID pgtype.UUID) error
+	IncrementListingCounters(ctx context.Context, arg IncrementListingCountersParams) error
+	IncrementShortLinkClicks(ctx context.Context, arg IncrementShortLinkClicksParams) error
+	// Search documents only link a remix to a parent that hasn't been deleted
+	IsListingLive(ctx context.Context, id pgtype.UUID) (bool, error)
+	// The worker calls this AFTER successfully pushing to Typesense. Clears any earlier failure the gateway recorded,
+	// the listing is in search again.
 	MarkListingAsIndexed(ctx context.Context, id pgtype.UUID) error
+	MarkSavedSearchChecked(ctx context.Context, arg MarkSavedSearchCheckedParams) error
+	MarkUploadCallbackDelivered(ctx context.Context, arg MarkUploadCallbackDeliveredParams) error
+	// Counts another failure in a row against the destination, disabling it at disable_after. Returns whether it is disabled.
+	RecordCallbackDestinationFailure(ctx context.Context, arg RecordCallbackDestinationFailureParams) (bool, error)
+	// Returns 0 rows when the batch was already applied, e.g. the worker died before clearing it from Redis
+	RecordCounterFlush(ctx context.Context, batchID string) (int64, error)
+	// A receipt queued by the gateway. The file must belong to the listing, replays of a receipt are ignored.
+	RecordDownload(ctx context.Context, arg RecordDownloadParams) (int64, error)
+	// A failed attempt, tried again at next_attempt_at unless abandon is set
+	RecordUploadCallbackFailure(ctx context.Context, arg RecordUploadCallbackFailureParams) error
+	// A delivery went through, the failures in a row start again
+	ResetCallbackDestination(ctx context.Context, destination string) error
+	// Records every window of the listing as brought in line at now, the same now the listing's is_featured was worked out at
+	SetFeaturedListingsApplied(ctx context.Context, arg SetFeaturedListingsAppliedParams) error
+	SetListingDownloadsCount(ctx context.Context, arg SetListingDownloadsCountParams) error
+	// Guarded by the vacation the listings were reindexed for, one the seller changed since is picked up on the next pass
+	SetSellerVacationApplied(ctx context.Context, arg SetSellerVacationAppliedParams) error
 }
 
 var _ Querier = (*Queries)(nil)
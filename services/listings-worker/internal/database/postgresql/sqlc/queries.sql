@@ -5,11 +5,305 @@ FROM listings
 WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: MarkListingAsIndexed :exec
--- The worker calls this AFTER successfully pushing to Typesense
+-- The worker calls this AFTER successfully pushing to Typesense. Clears any earlier failure the gateway recorded,
+-- the listing is in search again.
+WITH resolved AS (
+    DELETE FROM listing_index_failures WHERE listing_id = $1
+)
 UPDATE listings 
 SET last_indexed_at = CURRENT_TIMESTAMP
-WHERE id = $1;
+WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: GetFilesByListingID :many
 SELECT * FROM listing_files 
 WHERE listing_id = $1 AND deleted_at IS NULL;
+
+
+-- name: GetListingsForPurge :many
+-- Keyset paginated, oldest soft-deleted listings first so an interrupted purge resumes where it stopped
+SELECT * FROM listings
+WHERE deleted_at IS NOT NULL
+    AND deleted_at < sqlc.arg(cutoff)
+    AND (deleted_at, id) > (sqlc.arg(after_deleted_at)::timestamptz, sqlc.arg(after_id)::uuid)
+ORDER BY deleted_at ASC, id ASC
+LIMIT sqlc.arg(batch_size);
+
+-- name: GetAllFilesByListingID :many
+-- Includes soft-deleted files, the purge needs every object the listing ever owned
+SELECT * FROM listing_files
+WHERE listing_id = $1;
+
+-- name: CountOtherFileReferences :one
+-- Refcount check: other listings pointing at the same object keep it alive
+SELECT COUNT(*) FROM listing_files
+WHERE file_path = $1 AND listing_id <> $2;
+
+-- name: HardDeleteListingFiles :exec
+DELETE FROM listing_files
+WHERE listing_id = $1;
+
+-- name: HardDeleteListing :exec
+-- Only ever removes rows that have already been soft-deleted
+DELETE FROM listings
+WHERE id = $1 AND deleted_at IS NOT NULL;
+
+-- name: GetStaleListingIDs :many
+-- Listings whose search document is missing or older than the row, keyset paginated for bulk reindexing
+SELECT id FROM listings
+WHERE deleted_at IS NULL
+    AND (last_indexed_at IS NULL OR updated_at > last_indexed_at)
+    AND id > sqlc.arg(after_id)::uuid
+ORDER BY id ASC
+LIMIT sqlc.arg(batch_size);
+
+-- name: GetListingsForBackfill :many
+-- Every live listing, keyset paginated so a backfill can resume after the last listing it finished
+SELECT * FROM listings
+WHERE deleted_at IS NULL
+    AND id > sqlc.arg(after_id)::uuid
+ORDER BY id ASC
+LIMIT sqlc.arg(batch_size);
+
+-- name: RecordCounterFlush :execrows
+-- Returns 0 rows when the batch was already applied, e.g. the worker died before clearing it from Redis
+INSERT INTO counter_flushes (batch_id) VALUES ($1)
+ON CONFLICT (batch_id) DO NOTHING;
+
+-- name: IncrementListingCounters :exec
+UPDATE listings
+SET downloads_count = downloads_count + sqlc.arg(downloads)::int,
+    views_count = views_count + sqlc.arg(views)::int
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL;
+
+-- name: GetListingCounters :many
+-- Deleted listings are left out, a counter patch for one would put its search document back
+SELECT l.id, l.downloads_count, l.views_count, s.last_active_at AS seller_last_active_at
+FROM listings l
+LEFT JOIN sellers s ON s.user_id = l.seller_id
+WHERE l.id = ANY(sqlc.arg(ids)::uuid[]) AND l.deleted_at IS NULL;
+
+-- name: DeleteCounterFlushesBefore :exec
+DELETE FROM counter_flushes WHERE flushed_at < $1;
+
+-- name: GetSellerByUserID :one
+SELECT * FROM sellers
+WHERE user_id = $1;
+
+-- name: GetSellerLastActive :one
+SELECT last_active_at FROM sellers
+WHERE user_id = $1;
+
+-- name: GetSellerVacation :one
+SELECT vacation_starts_at, vacation_ends_at FROM sellers
+WHERE user_id = $1;
+
+-- name: GetSellersWithVacationChanges :many
+-- Sellers whose listings are indexed for the wrong side of a vacation boundary, or whose vacation is over and can be cleared
+SELECT user_id, vacation_starts_at, vacation_ends_at FROM sellers
+WHERE vacation_ends_at IS NOT NULL
+    AND (vacation_ends_at <= now() OR vacation_applied <> (vacation_starts_at <= now()))
+ORDER BY vacation_ends_at ASC
+LIMIT sqlc.arg(batch_size);
+
+-- name: SetSellerVacationApplied :exec
+-- Guarded by the vacation the listings were reindexed for, one the seller changed since is picked up on the next pass
+UPDATE sellers
+SET vacation_applied = sqlc.arg(applied)
+WHERE user_id = sqlc.arg(user_id)
+    AND vacation_starts_at = sqlc.arg(starts_at)
+    AND vacation_ends_at = sqlc.arg(ends_at);
+
+-- name: ClearSellerVacation :exec
+-- Same guard as SetSellerVacationApplied, a new vacation booked while the last one was being wound down is kept
+UPDATE sellers
+SET vacation_starts_at = NULL, vacation_ends_at = NULL, vacation_message = NULL, vacation_applied = false
+WHERE user_id = sqlc.arg(user_id) AND vacation_ends_at = sqlc.arg(ends_at);
+
+-- name: GetSellerListingIDs :many
+-- A seller's live listings, keyset paginated for reindexing them when a vacation starts or ends
+SELECT id FROM listings
+WHERE seller_id = sqlc.arg(seller_id)
+    AND deleted_at IS NULL
+    AND id > sqlc.arg(after_id)::uuid
+ORDER BY id ASC
+LIMIT sqlc.arg(batch_size);
+
+-- name: GetLatestPriceChange :one
+SELECT * FROM listing_price_history
+WHERE listing_id = $1
+ORDER BY changed_at DESC
+LIMIT 1;
+
+-- name: GetCheapestVariantPrice :one
+-- No row when the listing isn't sold as variants
+SELECT price_min_unit FROM listing_variants
+WHERE listing_id = $1
+ORDER BY price_min_unit
+LIMIT 1;
+
+-- name: GetListingsWithExpiredPriceDrops :many
+-- Listings still indexed as price_dropped_recently whose drop is now older than the window, so the flag can be cleared
+SELECT l.id FROM listings l
+JOIN LATERAL (
+    SELECT h.old_price_min_unit, h.new_price_min_unit, h.old_currency, h.new_currency, h.changed_at
+    FROM listing_price_history h
+    WHERE h.listing_id = l.id
+    ORDER BY h.changed_at DESC
+    LIMIT 1
+) latest ON true
+WHERE l.deleted_at IS NULL
+    AND l.status = 'ACTIVE'
+    AND l.thumbnail_path IS NOT NULL
+    AND latest.new_price_min_unit < latest.old_price_min_unit
+    AND latest.new_currency = latest.old_currency
+    AND latest.changed_at + sqlc.arg(drop_window)::interval <= now()
+    AND l.last_indexed_at < latest.changed_at + sqlc.arg(drop_window)::interval
+ORDER BY latest.changed_at ASC
+LIMIT sqlc.arg(batch_size);
+
+-- name: GetDueSavedSearches :many
+-- Saved searches whose next check has come round, longest overdue first
+SELECT * FROM saved_searches
+WHERE next_check_at <= now()
+ORDER BY next_check_at ASC
+LIMIT sqlc.arg(batch_size);
+
+-- name: MarkSavedSearchChecked :exec
+UPDATE saved_searches
+SET last_checked_at = sqlc.arg(checked_at),
+    next_check_at = sqlc.arg(next_check_at),
+    notified_listing_ids = sqlc.arg(notified_listing_ids)::text[]
+WHERE id = sqlc.arg(id);
+
+-- name: RecordDownload :execrows
+-- A receipt queued by the gateway. The file must belong to the listing, replays of a receipt are ignored.
+INSERT INTO downloads (id, user_id, listing_id, file_id, downloaded_at, expires_at)
+SELECT sqlc.arg(id)::uuid, sqlc.narg(user_id)::uuid, f.listing_id, f.id, sqlc.arg(downloaded_at)::timestamptz, sqlc.arg(expires_at)::timestamptz
+FROM listing_files f
+WHERE f.id = sqlc.arg(file_id) AND f.listing_id = sqlc.arg(listing_id)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: AnonymizeDownloadsBefore :execrows
+-- Forgets who made downloads older than the retention period, the rows stay for aggregate stats
+UPDATE downloads SET user_id = NULL
+WHERE user_id IS NOT NULL AND downloaded_at < $1;
+
+-- name: AnonymizeStatusEventsBefore :execrows
+-- Forgets which client and trace created listings longer ago than the trace retention window, a batch at a time
+UPDATE listing_status_events SET client_id = NULL, trace_id = NULL
+WHERE id IN (
+    SELECT e.id FROM listing_status_events e
+    WHERE (e.client_id IS NOT NULL OR e.trace_id IS NOT NULL) AND e.created_at < sqlc.arg(cutoff)
+    LIMIT sqlc.arg(batch_size)
+);
+
+-- name: IsListingLive :one
+-- Search documents only link a remix to a parent that hasn't been deleted
+SELECT EXISTS (
+    SELECT 1 FROM listings
+    WHERE id = $1 AND deleted_at IS NULL
+);
+
+-- name: DetachRemixes :execrows
+-- Runs in the purge transaction before the parent row goes, the foreign key would do the same but silently
+UPDATE listings SET parent_listing_id = NULL
+WHERE parent_listing_id = $1;
+
+-- name: GetListingCountsForReconcile :many
+-- Live listings' counters next to the downloads recorded for them, keyset paginated. listing_id narrows it to one.
+SELECT l.id, l.downloads_count, l.views_count, l.likes_count, l.updated_at,
+    (SELECT count(*) FROM downloads d WHERE d.listing_id = l.id) AS recorded_downloads,
+    s.last_active_at AS seller_last_active_at
+FROM listings l
+LEFT JOIN sellers s ON s.user_id = l.seller_id
+WHERE l.deleted_at IS NULL
+    AND l.id > sqlc.arg(after_id)::uuid
+    AND (sqlc.narg(listing_id)::uuid IS NULL OR l.id = sqlc.narg(listing_id)::uuid)
+ORDER BY l.id ASC
+LIMIT sqlc.arg(batch_size);
+
+-- name: SetListingDownloadsCount :exec
+UPDATE listings SET downloads_count = sqlc.arg(downloads)::int
+WHERE id = sqlc.arg(id) AND deleted_at IS NULL;
+
+-- name: IncrementShortLinkClicks :exec
+UPDATE short_links SET clicks_count = clicks_count + sqlc.arg(clicks)::int
+WHERE code = sqlc.arg(code);
+
+-- name: GetReplicaLagSeconds :one
+-- How far behind its primary this database is, 0 once it has replayed everything it received or when it is the primary
+SELECT COALESCE(
+    CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+        ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+    END, 0)::float8 AS lag_seconds;
+
+-- name: GetFeatureWindows :many
+-- A listing's featured windows that haven't ended yet, it is featured while any of them runs
+SELECT starts_at, ends_at FROM featured_listings
+WHERE listing_id = $1 AND ends_at > now() AND deleted_at IS NULL
+ORDER BY starts_at ASC;
+
+-- name: GetListingsWithFeatureChanges :many
+-- Listings with a featured window that started, ended or was deleted since is_featured was last brought in line with it
+SELECT DISTINCT listing_id FROM featured_listings
+WHERE applied <> (deleted_at IS NULL AND starts_at <= sqlc.arg(now)::timestamptz AND ends_at > sqlc.arg(now)::timestamptz)
+ORDER BY listing_id ASC
+LIMIT sqlc.arg(batch_size);
+
+-- name: SetFeaturedListingsApplied :exec
+-- Records every window of the listing as brought in line at now, the same now the listing's is_featured was worked out at
+UPDATE featured_listings
+SET applied = (deleted_at IS NULL AND starts_at <= sqlc.arg(now)::timestamptz AND ends_at > sqlc.arg(now)::timestamptz)
+WHERE listing_id = sqlc.arg(listing_id);
+
+-- name: GetDueUploadCallbacks :many
+-- Callbacks whose file has finished validation and that are due an attempt, leaving out disabled destinations. At most
+-- per_destination per destination (0 for no cap) so one busy partner can't fill the batch, oldest first.
+SELECT storage_key, callback_url, destination, attempts, file_size, status, error_message
+FROM (
+    SELECT uc.storage_key, uc.callback_url, uc.destination, uc.attempts, uc.next_attempt_at,
+        lf.file_size, lf.status, lf.error_message,
+        row_number() OVER (PARTITION BY uc.destination ORDER BY uc.next_attempt_at ASC) AS destination_rank
+    FROM upload_callbacks uc
+    JOIN listing_files lf ON lf.id = uc.file_id
+    LEFT JOIN callback_destinations cd ON cd.destination = uc.destination
+    WHERE uc.delivered_at IS NULL AND uc.abandoned_at IS NULL
+        AND uc.next_attempt_at <= sqlc.arg(now)::timestamptz
+        AND lf.status IN ('VALID', 'INVALID', 'FAILED')
+        AND cd.disabled_at IS NULL
+) due
+WHERE sqlc.arg(per_destination)::int = 0 OR destination_rank <= sqlc.arg(per_destination)::int
+ORDER BY next_attempt_at ASC
+LIMIT sqlc.arg(batch_size);
+
+-- name: MarkUploadCallbackDelivered :exec
+UPDATE upload_callbacks
+SET delivered_at = sqlc.arg(now)::timestamptz, attempts = attempts + 1, last_error = NULL
+WHERE storage_key = sqlc.arg(storage_key);
+
+-- name: RecordUploadCallbackFailure :exec
+-- A failed attempt, tried again at next_attempt_at unless abandon is set
+UPDATE upload_callbacks
+SET attempts = attempts + 1, last_error = sqlc.arg(last_error), next_attempt_at = sqlc.arg(next_attempt_at),
+    abandoned_at = CASE WHEN sqlc.arg(abandon)::boolean THEN now() END
+WHERE storage_key = sqlc.arg(storage_key);
+
+-- name: RecordCallbackDestinationFailure :one
+-- Counts another failure in a row against the destination, disabling it at disable_after. Returns whether it is disabled.
+INSERT INTO callback_destinations (destination, consecutive_failures, disabled_at)
+VALUES (sqlc.arg(destination), 1, CASE WHEN sqlc.arg(disable_after)::int <= 1 THEN now() END)
+ON CONFLICT (destination) DO UPDATE SET
+    consecutive_failures = callback_destinations.consecutive_failures + 1,
+    disabled_at = COALESCE(callback_destinations.disabled_at,
+        CASE WHEN callback_destinations.consecutive_failures + 1 >= sqlc.arg(disable_after)::int THEN now() END),
+    updated_at = now()
+RETURNING (disabled_at IS NOT NULL)::boolean AS disabled;
+
+-- name: ResetCallbackDestination :exec
+-- A delivery went through, the failures in a row start again
+UPDATE callback_destinations SET consecutive_failures = 0, updated_at = now()
+WHERE destination = $1 AND consecutive_failures > 0;
+
+-- name: DeleteExpiredUploadCallbacks :execrows
+-- Callbacks for uploads no listing claimed in time
+DELETE FROM upload_callbacks WHERE file_id IS NULL AND expires_at < now();
@@ -11,6 +11,304 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const anonymizeDownloadsBefore = `-- name: AnonymizeDownloadsBefore :execrows
+UPDATE downloads SET user_id = NULL
+WHERE user_id IS NOT NULL AND downloaded_at < $1
+`
+
+// Forgets who made downloads older than the retention period, the rows stay for aggregate stats
+func (q *Queries) AnonymizeDownloadsBefore(ctx context.Context, downloadedAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, anonymizeDownloadsBefore, downloadedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const anonymizeStatusEventsBefore = `-- name: AnonymizeStatusEventsBefore :execrows
+UPDATE listing_status_events SET client_id = NULL, trace_id = NULL
+WHERE id IN (
+    SELECT e.id FROM listing_status_events e
+    WHERE (e.client_id IS NOT NULL OR e.trace_id IS NOT NULL) AND e.created_at < $1
+    LIMIT $2
+)
+`
+
+type AnonymizeStatusEventsBeforeParams struct {
+	Cutoff    pgtype.Timestamptz `json:"cutoff"`
+	BatchSize int32              `json:"batch_size"`
+}
+
+// Forgets which client and trace created listings longer ago than the trace retention window, a batch at a time
+func (q *Queries) AnonymizeStatusEventsBefore(ctx context.Context, arg AnonymizeStatusEventsBeforeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, anonymizeStatusEventsBefore, arg.Cutoff, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const clearSellerVacation = `-- name: ClearSellerVacation :exec
+UPDATE sellers
+SET vacation_starts_at = NULL, vacation_ends_at = NULL, vacation_message = NULL, vacation_applied = false
+WHERE user_id = $1 AND vacation_ends_at = $2
+`
+
+type ClearSellerVacationParams struct {
+	UserID pgtype.UUID        `json:"user_id"`
+	EndsAt pgtype.Timestamptz `json:"ends_at"`
+}
+
+// Same guard as SetSellerVacationApplied, a new vacation booked while the last one was being wound down is kept
+func (q *Queries) ClearSellerVacation(ctx context.Context, arg ClearSellerVacationParams) error {
+	_, err := q.db.Exec(ctx, clearSellerVacation, arg.UserID, arg.EndsAt)
+	return err
+}
+
+const countOtherFileReferences = `-- name: CountOtherFileReferences :one
+SELECT COUNT(*) FROM listing_files
+WHERE file_path = $1 AND listing_id <> $2
+`
+
+type CountOtherFileReferencesParams struct {
+	FilePath  string      `json:"file_path"`
+	ListingID pgtype.UUID `json:"listing_id"`
+}
+
+// Refcount check: other listings pointing at the same object keep it alive
+func (q *Queries) CountOtherFileReferences(ctx context.Context, arg CountOtherFileReferencesParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countOtherFileReferences, arg.FilePath, arg.ListingID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteCounterFlushesBefore = `-- name: DeleteCounterFlushesBefore :exec
+DELETE FROM counter_flushes WHERE flushed_at < $1
+`
+
+func (q *Queries) DeleteCounterFlushesBefore(ctx context.Context, flushedAt pgtype.Timestamptz) error {
+	_, err := q.db.Exec(ctx, deleteCounterFlushesBefore, flushedAt)
+	return err
+}
+
+const deleteExpiredUploadCallbacks = `-- name: DeleteExpiredUploadCallbacks :execrows
+DELETE FROM upload_callbacks WHERE file_id IS NULL AND expires_at < now()
+`
+
+// Callbacks for uploads no listing claimed in time
+func (q *Queries) DeleteExpiredUploadCallbacks(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredUploadCallbacks)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const detachRemixes = `-- name: DetachRemixes :execrows
+UPDATE listings SET parent_listing_id = NULL
+WHERE parent_listing_id = $1
+`
+
+// Runs in the purge transaction before the parent row goes, the foreign key would do the same but silently
+func (q *Queries) DetachRemixes(ctx context.Context, parentListingID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, detachRemixes, parentListingID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getAllFilesByListingID = `-- name: GetAllFilesByListingID :many
+SELECT id, listing_id, file_path, file_type, file_size, metadata, status, error_message, is_generated, source_file_id, created_at, updated_at, deleted_at FROM listing_files
+WHERE listing_id = $1
+`
+
+// Includes soft-deleted files, the purge needs every object the listing ever owned
+func (q *Queries) GetAllFilesByListingID(ctx context.Context, listingID pgtype.UUID) ([]ListingFile, error) {
+	rows, err := q.db.Query(ctx, getAllFilesByListingID, listingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListingFile
+	for rows.Next() {
+		var i ListingFile
+		if err := rows.Scan(
+			&i.ID,
+			&i.ListingID,
+			&i.FilePath,
+			&i.FileType,
+			&i.FileSize,
+			&i.Metadata,
+			&i.Status,
+			&i.ErrorMessage,
+			&i.IsGenerated,
+			&i.SourceFileID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCheapestVariantPrice = `-- name: GetCheapestVariantPrice :one
+SELECT price_min_unit FROM listing_variants
+WHERE listing_id = $1
+ORDER BY price_min_unit
+LIMIT 1
+`
+
+// No row when the listing isn't sold as variants
+func (q *Queries) GetCheapestVariantPrice(ctx context.Context, listingID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, getCheapestVariantPrice, listingID)
+	var price_min_unit int64
+	err := row.Scan(&price_min_unit)
+	return price_min_unit, err
+}
+
+const getDueSavedSearches = `-- name: GetDueSavedSearches :many
+SELECT id, user_id, query, filters, filter_by, notified_listing_ids, last_checked_at, next_check_at, created_at FROM saved_searches
+WHERE next_check_at <= now()
+ORDER BY next_check_at ASC
+LIMIT $1
+`
+
+// Saved searches whose next check has come round, longest overdue first
+func (q *Queries) GetDueSavedSearches(ctx context.Context, batchSize int32) ([]SavedSearch, error) {
+	rows, err := q.db.Query(ctx, getDueSavedSearches, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SavedSearch
+	for rows.Next() {
+		var i SavedSearch
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Query,
+			&i.Filters,
+			&i.FilterBy,
+			&i.NotifiedListingIds,
+			&i.LastCheckedAt,
+			&i.NextCheckAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDueUploadCallbacks = `-- name: GetDueUploadCallbacks :many
+SELECT storage_key, callback_url, destination, attempts, file_size, status, error_message
+FROM (
+    SELECT uc.storage_key, uc.callback_url, uc.destination, uc.attempts, uc.next_attempt_at,
+        lf.file_size, lf.status, lf.error_message,
+        row_number() OVER (PARTITION BY uc.destination ORDER BY uc.next_attempt_at ASC) AS destination_rank
+    FROM upload_callbacks uc
+    JOIN listing_files lf ON lf.id = uc.file_id
+    LEFT JOIN callback_destinations cd ON cd.destination = uc.destination
+    WHERE uc.delivered_at IS NULL AND uc.abandoned_at IS NULL
+        AND uc.next_attempt_at <= $1::timestamptz
+        AND lf.status IN ('VALID', 'INVALID', 'FAILED')
+        AND cd.disabled_at IS NULL
+) due
+WHERE $2::int = 0 OR destination_rank <= $2::int
+ORDER BY next_attempt_at ASC
+LIMIT $3
+`
+
+type GetDueUploadCallbacksParams struct {
+	Now            pgtype.Timestamptz `json:"now"`
+	PerDestination int32              `json:"per_destination"`
+	BatchSize      int32              `json:"batch_size"`
+}
+
+type GetDueUploadCallbacksRow struct {
+	StorageKey   string         `json:"storage_key"`
+	CallbackUrl  string         `json:"callback_url"`
+	Destination  string         `json:"destination"`
+	Attempts     int32          `json:"attempts"`
+	FileSize     pgtype.Int8    `json:"file_size"`
+	Status       NullFileStatus `json:"status"`
+	ErrorMessage pgtype.Text    `json:"error_message"`
+}
+
+// Callbacks whose file has finished validation and that are due an attempt, leaving out disabled destinations. At most
+// per_destination per destination (0 for no cap) so one busy partner can't fill the batch, oldest first.
+func (q *Queries) GetDueUploadCallbacks(ctx context.Context, arg GetDueUploadCallbacksParams) ([]GetDueUploadCallbacksRow, error) {
+	rows, err := q.db.Query(ctx, getDueUploadCallbacks, arg.Now, arg.PerDestination, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDueUploadCallbacksRow
+	for rows.Next() {
+		var i GetDueUploadCallbacksRow
+		if err := rows.Scan(
+			&i.StorageKey,
+			&i.CallbackUrl,
+			&i.Destination,
+			&i.Attempts,
+			&i.FileSize,
+			&i.Status,
+			&i.ErrorMessage,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeatureWindows = `-- name: GetFeatureWindows :many
+SELECT starts_at, ends_at FROM featured_listings
+WHERE listing_id = $1 AND ends_at > now() AND deleted_at IS NULL
+ORDER BY starts_at ASC
+`
+
+type GetFeatureWindowsRow struct {
+	StartsAt pgtype.Timestamptz `json:"starts_at"`
+	EndsAt   pgtype.Timestamptz `json:"ends_at"`
+}
+
+// A listing's featured windows that haven't ended yet, it is featured while any of them runs
+func (q *Queries) GetFeatureWindows(ctx context.Context, listingID pgtype.UUID) ([]GetFeatureWindowsRow, error) {
+	rows, err := q.db.Query(ctx, getFeatureWindows, listingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeatureWindowsRow
+	for rows.Next() {
+		var i GetFeatureWindowsRow
+		if err := rows.Scan(&i.StartsAt, &i.EndsAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getFilesByListingID = `-- name: GetFilesByListingID :many
 SELECT id, listing_id, file_path, file_type, file_size, metadata, status, error_message, is_generated, source_file_id, created_at, updated_at, deleted_at FROM listing_files 
 WHERE listing_id = $1 AND deleted_at IS NULL
@@ -50,9 +348,31 @@ func (q *Queries) GetFilesByListingID(ctx context.Context, listingID pgtype.UUID
 	return items, nil
 }
 
+const getLatestPriceChange = `-- name: GetLatestPriceChange :one
+SELECT id, listing_id, old_price_min_unit, new_price_min_unit, old_currency, new_currency, changed_at FROM listing_price_history
+WHERE listing_id = $1
+ORDER BY changed_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestPriceChange(ctx context.Context, listingID pgtype.UUID) (ListingPriceHistory, error) {
+	row := q.db.QueryRow(ctx, getLatestPriceChange, listingID)
+	var i ListingPriceHistory
+	err := row.Scan(
+		&i.ID,
+		&i.ListingID,
+		&i.OldPriceMinUnit,
+		&i.NewPriceMinUnit,
+		&i.OldCurrency,
+		&i.NewCurrency,
+		&i.ChangedAt,
+	)
+	return i, err
+}
+
 const getListingByID = `-- name: GetListingByID :one
 SELECT 
-    id, seller_id, seller_name, seller_username, seller_verified, title, description, price_min_unit, currency, categories, license, client_id, trace_id, thumbnail_path, last_indexed_at, status, is_remixing_allowed, parent_listing_id, is_physical, total_weight_grams, is_assembly_required, is_hardware_required, hardware_required, is_multicolor, dimensions_mm, recommended_nozzle_temp_c, recommended_materials, is_ai_generated, ai_model_name, likes_count, downloads_count, comments_count, is_sale_active, sale_price, sale_name, sale_end_timestamp, seller_rating_average, seller_total_ratings, seller_total_sales, is_nsfw, created_at, updated_at, deleted_at
+    id, seller_id, seller_name, seller_username, seller_verified, title, description, price_min_unit, currency, categories, license, thumbnail_path, last_indexed_at, status, is_remixing_allowed, parent_listing_id, is_physical, total_weight_grams, is_assembly_required, is_hardware_required, hardware_required, is_multicolor, dimensions_mm, recommended_nozzle_temp_c, recommended_materials, is_ai_generated, ai_model_name, likes_count, downloads_count, comments_count, is_sale_active, sale_price, sale_name, sale_end_timestamp, seller_rating_average, seller_total_ratings, seller_total_sales, is_nsfw, created_at, updated_at, deleted_at, views_count, nozzle_diameter_mm, language
 FROM listings 
 WHERE id = $1 AND deleted_at IS NULL
 `
@@ -72,8 +392,6 @@ func (q *Queries) GetListingByID(ctx context.Context, id pgtype.UUID) (Listing,
 		&i.Currency,
 		&i.Categories,
 		&i.License,
-		&i.ClientID,
-		&i.TraceID,
 		&i.ThumbnailPath,
 		&i.LastIndexedAt,
 		&i.Status,
@@ -104,18 +422,819 @@ func (q *Queries) GetListingByID(ctx context.Context, id pgtype.UUID) (Listing,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.DeletedAt,
+		&i.ViewsCount,
+		&i.NozzleDiameterMm,
+		&i.Language,
+	)
+	return i, err
+}
+
+const getListingCounters = `-- name: GetListingCounters :many
+SELECT l.id, l.downloads_count, l.views_count, s.last_active_at AS seller_last_active_at
+FROM listings l
+LEFT JOIN sellers s ON s.user_id = l.seller_id
+WHERE l.id = ANY($1::uuid[]) AND l.deleted_at IS NULL
+`
+
+type GetListingCountersRow struct {
+	ID                 pgtype.UUID        `json:"id"`
+	DownloadsCount     int32              `json:"downloads_count"`
+	ViewsCount         int32              `json:"views_count"`
+	SellerLastActiveAt pgtype.Timestamptz `json:"seller_last_active_at"`
+}
+
+// Deleted listings are left out, a counter patch for one would put its search document back
+func (q *Queries) GetListingCounters(ctx context.Context, ids []pgtype.UUID) ([]GetListingCountersRow, error) {
+	rows, err := q.db.Query(ctx, getListingCounters, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetListingCountersRow
+	for rows.Next() {
+		var i GetListingCountersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.DownloadsCount,
+			&i.ViewsCount,
+			&i.SellerLastActiveAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getListingCountsForReconcile = `-- name: GetListingCountsForReconcile :many
+SELECT l.id, l.downloads_count, l.views_count, l.likes_count, l.updated_at,
+    (SELECT count(*) FROM downloads d WHERE d.listing_id = l.id) AS recorded_downloads,
+    s.last_active_at AS seller_last_active_at
+FROM listings l
+LEFT JOIN sellers s ON s.user_id = l.seller_id
+WHERE l.deleted_at IS NULL
+    AND l.id > $1::uuid
+    AND ($2::uuid IS NULL OR l.id = $2::uuid)
+ORDER BY l.id ASC
+LIMIT $3
+`
+
+type GetListingCountsForReconcileParams struct {
+	AfterID   pgtype.UUID `json:"after_id"`
+	ListingID pgtype.UUID `json:"listing_id"`
+	BatchSize int32       `json:"batch_size"`
+}
+
+type GetListingCountsForReconcileRow struct {
+	ID                 pgtype.UUID        `json:"id"`
+	DownloadsCount     int32              `json:"downloads_count"`
+	ViewsCount         int32              `json:"views_count"`
+	LikesCount         int32              `json:"likes_count"`
+	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+	RecordedDownloads  int64              `json:"recorded_downloads"`
+	SellerLastActiveAt pgtype.Timestamptz `json:"seller_last_active_at"`
+}
+
+// Live listings' counters next to the downloads recorded for them, keyset paginated. listing_id narrows it to one.
+func (q *Queries) GetListingCountsForReconcile(ctx context.Context, arg GetListingCountsForReconcileParams) ([]GetListingCountsForReconcileRow, error) {
+	rows, err := q.db.Query(ctx, getListingCountsForReconcile, arg.AfterID, arg.ListingID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetListingCountsForReconcileRow
+	for rows.Next() {
+		var i GetListingCountsForReconcileRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.DownloadsCount,
+			&i.ViewsCount,
+			&i.LikesCount,
+			&i.UpdatedAt,
+			&i.RecordedDownloads,
+			&i.SellerLastActiveAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getListingsForBackfill = `-- name: GetListingsForBackfill :many
+SELECT id, seller_id, seller_name, seller_username, seller_verified, title, description, price_min_unit, currency, categories, license, thumbnail_path, last_indexed_at, status, is_remixing_allowed, parent_listing_id, is_physical, total_weight_grams, is_assembly_required, is_hardware_required, hardware_required, is_multicolor, dimensions_mm, recommended_nozzle_temp_c, recommended_materials, is_ai_generated, ai_model_name, likes_count, downloads_count, comments_count, is_sale_active, sale_price, sale_name, sale_end_timestamp, seller_rating_average, seller_total_ratings, seller_total_sales, is_nsfw, created_at, updated_at, deleted_at, views_count, nozzle_diameter_mm, language FROM listings
+WHERE deleted_at IS NULL
+    AND id > $1::uuid
+ORDER BY id ASC
+LIMIT $2
+`
+
+type GetListingsForBackfillParams struct {
+	AfterID   pgtype.UUID `json:"after_id"`
+	BatchSize int32       `json:"batch_size"`
+}
+
+// Every live listing, keyset paginated so a backfill can resume after the last listing it finished
+func (q *Queries) GetListingsForBackfill(ctx context.Context, arg GetListingsForBackfillParams) ([]Listing, error) {
+	rows, err := q.db.Query(ctx, getListingsForBackfill, arg.AfterID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Listing
+	for rows.Next() {
+		var i Listing
+		if err := rows.Scan(
+			&i.ID,
+			&i.SellerID,
+			&i.SellerName,
+			&i.SellerUsername,
+			&i.SellerVerified,
+			&i.Title,
+			&i.Description,
+			&i.PriceMinUnit,
+			&i.Currency,
+			&i.Categories,
+			&i.License,
+			&i.ThumbnailPath,
+			&i.LastIndexedAt,
+			&i.Status,
+			&i.IsRemixingAllowed,
+			&i.ParentListingID,
+			&i.IsPhysical,
+			&i.TotalWeightGrams,
+			&i.IsAssemblyRequired,
+			&i.IsHardwareRequired,
+			&i.HardwareRequired,
+			&i.IsMulticolor,
+			&i.DimensionsMm,
+			&i.RecommendedNozzleTempC,
+			&i.RecommendedMaterials,
+			&i.IsAiGenerated,
+			&i.AiModelName,
+			&i.LikesCount,
+			&i.DownloadsCount,
+			&i.CommentsCount,
+			&i.IsSaleActive,
+			&i.SalePrice,
+			&i.SaleName,
+			&i.SaleEndTimestamp,
+			&i.SellerRatingAverage,
+			&i.SellerTotalRatings,
+			&i.SellerTotalSales,
+			&i.IsNsfw,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.ViewsCount,
+			&i.NozzleDiameterMm,
+			&i.Language,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getListingsForPurge = `-- name: GetListingsForPurge :many
+SELECT id, seller_id, seller_name, seller_username, seller_verified, title, description, price_min_unit, currency, categories, license, thumbnail_path, last_indexed_at, status, is_remixing_allowed, parent_listing_id, is_physical, total_weight_grams, is_assembly_required, is_hardware_required, hardware_required, is_multicolor, dimensions_mm, recommended_nozzle_temp_c, recommended_materials, is_ai_generated, ai_model_name, likes_count, downloads_count, comments_count, is_sale_active, sale_price, sale_name, sale_end_timestamp, seller_rating_average, seller_total_ratings, seller_total_sales, is_nsfw, created_at, updated_at, deleted_at, views_count, nozzle_diameter_mm, language FROM listings
+WHERE deleted_at IS NOT NULL
+    AND deleted_at < $1
+    AND (deleted_at, id) > ($2::timestamptz, $3::uuid)
+ORDER BY deleted_at ASC, id ASC
+LIMIT $4
+`
+
+type GetListingsForPurgeParams struct {
+	Cutoff         pgtype.Timestamptz `json:"cutoff"`
+	AfterDeletedAt pgtype.Timestamptz `json:"after_deleted_at"`
+	AfterID        pgtype.UUID        `json:"after_id"`
+	BatchSize      int32              `json:"batch_size"`
+}
+
+// Keyset paginated, oldest soft-deleted listings first so an interrupted purge resumes where it stopped
+func (q *Queries) GetListingsForPurge(ctx context.Context, arg GetListingsForPurgeParams) ([]Listing, error) {
+	rows, err := q.db.Query(ctx, getListingsForPurge,
+		arg.Cutoff,
+		arg.AfterDeletedAt,
+		arg.AfterID,
+		arg.BatchSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Listing
+	for rows.Next() {
+		var i Listing
+		if err := rows.Scan(
+			&i.ID,
+			&i.SellerID,
+			&i.SellerName,
+			&i.SellerUsername,
+			&i.SellerVerified,
+			&i.Title,
+			&i.Description,
+			&i.PriceMinUnit,
+			&i.Currency,
+			&i.Categories,
+			&i.License,
+			&i.ThumbnailPath,
+			&i.LastIndexedAt,
+			&i.Status,
+			&i.IsRemixingAllowed,
+			&i.ParentListingID,
+			&i.IsPhysical,
+			&i.TotalWeightGrams,
+			&i.IsAssemblyRequired,
+			&i.IsHardwareRequired,
+			&i.HardwareRequired,
+			&i.IsMulticolor,
+			&i.DimensionsMm,
+			&i.RecommendedNozzleTempC,
+			&i.RecommendedMaterials,
+			&i.IsAiGenerated,
+			&i.AiModelName,
+			&i.LikesCount,
+			&i.DownloadsCount,
+			&i.CommentsCount,
+			&i.IsSaleActive,
+			&i.SalePrice,
+			&i.SaleName,
+			&i.SaleEndTimestamp,
+			&i.SellerRatingAverage,
+			&i.SellerTotalRatings,
+			&i.SellerTotalSales,
+			&i.IsNsfw,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.ViewsCount,
+			&i.NozzleDiameterMm,
+			&i.Language,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getListingsWithExpiredPriceDrops = `-- name: GetListingsWithExpiredPriceDrops :many
+SELECT l.id FROM listings l
+JOIN LATERAL (
+    SELECT h.old_price_min_unit, h.new_price_min_unit, h.old_currency, h.new_currency, h.changed_at
+    FROM listing_price_history h
+    WHERE h.listing_id = l.id
+    ORDER BY h.changed_at DESC
+    LIMIT 1
+) latest ON true
+WHERE l.deleted_at IS NULL
+    AND l.status = 'ACTIVE'
+    AND l.thumbnail_path IS NOT NULL
+    AND latest.new_price_min_unit < latest.old_price_min_unit
+    AND latest.new_currency = latest.old_currency
+    AND latest.changed_at + $1::interval <= now()
+    AND l.last_indexed_at < latest.changed_at + $1::interval
+ORDER BY latest.changed_at ASC
+LIMIT $2
+`
+
+type GetListingsWithExpiredPriceDropsParams struct {
+	DropWindow pgtype.Interval `json:"drop_window"`
+	BatchSize  int32           `json:"batch_size"`
+}
+
+// Listings still indexed as price_dropped_recently whose drop is now older than the window, so the flag can be cleared
+func (q *Queries) GetListingsWithExpiredPriceDrops(ctx context.Context, arg GetListingsWithExpiredPriceDropsParams) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, getListingsWithExpiredPriceDrops, arg.DropWindow, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getListingsWithFeatureChanges = `-- name: GetListingsWithFeatureChanges :many
+SELECT DISTINCT listing_id FROM featured_listings
+WHERE applied <> (deleted_at IS NULL AND starts_at <= $1::timestamptz AND ends_at > $1::timestamptz)
+ORDER BY listing_id ASC
+LIMIT $2
+`
+
+type GetListingsWithFeatureChangesParams struct {
+	Now       pgtype.Timestamptz `json:"now"`
+	BatchSize int32              `json:"batch_size"`
+}
+
+// Listings with a featured window that started, ended or was deleted since is_featured was last brought in line with it
+func (q *Queries) GetListingsWithFeatureChanges(ctx context.Context, arg GetListingsWithFeatureChangesParams) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, getListingsWithFeatureChanges, arg.Now, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var listing_id pgtype.UUID
+		if err := rows.Scan(&listing_id); err != nil {
+			return nil, err
+		}
+		items = append(items, listing_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReplicaLagSeconds = `-- name: GetReplicaLagSeconds :one
+SELECT COALESCE(
+    CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+        ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+    END, 0)::float8 AS lag_seconds
+`
+
+// How far behind its primary this database is, 0 once it has replayed everything it received or when it is the primary
+func (q *Queries) GetReplicaLagSeconds(ctx context.Context) (float64, error) {
+	row := q.db.QueryRow(ctx, getReplicaLagSeconds)
+	var lag_seconds float64
+	err := row.Scan(&lag_seconds)
+	return lag_seconds, err
+}
+
+const getSellerByUserID = `-- name: GetSellerByUserID :one
+SELECT user_id, display_name, country, payout_status, accepted_terms_version, accepted_terms_at, created_at, updated_at, vacation_starts_at, vacation_ends_at, vacation_message, vacation_applied, last_active_at FROM sellers
+WHERE user_id = $1
+`
+
+func (q *Queries) GetSellerByUserID(ctx context.Context, userID pgtype.UUID) (Seller, error) {
+	row := q.db.QueryRow(ctx, getSellerByUserID, userID)
+	var i Seller
+	err := row.Scan(
+		&i.UserID,
+		&i.DisplayName,
+		&i.Country,
+		&i.PayoutStatus,
+		&i.AcceptedTermsVersion,
+		&i.AcceptedTermsAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.VacationStartsAt,
+		&i.VacationEndsAt,
+		&i.VacationMessage,
+		&i.VacationApplied,
+		&i.LastActiveAt,
 	)
 	return i, err
 }
 
+const getSellerLastActive = `-- name: GetSellerLastActive :one
+SELECT last_active_at FROM sellers
+WHERE user_id = $1
+`
+
+func (q *Queries) GetSellerLastActive(ctx context.Context, userID pgtype.UUID) (pgtype.Timestamptz, error) {
+	row := q.db.QueryRow(ctx, getSellerLastActive, userID)
+	var last_active_at pgtype.Timestamptz
+	err := row.Scan(&last_active_at)
+	return last_active_at, err
+}
+
+const getSellerListingIDs = `-- name: GetSellerListingIDs :many
+SELECT id FROM listings
+WHERE seller_id = $1
+    AND deleted_at IS NULL
+    AND id > $2::uuid
+ORDER BY id ASC
+LIMIT $3
+`
+
+type GetSellerListingIDsParams struct {
+	SellerID  pgtype.UUID `json:"seller_id"`
+	AfterID   pgtype.UUID `json:"after_id"`
+	BatchSize int32       `json:"batch_size"`
+}
+
+// A seller's live listings, keyset paginated for reindexing them when a vacation starts or ends
+func (q *Queries) GetSellerListingIDs(ctx context.Context, arg GetSellerListingIDsParams) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, getSellerListingIDs, arg.SellerID, arg.AfterID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSellerVacation = `-- name: GetSellerVacation :one
+SELECT vacation_starts_at, vacation_ends_at FROM sellers
+WHERE user_id = $1
+`
+
+type GetSellerVacationRow struct {
+	VacationStartsAt pgtype.Timestamptz `json:"vacation_starts_at"`
+	VacationEndsAt   pgtype.Timestamptz `json:"vacation_ends_at"`
+}
+
+func (q *Queries) GetSellerVacation(ctx context.Context, userID pgtype.UUID) (GetSellerVacationRow, error) {
+	row := q.db.QueryRow(ctx, getSellerVacation, userID)
+	var i GetSellerVacationRow
+	err := row.Scan(&i.VacationStartsAt, &i.VacationEndsAt)
+	return i, err
+}
+
+const getSellersWithVacationChanges = `-- name: GetSellersWithVacationChanges :many
+SELECT user_id, vacation_starts_at, vacation_ends_at FROM sellers
+WHERE vacation_ends_at IS NOT NULL
+    AND (vacation_ends_at <= now() OR vacation_applied <> (vacation_starts_at <= now()))
+ORDER BY vacation_ends_at ASC
+LIMIT $1
+`
+
+type GetSellersWithVacationChangesRow struct {
+	UserID           pgtype.UUID        `json:"user_id"`
+	VacationStartsAt pgtype.Timestamptz `json:"vacation_starts_at"`
+	VacationEndsAt   pgtype.Timestamptz `json:"vacation_ends_at"`
+}
+
+// Sellers whose listings are indexed for the wrong side of a vacation boundary, or whose vacation is over and can be cleared
+func (q *Queries) GetSellersWithVacationChanges(ctx context.Context, batchSize int32) ([]GetSellersWithVacationChangesRow, error) {
+	rows, err := q.db.Query(ctx, getSellersWithVacationChanges, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSellersWithVacationChangesRow
+	for rows.Next() {
+		var i GetSellersWithVacationChangesRow
+		if err := rows.Scan(&i.UserID, &i.VacationStartsAt, &i.VacationEndsAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStaleListingIDs = `-- name: GetStaleListingIDs :many
+SELECT id FROM listings
+WHERE deleted_at IS NULL
+    AND (last_indexed_at IS NULL OR updated_at > last_indexed_at)
+    AND id > $1::uuid
+ORDER BY id ASC
+LIMIT $2
+`
+
+type GetStaleListingIDsParams struct {
+	AfterID   pgtype.UUID `json:"after_id"`
+	BatchSize int32       `json:"batch_size"`
+}
+
+// Listings whose search document is missing or older than the row, keyset paginated for bulk reindexing
+func (q *Queries) GetStaleListingIDs(ctx context.Context, arg GetStaleListingIDsParams) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, getStaleListingIDs, arg.AfterID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hardDeleteListing = `-- name: HardDeleteListing :exec
+DELETE FROM listings
+WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+// Only ever removes rows that have already been soft-deleted
+func (q *Queries) HardDeleteListing(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, hardDeleteListing, id)
+	return err
+}
+
+const hardDeleteListingFiles = `-- name: HardDeleteListingFiles :exec
+DELETE FROM listing_files
+WHERE listing_id = $1
+`
+
+func (q *Queries) HardDeleteListingFiles(ctx context.Context, listingID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, hardDeleteListingFiles, listingID)
+	return err
+}
+
+const incrementListingCounters = `-- name: IncrementListingCounters :exec
+UPDATE listings
+SET downloads_count = downloads_count + $1::int,
+    views_count = views_count + $2::int
+WHERE id = $3 AND deleted_at IS NULL
+`
+
+type IncrementListingCountersParams struct {
+	Downloads int32       `json:"downloads"`
+	Views     int32       `json:"views"`
+	ID        pgtype.UUID `json:"id"`
+}
+
+func (q *Queries) IncrementListingCounters(ctx context.Context, arg IncrementListingCountersParams) error {
+	_, err := q.db.Exec(ctx, incrementListingCounters, arg.Downloads, arg.Views, arg.ID)
+	return err
+}
+
+const incrementShortLinkClicks = `-- name: IncrementShortLinkClicks :exec
+UPDATE short_links SET clicks_count = clicks_count + $1::int
+WHERE code = $2
+`
+
+type IncrementShortLinkClicksParams struct {
+	Clicks int32  `json:"clicks"`
+	Code   string `json:"code"`
+}
+
+func (q *Queries) IncrementShortLinkClicks(ctx context.Context, arg IncrementShortLinkClicksParams) error {
+	_, err := q.db.Exec(ctx, incrementShortLinkClicks, arg.Clicks, arg.Code)
+	return err
+}
+
+const isListingLive = `-- name: IsListingLive :one
+SELECT EXISTS (
+    SELECT 1 FROM listings
+    WHERE id = $1 AND deleted_at IS NULL
+)
+`
+
+// Search documents only link a remix to a parent that hasn't been deleted
+func (q *Queries) IsListingLive(ctx context.Context, id pgtype.UUID) (bool, error) {
+	row := q.db.QueryRow(ctx, isListingLive, id)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const markListingAsIndexed = `-- name: MarkListingAsIndexed :exec
+WITH resolved AS (
+    DELETE FROM listing_index_failures WHERE listing_id = $1
+)
 UPDATE listings 
 SET last_indexed_at = CURRENT_TIMESTAMP
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
 `
 
-// The worker calls this AFTER successfully pushing to Typesense
+// The worker calls this AFTER successfully pushing to Typesense. Clears any earlier failure the gateway recorded,
+// the listing is in search again.
 func (q *Queries) MarkListingAsIndexed(ctx context.Context, id pgtype.UUID) error {
 	_, err := q.db.Exec(ctx, markListingAsIndexed, id)
 	return err
 }
+
+const markSavedSearchChecked = `-- name: MarkSavedSearchChecked :exec
+UPDATE saved_searches
+SET last_checked_at = $1,
+    next_check_at = $2,
+    notified_listing_ids = $3::text[]
+WHERE id = $4
+`
+
+type MarkSavedSearchCheckedParams struct {
+	CheckedAt          pgtype.Timestamptz `json:"checked_at"`
+	NextCheckAt        pgtype.Timestamptz `json:"next_check_at"`
+	NotifiedListingIds []string           `json:"notified_listing_ids"`
+	ID                 pgtype.UUID        `json:"id"`
+}
+
+func (q *Queries) MarkSavedSearchChecked(ctx context.Context, arg MarkSavedSearchCheckedParams) error {
+	_, err := q.db.Exec(ctx, markSavedSearchChecked,
+		arg.CheckedAt,
+		arg.NextCheckAt,
+		arg.NotifiedListingIds,
+		arg.ID,
+	)
+	return err
+}
+
+const markUploadCallbackDelivered = `-- name: MarkUploadCallbackDelivered :exec
+UPDATE upload_callbacks
+SET delivered_at = $1::timestamptz, attempts = attempts + 1, last_error = NULL
+WHERE storage_key = $2
+`
+
+type MarkUploadCallbackDeliveredParams struct {
+	Now        pgtype.Timestamptz `json:"now"`
+	StorageKey string             `json:"storage_key"`
+}
+
+func (q *Queries) MarkUploadCallbackDelivered(ctx context.Context, arg MarkUploadCallbackDeliveredParams) error {
+	_, err := q.db.Exec(ctx, markUploadCallbackDelivered, arg.Now, arg.StorageKey)
+	return err
+}
+
+const recordCallbackDestinationFailure = `-- name: RecordCallbackDestinationFailure :one
+INSERT INTO callback_destinations (destination, consecutive_failures, disabled_at)
+VALUES ($1, 1, CASE WHEN $2::int <= 1 THEN now() END)
+ON CONFLICT (destination) DO UPDATE SET
+    consecutive_failures = callback_destinations.consecutive_failures + 1,
+    disabled_at = COALESCE(callback_destinations.disabled_at,
+        CASE WHEN callback_destinations.consecutive_failures + 1 >= $2::int THEN now() END),
+    updated_at = now()
+RETURNING (disabled_at IS NOT NULL)::boolean AS disabled
+`
+
+type RecordCallbackDestinationFailureParams struct {
+	Destination  string `json:"destination"`
+	DisableAfter int32  `json:"disable_after"`
+}
+
+// Counts another failure in a row against the destination, disabling it at disable_after. Returns whether it is disabled.
+func (q *Queries) RecordCallbackDestinationFailure(ctx context.Context, arg RecordCallbackDestinationFailureParams) (bool, error) {
+	row := q.db.QueryRow(ctx, recordCallbackDestinationFailure, arg.Destination, arg.DisableAfter)
+	var disabled bool
+	err := row.Scan(&disabled)
+	return disabled, err
+}
+
+const recordCounterFlush = `-- name: RecordCounterFlush :execrows
+INSERT INTO counter_flushes (batch_id) VALUES ($1)
+ON CONFLICT (batch_id) DO NOTHING
+`
+
+// Returns 0 rows when the batch was already applied, e.g. the worker died before clearing it from Redis
+func (q *Queries) RecordCounterFlush(ctx context.Context, batchID string) (int64, error) {
+	result, err := q.db.Exec(ctx, recordCounterFlush, batchID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const recordDownload = `-- name: RecordDownload :execrows
+INSERT INTO downloads (id, user_id, listing_id, file_id, downloaded_at, expires_at)
+SELECT $1::uuid, $2::uuid, f.listing_id, f.id, $3::timestamptz, $4::timestamptz
+FROM listing_files f
+WHERE f.id = $5 AND f.listing_id = $6
+ON CONFLICT (id) DO NOTHING
+`
+
+type RecordDownloadParams struct {
+	ID           pgtype.UUID        `json:"id"`
+	UserID       pgtype.UUID        `json:"user_id"`
+	DownloadedAt pgtype.Timestamptz `json:"downloaded_at"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	FileID       pgtype.UUID        `json:"file_id"`
+	ListingID    pgtype.UUID        `json:"listing_id"`
+}
+
+// A receipt queued by the gateway. The file must belong to the listing, replays of a receipt are ignored.
+func (q *Queries) RecordDownload(ctx context.Context, arg RecordDownloadParams) (int64, error) {
+	result, err := q.db.Exec(ctx, recordDownload,
+		arg.ID,
+		arg.UserID,
+		arg.DownloadedAt,
+		arg.ExpiresAt,
+		arg.FileID,
+		arg.ListingID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const recordUploadCallbackFailure = `-- name: RecordUploadCallbackFailure :exec
+UPDATE upload_callbacks
+SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2,
+    abandoned_at = CASE WHEN $3::boolean THEN now() END
+WHERE storage_key = $4
+`
+
+type RecordUploadCallbackFailureParams struct {
+	LastError     pgtype.Text        `json:"last_error"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+	Abandon       bool               `json:"abandon"`
+	StorageKey    string             `json:"storage_key"`
+}
+
+// A failed attempt, tried again at next_attempt_at unless abandon is set
+func (q *Queries) RecordUploadCallbackFailure(ctx context.Context, arg RecordUploadCallbackFailureParams) error {
+	_, err := q.db.Exec(ctx, recordUploadCallbackFailure,
+		arg.LastError,
+		arg.NextAttemptAt,
+		arg.Abandon,
+		arg.StorageKey,
+	)
+	return err
+}
+
+const resetCallbackDestination = `-- name: ResetCallbackDestination :exec
+UPDATE callback_destinations SET consecutive_failures = 0, updated_at = now()
+WHERE destination = $1 AND consecutive_failures > 0
+`
+
+// A delivery went through, the failures in a row start again
+func (q *Queries) ResetCallbackDestination(ctx context.Context, destination string) error {
+	_, err := q.db.Exec(ctx, resetCallbackDestination, destination)
+	return err
+}
+
+const setFeaturedListingsApplied = `-- name: SetFeaturedListingsApplied :exec
+UPDATE featured_listings
+SET applied = (deleted_at IS NULL AND starts_at <= $1::timestamptz AND ends_at > $1::timestamptz)
+WHERE listing_id = $2
+`
+
+type SetFeaturedListingsAppliedParams struct {
+	Now       pgtype.Timestamptz `json:"now"`
+	ListingID pgtype.UUID        `json:"listing_id"`
+}
+
+// Records every window of the listing as brought in line at now, the same now the listing's is_featured was worked out at
+func (q *Queries) SetFeaturedListingsApplied(ctx context.Context, arg SetFeaturedListingsAppliedParams) error {
+	_, err := q.db.Exec(ctx, setFeaturedListingsApplied, arg.Now, arg.ListingID)
+	return err
+}
+
+const setListingDownloadsCount = `-- name: SetListingDownloadsCount :exec
+UPDATE listings SET downloads_count = $1::int
+WHERE id = $2 AND deleted_at IS NULL
+`
+
+type SetListingDownloadsCountParams struct {
+	Downloads int32       `json:"downloads"`
+	ID        pgtype.UUID `json:"id"`
+}
+
+func (q *Queries) SetListingDownloadsCount(ctx context.Context, arg SetListingDownloadsCountParams) error {
+	_, err := q.db.Exec(ctx, setListingDownloadsCount, arg.Downloads, arg.ID)
+	return err
+}
+
+const setSellerVacationApplied = `-- name: SetSellerVacationApplied :exec
+UPDATE sellers
+SET vacation_applied = $1
+WHERE user_id = $2
+    AND vacation_starts_at = $3
+    AND vacation_ends_at = $4
+`
+
+type SetSellerVacationAppliedParams struct {
+	Applied  bool               `json:"applied"`
+	UserID   pgtype.UUID        `json:"user_id"`
+	StartsAt pgtype.Timestamptz `json:"starts_at"`
+	EndsAt   pgtype.Timestamptz `json:"ends_at"`
+}
+
+// Guarded by the vacation the listings were reindexed for, one the seller changed since is picked up on the next pass
+func (q *Queries) SetSellerVacationApplied(ctx context.Context, arg SetSellerVacationAppliedParams) error {
+	_, err := q.db.Exec(ctx, setSellerVacationApplied,
+		arg.Applied,
+		arg.UserID,
+		arg.StartsAt,
+		arg.EndsAt,
+	)
+	return err
+}
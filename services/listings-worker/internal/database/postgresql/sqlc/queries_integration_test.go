@@ -0,0 +1,107 @@
+//go:build integration
+
+package listings_worker_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	repo "indexer/internal/database/postgresql/sqlc"
+	"shared/testinfra"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var db *pgxpool.Pool
+
+func TestMain(m *testing.M) {
+	os.Exit(runIntegration(m))
+}
+
+func runIntegration(m *testing.M) int {
+	suite, err := testinfra.NewSuite()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "integration: ", err)
+		return 1
+	}
+	defer suite.Close()
+
+	dsn, err := suite.Postgres("../../../../../../db/migrations")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "integration: ", err)
+		return 1
+	}
+	db, err = pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "integration: ", err)
+		return 1
+	}
+	defer db.Close()
+
+	return m.Run()
+}
+
+// validatedFile is a listing with one file that has finished validation, what an upload callback waits for
+func validatedFile(t *testing.T) pgtype.UUID {
+	t.Helper()
+	ctx := context.Background()
+
+	var fileID pgtype.UUID
+	err := db.QueryRow(ctx, `
+		WITH listing AS (
+			INSERT INTO listings (seller_id, seller_name, seller_username, title, price_min_unit, currency, license)
+			VALUES ('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11', 'Tester Prints', 'tester', 'Benchy', 500, 'gbp', 'MIT')
+			RETURNING id
+		)
+		INSERT INTO listing_files (listing_id, file_path, file_type, file_size, status)
+		SELECT id, 'incoming/benchy.stl', 'MODEL', 1024, 'VALID' FROM listing
+		RETURNING id`).Scan(&fileID)
+	require.NoError(t, err)
+	return fileID
+}
+
+func TestGetDueUploadCallbacks_PerDestinationCap(t *testing.T) {
+	// SCENARIO: One partner has five callbacks due and another has one, the oldest of them all belonging to the busy
+	// partner, and the cap is two per destination.
+	// EXPECT: The busy partner's two oldest and the other partner's one, oldest first. Without a cap all six.
+
+	ctx := context.Background()
+	q := repo.New(db)
+	fileID := validatedFile(t)
+	now := time.Now()
+
+	insert := func(key, destination string, age time.Duration) {
+		_, err := db.Exec(ctx, `
+			INSERT INTO upload_callbacks (storage_key, user_id, callback_url, destination, file_id, next_attempt_at, expires_at)
+			VALUES ($1, 'a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11', 'https://' || $2 || '/done', $2, $3, $4, $5)`,
+			key, destination, fileID, now.Add(-age), now.Add(time.Hour))
+		require.NoError(t, err)
+	}
+	for i := range 5 {
+		insert(fmt.Sprintf("busy-%d", i), "busy.test", time.Duration(10-i)*time.Minute)
+	}
+	insert("quiet-0", "quiet.test", 3*time.Minute)
+
+	due := func(perDestination int32) []string {
+		rows, err := q.GetDueUploadCallbacks(ctx, repo.GetDueUploadCallbacksParams{
+			Now:            pgtype.Timestamptz{Time: now, Valid: true},
+			PerDestination: perDestination,
+			BatchSize:      10,
+		})
+		require.NoError(t, err)
+		keys := make([]string, 0, len(rows))
+		for _, row := range rows {
+			keys = append(keys, row.StorageKey)
+		}
+		return keys
+	}
+
+	assert.Equal(t, []string{"busy-0", "busy-1", "quiet-0"}, due(2))
+	assert.Len(t, due(0), 6)
+}
@@ -13,5 +13,14 @@ type Subscription struct {
 
 type Bus interface {
 	Subscribe(subject string, group string, name string, handler Handler) (Subscription, error)
+	Publish(subject string, data []byte, msgId string) error
 	Close() error
 }
+
+// DeadLetterHook is told about a message once it has been moved to the dead letter stream
+type DeadLetterHook func(payload []byte, deliveries int, cause error)
+
+// DeadLetterNotifier is implemented by buses that dead letter messages themselves, NATSBus does
+type DeadLetterNotifier interface {
+	OnDeadLetter(subject string, hook DeadLetterHook)
+}
@@ -4,32 +4,65 @@ import (
 	"context"
 	"encoding/json"
 	"log/slog"
+	"shared/pii"
 )
 
 type EventReader struct {
 	bus    Bus
 	config *EventConfig
 	logger *slog.Logger
+	// Opens the PII fields publishers encrypted with EVENT_PII_KEY, without the key they reach handlers still sealed
+	redactor *pii.Redactor
 }
 
 func NewEventReader(bus Bus, config *EventConfig, logger *slog.Logger) *EventReader {
 	return &EventReader{
-		bus:    bus,
-		config: config,
-		logger: logger,
+		bus:      bus,
+		config:   config,
+		logger:   logger,
+		redactor: newRedactor(config.PIIKey, logger),
 	}
 }
 
+// newRedactor leaves encrypted fields sealed when the key is missing or invalid
+func newRedactor(encodedKey string, logger *slog.Logger) *pii.Redactor {
+	var key []byte
+	if encodedKey != "" {
+		var err error
+		if key, err = pii.ParseKey(encodedKey); err != nil {
+			logger.Error("Invalid EVENT_PII_KEY, encrypted event fields will not be decrypted", "error", err)
+			key = nil
+		}
+	}
+
+	redactor, err := pii.NewRedactor(key)
+	if err != nil {
+		logger.Error("Invalid EVENT_PII_KEY, encrypted event fields will not be decrypted", "error", err)
+		redactor, _ = pii.NewRedactor(nil)
+	}
+	return redactor
+}
+
+// open decrypts evt's PII fields. A failure is returned rather than acked, the message goes to the DLQ and can be
+// replayed once the key is fixed.
+func (r *EventReader) open(subject string, evt any) error {
+	if err := r.redactor.Open(evt); err != nil {
+		r.logger.Error("Failed to decrypt event", "subject", subject, "error", err)
+		return err
+	}
+	return nil
+}
+
 const queue = "listings-worker"
 
-func (r *EventReader) SubscribeToIndexListingEvents(handler func(evt IndexListingEvent) error) error {
+func (r *EventReader) SubscribeToIndexEvents(handler func(evt IndexEvent) error) error {
 	subject := r.config.IndexListing
-	r.logger.Info("Subscribing to IndexListing events", "subject", subject)
+	r.logger.Info("Subscribing to Index events", "subject", subject)
 
 	workerDurable := r.config.WorkerName
 
 	_, err := r.bus.Subscribe(subject, queue, workerDurable, func(ctx context.Context, payload []byte) error {
-		var evt IndexListingEvent
+		var evt IndexEvent
 
 		if err := json.Unmarshal(payload, &evt); err != nil {
 			// Log the error as critical
@@ -40,9 +73,119 @@ func (r *EventReader) SubscribeToIndexListingEvents(handler func(evt IndexListin
 			return nil
 		}
 
+		if err := r.open(subject, &evt); err != nil {
+			return err
+		}
+
+		evt.fillEntity()
+
 		// If logic fails (e.g. Typesense down), return error to Retry
 		return handler(evt)
 	})
 
 	return err
 }
+
+// OnIndexDeadLetter calls handler for every index event given up on after MaxDeliveries, once it's in the DLQ.
+// Only logs on a bus that doesn't dead letter messages itself.
+func (r *EventReader) OnIndexDeadLetter(handler func(evt IndexEvent, attempts int, cause error)) {
+	notifier, ok := r.bus.(DeadLetterNotifier)
+	if !ok {
+		r.logger.Warn("Event bus doesn't report dead letters, index failures will only be logged")
+		return
+	}
+
+	subject := r.config.IndexListing
+	notifier.OnDeadLetter(subject, func(payload []byte, deliveries int, cause error) {
+		var evt IndexEvent
+		if err := json.Unmarshal(payload, &evt); err != nil {
+			r.logger.Error("Dead lettered index event is malformed, not reporting it", "subject", subject, "error", err)
+			return
+		}
+		// Index events carry no PII, so there's nothing to open even when decrypting was what failed
+		evt.fillEntity()
+		handler(evt, deliveries, cause)
+	})
+}
+
+func (r *EventReader) SubscribeToListingCountersEvents(handler func(evt ListingCountersEvent) error) error {
+	subject := r.config.ListingCounters
+	r.logger.Info("Subscribing to ListingCounters events", "subject", subject)
+
+	// Separate durable from the index consumer, each subject keeps its own position
+	workerDurable := r.config.WorkerName + "-counters"
+
+	_, err := r.bus.Subscribe(subject, queue, workerDurable, func(ctx context.Context, payload []byte) error {
+		var evt ListingCountersEvent
+
+		if err := json.Unmarshal(payload, &evt); err != nil {
+			r.logger.Error("Discarding malformed JSON event", "subject", subject, "error", err)
+
+			return nil
+		}
+		if err := r.open(subject, &evt); err != nil {
+			return err
+		}
+
+		return handler(evt)
+	})
+
+	return err
+}
+
+func (r *EventReader) SubscribeToDeleteListingEvents(handler func(evt DeleteListingEvent) error) error {
+	subject := r.config.DeleteListing
+	r.logger.Info("Subscribing to DeleteListing events", "subject", subject)
+
+	workerDurable := r.config.WorkerName + "-delete"
+
+	_, err := r.bus.Subscribe(subject, queue, workerDurable, func(ctx context.Context, payload []byte) error {
+		var evt DeleteListingEvent
+
+		if err := json.Unmarshal(payload, &evt); err != nil {
+			r.logger.Error("Discarding malformed JSON event", "subject", subject, "error", err)
+
+			return nil
+		}
+		if err := r.open(subject, &evt); err != nil {
+			return err
+		}
+
+		return handler(evt)
+	})
+
+	return err
+}
+
+func (r *EventReader) SubscribeToListingCreatedEvents(consumer string, handler func(evt ListingCreatedEvent) error) error {
+	return subscribeToListingEvent(r, r.config.ListingCreated, consumer, handler)
+}
+
+func (r *EventReader) SubscribeToListingPublishedEvents(consumer string, handler func(evt ListingPublishedEvent) error) error {
+	return subscribeToListingEvent(r, r.config.ListingPublished, consumer, handler)
+}
+
+// subscribeToListingEvent subscribes to a listing lifecycle subject. These live on the LISTINGS stream,
+// which fans out, so every consumer (notifications, analytics, ...) needs its own durable to see every event.
+func subscribeToListingEvent[T any](r *EventReader, subject string, consumer string, handler func(evt T) error) error {
+	r.logger.Info("Subscribing to listing events", "subject", subject, "consumer", consumer)
+
+	workerDurable := r.config.WorkerName + "-" + consumer
+
+	_, err := r.bus.Subscribe(subject, queue+"-"+consumer, workerDurable, func(ctx context.Context, payload []byte) error {
+		var evt T
+
+		if err := json.Unmarshal(payload, &evt); err != nil {
+			r.logger.Error("Discarding malformed JSON event", "subject", subject, "error", err)
+
+			return nil
+		}
+		if err := r.open(subject, &evt); err != nil {
+			return err
+		}
+
+		return handler(evt)
+	})
+
+	return err
+}
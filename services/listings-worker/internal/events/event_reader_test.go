@@ -2,53 +2,40 @@ package events_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"os"
 	"testing"
 
 	"indexer/internal/events"
+	"indexer/internal/mocks/mockevents"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
-// --- 1. THE CAPTURING MOCK BUS ---
-
-type MockBus struct {
-	mock.Mock
-}
-
-func (m *MockBus) Close() error { return nil }
-
-func (m *MockBus) Subscribe(subject, group string, identifier string, handler events.Handler) (events.Subscription, error) {
-	// This allows testify to record the call
-	args := m.Called(subject, group, handler)
-	return args.Get(0).(events.Subscription), args.Error(1)
-}
-
-// --- 2. THE TEST SUITE ---
+// --- TESTS ---
 
 func TestSubscribe_Wiring_CorrectSubjectAndQueue(t *testing.T) {
 	// SCENARIO: Verify the Reader connects to the correct config values.
 
 	// Setup
-	mockBus := new(MockBus)
+	mockBus := mockevents.NewBus(t)
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	config := &events.EventConfig{IndexListing: "listing.index"}
 
 	reader := events.NewEventReader(mockBus, config, logger)
 
 	// Expectation: Must use the specific Subject and Queue Group
-	mockBus.On("Subscribe", "listing.index", "listings-worker", mock.Anything).
+	mockBus.EXPECT().Subscribe("listing.index", "listings-worker", mock.Anything, mock.Anything).
 		Return(events.Subscription{}, nil)
 
 	// Execute
-	err := reader.SubscribeToIndexListingEvents(func(e events.IndexListingEvent) error { return nil })
+	err := reader.SubscribeToIndexEvents(func(e events.IndexEvent) error { return nil })
 
 	// Assert
 	assert.NoError(t, err)
-	mockBus.AssertExpectations(t)
 }
 
 func TestSubscribe_PoisonPill_AcksBadJSON(t *testing.T) {
@@ -56,22 +43,22 @@ func TestSubscribe_PoisonPill_AcksBadJSON(t *testing.T) {
 	// EXPECT: The handler returns nil (Ack) to discard the message.
 	// The Service Logic must NOT be called.
 
-	mockBus := new(MockBus)
+	mockBus := mockevents.NewBus(t)
 	reader := events.NewEventReader(mockBus, &events.EventConfig{IndexListing: "subj"}, slog.Default())
 
 	// 1. Capture the NATS Handler
 	// We use .Run() to steal the function that Reader passes to Subscribe
 	var natsHandler events.Handler
 
-	mockBus.On("Subscribe", mock.Anything, mock.Anything, mock.Anything).
-		Run(func(args mock.Arguments) {
-			natsHandler = args.Get(2).(events.Handler) // Capture it!
+	mockBus.EXPECT().Subscribe(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(subject, group, name string, handler events.Handler) {
+			natsHandler = handler // Capture it!
 		}).
 		Return(events.Subscription{}, nil)
 
 	// 2. Initialize
 	serviceCalled := false
-	_ = reader.SubscribeToIndexListingEvents(func(e events.IndexListingEvent) error {
+	_ = reader.SubscribeToIndexEvents(func(e events.IndexEvent) error {
 		serviceCalled = true
 		return nil
 	})
@@ -89,24 +76,24 @@ func TestSubscribe_HappyPath_ParsesAndForward(t *testing.T) {
 	// SCENARIO: Valid JSON arrives.
 	// EXPECT: JSON is parsed into struct and Service Logic is called.
 
-	mockBus := new(MockBus)
+	mockBus := mockevents.NewBus(t)
 	reader := events.NewEventReader(mockBus, &events.EventConfig{IndexListing: "subj"}, slog.Default())
 
 	var natsHandler events.Handler
-	mockBus.On("Subscribe", mock.Anything, mock.Anything, mock.Anything).
-		Run(func(args mock.Arguments) {
-			natsHandler = args.Get(2).(events.Handler)
+	mockBus.EXPECT().Subscribe(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(subject, group, name string, handler events.Handler) {
+			natsHandler = handler
 		}).
 		Return(events.Subscription{}, nil)
 
 	// 2. Define Service Logic
 	var capturedID string
-	serviceLogic := func(e events.IndexListingEvent) error {
+	serviceLogic := func(e events.IndexEvent) error {
 		capturedID = e.ListingID
 		return nil
 	}
 
-	_ = reader.SubscribeToIndexListingEvents(serviceLogic)
+	_ = reader.SubscribeToIndexEvents(serviceLogic)
 
 	// 3. Simulate NATS delivery of GOOD JSON
 	validJSON := []byte(`{"listing_id": "550e8400-e29b-41d4-a716-446655440000"}`)
@@ -121,22 +108,22 @@ func TestSubscribe_LogicFailure_Nacks(t *testing.T) {
 	// SCENARIO: Service Logic fails (e.g. DB down).
 	// EXPECT: Handler returns error (Nack) so NATS retries.
 
-	mockBus := new(MockBus)
+	mockBus := mockevents.NewBus(t)
 	reader := events.NewEventReader(mockBus, &events.EventConfig{IndexListing: "subj"}, slog.Default())
 
 	var natsHandler events.Handler
-	mockBus.On("Subscribe", mock.Anything, mock.Anything, mock.Anything).
-		Run(func(args mock.Arguments) {
-			natsHandler = args.Get(2).(events.Handler)
+	mockBus.EXPECT().Subscribe(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(subject, group, name string, handler events.Handler) {
+			natsHandler = handler
 		}).
 		Return(events.Subscription{}, nil)
 
 	// 2. Define Service Logic that FAILS
-	serviceLogic := func(e events.IndexListingEvent) error {
+	serviceLogic := func(e events.IndexEvent) error {
 		return errors.New("db connection lost")
 	}
 
-	_ = reader.SubscribeToIndexListingEvents(serviceLogic)
+	_ = reader.SubscribeToIndexEvents(serviceLogic)
 
 	// 3. Simulate NATS delivery
 	err := natsHandler(context.Background(), []byte(`{"listing_id":"123"}`))
@@ -145,3 +132,226 @@ func TestSubscribe_LogicFailure_Nacks(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "db connection lost")
 }
+
+func TestSubscribeToDeleteListing_PoisonPill_AcksBadJSON(t *testing.T) {
+	// SCENARIO: A delete event arrives as malformed JSON.
+	// EXPECT: It's acked and dropped, the document isn't touched.
+
+	config := &events.EventConfig{WorkerName: "listings-worker", DeleteListing: "index.listing.delete"}
+
+	serviceCalled := false
+	handler, subject, durable := captureHandler(t, config, func(r *events.EventReader) error {
+		return r.SubscribeToDeleteListingEvents(func(evt events.DeleteListingEvent) error {
+			serviceCalled = true
+			return nil
+		})
+	})
+
+	assert.NoError(t, handler(context.Background(), []byte(`{ NOT VALID JSON`)), "Handler MUST return nil (Ack) for bad JSON")
+	assert.False(t, serviceCalled)
+	assert.Equal(t, "index.listing.delete", subject)
+	assert.Equal(t, "listings-worker-delete", durable, "deletes keep their own position on the INDEX stream")
+}
+
+func TestSubscribeToDeleteListing_GatewayContract(t *testing.T) {
+	// SCENARIO: The gateway's DeleteListingIndexEvent arrives, exactly as it marshals it.
+	// EXPECT: The listing ID reaches the handler.
+
+	var got events.DeleteListingEvent
+	handler, _, _ := captureHandler(t, &events.EventConfig{DeleteListing: "index.listing.delete"}, func(r *events.EventReader) error {
+		return r.SubscribeToDeleteListingEvents(func(evt events.DeleteListingEvent) error {
+			got = evt
+			return nil
+		})
+	})
+
+	assert.NoError(t, handler(context.Background(), []byte(`{"listing_id":"550e8400-e29b-41d4-a716-446655440000","trace_id":"abc"}`)))
+	assert.Equal(t, events.DeleteListingEvent{ListingID: "550e8400-e29b-41d4-a716-446655440000", TraceID: "abc"}, got)
+}
+
+func TestSubscribeToDeleteListing_LogicFailure_Nacks(t *testing.T) {
+	// SCENARIO: Typesense is down when the delete arrives.
+	// EXPECT: The error comes back (Nack) so NATS retries.
+
+	handler, _, _ := captureHandler(t, &events.EventConfig{DeleteListing: "index.listing.delete"}, func(r *events.EventReader) error {
+		return r.SubscribeToDeleteListingEvents(func(evt events.DeleteListingEvent) error {
+			return errors.New("typesense delete failed: 503")
+		})
+	})
+
+	err := handler(context.Background(), []byte(`{"listing_id":"123"}`))
+
+	assert.ErrorContains(t, err, "503")
+}
+
+// captureHandler subscribes through fn and returns the handler the reader registered with the bus
+func captureHandler(t *testing.T, config *events.EventConfig, fn func(r *events.EventReader) error) (events.Handler, string, string) {
+	t.Helper()
+
+	mockBus := mockevents.NewBus(t)
+	var natsHandler events.Handler
+	var subject, durable string
+	mockBus.EXPECT().Subscribe(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(s, group, name string, handler events.Handler) {
+			subject, durable, natsHandler = s, name, handler
+		}).
+		Return(events.Subscription{}, nil)
+
+	assert.NoError(t, fn(events.NewEventReader(mockBus, config, slog.Default())))
+	return natsHandler, subject, durable
+}
+
+func TestSubscribe_ListingCreated_GatewayContract(t *testing.T) {
+	// SCENARIO: The gateway's ListingCreatedEvent arrives, exactly as it marshals it.
+	// EXPECT: Every field makes it across and the consumer gets its own durable.
+
+	config := &events.EventConfig{WorkerName: "listings-worker", ListingCreated: "listings.created"}
+
+	var got events.ListingCreatedEvent
+	handler, subject, durable := captureHandler(t, config, func(r *events.EventReader) error {
+		return r.SubscribeToListingCreatedEvents("notifications", func(evt events.ListingCreatedEvent) error {
+			got = evt
+			return nil
+		})
+	})
+
+	payload := []byte(`{"listing_id":"11111111111111111111111111111111","seller_id":"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11",` +
+		`"title":"Benchy","price_min_unit":1050,"currency":"gbp","categories":["Art"],"trace_id":"abc","request_id":"host/xyz-000001"}`)
+	assert.NoError(t, handler(context.Background(), payload))
+
+	assert.Equal(t, "listings.created", subject)
+	assert.Equal(t, "listings-worker-notifications", durable)
+	assert.Equal(t, events.ListingCreatedEvent{
+		ListingID:    "11111111111111111111111111111111",
+		SellerID:     "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11",
+		Title:        "Benchy",
+		PriceMinUnit: 1050,
+		Currency:     "gbp",
+		Categories:   []string{"Art"},
+		TraceID:      "abc",
+		RequestID:    "host/xyz-000001",
+	}, got)
+}
+
+func TestSubscribe_ListingPublished_ValidationWorkerContract(t *testing.T) {
+	// SCENARIO: The validation worker's pydantic ListingPublishedEvent arrives, timestamp without an offset.
+	// EXPECT: It still decodes instead of being discarded as malformed.
+
+	config := &events.EventConfig{WorkerName: "listings-worker", ListingPublished: "listings.published"}
+
+	var got events.ListingPublishedEvent
+	handler, subject, _ := captureHandler(t, config, func(r *events.EventReader) error {
+		return r.SubscribeToListingPublishedEvents("notifications", func(evt events.ListingPublishedEvent) error {
+			got = evt
+			return nil
+		})
+	})
+
+	payload := []byte(`{"event_id":"5f0c","timestamp":"2026-10-16T01:46:43.123456","topic":"listings.published","listing_id":"list_xyz","status":"ACTIVE"}`)
+	assert.NoError(t, handler(context.Background(), payload))
+
+	assert.Equal(t, "listings.published", subject)
+	assert.Equal(t, events.ListingPublishedEvent{EventID: "5f0c", Timestamp: "2026-10-16T01:46:43.123456", ListingID: "list_xyz", Status: "ACTIVE"}, got)
+}
+
+func TestSubscribe_IndexEvent_Envelope(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    events.IndexEvent
+	}{
+		{
+			// The gateway and validation worker predate entity_type
+			name:    "Legacy listing event",
+			payload: `{"listing_id":"550e8400e29b41d4a716446655440000","trace_id":"abc"}`,
+			want:    events.IndexEvent{EntityType: "listing", EntityID: "550e8400e29b41d4a716446655440000", ListingID: "550e8400e29b41d4a716446655440000"},
+		},
+		{
+			name:    "Seller event",
+			payload: `{"entity_type":"seller","entity_id":"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"}`,
+			want:    events.IndexEvent{EntityType: "seller", EntityID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got events.IndexEvent
+			handler, _, _ := captureHandler(t, &events.EventConfig{IndexListing: "listing.index"}, func(r *events.EventReader) error {
+				return r.SubscribeToIndexEvents(func(evt events.IndexEvent) error {
+					got = evt
+					return nil
+				})
+			})
+
+			assert.NoError(t, handler(context.Background(), []byte(tt.payload)))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// deadLetterBus is a bus that dead letters messages itself, like NATSBus
+type deadLetterBus struct {
+	*mockevents.Bus
+	hooks map[string]events.DeadLetterHook
+}
+
+func (b *deadLetterBus) OnDeadLetter(subject string, hook events.DeadLetterHook) {
+	b.hooks[subject] = hook
+}
+
+func TestOnIndexDeadLetter_DecodesTheEvent(t *testing.T) {
+	// SCENARIO: A legacy index event from the gateway is dead lettered after every delivery failed.
+	// EXPECT: The handler gets the event with its entity filled in, the attempts and the last error.
+
+	bus := &deadLetterBus{Bus: mockevents.NewBus(t), hooks: map[string]events.DeadLetterHook{}}
+	reader := events.NewEventReader(bus, &events.EventConfig{IndexListing: "index.listing"}, slog.Default())
+
+	var (
+		got      events.IndexEvent
+		attempts int
+		cause    error
+	)
+	reader.OnIndexDeadLetter(func(evt events.IndexEvent, n int, err error) {
+		got, attempts, cause = evt, n, err
+	})
+
+	hook := bus.hooks["index.listing"]
+	if assert.NotNil(t, hook) {
+		hook([]byte(`{"listing_id":"550e8400e29b41d4a716446655440000","trace_id":"abc"}`), events.MaxDeliveries, errors.New("503"))
+	}
+
+	assert.Equal(t, events.IndexEvent{EntityType: "listing", EntityID: "550e8400e29b41d4a716446655440000", ListingID: "550e8400e29b41d4a716446655440000"}, got)
+	assert.Equal(t, events.MaxDeliveries, attempts)
+	assert.EqualError(t, cause, "503")
+}
+
+func TestPublishListingIndexFailed_WireFormat(t *testing.T) {
+	// SCENARIO: A listing index failure is reported.
+	// EXPECT: The payload keys match what the gateway decodes, see TestSubscribeToListingIndexFailed_WorkerContract there.
+
+	mockBus := mockevents.NewBus(t)
+	writer := events.NewEventWriter(mockBus, &events.EventConfig{ListingIndexFailed: "listings.index_failed"}, slog.Default())
+
+	var payload map[string]any
+	mockBus.EXPECT().Publish("listings.index_failed", mock.Anything, "index-failed.abc").
+		Run(func(_ string, data []byte, _ string) { assert.NoError(t, json.Unmarshal(data, &payload)) }).
+		Return(nil)
+
+	assert.NoError(t, writer.PublishListingIndexFailed(events.ListingIndexFailedEvent{
+		ListingID:  "550e8400e29b41d4a716446655440000",
+		SellerID:   "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11",
+		ErrorClass: "search",
+		Error:      "503 service unavailable",
+		Attempts:   5,
+		FailedAt:   "2026-10-16T09:30:00Z",
+	}, "index-failed.abc"))
+
+	assert.Equal(t, map[string]any{
+		"listing_id":  "550e8400e29b41d4a716446655440000",
+		"seller_id":   "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11",
+		"error_class": "search",
+		"error":       "503 service unavailable",
+		"attempts":    float64(5),
+		"failed_at":   "2026-10-16T09:30:00Z",
+	}, payload)
+}
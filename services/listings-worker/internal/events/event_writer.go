@@ -0,0 +1,64 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+)
+
+type EventWriter struct {
+	bus    Bus
+	config *EventConfig
+	logger *slog.Logger
+}
+
+func NewEventWriter(bus Bus, config *EventConfig, logger *slog.Logger) *EventWriter {
+	return &EventWriter{
+		bus:    bus,
+		config: config,
+		logger: logger,
+	}
+}
+
+// PublishListingCounters announces new counter totals for the index. msgID should be stable across retries.
+func (w *EventWriter) PublishListingCounters(evt ListingCountersEvent, msgID string) error {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("failed to marshal listing counters event: %w", err)
+	}
+
+	if err := w.bus.Publish(w.config.ListingCounters, data, msgID); err != nil {
+		w.logger.Error("Failed to publish listing counters event", "listing_id", evt.ListingID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// PublishListingIndexFailed reports a listing the worker gave up indexing. msgID should be stable across retries.
+func (w *EventWriter) PublishListingIndexFailed(evt ListingIndexFailedEvent, msgID string) error {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("failed to marshal listing index failed event: %w", err)
+	}
+
+	if err := w.bus.Publish(w.config.ListingIndexFailed, data, msgID); err != nil {
+		w.logger.Error("Failed to publish listing index failed event", "listing_id", evt.ListingID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// PublishSavedSearchMatched tells the notification service about new matches for a user's saved searches. msgID
+// should be stable across retries.
+func (w *EventWriter) PublishSavedSearchMatched(evt SavedSearchMatchedEvent, msgID string) error {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("failed to marshal saved search matched event: %w", err)
+	}
+
+	if err := w.bus.Publish(w.config.SavedSearchMatched, data, msgID); err != nil {
+		w.logger.Error("Failed to publish saved search matched event", "user_id", evt.UserID, "error", err)
+		return err
+	}
+	return nil
+}
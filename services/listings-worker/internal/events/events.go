@@ -4,18 +4,114 @@ import (
 	"os"
 )
 
-type IndexListingEvent struct {
-	ListingID string `json:"listing_id"` // This is the database ID of the listing the file is associated with
+// defaultEntityType is what index events from publishers that predate entity_type are about
+const defaultEntityType = "listing"
+
+// IndexEvent asks for one document to be rebuilt from the database, entity_type picks the source that builds it.
+// The gateway and validation worker only send listing_id, the reader fills EntityType and EntityID in from it.
+type IndexEvent struct {
+	EntityType string `json:"entity_type"`
+	EntityID   string `json:"entity_id"`
+	ListingID  string `json:"listing_id"` // This is the database ID of the listing the file is associated with
+}
+
+// fillEntity fills EntityType and EntityID in for publishers that only send listing_id
+func (e *IndexEvent) fillEntity() {
+	if e.EntityType == "" {
+		e.EntityType = defaultEntityType
+	}
+	if e.EntityID == "" && e.EntityType == defaultEntityType {
+		e.EntityID = e.ListingID
+	}
+}
+
+// ListingCountersEvent carries absolute counts, not deltas, so applying it twice is harmless
+type ListingCountersEvent struct {
+	ListingID      string `json:"listing_id"`
+	DownloadsCount int64  `json:"downloads_count"`
+	ViewsCount     int64  `json:"views_count"`
+	// The seller's bucket when the counts were read, see indexing.SellerActivityBucket
+	SellerActivityBucket string `json:"seller_activity_bucket,omitempty"`
+}
+
+// DeleteListingEvent is published by the gateway once a listing is deleted. Unlike an index event it doesn't wait
+// for the row to be read back, the document is just removed.
+type DeleteListingEvent struct {
+	ListingID string `json:"listing_id"`
+	TraceID   string `json:"trace_id"`
+}
+
+// ListingCreatedEvent is published by the gateway once a listing is committed, before its files are validated
+type ListingCreatedEvent struct {
+	ListingID    string   `json:"listing_id"`
+	SellerID     string   `json:"seller_id"`
+	Title        string   `json:"title"`
+	PriceMinUnit int64    `json:"price_min_unit"`
+	Currency     string   `json:"currency"`
+	Categories   []string `json:"categories"`
+	TraceID      string   `json:"trace_id"`
+	RequestID    string   `json:"request_id"`
+}
+
+// ListingPublishedEvent is published by the validation worker when every file passed and the listing went ACTIVE
+type ListingPublishedEvent struct {
+	EventID   string `json:"event_id"`
+	Timestamp string `json:"timestamp"` // UTC but without an offset, so it won't parse as a time.Time
+	ListingID string `json:"listing_id"`
+	Status    string `json:"status"` // ACTIVE, empty from workers that predate it
+}
+
+// ListingIndexFailedEvent is published when an index event for a listing is dead lettered. The gateway records it for the
+// seller and moderators, and the worker clears it again the next time the listing is indexed.
+type ListingIndexFailedEvent struct {
+	ListingID  string `json:"listing_id"`
+	SellerID   string `json:"seller_id"`   // Empty when the listing couldn't be read, e.g. the database was the problem
+	ErrorClass string `json:"error_class"` // See indexing.ErrorClass
+	Error      string `json:"error"`       // The last attempt's error
+	Attempts   int    `json:"attempts"`
+	FailedAt   string `json:"failed_at"` // RFC 3339 with fractional seconds
+}
+
+// SavedSearchMatchedEvent lists the new listings matching a user's saved searches, one event per user per check. A
+// listing appears under one saved search only, even when several of the user's searches match it.
+type SavedSearchMatchedEvent struct {
+	UserID    string             `json:"user_id"`
+	Matches   []SavedSearchMatch `json:"matches"`
+	MatchedAt string             `json:"matched_at"` // RFC 3339
+}
+
+type SavedSearchMatch struct {
+	SavedSearchID string   `json:"saved_search_id"`
+	Query         string   `json:"query"`
+	ListingIDs    []string `json:"listing_ids"` // Newest first
 }
 
 type EventConfig struct {
-	WorkerName   string
-	IndexListing string
+	WorkerName       string
+	IndexListing     string
+	ListingCounters  string
+	ListingCreated   string
+	ListingPublished string
+	// DeleteListing is optional, without it deleted listings only leave the index through IndexListing events
+	DeleteListing string
+	// ListingIndexFailed is optional, without it dead lettered index events are only logged
+	ListingIndexFailed string
+	// SavedSearchMatched is optional, without it saved searches aren't checked
+	SavedSearchMatched string
+	// PIIKey is the base64 key publishers encrypt PII fields with, see EVENT_PII_KEY and shared/pii
+	PIIKey string
 }
 
 func NewEventConfig() *EventConfig {
 	return &EventConfig{
-		WorkerName:   os.Getenv("INDEXING_WORKER_NAME"),
-		IndexListing: os.Getenv("EVENT_INDEX_LISTING"),
+		WorkerName:         os.Getenv("INDEXING_WORKER_NAME"),
+		IndexListing:       os.Getenv("EVENT_INDEX_LISTING"),
+		ListingCounters:    os.Getenv("EVENT_LISTING_COUNTERS"),
+		ListingCreated:     os.Getenv("EVENT_LISTING_CREATED"),
+		ListingPublished:   os.Getenv("EVENT_LISTING_PUBLISHED"),
+		DeleteListing:      os.Getenv("EVENT_DELETE_LISTING"),
+		ListingIndexFailed: os.Getenv("EVENT_LISTING_INDEX_FAILED"),
+		SavedSearchMatched: os.Getenv("EVENT_SAVED_SEARCH_MATCHED"),
+		PIIKey:             os.Getenv("EVENT_PII_KEY"),
 	}
 }
@@ -4,51 +4,52 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"os"
+	"shared/natsconn"
+	"sync"
 	"time"
 
 	"github.com/nats-io/nats.go"
 )
 
-type NATSBus struct {
-	nats          *nats.Conn
-	js            nats.JetStreamContext
-	log           *slog.Logger
-	workerDurable string
-}
+const (
+	// MaxDeliveries is how many times a message is handled before it's moved to the dead letter stream
+	MaxDeliveries = 5
 
-func NewNATSBus(addr string, logger *slog.Logger) (Bus, error) {
+	// DeadLetterStream holds messages that kept failing, under dlq.<original subject>
+	DeadLetterStream = natsconn.DeadLetterStream
 
-	opts := []nats.Option{
-		// 1. Identification: Makes debugging on the NATS dashboard easier
-		nats.Name("listings-worker"),
+	HeaderDeadLetterError    = "Dead-Letter-Error"    // What the handler returned on the last attempt
+	HeaderDeadLetterConsumer = "Dead-Letter-Consumer" // Durable that gave up on the message
+)
 
-		// 2. Resilience: NEVER give up trying to reconnect.
-		// Default is 60. We set -1 (infinite).
-		nats.MaxReconnects(-1),
+// DeadLetterSubject is where messages from subject end up once they've failed MaxDeliveries times
+func DeadLetterSubject(subject string) string {
+	return "dlq." + subject
+}
 
-		// 3. Backoff: Don't spam the server. Wait 3s between attempts.
-		nats.ReconnectWait(3 * time.Second),
+// ConsumerRef is a durable this worker consumes a subject with
+type ConsumerRef struct {
+	Subject string
+	Durable string
+}
 
-		// 4. Observability: Log when things go wrong
-		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
-			logger.Warn("NATS disconnected! Buffering messages...", "error", err)
-		}),
+type NATSBus struct {
+	nats          *nats.Conn
+	js            nats.JetStreamContext
+	log           *slog.Logger
+	workerDurable string
 
-		nats.ReconnectHandler(func(nc *nats.Conn) {
-			logger.Info("NATS reconnected successfully!", "url", nc.ConnectedUrl())
-		}),
+	mu        sync.Mutex
+	consumers []ConsumerRef
+	hooks     map[string]DeadLetterHook // By subscribed subject
+}
 
-		// 5. Safety Net: If the connection is permanently dead (e.g. auth failure),
-		// kill the app so Docker can restart it with fresh config/state.
-		nats.ClosedHandler(func(nc *nats.Conn) {
-			logger.Error("NATS connection closed permanently. Exiting process.")
-			os.Exit(1)
-		}),
-	}
-	nc, err := nats.Connect(addr, opts...)
+// NewNATSBus connects with cfg. The error wraps natsconn.ErrAuth when the credentials were turned down and
+// natsconn.ErrUnreachable when the server couldn't be reached.
+func NewNATSBus(cfg natsconn.Config, logger *slog.Logger) (*NATSBus, error) {
+	nc, err := natsconn.Connect("listings-worker", cfg, logger)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to create nats client: %w", err)
+		return nil, err
 	}
 
 	js, err := nc.JetStream()
@@ -56,23 +57,9 @@ func NewNATSBus(addr string, logger *slog.Logger) (Bus, error) {
 		return nil, err
 	}
 
-	// 3. Ensure Stream Exists (Idempotent)
-	streamName := "INDEX"
-	streamSubject := "index.>" // Listen to index.created, index.updated, etc.
-
-	// Check if stream info exists, if not, create it
-	_, err = js.StreamInfo(streamName)
-	if err != nil {
-		logger.Info("⚠️ Stream %s not found, creating...", streamName)
-		_, err = js.AddStream(&nats.StreamConfig{
-			Name:      streamName,
-			Subjects:  []string{streamSubject},
-			Retention: nats.WorkQueuePolicy,
-		})
-		if err != nil {
-			return nil, err
-		}
-		logger.Info("✅ JetStream '%s' stream verified.", streamName)
+	// 3. Ensure Streams Exist (Idempotent)
+	if err := natsconn.EnsureStreams(js, logger); err != nil {
+		return nil, err
 	}
 
 	return &NATSBus{
@@ -102,6 +89,10 @@ func (b *NATSBus) Subscribe(subject string, group string, name string, handler H
 
 		// Execute User Handler
 		if err := handler(ctx, msg.Data); err != nil {
+			if meta, metaErr := msg.Metadata(); metaErr == nil && meta.NumDelivered >= MaxDeliveries {
+				b.deadLetter(msg, subject, name, err)
+				return
+			}
 			b.log.Error("Handler failed, Nacking message", "subject", subject, "error", err)
 			msg.Nak() // Retry the message later
 			return
@@ -117,6 +108,10 @@ func (b *NATSBus) Subscribe(subject string, group string, name string, handler H
 		return Subscription{}, fmt.Errorf("Failed to subscribe to subject %s: %w", subject, err)
 	}
 
+	b.mu.Lock()
+	b.consumers = append(b.consumers, ConsumerRef{Subject: subject, Durable: name})
+	b.mu.Unlock()
+
 	return Subscription{
 		Unsubscribe: func() error {
 			return sub.Unsubscribe()
@@ -124,6 +119,67 @@ func (b *NATSBus) Subscribe(subject string, group string, name string, handler H
 	}, nil
 }
 
+// deadLetter moves a message that keeps failing out of the way so the rest of the queue isn't held up behind it.
+// It's only removed from the consumer once the copy is stored, otherwise it's retried like any other failure.
+func (b *NATSBus) deadLetter(msg *nats.Msg, subject string, consumer string, cause error) {
+	dead := nats.NewMsg(DeadLetterSubject(msg.Subject))
+	dead.Data = msg.Data
+	dead.Header.Set(HeaderDeadLetterError, cause.Error())
+	dead.Header.Set(HeaderDeadLetterConsumer, consumer)
+
+	if _, err := b.js.PublishMsg(dead); err != nil {
+		b.log.Error("Failed to dead letter message, Nacking", "subject", msg.Subject, "consumer", consumer, "error", err)
+		msg.Nak()
+		return
+	}
+
+	b.log.Error("Message kept failing, moved to the dead letter stream", "subject", msg.Subject, "consumer", consumer, "deliveries", MaxDeliveries, "error", cause)
+	if err := msg.Term(); err != nil {
+		b.log.Error("Failed to Term dead lettered message", "subject", msg.Subject, "error", err)
+	}
+
+	b.mu.Lock()
+	hook := b.hooks[subject]
+	b.mu.Unlock()
+	if hook != nil {
+		hook(msg.Data, MaxDeliveries, cause)
+	}
+}
+
+// OnDeadLetter calls hook for every message on subject that is dead lettered from now on, replacing any earlier hook.
+// subject is the one passed to Subscribe.
+func (b *NATSBus) OnDeadLetter(subject string, hook DeadLetterHook) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.hooks == nil {
+		b.hooks = make(map[string]DeadLetterHook)
+	}
+	b.hooks[subject] = hook
+}
+
+// StreamForSubject names the JetStream stream that captures subject, subscriptions to a subject without one fail
+func (b *NATSBus) StreamForSubject(subject string) (string, error) {
+	return b.js.StreamNameBySubject(subject)
+}
+
+// JetStream is the context the bus consumes with, for reading consumer and stream state
+func (b *NATSBus) JetStream() nats.JetStreamContext {
+	return b.js
+}
+
+// Consumers lists every durable subscribed so far
+func (b *NATSBus) Consumers() []ConsumerRef {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]ConsumerRef(nil), b.consumers...)
+}
+
+// Publish writes to JetStream. msgId lets the server drop duplicates when a publish is retried.
+func (b *NATSBus) Publish(subject string, data []byte, msgId string) error {
+	_, err := b.js.Publish(subject, data, nats.MsgId(msgId))
+	return err
+}
+
 func (b *NATSBus) Close() error {
 	b.log.Info("Closing NATS connection")
 	return b.nats.Drain()
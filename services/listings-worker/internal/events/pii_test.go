@@ -0,0 +1,92 @@
+package events
+
+import (
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"shared/pii"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPIIKey is 32 bytes of 0x01
+var testPIIKey = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("\x01", 32)))
+
+// purgeEvent carries an email that publishers encrypt
+type purgeEvent struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email" pii:"true"`
+}
+
+func (purgeEvent) PIIMode() pii.Mode { return pii.Encrypt }
+
+// publishedPurge is what a publisher holding testPIIKey sends
+func publishedPurge(t *testing.T) []byte {
+	t.Helper()
+	key, err := pii.ParseKey(testPIIKey)
+	require.NoError(t, err)
+	redactor, err := pii.NewRedactor(key)
+	require.NoError(t, err)
+	data, err := redactor.Marshal(purgeEvent{UserID: "user-1", Email: "tester@example.com"})
+	require.NoError(t, err)
+	return data
+}
+
+// captureBus keeps the handler it was subscribed with, the generated mocks import this package
+type captureBus struct {
+	handler Handler
+}
+
+func (b *captureBus) Subscribe(subject, group, name string, handler Handler) (Subscription, error) {
+	b.handler = handler
+	return Subscription{}, nil
+}
+
+func (b *captureBus) Publish(subject string, data []byte, msgId string) error { return nil }
+func (b *captureBus) Close() error                                            { return nil }
+
+func subscribePurge(t *testing.T, key string, got *purgeEvent) Handler {
+	t.Helper()
+
+	bus := &captureBus{}
+	r := NewEventReader(bus, &EventConfig{WorkerName: "listings-worker", PIIKey: key}, slog.Default())
+	require.NoError(t, subscribeToListingEvent(r, "users.purge", "gdpr", func(evt purgeEvent) error {
+		*got = evt
+		return nil
+	}))
+	return bus.handler
+}
+
+func TestSubscribe_DecryptsPII(t *testing.T) {
+	// SCENARIO: An event with an encrypted email arrives at a worker holding the key.
+	// EXPECT: The handler gets the plaintext email.
+
+	var got purgeEvent
+	handler := subscribePurge(t, testPIIKey, &got)
+
+	require.NoError(t, handler(context.Background(), publishedPurge(t)))
+	assert.Equal(t, purgeEvent{UserID: "user-1", Email: "tester@example.com"}, got)
+}
+
+func TestSubscribe_WithoutKeyLeavesPIISealed(t *testing.T) {
+	var got purgeEvent
+	handler := subscribePurge(t, "", &got)
+
+	require.NoError(t, handler(context.Background(), publishedPurge(t)))
+	assert.Equal(t, "user-1", got.UserID)
+	assert.True(t, strings.HasPrefix(got.Email, pii.EncryptedPrefix))
+}
+
+func TestSubscribe_WrongKeyIsRetried(t *testing.T) {
+	// SCENARIO: The worker's key doesn't match the publisher's.
+	// EXPECT: An error, so the message is redelivered and ends up in the DLQ instead of being acked and lost.
+
+	var got purgeEvent
+	handler := subscribePurge(t, base64.StdEncoding.EncodeToString([]byte(strings.Repeat("\x02", 32))), &got)
+
+	assert.Error(t, handler(context.Background(), publishedPurge(t)))
+	assert.Empty(t, got.UserID)
+}
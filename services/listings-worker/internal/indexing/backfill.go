@@ -0,0 +1,167 @@
+package indexing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	repo "indexer/internal/database/postgresql/sqlc"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// errNotInDocument stops a backfill, every listing would fail the same way
+var errNotInDocument = errors.New("field isn't part of the listing document")
+
+// BackfillOptions picks the fields a backfill writes and where it starts
+type BackfillOptions struct {
+	Fields    []string
+	BatchSize int
+
+	// After resumes the backfill after this listing, the zero value starts from the beginning
+	After pgtype.UUID
+
+	// Progress is called after every page, e.g. to save a checkpoint to resume from
+	Progress func(BackfillProgress)
+}
+
+// BackfillProgress is a running total for a backfill
+type BackfillProgress struct {
+	LastID  pgtype.UUID // Last listing the backfill finished with, pass as After to resume
+	Scanned int
+	Updated int
+	Skipped int // Not in the index, the next full index writes every field anyway
+	Failed  int
+}
+
+// Backfill writes the given fields onto every indexed listing with partial updates, leaving the rest of each document alone.
+// New search fields only reach a document when its listing is next indexed, until then the listing silently drops out of
+// sorts and filters on that field. The fields must already be in the live collection schema, Typesense would otherwise
+// accept the values without indexing them.
+//
+// Listings are read from the replica when there is one, a listing changed within the replica's lag can get the fields
+// as they were before the change until it's next indexed.
+func (s *svc) Backfill(ctx context.Context, opts BackfillOptions) (BackfillProgress, error) {
+	progress := BackfillProgress{LastID: opts.After}
+	if !progress.LastID.Valid {
+		// Zero UUID sorts first
+		progress.LastID = pgtype.UUID{Valid: true}
+	}
+
+	if err := s.checkBackfillFields(ctx, opts.Fields); err != nil {
+		return progress, err
+	}
+
+	s.logger.Info("Starting backfill", "fields", opts.Fields, "after", progress.LastID.String())
+
+	for {
+		listings, err := s.reader.GetListingsForBackfill(ctx, repo.GetListingsForBackfillParams{
+			AfterID:   progress.LastID,
+			BatchSize: int32(opts.BatchSize),
+		})
+		if err != nil {
+			return progress, fmt.Errorf("failed to fetch listings: %w", err)
+		}
+		if len(listings) == 0 {
+			break
+		}
+
+		for _, listing := range listings {
+			if err := ctx.Err(); err != nil {
+				return progress, err
+			}
+			progress.Scanned++
+
+			if err := s.backfillListing(ctx, listing, opts.Fields); err != nil {
+				if errors.Is(err, errNotInDocument) {
+					return progress, err
+				}
+				if errors.Is(err, ErrNotFound) {
+					progress.Skipped++
+				} else {
+					// Keep going, a rerun with the same fields picks it up
+					s.logger.Error("Failed to backfill listing", "error", err, "listing_id", listing.ID.String())
+					progress.Failed++
+				}
+			} else {
+				progress.Updated++
+			}
+			progress.LastID = listing.ID
+		}
+
+		s.logger.Info("Backfill progress", "scanned", progress.Scanned, "updated", progress.Updated,
+			"skipped", progress.Skipped, "failed", progress.Failed, "last_id", progress.LastID.String())
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+	}
+
+	s.logger.Info("Backfill complete", "fields", opts.Fields, "scanned", progress.Scanned, "updated", progress.Updated,
+		"skipped", progress.Skipped, "failed", progress.Failed)
+	return progress, nil
+}
+
+// checkBackfillFields refuses fields the live collection doesn't have, and the id which documents are keyed on
+func (s *svc) checkBackfillFields(ctx context.Context, fields []string) error {
+	if len(fields) == 0 {
+		return errors.New("no fields to backfill")
+	}
+	if slices.Contains(fields, "id") {
+		return errors.New("the id field can't be backfilled")
+	}
+
+	schema, err := s.indexer.Fields(ctx, "listings")
+	if err != nil {
+		return fmt.Errorf("failed to read listings schema: %w", err)
+	}
+
+	var missing []string
+	for _, field := range fields {
+		if !slices.Contains(schema, field) {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("fields not in the listings collection schema, add them before backfilling: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// backfillListing patches the requested fields onto one listing's document, ErrNotFound means it isn't indexed
+func (s *svc) backfillListing(ctx context.Context, listing repo.Listing, fields []string) error {
+	// Same dashless format the gateway publishes, so we update the existing document
+	listingID := fmt.Sprintf("%x", listing.ID.Bytes)
+
+	document, err := s.listings.Document(listingID, listing)
+	if err != nil {
+		return err
+	}
+	// The file fields aren't in the listing row, only read the files when one of them is asked for
+	if slices.ContainsFunc(fields, func(field string) bool { return slices.Contains(modelFileFields, field) }) {
+		files, err := s.listings.modelFiles(ctx, listing.ID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch listing files: %w", err)
+		}
+		maps.Copy(document, files)
+	}
+	if slices.Contains(fields, "price_min_unit") {
+		price, err := s.listings.searchPrice(ctx, listing)
+		if err != nil {
+			return fmt.Errorf("failed to fetch listing variants: %w", err)
+		}
+		document["price_min_unit"] = price
+	}
+
+	update := make(map[string]any, len(fields))
+	for _, field := range fields {
+		value, ok := document[field]
+		if !ok {
+			return fmt.Errorf("%w: %s", errNotInDocument, field)
+		}
+		update[field] = value
+	}
+
+	return s.indexer.Update(ctx, "listings", listingID, update)
+}
@@ -0,0 +1,210 @@
+package indexing_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/indexing"
+	"indexer/internal/mocks/mockrepo"
+	"indexer/internal/publicurl"
+	"indexer/internal/testutil/fixtures"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func backfillListings(n int) []repo.Listing {
+	listings := make([]repo.Listing, n)
+	for i := range listings {
+		listings[i] = fixtures.NewListing(fixtures.With(func(l *repo.Listing) {
+			l.ID = pgtype.UUID{Bytes: [16]byte{15: byte(i + 1)}, Valid: true}
+			l.ViewsCount = int32(100 * (i + 1))
+		}))
+	}
+	return listings
+}
+
+func documentID(listing repo.Listing) string {
+	return fmt.Sprintf("%x", listing.ID.Bytes)
+}
+
+func TestBackfill_UpdatesOnlyRequestedFields(t *testing.T) {
+	// SCENARIO: views_count was added to the schema after two listings were indexed, a third was never indexed.
+	// EXPECT: The indexed documents get views_count and nothing else changes, even fields that are out of date.
+
+	mockRepo := mockrepo.NewQuerier(t)
+	fakeIndexer := indexing.NewInMemoryIndexer().(*indexing.InMemoryIndexer)
+	fakeIndexer.SetFields("listings", "id", "title", "views_count", "file_formats")
+	svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	listings := backfillListings(3)
+	for _, listing := range listings[:2] {
+		require.NoError(t, fakeIndexer.Upsert(context.Background(), "listings", map[string]any{
+			"id":    documentID(listing),
+			"title": "Old title",
+		}))
+	}
+
+	mockRepo.EXPECT().GetListingsForBackfill(mock.Anything, repo.GetListingsForBackfillParams{AfterID: pgtype.UUID{Valid: true}, BatchSize: 2}).
+		Return(listings[:2], nil).Once()
+	mockRepo.EXPECT().GetListingsForBackfill(mock.Anything, repo.GetListingsForBackfillParams{AfterID: listings[1].ID, BatchSize: 2}).
+		Return(listings[2:], nil).Once()
+	mockRepo.EXPECT().GetListingsForBackfill(mock.Anything, repo.GetListingsForBackfillParams{AfterID: listings[2].ID, BatchSize: 2}).
+		Return([]repo.Listing{}, nil).Once()
+
+	var checkpoints []pgtype.UUID
+	progress, err := svc.Backfill(context.Background(), indexing.BackfillOptions{
+		Fields:    []string{"views_count"},
+		BatchSize: 2,
+		Progress:  func(p indexing.BackfillProgress) { checkpoints = append(checkpoints, p.LastID) },
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, indexing.BackfillProgress{LastID: listings[2].ID, Scanned: 3, Updated: 2, Skipped: 1}, progress)
+	assert.Equal(t, []pgtype.UUID{listings[1].ID, listings[2].ID}, checkpoints)
+
+	for _, listing := range listings[:2] {
+		doc, found, _ := fakeIndexer.Get(context.Background(), "listings", documentID(listing))
+		require.True(t, found)
+		assert.Equal(t, map[string]any{
+			"id":          documentID(listing),
+			"title":       "Old title", // Not requested, so left stale
+			"views_count": listing.ViewsCount,
+		}, doc)
+	}
+
+	// A backfill never creates documents
+	_, found, _ := fakeIndexer.Get(context.Background(), "listings", documentID(listings[2]))
+	assert.False(t, found)
+}
+
+func TestBackfill_ResumesAfterCheckpoint(t *testing.T) {
+	mockRepo := mockrepo.NewQuerier(t)
+	fakeIndexer := indexing.NewInMemoryIndexer().(*indexing.InMemoryIndexer)
+	fakeIndexer.SetFields("listings", "id", "views_count")
+	svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	listings := backfillListings(3)
+	require.NoError(t, fakeIndexer.Upsert(context.Background(), "listings", map[string]any{"id": documentID(listings[2])}))
+
+	// Pages before the checkpoint are never fetched again
+	mockRepo.EXPECT().GetListingsForBackfill(mock.Anything, repo.GetListingsForBackfillParams{AfterID: listings[1].ID, BatchSize: 50}).
+		Return(listings[2:], nil).Once()
+	mockRepo.EXPECT().GetListingsForBackfill(mock.Anything, repo.GetListingsForBackfillParams{AfterID: listings[2].ID, BatchSize: 50}).
+		Return([]repo.Listing{}, nil).Once()
+
+	progress, err := svc.Backfill(context.Background(), indexing.BackfillOptions{
+		Fields:    []string{"views_count"},
+		BatchSize: 50,
+		After:     listings[1].ID,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, progress.Updated)
+
+	doc, _, _ := fakeIndexer.Get(context.Background(), "listings", documentID(listings[2]))
+	assert.Equal(t, listings[2].ViewsCount, doc.(map[string]any)["views_count"])
+}
+
+func TestBackfill_Refuses(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  []string
+		fields  []string
+		wantErr string
+	}{
+		{
+			name:    "Field missing from the live schema",
+			schema:  []string{"id", "title", "views_count"},
+			fields:  []string{"views_count", "print_time_minutes", "slug"},
+			wantErr: "fields not in the listings collection schema, add them before backfilling: print_time_minutes, slug",
+		},
+		{
+			name:    "No fields",
+			schema:  []string{"id", "title"},
+			wantErr: "no fields to backfill",
+		},
+		{
+			name:    "Document ID",
+			schema:  []string{"id", "title"},
+			fields:  []string{"id"},
+			wantErr: "the id field can't be backfilled",
+		},
+		{
+			name:    "Collection doesn't exist",
+			fields:  []string{"views_count"},
+			wantErr: indexing.ErrNotFound.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// No expectations, the backfill must stop before touching the database
+			mockRepo := mockrepo.NewQuerier(t)
+			fakeIndexer := indexing.NewInMemoryIndexer().(*indexing.InMemoryIndexer)
+			if tt.schema != nil {
+				fakeIndexer.SetFields("listings", tt.schema...)
+			}
+			svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+			_, err := svc.Backfill(context.Background(), indexing.BackfillOptions{Fields: tt.fields, BatchSize: 10})
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestBackfill_StopsOnFieldTheIndexerDoesNotCompute(t *testing.T) {
+	// SCENARIO: The schema has a field the worker doesn't produce yet (e.g. embeddings).
+	// EXPECT: The backfill stops at the first listing instead of failing every one of them.
+
+	mockRepo := mockrepo.NewQuerier(t)
+	fakeIndexer := indexing.NewInMemoryIndexer().(*indexing.InMemoryIndexer)
+	fakeIndexer.SetFields("listings", "id", "embedding")
+	svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	listings := backfillListings(2)
+	require.NoError(t, fakeIndexer.Upsert(context.Background(), "listings", map[string]any{"id": documentID(listings[0])}))
+	mockRepo.EXPECT().GetListingsForBackfill(mock.Anything, mock.Anything).Return(listings, nil).Once()
+
+	progress, err := svc.Backfill(context.Background(), indexing.BackfillOptions{Fields: []string{"embedding"}, BatchSize: 10})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "embedding")
+	assert.Equal(t, 1, progress.Scanned)
+	assert.Equal(t, 0, progress.Updated)
+}
+
+func TestBackfill_FileFields(t *testing.T) {
+	// SCENARIO: file_formats is backfilled onto a listing indexed while the worker still wrote ["stl"] for everything.
+	// EXPECT: The formats are read from the listing's validated files.
+
+	mockRepo := mockrepo.NewQuerier(t)
+	fakeIndexer := indexing.NewInMemoryIndexer().(*indexing.InMemoryIndexer)
+	fakeIndexer.SetFields("listings", "id", "file_formats")
+	svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	listings := backfillListings(1)
+	require.NoError(t, fakeIndexer.Upsert(context.Background(), "listings", map[string]any{
+		"id":           documentID(listings[0]),
+		"file_formats": []string{"stl"},
+	}))
+	mockRepo.EXPECT().GetListingsForBackfill(mock.Anything, mock.Anything).Return(listings, nil).Once()
+	mockRepo.EXPECT().GetListingsForBackfill(mock.Anything, mock.Anything).Return([]repo.Listing{}, nil).Once()
+	mockRepo.EXPECT().GetFilesByListingID(mock.Anything, listings[0].ID).Return([]repo.ListingFile{
+		{FilePath: "plate.3mf", FileType: repo.FileTypeMODEL, Status: repo.NullFileStatus{FileStatus: repo.FileStatusVALID, Valid: true}},
+	}, nil)
+
+	progress, err := svc.Backfill(context.Background(), indexing.BackfillOptions{Fields: []string{"file_formats"}, BatchSize: 10})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, progress.Updated)
+	doc, _, _ := fakeIndexer.Get(context.Background(), "listings", documentID(listings[0]))
+	assert.Equal(t, []string{"3mf"}, doc.(map[string]any)["file_formats"])
+}
@@ -0,0 +1,39 @@
+package indexing
+
+import (
+	"fmt"
+	repo "indexer/internal/database/postgresql/sqlc"
+	"shared/contenthash"
+)
+
+// contentHash is the document's content_hash, see shared/contenthash
+func contentHash(listing repo.Listing) string {
+	content := contenthash.Listing{
+		ID:             fmt.Sprintf("%x", listing.ID.Bytes),
+		Status:         string(listing.Status.ListingStatus),
+		Title:          listing.Title,
+		Description:    listing.Description.String,
+		PriceMinUnit:   listing.PriceMinUnit,
+		Currency:       listing.Currency,
+		Categories:     listing.Categories,
+		License:        listing.License,
+		ThumbnailPath:  listing.ThumbnailPath.String,
+		IsSaleActive:   listing.IsSaleActive,
+		SaleName:       listing.SaleName.String,
+		SellerName:     publicSellerName(listing.SellerName, listing.SellerUsername),
+		SellerUsername: listing.SellerUsername,
+		SellerVerified: listing.SellerVerified,
+		IsNSFW:         listing.IsNsfw,
+		IsAIGenerated:  listing.IsAiGenerated,
+		IsPhysical:     listing.IsPhysical,
+	}
+	if listing.SalePrice.Valid {
+		content.SalePrice = &listing.SalePrice.Int64
+	}
+	if listing.SaleEndTimestamp.Valid {
+		end := listing.SaleEndTimestamp.Time.Unix()
+		content.SaleEnd = &end
+	}
+
+	return content.Sum()
+}
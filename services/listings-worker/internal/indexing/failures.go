@@ -0,0 +1,117 @@
+package indexing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/events"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Error classes a failed index is reported with, so an outage can be told apart from a listing that will never index
+const (
+	ErrorClassDatabase = "database" // Reading the row or recording that it was indexed
+	ErrorClassDocument = "document" // The row couldn't be turned into a document, retrying won't help
+	ErrorClassSearch   = "search"   // Typesense refused or didn't answer the write
+	ErrorClassEvent    = "event"    // The event itself couldn't be handled, e.g. it couldn't be decrypted
+)
+
+// reportTimeout bounds the seller lookup and publish for one failure, they run on the consumer's goroutine
+const reportTimeout = 5 * time.Second
+
+// IndexError is an indexing failure tagged with the part of the pipeline that failed
+type IndexError struct {
+	Class string
+	Err   error
+}
+
+func (e *IndexError) Error() string { return e.Err.Error() }
+func (e *IndexError) Unwrap() error { return e.Err }
+
+// classified tags err with class, unless a source already tagged it more precisely
+func classified(class string, err error) error {
+	var indexErr *IndexError
+	if err == nil || errors.As(err, &indexErr) {
+		return err
+	}
+	return &IndexError{Class: class, Err: err}
+}
+
+// ErrorClass is the class err was tagged with. Anything that failed before indexing started is ErrorClassEvent.
+func ErrorClass(err error) string {
+	var indexErr *IndexError
+	if errors.As(err, &indexErr) {
+		return indexErr.Class
+	}
+	return ErrorClassEvent
+}
+
+// FailurePublisher is where reported failures go, events.EventWriter implements it
+type FailurePublisher interface {
+	PublishListingIndexFailed(evt events.ListingIndexFailedEvent, msgID string) error
+}
+
+// FailureReporter tells the gateway about listings the worker gave up indexing, which would otherwise just never
+// show up in search
+type FailureReporter struct {
+	repo      repo.Querier
+	publisher FailurePublisher // Nil only counts and logs failures
+	logger    *slog.Logger
+	now       func() time.Time
+}
+
+func NewFailureReporter(repo repo.Querier, publisher FailurePublisher, logger *slog.Logger) *FailureReporter {
+	return &FailureReporter{
+		repo:      repo,
+		publisher: publisher,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// Report handles an index event that was dead lettered after attempts deliveries. Only listings are reported to the
+// gateway, other entity types have nobody to tell.
+func (f *FailureReporter) Report(evt events.IndexEvent, attempts int, cause error) {
+	class := ErrorClass(cause)
+	indexFailuresTotal.WithLabelValues(evt.EntityType, class).Inc()
+	f.logger.Error("Gave up indexing", "entity_type", evt.EntityType, "id", evt.EntityID, "error_class", class, "attempts", attempts, "error", cause)
+
+	if evt.EntityType != EntityListing || f.publisher == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+	defer cancel()
+
+	failure := events.ListingIndexFailedEvent{
+		ListingID:  evt.EntityID,
+		SellerID:   f.sellerID(ctx, evt.EntityID),
+		ErrorClass: class,
+		Error:      cause.Error(),
+		Attempts:   attempts,
+		FailedAt:   f.now().UTC().Format(time.RFC3339Nano),
+	}
+	// Each dead letter is its own failure, a replay that fails again is reported again
+	msgID := fmt.Sprintf("index-failed.%s.%s", failure.ListingID, failure.FailedAt)
+	if err := f.publisher.PublishListingIndexFailed(failure, msgID); err != nil {
+		f.logger.Error("Failed to report listing index failure", "listing_id", failure.ListingID, "error", err)
+	}
+}
+
+// sellerID is best effort, the database may well be why indexing failed and the gateway only needs the listing ID
+func (f *FailureReporter) sellerID(ctx context.Context, listingID string) string {
+	var listingUUID pgtype.UUID
+	if err := listingUUID.Scan(listingID); err != nil {
+		return ""
+	}
+	listing, err := f.repo.GetListingByID(ctx, listingUUID)
+	if err != nil {
+		f.logger.Warn("Failed to look up the seller of a listing that failed to index", "listing_id", listingID, "error", err)
+		return ""
+	}
+	return listing.SellerID.String()
+}
@@ -0,0 +1,104 @@
+package indexing_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/events"
+	"indexer/internal/indexing"
+	"indexer/internal/mocks/mockrepo"
+	"indexer/internal/testutil/fixtures"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFailurePublisher keeps what was published
+type fakeFailurePublisher struct {
+	published []events.ListingIndexFailedEvent
+	msgIDs    []string
+}
+
+func (f *fakeFailurePublisher) PublishListingIndexFailed(evt events.ListingIndexFailedEvent, msgID string) error {
+	f.published = append(f.published, evt)
+	f.msgIDs = append(f.msgIDs, msgID)
+	return nil
+}
+
+func TestFailureReporter_Report(t *testing.T) {
+	const listingID = "550e8400e29b41d4a716446655440000"
+	var listingUUID pgtype.UUID
+	require.NoError(t, listingUUID.Scan(listingID))
+	searchDown := &indexing.IndexError{Class: indexing.ErrorClassSearch, Err: errors.New("503 service unavailable")}
+
+	t.Run("Listing is reported with its seller", func(t *testing.T) {
+		mockRepo := mockrepo.NewQuerier(t)
+		publisher := &fakeFailurePublisher{}
+		reporter := indexing.NewFailureReporter(mockRepo, publisher, slog.Default())
+
+		mockRepo.EXPECT().GetListingByID(mock.Anything, listingUUID).Return(fixtures.NewListing(fixtures.WithID(listingID), fixtures.WithSeller(fixtures.SellerID)), nil)
+
+		reporter.Report(events.IndexEvent{EntityType: indexing.EntityListing, EntityID: listingID}, 5, searchDown)
+
+		require.Len(t, publisher.published, 1)
+		got := publisher.published[0]
+		assert.Equal(t, listingID, got.ListingID)
+		assert.Equal(t, "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", got.SellerID)
+		assert.Equal(t, indexing.ErrorClassSearch, got.ErrorClass)
+		assert.Equal(t, "503 service unavailable", got.Error)
+		assert.Equal(t, 5, got.Attempts)
+		_, err := time.Parse(time.RFC3339Nano, got.FailedAt)
+		assert.NoError(t, err)
+		assert.Equal(t, "index-failed."+listingID+"."+got.FailedAt, publisher.msgIDs[0])
+	})
+
+	t.Run("Reported without a seller when the database is down", func(t *testing.T) {
+		mockRepo := mockrepo.NewQuerier(t)
+		publisher := &fakeFailurePublisher{}
+		reporter := indexing.NewFailureReporter(mockRepo, publisher, slog.Default())
+
+		mockRepo.EXPECT().GetListingByID(mock.Anything, listingUUID).Return(repo.Listing{}, errors.New("connection refused"))
+
+		reporter.Report(events.IndexEvent{EntityType: indexing.EntityListing, EntityID: listingID}, 5, errors.New("cipher: message authentication failed"))
+
+		require.Len(t, publisher.published, 1)
+		assert.Empty(t, publisher.published[0].SellerID)
+		assert.Equal(t, indexing.ErrorClassEvent, publisher.published[0].ErrorClass)
+	})
+
+	t.Run("Other entity types are only counted", func(t *testing.T) {
+		publisher := &fakeFailurePublisher{}
+		reporter := indexing.NewFailureReporter(mockrepo.NewQuerier(t), publisher, slog.Default())
+
+		reporter.Report(events.IndexEvent{EntityType: indexing.EntitySeller, EntityID: "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"}, 5, searchDown)
+
+		assert.Empty(t, publisher.published)
+	})
+}
+
+func TestMarkListingAsIndexed_ClearsIndexFailure(t *testing.T) {
+	// SCENARIO: A listing that was reported as failed is indexed later, e.g. after a redrive.
+	// EXPECT: The same statement that stamps last_indexed_at deletes the failure, so the gateway stops showing
+	// index_error. A failure event that arrives after this is ignored there, see TestRecordIndexFailure.
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	var listingUUID pgtype.UUID
+	require.NoError(t, listingUUID.Scan("550e8400e29b41d4a716446655440000"))
+
+	mockPool.ExpectExec(`DELETE FROM listing_index_failures WHERE listing_id = \$1 \) UPDATE listings`).
+		WithArgs(listingUUID).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	assert.NoError(t, repo.New(mockPool).MarkListingAsIndexed(context.Background(), listingUUID))
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -0,0 +1,88 @@
+package indexing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	repo "indexer/internal/database/postgresql/sqlc"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// featured is true while any of the listing's windows runs, they can overlap
+func featured(windows []repo.GetFeatureWindowsRow, now time.Time) bool {
+	for _, window := range windows {
+		if !window.StartsAt.Time.After(now) && window.EndsAt.Time.After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFeatured is the listing's is_featured. SyncFeatures patches it as windows start and end.
+func (l *ListingSource) isFeatured(ctx context.Context, listingID pgtype.UUID) (bool, error) {
+	windows, err := l.repo.GetFeatureWindows(ctx, listingID)
+	if err != nil {
+		return false, err
+	}
+	return featured(windows, time.Now()), nil
+}
+
+// SyncFeatures patches is_featured onto the documents of listings whose featured windows started or ended since it
+// was last set, nothing on the listing changes when they do. Only the flag is sent, the rest of the document is left
+// as it is. A listing that isn't indexed picks the flag up when it is. A listing is marked as done only once its
+// document has been patched, a failure leaves it for the next pass. Returns how many listings were brought up to date.
+func (s *svc) SyncFeatures(ctx context.Context, batchSize int) (int, error) {
+	// Taken once, so every listing is worked out and marked at the same instant and a boundary crossed part way
+	// through is picked up on the next pass
+	now := time.Now()
+	at := pgtype.Timestamptz{Time: now, Valid: true}
+
+	ids, err := s.reader.GetListingsWithFeatureChanges(ctx, repo.GetListingsWithFeatureChangesParams{
+		Now:       at,
+		BatchSize: int32(batchSize),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch listings with feature changes: %w", err)
+	}
+
+	synced := 0
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return synced, err
+		}
+		// Same dashless format the gateway publishes, so we patch the existing document
+		listingID := fmt.Sprintf("%x", id.Bytes)
+		if err := s.syncFeature(ctx, id, listingID, now); err != nil {
+			s.logger.Error("Failed to sync featured listing", "error", err, "listing_id", listingID)
+			continue
+		}
+		if err := s.repo.SetFeaturedListingsApplied(ctx, repo.SetFeaturedListingsAppliedParams{Now: at, ListingID: id}); err != nil {
+			s.logger.Error("Failed to mark featured listing as applied", "error", err, "listing_id", listingID)
+			continue
+		}
+		synced++
+	}
+
+	if synced > 0 {
+		s.logger.Info("Featured sync complete", "synced", synced, "listings", len(ids))
+	}
+	return synced, nil
+}
+
+// syncFeature patches the listing's is_featured for now. Read from the primary, an admin may have just moved a window.
+func (s *svc) syncFeature(ctx context.Context, id pgtype.UUID, listingID string, now time.Time) error {
+	windows, err := s.repo.GetFeatureWindows(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to fetch feature windows: %w", err)
+	}
+
+	err = s.indexer.Update(ctx, "listings", listingID, map[string]any{"is_featured": featured(windows, now)})
+	if errors.Is(err, ErrNotFound) {
+		// Not searchable, the full document works the flag out when it is indexed
+		s.logger.Debug("Listing not indexed, skipping is_featured", "listing_id", listingID)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,188 @@
+package indexing_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/indexing"
+	"indexer/internal/mocks/mockrepo"
+	"indexer/internal/publicurl"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// featureWindow runs from start to end, both relative to now
+func featureWindow(start, end time.Duration) repo.GetFeatureWindowsRow {
+	return repo.GetFeatureWindowsRow{
+		StartsAt: pgtype.Timestamptz{Time: time.Now().Add(start), Valid: true},
+		EndsAt:   pgtype.Timestamptz{Time: time.Now().Add(end), Valid: true},
+	}
+}
+
+// indexedAs puts a listing document with the given is_featured into the index
+func indexedAs(t *testing.T, indexer indexing.Indexer, id pgtype.UUID, isFeatured bool) string {
+	t.Helper()
+	listingID := fmt.Sprintf("%x", id.Bytes)
+	require.NoError(t, indexer.Upsert(context.Background(), "listings", map[string]any{"id": listingID, "title": "Benchy", "is_featured": isFeatured}))
+	return listingID
+}
+
+func isFeatured(t *testing.T, indexer indexing.Indexer, listingID string) any {
+	t.Helper()
+	doc, found, err := indexer.Get(context.Background(), "listings", listingID)
+	require.NoError(t, err)
+	require.True(t, found)
+	return doc.(map[string]any)["is_featured"]
+}
+
+func TestIndexListing_IsFeatured(t *testing.T) {
+	tests := map[string]struct {
+		windows []repo.GetFeatureWindowsRow
+		want    bool
+	}{
+		"never featured": {},
+		"running":        {windows: []repo.GetFeatureWindowsRow{featureWindow(-time.Hour, time.Hour)}, want: true},
+		"upcoming":       {windows: []repo.GetFeatureWindowsRow{featureWindow(time.Hour, 2*time.Hour)}},
+		"between windows": {windows: []repo.GetFeatureWindowsRow{
+			featureWindow(-2*time.Hour, -time.Hour),
+			featureWindow(time.Hour, 2*time.Hour),
+		}},
+		"overlapping": {windows: []repo.GetFeatureWindowsRow{
+			featureWindow(-2*time.Hour, time.Hour),
+			featureWindow(-time.Hour, 2*time.Hour),
+		}, want: true},
+		"one of several running": {windows: []repo.GetFeatureWindowsRow{
+			featureWindow(time.Hour, 2*time.Hour),
+			featureWindow(-time.Minute, time.Minute),
+		}, want: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockRepo := mockrepo.NewQuerier(t)
+			fakeIndexer := indexing.NewInMemoryIndexer()
+			svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+			listingID := pgtype.UUID{Bytes: [16]byte{15: 1}, Valid: true}
+			sellerID := pgtype.UUID{Bytes: [16]byte{0: 1}, Valid: true}
+			mockRepo.EXPECT().GetListingByID(mock.Anything, listingID).Return(vacationListing(listingID, sellerID), nil)
+			mockRepo.EXPECT().GetSellerVacation(mock.Anything, sellerID).Return(repo.GetSellerVacationRow{}, nil)
+			mockRepo.EXPECT().GetLatestPriceChange(mock.Anything, listingID).Return(repo.ListingPriceHistory{}, pgx.ErrNoRows)
+			mockRepo.EXPECT().GetFeatureWindows(mock.Anything, listingID).Return(tt.windows, nil)
+			mockRepo.EXPECT().GetFilesByListingID(mock.Anything, listingID).Return(nil, nil)
+			mockRepo.EXPECT().GetCheapestVariantPrice(mock.Anything, listingID).Return(0, pgx.ErrNoRows)
+			mockRepo.EXPECT().GetSellerLastActive(mock.Anything, mock.Anything).Return(pgtype.Timestamptz{}, nil)
+			mockRepo.EXPECT().MarkListingAsIndexed(mock.Anything, listingID).Return(nil)
+
+			id := fmt.Sprintf("%x", listingID.Bytes)
+			require.NoError(t, svc.IndexListing(context.Background(), id))
+
+			assert.Equal(t, tt.want, isFeatured(t, fakeIndexer, id))
+		})
+	}
+}
+
+func TestSyncFeatures_Transitions(t *testing.T) {
+	// SCENARIO: One listing's window has started, another's only window has ended, and a third's first window has
+	// ended while an overlapping one still runs.
+	// EXPECT: The first is flagged, the second cleared and the third stays flagged. Only is_featured is patched, and
+	// each listing is marked applied at the instant the listings were looked up at.
+
+	mockRepo := mockrepo.NewQuerier(t)
+	fakeIndexer := indexing.NewInMemoryIndexer()
+	svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	started := pgtype.UUID{Bytes: [16]byte{15: 1}, Valid: true}
+	expired := pgtype.UUID{Bytes: [16]byte{15: 2}, Valid: true}
+	overlapping := pgtype.UUID{Bytes: [16]byte{15: 3}, Valid: true}
+	startedID := indexedAs(t, fakeIndexer, started, false)
+	expiredID := indexedAs(t, fakeIndexer, expired, true)
+	overlappingID := indexedAs(t, fakeIndexer, overlapping, true)
+
+	var now pgtype.Timestamptz
+	mockRepo.EXPECT().GetListingsWithFeatureChanges(mock.Anything, mock.MatchedBy(func(arg repo.GetListingsWithFeatureChangesParams) bool {
+		now = arg.Now
+		return arg.BatchSize == 50
+	})).Return([]pgtype.UUID{started, expired, overlapping}, nil)
+	mockRepo.EXPECT().GetFeatureWindows(mock.Anything, started).Return([]repo.GetFeatureWindowsRow{featureWindow(-time.Minute, time.Hour)}, nil)
+	// Ended windows aren't returned
+	mockRepo.EXPECT().GetFeatureWindows(mock.Anything, expired).Return(nil, nil)
+	mockRepo.EXPECT().GetFeatureWindows(mock.Anything, overlapping).Return([]repo.GetFeatureWindowsRow{featureWindow(-time.Hour, time.Hour)}, nil)
+	applied := map[pgtype.UUID]pgtype.Timestamptz{}
+	mockRepo.EXPECT().SetFeaturedListingsApplied(mock.Anything, mock.Anything).
+		Run(func(_ context.Context, arg repo.SetFeaturedListingsAppliedParams) { applied[arg.ListingID] = arg.Now }).
+		Return(nil)
+
+	synced, err := svc.SyncFeatures(context.Background(), 50)
+	require.NoError(t, err)
+	assert.Equal(t, 3, synced)
+
+	assert.Equal(t, true, isFeatured(t, fakeIndexer, startedID))
+	assert.Equal(t, false, isFeatured(t, fakeIndexer, expiredID))
+	assert.Equal(t, true, isFeatured(t, fakeIndexer, overlappingID))
+	doc, _, _ := fakeIndexer.Get(context.Background(), "listings", startedID)
+	assert.Equal(t, "Benchy", doc.(map[string]any)["title"])
+
+	require.True(t, now.Valid)
+	assert.Equal(t, map[pgtype.UUID]pgtype.Timestamptz{started: now, expired: now, overlapping: now}, applied)
+}
+
+func TestSyncFeatures_NotIndexed(t *testing.T) {
+	// SCENARIO: A featured listing has no search document, e.g. it was unpublished.
+	// EXPECT: Nothing is patched and the window is still marked applied, the full document works the flag out.
+
+	mockRepo := mockrepo.NewQuerier(t)
+	fakeIndexer := indexing.NewInMemoryIndexer()
+	svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	id := pgtype.UUID{Bytes: [16]byte{15: 1}, Valid: true}
+	mockRepo.EXPECT().GetListingsWithFeatureChanges(mock.Anything, mock.Anything).Return([]pgtype.UUID{id}, nil)
+	mockRepo.EXPECT().GetFeatureWindows(mock.Anything, id).Return([]repo.GetFeatureWindowsRow{featureWindow(-time.Minute, time.Hour)}, nil)
+	mockRepo.EXPECT().SetFeaturedListingsApplied(mock.Anything, mock.MatchedBy(func(arg repo.SetFeaturedListingsAppliedParams) bool {
+		return arg.ListingID == id
+	})).Return(nil)
+
+	synced, err := svc.SyncFeatures(context.Background(), 50)
+	require.NoError(t, err)
+	assert.Equal(t, 1, synced)
+
+	_, found, _ := fakeIndexer.Get(context.Background(), "listings", fmt.Sprintf("%x", id.Bytes))
+	assert.False(t, found)
+}
+
+func TestSyncFeatures_FailureLeftForNextPass(t *testing.T) {
+	// SCENARIO: The windows of one of two listings can't be read.
+	// EXPECT: That listing is neither patched nor marked applied, so the next pass retries it. The other is synced.
+
+	mockRepo := mockrepo.NewQuerier(t)
+	fakeIndexer := indexing.NewInMemoryIndexer()
+	svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	failing := pgtype.UUID{Bytes: [16]byte{15: 1}, Valid: true}
+	started := pgtype.UUID{Bytes: [16]byte{15: 2}, Valid: true}
+	failingID := indexedAs(t, fakeIndexer, failing, false)
+	startedID := indexedAs(t, fakeIndexer, started, false)
+
+	mockRepo.EXPECT().GetListingsWithFeatureChanges(mock.Anything, mock.Anything).Return([]pgtype.UUID{failing, started}, nil)
+	mockRepo.EXPECT().GetFeatureWindows(mock.Anything, failing).Return(nil, errors.New("connection refused"))
+	mockRepo.EXPECT().GetFeatureWindows(mock.Anything, started).Return([]repo.GetFeatureWindowsRow{featureWindow(-time.Minute, time.Hour)}, nil)
+	mockRepo.EXPECT().SetFeaturedListingsApplied(mock.Anything, mock.MatchedBy(func(arg repo.SetFeaturedListingsAppliedParams) bool {
+		return arg.ListingID == started
+	})).Return(nil).Once()
+
+	synced, err := svc.SyncFeatures(context.Background(), 50)
+	require.NoError(t, err)
+	assert.Equal(t, 1, synced)
+
+	assert.Equal(t, false, isFeatured(t, fakeIndexer, failingID))
+	assert.Equal(t, true, isFeatured(t, fakeIndexer, startedID))
+}
@@ -6,19 +6,22 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
 )
 
 // InMemoryIndexer is a thread-safe Fake for testing.
 // It stores documents in a map: store[collectionName][documentID] = document
 type InMemoryIndexer struct {
-	mu    sync.RWMutex
-	store map[string]map[string]any
+	mu      sync.RWMutex
+	store   map[string]map[string]any
+	schemas map[string][]string // Set with SetFields, documents aren't checked against them
 }
 
 func NewInMemoryIndexer() Indexer {
 	return &InMemoryIndexer{
-		store: make(map[string]map[string]any),
+		store:   make(map[string]map[string]any),
+		schemas: make(map[string][]string),
 	}
 }
 
@@ -59,14 +62,39 @@ func (i *InMemoryIndexer) Upsert(ctx context.Context, collectionName string, doc
 	return nil
 }
 
+// Update merges fields into a stored map document, mirroring a Typesense partial update.
+func (i *InMemoryIndexer) Update(ctx context.Context, collectionName string, id string, fields map[string]any) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	doc, found := i.store[collectionName][id]
+	if !found {
+		return ErrNotFound
+	}
+
+	docMap, ok := doc.(map[string]any)
+	if !ok {
+		return fmt.Errorf("in-memory update failed: document %s is not a map", id)
+	}
+	for k, v := range fields {
+		docMap[k] = v
+	}
+	return nil
+}
+
 func (i *InMemoryIndexer) Delete(ctx context.Context, collectionName string, id string) error {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	if bucket, exists := i.store[collectionName]; exists {
-		delete(bucket, id)
+	bucket, exists := i.store[collectionName]
+	if !exists {
+		return ErrNotFound
 	}
-	// Most search engines don't error if you delete a non-existent ID, they just return success.
+	if _, found := bucket[id]; !found {
+		// Typesense answers 404 for a missing document, mirror that so callers handle it.
+		return ErrNotFound
+	}
+	delete(bucket, id)
 	return nil
 }
 
@@ -80,8 +108,40 @@ func (i *InMemoryIndexer) Count(ctx context.Context, collectionName string) (int
 	return 0, nil
 }
 
+// SearchIDs can't evaluate a query or filter, it returns every document in the collection by ID up to the limit.
+func (i *InMemoryIndexer) SearchIDs(ctx context.Context, collectionName string, query SearchQuery) ([]string, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	ids := make([]string, 0, len(i.store[collectionName]))
+	for id := range i.store[collectionName] {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids[:min(len(ids), max(query.Limit, 1), MaxSearchLimit)], nil
+}
+
+// Fields returns whatever SetFields was given for the collection.
+func (i *InMemoryIndexer) Fields(ctx context.Context, collectionName string) ([]string, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	fields, exists := i.schemas[collectionName]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return fields, nil
+}
+
 // --- Test Helper Methods (Not part of Indexer interface) ---
 
+// SetFields defines the schema Fields reports for a collection
+func (i *InMemoryIndexer) SetFields(collectionName string, fields ...string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.schemas[collectionName] = fields
+}
+
 // Get allows your tests to inspect the state of the index
 func (i *InMemoryIndexer) Get(ctx context.Context, collectionName string, id string) (any, bool, error) {
 	i.mu.RLock()
@@ -105,6 +165,7 @@ func (i *InMemoryIndexer) Clear() {
 	i.mu.Lock()
 	defer i.mu.Unlock()
 	i.store = make(map[string]map[string]any)
+	i.schemas = make(map[string][]string)
 }
 
 // --- Internal Helper ---
@@ -1,6 +1,24 @@
 package indexing
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned when a document (or collection) does not exist in the index.
+var ErrNotFound = errors.New("indexer: document not found")
+
+// MaxSearchLimit is the most results one search can return, Typesense's largest page
+const MaxSearchLimit = 250
+
+// SearchQuery is a full text search for Indexer.SearchIDs
+type SearchQuery struct {
+	Q        string // "*" matches every document
+	QueryBy  string // Comma separated fields Q is matched against
+	FilterBy string // Optional filter_by expression
+	SortBy   string // Optional, e.g. "created_at:desc"
+	Limit    int    // Capped at MaxSearchLimit
+}
 
 // Indexer defines the contract for any search engine we support.
 // This allows us to swap Typesense for Algolia/Elasticsearch later,
@@ -10,12 +28,21 @@ type Indexer interface {
 	// We use 'any' to allow flexibility, but you could restrict this to a specific interface.
 	Upsert(ctx context.Context, collectionName string, document any) error
 
-	// Delete removes a document by ID.
+	// Update merges fields into an existing document. Returns ErrNotFound if it is not indexed.
+	Update(ctx context.Context, collectionName string, id string, fields map[string]any) error
+
+	// Delete removes a document by ID. Returns ErrNotFound if it is not indexed.
 	Delete(ctx context.Context, collectionName string, id string) error
 
 	// Get retrieves a document by ID.
 	Get(ctx context.Context, collectionName string, id string) (any, bool, error)
 
+	// Fields lists the field names in the collection's live schema. Returns ErrNotFound if the collection doesn't exist.
+	Fields(ctx context.Context, collectionName string) ([]string, error)
+
+	// SearchIDs runs a search and returns the IDs of the matching documents in the query's sort order.
+	SearchIDs(ctx context.Context, collectionName string, query SearchQuery) ([]string, error)
+
 	// Count returns the number of documents in a collection.
 	Count(ctx context.Context, collectionName string) (int64, error)
 
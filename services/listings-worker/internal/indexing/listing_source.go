@@ -0,0 +1,323 @@
+package indexing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/publicurl"
+	"log/slog"
+	"maps"
+	"math"
+	"shared/materials"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ListingSource builds the documents in the listings collection
+type ListingSource struct {
+	repo   repo.Querier
+	logger *slog.Logger
+	urls   publicurl.Config
+}
+
+func NewListingSource(repo repo.Querier, logger *slog.Logger, urls publicurl.Config) *ListingSource {
+	return &ListingSource{
+		repo:   repo,
+		logger: logger,
+		urls:   urls,
+	}
+}
+
+func (l *ListingSource) Fetch(ctx context.Context, listingID string) (any, Action, error) {
+	l.logger.Info("Indexing listing", "listing_id", listingID)
+
+	var listingUUID pgtype.UUID
+	if err := listingUUID.Scan(listingID); err != nil {
+		// PERMANENT ERROR: This UUID will never be valid.
+		// Skip to Ack/Discard.
+		l.logger.Error("Invalid UUID format, discarding", "id", listingID)
+		return nil, ActionSkip, nil
+	}
+
+	// Fetch listing from DB
+	listing, err := l.repo.GetListingByID(ctx, listingUUID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Deleted (or never existed), make sure search doesn't keep serving it
+			l.logger.Info("Listing not found in DB, removing from index", "id", listingID)
+			return nil, ActionDelete, nil
+		}
+
+		l.logger.Error("Failed to fetch listing from DB", "error", err, "listing_id", listingID)
+		return nil, ActionSkip, err
+	}
+
+	// Only ACTIVE listings are searchable, this is how unpublishing and moderation take a listing out of search
+	if listing.Status.ListingStatus != repo.ListingStatusACTIVE {
+		l.logger.Info("Listing is not active, removing from index", "id", listingID, "status", listing.Status.ListingStatus)
+		return nil, ActionDelete, nil
+	}
+
+	if !listing.ThumbnailPath.Valid {
+		l.logger.Warn("Listing missing thumbnail URL, cannot index", "id", listingID)
+		return nil, ActionSkip, nil
+	}
+
+	document, err := l.Document(listingID, listing)
+	if err != nil {
+		l.logger.Error("Failed to build listing document", "error", err, "listing_id", listingID)
+		return nil, ActionSkip, &IndexError{Class: ErrorClassDocument, Err: err}
+	}
+
+	// A deleted parent 404s, so the remix stops linking to it. The gateway reindexes remixes when it deletes a parent.
+	if listing.ParentListingID.Valid {
+		live, err := l.repo.IsListingLive(ctx, listing.ParentListingID)
+		if err != nil {
+			l.logger.Error("Failed to check remix parent", "error", err, "listing_id", listingID)
+			return nil, ActionSkip, err
+		}
+		if !live {
+			document["parent_listing_id"] = nil
+		}
+	}
+
+	away, err := l.sellerOnVacation(ctx, listing.SellerID)
+	if err != nil {
+		l.logger.Error("Failed to fetch seller vacation", "error", err, "listing_id", listingID)
+		return nil, ActionSkip, err
+	}
+	document["seller_on_vacation"] = away
+
+	activity, err := l.sellerActivity(ctx, listing.SellerID)
+	if err != nil {
+		l.logger.Error("Failed to fetch seller activity", "error", err, "listing_id", listingID)
+		return nil, ActionSkip, err
+	}
+	document["seller_activity_bucket"] = activity
+
+	dropped, err := l.priceDroppedRecently(ctx, listingUUID)
+	if err != nil {
+		l.logger.Error("Failed to fetch latest price change", "error", err, "listing_id", listingID)
+		return nil, ActionSkip, err
+	}
+	document["price_dropped_recently"] = dropped
+
+	isFeatured, err := l.isFeatured(ctx, listingUUID)
+	if err != nil {
+		l.logger.Error("Failed to fetch feature windows", "error", err, "listing_id", listingID)
+		return nil, ActionSkip, err
+	}
+	document["is_featured"] = isFeatured
+
+	files, err := l.modelFiles(ctx, listingUUID)
+	if err != nil {
+		l.logger.Error("Failed to fetch listing files", "error", err, "listing_id", listingID)
+		return nil, ActionSkip, err
+	}
+	maps.Copy(document, files)
+
+	price, err := l.searchPrice(ctx, listing)
+	if err != nil {
+		l.logger.Error("Failed to fetch listing variants", "error", err, "listing_id", listingID)
+		return nil, ActionSkip, err
+	}
+	document["price_min_unit"] = price
+
+	return document, ActionUpsert, nil
+}
+
+// sellerOnVacation is true between the start and end of the seller's vacation. The vacation sync reindexes the
+// seller's listings at both ends, so the flag doesn't go stale.
+func (l *ListingSource) sellerOnVacation(ctx context.Context, sellerID pgtype.UUID) (bool, error) {
+	vacation, err := l.repo.GetSellerVacation(ctx, sellerID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// No seller profile, so never away
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	return vacation.VacationStartsAt.Valid && vacation.VacationEndsAt.Valid &&
+		!vacation.VacationStartsAt.Time.After(now) && vacation.VacationEndsAt.Time.After(now), nil
+}
+
+// MarkIndexed updates the indexed_at timestamp in the DB, the stale sweep relies on it
+func (l *ListingSource) MarkIndexed(ctx context.Context, listingID string) error {
+	var listingUUID pgtype.UUID
+	if err := listingUUID.Scan(listingID); err != nil {
+		return err
+	}
+
+	if err := l.repo.MarkListingAsIndexed(ctx, listingUUID); err != nil {
+		l.logger.Error("Failed to update listing indexed_at timestamp", "error", err, "listing_id", listingID)
+		return err
+	}
+	return nil
+}
+
+// Document builds the search document for a listing from its row, the modelFileFields are added from its files by
+// modelFiles. Backfills compute their fields from these too, so a field only ever has one definition.
+func (l *ListingSource) Document(listingID string, listing repo.Listing) (map[string]any, error) {
+	// Hashed before the thumbnail path becomes a URL, the gateway hashes the path
+	hash := contentHash(listing)
+
+	// Same URL the gateway serves, through the CDN when there is one. Changing the base URL needs a reindex to reach documents.
+	listing.ThumbnailPath.String = l.urls.Image(listing.ThumbnailPath.String)
+
+	// Size and weight are only indexed for physical listings that have them, so "fits my printer" range filters skip
+	// digital-only listings instead of matching them as 0mm in every direction
+	var dimX, dimY, dimZ *int
+	if listing.IsPhysical && len(listing.DimensionsMm) > 0 {
+		var listingDimensions ListingDimensionsJSON
+		if err := json.Unmarshal(listing.DimensionsMm, &listingDimensions); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal listing dimensions: %w", err)
+		}
+		if listingDimensions.Width > 0 && listingDimensions.Depth > 0 && listingDimensions.Height > 0 {
+			dimX, dimY, dimZ = &listingDimensions.Width, &listingDimensions.Depth, &listingDimensions.Height
+		}
+	}
+
+	return map[string]interface{}{
+		"id":            listingID,
+		"title":         listing.Title,
+		"description":   listing.Description,
+		"thumbnail_url": listing.ThumbnailPath.String,
+		"categories":    orEmpty(listing.Categories),
+		"license":       listing.License,
+		"language":      listing.Language, // "und" when the gateway couldn't tell, searches for any language include those
+
+		// TODO Properties
+		// "embedding":    []float32{}, // Empty for now
+
+		// Physical Properties
+		"is_physical": listing.IsPhysical,
+		"dim_x_mm":    dimX,
+		"dim_y_mm":    dimY,
+		"dim_z_mm":    dimZ,
+		"total_weight_grams": func() *int64 {
+			if listing.IsPhysical && listing.TotalWeightGrams.Valid {
+				weight := int64(listing.TotalWeightGrams.Int32)
+				return &weight
+			}
+			return nil
+		}(),
+
+		// Assembly
+		"is_assembly_required":  listing.IsAssemblyRequired,
+		"is_hardware_required":  listing.IsHardwareRequired,
+		"recommended_materials": orEmpty(materials.Normalize(listing.RecommendedMaterials)),
+		"is_multicolor":         listing.IsMulticolor,
+		"recommended_nozzle_temp_c": func() *int64 {
+			if listing.RecommendedNozzleTempC.Valid {
+				temp := int64(listing.RecommendedNozzleTempC.Int32)
+				return &temp
+			}
+			return nil
+		}(),
+		"nozzle_diameter_mm": func() *float64 {
+			diameter, err := listing.NozzleDiameterMm.Float64Value()
+			if err != nil || !diameter.Valid {
+				return nil
+			}
+			// NUMERIC(3,2), round off the float error so facets read 0.4 rather than 0.39999...
+			mm := math.Round(diameter.Float64*100) / 100
+			return &mm
+		}(),
+		"hardware_required": orEmpty(listing.HardwareRequired),
+
+		"is_nsfw": listing.IsNsfw,
+
+		// AI
+		"is_ai_generated": listing.IsAiGenerated,
+		"ai_model_name": func() *string {
+			if listing.AiModelName.Valid {
+				return &listing.AiModelName.String
+			}
+			return nil
+		}(),
+
+		// Remixing
+		"parent_listing_id": func() *string {
+			if listing.ParentListingID.Valid {
+				// Option A: Use the String() method if your pgx version supports it
+				s := fmt.Sprintf("%x-%x-%x-%x-%x",
+					listing.ParentListingID.Bytes[0:4],
+					listing.ParentListingID.Bytes[4:6],
+					listing.ParentListingID.Bytes[6:8],
+					listing.ParentListingID.Bytes[8:10],
+					listing.ParentListingID.Bytes[10:16])
+				return &s
+			}
+			return nil
+		}(),
+		"is_remix_allowed": listing.IsRemixingAllowed,
+
+		// Social Signals
+		"likes_count":     listing.LikesCount,
+		"downloads_count": listing.DownloadsCount,
+		"views_count":     listing.ViewsCount,
+		"comments_count":  listing.CommentsCount,
+
+		// Sales
+		"price_min_unit": listing.PriceMinUnit,
+		"sale_price": func() *int64 {
+			if listing.SalePrice.Valid {
+				return &listing.SalePrice.Int64
+			}
+			return nil
+		}(),
+		"sale_end_timestamp": func() *int64 {
+			if listing.SaleEndTimestamp.Valid {
+				timestamp := listing.SaleEndTimestamp.Time.Unix()
+				return &timestamp
+			}
+			return nil
+		}(),
+		"is_sale_active": listing.IsSaleActive,
+		"sale_name": func() *string {
+			if listing.SaleName.Valid {
+				return &listing.SaleName.String
+			}
+			return nil
+		}(),
+		"currency":        listing.Currency,
+		"seller_username": listing.SellerUsername,
+		"seller_name":     publicSellerName(listing.SellerName, listing.SellerUsername),
+		"seller_id":       listing.SellerID.String(),
+		"seller_verified": listing.SellerVerified,
+		// Unix seconds like sale_end_timestamp, so the document never depends on the zone pgx read the row in
+		"created_at": listing.CreatedAt.Time.Unix(),
+		"updated_at": listing.UpdatedAt.Time.Unix(),
+		// The gateway's POST /listings/hydrate serves its cached copy of the listing when this matches it
+		"content_hash": hash,
+	}, nil
+}
+
+// publicSellerName never lets an email address reach the search index, older rows used the email as the seller name.
+func publicSellerName(name, username string) string {
+	if strings.Contains(name, "@") {
+		return username
+	}
+	return name
+}
+
+type ListingDimensionsJSON struct {
+	Width  int `json:"width"`  // Maps to DimX
+	Depth  int `json:"depth"`  // Maps to DimY
+	Height int `json:"height"` // Maps to DimZ
+}
+
+// orEmpty sends NULL array columns as [], a null would otherwise show up as its own bucket in facet counts
+func orEmpty(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
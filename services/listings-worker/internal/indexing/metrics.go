@@ -0,0 +1,17 @@
+package indexing
+
+import (
+	"shared/typesensehttp"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	typesenseMetrics = typesensehttp.NewMetrics(prometheus.DefaultRegisterer, "listings_worker", []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5})
+
+	indexFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "listings_worker_index_failures_total",
+		Help: "Index events dead lettered after every retry, by entity type and error class. Alert on any increase.",
+	}, []string{"entity_type", "error_class"})
+)
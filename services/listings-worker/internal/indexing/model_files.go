@@ -0,0 +1,114 @@
+package indexing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	repo "indexer/internal/database/postgresql/sqlc"
+	"math"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// modelFileFields are the search fields read from the listing's files rather than the listing row
+var modelFileFields = []string{"total_model_size_bytes", "total_model_size_display", "file_formats", "is_manifold"}
+
+// fileScanMetadata is the part of listing_files.metadata search uses, the gateway's FileMetadata is the whole
+// whitelist. Older validation workers wrote is_watertight at the top level instead of under scan.
+type fileScanMetadata struct {
+	Scan *struct {
+		Watertight *bool `json:"watertight"`
+	} `json:"scan"`
+	IsWatertight *bool `json:"is_watertight"`
+}
+
+// modelFiles builds the modelFileFields from the model files that passed validation, the listing's download package.
+// The validation worker reindexes the listing once its files are checked, which is how these follow files being
+// added or removed.
+func (l *ListingSource) modelFiles(ctx context.Context, listingID pgtype.UUID) (map[string]any, error) {
+	files, err := l.repo.GetFilesByListingID(ctx, listingID)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	formats := []string{}
+	models, watertight, leaking := 0, 0, 0
+	for _, f := range files {
+		if f.FileType != repo.FileTypeMODEL || f.Status.FileStatus != repo.FileStatusVALID {
+			continue
+		}
+		models++
+
+		if f.FileSize.Valid {
+			total += f.FileSize.Int64
+		}
+		if format := strings.ToLower(strings.TrimPrefix(path.Ext(f.FilePath), ".")); format != "" && !slices.Contains(formats, format) {
+			formats = append(formats, format)
+		}
+
+		if closed, reported := l.watertight(f); reported && closed {
+			watertight++
+		} else if reported {
+			leaking++
+		}
+	}
+	slices.Sort(formats)
+
+	// One leaking model is enough to say no, saying yes needs every model checked
+	var manifold *bool
+	if leaking > 0 || (models > 0 && watertight == models) {
+		closed := leaking == 0
+		manifold = &closed
+	}
+
+	return map[string]any{
+		"total_model_size_bytes":   total,
+		"total_model_size_display": formatFileSize(total),
+		"file_formats":             formats,
+		"is_manifold":              manifold,
+	}, nil
+}
+
+// watertight is what the validation worker's mesh scan found, reported is false when it didn't say. Metadata that
+// doesn't parse is treated as missing, it shouldn't keep the listing out of search.
+func (l *ListingSource) watertight(f repo.ListingFile) (watertight, reported bool) {
+	if len(f.Metadata) == 0 {
+		return false, false
+	}
+
+	var meta fileScanMetadata
+	if err := json.Unmarshal(f.Metadata, &meta); err != nil {
+		l.logger.Warn("Ignoring file metadata that doesn't parse", "error", err, "file_id", f.ID.String())
+		return false, false
+	}
+
+	found := meta.IsWatertight
+	if meta.Scan != nil && meta.Scan.Watertight != nil {
+		found = meta.Scan.Watertight
+	}
+	if found == nil {
+		return false, false
+	}
+	return *found, true
+}
+
+var fileSizeUnits = []string{"KB", "MB", "GB", "TB"}
+
+// formatFileSize is the gateway's total_model_size_display, so a search hit shows the same size as the listing page
+func formatFileSize(bytes int64) string {
+	if bytes < 1024 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value, unit := float64(bytes), ""
+	for _, unit = range fileSizeUnits {
+		value /= 1024
+		if math.Round(value*10) < 1024*10 {
+			break
+		}
+	}
+	return fmt.Sprintf("%.1f %s", value, unit)
+}
@@ -0,0 +1,184 @@
+package indexing_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/indexing"
+	"indexer/internal/mocks/mockrepo"
+	"indexer/internal/publicurl"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func listingFile(fileType repo.FileType, status repo.FileStatus, size int64) repo.ListingFile {
+	return repo.ListingFile{
+		FileType: fileType,
+		Status:   repo.NullFileStatus{FileStatus: status, Valid: true},
+		FileSize: pgtype.Int8{Int64: size, Valid: true},
+	}
+}
+
+// indexWithFiles indexes a listing with the given files and returns its document
+func indexWithFiles(t *testing.T, files []repo.ListingFile) map[string]any {
+	t.Helper()
+	return indexWith(t, files, 0, pgx.ErrNoRows)
+}
+
+// indexWith is indexWithFiles for a listing whose cheapest variant lookup returns variantPrice and variantErr
+func indexWith(t *testing.T, files []repo.ListingFile, variantPrice int64, variantErr error) map[string]any {
+	t.Helper()
+	mockRepo := mockrepo.NewQuerier(t)
+	fakeIndexer := indexing.NewInMemoryIndexer()
+	svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	listingID := pgtype.UUID{Bytes: [16]byte{15: 1}, Valid: true}
+	sellerID := pgtype.UUID{Bytes: [16]byte{0: 1}, Valid: true}
+	mockRepo.EXPECT().GetListingByID(mock.Anything, listingID).Return(vacationListing(listingID, sellerID), nil)
+	mockRepo.EXPECT().GetSellerVacation(mock.Anything, sellerID).Return(repo.GetSellerVacationRow{}, nil)
+	mockRepo.EXPECT().GetLatestPriceChange(mock.Anything, listingID).Return(repo.ListingPriceHistory{}, pgx.ErrNoRows)
+	mockRepo.EXPECT().GetFeatureWindows(mock.Anything, listingID).Return(nil, nil)
+	mockRepo.EXPECT().GetFilesByListingID(mock.Anything, listingID).Return(files, nil)
+	mockRepo.EXPECT().GetCheapestVariantPrice(mock.Anything, listingID).Return(variantPrice, variantErr)
+	mockRepo.EXPECT().GetSellerLastActive(mock.Anything, mock.Anything).Return(pgtype.Timestamptz{}, nil)
+	mockRepo.EXPECT().MarkListingAsIndexed(mock.Anything, listingID).Return(nil)
+
+	id := fmt.Sprintf("%x", listingID.Bytes)
+	require.NoError(t, svc.IndexListing(context.Background(), id))
+
+	doc, found, _ := fakeIndexer.Get(context.Background(), "listings", id)
+	require.True(t, found)
+	return doc.(map[string]any)
+}
+
+func TestIndexListing_TotalModelSize(t *testing.T) {
+	// SCENARIO: A listing with two validated models, one still validating, one rejected and a validated image.
+	// EXPECT: Only the validated models count towards the download size.
+
+	doc := indexWithFiles(t, []repo.ListingFile{
+		listingFile(repo.FileTypeMODEL, repo.FileStatusVALID, 3<<20),
+		listingFile(repo.FileTypeMODEL, repo.FileStatusVALID, 512<<10),
+		listingFile(repo.FileTypeMODEL, repo.FileStatusPENDING, 40<<20),
+		listingFile(repo.FileTypeMODEL, repo.FileStatusINVALID, 7<<20),
+		listingFile(repo.FileTypeIMAGE, repo.FileStatusVALID, 2<<20),
+	})
+
+	assert.Equal(t, int64(3<<20+512<<10), doc["total_model_size_bytes"])
+	assert.Equal(t, "3.5 MB", doc["total_model_size_display"])
+}
+
+func TestIndexListing_TotalModelSizeDisplay(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KB"},
+		{1<<20 - 1, "1.0 MB"}, // Rounds up to 1024.0 KB
+		{1 << 20, "1.0 MB"},
+		{1<<30 - 1, "1.0 GB"},
+		{1 << 30, "1.0 GB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			doc := indexWithFiles(t, []repo.ListingFile{listingFile(repo.FileTypeMODEL, repo.FileStatusVALID, tt.bytes)})
+			assert.Equal(t, tt.want, doc["total_model_size_display"])
+		})
+	}
+}
+
+// modelFile is a model upload with the metadata the validation worker wrote for it, "" for none
+func modelFile(path string, status repo.FileStatus, metadata string) repo.ListingFile {
+	file := listingFile(repo.FileTypeMODEL, status, 1<<20)
+	file.FilePath = path
+	if metadata != "" {
+		file.Metadata = []byte(metadata)
+	}
+	return file
+}
+
+func TestIndexListing_FileFormats(t *testing.T) {
+	// SCENARIO: A listing with validated STL and 3MF models, an OBJ that failed validation, a STEP still validating and a validated image.
+	// EXPECT: Only the validated models' extensions are indexed, lower cased and each once.
+
+	image := listingFile(repo.FileTypeIMAGE, repo.FileStatusVALID, 1024)
+	image.FilePath = "listings/abc/cover.png"
+	doc := indexWithFiles(t, []repo.ListingFile{
+		modelFile("listings/abc/Benchy.STL", repo.FileStatusVALID, ""),
+		modelFile("listings/abc/benchy-supports.stl", repo.FileStatusVALID, ""),
+		modelFile("listings/abc/plate.3mf", repo.FileStatusVALID, ""),
+		modelFile("listings/abc/broken.obj", repo.FileStatusINVALID, ""),
+		modelFile("listings/abc/bracket.step", repo.FileStatusPENDING, ""),
+		image,
+	})
+
+	assert.Equal(t, []string{"3mf", "stl"}, doc["file_formats"])
+}
+
+func TestIndexListing_IsManifold(t *testing.T) {
+	watertight := `{"format": "model/stl", "scan": {"watertight": true, "winding_consistent": true}}`
+	leaking := `{"format": "model/stl", "scan": {"watertight": false}}`
+	yes, no := true, false
+
+	tests := []struct {
+		name  string
+		files []repo.ListingFile
+		want  *bool
+	}{
+		{
+			name:  "Every model watertight",
+			files: []repo.ListingFile{modelFile("a.stl", repo.FileStatusVALID, watertight), modelFile("b.3mf", repo.FileStatusVALID, watertight)},
+			want:  &yes,
+		},
+		{
+			name:  "One model leaks",
+			files: []repo.ListingFile{modelFile("a.stl", repo.FileStatusVALID, watertight), modelFile("b.stl", repo.FileStatusVALID, leaking)},
+			want:  &no,
+		},
+		{
+			name:  "Leaking model failed validation",
+			files: []repo.ListingFile{modelFile("a.stl", repo.FileStatusVALID, watertight), modelFile("b.stl", repo.FileStatusINVALID, leaking)},
+			want:  &yes,
+		},
+		{
+			name:  "Written by an older validation worker",
+			files: []repo.ListingFile{modelFile("a.stl", repo.FileStatusVALID, `{"mime": "model/stl", "is_watertight": true}`)},
+			want:  &yes,
+		},
+		{
+			name:  "A model without a scan",
+			files: []repo.ListingFile{modelFile("a.stl", repo.FileStatusVALID, watertight), modelFile("b.stl", repo.FileStatusVALID, `{"format": "model/stl"}`)},
+		},
+		{
+			name:  "A model without metadata",
+			files: []repo.ListingFile{modelFile("a.stl", repo.FileStatusVALID, watertight), modelFile("b.stl", repo.FileStatusVALID, "")},
+		},
+		{
+			name:  "Metadata that doesn't parse",
+			files: []repo.ListingFile{modelFile("a.stl", repo.FileStatusVALID, `{"scan": {"watertight": "yes"}}`)},
+		},
+		{
+			name:  "No validated models",
+			files: []repo.ListingFile{modelFile("a.stl", repo.FileStatusPENDING, watertight)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// SCENARIO: A listing is indexed with models the validation worker has scanned, or not.
+			// EXPECT: is_manifold is only true when every validated model is watertight, null when that isn't known.
+
+			doc := indexWithFiles(t, tt.files)
+			assert.Equal(t, tt.want, doc["is_manifold"])
+		})
+	}
+}
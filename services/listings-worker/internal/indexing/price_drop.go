@@ -0,0 +1,65 @@
+package indexing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	repo "indexer/internal/database/postgresql/sqlc"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// PriceDropWindow is how long a listing is flagged price_dropped_recently after its price goes down
+const PriceDropWindow = 14 * 24 * time.Hour
+
+// priceDroppedRecently is true when the listing's latest price change was a drop within PriceDropWindow. A change of
+// currency isn't a drop, the numbers can't be compared. SweepPriceDrops clears the flag once the window has passed.
+func (l *ListingSource) priceDroppedRecently(ctx context.Context, listingID pgtype.UUID) (bool, error) {
+	change, err := l.repo.GetLatestPriceChange(ctx, listingID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Never changed since history started being kept
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return change.OldCurrency == change.NewCurrency &&
+		change.NewPriceMinUnit < change.OldPriceMinUnit &&
+		time.Since(change.ChangedAt.Time) < PriceDropWindow, nil
+}
+
+// SweepPriceDrops reindexes listings whose price drop has aged out of PriceDropWindow since they were last indexed,
+// so search stops showing them as recently reduced. Nothing changes on the row when the window passes, so neither
+// the gateway's events nor ReindexStale would pick them up. Returns how many listings were reindexed.
+func (s *svc) SweepPriceDrops(ctx context.Context, batchSize int) (int, error) {
+	ids, err := s.reader.GetListingsWithExpiredPriceDrops(ctx, repo.GetListingsWithExpiredPriceDropsParams{
+		DropWindow: pgtype.Interval{Microseconds: PriceDropWindow.Microseconds(), Valid: true},
+		BatchSize:  int32(batchSize),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch listings with expired price drops: %w", err)
+	}
+
+	total := 0
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		// Same dashless format the gateway publishes, so we overwrite the existing document
+		listingID := fmt.Sprintf("%x", id.Bytes)
+		// Keep going on failure, the listing is still indexed too early and the next sweep picks it up
+		if err := s.IndexListing(ctx, listingID); err != nil {
+			s.logger.Error("Failed to reindex listing after its price drop expired", "error", err, "listing_id", listingID)
+			continue
+		}
+		total++
+	}
+
+	if total > 0 {
+		s.logger.Info("Price drop sweep complete", "reindexed", total, "listings", len(ids))
+	}
+	return total, nil
+}
@@ -0,0 +1,107 @@
+package indexing_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/indexing"
+	"indexer/internal/mocks/mockrepo"
+	"indexer/internal/publicurl"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func priceChange(oldPrice, newPrice int64, oldCurrency, newCurrency string, ago time.Duration) repo.ListingPriceHistory {
+	return repo.ListingPriceHistory{
+		OldPriceMinUnit: oldPrice,
+		NewPriceMinUnit: newPrice,
+		OldCurrency:     oldCurrency,
+		NewCurrency:     newCurrency,
+		ChangedAt:       pgtype.Timestamptz{Time: time.Now().Add(-ago), Valid: true},
+	}
+}
+
+func TestIndexListing_PriceDroppedRecently(t *testing.T) {
+	tests := map[string]struct {
+		change repo.ListingPriceHistory
+		err    error
+		want   bool
+	}{
+		"dropped yesterday":        {change: priceChange(1500, 1200, "USD", "USD", 24*time.Hour), want: true},
+		"dropped over 14 days ago": {change: priceChange(1500, 1200, "USD", "USD", indexing.PriceDropWindow+time.Hour)},
+		"raised yesterday":         {change: priceChange(1200, 1500, "USD", "USD", 24*time.Hour)},
+		"currency changed":         {change: priceChange(1500, 1200, "GBP", "USD", 24*time.Hour)},
+		"never changed":            {err: pgx.ErrNoRows},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockRepo := mockrepo.NewQuerier(t)
+			fakeIndexer := indexing.NewInMemoryIndexer()
+			svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+			listingID := pgtype.UUID{Bytes: [16]byte{15: 1}, Valid: true}
+			sellerID := pgtype.UUID{Bytes: [16]byte{0: 1}, Valid: true}
+			mockRepo.EXPECT().GetListingByID(mock.Anything, listingID).Return(vacationListing(listingID, sellerID), nil)
+			mockRepo.EXPECT().GetSellerVacation(mock.Anything, sellerID).Return(repo.GetSellerVacationRow{}, nil)
+			mockRepo.EXPECT().GetLatestPriceChange(mock.Anything, listingID).Return(tt.change, tt.err)
+			mockRepo.EXPECT().GetFeatureWindows(mock.Anything, listingID).Return(nil, nil)
+			mockRepo.EXPECT().GetFilesByListingID(mock.Anything, listingID).Return(nil, nil)
+			mockRepo.EXPECT().GetCheapestVariantPrice(mock.Anything, listingID).Return(0, pgx.ErrNoRows)
+			mockRepo.EXPECT().GetSellerLastActive(mock.Anything, mock.Anything).Return(pgtype.Timestamptz{}, nil)
+			mockRepo.EXPECT().MarkListingAsIndexed(mock.Anything, listingID).Return(nil)
+
+			id := fmt.Sprintf("%x", listingID.Bytes)
+			require.NoError(t, svc.IndexListing(context.Background(), id))
+
+			doc, found, _ := fakeIndexer.Get(context.Background(), "listings", id)
+			require.True(t, found)
+			assert.Equal(t, tt.want, doc.(map[string]any)["price_dropped_recently"])
+		})
+	}
+}
+
+func TestSweepPriceDrops_ReindexesExpiredDrops(t *testing.T) {
+	// SCENARIO: Two listings were indexed as price_dropped_recently and their drops are now over 14 days old.
+	// EXPECT: Both are reindexed without the flag. A failure on one doesn't stop the other.
+
+	mockRepo := mockrepo.NewQuerier(t)
+	fakeIndexer := indexing.NewInMemoryIndexer()
+	svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	sellerID := pgtype.UUID{Bytes: [16]byte{0: 1}, Valid: true}
+	failing := pgtype.UUID{Bytes: [16]byte{15: 1}, Valid: true}
+	expired := pgtype.UUID{Bytes: [16]byte{15: 2}, Valid: true}
+
+	mockRepo.EXPECT().GetListingsWithExpiredPriceDrops(mock.Anything, repo.GetListingsWithExpiredPriceDropsParams{
+		DropWindow: pgtype.Interval{Microseconds: indexing.PriceDropWindow.Microseconds(), Valid: true},
+		BatchSize:  50,
+	}).Return([]pgtype.UUID{failing, expired}, nil)
+	mockRepo.EXPECT().GetListingByID(mock.Anything, failing).Return(repo.Listing{}, errors.New("connection refused"))
+	mockRepo.EXPECT().GetListingByID(mock.Anything, expired).Return(vacationListing(expired, sellerID), nil)
+	mockRepo.EXPECT().GetSellerVacation(mock.Anything, sellerID).Return(repo.GetSellerVacationRow{}, nil)
+	mockRepo.EXPECT().GetLatestPriceChange(mock.Anything, expired).
+		Return(priceChange(1500, 1200, "USD", "USD", indexing.PriceDropWindow+time.Minute), nil)
+	mockRepo.EXPECT().GetFeatureWindows(mock.Anything, expired).Return(nil, nil)
+	mockRepo.EXPECT().GetFilesByListingID(mock.Anything, expired).Return(nil, nil)
+	mockRepo.EXPECT().GetCheapestVariantPrice(mock.Anything, expired).Return(0, pgx.ErrNoRows)
+	mockRepo.EXPECT().GetSellerLastActive(mock.Anything, mock.Anything).Return(pgtype.Timestamptz{}, nil)
+	mockRepo.EXPECT().MarkListingAsIndexed(mock.Anything, expired).Return(nil)
+
+	total, err := svc.SweepPriceDrops(context.Background(), 50)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	doc, found, _ := fakeIndexer.Get(context.Background(), "listings", fmt.Sprintf("%x", expired.Bytes))
+	require.True(t, found)
+	assert.Equal(t, false, doc.(map[string]any)["price_dropped_recently"])
+}
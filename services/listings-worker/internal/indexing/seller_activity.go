@@ -0,0 +1,43 @@
+package indexing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// seller_activity_bucket values, coarse so a document only changes a couple of times as its seller drifts away
+const (
+	ActivityActive7d  = "active_7d"
+	ActivityActive30d = "active_30d"
+	ActivityDormant   = "dormant"
+)
+
+// SellerActivityBucket is the seller_activity_bucket of a seller last seen at lastActive. A seller never seen, or
+// without a profile, is dormant. The buckets drift as time passes without the seller doing anything, the counter
+// reconcile puts documents back in line.
+func SellerActivityBucket(lastActive pgtype.Timestamptz, now time.Time) string {
+	if !lastActive.Valid {
+		return ActivityDormant
+	}
+	switch idle := now.Sub(lastActive.Time); {
+	case idle <= 7*24*time.Hour:
+		return ActivityActive7d
+	case idle <= 30*24*time.Hour:
+		return ActivityActive30d
+	default:
+		return ActivityDormant
+	}
+}
+
+// sellerActivity is the listing's seller_activity_bucket
+func (l *ListingSource) sellerActivity(ctx context.Context, sellerID pgtype.UUID) (string, error) {
+	lastActive, err := l.repo.GetSellerLastActive(ctx, sellerID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", err
+	}
+	return SellerActivityBucket(lastActive, time.Now()), nil
+}
@@ -0,0 +1,111 @@
+package indexing_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/indexing"
+	"indexer/internal/mocks/mockrepo"
+	"indexer/internal/publicurl"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSellerActivityBucket(t *testing.T) {
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	seen := func(ago time.Duration) pgtype.Timestamptz {
+		return pgtype.Timestamptz{Time: now.Add(-ago), Valid: true}
+	}
+
+	tests := []struct {
+		name       string
+		lastActive pgtype.Timestamptz
+		want       string
+	}{
+		{name: "Just now", lastActive: seen(0), want: indexing.ActivityActive7d},
+		{name: "Exactly 7 days", lastActive: seen(7 * day), want: indexing.ActivityActive7d},
+		{name: "Just over 7 days", lastActive: seen(7*day + time.Second), want: indexing.ActivityActive30d},
+		{name: "Exactly 30 days", lastActive: seen(30 * day), want: indexing.ActivityActive30d},
+		{name: "Just over 30 days", lastActive: seen(30*day + time.Second), want: indexing.ActivityDormant},
+		{name: "A year", lastActive: seen(365 * day), want: indexing.ActivityDormant},
+		{name: "Never seen", lastActive: pgtype.Timestamptz{}, want: indexing.ActivityDormant},
+		// Another pod's clock a little ahead of ours
+		{name: "In the future", lastActive: seen(-time.Minute), want: indexing.ActivityActive7d},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, indexing.SellerActivityBucket(tt.lastActive, now))
+		})
+	}
+}
+
+func TestIndexListing_SellerActivity(t *testing.T) {
+	tests := []struct {
+		name       string
+		lastActive pgtype.Timestamptz
+		err        error
+		want       string
+	}{
+		{name: "Seen this week", lastActive: pgtype.Timestamptz{Time: time.Now().Add(-3 * 24 * time.Hour), Valid: true}, want: "active_7d"},
+		{name: "Seen this month", lastActive: pgtype.Timestamptz{Time: time.Now().Add(-20 * 24 * time.Hour), Valid: true}, want: "active_30d"},
+		{name: "No seller profile", err: pgx.ErrNoRows, want: "dormant"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// SCENARIO: A listing is indexed.
+			// EXPECT: Its document has the bucket of when its seller was last seen.
+
+			mockRepo := mockrepo.NewQuerier(t)
+			fakeIndexer := indexing.NewInMemoryIndexer()
+			svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+			listingID := pgtype.UUID{Bytes: [16]byte{15: 1}, Valid: true}
+			sellerID := pgtype.UUID{Bytes: [16]byte{0: 1}, Valid: true}
+			mockRepo.EXPECT().GetListingByID(mock.Anything, listingID).Return(vacationListing(listingID, sellerID), nil)
+			mockRepo.EXPECT().GetSellerVacation(mock.Anything, sellerID).Return(repo.GetSellerVacationRow{}, nil)
+			mockRepo.EXPECT().GetSellerLastActive(mock.Anything, sellerID).Return(tt.lastActive, tt.err)
+			mockRepo.EXPECT().GetLatestPriceChange(mock.Anything, listingID).Return(repo.ListingPriceHistory{}, pgx.ErrNoRows)
+			mockRepo.EXPECT().GetFeatureWindows(mock.Anything, listingID).Return(nil, nil)
+			mockRepo.EXPECT().GetFilesByListingID(mock.Anything, listingID).Return(nil, nil)
+			mockRepo.EXPECT().GetCheapestVariantPrice(mock.Anything, listingID).Return(0, pgx.ErrNoRows)
+			mockRepo.EXPECT().MarkListingAsIndexed(mock.Anything, listingID).Return(nil)
+
+			id := fmt.Sprintf("%x", listingID.Bytes)
+			require.NoError(t, svc.IndexListing(context.Background(), id))
+
+			doc, found, _ := fakeIndexer.Get(context.Background(), "listings", id)
+			require.True(t, found)
+			assert.Equal(t, tt.want, doc.(map[string]any)["seller_activity_bucket"])
+		})
+	}
+}
+
+func TestUpdateCounters_SellerActivity(t *testing.T) {
+	// SCENARIO: A counter event carries the seller's bucket, a later one from an older worker doesn't.
+	// EXPECT: The bucket is patched with the counts, and left as it is by the event without one.
+
+	fakeIndexer := indexing.NewInMemoryIndexer()
+	svc := indexing.NewService(fakeIndexer, mockrepo.NewQuerier(t), slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	idStr := "550e8400e29b41d4a716446655440000"
+	require.NoError(t, fakeIndexer.Upsert(context.Background(), "listings", map[string]any{"id": idStr, "seller_activity_bucket": "dormant"}))
+
+	require.NoError(t, svc.UpdateCounters(context.Background(), idStr, 1, 10, "active_7d"))
+	require.NoError(t, svc.UpdateCounters(context.Background(), idStr, 2, 20, ""))
+
+	doc, _, _ := fakeIndexer.Get(context.Background(), "listings", idStr)
+	docMap := doc.(map[string]any)
+	assert.Equal(t, "active_7d", docMap["seller_activity_bucket"])
+	assert.Equal(t, int64(2), docMap["downloads_count"])
+}
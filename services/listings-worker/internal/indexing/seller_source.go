@@ -0,0 +1,51 @@
+package indexing
+
+import (
+	"context"
+	"errors"
+	repo "indexer/internal/database/postgresql/sqlc"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// SellerSource builds the documents in the sellers collection. Nothing publishes seller index events yet,
+// the collection has to exist in Typesense before anything does.
+type SellerSource struct {
+	repo   repo.Querier
+	logger *slog.Logger
+}
+
+func NewSellerSource(repo repo.Querier, logger *slog.Logger) *SellerSource {
+	return &SellerSource{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (src *SellerSource) Fetch(ctx context.Context, sellerID string) (any, Action, error) {
+	var userID pgtype.UUID
+	if err := userID.Scan(sellerID); err != nil {
+		src.logger.Error("Invalid UUID format, discarding", "id", sellerID)
+		return nil, ActionSkip, nil
+	}
+
+	seller, err := src.repo.GetSellerByUserID(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// No seller profile means nothing to search for, drop any document left behind
+		return nil, ActionDelete, nil
+	}
+	if err != nil {
+		src.logger.Error("Failed to fetch seller from DB", "error", err, "seller_id", sellerID)
+		return nil, ActionSkip, err
+	}
+
+	// Public fields only, payout and terms details never leave the database
+	return map[string]any{
+		"id":           sellerID,
+		"display_name": seller.DisplayName,
+		"country":      seller.Country,
+		"created_at":   seller.CreatedAt.Time.Unix(),
+	}, ActionUpsert, nil
+}
@@ -2,187 +2,129 @@ package indexing
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/publicurl"
 	"log/slog"
 
-	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // Handles the business logic
 type svc struct {
-	indexer           Indexer
-	repo              repo.Querier
-	logger            *slog.Logger
-	publicFilesBucket string
+	indexer Indexer
+	repo    repo.Querier
+	// reader serves the scans over every listing or seller, a replica when there is one. Documents are always built
+	// from and marked indexed on the primary, the replica may be a few seconds behind.
+	reader   repo.Querier
+	logger   *slog.Logger
+	listings *ListingSource
+	sources  map[string]registeredSource
 }
 
-func NewService(indexer Indexer, repo repo.Querier, logger *slog.Logger, publicFilesBucket string) *svc {
-	return &svc{
-		indexer:           indexer,
-		repo:              repo,
-		logger:            logger,
-		publicFilesBucket: publicFilesBucket,
+func NewService(indexer Indexer, repo repo.Querier, logger *slog.Logger, urls publicurl.Config) *svc {
+	return NewServiceWithReader(indexer, repo, nil, logger, urls)
+}
+
+// NewServiceWithReader is NewService with the bulk reads sent to reader, e.g. a read replica. A nil reader reads from repo.
+func NewServiceWithReader(indexer Indexer, repo repo.Querier, reader repo.Querier, logger *slog.Logger, urls publicurl.Config) *svc {
+	if reader == nil {
+		reader = repo
+	}
+	s := &svc{
+		indexer:  indexer,
+		repo:     repo,
+		reader:   reader,
+		logger:   logger,
+		listings: NewListingSource(repo, logger, urls),
+		sources:  make(map[string]registeredSource),
 	}
+	s.Register(EntityListing, "listings", s.listings)
+	s.Register(EntitySeller, "sellers", NewSellerSource(repo, logger))
+	return s
 }
 
 func (s *svc) IndexListing(ctx context.Context, listingID string) error {
-	s.logger.Info("Indexing listing", "listing_id", listingID)
+	return s.Index(ctx, EntityListing, listingID)
+}
 
-	var listingUUID pgtype.UUID
-	if err := listingUUID.Scan(listingID); err != nil {
-		// PERMANENT ERROR: This UUID will never be valid.
-		// Return nil to Ack/Discard.
-		s.logger.Error("Invalid UUID format, discarding", "id", listingID)
+// DeleteListing removes a deleted listing's document. One that isn't indexed is already where it should be.
+func (s *svc) DeleteListing(ctx context.Context, listingID string) error {
+	err := s.indexer.Delete(ctx, "listings", listingID)
+	if errors.Is(err, ErrNotFound) {
+		s.logger.Debug("Listing not indexed, nothing to delete", "listing_id", listingID)
 		return nil
 	}
-
-	// Fetch listing from DB
-	listing, err := s.repo.GetListingByID(ctx, listingUUID)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			s.logger.Warn("Listing not found in DB (might be deleted), skipping index", "id", listingID)
-			// Return nil to Ack. We can't index what doesn't exist.
-			return nil
-		}
-
-		s.logger.Error("Failed to fetch listing from DB", "error", err, "listing_id", listingID)
-		return err
+		s.logger.Error("Failed to delete listing document", "error", err, "listing_id", listingID)
+		return classified(ErrorClassSearch, err)
 	}
+	s.logger.Info("Removed deleted listing from index", "listing_id", listingID)
+	return nil
+}
 
-	if !listing.ThumbnailPath.Valid {
-		s.logger.Warn("Listing missing thumbnail URL, cannot index", "id", listingID)
+// UpdateCounters patches the social counters on an indexed listing without rebuilding the whole document. The
+// seller's activity bucket rides along when the event has one, events from before it was added don't.
+func (s *svc) UpdateCounters(ctx context.Context, listingID string, downloads, views int64, activity string) error {
+	update := map[string]any{
+		"downloads_count": downloads,
+		"views_count":     views,
+	}
+	if activity != "" {
+		update["seller_activity_bucket"] = activity
+	}
+	err := s.indexer.Update(ctx, "listings", listingID, update)
+	if errors.Is(err, ErrNotFound) {
+		// Not indexed (yet), the full document picks the counts up when it is
+		s.logger.Debug("Listing not indexed, skipping counter update", "listing_id", listingID)
 		return nil
 	}
-
-	// Add the location of the public-files bucket to the thumbnail path
-	listing.ThumbnailPath.String = s.publicFilesBucket + listing.ThumbnailPath.String
-
-	var listingDimensions ListingDimensionsJSON
-	if err := json.Unmarshal(listing.DimensionsMm, &listingDimensions); err != nil {
-		s.logger.Error("Failed to unmarshal listing dimensions", "error", err, "listing_id", listingID)
+	if err != nil {
+		s.logger.Error("Failed to update listing counters", "error", err, "listing_id", listingID)
 		return err
 	}
+	return nil
+}
 
-	document := map[string]interface{}{
-		"id":            listingID,
-		"title":         listing.Title,
-		"description":   listing.Description,
-		"thumbnail_url": listing.ThumbnailPath.String,
-		"categories":    listing.Categories,
-		"license":       listing.License,
-
-		// TODO Properties
-		"is_manifold":  false,
-		"file_formats": []string{"stl"},
-		// "embedding":    []float32{}, // Empty for now
-
-		// Physical Properties
-		"is_physical": listing.IsPhysical,
-		"dim_x_mm":    listingDimensions.Width,
-		"dim_y_mm":    listingDimensions.Height,
-		"dim_z_mm":    listingDimensions.Depth,
-		"total_weight_grams": func() *int64 {
-			if listing.TotalWeightGrams.Valid {
-				weight := int64(listing.TotalWeightGrams.Int32)
-				return &weight
-			}
-			return nil
-		}(),
-
-		// Assembly
-		"is_assembly_required":  listing.IsAssemblyRequired,
-		"is_hardware_required":  listing.IsHardwareRequired,
-		"recommended_materials": listing.RecommendedMaterials,
-		"is_multicolor":         listing.IsMulticolor,
-		"recommended_nozzle_temp_c": func() *int64 {
-			if listing.RecommendedNozzleTempC.Valid {
-				temp := int64(listing.RecommendedNozzleTempC.Int32)
-				return &temp
-			}
-			return nil
-		}(),
-		"hardware_required": listing.HardwareRequired,
-
-		"is_nsfw": listing.IsNsfw,
-
-		// AI
-		"is_ai_generated": listing.IsAiGenerated,
-		"ai_model_name": func() *string {
-			if listing.AiModelName.Valid {
-				return &listing.AiModelName.String
-			}
-			return nil
-		}(),
-
-		// Remixing
-		"parent_listing_id": func() *string {
-			if listing.ParentListingID.Valid {
-				// Option A: Use the String() method if your pgx version supports it
-				s := fmt.Sprintf("%x-%x-%x-%x-%x",
-					listing.ParentListingID.Bytes[0:4],
-					listing.ParentListingID.Bytes[4:6],
-					listing.ParentListingID.Bytes[6:8],
-					listing.ParentListingID.Bytes[8:10],
-					listing.ParentListingID.Bytes[10:16])
-				return &s
-			}
-			return nil
-		}(),
-		"is_remix_allowed": listing.IsRemixingAllowed,
-
-		// Social Signals
-		"likes_count":     listing.LikesCount,
-		"downloads_count": listing.DownloadsCount,
-		"comments_count":  listing.CommentsCount,
+// ReindexStale pushes every listing whose document is missing or older than the row back through IndexListing.
+// Used after data fixes (e.g. the seller_name backfill) so the search index catches up without replaying events.
+func (s *svc) ReindexStale(ctx context.Context, batchSize int) (int, error) {
+	var (
+		afterID pgtype.UUID // Zero UUID sorts first, so the first page starts at the beginning
+		total   int
+	)
+	afterID.Valid = true
+
+	for {
+		ids, err := s.reader.GetStaleListingIDs(ctx, repo.GetStaleListingIDsParams{
+			AfterID:   afterID,
+			BatchSize: int32(batchSize),
+		})
+		if err != nil {
+			return total, fmt.Errorf("failed to fetch stale listings: %w", err)
+		}
+		if len(ids) == 0 {
+			break
+		}
 
-		// Sales
-		"price_min_unit": listing.PriceMinUnit,
-		"sale_price":     listing.SalePrice,
-		"sale_end_timestamp": func() *int64 {
-			if listing.SaleEndTimestamp.Valid {
-				timestamp := listing.SaleEndTimestamp.Time.Unix()
-				return &timestamp
+		for _, id := range ids {
+			if err := ctx.Err(); err != nil {
+				return total, err
 			}
-			return nil
-		}(),
-		"is_sale_active": listing.IsSaleActive,
-		"sale_name": func() *string {
-			if listing.SaleName.Valid {
-				return &listing.SaleName.String
+			// Same dashless format the gateway publishes, so we overwrite the existing document rather than adding a second one
+			listingID := fmt.Sprintf("%x", id.Bytes)
+			// Keep going on failure, the listing stays stale and the next sweep picks it up
+			if err := s.IndexListing(ctx, listingID); err != nil {
+				s.logger.Error("Failed to reindex listing", "error", err, "listing_id", listingID)
+				continue
 			}
-			return nil
-		}(),
-		"currency":        listing.Currency,
-		"seller_username": listing.SellerUsername,
-		"seller_name":     listing.SellerName,
-		"seller_id":       listing.SellerID.String(),
-		"seller_verified": listing.SellerVerified,
-		"created_at":      listing.CreatedAt.Time.Unix(),
-		"updated_at":      listing.UpdatedAt.Time.Unix(),
-	}
+			total++
+		}
 
-	if err := s.indexer.Upsert(ctx, "listings", document); err != nil {
-		// TRANSIENT ERROR: Search Engine is down. Return err to Retry.
-		s.logger.Error("Failed to upsert listing", "error", err)
-		return err
+		afterID = ids[len(ids)-1]
 	}
 
-	s.logger.Info("Successfully indexed listing", "listing_id", listingID)
-	// Update the indexed_at timestamp in the DB
-	if err := s.repo.MarkListingAsIndexed(ctx, listingUUID); err != nil {
-		s.logger.Error("Failed to update listing indexed_at timestamp", "error", err, "listing_id", listingID)
-		return err
-	}
-
-	return nil
-}
-
-type ListingDimensionsJSON struct {
-	Width  int `json:"width"`  // Maps to DimX
-	Depth  int `json:"depth"`  // Maps to DimY
-	Height int `json:"height"` // Maps to DimZ
+	s.logger.Info("Reindex complete", "reindexed", total)
+	return total, nil
 }
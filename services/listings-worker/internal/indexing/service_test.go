@@ -2,15 +2,20 @@ package indexing_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
-	"math/big"
 	"os"
 	"testing"
 	"time"
 
 	repo "indexer/internal/database/postgresql/sqlc"
 	"indexer/internal/indexing"
+	"indexer/internal/mocks/mockindexing"
+	"indexer/internal/mocks/mockrepo"
+	"indexer/internal/publicurl"
+	"indexer/internal/testutil/fixtures"
 
 	// "indexer/internal/search/memory" // Import where you put InMemoryIndexer
 
@@ -23,57 +28,33 @@ import (
 
 // --- MOCKS ---
 
-// MockRepo simulates the SQLC generated interface
-type MockRepo struct {
-	mock.Mock
-}
-
-func (m *MockRepo) GetListingByID(ctx context.Context, id pgtype.UUID) (repo.GetListingByIDRow, error) {
-	args := m.Called(ctx, id)
-	return args.Get(0).(repo.GetListingByIDRow), args.Error(1)
-}
-
-// Stub for interface compliance
-func (m *MockRepo) GetFilesByListingID(ctx context.Context, id pgtype.UUID) ([]repo.ListingFile, error) {
-	return nil, nil
-}
-func (m *MockRepo) GetListingByIDAdmin(ctx context.Context, id pgtype.UUID) (repo.Listing, error) {
-	return repo.Listing{}, nil
-}
-func (m *MockRepo) MarkListingAsIndexed(ctx context.Context, id pgtype.UUID) error {
-	return nil
-}
-
 // --- TESTS ---
 
 func TestIndexListing_HappyPath(t *testing.T) {
 	// 1. Setup
-	mockRepo := new(MockRepo)
+	mockRepo := mockrepo.NewQuerier(t)
 	// NOTE: We cast to concrete type to access .Get() helper if it's not in the interface
 	fakeIndexer := indexing.NewInMemoryIndexer()
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
-	svc := indexing.NewService(fakeIndexer, mockRepo, logger, "http://s3.amazonaws.com/public-files")
+	svc := indexing.NewService(fakeIndexer, mockRepo, logger, publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
 
 	// 2. Data Setup
 	idStr := "550e8400-e29b-41d4-a716-446655440000"
 	var uuid pgtype.UUID
 	uuid.Scan(idStr)
 
-	// ... (dbListing setup remains the same) ...
-	dbListing := repo.GetListingByIDRow{
-		ID:             uuid,
-		SellerName:     "John Doe",
-		SellerUsername: "johndoe",
-		Title:          "Production Asset",
-		Description:    pgtype.Text{String: "High quality model", Valid: true},
-		PriceMinUnit:   pgtype.Numeric{Int: big.NewInt(5000), Valid: true},
-		Currency:       "USD",
-		CreatedAt:      pgtype.Timestamptz{Time: time.Now(), Valid: true},
-	}
+	dbListing := fixtures.NewListing(fixtures.WithID(idStr), fixtures.WithTitle("Production Asset"))
 
 	// 3. Expectation
 	mockRepo.On("GetListingByID", mock.Anything, uuid).Return(dbListing, nil)
+	mockRepo.EXPECT().GetLatestPriceChange(mock.Anything, mock.Anything).Return(repo.ListingPriceHistory{}, pgx.ErrNoRows)
+	mockRepo.EXPECT().GetFeatureWindows(mock.Anything, mock.Anything).Return(nil, nil)
+	mockRepo.EXPECT().GetFilesByListingID(mock.Anything, mock.Anything).Return(nil, nil)
+	mockRepo.EXPECT().GetCheapestVariantPrice(mock.Anything, mock.Anything).Return(0, pgx.ErrNoRows)
+	mockRepo.EXPECT().GetSellerLastActive(mock.Anything, mock.Anything).Return(pgtype.Timestamptz{}, nil)
+	mockRepo.EXPECT().GetSellerVacation(mock.Anything, mock.Anything).Return(repo.GetSellerVacationRow{}, nil)
+	mockRepo.EXPECT().MarkListingAsIndexed(mock.Anything, uuid).Return(nil)
 
 	// 4. Execute
 	err := svc.IndexListing(context.Background(), idStr)
@@ -98,21 +79,98 @@ func TestIndexListing_HappyPath(t *testing.T) {
 	assert.Equal(t, "Production Asset", docMap["title"])
 	// Note: Verify the ID matches the string, not a pointer address!
 	assert.Equal(t, idStr, docMap["id"])
+	assert.Equal(t, false, docMap["seller_on_vacation"])
+	assert.Equal(t, "en", docMap["language"])
+	// Which client and trace created the listing stay in its status history, they aren't searchable
+	assert.NotContains(t, docMap, "client_id")
+	assert.NotContains(t, docMap, "trace_id")
+	// Faceted, so it has to come out as the number sellers picked
+	if assert.NotNil(t, docMap["nozzle_diameter_mm"]) {
+		assert.Equal(t, 0.4, *docMap["nozzle_diameter_mm"].(*float64))
+	}
+}
+
+func TestIndexListing_RemixParent(t *testing.T) {
+	// SCENARIO: A remix is indexed while its parent is live, after the parent is deleted, and after it's restored.
+	// EXPECT: The document only links the parent while it's live.
+
+	idStr := "550e8400-e29b-41d4-a716-446655440000"
+	var id, parentID pgtype.UUID
+	require.NoError(t, id.Scan(idStr))
+	require.NoError(t, parentID.Scan("660e8400-e29b-41d4-a716-446655440000"))
+
+	for _, step := range []struct {
+		name string
+		live bool
+		want any
+	}{
+		{name: "Live", live: true, want: "660e8400-e29b-41d4-a716-446655440000"},
+		{name: "Deleted", live: false, want: nil},
+		{name: "Restored", live: true, want: "660e8400-e29b-41d4-a716-446655440000"},
+	} {
+		t.Run(step.name, func(t *testing.T) {
+			mockRepo := mockrepo.NewQuerier(t)
+			fakeIndexer := indexing.NewInMemoryIndexer()
+			svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+			mockRepo.EXPECT().GetListingByID(mock.Anything, id).Return(fixtures.NewListing(
+				fixtures.WithID(idStr), fixtures.WithTitle("Benchy remix"), fixtures.WithRemixOf("660e8400-e29b-41d4-a716-446655440000"),
+			), nil)
+			mockRepo.EXPECT().IsListingLive(mock.Anything, parentID).Return(step.live, nil)
+			mockRepo.EXPECT().GetSellerVacation(mock.Anything, mock.Anything).Return(repo.GetSellerVacationRow{}, pgx.ErrNoRows)
+			mockRepo.EXPECT().GetLatestPriceChange(mock.Anything, mock.Anything).Return(repo.ListingPriceHistory{}, pgx.ErrNoRows)
+			mockRepo.EXPECT().GetFeatureWindows(mock.Anything, mock.Anything).Return(nil, nil)
+			mockRepo.EXPECT().GetFilesByListingID(mock.Anything, mock.Anything).Return(nil, nil)
+			mockRepo.EXPECT().GetCheapestVariantPrice(mock.Anything, mock.Anything).Return(0, pgx.ErrNoRows)
+			mockRepo.EXPECT().GetSellerLastActive(mock.Anything, mock.Anything).Return(pgtype.Timestamptz{}, nil)
+			mockRepo.EXPECT().MarkListingAsIndexed(mock.Anything, id).Return(nil)
+
+			require.NoError(t, svc.IndexListing(context.Background(), idStr))
+
+			doc, found, _ := fakeIndexer.(*indexing.InMemoryIndexer).Get(context.Background(), "listings", idStr)
+			require.True(t, found)
+			parent := doc.(map[string]any)["parent_listing_id"]
+			if step.want == nil {
+				assert.Nil(t, parent)
+			} else {
+				require.NotNil(t, parent)
+				assert.Equal(t, step.want, *parent.(*string))
+			}
+		})
+	}
+}
+
+func TestIndexListing_RemixParentCheckFails_Retries(t *testing.T) {
+	mockRepo := mockrepo.NewQuerier(t)
+	fakeIndexer := indexing.NewInMemoryIndexer()
+	svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{})
+
+	var parentID pgtype.UUID
+	require.NoError(t, parentID.Scan("660e8400-e29b-41d4-a716-446655440000"))
+	mockRepo.EXPECT().GetListingByID(mock.Anything, mock.Anything).
+		Return(fixtures.NewListing(fixtures.WithRemixOf("660e8400-e29b-41d4-a716-446655440000")), nil)
+	mockRepo.EXPECT().IsListingLive(mock.Anything, parentID).Return(false, errors.New("connection refused"))
+
+	err := svc.IndexListing(context.Background(), "550e8400-e29b-41d4-a716-446655440000")
+
+	assert.Error(t, err, "a failed check must not index the remix as orphaned")
+	count, _ := fakeIndexer.Count(context.Background(), "listings")
+	assert.Equal(t, int64(0), count)
 }
 
 func TestIndexListing_GhostRecord_Acknowledges(t *testing.T) {
 	// SCENARIO: ID is valid UUID, but not found in DB.
 	// EXPECT: Return nil (Ack) to stop retry loop.
 
-	mockRepo := new(MockRepo)
+	mockRepo := mockrepo.NewQuerier(t)
 	fakeIndexer := indexing.NewInMemoryIndexer()
-	svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), "http://s3.amazonaws.com/public-files")
+	svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
 
 	idStr := "550e8400-e29b-41d4-a716-446655440000"
 
 	// Mock DB returning ErrNoRows
 	mockRepo.On("GetListingByID", mock.Anything, mock.Anything).
-		Return(repo.GetListingByIDRow{}, pgx.ErrNoRows)
+		Return(repo.Listing{}, pgx.ErrNoRows)
 
 	err := svc.IndexListing(context.Background(), idStr)
 	count, err := fakeIndexer.Count(context.Background(), "listings")
@@ -121,30 +179,403 @@ func TestIndexListing_GhostRecord_Acknowledges(t *testing.T) {
 	assert.Equal(t, int64(0), count) // Nothing indexed
 }
 
+func TestIndexListing_Unpublished_RemovedFromIndex(t *testing.T) {
+	// SCENARIO: A searchable listing is hidden by its seller, or deleted, and the gateway raises an index event.
+	// EXPECT: The document is removed and nothing is marked as indexed.
+
+	idStr := "550e8400-e29b-41d4-a716-446655440000"
+	var uuid pgtype.UUID
+	uuid.Scan(idStr)
+
+	tests := []struct {
+		name    string
+		listing repo.Listing
+		err     error
+	}{
+		{
+			name:    "Hidden",
+			listing: fixtures.NewListing(fixtures.WithID(idStr), fixtures.WithStatus(repo.ListingStatusHIDDEN)),
+		},
+		{name: "Deleted", err: pgx.ErrNoRows},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mockrepo.NewQuerier(t)
+			fakeIndexer := indexing.NewInMemoryIndexer()
+			svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+			require.NoError(t, fakeIndexer.Upsert(context.Background(), "listings", map[string]any{"id": idStr, "title": "Production Asset"}))
+
+			mockRepo.EXPECT().GetListingByID(mock.Anything, uuid).Return(tt.listing, tt.err)
+
+			require.NoError(t, svc.IndexListing(context.Background(), idStr))
+
+			_, found, _ := fakeIndexer.(*indexing.InMemoryIndexer).Get(context.Background(), "listings", idStr)
+			assert.False(t, found)
+			mockRepo.AssertNotCalled(t, "MarkListingAsIndexed", mock.Anything, mock.Anything)
+		})
+	}
+}
+
 func TestIndexListing_DBError_Retries(t *testing.T) {
 	// SCENARIO: DB Connection fails.
 	// EXPECT: Return error (Nack) to retry.
 
-	mockRepo := new(MockRepo)
+	mockRepo := mockrepo.NewQuerier(t)
 	fakeIndexer := indexing.NewInMemoryIndexer()
-	svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), "http://s3.amazonaws.com/public-files")
+	svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
 
 	mockRepo.On("GetListingByID", mock.Anything, mock.Anything).
-		Return(repo.GetListingByIDRow{}, errors.New("connection refused"))
+		Return(repo.Listing{}, errors.New("connection refused"))
 
 	err := svc.IndexListing(context.Background(), "550e8400-e29b-41d4-a716-446655440000")
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "connection refused")
+	assert.Equal(t, indexing.ErrorClassDatabase, indexing.ErrorClass(err))
+}
+
+func TestIndexListing_SearchDown_RetriesWithoutMarking(t *testing.T) {
+	// SCENARIO: Typesense rejects the upsert.
+	// EXPECT: Return error (Nack) and leave last_indexed_at alone so the stale sweep picks it up too.
+
+	mockRepo := mockrepo.NewQuerier(t)
+	mockIndexer := mockindexing.NewIndexer(t)
+	svc := indexing.NewService(mockIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	mockRepo.EXPECT().GetListingByID(mock.Anything, mock.Anything).Return(fixtures.NewListing(), nil)
+	mockRepo.EXPECT().GetLatestPriceChange(mock.Anything, mock.Anything).Return(repo.ListingPriceHistory{}, pgx.ErrNoRows)
+	mockRepo.EXPECT().GetFeatureWindows(mock.Anything, mock.Anything).Return(nil, nil)
+	mockRepo.EXPECT().GetFilesByListingID(mock.Anything, mock.Anything).Return(nil, nil)
+	mockRepo.EXPECT().GetCheapestVariantPrice(mock.Anything, mock.Anything).Return(0, pgx.ErrNoRows)
+	mockRepo.EXPECT().GetSellerLastActive(mock.Anything, mock.Anything).Return(pgtype.Timestamptz{}, nil)
+	mockRepo.EXPECT().GetSellerVacation(mock.Anything, mock.Anything).Return(repo.GetSellerVacationRow{}, nil)
+	mockIndexer.EXPECT().Upsert(mock.Anything, "listings", mock.Anything).Return(errors.New("503 service unavailable"))
+
+	err := svc.IndexListing(context.Background(), "550e8400-e29b-41d4-a716-446655440000")
+
+	assert.ErrorContains(t, err, "503")
+	assert.Equal(t, indexing.ErrorClassSearch, indexing.ErrorClass(err))
+	mockRepo.AssertNotCalled(t, "MarkListingAsIndexed", mock.Anything, mock.Anything)
 }
 
 func TestIndexListing_InvalidUUID_Acknowledges(t *testing.T) {
 	// SCENARIO: Malformed ID string.
 	// EXPECT: Return nil (Ack) immediately.
 
-	svc := indexing.NewService(nil, nil, slog.Default(), "http://s3.amazonaws.com/public-files")
+	svc := indexing.NewService(nil, nil, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
 
 	err := svc.IndexListing(context.Background(), "not-a-uuid")
 
 	assert.NoError(t, err)
 }
+
+func TestIndexListing_EmailSellerName_NotIndexed(t *testing.T) {
+	// SCENARIO: Legacy row where seller_name was defaulted to the seller's email.
+	// EXPECT: The email never reaches the search document, the username is used instead.
+
+	mockRepo := mockrepo.NewQuerier(t)
+	fakeIndexer := indexing.NewInMemoryIndexer()
+	svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	idStr := "550e8400-e29b-41d4-a716-446655440000"
+	var uuid pgtype.UUID
+	uuid.Scan(idStr)
+
+	email := "john.doe@example.com"
+	mockRepo.On("GetListingByID", mock.Anything, uuid).Return(fixtures.NewListing(fixtures.With(func(l *repo.Listing) {
+		l.SellerName = email
+		l.SellerUsername = "johndoe"
+	})), nil)
+	mockRepo.EXPECT().GetLatestPriceChange(mock.Anything, mock.Anything).Return(repo.ListingPriceHistory{}, pgx.ErrNoRows)
+	mockRepo.EXPECT().GetFeatureWindows(mock.Anything, mock.Anything).Return(nil, nil)
+	mockRepo.EXPECT().GetFilesByListingID(mock.Anything, mock.Anything).Return(nil, nil)
+	mockRepo.EXPECT().GetCheapestVariantPrice(mock.Anything, mock.Anything).Return(0, pgx.ErrNoRows)
+	mockRepo.EXPECT().GetSellerLastActive(mock.Anything, mock.Anything).Return(pgtype.Timestamptz{}, nil)
+	mockRepo.EXPECT().GetSellerVacation(mock.Anything, mock.Anything).Return(repo.GetSellerVacationRow{}, pgx.ErrNoRows)
+	mockRepo.EXPECT().MarkListingAsIndexed(mock.Anything, uuid).Return(nil)
+
+	require.NoError(t, svc.IndexListing(context.Background(), idStr))
+
+	doc, found, _ := fakeIndexer.(*indexing.InMemoryIndexer).Get(context.Background(), "listings", idStr)
+	require.True(t, found)
+
+	docMap := doc.(map[string]interface{})
+	assert.Equal(t, "johndoe", docMap["seller_name"])
+	for field, value := range docMap {
+		assert.NotContains(t, fmt.Sprint(value), "@", "field %s leaks an email address", field)
+	}
+}
+
+func TestReindexStale_PagesThroughAllListings(t *testing.T) {
+	// SCENARIO: Two pages of stale listings, the second shorter than the batch size.
+	// EXPECT: Every listing is indexed under its dashless ID and paging continues from the last ID seen.
+
+	mockRepo := mockrepo.NewQuerier(t)
+	fakeIndexer := indexing.NewInMemoryIndexer()
+	svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	ids := make([]pgtype.UUID, 3)
+	for i := range ids {
+		ids[i] = pgtype.UUID{Bytes: [16]byte{15: byte(i + 1)}, Valid: true}
+		mockRepo.On("GetListingByID", mock.Anything, ids[i]).Return(fixtures.NewListing(fixtures.With(func(l *repo.Listing) {
+			l.ID = ids[i]
+		})), nil)
+		mockRepo.EXPECT().MarkListingAsIndexed(mock.Anything, ids[i]).Return(nil)
+	}
+	mockRepo.EXPECT().GetLatestPriceChange(mock.Anything, mock.Anything).Return(repo.ListingPriceHistory{}, pgx.ErrNoRows)
+	mockRepo.EXPECT().GetFeatureWindows(mock.Anything, mock.Anything).Return(nil, nil)
+	mockRepo.EXPECT().GetFilesByListingID(mock.Anything, mock.Anything).Return(nil, nil)
+	mockRepo.EXPECT().GetCheapestVariantPrice(mock.Anything, mock.Anything).Return(0, pgx.ErrNoRows)
+	mockRepo.EXPECT().GetSellerLastActive(mock.Anything, mock.Anything).Return(pgtype.Timestamptz{}, nil)
+	mockRepo.EXPECT().GetSellerVacation(mock.Anything, mock.Anything).Return(repo.GetSellerVacationRow{}, nil)
+
+	mockRepo.On("GetStaleListingIDs", mock.Anything, repo.GetStaleListingIDsParams{AfterID: pgtype.UUID{Valid: true}, BatchSize: 2}).
+		Return(ids[:2], nil)
+	mockRepo.On("GetStaleListingIDs", mock.Anything, repo.GetStaleListingIDsParams{AfterID: ids[1], BatchSize: 2}).
+		Return(ids[2:], nil)
+	mockRepo.On("GetStaleListingIDs", mock.Anything, repo.GetStaleListingIDsParams{AfterID: ids[2], BatchSize: 2}).
+		Return([]pgtype.UUID{}, nil)
+
+	total, err := svc.ReindexStale(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+
+	count, _ := fakeIndexer.Count(context.Background(), "listings")
+	assert.Equal(t, int64(3), count)
+
+	_, found, _ := fakeIndexer.(*indexing.InMemoryIndexer).Get(context.Background(), "listings", fmt.Sprintf("%x", ids[0].Bytes))
+	assert.True(t, found)
+}
+
+func TestReindexStale_ReplicaReadsPrimaryWrites(t *testing.T) {
+	// SCENARIO: The worker has a read replica.
+	// EXPECT: The stale scan goes to the replica, the document is built from and marked indexed on the primary.
+
+	primary := mockrepo.NewQuerier(t)
+	replica := mockrepo.NewQuerier(t)
+	fakeIndexer := indexing.NewInMemoryIndexer()
+	svc := indexing.NewServiceWithReader(fakeIndexer, primary, replica, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	id := fixtures.UUID(fixtures.ListingID)
+	replica.EXPECT().GetStaleListingIDs(mock.Anything, repo.GetStaleListingIDsParams{AfterID: pgtype.UUID{Valid: true}, BatchSize: 10}).
+		Return([]pgtype.UUID{id}, nil)
+	replica.EXPECT().GetStaleListingIDs(mock.Anything, repo.GetStaleListingIDsParams{AfterID: id, BatchSize: 10}).
+		Return([]pgtype.UUID{}, nil)
+	primary.EXPECT().GetListingByID(mock.Anything, id).Return(fixtures.NewListing(), nil)
+	primary.EXPECT().GetLatestPriceChange(mock.Anything, id).Return(repo.ListingPriceHistory{}, pgx.ErrNoRows)
+	primary.EXPECT().GetFeatureWindows(mock.Anything, id).Return(nil, nil)
+	primary.EXPECT().GetFilesByListingID(mock.Anything, id).Return(nil, nil)
+	primary.EXPECT().GetCheapestVariantPrice(mock.Anything, id).Return(0, pgx.ErrNoRows)
+	primary.EXPECT().GetSellerLastActive(mock.Anything, mock.Anything).Return(pgtype.Timestamptz{}, nil)
+	primary.EXPECT().GetSellerVacation(mock.Anything, mock.Anything).Return(repo.GetSellerVacationRow{}, pgx.ErrNoRows)
+	primary.EXPECT().MarkListingAsIndexed(mock.Anything, id).Return(nil)
+
+	total, err := svc.ReindexStale(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+}
+
+func TestNewServiceWithReader_NoReplica_ReadsPrimary(t *testing.T) {
+	primary := mockrepo.NewQuerier(t)
+	svc := indexing.NewServiceWithReader(indexing.NewInMemoryIndexer(), primary, nil, slog.Default(), publicurl.Config{})
+
+	primary.EXPECT().GetStaleListingIDs(mock.Anything, mock.Anything).Return([]pgtype.UUID{}, nil)
+
+	total, err := svc.ReindexStale(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+}
+
+func TestUpdateCounters_PatchesExistingDocument(t *testing.T) {
+	fakeIndexer := indexing.NewInMemoryIndexer()
+	svc := indexing.NewService(fakeIndexer, mockrepo.NewQuerier(t), slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	idStr := "550e8400e29b41d4a716446655440000"
+	require.NoError(t, fakeIndexer.Upsert(context.Background(), "listings", map[string]interface{}{"id": idStr, "title": "Production Asset"}))
+
+	require.NoError(t, svc.UpdateCounters(context.Background(), idStr, 12, 340, ""))
+
+	doc, _, _ := fakeIndexer.Get(context.Background(), "listings", idStr)
+	docMap := doc.(map[string]interface{})
+	assert.Equal(t, int64(12), docMap["downloads_count"])
+	assert.Equal(t, int64(340), docMap["views_count"])
+	assert.Equal(t, "Production Asset", docMap["title"], "other fields must be left alone")
+}
+
+func TestUpdateCounters_NotIndexed_Acknowledges(t *testing.T) {
+	svc := indexing.NewService(indexing.NewInMemoryIndexer(), mockrepo.NewQuerier(t), slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	assert.NoError(t, svc.UpdateCounters(context.Background(), "550e8400e29b41d4a716446655440000", 1, 1, ""))
+}
+
+func TestDeleteListing_RemovesDocument(t *testing.T) {
+	fakeIndexer := indexing.NewInMemoryIndexer()
+	svc := indexing.NewService(fakeIndexer, mockrepo.NewQuerier(t), slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	idStr := "550e8400-e29b-41d4-a716-446655440000"
+	require.NoError(t, fakeIndexer.Upsert(context.Background(), "listings", map[string]any{"id": idStr, "title": "Production Asset"}))
+
+	require.NoError(t, svc.DeleteListing(context.Background(), idStr))
+
+	_, found, _ := fakeIndexer.Get(context.Background(), "listings", idStr)
+	assert.False(t, found)
+}
+
+func TestDeleteListing_NotIndexed_Acknowledges(t *testing.T) {
+	// SCENARIO: The delete is redelivered, or the listing never made it into search.
+	// EXPECT: Typesense's 404 is treated as done, the event isn't retried.
+
+	svc := indexing.NewService(indexing.NewInMemoryIndexer(), mockrepo.NewQuerier(t), slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	assert.NoError(t, svc.DeleteListing(context.Background(), "550e8400-e29b-41d4-a716-446655440000"))
+}
+
+func TestDeleteListing_SearchDown_Retries(t *testing.T) {
+	mockIndexer := mockindexing.NewIndexer(t)
+	svc := indexing.NewService(mockIndexer, mockrepo.NewQuerier(t), slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	mockIndexer.EXPECT().Delete(mock.Anything, "listings", "550e8400-e29b-41d4-a716-446655440000").Return(errors.New("503"))
+
+	err := svc.DeleteListing(context.Background(), "550e8400-e29b-41d4-a716-446655440000")
+
+	assert.Error(t, err)
+	assert.Equal(t, indexing.ErrorClassSearch, indexing.ErrorClass(err))
+}
+
+func TestListingDocument_PhysicalDimensions(t *testing.T) {
+	// SCENARIO: Search filters listings by whether they fit on a printer.
+	// EXPECT: Only physical listings with a full size carry dimensions, everything else has none rather than 0mm.
+
+	source := indexing.NewListingSource(nil, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	tests := []struct {
+		name       string
+		listing    repo.Listing
+		wantDims   []int // x, y, z, nil for none
+		wantWeight bool
+	}{
+		{name: "Physical with a size", listing: fixtures.NewListing(), wantDims: []int{120, 80, 45}, wantWeight: true},
+		{name: "Physical without a size", listing: fixtures.NewListing(fixtures.WithNoDimensions()), wantWeight: true},
+		{name: "Physical with an empty size", listing: fixtures.NewListing(fixtures.With(func(l *repo.Listing) { l.DimensionsMm = []byte(`{}`) })), wantWeight: true},
+		{name: "Digital with a leftover size", listing: fixtures.NewListing(fixtures.With(func(l *repo.Listing) { l.IsPhysical = false }))},
+		{name: "Digital", listing: fixtures.NewListing(fixtures.WithDigital())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := source.Document("listing-1", tt.listing)
+			require.NoError(t, err)
+
+			dims := []*int{doc["dim_x_mm"].(*int), doc["dim_y_mm"].(*int), doc["dim_z_mm"].(*int)}
+			for i, dim := range dims {
+				if tt.wantDims == nil {
+					assert.Nil(t, dim)
+				} else if assert.NotNil(t, dim) {
+					assert.Equal(t, tt.wantDims[i], *dim)
+				}
+			}
+			assert.Equal(t, tt.wantWeight, doc["total_weight_grams"].(*int64) != nil)
+		})
+	}
+}
+
+func TestListingDocument_EmptyArrays(t *testing.T) {
+	// SCENARIO: A listing whose array columns are NULL in Postgres.
+	// EXPECT: The document sends [] for each of them, never null.
+
+	source := indexing.NewListingSource(nil, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+	doc, err := source.Document("listing-1", repo.Listing{})
+	require.NoError(t, err)
+
+	body, err := json.Marshal(doc)
+	require.NoError(t, err)
+	var got map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(body, &got))
+	for _, field := range []string{"categories", "recommended_materials", "hardware_required"} {
+		assert.JSONEq(t, `[]`, string(got[field]), field)
+	}
+}
+
+func TestListingDocument_TimestampsIgnoreLocalZone(t *testing.T) {
+	// SCENARIO: The worker runs with TZ=UTC-5, so pgx hands back every timestamp in that zone.
+	// EXPECT: The document holds the same Unix seconds it would in UTC, the zone never reaches the index.
+
+	zone := time.FixedZone("UTC-5", -5*60*60)
+	createdAt := time.Date(2026, 3, 2, 3, 30, 0, 0, time.UTC)
+	at := func(t time.Time) pgtype.Timestamptz { return pgtype.Timestamptz{Time: t.In(zone), Valid: true} }
+
+	source := indexing.NewListingSource(nil, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+	doc, err := source.Document("listing-1", repo.Listing{
+		CreatedAt:        at(createdAt),
+		UpdatedAt:        at(createdAt.Add(time.Hour)),
+		SaleEndTimestamp: at(createdAt.Add(24 * time.Hour)),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1772422200), doc["created_at"])
+	assert.Equal(t, int64(1772422200+3600), doc["updated_at"])
+	if assert.NotNil(t, doc["sale_end_timestamp"]) {
+		assert.Equal(t, int64(1772422200+86400), *doc["sale_end_timestamp"].(*int64))
+	}
+}
+
+func TestListingDocument_PricesExact(t *testing.T) {
+	// SCENARIO: Listings priced at 19.99 and at just over a million, one on sale, and one never downloaded or viewed.
+	// EXPECT: Both prices reach the index as the exact minor units stored, and the counters are 0, never null.
+
+	source := indexing.NewListingSource(nil, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	tests := []struct {
+		name      string
+		listing   repo.Listing
+		wantPrice string
+		wantSale  string
+	}{
+		{name: "19.99", listing: fixtures.NewListing(fixtures.WithPrice(1999, "usd")), wantPrice: "1999", wantSale: "null"},
+		{name: "1000000.01", listing: fixtures.NewListing(fixtures.WithPrice(100000001, "usd")), wantPrice: "100000001", wantSale: "null"},
+		{
+			name:      "On sale",
+			listing:   fixtures.NewListing(fixtures.WithPrice(100000001, "usd"), fixtures.WithSale("Spring sale", 1999, fixtures.CreatedAt.Add(time.Hour))),
+			wantPrice: "100000001",
+			wantSale:  "1999",
+		},
+		{name: "Never counted", listing: repo.Listing{}, wantPrice: "0", wantSale: "null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := source.Document("listing-1", tt.listing)
+			require.NoError(t, err)
+
+			body, err := json.Marshal(doc)
+			require.NoError(t, err)
+			var got map[string]json.RawMessage
+			require.NoError(t, json.Unmarshal(body, &got))
+			assert.Equal(t, tt.wantPrice, string(got["price_min_unit"]))
+			assert.Equal(t, tt.wantSale, string(got["sale_price"]))
+			for _, field := range []string{"likes_count", "downloads_count", "views_count", "comments_count"} {
+				assert.NotEqual(t, "null", string(got[field]), field)
+			}
+		})
+	}
+}
+
+func TestListingDocument_ContentHash(t *testing.T) {
+	// SCENARIO: The gateway serves its cached listing to a search hit whose content_hash matches it.
+	// EXPECT: The fixture listing hashes to the value the gateway's hydrate test pins, whatever URL the thumbnail is
+	// served from, and the hash changes with the title.
+
+	s3 := indexing.NewListingSource(nil, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+	cdn := indexing.NewListingSource(nil, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files", CDNImageBaseURL: "https://img.example.com"})
+
+	for _, source := range []*indexing.ListingSource{s3, cdn} {
+		doc, err := source.Document(fixtures.ListingID, fixtures.NewListing())
+		require.NoError(t, err)
+		assert.Equal(t, "b1356dd0fc1f50463080b15f11abe682", doc["content_hash"])
+	}
+
+	doc, err := s3.Document(fixtures.ListingID, fixtures.NewListing(fixtures.WithTitle("Benchy v2")))
+	require.NoError(t, err)
+	assert.NotEqual(t, "b1356dd0fc1f50463080b15f11abe682", doc["content_hash"])
+}
@@ -0,0 +1,109 @@
+package indexing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Entity types the worker knows how to index, index events name one in their entity_type field
+const (
+	EntityListing = "listing"
+	EntitySeller  = "seller"
+)
+
+// Action tells the service what to do with the document a source fetched
+type Action int
+
+const (
+	// ActionSkip acknowledges the event without touching the index, e.g. the row isn't ready to be searchable yet
+	ActionSkip Action = iota
+	// ActionUpsert writes the returned document
+	ActionUpsert
+	// ActionDelete removes the document from the index, the returned document is ignored
+	ActionDelete
+)
+
+func (a Action) String() string {
+	switch a {
+	case ActionSkip:
+		return "skip"
+	case ActionUpsert:
+		return "upsert"
+	case ActionDelete:
+		return "delete"
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+// DocumentSource builds the search document for one entity type from the database.
+// A nil error with ActionSkip acks the event, an error retries it, so only return one for transient failures.
+type DocumentSource interface {
+	Fetch(ctx context.Context, id string) (document any, action Action, err error)
+}
+
+// IndexedMarker is implemented by sources that record when an entity was last written to the index
+type IndexedMarker interface {
+	MarkIndexed(ctx context.Context, id string) error
+}
+
+type registeredSource struct {
+	collection string
+	source     DocumentSource
+}
+
+// Register routes index events for entityType to source, writing its documents to collection.
+// Registering the same entity type twice replaces the earlier source.
+func (s *svc) Register(entityType, collection string, source DocumentSource) {
+	s.sources[entityType] = registeredSource{collection: collection, source: source}
+}
+
+// Index rebuilds one document from its source and applies it to the index
+func (s *svc) Index(ctx context.Context, entityType, id string) error {
+	document, action, err := s.Build(ctx, entityType, id)
+	if err != nil {
+		return err
+	}
+	return s.Apply(ctx, entityType, id, document, action)
+}
+
+// Build fetches the document for one entity without touching the index, so it can be inspected before it's written
+func (s *svc) Build(ctx context.Context, entityType, id string) (any, Action, error) {
+	registered, ok := s.sources[entityType]
+	if !ok {
+		// PERMANENT ERROR: Nothing will ever handle it, Ack so it doesn't block the queue
+		s.logger.Error("No document source for entity type, discarding", "entity_type", entityType, "id", id)
+		return nil, ActionSkip, nil
+	}
+	document, action, err := registered.source.Fetch(ctx, id)
+	return document, action, classified(ErrorClassDatabase, err)
+}
+
+// Apply writes a document from Build to the index, or removes it, depending on the action
+func (s *svc) Apply(ctx context.Context, entityType, id string, document any, action Action) error {
+	registered, ok := s.sources[entityType]
+	if !ok || action == ActionSkip {
+		return nil
+	}
+
+	if action == ActionDelete {
+		if err := s.indexer.Delete(ctx, registered.collection, id); err != nil && !errors.Is(err, ErrNotFound) {
+			s.logger.Error("Failed to delete document", "error", err, "collection", registered.collection, "id", id)
+			return classified(ErrorClassSearch, err)
+		}
+		s.logger.Info("Removed document from index", "collection", registered.collection, "id", id)
+		return nil
+	}
+
+	if err := s.indexer.Upsert(ctx, registered.collection, document); err != nil {
+		// TRANSIENT ERROR: Search Engine is down. Return err to Retry.
+		s.logger.Error("Failed to upsert document", "error", err, "collection", registered.collection, "id", id)
+		return classified(ErrorClassSearch, err)
+	}
+
+	s.logger.Info("Successfully indexed document", "collection", registered.collection, "id", id)
+	if marker, ok := registered.source.(IndexedMarker); ok {
+		return classified(ErrorClassDatabase, marker.MarkIndexed(ctx, id))
+	}
+	return nil
+}
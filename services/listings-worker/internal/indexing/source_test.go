@@ -0,0 +1,164 @@
+package indexing_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/indexing"
+	"indexer/internal/mocks/mockrepo"
+	"indexer/internal/publicurl"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// stubSource hands back whatever it was built with
+type stubSource struct {
+	document any
+	action   indexing.Action
+	err      error
+	marked   []string
+}
+
+func (s *stubSource) Fetch(ctx context.Context, id string) (any, indexing.Action, error) {
+	return s.document, s.action, s.err
+}
+
+func (s *stubSource) MarkIndexed(ctx context.Context, id string) error {
+	s.marked = append(s.marked, id)
+	return nil
+}
+
+func TestIndex_DispatchesToRegisteredSource(t *testing.T) {
+	// SCENARIO: A new entity type is registered next to listings.
+	// EXPECT: Its events land in its own collection and the listing source is never asked.
+
+	fakeIndexer := indexing.NewInMemoryIndexer()
+	svc := indexing.NewService(fakeIndexer, mockrepo.NewQuerier(t), slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	source := &stubSource{document: map[string]any{"id": "c1", "name": "Tools"}, action: indexing.ActionUpsert}
+	svc.Register("collection", "collections", source)
+
+	require.NoError(t, svc.Index(context.Background(), "collection", "c1"))
+
+	doc, found, _ := fakeIndexer.Get(context.Background(), "collections", "c1")
+	require.True(t, found)
+	assert.Equal(t, "Tools", doc.(map[string]any)["name"])
+	assert.Equal(t, []string{"c1"}, source.marked)
+}
+
+func TestIndex_Actions(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    *stubSource
+		indexed   bool // Whether a document is already in the index
+		wantErr   bool
+		wantFound bool
+		wantMark  bool
+	}{
+		{"Skip leaves the index alone", &stubSource{action: indexing.ActionSkip}, true, false, true, false},
+		{"Delete removes the document", &stubSource{action: indexing.ActionDelete}, true, false, false, false},
+		{"Delete of a missing document acks", &stubSource{action: indexing.ActionDelete}, false, false, false, false},
+		{"Fetch error retries", &stubSource{err: errors.New("db down")}, true, true, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeIndexer := indexing.NewInMemoryIndexer()
+			svc := indexing.NewService(fakeIndexer, mockrepo.NewQuerier(t), slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+			svc.Register("collection", "collections", tt.source)
+			if tt.indexed {
+				require.NoError(t, fakeIndexer.Upsert(context.Background(), "collections", map[string]any{"id": "c1"}))
+			}
+
+			err := svc.Index(context.Background(), "collection", "c1")
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			_, found, _ := fakeIndexer.Get(context.Background(), "collections", "c1")
+			assert.Equal(t, tt.wantFound, found)
+			assert.Equal(t, tt.wantMark, len(tt.source.marked) > 0)
+		})
+	}
+}
+
+func TestBuild_LeavesIndexAloneUntilApplied(t *testing.T) {
+	// SCENARIO: `--index-one --dry-run` builds a document to print it.
+	// EXPECT: Nothing is written or marked until Apply is called with the same document.
+
+	fakeIndexer := indexing.NewInMemoryIndexer()
+	svc := indexing.NewService(fakeIndexer, mockrepo.NewQuerier(t), slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+	source := &stubSource{document: map[string]any{"id": "c1", "name": "Tools"}, action: indexing.ActionUpsert}
+	svc.Register("collection", "collections", source)
+
+	document, action, err := svc.Build(context.Background(), "collection", "c1")
+	require.NoError(t, err)
+	assert.Equal(t, source.document, document)
+	assert.Equal(t, "upsert", action.String())
+
+	_, found, _ := fakeIndexer.Get(context.Background(), "collections", "c1")
+	assert.False(t, found)
+	assert.Empty(t, source.marked)
+
+	require.NoError(t, svc.Apply(context.Background(), "collection", "c1", document, action))
+	_, found, _ = fakeIndexer.Get(context.Background(), "collections", "c1")
+	assert.True(t, found)
+	assert.Equal(t, []string{"c1"}, source.marked)
+}
+
+func TestIndex_UnknownEntityType_Acknowledges(t *testing.T) {
+	// No expectations, nothing may be fetched for an entity type without a source
+	svc := indexing.NewService(indexing.NewInMemoryIndexer(), mockrepo.NewQuerier(t), slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	assert.NoError(t, svc.Index(context.Background(), "playlist", "p1"))
+}
+
+func TestIndex_Seller(t *testing.T) {
+	const sellerID = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	var userID pgtype.UUID
+	require.NoError(t, userID.Scan(sellerID))
+
+	t.Run("Indexes public profile", func(t *testing.T) {
+		mockRepo := mockrepo.NewQuerier(t)
+		fakeIndexer := indexing.NewInMemoryIndexer()
+		svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+		mockRepo.EXPECT().GetSellerByUserID(mock.Anything, userID).Return(repo.Seller{
+			UserID:       userID,
+			DisplayName:  "Jane's Prints",
+			Country:      "GB",
+			PayoutStatus: repo.PayoutStatusVERIFIED,
+		}, nil)
+
+		require.NoError(t, svc.Index(context.Background(), indexing.EntitySeller, sellerID))
+
+		doc, found, _ := fakeIndexer.Get(context.Background(), "sellers", sellerID)
+		require.True(t, found)
+		docMap := doc.(map[string]any)
+		assert.Equal(t, "Jane's Prints", docMap["display_name"])
+		assert.NotContains(t, docMap, "payout_status")
+	})
+
+	t.Run("Missing profile removes document", func(t *testing.T) {
+		mockRepo := mockrepo.NewQuerier(t)
+		fakeIndexer := indexing.NewInMemoryIndexer()
+		svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+		require.NoError(t, fakeIndexer.Upsert(context.Background(), "sellers", map[string]any{"id": sellerID}))
+
+		mockRepo.EXPECT().GetSellerByUserID(mock.Anything, userID).Return(repo.Seller{}, pgx.ErrNoRows)
+
+		require.NoError(t, svc.Index(context.Background(), indexing.EntitySeller, sellerID))
+
+		_, found, _ := fakeIndexer.Get(context.Background(), "sellers", sellerID)
+		assert.False(t, found)
+	})
+}
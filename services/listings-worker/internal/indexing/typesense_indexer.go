@@ -2,24 +2,45 @@ package indexing
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"shared/typesensehttp"
 	"time"
 
 	"github.com/typesense/typesense-go/typesense"
+	"github.com/typesense/typesense-go/typesense/api"
+	"github.com/typesense/typesense-go/typesense/api/circuit"
 )
 
 type TypesenseClient struct {
 	client *typesense.Client
 }
 
-func NewClient(apiKey, url string) Indexer {
+func NewClient(apiKey, url string, logger *slog.Logger) Indexer {
 	client := typesense.NewClient(
-		typesense.WithServer(url),
-		typesense.WithAPIKey(apiKey),
+		// Same timeout typesense.NewClient defaults to
+		typesense.WithAPIClient(newAPIClient(apiKey, url, 5*time.Second, logger)),
 	)
 	return &TypesenseClient{client: client}
 }
 
+// newAPIClient builds the same client typesense.NewClient would, circuit breaker included, with the instrumented
+// transport underneath, the only way typesense-go takes one
+func newAPIClient(apiKey, url string, timeout time.Duration, logger *slog.Logger) typesense.APIClientInterface {
+	httpClient := circuit.NewHTTPClient(
+		circuit.WithHTTPRequestDoer(&http.Client{
+			Timeout:   timeout,
+			Transport: typesensehttp.NewTransport(nil, typesenseMetrics, logger),
+		}),
+		circuit.WithCircuitBreaker(circuit.NewGoBreaker(circuit.WithGoBreakerName("typesenseClient"))),
+	)
+	// Only errors when an option does, neither of these can
+	apiClient, _ := api.NewClientWithResponses(url, api.WithAPIKey(apiKey), api.WithHTTPClient(httpClient))
+	return apiClient
+}
+
 func (t *TypesenseClient) Upsert(ctx context.Context, collectionName string, document any) error {
 	// Typesense "Upsert" logic
 	_, err := t.client.Collection(collectionName).Documents().Upsert(ctx, document)
@@ -30,9 +51,25 @@ func (t *TypesenseClient) Upsert(ctx context.Context, collectionName string, doc
 	return nil
 }
 
+func (t *TypesenseClient) Update(ctx context.Context, collectionName string, id string, fields map[string]any) error {
+	_, err := t.client.Collection(collectionName).Document(id).Update(ctx, fields)
+	if err != nil {
+		var httpErr *typesense.HTTPError
+		if errors.As(err, &httpErr) && httpErr.Status == http.StatusNotFound {
+			return ErrNotFound
+		}
+		return fmt.Errorf("typesense update failed: %w", err)
+	}
+	return nil
+}
+
 func (t *TypesenseClient) Delete(ctx context.Context, collectionName string, id string) error {
 	_, err := t.client.Collection(collectionName).Document(id).Delete(ctx)
 	if err != nil {
+		var httpErr *typesense.HTTPError
+		if errors.As(err, &httpErr) && httpErr.Status == http.StatusNotFound {
+			return ErrNotFound
+		}
 		return fmt.Errorf("typesense delete failed: %w", err)
 	}
 	return nil
@@ -46,11 +83,51 @@ func (t *TypesenseClient) Close() error {
 func (t *TypesenseClient) Get(ctx context.Context, collectionName string, id string) (any, bool, error) {
 	document, err := t.client.Collection(collectionName).Document(id).Retrieve(ctx)
 	if err != nil {
+		var httpErr *typesense.HTTPError
+		if errors.As(err, &httpErr) && httpErr.Status == http.StatusNotFound {
+			return nil, false, nil
+		}
 		return nil, false, fmt.Errorf("typesense get failed: %w", err)
 	}
 	return document, true, nil
 }
 
+func (t *TypesenseClient) SearchIDs(ctx context.Context, collectionName string, query SearchQuery) ([]string, error) {
+	perPage := min(max(query.Limit, 1), MaxSearchLimit)
+	includeFields := "id"
+	params := &api.SearchCollectionParams{
+		Q:             query.Q,
+		QueryBy:       query.QueryBy,
+		PerPage:       &perPage,
+		IncludeFields: &includeFields,
+	}
+	if query.FilterBy != "" {
+		params.FilterBy = &query.FilterBy
+	}
+	if query.SortBy != "" {
+		params.SortBy = &query.SortBy
+	}
+
+	resp, err := t.client.Collection(collectionName).Documents().Search(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("typesense search failed: %w", err)
+	}
+	if resp.Hits == nil {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(*resp.Hits))
+	for _, hit := range *resp.Hits {
+		if hit.Document == nil {
+			continue
+		}
+		if id, ok := (*hit.Document)["id"].(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
 func (t *TypesenseClient) Count(ctx context.Context, collectionName string) (int64, error) {
 	resp, err := t.client.Collection(collectionName).Retrieve(ctx)
 	if err != nil {
@@ -59,6 +136,23 @@ func (t *TypesenseClient) Count(ctx context.Context, collectionName string) (int
 	return *resp.NumDocuments, nil
 }
 
+func (t *TypesenseClient) Fields(ctx context.Context, collectionName string) ([]string, error) {
+	resp, err := t.client.Collection(collectionName).Retrieve(ctx)
+	if err != nil {
+		var httpErr *typesense.HTTPError
+		if errors.As(err, &httpErr) && httpErr.Status == http.StatusNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("typesense schema lookup failed: %w", err)
+	}
+
+	fields := make([]string, len(resp.Fields))
+	for i, field := range resp.Fields {
+		fields[i] = field.Name
+	}
+	return fields, nil
+}
+
 func (t *TypesenseClient) HealthCheck(ctx context.Context) error {
 	isHealthy, err := t.client.Health(ctx, time.Second*5)
 	if err != nil {
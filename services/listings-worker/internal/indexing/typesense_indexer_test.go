@@ -0,0 +1,58 @@
+package indexing
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// observedCount reads how many values a histogram series has seen
+func observedCount(t *testing.T, operation string) uint64 {
+	t.Helper()
+
+	var metric dto.Metric
+	require.NoError(t, typesenseMetrics.RequestDuration.WithLabelValues(operation).(prometheus.Histogram).Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestTypesenseClient_RecordsMetrics(t *testing.T) {
+	// SCENARIO: The worker upserts a listing and removes another that Typesense never had.
+	// EXPECT: Both calls are observed per operation, the delete counted as a 4xx without being retried.
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Could not find a document with id: missing"}`))
+			return
+		}
+		assert.Equal(t, "upsert", r.URL.Query().Get("action"))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": "listing-1"}`))
+	}))
+	defer server.Close()
+
+	beforeUpserts := observedCount(t, "documents.upsert")
+	beforeDeletes := observedCount(t, "documents.delete")
+	beforeNotFound := promtest.ToFloat64(typesenseMetrics.Requests.WithLabelValues("documents.delete", "4xx"))
+	beforeRetries := promtest.ToFloat64(typesenseMetrics.Retries.WithLabelValues("documents.delete"))
+
+	client := NewClient("admin-key", server.URL, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+
+	require.NoError(t, client.Upsert(context.Background(), "listings", map[string]any{"id": "listing-1", "title": "Benchy"}))
+	assert.ErrorIs(t, client.Delete(context.Background(), "listings", "missing"), ErrNotFound)
+
+	assert.Equal(t, beforeUpserts+1, observedCount(t, "documents.upsert"))
+	assert.Equal(t, beforeDeletes+1, observedCount(t, "documents.delete"))
+	assert.Equal(t, beforeNotFound+1, promtest.ToFloat64(typesenseMetrics.Requests.WithLabelValues("documents.delete", "4xx")))
+	assert.Equal(t, beforeRetries, promtest.ToFloat64(typesenseMetrics.Retries.WithLabelValues("documents.delete")))
+}
@@ -0,0 +1,94 @@
+package indexing
+
+import (
+	"context"
+	"fmt"
+	repo "indexer/internal/database/postgresql/sqlc"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// SyncVacations reindexes the listings of every seller whose vacation started or ended since their listings were
+// last indexed, so search hides them while the seller is away and brings them back after. Sellers are handled in
+// batches of batchSize, as are their listings. A seller is only marked as done once all of their listings have
+// been reindexed, a failure leaves them for the next pass. Returns how many sellers were brought up to date.
+func (s *svc) SyncVacations(ctx context.Context, batchSize int) (int, error) {
+	sellers, err := s.reader.GetSellersWithVacationChanges(ctx, int32(batchSize))
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch sellers with vacation changes: %w", err)
+	}
+
+	synced := 0
+	for _, seller := range sellers {
+		if err := ctx.Err(); err != nil {
+			return synced, err
+		}
+		if err := s.syncVacation(ctx, seller, batchSize); err != nil {
+			s.logger.Error("Failed to sync seller vacation", "error", err, "seller_id", seller.UserID.String())
+			continue
+		}
+		synced++
+	}
+
+	if synced > 0 {
+		s.logger.Info("Vacation sync complete", "synced", synced, "sellers", len(sellers))
+	}
+	return synced, nil
+}
+
+// syncVacation reindexes one seller's listings and records the vacation state they were indexed for
+func (s *svc) syncVacation(ctx context.Context, seller repo.GetSellersWithVacationChangesRow, batchSize int) error {
+	// Taken before reindexing, a boundary crossed part way through is picked up on the next pass
+	now := time.Now()
+
+	if err := s.reindexSeller(ctx, seller.UserID, batchSize); err != nil {
+		return err
+	}
+
+	// The guards on both queries skip the update when the seller changed the vacation while we were reindexing
+	if !seller.VacationEndsAt.Time.After(now) {
+		return s.repo.ClearSellerVacation(ctx, repo.ClearSellerVacationParams{
+			UserID: seller.UserID,
+			EndsAt: seller.VacationEndsAt,
+		})
+	}
+	return s.repo.SetSellerVacationApplied(ctx, repo.SetSellerVacationAppliedParams{
+		Applied:  !seller.VacationStartsAt.Time.After(now),
+		UserID:   seller.UserID,
+		StartsAt: seller.VacationStartsAt,
+		EndsAt:   seller.VacationEndsAt,
+	})
+}
+
+// reindexSeller pushes every live listing of the seller back through IndexListing, stopping at the first failure
+func (s *svc) reindexSeller(ctx context.Context, sellerID pgtype.UUID, batchSize int) error {
+	afterID := pgtype.UUID{Valid: true} // Zero UUID sorts first
+
+	for {
+		ids, err := s.reader.GetSellerListingIDs(ctx, repo.GetSellerListingIDsParams{
+			SellerID:  sellerID,
+			AfterID:   afterID,
+			BatchSize: int32(batchSize),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to fetch seller's listings: %w", err)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		for _, id := range ids {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			// Same dashless format the gateway publishes, so we overwrite the existing document
+			listingID := fmt.Sprintf("%x", id.Bytes)
+			if err := s.IndexListing(ctx, listingID); err != nil {
+				return fmt.Errorf("failed to reindex listing %s: %w", listingID, err)
+			}
+		}
+
+		afterID = ids[len(ids)-1]
+	}
+}
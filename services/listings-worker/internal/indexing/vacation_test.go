@@ -0,0 +1,172 @@
+package indexing_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/indexing"
+	"indexer/internal/mocks/mockrepo"
+	"indexer/internal/publicurl"
+	"indexer/internal/testutil/fixtures"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func vacationListing(id, sellerID pgtype.UUID) repo.Listing {
+	return fixtures.NewListing(fixtures.With(func(l *repo.Listing) {
+		l.ID = id
+		l.SellerID = sellerID
+	}))
+}
+
+func TestSyncVacations_StartedVacation_HidesListings(t *testing.T) {
+	// SCENARIO: A seller's vacation has started and their listings are still indexed as available.
+	// EXPECT: Every listing is reindexed as on vacation, page by page, and the vacation is marked as applied.
+
+	mockRepo := mockrepo.NewQuerier(t)
+	fakeIndexer := indexing.NewInMemoryIndexer()
+	svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	sellerID := pgtype.UUID{Bytes: [16]byte{0: 1}, Valid: true}
+	startsAt := pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true}
+	endsAt := pgtype.Timestamptz{Time: time.Now().Add(24 * time.Hour), Valid: true}
+
+	ids := make([]pgtype.UUID, 3)
+	for i := range ids {
+		ids[i] = pgtype.UUID{Bytes: [16]byte{15: byte(i + 1)}, Valid: true}
+		mockRepo.EXPECT().GetListingByID(mock.Anything, ids[i]).Return(vacationListing(ids[i], sellerID), nil)
+		mockRepo.EXPECT().MarkListingAsIndexed(mock.Anything, ids[i]).Return(nil)
+	}
+	mockRepo.EXPECT().GetLatestPriceChange(mock.Anything, mock.Anything).Return(repo.ListingPriceHistory{}, pgx.ErrNoRows)
+	mockRepo.EXPECT().GetFeatureWindows(mock.Anything, mock.Anything).Return(nil, nil)
+	mockRepo.EXPECT().GetFilesByListingID(mock.Anything, mock.Anything).Return(nil, nil)
+	mockRepo.EXPECT().GetCheapestVariantPrice(mock.Anything, mock.Anything).Return(0, pgx.ErrNoRows)
+	mockRepo.EXPECT().GetSellerLastActive(mock.Anything, mock.Anything).Return(pgtype.Timestamptz{}, nil)
+	mockRepo.EXPECT().GetSellerVacation(mock.Anything, sellerID).
+		Return(repo.GetSellerVacationRow{VacationStartsAt: startsAt, VacationEndsAt: endsAt}, nil)
+
+	mockRepo.EXPECT().GetSellersWithVacationChanges(mock.Anything, int32(2)).
+		Return([]repo.GetSellersWithVacationChangesRow{{UserID: sellerID, VacationStartsAt: startsAt, VacationEndsAt: endsAt}}, nil)
+	mockRepo.EXPECT().GetSellerListingIDs(mock.Anything, repo.GetSellerListingIDsParams{SellerID: sellerID, AfterID: pgtype.UUID{Valid: true}, BatchSize: 2}).
+		Return(ids[:2], nil)
+	mockRepo.EXPECT().GetSellerListingIDs(mock.Anything, repo.GetSellerListingIDsParams{SellerID: sellerID, AfterID: ids[1], BatchSize: 2}).
+		Return(ids[2:], nil)
+	mockRepo.EXPECT().GetSellerListingIDs(mock.Anything, repo.GetSellerListingIDsParams{SellerID: sellerID, AfterID: ids[2], BatchSize: 2}).
+		Return([]pgtype.UUID{}, nil)
+	mockRepo.EXPECT().SetSellerVacationApplied(mock.Anything, repo.SetSellerVacationAppliedParams{
+		Applied: true, UserID: sellerID, StartsAt: startsAt, EndsAt: endsAt,
+	}).Return(nil)
+
+	synced, err := svc.SyncVacations(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, synced)
+
+	for _, id := range ids {
+		doc, found, _ := fakeIndexer.Get(context.Background(), "listings", fmt.Sprintf("%x", id.Bytes))
+		require.True(t, found)
+		assert.Equal(t, true, doc.(map[string]any)["seller_on_vacation"])
+	}
+}
+
+func TestSyncVacations_ReplicaReadsPrimaryWrites(t *testing.T) {
+	// SCENARIO: The worker has a read replica and a seller's vacation has ended.
+	// EXPECT: The sellers and their listings are found on the replica, the listing is indexed from the primary and
+	// the vacation is cleared there.
+
+	primary := mockrepo.NewQuerier(t)
+	replica := mockrepo.NewQuerier(t)
+	svc := indexing.NewServiceWithReader(indexing.NewInMemoryIndexer(), primary, replica, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	sellerID := pgtype.UUID{Bytes: [16]byte{0: 1}, Valid: true}
+	id := pgtype.UUID{Bytes: [16]byte{15: 1}, Valid: true}
+	startsAt := pgtype.Timestamptz{Time: time.Now().Add(-48 * time.Hour), Valid: true}
+	endsAt := pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true}
+
+	replica.EXPECT().GetSellersWithVacationChanges(mock.Anything, int32(10)).
+		Return([]repo.GetSellersWithVacationChangesRow{{UserID: sellerID, VacationStartsAt: startsAt, VacationEndsAt: endsAt}}, nil)
+	replica.EXPECT().GetSellerListingIDs(mock.Anything, repo.GetSellerListingIDsParams{SellerID: sellerID, AfterID: pgtype.UUID{Valid: true}, BatchSize: 10}).
+		Return([]pgtype.UUID{id}, nil)
+	replica.EXPECT().GetSellerListingIDs(mock.Anything, repo.GetSellerListingIDsParams{SellerID: sellerID, AfterID: id, BatchSize: 10}).
+		Return([]pgtype.UUID{}, nil)
+	primary.EXPECT().GetListingByID(mock.Anything, id).Return(vacationListing(id, sellerID), nil)
+	primary.EXPECT().GetLatestPriceChange(mock.Anything, id).Return(repo.ListingPriceHistory{}, pgx.ErrNoRows)
+	primary.EXPECT().GetFeatureWindows(mock.Anything, id).Return(nil, nil)
+	primary.EXPECT().GetFilesByListingID(mock.Anything, id).Return(nil, nil)
+	primary.EXPECT().GetCheapestVariantPrice(mock.Anything, id).Return(0, pgx.ErrNoRows)
+	primary.EXPECT().GetSellerLastActive(mock.Anything, mock.Anything).Return(pgtype.Timestamptz{}, nil)
+	primary.EXPECT().GetSellerVacation(mock.Anything, sellerID).Return(repo.GetSellerVacationRow{}, pgx.ErrNoRows)
+	primary.EXPECT().MarkListingAsIndexed(mock.Anything, id).Return(nil)
+	primary.EXPECT().ClearSellerVacation(mock.Anything, repo.ClearSellerVacationParams{UserID: sellerID, EndsAt: endsAt}).Return(nil)
+
+	synced, err := svc.SyncVacations(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, synced)
+}
+
+func TestSyncVacations_EndedVacation_Cleared(t *testing.T) {
+	// SCENARIO: A seller's vacation has ended, on schedule or because they came back early.
+	// EXPECT: The listings are reindexed as available and the vacation is cleared from the profile.
+
+	mockRepo := mockrepo.NewQuerier(t)
+	fakeIndexer := indexing.NewInMemoryIndexer()
+	svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	sellerID := pgtype.UUID{Bytes: [16]byte{0: 1}, Valid: true}
+	listingID := pgtype.UUID{Bytes: [16]byte{15: 1}, Valid: true}
+	startsAt := pgtype.Timestamptz{Time: time.Now().Add(-48 * time.Hour), Valid: true}
+	endsAt := pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true}
+
+	mockRepo.EXPECT().GetSellersWithVacationChanges(mock.Anything, mock.Anything).
+		Return([]repo.GetSellersWithVacationChangesRow{{UserID: sellerID, VacationStartsAt: startsAt, VacationEndsAt: endsAt}}, nil)
+	mockRepo.EXPECT().GetSellerListingIDs(mock.Anything, mock.Anything).Return([]pgtype.UUID{listingID}, nil).Once()
+	mockRepo.EXPECT().GetSellerListingIDs(mock.Anything, mock.Anything).Return([]pgtype.UUID{}, nil).Once()
+	mockRepo.EXPECT().GetListingByID(mock.Anything, listingID).Return(vacationListing(listingID, sellerID), nil)
+	mockRepo.EXPECT().GetLatestPriceChange(mock.Anything, mock.Anything).Return(repo.ListingPriceHistory{}, pgx.ErrNoRows)
+	mockRepo.EXPECT().GetFeatureWindows(mock.Anything, mock.Anything).Return(nil, nil)
+	mockRepo.EXPECT().GetFilesByListingID(mock.Anything, mock.Anything).Return(nil, nil)
+	mockRepo.EXPECT().GetCheapestVariantPrice(mock.Anything, mock.Anything).Return(0, pgx.ErrNoRows)
+	mockRepo.EXPECT().GetSellerLastActive(mock.Anything, mock.Anything).Return(pgtype.Timestamptz{}, nil)
+	mockRepo.EXPECT().GetSellerVacation(mock.Anything, sellerID).
+		Return(repo.GetSellerVacationRow{VacationStartsAt: startsAt, VacationEndsAt: endsAt}, nil)
+	mockRepo.EXPECT().MarkListingAsIndexed(mock.Anything, listingID).Return(nil)
+	mockRepo.EXPECT().ClearSellerVacation(mock.Anything, repo.ClearSellerVacationParams{UserID: sellerID, EndsAt: endsAt}).Return(nil)
+
+	synced, err := svc.SyncVacations(context.Background(), 100)
+	require.NoError(t, err)
+	assert.Equal(t, 1, synced)
+
+	doc, found, _ := fakeIndexer.Get(context.Background(), "listings", fmt.Sprintf("%x", listingID.Bytes))
+	require.True(t, found)
+	assert.Equal(t, false, doc.(map[string]any)["seller_on_vacation"])
+}
+
+func TestSyncVacations_ReindexFails_LeftForNextPass(t *testing.T) {
+	// SCENARIO: The database fails while one seller's listings are being reindexed.
+	// EXPECT: That seller isn't marked as done so the next pass retries them, and the run itself carries on.
+
+	mockRepo := mockrepo.NewQuerier(t)
+	svc := indexing.NewService(indexing.NewInMemoryIndexer(), mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	mockRepo.EXPECT().GetSellersWithVacationChanges(mock.Anything, mock.Anything).
+		Return([]repo.GetSellersWithVacationChangesRow{{
+			UserID:           pgtype.UUID{Bytes: [16]byte{0: 1}, Valid: true},
+			VacationStartsAt: pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true},
+			VacationEndsAt:   pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+		}}, nil)
+	mockRepo.EXPECT().GetSellerListingIDs(mock.Anything, mock.Anything).Return(nil, errors.New("connection refused"))
+
+	synced, err := svc.SyncVacations(context.Background(), 100)
+	require.NoError(t, err)
+	assert.Equal(t, 0, synced)
+	mockRepo.AssertNotCalled(t, "SetSellerVacationApplied", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "ClearSellerVacation", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,23 @@
+package indexing
+
+import (
+	"context"
+	"errors"
+	repo "indexer/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// searchPrice is the listing's price_min_unit in search. A listing sold as variants goes in at its cheapest one, so
+// sorting by price places it at the least a buyer can pay. The gateway reindexes the listing whenever a variant is
+// added or removed.
+func (l *ListingSource) searchPrice(ctx context.Context, listing repo.Listing) (int64, error) {
+	price, err := l.repo.GetCheapestVariantPrice(ctx, listing.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return listing.PriceMinUnit, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return price, nil
+}
@@ -0,0 +1,52 @@
+package indexing_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/indexing"
+	"indexer/internal/mocks/mockrepo"
+	"indexer/internal/publicurl"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexListing_VariantPrice(t *testing.T) {
+	// SCENARIO: A listing priced at 1050 is indexed with and without variants.
+	// EXPECT: With variants price_min_unit is the cheapest one's, a free preview makes it 0. Without, the listing's.
+
+	assert.Equal(t, int64(0), indexWith(t, nil, 0, nil)["price_min_unit"])
+	assert.Equal(t, int64(1500), indexWith(t, nil, 1500, nil)["price_min_unit"])
+	assert.Equal(t, int64(1050), indexWith(t, nil, 0, pgx.ErrNoRows)["price_min_unit"])
+}
+
+func TestBackfill_VariantPrice(t *testing.T) {
+	// SCENARIO: price_min_unit is backfilled onto a listing indexed at its own price before it had variants.
+	// EXPECT: The cheapest variant's price is written.
+
+	mockRepo := mockrepo.NewQuerier(t)
+	fakeIndexer := indexing.NewInMemoryIndexer().(*indexing.InMemoryIndexer)
+	fakeIndexer.SetFields("listings", "id", "price_min_unit")
+	svc := indexing.NewService(fakeIndexer, mockRepo, slog.Default(), publicurl.Config{AssetsBaseURL: "http://s3.amazonaws.com/public-files"})
+
+	listings := backfillListings(1)
+	require.NoError(t, fakeIndexer.Upsert(context.Background(), "listings", map[string]any{
+		"id":             documentID(listings[0]),
+		"price_min_unit": listings[0].PriceMinUnit,
+	}))
+	mockRepo.EXPECT().GetListingsForBackfill(mock.Anything, mock.Anything).Return(listings, nil).Once()
+	mockRepo.EXPECT().GetListingsForBackfill(mock.Anything, mock.Anything).Return([]repo.Listing{}, nil).Once()
+	mockRepo.EXPECT().GetCheapestVariantPrice(mock.Anything, listings[0].ID).Return(int64(250), nil)
+
+	progress, err := svc.Backfill(context.Background(), indexing.BackfillOptions{Fields: []string{"price_min_unit"}, BatchSize: 10})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, progress.Updated)
+	doc, _, _ := fakeIndexer.Get(context.Background(), "listings", documentID(listings[0]))
+	assert.Equal(t, int64(250), doc.(map[string]any)["price_min_unit"])
+}
@@ -0,0 +1,181 @@
+// Package lock keeps background jobs from running on more than one replica at a time. A lock is a single Redis key
+// holding a fencing token, renewed while held and only released by the holder that set it.
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultTTL is how long a lock outlives a holder that stopped renewing it, e.g. one that crashed
+const DefaultTTL = 30 * time.Second
+
+var (
+	// ErrNotAcquired means another instance holds the lock
+	ErrNotAcquired = errors.New("lock is held by another instance")
+	// ErrNotHeld means the lock expired and may have been taken over since it was acquired
+	ErrNotHeld = errors.New("lock is no longer held")
+)
+
+// The fence counter is never deleted, so every acquire gets a higher token than the last, even after expiry.
+// KEYS[1] lock, KEYS[2] fence counter, ARGV[1] ttl in ms
+var acquireScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return 0
+end
+local token = redis.call("INCR", KEYS[2])
+redis.call("SET", KEYS[1], token, "PX", ARGV[1])
+return token
+`)
+
+// KEYS[1] lock, ARGV[1] token, ARGV[2] ttl in ms
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// KEYS[1] lock, ARGV[1] token
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type Locker struct {
+	rdb    *redis.Client
+	logger *slog.Logger
+}
+
+func New(rdb *redis.Client, logger *slog.Logger) *Locker {
+	return &Locker{rdb: rdb, logger: logger}
+}
+
+// Lock is one held lock. Its context is cancelled if renewal finds the lock gone, the holder should stop writing.
+type Lock struct {
+	name   string
+	token  int64
+	ttl    time.Duration
+	locker *Locker
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+func key(name string) string {
+	// The braces keep the lock and its fence in the same slot on Redis Cluster, the scripts touch both
+	return "lock:{" + name + "}"
+}
+
+// Acquire takes the lock called name for ttl, renewing it every ttl/3 until Release. Returns ErrNotAcquired
+// without waiting if another instance holds it.
+func (l *Locker) Acquire(ctx context.Context, name string, ttl time.Duration) (*Lock, error) {
+	token, err := acquireScript.Run(ctx, l.rdb, []string{key(name), key(name) + ":fence"}, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+	if token == 0 {
+		return nil, ErrNotAcquired
+	}
+
+	lockCtx, cancel := context.WithCancel(ctx)
+	lock := &Lock{
+		name:   name,
+		token:  token,
+		ttl:    ttl,
+		locker: l,
+		ctx:    lockCtx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go lock.renew()
+
+	return lock, nil
+}
+
+// Token is the fencing token, higher than any token handed out for this lock before
+func (lock *Lock) Token() int64 {
+	return lock.token
+}
+
+// Context is cancelled once the lock is lost or released
+func (lock *Lock) Context() context.Context {
+	return lock.ctx
+}
+
+func (lock *Lock) renew() {
+	defer close(lock.done)
+
+	ticker := time.NewTicker(lock.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lock.ctx.Done():
+			return
+		case <-ticker.C:
+			renewed, err := renewScript.Run(lock.ctx, lock.locker.rdb, []string{key(lock.name)}, lock.token, lock.ttl.Milliseconds()).Int64()
+			if err != nil {
+				// A blip in Redis isn't a lost lock, the next tick tries again before the TTL runs out
+				lock.locker.logger.Warn("Failed to renew lock", "lock", lock.name, "error", err)
+				continue
+			}
+			if renewed == 0 {
+				lock.locker.logger.Error("Lock lost, another instance may have taken it over", "lock", lock.name, "token", lock.token)
+				lock.cancel()
+				return
+			}
+		}
+	}
+}
+
+// Release stops renewal and deletes the lock if this holder still owns it. Returns ErrNotHeld if it expired,
+// a newer holder's lock is left alone.
+func (lock *Lock) Release(ctx context.Context) error {
+	var err error
+	lock.once.Do(func() {
+		lock.cancel()
+		<-lock.done
+
+		var released int64
+		released, err = releaseScript.Run(ctx, lock.locker.rdb, []string{key(lock.name)}, lock.token).Int64()
+		if err != nil {
+			err = fmt.Errorf("failed to release lock %s: %w", lock.name, err)
+			return
+		}
+		if released == 0 {
+			err = ErrNotHeld
+		}
+	})
+	return err
+}
+
+// RunExclusive runs fn while holding the lock called name, or returns ErrNotAcquired straight away if another
+// instance is already running it. fn's context is cancelled if the lock is lost part way through.
+func (l *Locker) RunExclusive(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	lock, err := l.Acquire(ctx, name, DefaultTTL)
+	if err != nil {
+		return err
+	}
+
+	fnErr := fn(lock.Context())
+
+	// Released with a fresh context, the job's may already be cancelled on shutdown
+	releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := lock.Release(releaseCtx); err != nil {
+		l.logger.Warn("Failed to release lock", "lock", name, "error", err)
+	}
+
+	return fnErr
+}
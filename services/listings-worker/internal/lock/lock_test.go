@@ -0,0 +1,168 @@
+package lock_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"indexer/internal/lock"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLocker(t *testing.T) (*lock.Locker, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	return lock.New(rdb, slog.New(slog.NewTextHandler(io.Discard, nil))), mr
+}
+
+func TestAcquire_Contention(t *testing.T) {
+	// SCENARIO: Two replicas try to take the same lock.
+	// EXPECT: The second gets ErrNotAcquired until the first releases it.
+
+	locker, _ := newLocker(t)
+	ctx := context.Background()
+
+	first, err := locker.Acquire(ctx, "purge", time.Minute)
+	require.NoError(t, err)
+
+	_, err = locker.Acquire(ctx, "purge", time.Minute)
+	assert.ErrorIs(t, err, lock.ErrNotAcquired)
+
+	// Other names are independent
+	other, err := locker.Acquire(ctx, "counters", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, other.Release(ctx))
+
+	require.NoError(t, first.Release(ctx))
+
+	second, err := locker.Acquire(ctx, "purge", time.Minute)
+	require.NoError(t, err)
+	assert.Greater(t, second.Token(), first.Token())
+	require.NoError(t, second.Release(ctx))
+}
+
+func TestAcquire_ExpiryTakeover(t *testing.T) {
+	// SCENARIO: The holder stops renewing, e.g. it crashed, and the TTL runs out.
+	// EXPECT: Another replica takes the lock with a higher fencing token.
+
+	locker, mr := newLocker(t)
+	ctx := context.Background()
+
+	stale, err := locker.Acquire(ctx, "purge", time.Minute)
+	require.NoError(t, err)
+
+	mr.FastForward(2 * time.Minute)
+
+	fresh, err := locker.Acquire(ctx, "purge", time.Minute)
+	require.NoError(t, err)
+	assert.Greater(t, fresh.Token(), stale.Token())
+	require.NoError(t, fresh.Release(ctx))
+}
+
+func TestRelease_WrongToken(t *testing.T) {
+	// SCENARIO: A stale holder releases after its lock expired and was taken over.
+	// EXPECT: ErrNotHeld, and the newer holder keeps the lock.
+
+	locker, mr := newLocker(t)
+	ctx := context.Background()
+
+	stale, err := locker.Acquire(ctx, "purge", time.Minute)
+	require.NoError(t, err)
+	mr.FastForward(2 * time.Minute)
+	fresh, err := locker.Acquire(ctx, "purge", time.Minute)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, stale.Release(ctx), lock.ErrNotHeld)
+
+	_, err = locker.Acquire(ctx, "purge", time.Minute)
+	assert.ErrorIs(t, err, lock.ErrNotAcquired)
+	require.NoError(t, fresh.Release(ctx))
+}
+
+func TestRenew_CancelsContextWhenLost(t *testing.T) {
+	// SCENARIO: The lock disappears from under a holder while it's working.
+	// EXPECT: The next renewal notices and cancels the holder's context.
+
+	locker, mr := newLocker(t)
+	ctx := context.Background()
+
+	held, err := locker.Acquire(ctx, "purge", 90*time.Millisecond)
+	require.NoError(t, err)
+
+	mr.Set("lock:{purge}", "999")
+
+	select {
+	case <-held.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("lock context was not cancelled after the lock was lost")
+	}
+	assert.ErrorIs(t, held.Release(ctx), lock.ErrNotHeld)
+}
+
+func TestRenew_ExtendsTTL(t *testing.T) {
+	locker, mr := newLocker(t)
+	ctx := context.Background()
+
+	held, err := locker.Acquire(ctx, "purge", 90*time.Millisecond)
+	require.NoError(t, err)
+	defer held.Release(ctx)
+
+	// miniredis only counts TTLs down on FastForward, shorten it and wait for a renewal to restore it
+	mr.SetTTL("lock:{purge}", time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return mr.TTL("lock:{purge}") == 90*time.Millisecond
+	}, time.Second, 10*time.Millisecond)
+	assert.NoError(t, held.Context().Err())
+}
+
+func TestRunExclusive(t *testing.T) {
+	// SCENARIO: Several replicas start the same job at once.
+	// EXPECT: It runs once, the rest get ErrNotAcquired, and the lock is free afterwards.
+
+	locker, _ := newLocker(t)
+	ctx := context.Background()
+
+	var runs atomic.Int32
+	started := make(chan struct{})
+	finish := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		err := locker.RunExclusive(ctx, "purge", func(ctx context.Context) error {
+			runs.Add(1)
+			close(started)
+			<-finish
+			return nil
+		})
+		assert.NoError(t, err)
+	}()
+	<-started
+
+	for range 3 {
+		err := locker.RunExclusive(ctx, "purge", func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		})
+		assert.ErrorIs(t, err, lock.ErrNotAcquired)
+	}
+
+	close(finish)
+	wg.Wait()
+	assert.Equal(t, int32(1), runs.Load())
+
+	require.NoError(t, locker.RunExclusive(ctx, "purge", func(ctx context.Context) error { return nil }))
+}
@@ -0,0 +1,9 @@
+// Package mocks holds the generated testify mocks for the worker's interfaces, see .mockery.yaml in the
+// service root for the list. Each source package gets its own subpackage (mockrepo, mockindexing, ...)
+// so a package's own tests never import a mock that imports them back.
+//
+// Prefer these for wiring tests. InMemoryIndexer stays the fake of choice when a test needs a search
+// index that actually stores documents.
+package mocks
+
+//go:generate sh -c "cd ../.. && go run github.com/vektra/mockery/v2@v2.53.7"
@@ -0,0 +1,31 @@
+// Package notifications reacts to listing lifecycle events. Nothing is sent yet, it's the place email
+// notifications to sellers will live and shows how a fan-out consumer is wired up in main.
+package notifications
+
+import (
+	"indexer/internal/events"
+	"log/slog"
+)
+
+// Consumer is this package's durable suffix on the LISTINGS stream
+const Consumer = "notifications"
+
+type Service struct {
+	logger *slog.Logger
+}
+
+func NewService(logger *slog.Logger) *Service {
+	return &Service{logger: logger}
+}
+
+// ListingCreated will let the seller know their listing is being checked
+func (s *Service) ListingCreated(evt events.ListingCreatedEvent) error {
+	s.logger.Debug("Listing created", "listing_id", evt.ListingID, "seller_id", evt.SellerID, "request_id", evt.RequestID)
+	return nil
+}
+
+// ListingPublished will let the seller know their listing is live
+func (s *Service) ListingPublished(evt events.ListingPublishedEvent) error {
+	s.logger.Debug("Listing published", "listing_id", evt.ListingID, "event_id", evt.EventID)
+	return nil
+}
@@ -0,0 +1,75 @@
+package publicurl_test
+
+import (
+	"indexer/internal/publicurl"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_URLs(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    publicurl.Config
+		path      string
+		wantFile  string
+		wantImage string
+	}{
+		{
+			name:      "No CDN",
+			config:    publicurl.Config{AssetsBaseURL: "https://s3.eu-west-2.amazonaws.com/public-files"},
+			path:      "listings/1/thumb.webp",
+			wantFile:  "https://s3.eu-west-2.amazonaws.com/public-files/listings/1/thumb.webp",
+			wantImage: "https://s3.eu-west-2.amazonaws.com/public-files/listings/1/thumb.webp",
+		},
+		{
+			name:      "CDN serves images only",
+			config:    publicurl.Config{AssetsBaseURL: "https://s3.eu-west-2.amazonaws.com/public-files", CDNImageBaseURL: "https://img.example.com"},
+			path:      "listings/1/thumb.webp",
+			wantFile:  "https://s3.eu-west-2.amazonaws.com/public-files/listings/1/thumb.webp",
+			wantImage: "https://img.example.com/listings/1/thumb.webp",
+		},
+		{
+			// Thumbnail paths have been stored with a leading slash, gallery paths without
+			name:      "Slashes on both sides",
+			config:    publicurl.Config{AssetsBaseURL: "http://localhost:9000/public-files/", CDNImageBaseURL: "https://img.example.com/"},
+			path:      "/thumb.png",
+			wantFile:  "http://localhost:9000/public-files/thumb.png",
+			wantImage: "https://img.example.com/thumb.png",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantFile, tt.config.File(tt.path))
+			assert.Equal(t, tt.wantImage, tt.config.Image(tt.path))
+		})
+	}
+}
+
+func TestConfig_Version(t *testing.T) {
+	base := publicurl.Config{AssetsBaseURL: "https://s3.eu-west-2.amazonaws.com/public-files"}
+	withCDN := publicurl.Config{AssetsBaseURL: base.AssetsBaseURL, CDNImageBaseURL: "https://img.example.com"}
+
+	assert.Equal(t, base.Version(), publicurl.Config{AssetsBaseURL: base.AssetsBaseURL}.Version())
+	assert.NotEqual(t, base.Version(), withCDN.Version())
+	assert.NotEqual(t, base.Version(), publicurl.Config{AssetsBaseURL: "https://other.example.com"}.Version())
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Run("Legacy variable", func(t *testing.T) {
+		t.Setenv("PUBLIC_ASSETS_BASE_URL", "")
+		t.Setenv("PUBLIC_FILES_URL", "http://localhost:9000/public-files")
+		t.Setenv("CDN_IMAGE_BASE_URL", "")
+
+		assert.Equal(t, publicurl.Config{AssetsBaseURL: "http://localhost:9000/public-files"}, publicurl.FromEnv())
+	})
+
+	t.Run("New variable wins", func(t *testing.T) {
+		t.Setenv("PUBLIC_ASSETS_BASE_URL", "https://assets.example.com")
+		t.Setenv("PUBLIC_FILES_URL", "http://localhost:9000/public-files")
+		t.Setenv("CDN_IMAGE_BASE_URL", "https://img.example.com")
+
+		assert.Equal(t, publicurl.Config{AssetsBaseURL: "https://assets.example.com", CDNImageBaseURL: "https://img.example.com"}, publicurl.FromEnv())
+	})
+}
@@ -0,0 +1,33 @@
+package purge
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	purgedListingsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "listings_worker_purge_listings_total",
+		Help: "Soft-deleted listings hard-deleted by the purge job.",
+	})
+
+	purgedObjectsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "listings_worker_purge_objects_total",
+		Help: "Storage objects removed by the purge job.",
+	})
+
+	purgedBytesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "listings_worker_purge_bytes_total",
+		Help: "Bytes of storage reclaimed by the purge job.",
+	})
+
+	purgeFailuresTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "listings_worker_purge_failures_total",
+		Help: "Listings the purge job failed to remove (retried on the next run).",
+	})
+
+	anonymizedTracesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "listings_worker_status_events_anonymized_total",
+		Help: "Status events whose client and trace IDs were dropped after the trace retention window.",
+	})
+)
@@ -0,0 +1,243 @@
+package purge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"indexer/internal/database/postgresql"
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/indexing"
+	"indexer/internal/storage"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const listingsCollection = "listings"
+
+type Config struct {
+	// RetentionDays is how long a listing stays soft-deleted before it is purged.
+	RetentionDays int
+	// BatchSize is how many listings are fetched from the DB per page.
+	BatchSize int
+	// ObjectDeletesPerSecond caps the request rate against storage. 0 disables the limit.
+	ObjectDeletesPerSecond int
+	// TraceRetentionDays is how long a listing's creation keeps the client and trace it came from. 0 keeps them forever.
+	TraceRetentionDays int
+}
+
+// Report summarises a purge run. In dry-run mode it describes what WOULD have been removed.
+type Report struct {
+	Listings          int   `json:"listings"`
+	Objects           int   `json:"objects"`
+	Bytes             int64 `json:"bytes"`
+	SharedObjectsKept int   `json:"shared_objects_kept"`
+	Failed            int   `json:"failed"`
+}
+
+// Hard-deletes listings that have been soft-deleted for longer than the retention window
+type svc struct {
+	repo    repo.Querier
+	db      postgresql.DBPool
+	storage storage.Provider
+	indexer indexing.Indexer
+	logger  *slog.Logger
+	config  Config
+}
+
+func NewService(repo repo.Querier, db postgresql.DBPool, storage storage.Provider, indexer indexing.Indexer, logger *slog.Logger, config Config) *svc {
+	if config.RetentionDays <= 0 {
+		config.RetentionDays = 30
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+
+	return &svc{
+		repo:    repo,
+		db:      db,
+		storage: storage,
+		indexer: indexer,
+		logger:  logger,
+		config:  config,
+	}
+}
+
+// Run pages through every listing past the retention window and purges it.
+// Each listing is removed in its own transaction, so a crash mid-run loses nothing:
+// the next run simply picks up the listings that are still there.
+func (s *svc) Run(ctx context.Context, dryRun bool) (Report, error) {
+	var report Report
+
+	cutoff := time.Now().AddDate(0, 0, -s.config.RetentionDays)
+	s.logger.Info("Starting purge", "cutoff", cutoff, "dry_run", dryRun)
+
+	var throttle <-chan time.Time
+	if s.config.ObjectDeletesPerSecond > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(s.config.ObjectDeletesPerSecond))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	params := repo.GetListingsForPurgeParams{
+		Cutoff:         pgtype.Timestamptz{Time: cutoff, Valid: true},
+		AfterDeletedAt: pgtype.Timestamptz{Time: time.Unix(0, 0), Valid: true},
+		AfterID:        pgtype.UUID{Valid: true},
+		BatchSize:      int32(s.config.BatchSize),
+	}
+
+	for {
+		listings, err := s.repo.GetListingsForPurge(ctx, params)
+		if err != nil {
+			return report, fmt.Errorf("failed to fetch listings for purge: %w", err)
+		}
+
+		for _, listing := range listings {
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+
+			if err := s.purgeListing(ctx, listing, dryRun, throttle, &report); err != nil {
+				// Keep going, a single bad listing shouldn't block the rest. It will be retried next run.
+				s.logger.Error("Failed to purge listing", "listing_id", fmt.Sprintf("%x", listing.ID.Bytes), "error", err)
+				report.Failed++
+				purgeFailuresTotal.Inc()
+			}
+		}
+
+		if len(listings) < s.config.BatchSize {
+			break
+		}
+
+		last := listings[len(listings)-1]
+		params.AfterDeletedAt = last.DeletedAt
+		params.AfterID = last.ID
+	}
+
+	s.logger.Info("Purge finished",
+		"dry_run", dryRun,
+		"listings", report.Listings,
+		"objects", report.Objects,
+		"bytes", report.Bytes,
+		"shared_objects_kept", report.SharedObjectsKept,
+		"failed", report.Failed,
+	)
+
+	return report, nil
+}
+
+func (s *svc) purgeListing(ctx context.Context, listing repo.Listing, dryRun bool, throttle <-chan time.Time, report *Report) error {
+	listingID := fmt.Sprintf("%x", listing.ID.Bytes)
+
+	// 1. Storage objects (skipping anything another listing still references)
+	files, err := s.repo.GetAllFilesByListingID(ctx, listing.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch files: %w", err)
+	}
+
+	for _, file := range files {
+		refs, err := s.repo.CountOtherFileReferences(ctx, repo.CountOtherFileReferencesParams{
+			FilePath:  file.FilePath,
+			ListingID: listing.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to count references for %s: %w", file.FilePath, err)
+		}
+
+		if refs > 0 {
+			s.logger.Debug("Object still referenced by another listing, keeping", "listing_id", listingID, "file_path", file.FilePath, "references", refs)
+			report.SharedObjectsKept++
+			continue
+		}
+
+		if dryRun {
+			s.logger.Info("Would delete object", "listing_id", listingID, "file_path", file.FilePath, "bytes", file.FileSize.Int64)
+			report.Objects++
+			report.Bytes += file.FileSize.Int64
+			continue
+		}
+
+		for _, bucket := range bucketsFor(file.FileType) {
+			if err := wait(ctx, throttle); err != nil {
+				return err
+			}
+
+			if err := s.storage.Delete(ctx, bucket, file.FilePath); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return fmt.Errorf("failed to delete %s/%s: %w", bucket, file.FilePath, err)
+			}
+		}
+
+		report.Objects++
+		report.Bytes += file.FileSize.Int64
+		purgedObjectsTotal.Inc()
+		purgedBytesTotal.Add(float64(file.FileSize.Int64))
+	}
+
+	if dryRun {
+		s.logger.Info("Would purge listing", "listing_id", listingID, "deleted_at", listing.DeletedAt.Time, "files", len(files))
+		report.Listings++
+		return nil
+	}
+
+	// 2. Search document (should already be gone, but deleted listings have leaked into the index before)
+	if err := s.indexer.Delete(ctx, listingsCollection, listingID); err != nil && !errors.Is(err, indexing.ErrNotFound) {
+		return fmt.Errorf("failed to delete search document: %w", err)
+	}
+
+	// 3. DB rows, remixes are detached first so they stop naming a listing that no longer exists, then files, then
+	// the listing. Their documents dropped the parent when it was soft-deleted, so they don't need reindexing.
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := repo.New(tx)
+	remixes, err := qtx.DetachRemixes(ctx, listing.ID)
+	if err != nil {
+		return fmt.Errorf("failed to detach remixes: %w", err)
+	}
+	if err := qtx.HardDeleteListingFiles(ctx, listing.ID); err != nil {
+		return fmt.Errorf("failed to delete file rows: %w", err)
+	}
+	if err := qtx.HardDeleteListing(ctx, listing.ID); err != nil {
+		return fmt.Errorf("failed to delete listing row: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit purge: %w", err)
+	}
+
+	s.logger.Info("Purged listing", "listing_id", listingID, "files", len(files), "remixes_detached", remixes)
+	report.Listings++
+	purgedListingsTotal.Inc()
+
+	return nil
+}
+
+// bucketsFor lists every bucket an object of this type may have been written to.
+// Deleting a key that was never copied to a bucket is a no-op.
+func bucketsFor(fileType repo.FileType) []storage.Bucket {
+	switch fileType {
+	case repo.FileTypeMODEL:
+		return []storage.Bucket{storage.BucketIncoming, storage.BucketProduct}
+	case repo.FileTypeIMAGE:
+		return []storage.Bucket{storage.BucketIncoming, storage.BucketPublic}
+	default:
+		return []storage.Bucket{storage.BucketIncoming}
+	}
+}
+
+func wait(ctx context.Context, throttle <-chan time.Time) error {
+	if throttle == nil {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-throttle:
+		return nil
+	}
+}
@@ -0,0 +1,252 @@
+package purge_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"testing"
+	"time"
+
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/indexing"
+	"indexer/internal/mocks/mockrepo"
+	"indexer/internal/purge"
+	"indexer/internal/storage"
+	"indexer/internal/testutil/fixtures"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- MOCKS ---
+
+// FakeStorage records every delete instead of talking to S3
+type FakeStorage struct {
+	deleted []string
+}
+
+func (f *FakeStorage) Delete(ctx context.Context, bucket storage.Bucket, key string) error {
+	f.deleted = append(f.deleted, string(bucket)+"/"+key)
+	return nil
+}
+
+// --- HELPERS ---
+
+// newListing was deleted long enough ago to be purged
+func newListing() repo.Listing {
+	return fixtures.NewListing(fixtures.WithDeleted(time.Now().AddDate(0, 0, -60)))
+}
+
+func newFile(listing repo.Listing, fileType repo.FileType, path string, size int64) repo.ListingFile {
+	return repo.ListingFile{
+		ListingID: listing.ID,
+		FileType:  fileType,
+		FilePath:  path,
+		FileSize:  pgtype.Int8{Int64: size, Valid: true},
+	}
+}
+
+// --- TESTS ---
+
+func TestRun_HardDeletesListingAndObjects(t *testing.T) {
+	mockRepo := mockrepo.NewQuerier(t)
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	store := &FakeStorage{}
+	fakeIndexer := indexing.NewInMemoryIndexer()
+
+	listing := newListing()
+	listingID := "550e8400e29b41d4a716446655440000"
+	require.NoError(t, fakeIndexer.Upsert(context.Background(), "listings", map[string]interface{}{"id": listingID}))
+
+	files := []repo.ListingFile{
+		newFile(listing, repo.FileTypeMODEL, "2025/01/01/u/d/model/abc.stl", 1000),
+		newFile(listing, repo.FileTypeIMAGE, "2025/01/01/u/d/image/def.png", 200),
+	}
+
+	mockRepo.On("GetListingsForPurge", mock.Anything, mock.Anything).Return([]repo.Listing{listing}, nil)
+	mockRepo.On("GetAllFilesByListingID", mock.Anything, listing.ID).Return(files, nil)
+	mockRepo.On("CountOtherFileReferences", mock.Anything, mock.Anything).Return(int64(0), nil)
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: DetachRemixes :execrows`)).
+		WithArgs(listing.ID).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mockPool.ExpectExec(regexp.QuoteMeta(`DELETE FROM listing_files`)).
+		WithArgs(listing.ID).
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+	mockPool.ExpectExec(regexp.QuoteMeta(`DELETE FROM listings`)).
+		WithArgs(listing.ID).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mockPool.ExpectCommit()
+
+	svc := purge.NewService(mockRepo, mockPool, store, fakeIndexer, slog.Default(), purge.Config{BatchSize: 10})
+
+	report, err := svc.Run(context.Background(), false)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, report.Listings)
+	assert.Equal(t, 2, report.Objects)
+	assert.Equal(t, int64(1200), report.Bytes)
+	assert.Equal(t, 0, report.Failed)
+
+	assert.ElementsMatch(t, []string{
+		"incoming-files/2025/01/01/u/d/model/abc.stl",
+		"product-files/2025/01/01/u/d/model/abc.stl",
+		"incoming-files/2025/01/01/u/d/image/def.png",
+		"public-files/2025/01/01/u/d/image/def.png",
+	}, store.deleted)
+
+	count, _ := fakeIndexer.Count(context.Background(), "listings")
+	assert.Equal(t, int64(0), count)
+
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRun_DryRun_DeletesNothing(t *testing.T) {
+	mockRepo := mockrepo.NewQuerier(t)
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	store := &FakeStorage{}
+	listing := newListing()
+
+	mockRepo.On("GetListingsForPurge", mock.Anything, mock.Anything).Return([]repo.Listing{listing}, nil)
+	mockRepo.On("GetAllFilesByListingID", mock.Anything, listing.ID).
+		Return([]repo.ListingFile{newFile(listing, repo.FileTypeMODEL, "model/abc.stl", 1000)}, nil)
+	mockRepo.On("CountOtherFileReferences", mock.Anything, mock.Anything).Return(int64(0), nil)
+
+	// No DB expectations: any Begin/Exec would fail the test
+	svc := purge.NewService(mockRepo, mockPool, store, indexing.NewInMemoryIndexer(), slog.Default(), purge.Config{})
+
+	report, err := svc.Run(context.Background(), true)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, report.Listings)
+	assert.Equal(t, 1, report.Objects)
+	assert.Equal(t, int64(1000), report.Bytes)
+	assert.Empty(t, store.deleted)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRun_SharedObject_IsKept(t *testing.T) {
+	// SCENARIO: A remix still points at the same model file.
+	// EXPECT: Listing rows are removed but the object stays in storage.
+
+	mockRepo := mockrepo.NewQuerier(t)
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	store := &FakeStorage{}
+	listing := newListing()
+
+	mockRepo.On("GetListingsForPurge", mock.Anything, mock.Anything).Return([]repo.Listing{listing}, nil)
+	mockRepo.On("GetAllFilesByListingID", mock.Anything, listing.ID).
+		Return([]repo.ListingFile{newFile(listing, repo.FileTypeMODEL, "model/shared.stl", 1000)}, nil)
+	mockRepo.On("CountOtherFileReferences", mock.Anything, mock.Anything).Return(int64(1), nil)
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: DetachRemixes :execrows`)).
+		WithArgs(listing.ID).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockPool.ExpectExec(regexp.QuoteMeta(`DELETE FROM listing_files`)).
+		WithArgs(listing.ID).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mockPool.ExpectExec(regexp.QuoteMeta(`DELETE FROM listings`)).
+		WithArgs(listing.ID).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mockPool.ExpectCommit()
+
+	svc := purge.NewService(mockRepo, mockPool, store, indexing.NewInMemoryIndexer(), slog.Default(), purge.Config{})
+
+	report, err := svc.Run(context.Background(), false)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, report.Listings)
+	assert.Equal(t, 0, report.Objects)
+	assert.Equal(t, 1, report.SharedObjectsKept)
+	assert.Empty(t, store.deleted)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestRun_DetachRemixesFails_KeepsListing(t *testing.T) {
+	// SCENARIO: Remixes of the listing can't be detached.
+	// EXPECT: The transaction is rolled back before any row is deleted, so no remix is left pointing at a purged
+	// listing, and the listing is retried on the next run.
+
+	mockRepo := mockrepo.NewQuerier(t)
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	listing := newListing()
+
+	mockRepo.On("GetListingsForPurge", mock.Anything, mock.Anything).Return([]repo.Listing{listing}, nil)
+	mockRepo.On("GetAllFilesByListingID", mock.Anything, listing.ID).Return([]repo.ListingFile{}, nil)
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectExec(regexp.QuoteMeta(`-- name: DetachRemixes :execrows`)).
+		WithArgs(listing.ID).
+		WillReturnError(errors.New("lock timeout"))
+	mockPool.ExpectRollback()
+
+	svc := purge.NewService(mockRepo, mockPool, &FakeStorage{}, indexing.NewInMemoryIndexer(), slog.Default(), purge.Config{})
+
+	report, err := svc.Run(context.Background(), false)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, report.Listings)
+	assert.Equal(t, 1, report.Failed)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestAnonymizeTraces_Batches(t *testing.T) {
+	// SCENARIO: More status events are past the trace retention window than fit in one batch.
+	// EXPECT: Batches run until one comes back short, all with the same cutoff.
+
+	mockRepo := mockrepo.NewQuerier(t)
+	var batches []repo.AnonymizeStatusEventsBeforeParams
+	record := func(args mock.Arguments) {
+		batches = append(batches, args.Get(1).(repo.AnonymizeStatusEventsBeforeParams))
+	}
+	mockRepo.On("AnonymizeStatusEventsBefore", mock.Anything, mock.Anything).Run(record).Return(int64(2), nil).Once()
+	mockRepo.On("AnonymizeStatusEventsBefore", mock.Anything, mock.Anything).Run(record).Return(int64(1), nil).Once()
+
+	svc := purge.NewService(mockRepo, nil, &FakeStorage{}, indexing.NewInMemoryIndexer(), slog.Default(), purge.Config{BatchSize: 2, TraceRetentionDays: 90})
+
+	n, err := svc.AnonymizeTraces(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(3), n)
+	require.Len(t, batches, 2)
+	assert.Equal(t, batches[0], batches[1])
+	assert.Equal(t, int32(2), batches[0].BatchSize)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, -90), batches[0].Cutoff.Time, time.Minute)
+}
+
+func TestAnonymizeTraces_NoRetention_KeepsThem(t *testing.T) {
+	// No expectations: any query would fail the test
+	mockRepo := mockrepo.NewQuerier(t)
+	svc := purge.NewService(mockRepo, nil, &FakeStorage{}, indexing.NewInMemoryIndexer(), slog.Default(), purge.Config{})
+
+	n, err := svc.AnonymizeTraces(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestAnonymizeTraces_Fails(t *testing.T) {
+	mockRepo := mockrepo.NewQuerier(t)
+	mockRepo.On("AnonymizeStatusEventsBefore", mock.Anything, mock.Anything).Return(int64(0), errors.New("connection reset"))
+	svc := purge.NewService(mockRepo, nil, &FakeStorage{}, indexing.NewInMemoryIndexer(), slog.Default(), purge.Config{TraceRetentionDays: 30})
+
+	_, err := svc.AnonymizeTraces(context.Background())
+	assert.ErrorContains(t, err, "connection reset")
+}
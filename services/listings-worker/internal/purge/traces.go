@@ -0,0 +1,45 @@
+package purge
+
+import (
+	"context"
+	"fmt"
+	repo "indexer/internal/database/postgresql/sqlc"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// AnonymizeTraces drops the client and trace IDs from status events older than the trace retention window, a batch
+// per statement so a big backlog doesn't hold locks on the whole table. It returns how many events it changed.
+func (s *svc) AnonymizeTraces(ctx context.Context) (int64, error) {
+	if s.config.TraceRetentionDays <= 0 {
+		return 0, nil
+	}
+
+	params := repo.AnonymizeStatusEventsBeforeParams{
+		Cutoff:    pgtype.Timestamptz{Time: time.Now().AddDate(0, 0, -s.config.TraceRetentionDays), Valid: true},
+		BatchSize: int32(s.config.BatchSize),
+	}
+
+	var total int64
+	for {
+		n, err := s.repo.AnonymizeStatusEventsBefore(ctx, params)
+		if err != nil {
+			return total, fmt.Errorf("failed to anonymize status events: %w", err)
+		}
+		total += n
+		anonymizedTracesTotal.Add(float64(n))
+
+		if n < int64(params.BatchSize) {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+
+	if total > 0 {
+		s.logger.Info("Anonymized status events past trace retention", "events", total, "retention_days", s.config.TraceRetentionDays)
+	}
+	return total, nil
+}
@@ -0,0 +1,92 @@
+package queues
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// TokenHeader carries the shared secret every admin request needs
+const TokenHeader = "X-Admin-Token"
+
+// ErrorResponse has the same shape as the gateway's errors
+type ErrorResponse struct {
+	ErrorCode string `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+type QueuesResponse struct {
+	Queues []QueueStatus `json:"queues"`
+}
+
+type Handler struct {
+	service *Service
+	token   string
+}
+
+// NewHandler serves the queue admin endpoints, every request is refused when token is empty
+func NewHandler(service *Service, token string) *Handler {
+	return &Handler{service: service, token: token}
+}
+
+// Register mounts the admin endpoints on mux
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.Handle("GET /admin/queues", h.authorized(h.List))
+	mux.Handle("POST /admin/queues/{subject}/replay-dlq", h.authorized(h.ReplayDeadLetters))
+}
+
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, QueuesResponse{Queues: h.service.Status()})
+}
+
+// ReplayDeadLetters republishes up to ?limit= dead letters for the subject, DefaultReplayLimit when it's left out
+func (h *Handler) ReplayDeadLetters(w http.ResponseWriter, r *http.Request) {
+	subject := r.PathValue("subject")
+
+	limit := DefaultReplayLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > MaxReplayLimit {
+			writeError(w, http.StatusBadRequest, "INVALID_INPUT", "limit must be between 1 and "+strconv.Itoa(MaxReplayLimit))
+			return
+		}
+		limit = parsed
+	}
+
+	result, err := h.service.ReplayDeadLetters(subject, limit)
+	if err != nil {
+		if errors.Is(err, ErrUnknownSubject) {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "No queue for subject '"+subject+"'")
+			return
+		}
+		h.service.logger.Error("Dead letter replay failed", "subject", subject, "replayed", result.Replayed, "error", err)
+		writeError(w, http.StatusInternalServerError, "INTERNAL", "Replay stopped after "+strconv.Itoa(result.Replayed)+" messages: "+err.Error())
+		return
+	}
+
+	h.service.logger.Info("Dead letters replayed", "subject", subject, "replayed", result.Replayed, "left", result.DeadLetters)
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (h *Handler) authorized(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(TokenHeader)
+		if h.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid "+TokenHeader+" header")
+			return
+		}
+		next(w, r)
+	})
+}
+
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	writeJSON(w, status, ErrorResponse{ErrorCode: code, Message: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(payload)
+}
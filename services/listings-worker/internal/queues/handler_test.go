@@ -0,0 +1,118 @@
+package queues_test
+
+import (
+	"encoding/json"
+	"indexer/internal/queues"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const adminToken = "s3cret"
+
+func serve(t *testing.T, handler *queues.Handler, method, target, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	handler.Register(mux)
+
+	req := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		req.Header.Set(queues.TokenHeader, token)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) queues.ErrorResponse {
+	t.Helper()
+
+	var body queues.ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return body
+}
+
+func TestHandler_Token(t *testing.T) {
+	js := newFakeJetStream()
+
+	tests := []struct {
+		name       string
+		configured string
+		sent       string
+		want       int
+	}{
+		{name: "Valid token", configured: adminToken, sent: adminToken, want: http.StatusOK},
+		{name: "Missing token", configured: adminToken, sent: "", want: http.StatusUnauthorized},
+		{name: "Wrong token", configured: adminToken, sent: "guess", want: http.StatusUnauthorized},
+		{name: "Not configured", configured: "", sent: "", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(t, queues.NewHandler(newService(js), tt.configured), "GET", "/admin/queues", tt.sent)
+
+			assert.Equal(t, tt.want, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			if tt.want == http.StatusUnauthorized {
+				assert.Equal(t, "UNAUTHORIZED", decodeError(t, rec).ErrorCode)
+			}
+		})
+	}
+}
+
+func TestHandler_List(t *testing.T) {
+	js := newFakeJetStream()
+	js.consumers["INDEX/listings-worker"] = &nats.ConsumerInfo{NumPending: 7}
+	js.consumers["LISTINGS/listings-worker-notifications"] = &nats.ConsumerInfo{}
+	js.deadLetter("index.listing", `{}`)
+
+	rec := serve(t, queues.NewHandler(newService(js), adminToken), "GET", "/admin/queues", adminToken)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body queues.QueuesResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Len(t, body.Queues, 2)
+	assert.Equal(t, uint64(7), body.Queues[0].Pending)
+	assert.Equal(t, uint64(1), body.Queues[0].DeadLetters)
+}
+
+func TestHandler_Replay(t *testing.T) {
+	js := newFakeJetStream()
+	js.deadLetter("index.listing", `{"listing_id":"a"}`)
+	js.deadLetter("index.listing", `{"listing_id":"b"}`)
+	handler := queues.NewHandler(newService(js), adminToken)
+
+	t.Run("Replays up to the limit", func(t *testing.T) {
+		rec := serve(t, handler, "POST", "/admin/queues/index.listing/replay-dlq?limit=1", adminToken)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var body queues.ReplayResult
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, queues.ReplayResult{Subject: "index.listing", Replayed: 1, DeadLetters: 1}, body)
+	})
+
+	t.Run("Limit out of range", func(t *testing.T) {
+		rec := serve(t, handler, "POST", "/admin/queues/index.listing/replay-dlq?limit=1000", adminToken)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "INVALID_INPUT", decodeError(t, rec).ErrorCode)
+	})
+
+	t.Run("Subject not consumed here", func(t *testing.T) {
+		rec := serve(t, handler, "POST", "/admin/queues/files.validate.model/replay-dlq", adminToken)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "NOT_FOUND", decodeError(t, rec).ErrorCode)
+	})
+
+	t.Run("Wrong method", func(t *testing.T) {
+		rec := serve(t, handler, "GET", "/admin/queues/index.listing/replay-dlq", adminToken)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
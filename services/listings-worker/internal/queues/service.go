@@ -0,0 +1,168 @@
+package queues
+
+import (
+	"errors"
+	"fmt"
+	"indexer/internal/events"
+	"log/slog"
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	DefaultReplayLimit = 10
+	MaxReplayLimit     = 100
+)
+
+// ErrUnknownSubject is returned for subjects this worker doesn't consume, replaying those would publish on someone
+// else's behalf
+var ErrUnknownSubject = errors.New("subject is not consumed by this worker")
+
+// JetStream is the part of nats.JetStreamContext the queue admin needs
+type JetStream interface {
+	StreamNameBySubject(subject string, opts ...nats.JSOpt) (string, error)
+	StreamInfo(stream string, opts ...nats.JSOpt) (*nats.StreamInfo, error)
+	ConsumerInfo(stream, consumer string, opts ...nats.JSOpt) (*nats.ConsumerInfo, error)
+	GetMsg(name string, seq uint64, opts ...nats.JSOpt) (*nats.RawStreamMsg, error)
+	DeleteMsg(name string, seq uint64, opts ...nats.JSOpt) error
+	Publish(subject string, data []byte, opts ...nats.PubOpt) (*nats.PubAck, error)
+}
+
+// QueueStatus is how far behind one of the worker's durables is
+type QueueStatus struct {
+	Subject          string `json:"subject"`
+	Stream           string `json:"stream"`
+	Consumer         string `json:"consumer"`
+	Pending          uint64 `json:"pending"`     // Not delivered yet
+	AckPending       int    `json:"ack_pending"` // Delivered, still being handled or waiting to be redelivered
+	Redelivered      int    `json:"redelivered"` // Delivered more than once and not acked yet
+	LastDeliveredSeq uint64 `json:"last_delivered_seq"`
+	DeadLetters      uint64 `json:"dead_letters"`
+	Error            string `json:"error,omitempty"` // Set when NATS couldn't be asked, the numbers are then zero
+}
+
+// ReplayResult is what a dead letter replay did
+type ReplayResult struct {
+	Subject     string `json:"subject"`
+	Replayed    int    `json:"replayed"`
+	DeadLetters uint64 `json:"dead_letters"` // Left after the replay
+}
+
+type Service struct {
+	js        JetStream
+	consumers func() []events.ConsumerRef
+	logger    *slog.Logger
+}
+
+func NewService(js JetStream, consumers func() []events.ConsumerRef, logger *slog.Logger) *Service {
+	return &Service{
+		js:        js,
+		consumers: consumers,
+		logger:    logger,
+	}
+}
+
+// Status reports every durable. One NATS call failing only blanks out that queue, the rest are still worth seeing.
+func (s *Service) Status() []QueueStatus {
+	consumers := s.consumers()
+	statuses := make([]QueueStatus, 0, len(consumers))
+	for _, consumer := range consumers {
+		status := QueueStatus{Subject: consumer.Subject, Consumer: consumer.Durable}
+		if err := s.fill(&status); err != nil {
+			s.logger.Warn("Failed to read queue status", "subject", consumer.Subject, "consumer", consumer.Durable, "error", err)
+			status.Error = err.Error()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (s *Service) fill(status *QueueStatus) error {
+	stream, err := s.js.StreamNameBySubject(status.Subject)
+	if err != nil {
+		return fmt.Errorf("failed to find stream: %w", err)
+	}
+	status.Stream = stream
+
+	info, err := s.js.ConsumerInfo(stream, status.Consumer)
+	if err != nil {
+		return fmt.Errorf("failed to read consumer: %w", err)
+	}
+	status.Pending = info.NumPending
+	status.AckPending = info.NumAckPending
+	status.Redelivered = info.NumRedelivered
+	status.LastDeliveredSeq = info.Delivered.Stream
+
+	status.DeadLetters, err = s.deadLetters(status.Subject)
+	return err
+}
+
+// deadLetters counts what's parked for subject, 0 when nothing has ever been dead lettered
+func (s *Service) deadLetters(subject string) (uint64, error) {
+	dlqSubject := events.DeadLetterSubject(subject)
+	info, err := s.js.StreamInfo(events.DeadLetterStream, &nats.StreamInfoRequest{SubjectsFilter: dlqSubject})
+	if err != nil {
+		if errors.Is(err, nats.ErrStreamNotFound) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read dead letters: %w", err)
+	}
+	return info.State.Subjects[dlqSubject], nil
+}
+
+// ReplayDeadLetters publishes up to limit dead letters back onto subject, oldest first so they arrive in the order they
+// were first sent, removing each one once it's been published. A replay that fails part way keeps what it already did and can just be run again.
+//
+// Handlers have to cope with seeing a message twice anyway. On a fan-out stream every consumer gets the replay,
+// not only the one that gave up on it.
+func (s *Service) ReplayDeadLetters(subject string, limit int) (ReplayResult, error) {
+	if !s.consumes(subject) {
+		return ReplayResult{}, ErrUnknownSubject
+	}
+	if limit <= 0 {
+		limit = DefaultReplayLimit
+	}
+	limit = min(limit, MaxReplayLimit)
+
+	result := ReplayResult{Subject: subject}
+	dlqSubject := events.DeadLetterSubject(subject)
+	for result.Replayed < limit {
+		// The first message on the subject from the start of the stream, replayed ones are deleted
+		msg, err := s.js.GetMsg(events.DeadLetterStream, 1, nats.DirectGetNext(dlqSubject))
+		if err != nil {
+			if errors.Is(err, nats.ErrMsgNotFound) || errors.Is(err, nats.ErrStreamNotFound) {
+				break
+			}
+			return result, fmt.Errorf("failed to read dead letter: %w", err)
+		}
+
+		// Deduplicated by the stream, so a retry after the delete below failed doesn't publish it twice
+		msgID := "replay-" + strconv.FormatUint(msg.Sequence, 10)
+		if _, err := s.js.Publish(subject, msg.Data, nats.MsgId(msgID)); err != nil {
+			return result, fmt.Errorf("failed to republish dead letter %d: %w", msg.Sequence, err)
+		}
+		if err := s.js.DeleteMsg(events.DeadLetterStream, msg.Sequence); err != nil {
+			return result, fmt.Errorf("failed to remove replayed dead letter %d: %w", msg.Sequence, err)
+		}
+		result.Replayed++
+
+		s.logger.Info("Replayed dead letter", "subject", subject, "sequence", msg.Sequence, "error", msg.Header.Get(events.HeaderDeadLetterError))
+	}
+
+	left, err := s.deadLetters(subject)
+	if err != nil {
+		return result, err
+	}
+	result.DeadLetters = left
+	return result, nil
+}
+
+func (s *Service) consumes(subject string) bool {
+	for _, consumer := range s.consumers() {
+		if consumer.Subject == subject {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+package queues_test
+
+import (
+	"errors"
+	"indexer/internal/events"
+	"indexer/internal/queues"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- MOCKS ---
+
+type published struct {
+	subject string
+	data    string
+}
+
+// FakeJetStream keeps streams, consumers and dead letters in memory
+type FakeJetStream struct {
+	streams     map[string]string // subject -> stream
+	consumers   map[string]*nats.ConsumerInfo
+	deadLetters map[string][]*nats.RawStreamMsg // dlq subject -> messages, oldest first
+	noDLQ       bool
+	consumerErr error
+	publishErr  error
+
+	published []published
+	nextSeq   uint64
+}
+
+func newFakeJetStream() *FakeJetStream {
+	return &FakeJetStream{
+		streams:     map[string]string{"index.listing": "INDEX", "listings.created": "LISTINGS"},
+		consumers:   map[string]*nats.ConsumerInfo{},
+		deadLetters: map[string][]*nats.RawStreamMsg{},
+	}
+}
+
+func (f *FakeJetStream) deadLetter(subject, data string) {
+	f.nextSeq++
+	dlqSubject := events.DeadLetterSubject(subject)
+	f.deadLetters[dlqSubject] = append(f.deadLetters[dlqSubject], &nats.RawStreamMsg{
+		Subject:  dlqSubject,
+		Sequence: f.nextSeq,
+		Data:     []byte(data),
+		Header:   nats.Header{events.HeaderDeadLetterError: []string{"typesense unavailable"}},
+	})
+}
+
+func (f *FakeJetStream) StreamNameBySubject(subject string, opts ...nats.JSOpt) (string, error) {
+	if stream, ok := f.streams[subject]; ok {
+		return stream, nil
+	}
+	return "", nats.ErrNoMatchingStream
+}
+
+func (f *FakeJetStream) StreamInfo(stream string, opts ...nats.JSOpt) (*nats.StreamInfo, error) {
+	if stream != events.DeadLetterStream || f.noDLQ {
+		return nil, nats.ErrStreamNotFound
+	}
+	filter := opts[0].(*nats.StreamInfoRequest).SubjectsFilter
+	info := &nats.StreamInfo{State: nats.StreamState{Subjects: map[string]uint64{}}}
+	if n := len(f.deadLetters[filter]); n > 0 {
+		info.State.Subjects[filter] = uint64(n)
+	}
+	return info, nil
+}
+
+func (f *FakeJetStream) ConsumerInfo(stream, consumer string, opts ...nats.JSOpt) (*nats.ConsumerInfo, error) {
+	if f.consumerErr != nil {
+		return nil, f.consumerErr
+	}
+	if info, ok := f.consumers[stream+"/"+consumer]; ok {
+		return info, nil
+	}
+	return nil, nats.ErrConsumerNotFound
+}
+
+// GetMsg is the first dead letter from seq on. The DirectGetNext subject can't be read back out of the option, so
+// replay tests dead letter a single subject.
+func (f *FakeJetStream) GetMsg(name string, seq uint64, opts ...nats.JSOpt) (*nats.RawStreamMsg, error) {
+	var first *nats.RawStreamMsg
+	for _, msgs := range f.deadLetters {
+		for _, msg := range msgs {
+			if msg.Sequence >= seq && (first == nil || msg.Sequence < first.Sequence) {
+				first = msg
+			}
+		}
+	}
+	if first == nil {
+		return nil, nats.ErrMsgNotFound
+	}
+	return first, nil
+}
+
+func (f *FakeJetStream) DeleteMsg(name string, seq uint64, opts ...nats.JSOpt) error {
+	for subject, msgs := range f.deadLetters {
+		for i, msg := range msgs {
+			if msg.Sequence == seq {
+				f.deadLetters[subject] = append(msgs[:i], msgs[i+1:]...)
+				return nil
+			}
+		}
+	}
+	return nats.ErrMsgNotFound
+}
+
+func (f *FakeJetStream) Publish(subject string, data []byte, opts ...nats.PubOpt) (*nats.PubAck, error) {
+	if f.publishErr != nil {
+		return nil, f.publishErr
+	}
+	f.published = append(f.published, published{subject: subject, data: string(data)})
+	return &nats.PubAck{}, nil
+}
+
+// --- HELPERS ---
+
+var consumers = []events.ConsumerRef{
+	{Subject: "index.listing", Durable: "listings-worker"},
+	{Subject: "listings.created", Durable: "listings-worker-notifications"},
+}
+
+func newService(js *FakeJetStream) *queues.Service {
+	return queues.NewService(js, func() []events.ConsumerRef { return consumers }, slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+}
+
+// --- TESTS ---
+
+func TestStatus(t *testing.T) {
+	// SCENARIO: Indexing is falling behind and two index events have been dead lettered.
+	// EXPECT: Lag and dead letters are reported per durable, the notifications queue is clean.
+
+	js := newFakeJetStream()
+	js.consumers["INDEX/listings-worker"] = &nats.ConsumerInfo{
+		NumPending: 120, NumAckPending: 10, NumRedelivered: 3,
+		Delivered: nats.SequenceInfo{Stream: 4512},
+	}
+	js.consumers["LISTINGS/listings-worker-notifications"] = &nats.ConsumerInfo{Delivered: nats.SequenceInfo{Stream: 88}}
+	js.deadLetter("index.listing", `{"listing_id":"a"}`)
+	js.deadLetter("index.listing", `{"listing_id":"b"}`)
+
+	statuses := newService(js).Status()
+
+	assert.Equal(t, []queues.QueueStatus{
+		{Subject: "index.listing", Stream: "INDEX", Consumer: "listings-worker", Pending: 120, AckPending: 10, Redelivered: 3, LastDeliveredSeq: 4512, DeadLetters: 2},
+		{Subject: "listings.created", Stream: "LISTINGS", Consumer: "listings-worker-notifications", LastDeliveredSeq: 88},
+	}, statuses)
+}
+
+func TestStatus_NoDeadLetterStream(t *testing.T) {
+	js := newFakeJetStream()
+	js.noDLQ = true
+	js.consumers["INDEX/listings-worker"] = &nats.ConsumerInfo{NumPending: 5}
+	js.consumers["LISTINGS/listings-worker-notifications"] = &nats.ConsumerInfo{}
+
+	statuses := newService(js).Status()
+
+	require.Len(t, statuses, 2)
+	assert.Empty(t, statuses[0].Error)
+	assert.Equal(t, uint64(5), statuses[0].Pending)
+	assert.Zero(t, statuses[0].DeadLetters)
+}
+
+func TestStatus_ConsumerUnavailable(t *testing.T) {
+	// SCENARIO: NATS times out on the consumer info request.
+	// EXPECT: Every queue is still listed, with the error instead of numbers.
+
+	js := newFakeJetStream()
+	js.consumerErr = nats.ErrTimeout
+
+	statuses := newService(js).Status()
+
+	require.Len(t, statuses, 2)
+	for _, status := range statuses {
+		assert.Contains(t, status.Error, nats.ErrTimeout.Error())
+		assert.Zero(t, status.Pending)
+	}
+}
+
+func TestReplayDeadLetters(t *testing.T) {
+	// SCENARIO: Typesense is back, the operator replays two of three dead letters.
+	// EXPECT: The two oldest go back onto the subject in the order they failed and are removed, one is left.
+
+	js := newFakeJetStream()
+	js.deadLetter("index.listing", `{"listing_id":"a"}`)
+	js.deadLetter("index.listing", `{"listing_id":"b"}`)
+	js.deadLetter("index.listing", `{"listing_id":"c"}`)
+
+	result, err := newService(js).ReplayDeadLetters("index.listing", 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, queues.ReplayResult{Subject: "index.listing", Replayed: 2, DeadLetters: 1}, result)
+	assert.Equal(t, []published{
+		{subject: "index.listing", data: `{"listing_id":"a"}`},
+		{subject: "index.listing", data: `{"listing_id":"b"}`},
+	}, js.published)
+}
+
+func TestReplayDeadLetters_FewerThanLimit(t *testing.T) {
+	js := newFakeJetStream()
+	js.deadLetter("index.listing", `{"listing_id":"a"}`)
+
+	result, err := newService(js).ReplayDeadLetters("index.listing", 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Replayed)
+	assert.Zero(t, result.DeadLetters)
+}
+
+func TestReplayDeadLetters_UnknownSubject(t *testing.T) {
+	js := newFakeJetStream()
+	js.deadLetter("files.validate.model", `{}`)
+
+	_, err := newService(js).ReplayDeadLetters("files.validate.model", 10)
+
+	assert.ErrorIs(t, err, queues.ErrUnknownSubject)
+	assert.Empty(t, js.published)
+}
+
+func TestReplayDeadLetters_PublishFails(t *testing.T) {
+	// SCENARIO: NATS refuses the republish.
+	// EXPECT: The dead letter stays where it is so nothing is lost.
+
+	js := newFakeJetStream()
+	js.deadLetter("index.listing", `{"listing_id":"a"}`)
+	js.publishErr = errors.New("no responders")
+
+	result, err := newService(js).ReplayDeadLetters("index.listing", 10)
+
+	require.Error(t, err)
+	assert.Zero(t, result.Replayed)
+	assert.Len(t, js.deadLetters[events.DeadLetterSubject("index.listing")], 1)
+}
@@ -0,0 +1,23 @@
+package savedsearch
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	checksTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "listings_worker_saved_search_checks_total",
+		Help: "Saved searches run against the search index.",
+	})
+
+	matchesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "listings_worker_saved_search_matches_total",
+		Help: "New listings reported to users for their saved searches.",
+	})
+
+	failuresTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "listings_worker_saved_search_failures_total",
+		Help: "Saved searches that failed to run or be reported (retried on the next run).",
+	})
+)
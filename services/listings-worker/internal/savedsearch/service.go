@@ -0,0 +1,267 @@
+package savedsearch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/events"
+	"indexer/internal/indexing"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	listingsCollection = "listings"
+	// Same fields the web UI searches
+	queryBy = "title,description,categories"
+)
+
+type Config struct {
+	// BatchSize is how many due saved searches one run checks.
+	BatchSize int
+	// SearchesPerSecond caps the request rate against Typesense. 0 disables the limit.
+	SearchesPerSecond int
+	// CheckEvery is how often each saved search is run. Up to a tenth more is added at random, so searches saved
+	// at the same time drift apart instead of always running together.
+	CheckEvery time.Duration
+	// Lookback widens the since-filter to listings created this long before the last check, so a listing indexed a
+	// while after it was created is still found. Listings already reported are skipped.
+	Lookback time.Duration
+}
+
+type Searcher interface {
+	SearchIDs(ctx context.Context, collectionName string, query indexing.SearchQuery) ([]string, error)
+}
+
+type Publisher interface {
+	PublishSavedSearchMatched(evt events.SavedSearchMatchedEvent, msgID string) error
+}
+
+// Report summarises a run
+type Report struct {
+	Checked  int `json:"checked"`
+	Matched  int `json:"matched"` // Listings reported, across every user
+	Notified int `json:"notified"`
+	Failed   int `json:"failed"`
+}
+
+// Runs saved searches against the index and tells users about listings created since the last check
+type svc struct {
+	repo      repo.Querier
+	search    Searcher
+	publisher Publisher
+	logger    *slog.Logger
+	config    Config
+	now       func() time.Time
+}
+
+func NewService(repo repo.Querier, search Searcher, publisher Publisher, logger *slog.Logger, config Config) *svc {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 500
+	}
+	if config.CheckEvery <= 0 {
+		config.CheckEvery = time.Hour
+	}
+
+	return &svc{
+		repo:      repo,
+		search:    search,
+		publisher: publisher,
+		logger:    logger,
+		config:    config,
+		now:       time.Now,
+	}
+}
+
+// userMatches is one user's share of a run, their searches are only marked checked once the event is out
+type userMatches struct {
+	event   events.SavedSearchMatchedEvent
+	checked []repo.MarkSavedSearchCheckedParams
+	seen    map[string]bool // Listings already reported under another of the user's searches
+}
+
+// Run checks the saved searches that are due. A search that fails to run, or whose user's event fails to publish,
+// stays due and is retried on the next run with the same since-filter.
+func (s *svc) Run(ctx context.Context) (Report, error) {
+	var report Report
+
+	due, err := s.repo.GetDueSavedSearches(ctx, int32(s.config.BatchSize))
+	if err != nil {
+		return report, fmt.Errorf("failed to fetch due saved searches: %w", err)
+	}
+	if len(due) == 0 {
+		return report, nil
+	}
+
+	var throttle <-chan time.Time
+	if s.config.SearchesPerSecond > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(s.config.SearchesPerSecond))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	now := s.now().UTC()
+	var order []string
+	byUser := map[string]*userMatches{}
+
+	for _, saved := range due {
+		if err := wait(ctx, throttle); err != nil {
+			return report, err
+		}
+
+		since := saved.LastCheckedAt.Time.Add(-s.config.Lookback)
+		hits, err := s.search.SearchIDs(ctx, listingsCollection, indexing.SearchQuery{
+			Q:        searchQ(saved.Query),
+			QueryBy:  queryBy,
+			FilterBy: SinceFilter(saved.FilterBy, since),
+			SortBy:   "created_at:desc",
+			Limit:    indexing.MaxSearchLimit,
+		})
+		if err != nil {
+			s.logger.Error("Failed to run saved search", "saved_search_id", saved.ID.String(), "error", err)
+			report.Failed++
+			failuresTotal.Inc()
+			continue
+		}
+		report.Checked++
+		checksTotal.Inc()
+
+		userID := saved.UserID.String()
+		user, ok := byUser[userID]
+		if !ok {
+			user = &userMatches{
+				event: events.SavedSearchMatchedEvent{UserID: userID, MatchedAt: now.Format(time.RFC3339)},
+				seen:  map[string]bool{},
+			}
+			byUser[userID] = user
+			order = append(order, userID)
+		}
+
+		if fresh := NewMatches(hits, saved.NotifiedListingIds, user.seen); len(fresh) > 0 {
+			user.event.Matches = append(user.event.Matches, events.SavedSearchMatch{
+				SavedSearchID: saved.ID.String(),
+				Query:         saved.Query,
+				ListingIDs:    fresh,
+			})
+		}
+
+		// Everything in the window is remembered, the next since-filter overlaps this one by Lookback
+		notified := hits
+		if notified == nil {
+			notified = []string{}
+		}
+		user.checked = append(user.checked, repo.MarkSavedSearchCheckedParams{
+			ID:                 saved.ID,
+			CheckedAt:          pgtype.Timestamptz{Time: now, Valid: true},
+			NextCheckAt:        pgtype.Timestamptz{Time: now.Add(s.nextCheckIn()), Valid: true},
+			NotifiedListingIds: notified,
+		})
+	}
+
+	for _, userID := range order {
+		user := byUser[userID]
+		if len(user.event.Matches) > 0 {
+			if err := s.publisher.PublishSavedSearchMatched(user.event, messageID(user.event)); err != nil {
+				s.logger.Error("Failed to publish saved search matches", "user_id", userID, "error", err)
+				report.Failed += len(user.checked)
+				failuresTotal.Add(float64(len(user.checked)))
+				continue
+			}
+			report.Notified++
+			for _, match := range user.event.Matches {
+				report.Matched += len(match.ListingIDs)
+				matchesTotal.Add(float64(len(match.ListingIDs)))
+			}
+		}
+
+		for _, params := range user.checked {
+			if err := s.repo.MarkSavedSearchChecked(ctx, params); err != nil {
+				// The listings were reported, the next run finds them again but the event's message ID dedupes it
+				s.logger.Error("Failed to mark saved search checked", "saved_search_id", params.ID.String(), "error", err)
+				report.Failed++
+				failuresTotal.Inc()
+			}
+		}
+	}
+
+	s.logger.Info("Checked saved searches", "report", report)
+	return report, nil
+}
+
+// SinceFilter narrows a saved search's filter_by to listings created at or after since. The gateway only saves
+// clauses joined by &&, so another one can be appended without brackets.
+func SinceFilter(filterBy string, since time.Time) string {
+	clause := "created_at:>=" + strconv.FormatInt(since.Unix(), 10)
+	if filterBy == "" {
+		return clause
+	}
+	return filterBy + " && " + clause
+}
+
+// NewMatches is hits without the listings the saved search already reported, or that another of the user's
+// searches reported in this run, which are added to seen
+func NewMatches(hits, notified []string, seen map[string]bool) []string {
+	already := make(map[string]bool, len(notified))
+	for _, id := range notified {
+		already[id] = true
+	}
+
+	var fresh []string
+	for _, id := range hits {
+		if already[id] || seen[id] {
+			continue
+		}
+		seen[id] = true
+		fresh = append(fresh, id)
+	}
+	return fresh
+}
+
+func (s *svc) nextCheckIn() time.Duration {
+	jitter := s.config.CheckEvery / 10
+	if jitter <= 0 {
+		return s.config.CheckEvery
+	}
+	return s.config.CheckEvery + rand.N(jitter)
+}
+
+// searchQ is the q param, a search saved with only filters matches every listing that passes them
+func searchQ(query string) string {
+	if query == "" {
+		return "*"
+	}
+	return query
+}
+
+// messageID is the same for the same matches, so a run retried after a publish that did get through is deduped
+func messageID(evt events.SavedSearchMatchedEvent) string {
+	h := sha256.New()
+	for _, match := range evt.Matches {
+		h.Write([]byte(match.SavedSearchID))
+		for _, id := range match.ListingIDs {
+			h.Write([]byte{0})
+			h.Write([]byte(id))
+		}
+		h.Write([]byte{1})
+	}
+	return "saved-search." + evt.UserID + "." + hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+func wait(ctx context.Context, throttle <-chan time.Time) error {
+	if throttle == nil {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-throttle:
+		return nil
+	}
+}
@@ -0,0 +1,165 @@
+package savedsearch_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+
+	repo "indexer/internal/database/postgresql/sqlc"
+	"indexer/internal/events"
+	"indexer/internal/indexing"
+	"indexer/internal/mocks/mockindexing"
+	"indexer/internal/mocks/mockrepo"
+	"indexer/internal/savedsearch"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- MOCKS ---
+
+// FakePublisher records every event instead of publishing it
+type FakePublisher struct {
+	published []events.SavedSearchMatchedEvent
+	err       error
+}
+
+func (f *FakePublisher) PublishSavedSearchMatched(evt events.SavedSearchMatchedEvent, msgID string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.published = append(f.published, evt)
+	return nil
+}
+
+// --- HELPERS ---
+
+var lastChecked = time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+
+func uuid(t *testing.T, s string) pgtype.UUID {
+	t.Helper()
+	var id pgtype.UUID
+	require.NoError(t, id.Scan(s))
+	return id
+}
+
+func newSavedSearch(t *testing.T, id, userID, query, filterBy string, notified ...string) repo.SavedSearch {
+	return repo.SavedSearch{
+		ID:                 uuid(t, id),
+		UserID:             uuid(t, userID),
+		Query:              query,
+		FilterBy:           filterBy,
+		NotifiedListingIds: notified,
+		LastCheckedAt:      pgtype.Timestamptz{Time: lastChecked, Valid: true},
+	}
+}
+
+// --- TESTS ---
+
+func TestSinceFilter(t *testing.T) {
+	since := time.Unix(1792137600, 0)
+
+	assert.Equal(t, "created_at:>=1792137600", savedsearch.SinceFilter("", since))
+	assert.Equal(t, "categories:=[`toys`] && dim_x_mm:<=220 && created_at:>=1792137600",
+		savedsearch.SinceFilter("categories:=[`toys`] && dim_x_mm:<=220", since))
+}
+
+func TestNewMatches(t *testing.T) {
+	seen := map[string]bool{"c": true}
+
+	fresh := savedsearch.NewMatches([]string{"a", "b", "c", "d"}, []string{"b"}, seen)
+
+	assert.Equal(t, []string{"a", "d"}, fresh)
+	assert.Equal(t, map[string]bool{"a": true, "c": true, "d": true}, seen)
+	assert.Nil(t, savedsearch.NewMatches([]string{"a", "d"}, nil, seen), "already reported under another search")
+}
+
+func TestRun_ReportsNewListingsOncePerUser(t *testing.T) {
+	// SCENARIO: A user has two saved searches matching the same new listing, one of them already reported a listing.
+	// EXPECT: One event for the user, each listing reported once, and both searches remember every hit.
+
+	mockRepo := mockrepo.NewQuerier(t)
+	mockSearch := mockindexing.NewIndexer(t)
+	publisher := &FakePublisher{}
+
+	user := "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	dragons := newSavedSearch(t, "550e8400-e29b-41d4-a716-446655440001", user, "dragon", "categories:=[`toys`]", "old")
+	everything := newSavedSearch(t, "550e8400-e29b-41d4-a716-446655440002", user, "", "dim_z_mm:<=220")
+	mockRepo.EXPECT().GetDueSavedSearches(mock.Anything, int32(500)).Return([]repo.SavedSearch{dragons, everything}, nil)
+
+	since := lastChecked.Add(-time.Hour).Unix()
+	mockSearch.EXPECT().SearchIDs(mock.Anything, "listings", indexing.SearchQuery{
+		Q: "dragon", QueryBy: "title,description,categories", SortBy: "created_at:desc", Limit: indexing.MaxSearchLimit,
+		FilterBy: "categories:=[`toys`] && created_at:>=" + strconv.FormatInt(since, 10),
+	}).Return([]string{"new-dragon", "old"}, nil)
+	mockSearch.EXPECT().SearchIDs(mock.Anything, "listings", mock.MatchedBy(func(q indexing.SearchQuery) bool {
+		return q.Q == "*" && q.FilterBy == "dim_z_mm:<=220 && created_at:>="+strconv.FormatInt(since, 10)
+	})).Return([]string{"new-dragon", "benchy"}, nil)
+
+	var marked []repo.MarkSavedSearchCheckedParams
+	mockRepo.EXPECT().MarkSavedSearchChecked(mock.Anything, mock.Anything).
+		Run(func(_ context.Context, arg repo.MarkSavedSearchCheckedParams) { marked = append(marked, arg) }).
+		Return(nil)
+
+	svc := savedsearch.NewService(mockRepo, mockSearch, publisher, slog.Default(), savedsearch.Config{Lookback: time.Hour})
+	report, err := svc.Run(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, savedsearch.Report{Checked: 2, Matched: 2, Notified: 1}, report)
+	require.Len(t, publisher.published, 1)
+	assert.Equal(t, user, publisher.published[0].UserID)
+	assert.Equal(t, []events.SavedSearchMatch{
+		{SavedSearchID: "550e8400-e29b-41d4-a716-446655440001", Query: "dragon", ListingIDs: []string{"new-dragon"}},
+		{SavedSearchID: "550e8400-e29b-41d4-a716-446655440002", Query: "", ListingIDs: []string{"benchy"}},
+	}, publisher.published[0].Matches)
+
+	require.Len(t, marked, 2)
+	assert.Equal(t, []string{"new-dragon", "old"}, marked[0].NotifiedListingIds)
+	assert.Equal(t, []string{"new-dragon", "benchy"}, marked[1].NotifiedListingIds)
+	for _, m := range marked {
+		assert.True(t, m.NextCheckAt.Time.Sub(m.CheckedAt.Time) >= time.Hour, "next check is at least CheckEvery away")
+		assert.True(t, m.NextCheckAt.Time.Sub(m.CheckedAt.Time) < time.Hour+6*time.Minute, "jitter is at most a tenth")
+	}
+}
+
+func TestRun_PublishFailureLeavesSearchesDue(t *testing.T) {
+	mockRepo := mockrepo.NewQuerier(t)
+	mockSearch := mockindexing.NewIndexer(t)
+	publisher := &FakePublisher{err: errors.New("nats down")}
+
+	saved := newSavedSearch(t, "550e8400-e29b-41d4-a716-446655440001", "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", "dragon", "")
+	mockRepo.EXPECT().GetDueSavedSearches(mock.Anything, mock.Anything).Return([]repo.SavedSearch{saved}, nil)
+	mockSearch.EXPECT().SearchIDs(mock.Anything, "listings", mock.Anything).Return([]string{"new-dragon"}, nil)
+
+	svc := savedsearch.NewService(mockRepo, mockSearch, publisher, slog.Default(), savedsearch.Config{})
+	report, err := svc.Run(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Failed)
+	mockRepo.AssertNotCalled(t, "MarkSavedSearchChecked", mock.Anything, mock.Anything)
+}
+
+func TestRun_NoMatchesStillMarksChecked(t *testing.T) {
+	mockRepo := mockrepo.NewQuerier(t)
+	mockSearch := mockindexing.NewIndexer(t)
+	publisher := &FakePublisher{}
+
+	saved := newSavedSearch(t, "550e8400-e29b-41d4-a716-446655440001", "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", "dragon", "", "old")
+	mockRepo.EXPECT().GetDueSavedSearches(mock.Anything, mock.Anything).Return([]repo.SavedSearch{saved}, nil)
+	mockSearch.EXPECT().SearchIDs(mock.Anything, "listings", mock.Anything).Return([]string{"old"}, nil)
+	mockRepo.EXPECT().MarkSavedSearchChecked(mock.Anything, mock.MatchedBy(func(arg repo.MarkSavedSearchCheckedParams) bool {
+		return arg.ID == saved.ID && len(arg.NotifiedListingIds) == 1
+	})).Return(nil)
+
+	svc := savedsearch.NewService(mockRepo, mockSearch, publisher, slog.Default(), savedsearch.Config{})
+	report, err := svc.Run(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, savedsearch.Report{Checked: 1}, report)
+	assert.Empty(t, publisher.published)
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+var _ Provider = (*MinioProvider)(nil)
+
+type MinioProvider struct {
+	client *minio.Client
+}
+
+// NewMinioProvider initializes the MinIO client.
+// In production, pass 'useSSL: true' for S3/Cloud.
+func NewMinioProvider(endpoint, accessKeyID, secretAccessKey string, useSSL bool) (Provider, error) {
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
+		Secure: useSSL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create minio client: %w", err)
+	}
+
+	return &MinioProvider{client: client}, nil
+}
+
+// Delete removes a file.
+func (m *MinioProvider) Delete(ctx context.Context, bucket Bucket, key string) error {
+	opts := minio.RemoveObjectOptions{
+		GovernanceBypass: true, // Useful if you have object locking enabled
+	}
+
+	err := m.client.RemoveObject(ctx, string(bucket), key, opts)
+	if err != nil {
+		return mapMinioError(err)
+	}
+	return nil
+}
+
+// ProbeBucket checks the bucket exists and these credentials can delete from it, by deleting a key that was never
+// written. Used by the startup preflight.
+func (m *MinioProvider) ProbeBucket(ctx context.Context, bucket Bucket) error {
+	exists, err := m.client.BucketExists(ctx, string(bucket))
+	if err != nil {
+		return mapMinioError(err)
+	}
+	if !exists {
+		return ErrNotFound
+	}
+
+	host, _ := os.Hostname()
+	return m.Delete(ctx, bucket, fmt.Sprintf(".preflight/%s-%d", host, time.Now().UnixNano()))
+}
+
+// mapMinioError translates MinIO SDK errors into our domain errors
+func mapMinioError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	errResp := minio.ToErrorResponse(err)
+
+	switch errResp.Code {
+	case "NoSuchKey":
+		return ErrNotFound
+	case "AccessDenied":
+		return ErrAccessDenied
+	}
+
+	if errResp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if errResp.StatusCode == http.StatusForbidden {
+		return ErrAccessDenied
+	}
+
+	return fmt.Errorf("storage provider error: %w", err)
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"errors"
+)
+
+// Bucket represents a logical storage zone.
+// These mirror the buckets the gateway writes to.
+type Bucket string
+
+const (
+	// BucketIncoming: Private, 24h retention policy.
+	// Users upload here directly.
+	BucketIncoming Bucket = "incoming-files"
+
+	// BucketPublic: Public Read.
+	// Validated images live here.
+	BucketPublic Bucket = "public-files"
+
+	// BucketProduct: Private.
+	// Final storage for product files (3D models, etc).
+	BucketProduct Bucket = "product-files"
+)
+
+// Wrapper for standard errors so checking them is consistent
+var (
+	ErrNotFound     = errors.New("storage: file not found")
+	ErrAccessDenied = errors.New("storage: access denied")
+)
+
+// Provider abstracts S3, MinIO, or Google Cloud Storage.
+// The worker only needs the destructive side of the API today.
+type Provider interface {
+	// Delete removes a file. Deleting a missing key is not an error.
+	Delete(ctx context.Context, bucket Bucket, key string) error
+}
@@ -0,0 +1,181 @@
+// Package fixtures builds listings for tests. Every column is filled with a value that agrees with the others, so a
+// test only spells out what it's about and a new column can't go unnoticed as a zero value, see listing_test.go.
+// It mirrors the gateway's fixtures, both services read the same listings table.
+package fixtures
+
+import (
+	"fmt"
+	"time"
+
+	repo "indexer/internal/database/postgresql/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	ListingID = "550e8400-e29b-41d4-a716-446655440000"
+	SellerID  = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+	ParentID  = "660e8400-e29b-41d4-a716-446655440000"
+)
+
+// CreatedAt is when every fixture listing was created, the other timestamps follow from it
+var CreatedAt = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// Dimensions is the size every physical fixture listing has, in the shape of the dimensions_mm column
+const Dimensions = `{"width":120,"depth":80,"height":45}`
+
+// Opt changes the default listing, options are applied in order
+type Opt func(l *repo.Listing)
+
+func defaultListing() repo.Listing {
+	return repo.Listing{
+		ID:             UUID(ListingID),
+		SellerID:       UUID(SellerID),
+		SellerName:     "Tester Prints",
+		SellerUsername: "tester",
+		SellerVerified: true,
+
+		Title:         "Benchy",
+		Description:   pgtype.Text{String: "The classic", Valid: true},
+		PriceMinUnit:  1050,
+		Currency:      "gbp",
+		Categories:    []string{"Art"},
+		License:       "MIT",
+		ThumbnailPath: pgtype.Text{String: "public/thumb.webp", Valid: true},
+		LastIndexedAt: pgtype.Timestamptz{Time: CreatedAt.Add(2 * time.Hour), Valid: true},
+		Status:        repo.NullListingStatus{ListingStatus: repo.ListingStatusACTIVE, Valid: true},
+
+		IsRemixingAllowed: true,
+
+		IsPhysical:             true,
+		TotalWeightGrams:       pgtype.Int4{Int32: 150, Valid: true},
+		IsAssemblyRequired:     true,
+		IsHardwareRequired:     true,
+		HardwareRequired:       []string{"M3 screws"},
+		IsMulticolor:           true,
+		DimensionsMm:           []byte(Dimensions),
+		RecommendedNozzleTempC: pgtype.Int4{Int32: 215, Valid: true},
+		RecommendedMaterials:   []string{"PLA"},
+		NozzleDiameterMm:       Numeric("0.40"),
+		Language:               "en",
+
+		LikesCount:          12,
+		DownloadsCount:      34,
+		CommentsCount:       5,
+		ViewsCount:          210,
+		SellerRatingAverage: Numeric("4.5"),
+		SellerTotalRatings:  pgtype.Int4{Int32: 8, Valid: true},
+		SellerTotalSales:    pgtype.Int4{Int32: 40, Valid: true},
+
+		CreatedAt: pgtype.Timestamptz{Time: CreatedAt, Valid: true},
+		UpdatedAt: pgtype.Timestamptz{Time: CreatedAt.Add(time.Hour), Valid: true},
+	}
+}
+
+// NewListing is an active physical listing by SellerID, not on sale, not a remix
+func NewListing(opts ...Opt) repo.Listing {
+	l := defaultListing()
+	for _, opt := range opts {
+		opt(&l)
+	}
+	return l
+}
+
+// With edits the listing directly, for the one-off values no other option covers
+func With(edit func(l *repo.Listing)) Opt {
+	return edit
+}
+
+func WithID(id string) Opt {
+	return func(l *repo.Listing) { l.ID = UUID(id) }
+}
+
+func WithSeller(id string) Opt {
+	return func(l *repo.Listing) { l.SellerID = UUID(id) }
+}
+
+func WithTitle(title string) Opt {
+	return func(l *repo.Listing) { l.Title = title }
+}
+
+func WithStatus(status repo.ListingStatus) Opt {
+	return func(l *repo.Listing) {
+		l.Status = repo.NullListingStatus{ListingStatus: status, Valid: true}
+	}
+}
+
+func WithPrice(minUnit int64, currency string) Opt {
+	return func(l *repo.Listing) {
+		l.PriceMinUnit = minUnit
+		l.Currency = currency
+	}
+}
+
+// WithThumbnail sets the thumbnail's object path, "" for a listing without one
+func WithThumbnail(path string) Opt {
+	return func(l *repo.Listing) { l.ThumbnailPath = pgtype.Text{String: path, Valid: path != ""} }
+}
+
+// WithNoDimensions is a physical listing whose seller never gave its size
+func WithNoDimensions() Opt {
+	return func(l *repo.Listing) { l.DimensionsMm = nil }
+}
+
+// WithDigital is a digital-only listing, so nothing about shipping a print is set
+func WithDigital() Opt {
+	return func(l *repo.Listing) {
+		l.IsPhysical = false
+		l.DimensionsMm = nil
+		l.TotalWeightGrams = pgtype.Int4{}
+		l.IsAssemblyRequired = false
+		l.IsHardwareRequired = false
+		l.HardwareRequired = nil
+	}
+}
+
+// WithSale puts the listing on sale at priceMinUnit, in the listing's currency, until ends
+func WithSale(name string, priceMinUnit int64, ends time.Time) Opt {
+	return func(l *repo.Listing) {
+		l.IsSaleActive = true
+		l.SaleName = pgtype.Text{String: name, Valid: true}
+		l.SalePrice = pgtype.Int8{Int64: priceMinUnit, Valid: true}
+		l.SaleEndTimestamp = pgtype.Timestamptz{Time: ends, Valid: true}
+	}
+}
+
+func WithRemixOf(parentID string) Opt {
+	return func(l *repo.Listing) { l.ParentListingID = UUID(parentID) }
+}
+
+func WithAIModel(name string) Opt {
+	return func(l *repo.Listing) {
+		l.IsAiGenerated = true
+		l.AiModelName = pgtype.Text{String: name, Valid: true}
+	}
+}
+
+func WithNSFW() Opt {
+	return func(l *repo.Listing) { l.IsNsfw = true }
+}
+
+func WithDeleted(at time.Time) Opt {
+	return func(l *repo.Listing) { l.DeletedAt = pgtype.Timestamptz{Time: at, Valid: true} }
+}
+
+// UUID parses a fixture ID, panicking on a typo
+func UUID(id string) pgtype.UUID {
+	var u pgtype.UUID
+	if err := u.Scan(id); err != nil {
+		panic(fmt.Sprintf("fixtures: invalid UUID %q: %v", id, err))
+	}
+	return u
+}
+
+// Numeric parses a decimal, panicking on a typo
+func Numeric(n string) pgtype.Numeric {
+	var num pgtype.Numeric
+	if err := num.Scan(n); err != nil {
+		panic(fmt.Sprintf("fixtures: invalid numeric %q: %v", n, err))
+	}
+	return num
+}
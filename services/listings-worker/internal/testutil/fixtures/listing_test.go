@@ -0,0 +1,74 @@
+package fixtures_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"indexer/internal/testutil/fixtures"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unsetByDefault are the columns the default listing leaves NULL or false, each has an option that sets it
+var unsetByDefault = map[string]bool{
+	"ParentListingID":  true, // WithRemixOf
+	"IsAiGenerated":    true, // WithAIModel
+	"AiModelName":      true,
+	"IsSaleActive":     true, // WithSale
+	"SalePrice":        true,
+	"SaleName":         true,
+	"SaleEndTimestamp": true,
+	"IsNsfw":           true, // WithNSFW
+	"DeletedAt":        true, // WithDeleted
+}
+
+func zeroFields(row any) (zero []string) {
+	v := reflect.ValueOf(row)
+	for i := range v.NumField() {
+		if v.Field(i).IsZero() {
+			zero = append(zero, v.Type().Field(i).Name)
+		}
+	}
+	return zero
+}
+
+func TestNewListing_Complete(t *testing.T) {
+	// SCENARIO: A column was added to listings and regenerated into the sqlc structs.
+	// EXPECT: This fails until the default fixture fills it, or it's listed in unsetByDefault with an option that does.
+
+	for _, field := range zeroFields(fixtures.NewListing()) {
+		assert.True(t, unsetByDefault[field], "%s is zero in the default fixture, fill it in defaultListing", field)
+	}
+
+	everything := fixtures.NewListing(
+		fixtures.WithRemixOf(fixtures.ParentID),
+		fixtures.WithAIModel("Meshy"),
+		fixtures.WithSale("Spring sale", 800, fixtures.CreatedAt.Add(7*24*time.Hour)),
+		fixtures.WithNSFW(),
+		fixtures.WithDeleted(fixtures.CreatedAt.Add(48*time.Hour)),
+	)
+	assert.Empty(t, zeroFields(everything), "no option sets these, add one")
+}
+
+func TestNewListing_Consistent(t *testing.T) {
+	listing := fixtures.NewListing()
+
+	var dims struct{ Width, Depth, Height int }
+	require.NoError(t, json.Unmarshal(listing.DimensionsMm, &dims))
+	assert.Positive(t, dims.Width*dims.Depth*dims.Height, "a physical listing has a full size")
+	assert.True(t, listing.TotalWeightGrams.Valid)
+
+	digital := fixtures.NewListing(fixtures.WithDigital())
+	assert.False(t, digital.IsPhysical)
+	assert.Nil(t, digital.DimensionsMm)
+	assert.False(t, digital.TotalWeightGrams.Valid)
+	assert.Empty(t, digital.HardwareRequired)
+
+	sale := fixtures.NewListing(fixtures.WithSale("Spring sale", 800, fixtures.CreatedAt.Add(time.Hour)))
+	assert.True(t, sale.SalePrice.Valid)
+	assert.Less(t, sale.SalePrice.Int64, sale.PriceMinUnit, "a sale is cheaper than the listing")
+	assert.True(t, sale.SaleEndTimestamp.Time.After(sale.CreatedAt.Time))
+}
@@ -21,6 +21,7 @@ import {
   PopoverTrigger,
 } from "@/components/ui/popover"
 import { type ListingDraft } from "@/hooks/use-listing-draft"
+import { ListingService } from "@/lib/services/listing-service"
 import { cn } from "@/lib/utils"
 
 // Define your categories here (or import from a constant file)
@@ -59,9 +60,32 @@ export function StageGeneral({ draft, update, onNext }: Props) {
       update({
         categories: [...draft.categories, categoryValue],
       })
+      if (draft.categories.length === 0) {
+        prefillFromDefaults(categoryValue)
+      }
     }
   }
 
+  // The first category picked fills in its recommended settings, only where the seller hasn't entered anything yet
+  const prefillFromDefaults = async (categoryValue: string) => {
+    const defaults = await ListingService.getCategoryDefaults(categoryValue)
+    if (!defaults) return
+
+    const settings = draft.printerSettings
+    const untouchedMaterials = settings.recommendedMaterials.length === 0 ||
+      (settings.recommendedMaterials.length === 1 && settings.recommendedMaterials[0] === "PLA")
+    const untouchedDimensions = !draft.dimensions || (draft.dimensions.x === 0 && draft.dimensions.y === 0 && draft.dimensions.z === 0)
+
+    update({
+      printerSettings: {
+        ...settings,
+        recommendedMaterials: untouchedMaterials ? defaults.recommended_materials : settings.recommendedMaterials,
+        nozzleTemperature: settings.nozzleTemperature ?? Math.round((defaults.nozzle_temp_c.min + defaults.nozzle_temp_c.max) / 2),
+      },
+      ...(untouchedDimensions && { dimensions: defaults.typical_dimensions_mm }),
+    })
+  }
+
   return (
     <div className="space-y-6 animate-in fade-in slide-in-from-bottom-4">
       <div className="border-b pb-4">
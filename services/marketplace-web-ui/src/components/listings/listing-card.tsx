@@ -11,7 +11,8 @@ interface ListingCardProps {
 }
 
 export function ListingCard({ listing, onClick, className }: ListingCardProps) {
-  const isPending = listing.status === "PENDING_VALIDATION";
+  const isInReview = listing.status === "PENDING_REVIEW";
+  const isPending = listing.status === "PENDING_VALIDATION" || isInReview;
   const isRejected = listing.status === "REJECTED";
   const isActive = listing.status === "ACTIVE";
 
@@ -66,8 +67,8 @@ export function ListingCard({ listing, onClick, className }: ListingCardProps) {
                  </div>
                </div>
                <div className="space-y-0.5">
-                 <p className="text-sm font-semibold text-foreground">Processing</p>
-                 <p className="text-[10px] text-muted-foreground">Validating files...</p>
+                 <p className="text-sm font-semibold text-foreground">{isInReview ? "In review" : "Processing"}</p>
+                 <p className="text-[10px] text-muted-foreground">{isInReview ? "A moderator will take a look shortly" : "Validating files..."}</p>
                </div>
             </div>
           )}
@@ -132,7 +133,7 @@ export function ListingCard({ listing, onClick, className }: ListingCardProps) {
 
           <p className="line-clamp-1 text-xs text-muted-foreground">
              {isRejected 
-               ? <span className="text-destructive font-medium">Security check failed</span>
+               ? <span className="text-destructive font-medium" title={listing.status_reason ?? undefined}>Security check failed</span>
                : listing.categories?.join(", ") || "No category"
              }
           </p>
@@ -137,7 +137,7 @@ export function ListingEditSheet({ listing, isOpen, onClose }: ListingEditSheetP
         z: listing.dim_z_mm || 0,
       },
       printerSettings: {
-        nozzleDiameter: listing.recommended_nozzle_diameter || "",
+        nozzleDiameter: listing.nozzle_diameter_mm?.toString() || "",
         nozzleTemperature: listing.recommended_nozzle_temp_c || undefined,
         recommendedMaterials: listing.recommended_materials || [],
         isMulticolor: listing.is_multicolor || false,
@@ -198,6 +198,9 @@ export interface ListingProps {
     // Under which license the listing is provided
     license: string;
 
+    // Language detected from the title and description, "und" when it couldn't be told
+    language?: string;
+
     // Categories associated with the listing
     categories: string[];
 
@@ -213,7 +216,7 @@ export interface ListingProps {
 
     is_assembly_required: boolean;
     is_hardware_required: boolean;
-    hardware_required: string[] | null;
+    hardware_required: string[];
 
     // Remixing and modification permissions
     is_remixing_allowed: boolean;
@@ -222,6 +225,7 @@ export interface ListingProps {
     is_multicolor: boolean;
     recommended_materials: string[];
     recommended_nozzle_temp_c: number | null;
+    nozzle_diameter_mm: number | null;
 
     // AI generation flags
     is_ai_generated: boolean;
@@ -238,6 +242,8 @@ export interface ListingProps {
     is_sale_active: boolean;
     sale_name: string | null;
     sale_end_timestamp: string | null; // Millseconds since epoch
+    // Owner only, the latest price changes newest first
+    price_history?: PriceChange[];
 
     // Seller
     seller_id: string
@@ -245,13 +251,52 @@ export interface ListingProps {
     seller_verified: boolean
     seller_username:string
 
-    // Timestamps
+    // Timestamps, always UTC
     created_at: string;
+    created_at_unix?: number;
     updated_at: string;
     last_indexed_at?: string | null;
 
-    status: "PENDING_VALIDATION" | "ACTIVE" | "INACTIVE" | "REJECTED"
+    status: "PENDING_VALIDATION" | "PENDING_REVIEW" | "ACTIVE" | "INACTIVE" | "REJECTED" | "SUSPENDED"
+    // Why the listing failed, only sent for REJECTED, HIDDEN and SUSPENDED listings
+    status_reason?: string | null;
+    // Only on the seller's own listings, set while the listing couldn't be added to search
+    index_error?: string | null;
+
+    // Set while the seller is on vacation, paid downloads are paused until unavailable_until
+    temporarily_unavailable?: boolean;
+    unavailable_until?: string | null;
+    seller_away_message?: string | null;
+    // Search documents only, the same state as temporarily_unavailable
+    seller_on_vacation?: boolean;
+    // Search documents only, when the seller was last signed in: active_7d, active_30d or dormant
+    seller_activity_bucket?: "active_7d" | "active_30d" | "dormant";
+    // Search documents only, the latest price change was a drop in the last 14 days
+    price_dropped_recently?: boolean;
+}
 
+export interface PriceChange {
+    old_price_min_unit: number;
+    new_price_min_unit: number;
+    old_currency: string;
+    new_currency: string;
+    changed_at: string;
 }
 
-export type IndexedListingProps = Omit<ListingProps, "description" | "files">;
\ No newline at end of file
+export type IndexedListingProps = Omit<ListingProps, "description" | "files">;
+// Recommended printer settings for a category, GET /categories/{slug}/defaults
+export interface CategoryDefaults {
+    category: string;
+    recommended_materials: string[];
+    nozzle_temp_c: { min: number; max: number };
+    typical_dimensions_mm: ListingDimensions;
+    updated_at: string;
+}
+
+// Returned with a created listing when a value is far off its category's defaults, never blocks the listing
+export interface ListingWarning {
+    field: string;
+    reason: "LISTING_NOZZLE_TEMP_UNUSUAL" | "LISTING_MATERIALS_UNUSUAL" | "LISTING_DIMENSIONS_UNUSUAL";
+    message: string;
+    params: Record<string, string>;
+}
@@ -1,11 +1,32 @@
 import { MOCK_TRENDING_LISTINGS } from "@/components/listings/trending-listings";
 import { apiClient, publicRoutesApiClient } from "@/lib/api/http";
-import { type CategoryFilter, type CreateListingRequest, type IndexedListingProps, type ListingProps } from "@/lib/api/models";
+import { type CategoryDefaults, type CategoryFilter, type CreateListingRequest, type IndexedListingProps, type ListingProps, type ListingWarning } from "@/lib/api/models";
 import type { SearchResponse } from "typesense/lib/Typesense/Documents";
 import { typesenseClient } from "../typesense/typesense";
+import { MARKETPLACE_CONFIG } from "../utils";
+
+// Backtick quoted so commas and && in a value stay literal. Typesense can't escape a backtick, so those are dropped
+const quoteFilterValue = (value: string) => `\`${value.replace(/`/g, '')}\``
+
+// Codes the gateway's language detector tags listings with, the same list as language.Supported()
+const DETECTED_LANGUAGES = ['ar', 'de', 'el', 'en', 'es', 'fr', 'he', 'it', 'ja', 'ko', 'nl', 'pl', 'pt', 'ru', 'th', 'zh']
+
+// Searching in every language, the same as the gateway's lang=all
+export const ALL_LANGUAGES = 'all'
+
+// The first of the browser's languages listings are tagged with, like the gateway does with Accept-Language
+const browserLanguage = (): string => {
+  if (typeof navigator === 'undefined') return ALL_LANGUAGES
+  const preferred = navigator.languages?.length ? navigator.languages : [navigator.language]
+  for (const tag of preferred) {
+    const code = tag?.split('-')[0].toLowerCase()
+    if (code && DETECTED_LANGUAGES.includes(code)) return code
+  }
+  return ALL_LANGUAGES
+}
 
 export const ListingService = {
- async create(payload: CreateListingRequest, idempotencyKey: string) {
+ async create(payload: CreateListingRequest, idempotencyKey: string) : Promise<{ id: string; warnings: ListingWarning[] }> {
     const { data } = await apiClient.post("/listings", payload, {
       headers: {
         "Idempotency-Key": idempotencyKey,
@@ -25,6 +46,15 @@ export const ListingService = {
     const { data } = await apiClient.delete(`/listings/${id}`);
     return data;
   },
+  // Null when the category has no template, the form is just left as it is
+  async getCategoryDefaults(slug: string) : Promise<CategoryDefaults | null>{
+    try {
+      const { data } = await publicRoutesApiClient.get(`/categories/${encodeURIComponent(slug)}/defaults`);
+      return data;
+    } catch {
+      return null;
+    }
+  },
   async getListingById(id: string) : Promise<ListingProps>{
     console.log("Fetching listing by ID:", id);
     const { data } = await publicRoutesApiClient.get(`/listings/${id}`);
@@ -34,24 +64,39 @@ export const ListingService = {
   async getListings({
     categories, 
     query, 
-    pageParam = 1
-  }: {categories: CategoryFilter[], query: string, pageParam: number}) : Promise<SearchResponse<IndexedListingProps>> {
-      // 1. Construct Typesense filter string
-      // Format: category:=[value1, value2]
+    pageParam = 1,
+    language = browserLanguage()
+  }: {categories: CategoryFilter[], query: string, pageParam: number, language?: string}) : Promise<SearchResponse<IndexedListingProps>> {
+      // 1. Construct Typesense filter string, the same rules as the gateway's searchfilter package
+      // Format: categories:=[`value1`,`value2`]
       const activeCategories = categories
         .map((c) => c.value)
         .filter((v): v is string => v !== null) // Exclude null ('All Categories')
 
-      let filterBy = ''
+      const filters: string[] = []
       if (activeCategories.length > 0) {
-        filterBy = `category:=[${activeCategories.join(',')}]`
+        filters.push(`categories:=[${activeCategories.map(quoteFilterValue).join(',')}]`)
+      }
+
+      // Listings the detector couldn't tell ("und") show up whatever the language
+      if (language !== ALL_LANGUAGES) {
+        filters.push(`language:=[${[language, 'und'].map(quoteFilterValue).join(',')}]`)
+      }
+
+      // Listings of sellers on vacation are hidden or ranked last, documents indexed before vacations existed have no flag
+      let sortBy: string | undefined
+      if (MARKETPLACE_CONFIG.typesense.away_sellers === 'hide') {
+        filters.push('seller_on_vacation:!=true')
+      } else {
+        sortBy = '_eval(seller_on_vacation:true):asc,_text_match:desc,created_at:desc'
       }
 
       // 2. Perform the search
       const searchParameters = {
         q: query || '*',
         query_by: 'title,description,categories',
-        filter_by: filterBy,
+        filter_by: filters.join(' && '),
+        ...(sortBy && { sort_by: sortBy }),
         page: pageParam,
         collection: 'listings', // Typesense collection name
         per_page: 20,
@@ -16,7 +16,9 @@ export const MARKETPLACE_CONFIG = Object.freeze({
         host: "localhost",
         connectionTimeout: 2,
         port: 8108,
-        protocol: "http"
+        protocol: "http",
+        // What search does with listings of sellers on vacation: "hide" leaves them out, "demote" ranks them last
+        away_sellers: "hide" as "hide" | "demote",
     }
 })
 
@@ -46,6 +46,9 @@ class PostgresConfig(BaseSettings):
 class EventsConfig(BaseSettings):
     incoming_validation: str = Field(..., alias="VALIDATION_WORKER_EVENT_SUBJECT")
     index_listing: str = Field(..., alias="EVENT_INDEX_LISTING")
+    listing_published: str = Field(..., alias="EVENT_LISTING_PUBLISHED")
+    # Unset leaves per-file progress unpublished, the gateway's listing event stream then only sees listings go live
+    file_validated: Optional[str] = Field(None, alias="EVENT_FILE_VALIDATED")
 
 
 class EnvironmentConfig(abc.ABC):
@@ -320,6 +323,30 @@ class IndexListingEvent(BaseEvent):
     listing_id: str
 
 
+class ListingPublishedEvent(BaseEvent):
+    """
+    The listing passed validation and went ACTIVE. Fans out to notifications, analytics, etc.
+    (ListingPublishedEvent in the listings worker's events package).
+    """
+
+    topic: str
+    listing_id: str
+    status: str  # The listing's status now, ACTIVE
+
+
+class FileValidatedEvent(BaseEvent):
+    """
+    One file of a listing finished validation. Fans out to the gateway's listing event stream, so a seller watching
+    their new listing sees each file land (FileValidatedEvent in the gateway's events package).
+    """
+
+    topic: str
+    listing_id: str
+    file_id: str
+    status: str  # VALID, INVALID or FAILED, as stored on the file
+    error: Optional[str] = None  # Why the file isn't valid
+
+
 class DeadLetterEvent(BaseEvent):
     topic: str
     original_event: dict
@@ -172,6 +172,7 @@ class NatsEventBus(EventBus):
                 storage=StorageType.FILE,  # Save to disk so they survive restarts
                 retention=RetentionPolicy.LIMITS,  # Keep messages until they hit age/size limits
                 max_age=14 * 24 * 60 * 60,  # Optional: Auto-delete after 14 days
+                allow_direct=True,  # The listings worker replays dead letters oldest first with direct gets
             )
             logger.info("✅ JetStream 'DLQ' stream verified.")
         except Exception as e:
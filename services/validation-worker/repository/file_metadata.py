@@ -0,0 +1,96 @@
+import json
+import logging
+from typing import Any
+
+logger = logging.getLogger(__name__)
+
+# Mirrors MaxFileMetadataBytes in the gateway, which drops anything larger on read
+MAX_FILE_METADATA_BYTES = 16 * 1024
+
+# Validator output key -> (field in listing_files.metadata, expected type)
+_TOP_LEVEL_FIELDS: dict[str, tuple[str, type]] = {
+    "format": ("format", str),
+    "mime": ("format", str),
+    "triangle_count": ("triangle_count", int),
+    "triangles": ("triangle_count", int),
+    "byte_size": ("byte_size", int),
+}
+
+_SCAN_FIELDS: dict[str, str] = {
+    "is_watertight": "watertight",
+    "is_winding_consistent": "winding_consistent",
+}
+
+
+def normalize_file_metadata(raw: dict[str, Any] | None) -> dict[str, Any]:
+    """
+    Reduces whatever the validators reported to the whitelisted document the gateway exposes
+    (see FileMetadata in the gateway's listings package):
+
+        {"format": str, "triangle_count": int, "byte_size": int,
+         "bounding_box": {"min": [x, y, z], "max": [x, y, z]},
+         "scan": {"watertight": bool, "winding_consistent": bool}}
+
+    Unknown keys and values of the wrong type are dropped with a warning. If the result is still
+    over MAX_FILE_METADATA_BYTES it's replaced with an empty document rather than failing the file.
+    """
+    if not raw:
+        return {}
+
+    out: dict[str, Any] = {}
+    scan: dict[str, bool] = {}
+    dropped: list[str] = []
+
+    for key, value in raw.items():
+        if value is None:
+            continue
+
+        if key in _TOP_LEVEL_FIELDS:
+            field, expected = _TOP_LEVEL_FIELDS[key]
+            # bool is an int subclass, don't let True through as a triangle count
+            if isinstance(value, expected) and not isinstance(value, bool):
+                out[field] = value
+            else:
+                dropped.append(key)
+        elif key in _SCAN_FIELDS:
+            if isinstance(value, bool):
+                scan[_SCAN_FIELDS[key]] = value
+            else:
+                dropped.append(key)
+        elif key == "bounds":
+            box = _bounding_box(value)
+            if box is not None:
+                out["bounding_box"] = box
+            else:
+                dropped.append(key)
+        else:
+            dropped.append(key)
+
+    if scan:
+        out["scan"] = scan
+
+    if dropped:
+        logger.warning(f"Dropping file metadata keys not in the whitelist or of the wrong type: {sorted(dropped)}")
+
+    size = len(json.dumps(out).encode())
+    if size > MAX_FILE_METADATA_BYTES:
+        logger.warning(f"Rejecting file metadata of {size} bytes, limit is {MAX_FILE_METADATA_BYTES}")
+        return {}
+
+    return out
+
+
+def _bounding_box(value: Any) -> dict[str, list[float]] | None:
+    """trimesh bounds are [[minX, minY, minZ], [maxX, maxY, maxZ]]"""
+    if not isinstance(value, list) or len(value) != 2:
+        return None
+
+    corners = []
+    for corner in value:
+        if not isinstance(corner, list) or len(corner) != 3:
+            return None
+        if not all(isinstance(c, (int, float)) and not isinstance(c, bool) for c in corner):
+            return None
+        corners.append([float(c) for c in corner])
+
+    return {"min": corners[0], "max": corners[1]}
@@ -9,18 +9,30 @@ class InMemoryRepository(ListingRepository):
         # file_id -> {"status": "PENDING"|"VALID"|"INVALID"|"FAILED", "listing_id": "...", "error": "..."}
         self.files: Dict[str, Dict[str, Any]] = {}
 
-        # listing_id -> {"status": "PENDING_VALIDATION"|"ACTIVE"|"REJECTED", "id": "..."}
+        # listing_id -> {"status": "PENDING_VALIDATION"|"PENDING_REVIEW"|"ACTIVE"|"REJECTED", "id": "..."}
         self.listings: Dict[str, Dict[str, Any]] = {}
 
+        # Mirrors listing_status_events: {"listing_id", "from_status", "to_status", "reason"}
+        self.status_events: List[Dict[str, Any]] = []
+
     def seed(self, listing_id: str, file_ids: List[str], initial_status="PENDING_VALIDATION"):
         """
         Helper method to setup test state (creating the 'listing' and 'files')
         """
-        self.listings[listing_id] = {"status": initial_status, "id": listing_id}
+        self.listings[listing_id] = {"status": initial_status, "id": listing_id, "deleted": False}
 
         for fid in file_ids:
             self.files[fid] = {"id": fid, "listing_id": listing_id, "status": "PENDING", "error": None}
 
+    def _transition(self, listing_id: str, to_status: str, reason: str) -> None:
+        from_status = self.listings[listing_id]["status"]
+        if from_status == to_status:
+            return
+        self.listings[listing_id]["status"] = to_status
+        self.status_events.append(
+            {"listing_id": listing_id, "from_status": from_status, "to_status": to_status, "reason": reason}
+        )
+
     async def complete_file_validation(
         self,
         file_id: str,
@@ -70,6 +82,11 @@ class InMemoryRepository(ListingRepository):
         if pending_count > 0:
             return False  # Still waiting for other workers
 
+        # Unpublished, hidden or deleted since the file was queued, leave the status alone
+        listing = self.listings.get(listing_id)
+        if listing is None or listing["deleted"] or listing["status"] not in ("PENDING_VALIDATION", "PENDING_REVIEW"):
+            return False
+
         # 3. Work is Done (Pending == 0). Determine Outcome.
         failed_count = sum(
             1
@@ -79,17 +96,17 @@ class InMemoryRepository(ListingRepository):
 
         if failed_count > 0:
             # REJECT logic
-            self.listings[listing_id]["status"] = "REJECTED"
+            self._transition(listing_id, "REJECTED", f"{failed_count} file(s) failed validation")
             return False
         else:
             # ACTIVATE logic (Idempotent check)
             current_status = self.listings[listing_id]["status"]
 
-            if current_status != "ACTIVE":
-                self.listings[listing_id]["status"] = "ACTIVE"
+            if current_status not in ("ACTIVE", "PENDING_REVIEW"):
+                self._transition(listing_id, "ACTIVE", "All files passed validation")
                 return True  # We successfully activated it
 
-            return False  # Was already active
+            return False  # Was already active, or is waiting for a moderator
 
     async def mark_file_invalid(self, file_id: str, error: str) -> None:
         """
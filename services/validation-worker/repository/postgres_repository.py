@@ -3,12 +3,44 @@ import json
 import asyncpg
 
 from core import ListingRepository
+from repository.file_metadata import normalize_file_metadata
+
+
+# Moves a listing to $2 and records the change in listing_status_events in one statement, so the history
+# can't disagree with the listing. Nothing happens if the listing is already in $2 or in any of the $4 statuses.
+# Returns "INSERT 0 1" when the listing moved, "INSERT 0 0" when it didn't.
+TRANSITION_LISTING_SQL = """
+WITH previous AS (
+    SELECT id, status FROM listings WHERE id = $1 FOR UPDATE
+), moved AS (
+    UPDATE listings l SET status = $2::listing_status
+    FROM previous
+    WHERE l.id = previous.id
+      AND previous.status IS DISTINCT FROM $2::listing_status
+      AND NOT (previous.status = ANY($4::listing_status[]))
+    RETURNING previous.status AS from_status
+)
+INSERT INTO listing_status_events (listing_id, actor, from_status, to_status, reason)
+SELECT $1, 'SYSTEM', from_status, $2::listing_status, $3 FROM moved
+"""
+
+# Validation only ever moves a listing out of these. Anything else was decided since the file was queued,
+# e.g. the seller unpublished it or a moderator hid it, and must not be overwritten by a late result.
+VALIDATING_STATUSES = ("PENDING_VALIDATION", "PENDING_REVIEW")
 
 
 class PostgresListingRepository(ListingRepository):
     def __init__(self, pool: asyncpg.Pool):
         self.pool = pool
 
+    @staticmethod
+    async def _transition_listing(
+        conn: asyncpg.Connection, listing_id: str, to_status: str, reason: str, unless: list[str] | None = None
+    ) -> bool:
+        """Moves the listing to to_status and records why. Returns True if the status actually changed."""
+        result = await conn.execute(TRANSITION_LISTING_SQL, listing_id, to_status, reason, unless or [])
+        return result != "INSERT 0 0"
+
     async def complete_file_validation(
         self,
         file_id: str,
@@ -22,8 +54,16 @@ class PostgresListingRepository(ListingRepository):
         Marks file as VALID, updates its S3 key to the new WebP version,
         and checks if the listing can be activated.
         """
+        metadata = normalize_file_metadata(metadata)
+
         async with self.pool.acquire() as conn:
             async with conn.transaction():
+                # 1. Lock the listing and re-read it. Gateway edits lock the same row, so whatever we see here
+                # stays true until we commit.
+                listing = await conn.fetchrow(
+                    "SELECT status, deleted_at FROM listings WHERE id=$1 FOR UPDATE", listing_id
+                )
+
                 if generated_image_paths:
                     # Insert any generated files (model renders, etc)
                     for file_path in generated_image_paths:
@@ -34,9 +74,6 @@ class PostgresListingRepository(ListingRepository):
                             file_id,
                         )
 
-                # 1. Lock listing
-                await conn.execute("SELECT 1 FROM listings WHERE id=$1 FOR UPDATE", listing_id)
-
                 # 2. Update File: Set status VALID and update the Key to the new WebP location
                 if new_file_key is not None:
                     # 2.5 If the file has been updated (its an image) & it's the thumbnail, update the listing record too
@@ -78,21 +115,33 @@ class PostgresListingRepository(ListingRepository):
                 if pending_count and pending_count > 0:
                     return False  # Still working on other files
 
+                # The file results above still count, but the listing's status is no longer ours to change
+                if listing is None or listing["deleted_at"] is not None or listing["status"] not in VALIDATING_STATUSES:
+                    return False
+
                 # 4. Check for failures
                 failed_count = await conn.fetchval(
                     "SELECT count(*) FROM listing_files WHERE listing_id=$1 AND status = 'FAILED'", listing_id
                 )
 
                 if failed_count and failed_count > 0:
-                    # REJECT listing if any file failed
-                    await conn.execute("UPDATE listings SET status='REJECTED' WHERE id=$1", listing_id)
+                    # REJECT listing if any file failed, the first error tells the seller where to start
+                    first_error = await conn.fetchval(
+                        "SELECT error_message FROM listing_files WHERE listing_id=$1 AND status = 'FAILED' "
+                        "AND error_message IS NOT NULL ORDER BY updated_at LIMIT 1",
+                        listing_id,
+                    )
+                    reason = f"{failed_count} file(s) failed validation"
+                    if first_error:
+                        reason += f": {first_error}"
+                    await self._transition_listing(conn, listing_id, "REJECTED", reason)
                     return False
                 else:
                     # ALL CLEAR -> ACTIVATE
-                    await conn.execute(
-                        "UPDATE listings SET status='ACTIVE' WHERE id=$1 AND status != 'ACTIVE'", listing_id
+                    # Listings flagged at creation stay in PENDING_REVIEW until a moderator lets them through
+                    return await self._transition_listing(
+                        conn, listing_id, "ACTIVE", "All files passed validation", unless=["PENDING_REVIEW"]
                     )
-                    return True
 
     async def mark_file_failed(self, file_id: str, error: str) -> None:
         await self.pool.execute(
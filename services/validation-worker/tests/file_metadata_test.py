@@ -0,0 +1,61 @@
+import logging
+
+from repository.file_metadata import MAX_FILE_METADATA_BYTES, normalize_file_metadata
+
+
+def test_normalizes_mesh_validator_output():
+    """
+    Scenario: The model pipeline merges MeshLoadValidator and ModelFileTypeValidator output.
+    Expectation: Keys are renamed to the whitelisted shape and internals are dropped.
+    """
+    raw = {
+        "mime": "model/stl",
+        "triangles": 1200,
+        "vertices": 600,
+        "faces": 1200,
+        "euler_number": 2,
+        "is_watertight": True,
+        "is_winding_consistent": False,
+        "bounds": [[0, 0, 0], [20, 30, 40.5]],
+    }
+
+    assert normalize_file_metadata(raw) == {
+        "format": "model/stl",
+        "triangle_count": 1200,
+        "bounding_box": {"min": [0.0, 0.0, 0.0], "max": [20.0, 30.0, 40.5]},
+        "scan": {"watertight": True, "winding_consistent": False},
+    }
+
+
+def test_unknown_keys_dropped_with_warning(caplog):
+    with caplog.at_level(logging.WARNING):
+        out = normalize_file_metadata({"format": "image/png", "worker_host": "validation-7"})
+
+    assert out == {"format": "image/png"}
+    assert "worker_host" in caplog.text
+
+
+def test_wrong_types_dropped():
+    raw = {
+        "triangles": "lots",
+        "is_watertight": "yes",
+        "byte_size": True,
+        "bounds": [[0, 0], [1, 1]],
+        "format": 42,
+    }
+
+    assert normalize_file_metadata(raw) == {}
+
+
+def test_oversized_document_rejected(caplog):
+    raw = {"format": "x" * (MAX_FILE_METADATA_BYTES + 1)}
+
+    with caplog.at_level(logging.WARNING):
+        assert normalize_file_metadata(raw) == {}
+
+    assert "Rejecting file metadata" in caplog.text
+
+
+def test_empty_input():
+    assert normalize_file_metadata(None) == {}
+    assert normalize_file_metadata({}) == {}
@@ -20,6 +20,14 @@ async def test_repo_activates_listing_only_on_last_file():
     activated = await repo.complete_file_validation("file_B", "listing_123", None)
     assert activated is True
     assert repo.listings["listing_123"]["status"] == "ACTIVE"
+    assert repo.status_events == [
+        {
+            "listing_id": "listing_123",
+            "from_status": "PENDING_VALIDATION",
+            "to_status": "ACTIVE",
+            "reason": "All files passed validation",
+        }
+    ]
 
 
 @pytest.mark.asyncio
@@ -37,3 +45,31 @@ async def test_repo_rejects_listing_if_any_file_fails():
 
     assert activated is False
     assert repo.listings["listing_bad"]["status"] == "REJECTED"
+
+
+@pytest.mark.asyncio
+async def test_repo_leaves_listing_pending_review():
+    # Setup: the gateway flagged this listing as a likely duplicate when it was created
+    repo = InMemoryRepository()
+    repo.seed("listing_dup", ["file_A"], initial_status="PENDING_REVIEW")
+
+    # Expectation: Validation finishes cleanly, but the listing waits for a moderator instead of going live
+    activated = await repo.complete_file_validation("file_A", "listing_dup", None)
+
+    assert activated is False
+    assert repo.listings["listing_dup"]["status"] == "PENDING_REVIEW"
+    assert repo.status_events == []
+
+
+@pytest.mark.asyncio
+async def test_repo_leaves_unpublished_listing_alone():
+    # Setup: the seller hid the listing while its last file was still being validated
+    repo = InMemoryRepository()
+    repo.seed("listing_hidden", ["file_A"], initial_status="HIDDEN")
+
+    activated = await repo.complete_file_validation("file_A", "listing_hidden", None)
+
+    assert activated is False
+    assert repo.files["file_A"]["status"] == "VALID"
+    assert repo.listings["listing_hidden"]["status"] == "HIDDEN"
+    assert repo.status_events == []
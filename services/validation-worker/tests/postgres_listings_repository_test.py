@@ -3,7 +3,9 @@ from unittest.mock import AsyncMock, MagicMock
 import asyncpg
 import pytest
 
-from repository.postgres_repository import PostgresListingRepository
+from datetime import datetime, timezone
+
+from repository.postgres_repository import TRANSITION_LISTING_SQL, PostgresListingRepository
 
 
 # Helper to setup the mock chain: Pool -> Connection -> Transaction
@@ -19,6 +21,9 @@ def mock_db_pool():
     # Determine what happens when we do: async with conn.transaction():
     conn.transaction.return_value.__aenter__.return_value = tx
 
+    # The listing row re-read under FOR UPDATE, still waiting on validation unless a test says otherwise
+    conn.fetchrow.return_value = {"status": "PENDING_VALIDATION", "deleted_at": None}
+
     return pool, conn
 
 
@@ -88,3 +93,97 @@ async def test_complete_validation_skips_thumbnail_if_no_match(mock_db_pool):
     # 2. But we DID update the file status
     file_update_sql = "UPDATE listing_files SET status='VALID'"
     assert any(file_update_sql in cmd for cmd in execute_calls)
+
+
+@pytest.mark.asyncio
+async def test_complete_validation_records_activation(mock_db_pool):
+    """
+    Scenario: The last pending file passes validation.
+    Expectation: The listing goes ACTIVE through the transition statement, which writes the status history
+    in the same transaction, and listings held for review are excluded.
+    """
+    pool, conn = mock_db_pool
+    repo = PostgresListingRepository(pool)
+
+    conn.fetchval.side_effect = [False, 0, 0]
+    conn.execute.return_value = "INSERT 0 1"
+
+    activated = await repo.complete_file_validation("file_123", "listing_abc", None)
+
+    assert activated is True
+    conn.execute.assert_any_call(
+        TRANSITION_LISTING_SQL, "listing_abc", "ACTIVE", "All files passed validation", ["PENDING_REVIEW"]
+    )
+    assert "INSERT INTO listing_status_events" in TRANSITION_LISTING_SQL
+
+
+@pytest.mark.asyncio
+async def test_complete_validation_held_for_review_is_not_activated(mock_db_pool):
+    """
+    Scenario: Validation finishes on a listing the gateway put in PENDING_REVIEW.
+    Expectation: The transition matches no row, so nothing is published.
+    """
+    pool, conn = mock_db_pool
+    repo = PostgresListingRepository(pool)
+
+    conn.fetchval.side_effect = [False, 0, 0]
+    conn.execute.return_value = "INSERT 0 0"
+
+    activated = await repo.complete_file_validation("file_123", "listing_abc", None)
+
+    assert activated is False
+
+
+@pytest.mark.asyncio
+async def test_complete_validation_locks_listing_before_writing(mock_db_pool):
+    """
+    Scenario: Any validation result.
+    Expectation: The listing row is re-read FOR UPDATE before any file row is written, so a gateway edit
+    holding the same lock either finishes first or waits for us.
+    """
+    pool, conn = mock_db_pool
+    repo = PostgresListingRepository(pool)
+
+    calls = []
+    listing = {"status": "PENDING_VALIDATION", "deleted_at": None}
+    conn.fetchrow.side_effect = lambda *args: calls.append("lock") or listing
+    conn.execute.side_effect = lambda *args: calls.append(args[0]) or "INSERT 0 1"
+    conn.fetchval.side_effect = [False, 0, 0]
+
+    await repo.complete_file_validation("file_123", "listing_abc", "new/path.webp", ["render.webp"])
+
+    conn.fetchrow.assert_called_once_with(
+        "SELECT status, deleted_at FROM listings WHERE id=$1 FOR UPDATE", "listing_abc"
+    )
+    assert calls[0] == "lock"
+
+
+@pytest.mark.asyncio
+@pytest.mark.parametrize(
+    "listing",
+    [
+        # Unpublished by the seller while the file was queued
+        {"status": "HIDDEN", "deleted_at": None},
+        {"status": "PENDING_VALIDATION", "deleted_at": datetime(2026, 10, 16, tzinfo=timezone.utc)},
+        # Already rejected by an earlier file
+        {"status": "REJECTED", "deleted_at": None},
+        None,
+    ],
+)
+async def test_complete_validation_never_resurrects_listing(mock_db_pool, listing):
+    """
+    Scenario: The last file passes, but the listing left validation since the file was queued.
+    Expectation: The file is still marked VALID, the listing's status is left alone.
+    """
+    pool, conn = mock_db_pool
+    repo = PostgresListingRepository(pool)
+
+    conn.fetchrow.return_value = listing
+    conn.fetchval.side_effect = [0, 0]
+
+    activated = await repo.complete_file_validation("file_123", "listing_abc", None)
+
+    assert activated is False
+    execute_calls = [str(c) for c in conn.execute.mock_calls]
+    assert any("UPDATE listing_files SET status='VALID'" in cmd for cmd in execute_calls)
+    assert not any(TRANSITION_LISTING_SQL in c.args for c in conn.execute.mock_calls)
@@ -3,7 +3,7 @@ from unittest.mock import AsyncMock, MagicMock
 
 import pytest
 
-from core import IncomingMessage, ProductionConfig
+from core import IncomingMessage, ModelProcessingOutput, ProductionConfig
 
 # Import your actual classes
 from worker import ProcessingResult, ValidationWorker
@@ -117,9 +117,16 @@ async def test_worker_happy_path_image(worker, in_memory_bus, mock_repo, mock_pr
         metadata={},
     )
 
-    # Verify Success Event Published
-    assert len(in_memory_bus.published_messages) == 1
+    # Verify Success Events Published, indexing first then the fan-out lifecycle event
+    assert len(in_memory_bus.published_messages) == 2
     assert in_memory_bus.published_messages[0][0] == worker.config.events.index_listing  # or whatever your topic is
+    assert in_memory_bus.published_messages[1][0] == worker.config.events.listing_published
+
+    # Field names are what the listings worker decodes, see TestSubscribe_ListingPublished_ValidationWorkerContract
+    published = in_memory_bus.published_messages[1][1].model_dump(mode="json")
+    assert set(published) == {"event_id", "timestamp", "topic", "listing_id", "status"}
+    assert published["listing_id"] == "list_xyz"
+    assert published["status"] == "ACTIVE"
 
 
 @pytest.mark.asyncio
@@ -210,3 +217,144 @@ async def test_worker_validation_failure(worker, mock_repo):
 
     # Verify DB marked as failed
     mock_repo.mark_file_invalid.assert_called_with("file_abc", "Image too large")
+
+
+def listing_payload() -> dict:
+    """A StartListingValidationEvent as the gateway sends it, see TestRaiseStartListingValidationEvent_WireFormat"""
+    return {
+        "listing_id": "list_xyz",
+        "user_id": "user_1",
+        "trace_id": "123",
+        "files": [
+            {"file_id": "file_model", "file_key": "raw/model.stl", "file_type": "model"},
+            {"file_id": "file_img", "file_key": "raw/img.jpg", "file_type": "image"},
+        ],
+    }
+
+
+@pytest.mark.asyncio
+async def test_worker_batched_contract(worker, in_memory_bus, mock_repo, mock_provider):
+    """
+    Scenario: The gateway sends one event with a listing's model and image.
+    Expectation: Both files are validated in turn, the listing is published once the last one completes.
+    """
+    msg = MockIncomingMessage(listing_payload())
+
+    worker._run_model_pipeline.return_value = ProcessingResult(
+        processor_name="Test",
+        success=True,
+        output_path=ModelProcessingOutput(generated_image_paths=[], original_file_path=Path("/tmp/model.stl")),
+    )
+    worker._run_image_pipeline.return_value = ProcessingResult(
+        processor_name="Test", success=True, output_path=Path("/tmp/output.webp")
+    )
+    # The listing is only finished by the last file
+    mock_repo.complete_file_validation.side_effect = [False, True]
+
+    await worker.handle_job(msg)
+
+    assert msg.acked is True
+    assert msg.naked is False
+    worker._run_model_pipeline.assert_called_once_with("raw/model.stl", mock_provider)
+    worker._run_image_pipeline.assert_called_once_with("raw/img.jpg", mock_provider)
+    completed = [c.args[:3] for c in mock_repo.complete_file_validation.call_args_list]
+    assert completed == [
+        ("file_model", "list_xyz", "user_1/list_xyz/file_model.stl"),
+        ("file_img", "list_xyz", "user_1/list_xyz/file_img.webp"),
+    ]
+    assert [topic for topic, _ in in_memory_bus.published_messages] == [
+        worker.config.events.index_listing,
+        worker.config.events.listing_published,
+    ]
+
+
+@pytest.mark.asyncio
+async def test_worker_batched_publishes_file_progress(worker, in_memory_bus, mock_repo, mock_provider):
+    """
+    Scenario: EVENT_FILE_VALIDATED is set and a listing's model fails validation while its image passes.
+    Expectation: One FileValidatedEvent per file in the order they finished, with the reason on the invalid one.
+    """
+    worker.config.events.file_validated = "files.validated"
+    msg = MockIncomingMessage(listing_payload())
+
+    worker._run_model_pipeline.return_value = ProcessingResult(
+        processor_name="Test", success=False, error_message="Model too complex"
+    )
+    worker._run_image_pipeline.return_value = ProcessingResult(
+        processor_name="Test", success=True, output_path=Path("/tmp/output.webp")
+    )
+    mock_repo.complete_file_validation.return_value = False
+
+    await worker.handle_job(msg)
+
+    assert msg.acked is True
+    # Field names are what the gateway decodes, see TestListenToFileValidated_ValidationWorkerContract
+    published = [event.model_dump(mode="json") for topic, event in in_memory_bus.published_messages]
+    assert [topic for topic, _ in in_memory_bus.published_messages] == ["files.validated", "files.validated"]
+    assert set(published[0]) == {"event_id", "timestamp", "topic", "listing_id", "file_id", "status", "error"}
+    assert [(p["file_id"], p["status"], p["error"]) for p in published] == [
+        ("file_model", "INVALID", "Model too complex"),
+        ("file_img", "VALID", None),
+    ]
+    assert all(p["listing_id"] == "list_xyz" for p in published)
+
+
+@pytest.mark.asyncio
+async def test_worker_batched_invalid_file_continues(worker, mock_repo):
+    """
+    Scenario: The first file of a listing fails validation.
+    Expectation: It's marked invalid, the next file is still validated and the message ACKed.
+    """
+    msg = MockIncomingMessage(listing_payload())
+
+    worker._run_model_pipeline.return_value = ProcessingResult(
+        processor_name="Test", success=False, error_message="Model too complex"
+    )
+    worker._run_image_pipeline.return_value = ProcessingResult(
+        processor_name="Test", success=True, output_path=Path("/tmp/output.webp")
+    )
+    mock_repo.complete_file_validation.return_value = False
+
+    await worker.handle_job(msg)
+
+    assert msg.acked is True
+    mock_repo.mark_file_invalid.assert_called_once_with("file_model", "Model too complex")
+    mock_repo.complete_file_validation.assert_called_once()
+    assert mock_repo.complete_file_validation.call_args.args[0] == "file_img"
+
+
+@pytest.mark.asyncio
+async def test_worker_batched_transient_failure_retries_listing(worker, mock_repo, mock_provider):
+    """
+    Scenario: Storage fails while uploading a file of the listing.
+    Expectation: The whole listing is NAKed for a retry, no file is marked invalid.
+    """
+    payload = listing_payload()
+    payload["files"] = payload["files"][1:]
+    msg = MockIncomingMessage(payload)
+
+    worker._run_image_pipeline.return_value = ProcessingResult(
+        processor_name="Test", success=True, output_path=Path("/tmp/output.webp")
+    )
+    mock_provider.store_image.side_effect = Exception("S3 Connection Reset")
+
+    await worker.handle_job(msg)
+
+    assert msg.acked is False
+    assert msg.naked is True
+    assert msg.nak_delay == 5
+    mock_repo.mark_file_invalid.assert_not_called()
+
+
+@pytest.mark.asyncio
+async def test_worker_batched_empty_manifest(worker, mock_repo):
+    """
+    Scenario: A listing event without any files.
+    Expectation: ACKed and dropped, there's nothing to validate.
+    """
+    msg = MockIncomingMessage({"listing_id": "list_xyz", "user_id": "user_1", "files": []})
+
+    await worker.handle_job(msg)
+
+    assert msg.acked is True
+    mock_repo.complete_file_validation.assert_not_called()
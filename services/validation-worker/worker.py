@@ -9,8 +9,10 @@ from core import (
     AssetContext,
     EnvironmentConfig,
     EventBus,
+    FileValidatedEvent,
     IncomingMessage,
     IndexListingEvent,
+    ListingPublishedEvent,
     ListingRepository,
     ModelProcessingOutput,
     PermanentError,
@@ -104,21 +106,34 @@ class ValidationWorker:
         self.semaphore = asyncio.Semaphore(self.concurrent_workers)
 
     async def handle_system_failure(self, msg: IncomingMessage, error: Exception):
-        file_id = msg.data.get("file_id")
-        if file_id is None:
+        # A listing job names its files in the manifest, a file job just the one
+        files = msg.data.get("files")
+        if isinstance(files, list):
+            file_ids = [f.get("file_id") for f in files if isinstance(f, dict) and f.get("file_id")]
+        else:
+            file_ids = [msg.data.get("file_id")] if msg.data.get("file_id") else []
+        if not file_ids:
             self.logger.error("🚨 No file_id in message; cannot mark file as FAILED")
             return
 
-        try:
-            self.logger.error(f"🚨 Marking File {file_id} as FAILED due to system error. {str(error)}")
+        for file_id in file_ids:
+            try:
+                self.logger.error(f"🚨 Marking File {file_id} as FAILED due to system error. {str(error)}")
 
-            # Update DB status to 'FAILED' (Distinct from 'INVALID')
-            # 'FAILED' implies: "It's not you, it's us. Try again later."
-            await self.repository.mark_file_failed(
-                file_id, error="Internal error during processing. We are investigating"
-            )
-        except Exception:
-            self.logger.exception("CRITICAL: Failed to update DB during system failure handling. ")
+                # Update DB status to 'FAILED' (Distinct from 'INVALID')
+                # 'FAILED' implies: "It's not you, it's us. Try again later."
+                await self.repository.mark_file_failed(
+                    file_id, error="Internal error during processing. We are investigating"
+                )
+                await self._publish_file_validated(
+                    msg.data.get("listing_id"),
+                    file_id,
+                    "FAILED",
+                    "Internal error during processing. We are investigating",
+                    self.logger,
+                )
+            except Exception:
+                self.logger.exception("CRITICAL: Failed to update DB during system failure handling. ")
 
     async def start(self):
         """
@@ -168,6 +183,13 @@ class ValidationWorker:
                 return
 
             trace_id = data.get("trace_id", str(uuid.uuid4()))
+
+            # The gateway sends a listing's files in one StartListingValidationEvent, or a StartFileValidationEvent
+            # per file while EVENT_VALIDATE_LEGACY_FILE_EVENTS is on
+            if "files" in data:
+                await self._handle_listing_job(msg, data, trace_id)
+                return
+
             file_id = data.get("file_id")
             listing_id = data.get("listing_id")
 
@@ -193,6 +215,7 @@ class ValidationWorker:
                 # Update DB so user knows it failed
                 if isinstance(file_id, str):
                     await self.repository.mark_file_invalid(file_id, str(e))
+                    await self._publish_file_validated(listing_id, file_id, "INVALID", str(e), job_logger)
                 # ACK to remove from queue (we don't want to retry bad data)
                 await msg.ack()
 
@@ -209,6 +232,65 @@ class ValidationWorker:
                 job_logger.exception(f"💥 Unhandled Exception: {e}")
                 await msg.nak(delay=RETRY_DELAY_SECONDS)
 
+    async def _handle_listing_job(self, msg: IncomingMessage, data: dict, trace_id: str):
+        """
+        Validates every file in a listing's manifest, one after another, so a listing with many files is fetched from
+        storage by one worker rather than all of them at once. A file that fails validation is marked invalid and the
+        rest carry on. A transient failure retries the whole listing, completing a file again is harmless.
+        """
+        listing_id = data.get("listing_id")
+        files = data.get("files")
+
+        job_logger = logging.LoggerAdapter(
+            self.logger,
+            {"trace_id": trace_id, "file_id": None, "listing_id": listing_id},
+        )
+
+        if not isinstance(files, list) or not files:
+            job_logger.error("❌ Listing job has no files. Discarding.")
+            await msg.ack()
+            return
+
+        job_logger.info(f"📥 Processing Listing Job with {len(files)} file(s)...")
+
+        try:
+            for entry in files:
+                if not isinstance(entry, dict):
+                    job_logger.error(f"❌ Skipping malformed manifest entry: {entry!r}")
+                    continue
+
+                file_id = entry.get("file_id")
+                file_logger = logging.LoggerAdapter(
+                    self.logger,
+                    {"trace_id": trace_id, "file_id": file_id, "listing_id": listing_id},
+                )
+                file_data = {
+                    **entry,
+                    "listing_id": listing_id,
+                    "user_id": data.get("user_id"),
+                    "trace_id": trace_id,
+                }
+
+                try:
+                    await self._process_logic(file_data, file_logger)
+                    file_logger.info("✅ File Complete.")
+                except PermanentError as e:
+                    file_logger.error(f"❌ Permanent Failure: {e}. Marking DB as Failed.")
+                    if isinstance(file_id, str):
+                        await self.repository.mark_file_invalid(file_id, str(e))
+                        await self._publish_file_validated(listing_id, file_id, "INVALID", str(e), file_logger)
+
+            job_logger.info("✅ Listing Job Complete. Acknowledging message.")
+            await msg.ack()
+
+        except TransientError as e:
+            job_logger.warning(f"⚠️ Transient Error: {e}. Triggering Retry.")
+            await msg.nak(delay=RETRY_DELAY_SECONDS)
+
+        except Exception as e:
+            job_logger.exception(f"💥 Unhandled Exception: {e}")
+            await msg.nak(delay=RETRY_DELAY_SECONDS)
+
     async def _process_logic(self, data: dict, logger: logging.LoggerAdapter):
         """
         Pure Business Logic.
@@ -272,6 +354,8 @@ class ValidationWorker:
             # DB connection lost?
             raise TransientError(f"Database update failed: {e}")
 
+        await self._publish_file_validated(listing_id, file_id, "VALID", None, logger)
+
         if is_finished:
             logger.info(f"Listing {listing_id} is complete! Publishing index event.")
             # Notify other services that listing is ready to be indexed
@@ -281,6 +365,37 @@ class ValidationWorker:
             except Exception as e:
                 logger.error(f"Failed to publish IndexListingEvent: {e}")
 
+            # Separate from indexing, the index subject is a work queue only the listings worker reads
+            published = ListingPublishedEvent(
+                topic=self.config.events.listing_published, listing_id=listing_id, status="ACTIVE"
+            )
+            try:
+                await self.bus.publish(published)
+            except Exception as e:
+                logger.error(f"Failed to publish ListingPublishedEvent: {e}")
+
+    async def _publish_file_validated(
+        self,
+        listing_id: str | None,
+        file_id: str,
+        status: str,
+        error: str | None,
+        logger: logging.Logger | logging.LoggerAdapter,
+    ):
+        """
+        Tells the gateway's listing event stream a file is done. Only progress for a seller watching the listing, the
+        file's status is already saved, so a failure here is logged and the job carries on.
+        """
+        topic = self.config.events.file_validated
+        if not topic or not listing_id:
+            return
+
+        event = FileValidatedEvent(topic=topic, listing_id=listing_id, file_id=file_id, status=status, error=error)
+        try:
+            await self.bus.publish(event)
+        except Exception as e:
+            logger.error(f"Failed to publish FileValidatedEvent: {e}")
+
     def _run_image_pipeline(
         self,
         file_key: str,
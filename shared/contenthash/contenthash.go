@@ -0,0 +1,58 @@
+// Package contenthash is the content_hash of a listing's search document. The listings worker writes it into the
+// document and the gateway into the listing responses it caches, POST /listings/hydrate serves the cached copy to a
+// search hit whose hash matches it. Both hash through here so they can't drift apart.
+package contenthash
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// Length is the hex length of a hash, the first 16 bytes of a SHA-256
+const Length = 32
+
+// Listing is what a search hit shows of a listing, so two copies with the same hash look the same to the person who
+// clicked the hit. Adding a field changes every hash, the documents then need reindexing before hydrate hits the
+// cache again.
+type Listing struct {
+	ID             string   `json:"id"` // Dashless
+	Status         string   `json:"status"`
+	Title          string   `json:"title"`
+	Description    string   `json:"description"`
+	PriceMinUnit   int64    `json:"price_min_unit"`
+	Currency       string   `json:"currency"`
+	Categories     []string `json:"categories"`
+	License        string   `json:"license"`
+	ThumbnailPath  string   `json:"thumbnail_path"` // The object path, so a new public base URL doesn't change it
+	IsSaleActive   bool     `json:"is_sale_active"`
+	SalePrice      *int64   `json:"sale_price"`
+	SaleName       string   `json:"sale_name"`
+	SaleEnd        *int64   `json:"sale_end"` // Unix seconds
+	SellerName     string   `json:"seller_name"`
+	SellerUsername string   `json:"seller_username"`
+	SellerVerified bool     `json:"seller_verified"`
+	IsNSFW         bool     `json:"is_nsfw"`
+	IsAIGenerated  bool     `json:"is_ai_generated"`
+	IsPhysical     bool     `json:"is_physical"`
+}
+
+// Sum is the listing's hash. No categories and an empty list hash the same.
+func (l Listing) Sum() string {
+	if l.Categories == nil {
+		l.Categories = []string{}
+	}
+	// Only plain fields, marshalling can't fail
+	data, _ := json.Marshal(l)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:Length/2])
+}
+
+// Valid is false for anything that can't have come from Sum
+func Valid(hash string) bool {
+	if len(hash) != Length {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
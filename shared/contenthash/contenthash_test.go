@@ -0,0 +1,33 @@
+package contenthash_test
+
+import (
+	"shared/contenthash"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSum(t *testing.T) {
+	listing := contenthash.Listing{ID: "550e8400e29b41d4a716446655440000", Status: "ACTIVE", Title: "Benchy", Currency: "GBP"}
+
+	sum := listing.Sum()
+	assert.Len(t, sum, contenthash.Length)
+	assert.True(t, contenthash.Valid(sum))
+
+	// The gateway's rows and the worker's can disagree on nil against an empty list
+	withEmpty := listing
+	withEmpty.Categories = []string{}
+	assert.Equal(t, sum, withEmpty.Sum())
+
+	retitled := listing
+	retitled.Title = "Benchy v2"
+	assert.NotEqual(t, sum, retitled.Sum())
+}
+
+func TestValid(t *testing.T) {
+	assert.True(t, contenthash.Valid("b1356dd0fc1f50463080b15f11abe682"))
+	assert.False(t, contenthash.Valid(""))
+	assert.False(t, contenthash.Valid("b1356dd0fc1f5046"))
+	assert.False(t, contenthash.Valid(`{"title":"Free Benchy","x":1}`))
+	assert.False(t, contenthash.Valid("zz356dd0fc1f50463080b15f11abe682"))
+}
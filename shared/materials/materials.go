@@ -0,0 +1,110 @@
+// Package materials is the canonical list of materials a listing can recommend, so search facets get one bucket per
+// material rather than one per spelling. The gateway saves and the listings worker indexes through it, so the index
+// stores what the gateway saves.
+package materials
+
+import (
+	"shared/fuzzy"
+	"strings"
+)
+
+// Material is a canonical name and the other ways sellers write it
+type Material struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
+
+// list is in the order the listing form offers it, most common first
+var list = []Material{
+	{Name: "PLA", Aliases: []string{"polylactic acid", "pla basic"}},
+	{Name: "PLA+", Aliases: []string{"pla plus", "pla pro", "tough pla"}},
+	{Name: "PETG", Aliases: []string{"pet", "polyethylene terephthalate glycol"}},
+	{Name: "ABS", Aliases: []string{"acrylonitrile butadiene styrene"}},
+	{Name: "ASA", Aliases: []string{"acrylonitrile styrene acrylate"}},
+	{Name: "TPU", Aliases: []string{"thermoplastic polyurethane", "flex", "flexible", "tpe"}},
+	{Name: "Nylon", Aliases: []string{"pa", "polyamide", "pa6", "pa12"}},
+	{Name: "PC", Aliases: []string{"polycarbonate"}},
+	{Name: "HIPS", Aliases: []string{"high impact polystyrene"}},
+	{Name: "PVA", Aliases: []string{"polyvinyl alcohol"}},
+	{Name: "PP", Aliases: []string{"polypropylene"}},
+	{Name: "PLA-CF", Aliases: []string{"carbon fiber pla", "carbon fibre pla"}},
+	{Name: "PETG-CF", Aliases: []string{"carbon fiber petg", "carbon fibre petg"}},
+	{Name: "Nylon-CF", Aliases: []string{"pa-cf", "pa6-cf", "pa12-cf", "carbon fiber nylon", "carbon fibre nylon"}},
+	{Name: "Wood PLA", Aliases: []string{"wood", "wood fill"}},
+	{Name: "Silk PLA", Aliases: []string{"silk"}},
+	{Name: "Resin", Aliases: []string{"uv resin", "photopolymer", "photopolymer resin", "standard resin"}},
+	{Name: "Tough Resin", Aliases: []string{"abs-like resin"}},
+	{Name: "Flexible Resin", Aliases: []string{"flex resin", "elastic resin"}},
+}
+
+// byKey maps the key of every name and alias to its canonical name
+var byKey = func() map[string]string {
+	m := map[string]string{}
+	for _, material := range list {
+		m[key(material.Name)] = material.Name
+		for _, alias := range material.Aliases {
+			m[key(alias)] = material.Name
+		}
+	}
+	return m
+}()
+
+// List is every canonical material with its aliases
+func List() []Material {
+	return list
+}
+
+// key ignores case, spaces, hyphens and underscores, so "PET-G", "pet g" and "PETG" match
+func key(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '-', '_':
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(value)))
+}
+
+// Lookup is the canonical name for value, a name or alias in any case
+func Lookup(value string) (string, bool) {
+	name, ok := byKey[key(value)]
+	return name, ok
+}
+
+// Normalize swaps the values it knows for their canonical names and drops blanks and repeats. Anything unknown is kept
+// as the seller wrote it, for values saved before the list was enforced. Nil stays nil.
+func Normalize(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	normalized := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		value = strings.Join(strings.Fields(value), " ")
+		if name, ok := Lookup(value); ok {
+			value = name
+		}
+		if value == "" || seen[strings.ToLower(value)] {
+			continue
+		}
+		seen[strings.ToLower(value)] = true
+		normalized = append(normalized, value)
+	}
+	return normalized
+}
+
+// Suggest is the canonical name closest to value, or "" when nothing is close enough. Ties go to the material
+// earlier in the list.
+func Suggest(value string) string {
+	k := key(value)
+	// A typo in a short name, more slack for longer ones
+	best, bestDistance := "", max(1, len(k)/4)+1
+	for _, material := range list {
+		for _, candidate := range append([]string{material.Name}, material.Aliases...) {
+			if d := fuzzy.Levenshtein(k, key(candidate)); d < bestDistance {
+				best, bestDistance = material.Name, d
+			}
+		}
+	}
+	return best
+}
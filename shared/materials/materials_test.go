@@ -0,0 +1,34 @@
+package materials_test
+
+import (
+	"shared/materials"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize_KeepsLegacyValues(t *testing.T) {
+	// SCENARIO: A listing saved before the list was enforced has a known material spelled its own way and one we
+	// don't know.
+	// EXPECT: The known one is renamed, the unknown one is kept so the edit doesn't fail or lose it.
+
+	assert.Equal(t, []string{"PETG", "Glow in the dark filament"}, materials.Normalize([]string{"pet g", " Glow in the  dark filament", "PETG"}))
+	assert.Nil(t, materials.Normalize(nil))
+}
+
+func TestList_EveryNameAndAliasLooksUp(t *testing.T) {
+	for _, material := range materials.List() {
+		for _, value := range append([]string{material.Name}, material.Aliases...) {
+			name, ok := materials.Lookup(value)
+			require.True(t, ok, value)
+			assert.Equal(t, material.Name, name, "%q belongs to two materials", value)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	assert.Equal(t, "PLA", materials.Suggest("PLAA"))
+	assert.Equal(t, "PC", materials.Suggest("polycarbonat"))
+	assert.Equal(t, "", materials.Suggest("unobtainium"))
+}
@@ -0,0 +1,202 @@
+// Package natsconn connects a service to NATS with the credentials and TLS settings from its environment, telling a
+// rejected login and an unreachable server apart, and creates the JetStream streams the services publish to
+package natsconn
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+var (
+	// ErrAuth is returned when the server turned down the credentials, retrying with the same config won't help
+	ErrAuth = errors.New("nats rejected the credentials")
+	// ErrUnreachable is returned when no server could be reached or the TLS handshake failed
+	ErrUnreachable = errors.New("nats is unreachable")
+)
+
+// Config is how to reach the server and who to be once there. Set at most one of User/Password, Token,
+// NKeySeedFile and CredsFile, none connects without credentials as the dev docker network does.
+type Config struct {
+	URL string
+
+	User     string
+	Password string
+	Token    string
+	// NKeySeedFile holds a user nkey seed (SU...), the server has to know the matching public key
+	NKeySeedFile string
+	// CredsFile is a decentralised auth .creds file, the user JWT and its seed together
+	CredsFile string
+
+	// CAFile trusts a private CA, and makes the connection TLS even for a nats:// URL
+	CAFile string
+	// CertFile and KeyFile are a client certificate, for servers that verify clients. Both or neither.
+	CertFile string
+	KeyFile  string
+}
+
+// ConfigFromEnv reads NATS_ENDPOINT and the NATS_* auth and TLS variables
+func ConfigFromEnv() Config {
+	return Config{
+		URL:          os.Getenv("NATS_ENDPOINT"),
+		User:         os.Getenv("NATS_USER"),
+		Password:     os.Getenv("NATS_PASSWORD"),
+		Token:        os.Getenv("NATS_TOKEN"),
+		NKeySeedFile: os.Getenv("NATS_NKEY_SEED_FILE"),
+		CredsFile:    os.Getenv("NATS_CREDS_FILE"),
+		CAFile:       os.Getenv("NATS_TLS_CA_FILE"),
+		CertFile:     os.Getenv("NATS_TLS_CERT_FILE"),
+		KeyFile:      os.Getenv("NATS_TLS_KEY_FILE"),
+	}
+}
+
+// AuthMode names the credentials in use, for logs. Never the credentials themselves.
+func (c Config) AuthMode() string {
+	switch {
+	case c.User != "":
+		return "user"
+	case c.Token != "":
+		return "token"
+	case c.NKeySeedFile != "":
+		return "nkey"
+	case c.CredsFile != "":
+		return "creds"
+	}
+	return "none"
+}
+
+func (c Config) authOptions() ([]nats.Option, error) {
+	set := 0
+	for _, v := range []string{c.User, c.Token, c.NKeySeedFile, c.CredsFile} {
+		if v != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, errors.New("set only one of NATS_USER, NATS_TOKEN, NATS_NKEY_SEED_FILE and NATS_CREDS_FILE")
+	}
+	if c.Password != "" && c.User == "" {
+		return nil, errors.New("NATS_PASSWORD is set without NATS_USER")
+	}
+
+	switch c.AuthMode() {
+	case "user":
+		return []nats.Option{nats.UserInfo(c.User, c.Password)}, nil
+	case "token":
+		return []nats.Option{nats.Token(c.Token)}, nil
+	case "nkey":
+		opt, err := nats.NkeyOptionFromSeed(c.NKeySeedFile)
+		if err != nil {
+			return nil, fmt.Errorf("NATS_NKEY_SEED_FILE: %w", err)
+		}
+		return []nats.Option{opt}, nil
+	case "creds":
+		// Read on every connect, so only its presence can be checked here
+		if _, err := os.Stat(c.CredsFile); err != nil {
+			return nil, fmt.Errorf("NATS_CREDS_FILE: %w", err)
+		}
+		return []nats.Option{nats.UserCredentials(c.CredsFile)}, nil
+	}
+	return nil, nil
+}
+
+func (c Config) tlsOptions() ([]nats.Option, error) {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return nil, errors.New("NATS_TLS_CERT_FILE and NATS_TLS_KEY_FILE go together")
+	}
+
+	var opts []nats.Option
+	if c.CAFile != "" {
+		opts = append(opts, nats.RootCAs(c.CAFile))
+	}
+	if c.CertFile != "" {
+		opts = append(opts, nats.ClientCert(c.CertFile, c.KeyFile))
+	}
+	return opts, nil
+}
+
+// options is everything Connect connects with. The options are tried on a scratch nats.Options first, so an
+// unreadable CA or client certificate fails here rather than looking like the server being down.
+func options(name string, cfg Config, logger *slog.Logger) ([]nats.Option, error) {
+	opts := []nats.Option{
+		// 1. Identification: Makes debugging on the NATS dashboard easier
+		nats.Name(name),
+
+		// 2. Resilience: NEVER give up trying to reconnect.
+		// Default is 60. We set -1 (infinite).
+		nats.MaxReconnects(-1),
+
+		// 3. Backoff: Don't spam the server. Wait 3s between attempts.
+		nats.ReconnectWait(3 * time.Second),
+
+		// 4. Observability: Log when things go wrong
+		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
+			logger.Warn("NATS disconnected! Buffering messages...", "error", err)
+		}),
+
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			logger.Info("NATS reconnected successfully!", "url", nc.ConnectedUrl())
+		}),
+
+		// 5. Safety Net: If the connection is permanently dead (e.g. credentials revoked while running),
+		// kill the app so Docker can restart it with fresh config/state. Closing it ourselves isn't a failure.
+		nats.ClosedHandler(func(nc *nats.Conn) {
+			if nc.LastError() == nil {
+				logger.Info("NATS connection closed")
+				return
+			}
+			logger.Error("NATS connection closed permanently. Exiting process.", "error", classify(nc.LastError()))
+			os.Exit(1)
+		}),
+	}
+
+	auth, err := cfg.authOptions()
+	if err != nil {
+		return nil, err
+	}
+	tls, err := cfg.tlsOptions()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(append(opts, auth...), tls...)
+
+	probe := nats.GetDefaultOptions()
+	for _, opt := range opts {
+		if err := opt(&probe); err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+// classify wraps err in ErrAuth or ErrUnreachable
+func classify(err error) error {
+	switch {
+	case errors.Is(err, nats.ErrAuthorization), errors.Is(err, nats.ErrAuthExpired),
+		errors.Is(err, nats.ErrAuthRevoked), errors.Is(err, nats.ErrAccountAuthExpired),
+		errors.Is(err, nats.ErrNkeysNotSupported):
+		return fmt.Errorf("%w: %w", ErrAuth, err)
+	}
+	return fmt.Errorf("%w: %w", ErrUnreachable, err)
+}
+
+// Connect connects as name, telling a config mistake, a rejected login and a server that can't be reached apart. The
+// error wraps ErrAuth when the credentials were turned down and ErrUnreachable when the server couldn't be reached.
+func Connect(name string, cfg Config, logger *slog.Logger) (*nats.Conn, error) {
+	opts, err := options(name, cfg, logger)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nats config: %w", err)
+	}
+
+	nc, err := nats.Connect(cfg.URL, opts...)
+	if err != nil {
+		return nil, classify(err)
+	}
+	_, notTLS := nc.TLSConnectionState()
+	logger.Info("Connected to NATS", "url", nc.ConnectedUrl(), "auth", cfg.AuthMode(), "tls", notTLS == nil)
+	return nc, nil
+}
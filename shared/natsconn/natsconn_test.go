@@ -0,0 +1,228 @@
+package natsconn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// A throwaway user nkey, only ever used here
+const (
+	testNKeySeed   = "SUACITTCT72A76SGK3QAGAB4CKCEOTYTS5RJZNFEDIU2FKLHQIZK5RDWVA"
+	testNKeyPublic = "UDOIBHBT6LNUTE67FOJYAKVUFXLVI2KHBIR3CWRH5WNNJ6354TZIBYDV"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// writeCert writes a self-signed certificate and its key, good as both a CA bundle and a client certificate
+func writeCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "nats-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = writeFile(t, "cert.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+	keyFile = writeFile(t, "key.pem", string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})))
+	return certFile, keyFile
+}
+
+// applied is what nats.Connect would connect with
+func applied(t *testing.T, cfg Config) (nats.Options, error) {
+	t.Helper()
+	opts, err := options("test", cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		return nats.Options{}, err
+	}
+	o := nats.GetDefaultOptions()
+	for _, opt := range opts {
+		require.NoError(t, opt(&o))
+	}
+	return o, nil
+}
+
+func TestOptions_NoAuth(t *testing.T) {
+	// SCENARIO: The dev docker network, a bare URL and nothing else.
+	// EXPECT: No credentials, no TLS, and the reconnect behaviour both services rely on.
+
+	o, err := applied(t, Config{URL: "nats://nats:4222"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "test", o.Name)
+	assert.Equal(t, -1, o.MaxReconnect)
+	assert.Empty(t, o.User)
+	assert.Empty(t, o.Token)
+	assert.Empty(t, o.Nkey)
+	assert.Nil(t, o.UserJWT)
+	assert.False(t, o.Secure)
+	assert.Equal(t, "none", Config{}.AuthMode())
+}
+
+func TestOptions_UserPassword(t *testing.T) {
+	cfg := Config{User: "gateway", Password: "s3cret"}
+	o, err := applied(t, cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, "gateway", o.User)
+	assert.Equal(t, "s3cret", o.Password)
+	assert.Equal(t, "user", cfg.AuthMode())
+}
+
+func TestOptions_Token(t *testing.T) {
+	cfg := Config{Token: "t0ken"}
+	o, err := applied(t, cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, "t0ken", o.Token)
+	assert.Empty(t, o.User)
+	assert.Equal(t, "token", cfg.AuthMode())
+}
+
+func TestOptions_NKey(t *testing.T) {
+	cfg := Config{NKeySeedFile: writeFile(t, "user.nk", testNKeySeed+"\n")}
+	o, err := applied(t, cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, testNKeyPublic, o.Nkey)
+	require.NotNil(t, o.SignatureCB)
+	sig, err := o.SignatureCB([]byte("nonce"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, sig)
+	assert.Equal(t, "nkey", cfg.AuthMode())
+}
+
+func TestOptions_NKey_BadSeed(t *testing.T) {
+	_, err := applied(t, Config{NKeySeedFile: writeFile(t, "user.nk", "not a seed")})
+	assert.ErrorContains(t, err, "NATS_NKEY_SEED_FILE")
+
+	_, err = applied(t, Config{NKeySeedFile: filepath.Join(t.TempDir(), "missing.nk")})
+	assert.ErrorContains(t, err, "NATS_NKEY_SEED_FILE")
+}
+
+func TestOptions_Creds(t *testing.T) {
+	cfg := Config{CredsFile: writeFile(t, "user.creds", "-----BEGIN NATS USER JWT-----\n...\n------END NATS USER JWT------\n")}
+	o, err := applied(t, cfg)
+	require.NoError(t, err)
+
+	assert.NotNil(t, o.UserJWT)
+	assert.NotNil(t, o.SignatureCB)
+	assert.Equal(t, "creds", cfg.AuthMode())
+
+	_, err = applied(t, Config{CredsFile: filepath.Join(t.TempDir(), "missing.creds")})
+	assert.ErrorContains(t, err, "NATS_CREDS_FILE")
+}
+
+func TestOptions_ConflictingAuth(t *testing.T) {
+	seed := writeFile(t, "user.nk", testNKeySeed)
+	for _, cfg := range []Config{
+		{User: "gateway", Token: "t0ken"},
+		{Token: "t0ken", NKeySeedFile: seed},
+		{User: "gateway", CredsFile: "user.creds"},
+		{Password: "s3cret"},
+	} {
+		_, err := applied(t, cfg)
+		assert.Error(t, err, "%+v", cfg)
+	}
+}
+
+func TestOptions_TLS(t *testing.T) {
+	cert, key := writeCert(t)
+
+	t.Run("CA only", func(t *testing.T) {
+		o, err := applied(t, Config{CAFile: cert})
+		require.NoError(t, err)
+		assert.True(t, o.Secure)
+		require.NotNil(t, o.RootCAsCB)
+		pool, err := o.RootCAsCB()
+		require.NoError(t, err)
+		assert.NotNil(t, pool)
+		assert.Nil(t, o.TLSCertCB)
+	})
+
+	t.Run("Client certificate", func(t *testing.T) {
+		o, err := applied(t, Config{CAFile: cert, CertFile: cert, KeyFile: key, User: "gateway", Password: "s3cret"})
+		require.NoError(t, err)
+		assert.True(t, o.Secure)
+		require.NotNil(t, o.TLSCertCB)
+		_, err = o.TLSCertCB()
+		assert.NoError(t, err)
+		assert.Equal(t, "gateway", o.User)
+	})
+
+	t.Run("Certificate without key", func(t *testing.T) {
+		_, err := applied(t, Config{CertFile: cert})
+		assert.ErrorContains(t, err, "NATS_TLS_KEY_FILE")
+	})
+
+	t.Run("Unreadable CA", func(t *testing.T) {
+		_, err := applied(t, Config{CAFile: writeFile(t, "ca.pem", "not a certificate")})
+		assert.Error(t, err)
+	})
+
+	t.Run("Unreadable client certificate", func(t *testing.T) {
+		_, err := applied(t, Config{CertFile: cert, KeyFile: writeFile(t, "key.pem", "not a key")})
+		assert.Error(t, err)
+	})
+}
+
+func TestClassify(t *testing.T) {
+	for _, err := range []error{nats.ErrAuthorization, nats.ErrAuthExpired, nats.ErrAuthRevoked, nats.ErrNkeysNotSupported} {
+		classified := classify(err)
+		assert.ErrorIs(t, classified, ErrAuth)
+		assert.ErrorIs(t, classified, err)
+		assert.NotErrorIs(t, classified, ErrUnreachable)
+	}
+	for _, err := range []error{nats.ErrNoServers, errors.New("dial tcp 10.0.0.1:4222: connect: connection refused")} {
+		classified := classify(err)
+		assert.ErrorIs(t, classified, ErrUnreachable)
+		assert.NotErrorIs(t, classified, ErrAuth)
+	}
+}
+
+func TestConnect_ConfigErrorBeforeDialling(t *testing.T) {
+	// SCENARIO: Two kinds of credentials are set and the URL points nowhere.
+	// EXPECT: The config mistake is reported, not an unreachable server.
+
+	_, err := Connect("test", Config{URL: "nats://127.0.0.1:1", User: "gateway", Token: "t0ken"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "invalid nats config")
+	assert.NotErrorIs(t, err, ErrUnreachable)
+}
+
+func TestConnect_Unreachable(t *testing.T) {
+	_, err := Connect("test", Config{URL: "nats://127.0.0.1:1"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	assert.ErrorIs(t, err, ErrUnreachable, fmt.Sprint(err))
+}
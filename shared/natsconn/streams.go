@@ -0,0 +1,84 @@
+package natsconn
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// DeadLetterStream holds messages that kept failing, under dlq.<original subject>. Shared with the validation worker.
+const DeadLetterStream = "DLQ"
+
+// Streams are the JetStream streams the services publish to. Every service that publishes creates the ones missing
+// at startup, a publish to a subject no stream captures fails, and nothing says which service starts first.
+func Streams() []*nats.StreamConfig {
+	return []*nats.StreamConfig{
+		{
+			Name:      "INDEX",
+			Subjects:  []string{"index.>"}, // Listen to index.created, index.updated, etc.
+			Retention: nats.WorkQueuePolicy,
+		},
+		{
+			// Listing lifecycle (listings.created, listings.published). Fans out to every consumer, so
+			// messages are kept for a week instead of being removed on the first ack.
+			Name:      "LISTINGS",
+			Subjects:  []string{"listings.>"},
+			Retention: nats.LimitsPolicy,
+			MaxAge:    7 * 24 * time.Hour,
+		},
+		{
+			// Kept on disk for two weeks so operators have time to fix the cause and replay them. Direct gets let a
+			// replay read the oldest dead letter of a subject.
+			Name:        DeadLetterStream,
+			Subjects:    []string{"dlq.>"},
+			Retention:   nats.LimitsPolicy,
+			Storage:     nats.FileStorage,
+			MaxAge:      14 * 24 * time.Hour,
+			AllowDirect: true,
+		},
+	}
+}
+
+// StreamManager is the part of nats.JetStreamContext EnsureStreams needs
+type StreamManager interface {
+	StreamInfo(stream string, opts ...nats.JSOpt) (*nats.StreamInfo, error)
+	AddStream(cfg *nats.StreamConfig, opts ...nats.JSOpt) (*nats.StreamInfo, error)
+	UpdateStream(cfg *nats.StreamConfig, opts ...nats.JSOpt) (*nats.StreamInfo, error)
+}
+
+// EnsureStreams creates the Streams that don't exist yet. Existing ones are left as they are, other than turning on
+// direct gets where they are needed.
+func EnsureStreams(js StreamManager, logger *slog.Logger) error {
+	for _, stream := range Streams() {
+		info, err := js.StreamInfo(stream.Name)
+		if err == nil {
+			// Streams created before direct gets were needed, e.g. the DLQ by the validation worker
+			if stream.AllowDirect && !info.Config.AllowDirect {
+				config := info.Config
+				config.AllowDirect = true
+				if _, err := js.UpdateStream(&config); err != nil {
+					return fmt.Errorf("failed to allow direct gets on stream %s: %w", stream.Name, err)
+				}
+				logger.Info("✅ JetStream stream updated.", "stream", stream.Name)
+			}
+			continue
+		}
+		if !errors.Is(err, nats.ErrStreamNotFound) {
+			return fmt.Errorf("failed to look up stream %s: %w", stream.Name, err)
+		}
+
+		logger.Info("⚠️ Stream not found, creating...", "stream", stream.Name)
+		if _, err := js.AddStream(stream); err != nil {
+			// The other service got there first
+			if errors.Is(err, nats.ErrStreamNameAlreadyInUse) {
+				continue
+			}
+			return fmt.Errorf("failed to create stream %s: %w", stream.Name, err)
+		}
+		logger.Info("✅ JetStream stream verified.", "stream", stream.Name)
+	}
+	return nil
+}
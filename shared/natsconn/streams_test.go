@@ -0,0 +1,83 @@
+package natsconn
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStreams is a JetStream server's streams, by name
+type fakeStreams struct {
+	streams map[string]nats.StreamConfig
+	added   []string
+	updated []string
+	lookup  error // Returned by every StreamInfo when set
+}
+
+func (f *fakeStreams) StreamInfo(stream string, _ ...nats.JSOpt) (*nats.StreamInfo, error) {
+	if f.lookup != nil {
+		return nil, f.lookup
+	}
+	config, ok := f.streams[stream]
+	if !ok {
+		return nil, nats.ErrStreamNotFound
+	}
+	return &nats.StreamInfo{Config: config}, nil
+}
+
+func (f *fakeStreams) AddStream(cfg *nats.StreamConfig, _ ...nats.JSOpt) (*nats.StreamInfo, error) {
+	f.streams[cfg.Name] = *cfg
+	f.added = append(f.added, cfg.Name)
+	return &nats.StreamInfo{Config: *cfg}, nil
+}
+
+func (f *fakeStreams) UpdateStream(cfg *nats.StreamConfig, _ ...nats.JSOpt) (*nats.StreamInfo, error) {
+	f.streams[cfg.Name] = *cfg
+	f.updated = append(f.updated, cfg.Name)
+	return &nats.StreamInfo{Config: *cfg}, nil
+}
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestEnsureStreams_CreatesMissing(t *testing.T) {
+	// SCENARIO: The gateway starts before the listings worker has ever run, the validation worker has made its DLQ.
+	// EXPECT: INDEX and LISTINGS are created, the DLQ is kept but gets direct gets.
+
+	js := &fakeStreams{streams: map[string]nats.StreamConfig{
+		DeadLetterStream: {Name: DeadLetterStream, Subjects: []string{"dlq.>"}},
+	}}
+
+	require.NoError(t, EnsureStreams(js, discard))
+
+	assert.Equal(t, []string{"INDEX", "LISTINGS"}, js.added)
+	assert.Equal(t, []string{DeadLetterStream}, js.updated)
+	assert.Equal(t, []string{"listings.>"}, js.streams["LISTINGS"].Subjects)
+	assert.True(t, js.streams[DeadLetterStream].AllowDirect)
+}
+
+func TestEnsureStreams_LeavesExisting(t *testing.T) {
+	js := &fakeStreams{streams: map[string]nats.StreamConfig{}}
+	for _, stream := range Streams() {
+		js.streams[stream.Name] = *stream
+	}
+
+	require.NoError(t, EnsureStreams(js, discard))
+
+	assert.Empty(t, js.added)
+	assert.Empty(t, js.updated)
+}
+
+func TestEnsureStreams_LookupFails(t *testing.T) {
+	// SCENARIO: JetStream is down or the account has no JetStream access.
+	// EXPECT: The error comes back instead of an attempt to create a stream over the top.
+
+	js := &fakeStreams{streams: map[string]nats.StreamConfig{}, lookup: errors.New("nats: jetstream not enabled for account")}
+
+	assert.Error(t, EnsureStreams(js, discard))
+	assert.Empty(t, js.added)
+}
@@ -0,0 +1,257 @@
+// Package pii protects the fields of an event tagged `pii:"true"`. Subjects can be read by any team on the bus, so
+// publishers omit, hash or encrypt those fields according to the event's Mode, and consumers holding the key get
+// encrypted ones back in plaintext.
+package pii
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Mode is what happens to an event's PII fields before it is published
+type Mode string
+
+const (
+	// Omit drops the field, for consumers that never need it
+	Omit Mode = "omit"
+	// Hash replaces the value with a keyed hash, consumers can match on it without learning it
+	Hash Mode = "hash"
+	// Encrypt seals the value with AES-GCM, consumers holding the key decrypt it transparently
+	Encrypt Mode = "encrypt"
+)
+
+const (
+	// EncryptedPrefix marks a sealed field. v1 sealed with the configured key itself and is no longer opened.
+	EncryptedPrefix = "enc:v2:"
+	HashedPrefix    = "hmac-sha256:"
+)
+
+// Subkeys of the configured key, one per use so a value hashed under one can never be related to a ciphertext
+// under the other
+const (
+	hashKeyInfo    = "printing-marketplace/pii/hmac-sha256"
+	encryptKeyInfo = "printing-marketplace/pii/aes-256-gcm"
+)
+
+// Event is implemented by events that carry PII. Tagged fields on an event that doesn't say how to protect them are
+// omitted.
+type Event interface {
+	PIIMode() Mode
+}
+
+// Redactor applies each event's Mode on the way out and reverses Encrypt on the way in
+type Redactor struct {
+	hashKey []byte
+	aead    cipher.AEAD
+}
+
+// ParseKey decodes a base64 256-bit key, e.g. from `openssl rand -base64 32`
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("pii key is not base64: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("pii key must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+// NewRedactor builds a redactor for key, deriving separate HMAC and AES-GCM subkeys from it with HKDF-SHA256.
+// Without a key there is nothing to hash or encrypt with, so every PII field is omitted whatever its event asks for,
+// and encrypted fields are left sealed.
+func NewRedactor(key []byte) (*Redactor, error) {
+	if len(key) == 0 {
+		return &Redactor{}, nil
+	}
+
+	hashKey, err := hkdf.Key(sha256.New, key, nil, hashKeyInfo, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive pii hash key: %w", err)
+	}
+	encryptKey, err := hkdf.Key(sha256.New, key, nil, encryptKeyInfo, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive pii encryption key: %w", err)
+	}
+
+	block, err := aes.NewCipher(encryptKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pii key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pii key: %w", err)
+	}
+	return &Redactor{hashKey: hashKey, aead: aead}, nil
+}
+
+// fieldName is the JSON name of f
+func fieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name
+}
+
+// fields returns the JSON names of the string fields of evt tagged `pii:"true"`. Only top level fields are looked
+// at.
+func fields(evt any) []string {
+	t := reflect.TypeOf(evt)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var names []string
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if f.Tag.Get("pii") != "true" || f.Type.Kind() != reflect.String {
+			continue
+		}
+		names = append(names, fieldName(f))
+	}
+	return names
+}
+
+// Marshal encodes evt as JSON with its PII fields protected
+func (r *Redactor) Marshal(evt any) ([]byte, error) {
+	names := fields(evt)
+	if len(names) == 0 {
+		return json.Marshal(evt)
+	}
+
+	mode := Omit
+	if p, ok := evt.(Event); ok {
+		mode = p.PIIMode()
+	}
+	if r.aead == nil {
+		mode = Omit
+	}
+
+	payload, err := toMap(evt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		value, ok := payload[name].(string)
+		if !ok || value == "" {
+			continue
+		}
+
+		switch mode {
+		case Hash:
+			payload[name] = r.hash(value)
+		case Encrypt:
+			sealed, err := r.encrypt(name, value)
+			if err != nil {
+				return nil, err
+			}
+			payload[name] = sealed
+		default:
+			delete(payload, name)
+		}
+	}
+
+	return json.Marshal(payload)
+}
+
+// Unmarshal decodes data into evt and opens its encrypted PII fields, see Open
+func (r *Redactor) Unmarshal(data []byte, evt any) error {
+	if err := json.Unmarshal(data, evt); err != nil {
+		return err
+	}
+	return r.Open(evt)
+}
+
+// Open decrypts the encrypted PII fields of evt, a pointer to a decoded event, in place. Hashed and omitted fields
+// stay as they are, so do encrypted ones when this redactor has no key.
+func (r *Redactor) Open(evt any) error {
+	if r == nil || r.aead == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(evt)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	v = v.Elem()
+
+	for i := range v.NumField() {
+		f := v.Type().Field(i)
+		if f.Tag.Get("pii") != "true" || f.Type.Kind() != reflect.String {
+			continue
+		}
+		value := v.Field(i).String()
+		if !strings.HasPrefix(value, EncryptedPrefix) {
+			continue
+		}
+
+		plain, err := r.decrypt(fieldName(f), value)
+		if err != nil {
+			return err
+		}
+		v.Field(i).SetString(plain)
+	}
+	return nil
+}
+
+func (r *Redactor) hash(value string) string {
+	mac := hmac.New(sha256.New, r.hashKey)
+	mac.Write([]byte(value))
+	return HashedPrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// encrypt seals value with a fresh random nonce, so the same value never encrypts to the same ciphertext twice.
+// The field name is the additional data, a ciphertext moved to another field won't open.
+func (r *Redactor) encrypt(field, value string) (string, error) {
+	nonce := make([]byte, r.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := r.aead.Seal(nonce, nonce, []byte(value), []byte(field))
+	return EncryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (r *Redactor) decrypt(field, value string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, EncryptedPrefix))
+	if err != nil || len(sealed) < r.aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted field %s", field)
+	}
+	nonce, ciphertext := sealed[:r.aead.NonceSize()], sealed[r.aead.NonceSize():]
+	plain, err := r.aead.Open(nil, nonce, ciphertext, []byte(field))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt field %s: %w", field, err)
+	}
+	return string(plain), nil
+}
+
+// toMap round trips evt through JSON so fields can be dropped or replaced by their JSON name
+func toMap(evt any) (map[string]any, error) {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Keeps int64s like price_min_unit exact
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
@@ -0,0 +1,234 @@
+package pii_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"shared/pii"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testEmail = "tester@example.com"
+
+// testKey is 32 bytes of 0x01, base64 encoded
+var testKey = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("\x01", 32)))
+
+func newRedactor(t *testing.T, encodedKey string) *pii.Redactor {
+	t.Helper()
+	var key []byte
+	if encodedKey != "" {
+		var err error
+		key, err = pii.ParseKey(encodedKey)
+		require.NoError(t, err)
+	}
+	r, err := pii.NewRedactor(key)
+	require.NoError(t, err)
+	return r
+}
+
+func decodeMap(t *testing.T, data []byte) map[string]any {
+	t.Helper()
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(data, &payload))
+	return payload
+}
+
+// encryptedEvent needs the address itself, e.g. to erase a user from systems keyed by email
+type encryptedEvent struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email" pii:"true"`
+}
+
+func (encryptedEvent) PIIMode() pii.Mode { return pii.Encrypt }
+
+// hashedEvent only needs to recognise the user
+type hashedEvent struct {
+	UserID  string          `json:"user_id"`
+	Email   string          `json:"email" pii:"true"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func (hashedEvent) PIIMode() pii.Mode { return pii.Hash }
+
+// omittedEvent has a PII field but no PIIMode, so it falls back to omitting it
+type omittedEvent struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email" pii:"true"`
+}
+
+// --- POLICY MODES ---
+
+func TestRedactor_Encrypt_RoundTrip(t *testing.T) {
+	// SCENARIO: An event with an encrypted email is published and read back by a consumer holding the key.
+	// EXPECT: The wire payload has no plaintext email, the consumer gets the original event back.
+
+	r := newRedactor(t, testKey)
+	evt := encryptedEvent{UserID: "user-1", Email: testEmail}
+
+	data, err := r.Marshal(evt)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), testEmail)
+	assert.True(t, strings.HasPrefix(decodeMap(t, data)["email"].(string), pii.EncryptedPrefix))
+
+	var got encryptedEvent
+	require.NoError(t, r.Unmarshal(data, &got))
+	assert.Equal(t, evt, got)
+}
+
+func TestRedactor_Encrypt_WithoutKeyStaysSealed(t *testing.T) {
+	// SCENARIO: A consumer without the key reads an encrypted event.
+	// EXPECT: It decodes, with the ciphertext left in place of the email.
+
+	data, err := newRedactor(t, testKey).Marshal(encryptedEvent{UserID: "user-1", Email: testEmail})
+	require.NoError(t, err)
+
+	var got encryptedEvent
+	require.NoError(t, newRedactor(t, "").Unmarshal(data, &got))
+	assert.Equal(t, "user-1", got.UserID)
+	assert.True(t, strings.HasPrefix(got.Email, pii.EncryptedPrefix))
+}
+
+func TestRedactor_Encrypt_FreshNonce(t *testing.T) {
+	// SCENARIO: The same email is encrypted in two messages.
+	// EXPECT: The ciphertexts differ, so equal emails can't be linked across messages.
+
+	r := newRedactor(t, testKey)
+	evt := encryptedEvent{UserID: "user-1", Email: testEmail}
+
+	first, err := r.Marshal(evt)
+	require.NoError(t, err)
+	second, err := r.Marshal(evt)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, decodeMap(t, first)["email"], decodeMap(t, second)["email"])
+}
+
+func TestRedactor_Encrypt_RejectsTamperedCiphertext(t *testing.T) {
+	r := newRedactor(t, testKey)
+	data, err := r.Marshal(encryptedEvent{UserID: "user-1", Email: testEmail})
+	require.NoError(t, err)
+
+	t.Run("Wrong key", func(t *testing.T) {
+		other := newRedactor(t, base64.StdEncoding.EncodeToString([]byte(strings.Repeat("\x02", 32))))
+		var got encryptedEvent
+		assert.Error(t, other.Unmarshal(data, &got))
+	})
+
+	t.Run("Moved to another field", func(t *testing.T) {
+		// The field name is bound in as additional data
+		payload := decodeMap(t, data)
+		moved, err := json.Marshal(map[string]any{"user_id": "user-1", "email": "", "trace_id": payload["email"]})
+		require.NoError(t, err)
+
+		type renamed struct {
+			TraceID string `json:"trace_id" pii:"true"`
+		}
+		var got renamed
+		assert.Error(t, r.Unmarshal(moved, &got))
+	})
+}
+
+func TestRedactor_Hash(t *testing.T) {
+	// SCENARIO: An event that hashes PII carries the user's email.
+	// EXPECT: It is replaced by a keyed hash that is the same every time, and reading it back leaves it hashed.
+
+	r := newRedactor(t, testKey)
+	evt := hashedEvent{UserID: "user-1", Email: testEmail, Payload: json.RawMessage(`{"a":1}`)}
+
+	first, err := r.Marshal(evt)
+	require.NoError(t, err)
+	second, err := r.Marshal(evt)
+	require.NoError(t, err)
+
+	hashed := decodeMap(t, first)["email"].(string)
+	assert.True(t, strings.HasPrefix(hashed, pii.HashedPrefix))
+	assert.Equal(t, first, second)
+
+	var got hashedEvent
+	require.NoError(t, r.Unmarshal(first, &got))
+	assert.Equal(t, hashed, got.Email)
+	assert.JSONEq(t, `{"a":1}`, string(got.Payload))
+}
+
+func TestRedactor_SeparateSubkeys(t *testing.T) {
+	// SCENARIO: The same key configures hashing and encryption.
+	// EXPECT: Neither uses the key itself, a hash can't be recomputed and a ciphertext can't be opened with it.
+
+	key, err := pii.ParseKey(testKey)
+	require.NoError(t, err)
+	r := newRedactor(t, testKey)
+
+	hashed, err := r.Marshal(hashedEvent{UserID: "user-1", Email: testEmail})
+	require.NoError(t, err)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(testEmail))
+	assert.NotEqual(t, pii.HashedPrefix+hex.EncodeToString(mac.Sum(nil)), decodeMap(t, hashed)["email"])
+
+	encrypted, err := r.Marshal(encryptedEvent{UserID: "user-1", Email: testEmail})
+	require.NoError(t, err)
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(decodeMap(t, encrypted)["email"].(string), pii.EncryptedPrefix))
+	require.NoError(t, err)
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	_, err = aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte("email"))
+	assert.Error(t, err)
+}
+
+func TestRedactor_Omit(t *testing.T) {
+	// SCENARIO: An event has a PII field but doesn't declare a mode.
+	// EXPECT: The field is left out of the payload.
+
+	data, err := newRedactor(t, testKey).Marshal(omittedEvent{UserID: "user-1", Email: testEmail})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"user_id": "user-1"}, decodeMap(t, data))
+
+	var got omittedEvent
+	require.NoError(t, newRedactor(t, testKey).Unmarshal(data, &got))
+	assert.Equal(t, omittedEvent{UserID: "user-1"}, got)
+}
+
+func TestRedactor_NoKeyOmitsEverything(t *testing.T) {
+	// SCENARIO: EVENT_PII_KEY isn't set.
+	// EXPECT: Events that ask for hashing or encryption omit the email rather than sending it in plaintext.
+
+	r := newRedactor(t, "")
+	for _, evt := range []any{
+		encryptedEvent{UserID: "user-1", Email: testEmail},
+		hashedEvent{UserID: "user-1", Email: testEmail},
+	} {
+		data, err := r.Marshal(evt)
+		require.NoError(t, err)
+		assert.NotContains(t, decodeMap(t, data), "email")
+	}
+}
+
+func TestRedactor_LeavesEventsWithoutPIIAlone(t *testing.T) {
+	evt := struct {
+		ListingID    string `json:"listing_id"`
+		PriceMinUnit int64  `json:"price_min_unit"`
+	}{ListingID: "abc123", PriceMinUnit: 1 << 60}
+
+	data, err := newRedactor(t, testKey).Marshal(evt)
+	require.NoError(t, err)
+
+	want, _ := json.Marshal(evt)
+	assert.Equal(t, string(want), string(data))
+}
+
+func TestParseKey_Invalid(t *testing.T) {
+	_, err := pii.ParseKey("not base64!")
+	assert.Error(t, err)
+
+	_, err = pii.ParseKey(base64.StdEncoding.EncodeToString([]byte("too short")))
+	assert.Error(t, err)
+}
@@ -0,0 +1,76 @@
+// Package poolmetrics exports connection pool health to Prometheus. The collectors read the pool's stats on every
+// scrape rather than sampling them, so the numbers are never older than the scrape. Each service passes its own
+// namespace, the rest of the metric names are the same everywhere so dashboards can use either.
+package poolmetrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ prometheus.Collector = (*PGXCollector)(nil)
+
+// PGXCollector reports pgxpool.Stat. Utilization is acquired_conns over the max_conns setting of db_pool_config.
+type PGXCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns     *prometheus.Desc
+	idleConns         *prometheus.Desc
+	constructingConns *prometheus.Desc
+	totalConns        *prometheus.Desc
+	acquires          *prometheus.Desc
+	acquireDuration   *prometheus.Desc
+	emptyAcquires     *prometheus.Desc
+	canceledAcquires  *prometheus.Desc
+	config            *prometheus.Desc
+}
+
+// NewPGXCollector reports pool under <namespace>_db_pool_*, e.g. gateway_db_pool_acquired_conns
+func NewPGXCollector(namespace string, pool *pgxpool.Pool) *PGXCollector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, labels, nil)
+	}
+	return &PGXCollector{
+		pool:              pool,
+		acquiredConns:     desc("acquired_conns", "Postgres connections currently checked out of the pool."),
+		idleConns:         desc("idle_conns", "Postgres connections open and waiting in the pool."),
+		constructingConns: desc("constructing_conns", "Postgres connections being opened."),
+		totalConns:        desc("total_conns", "Postgres connections open or being opened."),
+		acquires:          desc("acquires_total", "Connections handed out by the pool."),
+		acquireDuration:   desc("acquire_duration_seconds_total", "Time spent waiting for connections, divide by acquires_total for the average wait."),
+		emptyAcquires:     desc("empty_acquires_total", "Acquires that had to wait because no connection was idle, the first sign of exhaustion."),
+		canceledAcquires:  desc("canceled_acquires_total", "Acquires given up on before a connection became free."),
+		config:            desc("config", "Pool settings, by setting. Constant while the process runs.", "setting"),
+	}
+}
+
+func (c *PGXCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.constructingConns
+	ch <- c.totalConns
+	ch <- c.acquires
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquires
+	ch <- c.canceledAcquires
+	ch <- c.config
+}
+
+func (c *PGXCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.constructingConns, prometheus.GaugeValue, float64(stat.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+
+	config := c.pool.Config()
+	ch <- prometheus.MustNewConstMetric(c.config, prometheus.GaugeValue, float64(config.MaxConns), "max_conns")
+	ch <- prometheus.MustNewConstMetric(c.config, prometheus.GaugeValue, float64(config.MinConns), "min_conns")
+	ch <- prometheus.MustNewConstMetric(c.config, prometheus.GaugeValue, config.MaxConnLifetime.Seconds(), "max_conn_lifetime_seconds")
+	ch <- prometheus.MustNewConstMetric(c.config, prometheus.GaugeValue, config.MaxConnIdleTime.Seconds(), "max_conn_idle_time_seconds")
+}
@@ -0,0 +1,71 @@
+package poolmetrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisPool is the part of *redis.Client the collector reads
+type RedisPool interface {
+	PoolStats() *redis.PoolStats
+	Options() *redis.Options
+}
+
+var _ prometheus.Collector = (*RedisCollector)(nil)
+
+// RedisCollector reports go-redis PoolStats. Utilization is (total_conns - idle_conns) over the pool_size setting
+// of redis_pool_config.
+type RedisCollector struct {
+	pool RedisPool
+
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	staleConns *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+	config     *prometheus.Desc
+}
+
+// NewRedisCollector reports pool under <namespace>_redis_pool_*
+func NewRedisCollector(namespace string, pool RedisPool) *RedisCollector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis_pool", name), help, labels, nil)
+	}
+	return &RedisCollector{
+		pool:       pool,
+		hits:       desc("hits_total", "Commands that found an idle Redis connection in the pool."),
+		misses:     desc("misses_total", "Commands that had to open a new Redis connection."),
+		timeouts:   desc("timeouts_total", "Commands that gave up waiting for a Redis connection, the pool is exhausted."),
+		staleConns: desc("stale_conns_total", "Idle Redis connections closed for being too old."),
+		totalConns: desc("total_conns", "Redis connections open."),
+		idleConns:  desc("idle_conns", "Redis connections open and waiting in the pool."),
+		config:     desc("config", "Pool settings, by setting. Constant while the process runs.", "setting"),
+	}
+}
+
+func (c *RedisCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.staleConns
+	ch <- c.totalConns
+	ch <- c.idleConns
+	ch <- c.config
+}
+
+func (c *RedisCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.pool.PoolStats()
+
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(stats.StaleConns))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+
+	options := c.pool.Options()
+	ch <- prometheus.MustNewConstMetric(c.config, prometheus.GaugeValue, float64(options.PoolSize), "pool_size")
+	ch <- prometheus.MustNewConstMetric(c.config, prometheus.GaugeValue, float64(options.MinIdleConns), "min_idle_conns")
+	ch <- prometheus.MustNewConstMetric(c.config, prometheus.GaugeValue, options.PoolTimeout.Seconds(), "pool_timeout_seconds")
+}
@@ -0,0 +1,77 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// StreamFinder is the event bus, see events.NATSBus
+type StreamFinder interface {
+	// StreamForSubject names the JetStream stream that captures subject
+	StreamForSubject(subject string) (string, error)
+}
+
+// Subjects checks a JetStream stream captures every subject, keyed by the variable it was configured with. A
+// publish to a subject no stream captures fails, and a subscription to one never gets a message. fix is appended to
+// the error for an uncaptured subject, it should say where the streams come from.
+func Subjects(streams StreamFinder, fix string, subjects map[string]string) Check {
+	return Check{
+		Name: "event subjects",
+		Run: func(ctx context.Context) error {
+			vars := make([]string, 0, len(subjects))
+			for v := range subjects {
+				vars = append(vars, v)
+			}
+			sort.Strings(vars)
+
+			var errs []error
+			for _, v := range vars {
+				subject := subjects[v]
+				if subject == "" {
+					errs = append(errs, fmt.Errorf("%s is not set", v))
+					continue
+				}
+				if _, err := streams.StreamForSubject(subject); err != nil {
+					errs = append(errs, fmt.Errorf("%s=%q is not captured by any JetStream stream (%w), %s", v, subject, err, fix))
+				}
+			}
+			return errors.Join(errs...)
+		},
+	}
+}
+
+// QueryRower is the database pool
+type QueryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// undefinedTable is Postgres' code for a missing relation
+const undefinedTable = "42P01"
+
+// SchemaVersion checks goose has applied at least want. A newer schema is fine, migrations are deployed ahead of
+// the code that needs them.
+func SchemaVersion(db QueryRower, want int64) Check {
+	return Check{
+		Name: "database schema",
+		Run: func(ctx context.Context) error {
+			var version int64
+			err := db.QueryRow(ctx, `SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied`).Scan(&version)
+
+			var pgErr *pgconn.PgError
+			switch {
+			case errors.As(err, &pgErr) && pgErr.Code == undefinedTable:
+				return fmt.Errorf("no goose_db_version table, the database has never been migrated, run `task migrate-up`")
+			case err != nil:
+				return fmt.Errorf("failed to read the schema version (%w), check DB_DSN", err)
+			case version < want:
+				return fmt.Errorf("schema is at version %d but this build needs %d, run `task migrate-up`", version, want)
+			}
+			return nil
+		},
+	}
+}
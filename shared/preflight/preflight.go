@@ -0,0 +1,67 @@
+// Package preflight checks at startup that the dependencies are set up the way this build expects, so a
+// misconfigured environment fails the deploy instead of the first request that touches it.
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Check is one thing to verify. Err should say what to do about it, not just what went wrong.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Failure is one check that failed and why
+type Failure struct {
+	Check string
+	Err   error
+}
+
+// Error lists every check that failed, so one deploy shows everything that needs fixing
+type Error struct {
+	Failures []Failure
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "preflight failed, %d check(s):", len(e.Failures))
+	for _, f := range e.Failures {
+		fmt.Fprintf(&b, "\n  - %s: %v", f.Check, f.Err)
+	}
+	return b.String()
+}
+
+// Run runs every check at once, each with timeout, and returns an *Error holding all the failures in the order the
+// checks were given
+func Run(ctx context.Context, timeout time.Duration, checks []Check) error {
+	errs := make([]error, len(checks))
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			errs[i] = check.Run(checkCtx)
+		}()
+	}
+	wg.Wait()
+
+	var failures []Failure
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, Failure{Check: checks[i].Name, Err: err})
+		}
+	}
+	if len(failures) > 0 {
+		return &Error{Failures: failures}
+	}
+	return nil
+}
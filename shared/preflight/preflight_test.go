@@ -0,0 +1,143 @@
+package preflight_test
+
+import (
+	"context"
+	"errors"
+	"shared/preflight"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- FAKES ---
+
+// fakeStreams captures every subject under "listings.>"
+type fakeStreams struct{}
+
+func (fakeStreams) StreamForSubject(subject string) (string, error) {
+	if strings.HasPrefix(subject, "listings.") {
+		return "LISTINGS", nil
+	}
+	return "", errors.New("nats: no stream matches subject")
+}
+
+type fakeRow struct {
+	version int64
+	err     error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*int64) = r.version
+	return nil
+}
+
+type fakeDB struct{ row fakeRow }
+
+func (f fakeDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row { return f.row }
+
+const fix = "start the listings worker once"
+
+func runOne(t *testing.T, check preflight.Check) error {
+	t.Helper()
+	return preflight.Run(context.Background(), time.Second, []preflight.Check{check})
+}
+
+// --- RUN ---
+
+func TestRun_AllHealthy(t *testing.T) {
+	checks := []preflight.Check{
+		preflight.SchemaVersion(fakeDB{row: fakeRow{version: 8}}, 8),
+		preflight.Subjects(fakeStreams{}, fix, map[string]string{"EVENT_LISTING_CREATED": "listings.created"}),
+	}
+
+	assert.NoError(t, preflight.Run(context.Background(), time.Second, checks))
+}
+
+func TestRun_ReportsEveryFailure(t *testing.T) {
+	// SCENARIO: The schema, the event subjects and a service specific check are all wrong at once.
+	// EXPECT: One error naming each of them in the order given, so a single deploy shows everything to fix.
+
+	checks := []preflight.Check{
+		preflight.SchemaVersion(fakeDB{row: fakeRow{version: 6}}, 8),
+		preflight.Subjects(fakeStreams{}, fix, map[string]string{"EVENT_INDEX_LISTING": "index.listing", "EVENT_LISTING_COUNTERS": ""}),
+		{Name: "bucket public", Run: func(ctx context.Context) error { return errors.New("access denied") }},
+	}
+
+	err := preflight.Run(context.Background(), time.Second, checks)
+
+	var perr *preflight.Error
+	require.ErrorAs(t, err, &perr)
+	names := make([]string, 0, len(perr.Failures))
+	for _, f := range perr.Failures {
+		names = append(names, f.Check)
+	}
+	assert.Equal(t, []string{"database schema", "event subjects", "bucket public"}, names)
+
+	assert.Contains(t, err.Error(), "schema is at version 6 but this build needs 8")
+	assert.Contains(t, err.Error(), `EVENT_INDEX_LISTING="index.listing"`)
+	assert.Contains(t, err.Error(), fix)
+	assert.Contains(t, err.Error(), "EVENT_LISTING_COUNTERS is not set")
+	assert.Contains(t, err.Error(), "access denied")
+}
+
+func TestRun_TimesOutHangingCheck(t *testing.T) {
+	// SCENARIO: A dependency accepts the connection but never answers.
+	// EXPECT: The check fails after the timeout instead of hanging startup.
+
+	hang := preflight.Check{Name: "hang", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	start := time.Now()
+	err := preflight.Run(context.Background(), 20*time.Millisecond, []preflight.Check{hang})
+
+	var perr *preflight.Error
+	require.ErrorAs(t, err, &perr)
+	assert.ErrorIs(t, perr.Failures[0].Err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+// --- CHECKS ---
+
+func TestSubjects_UnsetVariable(t *testing.T) {
+	err := runOne(t, preflight.Subjects(fakeStreams{}, fix, map[string]string{"EVENT_LISTING_CREATED": "", "EVENT_INDEX_LISTING": "listings.index"}))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "EVENT_LISTING_CREATED is not set")
+	assert.NotContains(t, err.Error(), "EVENT_INDEX_LISTING")
+}
+
+func TestSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		row     fakeRow
+		wantErr string
+	}{
+		{name: "Current", row: fakeRow{version: 8}},
+		{name: "Newer schema is fine", row: fakeRow{version: 9}},
+		{name: "Behind", row: fakeRow{version: 7}, wantErr: "run `task migrate-up`"},
+		{name: "Never migrated", row: fakeRow{err: &pgconn.PgError{Code: "42P01"}}, wantErr: "never been migrated"},
+		{name: "Unreachable", row: fakeRow{err: errors.New("connection refused")}, wantErr: "check DB_DSN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runOne(t, preflight.SchemaVersion(fakeDB{row: tt.row}, 8))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
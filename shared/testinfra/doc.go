@@ -0,0 +1,10 @@
+// Package testinfra starts throwaway Postgres, Redis, MinIO and NATS containers for integration tests.
+//
+// Everything here is behind the integration build tag so normal builds and `go test ./...` never pull in
+// Docker. Run the suites with:
+//
+//	go test -tags integration ./...
+//
+// The package imports nothing from either service and only hands back connection details, the gateway's and the
+// listings worker's suites both start their containers through it.
+package testinfra
@@ -0,0 +1,58 @@
+//go:build integration
+
+package testinfra
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/ory/dockertest/v3"
+)
+
+type MinIO struct {
+	Endpoint  string // host:port, no scheme
+	AccessKey string
+	SecretKey string
+}
+
+// URL is the HTTP base URL, e.g. for building public file links
+func (m MinIO) URL() string {
+	return "http://" + m.Endpoint
+}
+
+// MinIO starts MinIO and creates the given buckets
+func (s *Suite) MinIO(buckets ...string) (MinIO, error) {
+	cfg := MinIO{AccessKey: "marketplace", SecretKey: "marketplace-secret"}
+
+	var client *minio.Client
+	_, err := s.run(&dockertest.RunOptions{
+		Repository: "minio/minio",
+		Tag:        "latest",
+		Cmd:        []string{"server", "/data"},
+		Env: []string{
+			"MINIO_ROOT_USER=" + cfg.AccessKey,
+			"MINIO_ROOT_PASSWORD=" + cfg.SecretKey,
+		},
+	}, func(r *dockertest.Resource) error {
+		cfg.Endpoint = r.GetHostPort("9000/tcp")
+		var err error
+		client, err = minio.New(cfg.Endpoint, &minio.Options{Creds: credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, "")})
+		if err != nil {
+			return err
+		}
+		_, err = client.ListBuckets(context.Background())
+		return err
+	})
+	if err != nil {
+		return MinIO{}, err
+	}
+
+	for _, bucket := range buckets {
+		if err := client.MakeBucket(context.Background(), bucket, minio.MakeBucketOptions{}); err != nil {
+			return MinIO{}, fmt.Errorf("create bucket %s: %w", bucket, err)
+		}
+	}
+	return cfg, nil
+}
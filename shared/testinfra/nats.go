@@ -0,0 +1,46 @@
+//go:build integration
+
+package testinfra
+
+import (
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"github.com/ory/dockertest/v3"
+)
+
+// NATS starts NATS with JetStream enabled and creates one stream covering subjects, returning the client URL
+func (s *Suite) NATS(stream string, subjects ...string) (string, error) {
+	var url string
+	_, err := s.run(&dockertest.RunOptions{
+		Repository: "nats",
+		Tag:        "2.10-alpine",
+		Cmd:        []string{"-js"},
+	}, func(r *dockertest.Resource) error {
+		url = "nats://" + r.GetHostPort("4222/tcp")
+		nc, err := nats.Connect(url)
+		if err != nil {
+			return err
+		}
+		nc.Close()
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	nc, err := nats.Connect(url)
+	if err != nil {
+		return "", err
+	}
+	defer nc.Close()
+
+	js, err := nc.JetStream()
+	if err != nil {
+		return "", err
+	}
+	if _, err := js.AddStream(&nats.StreamConfig{Name: stream, Subjects: subjects}); err != nil {
+		return "", fmt.Errorf("create stream %s: %w", stream, err)
+	}
+	return url, nil
+}
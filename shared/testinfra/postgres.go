@@ -0,0 +1,87 @@
+//go:build integration
+
+package testinfra
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/ory/dockertest/v3"
+)
+
+// Postgres starts Postgres and applies the goose migrations in migrationsDir, returning a DSN
+func (s *Suite) Postgres(migrationsDir string) (string, error) {
+	var dsn string
+	_, err := s.run(&dockertest.RunOptions{
+		Repository: "postgres",
+		Tag:        "16-alpine",
+		Env: []string{
+			"POSTGRES_USER=marketplace",
+			"POSTGRES_PASSWORD=marketplace",
+			"POSTGRES_DB=marketplace",
+		},
+	}, func(r *dockertest.Resource) error {
+		dsn = fmt.Sprintf("postgres://marketplace:marketplace@%s/marketplace?sslmode=disable", r.GetHostPort("5432/tcp"))
+		conn, err := pgx.Connect(context.Background(), dsn)
+		if err != nil {
+			return err
+		}
+		defer conn.Close(context.Background())
+		return conn.Ping(context.Background())
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := ApplyMigrations(context.Background(), dsn, migrationsDir); err != nil {
+		return "", err
+	}
+	return dsn, nil
+}
+
+// ApplyMigrations runs the "-- +goose Up" half of every migration in dir, in file name order.
+// It's enough for a fresh test database without pulling goose into the module.
+func ApplyMigrations(ctx context.Context, dsn, dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		// No arguments, so pgx uses the simple protocol and the whole block runs as one multi-statement query
+		if _, err := conn.Exec(ctx, gooseUp(string(data))); err != nil {
+			return fmt.Errorf("migration %s: %w", filepath.Base(file), err)
+		}
+	}
+	return nil
+}
+
+// gooseUp returns the SQL between "-- +goose Up" and "-- +goose Down" with the goose annotations removed
+func gooseUp(migration string) string {
+	up, _, _ := strings.Cut(migration, "-- +goose Down")
+	var b strings.Builder
+	for _, line := range strings.Split(up, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "-- +goose") {
+			continue
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
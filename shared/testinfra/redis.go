@@ -0,0 +1,25 @@
+//go:build integration
+
+package testinfra
+
+import (
+	"context"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis starts Redis and returns its host:port
+func (s *Suite) Redis() (string, error) {
+	var addr string
+	_, err := s.run(&dockertest.RunOptions{
+		Repository: "redis",
+		Tag:        "7-alpine",
+	}, func(r *dockertest.Resource) error {
+		addr = r.GetHostPort("6379/tcp")
+		client := redis.NewClient(&redis.Options{Addr: addr})
+		defer client.Close()
+		return client.Ping(context.Background()).Err()
+	})
+	return addr, err
+}
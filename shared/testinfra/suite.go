@@ -0,0 +1,72 @@
+//go:build integration
+
+package testinfra
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+)
+
+const (
+	// Containers are killed by Docker after this even if the test binary crashes before Close
+	containerExpirySeconds = 600
+
+	startupTimeout = 2 * time.Minute
+)
+
+// Suite owns the containers for one test binary. Create it in TestMain and Close it once m.Run returns.
+type Suite struct {
+	pool      *dockertest.Pool
+	resources []*dockertest.Resource
+}
+
+func NewSuite() (*Suite, error) {
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		return nil, fmt.Errorf("connect to docker: %w", err)
+	}
+	if err := pool.Client.Ping(); err != nil {
+		return nil, fmt.Errorf("docker is not reachable: %w", err)
+	}
+	pool.MaxWait = startupTimeout
+
+	return &Suite{pool: pool}, nil
+}
+
+// run starts a container and waits until ready returns nil
+func (s *Suite) run(opts *dockertest.RunOptions, ready func(r *dockertest.Resource) error) (*dockertest.Resource, error) {
+	resource, err := s.pool.RunWithOptions(opts, func(hc *docker.HostConfig) {
+		hc.AutoRemove = true
+		hc.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("start %s: %w", opts.Repository, err)
+	}
+	s.resources = append(s.resources, resource)
+
+	if err := resource.Expire(containerExpirySeconds); err != nil {
+		return nil, fmt.Errorf("set expiry on %s: %w", opts.Repository, err)
+	}
+
+	if err := s.pool.Retry(func() error { return ready(resource) }); err != nil {
+		return nil, fmt.Errorf("%s never became ready: %w", opts.Repository, err)
+	}
+
+	return resource, nil
+}
+
+// Close removes every container the suite started
+func (s *Suite) Close() error {
+	var errs []error
+	for _, r := range s.resources {
+		if err := s.pool.Purge(r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	s.resources = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,211 @@
+// Package typesensehttp instruments the HTTP calls the typesense-go client makes. The Transport goes under the
+// client's http.Client and records every call per operation, so the gateway's search client and the listings worker's
+// indexer report the same metric and operation names under their own namespace and dashboards can use either.
+package typesensehttp
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// maxRetries is on top of the first attempt. Typesense answers 503 while it's still loading or lagging behind on
+// writes, which usually clears up within the request timeout.
+const maxRetries = 1
+
+// Metrics are the per-operation series a Transport records into
+type Metrics struct {
+	RequestDuration *prometheus.HistogramVec
+	Requests        *prometheus.CounterVec
+	Retries         *prometheus.CounterVec
+}
+
+// NewMetrics registers <namespace>_typesense_* with reg. buckets are the request duration buckets in seconds, a
+// service that only searches wants finer ones than one that imports.
+func NewMetrics(reg prometheus.Registerer, namespace string, buckets []float64) *Metrics {
+	factory := promauto.With(reg)
+	return &Metrics{
+		RequestDuration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "typesense_request_duration_seconds",
+			Help:      "Time spent on Typesense calls including retries, by operation.",
+			Buckets:   buckets,
+		}, []string{"operation"}),
+		Requests: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "typesense_requests_total",
+			Help:      "Typesense calls by operation and the status class of the final attempt (error when no response came back).",
+		}, []string{"operation", "status_class"}),
+		Retries: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "typesense_retries_total",
+			Help:      "Idempotent Typesense calls repeated after a connection error or 503, by operation.",
+		}, []string{"operation"}),
+	}
+}
+
+// Transport sits under the typesense-go client and records every call it makes
+type Transport struct {
+	next    http.RoundTripper
+	metrics *Metrics
+	logger  *slog.Logger
+}
+
+// NewTransport wraps next, http.DefaultTransport when nil
+func NewTransport(next http.RoundTripper, metrics *Metrics, logger *slog.Logger) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Transport{next: next, metrics: metrics, logger: logger}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	collection, operation := describeRequest(req)
+	start := time.Now()
+
+	var (
+		resp    *http.Response
+		err     error
+		retries int
+	)
+	for {
+		resp, err = t.next.RoundTrip(req)
+		if retries >= maxRetries || !shouldRetry(req, resp, err) {
+			break
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		next, rewindErr := rewind(req)
+		if rewindErr != nil {
+			resp, err = nil, rewindErr
+			break
+		}
+		req = next
+		retries++
+		t.metrics.Retries.WithLabelValues(operation).Inc()
+	}
+
+	duration := time.Since(start)
+	status := statusClass(resp, err)
+	t.metrics.RequestDuration.WithLabelValues(operation).Observe(duration.Seconds())
+	t.metrics.Requests.WithLabelValues(operation, status).Inc()
+
+	// Never log the body, documents and queries carry user content
+	t.logger.DebugContext(req.Context(), "Typesense request",
+		"collection", collection,
+		"operation", operation,
+		"status_class", status,
+		"retries", retries,
+		"duration_ms", duration.Milliseconds(),
+	)
+	return resp, err
+}
+
+// idempotent are the methods a repeat can't apply twice. Typesense creates, imports and partial updates go over POST
+// and PATCH, a 503 or dropped connection there may still have been applied, so they are left to the caller.
+var idempotent = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+// shouldRetry only retries idempotent calls that didn't reach Typesense or that it asked us to repeat, and never once
+// the caller has given up
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if !idempotent[req.Method] || req.Context().Err() != nil {
+		return false
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+	return err != nil || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// rewind returns a copy of req with a fresh body, a RoundTripper mustn't modify the request it was given
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return req, err
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
+
+func statusClass(resp *http.Response, err error) string {
+	if err != nil {
+		return "error"
+	}
+	switch {
+	case resp.StatusCode >= 500:
+		return "5xx"
+	case resp.StatusCode >= 400:
+		return "4xx"
+	case resp.StatusCode >= 300:
+		return "3xx"
+	default:
+		return "2xx"
+	}
+}
+
+// methodVerbs names what a method does to a single collection or document
+var methodVerbs = map[string]string{
+	http.MethodGet:    "retrieve",
+	http.MethodPost:   "create",
+	http.MethodPut:    "upsert",
+	http.MethodPatch:  "update",
+	http.MethodDelete: "delete",
+}
+
+// describeRequest maps a Typesense API path to the collection it targets and a low-cardinality operation name,
+// e.g. POST /collections/listings/documents?action=upsert is ("listings", "documents.upsert")
+func describeRequest(req *http.Request) (collection, operation string) {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	verb, ok := methodVerbs[req.Method]
+	if !ok {
+		verb = strings.ToLower(req.Method)
+	}
+
+	if segments[0] != "collections" {
+		// health, multi_search, keys, aliases...
+		return "", segments[0]
+	}
+
+	switch len(segments) {
+	case 1:
+		if req.Method == http.MethodGet {
+			return "", "collections.list"
+		}
+		return "", "collections." + verb
+	case 2:
+		return segments[1], "collections." + verb
+	case 3:
+		if segments[2] != "documents" {
+			return segments[1], segments[2] + "." + verb
+		}
+		if req.Method == http.MethodPost {
+			if action := req.URL.Query().Get("action"); action != "" {
+				return segments[1], "documents." + action
+			}
+			return segments[1], "documents.create"
+		}
+		// Delete and update by filter
+		return segments[1], "documents." + verb + "_many"
+	default:
+		switch segments[3] {
+		case "search", "import", "export":
+			return segments[1], segments[2] + "." + segments[3]
+		}
+		return segments[1], segments[2] + "." + verb
+	}
+}
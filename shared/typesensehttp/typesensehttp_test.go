@@ -0,0 +1,104 @@
+package typesensehttp
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadingServer answers 503 to the first call, like Typesense while it loads, and 200 after
+func loadingServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &attempts
+}
+
+func newClient(metrics *Metrics) *http.Client {
+	return &http.Client{Transport: NewTransport(nil, metrics, slog.New(slog.NewTextHandler(io.Discard, nil)))}
+}
+
+func TestTransport_RetriesIdempotentCalls(t *testing.T) {
+	// SCENARIO: Typesense is still loading when a search comes in.
+	// EXPECT: The search is repeated once, observed once and counted under the class of the final response.
+
+	server, attempts := loadingServer(t)
+	metrics := NewMetrics(prometheus.NewRegistry(), "test", prometheus.DefBuckets)
+
+	resp, err := newClient(metrics).Get(server.URL + "/collections/listings/documents/search?q=*")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), attempts.Load())
+	assert.Equal(t, 1.0, promtest.ToFloat64(metrics.Retries.WithLabelValues("documents.search")))
+	assert.Equal(t, 1.0, promtest.ToFloat64(metrics.Requests.WithLabelValues("documents.search", "2xx")))
+
+	var observed dto.Metric
+	require.NoError(t, metrics.RequestDuration.WithLabelValues("documents.search").(prometheus.Histogram).Write(&observed))
+	assert.Equal(t, uint64(1), observed.GetHistogram().GetSampleCount())
+}
+
+func TestTransport_NeverRetriesPOST(t *testing.T) {
+	// SCENARIO: Typesense answers 503 to an import, which it may have applied in part.
+	// EXPECT: The 503 goes back to the caller without a second attempt.
+
+	server, attempts := loadingServer(t)
+	metrics := NewMetrics(prometheus.NewRegistry(), "test", prometheus.DefBuckets)
+
+	resp, err := newClient(metrics).Post(server.URL+"/collections/listings/documents/import?action=upsert", "text/plain", strings.NewReader(`{"id":"1"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), attempts.Load())
+	assert.Equal(t, 0.0, promtest.ToFloat64(metrics.Retries.WithLabelValues("documents.import")))
+	assert.Equal(t, 1.0, promtest.ToFloat64(metrics.Requests.WithLabelValues("documents.import", "5xx")))
+}
+
+func TestDescribeRequest(t *testing.T) {
+	tests := []struct {
+		method         string
+		target         string
+		wantCollection string
+		wantOperation  string
+	}{
+		{http.MethodGet, "/health", "", "health"},
+		{http.MethodPost, "/multi_search", "", "multi_search"},
+		{http.MethodGet, "/collections", "", "collections.list"},
+		{http.MethodGet, "/collections/listings", "listings", "collections.retrieve"},
+		{http.MethodGet, "/collections/listings/documents/search?q=*", "listings", "documents.search"},
+		{http.MethodPost, "/collections/listings/documents?action=upsert", "listings", "documents.upsert"},
+		{http.MethodPost, "/collections/listings/documents", "listings", "documents.create"},
+		{http.MethodDelete, "/collections/listings/documents?filter_by=seller_id:1", "listings", "documents.delete_many"},
+		{http.MethodGet, "/collections/listings/documents/0b5e6c1a", "listings", "documents.retrieve"},
+		{http.MethodPatch, "/collections/listings/documents/0b5e6c1a", "listings", "documents.update"},
+		{http.MethodDelete, "/collections/sellers/documents/0b5e6c1a", "sellers", "documents.delete"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			collection, operation := describeRequest(httptest.NewRequest(tt.method, tt.target, nil))
+			assert.Equal(t, tt.wantCollection, collection)
+			assert.Equal(t, tt.wantOperation, operation)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Package version is the build the running binary came from, so a log line, probe or metric can be traced back to a
+// commit. The Dockerfile stamps it in with -ldflags:
+//
+//	-X shared/version.Version=1.4.0 -X shared/version.Commit=3f2c1ab... -X shared/version.BuildTime=2026-01-31T12:00:00Z
+//
+// A plain go build in a checkout falls back to the VCS details Go records itself.
+package version
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Set by -ldflags, see the package doc
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+const (
+	// Header carries Info.String on every gateway response
+	Header = "X-Service-Version"
+
+	unknown = "unknown"
+)
+
+// Info is the build on the health endpoints and in the build_info metric
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"` // RFC 3339
+	GoVersion string `json:"go_version"`
+}
+
+// Get reads the stamped values, or what Go recorded from the checkout for the ones that weren't stamped
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, BuildTime: BuildTime, GoVersion: runtime.Version()}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = setting.Value
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = unknown
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = unknown
+	}
+	return info
+}
+
+// String is the version with the short commit as build metadata, e.g. 1.4.0+3f2c1ab
+func (i Info) String() string {
+	if i.Commit == "" || i.Commit == unknown {
+		return i.Version
+	}
+	return i.Version + "+" + i.Commit[:min(len(i.Commit), 7)]
+}
+
+func (i Info) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("version", i.Version),
+		slog.String("commit", i.Commit),
+		slog.String("build_time", i.BuildTime),
+		slog.String("go_version", i.GoVersion),
+	)
+}
+
+// NewCollector is <namespace>_build_info, always 1 with the build in its labels, so a query can join it onto other
+// metrics by instance to see which version they came from
+func NewCollector(namespace string, info Info) prometheus.Collector {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "build_info",
+		Help:      "Build the process is running, always 1. The labels are the version, commit, build time and Go version.",
+		ConstLabels: prometheus.Labels{
+			"version":    info.Version,
+			"commit":     info.Commit,
+			"build_time": info.BuildTime,
+			"go_version": info.GoVersion,
+		},
+	}, func() float64 { return 1 })
+}
+
+// Middleware adds the Header to every response
+func Middleware(info Info) func(http.Handler) http.Handler {
+	value := info.String()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(Header, value)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
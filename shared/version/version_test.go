@@ -0,0 +1,60 @@
+package version_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"shared/version"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var stamped = version.Info{Version: "1.4.0", Commit: "3f2c1ab9d0e4", BuildTime: "2026-01-31T12:00:00Z", GoVersion: "go1.24.0"}
+
+func TestGet_Stamped(t *testing.T) {
+	// SCENARIO: The Dockerfile set the variables with -ldflags -X.
+	// EXPECT: Get reports them as they were given, not what Go recorded about the checkout.
+
+	defer func(v, c, b string) { version.Version, version.Commit, version.BuildTime = v, c, b }(version.Version, version.Commit, version.BuildTime)
+	version.Version, version.Commit, version.BuildTime = stamped.Version, stamped.Commit, stamped.BuildTime
+
+	info := version.Get()
+	assert.Equal(t, "1.4.0", info.Version)
+	assert.Equal(t, "3f2c1ab9d0e4", info.Commit)
+	assert.Equal(t, "2026-01-31T12:00:00Z", info.BuildTime)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+}
+
+func TestInfo_String(t *testing.T) {
+	assert.Equal(t, "1.4.0+3f2c1ab", stamped.String())
+	assert.Equal(t, "dev", version.Info{Version: "dev", Commit: "unknown"}.String())
+}
+
+func TestMiddleware_SetsHeader(t *testing.T) {
+	handler := version.Middleware(stamped)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Equal(t, "1.4.0+3f2c1ab", w.Header().Get(version.Header))
+}
+
+func TestNewCollector_BuildInfo(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(version.NewCollector("gateway", stamped)))
+
+	expected := `
+# HELP gateway_build_info Build the process is running, always 1. The labels are the version, commit, build time and Go version.
+# TYPE gateway_build_info gauge
+gateway_build_info{build_time="2026-01-31T12:00:00Z",commit="3f2c1ab9d0e4",go_version="go1.24.0",version="1.4.0"} 1
+`
+	assert.NoError(t, promtest.GatherAndCompare(registry, strings.NewReader(expected), "gateway_build_info"))
+}